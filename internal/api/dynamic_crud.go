@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -71,6 +72,36 @@ func (d *dynamicConfMgr) Remove(id string) {
 
 //------------------------------------------------------------------------------
 
+// DynamicStore is an interface implemented by types able to persist the raw
+// configurations of dynamic components so that they can be restored after a
+// restart.
+type DynamicStore interface {
+	// Load returns the last saved map of dynamic component ids to their raw
+	// configurations. An empty map should be returned when nothing has been
+	// saved yet.
+	Load(ctx context.Context) (map[string][]byte, error)
+
+	// Save replaces the persisted set of dynamic component configurations.
+	Save(ctx context.Context, configs map[string][]byte) error
+}
+
+// DynamicEvent describes a change to a dynamic component.
+type DynamicEvent struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Event types emitted by the dynamic API.
+const (
+	DynamicEventStarted = "started"
+	DynamicEventStopped = "stopped"
+	DynamicEventUpdated = "updated"
+	DynamicEventDeleted = "deleted"
+)
+
+//------------------------------------------------------------------------------
+
 // Dynamic is a type for exposing CRUD operations on dynamic broker
 // configurations as an HTTP interface. Events can be registered for listening
 // to configuration changes, and these events should be forwarded to the
@@ -84,10 +115,22 @@ type Dynamic struct {
 	configHashes *dynamicConfMgr
 	configsMut   sync.Mutex
 
+	// rawConfigs is a map of the raw configs successfully applied by our CRUD
+	// clients, which is what gets persisted to a store when one is set.
+	rawConfigs map[string][]byte
+	store      DynamicStore
+
+	// persistMut serialises saves to the store, which are performed without
+	// holding configsMut so that a slow store doesn't block other requests.
+	persistMut sync.Mutex
+
 	// ids is a map of dynamic components that are currently active and their
 	// start times.
 	ids    map[string]time.Time
 	idsMut sync.Mutex
+
+	subscribers    map[chan DynamicEvent]struct{}
+	subscribersMut sync.Mutex
 }
 
 // NewDynamic creates a new Dynamic API type.
@@ -97,7 +140,127 @@ func NewDynamic() *Dynamic {
 		onDelete:     func(ctx context.Context, id string) error { return nil },
 		configs:      map[string][]byte{},
 		configHashes: newDynamicConfMgr(),
+		rawConfigs:   map[string][]byte{},
 		ids:          map[string]time.Time{},
+		subscribers:  map[chan DynamicEvent]struct{}{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SetStore sets a store used for persisting the configurations of dynamic
+// components that are added or removed via the CRUD API.
+func (d *Dynamic) SetStore(store DynamicStore) {
+	d.configsMut.Lock()
+	d.store = store
+	d.configsMut.Unlock()
+}
+
+// Restore loads all configurations from the store, if one has been set, and
+// applies each of them via the registered update func. An attempt is made to
+// restore all configurations and the first error encountered, if any, is
+// returned.
+func (d *Dynamic) Restore(ctx context.Context) error {
+	d.configsMut.Lock()
+	store := d.store
+	d.configsMut.Unlock()
+	if store == nil {
+		return nil
+	}
+
+	configs, err := store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load dynamic configs: %w", err)
+	}
+
+	var firstErr error
+	for id, conf := range configs {
+		if err := d.onUpdate(ctx, id, conf); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to restore dynamic component '%v': %w", id, err)
+			}
+			continue
+		}
+		d.configsMut.Lock()
+		d.configHashes.Set(id, conf)
+		d.rawConfigs[id] = conf
+		d.configsMut.Unlock()
+		d.emit(DynamicEventUpdated, id)
+	}
+	return firstErr
+}
+
+// persist saves the raw configurations to the store, if one has been set, with
+// the configuration of an id replaced, or removed when conf is nil. The raw
+// configurations and config hashes are only modified once the save succeeds,
+// so that a failed request can be retried.
+func (d *Dynamic) persist(ctx context.Context, id string, conf []byte) error {
+	d.persistMut.Lock()
+	defer d.persistMut.Unlock()
+
+	d.configsMut.Lock()
+	store := d.store
+	configs := make(map[string][]byte, len(d.rawConfigs)+1)
+	for k, v := range d.rawConfigs {
+		configs[k] = v
+	}
+	d.configsMut.Unlock()
+
+	if conf == nil {
+		delete(configs, id)
+	} else {
+		configs[id] = conf
+	}
+	if store != nil {
+		if err := store.Save(ctx, configs); err != nil {
+			return fmt.Errorf("failed to persist dynamic configs: %w", err)
+		}
+	}
+
+	d.configsMut.Lock()
+	if conf == nil {
+		delete(d.rawConfigs, id)
+	} else {
+		d.configHashes.Set(id, conf)
+		d.rawConfigs[id] = conf
+	}
+	d.configsMut.Unlock()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *Dynamic) subscribe() chan DynamicEvent {
+	c := make(chan DynamicEvent, 32)
+	d.subscribersMut.Lock()
+	d.subscribers[c] = struct{}{}
+	d.subscribersMut.Unlock()
+	return c
+}
+
+func (d *Dynamic) unsubscribe(c chan DynamicEvent) {
+	d.subscribersMut.Lock()
+	delete(d.subscribers, c)
+	d.subscribersMut.Unlock()
+}
+
+// emit delivers an event to all subscribers, subscribers that are not keeping
+// up with events will miss them rather than block the caller.
+func (d *Dynamic) emit(eventType, id string) {
+	event := DynamicEvent{
+		Type:      eventType,
+		ID:        id,
+		Timestamp: time.Now(),
+	}
+
+	d.subscribersMut.Lock()
+	defer d.subscribersMut.Unlock()
+
+	for c := range d.subscribers {
+		select {
+		case c <- event:
+		default:
+		}
 	}
 }
 
@@ -121,9 +284,10 @@ func (d *Dynamic) OnDelete(onDelete func(ctx context.Context, id string) error)
 // whether by naturally winding down or from a request.
 func (d *Dynamic) Stopped(id string) {
 	d.idsMut.Lock()
-	defer d.idsMut.Unlock()
-
 	delete(d.ids, id)
+	d.idsMut.Unlock()
+
+	d.emit(DynamicEventStopped, id)
 }
 
 // Started should be called whenever an active dynamic component has started
@@ -139,13 +303,22 @@ func (d *Dynamic) Started(id string, config []byte) {
 		d.configs[id] = config
 		d.configsMut.Unlock()
 	}
+
+	d.emit(DynamicEventStarted, id)
 }
 
 //------------------------------------------------------------------------------
 
 // HandleList is an http.HandleFunc for returning maps of active dynamic
-// components by their id to uptime.
+// components by their id to uptime. When the request accepts the content type
+// text/event-stream the response is instead a stream of server-sent events
+// describing changes to dynamic components as they occur.
 func (d *Dynamic) HandleList(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		d.handleEventStream(w, r)
+		return
+	}
+
 	var httpErr error
 	defer func() {
 		if r.Body != nil {
@@ -192,6 +365,39 @@ func (d *Dynamic) HandleList(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (d *Dynamic) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events := d.subscribe()
+	defer d.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e := <-events:
+			eBytes, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err = fmt.Fprintf(w, "event: %v\ndata: %s\n\n", e.Type, eBytes); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
 func (d *Dynamic) handleGETInput(w http.ResponseWriter, r *http.Request) error {
 	id := mux.Vars(r)["id"]
 
@@ -225,10 +431,9 @@ func (d *Dynamic) handlePOSTInput(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
-	d.configsMut.Lock()
-	d.configHashes.Set(id, reqBytes)
-	d.configsMut.Unlock()
-	return nil
+	err = d.persist(r.Context(), id, reqBytes)
+	d.emit(DynamicEventUpdated, id)
+	return err
 }
 
 func (d *Dynamic) handleDELInput(w http.ResponseWriter, r *http.Request) error {
@@ -238,12 +443,17 @@ func (d *Dynamic) handleDELInput(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	// The component is no longer running regardless of whether the removal is
+	// persisted, and so posting the same config again must recreate it.
 	d.configsMut.Lock()
 	d.configHashes.Remove(id)
 	delete(d.configs, id)
 	d.configsMut.Unlock()
 
-	return nil
+	err := d.persist(r.Context(), id, nil)
+
+	d.emit(DynamicEventDeleted, id)
+	return err
 }
 
 // HandleCRUD is an http.HandleFunc for performing CRUD operations on dynamic
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
@@ -9,8 +10,11 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 //------------------------------------------------------------------------------
@@ -251,4 +255,171 @@ func TestDynamicListing(t *testing.T) {
 	}
 }
 
-//------------------------------------------------------------------------------
+type memDynamicStore struct {
+	configs map[string][]byte
+	saveErr error
+}
+
+func (m *memDynamicStore) Load(ctx context.Context) (map[string][]byte, error) {
+	return m.configs, nil
+}
+
+func (m *memDynamicStore) Save(ctx context.Context, configs map[string][]byte) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.configs = configs
+	return nil
+}
+
+func TestDynamicPersistence(t *testing.T) {
+	store := &memDynamicStore{
+		configs: map[string][]byte{
+			"foo": []byte("foo conf"),
+		},
+	}
+
+	dAPI := NewDynamic()
+	r := router(dAPI)
+
+	updated := map[string]string{}
+	dAPI.OnUpdate(func(ctx context.Context, id string, content []byte) error {
+		updated[id] = string(content)
+		return nil
+	})
+	dAPI.OnDelete(func(ctx context.Context, id string) error {
+		delete(updated, id)
+		return nil
+	})
+
+	dAPI.SetStore(store)
+	require.NoError(t, dAPI.Restore(context.Background()))
+	assert.Equal(t, map[string]string{"foo": "foo conf"}, updated)
+
+	// Posting the same config as restored should be a no-op.
+	request, _ := http.NewRequest("POST", "/input/foo", bytes.NewReader([]byte("foo conf")))
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	request, _ = http.NewRequest("POST", "/input/bar", bytes.NewReader([]byte("bar conf")))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("foo conf"),
+		"bar": []byte("bar conf"),
+	}, store.configs)
+
+	request, _ = http.NewRequest("DELETE", "/input/foo", http.NoBody)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	assert.Equal(t, map[string][]byte{
+		"bar": []byte("bar conf"),
+	}, store.configs)
+	assert.Equal(t, map[string]string{"bar": "bar conf"}, updated)
+}
+
+func TestDynamicPersistenceFailure(t *testing.T) {
+	store := &memDynamicStore{
+		configs: map[string][]byte{},
+		saveErr: errors.New("store is down"),
+	}
+
+	dAPI := NewDynamic()
+	r := router(dAPI)
+
+	updates := 0
+	dAPI.OnUpdate(func(ctx context.Context, id string, content []byte) error {
+		updates++
+		return nil
+	})
+	dAPI.SetStore(store)
+
+	request, _ := http.NewRequest("POST", "/input/foo", bytes.NewReader([]byte("foo conf")))
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadGateway, response.Code)
+	assert.Contains(t, response.Body.String(), "store is down")
+	assert.Equal(t, 1, updates)
+	assert.Empty(t, store.configs)
+
+	// Retrying the same config must not be treated as a no-op as it was never
+	// persisted.
+	store.saveErr = nil
+	request, _ = http.NewRequest("POST", "/input/foo", bytes.NewReader([]byte("foo conf")))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 2, updates)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("foo conf"),
+	}, store.configs)
+
+	// Now that it's persisted the same config is a no-op.
+	request, _ = http.NewRequest("POST", "/input/foo", bytes.NewReader([]byte("foo conf")))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 2, updates)
+
+	// A failed removal leaves the stored config in place, but posting the same
+	// config again recreates the component.
+	store.saveErr = errors.New("store is down")
+	request, _ = http.NewRequest("DELETE", "/input/foo", http.NoBody)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadGateway, response.Code)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("foo conf"),
+	}, store.configs)
+
+	store.saveErr = nil
+	request, _ = http.NewRequest("POST", "/input/foo", bytes.NewReader([]byte("foo conf")))
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 3, updates)
+}
+
+func TestDynamicEventStream(t *testing.T) {
+	dAPI := NewDynamic()
+
+	server := httptest.NewServer(router(dAPI))
+	defer server.Close()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/inputs", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	request, _ := http.NewRequest("POST", "/input/foo", bytes.NewReader([]byte("foo conf")))
+	response := httptest.NewRecorder()
+	router(dAPI).ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code)
+
+	dAPI.Started("foo", nil)
+	dAPI.Stopped("foo")
+
+	scanner := bufio.NewScanner(res.Body)
+	var events []string
+	for len(events) < 3 && scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "event: ") {
+			events = append(events, strings.TrimPrefix(line, "event: "))
+		} else if strings.HasPrefix(line, "data: ") {
+			assert.Contains(t, line, `"id":"foo"`)
+		}
+	}
+	assert.Equal(t, []string{"updated", "started", "stopped"}, events)
+}
@@ -70,3 +70,21 @@ Allows you to configure a [batching policy](/docs/configuration/batching).`,
 		},
 	}
 }
+
+// OutputFieldSpec returns a spec for the batching field of outputs, which
+// extends FieldSpec with fields that only apply whilst sending batches.
+func OutputFieldSpec() docs.FieldSpec {
+	spec := FieldSpec()
+	children := make(docs.FieldSpecs, 0, len(spec.Children)+1)
+	for _, f := range spec.Children {
+		if f.Name == "processors" {
+			children = append(children, docs.FieldBool(
+				"coalesce",
+				"Whilst the output is busy sending a batch, continue to add messages to the next batch up to the limits of `count` and `byte_size`, merging what would otherwise be several batches into one before the `processors` are applied. Messages stop being added once any other condition of the policy triggers a flush. This improves throughput during bursts for outputs with a high overhead per request. Requires `count` or `byte_size` to be set.",
+			).HasDefault(false).Advanced())
+		}
+		children = append(children, f)
+	}
+	spec.Children = children
+	return spec
+}
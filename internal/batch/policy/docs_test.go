@@ -31,3 +31,39 @@ processors: []
 	require.NoError(t, err)
 	assert.Equal(t, expSanit, string(b))
 }
+
+func TestBatchPolicyOutputSanit(t *testing.T) {
+	conf := policy.NewOutputConfig()
+
+	var node yaml.Node
+	require.NoError(t, node.Encode(conf))
+	require.NoError(t, policy.OutputFieldSpec().SanitiseYAML(&node, docs.SanitiseConfig{
+		RemoveTypeField: true,
+	}))
+
+	expSanit := `count: 0
+byte_size: 0
+period: ""
+check: ""
+coalesce: false
+processors: []
+`
+
+	b, err := yaml.Marshal(node)
+	require.NoError(t, err)
+	assert.Equal(t, expSanit, string(b))
+}
+
+func TestBatchPolicyCoalesceLint(t *testing.T) {
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+count: 10
+coalesce: true
+`), &node))
+
+	assert.Empty(t, policy.OutputFieldSpec().Children.LintYAML(docs.NewLintContext(), node.Content[0]))
+
+	lints := policy.FieldSpec().Children.LintYAML(docs.NewLintContext(), node.Content[0])
+	require.Len(t, lints, 1)
+	assert.Contains(t, lints[0].What, "coalesce")
+}
@@ -55,6 +55,22 @@ func (p Config) IsNoop() bool {
 	return true
 }
 
+// OutputConfig contains configuration parameters for the batch policy of an
+// output, which extends Config with fields that only apply whilst sending
+// batches.
+type OutputConfig struct {
+	Config   `json:",inline" yaml:",inline"`
+	Coalesce bool `json:"coalesce" yaml:"coalesce"`
+}
+
+// NewOutputConfig creates a default OutputConfig.
+func NewOutputConfig() OutputConfig {
+	return OutputConfig{
+		Config:   NewConfig(),
+		Coalesce: false,
+	}
+}
+
 func (p Config) isLimited() bool {
 	if p.ByteSize > 0 {
 		return true
@@ -96,6 +112,7 @@ type Batcher struct {
 	period    time.Duration
 	check     *mapping.Executor
 	procs     []iprocessor.V1
+	coalesce  bool
 	sizeTally int
 	parts     []*message.Part
 
@@ -158,6 +175,20 @@ func New(conf Config, mgr interop.Manager) (*Batcher, error) {
 	}, nil
 }
 
+// NewOutput creates an empty policy for an output, where batches may also be
+// coalesced whilst the output is busy.
+func NewOutput(conf OutputConfig, mgr interop.Manager) (*Batcher, error) {
+	if conf.Coalesce && conf.Count <= 0 && conf.ByteSize <= 0 {
+		return nil, errors.New("batch policy must have a count or byte_size in order to coalesce batches")
+	}
+	p, err := New(conf.Config, mgr)
+	if err != nil {
+		return nil, err
+	}
+	p.coalesce = conf.Coalesce
+	return p, nil
+}
+
 //------------------------------------------------------------------------------
 
 // Add a new message part to this batch policy. Returns true if this part
@@ -195,7 +226,8 @@ func (p *Batcher) Add(part *message.Part) bool {
 }
 
 // Flush clears all messages stored by this batch policy. Returns nil if the
-// policy is currently empty.
+// policy is currently empty. The resulting batch is given new batch info with
+// the batches that its messages previously belonged to recorded as parents.
 func (p *Batcher) Flush() *message.Batch {
 	var newMsg *message.Batch
 
@@ -213,6 +245,9 @@ func (p *Batcher) Flush() *message.Batch {
 		}
 		newMsg.SetAll(parts)
 	}
+	if newMsg != nil {
+		message.MergeBatchInfo("batching", newMsg)
+	}
 	return newMsg
 }
 
@@ -253,6 +288,31 @@ func (p *Batcher) Count() int {
 	return len(p.parts)
 }
 
+// Coalesce returns true if messages should continue to be added to this policy
+// while a flushed batch is waiting to be sent, merging what would otherwise be
+// several batches into one.
+func (p *Batcher) Coalesce() bool {
+	return p.coalesce
+}
+
+// Fits returns true if a given number of messages and total byte size, added
+// to the currently buffered message parts, would not exceed the count and
+// byte_size limits of this policy. Both the limits and the buffered parts are
+// measured before the processors of the policy are applied. A policy without a
+// count or byte_size limit never fits.
+func (p *Batcher) Fits(count, byteSize int) bool {
+	if p.count <= 0 && p.byteSize <= 0 {
+		return false
+	}
+	if p.count > 0 && count+len(p.parts) > p.count {
+		return false
+	}
+	if p.byteSize > 0 && byteSize+p.sizeTally > p.byteSize {
+		return false
+	}
+	return true
+}
+
 // UntilNext returns a duration indicating how long until the current batch
 // should be flushed due to a configured period. A negative duration indicates
 // a period has not been set.
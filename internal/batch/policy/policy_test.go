@@ -303,3 +303,38 @@ func TestPolicySplit(t *testing.T) {
 		t.Error("Non-nil empty flush")
 	}
 }
+
+func TestPolicyBatchInfo(t *testing.T) {
+	conf := NewConfig()
+	conf.Count = 3
+
+	pol, err := New(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		pol.CloseAsync()
+		require.NoError(t, pol.WaitForClose(time.Second))
+	})
+
+	inA := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+	message.InitBatchInfo("foo", inA)
+	infoA, _ := message.GetBatchInfo(inA.Get(0))
+
+	inB := message.QuickBatch([][]byte{[]byte("baz")})
+	message.InitBatchInfo("foo", inB)
+	infoB, _ := message.GetBatchInfo(inB.Get(0))
+
+	assert.False(t, pol.Add(inA.Get(0)))
+	assert.False(t, pol.Add(inA.Get(1)))
+	assert.True(t, pol.Add(inB.Get(0)))
+
+	msg := pol.Flush()
+	require.NotNil(t, msg)
+	require.Equal(t, 3, msg.Len())
+
+	info, exists := message.GetBatchInfo(msg.Get(2))
+	require.True(t, exists)
+	assert.Equal(t, "batching", info.Origin)
+	assert.Equal(t, 3, info.Size)
+	assert.Equal(t, []string{infoA.ID, infoB.ID}, info.ParentIDs)
+}
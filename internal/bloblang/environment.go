@@ -168,6 +168,22 @@ func (e *Environment) WithoutFunctions(names ...string) *Environment {
 	return &env
 }
 
+// WithVars returns a copy of the environment where the function `var` falls
+// back to a static set of values when the referenced variable has not been
+// defined within a mapping.
+func (e *Environment) WithVars(vars map[string]interface{}) *Environment {
+	env := *e
+	env.pCtx.Functions = env.pCtx.Functions.Without()
+	_ = env.pCtx.Functions.Add(query.VarFunctionSpec, func(args *query.ParsedParams) (query.Function, error) {
+		name, err := args.FieldString("name")
+		if err != nil {
+			return nil, err
+		}
+		return query.NewVarFunctionWithFallback(name, vars), nil
+	})
+	return &env
+}
+
 // WithMaxMapRecursion returns a copy of the environment where the maximum
 // recursion allowed for maps is set to a given value. If the execution of a
 // mapping from this environment matches this number of recursive map calls the
@@ -93,3 +93,19 @@ func (e *Expression) String(index int, msg Message) string {
 	}
 	return string(e.Bytes(index, msg))
 }
+
+// Value returns the expression resolved for a message of a batch. When the
+// expression consists of a single interpolation function the result is
+// returned in its structured form, which allows typed values such as metadata
+// set as numbers or booleans to be used without parsing them from a string.
+// Otherwise the expression is resolved as a string.
+func (e *Expression) Value(index int, msg Message) interface{} {
+	if len(e.resolvers) == 1 {
+		if q, ok := e.resolvers[0].(*QueryResolver); ok {
+			if v, err := q.ResolveValue(index, msg); err == nil {
+				return v
+			}
+		}
+	}
+	return e.String(index, msg)
+}
@@ -356,3 +356,27 @@ func TestExpressions(t *testing.T) {
 		})
 	}
 }
+
+func TestExpressionValue(t *testing.T) {
+	metadataFn := func(key string) query.Function {
+		fn, err := query.InitFunctionHelper("metadata", key)
+		require.NoError(t, err)
+		return fn
+	}
+
+	part := message.NewPart([]byte("hello world"))
+	part.MetaSetTyped("retained", true)
+	part.MetaSetTyped("partition", int64(5))
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	assert.Equal(t, true, NewExpression(NewQueryResolver(metadataFn("retained"))).Value(0, msg))
+	assert.Equal(t, int64(5), NewExpression(NewQueryResolver(metadataFn("partition"))).Value(0, msg))
+
+	// Expressions with static parts or multiple functions resolve to strings.
+	assert.Equal(t, "5 partitions", NewExpression(
+		NewQueryResolver(metadataFn("partition")),
+		StaticResolver(" partitions"),
+	).Value(0, msg))
+	assert.Equal(t, "static", NewExpression(StaticResolver("static")).Value(0, msg))
+}
@@ -84,6 +84,24 @@ func (q QueryResolver) ResolveBytes(index int, msg Message, escaped, legacy bool
 	return bs
 }
 
+// ResolveValue returns the result of the query in its structured form, which
+// preserves the type of values such as typed metadata.
+func (q QueryResolver) ResolveValue(index int, msg Message) (interface{}, error) {
+	if msg == nil {
+		msg = message.QuickBatch(nil)
+	}
+	return q.fn.Exec(query.FunctionContext{
+		Index:    index,
+		MsgBatch: msg,
+		NewMeta:  msg.Get(index),
+	}.WithValueFunc(func() *interface{} {
+		if jObj, err := msg.Get(index).JSON(); err == nil {
+			return &jObj
+		}
+		return nil
+	}))
+}
+
 func escapeBytes(in []byte) []byte {
 	quoted := strconv.Quote(string(in))
 	if len(quoted) < 3 {
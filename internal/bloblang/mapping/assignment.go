@@ -15,6 +15,7 @@ import (
 
 type metaMsg interface {
 	MetaSet(key, value string)
+	MetaSetTyped(key string, value interface{})
 	MetaDelete(key string)
 	MetaIter(f func(k, v string) error) error
 }
@@ -99,7 +100,7 @@ func (m *MetaAssignment) Apply(value interface{}, ctx AssignmentContext) error {
 					return nil
 				})
 				for k, v := range m {
-					ctx.Meta.MetaSet(k, query.IToString(v))
+					setMetaValue(ctx.Meta, k, v)
 				}
 			} else {
 				return fmt.Errorf("setting root meta object requires object value, received: %T", value)
@@ -110,11 +111,22 @@ func (m *MetaAssignment) Apply(value interface{}, ctx AssignmentContext) error {
 	if deleted {
 		ctx.Meta.MetaDelete(*m.key)
 	} else {
-		ctx.Meta.MetaSet(*m.key, query.IToString(value))
+		setMetaValue(ctx.Meta, *m.key, value)
 	}
 	return nil
 }
 
+// setMetaValue sets a metadata value, where scalar values retain their type and
+// all other values are stored in their string form.
+func setMetaValue(meta metaMsg, key string, value interface{}) {
+	switch t := query.ISanitize(value).(type) {
+	case string, []byte, int64, uint64, float64, bool:
+		meta.MetaSetTyped(key, t)
+	default:
+		meta.MetaSet(key, query.IToString(value))
+	}
+}
+
 // Target returns a representation of what the assignment targets.
 func (m *MetaAssignment) Target() TargetPath {
 	var path []string
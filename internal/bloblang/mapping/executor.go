@@ -124,16 +124,32 @@ func (e *Executor) QueryPart(index int, msg Message) (bool, error) {
 // query.Delete value, in which case nil is returned and the part should be
 // discarded.
 func (e *Executor) MapPart(index int, msg Message) (*message.Part, error) {
-	return e.mapPart(nil, index, msg)
+	return e.mapPart(nil, nil, index, msg)
+}
+
+// MapBatch executes the bloblang mapping on each message of a batch in the
+// same way as MapPart, but with state shared by the executions across the
+// batch, meaning functions such as now() are evaluated once for the entire
+// batch and methods with dynamic arguments are instantiated once for each
+// distinct set of arguments.
+//
+// The result of each message is provided to a closure along with its index,
+// where a nil part and nil error indicates that the message was deleted.
+func (e *Executor) MapBatch(msg Message, fn func(i int, p *message.Part, err error)) {
+	cache := query.NewBatchCache()
+	for i := 0; i < msg.Len(); i++ {
+		p, err := e.mapPart(nil, cache, i, msg)
+		fn(i, p, err)
+	}
 }
 
 // MapOnto maps into an existing message part, where mappings are appended to
 // the message rather than being used to construct a new message.
 func (e *Executor) MapOnto(part *message.Part, index int, msg Message) (*message.Part, error) {
-	return e.mapPart(part, index, msg)
+	return e.mapPart(part, nil, index, msg)
 }
 
-func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message) (*message.Part, error) {
+func (e *Executor) mapPart(appendTo *message.Part, cache *query.BatchCache, index int, reference Message) (*message.Part, error) {
 	var valuePtr *interface{}
 	var parseErr error
 
@@ -174,6 +190,7 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 			MsgBatch: reference,
 			NewMeta:  newPart,
 			NewValue: &newValue,
+			Batch:    cache,
 		}.WithValueFunc(lazyValue))
 		if err != nil {
 			var line int
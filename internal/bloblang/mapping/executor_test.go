@@ -278,6 +278,52 @@ func TestAssignments(t *testing.T) {
 	}
 }
 
+func TestTypedMetaAssignments(t *testing.T) {
+	metaKey := func(k string) *string {
+		return &k
+	}
+
+	initFunc := func(name string, args ...interface{}) query.Function {
+		t.Helper()
+		fn, err := query.InitFunctionHelper(name, args...)
+		require.NoError(t, err)
+		return fn
+	}
+
+	mapping := NewExecutor("", nil, nil,
+		NewStatement(nil, NewMetaAssignment(metaKey("int")), query.NewLiteralFunction("", int64(5))),
+		NewStatement(nil, NewMetaAssignment(metaKey("float")), query.NewLiteralFunction("", 1.5)),
+		NewStatement(nil, NewMetaAssignment(metaKey("bool")), query.NewLiteralFunction("", true)),
+		NewStatement(nil, NewMetaAssignment(metaKey("obj")), query.NewLiteralFunction("", map[string]interface{}{"foo": "bar"})),
+		NewStatement(nil, NewJSONAssignment("typed"), initFunc("metadata", "in")),
+		NewStatement(nil, NewJSONAssignment("str"), initFunc("meta", "in")),
+	)
+
+	part := message.NewPart([]byte(`{}`))
+	part.MetaSetTyped("in", int64(10))
+
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	resPart, err := mapping.MapPart(0, msg)
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"str":"10","typed":10}`, string(resPart.Get()))
+
+	for k, exp := range map[string]interface{}{
+		"int":   int64(5),
+		"float": 1.5,
+		"bool":  true,
+		"obj":   `{"foo":"bar"}`,
+	} {
+		act, exists := resPart.MetaGetTyped(k)
+		require.True(t, exists, k)
+		assert.Equal(t, exp, act, k)
+	}
+	assert.Equal(t, "5", resPart.MetaGet("int"))
+	assert.Equal(t, "true", resPart.MetaGet("bool"))
+}
+
 func TestTargets(t *testing.T) {
 	function := func(name string, args ...interface{}) query.Function {
 		t.Helper()
@@ -1,6 +1,7 @@
 package bloblang
 
 import (
+	"strconv"
 	"sync"
 	"testing"
 
@@ -224,3 +225,106 @@ func TestMappingParallelExecution(t *testing.T) {
 		})
 	}
 }
+
+func TestMappingBatchExecution(t *testing.T) {
+	m, err := GlobalEnvironment().NewMapping(`root.now = now()
+root.matches = this.value.re_match(this.pattern)`)
+	require.NoError(t, err)
+
+	msg := message.QuickBatch(nil)
+	for i := 0; i < 10; i++ {
+		part := message.NewPart(nil)
+		part.SetJSON(map[string]interface{}{
+			"value":   "foo" + strconv.Itoa(i),
+			"pattern": "^foo[0-4]$",
+		})
+		msg.Append(part)
+	}
+
+	var nows []interface{}
+	m.MapBatch(msg, func(i int, p *message.Part, err error) {
+		require.NoError(t, err)
+
+		res, err := p.JSON()
+		require.NoError(t, err)
+
+		obj := res.(map[string]interface{})
+		assert.Equal(t, i < 5, obj["matches"], i)
+		nows = append(nows, obj["now"])
+	})
+	require.Len(t, nows, 10)
+	for _, n := range nows[1:] {
+		assert.Equal(t, nows[0], n)
+	}
+}
+
+func benchmarkDynamicRegexpBatch(b *testing.B, batched bool) {
+	m, err := GlobalEnvironment().NewMapping(`root = this.value.re_replace_all(this.pattern, "bar")`)
+	require.NoError(b, err)
+
+	msg := message.QuickBatch(nil)
+	for i := 0; i < 100; i++ {
+		part := message.NewPart(nil)
+		part.SetJSON(map[string]interface{}{
+			"value":   "foo" + strconv.Itoa(i),
+			"pattern": "f[o]+([0-9]*)",
+		})
+		msg.Append(part)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if batched {
+			m.MapBatch(msg, func(_ int, _ *message.Part, err error) {
+				if err != nil {
+					b.Fatal(err)
+				}
+			})
+			continue
+		}
+		for j := 0; j < msg.Len(); j++ {
+			if _, err := m.MapPart(j, msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDynamicRegexpPerMessage(b *testing.B) {
+	benchmarkDynamicRegexpBatch(b, false)
+}
+
+func BenchmarkDynamicRegexpBatched(b *testing.B) {
+	benchmarkDynamicRegexpBatch(b, true)
+}
+
+func TestMappingWithVars(t *testing.T) {
+	env := GlobalEnvironment().WithVars(map[string]interface{}{
+		"region":  "eu-west-1",
+		"threads": int64(4),
+	})
+
+	m, err := env.NewMapping(`let threads = 8
+root.region = var("region")
+root.threads = var("threads")
+root.missing = var("nope").catch("default")`)
+	require.NoError(t, err)
+
+	part := message.NewPart(nil)
+	part.SetJSON(map[string]interface{}{})
+
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	p, err := m.MapPart(0, msg)
+	require.NoError(t, err)
+
+	res, err := p.JSON()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"region":  "eu-west-1",
+		"threads": int64(8),
+		"missing": "default",
+	}, res)
+}
@@ -0,0 +1,88 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchCache holds state that is shared by the executions of a mapping across
+// all messages of a batch, which allows functions such as `now()` to be
+// evaluated once per batch, and methods with dynamic arguments to reuse the
+// work of instantiating themselves, such as the compilation of a regular
+// expression, rather than repeating it for each message.
+type BatchCache struct {
+	mut    sync.Mutex
+	now    time.Time
+	values map[interface{}]interface{}
+}
+
+// NewBatchCache returns an empty batch cache.
+func NewBatchCache() *BatchCache {
+	return &BatchCache{
+		values: map[interface{}]interface{}{},
+	}
+}
+
+// Now returns the time at which the current time was first requested from the
+// cache.
+func (b *BatchCache) Now() time.Time {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	if b.now.IsZero() {
+		b.now = time.Now()
+	}
+	return b.now
+}
+
+// Get returns a value stored under a key, and if the key does not yet exist the
+// value is obtained from a function and stored. Errors returned by the function
+// are not cached.
+func (b *BatchCache) Get(key interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	if v, exists := b.values[key]; exists {
+		return v, nil
+	}
+	v, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	b.values[key] = v
+	return v, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Now returns the current time, which is shared by all executions within a
+// batch when the context has a batch cache.
+func (ctx FunctionContext) Now() time.Time {
+	if ctx.Batch == nil {
+		return time.Now()
+	}
+	return ctx.Batch.Now()
+}
+
+type batchCacheKey struct {
+	owner *int
+	args  string
+}
+
+// batchCacheKey returns a key that identifies the instantiation of a method
+// with resolved arguments within the batch cache of the context, which is only
+// possible when the context has a batch cache and all arguments are scalar
+// values.
+func (ctx FunctionContext) batchCacheKey(owner *int, p *ParsedParams) (batchCacheKey, bool) {
+	if ctx.Batch == nil {
+		return batchCacheKey{}, false
+	}
+	for _, v := range p.values {
+		switch v.(type) {
+		case nil, string, bool, int64, float64, json.Number:
+		default:
+			return batchCacheKey{}, false
+		}
+	}
+	return batchCacheKey{owner: owner, args: fmt.Sprintf("%#v", p.values)}, true
+}
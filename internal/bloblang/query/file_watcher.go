@@ -0,0 +1,80 @@
+//go:build !wasm
+// +build !wasm
+
+package query
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var fileWatchers = struct {
+	sync.Mutex
+	watcher     *fsnotify.Watcher
+	dirs        map[string]struct{}
+	generations map[string]*uint64
+}{
+	dirs:        map[string]struct{}{},
+	generations: map[string]*uint64{},
+}
+
+// watchFileGeneration returns a counter that is incremented each time the file
+// at the given path is modified. Parent directories are watched rather than the
+// files themselves so that files replaced by a rename are also detected. A
+// single watcher is shared across all mappings and lives for the lifetime of
+// the process.
+func watchFileGeneration(path string) (*uint64, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileWatchers.Lock()
+	defer fileWatchers.Unlock()
+
+	if gen, exists := fileWatchers.generations[absPath]; exists {
+		return gen, nil
+	}
+
+	if fileWatchers.watcher == nil {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			return nil, err
+		}
+		fileWatchers.watcher = w
+		go func() {
+			for {
+				select {
+				case event, open := <-w.Events:
+					if !open {
+						return
+					}
+					fileWatchers.Lock()
+					if gen, exists := fileWatchers.generations[filepath.Clean(event.Name)]; exists {
+						atomic.AddUint64(gen, 1)
+					}
+					fileWatchers.Unlock()
+				case _, open := <-w.Errors:
+					if !open {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	dir := filepath.Dir(absPath)
+	if _, exists := fileWatchers.dirs[dir]; !exists {
+		if err := fileWatchers.watcher.Add(dir); err != nil {
+			return nil, err
+		}
+		fileWatchers.dirs[dir] = struct{}{}
+	}
+
+	gen := new(uint64)
+	fileWatchers.generations[absPath] = gen
+	return gen, nil
+}
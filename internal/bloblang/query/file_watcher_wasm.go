@@ -0,0 +1,12 @@
+//go:build wasm
+// +build wasm
+
+package query
+
+import (
+	"errors"
+)
+
+func watchFileGeneration(path string) (*uint64, error) {
+	return nil, errors.New("watching files is not supported in WASM builds")
+}
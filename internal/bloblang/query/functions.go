@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/gabs/v2"
@@ -207,6 +208,38 @@ var _ = registerSimpleFunction(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "batch_meta",
+		"Returns an object describing the batch that the message originated from, or `null` if the message has no batch information. The object contains the fields `id`, a unique identifier of the batch, `origin`, the input type that created the batch or `batching` for batches formed by a batch policy, `size`, the number of messages in the batch when it was created, `created_at`, the time at which the batch was created as a string in ISO 8601 format, and `parent_ids`, the identifiers of the batches that were merged in order to form the batch. Batch information is retained when a batch is split, and therefore continues to reference the originating batch after processors such as `split`.",
+		NewExampleSpec("",
+			`meta batch_id = batch_meta().id`,
+		),
+		NewExampleSpec("",
+			`root.audit.batch = batch_meta().without("parent_ids")`,
+		),
+	).Beta(),
+	func(ctx FunctionContext) (interface{}, error) {
+		info, exists := message.GetBatchInfo(ctx.MsgBatch.Get(ctx.Index))
+		if !exists {
+			return nil, nil
+		}
+		parentIDs := make([]interface{}, len(info.ParentIDs))
+		for i, id := range info.ParentIDs {
+			parentIDs[i] = id
+		}
+		return map[string]interface{}{
+			"id":         info.ID,
+			"origin":     info.Origin,
+			"size":       int64(info.Size),
+			"created_at": info.Created.Format(time.RFC3339Nano),
+			"parent_ids": parentIDs,
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleFunction(
 	NewFunctionSpec(
 		FunctionCategoryMessage, "content",
@@ -351,6 +384,75 @@ var _ = registerSimpleFunction(
 	},
 )
 
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_source",
+		"If an error has occurred during the processing of a message this function returns the label of the processor that failed, or its path within the config when it has no label, otherwise `null`. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root.doc.failed_at = error_source()`,
+		),
+	),
+	errMetaFunction(message.ErrorSourceKey),
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_class",
+		"If an error has occurred during the processing of a message this function returns the class of the error, otherwise `null`. The class is one of `schema` (the message could not be parsed or did not match an expected structure), `transient` (a temporary failure such as a timeout, connection error or rate limit), `rejected` (a remote service rejected the request) or `unknown`. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root = if error_class() == "schema" { deleted() }`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		part := ctx.MsgBatch.Get(ctx.Index)
+		if part.MetaGet(message.FailFlagKey) == "" {
+			return nil, nil
+		}
+		if v := part.MetaGet(message.ErrorClassKey); v != "" {
+			return v, nil
+		}
+		return "unknown", nil
+	},
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_code",
+		"If an error with a known code has occurred during the processing of a message this function returns the code as a string, such as the status code of an HTTP response, otherwise `null`. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root.doc.status = error_code().or("500")`,
+		),
+	),
+	errMetaFunction(message.ErrorCodeKey),
+)
+
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "error_retriable",
+		"If an error has occurred during the processing of a message this function returns a boolean indicating whether retrying might succeed, such as after a timeout, otherwise `null`. For more information about error handling patterns read [here][error_handling].",
+		NewExampleSpec("",
+			`root.doc.retry = error_retriable()`,
+		),
+	),
+	func(ctx FunctionContext) (interface{}, error) {
+		part := ctx.MsgBatch.Get(ctx.Index)
+		if part.MetaGet(message.FailFlagKey) == "" {
+			return nil, nil
+		}
+		return part.MetaGet(message.ErrorRetriableKey) == "true", nil
+	},
+)
+
+func errMetaFunction(key string) func(ctx FunctionContext) (interface{}, error) {
+	return func(ctx FunctionContext) (interface{}, error) {
+		v := ctx.MsgBatch.Get(ctx.Index).MetaGet(key)
+		if v == "" {
+			return nil, nil
+		}
+		return v, nil
+	}
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -381,6 +483,98 @@ func fileFunction(args *ParsedParams) (Function, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryEnvironment, "cached_file",
+		"Reads a file and returns its contents, caching the contents and reloading them from the file once the `ttl` has passed. When `watch` is `true` the contents are also reloaded as soon as the file is modified, allowing files used for enrichment to be updated without restarting the stream. Relative paths are resolved from the directory of the process executing the mapping.",
+		NewExampleSpec("",
+			`root.doc = cached_file(env("BENTHOS_TEST_BLOBLANG_FILE")).parse_json()`,
+			`{}`,
+			`{"doc":{"foo":"bar"}}`,
+		),
+		NewExampleSpec("By disabling the `ttl` and enabling `watch` the contents are only reloaded when the file is modified.",
+			`root.doc = cached_file(path: env("BENTHOS_TEST_BLOBLANG_FILE"), ttl: "", watch: true).parse_json().foo`,
+			`{}`,
+			`{"doc":"bar"}`,
+		),
+	).Beta().MarkImpure().
+		Param(ParamString("path", "The path of the target file.")).
+		Param(ParamString("ttl", "The duration after which the contents are reloaded from the file. An empty string disables expiry, which is useful in combination with `watch`.").Default("1m")).
+		Param(ParamBool("watch", "Whether to reload the contents as soon as the file is modified.").Default(false)),
+	cachedFileFunction,
+)
+
+func cachedFileFunction(args *ParsedParams) (Function, error) {
+	path, err := args.FieldString("path")
+	if err != nil {
+		return nil, err
+	}
+	ttlStr, err := args.FieldString("ttl")
+	if err != nil {
+		return nil, err
+	}
+	watch, err := args.FieldBool("watch")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &cachedFile{path: path}
+	if ttlStr != "" {
+		if f.ttl, err = time.ParseDuration(ttlStr); err != nil {
+			return nil, fmt.Errorf("failed to parse ttl: %w", err)
+		}
+	}
+	if watch {
+		if f.generation, err = watchFileGeneration(path); err != nil {
+			return nil, fmt.Errorf("failed to watch file %v: %w", path, err)
+		}
+	}
+
+	// Read the file eagerly so that missing files are reported when the
+	// mapping is parsed, matching the behaviour of the file function.
+	if _, err := f.get(); err != nil {
+		return nil, err
+	}
+	return ClosureFunction("function cached_file", func(ctx FunctionContext) (interface{}, error) {
+		return f.get()
+	}, nil), nil
+}
+
+type cachedFile struct {
+	path       string
+	ttl        time.Duration
+	generation *uint64
+
+	mut         sync.Mutex
+	contents    []byte
+	loaded      bool
+	loadedAt    time.Time
+	loadedAtGen uint64
+}
+
+func (c *cachedFile) get() ([]byte, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var gen uint64
+	if c.generation != nil {
+		gen = atomic.LoadUint64(c.generation)
+	}
+	if c.loaded && gen == c.loadedAtGen && (c.ttl <= 0 || time.Since(c.loadedAt) < c.ttl) {
+		return c.contents, nil
+	}
+
+	contents, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	c.contents, c.loaded = contents, true
+	c.loadedAt, c.loadedAtGen = time.Now(), gen
+	return c.contents, nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "range",
@@ -453,6 +647,29 @@ var _ = registerSimpleFunction(
 	},
 )
 
+var _ = registerSimpleFunction(
+	NewFunctionSpec(
+		FunctionCategoryEnvironment, "pod_name",
+		"Returns the name of the Kubernetes pod running Benthos, which is read from the environment variable `POD_NAME` when set (usually via the downward API) and otherwise falls back to the hostname of the machine, which matches the pod name by default.",
+		NewExampleSpec("",
+			`root.thing.pod = pod_name()`,
+		),
+	).MarkImpure(),
+	func(_ FunctionContext) (interface{}, error) {
+		if name := os.Getenv("POD_NAME"); name != "" {
+			return name, nil
+		}
+		hn, err := os.Hostname()
+		if err != nil {
+			return nil, &ErrRecoverable{
+				Recovered: "",
+				Err:       err,
+			}
+		}
+		return hn, err
+	},
+)
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -621,6 +838,57 @@ var _ = registerFunction(
 
 //------------------------------------------------------------------------------
 
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "metadata",
+		"Returns the value of a metadata key from the input message in its typed form, or `null` if the key does not exist. Unlike the [`meta` function](#meta), which always returns strings, metadata values that were set as numbers, booleans or bytes are returned as such, and empty string values are returned rather than `null`. Timestamp values are returned as strings in ISO 8601 format. Since values are extracted from the read-only input message they do NOT reflect changes made from within the map.",
+		NewExampleSpec("",
+			`root.partition = metadata("kafka_partition") + 1`,
+		),
+		NewExampleSpec(
+			"The key parameter is optional and if omitted the entire metadata contents are returned as an object.",
+			`root.all_metadata = metadata()`,
+		),
+	).Beta().Param(ParamString("key", "An optional key of a metadata value to obtain.").Default("")),
+	func(args *ParsedParams) (Function, error) {
+		key, err := args.FieldString("key")
+		if err != nil {
+			return nil, err
+		}
+		if len(key) > 0 {
+			return ClosureFunction("metadata field "+key, func(ctx FunctionContext) (interface{}, error) {
+				v, exists := ctx.MsgBatch.Get(ctx.Index).MetaGetTyped(key)
+				if !exists {
+					return nil, nil
+				}
+				return ISanitize(v), nil
+			}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
+				paths := []TargetPath{
+					NewTargetPath(TargetMetadata, key),
+				}
+				ctx = ctx.WithValues(paths)
+				return ctx, paths
+			}), nil
+		}
+		return ClosureFunction("metadata object", func(ctx FunctionContext) (interface{}, error) {
+			kvs := map[string]interface{}{}
+			_ = ctx.MsgBatch.Get(ctx.Index).MetaIterTyped(func(k string, v interface{}) error {
+				kvs[k] = ISanitize(v)
+				return nil
+			})
+			return kvs, nil
+		}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
+			paths := []TargetPath{
+				NewTargetPath(TargetMetadata),
+			}
+			ctx = ctx.WithValues(paths)
+			return ctx, paths
+		}), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerFunction(
 	NewHiddenFunctionSpec("nothing"),
 	func(*ParsedParams) (Function, error) {
@@ -696,8 +964,8 @@ var _ = registerFunction(
 		),
 	),
 	func(args *ParsedParams) (Function, error) {
-		return ClosureFunction("function now", func(_ FunctionContext) (interface{}, error) {
-			return time.Now().Format(time.RFC3339Nano), nil
+		return ClosureFunction("function now", func(ctx FunctionContext) (interface{}, error) {
+			return ctx.Now().Format(time.RFC3339Nano), nil
 		}, nil), nil
 	},
 )
@@ -710,8 +978,8 @@ var _ = registerSimpleFunction(
 			`root.received_at = timestamp_unix()`,
 		),
 	),
-	func(_ FunctionContext) (interface{}, error) {
-		return time.Now().Unix(), nil
+	func(ctx FunctionContext) (interface{}, error) {
+		return ctx.Now().Unix(), nil
 	},
 )
 
@@ -723,8 +991,8 @@ var _ = registerSimpleFunction(
 			`root.received_at = timestamp_unix_nano()`,
 		),
 	),
-	func(_ FunctionContext) (interface{}, error) {
-		return time.Now().UnixNano(), nil
+	func(ctx FunctionContext) (interface{}, error) {
+		return ctx.Now().UnixNano(), nil
 	},
 )
 
@@ -828,8 +1096,12 @@ var _ = registerSimpleFunction(
 
 //------------------------------------------------------------------------------
 
+// VarFunctionSpec describes the hidden function used for referencing
+// variables.
+var VarFunctionSpec = NewHiddenFunctionSpec("var").Param(ParamString("name", "The name of the target variable."))
+
 var _ = registerFunction(
-	NewHiddenFunctionSpec("var").Param(ParamString("name", "The name of the target variable.")),
+	VarFunctionSpec,
 	func(args *ParsedParams) (Function, error) {
 		name, err := args.FieldString("name")
 		if err != nil {
@@ -841,13 +1113,23 @@ var _ = registerFunction(
 
 // NewVarFunction creates a new variable function.
 func NewVarFunction(name string) Function {
+	return NewVarFunctionWithFallback(name, nil)
+}
+
+// NewVarFunctionWithFallback creates a new variable function that resolves to
+// a value from a static set of fallback values when the variable has not been
+// defined within the mapping, which is how config variables are exposed.
+func NewVarFunctionWithFallback(name string, fallback map[string]interface{}) Function {
 	return ClosureFunction("variable "+name, func(ctx FunctionContext) (interface{}, error) {
-		if ctx.Vars == nil {
-			return nil, errors.New("variables were undefined")
-		}
 		if res, ok := ctx.Vars[name]; ok {
 			return res, nil
 		}
+		if res, ok := fallback[name]; ok {
+			return res, nil
+		}
+		if ctx.Vars == nil {
+			return nil, errors.New("variables were undefined")
+		}
 		return nil, fmt.Errorf("variable '%v' undefined", name)
 	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
 		paths := []TargetPath{
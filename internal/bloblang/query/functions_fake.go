@@ -0,0 +1,249 @@
+package query
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var fakeGenerators = map[string]func(r *rand.Rand) string{
+	"first_name": func(r *rand.Rand) string {
+		return fakePick(r, fakeFirstNames)
+	},
+	"last_name": func(r *rand.Rand) string {
+		return fakePick(r, fakeLastNames)
+	},
+	"name": func(r *rand.Rand) string {
+		return fakePick(r, fakeFirstNames) + " " + fakePick(r, fakeLastNames)
+	},
+	"username": fakeUsername,
+	"email": func(r *rand.Rand) string {
+		return fakeUsername(r) + "@" + fakePick(r, fakeEmailDomains)
+	},
+	"phone_number": func(r *rand.Rand) string {
+		return fmt.Sprintf("+1-%03d-%03d-%04d", 200+r.Intn(800), 200+r.Intn(800), r.Intn(10000))
+	},
+	"street_address": func(r *rand.Rand) string {
+		return strconv.Itoa(1+r.Intn(9999)) + " " + fakePick(r, fakeStreetNames) + " " + fakePick(r, fakeStreetSuffixes)
+	},
+	"city": func(r *rand.Rand) string {
+		return fakePick(r, fakeCities)
+	},
+	"country": func(r *rand.Rand) string {
+		return fakeCountries[r.Intn(len(fakeCountries))][0]
+	},
+	"country_code": func(r *rand.Rand) string {
+		return fakeCountries[r.Intn(len(fakeCountries))][1]
+	},
+	"postcode": func(r *rand.Rand) string {
+		return fmt.Sprintf("%05d", r.Intn(100000))
+	},
+	"company": func(r *rand.Rand) string {
+		return fakePick(r, fakeLastNames) + " " + fakePick(r, fakeCompanySuffixes)
+	},
+	"ipv4": func(r *rand.Rand) string {
+		return fmt.Sprintf("%d.%d.%d.%d", 1+r.Intn(223), r.Intn(256), r.Intn(256), 1+r.Intn(254))
+	},
+	"url": func(r *rand.Rand) string {
+		return "https://www." + strings.ToLower(fakePick(r, fakeLastNames)) + "." + fakePick(r, fakeTLDs) + "/" + fakePick(r, fakeLoremWords)
+	},
+	"word": func(r *rand.Rand) string {
+		return fakePick(r, fakeLoremWords)
+	},
+	"sentence": fakeSentence,
+	"paragraph": func(r *rand.Rand) string {
+		sentences := make([]string, 3+r.Intn(4))
+		for i := range sentences {
+			sentences[i] = fakeSentence(r)
+		}
+		return strings.Join(sentences, " ")
+	},
+}
+
+func fakeKinds() []string {
+	kinds := make([]string, 0, len(fakeGenerators))
+	for k := range fakeGenerators {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "fake",
+		"Generates a realistic looking fake value of a given kind, which is useful for producing test data with the `generate` input. Values are generated with a pseudo-random number generator, and therefore a mapping with a fixed seed produces the same sequence of values each time it is executed. The following kinds are supported: `"+strings.Join(fakeKinds(), "`, `")+"`.",
+		NewExampleSpec("",
+			`root.name = fake("name")
+root.email = fake("email")
+root.address = fake("street_address") + ", " + fake("city")`,
+		),
+		NewExampleSpec("It is possible to specify a dynamic seed argument, in which case the argument will only be resolved once during the lifetime of the mapping.",
+			`root.bio = fake(kind: "paragraph", seed: timestamp_unix_nano())`,
+		),
+	).Beta().
+		Param(ParamString("kind", "The kind of value to generate.")).
+		Param(ParamQuery(
+			"seed",
+			"A seed to use, if a query is provided it will only be resolved once during the lifetime of the mapping.",
+			true,
+		).Default(NewLiteralFunction("", 0))),
+	fakeFunction,
+)
+
+func fakeFunction(args *ParsedParams) (Function, error) {
+	kind, err := args.FieldString("kind")
+	if err != nil {
+		return nil, err
+	}
+	gen, exists := fakeGenerators[kind]
+	if !exists {
+		return nil, fmt.Errorf("unrecognised fake kind: %v", kind)
+	}
+	seedFn, err := args.FieldQuery("seed")
+	if err != nil {
+		return nil, err
+	}
+
+	var randMut sync.Mutex
+	var r *rand.Rand
+
+	return ClosureFunction("function fake", func(ctx FunctionContext) (interface{}, error) {
+		randMut.Lock()
+		defer randMut.Unlock()
+
+		if r == nil {
+			seedI, err := seedFn.Exec(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to seed random number generator: %v", err)
+			}
+
+			seed, err := IToInt(seedI)
+			if err != nil {
+				return nil, fmt.Errorf("failed to seed random number generator: %v", err)
+			}
+
+			r = rand.New(rand.NewSource(seed))
+		}
+
+		return gen(r), nil
+	}, nil), nil
+}
+
+//------------------------------------------------------------------------------
+
+func fakePick(r *rand.Rand, from []string) string {
+	return from[r.Intn(len(from))]
+}
+
+func fakeUsername(r *rand.Rand) string {
+	first := strings.ToLower(fakePick(r, fakeFirstNames))
+	last := strings.ToLower(fakePick(r, fakeLastNames))
+	switch r.Intn(3) {
+	case 0:
+		return first + "." + last
+	case 1:
+		return first[:1] + last + strconv.Itoa(r.Intn(100))
+	}
+	return first + "_" + last
+}
+
+func fakeSentence(r *rand.Rand) string {
+	words := make([]string, 4+r.Intn(9))
+	for i := range words {
+		words[i] = fakePick(r, fakeLoremWords)
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "."
+}
+
+var fakeFirstNames = []string{
+	"Aaliyah", "Aaron", "Abigail", "Adam", "Aiden", "Alexander", "Alice", "Amara",
+	"Amelia", "Andre", "Anna", "Ari", "Aria", "Arjun", "Ava", "Benjamin", "Bianca",
+	"Caleb", "Camila", "Carlos", "Charlotte", "Chen", "Chloe", "Daniel", "David",
+	"Diego", "Dmitri", "Elena", "Eli", "Elijah", "Emily", "Emma", "Ethan", "Fatima",
+	"Felix", "Gabriel", "Grace", "Hana", "Harper", "Hiroshi", "Ibrahim", "Isabella",
+	"Isla", "Jack", "James", "Jin", "Jonah", "Jose", "Julia", "Kai", "Kenji",
+	"Layla", "Leah", "Leo", "Liam", "Lucas", "Lucia", "Maya", "Mateo", "Mia",
+	"Mohammed", "Nadia", "Noah", "Nora", "Olivia", "Omar", "Priya", "Quinn", "Rafael",
+	"Riley", "Rosa", "Ruby", "Samuel", "Sara", "Sofia", "Sophie", "Tariq", "Theo",
+	"Uma", "Valentina", "Victor", "Wei", "William", "Yara", "Yusuf", "Zara", "Zoe",
+}
+
+var fakeLastNames = []string{
+	"Adams", "Ahmed", "Alvarez", "Anderson", "Bailey", "Baker", "Bennett", "Brooks",
+	"Brown", "Campbell", "Carter", "Chen", "Clark", "Cohen", "Collins", "Cooper",
+	"Davis", "Diaz", "Edwards", "Evans", "Fischer", "Flores", "Garcia", "Gomez",
+	"Gonzalez", "Green", "Gupta", "Hall", "Harris", "Hernandez", "Hill", "Ito",
+	"Jackson", "Johnson", "Jones", "Kaur", "Khan", "Kim", "King", "Kowalski", "Lee",
+	"Lewis", "Lopez", "Martin", "Martinez", "Miller", "Mitchell", "Moore", "Morgan",
+	"Murphy", "Nakamura", "Nelson", "Nguyen", "Novak", "Okafor", "Parker", "Patel",
+	"Perez", "Petrov", "Phillips", "Ramirez", "Reed", "Roberts", "Robinson",
+	"Rodriguez", "Rossi", "Sanchez", "Schmidt", "Scott", "Silva", "Singh", "Smith",
+	"Tanaka", "Taylor", "Thomas", "Thompson", "Torres", "Turner", "Walker", "Wang",
+	"White", "Williams", "Wilson", "Wright", "Yamamoto", "Young", "Zhang",
+}
+
+var fakeEmailDomains = []string{
+	"example.com", "example.net", "example.org", "mail.example.com", "test.example.org",
+}
+
+var fakeStreetNames = []string{
+	"Acacia", "Ash", "Bay", "Birch", "Cedar", "Cherry", "Chestnut", "Church", "Elm",
+	"Forest", "Garden", "Hickory", "High", "Highland", "Hill", "Lake", "Laurel",
+	"Madison", "Magnolia", "Main", "Maple", "Meadow", "Mill", "Oak", "Orchard",
+	"Park", "Pine", "Railroad", "Ridge", "River", "Spring", "Sunset", "Valley",
+	"Walnut", "Washington", "Willow",
+}
+
+var fakeStreetSuffixes = []string{
+	"Avenue", "Boulevard", "Close", "Court", "Drive", "Lane", "Place", "Road",
+	"Street", "Terrace", "Way",
+}
+
+var fakeCities = []string{
+	"Amsterdam", "Athens", "Auckland", "Austin", "Bangalore", "Barcelona", "Berlin",
+	"Bogotá", "Boston", "Brisbane", "Buenos Aires", "Cairo", "Cape Town", "Chicago",
+	"Copenhagen", "Denver", "Dublin", "Edinburgh", "Helsinki", "Istanbul", "Jakarta",
+	"Lagos", "Lima", "Lisbon", "London", "Los Angeles", "Madrid", "Manchester",
+	"Melbourne", "Mexico City", "Milan", "Montreal", "Mumbai", "Nairobi", "Osaka",
+	"Oslo", "Paris", "Prague", "Rome", "San Francisco", "Santiago", "São Paulo",
+	"Seattle", "Seoul", "Singapore", "Stockholm", "Sydney", "Taipei", "Tokyo",
+	"Toronto", "Vancouver", "Vienna", "Warsaw", "Zurich",
+}
+
+var fakeCountries = [][2]string{
+	{"Argentina", "AR"}, {"Australia", "AU"}, {"Austria", "AT"}, {"Belgium", "BE"},
+	{"Brazil", "BR"}, {"Canada", "CA"}, {"Chile", "CL"}, {"China", "CN"},
+	{"Colombia", "CO"}, {"Denmark", "DK"}, {"Egypt", "EG"}, {"Finland", "FI"},
+	{"France", "FR"}, {"Germany", "DE"}, {"Greece", "GR"}, {"India", "IN"},
+	{"Indonesia", "ID"}, {"Ireland", "IE"}, {"Italy", "IT"}, {"Japan", "JP"},
+	{"Kenya", "KE"}, {"Mexico", "MX"}, {"Netherlands", "NL"}, {"New Zealand", "NZ"},
+	{"Nigeria", "NG"}, {"Norway", "NO"}, {"Peru", "PE"}, {"Poland", "PL"},
+	{"Portugal", "PT"}, {"Singapore", "SG"}, {"South Africa", "ZA"},
+	{"South Korea", "KR"}, {"Spain", "ES"}, {"Sweden", "SE"}, {"Switzerland", "CH"},
+	{"Turkey", "TR"}, {"United Kingdom", "GB"}, {"United States", "US"},
+}
+
+var fakeCompanySuffixes = []string{
+	"Group", "Holdings", "Inc", "Industries", "LLC", "Labs", "Ltd", "Partners",
+	"Solutions", "Systems", "Technologies",
+}
+
+var fakeTLDs = []string{
+	"com", "net", "org", "io", "dev",
+}
+
+var fakeLoremWords = []string{
+	"ad", "adipiscing", "aliqua", "aliquip", "amet", "anim", "aute", "cillum",
+	"commodo", "consectetur", "consequat", "culpa", "cupidatat", "deserunt", "do",
+	"dolor", "dolore", "duis", "ea", "eiusmod", "elit", "enim", "esse", "est", "et",
+	"eu", "ex", "excepteur", "exercitation", "fugiat", "id", "in", "incididunt",
+	"ipsum", "irure", "labore", "laboris", "laborum", "lorem", "magna", "minim",
+	"mollit", "nisi", "non", "nostrud", "nulla", "occaecat", "officia", "pariatur",
+	"proident", "qui", "quis", "reprehenderit", "sed", "sint", "sit", "sunt",
+	"tempor", "ullamco", "ut", "velit", "veniam", "voluptate",
+}
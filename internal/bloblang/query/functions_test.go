@@ -3,8 +3,10 @@ package query
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -141,6 +143,26 @@ func TestFunctions(t *testing.T) {
 			},
 			err: `with negative step arg stop (100) must be <= start (10)`,
 		},
+		"check error_retriable without error": {
+			input:    mustFunc("error_retriable"),
+			output:   nil,
+			messages: []easyMsg{{content: "foo"}},
+		},
+		"check error_retriable not retriable": {
+			input:  mustFunc("error_retriable"),
+			output: false,
+			messages: []easyMsg{{content: "foo", meta: map[string]string{
+				message.FailFlagKey: "nope",
+			}}},
+		},
+		"check error_retriable retriable": {
+			input:  mustFunc("error_retriable"),
+			output: true,
+			messages: []easyMsg{{content: "foo", meta: map[string]string{
+				message.FailFlagKey:       "nope",
+				message.ErrorRetriableKey: "true",
+			}}},
+		},
 	}
 
 	for name, test := range tests {
@@ -292,6 +314,98 @@ func TestEnvFunction(t *testing.T) {
 	assert.Equal(t, "foobar", res)
 }
 
+func TestPodNameFunction(t *testing.T) {
+	t.Setenv("POD_NAME", "benthos-abc123")
+
+	e, err := InitFunctionHelper("pod_name")
+	require.NoError(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "benthos-abc123", res)
+}
+
+func TestBatchMetaFunction(t *testing.T) {
+	e, err := InitFunctionHelper("batch_meta")
+	require.NoError(t, err)
+
+	msg := message.QuickBatch([][]byte{[]byte("foo")})
+
+	res, err := e.Exec(FunctionContext{MsgBatch: msg})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	info := message.NewBatchInfo("foo", 5)
+	info.ParentIDs = []string{"a", "b"}
+	msg.SetAll([]*message.Part{message.WithBatchInfo(info, msg.Get(0))})
+
+	res, err = e.Exec(FunctionContext{MsgBatch: msg})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":         info.ID,
+		"origin":     "foo",
+		"size":       int64(5),
+		"created_at": info.Created.Format(time.RFC3339Nano),
+		"parent_ids": []interface{}{"a", "b"},
+	}, res)
+}
+
+func TestCachedFileFunctionTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o644))
+
+	e, err := InitFunctionHelper("cached_file", path, "1h")
+	require.NoError(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), res)
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o644))
+
+	res, err = e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), res)
+
+	e, err = InitFunctionHelper("cached_file", path, "1ns")
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("third"), 0o644))
+	<-time.After(time.Millisecond)
+
+	res, err = e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("third"), res)
+
+	_, err = InitFunctionHelper("cached_file", filepath.Join(t.TempDir(), "nope.txt"))
+	require.Error(t, err)
+
+	_, err = InitFunctionHelper("cached_file", path, "nope")
+	require.Error(t, err)
+}
+
+func TestCachedFileFunctionWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0o644))
+
+	e, err := InitFunctionHelper("cached_file", path, "", true)
+	require.NoError(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), res)
+
+	// Replace the file with a rename, as is common when updating files.
+	tmpPath := path + ".tmp"
+	require.NoError(t, os.WriteFile(tmpPath, []byte("second"), 0o644))
+	require.NoError(t, os.Rename(tmpPath, path))
+
+	assert.Eventually(t, func() bool {
+		res, err := e.Exec(FunctionContext{})
+		return err == nil && string(res.([]byte)) == "second"
+	}, time.Second*5, time.Millisecond*10)
+}
+
 func TestRandomInt(t *testing.T) {
 	e, err := InitFunctionHelper("random_int")
 	require.Nil(t, err)
@@ -415,3 +529,34 @@ func TestRandomIntDynamicParallel(t *testing.T) {
 	close(startChan)
 	wg.Wait()
 }
+
+func TestFakeFunction(t *testing.T) {
+	for _, kind := range fakeKinds() {
+		e, err := InitFunctionHelper("fake", kind, 10)
+		require.NoError(t, err, kind)
+
+		f, err := InitFunctionHelper("fake", kind, 10)
+		require.NoError(t, err, kind)
+
+		for i := 0; i < 10; i++ {
+			res, err := e.Exec(FunctionContext{})
+			require.NoError(t, err, kind)
+			require.IsType(t, "", res, kind)
+			assert.NotEmpty(t, res, kind)
+
+			// Generators with the same seed produce the same sequence.
+			other, err := f.Exec(FunctionContext{})
+			require.NoError(t, err, kind)
+			assert.Equal(t, res, other, kind)
+		}
+	}
+
+	e, err := InitFunctionHelper("fake", "email")
+	require.NoError(t, err)
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Regexp(t, `^[a-z._0-9]+@[a-z.]+$`, res)
+
+	_, err = InitFunctionHelper("fake", "nope")
+	require.EqualError(t, err, "unrecognised fake kind: nope")
+}
@@ -167,15 +167,27 @@ func wrapMethodCtorWithDynamicArgs(name string, target Function, args *ParsedPar
 	if len(fns) == 0 {
 		return fn(target, args)
 	}
+	owner := new(int)
 	return ClosureFunction("method "+name, func(ctx FunctionContext) (interface{}, error) {
 		newArgs, err := args.ResolveDynamic(ctx)
 		if err != nil {
 			return nil, err
 		}
-		dynFunc, err := fn(target, newArgs)
+		ctor := func() (interface{}, error) {
+			return fn(target, newArgs)
+		}
+		// Within a batch the method is instantiated once for each distinct set
+		// of arguments, as this may involve expensive work such as compiling a
+		// regular expression.
+		var dynFunc interface{}
+		if key, ok := ctx.batchCacheKey(owner, newArgs); ok {
+			dynFunc, err = ctx.Batch.Get(key, ctor)
+		} else {
+			dynFunc, err = ctor()
+		}
 		if err != nil {
 			return nil, err
 		}
-		return dynFunc.Exec(ctx)
+		return dynFunc.(Function).Exec(ctx)
 	}, aggregateTargetPaths(fns...)), nil
 }
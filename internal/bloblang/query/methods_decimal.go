@@ -0,0 +1,390 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimals are represented as json.Number values, which are serialized as JSON
+// numbers without any loss of precision. Arithmetic is performed with rational
+// numbers and is therefore exact, with the exception of division, which is
+// rounded to a specified number of decimal places.
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"parse_decimal", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Attempts to parse a string or number as an arbitrary precision decimal number. Decimals are serialized as JSON numbers without any loss of precision, but are converted into floating point numbers when used with arithmetic operators such as `+`, and therefore the methods [`decimal_add`](#decimal_add), [`decimal_sub`](#decimal_sub), [`decimal_mul`](#decimal_mul) and [`decimal_div`](#decimal_div) should be used in order to perform arithmetic without loss of precision. Numbers within JSON documents are already parsed with arbitrary precision, but string values and numbers that have passed through floating point arithmetic must first be parsed with this method. Floating point numbers are parsed as the shortest decimal that represents them.",
+		NewExampleSpec("",
+			`root.amount = this.amount.parse_decimal()`,
+			`{"amount":"1234567890123456789.123456789"}`,
+			`{"amount":1234567890123456789.123456789}`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			d, err := IGetDecimal(v)
+			if err != nil {
+				return nil, err
+			}
+			return decimalToNumber(d), nil
+		}, nil
+	},
+)
+
+func registerDecimalArithmeticMethod(name, description string, example ExampleSpec, fn func(lhs, rhs *big.Rat) *big.Rat) struct{} {
+	return registerSimpleMethod(
+		NewMethodSpec(
+			name, "",
+		).InCategory(
+			MethodCategoryNumbers,
+			description+" Both values can either be numbers or strings containing decimal numbers, and the result is an arbitrary precision decimal.",
+			example,
+		).Beta().
+			Param(ParamAny("value", "The decimal value to operate with.")),
+		func(args *ParsedParams) (simpleMethod, error) {
+			rhsV, err := args.Field("value")
+			if err != nil {
+				return nil, err
+			}
+			return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+				lhs, err := IGetDecimal(v)
+				if err != nil {
+					return nil, err
+				}
+				rhs, err := IGetDecimal(rhsV)
+				if err != nil {
+					return nil, err
+				}
+				return decimalToNumber(fn(lhs, rhs)), nil
+			}, nil
+		},
+	)
+}
+
+var _ = registerDecimalArithmeticMethod(
+	"decimal_add", "Adds a value to a decimal without any loss of precision.",
+	NewExampleSpec("",
+		`root.total = this.amount.decimal_add(this.fee)`,
+		`{"amount":"0.1","fee":"0.2"}`,
+		`{"total":0.3}`,
+	),
+	func(lhs, rhs *big.Rat) *big.Rat {
+		return new(big.Rat).Add(lhs, rhs)
+	},
+)
+
+var _ = registerDecimalArithmeticMethod(
+	"decimal_sub", "Subtracts a value from a decimal without any loss of precision.",
+	NewExampleSpec("",
+		`root.remaining = this.balance.decimal_sub(this.amount)`,
+		`{"balance":"10000000000000000.01","amount":"0.02"}`,
+		`{"remaining":9999999999999999.99}`,
+	),
+	func(lhs, rhs *big.Rat) *big.Rat {
+		return new(big.Rat).Sub(lhs, rhs)
+	},
+)
+
+var _ = registerDecimalArithmeticMethod(
+	"decimal_mul", "Multiplies a decimal by a value without any loss of precision.",
+	NewExampleSpec("",
+		`root.total = this.price.decimal_mul(this.quantity)`,
+		`{"price":"19.99","quantity":3}`,
+		`{"total":59.97}`,
+	),
+	func(lhs, rhs *big.Rat) *big.Rat {
+		return new(big.Rat).Mul(lhs, rhs)
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"decimal_div", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Divides a decimal by a value, rounding the result to a number of decimal places according to a [rounding mode](#decimal_round). Both values can either be numbers or strings containing decimal numbers, and the result is an arbitrary precision decimal.",
+		NewExampleSpec("",
+			`root.share = this.amount.decimal_div(value: 3, places: 2)`,
+			`{"amount":"100"}`,
+			`{"share":33.33}`,
+		),
+	).Beta().
+		Param(ParamAny("value", "The decimal value to divide by.")).
+		Param(ParamInt64("places", "The number of decimal places to round the result to.").Default(16)).
+		Param(ParamString("mode", "The [rounding mode](#decimal_round) to use.").Default(roundHalfUp)),
+	func(args *ParsedParams) (simpleMethod, error) {
+		rhsV, err := args.Field("value")
+		if err != nil {
+			return nil, err
+		}
+		places, mode, err := decimalRoundingArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			lhs, err := IGetDecimal(v)
+			if err != nil {
+				return nil, err
+			}
+			rhs, err := IGetDecimal(rhsV)
+			if err != nil {
+				return nil, err
+			}
+			if rhs.Sign() == 0 {
+				return nil, errors.New("attempted to divide by zero")
+			}
+			return decimalToNumber(roundDecimal(new(big.Rat).Quo(lhs, rhs), places, mode)), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"decimal_round", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		`Rounds a decimal to a number of decimal places according to a rounding mode, and returns an arbitrary precision decimal. The following rounding modes are supported:
+
+- `+"`half_up`"+`: Round to the nearest neighbour, where halves are rounded away from zero.
+- `+"`half_down`"+`: Round to the nearest neighbour, where halves are rounded towards zero.
+- `+"`half_even`"+`: Round to the nearest neighbour, where halves are rounded to the even neighbour, also known as banker's rounding.
+- `+"`up`"+`: Round away from zero.
+- `+"`down`"+`: Round towards zero.
+- `+"`ceiling`"+`: Round towards positive infinity.
+- `+"`floor`"+`: Round towards negative infinity.`,
+		NewExampleSpec("",
+			`root.amount = this.amount.decimal_round(2)`,
+			`{"amount":"2.345"}`,
+			`{"amount":2.35}`,
+		),
+		NewExampleSpec("",
+			`root.amount = this.amount.decimal_round(places: 2, mode: "half_even")`,
+			`{"amount":"2.345"}`,
+			`{"amount":2.34}`,
+		),
+	).Beta().
+		Param(ParamInt64("places", "The number of decimal places to round to.").Default(0)).
+		Param(ParamString("mode", "The rounding mode to use.").Default(roundHalfUp)),
+	func(args *ParsedParams) (simpleMethod, error) {
+		places, mode, err := decimalRoundingArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			d, err := IGetDecimal(v)
+			if err != nil {
+				return nil, err
+			}
+			return decimalToNumber(roundDecimal(d, places, mode)), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"format_decimal", "",
+	).InCategory(
+		MethodCategoryNumbers,
+		"Formats a decimal as a string without any loss of precision. When `places` is specified the decimal is [rounded](#decimal_round) to that number of decimal places and padded with trailing zeros, otherwise the shortest exact representation is used.",
+		NewExampleSpec("",
+			`root.amount = this.amount.format_decimal(places: 2)`,
+			`{"amount":12.5}`,
+			`{"amount":"12.50"}`,
+		),
+		NewExampleSpec("",
+			`root.amount = this.amount.decimal_mul(3).format_decimal()`,
+			`{"amount":"1234567890123456789.1"}`,
+			`{"amount":"3703703670370370367.3"}`,
+		),
+	).Beta().
+		Param(ParamInt64("places", "An optional number of decimal places to format with.").Optional()).
+		Param(ParamString("mode", "The [rounding mode](#decimal_round) to use when `places` is specified.").Default(roundHalfUp)),
+	func(args *ParsedParams) (simpleMethod, error) {
+		placesOpt, err := args.FieldOptionalInt64("places")
+		if err != nil {
+			return nil, err
+		}
+		mode, err := args.FieldString("mode")
+		if err != nil {
+			return nil, err
+		}
+		if err := validateRoundingMode(mode); err != nil {
+			return nil, err
+		}
+		if placesOpt != nil && *placesOpt < 0 {
+			return nil, errors.New("places must not be negative")
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			d, err := IGetDecimal(v)
+			if err != nil {
+				return nil, err
+			}
+			if placesOpt == nil {
+				return decimalString(d), nil
+			}
+			places := int(*placesOpt)
+			return roundDecimal(d, places, mode).FloatString(places), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+// IGetDecimal takes a boxed value and attempts to extract an arbitrary
+// precision decimal from it.
+func IGetDecimal(v interface{}) (*big.Rat, error) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	case json.Number:
+		s = t.String()
+	case int:
+		return new(big.Rat).SetInt64(int64(t)), nil
+	case int64:
+		return new(big.Rat).SetInt64(t), nil
+	case uint64:
+		return new(big.Rat).SetUint64(t), nil
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return nil, NewTypeError(v, ValueNumber, ValueString)
+	}
+
+	// Rationals also accept fractions such as 1/3, which aren't decimals.
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		return nil, fmt.Errorf("failed to parse value '%v' as a decimal", s)
+	}
+	d, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse value '%v' as a decimal", s)
+	}
+	return d, nil
+}
+
+// decimalString returns the shortest exact decimal representation of a
+// rational, which must be a terminating decimal. Non-terminating decimals are
+// only produced by division, which is always rounded.
+func decimalString(d *big.Rat) string {
+	if d.IsInt() {
+		return d.Num().String()
+	}
+
+	// A rational is a terminating decimal with n decimal places when its
+	// denominator is of the form 2^a * 5^b, where n = max(a, b).
+	denom := new(big.Int).Set(d.Denom())
+	two, five := big.NewInt(2), big.NewInt(5)
+	var twos, fives int
+	rem := new(big.Int)
+	for {
+		q, r := new(big.Int).QuoRem(denom, two, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		denom, twos = q, twos+1
+	}
+	for {
+		q, r := new(big.Int).QuoRem(denom, five, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		denom, fives = q, fives+1
+	}
+	places := twos
+	if fives > places {
+		places = fives
+	}
+	return d.FloatString(places)
+}
+
+func decimalToNumber(d *big.Rat) json.Number {
+	return json.Number(decimalString(d))
+}
+
+const (
+	roundHalfUp   = "half_up"
+	roundHalfDown = "half_down"
+	roundHalfEven = "half_even"
+	roundUp       = "up"
+	roundDown     = "down"
+	roundCeiling  = "ceiling"
+	roundFloor    = "floor"
+)
+
+func validateRoundingMode(mode string) error {
+	switch mode {
+	case roundHalfUp, roundHalfDown, roundHalfEven, roundUp, roundDown, roundCeiling, roundFloor:
+		return nil
+	}
+	return fmt.Errorf("unrecognised rounding mode: %v", mode)
+}
+
+func decimalRoundingArgs(args *ParsedParams) (places int, mode string, err error) {
+	placesI, err := args.FieldInt64("places")
+	if err != nil {
+		return 0, "", err
+	}
+	if placesI < 0 {
+		return 0, "", errors.New("places must not be negative")
+	}
+	if mode, err = args.FieldString("mode"); err != nil {
+		return 0, "", err
+	}
+	if err = validateRoundingMode(mode); err != nil {
+		return 0, "", err
+	}
+	return int(placesI), mode, nil
+}
+
+// roundDecimal rounds a rational to a number of decimal places according to a
+// rounding mode.
+func roundDecimal(d *big.Rat, places int, mode string) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	num := new(big.Int).Mul(d.Num(), scale)
+
+	// QuoRem truncates towards zero, and therefore the remainder has the same
+	// sign as the numerator.
+	quo, rem := new(big.Int).QuoRem(num, d.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		neg := num.Sign() < 0
+
+		// Compare the magnitude of the remainder against half of the
+		// denominator.
+		halfCmp := new(big.Int).Lsh(new(big.Int).Abs(rem), 1).Cmp(d.Denom())
+
+		var awayFromZero bool
+		switch mode {
+		case roundHalfUp:
+			awayFromZero = halfCmp >= 0
+		case roundHalfDown:
+			awayFromZero = halfCmp > 0
+		case roundHalfEven:
+			awayFromZero = halfCmp > 0 || (halfCmp == 0 && quo.Bit(0) == 1)
+		case roundUp:
+			awayFromZero = true
+		case roundDown:
+			awayFromZero = false
+		case roundCeiling:
+			awayFromZero = !neg
+		case roundFloor:
+			awayFromZero = neg
+		}
+		if awayFromZero {
+			if neg {
+				quo.Sub(quo, big.NewInt(1))
+			} else {
+				quo.Add(quo, big.NewInt(1))
+			}
+		}
+	}
+	return new(big.Rat).SetFrac(quo, scale)
+}
@@ -0,0 +1,159 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimalMethods(t *testing.T) {
+	testCases := []struct {
+		name        string
+		method      string
+		target      interface{}
+		args        []interface{}
+		exp         interface{}
+		errContains string
+	}{
+		{
+			name:   "parse string",
+			method: "parse_decimal",
+			target: " 00123456789012345678901234567890.1200 ",
+			exp:    json.Number("123456789012345678901234567890.12"),
+		},
+		{
+			name:   "parse exponent",
+			method: "parse_decimal",
+			target: "1.5e-3",
+			exp:    json.Number("0.0015"),
+		},
+		{
+			name:   "parse float",
+			method: "parse_decimal",
+			target: 0.1,
+			exp:    json.Number("0.1"),
+		},
+		{
+			name:   "parse int",
+			method: "parse_decimal",
+			target: int64(-42),
+			exp:    json.Number("-42"),
+		},
+		{
+			name:        "parse fraction",
+			method:      "parse_decimal",
+			target:      "1/3",
+			errContains: "failed to parse value '1/3' as a decimal",
+		},
+		{
+			name:        "parse garbage",
+			method:      "parse_decimal",
+			target:      "nope",
+			errContains: "failed to parse value 'nope' as a decimal",
+		},
+		{
+			name:   "add json numbers",
+			method: "decimal_add",
+			target: json.Number("9007199254740993"),
+			args:   []interface{}{json.Number("0.000000000000000001")},
+			exp:    json.Number("9007199254740993.000000000000000001"),
+		},
+		{
+			name:   "sub to negative",
+			method: "decimal_sub",
+			target: "0.1",
+			args:   []interface{}{"0.3"},
+			exp:    json.Number("-0.2"),
+		},
+		{
+			name:   "mul to integer",
+			method: "decimal_mul",
+			target: "2.5",
+			args:   []interface{}{int64(4)},
+			exp:    json.Number("10"),
+		},
+		{
+			name:   "div default places",
+			method: "decimal_div",
+			target: "1",
+			args:   []interface{}{"3"},
+			exp:    json.Number("0.3333333333333333"),
+		},
+		{
+			name:   "div floor negative",
+			method: "decimal_div",
+			target: "-10",
+			args:   []interface{}{"3", int64(2), "floor"},
+			exp:    json.Number("-3.34"),
+		},
+		{
+			name:        "div by zero",
+			method:      "decimal_div",
+			target:      "10",
+			args:        []interface{}{"0.00"},
+			errContains: "divide by zero",
+		},
+		{
+			name:   "format places pads",
+			method: "format_decimal",
+			target: json.Number("3"),
+			args:   []interface{}{int64(3)},
+			exp:    "3.000",
+		},
+		{
+			name:   "format shortest",
+			method: "format_decimal",
+			target: "-0.50",
+			exp:    "-0.5",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := InitMethodHelper(test.method, NewLiteralFunction("", test.target), test.args...)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, res)
+		})
+	}
+}
+
+func TestDecimalRoundingModes(t *testing.T) {
+	inputs := []string{"2.5", "-2.5", "1.5", "2.51", "-2.49", "3"}
+	expected := map[string][]string{
+		"half_up":   {"3", "-3", "2", "3", "-2", "3"},
+		"half_down": {"2", "-2", "1", "3", "-2", "3"},
+		"half_even": {"2", "-2", "2", "3", "-2", "3"},
+		"up":        {"3", "-3", "2", "3", "-3", "3"},
+		"down":      {"2", "-2", "1", "2", "-2", "3"},
+		"ceiling":   {"3", "-2", "2", "3", "-2", "3"},
+		"floor":     {"2", "-3", "1", "2", "-3", "3"},
+	}
+
+	for mode, exp := range expected {
+		for i, input := range inputs {
+			fn, err := InitMethodHelper("decimal_round", NewLiteralFunction("", input), int64(0), mode)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			require.NoError(t, err)
+			assert.Equal(t, json.Number(exp[i]), res, "%v %v", mode, input)
+		}
+	}
+
+	_, err := InitMethodHelper("decimal_round", NewLiteralFunction("", "1"), int64(0), "sideways")
+	require.EqualError(t, err, "unrecognised rounding mode: sideways")
+
+	_, err = InitMethodHelper("decimal_round", NewLiteralFunction("", "1"), int64(-1))
+	require.EqualError(t, err, "places must not be negative")
+}
@@ -25,6 +25,10 @@ import (
 	"strings"
 	"time"
 
+	// Embed the timezone database so that named timezones can be used by
+	// timestamp methods on hosts without one installed.
+	_ "time/tzdata"
+
 	"github.com/OneOfOne/xxhash"
 	"github.com/itchyny/timefmt-go"
 	"github.com/microcosm-cc/bluemonday"
@@ -1017,7 +1021,9 @@ var _ = registerSimpleMethod(
 - If the element is a simple element and has attributes, the element value is given the key `+"`#text`"+`.
 - XML comments, directives, and process instructions are ignored.
 - When elements are repeated the resulting JSON value is an array.
-- If cast is true, try to cast values to numbers and booleans instead of returning strings.`,
+- If cast is true, try to cast values to numbers and booleans instead of returning strings.
+
+The prefix given to attribute keys can be customised with the parameter `+"`attribute_prefix`"+`, where an empty prefix results in attributes sharing the same key format as child elements.`,
 		NewExampleSpec("",
 			`root.doc = this.doc.parse_xml()`,
 			`{"doc":"<root><title>This is a title</title><content>This is some content</content></root>"}`,
@@ -1033,7 +1039,15 @@ var _ = registerSimpleMethod(
 			`{"doc":"<root><title>This is a title</title><number id=99>123</number><bool>True</bool></root>"}`,
 			`{"doc":{"root":{"bool":true,"number":{"#text":123,"-id":99},"title":"This is a title"}}}`,
 		),
-	).Param(ParamBool("cast", "whether to try to cast values that are numbers and booleans to the right type. default: false").Optional()).Beta(),
+		NewExampleSpec("",
+			`root.doc = this.doc.parse_xml(attribute_prefix: "@")`,
+			`{"doc":"<root><number id=\"99\">123</number></root>"}`,
+			`{"doc":{"root":{"number":{"#text":"123","@id":"99"}}}}`,
+		),
+	).
+		Param(ParamBool("cast", "whether to try to cast values that are numbers and booleans to the right type. default: false").Optional()).
+		Param(ParamString("attribute_prefix", "The prefix to add to the keys of attributes.").Default(xml.DefaultAttrPrefix)).
+		Beta(),
 	func(args *ParsedParams) (simpleMethod, error) {
 		castOpt, err := args.FieldOptionalBool("cast")
 		if err != nil {
@@ -1043,6 +1057,10 @@ var _ = registerSimpleMethod(
 		if castOpt != nil {
 			cast = *castOpt
 		}
+		attrPrefix, err := args.FieldString("attribute_prefix")
+		if err != nil {
+			return nil, err
+		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var xmlBytes []byte
 			switch t := v.(type) {
@@ -1053,7 +1071,7 @@ var _ = registerSimpleMethod(
 			default:
 				return nil, NewTypeError(v, ValueString)
 			}
-			xmlObj, err := xml.ToMap(xmlBytes, cast)
+			xmlObj, err := xml.ToMapWithAttrPrefix(xmlBytes, cast, attrPrefix)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse value as XML: %w", err)
 			}
@@ -1176,6 +1194,66 @@ var _ = registerSimpleMethod(
 	},
 )
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"format_xml", "",
+	).InCategory(
+		MethodCategoryParsing,
+		`Serializes a target object into an XML byte array, following the same rules as [`+"`parse_xml`"+`](#parse_xml) in reverse:
+
+- Keys beginning with the `+"`attribute_prefix`"+` are serialized as attributes of their parent element.
+- The key `+"`#text`"+` is serialized as the value of an element that also has attributes.
+- Array values are serialized as repeated elements.
+- When the object contains a single key it is used as the root element, otherwise the elements are wrapped within a root element named by `+"`root_tag`"+`.
+
+Child elements and attributes are serialized in alphabetical order.`,
+		NewExampleSpec("",
+			`root = this.format_xml()`,
+			`{"root":{"title":"This is a title","number":{"#text":"123","-id":"99"}}}`,
+			`<root>
+    <number id="99">123</number>
+    <title>This is a title</title>
+</root>`,
+		),
+		NewExampleSpec("Provide an empty indent in order to serialize a compact document, and use the `.string()` method in order to coerce the result into a string.",
+			`root.doc = this.doc.format_xml(indent: "", attribute_prefix: "@", root_tag: "items").string()`,
+			`{"doc":{"item":[{"@id":"1","#text":"foo"},{"@id":"2","#text":"bar"}]}}`,
+			`{"doc":"<items><item id=\"1\">foo</item><item id=\"2\">bar</item></items>"}`,
+		),
+	).
+		Beta().
+		Param(ParamString(
+			"indent",
+			"Indentation string. Each child element will begin on a new, indented line followed by one or more copies of indent according to the nesting. An empty string results in a compact document.",
+		).Default(strings.Repeat(" ", 4))).
+		Param(ParamString("attribute_prefix", "The prefix of keys to serialize as attributes.").Default(xml.DefaultAttrPrefix)).
+		Param(ParamString("root_tag", "The name of the root element to wrap the object within. When empty the root element is only added when the object contains more than one key, in which case it is named `doc`.").Default("")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		indent, err := args.FieldString("indent")
+		if err != nil {
+			return nil, err
+		}
+		attrPrefix, err := args.FieldString("attribute_prefix")
+		if err != nil {
+			return nil, err
+		}
+		if attrPrefix == "" {
+			return nil, errors.New("attribute_prefix must not be empty")
+		}
+		rootTag, err := args.FieldString("root_tag")
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueObject)
+			}
+			return xml.FromMap(obj, rootTag, attrPrefix, indent)
+		}, nil
+	},
+)
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -1541,6 +1619,195 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ts_tz", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Converts a timestamp value to a named timezone from the IANA Time Zone database and outputs a string following ISO 8601, which includes the offset of the timezone at that time. Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format.",
+		NewExampleSpec("",
+			`root.local_at = this.created_at.ts_tz("Europe/Paris")`,
+			`{"created_at":"2020-08-14T11:50:26.371Z"}`,
+			`{"local_at":"2020-08-14T13:50:26.371+02:00"}`,
+			`{"created_at":"2020-12-14T11:50:26.371Z"}`,
+			`{"local_at":"2020-12-14T12:50:26.371+01:00"}`,
+		),
+	).Beta().
+		Param(ParamString("tz", "The name of the timezone to convert to, such as `Europe/Paris`, `UTC` or `Local`.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		tzStr, err := args.FieldString("tz")
+		if err != nil {
+			return nil, err
+		}
+		timezone, err := time.LoadLocation(tzStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timezone location name: %w", err)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			return target.In(timezone).Format(time.RFC3339Nano), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ts_iso_week", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Returns the ISO 8601 week number of a timestamp value, between 1 and 53, within the timezone of the timestamp. Weeks begin on a Monday and the first week of a year is the one containing its first Thursday, and therefore dates at the beginning and end of a year can belong to a week of a neighbouring year. Use the [`ts_tz`](#ts_tz) method in order to obtain the week within a different timezone.",
+		NewExampleSpec("",
+			`root.week = this.created_at.ts_iso_week()`,
+			`{"created_at":"2020-08-14T11:50:26.371Z"}`,
+			`{"week":33}`,
+			`{"created_at":"2021-01-01T00:00:00Z"}`,
+			`{"week":53}`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			_, week := target.ISOWeek()
+			return int64(week), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ts_quarter", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Returns the quarter of the year of a timestamp value, between 1 and 4, within the timezone of the timestamp.",
+		NewExampleSpec("",
+			`root.quarter = this.created_at.ts_quarter()`,
+			`{"created_at":"2020-08-14T11:50:26.371Z"}`,
+			`{"quarter":3}`,
+		),
+	).Beta(),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			return int64((target.Month()-1)/3 + 1), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ts_truncate", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Rounds a timestamp value down to a multiple of a duration and outputs a string following ISO 8601, which is useful for grouping timestamps into fixed windows. Multiples are calculated since the zero time, and therefore durations greater than an hour may not align with timezones that have an offset from UTC that isn't a whole number of those durations. Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format.",
+		NewExampleSpec("",
+			`root.window = this.created_at.ts_truncate("15m")`,
+			`{"created_at":"2020-08-14T11:50:26.371Z"}`,
+			`{"window":"2020-08-14T11:45:00Z"}`,
+		),
+	).Beta().
+		Param(ParamString("duration", "A duration string, such as `1h` or `15m`, to round down to a multiple of.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		durStr, err := args.FieldString("duration")
+		if err != nil {
+			return nil, err
+		}
+		dur, err := time.ParseDuration(durStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse duration: %w", err)
+		}
+		if dur <= 0 {
+			return nil, errors.New("duration must be greater than zero")
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			return target.Truncate(dur).Format(time.RFC3339Nano), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ts_add_business_days", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Adds a number of business days, Monday to Friday, to a timestamp value and outputs a string following ISO 8601, where negative numbers subtract business days. Weekends are determined within the timezone of the timestamp, and the time of day is preserved. Timestamps that fall on a weekend are first moved to the neighbouring business day in the direction of travel, and therefore adding one business day to a Saturday results in the following Monday. Public holidays are not taken into account.",
+		NewExampleSpec("",
+			`root.due_at = this.created_at.ts_add_business_days(3)`,
+			`{"created_at":"2020-08-14T11:50:26Z"}`,
+			`{"due_at":"2020-08-19T11:50:26Z"}`,
+		),
+		NewExampleSpec("",
+			`root.started_at = this.created_at.ts_add_business_days(-1)`,
+			`{"created_at":"2020-08-17T11:50:26Z"}`,
+			`{"started_at":"2020-08-14T11:50:26Z"}`,
+		),
+	).Beta().
+		Param(ParamInt64("days", "The number of business days to add.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		days, err := args.FieldInt64("days")
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			return addBusinessDays(target, int(days)).Format(time.RFC3339Nano), nil
+		}, nil
+	},
+)
+
+func addBusinessDays(t time.Time, days int) time.Time {
+	if days == 0 {
+		return t
+	}
+
+	step := 1
+	if days < 0 {
+		step = -1
+	}
+
+	// Move weekend timestamps back against the direction of travel onto the
+	// nearest business day, so that a single step lands on the next business
+	// day in the direction of travel.
+	for t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		t = t.AddDate(0, 0, -step)
+	}
+
+	// Each full week of business days is exactly seven days.
+	t = t.AddDate(0, 0, (days/5)*7)
+	for remaining := days % 5; remaining != 0; remaining -= step {
+		t = t.AddDate(0, 0, step)
+		for t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			t = t.AddDate(0, 0, step)
+		}
+	}
+	return t
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"quote", "",
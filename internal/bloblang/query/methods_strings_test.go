@@ -2,6 +2,7 @@ package query
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,6 +37,19 @@ func TestParseXML(t *testing.T) {
 			args:   []interface{}{true},
 			exp:    map[string]interface{}{"root": map[string]interface{}{"bool": true, "number": map[string]interface{}{"#text": float64(123), "-id": float64(99)}, "title": "This is a title"}},
 		},
+		{
+			name:   "parsing with custom attribute prefix",
+			method: "parse_xml",
+			target: `<root><number id="99">123</number><item key="a"/><item key="b"/></root>`,
+			args:   []interface{}{false, "@"},
+			exp: map[string]interface{}{"root": map[string]interface{}{
+				"number": map[string]interface{}{"#text": "123", "@id": "99"},
+				"item": []interface{}{
+					map[string]interface{}{"@key": "a"},
+					map[string]interface{}{"@key": "b"},
+				},
+			}},
+		},
 	}
 
 	for _, test := range testCases {
@@ -60,3 +74,122 @@ func TestParseXML(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatXML(t *testing.T) {
+	testCases := []struct {
+		name        string
+		target      interface{}
+		args        []interface{}
+		exp         string
+		errContains string
+	}{
+		{
+			name:   "compact single root",
+			target: map[string]interface{}{"root": map[string]interface{}{"title": "foo & <bar>", "-id": "1"}},
+			args:   []interface{}{""},
+			exp:    `<root id="1"><title>foo &amp; &lt;bar&gt;</title></root>`,
+		},
+		{
+			name:   "multiple keys default root",
+			target: map[string]interface{}{"a": "foo", "b": "bar"},
+			args:   []interface{}{""},
+			exp:    `<doc><a>foo</a><b>bar</b></doc>`,
+		},
+		{
+			name:   "custom prefix and root",
+			target: map[string]interface{}{"item": map[string]interface{}{"@id": "1", "#text": "foo"}},
+			args:   []interface{}{"  ", "@", "items"},
+			exp:    "<items>\n  <item id=\"1\">foo</item>\n</items>",
+		},
+		{
+			name:        "not an object",
+			target:      "foo",
+			args:        []interface{}{},
+			errContains: "expected object value",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := InitMethodHelper("format_xml", NewLiteralFunction("", IClone(test.target)), test.args...)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, string(res.([]byte)))
+		})
+	}
+}
+
+func TestFormatXMLRoundTrip(t *testing.T) {
+	doc := `<root><item id="1">foo</item><item id="2">bar</item><title>baz</title></root>`
+
+	parse, err := InitMethodHelper("parse_xml", NewLiteralFunction("", doc), false, "@")
+	require.NoError(t, err)
+
+	parsed, err := parse.Exec(FunctionContext{})
+	require.NoError(t, err)
+
+	format, err := InitMethodHelper("format_xml", NewLiteralFunction("", parsed), "", "@")
+	require.NoError(t, err)
+
+	res, err := format.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, doc, string(res.([]byte)))
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	// 2020-08-14 is a Friday.
+	testCases := []struct {
+		from string
+		days int
+		exp  string
+	}{
+		{from: "2020-08-14T10:00:00Z", days: 0, exp: "2020-08-14T10:00:00Z"},
+		{from: "2020-08-14T10:00:00Z", days: 1, exp: "2020-08-17T10:00:00Z"},
+		{from: "2020-08-14T10:00:00Z", days: 5, exp: "2020-08-21T10:00:00Z"},
+		{from: "2020-08-14T10:00:00Z", days: 6, exp: "2020-08-24T10:00:00Z"},
+		{from: "2020-08-14T10:00:00Z", days: -4, exp: "2020-08-10T10:00:00Z"},
+		{from: "2020-08-14T10:00:00Z", days: -5, exp: "2020-08-07T10:00:00Z"},
+		{from: "2020-08-15T10:00:00Z", days: 1, exp: "2020-08-17T10:00:00Z"},
+		{from: "2020-08-15T10:00:00Z", days: 5, exp: "2020-08-21T10:00:00Z"},
+		{from: "2020-08-16T10:00:00Z", days: -1, exp: "2020-08-14T10:00:00Z"},
+		{from: "2020-08-17T10:00:00Z", days: -1, exp: "2020-08-14T10:00:00Z"},
+		{from: "2020-08-12T10:00:00Z", days: 12, exp: "2020-08-28T10:00:00Z"},
+	}
+
+	for _, test := range testCases {
+		from, err := time.Parse(time.RFC3339, test.from)
+		require.NoError(t, err)
+		assert.Equal(t, test.exp, addBusinessDays(from, test.days).Format(time.RFC3339), "%v + %v", test.from, test.days)
+	}
+}
+
+func TestTimestampTZMethods(t *testing.T) {
+	fn, err := InitMethodHelper("ts_tz", NewLiteralFunction("", "2021-03-28T00:30:00Z"), "Europe/Paris")
+	require.NoError(t, err)
+
+	res, err := fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "2021-03-28T01:30:00+01:00", res)
+
+	// Business days are calculated within the offset of the timestamp.
+	fn, err = InitMethodHelper("ts_add_business_days", NewLiteralFunction("", "2021-03-26T23:00:00+01:00"), 1)
+	require.NoError(t, err)
+
+	res, err = fn.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "2021-03-29T23:00:00+01:00", res)
+
+	_, err = InitMethodHelper("ts_tz", NewLiteralFunction("", "2021-03-28T00:30:00Z"), "Nope/Nowhere")
+	require.Error(t, err)
+
+	_, err = InitMethodHelper("ts_truncate", NewLiteralFunction("", "2021-03-28T00:30:00Z"), "0s")
+	require.Error(t, err)
+}
@@ -0,0 +1,174 @@
+package query
+
+import (
+	"fmt"
+)
+
+type measurementUnit struct {
+	dimension string
+
+	// A value is converted to the base unit of its dimension with
+	// (value - zero) * factor / divisor, and from the base unit with the
+	// inverse. Keeping the divisor separate avoids accumulating rounding
+	// errors for factors such as 5/9.
+	zero    float64
+	factor  float64
+	divisor float64
+}
+
+var measurementUnits = map[string]measurementUnit{
+	// Temperature, base unit degrees celsius.
+	"K":    {dimension: "temperature", zero: 273.15, factor: 1},
+	"degC": {dimension: "temperature", factor: 1},
+	"degF": {dimension: "temperature", zero: 32, factor: 5, divisor: 9},
+
+	// Pressure, base unit pascal.
+	"Pa":   {dimension: "pressure", factor: 1},
+	"hPa":  {dimension: "pressure", factor: 100},
+	"kPa":  {dimension: "pressure", factor: 1e3},
+	"MPa":  {dimension: "pressure", factor: 1e6},
+	"mbar": {dimension: "pressure", factor: 100},
+	"bar":  {dimension: "pressure", factor: 1e5},
+	"psi":  {dimension: "pressure", factor: 6894.757293168361},
+	"atm":  {dimension: "pressure", factor: 101325},
+	"mmHg": {dimension: "pressure", factor: 133.322387415},
+	"inHg": {dimension: "pressure", factor: 3386.388640341},
+
+	// Data size, base unit byte.
+	"bit": {dimension: "data size", factor: 0.125},
+	"B":   {dimension: "data size", factor: 1},
+	"KB":  {dimension: "data size", factor: 1e3},
+	"MB":  {dimension: "data size", factor: 1e6},
+	"GB":  {dimension: "data size", factor: 1e9},
+	"TB":  {dimension: "data size", factor: 1e12},
+	"KiB": {dimension: "data size", factor: 1 << 10},
+	"MiB": {dimension: "data size", factor: 1 << 20},
+	"GiB": {dimension: "data size", factor: 1 << 30},
+	"TiB": {dimension: "data size", factor: 1 << 40},
+
+	// Duration, base unit second.
+	"ns": {dimension: "duration", factor: 1e-9},
+	"us": {dimension: "duration", factor: 1e-6},
+	"ms": {dimension: "duration", factor: 1e-3},
+	"s":  {dimension: "duration", factor: 1},
+	"m":  {dimension: "duration", factor: 60},
+	"h":  {dimension: "duration", factor: 3600},
+	"d":  {dimension: "duration", factor: 86400},
+}
+
+func lookupMeasurementUnit(name string) (measurementUnit, error) {
+	u, exists := measurementUnits[name]
+	if !exists {
+		return u, fmt.Errorf("unrecognised unit: %v", name)
+	}
+	if u.divisor == 0 {
+		u.divisor = 1
+	}
+	return u, nil
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"convert_unit",
+		"Converts a number from one unit of measurement to another. Both units must belong to the same dimension, supported units are: temperature (`K`, `degC`, `degF`), pressure (`Pa`, `hPa`, `kPa`, `MPa`, `mbar`, `bar`, `psi`, `atm`, `mmHg`, `inHg`), data size (`bit`, `B`, `KB`, `MB`, `GB`, `TB`, `KiB`, `MiB`, `GiB`, `TiB`) and duration (`ns`, `us`, `ms`, `s`, `m`, `h`, `d`). The result is always a floating point number.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("",
+			`root.temp_f = this.temp_c.convert_unit("degC", "degF")`,
+			`{"temp_c":100}`,
+			`{"temp_f":212}`,
+			`{"temp_c":-40}`,
+			`{"temp_f":-40}`,
+		),
+		NewExampleSpec("",
+			`root.pressure_psi = this.pressure_bar.convert_unit("bar", "psi").round()`,
+			`{"pressure_bar":2.5}`,
+			`{"pressure_psi":36}`,
+		),
+		NewExampleSpec("",
+			`root.uptime_hours = this.uptime_ms.convert_unit("ms", "h")`,
+			`{"uptime_ms":5400000}`,
+			`{"uptime_hours":1.5}`,
+		),
+	).
+		Param(ParamString("from", "The unit the number is currently in.")).
+		Param(ParamString("to", "The unit to convert the number to.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		fromStr, err := args.FieldString("from")
+		if err != nil {
+			return nil, err
+		}
+		toStr, err := args.FieldString("to")
+		if err != nil {
+			return nil, err
+		}
+		from, err := lookupMeasurementUnit(fromStr)
+		if err != nil {
+			return nil, err
+		}
+		to, err := lookupMeasurementUnit(toStr)
+		if err != nil {
+			return nil, err
+		}
+		if from.dimension != to.dimension {
+			return nil, fmt.Errorf("cannot convert %v (%v) to %v (%v)", fromStr, from.dimension, toStr, to.dimension)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			f, err := IGetNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			if fromStr == toStr {
+				return f, nil
+			}
+			base := (f - from.zero) * from.factor / from.divisor
+			return base*to.divisor/to.factor + to.zero, nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"polynomial",
+		"Evaluates a polynomial with the provided coefficients against a number, where the coefficient at index `n` is multiplied by the number raised to the power of `n`. This can be used in order to apply linear or non-linear sensor calibrations, where a linear calibration is written as `[offset, gain]`.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("A linear calibration with an offset of `-0.5` and a gain of `2`.",
+			`root.calibrated = this.raw.polynomial([-0.5, 2])`,
+			`{"raw":10}`,
+			`{"calibrated":19.5}`,
+		),
+		NewExampleSpec("A quadratic calibration curve.",
+			`root.calibrated = this.raw.polynomial([1, 0.5, 0.25])`,
+			`{"raw":4}`,
+			`{"calibrated":7}`,
+		),
+	).Param(ParamArray("coefficients", "An array of numerical coefficients in ascending order of degree.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		coeffsArr, err := args.FieldArray("coefficients")
+		if err != nil {
+			return nil, err
+		}
+		if len(coeffsArr) == 0 {
+			return nil, fmt.Errorf("at least one coefficient must be provided")
+		}
+		coeffs := make([]float64, len(coeffsArr))
+		for i, c := range coeffsArr {
+			if coeffs[i], err = IGetNumber(c); err != nil {
+				return nil, fmt.Errorf("coefficient %v: %w", i, err)
+			}
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			x, err := IGetNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			// Horner's method
+			var res float64
+			for i := len(coeffs) - 1; i >= 0; i-- {
+				res = res*x + coeffs[i]
+			}
+			return res, nil
+		}, nil
+	},
+)
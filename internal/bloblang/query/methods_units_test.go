@@ -0,0 +1,130 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitMethods(t *testing.T) {
+	testCases := []struct {
+		name        string
+		method      string
+		target      interface{}
+		args        []interface{}
+		exp         interface{}
+		errContains string
+	}{
+		{
+			name:   "celsius to kelvin",
+			method: "convert_unit",
+			target: int64(25),
+			args:   []interface{}{"degC", "K"},
+			exp:    298.15,
+		},
+		{
+			name:   "fahrenheit to celsius",
+			method: "convert_unit",
+			target: json.Number("212"),
+			args:   []interface{}{"degF", "degC"},
+			exp:    100.0,
+		},
+		{
+			name:   "same unit",
+			method: "convert_unit",
+			target: 1.5,
+			args:   []interface{}{"bar", "bar"},
+			exp:    1.5,
+		},
+		{
+			name:   "atm to kpa",
+			method: "convert_unit",
+			target: int64(1),
+			args:   []interface{}{"atm", "kPa"},
+			exp:    101.325,
+		},
+		{
+			name:   "mebibytes to bytes",
+			method: "convert_unit",
+			target: int64(2),
+			args:   []interface{}{"MiB", "B"},
+			exp:    2097152.0,
+		},
+		{
+			name:   "bits to bytes",
+			method: "convert_unit",
+			target: uint64(64),
+			args:   []interface{}{"bit", "B"},
+			exp:    8.0,
+		},
+		{
+			name:   "days to minutes",
+			method: "convert_unit",
+			target: int64(2),
+			args:   []interface{}{"d", "m"},
+			exp:    2880.0,
+		},
+		{
+			name:        "not a number",
+			method:      "convert_unit",
+			target:      "nope",
+			args:        []interface{}{"s", "ms"},
+			errContains: "expected number value",
+		},
+		{
+			name:   "polynomial constant",
+			method: "polynomial",
+			target: int64(100),
+			args:   []interface{}{[]interface{}{int64(3)}},
+			exp:    3.0,
+		},
+		{
+			name:   "polynomial linear",
+			method: "polynomial",
+			target: json.Number("4"),
+			args:   []interface{}{[]interface{}{int64(1), 0.5}},
+			exp:    3.0,
+		},
+		{
+			name:   "polynomial cubic",
+			method: "polynomial",
+			target: int64(-2),
+			args:   []interface{}{[]interface{}{int64(1), int64(0), int64(0), int64(1)}},
+			exp:    -7.0,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := InitMethodHelper(test.method, NewLiteralFunction("", test.target), test.args...)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, test.exp, res, 1e-9)
+		})
+	}
+}
+
+func TestUnitMethodsBadArgs(t *testing.T) {
+	_, err := InitMethodHelper("convert_unit", NewLiteralFunction("", 1.0), "degC", "furlongs")
+	require.EqualError(t, err, "unrecognised unit: furlongs")
+
+	_, err = InitMethodHelper("convert_unit", NewLiteralFunction("", 1.0), "psi", "MB")
+	require.EqualError(t, err, "cannot convert psi (pressure) to MB (data size)")
+
+	_, err = InitMethodHelper("polynomial", NewLiteralFunction("", 1.0), []interface{}{})
+	require.EqualError(t, err, "at least one coefficient must be provided")
+
+	_, err = InitMethodHelper("polynomial", NewLiteralFunction("", 1.0), []interface{}{int64(1), "two"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "coefficient 1")
+}
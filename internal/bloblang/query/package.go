@@ -70,6 +70,9 @@ type FunctionContext struct {
 	NewMeta  MetaMsg
 	NewValue *interface{}
 
+	// Batch is optional state shared by executions across a batch of messages.
+	Batch *BatchCache
+
 	valueFn    func() *interface{}
 	value      *interface{}
 	nextValue  *interface{}
@@ -0,0 +1,140 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Position describes the latest checkpoint reported by an input for a single
+// partition of the data that it consumes, such as a Kafka topic partition, a
+// Kinesis shard or a file.
+type Position struct {
+	Stream    string    `json:"stream,omitempty"`
+	Path      string    `json:"path"`
+	Label     string    `json:"label,omitempty"`
+	Partition string    `json:"partition"`
+	Offset    string    `json:"offset"`
+	Updated   time.Time `json:"updated"`
+}
+
+type positionKey struct {
+	stream    string
+	path      string
+	partition string
+}
+
+type positionEntry struct {
+	pos      Position
+	onRemove func()
+}
+
+// Registry keeps track of the latest checkpoint positions reported by inputs
+// across a Benthos instance so that they can be inspected centrally.
+type Registry struct {
+	mut       sync.RWMutex
+	positions map[positionKey]positionEntry
+}
+
+// NewRegistry returns an empty checkpoint registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		positions: map[positionKey]positionEntry{},
+	}
+}
+
+// Set stores a position, replacing any prior position reported by the same
+// component for the same partition. An optional onRemove func is called once
+// the position is removed, which can be used in order to clear state derived
+// from the position such as metrics.
+func (r *Registry) Set(p Position, onRemove func()) {
+	if p.Updated.IsZero() {
+		p.Updated = time.Now()
+	}
+	r.mut.Lock()
+	r.positions[positionKey{
+		stream:    p.Stream,
+		path:      p.Path,
+		partition: p.Partition,
+	}] = positionEntry{pos: p, onRemove: onRemove}
+	r.mut.Unlock()
+}
+
+// Remove deletes the position reported by a component for a partition, which
+// should be called once the partition is no longer consumed by the component,
+// such as when a Kafka topic partition is revoked.
+func (r *Registry) Remove(stream, path, partition string) {
+	key := positionKey{
+		stream:    stream,
+		path:      path,
+		partition: partition,
+	}
+	r.mut.Lock()
+	e, exists := r.positions[key]
+	delete(r.positions, key)
+	r.mut.Unlock()
+
+	if exists && e.onRemove != nil {
+		e.onRemove()
+	}
+}
+
+// Reset deletes all positions reported by the components of a stream, which
+// should be called once the stream is torn down.
+func (r *Registry) Reset(stream string) {
+	var removed []positionEntry
+	r.mut.Lock()
+	for k, e := range r.positions {
+		if k.stream == stream {
+			removed = append(removed, e)
+			delete(r.positions, k)
+		}
+	}
+	r.mut.Unlock()
+
+	for _, e := range removed {
+		if e.onRemove != nil {
+			e.onRemove()
+		}
+	}
+}
+
+// Positions returns all stored positions ordered by stream, component path and
+// partition. When a non-empty stream is provided only the positions of that
+// stream are returned.
+func (r *Registry) Positions(stream string) []Position {
+	r.mut.RLock()
+	positions := make([]Position, 0, len(r.positions))
+	for _, e := range r.positions {
+		if stream != "" && e.pos.Stream != stream {
+			continue
+		}
+		positions = append(positions, e.pos)
+	}
+	r.mut.RUnlock()
+
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Stream != positions[j].Stream {
+			return positions[i].Stream < positions[j].Stream
+		}
+		if positions[i].Path != positions[j].Path {
+			return positions[i].Path < positions[j].Path
+		}
+		return positions[i].Partition < positions[j].Partition
+	})
+	return positions
+}
+
+// HandleList is an http.HandlerFunc that writes all stored positions as a JSON
+// array. The optional query parameter `stream` filters positions by stream.
+func (r *Registry) HandleList(w http.ResponseWriter, req *http.Request) {
+	resBytes, err := json.Marshal(r.Positions(req.URL.Query().Get("stream")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resBytes)
+}
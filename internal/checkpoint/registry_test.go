@@ -0,0 +1,85 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryPositions(t *testing.T) {
+	r := NewRegistry()
+
+	r.Set(Position{Stream: "b", Path: "root.input", Partition: "foo:0", Offset: "10"}, nil)
+	r.Set(Position{Stream: "a", Path: "root.input", Partition: "foo:1", Offset: "5"}, nil)
+	r.Set(Position{Stream: "a", Path: "root.input", Partition: "foo:0", Offset: "3"}, nil)
+	r.Set(Position{Stream: "a", Path: "root.input", Partition: "foo:0", Offset: "4"}, nil)
+
+	var offsets []string
+	for _, p := range r.Positions("") {
+		assert.False(t, p.Updated.IsZero())
+		offsets = append(offsets, p.Stream+"/"+p.Partition+"="+p.Offset)
+	}
+	assert.Equal(t, []string{"a/foo:0=4", "a/foo:1=5", "b/foo:0=10"}, offsets)
+
+	positions := r.Positions("b")
+	require.Len(t, positions, 1)
+	assert.Equal(t, "10", positions[0].Offset)
+}
+
+func TestRegistryRemoveAndReset(t *testing.T) {
+	r := NewRegistry()
+
+	var removed []string
+	onRemove := func(name string) func() {
+		return func() {
+			removed = append(removed, name)
+		}
+	}
+
+	r.Set(Position{Stream: "a", Path: "root.input", Partition: "foo:0", Offset: "1"}, onRemove("a0"))
+	r.Set(Position{Stream: "a", Path: "root.input", Partition: "foo:1", Offset: "2"}, onRemove("a1"))
+	r.Set(Position{Stream: "a", Path: "root.input.broker.inputs.0", Partition: "foo:0", Offset: "3"}, nil)
+	r.Set(Position{Stream: "b", Path: "root.input", Partition: "foo:0", Offset: "4"}, onRemove("b0"))
+
+	r.Remove("a", "root.input", "foo:0")
+	r.Remove("a", "root.input", "nope")
+	assert.Equal(t, []string{"a0"}, removed)
+
+	var offsets []string
+	for _, p := range r.Positions("") {
+		offsets = append(offsets, p.Stream+"/"+p.Path+"/"+p.Partition+"="+p.Offset)
+	}
+	assert.Equal(t, []string{
+		"a/root.input/foo:1=2",
+		"a/root.input.broker.inputs.0/foo:0=3",
+		"b/root.input/foo:0=4",
+	}, offsets)
+
+	r.Reset("a")
+	assert.Equal(t, []string{"a0", "a1"}, removed)
+
+	offsets = nil
+	for _, p := range r.Positions("") {
+		offsets = append(offsets, p.Stream+"/"+p.Path+"/"+p.Partition+"="+p.Offset)
+	}
+	assert.Equal(t, []string{"b/root.input/foo:0=4"}, offsets)
+}
+
+func TestRegistryHandleList(t *testing.T) {
+	r := NewRegistry()
+	r.Set(Position{Stream: "a", Path: "root.input", Partition: "foo", Offset: "1"}, nil)
+	r.Set(Position{Stream: "b", Path: "root.input", Partition: "bar", Offset: "2"}, nil)
+
+	w := httptest.NewRecorder()
+	r.HandleList(w, httptest.NewRequest("GET", "/checkpoints?stream=b", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var positions []Position
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &positions))
+	require.Len(t, positions, 1)
+	assert.Equal(t, "bar", positions[0].Partition)
+	assert.Equal(t, "2", positions[0].Offset)
+}
@@ -0,0 +1,271 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
+)
+
+// CliCommand is a cli.Command definition for benchmarking the processors of
+// configs.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Benchmark the processors of configs against a generated load",
+		Description: `
+Runs the pipeline processors of one or more configs against a generated load
+and reports the throughput, latency and allocations of the pipeline as a whole
+and of each processor. When multiple configs are provided each is benchmarked
+in turn against the same load so that they can be compared.
+
+  benthos bench ./config.yaml
+  benthos bench --rate 5000 --size 100B:4KB --duration 30s ./a.yaml ./b.yaml
+  benthos bench --mapping 'root.id = uuid_v4()' --format json ./config.yaml
+
+Inputs, outputs and buffers are not executed. Unless a mapping is provided the
+payload of each message is a JSON object of the form {"id":1,"data":"..."},
+padded to a size drawn uniformly from the --size range.
+
+The latency of the pipeline is measured from the time at which each batch is
+scheduled until it has passed through all processors, and the latency of each
+processor is measured per batch. The throughput of each processor is the number
+of messages it processed per second of time spent within it, which is the
+capacity of a single thread. Allocations are measured by processing a sample
+of messages through each processor from a single goroutine after the load has
+completed.`[1:],
+		Flags: []cli.Flag{
+			&cli.Float64Flag{
+				Name:  "rate",
+				Value: 0,
+				Usage: "the number of messages to generate per second, zero means messages are generated as fast as they are consumed",
+			},
+			&cli.DurationFlag{
+				Name:  "duration",
+				Value: 10 * time.Second,
+				Usage: "the period of time to generate load for",
+			},
+			&cli.StringFlag{
+				Name:  "size",
+				Value: "1KB",
+				Usage: "the size of generated payloads, either fixed (1KB) or a range from which sizes are drawn uniformly (100B:4KB)",
+			},
+			&cli.StringFlag{
+				Name:  "mapping",
+				Value: "",
+				Usage: "an optional Bloblang mapping used to generate payloads instead of sized JSON objects",
+			},
+			&cli.IntFlag{
+				Name:  "batch-size",
+				Value: 1,
+				Usage: "the number of messages within each generated batch",
+			},
+			&cli.IntFlag{
+				Name:  "threads",
+				Value: 0,
+				Usage: "the number of parallel processing threads, zero means the pipeline threads of each config are used",
+			},
+			&cli.Int64Flag{
+				Name:  "seed",
+				Value: 1,
+				Usage: "the seed used to generate payloads",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "text",
+				Usage: "the format of the report, options are: text, json",
+			},
+			&cli.StringFlag{
+				Name:  "log",
+				Value: "",
+				Usage: "allow components to write logs at a provided level to stdout.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if err := runCommand(c); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
+
+func runCommand(c *cli.Context) error {
+	paths := c.Args().Slice()
+	if len(paths) == 0 {
+		if path := c.String("config"); path != "" {
+			paths = []string{path}
+		}
+	}
+	if len(paths) == 0 {
+		return errors.New("at least one config must be provided")
+	}
+
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unrecognised format: %v", format)
+	}
+
+	resourcesPaths, err := filepath.Globs(c.StringSlice("resources"))
+	if err != nil {
+		return fmt.Errorf("failed to resolve resource glob pattern: %w", err)
+	}
+
+	sizes, err := parseSizeRange(c.String("size"))
+	if err != nil {
+		return err
+	}
+
+	var exec *mapping.Executor
+	if m := c.String("mapping"); m != "" {
+		if exec, err = bloblang.GlobalEnvironment().NewMapping(m); err != nil {
+			return fmt.Errorf("failed to parse mapping: %w", err)
+		}
+	}
+
+	logger := log.Noop()
+	if logLevel := c.String("log"); len(logLevel) > 0 {
+		logConf := log.NewConfig()
+		logConf.LogLevel = logLevel
+		if logger, err = log.NewV2(os.Stdout, logConf); err != nil {
+			return fmt.Errorf("failed to init logger: %w", err)
+		}
+	}
+
+	ctx, done := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer done()
+
+	var results []*result
+	for _, path := range paths {
+		conf := config.New()
+		confReader := config.NewReader(path, resourcesPaths, config.OptAddOverrides(c.StringSlice("set")...))
+		lints, err := confReader.Read(&conf)
+		if err != nil {
+			return fmt.Errorf("failed to read config '%v': %w", path, err)
+		}
+		for _, lint := range lints {
+			fmt.Fprintf(os.Stderr, "%v: %v\n", path, lint)
+		}
+
+		p := profile{
+			Rate:      c.Float64("rate"),
+			Duration:  c.Duration("duration"),
+			BatchSize: c.Int("batch-size"),
+			Threads:   c.Int("threads"),
+			Sizes:     sizes,
+			Mapping:   exec,
+			Seed:      c.Int64("seed"),
+		}
+		if p.Threads == 0 {
+			if p.Threads = conf.Pipeline.Threads; p.Threads < 1 {
+				p.Threads = 1
+			}
+		}
+
+		if format == "text" {
+			fmt.Fprintf(os.Stderr, "Benchmarking %v for %v\n", path, p.Duration)
+		}
+		res, err := benchConfig(ctx, conf, p, logger)
+		if err != nil {
+			return fmt.Errorf("failed to benchmark config '%v': %w", path, err)
+		}
+		res.Config = path
+		results = append(results, res)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	for i, res := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		writeTextReport(os.Stdout, res)
+	}
+	return nil
+}
+
+func benchConfig(ctx context.Context, conf config.Type, p profile, logger log.Modular) (*result, error) {
+	mgr, err := manager.NewV2(conf.ResourceConfig, mock.NewManager(), logger, metrics.Noop())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise resources: %w", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(time.Second * 10)
+	}()
+
+	components := make([]component, len(conf.Pipeline.Processors))
+	defer func() {
+		for _, c := range components {
+			if c.proc != nil {
+				c.proc.CloseAsync()
+				_ = c.proc.WaitForClose(time.Second * 10)
+			}
+		}
+	}()
+	for i, procConf := range conf.Pipeline.Processors {
+		path := []string{"pipeline", "processors", strconv.Itoa(i)}
+		pMgr := mgr.IntoPath(path...)
+
+		label := procConf.Label
+		if label == "" {
+			label = strings.Join(path, ".")
+		}
+		components[i].label, components[i].typ = label, procConf.Type
+		if components[i].proc, err = processor.New(procConf, pMgr, pMgr.Logger(), metrics.Noop()); err != nil {
+			return nil, fmt.Errorf("failed to initialise processor '%v': %w", label, err)
+		}
+	}
+	return run(ctx, components, p)
+}
+
+func writeTextReport(w io.Writer, res *result) {
+	fmt.Fprintf(w, "Config:      %v\n", res.Config)
+	fmt.Fprintf(w, "Messages:    %v in, %v out, over %v (%.1f msg/s, %v/s)\n",
+		res.Messages, res.MessagesOut, res.Elapsed.Round(time.Millisecond), res.Throughput, humanize.Bytes(uint64(res.BytesThroughput)))
+	fmt.Fprintf(w, "Latency:     p50 %v, p99 %v\n", res.LatencyP50, res.LatencyP99)
+	fmt.Fprintf(w, "Allocations: %.1f allocs/msg, %v/msg\n", res.AllocsPerMessage, humanize.Bytes(uint64(res.BytesPerMessage)))
+	fmt.Fprintf(w, "Errors:      %v\n", res.Errors)
+
+	if len(res.Components) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROCESSOR\tTYPE\tCALLS\tIN\tOUT\tMSG/S\tP50\tP99\tALLOCS/MSG\tBYTES/MSG")
+	for _, c := range res.Components {
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%.1f\t%v\t%v\t%.1f\t%v\n",
+			c.Label, c.Type, c.Calls, c.MessagesIn, c.MessagesOut, c.Throughput,
+			c.LatencyP50, c.LatencyP99, c.AllocsPerMessage, humanize.Bytes(uint64(c.BytesPerMessage)))
+	}
+	_ = tw.Flush()
+}
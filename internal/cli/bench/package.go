@@ -0,0 +1,2 @@
+// Package bench implements the Benthos processor benchmarking command.
+package bench
@@ -0,0 +1,138 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// sizeRange describes a uniform distribution of payload sizes in bytes.
+type sizeRange struct {
+	min, max int
+}
+
+// parseSizeRange parses either a single size, such as `1KB`, or a range of
+// sizes separated by a colon, such as `100B:4KB`.
+func parseSizeRange(s string) (sizeRange, error) {
+	minStr, maxStr := s, s
+	if i := strings.Index(s, ":"); i >= 0 {
+		minStr, maxStr = s[:i], s[i+1:]
+	}
+	min, err := humanize.ParseBytes(minStr)
+	if err != nil {
+		return sizeRange{}, fmt.Errorf("failed to parse size '%v': %w", minStr, err)
+	}
+	max, err := humanize.ParseBytes(maxStr)
+	if err != nil {
+		return sizeRange{}, fmt.Errorf("failed to parse size '%v': %w", maxStr, err)
+	}
+	if max < min {
+		return sizeRange{}, fmt.Errorf("maximum size %v is smaller than minimum size %v", maxStr, minStr)
+	}
+	return sizeRange{min: int(min), max: int(max)}, nil
+}
+
+// profile describes the load generated during a benchmark.
+type profile struct {
+	// Rate is the number of messages generated per second, where zero means
+	// messages are generated as fast as they are consumed.
+	Rate      float64
+	Duration  time.Duration
+	BatchSize int
+	Threads   int
+	Sizes     sizeRange
+	Mapping   *mapping.Executor
+	Seed      int64
+}
+
+func (p profile) validate() error {
+	if p.Rate < 0 {
+		return errors.New("rate must not be negative")
+	}
+	if p.Duration <= 0 {
+		return errors.New("duration must be larger than zero")
+	}
+	if p.BatchSize < 1 {
+		return errors.New("batch size must be at least one")
+	}
+	if p.Threads < 1 {
+		return errors.New("threads must be at least one")
+	}
+	return nil
+}
+
+const payloadAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// generator produces message payloads for a profile, and is not safe for
+// concurrent use.
+type generator struct {
+	sizes   sizeRange
+	mapping *mapping.Executor
+	rand    *rand.Rand
+	seq     int64
+}
+
+func newGenerator(p profile) *generator {
+	return &generator{
+		sizes:   p.Sizes,
+		mapping: p.Mapping,
+		rand:    rand.New(rand.NewSource(p.Seed)),
+	}
+}
+
+// part generates a single message. Without a mapping the payload is a JSON
+// object with a sequential id and a random string padding it to a size drawn
+// from the size range.
+func (g *generator) part() (*message.Part, error) {
+	g.seq++
+	if g.mapping != nil {
+		p, err := g.mapping.MapPart(0, message.QuickBatch(nil))
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute mapping: %w", err)
+		}
+		if p == nil {
+			return nil, errors.New("mapping resulted in a deleted message")
+		}
+		return p, nil
+	}
+
+	size := g.sizes.min
+	if g.sizes.max > g.sizes.min {
+		size += g.rand.Intn(g.sizes.max - g.sizes.min + 1)
+	}
+
+	prefix := `{"id":` + strconv.FormatInt(g.seq, 10) + `,"data":"`
+	suffix := `"}`
+	padding := size - len(prefix) - len(suffix)
+	if padding < 0 {
+		padding = 0
+	}
+
+	b := make([]byte, 0, len(prefix)+padding+len(suffix))
+	b = append(b, prefix...)
+	for i := 0; i < padding; i++ {
+		b = append(b, payloadAlphabet[g.rand.Intn(len(payloadAlphabet))])
+	}
+	b = append(b, suffix...)
+	return message.NewPart(b), nil
+}
+
+func (g *generator) batch(size int) (*message.Batch, error) {
+	batch := message.QuickBatch(nil)
+	for i := 0; i < size; i++ {
+		p, err := g.part()
+		if err != nil {
+			return nil, err
+		}
+		batch.Append(p)
+	}
+	return batch, nil
+}
@@ -0,0 +1,93 @@
+package bench
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+)
+
+func TestParseSizeRange(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		expected    sizeRange
+		errContains string
+	}{
+		"fixed": {
+			input:    "100",
+			expected: sizeRange{min: 100, max: 100},
+		},
+		"fixed with units": {
+			input:    "1KB",
+			expected: sizeRange{min: 1000, max: 1000},
+		},
+		"range": {
+			input:    "100B:1KiB",
+			expected: sizeRange{min: 100, max: 1024},
+		},
+		"inverted range": {
+			input:       "1KB:100B",
+			errContains: "is smaller than",
+		},
+		"bad size": {
+			input:       "nope",
+			errContains: "failed to parse size 'nope'",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			res, err := parseSizeRange(test.input)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestGeneratorSizes(t *testing.T) {
+	gen := newGenerator(profile{Sizes: sizeRange{min: 50, max: 100}, Seed: 1})
+	for i := 0; i < 100; i++ {
+		p, err := gen.part()
+		require.NoError(t, err)
+
+		v, err := p.JSON()
+		require.NoError(t, err)
+		assert.Equal(t, json.Number(strconv.Itoa(i+1)), v.(map[string]interface{})["id"])
+
+		assert.GreaterOrEqual(t, len(p.Get()), 50)
+		assert.LessOrEqual(t, len(p.Get()), 100)
+	}
+
+	// Sizes smaller than the JSON structure are not padded.
+	gen = newGenerator(profile{Sizes: sizeRange{min: 1, max: 1}})
+	p, err := gen.part()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1,"data":""}`, string(p.Get()))
+}
+
+func TestGeneratorMapping(t *testing.T) {
+	exec, err := bloblang.GlobalEnvironment().NewMapping(`root.foo = "bar"`)
+	require.NoError(t, err)
+
+	gen := newGenerator(profile{Mapping: exec})
+	batch, err := gen.batch(3)
+	require.NoError(t, err)
+	require.Equal(t, 3, batch.Len())
+	assert.Equal(t, `{"foo":"bar"}`, string(batch.Get(2).Get()))
+
+	exec, err = bloblang.GlobalEnvironment().NewMapping(`root = deleted()`)
+	require.NoError(t, err)
+
+	_, err = newGenerator(profile{Mapping: exec}).part()
+	assert.EqualError(t, err, "mapping resulted in a deleted message")
+}
@@ -0,0 +1,380 @@
+package bench
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"runtime"
+	"sync"
+	"time"
+
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// allocSampleSize is the number of messages processed by each component
+// whilst measuring allocations.
+const allocSampleSize = 1000
+
+// component is a processor under test.
+type component struct {
+	label string
+	typ   string
+	proc  iprocessor.V1
+}
+
+// componentResult summarises the performance of a single component.
+type componentResult struct {
+	Label       string `json:"label"`
+	Type        string `json:"type"`
+	Calls       int    `json:"calls"`
+	MessagesIn  int64  `json:"messages_in"`
+	MessagesOut int64  `json:"messages_out"`
+
+	// Throughput is the number of messages processed per second of time
+	// spent within the component, which is the capacity of a single thread.
+	Throughput float64       `json:"messages_per_second"`
+	LatencyP50 time.Duration `json:"latency_p50_ns"`
+	LatencyP99 time.Duration `json:"latency_p99_ns"`
+
+	AllocsPerMessage float64 `json:"allocs_per_message"`
+	BytesPerMessage  float64 `json:"bytes_per_message"`
+}
+
+// result summarises the performance of a benchmark run.
+type result struct {
+	Config           string        `json:"config"`
+	Elapsed          time.Duration `json:"elapsed_ns"`
+	Messages         int64         `json:"messages"`
+	Bytes            int64         `json:"bytes"`
+	MessagesOut      int64         `json:"messages_out"`
+	Errors           int64         `json:"errors"`
+	Throughput       float64       `json:"messages_per_second"`
+	BytesThroughput  float64       `json:"bytes_per_second"`
+	LatencyP50       time.Duration `json:"latency_p50_ns"`
+	LatencyP99       time.Duration `json:"latency_p99_ns"`
+	AllocsPerMessage float64       `json:"allocs_per_message"`
+	BytesPerMessage  float64       `json:"bytes_per_message"`
+
+	Components []componentResult `json:"components"`
+}
+
+const (
+	// histogramSubBuckets is the number of buckets that each power of two of
+	// latencies is divided into, which bounds the relative error of recorded
+	// latencies to 1/histogramSubBuckets. Latencies below twice this value are
+	// recorded exactly.
+	histogramSubBuckets = 64
+	histogramSubBits    = 6
+	histogramBuckets    = (64 - histogramSubBits) * histogramSubBuckets
+)
+
+// latencyHistogram records latencies with a fixed memory footprint regardless
+// of the number of latencies recorded, in buckets with logarithmically
+// increasing widths.
+type latencyHistogram struct {
+	counts [histogramBuckets]int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func histogramIndex(v uint64) int {
+	if v < 2*histogramSubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - histogramSubBits - 1
+	return shift*histogramSubBuckets + int(v>>shift)
+}
+
+// histogramUpperBound returns the largest latency recorded by a bucket.
+func histogramUpperBound(i int) time.Duration {
+	if i < 2*histogramSubBuckets {
+		return time.Duration(i)
+	}
+	shift := i/histogramSubBuckets - 1
+	v := uint64(i - shift*histogramSubBuckets)
+	return time.Duration((v+1)<<shift - 1)
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[histogramIndex(uint64(d))]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+func (h *latencyHistogram) merge(o *latencyHistogram) {
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.count += o.count
+	h.sum += o.sum
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+// percentile returns the nearest-rank percentile of the recorded latencies,
+// which is the upper bound of the bucket that it falls within.
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p / 100 * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, c := range h.counts {
+		if seen += c; seen >= rank {
+			if v := histogramUpperBound(i); v < h.max {
+				return v
+			}
+			break
+		}
+	}
+	return h.max
+}
+
+//------------------------------------------------------------------------------
+
+type generated struct {
+	batch   *message.Batch
+	bytes   int64
+	created time.Time
+}
+
+type componentSamples struct {
+	latencies   latencyHistogram
+	messagesIn  int64
+	messagesOut int64
+}
+
+// samples are recorded by a single worker and merged once the run has ended
+// in order to avoid contention between workers.
+type samples struct {
+	components  []componentSamples
+	latencies   latencyHistogram
+	messages    int64
+	messagesOut int64
+	bytes       int64
+	errors      int64
+}
+
+func (s *samples) process(components []component, g generated) {
+	s.messages += int64(g.batch.Len())
+	s.bytes += g.bytes
+
+	batches := []*message.Batch{g.batch}
+	for i, c := range components {
+		var next []*message.Batch
+		for _, b := range batches {
+			in := b.Len()
+
+			start := time.Now()
+			out, err := c.proc.ProcessMessage(b)
+			s.components[i].latencies.record(time.Since(start))
+			s.components[i].messagesIn += int64(in)
+
+			if err != nil {
+				continue
+			}
+			for _, o := range out {
+				s.components[i].messagesOut += int64(o.Len())
+			}
+			next = append(next, out...)
+		}
+		batches = next
+	}
+
+	s.latencies.record(time.Since(g.created))
+	for _, b := range batches {
+		s.messagesOut += int64(b.Len())
+		_ = b.Iter(func(i int, p *message.Part) error {
+			if iprocessor.GetFail(p) != "" {
+				s.errors++
+			}
+			return nil
+		})
+	}
+}
+
+// produce generates batches according to the profile until the duration has
+// elapsed. When a rate is set the creation time of each batch is the time it
+// was scheduled at, so that latencies include any time spent waiting for a
+// worker to become available.
+func produce(ctx context.Context, p profile, gen *generator, batchChan chan<- generated) error {
+	defer close(batchChan)
+
+	var interval time.Duration
+	if p.Rate > 0 {
+		interval = time.Duration(float64(time.Second) * float64(p.BatchSize) / p.Rate)
+	}
+
+	start := time.Now()
+	deadline := start.Add(p.Duration)
+	next := start
+	for {
+		if interval > 0 {
+			if wait := time.Until(next); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+		created := time.Now()
+		if interval > 0 {
+			created = next
+			next = next.Add(interval)
+		}
+		if !created.Before(deadline) {
+			return nil
+		}
+
+		batch, err := gen.batch(p.BatchSize)
+		if err != nil {
+			return err
+		}
+		var bytes int64
+		_ = batch.Iter(func(i int, p *message.Part) error {
+			bytes += int64(len(p.Get()))
+			return nil
+		})
+
+		select {
+		case batchChan <- generated{batch: batch, bytes: bytes, created: created}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// run executes a benchmark of a sequence of components against a profile.
+func run(ctx context.Context, components []component, p profile) (*result, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	gen := newGenerator(p)
+	batchChan := make(chan generated, p.Threads)
+
+	var wg sync.WaitGroup
+	workerSamples := make([]*samples, p.Threads)
+	for i := range workerSamples {
+		s := &samples{components: make([]componentSamples, len(components))}
+		workerSamples[i] = s
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range batchChan {
+				s.process(components, g)
+			}
+		}()
+	}
+
+	start := time.Now()
+	genErr := produce(ctx, p, gen, batchChan)
+	wg.Wait()
+	elapsed := time.Since(start)
+	if genErr != nil {
+		return nil, genErr
+	}
+
+	res := &result{
+		Elapsed:    elapsed,
+		Components: make([]componentResult, len(components)),
+	}
+	var latencies latencyHistogram
+	for _, s := range workerSamples {
+		res.Messages += s.messages
+		res.Bytes += s.bytes
+		res.MessagesOut += s.messagesOut
+		res.Errors += s.errors
+		latencies.merge(&s.latencies)
+	}
+	res.LatencyP50 = latencies.percentile(50)
+	res.LatencyP99 = latencies.percentile(99)
+	res.Throughput = float64(res.Messages) / elapsed.Seconds()
+	res.BytesThroughput = float64(res.Bytes) / elapsed.Seconds()
+
+	for i, c := range components {
+		cRes := componentResult{Label: c.label, Type: c.typ}
+		var cLatencies latencyHistogram
+		for _, s := range workerSamples {
+			cs := &s.components[i]
+			cRes.MessagesIn += cs.messagesIn
+			cRes.MessagesOut += cs.messagesOut
+			cLatencies.merge(&cs.latencies)
+		}
+		cRes.Calls = int(cLatencies.count)
+		if cLatencies.sum > 0 {
+			cRes.Throughput = float64(cRes.MessagesIn) / cLatencies.sum.Seconds()
+		}
+		cRes.LatencyP50 = cLatencies.percentile(50)
+		cRes.LatencyP99 = cLatencies.percentile(99)
+		res.Components[i] = cRes
+	}
+
+	if err := measureAllocs(components, p, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// measureAllocs processes a sample of messages through each component in
+// turn from a single goroutine, recording the allocations made by each. The
+// outputs of each component are used as the inputs of the next, and inputs
+// are copied before measurements begin so that copying is not included.
+func measureAllocs(components []component, p profile, res *result) error {
+	gen := newGenerator(p)
+
+	var inputs []*message.Batch
+	for n := 0; n < allocSampleSize; n += p.BatchSize {
+		b, err := gen.batch(p.BatchSize)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, b)
+	}
+
+	var before, after runtime.MemStats
+	for i, c := range components {
+		batches := make([]*message.Batch, len(inputs))
+		messages := 0
+		for j, b := range inputs {
+			batches[j] = b.DeepCopy()
+			messages += b.Len()
+		}
+		outputs := make([]*message.Batch, 0, len(batches))
+		if messages == 0 {
+			inputs = outputs
+			continue
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		for _, b := range batches {
+			out, err := c.proc.ProcessMessage(b)
+			if err == nil {
+				outputs = append(outputs, out...)
+			}
+		}
+		runtime.ReadMemStats(&after)
+
+		res.Components[i].AllocsPerMessage = float64(after.Mallocs-before.Mallocs) / float64(messages)
+		res.Components[i].BytesPerMessage = float64(after.TotalAlloc-before.TotalAlloc) / float64(messages)
+		res.AllocsPerMessage += res.Components[i].AllocsPerMessage
+		res.BytesPerMessage += res.Components[i].BytesPerMessage
+		inputs = outputs
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package bench
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
+)
+
+func bloblangComponent(t *testing.T, label, mapping string) component {
+	t.Helper()
+
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = processor.BloblangConfig(mapping)
+
+	proc, err := processor.New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second))
+	})
+	return component{label: label, typ: "bloblang", proc: proc}
+}
+
+func TestPercentile(t *testing.T) {
+	var h latencyHistogram
+	assert.Equal(t, time.Duration(0), h.percentile(50))
+
+	for i := 100; i > 0; i-- {
+		h.record(time.Duration(i))
+	}
+
+	assert.Equal(t, time.Duration(50), h.percentile(50))
+	assert.Equal(t, time.Duration(99), h.percentile(99))
+	assert.Equal(t, time.Duration(100), h.percentile(100))
+	assert.Equal(t, time.Duration(1), h.percentile(0))
+	assert.Equal(t, int64(100), h.count)
+	assert.Equal(t, time.Duration(5050), h.sum)
+}
+
+func TestPercentileLarge(t *testing.T) {
+	var a, b latencyHistogram
+	for i := 1; i <= 1000; i++ {
+		a.record(time.Duration(i) * time.Millisecond)
+		b.record(time.Duration(i) * time.Microsecond)
+	}
+	a.record(time.Duration(math.MaxInt64))
+	a.merge(&b)
+
+	assert.Equal(t, int64(2001), a.count)
+	assert.Equal(t, time.Duration(math.MaxInt64), a.percentile(100))
+	assert.InEpsilon(t, float64(time.Millisecond), float64(a.percentile(50)), 1.0/histogramSubBuckets)
+	assert.InEpsilon(t, float64(980*time.Millisecond), float64(a.percentile(99)), 1.0/histogramSubBuckets)
+
+	for i := 0; i < histogramBuckets; i++ {
+		require.Equal(t, i, histogramIndex(uint64(histogramUpperBound(i))), i)
+	}
+}
+
+func TestRun(t *testing.T) {
+	components := []component{
+		bloblangComponent(t, "a", `root = this.id`),
+		bloblangComponent(t, "b", `root = if this % 2 == 0 { deleted() } else { this }`),
+		bloblangComponent(t, "c", `root = throw("nope")`),
+	}
+
+	res, err := run(context.Background(), components, profile{
+		Rate:      1000,
+		Duration:  time.Millisecond * 100,
+		BatchSize: 1,
+		Threads:   2,
+		Sizes:     sizeRange{min: 100, max: 100},
+	})
+	require.NoError(t, err)
+
+	assert.InDelta(t, 100, res.Messages, 10)
+	assert.Equal(t, res.Messages*100, res.Bytes)
+	assert.Greater(t, res.Throughput, float64(0))
+	assert.Greater(t, res.LatencyP99, time.Duration(0))
+	assert.GreaterOrEqual(t, res.LatencyP99, res.LatencyP50)
+
+	require.Len(t, res.Components, 3)
+	assert.Equal(t, "a", res.Components[0].Label)
+	assert.Equal(t, "bloblang", res.Components[0].Type)
+	assert.Equal(t, res.Messages, res.Components[0].MessagesIn)
+	assert.Equal(t, res.Messages, res.Components[0].MessagesOut)
+	assert.Equal(t, int(res.Messages), res.Components[0].Calls)
+
+	// Odd IDs pass through the second processor and are flagged as failed by
+	// the third.
+	odd := (res.Messages + 1) / 2
+	assert.Equal(t, odd, res.Components[1].MessagesOut)
+	assert.Equal(t, odd, res.Components[2].MessagesIn)
+	assert.Equal(t, odd, res.Errors)
+	assert.Equal(t, odd, res.MessagesOut)
+
+	for _, c := range res.Components {
+		assert.Greater(t, c.Throughput, float64(0))
+		assert.Greater(t, c.AllocsPerMessage, float64(0))
+		assert.Greater(t, c.BytesPerMessage, float64(0))
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	done()
+
+	res, err := run(ctx, []component{bloblangComponent(t, "a", `root = this`)}, profile{
+		Duration:  time.Hour,
+		BatchSize: 10,
+		Threads:   1,
+		Sizes:     sizeRange{min: 10, max: 10},
+	})
+	require.NoError(t, err)
+	assert.Less(t, res.Messages, int64(100))
+}
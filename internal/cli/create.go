@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/urfave/cli/v2"
@@ -100,6 +103,221 @@ type minimalCreateConfig struct {
 	ResourceRateLimits []ratelimit.Config `json:"rate_limit_resources,omitempty" yaml:"rate_limit_resources,omitempty"`
 }
 
+// createOpts describes the config to be created.
+type createOpts struct {
+	expression string
+	small      bool
+	comments   bool
+}
+
+func createConfig(opts createOpts) ([]byte, error) {
+	conf := config.New()
+	if len(opts.expression) > 0 {
+		if err := addExpression(&conf, opts.expression); err != nil {
+			return nil, err
+		}
+	}
+
+	var filter docs.FieldFilter
+	var iconf interface{} = conf
+
+	if opts.small {
+		iconf = minimalCreateConfig{
+			Input:              conf.Input,
+			Pipeline:           conf.Pipeline,
+			Output:             conf.Output,
+			ResourceCaches:     conf.ResourceCaches,
+			ResourceRateLimits: conf.ResourceRateLimits,
+		}
+
+		filter = func(spec docs.FieldSpec) bool {
+			return !spec.IsAdvanced
+		}
+	}
+
+	var node yaml.Node
+	if err := node.Encode(iconf); err != nil {
+		return nil, err
+	}
+	if err := config.Spec().SanitiseYAML(&node, docs.SanitiseConfig{
+		RemoveTypeField:  true,
+		RemoveDeprecated: true,
+		ForExample:       true,
+		DocumentFields:   opts.comments,
+		Filter:           filter,
+	}); err != nil {
+		return nil, err
+	}
+	return config.MarshalYAML(node)
+}
+
+//------------------------------------------------------------------------------
+
+// componentNames returns the names of all components of a type that are not
+// deprecated.
+func componentNames(specs []docs.ComponentSpec) []string {
+	var names []string
+	for _, s := range specs {
+		if s.Status != docs.StatusDeprecated {
+			names = append(names, s.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j] + 1
+			if v := cur[j-1] + 1; v < cur[j] {
+				cur[j] = v
+			}
+			if v := prev[j-1] + cost; v < cur[j] {
+				cur[j] = v
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// suggestNames returns up to five names that are similar to a given name.
+func suggestNames(name string, names []string) []string {
+	var suggestions []string
+	for _, n := range names {
+		if strings.Contains(n, name) || editDistance(n, name) <= 2 {
+			suggestions = append(suggestions, n)
+			if len(suggestions) == 5 {
+				break
+			}
+		}
+	}
+	return suggestions
+}
+
+// createWizard prompts for the components and options of a new config.
+type createWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (w *createWizard) ask(question string) (string, error) {
+	fmt.Fprint(w.out, question)
+	line, err := w.in.ReadString('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (w *createWizard) askBool(question string, def bool) (bool, error) {
+	opts := " [y/N]: "
+	if def {
+		opts = " [Y/n]: "
+	}
+	for {
+		answer, err := w.ask(question + opts)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(w.out, "Please answer yes or no.")
+	}
+}
+
+// askComponents prompts for a comma separated list of component names until
+// all of the names given are recognised.
+func (w *createWizard) askComponents(question string, names []string, required bool) ([]string, error) {
+	known := map[string]struct{}{}
+	for _, n := range names {
+		known[n] = struct{}{}
+	}
+	for {
+		answer, err := w.ask(question)
+		if err != nil {
+			return nil, err
+		}
+		if answer == "?" {
+			fmt.Fprintln(w.out, strings.Join(names, ", "))
+			continue
+		}
+
+		var chosen []string
+		valid := true
+		for _, t := range strings.Split(answer, ",") {
+			if t = strings.TrimSpace(t); len(t) == 0 {
+				continue
+			}
+			if _, exists := known[t]; !exists {
+				valid = false
+				fmt.Fprintf(w.out, "Unrecognised type '%v'", t)
+				if suggestions := suggestNames(t, names); len(suggestions) > 0 {
+					fmt.Fprintf(w.out, ", did you mean: %v", strings.Join(suggestions, ", "))
+				}
+				fmt.Fprintln(w.out)
+				continue
+			}
+			chosen = append(chosen, t)
+		}
+		if !valid {
+			continue
+		}
+		if required && len(chosen) == 0 {
+			fmt.Fprintln(w.out, "At least one type is required, enter ? to list them all.")
+			continue
+		}
+		return chosen, nil
+	}
+}
+
+// run prompts for the options of a config and the path to write it to, where
+// an empty path means stdout.
+func (w *createWizard) run() (opts createOpts, path string, err error) {
+	fmt.Fprintln(w.out, "Enter a comma separated list of types for each component, or ? to list them all.")
+
+	var inputs, processors, outputs []string
+	if inputs, err = w.askComponents("Inputs: ", componentNames(bundle.AllInputs.Docs()), true); err != nil {
+		return
+	}
+	if processors, err = w.askComponents("Processors (optional): ", componentNames(bundle.AllProcessors.Docs()), false); err != nil {
+		return
+	}
+	if outputs, err = w.askComponents("Outputs: ", componentNames(bundle.AllOutputs.Docs()), true); err != nil {
+		return
+	}
+	opts.expression = strings.Join(inputs, ",") + "/" + strings.Join(processors, ",") + "/" + strings.Join(outputs, ",")
+
+	var advanced bool
+	if advanced, err = w.askBool("Include advanced fields and sections?", false); err != nil {
+		return
+	}
+	opts.small = !advanced
+	if opts.comments, err = w.askBool("Include field descriptions as comments?", true); err != nil {
+		return
+	}
+	path, err = w.ask("Write config to file (leave empty for stdout): ")
+	return
+}
+
 func createCliCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "create",
@@ -113,7 +331,11 @@ forward slashes:
   benthos create stdin/bloblang,awk/nats
   benthos create file,http_server/protobuf/http_client
 
-If the expression is omitted a default config is created.`[1:],
+If the expression is omitted a default config is created. Alternatively, run
+with --interactive in order to be prompted for the components and options of
+the config:
+
+  benthos create --interactive`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "small",
@@ -121,54 +343,71 @@ If the expression is omitted a default config is created.`[1:],
 				Value:   false,
 				Usage:   "Print only the main components of a Benthos config (input, pipeline, output) and omit all fields marked as advanced.",
 			},
+			&cli.BoolFlag{
+				Name:  "comments",
+				Value: false,
+				Usage: "Add the description of each component and field as a comment.",
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Value:   "",
+				Usage:   "Write the config to a file rather than stdout. The file must not already exist.",
+			},
+			&cli.BoolFlag{
+				Name:    "interactive",
+				Aliases: []string{"i"},
+				Value:   false,
+				Usage:   "Prompt for the components and options of the config.",
+			},
 		},
 		Action: func(c *cli.Context) error {
-			conf := config.New()
+			opts := createOpts{
+				expression: c.Args().First(),
+				small:      c.Bool("small"),
+				comments:   c.Bool("comments"),
+			}
+			path := c.String("file")
 
-			if expression := c.Args().First(); len(expression) > 0 {
-				if err := addExpression(&conf, expression); err != nil {
-					fmt.Fprintf(os.Stderr, "Generate error: %v\n", err)
+			if c.Bool("interactive") {
+				if len(opts.expression) > 0 {
+					fmt.Fprintln(os.Stderr, "Cannot provide an expression with --interactive")
 					os.Exit(1)
 				}
-			}
-
-			var filter docs.FieldFilter
-			var iconf interface{} = conf
-
-			if c.Bool("small") {
-				iconf = minimalCreateConfig{
-					Input:              conf.Input,
-					Pipeline:           conf.Pipeline,
-					Output:             conf.Output,
-					ResourceCaches:     conf.ResourceCaches,
-					ResourceRateLimits: conf.ResourceRateLimits,
+				w := &createWizard{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+				var wizardPath string
+				var err error
+				if opts, wizardPath, err = w.run(); err != nil {
+					fmt.Fprintf(os.Stderr, "Prompt error: %v\n", err)
+					os.Exit(1)
 				}
-
-				filter = func(spec docs.FieldSpec) bool {
-					return !spec.IsAdvanced
+				if len(path) == 0 {
+					path = wizardPath
 				}
 			}
 
-			var node yaml.Node
-			err := node.Encode(iconf)
-			if err == nil {
-				err = config.Spec().SanitiseYAML(&node, docs.SanitiseConfig{
-					RemoveTypeField:  true,
-					RemoveDeprecated: true,
-					ForExample:       true,
-					Filter:           filter,
-				})
+			configYAML, err := createConfig(opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Generate error: %v\n", err)
+				os.Exit(1)
 			}
+
+			if len(path) == 0 {
+				fmt.Println(string(configYAML))
+				return nil
+			}
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
 			if err == nil {
-				var configYAML []byte
-				if configYAML, err = config.MarshalYAML(node); err == nil {
-					fmt.Println(string(configYAML))
+				_, err = f.Write(configYAML)
+				if cerr := f.Close(); err == nil {
+					err = cerr
 				}
 			}
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Generate error: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Write error: %v\n", err)
 				os.Exit(1)
 			}
+			fmt.Fprintf(os.Stderr, "Config written to %v\n", path)
 			return nil
 		},
 	}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/legacy"
+)
+
+func TestCreateConfigComments(t *testing.T) {
+	confBytes, err := createConfig(createOpts{
+		expression: "stdin//stdout",
+		small:      true,
+		comments:   true,
+	})
+	require.NoError(t, err)
+
+	conf := string(confBytes)
+	assert.Contains(t, conf, "# An input to source messages from.\ninput:\n")
+	assert.Contains(t, conf, "  # Consumes data piped to stdin as line delimited messages.\n  stdin:\n")
+
+	confBytes, err = createConfig(createOpts{expression: "stdin//stdout", small: true})
+	require.NoError(t, err)
+	assert.NotContains(t, string(confBytes), "#")
+}
+
+func TestCreateWizard(t *testing.T) {
+	var out bytes.Buffer
+	w := &createWizard{
+		in: bufio.NewReader(strings.NewReader(`stdn
+stdin
+bloblang, nope
+bloblang
+
+stdout,drop
+maybe
+y
+n
+./foo.yaml`)),
+		out: &out,
+	}
+
+	opts, path, err := w.run()
+	require.NoError(t, err)
+
+	assert.Equal(t, createOpts{
+		expression: "stdin/bloblang/stdout,drop",
+		small:      false,
+		comments:   false,
+	}, opts)
+	assert.Equal(t, "./foo.yaml", path)
+
+	assert.Contains(t, out.String(), "Unrecognised type 'stdn', did you mean: stdin")
+	assert.Contains(t, out.String(), "Unrecognised type 'nope'")
+	assert.Contains(t, out.String(), "At least one type is required")
+	assert.Contains(t, out.String(), "Please answer yes or no.")
+}
+
+func TestSuggestNames(t *testing.T) {
+	names := []string{"aws_s3", "kafka", "kafka_franz", "stdin"}
+	assert.Equal(t, []string{"kafka", "kafka_franz"}, suggestNames("kafka", names))
+	assert.Equal(t, []string{"kafka"}, suggestNames("kafak", names))
+	assert.Empty(t, suggestNames("nope", names))
+}
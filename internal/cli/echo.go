@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+func echoCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "echo",
+		Usage: "Parse a config file and echo back a normalised version",
+		Description: `
+This simple command is useful for sanity checking a config if it isn't
+behaving as expected, as it shows you a normalised version after environment
+variables have been resolved and default values have been filled:
+
+  benthos -c ./config.yaml echo | less
+
+Components created from templates can be replaced with the config they expand
+into with the --expand-templates flag, and the --annotate flag adds a comment
+to each value describing where it came from, which is either a file, an
+environment variable, a --set override, a template or a default:
+
+  benthos -c ./config.yaml -r ./resources.yaml echo --annotate`[1:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "expand-templates",
+				Value: false,
+				Usage: "replace components created from templates with the config they expand into",
+			},
+			&cli.BoolFlag{
+				Name:  "annotate",
+				Value: false,
+				Usage: "add a comment to each value describing where it came from",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			confReader := readConfig(c.String("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"), c.StringSlice("var"))
+			conf := config.New()
+			if _, err := confReader.Read(&conf); err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+				os.Exit(1)
+			}
+			sanitConf := docs.SanitiseConfig{
+				RemoveTypeField: true,
+			}
+			if c.Bool("expand-templates") {
+				sanitConf.ExpandComponent = template.ExpandComponentYAML
+			}
+			var node yaml.Node
+			err := node.Encode(conf)
+			if err == nil {
+				err = config.Spec().SanitiseYAML(&node, sanitConf)
+			}
+			if err == nil && c.Bool("annotate") {
+				err = confReader.AnnotateSources(&node)
+			}
+			if err == nil {
+				var configYAML []byte
+				if configYAML, err = config.MarshalYAML(node); err == nil {
+					fmt.Println(string(configYAML))
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Echo error: %v\n", err)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+}
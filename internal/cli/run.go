@@ -7,15 +7,13 @@ import (
 	"runtime/debug"
 
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/cli/bench"
 	"github.com/benthosdev/benthos/v4/internal/cli/blobl"
 	"github.com/benthosdev/benthos/v4/internal/cli/studio"
 	clitemplate "github.com/benthosdev/benthos/v4/internal/cli/template"
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
-	"github.com/benthosdev/benthos/v4/internal/config"
-	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/filepath"
 	"github.com/benthosdev/benthos/v4/internal/template"
 )
@@ -141,6 +139,10 @@ func Run() {
 			Aliases: []string{"s"},
 			Usage:   "set a field (identified by a dot path) in the main configuration file, e.g. `\"metrics.type=prometheus\"`",
 		},
+		&cli.StringSliceFlag{
+			Name:  "var",
+			Usage: "override the value of a variable declared in the vars section of configuration files, e.g. `\"region=eu-west-1\"`",
+		},
 		&cli.StringFlag{
 			Name:    "config",
 			Aliases: []string{"c"},
@@ -231,6 +233,7 @@ Either run Benthos as a stream processor or choose a command:
 				c.String("config"),
 				c.StringSlice("resources"),
 				c.StringSlice("set"),
+				c.StringSlice("var"),
 				c.String("log.level"),
 				!c.Bool("chilled"),
 				c.Bool("watcher"),
@@ -241,42 +244,7 @@ Either run Benthos as a stream processor or choose a command:
 			return nil
 		},
 		Commands: []*cli.Command{
-			{
-				Name:  "echo",
-				Usage: "Parse a config file and echo back a normalised version",
-				Description: `
-This simple command is useful for sanity checking a config if it isn't
-behaving as expected, as it shows you a normalised version after environment
-variables have been resolved:
-
-  benthos -c ./config.yaml echo | less`[1:],
-				Action: func(c *cli.Context) error {
-					confReader := readConfig(c.String("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
-					conf := config.New()
-					if _, err := confReader.Read(&conf); err != nil {
-						fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
-						os.Exit(1)
-					}
-					var node yaml.Node
-					err := node.Encode(conf)
-					if err == nil {
-						err = config.Spec().SanitiseYAML(&node, docs.SanitiseConfig{
-							RemoveTypeField: true,
-						})
-					}
-					if err == nil {
-						var configYAML []byte
-						if configYAML, err = config.MarshalYAML(node); err == nil {
-							fmt.Println(string(configYAML))
-						}
-					}
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "Echo error: %v\n", err)
-						os.Exit(1)
-					}
-					return nil
-				},
-			},
+			echoCliCommand(),
 			lintCliCommand(),
 			{
 				Name:  "streams",
@@ -309,6 +277,7 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 						c.String("config"),
 						c.StringSlice("resources"),
 						c.StringSlice("set"),
+						c.StringSlice("var"),
 						c.String("log.level"),
 						!c.Bool("chilled"),
 						c.Bool("watcher"),
@@ -323,6 +292,7 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 			createCliCommand(),
 			test.CliCommand(testSuffix),
 			clitemplate.CliCommand(),
+			bench.CliCommand(),
 			blobl.CliCommand(),
 			studio.CliCommand(Version, DateBuilt),
 		},
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"runtime/pprof"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -37,7 +39,7 @@ type stoppable interface {
 
 //------------------------------------------------------------------------------
 
-func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, overrides []string) *config.Reader {
+func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, overrides, varOverrides []string) *config.Reader {
 	if path == "" {
 		// Iterate default config paths
 		for _, dpath := range []string{
@@ -54,6 +56,7 @@ func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, ove
 	}
 	opts := []config.OptFunc{
 		config.OptAddOverrides(overrides...),
+		config.OptAddVarOverrides(varOverrides...),
 		config.OptTestSuffix(testSuffix),
 	}
 	if streamsMode {
@@ -123,9 +126,16 @@ func initStreamsMode(
 }
 
 type swappableStopper struct {
-	stopped bool
-	current stoppable
-	mut     sync.Mutex
+	stopped   bool
+	replacing int32
+	current   stoppable
+	mut       sync.Mutex
+}
+
+// isReplacing returns whether the current stream is being stopped in order to
+// be replaced, in which case its closure should not end the service.
+func (s *swappableStopper) isReplacing() bool {
+	return atomic.LoadInt32(&s.replacing) == 1
 }
 
 func (s *swappableStopper) Stop(timeout time.Duration) error {
@@ -149,7 +159,10 @@ func (s *swappableStopper) Replace(fn func() (stoppable, error)) error {
 		return nil
 	}
 
-	if err := s.current.Stop(time.Second * 30); err != nil {
+	atomic.StoreInt32(&s.replacing, 1)
+	err := s.current.Stop(time.Second * 30)
+	atomic.StoreInt32(&s.replacing, 0)
+	if err != nil {
 		return fmt.Errorf("failed to stop active stream: %w", err)
 	}
 
@@ -172,19 +185,19 @@ func initNormalMode(
 ) (newStream stoppable, stoppedChan chan struct{}) {
 	stoppedChan = make(chan struct{})
 
+	var stoppableStream swappableStopper
+
 	streamInit := func() (stoppable, error) {
 		return stream.New(
 			conf.Config, manager,
 			stream.OptOnClose(func() {
-				if !watching {
+				if !watching && !stoppableStream.isReplacing() {
 					close(stoppedChan)
 				}
 			}),
 		)
 	}
 
-	var stoppableStream swappableStopper
-
 	var err error
 	if stoppableStream.current, err = streamInit(); err != nil {
 		logger.Errorf("Service closing due to: %v\n", err)
@@ -215,20 +228,76 @@ func initNormalMode(
 		}
 	}
 
+	registerReloadEndpoints(strict, confReader, manager)
+
 	newStream = &stoppableStream
 	return
 }
 
+func registerReloadEndpoints(strict bool, confReader *config.Reader, manager *manager.Type) {
+	manager.RegisterEndpoint(
+		"/config/checksum",
+		"Returns a hash of the config files that are currently applied, which changes once a change to the config takes effect.",
+		func(w http.ResponseWriter, r *http.Request) {
+			resBytes, _ := json.Marshal(struct {
+				Checksum string `json:"checksum"`
+			}{
+				Checksum: confReader.Checksum(),
+			})
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(resBytes)
+		},
+	)
+	manager.RegisterEndpoint(
+		"/reload",
+		"POST: Re-reads the config files and replaces the pipeline and resources whose configs have changed, gracefully draining the previous pipeline.",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" {
+				http.Error(w, "Error: method not supported", http.StatusBadRequest)
+				return
+			}
+
+			applied, err := confReader.Reload(manager, strict)
+			if applied == nil {
+				applied = []string{}
+			}
+			res := struct {
+				Applied  []string `json:"applied"`
+				Checksum string   `json:"checksum"`
+				Error    string   `json:"error,omitempty"`
+			}{
+				Applied:  applied,
+				Checksum: confReader.Checksum(),
+			}
+
+			status := http.StatusOK
+			if err != nil {
+				manager.Logger().Errorf("Failed to reload config: %v", err)
+				res.Error = err.Error()
+				status = http.StatusBadRequest
+			} else if len(applied) > 0 {
+				manager.Logger().Infof("Reloaded config files: %v", strings.Join(applied, ", "))
+			}
+
+			resBytes, _ := json.Marshal(res)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write(resBytes)
+		},
+	)
+}
+
 func cmdService(
 	confPath string,
 	resourcesPaths []string,
 	confOverrides []string,
+	varOverrides []string,
 	overrideLogLevel string,
 	strict, watching, enableStreamsAPI bool,
 	streamsMode bool,
 	streamsPaths []string,
 ) int {
-	confReader := readConfig(confPath, streamsMode, resourcesPaths, streamsPaths, confOverrides)
+	confReader := readConfig(confPath, streamsMode, resourcesPaths, streamsPaths, confOverrides, varOverrides)
 	conf := config.New()
 
 	lints, err := confReader.Read(&conf)
@@ -319,6 +388,7 @@ func cmdService(
 	if streamsMode {
 		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, confReader, manager, logger, stats)
 	} else {
+		manager.RegisterComponentEndpoints()
 		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, confReader, manager, logger, stats)
 	}
 
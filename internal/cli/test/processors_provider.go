@@ -107,7 +107,7 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]iprocessor.V1, e
 	}
 
 	return []iprocessor.V1{
-		iprocessor.NewV2BatchedToV1Processor("bloblang", processor.NewBloblangFromExecutor(exec, p.logger), metrics.Noop()),
+		iprocessor.NewV2BatchedToV1Processor("bloblang", processor.NewBloblangFromExecutor(exec, p.logger), mock.NewManager()),
 	}, nil
 }
 
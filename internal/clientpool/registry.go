@@ -0,0 +1,102 @@
+// Package clientpool provides a registry of clients that are shared by
+// components connecting to the same target, such that a Benthos instance
+// running many components (or streams) against the same server does not open a
+// separate set of connections for each of them.
+package clientpool
+
+import (
+	"sort"
+	"sync"
+)
+
+// Ctor creates a new client along with a function that closes it once it is no
+// longer used by any component.
+type Ctor func() (client interface{}, closeFn func() error, err error)
+
+type entry struct {
+	client  interface{}
+	closeFn func() error
+	refs    int
+}
+
+// Registry keeps track of shared clients by a key that identifies the target
+// and settings of the client, where clients are reference counted and closed
+// once the last component holding them releases them.
+type Registry struct {
+	mut     sync.Mutex
+	clients map[string]*entry
+}
+
+// NewRegistry returns an empty client registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		clients: map[string]*entry{},
+	}
+}
+
+// Acquire returns the client registered under a key, creating it with the
+// provided constructor when it does not yet exist. The returned release
+// function must be called once the caller no longer uses the client, and
+// returns the error of closing the client when it was the last reference.
+// Calling release more than once has no effect.
+func (r *Registry) Acquire(key string, ctor Ctor) (client interface{}, release func() error, err error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	e, exists := r.clients[key]
+	if !exists {
+		c, closeFn, err := ctor()
+		if err != nil {
+			return nil, nil, err
+		}
+		e = &entry{client: c, closeFn: closeFn}
+		r.clients[key] = e
+	}
+	e.refs++
+
+	var once sync.Once
+	return e.client, func() (err error) {
+		once.Do(func() {
+			err = r.release(key, e)
+		})
+		return
+	}, nil
+}
+
+func (r *Registry) release(key string, e *entry) error {
+	r.mut.Lock()
+	e.refs--
+	closing := e.refs == 0
+	if closing && r.clients[key] == e {
+		delete(r.clients, key)
+	}
+	r.mut.Unlock()
+
+	if !closing || e.closeFn == nil {
+		return nil
+	}
+	return e.closeFn()
+}
+
+// Keys returns the keys of all clients currently held, in sorted order.
+func (r *Registry) Keys() []string {
+	r.mut.Lock()
+	keys := make([]string, 0, len(r.clients))
+	for k := range r.clients {
+		keys = append(keys, k)
+	}
+	r.mut.Unlock()
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Refs returns the number of references held of the client under a key.
+func (r *Registry) Refs(key string) int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if e, exists := r.clients[key]; exists {
+		return e.refs
+	}
+	return 0
+}
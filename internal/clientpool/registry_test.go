@@ -0,0 +1,75 @@
+package clientpool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrySharing(t *testing.T) {
+	r := NewRegistry()
+
+	var created, closed int
+	ctor := func(name string) Ctor {
+		return func() (interface{}, func() error, error) {
+			created++
+			return name, func() error {
+				closed++
+				return nil
+			}, nil
+		}
+	}
+
+	c1, release1, err := r.Acquire("foo", ctor("first"))
+	require.NoError(t, err)
+	c2, release2, err := r.Acquire("foo", ctor("second"))
+	require.NoError(t, err)
+	c3, release3, err := r.Acquire("bar", ctor("third"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "first", c1)
+	assert.Equal(t, "first", c2)
+	assert.Equal(t, "third", c3)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, []string{"bar", "foo"}, r.Keys())
+	assert.Equal(t, 2, r.Refs("foo"))
+
+	require.NoError(t, release1())
+	require.NoError(t, release1())
+	assert.Equal(t, 0, closed)
+	assert.Equal(t, 1, r.Refs("foo"))
+
+	require.NoError(t, release2())
+	assert.Equal(t, 1, closed)
+	assert.Equal(t, []string{"bar"}, r.Keys())
+
+	// A new client is created once the previous one has been closed.
+	c4, release4, err := r.Acquire("foo", ctor("fourth"))
+	require.NoError(t, err)
+	assert.Equal(t, "fourth", c4)
+
+	require.NoError(t, release3())
+	require.NoError(t, release4())
+	assert.Equal(t, 3, closed)
+	assert.Empty(t, r.Keys())
+}
+
+func TestRegistryErrors(t *testing.T) {
+	r := NewRegistry()
+
+	_, _, err := r.Acquire("foo", func() (interface{}, func() error, error) {
+		return nil, nil, errors.New("nope")
+	})
+	require.EqualError(t, err, "nope")
+	assert.Empty(t, r.Keys())
+
+	_, release, err := r.Acquire("foo", func() (interface{}, func() error, error) {
+		return "foo", func() error {
+			return errors.New("close failed")
+		}, nil
+	})
+	require.NoError(t, err)
+	require.EqualError(t, release(), "close failed")
+}
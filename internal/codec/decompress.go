@@ -0,0 +1,128 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+type compressionFormat struct {
+	name       string
+	magic      []byte
+	extensions []string
+	newReader  func(r io.Reader) (io.Reader, error)
+}
+
+var compressionFormats = []*compressionFormat{
+	{
+		name:       "gzip",
+		magic:      []byte{0x1f, 0x8b},
+		extensions: []string{".gz", ".gzip"},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			// Multistream mode is the default, which means concatenated gzip
+			// members are read as a single stream.
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		name:       "zstd",
+		magic:      []byte{0x28, 0xb5, 0x2f, 0xfd},
+		extensions: []string{".zst", ".zstd"},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			d, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return zstdReadCloser{d}, nil
+		},
+	},
+	{
+		name:       "bzip2",
+		magic:      []byte("BZh"),
+		extensions: []string{".bz2", ".bzip2"},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			return bzip2.NewReader(r), nil
+		},
+	},
+	{
+		name:       "xz",
+		magic:      []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		extensions: []string{".xz"},
+		newReader: func(r io.Reader) (io.Reader, error) {
+			return xz.NewReader(r)
+		},
+	},
+}
+
+// zstdReadCloser adapts a zstd decoder, which releases its resources with a
+// Close method that returns nothing, to an io.ReadCloser.
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (z zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+// decompressReader reads from a decompressing reader and closes both it and
+// the underlying compressed reader when closed.
+type decompressReader struct {
+	io.Reader
+	underlying io.ReadCloser
+}
+
+func (d *decompressReader) Close() error {
+	if c, ok := d.Reader.(io.Closer); ok {
+		_ = c.Close()
+	}
+	return d.underlying.Close()
+}
+
+type peekedReadCloser struct {
+	*bufio.Reader
+	underlying io.ReadCloser
+}
+
+func (p *peekedReadCloser) Close() error {
+	return p.underlying.Close()
+}
+
+// sniffDecompress inspects the first bytes of a reader and, when they match
+// the magic bytes of a known compression format, returns a reader of the
+// decompressed contents along with the detected format. Otherwise the returned
+// reader yields the original contents and the format is nil.
+func sniffDecompress(r io.ReadCloser) (io.ReadCloser, *compressionFormat, error) {
+	maxMagic := 0
+	for _, f := range compressionFormats {
+		if len(f.magic) > maxMagic {
+			maxMagic = len(f.magic)
+		}
+	}
+
+	br := bufio.NewReader(r)
+	peeked, err := br.Peek(maxMagic)
+	if err != nil && err != io.EOF {
+		r.Close()
+		return nil, nil, err
+	}
+
+	peekedRC := &peekedReadCloser{Reader: br, underlying: r}
+	for _, f := range compressionFormats {
+		if !bytes.HasPrefix(peeked, f.magic) {
+			continue
+		}
+		dr, err := f.newReader(br)
+		if err != nil {
+			r.Close()
+			return nil, nil, err
+		}
+		return &decompressReader{Reader: dr, underlying: peekedRC}, f, nil
+	}
+	return peekedRC, nil, nil
+}
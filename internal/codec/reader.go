@@ -4,7 +4,6 @@ import (
 	"archive/tar"
 	"bufio"
 	"bytes"
-	"compress/gzip"
 	"context"
 	"encoding/csv"
 	"errors"
@@ -24,17 +23,22 @@ import (
 var ReaderDocs = docs.FieldString(
 	"codec", "The way in which the bytes of a data source should be converted into discrete messages, codecs are useful for specifying how large files or contiunous streams of data might be processed in small chunks rather than loading it all in memory. It's possible to consume lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter. Codecs can be chained with `/`, for example a gzip compressed CSV file can be consumed with the codec `gzip/csv`.", "lines", "delim:\t", "delim:foobar", "gzip/csv",
 ).HasAnnotatedOptions(
-	"auto", "EXPERIMENTAL: Attempts to derive a codec for each file based on information such as the extension. For example, a .tar.gz file would be consumed with the `gzip/tar` codec. Defaults to all-bytes.",
+	"auto", "EXPERIMENTAL: Attempts to derive a codec for each file based on information such as the extension. Files compressed with gzip, zstd, bzip2 or xz are detected from their magic bytes and decompressed, after which the extension without its compression suffix determines the structure of the contents. For example, a .tar.gz file would be consumed with the `gzip/tar` codec. Defaults to all-bytes.",
 	"all-bytes", "Consume the entire file as a single binary message.",
 	"chunker:x", "Consume the file in chunks of a given number of bytes.",
 	"csv", "Consume structured rows as comma separated values, the first row must be a header row.",
 	"csv:x", "Consume structured rows as values separated by a custom delimiter, the first row must be a header row. The custom delimiter must be a single character, e.g. the codec `\"csv:\\t\"` would consume a tab delimited file.",
 	"delim:x", "Consume the file in segments divided by a custom delimiter.",
-	"gzip", "Decompress a gzip file, this codec should precede another codec, e.g. `gzip/all-bytes`, `gzip/tar`, `gzip/csv`, etc.",
+	"bzip2", "Decompress a bzip2 file, this codec should precede another codec, e.g. `bzip2/lines`.",
+	"decompress", "Detect the compression algorithm of a file from its magic bytes and decompress it, files that are not compressed with gzip, zstd, bzip2 or xz are consumed as they are. This codec should precede another codec, e.g. `decompress/lines`.",
+	"gzip", "Decompress a gzip file, this codec should precede another codec, e.g. `gzip/all-bytes`, `gzip/tar`, `gzip/csv`, etc. Files containing multiple concatenated gzip members are consumed as a single stream.",
 	"lines", "Consume the file in segments divided by linebreaks.",
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
 	"regex:(?m)^\\d\\d:\\d\\d:\\d\\d", "Consume the file in segments divided by regular expression.",
-	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message.",
+	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message. Files are read from the archive one at a time, and therefore only the file being consumed is held in memory.",
+	"tar:x", "Parse the file as a tar archive as with the `tar` codec, where files larger than a given number of bytes result in an error rather than being read into memory.",
+	"xz", "Decompress an xz file, this codec should precede another codec, e.g. `xz/lines`.",
+	"zstd", "Decompress a zstd file, this codec should precede another codec, e.g. `zstd/lines`.",
 ).Linter(nil) // Disable default option linter as it doesn't include foo:bar formats.
 
 //------------------------------------------------------------------------------
@@ -180,14 +184,24 @@ func chainedReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
 }
 
 func ioReader(codec string, conf ReaderConfig) (ioReaderConstructor, bool) {
-	if codec == "gzip" {
+	if codec == "decompress" {
 		return func(_ string, r io.ReadCloser) (io.ReadCloser, error) {
-			g, err := gzip.NewReader(r)
+			rc, _, err := sniffDecompress(r)
+			return rc, err
+		}, true
+	}
+	for _, f := range compressionFormats {
+		if codec != f.name {
+			continue
+		}
+		newReader := f.newReader
+		return func(_ string, r io.ReadCloser) (io.ReadCloser, error) {
+			dr, err := newReader(r)
 			if err != nil {
 				r.Close()
 				return nil, err
 			}
-			return g, nil
+			return &decompressReader{Reader: dr, underlying: r}, nil
 		}, true
 	}
 	return nil, false
@@ -217,7 +231,9 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newCSVReader(r, fn, nil)
 		}, true, nil
 	case "tar":
-		return newTarReader, true, nil
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newTarReader(r, 0, fn)
+		}, true, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
@@ -251,6 +267,15 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 			return newChunkerReader(conf, r, chunkSize, fn)
 		}, true, nil
 	}
+	if strings.HasPrefix(codec, "tar:") {
+		maxFileSize, err := strconv.ParseInt(strings.TrimPrefix(codec, "tar:"), 10, 64)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid max file size for tar codec: %w", err)
+		}
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return newTarReader(r, maxFileSize, fn)
+		}, true, nil
+	}
 	if strings.HasPrefix(codec, "regex:") {
 		by := strings.TrimPrefix(codec, "regex:")
 		if by == "" {
@@ -284,25 +309,34 @@ func GetReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
 
 func autoCodec(conf ReaderConfig) ReaderConstructor {
 	return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+		// Compressed data is detected from its magic bytes rather than the
+		// extension, so that mixed archives are decompressed regardless of
+		// how they are named.
+		r, format, err := sniffDecompress(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect compression: %w", err)
+		}
+		structurePath := path
+		if format != nil {
+			for _, ext := range format.extensions {
+				if strings.HasSuffix(strings.ToLower(structurePath), ext) {
+					structurePath = structurePath[:len(structurePath)-len(ext)]
+					break
+				}
+			}
+		}
+
 		codec := "all-bytes"
-		switch filepath.Ext(path) {
+		switch strings.ToLower(filepath.Ext(structurePath)) {
 		case ".csv":
 			codec = "csv"
-		case ".csv.gz", ".csv.gzip":
-			codec = "gzip/csv"
-		case ".tar":
+		case ".tar", ".tgz":
 			codec = "tar"
-		case ".tgz":
-			codec = "gzip/tar"
-		}
-		if strings.HasSuffix(path, ".tar.gzip") {
-			codec = "gzip/tar"
-		} else if strings.HasSuffix(path, ".tar.gz") {
-			codec = "gzip/tar"
 		}
 
 		ctor, err := GetReader(codec, conf)
 		if err != nil {
+			r.Close()
 			return nil, fmt.Errorf("failed to infer codec: %v", err)
 		}
 		return ctor(path, r, fn)
@@ -687,23 +721,39 @@ func (a *chunkerReader) Close(ctx context.Context) error {
 //------------------------------------------------------------------------------
 
 type tarReader struct {
-	buf       *tar.Reader
-	r         io.ReadCloser
-	sourceAck ReaderAckFn
+	buf         *tar.Reader
+	r           io.ReadCloser
+	maxFileSize int64
+	sourceAck   ReaderAckFn
 
 	mut      sync.Mutex
 	finished bool
 	pending  int32
 }
 
-func newTarReader(path string, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+func newTarReader(r io.ReadCloser, maxFileSize int64, ackFn ReaderAckFn) (Reader, error) {
 	return &tarReader{
-		buf:       tar.NewReader(r),
-		r:         r,
-		sourceAck: ackOnce(ackFn),
+		buf:         tar.NewReader(r),
+		r:           r,
+		maxFileSize: maxFileSize,
+		sourceAck:   ackOnce(ackFn),
 	}, nil
 }
 
+// nextFile advances to the next regular file of the archive, skipping entries
+// such as directories and links.
+func (a *tarReader) nextFile() (*tar.Header, error) {
+	for {
+		hdr, err := a.buf.Next()
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg || hdr.Typeflag == tar.TypeRegA {
+			return hdr, nil
+		}
+	}
+}
+
 func (a *tarReader) ack(ctx context.Context, err error) error {
 	a.mut.Lock()
 	a.pending--
@@ -720,12 +770,17 @@ func (a *tarReader) ack(ctx context.Context, err error) error {
 }
 
 func (a *tarReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
-	_, err := a.buf.Next()
+	hdr, err := a.nextFile()
 
 	a.mut.Lock()
 	defer a.mut.Unlock()
 
+	if err == nil && a.maxFileSize > 0 && hdr.Size > a.maxFileSize {
+		err = fmt.Errorf("tar file %v of size %v exceeds the maximum size of %v", hdr.Name, hdr.Size, a.maxFileSize)
+	}
 	if err == nil {
+		// The declared size of a file can't be trusted and therefore the
+		// buffer only grows with the data that is actually read.
 		fileBuf := bytes.Buffer{}
 		if _, err = fileBuf.ReadFrom(a.buf); err != nil {
 			_ = a.sourceAck(ctx, err)
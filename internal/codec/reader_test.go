@@ -5,14 +5,17 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"sync"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
 
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -372,6 +375,59 @@ func TestAutoReader(t *testing.T) {
 	testReaderSuite(t, "auto", "foo.csv", data)
 }
 
+func TestAutoReaderCompressed(t *testing.T) {
+	data := []byte("col1,col2,col3\nfoo1,bar1,baz1\nfoo2,bar2,baz2")
+	expected := []string{
+		`{"col1":"foo1","col2":"bar1","col3":"baz1"}`,
+		`{"col1":"foo2","col2":"bar2","col3":"baz2"}`,
+	}
+
+	var gzipBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzipBuf)
+	_, _ = gw.Write(data)
+	require.NoError(t, gw.Close())
+
+	var zstdBuf bytes.Buffer
+	zw, err := zstd.NewWriter(&zstdBuf)
+	require.NoError(t, err)
+	_, _ = zw.Write(data)
+	require.NoError(t, zw.Close())
+
+	var xzBuf bytes.Buffer
+	xw, err := xz.NewWriter(&xzBuf)
+	require.NoError(t, err)
+	_, _ = xw.Write(data)
+	require.NoError(t, xw.Close())
+
+	testReaderSuite(t, "auto", "foo.csv.gz", gzipBuf.Bytes(), expected...)
+	testReaderSuite(t, "auto", "foo.csv.zst", zstdBuf.Bytes(), expected...)
+	testReaderSuite(t, "auto", "foo.csv.xz", xzBuf.Bytes(), expected...)
+
+	// Compression is detected regardless of the extension.
+	testReaderSuite(t, "auto", "foo.csv", gzipBuf.Bytes(), expected...)
+	testReaderSuite(t, "auto", "foo", zstdBuf.Bytes(), string(data))
+
+	// bzip2 compressed "foo\nbar\nbaz"
+	bzipData, err := base64.StdEncoding.DecodeString("QlpoOTFBWSZTWfst+RQAAANBgAAQMQCQECAAMQwAlB6mjyaRkPF3JFOFCQ+y35FA")
+	require.NoError(t, err)
+	testReaderSuite(t, "auto", "foo.bz2", bzipData, "foo\nbar\nbaz")
+	testReaderSuite(t, "bzip2/lines", "", bzipData, "foo", "bar", "baz")
+}
+
+func TestDecompressReader(t *testing.T) {
+	var gzipBuf bytes.Buffer
+	for _, member := range []string{"foo\nbar\n", "baz\n"} {
+		gw := gzip.NewWriter(&gzipBuf)
+		_, _ = gw.Write([]byte(member))
+		require.NoError(t, gw.Close())
+	}
+
+	testReaderSuite(t, "gzip/lines", "", gzipBuf.Bytes(), "foo", "bar", "baz")
+	testReaderSuite(t, "decompress/lines", "", gzipBuf.Bytes(), "foo", "bar", "baz")
+	testReaderSuite(t, "decompress/lines", "", []byte("foo\nbar\nbaz"), "foo", "bar", "baz")
+	testReaderSuite(t, "decompress/lines", "", []byte("f"), "f")
+}
+
 func TestCSVGzipReader(t *testing.T) {
 	var gzipBuf bytes.Buffer
 	zw := gzip.NewWriter(&gzipBuf)
@@ -467,6 +523,96 @@ func TestTarReader(t *testing.T) {
 	testReaderSuite(t, "auto", "foo.tar", tarBuf.Bytes(), input...)
 }
 
+func TestTarReaderSkipsDirectories(t *testing.T) {
+	input := []string{
+		"first document",
+		"second document",
+	}
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     "foo/",
+		Mode:     0o700,
+	}))
+	for i := range input {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: fmt.Sprintf("foo/testfile%v", i),
+			Mode: 0o600,
+			Size: int64(len(input[i])),
+		}))
+		_, err := tw.Write([]byte(input[i]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	testReaderSuite(t, "tar", "", tarBuf.Bytes(), input...)
+	testReaderSuite(t, "tar:15", "", tarBuf.Bytes(), input...)
+}
+
+func TestTarReaderMaxFileSize(t *testing.T) {
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	for i, content := range []string{"small", "too large"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: fmt.Sprintf("testfile%v", i),
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	ctor, err := GetReader("tar:5", NewReaderConfig())
+	require.NoError(t, err)
+
+	var ackErr error
+	r, err := ctor("", noopCloser{bytes.NewReader(tarBuf.Bytes()), false}, func(ctx context.Context, err error) error {
+		ackErr = err
+		return nil
+	})
+	require.NoError(t, err)
+
+	p, _, err := r.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, p, 1)
+	assert.Equal(t, "small", string(p[0].Get()))
+
+	_, _, err = r.Next(context.Background())
+	assert.EqualError(t, err, "tar file testfile1 of size 9 exceeds the maximum size of 5")
+	assert.Equal(t, err, ackErr)
+
+	_, err = GetReader("tar:nope", NewReaderConfig())
+	assert.Error(t, err)
+}
+
+func TestTarReaderTruncatedFile(t *testing.T) {
+	// A header that claims a huge file without the data to back it must fail
+	// without allocating the claimed size.
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name: "testfile0",
+		Mode: 0o600,
+		Size: 1 << 40,
+	}))
+	_, err := tw.Write([]byte("not nearly enough"))
+	require.NoError(t, err)
+
+	ctor, err := GetReader("tar", NewReaderConfig())
+	require.NoError(t, err)
+
+	r, err := ctor("", noopCloser{bytes.NewReader(tarBuf.Bytes()), false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, _, err = r.Next(context.Background())
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
 func TestTarGzipReader(t *testing.T) {
 	input := []string{
 		"first document",
@@ -55,6 +55,10 @@ func (m *memoryBuffer) Write(ctx context.Context, msg *message.Batch, aFn AckFun
 	return nil
 }
 
+func (m *memoryBuffer) FillPercentage() int64 {
+	return int64(len(m.messages) * 100 / cap(m.messages))
+}
+
 func (m *memoryBuffer) EndOfInput() {
 	m.closeOnce.Do(func() {
 		close(m.endOfInputChan)
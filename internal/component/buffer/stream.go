@@ -45,6 +45,14 @@ type ReaderWriter interface {
 	Close(context.Context) error
 }
 
+// FillReporter is an optional interface implemented by buffers that hold a
+// bounded amount of data, and are therefore able to report how full they are.
+type FillReporter interface {
+	// FillPercentage returns the percentage, between 0 and 100, of the capacity
+	// of the buffer that is currently occupied.
+	FillPercentage() int64
+}
+
 // Stream wraps a read/write buffer implementation with a channel based
 // streaming component that satisfies the internal Benthos Consumer and Producer
 // interfaces.
@@ -61,6 +69,8 @@ type Stream struct {
 	messagesIn  <-chan message.Transaction
 	messagesOut chan message.Transaction
 
+	mFill metrics.StatGauge
+
 	closedWG sync.WaitGroup
 }
 
@@ -75,9 +85,21 @@ func NewStream(typeStr string, buffer ReaderWriter, log log.Modular, stats metri
 		messagesOut: make(chan message.Transaction),
 	}
 	m.errThrottle = throttle.New(throttle.OptCloseChan(m.shutSig.CloseAtLeisureChan()))
+	if _, ok := buffer.(FillReporter); ok {
+		m.mFill = stats.GetGauge("buffer_fill_percentage")
+	}
 	return &m
 }
 
+// updateFill sets the fill percentage gauge to the current fill of the buffer,
+// if supported.
+func (m *Stream) updateFill() {
+	if m.mFill == nil {
+		return
+	}
+	m.mFill.Set(m.buffer.(FillReporter).FillPercentage())
+}
+
 //------------------------------------------------------------------------------
 
 // inputLoop is an internal loop that brokers incoming messages to the buffer.
@@ -128,6 +150,7 @@ func (m *Stream) inputLoop() {
 		if err == nil {
 			mReceivedCount.Incr(int64(batchLen))
 			mReceivedBatchCount.Incr(1)
+			m.updateFill()
 		} else {
 			_ = ackFunc(closeNowCtx, err)
 		}
@@ -202,6 +225,7 @@ func (m *Stream) outputLoop() {
 						m.log.Errorf("Failed to ack buffer message: %v\n", ackErr)
 					}
 				}
+				m.updateFill()
 			case <-m.shutSig.CloseNowChan():
 				return
 			}
@@ -268,3 +268,51 @@ func TestStreamReadErrors(t *testing.T) {
 	close(resChan)
 	close(tChan)
 }
+
+func TestStreamFillPercentage(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	stats := metrics.NewLocal()
+
+	tChan := make(chan message.Transaction)
+	b := NewStream("meow", newMemoryBuffer(4), log.Noop(), stats)
+	require.NoError(t, b.Consume(tChan))
+
+	resChan := make(chan error)
+	for i := 0; i < 3; i++ {
+		select {
+		case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+		case <-tCtx.Done():
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res)
+		case <-tCtx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	// The stream reads one batch from the buffer in order to offer it
+	// downstream, and so at most three batches are buffered.
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()["buffer_fill_percentage"] >= 50
+	}, time.Second, time.Millisecond*10)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case outTr := <-b.TransactionChan():
+			require.NoError(t, outTr.Ack(tCtx, nil))
+		case <-tCtx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()["buffer_fill_percentage"] == 0
+	}, time.Second, time.Millisecond*10)
+
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+}
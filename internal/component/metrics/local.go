@@ -139,6 +139,9 @@ func createLabelledPath(name string, tagNames, tagValues []string) string {
 		for k, v := range tagNames {
 			tags[v] = tagValues[k]
 		}
+
+		// Sort a copy as the names are shared by all metrics of a vector.
+		tagNames = append([]string(nil), tagNames...)
 		sort.Strings(tagNames)
 
 		b.WriteByte('{')
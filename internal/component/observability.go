@@ -0,0 +1,14 @@
+package component
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// Observability is implemented by the manager given to component constructors
+// and provides the metrics of a component along with its location within a
+// config.
+type Observability interface {
+	Metrics() metrics.Type
+	Path() []string
+	Label() string
+}
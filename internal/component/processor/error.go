@@ -1,18 +1,172 @@
 package processor
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net"
+	"strconv"
+	"syscall"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
-// MarkErr marks a message part as having failed. This includes modifying
-// metadata to contain this error as well as adding the error to a tracing span
-// if the message has one.
-func MarkErr(part *message.Part, span *tracing.Span, err error) {
+// Classes of processing errors, which describe the nature of a failure in a
+// way that allows pipelines to handle each type of failure differently.
+const (
+	// ErrorClassUnknown is the class of errors that could not be classified.
+	ErrorClassUnknown = "unknown"
+
+	// ErrorClassSchema is the class of errors caused by the contents of a
+	// message not being parsable or not matching an expected structure.
+	// Retrying these errors is not expected to succeed.
+	ErrorClassSchema = "schema"
+
+	// ErrorClassTransient is the class of errors caused by temporary problems
+	// such as timeouts, dropped connections or rate limits, which may succeed
+	// when retried.
+	ErrorClassTransient = "transient"
+
+	// ErrorClassRejected is the class of errors where a remote service
+	// explicitly rejected a request, such as HTTP 4XX responses. Retrying these
+	// errors is not expected to succeed.
+	ErrorClassRejected = "rejected"
+)
+
+// Error is a processing error annotated with a class, an optional code that
+// identifies the specific error, and whether retrying might succeed.
+type Error struct {
+	Class     string
+	Code      string
+	Retriable bool
+	Err       error
+}
+
+// NewError returns a processing error annotated with a class, code and whether
+// retrying might succeed.
+func NewError(class, code string, retriable bool, err error) *Error {
+	return &Error{
+		Class:     class,
+		Code:      code,
+		Retriable: retriable,
+		Err:       err,
+	}
+}
+
+// Error returns the message of the underlying error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError returns the class, code and whether retrying might succeed for
+// an error. Errors that wrap an *Error are described by it, otherwise errors of
+// well known types such as timeouts and JSON parsing errors are classified
+// automatically, and all other errors are of the class ErrorClassUnknown.
+func ClassifyError(err error) (class, code string, retriable bool) {
+	var pErr *Error
+	if errors.As(err, &pErr) {
+		return pErr.Class, pErr.Code, pErr.Retriable
+	}
+
+	var httpErr component.ErrUnexpectedHTTPRes
+	if errors.As(err, &httpErr) {
+		code = strconv.Itoa(httpErr.Code)
+		if httpErr.Code == 429 || httpErr.Code >= 500 {
+			return ErrorClassTransient, code, true
+		}
+		return ErrorClassRejected, code, false
+	}
+
+	var jsonSyntaxErr *json.SyntaxError
+	var jsonTypeErr *json.UnmarshalTypeError
+	var xmlSyntaxErr *xml.SyntaxError
+	var numErr *strconv.NumError
+	switch {
+	case errors.As(err, &jsonSyntaxErr):
+		return ErrorClassSchema, "json_syntax", false
+	case errors.As(err, &jsonTypeErr):
+		return ErrorClassSchema, "json_type", false
+	case errors.As(err, &xmlSyntaxErr):
+		return ErrorClassSchema, "xml_syntax", false
+	case errors.As(err, &numErr):
+		return ErrorClassSchema, "number_syntax", false
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, component.ErrTimeout),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTransient, "timeout", true
+	case errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, component.ErrNotConnected):
+		return ErrorClassTransient, "connection", true
+	}
+	return ErrorClassUnknown, "", false
+}
+
+// ErrSource returns the source of errors flagged by a processor, which is its
+// label, or its path within a config when it has no label.
+func ErrSource(mgr component.Observability) string {
+	if label := mgr.Label(); label != "" {
+		return label
+	}
+	if path := mgr.Path(); len(path) > 0 {
+		return "root." + query.SliceToDotPath(path...)
+	}
+	return ""
+}
+
+// SetErrMetadata sets the metadata of a message part that describes a
+// processing error, which consists of the error message and the source of the
+// error as well as the class, code and retriability of the error when they're
+// known.
+func SetErrMetadata(part *message.Part, source string, err error) {
+	class, code, retriable := ClassifyError(err)
+	ClearErrMetadata(part)
+	part.MetaSet(message.FailFlagKey, err.Error())
+	if source != "" {
+		part.MetaSet(message.ErrorSourceKey, source)
+	}
+	if class != ErrorClassUnknown {
+		part.MetaSet(message.ErrorClassKey, class)
+	}
+	if code != "" {
+		part.MetaSet(message.ErrorCodeKey, code)
+	}
+	if retriable {
+		part.MetaSet(message.ErrorRetriableKey, "true")
+	}
+}
+
+// ClearErrMetadata removes all metadata describing a processing error from a
+// message part.
+func ClearErrMetadata(part *message.Part) {
+	part.MetaDelete(message.FailFlagKey)
+	part.MetaDelete(message.ErrorSourceKey)
+	part.MetaDelete(message.ErrorClassKey)
+	part.MetaDelete(message.ErrorCodeKey)
+	part.MetaDelete(message.ErrorRetriableKey)
+}
+
+// MarkErr marks a message part as having failed at a given source. This
+// includes modifying metadata to contain this error as well as adding the error
+// to a tracing span if the message has one.
+func MarkErr(part *message.Part, span *tracing.Span, source string, err error) {
 	if err == nil {
 		return
 	}
-	part.MetaSet(message.FailFlagKey, err.Error())
+	SetErrMetadata(part, source, err)
 	if span == nil {
 		span = tracing.GetSpan(part)
 	}
@@ -25,6 +179,19 @@ func MarkErr(part *message.Part, span *tracing.Span, err error) {
 	}
 }
 
+// GetFailClass returns the class of the error a message part has failed with,
+// which is ErrorClassUnknown when the error could not be classified, or an
+// empty string if the part has not failed.
+func GetFailClass(part *message.Part) string {
+	if GetFail(part) == "" {
+		return ""
+	}
+	if class := part.MetaGet(message.ErrorClassKey); class != "" {
+		return class
+	}
+	return ErrorClassUnknown
+}
+
 // GetFail returns an error string for a message part if it has failed, or an
 // empty string if not.
 func GetFail(part *message.Part) string {
@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestClassifyError(t *testing.T) {
+	var jsonErr error = json.Unmarshal([]byte(`{"foo":`), &struct{}{})
+
+	tests := []struct {
+		name      string
+		err       error
+		class     string
+		code      string
+		retriable bool
+	}{
+		{
+			name:  "unknown",
+			err:   errors.New("nope"),
+			class: ErrorClassUnknown,
+		},
+		{
+			name:      "annotated",
+			err:       fmt.Errorf("wrapped: %w", NewError(ErrorClassTransient, "foo", true, errors.New("nope"))),
+			class:     ErrorClassTransient,
+			code:      "foo",
+			retriable: true,
+		},
+		{
+			name:  "json syntax",
+			err:   fmt.Errorf("failed to parse: %w", jsonErr),
+			class: ErrorClassSchema,
+			code:  "json_syntax",
+		},
+		{
+			name:      "timeout",
+			err:       context.DeadlineExceeded,
+			class:     ErrorClassTransient,
+			code:      "timeout",
+			retriable: true,
+		},
+		{
+			name:      "http server error",
+			err:       component.ErrUnexpectedHTTPRes{Code: 503},
+			class:     ErrorClassTransient,
+			code:      "503",
+			retriable: true,
+		},
+		{
+			name:  "http client error",
+			err:   component.ErrUnexpectedHTTPRes{Code: 404},
+			class: ErrorClassRejected,
+			code:  "404",
+		},
+	}
+
+	for _, test := range tests {
+		class, code, retriable := ClassifyError(test.err)
+		assert.Equal(t, test.class, class, test.name)
+		assert.Equal(t, test.code, code, test.name)
+		assert.Equal(t, test.retriable, retriable, test.name)
+	}
+}
+
+func TestMarkErrMetadata(t *testing.T) {
+	part := message.NewPart([]byte("foo"))
+	part.MetaSet(message.ErrorSourceKey, "old")
+	part.MetaSet(message.ErrorCodeKey, "old")
+
+	MarkErr(part, nil, "foo", NewError(ErrorClassSchema, "", false, errors.New("nope")))
+	assert.Equal(t, "nope", part.MetaGet(message.FailFlagKey))
+	assert.Equal(t, "schema", part.MetaGet(message.ErrorClassKey))
+	assert.Equal(t, "", part.MetaGet(message.ErrorCodeKey))
+	assert.Equal(t, "", part.MetaGet(message.ErrorRetriableKey))
+	assert.Equal(t, "foo", part.MetaGet(message.ErrorSourceKey))
+
+	ClearErrMetadata(part)
+	_ = part.MetaIter(func(k, v string) error {
+		t.Errorf("unexpected metadata key: %v", k)
+		return nil
+	})
+}
+
+func TestGetFailClass(t *testing.T) {
+	part := message.NewPart([]byte("foo"))
+	assert.Equal(t, "", GetFailClass(part))
+
+	MarkErr(part, nil, "", errors.New("nope"))
+	assert.Equal(t, ErrorClassUnknown, GetFailClass(part))
+
+	MarkErr(part, nil, "", NewError(ErrorClassTransient, "timeout", true, errors.New("nope")))
+	assert.Equal(t, ErrorClassTransient, GetFailClass(part))
+
+	ClearErrMetadata(part)
+	assert.Equal(t, "", GetFailClass(part))
+}
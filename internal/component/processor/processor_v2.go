@@ -42,9 +42,10 @@ type V2Batched interface {
 
 // Implements V1
 type v2ToV1Processor struct {
-	typeStr string
-	p       V2
-	sig     *shutdown.Signaller
+	typeStr   string
+	errSource string
+	p         V2
+	sig       *shutdown.Signaller
 
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -55,9 +56,10 @@ type v2ToV1Processor struct {
 }
 
 // NewV2ToV1Processor wraps a processor.V2 with a struct that implements V1.
-func NewV2ToV1Processor(typeStr string, p V2, stats metrics.Type) V1 {
+func NewV2ToV1Processor(typeStr string, p V2, mgr component.Observability) V1 {
+	stats := mgr.Metrics()
 	return &v2ToV1Processor{
-		typeStr: typeStr, p: p, sig: shutdown.NewSignaller(),
+		typeStr: typeStr, errSource: ErrSource(mgr), p: p, sig: shutdown.NewSignaller(),
 
 		mReceived:      stats.GetCounter("processor_received"),
 		mBatchReceived: stats.GetCounter("processor_batch_received"),
@@ -83,7 +85,7 @@ func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch,
 		if err != nil {
 			newPart := part.Copy()
 			a.mError.Incr(1)
-			MarkErr(newPart, span, err)
+			MarkErr(newPart, span, a.errSource, err)
 			nextParts = append(nextParts, newPart)
 		}
 
@@ -128,9 +130,10 @@ func (a *v2ToV1Processor) WaitForClose(tout time.Duration) error {
 
 // Implements types.Processor
 type v2BatchedToV1Processor struct {
-	typeStr string
-	p       V2Batched
-	sig     *shutdown.Signaller
+	typeStr   string
+	errSource string
+	p         V2Batched
+	sig       *shutdown.Signaller
 
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -142,9 +145,10 @@ type v2BatchedToV1Processor struct {
 
 // NewV2BatchedToV1Processor wraps a processor.V2Batched with a struct that
 // implements types.Processor.
-func NewV2BatchedToV1Processor(typeStr string, p V2Batched, stats metrics.Type) V1 {
+func NewV2BatchedToV1Processor(typeStr string, p V2Batched, mgr component.Observability) V1 {
+	stats := mgr.Metrics()
 	return &v2BatchedToV1Processor{
-		typeStr: typeStr, p: p, sig: shutdown.NewSignaller(),
+		typeStr: typeStr, errSource: ErrSource(mgr), p: p, sig: shutdown.NewSignaller(),
 
 		mReceived:      stats.GetCounter("processor_received"),
 		mBatchReceived: stats.GetCounter("processor_batch_received"),
@@ -167,7 +171,7 @@ func (a *v2BatchedToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.
 		a.mError.Incr(1)
 		outputBatch := msg.Copy()
 		_ = outputBatch.Iter(func(i int, p *message.Part) error {
-			MarkErr(p, spans[i], err)
+			MarkErr(p, spans[i], a.errSource, err)
 			return nil
 		})
 		outputBatches = append(outputBatches, outputBatch)
@@ -15,6 +15,23 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
+type mockObservability struct {
+	label string
+	path  []string
+}
+
+func (m mockObservability) Metrics() metrics.Type {
+	return metrics.Noop()
+}
+
+func (m mockObservability) Path() []string {
+	return m.path
+}
+
+func (m mockObservability) Label() string {
+	return m.label
+}
+
 type fnProcessor struct {
 	fn     func(context.Context, *message.Part) ([]*message.Part, error)
 	closed bool
@@ -35,7 +52,7 @@ func (p *fnProcessor) Close(ctx context.Context) error {
 
 func TestProcessorAirGapShutdown(t *testing.T) {
 	rp := &fnProcessor{}
-	agrp := NewV2ToV1Processor("foo", rp, metrics.Noop())
+	agrp := NewV2ToV1Processor("foo", rp, mockObservability{})
 
 	err := agrp.WaitForClose(time.Millisecond * 5)
 	assert.EqualError(t, err, "action timed out")
@@ -61,7 +78,7 @@ func TestProcessorAirGapOneToOne(t *testing.T) {
 			newPart.Set([]byte("changed"))
 			return []*message.Part{newPart}, nil
 		},
-	}, metrics.Noop())
+	}, mockObservability{})
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -78,7 +95,7 @@ func TestProcessorAirGapOneToError(t *testing.T) {
 			_, err := m.JSON()
 			return nil, err
 		},
-	}, metrics.Noop())
+	}, mockObservability{})
 
 	msg := message.QuickBatch([][]byte{[]byte("not a structured doc")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -104,7 +121,7 @@ func TestProcessorAirGapOneToMany(t *testing.T) {
 			third.Set([]byte("changed 3"))
 			return []*message.Part{first, second, third}, nil
 		},
-	}, metrics.Noop())
+	}, mockObservability{})
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -135,7 +152,7 @@ func (p *fnBatchProcessor) Close(ctx context.Context) error {
 
 func TestBatchProcessorAirGapShutdown(t *testing.T) {
 	rp := &fnBatchProcessor{}
-	agrp := NewV2BatchedToV1Processor("foo", rp, metrics.Noop())
+	agrp := NewV2BatchedToV1Processor("foo", rp, mockObservability{})
 
 	err := agrp.WaitForClose(time.Millisecond * 5)
 	assert.EqualError(t, err, "action timed out")
@@ -159,7 +176,7 @@ func TestBatchProcessorAirGapOneToOne(t *testing.T) {
 			newBatch.Append(newMsg)
 			return []*message.Batch{newBatch}, nil
 		},
-	}, metrics.Noop())
+	}, mockObservability{})
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -176,7 +193,7 @@ func TestBatchProcessorAirGapOneToError(t *testing.T) {
 			_, err := msgs.Get(0).JSON()
 			return nil, err
 		},
-	}, metrics.Noop())
+	}, mockObservability{})
 
 	msg := message.QuickBatch([][]byte{[]byte("not a structured doc")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -208,7 +225,7 @@ func TestBatchProcessorAirGapOneToMany(t *testing.T) {
 			secondBatch.Append(third)
 			return []*message.Batch{firstBatch, secondBatch}, nil
 		},
-	}, metrics.Noop())
+	}, mockObservability{})
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -1,17 +1,107 @@
 package config
 
 import (
-	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"os"
 	"regexp"
 	"strings"
 )
 
 var (
-	envRegex        = regexp.MustCompile(`\${[0-9A-Za-z_.]+(:((\${[^}]+})|[^}])+)?}`)
-	escapedEnvRegex = regexp.MustCompile(`\${({[0-9A-Za-z_.]+(:((\${[^}]+})|[^}])+)?})}`)
+	envRegex        = regexp.MustCompile(`\${[0-9A-Za-z_.]+([:|]((\${[^}]+})|[^}])+)?}`)
+	escapedEnvRegex = regexp.MustCompile(`\${({[0-9A-Za-z_.]+([:|]((\${[^}]+})|[^}])+)?})}`)
 )
 
+// envTransforms are functions that can be applied to the value of an
+// environment variable interpolation with the suffix `|<name>`.
+var envTransforms = map[string]func(v string) (string, error){
+	"upper": func(v string) (string, error) {
+		return strings.ToUpper(v), nil
+	},
+	"lower": func(v string) (string, error) {
+		return strings.ToLower(v), nil
+	},
+	"base64decode": func(v string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+}
+
+// envInterpolation is a parsed environment variable interpolation of the form
+// `${NAME:default|transform}`.
+type envInterpolation struct {
+	name       string
+	defaultVal string
+	required   bool
+	errMsg     string
+	transforms []string
+}
+
+func parseEnvInterpolation(content string) (envInterpolation, error) {
+	var e envInterpolation
+
+	nameEnd := strings.IndexAny(content, ":|")
+	if nameEnd == -1 {
+		e.name = content
+		return e, nil
+	}
+	e.name, content = content[:nameEnd], content[nameEnd:]
+
+	// Transforms are only recognised as a suffix of known names, which allows
+	// default values to contain pipe characters.
+	for {
+		i := strings.LastIndexByte(content, '|')
+		if i == -1 {
+			break
+		}
+		tName := content[i+1:]
+		if _, exists := envTransforms[tName]; !exists {
+			break
+		}
+		e.transforms = append([]string{tName}, e.transforms...)
+		content = content[:i]
+	}
+
+	switch {
+	case content == "":
+	case strings.HasPrefix(content, ":?"):
+		e.required = true
+		e.errMsg = content[2:]
+	case strings.HasPrefix(content, ":-"):
+		e.defaultVal = content[2:]
+	case strings.HasPrefix(content, ":"):
+		e.defaultVal = content[1:]
+	default:
+		return e, fmt.Errorf("environment variable %v has unrecognised transform: %v", e.name, strings.TrimPrefix(content, "|"))
+	}
+	return e, nil
+}
+
+func (e envInterpolation) resolve() (string, error) {
+	value := os.Getenv(e.name)
+	if value == "" {
+		if e.required {
+			if e.errMsg == "" {
+				return "", fmt.Errorf("required environment variable %v is not set", e.name)
+			}
+			return "", fmt.Errorf("required environment variable %v is not set: %v", e.name, e.errMsg)
+		}
+		value = e.defaultVal
+	}
+	for _, tName := range e.transforms {
+		var err error
+		if value, err = envTransforms[tName](value); err != nil {
+			return "", fmt.Errorf("environment variable %v transform %v failed: %w", e.name, tName, err)
+		}
+	}
+	return value, nil
+}
+
 // ReplaceEnvVariables will search a blob of data for the pattern `${FOO:bar}`,
 // where `FOO` is an environment variable name and `bar` is a default value. The
 // `bar` section (including the colon) can be left out if there is no
@@ -21,20 +111,27 @@ var (
 // respective environment variable will be read and will replace the pattern. If
 // the environment variable is empty or does not exist then either the default
 // value is used or the field will be left empty.
-func ReplaceEnvVariables(inBytes []byte) []byte {
+//
+// The default can also be specified with the form `${FOO:-bar}`, and the form
+// `${FOO:?message}` marks the variable as required, where an error containing
+// the message is returned when it is empty or does not exist. Transforms can be
+// appended to the pattern, e.g. `${FOO|upper}` or `${FOO:-bar|base64decode}`.
+func ReplaceEnvVariables(inBytes []byte) ([]byte, error) {
+	var errs []string
 	replaced := envRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
 		var value string
 		if len(content) > 3 {
-			if colonIndex := bytes.IndexByte(content, ':'); colonIndex == -1 {
-				value = os.Getenv(string(content[2 : len(content)-1]))
-			} else {
-				targetVar := content[2:colonIndex]
-				defaultVal := content[colonIndex+1 : len(content)-1]
-
-				value = os.Getenv(string(targetVar))
-				if value == "" {
-					value = string(defaultVal)
-				}
+			if isVarInterpolation(string(content[2 : len(content)-1])) {
+				// Variable references are resolved separately.
+				return content
+			}
+			e, err := parseEnvInterpolation(string(content[2 : len(content)-1]))
+			if err == nil {
+				value, err = e.resolve()
+			}
+			if err != nil {
+				errs = append(errs, err.Error())
+				return nil
 			}
 			// Escape newlines, otherwise there's no way that they would work
 			// within a config.
@@ -42,6 +139,14 @@ func ReplaceEnvVariables(inBytes []byte) []byte {
 		}
 		return []byte(value)
 	})
-	replaced = escapedEnvRegex.ReplaceAll(replaced, []byte("$$$1"))
-	return replaced
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, ", "))
+	}
+	replaced = escapedEnvRegex.ReplaceAllFunc(replaced, func(content []byte) []byte {
+		if isVarInterpolation(string(content[3 : len(content)-2])) {
+			return content
+		}
+		return append([]byte("$"), content[2:len(content)-1]...)
+	})
+	return replaced, nil
 }
@@ -3,6 +3,9 @@ package config
 import (
 	"os"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEnvSwapping(t *testing.T) {
@@ -36,9 +39,59 @@ func TestEnvSwapping(t *testing.T) {
 	}
 
 	for in, exp := range tests {
-		out := ReplaceEnvVariables([]byte(in))
+		out, err := ReplaceEnvVariables([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
 		if act := string(out); act != exp {
 			t.Errorf("Wrong result: %v != %v", act, exp)
 		}
 	}
 }
+
+func TestEnvSwappingDefaultsAndTransforms(t *testing.T) {
+	os.Setenv("BENTHOS_TEST_FOO", "")
+	os.Setenv("BENTHOS_TEST_BAR", "bar")
+	os.Setenv("BENTHOS_TEST_SECRET", "aGVsbG8gd29ybGQ=")
+
+	tests := map[string]string{
+		"foo ${BENTHOS_TEST_FOO:-bar} baz":                  "foo bar baz",
+		"foo ${BENTHOS_TEST_BAR:-baz} baz":                  "foo bar baz",
+		"foo ${BENTHOS_TEST_FOO:-} baz":                     "foo  baz",
+		"foo ${BENTHOS_TEST_FOO:-a|b} baz":                  "foo a|b baz",
+		"foo ${BENTHOS_TEST_BAR:?must be set} baz":          "foo bar baz",
+		"foo ${BENTHOS_TEST_BAR|upper} baz":                 "foo BAR baz",
+		"foo ${BENTHOS_TEST_FOO:-NOPE|lower} baz":           "foo nope baz",
+		"foo ${BENTHOS_TEST_SECRET|base64decode} baz":       "foo hello world baz",
+		"foo ${BENTHOS_TEST_SECRET|base64decode|upper} baz": "foo HELLO WORLD baz",
+		"foo ${{BENTHOS_TEST_BAR|upper}} baz":               "foo ${BENTHOS_TEST_BAR|upper} baz",
+	}
+
+	for in, exp := range tests {
+		out, err := ReplaceEnvVariables([]byte(in))
+		require.NoError(t, err, in)
+		assert.Equal(t, exp, string(out), in)
+	}
+}
+
+func TestEnvSwappingErrors(t *testing.T) {
+	os.Setenv("BENTHOS_TEST_FOO", "")
+	os.Setenv("BENTHOS_TEST_BAR", "not base64")
+
+	tests := map[string]string{
+		"foo ${BENTHOS_TEST_FOO:?} baz":                            "required environment variable BENTHOS_TEST_FOO is not set",
+		"foo ${BENTHOS_TEST_FOO:?the foo address is required} baz": "required environment variable BENTHOS_TEST_FOO is not set: the foo address is required",
+		"foo ${BENTHOS_TEST_BAR|nope} baz":                         "environment variable BENTHOS_TEST_BAR has unrecognised transform: nope",
+		"foo ${BENTHOS_TEST_FOO:?a} ${BENTHOS_TEST_FOO:?b} baz":    "required environment variable BENTHOS_TEST_FOO is not set: a, required environment variable BENTHOS_TEST_FOO is not set: b",
+	}
+
+	for in, exp := range tests {
+		_, err := ReplaceEnvVariables([]byte(in))
+		require.Error(t, err, in)
+		assert.Equal(t, exp, err.Error(), in)
+	}
+
+	_, err := ReplaceEnvVariables([]byte("foo ${BENTHOS_TEST_BAR|base64decode} baz"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "environment variable BENTHOS_TEST_BAR transform base64decode failed")
+}
@@ -55,10 +55,14 @@ func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
 }
 
 // ReadFileEnvSwap reads a file and replaces any environment variable
-// interpolations before returning the contents. Linting errors are returned if
-// the file has an unexpected higher level format, such as invalid utf-8
-// encoding.
+// interpolations and references to config variables before returning the
+// contents. Linting errors are returned if the file has an unexpected higher
+// level format, such as invalid utf-8 encoding.
 func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error) {
+	return readFileEnvSwap(path, nil)
+}
+
+func readFileEnvSwap(path string, varOverrides []string) (configBytes []byte, lints []string, err error) {
 	configBytes, err = os.ReadFile(path)
 	if err != nil {
 		return nil, nil, err
@@ -68,6 +72,11 @@ func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error
 		lints = append(lints, "Detected invalid utf-8 encoding in config, this may result in interpolation functions not working as expected")
 	}
 
-	configBytes = ReplaceEnvVariables(configBytes)
+	if configBytes, err = ReplaceEnvVariables(configBytes); err != nil {
+		return nil, nil, err
+	}
+	if configBytes, err = ReplaceVarReferences(configBytes, varOverrides...); err != nil {
+		return nil, nil, err
+	}
 	return configBytes, lints, nil
 }
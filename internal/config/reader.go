@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -26,6 +27,7 @@ const (
 
 type configFileInfo struct {
 	updatedAt time.Time
+	checksum  [sha256.Size]byte
 }
 
 type streamFileInfo struct {
@@ -46,6 +48,7 @@ type Reader struct {
 	resourcePaths []string
 	streamsPaths  []string
 	overrides     []string
+	varOverrides  []string
 
 	// Controls whether the main config should include input, output, etc.
 	streamsMode bool
@@ -66,6 +69,10 @@ type Reader struct {
 	streamUpdateFn StreamUpdateFunc
 	watcher        *fsnotify.Watcher
 
+	// Prevents manual reloads from being applied at the same time as changes
+	// detected by the file watcher.
+	reloadMut sync.Mutex
+
 	changeFlushPeriod time.Duration
 	changeDelayPeriod time.Duration
 }
@@ -109,6 +116,15 @@ func OptAddOverrides(overrides ...string) OptFunc {
 	}
 }
 
+// OptAddVarOverrides adds one or more expressions of the form `name=value` to
+// the config reader, which override the values of variables declared within
+// the configs being read.
+func OptAddVarOverrides(overrides ...string) OptFunc {
+	return func(r *Reader) {
+		r.varOverrides = append(r.varOverrides, overrides...)
+	}
+}
+
 // OptSetStreamPaths marks this config reader as operating in streams mode, and
 // adds a list of paths to obtain individual stream configs from.
 func OptSetStreamPaths(streamsPaths ...string) OptFunc {
@@ -122,9 +138,11 @@ func OptSetStreamPaths(streamsPaths ...string) OptFunc {
 
 // Read a Benthos config from the files and options specified.
 func (r *Reader) Read(conf *Type) (lints []string, err error) {
-	if lints, err = r.readMain(conf); err != nil {
+	var mainInfo configFileInfo
+	if mainInfo, lints, err = r.readMain(conf); err != nil {
 		return
 	}
+	r.configFileInfo = mainInfo
 	var rLints []string
 	if rLints, err = r.readResources(&conf.ResourceConfig); err != nil {
 		return
@@ -230,6 +248,7 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 						continue
 					}
 					var succeeded bool
+					r.reloadMut.Lock()
 					if nameClean == filepath.Clean(r.mainPath) {
 						succeeded = r.reactMainUpdate(mgr, strict)
 					} else if _, exists := r.streamFileInfo[nameClean]; exists {
@@ -237,6 +256,7 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 					} else {
 						succeeded = r.reactResourceUpdate(mgr, strict, nameClean)
 					}
+					r.reloadMut.Unlock()
 					if succeeded {
 						delete(collapsedChanges, nameClean)
 					} else {
@@ -313,7 +333,7 @@ func applyOverrides(specs docs.FieldSpecs, root *yaml.Node, overrides ...string)
 	return nil
 }
 
-func (r *Reader) readMain(conf *Type) (lints []string, err error) {
+func (r *Reader) readMain(conf *Type) (info configFileInfo, lints []string, err error) {
 	defer func() {
 		if err != nil && r.mainPath != "" {
 			err = fmt.Errorf("%v: %w", r.mainPath, err)
@@ -327,12 +347,13 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 	var rawNode yaml.Node
 	var confBytes []byte
 	if r.mainPath != "" {
-		if confBytes, lints, err = ReadFileEnvSwap(r.mainPath); err != nil {
+		if confBytes, lints, err = readFileEnvSwap(r.mainPath, r.varOverrides); err != nil {
 			return
 		}
 		if err = yaml.Unmarshal(confBytes, &rawNode); err != nil {
 			return
 		}
+		info.checksum = sha256.Sum256(confBytes)
 	}
 
 	// This is an unlikely race condition as the file could've been updated
@@ -340,7 +361,7 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 	// ReadWithJSONPointersLinted in order to pull the file info out, and since
 	// it's going to be removed in V4 I'm just going with the simpler option for
 	// now (ignoring the issue).
-	r.configFileInfo.updatedAt = time.Now()
+	info.updatedAt = time.Now()
 
 	confSpec := Spec()
 	if r.streamsMode {
@@ -362,7 +383,10 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 		}
 	}
 
-	err = rawNode.Decode(conf)
+	if err = rawNode.Decode(conf); err != nil {
+		return
+	}
+	err = applyVarOverrides(conf.Vars, r.varOverrides)
 	return
 }
 
@@ -374,7 +398,7 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 	mgr.Logger().Infoln("Main config updated, attempting to update pipeline.")
 
 	conf := New()
-	lints, err := r.readMain(&conf)
+	info, lints, err := r.readMain(&conf)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated config: %v", err)
 
@@ -398,5 +422,9 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 		return false
 	}
 
-	return r.mainUpdateFn(conf.Config)
+	if !r.mainUpdateFn(conf.Config) {
+		return false
+	}
+	r.configFileInfo = info
+	return true
 }
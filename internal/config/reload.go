@@ -0,0 +1,132 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+)
+
+// Checksum returns a hex encoded SHA-256 hash of the main config and resource
+// files as they were when last successfully applied, along with any overrides.
+// The checksum therefore changes only once a change to the config has taken
+// effect.
+func (r *Reader) Checksum() string {
+	r.reloadMut.Lock()
+	defer r.reloadMut.Unlock()
+
+	h := sha256.New()
+	if r.mainPath != "" {
+		fmt.Fprintf(h, "%v\x00%x\n", filepath.Clean(r.mainPath), r.configFileInfo.checksum)
+	}
+
+	r.resourceFileInfoMut.Lock()
+	resPaths := make([]string, 0, len(r.resourceFileInfo))
+	for path := range r.resourceFileInfo {
+		resPaths = append(resPaths, path)
+	}
+	sort.Strings(resPaths)
+	for _, path := range resPaths {
+		fmt.Fprintf(h, "%v\x00%x\n", path, r.resourceFileInfo[path].checksum)
+	}
+	r.resourceFileInfoMut.Unlock()
+
+	for _, o := range r.overrides {
+		fmt.Fprintf(h, "override\x00%v\n", o)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Reload re-reads the main config and resource files and applies those that
+// have changed since they were last applied. Changed resources are replaced
+// through the provided manager, and a change to the main config results in the
+// closure registered with SubscribeConfigChanges being called.
+//
+// If any file fails to be read, or has linting errors whilst strict, then no
+// changes are applied. The paths of the files that were applied are returned.
+func (r *Reader) Reload(mgr bundle.NewManagement, strict bool) (applied []string, err error) {
+	if r.streamsMode {
+		return nil, errors.New("reloading the config is not supported in streams mode")
+	}
+
+	r.reloadMut.Lock()
+	defer r.reloadMut.Unlock()
+
+	conf := New()
+	mainInfo, lints, err := r.readMain(&conf)
+	if err != nil {
+		return nil, err
+	}
+
+	resourcesPaths, err := ifilepath.Globs(r.resourcePaths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve resource glob pattern: %w", err)
+	}
+
+	type changedResources struct {
+		path string
+		info resourceFileInfo
+	}
+	var changedRes []changedResources
+
+	for _, path := range resourcesPaths {
+		path = filepath.Clean(path)
+
+		rConf := manager.NewResourceConfig()
+		rFileInfo, rLints, err := readResource(path, &rConf)
+		if err != nil {
+			return nil, err
+		}
+		lints = append(lints, rLints...)
+
+		r.resourceFileInfoMut.Lock()
+		prevInfo, exists := r.resourceFileInfo[path]
+		r.resourceFileInfoMut.Unlock()
+		if exists && prevInfo.checksum == rFileInfo.checksum {
+			continue
+		}
+
+		resInfo := resInfoFromConfig(&rConf)
+		resInfo.checksum = rFileInfo.checksum
+		changedRes = append(changedRes, changedResources{path: path, info: resInfo})
+	}
+
+	for _, lint := range lints {
+		mgr.Logger().Infoln(lint)
+	}
+	if strict && len(lints) > 0 {
+		return nil, fmt.Errorf("rejecting config due to linter errors, to allow linting errors run Benthos with --chilled: %v", strings.Join(lints, ", "))
+	}
+
+	for _, res := range changedRes {
+		if !res.info.applyChanges(mgr) {
+			return applied, fmt.Errorf("failed to apply resources from %v", res.path)
+		}
+		r.resourceFileInfoMut.Lock()
+		r.resourceFileInfo[res.path] = res.info
+		r.resourceFileInfoMut.Unlock()
+		applied = append(applied, res.path)
+	}
+
+	if r.mainPath == "" || mainInfo.checksum == r.configFileInfo.checksum {
+		return applied, nil
+	}
+
+	mgr.Logger().Infoln("Main config changed, attempting to update pipeline.")
+	if newInfo := resInfoFromConfig(&conf.ResourceConfig); !newInfo.applyChanges(mgr) {
+		return applied, fmt.Errorf("failed to apply resources from %v", r.mainPath)
+	}
+	if r.mainUpdateFn != nil && !r.mainUpdateFn(conf.Config) {
+		return applied, fmt.Errorf("failed to replace stream from %v", r.mainPath)
+	}
+	r.configFileInfo = mainInfo
+	applied = append(applied, r.mainPath)
+	return applied, nil
+}
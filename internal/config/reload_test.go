@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+func TestReaderReload(t *testing.T) {
+	confDir := t.TempDir()
+
+	mainPath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "foo"'
+output:
+  drop: {}
+`), 0o644))
+
+	resPath := filepath.Join(confDir, "res.yaml")
+	require.NoError(t, os.WriteFile(resPath, []byte(`
+cache_resources:
+  - label: foocache
+    memory: {}
+`), 0o644))
+
+	rdr := NewReader(mainPath, []string{resPath})
+
+	conf := New()
+	lints, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	require.Empty(t, lints)
+
+	var updates []stream.Config
+	updateSucceeds := true
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf stream.Config) bool {
+		if !updateSucceeds {
+			return false
+		}
+		updates = append(updates, conf)
+		return true
+	}))
+
+	testMgr, err := manager.NewV2(conf.ResourceConfig, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	initSum := rdr.Checksum()
+	assert.Len(t, initSum, 64)
+
+	// Nothing has changed
+	applied, err := rdr.Reload(testMgr, true)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Empty(t, updates)
+	assert.Equal(t, initSum, rdr.Checksum())
+
+	// Only the resources have changed
+	require.NoError(t, os.WriteFile(resPath, []byte(`
+cache_resources:
+  - label: barcache
+    memory: {}
+`), 0o644))
+
+	applied, err = rdr.Reload(testMgr, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{resPath}, applied)
+	assert.Empty(t, updates)
+	assert.True(t, testMgr.ProbeCache("barcache"))
+
+	resSum := rdr.Checksum()
+	assert.NotEqual(t, initSum, resSum)
+
+	// Linting errors are rejected
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "bar"'
+    nope: true
+output:
+  drop: {}
+`), 0o644))
+
+	_, err = rdr.Reload(testMgr, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "linter errors")
+	assert.Empty(t, updates)
+	assert.Equal(t, resSum, rdr.Checksum())
+
+	// Failed stream updates are not reflected in the checksum
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "bar"'
+output:
+  drop: {}
+`), 0o644))
+
+	updateSucceeds = false
+	_, err = rdr.Reload(testMgr, true)
+	require.Error(t, err)
+	assert.Equal(t, resSum, rdr.Checksum())
+
+	updateSucceeds = true
+	applied, err = rdr.Reload(testMgr, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{mainPath}, applied)
+	require.Len(t, updates, 1)
+	assert.Equal(t, `root = "bar"`, updates[0].Input.Generate.Mapping)
+	assert.NotEqual(t, resSum, rdr.Checksum())
+}
+
+func TestReaderReloadStreamsMode(t *testing.T) {
+	rdr := NewReader("", nil, OptSetStreamPaths(t.TempDir()))
+
+	testMgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, err = rdr.Reload(testMgr, true)
+	require.EqualError(t, err, "reloading the config is not supported in streams mode")
+}
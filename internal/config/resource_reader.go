@@ -3,6 +3,7 @@ package config
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -71,8 +72,9 @@ func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, er
 	}
 	for _, path := range resourcesPaths {
 		rconf := manager.NewResourceConfig()
+		var rInfo configFileInfo
 		var rLints []string
-		if rLints, err = readResource(path, &rconf); err != nil {
+		if rInfo, rLints, err = readResource(path, &rconf); err != nil {
 			return
 		}
 		lints = append(lints, rLints...)
@@ -81,12 +83,14 @@ func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, er
 			err = fmt.Errorf("%v: %w", path, err)
 			return
 		}
-		r.resourceFileInfo[filepath.Clean(path)] = resInfoFromConfig(&rconf)
+		resInfo := resInfoFromConfig(&rconf)
+		resInfo.checksum = rInfo.checksum
+		r.resourceFileInfo[filepath.Clean(path)] = resInfo
 	}
 	return
 }
 
-func readResource(path string, conf *manager.ResourceConfig) (lints []string, err error) {
+func readResource(path string, conf *manager.ResourceConfig) (info configFileInfo, lints []string, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("%v: %w", path, err)
@@ -98,6 +102,9 @@ func readResource(path string, conf *manager.ResourceConfig) (lints []string, er
 		return
 	}
 
+	info.checksum = sha256.Sum256(confBytes)
+	info.updatedAt = time.Now()
+
 	var rawNode yaml.Node
 	if err = yaml.Unmarshal(confBytes, &rawNode); err != nil {
 		return
@@ -127,7 +134,7 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	mgr.Logger().Infof("Resource %v config updated, attempting to update resources.", path)
 
 	newResConf := manager.NewResourceConfig()
-	lints, err := readResource(path, &newResConf)
+	info, lints, err := readResource(path, &newResConf)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated resources config: %v", err)
 		return true
@@ -147,6 +154,7 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	// resources where the config hasn't changed.
 
 	newInfo := resInfoFromConfig(&newResConf)
+	newInfo.checksum = info.checksum
 	if !newInfo.applyChanges(mgr) {
 		return false
 	}
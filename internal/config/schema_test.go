@@ -253,6 +253,9 @@ func walkTypeWithConfig(t *testing.T, prefix string, spec docs.FieldSpec, v refl
 		eleSpec.Kind = docs.KindScalar
 		walkTypeWithConfig(t, prefix+"<>", eleSpec, v.Elem())
 	} else if len(spec.Children) > 0 {
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
 		fieldByYAMLTag := getFieldsByYAMLTag(v)
 		for _, child := range spec.Children {
 			field, ok := fieldByYAMLTag[child.Name]
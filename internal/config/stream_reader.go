@@ -42,10 +42,14 @@ func InferStreamID(dir, path string) (string, error) {
 
 // ReadStreamFile attempts to read a stream config and returns the result
 func ReadStreamFile(path string) (conf stream.Config, lints []string, err error) {
+	return readStreamConfig(path, nil)
+}
+
+func readStreamConfig(path string, varOverrides []string) (conf stream.Config, lints []string, err error) {
 	conf = stream.NewConfig()
 
 	var confBytes []byte
-	if confBytes, lints, err = ReadFileEnvSwap(path); err != nil {
+	if confBytes, lints, err = readFileEnvSwap(path, varOverrides); err != nil {
 		return
 	}
 
@@ -63,7 +67,10 @@ func ReadStreamFile(path string) (conf stream.Config, lints []string, err error)
 		}
 	}
 
-	err = rawNode.Decode(&conf)
+	if err = rawNode.Decode(&conf); err != nil {
+		return
+	}
+	err = applyVarOverrides(conf.Vars, varOverrides)
 	return
 }
 
@@ -82,7 +89,7 @@ func (r *Reader) readStreamFile(dir, path string, confs map[string]stream.Config
 		return nil, fmt.Errorf("stream id (%v) collision from file: %v", id, path)
 	}
 
-	conf, lints, err := ReadStreamFile(path)
+	conf, lints, err := readStreamConfig(path, r.varOverrides)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +162,7 @@ func (r *Reader) reactStreamUpdate(mgr bundle.NewManagement, strict bool, path s
 
 	mgr.Logger().Infof("Stream %v config updated, attempting to update stream.", info.id)
 
-	conf, lints, err := ReadStreamFile(path)
+	conf, lints, err := readStreamConfig(path, r.varOverrides)
 	if err != nil {
 		mgr.Logger().Errorf("Failed to read updated stream config: %v", err)
 		return true
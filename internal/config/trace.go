@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Jeffail/gabs/v2"
+	"gopkg.in/yaml.v3"
+
+	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+// rawSource is a node of a config file as written, before environment
+// variables are resolved, along with the path of the file.
+type rawSource struct {
+	node *yaml.Node
+	path string
+}
+
+func readRawSource(path string) (*yaml.Node, error) {
+	confBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(confBytes, &node); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0], nil
+	}
+	return &node, nil
+}
+
+func envVarNames(value string) []string {
+	var names []string
+	for _, match := range envRegex.FindAllString(value, -1) {
+		content := match[2 : len(match)-1]
+		if i := strings.IndexAny(content, ":|"); i != -1 {
+			content = content[:i]
+		}
+		names = append(names, content)
+	}
+	return names
+}
+
+// describeScalar returns the source of a scalar value written within a file.
+func describeScalar(raw rawSource) string {
+	names := envVarNames(raw.node.Value)
+	if len(names) == 0 {
+		return "file " + raw.path
+	}
+	var unset []string
+	for _, n := range names {
+		if os.Getenv(n) == "" {
+			unset = append(unset, n)
+		}
+	}
+	desc := "env " + strings.Join(names, ", ")
+	if len(unset) > 0 {
+		desc += " (unset: " + strings.Join(unset, ", ") + ")"
+	}
+	return desc + " in file " + raw.path
+}
+
+type sourceAnnotator struct {
+	overrides [][]string
+}
+
+func (a *sourceAnnotator) overridden(path []string) bool {
+	for _, o := range a.overrides {
+		if len(o) > len(path) {
+			continue
+		}
+		matched := true
+		for i, seg := range o {
+			if path[i] != seg {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// annotate walks a rendered config node and adds a line comment to each scalar
+// describing where its value came from. The raw sources are the nodes at the
+// same path within the files read, and inherited is set when the source of an
+// entire subtree is already known.
+func (a *sourceAnnotator) annotate(node *yaml.Node, raws []rawSource, path []string, inherited string) {
+	if strings.HasPrefix(node.HeadComment, template.ExpandedHeadCommentPrefix) && inherited == "" {
+		inherited = "template " + strings.TrimPrefix(node.HeadComment, template.ExpandedHeadCommentPrefix)
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		switch {
+		case a.overridden(path):
+			node.LineComment = "set"
+		case inherited != "":
+			node.LineComment = inherited
+		case len(raws) > 0 && raws[0].node.Kind == yaml.ScalarNode:
+			node.LineComment = describeScalar(raws[0])
+		default:
+			node.LineComment = "default"
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content)-1; i += 2 {
+			key := node.Content[i].Value
+			var childRaws []rawSource
+			for _, r := range raws {
+				if r.node.Kind != yaml.MappingNode {
+					continue
+				}
+				for j := 0; j < len(r.node.Content)-1; j += 2 {
+					if r.node.Content[j].Value == key {
+						childRaws = append(childRaws, rawSource{node: r.node.Content[j+1], path: r.path})
+						break
+					}
+				}
+			}
+			a.annotate(node.Content[i+1], childRaws, append(path, key), inherited)
+		}
+	case yaml.SequenceNode:
+		// Sequences from multiple files are concatenated in the order that the
+		// files were read, as is the case with resources.
+		var items []rawSource
+		for _, r := range raws {
+			if r.node.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, item := range r.node.Content {
+				items = append(items, rawSource{node: item, path: r.path})
+			}
+		}
+		for i, child := range node.Content {
+			var childRaws []rawSource
+			if i < len(items) {
+				childRaws = []rawSource{items[i]}
+			}
+			a.annotate(child, childRaws, append(path, fmt.Sprintf("%v", i)), inherited)
+		}
+	}
+}
+
+// AnnotateSources adds a line comment to each scalar value of a rendered config
+// node describing where the value came from, which is one of a config or
+// resource file (`file`), an environment variable (`env`), a `--set` override
+// (`set`), the expansion of a template (`template`) or a `default`.
+func (r *Reader) AnnotateSources(node *yaml.Node) error {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	var raws []rawSource
+	if r.mainPath != "" {
+		rawNode, err := readRawSource(r.mainPath)
+		if err != nil {
+			return err
+		}
+		raws = append(raws, rawSource{node: rawNode, path: r.mainPath})
+	}
+
+	resourcesPaths, err := ifilepath.Globs(r.resourcePaths)
+	if err != nil {
+		return fmt.Errorf("failed to resolve resource glob pattern: %w", err)
+	}
+	for _, path := range resourcesPaths {
+		rawNode, err := readRawSource(path)
+		if err != nil {
+			return err
+		}
+		raws = append(raws, rawSource{node: rawNode, path: path})
+	}
+
+	a := &sourceAnnotator{}
+	for _, o := range r.overrides {
+		if eqIndex := strings.Index(o, "="); eqIndex > 0 {
+			a.overrides = append(a.overrides, gabs.DotPathToSlice(o[:eqIndex]))
+		}
+	}
+	a.annotate(node, raws, nil, "")
+	return nil
+}
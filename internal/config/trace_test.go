@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+func TestReaderAnnotateSources(t *testing.T) {
+	dir := t.TempDir()
+
+	mainPath := filepath.Join(dir, "main.yaml")
+	require.NoError(t, os.WriteFile(mainPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "hello"'
+    interval: ${BENTHOS_TEST_TRACE_INTERVAL}
+output:
+  drop: {}
+`), 0o644))
+
+	resPath := filepath.Join(dir, "res.yaml")
+	require.NoError(t, os.WriteFile(resPath, []byte(`
+cache_resources:
+  - label: foo
+    memory: {}
+`), 0o644))
+
+	os.Setenv("BENTHOS_TEST_TRACE_INTERVAL", "5s")
+
+	rdr := NewReader(mainPath, []string{resPath}, OptAddOverrides("input.generate.count=3"))
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	var node yaml.Node
+	require.NoError(t, node.Encode(conf))
+	require.NoError(t, Spec().SanitiseYAML(&node, docs.SanitiseConfig{
+		RemoveTypeField: true,
+	}))
+	require.NoError(t, rdr.AnnotateSources(&node))
+
+	comments := map[string]string{}
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.ScalarNode:
+			comments[path] = n.LineComment
+		case yaml.MappingNode:
+			for i := 0; i < len(n.Content)-1; i += 2 {
+				walk(n.Content[i+1], path+"."+n.Content[i].Value)
+			}
+		case yaml.SequenceNode:
+			for _, c := range n.Content {
+				walk(c, path+".-")
+			}
+		}
+	}
+	walk(&node, "")
+
+	assert.Equal(t, "file "+mainPath, comments[".input.generate.mapping"])
+	assert.Equal(t, "env BENTHOS_TEST_TRACE_INTERVAL in file "+mainPath, comments[".input.generate.interval"])
+	assert.Equal(t, "set", comments[".input.generate.count"])
+	assert.Equal(t, "default", comments[".http.address"])
+	assert.Equal(t, "file "+resPath, comments[".cache_resources.-.label"])
+}
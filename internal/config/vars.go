@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+var varRegex = regexp.MustCompile(`\${{vars\.[0-9A-Za-z_]+}}|\${vars\.[0-9A-Za-z_]+}`)
+
+func isVarInterpolation(name string) bool {
+	return strings.HasPrefix(name, "vars.")
+}
+
+func parseVarOverrides(overrides []string) (map[string]string, error) {
+	values := make(map[string]string, len(overrides))
+	for _, o := range overrides {
+		eqIndex := strings.Index(o, "=")
+		if eqIndex <= 0 {
+			return nil, fmt.Errorf("invalid var expression '%v': expected foo=bar syntax", o)
+		}
+		values[o[:eqIndex]] = o[eqIndex+1:]
+	}
+	return values, nil
+}
+
+// applyVarOverrides sets the values of variables from override expressions of
+// the form `name=value`. Overrides of variables that are not declared are
+// ignored, as the same overrides are applied to all configs being read.
+func applyVarOverrides(vars map[string]stream.VarConfig, overrides []string) error {
+	values, err := parseVarOverrides(overrides)
+	if err != nil {
+		return err
+	}
+	for k, v := range values {
+		if conf, exists := vars[k]; exists {
+			conf.Default = v
+			vars[k] = conf
+		}
+	}
+	return nil
+}
+
+// ReplaceVarReferences resolves the variables declared within the `vars`
+// section of a config, with optional override expressions of the form
+// `name=value`, and replaces any references to them of the form
+// `${vars.name}`. A reference can be escaped with the form `${{vars.name}}`.
+func ReplaceVarReferences(inBytes []byte, overrides ...string) ([]byte, error) {
+	if !bytes.Contains(inBytes, []byte("${vars.")) && !bytes.Contains(inBytes, []byte("${{vars.")) {
+		return inBytes, nil
+	}
+
+	var conf struct {
+		Vars map[string]stream.VarConfig `yaml:"vars"`
+	}
+	if err := yaml.Unmarshal(inBytes, &conf); err != nil {
+		// Errors are reported when the config is parsed in full.
+		return inBytes, nil
+	}
+	if err := applyVarOverrides(conf.Vars, overrides); err != nil {
+		return nil, err
+	}
+
+	var errs []string
+	failed := map[string]struct{}{}
+	values := map[string]interface{}{}
+	replaced := varRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
+		if bytes.HasPrefix(content, []byte("${{")) {
+			return append([]byte("$"), content[2:len(content)-1]...)
+		}
+		name := strings.TrimSuffix(string(content[len("${vars."):]), "}")
+
+		if _, exists := failed[name]; exists {
+			return nil
+		}
+		v, exists := values[name]
+		if !exists {
+			vConf, declared := conf.Vars[name]
+			if !declared {
+				failed[name] = struct{}{}
+				errs = append(errs, fmt.Sprintf("variable %v is not declared", name))
+				return nil
+			}
+			var err error
+			if v, err = vConf.Resolve(); err != nil {
+				failed[name] = struct{}{}
+				errs = append(errs, fmt.Sprintf("variable %v: %v", name, err))
+				return nil
+			}
+			values[name] = v
+		}
+		return []byte(strings.ReplaceAll(fmt.Sprintf("%v", v), "\n", "\\n"))
+	})
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, errors.New(strings.Join(errs, ", "))
+	}
+	return replaced, nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarReferences(t *testing.T) {
+	conf := `
+vars:
+  region:
+    default: eu-west-1
+  threads:
+    type: int
+    default: 4
+  enabled:
+    type: bool
+    default: true
+`
+
+	tests := map[string]struct {
+		input     string
+		overrides []string
+		output    string
+		err       string
+	}{
+		"no references": {
+			input:  "foo: bar",
+			output: "foo: bar",
+		},
+		"basic references": {
+			input:  "a: ${vars.region}\nb: ${vars.threads}\nc: ${vars.enabled}\nd: ${vars.region}-${vars.threads}",
+			output: "a: eu-west-1\nb: 4\nc: true\nd: eu-west-1-4",
+		},
+		"overrides": {
+			input:     "a: ${vars.region}\nb: ${vars.threads}",
+			overrides: []string{"region=us-east-1", "threads=8", "undeclared=nope"},
+			output:    "a: us-east-1\nb: 8",
+		},
+		"escaped references": {
+			input:  "a: ${{vars.region}}",
+			output: "a: ${vars.region}",
+		},
+		"undeclared reference": {
+			input: "a: ${vars.nope}\nb: ${vars.nope}",
+			err:   "variable nope is not declared",
+		},
+		"bad override type": {
+			input:     "a: ${vars.threads}",
+			overrides: []string{"threads=many"},
+			err:       "variable threads: failed to parse 'many' as int",
+		},
+		"bad override expression": {
+			input:     "a: ${vars.threads}",
+			overrides: []string{"threads"},
+			err:       "invalid var expression 'threads': expected foo=bar syntax",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			input := conf + test.input
+			out, err := ReplaceVarReferences([]byte(input), test.overrides...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, conf+test.output, string(out))
+		})
+	}
+}
+
+func TestVarReferencesRequired(t *testing.T) {
+	conf := []byte(`
+vars:
+  region:
+    description: The region to deploy to.
+foo: ${vars.region}
+`)
+
+	_, err := ReplaceVarReferences(conf)
+	require.EqualError(t, err, "variable region: a value of type string is required")
+
+	out, err := ReplaceVarReferences(conf, "region=us-east-1")
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "foo: us-east-1")
+}
+
+func TestVarReferencesEnvInterpolation(t *testing.T) {
+	os.Setenv("BENTHOS_TEST_VARS_REGION", "ap-south-1")
+	defer os.Unsetenv("BENTHOS_TEST_VARS_REGION")
+
+	out, err := ReplaceEnvVariables([]byte(`
+vars:
+  region:
+    default: ${BENTHOS_TEST_VARS_REGION:eu-west-1}
+foo: ${vars.region}
+bar: ${{vars.region}}
+`))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "default: ap-south-1")
+	assert.Contains(t, string(out), "foo: ${vars.region}")
+	assert.Contains(t, string(out), "bar: ${{vars.region}}")
+
+	out, err = ReplaceVarReferences(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "foo: ap-south-1")
+	assert.Contains(t, string(out), "bar: ${vars.region}")
+}
+
+func TestReaderVarOverrides(t *testing.T) {
+	confFilePath := filepath.Join(t.TempDir(), "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+vars:
+  threads:
+    type: int
+    default: 1
+pipeline:
+  threads: ${vars.threads}
+`), 0o644))
+
+	rdr := NewReader(confFilePath, nil, OptAddVarOverrides("threads=3"))
+
+	conf := New()
+	lints, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	assert.Equal(t, 3, conf.Pipeline.Threads)
+	assert.Equal(t, "3", conf.Vars["threads"].Default)
+}
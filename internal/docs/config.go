@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
+	"gopkg.in/yaml.v3"
 )
 
 const labelExpression = `^[a-z0-9_]+$`
@@ -199,6 +200,16 @@ type SanitiseConfig struct {
 	ForExample       bool
 	Filter           FieldFilter
 	DocsProvider     Provider
+
+	// DocumentFields adds the summary of each component and the description
+	// of each field as comments above their keys.
+	DocumentFields bool
+
+	// ExpandComponent is an optional function called with each component node
+	// that may return an alternative node to replace it with, such as the
+	// expansion of a template. A nil node is returned when the component
+	// should not be replaced.
+	ExpandComponent func(cType Type, name string, node *yaml.Node) (*yaml.Node, error)
 }
 
 // GetDocs attempts to obtain documentation for a component implementation from
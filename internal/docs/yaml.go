@@ -2,6 +2,8 @@ package docs
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -185,6 +187,23 @@ func (f FieldSpec) shouldOmitYAML(parentFields FieldSpecs, fieldNode, parentNode
 	return f.omitWhenFn(field, parent)
 }
 
+var markdownLinkRegex = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+
+// yamlComment reduces a markdown description down to its first sentence, with
+// links replaced by their text, for use as a comment within a config.
+func yamlComment(desc string) string {
+	desc = strings.TrimSpace(desc)
+	if i := strings.Index(desc, "\n\n"); i >= 0 {
+		desc = desc[:i]
+	}
+	desc = strings.Join(strings.Fields(desc), " ")
+	desc = markdownLinkRegex.ReplaceAllString(desc, "$1")
+	if i := strings.Index(desc, ". "); i >= 0 {
+		desc = desc[:i+1]
+	}
+	return desc
+}
+
 // SanitiseYAML takes a yaml.Node and a config spec and sorts the fields of the
 // node according to the spec. Also optionally removes the `type` field from
 // this and all nested components.
@@ -226,6 +245,17 @@ func SanitiseYAML(cType Type, node *yaml.Node, conf SanitiseConfig) error {
 		}
 	}
 
+	if conf.ExpandComponent != nil {
+		expanded, err := conf.ExpandComponent(cType, name, node)
+		if err != nil {
+			return err
+		}
+		if expanded != nil {
+			*node = *unwrapDocumentNode(expanded)
+			return SanitiseYAML(cType, node, conf)
+		}
+	}
+
 	cSpec, exists := GetDocs(conf, name, cType)
 	if !exists {
 		return fmt.Errorf("failed to obtain docs for %v type %v", cType, name)
@@ -242,6 +272,9 @@ func SanitiseYAML(cType Type, node *yaml.Node, conf SanitiseConfig) error {
 		}
 
 		nameFound = true
+		if conf.DocumentFields {
+			node.Content[i].HeadComment = yamlComment(cSpec.Summary)
+		}
 		if err := cSpec.Config.SanitiseYAML(node.Content[i+1], conf); err != nil {
 			return err
 		}
@@ -256,6 +289,9 @@ func SanitiseYAML(cType Type, node *yaml.Node, conf SanitiseConfig) error {
 		if err := keyNode.Encode(name); err != nil {
 			return err
 		}
+		if conf.DocumentFields {
+			keyNode.HeadComment = yamlComment(cSpec.Summary)
+		}
 		bodyNode, err := cSpec.Config.ToYAML(conf.ForExample)
 		if err != nil {
 			return err
@@ -384,6 +420,9 @@ func (f FieldSpecs) SanitiseYAML(node *yaml.Node, conf SanitiseConfig) error {
 		if err := keyNode.Encode(field.Name); err != nil {
 			return err
 		}
+		if conf.DocumentFields {
+			keyNode.HeadComment = yamlComment(field.Description)
+		}
 		newNodes = append(newNodes, &keyNode, value)
 	}
 	node.Content = newNodes
@@ -1237,3 +1237,28 @@ processors:
 		})
 	}
 }
+
+func TestSanitiseYAMLDocumentFields(t *testing.T) {
+	spec := docs.FieldSpecs{
+		docs.FieldString("a", "The first sentence. The second sentence."),
+		docs.FieldString("b", `
+A field that wraps
+across lines with a [cache resource](/docs/components/caches/about).
+
+A second paragraph.`),
+	}
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("b: bar\na: foo\n"), &node))
+	require.NoError(t, spec.SanitiseYAML(&node, docs.SanitiseConfig{
+		DocumentFields: true,
+	}))
+
+	resBytes, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `# The first sentence.
+a: foo
+# A field that wraps across lines with a cache resource.
+b: bar
+`, string(resBytes))
+}
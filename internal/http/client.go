@@ -3,19 +3,22 @@ package http
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
-	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
@@ -26,6 +29,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/old/util/throttle"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
@@ -54,6 +58,11 @@ type Client struct {
 	metaInsertFilter  *metadata.IncludeFilter
 	metaExtractFilter *metadata.IncludeFilter
 
+	idempotencyHeader     string
+	idempotencyKey        *mapping.Executor
+	alreadyProcessedOn    map[int]struct{}
+	alreadyProcessedRegex *regexp.Regexp
+
 	conf          docs.Config
 	retryThrottle *throttle.Type
 
@@ -110,21 +119,20 @@ func NewClient(conf docs.Config, opts ...func(*Client)) (*Client, error) {
 		}
 	}
 
-	if h.conf.ProxyURL != "" {
-		proxyURL, err := url.Parse(h.conf.ProxyURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse proxy_url string: %v", err)
-		}
+	proxyConf := proxy.Config{URL: h.conf.ProxyURL, NoProxy: h.conf.NoProxy}
+	if proxyFn, err := proxyConf.Func(); err != nil {
+		return nil, fmt.Errorf("failed to parse proxy_url string: %v", err)
+	} else if proxyFn != nil {
 		if h.client.Transport != nil {
 			if tr, ok := h.client.Transport.(*http.Transport); ok {
-				tr.Proxy = http.ProxyURL(proxyURL)
+				tr.Proxy = proxyFn
 			} else {
 				return nil, fmt.Errorf("unable to apply proxy_url to transport, unexpected type %T", h.client.Transport)
 			}
 		} else {
-			h.client.Transport = &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			}
+			tr := http.DefaultTransport.(*http.Transport).Clone()
+			tr.Proxy = proxyFn
+			h.client.Transport = tr
 		}
 	}
 
@@ -229,6 +237,34 @@ func OptSetManager(mgr interop.Manager) func(*Client) {
 	}
 }
 
+// OptSetIdempotencyKey sets a mapping that is executed once for each message
+// sent, where the result is added to all attempts of the request as the value
+// of the given header.
+func OptSetIdempotencyKey(header string, key *mapping.Executor) func(*Client) {
+	return func(t *Client) {
+		t.idempotencyHeader = header
+		t.idempotencyKey = key
+	}
+}
+
+// OptSetAlreadyProcessed sets the status codes and response body pattern that
+// indicate a failed request has already been processed by the server, in
+// which case it is considered successful. A nil pattern matches any body, and
+// an empty slice of codes matches any status code, but at least one must be
+// set.
+func OptSetAlreadyProcessed(codes []int, bodyPattern *regexp.Regexp) func(*Client) {
+	return func(t *Client) {
+		if len(codes) == 0 && bodyPattern == nil {
+			return
+		}
+		t.alreadyProcessedOn = map[int]struct{}{}
+		for _, c := range codes {
+			t.alreadyProcessedOn[c] = struct{}{}
+		}
+		t.alreadyProcessedRegex = bodyPattern
+	}
+}
+
 // OptSetRoundTripper sets the *client.Transport to use for HTTP requests.
 // NOTE: This setting will override any configured TLS options.
 func OptSetRoundTripper(rt http.RoundTripper) func(*Client) {
@@ -293,6 +329,10 @@ func (h *Client) waitForAccess(ctx context.Context) bool {
 // CreateRequest forms an *http.Request from a message to be sent as the body,
 // and also a message used to form headers (they can be the same).
 func (h *Client) CreateRequest(sendMsg, refMsg *message.Batch) (req *http.Request, err error) {
+	return h.createRequest(sendMsg, refMsg, "")
+}
+
+func (h *Client) createRequest(sendMsg, refMsg *message.Batch, idempotencyKey string) (req *http.Request, err error) {
 	var overrideContentType string
 	var body io.Reader
 	if len(h.multipart) > 0 {
@@ -365,6 +405,10 @@ func (h *Client) CreateRequest(sendMsg, refMsg *message.Batch) (req *http.Reques
 		})
 	}
 
+	if idempotencyKey != "" {
+		req.Header.Set(h.idempotencyHeader, idempotencyKey)
+	}
+
 	if h.host != nil {
 		req.Host = h.host.String(0, refMsg)
 	}
@@ -486,6 +530,80 @@ func (h *Client) checkStatus(code int) (succeeded bool, retStrat retryStrategy)
 	return true, noRetry
 }
 
+// resolveIdempotencyKey executes the idempotency key mapping against a
+// message, returning an empty string when a key is not configured.
+func (h *Client) resolveIdempotencyKey(refMsg *message.Batch) (string, error) {
+	if h.idempotencyKey == nil || refMsg == nil || refMsg.Len() == 0 {
+		return "", nil
+	}
+	v, err := h.idempotencyKey.Exec(query.FunctionContext{
+		Maps:     h.idempotencyKey.Maps(),
+		Vars:     map[string]interface{}{},
+		MsgBatch: refMsg,
+	}.WithValueFunc(func() *interface{} {
+		if jObj, err := refMsg.Get(0).JSON(); err == nil {
+			return &jObj
+		}
+		return nil
+	}))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve idempotency key: %w", err)
+	}
+
+	var key string
+	switch t := v.(type) {
+	case query.Nothing, query.Delete, nil:
+	case []byte:
+		key = string(t)
+	default:
+		key = query.IToString(t)
+	}
+	if key == "" {
+		return "", errors.New("failed to resolve idempotency key: mapping resulted in an empty key")
+	}
+	return key, nil
+}
+
+// alreadyProcessed returns whether an unsuccessful response indicates that the
+// request had already been processed by the server. When the body of the
+// response is checked it is replaced so that it can be read again.
+func (h *Client) alreadyProcessed(res *http.Response) bool {
+	if h.alreadyProcessedOn == nil {
+		return false
+	}
+	if len(h.alreadyProcessedOn) > 0 {
+		if _, exists := h.alreadyProcessedOn[res.StatusCode]; !exists {
+			return false
+		}
+	}
+	if h.alreadyProcessedRegex == nil {
+		return true
+	}
+	if res.Body == nil {
+		return h.alreadyProcessedRegex.Match(nil)
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return h.alreadyProcessedRegex.Match(body)
+}
+
+// checkResponse determines whether a response indicates that the send
+// succeeded, and if not what the retry strategy should be.
+func (h *Client) checkResponse(res *http.Response) (succeeded bool, retStrat retryStrategy) {
+	if succeeded, retStrat = h.checkStatus(res.StatusCode); succeeded {
+		return
+	}
+	if h.alreadyProcessed(res) {
+		h.log.Debugf("Treating response with status code %v as already processed\n", res.StatusCode)
+		return true, noRetry
+	}
+	return
+}
+
 // SendToResponse attempts to create an HTTP request from a provided message,
 // performs it, and then returns the *http.Response, allowing the raw response
 // to be consumed.
@@ -508,8 +626,15 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 		}
 	}
 
+	// The key is resolved once so that all attempts of the request share it.
+	idempotencyKey, err := h.resolveIdempotencyKey(refMsg)
+	if err != nil {
+		logErr(err)
+		return nil, err
+	}
+
 	var req *http.Request
-	if req, err = h.CreateRequest(sendMsg, refMsg); err != nil {
+	if req, err = h.createRequest(sendMsg, refMsg, idempotencyKey); err != nil {
 		logErr(err)
 		return nil, err
 	}
@@ -530,7 +655,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 	startedAt := time.Now()
 	if res, err = h.client.Do(req.WithContext(ctx)); err == nil {
 		h.incrCode(res.StatusCode)
-		if resolved, retryStrat := h.checkStatus(res.StatusCode); !resolved {
+		if resolved, retryStrat := h.checkResponse(res); !resolved {
 			rateLimited = retryStrat == retryBackoff
 			if retryStrat == noRetry {
 				numRetries = 0
@@ -546,7 +671,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 	i, j := 0, numRetries
 	for i < j && err != nil {
 		logErr(err)
-		if req, err = h.CreateRequest(sendMsg, refMsg); err != nil {
+		if req, err = h.createRequest(sendMsg, refMsg, idempotencyKey); err != nil {
 			continue
 		}
 		if rateLimited {
@@ -566,7 +691,7 @@ func (h *Client) SendToResponse(ctx context.Context, sendMsg, refMsg *message.Ba
 		startedAt = time.Now()
 		if res, err = h.client.Do(req.WithContext(ctx)); err == nil {
 			h.incrCode(res.StatusCode)
-			if resolved, retryStrat := h.checkStatus(res.StatusCode); !resolved {
+			if resolved, retryStrat := h.checkResponse(res); !resolved {
 				rateLimited = retryStrat == retryBackoff
 				if retryStrat == noRetry {
 					j = 0
@@ -50,6 +50,35 @@ func TestHTTPClientRetries(t *testing.T) {
 	assert.Equal(t, uint32(4), atomic.LoadUint32(&reqCount))
 }
 
+func TestHTTPClientProxy(t *testing.T) {
+	var reqCount uint32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		assert.Equal(t, "http://target.example.com/testpost", r.URL.String())
+		_, _ = w.Write([]byte("proxied"))
+	}))
+	defer proxy.Close()
+
+	conf := docs.NewConfig()
+	conf.URL = "http://target.example.com/testpost"
+	conf.ProxyURL = proxy.URL
+	conf.NoProxy = ".internal.example.com"
+
+	h, err := NewClient(conf)
+	require.NoError(t, err)
+	defer h.Close(context.Background())
+
+	out := message.QuickBatch([][]byte{[]byte("test")})
+	res, err := h.Send(context.Background(), out, out)
+	require.NoError(t, err)
+	assert.Equal(t, "proxied", string(res.Get(0).Get()))
+	assert.Equal(t, uint32(1), atomic.LoadUint32(&reqCount))
+
+	conf.ProxyURL = "ftp://nope"
+	_, err = NewClient(conf)
+	require.Error(t, err)
+}
+
 func TestHTTPClientBadRequest(t *testing.T) {
 	conf := docs.NewConfig()
 	conf.URL = "htp://notvalid:1111"
@@ -39,7 +39,8 @@ func ClientFieldSpec(forOutput bool, extraChildren ...docs.FieldSpec) docs.Field
 		docs.FieldInt("backoff_on", "A list of status codes whereby the request should be considered to have failed and retries should be attempted, but the period between them should be increased gradually.").Array().Advanced(),
 		docs.FieldInt("drop_on", "A list of status codes whereby the request should be considered to have failed but retries should not be attempted. This is useful for preventing wasted retries for requests that will never succeed. Note that with these status codes the _request_ is dropped, but _message_ that caused the request will not be dropped.").Array().Advanced(),
 		docs.FieldInt("successful_on", "A list of status codes whereby the attempt should be considered successful, this is useful for dropping requests that return non-2XX codes indicating that the message has been dealt with, such as a 303 See Other or a 409 Conflict. All 2XX codes are considered successful unless they are present within `backoff_on` or `drop_on`, regardless of this field.").Array().Advanced(),
-		docs.FieldString("proxy_url", "An optional HTTP proxy URL.").Advanced(),
+		docs.FieldString("proxy_url", "An optional URL of a proxy to route requests through, which can be an HTTP, HTTPS or SOCKS5 proxy. When empty the proxy is selected according to the environment variables `HTTP_PROXY`, `HTTPS_PROXY` and `NO_PROXY`.", "http://proxy.example.com:3128", "socks5://proxy.example.com:1080").Advanced(),
+		docs.FieldString("no_proxy", "An optional comma separated list of hosts, domains, IP addresses or CIDR ranges that should not be proxied, following the same format as the environment variable `NO_PROXY`, which it takes precedence over.", "localhost,.internal.example.com,10.0.0.0/8").Advanced().HasDefault(""),
 	)
 	httpSpecs = append(httpSpecs, extraChildren...)
 
@@ -23,6 +23,7 @@ type Config struct {
 	SuccessfulOn    []int                        `json:"successful_on" yaml:"successful_on"`
 	TLS             tls.Config                   `json:"tls" yaml:"tls"`
 	ProxyURL        string                       `json:"proxy_url" yaml:"proxy_url"`
+	NoProxy         string                       `json:"no_proxy" yaml:"no_proxy"`
 	auth.Config     `json:",inline" yaml:",inline"`
 	OAuth2          auth.OAuth2Config `json:"oauth2" yaml:"oauth2"`
 }
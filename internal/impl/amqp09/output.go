@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +13,7 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -246,8 +246,8 @@ func (a *amqp09Writer) WriteWithContext(ctx context.Context, msg *message.Batch)
 		contentEncoding := a.contentEncoding.String(i, msg)
 
 		var priority uint8
-		if priorityString := a.priority.String(i, msg); priorityString != "" {
-			priorityInt, err := strconv.Atoi(priorityString)
+		if priorityValue := a.priority.Value(i, msg); priorityValue != "" {
+			priorityInt, err := query.IToInt(priorityValue)
 			if err != nil {
 				return fmt.Errorf("failed to parse valid integer from priority expression: %w", err)
 			}
@@ -365,6 +365,8 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 				reason = " because the pipeline is shutting down"
 				if _, err := k.checkpointer.Checkpoint(context.Background(), streamID, shardID, recordBatcher.GetSequence(), true); err != nil {
 					k.log.Errorf("Failed to store final checkpoint for stream '%v' shard '%v': %v\n", streamID, shardID, err)
+				} else {
+					k.reportCheckpoint(streamID, shardID, recordBatcher.GetSequence())
 				}
 			}
 
@@ -473,12 +475,15 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 				commitCtxClose()
 				commitCtx, commitCtxClose = context.WithTimeout(k.ctx, k.commitPeriod)
 
-				stillOwned, err := k.checkpointer.Checkpoint(k.ctx, streamID, shardID, recordBatcher.GetSequence(), false)
+				sequence := recordBatcher.GetSequence()
+				stillOwned, err := k.checkpointer.Checkpoint(k.ctx, streamID, shardID, sequence, false)
 				if err != nil {
 					k.log.Errorf("Failed to store checkpoint for Kinesis stream '%v' shard '%v': %v\n", streamID, shardID, err)
 				} else if !stillOwned {
 					state = awsKinesisConsumerYielding
 					return
+				} else {
+					k.reportCheckpoint(streamID, shardID, sequence)
 				}
 			case <-nextTimedBatchChan:
 				nextTimedBatchChan = nil
@@ -497,6 +502,13 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 
 //------------------------------------------------------------------------------
 
+func (k *kinesisReader) reportCheckpoint(streamID, shardID, sequence string) {
+	if sequence == "" {
+		return
+	}
+	k.mgr.ReportCheckpoint(streamID+":"+shardID, sequence)
+}
+
 func isShardFinished(s *kinesis.Shard) bool {
 	if s.SequenceNumberRange == nil {
 		return false
@@ -24,6 +24,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+	"github.com/benthosdev/benthos/v4/internal/inventory"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
@@ -60,6 +61,12 @@ If your notification events are being routed to SQS via an SNS topic then the ev
 
 When using SQS please make sure you have sensible values for ` + "`sqs.max_messages`" + ` and also the visibility timeout of the queue itself. When Benthos consumes an S3 object the SQS message that triggered it is not deleted until the S3 object has been sent onwards. This ensures at-least-once crash resiliency, but also means that if the S3 object takes longer to process than the visibility timeout of your queue then the same objects might be processed multiple times.
 
+## Consuming Objects from an Inventory
+
+Walking a bucket containing millions of objects is slow as the objects can only be listed a page at a time. When an [S3 Inventory](https://docs.aws.amazon.com/AmazonS3/latest/userguide/storage-inventory.html) report of the bucket is available the field ` + "`inventory.manifest`" + ` can instead be set to the key of its ` + "`manifest.json`" + ` file, in which case the objects listed by the report are consumed, and are downloaded ahead of time in parallel with up to ` + "`inventory.concurrency`" + ` objects held in memory at once. The manifest is downloaded from the bucket ` + "`inventory.bucket`" + `, or ` + "`bucket`" + ` when not set, and only reports in the CSV format are supported. The field ` + "`prefix`" + ` can be used in order to filter the objects of the report.
+
+When ` + "`inventory.checkpoint_cache`" + ` is set the position of the input within the report is stored in the cache, keyed by the location of the manifest, as objects are acknowledged. When the input is restarted it resumes from the last object where it and all prior objects were acknowledged.
+
 ## Downloading Large Files
 
 When downloading large files it's often necessary to process it in streamed parts in order to avoid loading the entire file in memory at a given time. In order to do this a ` + "[`codec`](#codec)" + ` can be specified that determines how to break the input into smaller individual messages.
@@ -103,6 +110,7 @@ You can access these metadata fields using [function interpolation](/docs/config
 				).Advanced(),
 				docs.FieldInt("max_messages", "The maximum number of SQS messages to consume from each request.").Advanced(),
 			),
+			inventory.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(oinput.NewAWSS3Config()),
 		Categories: []string{
 			"Services",
@@ -123,6 +131,9 @@ type s3ObjectTarget struct {
 	bucket         string
 	notificationAt time.Time
 
+	// An optional object that has already been downloaded.
+	obj *s3.GetObjectOutput
+
 	ackFn func(context.Context, error) error
 }
 
@@ -132,7 +143,7 @@ func newS3ObjectTarget(key, bucket string, notificationAt time.Time, ackFn codec
 			return nil
 		}
 	}
-	return &s3ObjectTarget{key, bucket, notificationAt, ackFn}
+	return &s3ObjectTarget{key: key, bucket: bucket, notificationAt: notificationAt, ackFn: ackFn}
 }
 
 type s3ObjectTargetReader interface {
@@ -515,6 +526,7 @@ type awsS3Reader struct {
 	objectMut sync.Mutex
 	object    *s3PendingObject
 
+	mgr bundle.NewManagement
 	log log.Modular
 }
 
@@ -527,14 +539,26 @@ type s3PendingObject struct {
 
 // NewAmazonS3 creates a new Amazon S3 bucket reader.Type.
 func newAmazonS3Reader(conf oinput.AWSS3Config, nm bundle.NewManagement) (*awsS3Reader, error) {
-	if conf.Bucket == "" && conf.SQS.URL == "" {
-		return nil, errors.New("either a bucket or an sqs.url must be specified")
+	if conf.Bucket == "" && conf.SQS.URL == "" && !conf.Inventory.Enabled() {
+		return nil, errors.New("either a bucket, an sqs.url or an inventory.manifest must be specified")
 	}
 	if conf.Prefix != "" && conf.SQS.URL != "" {
 		return nil, errors.New("cannot specify both a prefix and sqs.url")
 	}
+	if conf.Inventory.Enabled() {
+		if conf.SQS.URL != "" {
+			return nil, errors.New("cannot specify both an inventory.manifest and sqs.url")
+		}
+		if conf.Inventory.ManifestBucket(conf.Bucket) == "" {
+			return nil, errors.New("either a bucket or an inventory.bucket must be specified in order to download the inventory manifest")
+		}
+		if c := conf.Inventory.CheckpointCache; c != "" && !nm.ProbeCache(c) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", c)
+		}
+	}
 	s := &awsS3Reader{
 		conf: conf,
+		mgr:  nm,
 		log:  nm.Logger(),
 	}
 	var err error
@@ -553,6 +577,9 @@ func (a *awsS3Reader) getTargetReader(ctx context.Context) (s3ObjectTargetReader
 	if a.sqs != nil {
 		return newSQSTargetReader(a.conf, a.log, a.s3, a.sqs), nil
 	}
+	if a.conf.Inventory.Enabled() {
+		return newInventoryTargetReader(ctx, a.conf, a.log, a.s3, a.mgr)
+	}
 	return newStaticTargetReader(ctx, a.conf, a.log, a.s3)
 }
 
@@ -581,13 +608,16 @@ func (a *awsS3Reader) ConnectWithContext(ctx context.Context) error {
 	}
 
 	if a.keyReader, err = a.getTargetReader(ctx); err != nil {
+		a.keyReader = nil
 		a.session = nil
 		a.s3 = nil
 		a.sqs = nil
 		return err
 	}
 
-	if a.conf.SQS.URL == "" {
+	if a.conf.Inventory.Enabled() {
+		a.log.Infof("Downloading S3 objects listed by inventory manifest: %s\n", a.conf.Inventory.Manifest)
+	} else if a.conf.SQS.URL == "" {
 		a.log.Infof("Downloading S3 objects from bucket: %s\n", a.conf.Bucket)
 	} else {
 		a.log.Infof("Downloading S3 objects found in messages from SQS: %s\n", a.conf.SQS.URL)
@@ -625,6 +655,9 @@ func (a *awsS3Reader) getObjectTarget(ctx context.Context) (*s3PendingObject, er
 	if a.object != nil {
 		return a.object, nil
 	}
+	if a.keyReader == nil {
+		return nil, component.ErrTypeClosed
+	}
 
 	target, err := a.keyReader.Pop(ctx)
 	if err != nil {
@@ -642,13 +675,15 @@ func (a *awsS3Reader) getObjectTarget(ctx context.Context) (*s3PendingObject, er
 		}
 	}
 
-	obj, err := a.s3.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(target.bucket),
-		Key:    aws.String(target.key),
-	})
-	if err != nil {
-		_ = target.ackFn(ctx, err)
-		return nil, err
+	obj := target.obj
+	if obj == nil {
+		if obj, err = a.s3.GetObject(&s3.GetObjectInput{
+			Bucket: aws.String(target.bucket),
+			Key:    aws.String(target.key),
+		}); err != nil {
+			_ = target.ackFn(ctx, err)
+			return nil, err
+		}
 	}
 
 	object := &s3PendingObject{
@@ -723,6 +758,12 @@ func (a *awsS3Reader) CloseAsync() {
 			a.object.scanner.Close(context.Background())
 			a.object = nil
 		}
+		if a.keyReader != nil {
+			if err := a.keyReader.Close(context.Background()); err != nil {
+				a.log.Warnf("Failed to close object target reader cleanly: %v\n", err)
+			}
+			a.keyReader = nil
+		}
 		a.objectMut.Unlock()
 	}()
 }
@@ -0,0 +1,169 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/benthosdev/benthos/v4/internal/inventory"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
+)
+
+// inventoryTargetReader walks the objects listed within an S3 Inventory report
+// and downloads them in parallel ahead of them being consumed.
+type inventoryTargetReader struct {
+	conf oinput.AWSS3Config
+	log  log.Modular
+	s3   *s3.S3
+
+	fetcher  *inventory.Fetcher
+	progress *inventory.Progress
+
+	retryMut sync.Mutex
+	retries  []*s3ObjectTarget
+}
+
+func newInventoryTargetReader(
+	ctx context.Context,
+	conf oinput.AWSS3Config,
+	log log.Modular,
+	s3Client *s3.S3,
+	mgr inventory.CacheAccessor,
+) (*inventoryTargetReader, error) {
+	manifestBucket := conf.Inventory.ManifestBucket(conf.Bucket)
+
+	obj, err := s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(manifestBucket),
+		Key:    aws.String(conf.Inventory.Manifest),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download inventory manifest: %w", err)
+	}
+	manifestBytes, err := io.ReadAll(obj.Body)
+	obj.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download inventory manifest: %w", err)
+	}
+
+	manifest, err := inventory.ParseS3Manifest(manifestBytes)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.DefaultBucket == "" {
+		manifest.DefaultBucket = conf.Bucket
+	}
+
+	progress := inventory.NewProgress(mgr, conf.Inventory.CheckpointCache, "s3://"+manifestBucket+"/"+conf.Inventory.Manifest)
+	after, err := progress.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if after >= 0 {
+		log.Infof("Resuming inventory %v after object %v\n", conf.Inventory.Manifest, after)
+	}
+
+	lister := inventory.NewLister(manifest, func(ctx context.Context, key string) (io.ReadCloser, error) {
+		out, err := s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(manifestBucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return out.Body, nil
+	}, conf.Prefix, after)
+
+	fetcher := inventory.NewFetcher(lister, conf.Inventory.Concurrency, func(ctx context.Context, obj inventory.Object) (interface{}, error) {
+		out, err := s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(obj.Bucket),
+			Key:    aws.String(obj.Key),
+		})
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(out.Body)
+		out.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		return out, nil
+	})
+
+	return &inventoryTargetReader{
+		conf:     conf,
+		log:      log,
+		s3:       s3Client,
+		fetcher:  fetcher,
+		progress: progress,
+	}, nil
+}
+
+func isS3NoSuchKey(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey
+}
+
+func (r *inventoryTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
+	r.retryMut.Lock()
+	if len(r.retries) > 0 {
+		t := r.retries[0]
+		r.retries = r.retries[1:]
+		r.retryMut.Unlock()
+		return t, nil
+	}
+	r.retryMut.Unlock()
+
+	res, err := r.fetcher.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if res.Err != nil && res.Object.Key == "" {
+		return nil, res.Err
+	}
+
+	commitFn := r.progress.Track(res.Object)
+
+	target := newS3ObjectTarget(res.Object.Key, res.Object.Bucket, time.Time{}, nil)
+	target.ackFn = deleteS3ObjectAckFn(
+		r.s3, target.bucket, target.key, r.conf.DeleteObjects,
+		func(ctx context.Context, err error) error {
+			if err == nil {
+				return commitFn(ctx)
+			}
+			if isS3NoSuchKey(err) {
+				// Objects that no longer exist since the inventory was taken
+				// are skipped.
+				r.log.Warnf("Skipping inventory object %v that no longer exists\n", target.key)
+				return commitFn(ctx)
+			}
+			// The object failed to be downloaded, and so it is queued for
+			// another attempt.
+			target.obj = nil
+			r.retryMut.Lock()
+			r.retries = append(r.retries, target)
+			r.retryMut.Unlock()
+			return nil
+		},
+	)
+
+	if res.Err == nil {
+		target.obj = res.Data.(*s3.GetObjectOutput)
+	} else if !isS3NoSuchKey(res.Err) {
+		r.log.Debugf("Failed to download inventory object %v, attempting again: %v\n", target.key, res.Err)
+	}
+	return target, nil
+}
+
+func (r *inventoryTargetReader) Close(context.Context) error {
+	return r.fetcher.Close()
+}
@@ -0,0 +1,235 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// hiveMetastoreError is an exception returned by a Hive metastore.
+type hiveMetastoreError struct {
+	typ     string
+	message string
+}
+
+func (e *hiveMetastoreError) Error() string {
+	return fmt.Sprintf("%v: %v", e.typ, e.message)
+}
+
+// The exceptions declared by the append_partition_by_name call, indexed by
+// their field IDs within the result struct.
+var hiveAppendPartitionExceptions = map[int16]string{
+	1: "InvalidObjectException",
+	2: "AlreadyExistsException",
+	3: "MetaException",
+}
+
+// hiveMetastore is a minimal client of the Thrift API of a Hive metastore,
+// implementing only the call required in order to register partitions.
+type hiveMetastore struct {
+	address string
+	conf    *thrift.TConfiguration
+
+	mut   sync.Mutex
+	trans thrift.TTransport
+	prot  thrift.TProtocol
+	seqID int32
+}
+
+func newHiveMetastore(address string, timeout time.Duration) *hiveMetastore {
+	return &hiveMetastore{
+		address: address,
+		conf: &thrift.TConfiguration{
+			ConnectTimeout: timeout,
+			SocketTimeout:  timeout,
+		},
+	}
+}
+
+func (h *hiveMetastore) connectLocked() error {
+	if h.trans != nil {
+		return nil
+	}
+	trans := thrift.NewTBufferedTransport(thrift.NewTSocketConf(h.address, h.conf), 4096)
+	if err := trans.Open(); err != nil {
+		return fmt.Errorf("failed to connect to hive metastore: %w", err)
+	}
+	h.trans = trans
+	h.prot = thrift.NewTBinaryProtocolConf(trans, h.conf)
+	return nil
+}
+
+func (h *hiveMetastore) closeLocked() error {
+	if h.trans == nil {
+		return nil
+	}
+	err := h.trans.Close()
+	h.trans, h.prot = nil, nil
+	return err
+}
+
+// appendPartition adds a partition to a table by its name, such as
+// `year=2022/month=03`, where the location of the partition is derived by the
+// metastore from the location of the table. Returns true if the partition was
+// created and false if it already existed.
+func (h *hiveMetastore) appendPartition(ctx context.Context, database, table, name string) (bool, error) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	if err := h.connectLocked(); err != nil {
+		return false, err
+	}
+
+	err := h.call(ctx, "append_partition_by_name", database, table, name)
+
+	var metaErr *hiveMetastoreError
+	if errors.As(err, &metaErr) {
+		if metaErr.typ == "AlreadyExistsException" {
+			return false, nil
+		}
+		return false, err
+	}
+	if err != nil {
+		// The state of the connection is unknown and therefore it's reset.
+		_ = h.closeLocked()
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *hiveMetastore) close() error {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	return h.closeLocked()
+}
+
+// call executes a method with string arguments, returning an error if the
+// method failed, and discarding the result when it succeeded.
+func (h *hiveMetastore) call(ctx context.Context, method string, args ...string) error {
+	h.seqID++
+	if err := h.writeCall(ctx, method, h.seqID, args); err != nil {
+		return fmt.Errorf("failed to write %v call: %w", method, err)
+	}
+
+	_, mType, seqID, err := h.prot.ReadMessageBegin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read %v reply: %w", method, err)
+	}
+	if mType == thrift.EXCEPTION {
+		exc := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "")
+		if err := exc.Read(ctx, h.prot); err != nil {
+			return fmt.Errorf("failed to read %v exception: %w", method, err)
+		}
+		_ = h.prot.ReadMessageEnd(ctx)
+		return fmt.Errorf("%v call failed: %w", method, exc)
+	}
+	if mType != thrift.REPLY {
+		return fmt.Errorf("unexpected %v reply message type: %v", method, mType)
+	}
+	if seqID != h.seqID {
+		return fmt.Errorf("unexpected %v reply sequence ID: %v != %v", method, seqID, h.seqID)
+	}
+
+	resErr, err := h.readResult(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read %v reply: %w", method, err)
+	}
+	if err := h.prot.ReadMessageEnd(ctx); err != nil {
+		return fmt.Errorf("failed to read %v reply: %w", method, err)
+	}
+	return resErr
+}
+
+func (h *hiveMetastore) writeCall(ctx context.Context, method string, seqID int32, args []string) error {
+	if err := h.prot.WriteMessageBegin(ctx, method, thrift.CALL, seqID); err != nil {
+		return err
+	}
+	if err := h.prot.WriteStructBegin(ctx, method+"_args"); err != nil {
+		return err
+	}
+	for i, arg := range args {
+		if err := h.prot.WriteFieldBegin(ctx, "", thrift.STRING, int16(i+1)); err != nil {
+			return err
+		}
+		if err := h.prot.WriteString(ctx, arg); err != nil {
+			return err
+		}
+		if err := h.prot.WriteFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+	if err := h.prot.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	if err := h.prot.WriteStructEnd(ctx); err != nil {
+		return err
+	}
+	if err := h.prot.WriteMessageEnd(ctx); err != nil {
+		return err
+	}
+	return h.prot.Flush(ctx)
+}
+
+// readResult reads the result struct of a call, where the success value is
+// discarded and any declared exception is returned as a hiveMetastoreError.
+func (h *hiveMetastore) readResult(ctx context.Context) (resErr, err error) {
+	if _, err = h.prot.ReadStructBegin(ctx); err != nil {
+		return
+	}
+	for {
+		_, fType, id, err := h.prot.ReadFieldBegin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if fType == thrift.STOP {
+			break
+		}
+		if typ, exists := hiveAppendPartitionExceptions[id]; exists && fType == thrift.STRUCT {
+			msg, err := h.readExceptionMessage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			resErr = &hiveMetastoreError{typ: typ, message: msg}
+		} else if err := thrift.SkipDefaultDepth(ctx, h.prot, fType); err != nil {
+			return nil, err
+		}
+		if err := h.prot.ReadFieldEnd(ctx); err != nil {
+			return nil, err
+		}
+	}
+	err = h.prot.ReadStructEnd(ctx)
+	return
+}
+
+// readExceptionMessage reads an exception struct, all of which declared by the
+// metastore contain a message as their first field.
+func (h *hiveMetastore) readExceptionMessage(ctx context.Context) (msg string, err error) {
+	if _, err = h.prot.ReadStructBegin(ctx); err != nil {
+		return
+	}
+	for {
+		_, fType, id, err := h.prot.ReadFieldBegin(ctx)
+		if err != nil {
+			return "", err
+		}
+		if fType == thrift.STOP {
+			break
+		}
+		if id == 1 && fType == thrift.STRING {
+			if msg, err = h.prot.ReadString(ctx); err != nil {
+				return "", err
+			}
+		} else if err := thrift.SkipDefaultDepth(ctx, h.prot, fType); err != nil {
+			return "", err
+		}
+		if err := h.prot.ReadFieldEnd(ctx); err != nil {
+			return "", err
+		}
+	}
+	err = h.prot.ReadStructEnd(ctx)
+	return
+}
@@ -6,11 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Jeffail/gabs/v2"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/cenkalti/backoff/v4"
@@ -85,6 +87,23 @@ In which case the top level document fields will be written at the root of the
 item, potentially overwriting previously defined column values. If a path is not
 found within a document the column will not be populated.
 
+### Batch Writes
+
+Batches of messages are written with `+"`BatchWriteItem`"+` requests, which are split into chunks of 25 items as this is the limit of a single request. Items that DynamoDB reports as unprocessed are re-submitted with the backoff configured by the retry fields, and items that remain unwritten once the retries are exhausted are reported as failed individually, so that only those messages are retried or handled by [error handling patterns](/docs/configuration/error_handling).
+
+### Conditional Writes
+
+When a `+"`condition.expression`"+` is specified each item is written with a `+"`PutItem`"+` request that is only applied when the condition is met. Items that fail the condition are not retried and are reported as failed individually:
+
+`+"```yml"+`
+condition:
+  expression: attribute_not_exists(id) OR #v < :v
+  attribute_names:
+    "#v": version
+  attribute_values:
+    ":v": ${! json("version") }
+`+"```"+`
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -113,7 +132,16 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldString("ttl", "An optional TTL to set for items, calculated from the moment the message is sent.").Advanced(),
 			docs.FieldString("ttl_key", "The column key to place the TTL value within.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			policy.FieldSpec(),
+			docs.FieldObject("condition", "Write items only when a [condition expression](https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.ConditionExpressions.html) is met. Since batch writes do not support conditions each item is written with an individual request when a condition is specified.").WithChildren(
+				docs.FieldString("expression", "A condition expression that must be met in order for an item to be written.", "attribute_not_exists(id)", "#v < :v"),
+				docs.FieldString("attribute_names", "A map of attribute name placeholders used within the expression to attribute names.", map[string]string{
+					"#v": "version",
+				}).Map(),
+				docs.FieldString("attribute_values", "A map of attribute value placeholders used within the expression to values. Values that are valid JSON, such as numbers, are converted into the equivalent attribute type, and all other values are written as strings.", map[string]string{
+					":v": `${! json("version") }`,
+				}).IsInterpolated().Map(),
+			).Advanced(),
+			policy.OutputFieldSpec(),
 		).WithChildren(session.FieldSpecs()...).WithChildren(retries.FieldSpecs()...).ChildDefaultAndTypesFromStruct(ooutput.NewDynamoDBConfig()),
 		Categories: []string{
 			"Services",
@@ -133,23 +161,29 @@ type dynamoDBWriter struct {
 	backoffCtor func() backoff.BackOff
 	boffPool    sync.Pool
 
-	table          *string
-	ttl            time.Duration
-	strColumns     map[string]*field.Expression
-	jsonMapColumns map[string]string
+	table           *string
+	ttl             time.Duration
+	strColumns      map[string]*field.Expression
+	jsonMapColumns  map[string]string
+	conditionValues map[string]*field.Expression
 }
 
+// dynamoDBMaxBatchWriteItems is the maximum number of items that can be
+// written with a single BatchWriteItem request.
+const dynamoDBMaxBatchWriteItems = 25
+
 func newDynamoDBWriter(
 	conf ooutput.DynamoDBConfig,
 	mgr interop.Manager,
 	log log.Modular,
 ) (*dynamoDBWriter, error) {
 	db := &dynamoDBWriter{
-		conf:           conf,
-		log:            log,
-		table:          aws.String(conf.Table),
-		strColumns:     map[string]*field.Expression{},
-		jsonMapColumns: map[string]string{},
+		conf:            conf,
+		log:             log,
+		table:           aws.String(conf.Table),
+		strColumns:      map[string]*field.Expression{},
+		jsonMapColumns:  map[string]string{},
+		conditionValues: map[string]*field.Expression{},
 	}
 	if len(conf.StringColumns) == 0 && len(conf.JSONMapColumns) == 0 {
 		return nil, errors.New("you must provide at least one column")
@@ -160,6 +194,11 @@ func newDynamoDBWriter(
 			return nil, fmt.Errorf("failed to parse column '%v' expression: %v", k, err)
 		}
 	}
+	for k, v := range conf.Condition.AttributeValues {
+		if db.conditionValues[k], err = mgr.BloblEnvironment().NewField(v); err != nil {
+			return nil, fmt.Errorf("failed to parse condition attribute value '%v' expression: %v", k, err)
+		}
+	}
 	for k, v := range conf.JSONMapColumns {
 		if v == "." {
 			v = ""
@@ -322,6 +361,50 @@ func (d *dynamoDBWriter) WriteWithContext(ctx context.Context, msg *message.Batc
 		return nil
 	})
 
+	if d.conf.Condition.Expression != "" {
+		return d.writeConditional(ctx, boff, msg, writeReqs)
+	}
+
+	// Requests are split into chunks that fit within the item limit of a
+	// BatchWriteItem request, and the failures of each chunk are mapped back
+	// to the index of the origin message.
+	var batchErr *batch.Error
+	for start := 0; start < len(writeReqs); start += dynamoDBMaxBatchWriteItems {
+		end := start + dynamoDBMaxBatchWriteItems
+		if end > len(writeReqs) {
+			end = len(writeReqs)
+		}
+
+		boff.Reset()
+		failed, err := d.writeBatch(ctx, boff, writeReqs[start:end])
+		if err == nil {
+			continue
+		}
+		if batchErr == nil {
+			batchErr = batch.NewError(msg, err)
+		}
+		if failed == nil {
+			for i := start; i < end; i++ {
+				batchErr.Failed(i, err)
+			}
+			continue
+		}
+		for i, fErr := range failed {
+			batchErr.Failed(start+i, fErr)
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+// writeBatch writes a chunk of requests with BatchWriteItem, re-submitting any
+// unprocessed items until the backoff is exhausted. If items remain unwritten
+// an error is returned along with the errors of each failed request keyed by
+// their index within the chunk. If the failed requests cannot be mapped back
+// to their index then only an error is returned.
+func (d *dynamoDBWriter) writeBatch(ctx context.Context, boff backoff.BackOff, writeReqs []*dynamodb.WriteRequest) (map[int]error, error) {
 	batchResult, err := d.client.BatchWriteItem(&dynamodb.BatchWriteItemInput{
 		RequestItems: map[string][]*dynamodb.WriteRequest{
 			*d.table: writeReqs,
@@ -329,39 +412,7 @@ func (d *dynamoDBWriter) WriteWithContext(ctx context.Context, msg *message.Batc
 	})
 	if err != nil {
 		// None of the messages were successful, attempt to send individually
-	individualRequestsLoop:
-		for err != nil {
-			batchErr := batch.NewError(msg, err)
-			for i, req := range writeReqs {
-				if req == nil {
-					continue
-				}
-				if _, iErr := d.client.PutItem(&dynamodb.PutItemInput{
-					TableName: d.table,
-					Item:      req.PutRequest.Item,
-				}); iErr != nil {
-					d.log.Errorf("Put error: %v\n", iErr)
-					wait := boff.NextBackOff()
-					if wait == backoff.Stop {
-						break individualRequestsLoop
-					}
-					select {
-					case <-time.After(wait):
-					case <-ctx.Done():
-						break individualRequestsLoop
-					}
-					batchErr.Failed(i, iErr)
-				} else {
-					writeReqs[i] = nil
-				}
-			}
-			if batchErr.IndexedErrors() == 0 {
-				err = nil
-			} else {
-				err = batchErr
-			}
-		}
-		return err
+		return d.writeIndividually(ctx, boff, writeReqs, err)
 	}
 
 	unproc := batchResult.UnprocessedItems[*d.table]
@@ -390,32 +441,145 @@ unprocessedLoop:
 		}
 	}
 
-	if len(unproc) > 0 {
-		if err == nil {
-			err = errors.New("ran out of request retries")
+	if len(unproc) == 0 {
+		return nil, nil
+	}
+	if err == nil {
+		err = errors.New("ran out of request retries")
+	}
+
+	// Sad, we have unprocessed messages, we need to map the requests back
+	// to the origin message index. The DynamoDB API doesn't make this easy.
+	failed := map[int]error{}
+
+requestsLoop:
+	for _, req := range unproc {
+		for i, src := range writeReqs {
+			if cmp.Equal(req, src) {
+				failed[i] = errors.New("failed to set item")
+				continue requestsLoop
+			}
 		}
+		// If we're unable to map a single request to the origin message
+		// then we return a general error.
+		return nil, err
+	}
+	return failed, err
+}
 
-		// Sad, we have unprocessed messages, we need to map the requests back
-		// to the origin message index. The DynamoDB API doesn't make this easy.
-		batchErr := batch.NewError(msg, err)
+// writeIndividually writes each request with PutItem, retrying those that fail
+// until the backoff is exhausted.
+func (d *dynamoDBWriter) writeIndividually(ctx context.Context, boff backoff.BackOff, writeReqs []*dynamodb.WriteRequest, err error) (map[int]error, error) {
+	failed := map[int]error{}
+	pending := make([]int, len(writeReqs))
+	for i := range pending {
+		pending[i] = i
+	}
 
-	requestsLoop:
-		for _, req := range unproc {
-			for i, src := range writeReqs {
-				if cmp.Equal(req, src) {
-					batchErr.Failed(i, errors.New("failed to set item"))
-					continue requestsLoop
-				}
+	for {
+		var retry []int
+		for _, i := range pending {
+			if _, iErr := d.client.PutItem(&dynamodb.PutItemInput{
+				TableName: d.table,
+				Item:      writeReqs[i].PutRequest.Item,
+			}); iErr != nil {
+				d.log.Errorf("Put error: %v\n", iErr)
+				failed[i] = iErr
+				retry = append(retry, i)
+			} else {
+				delete(failed, i)
 			}
-			// If we're unable to map a single request to the origin message
-			// then we return a general error.
-			return err
 		}
+		if len(retry) == 0 {
+			return nil, nil
+		}
+		pending = retry
 
-		err = batchErr
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return failed, err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return failed, err
+		}
+	}
+}
+
+// writeConditional writes each request with a conditional PutItem, since
+// BatchWriteItem does not support condition expressions. Requests that fail
+// their condition are not retried.
+func (d *dynamoDBWriter) writeConditional(ctx context.Context, boff backoff.BackOff, msg *message.Batch, writeReqs []*dynamodb.WriteRequest) error {
+	var attrNames map[string]*string
+	if len(d.conf.Condition.AttributeNames) > 0 {
+		attrNames = make(map[string]*string, len(d.conf.Condition.AttributeNames))
+		for k, v := range d.conf.Condition.AttributeNames {
+			attrNames[k] = aws.String(v)
+		}
 	}
 
-	return err
+	var batchErr *batch.Error
+	for i, req := range writeReqs {
+		input := &dynamodb.PutItemInput{
+			TableName:                d.table,
+			Item:                     req.PutRequest.Item,
+			ConditionExpression:      aws.String(d.conf.Condition.Expression),
+			ExpressionAttributeNames: attrNames,
+		}
+		if len(d.conditionValues) > 0 {
+			input.ExpressionAttributeValues = make(map[string]*dynamodb.AttributeValue, len(d.conditionValues))
+			for k, v := range d.conditionValues {
+				input.ExpressionAttributeValues[k] = conditionAttributeValue(v.String(i, msg))
+			}
+		}
+
+		boff.Reset()
+		for {
+			_, err := d.client.PutItem(input)
+			if err == nil {
+				break
+			}
+
+			var aerr awserr.Error
+			if errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+				err = fmt.Errorf("condition check failed: %w", err)
+			} else if wait := boff.NextBackOff(); wait != backoff.Stop {
+				d.log.Errorf("Put error: %v\n", err)
+				select {
+				case <-time.After(wait):
+					continue
+				case <-ctx.Done():
+				}
+			}
+
+			if batchErr == nil {
+				batchErr = batch.NewError(msg, err)
+			}
+			batchErr.Failed(i, err)
+			break
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
+
+// conditionAttributeValue converts an interpolated condition value into an
+// attribute, where values that are valid JSON are converted into the
+// equivalent attribute type and all other values are strings.
+func conditionAttributeValue(v string) *dynamodb.AttributeValue {
+	dec := json.NewDecoder(strings.NewReader(v))
+	dec.UseNumber()
+
+	var root interface{}
+	if err := dec.Decode(&root); err == nil && !dec.More() {
+		return walkJSON(root)
+	}
+	return &dynamodb.AttributeValue{
+		S: aws.String(v),
+	}
 }
 
 func (d *dynamoDBWriter) CloseAsync() {
@@ -3,9 +3,11 @@ package aws
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/stretchr/testify/assert"
@@ -500,3 +502,148 @@ func TestDynamoDBSadBatch(t *testing.T) {
 
 	assert.Equal(t, expected, requests)
 }
+
+func TestDynamoDBBatchChunks(t *testing.T) {
+	t.Parallel()
+
+	conf := ooutput.NewDynamoDBConfig()
+	conf.StringColumns = map[string]string{
+		"id": `${!json("id")}`,
+	}
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+	conf.Table = "FooTable"
+
+	db, err := newDynamoDBWriter(conf, mock.NewManager(), log.Noop())
+	require.NoError(t, err)
+
+	var chunkSizes []int
+	db.client = &mockDynamoDB{
+		fn: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			t.Error("not expected")
+			return nil, errors.New("not implemented")
+		},
+		batchFn: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			request := input.RequestItems["FooTable"]
+			chunkSizes = append(chunkSizes, len(request))
+
+			// Item 30 is never processed.
+			var unproc []*dynamodb.WriteRequest
+			for _, req := range request {
+				if *req.PutRequest.Item["id"].S == "30" {
+					unproc = append(unproc, req)
+				}
+			}
+			return &dynamodb.BatchWriteItemOutput{
+				UnprocessedItems: map[string][]*dynamodb.WriteRequest{
+					"FooTable": unproc,
+				},
+			}, nil
+		},
+	}
+
+	var parts [][]byte
+	for i := 0; i < 60; i++ {
+		parts = append(parts, []byte(`{"id":"`+strconv.Itoa(i)+`"}`))
+	}
+	msg := message.QuickBatch(parts)
+
+	expErr := batch.NewError(msg, errors.New("failed to set 1 items"))
+	expErr.Failed(30, errors.New("failed to set item"))
+	require.Equal(t, expErr, db.WriteWithContext(context.Background(), msg))
+
+	// Chunks of 25, 25 and 10 where the second is re-submitted with its
+	// unprocessed item until the retries are exhausted.
+	assert.Equal(t, []int{25, 25, 1, 1, 1, 10}, chunkSizes)
+}
+
+func TestDynamoDBConditional(t *testing.T) {
+	t.Parallel()
+
+	conf := ooutput.NewDynamoDBConfig()
+	conf.StringColumns = map[string]string{
+		"id": `${!json("id")}`,
+	}
+	conf.Condition.Expression = "attribute_not_exists(id) OR #v < :v"
+	conf.Condition.AttributeNames = map[string]string{"#v": "version"}
+	conf.Condition.AttributeValues = map[string]string{
+		":v":  `${!json("version")}`,
+		":id": `${!json("id")}`,
+	}
+	conf.Backoff.InitialInterval = "1ms"
+	conf.Backoff.MaxInterval = "1ms"
+	conf.Table = "FooTable"
+
+	db, err := newDynamoDBWriter(conf, mock.NewManager(), log.Noop())
+	require.NoError(t, err)
+
+	condErr := awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "nope", nil)
+
+	attempts := map[string]int{}
+	var requests []*dynamodb.PutItemInput
+	db.client = &mockDynamoDB{
+		fn: func(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			id := *input.Item["id"].S
+			attempts[id]++
+			switch id {
+			case "bar":
+				return nil, condErr
+			case "baz":
+				if attempts[id] == 1 {
+					return nil, errors.New("throttled")
+				}
+			}
+			requests = append(requests, input)
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		batchFn: func(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			t.Error("not expected")
+			return nil, errors.New("not implemented")
+		},
+	}
+
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{"id":"foo","version":2}`),
+		[]byte(`{"id":"bar","version":3}`),
+		[]byte(`{"id":"baz","version":4}`),
+	})
+
+	err = db.WriteWithContext(context.Background(), msg)
+	require.Error(t, err)
+
+	var bErr *batch.Error
+	require.True(t, errors.As(err, &bErr))
+	assert.Equal(t, 1, bErr.IndexedErrors())
+	bErr.WalkParts(func(i int, _ *message.Part, err error) bool {
+		if i == 1 {
+			assert.True(t, errors.Is(err, condErr))
+		} else {
+			assert.NoError(t, err)
+		}
+		return true
+	})
+
+	assert.Equal(t, map[string]int{"foo": 1, "bar": 1, "baz": 2}, attempts)
+	assert.Equal(t, []*dynamodb.PutItemInput{
+		{
+			TableName:                aws.String("FooTable"),
+			Item:                     map[string]*dynamodb.AttributeValue{"id": {S: aws.String("foo")}},
+			ConditionExpression:      aws.String("attribute_not_exists(id) OR #v < :v"),
+			ExpressionAttributeNames: map[string]*string{"#v": aws.String("version")},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":v":  {N: aws.String("2")},
+				":id": {S: aws.String("foo")},
+			},
+		},
+		{
+			TableName:                aws.String("FooTable"),
+			Item:                     map[string]*dynamodb.AttributeValue{"id": {S: aws.String("baz")}},
+			ConditionExpression:      aws.String("attribute_not_exists(id) OR #v < :v"),
+			ExpressionAttributeNames: map[string]*string{"#v": aws.String("version")},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":v":  {N: aws.String("4")},
+				":id": {S: aws.String("baz")},
+			},
+		},
+	}, requests)
+}
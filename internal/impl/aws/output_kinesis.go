@@ -64,7 +64,7 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldString("partition_key", "A required key for partitioning messages.").IsInterpolated(),
 			docs.FieldString("hash_key", "A optional hash key for partitioning messages.").IsInterpolated().Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		).WithChildren(sess.FieldSpecs()...).WithChildren(retries.FieldSpecs()...).ChildDefaultAndTypesFromStruct(ooutput.NewKinesisConfig()),
 		Categories: []string{
 			"Services",
@@ -11,12 +11,15 @@ import (
 	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
 	"github.com/cenkalti/backoff/v4"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	ooutput "github.com/benthosdev/benthos/v4/internal/old/output"
@@ -25,7 +28,7 @@ import (
 
 func init() {
 	err := bundle.AllOutputs.Add(bundle.OutputConstructorFromSimple(func(c ooutput.Config, nm bundle.NewManagement) (output.Streamed, error) {
-		kin, err := newKinesisFirehoseWriter(c.AWSKinesisFirehose, nm.Logger())
+		kin, err := newKinesisFirehoseWriter(c.AWSKinesisFirehose, nm, nm.Logger())
 		if err != nil {
 			return nil, err
 		}
@@ -40,6 +43,16 @@ func init() {
 		Summary: `
 Sends messages to a Kinesis Firehose delivery stream.`,
 		Description: output.Description(true, true, `
+The `+"`stream`"+` field can be dynamically set using function interpolations
+described [here](/docs/configuration/interpolation#bloblang-queries), in which
+case messages of a batch are grouped by their resolved delivery stream.
+
+Messages are written with PutRecordBatch requests of up to 500 records or 4 MiB
+in size. Records rejected within a request are retried individually according to
+the backoff settings, and messages that could not be delivered once retries are
+exhausted are reattempted by the pipeline without resending the rest of the
+batch.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -47,9 +60,9 @@ services. It's also possible to set them explicitly at the component level,
 allowing you to transfer data across accounts. You can find out more
 [in this document](/docs/guides/cloud/aws).`),
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("stream", "The stream to publish messages to."),
+			docs.FieldString("stream", "The stream to publish messages to.").IsInterpolated(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		).WithChildren(sess.FieldSpecs()...).WithChildren(retries.FieldSpecs()...).ChildDefaultAndTypesFromStruct(ooutput.NewKinesisFirehoseConfig()),
 		Categories: []string{
 			"Services",
@@ -61,6 +74,11 @@ allowing you to transfer data across accounts. You can find out more
 	}
 }
 
+const (
+	firehoseMaxRecordsCount = 500
+	firehoseMaxRequestBytes = 4 * mebibyte
+)
+
 type kinesisFirehoseWriter struct {
 	conf ooutput.KinesisFirehoseConfig
 
@@ -68,32 +86,41 @@ type kinesisFirehoseWriter struct {
 	firehose firehoseiface.FirehoseAPI
 
 	backoffCtor func() backoff.BackOff
-	streamName  *string
+	stream      *field.Expression
 
 	log log.Modular
 }
 
-func newKinesisFirehoseWriter(conf ooutput.KinesisFirehoseConfig, log log.Modular) (*kinesisFirehoseWriter, error) {
+func newKinesisFirehoseWriter(conf ooutput.KinesisFirehoseConfig, mgr interop.Manager, log log.Modular) (*kinesisFirehoseWriter, error) {
 	k := kinesisFirehoseWriter{
-		conf:       conf,
-		log:        log,
-		streamName: aws.String(conf.Stream),
+		conf: conf,
+		log:  log,
 	}
 
 	var err error
+	if k.stream, err = mgr.BloblEnvironment().NewField(conf.Stream); err != nil {
+		return nil, fmt.Errorf("failed to parse stream expression: %v", err)
+	}
 	if k.backoffCtor, err = conf.Config.GetCtor(); err != nil {
 		return nil, err
 	}
 	return &k, nil
 }
 
-// toRecords converts an individual benthos message into a slice of Kinesis Firehose
-// batch put entries by promoting each message part into a single part message
-// and passing each new message through the partition and hash key interpolation
-// process, allowing the user to define the partition and hash key per message
-// part.
-func (a *kinesisFirehoseWriter) toRecords(msg *message.Batch) ([]*firehose.Record, error) {
-	entries := make([]*firehose.Record, msg.Len())
+// firehoseRecord is a Kinesis Firehose record along with the index of the
+// message part it was created from.
+type firehoseRecord struct {
+	index  int
+	record *firehose.Record
+}
+
+// toRecords converts an individual benthos message into Kinesis Firehose
+// records grouped by the delivery stream they target, with the stream name
+// resolved per message part. The returned stream names are ordered by first
+// appearance within the batch.
+func (a *kinesisFirehoseWriter) toRecords(msg *message.Batch) ([]string, map[string][]firehoseRecord, error) {
+	var streams []string
+	grouped := map[string][]firehoseRecord{}
 
 	err := msg.Iter(func(i int, p *message.Part) error {
 		entry := firehose.Record{
@@ -105,11 +132,18 @@ func (a *kinesisFirehoseWriter) toRecords(msg *message.Batch) ([]*firehose.Recor
 			return component.ErrMessageTooLarge
 		}
 
-		entries[i] = &entry
+		stream := a.stream.String(i, msg)
+		if _, exists := grouped[stream]; !exists {
+			streams = append(streams, stream)
+		}
+		grouped[stream] = append(grouped[stream], firehoseRecord{
+			index:  i,
+			record: &entry,
+		})
 		return nil
 	})
 
-	return entries, err
+	return streams, grouped, err
 }
 
 //------------------------------------------------------------------------------
@@ -121,7 +155,8 @@ func (a *kinesisFirehoseWriter) ConnectWithContext(ctx context.Context) error {
 }
 
 // Connect creates a new Kinesis Firehose client and ensures that the target
-// Kinesis Firehose delivery stream.
+// Kinesis Firehose delivery stream exists. When the stream name is dynamic the
+// check is skipped as the targets aren't known until messages are written.
 func (a *kinesisFirehoseWriter) Connect() error {
 	if a.session != nil {
 		return nil
@@ -132,104 +167,130 @@ func (a *kinesisFirehoseWriter) Connect() error {
 		return err
 	}
 
-	a.session = sess
-	a.firehose = firehose.New(sess)
-
-	if _, err := a.firehose.DescribeDeliveryStream(&firehose.DescribeDeliveryStreamInput{
-		DeliveryStreamName: a.streamName,
-	}); err != nil {
-		return err
+	client := firehose.New(sess)
+	if a.stream.NumDynamicExpressions() == 0 {
+		if _, err := client.DescribeDeliveryStream(&firehose.DescribeDeliveryStreamInput{
+			DeliveryStreamName: aws.String(a.conf.Stream),
+		}); err != nil {
+			return err
+		}
 	}
 
+	a.session = sess
+	a.firehose = client
+
 	a.log.Infof("Sending messages to Kinesis Firehose delivery stream: %v\n", a.conf.Stream)
 	return nil
 }
 
 // Write attempts to write message contents to a target Kinesis Firehose delivery
-// stream in batches of 500. If throttling is detected, failed messages are retried
-// according to the configurable backoff settings.
+// stream in batches of up to 500 records or 4 MiB. Records that fail are retried
+// individually according to the configurable backoff settings.
 func (a *kinesisFirehoseWriter) Write(msg *message.Batch) error {
 	return a.WriteWithContext(context.Background(), msg)
 }
 
-// WriteWithContext attempts to write message contents to a target Kinesis
-// Firehose delivery stream in batches of 500. If throttling is detected, failed
-// messages are retried according to the configurable backoff settings.
+// WriteWithContext attempts to write message contents to target Kinesis
+// Firehose delivery streams in batches of up to 500 records or 4 MiB. Records
+// that fail are retried individually according to the configurable backoff
+// settings, and any that could not be delivered are reported as a batch error
+// so that only those messages are reattempted.
 func (a *kinesisFirehoseWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
 	if a.session == nil {
 		return component.ErrNotConnected
 	}
 
-	backOff := a.backoffCtor()
-
-	records, err := a.toRecords(msg)
+	streams, grouped, err := a.toRecords(msg)
 	if err != nil {
 		return err
 	}
 
-	input := &firehose.PutRecordBatchInput{
-		Records:            records,
-		DeliveryStreamName: a.streamName,
+	var batchErr *batch.Error
+	for _, stream := range streams {
+		failed, err := a.writeStream(ctx, stream, grouped[stream])
+		if err == nil {
+			continue
+		}
+		if batchErr == nil {
+			batchErr = batch.NewError(msg, err)
+		}
+		for _, r := range failed {
+			batchErr.Failed(r.index, err)
+		}
 	}
+	if batchErr != nil {
+		return batchErr
+	}
+	return nil
+}
 
-	// trim input record length to max kinesis firehose batch size
-	if len(records) > kinesisMaxRecordsCount {
-		input.Records, records = records[:kinesisMaxRecordsCount], records[kinesisMaxRecordsCount:]
-	} else {
-		records = nil
+// fillFirehoseRequest moves records from the queue into a request until either
+// the record count or request size limits of PutRecordBatch are reached.
+func fillFirehoseRequest(request, queue []firehoseRecord) ([]firehoseRecord, []firehoseRecord) {
+	var size int
+	for _, r := range request {
+		size += len(r.record.Data)
+	}
+	for len(queue) > 0 && len(request) < firehoseMaxRecordsCount {
+		if size += len(queue[0].record.Data); size > firehoseMaxRequestBytes {
+			break
+		}
+		request, queue = append(request, queue[0]), queue[1:]
 	}
+	return request, queue
+}
 
-	var failed []*firehose.Record
-	for len(input.Records) > 0 {
-		wait := backOff.NextBackOff()
+// writeStream writes records to a single delivery stream, returning the
+// records that could not be delivered along with the reason.
+func (a *kinesisFirehoseWriter) writeStream(ctx context.Context, stream string, queue []firehoseRecord) ([]firehoseRecord, error) {
+	backOff := a.backoffCtor()
+
+	var request []firehoseRecord
+	for {
+		if request, queue = fillFirehoseRequest(request, queue); len(request) == 0 {
+			return nil, nil
+		}
+
+		input := &firehose.PutRecordBatchInput{
+			DeliveryStreamName: aws.String(stream),
+			Records:            make([]*firehose.Record, len(request)),
+		}
+		for i, r := range request {
+			input.Records[i] = r.record
+		}
 
 		// batch write to kinesis firehose
 		output, err := a.firehose.PutRecordBatch(input)
 		if err != nil {
 			a.log.Warnf("kinesis firehose error: %v\n", err)
-			// bail if a message is too large or all retry attempts expired
-			if wait == backoff.Stop {
-				return err
-			}
-			continue
-		}
-
-		// requeue any individual records that failed due to throttling
-		failed = nil
-		if output.FailedPutCount != nil {
+		} else {
+			// requeue only the individual records that failed
+			var failed []firehoseRecord
 			for i, entry := range output.RequestResponses {
-				if entry.ErrorCode != nil {
-					failed = append(failed, input.Records[i])
-					if *entry.ErrorCode != firehose.ErrCodeServiceUnavailableException {
-						err = fmt.Errorf("record failed with code [%s] %s: %+v", *entry.ErrorCode, *entry.ErrorMessage, input.Records[i])
-						a.log.Errorf("kinesis firehose record error: %v\n", err)
-						return err
-					}
+				if entry.ErrorCode != nil && i < len(request) {
+					failed = append(failed, request[i])
+					err = fmt.Errorf("record failed with code [%s] %s", *entry.ErrorCode, aws.StringValue(entry.ErrorMessage))
 				}
 			}
-		}
-		input.Records = failed
-
-		// if throttling errors detected, pause briefly
-		l := len(failed)
-		if l > 0 {
-			a.log.Warnf("scheduling retry of throttled records (%d)\n", l)
-			if wait == backoff.Stop {
-				return component.ErrTimeout
+			if len(failed) < len(request) {
+				backOff.Reset()
+			}
+			if request = failed; len(failed) == 0 {
+				continue
 			}
-			time.Sleep(wait)
+			a.log.Warnf("scheduling retry of failed records (%d): %v\n", len(failed), err)
 		}
 
-		// add remaining records to batch
-		if n := len(records); n > 0 && l < kinesisMaxRecordsCount {
-			if remaining := kinesisMaxRecordsCount - l; remaining < n {
-				input.Records, records = append(input.Records, records[:remaining]...), records[remaining:]
-			} else {
-				input.Records, records = append(input.Records, records...), nil
-			}
+		wait := backOff.NextBackOff()
+		if wait == backoff.Stop {
+			return append(request, queue...), err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return append(request, queue...), ctx.Err()
 		}
 	}
-	return err
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
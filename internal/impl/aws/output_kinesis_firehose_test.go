@@ -11,7 +11,11 @@ import (
 	"github.com/aws/aws-sdk-go/service/firehose"
 	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
 	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -43,6 +47,7 @@ func TestKinesisFirehoseWriteSinglePartMessage(t *testing.T) {
 		},
 		log: log.Noop(),
 	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
 
 	msg := message.QuickBatch(nil)
 	part := message.NewPart([]byte(`{"foo":"bar","id":123}`))
@@ -78,6 +83,7 @@ func TestKinesisFirehoseWriteMultiPartMessage(t *testing.T) {
 		},
 		log: log.Noop(),
 	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
 
 	msg := message.QuickBatch(nil)
 	for _, p := range parts {
@@ -108,6 +114,7 @@ func TestKinesisFirehoseWriteChunk(t *testing.T) {
 		},
 		log: log.Noop(),
 	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
 
 	msg := message.QuickBatch(nil)
 	for i := 0; i < n; i++ {
@@ -118,14 +125,14 @@ func TestKinesisFirehoseWriteChunk(t *testing.T) {
 	if err := k.Write(msg); err != nil {
 		t.Error(err)
 	}
-	if exp, act := n/kinesisMaxRecordsCount+1, len(batchLengths); act != exp {
+	if exp, act := n/firehoseMaxRecordsCount+1, len(batchLengths); act != exp {
 		t.Errorf("Expected kinesis firehose PutRecordBatch to have call count %d, got %d", exp, act)
 	}
 	for i, act := range batchLengths {
 		exp := n
-		if exp > kinesisMaxRecordsCount {
-			exp = kinesisMaxRecordsCount
-			n -= kinesisMaxRecordsCount
+		if exp > firehoseMaxRecordsCount {
+			exp = firehoseMaxRecordsCount
+			n -= firehoseMaxRecordsCount
 		}
 		if act != exp {
 			t.Errorf("Expected kinesis firehose PutRecordBatch call %d to have batch size %d, got %d", i, exp, act)
@@ -167,6 +174,7 @@ func TestKinesisFirehoseWriteChunkWithThrottling(t *testing.T) {
 		},
 		log: log.Noop(),
 	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
 
 	msg := message.QuickBatch(nil)
 	for i := 0; i < n; i++ {
@@ -209,6 +217,7 @@ func TestKinesisFirehoseWriteError(t *testing.T) {
 		},
 		log: log.Noop(),
 	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
 
 	msg := message.QuickBatch(nil)
 	msg.Append(message.NewPart([]byte(`{"foo":"bar"}`)))
@@ -252,6 +261,7 @@ func TestKinesisFirehoseWriteMessageThrottling(t *testing.T) {
 		},
 		log: log.Noop(),
 	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
 
 	msg := message.QuickBatch(nil)
 	msg.Append(message.NewPart([]byte(`{"foo":"bar","id":123}`)))
@@ -294,6 +304,7 @@ func TestKinesisFirehoseWriteBackoffMaxRetriesExceeded(t *testing.T) {
 		},
 		log: log.Noop(),
 	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
 
 	msg := message.QuickBatch(nil)
 	msg.Append(message.NewPart([]byte(`{"foo":"bar","id":123}`)))
@@ -305,3 +316,103 @@ func TestKinesisFirehoseWriteBackoffMaxRetriesExceeded(t *testing.T) {
 		t.Errorf("Expected kinesis firehose PutRecordBatch to have call count %d, got %d", exp, calls)
 	}
 }
+
+func TestKinesisFirehoseWriteDynamicStreams(t *testing.T) {
+	streams := map[string][]string{}
+	var requestSizes []int
+	k := kinesisFirehoseWriter{
+		backoffCtor: func() backoff.BackOff {
+			return backoff.NewExponentialBackOff()
+		},
+		session: session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+		})),
+		firehose: &mockKinesisFirehose{
+			fn: func(input *firehose.PutRecordBatchInput) (*firehose.PutRecordBatchOutput, error) {
+				var size int
+				for _, r := range input.Records {
+					size += len(r.Data)
+					streams[*input.DeliveryStreamName] = append(streams[*input.DeliveryStreamName], string(r.Data[:3]))
+				}
+				requestSizes = append(requestSizes, size)
+				return &firehose.PutRecordBatchOutput{}, nil
+			},
+		},
+		log: log.Noop(),
+	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField(`${! meta("stream") }`)
+
+	msg := message.QuickBatch(nil)
+	for i := 0; i < 10; i++ {
+		part := message.NewPart(append([]byte(fmt.Sprintf("%03d", i)), make([]byte, mebibyte-3)...))
+		if i%2 == 0 {
+			part.MetaSet("stream", "foo")
+		} else {
+			part.MetaSet("stream", "bar")
+		}
+		msg.Append(part)
+	}
+
+	require.NoError(t, k.Write(msg))
+	assert.Equal(t, map[string][]string{
+		"foo": {"000", "002", "004", "006", "008"},
+		"bar": {"001", "003", "005", "007", "009"},
+	}, streams)
+
+	// Each request is limited to 4 MiB of data.
+	assert.Equal(t, []int{4 * mebibyte, mebibyte, 4 * mebibyte, mebibyte}, requestSizes)
+}
+
+func TestKinesisFirehoseWritePartialFailure(t *testing.T) {
+	var calls int
+	k := kinesisFirehoseWriter{
+		backoffCtor: func() backoff.BackOff {
+			return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2)
+		},
+		session: session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+		})),
+		firehose: &mockKinesisFirehose{
+			fn: func(input *firehose.PutRecordBatchInput) (*firehose.PutRecordBatchOutput, error) {
+				calls++
+				var failed int64
+				var output firehose.PutRecordBatchOutput
+				for _, r := range input.Records {
+					entry := firehose.PutRecordBatchResponseEntry{}
+					if string(r.Data) == "bar" {
+						failed++
+						entry.SetErrorCode("InternalFailure")
+						entry.SetErrorMessage("nope")
+					}
+					output.RequestResponses = append(output.RequestResponses, &entry)
+				}
+				output.SetFailedPutCount(failed)
+				return &output, nil
+			},
+		},
+		log: log.Noop(),
+	}
+	k.stream, _ = bloblang.GlobalEnvironment().NewField("foo")
+
+	msg := message.QuickBatch([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	})
+
+	err := k.Write(msg)
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	var bErr *batch.Error
+	require.True(t, errors.As(err, &bErr))
+	assert.Equal(t, 1, bErr.IndexedErrors())
+
+	var failedIndexes []int
+	bErr.WalkParts(func(i int, _ *message.Part, err error) bool {
+		if err != nil {
+			failedIndexes = append(failedIndexes, i)
+			assert.Contains(t, err.Error(), "InternalFailure")
+		}
+		return true
+	})
+	assert.Equal(t, []int{1}, failedIndexes)
+}
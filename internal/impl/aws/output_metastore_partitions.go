@@ -0,0 +1,490 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func metastorePartitionsOutputConfig() *service.ConfigSpec {
+	glueFields := []*service.ConfigField{
+		service.NewStringField("catalog_id").
+			Description("The ID of the Data Catalog containing the table, when empty the catalog of the AWS account is used.").
+			Default(""),
+	}
+	glueFields = append(glueFields, sessionFields()...)
+
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Writes messages to a child output and then registers the partitions written to with an AWS Glue Data Catalog or a Hive metastore.").
+		Description(`
+Data written to object storage by outputs such as `+"[`aws_s3`](/docs/components/outputs/aws_s3)"+` and `+"[`gcp_cloud_storage`](/docs/components/outputs/gcp_cloud_storage)"+` isn't queryable by engines such as Athena, Trino and Spark until the partitions it belongs to are registered with the metastore of the table, which would otherwise require running a crawler or a statement such as `+"`MSCK REPAIR TABLE`"+`. This output writes each batch to a child output and, once the write has succeeded, registers the partitions written to that aren't already known, making the data queryable immediately.
+
+The partition of each message is the result of the `+"`partition`"+` field, which follows the Hive convention of `+"`key=value`"+` pairs separated by slashes, such as `+"`year=2022/month=03`"+`. The keys must match the partition keys of the table, and it's recommended that the path of the child output is constructed from the same values.
+
+### Registered Partitions
+
+Partitions that have been registered, or that already existed, are remembered for the period `+"`registered_ttl`"+` in order to avoid calling the metastore for every batch. If a partition is dropped from the table within this period it isn't registered again until the period has passed.
+
+### Delivery Guarantees
+
+Partitions are registered after the child output has written a batch, and if registration fails the batch is rejected and therefore written again when it's retried. Outputs that write objects with deterministic paths overwrite the same objects in this case, otherwise data may be duplicated.
+
+### Metastores
+
+#### `+"`glue`"+`
+
+Partitions are created with the AWS Glue API, inheriting the storage descriptor (format, serialization and columns) of the table. The location of each partition is the result of the field `+"`location`"+` when it's set, otherwise the location of the table followed by the partition name.
+
+#### `+"`hive`"+`
+
+Partitions are created with the Thrift API of a Hive metastore, where the location of each partition is determined by the metastore from the location of the table and the partition name, and therefore the field `+"`location`"+` isn't supported. Connections are unauthenticated and unencrypted.`).
+		Field(service.NewOutputField("output").
+			Description("The child output to write messages to.")).
+		Field(service.NewStringEnumField("metastore", "glue", "hive").
+			Description("The type of metastore to register partitions with.")).
+		Field(service.NewStringField("database").
+			Description("The database containing the table.")).
+		Field(service.NewStringField("table").
+			Description("The table to register partitions with.")).
+		Field(service.NewInterpolatedStringField("partition").
+			Description("The partition written to by each message, in the form `key=value` with pairs separated by slashes. Messages resulting in an empty string are not registered with a partition.").
+			Example(`year=${! timestamp_unix().format_timestamp("2006", "UTC") }/month=${! timestamp_unix().format_timestamp("01", "UTC") }`).
+			Example(`dt=${! meta("date") }/region=${! this.region }`)).
+		Field(service.NewInterpolatedStringField("location").
+			Description("An optional location of the partition written to by each message, only supported by the `glue` metastore. When empty the location of the table followed by the partition name is used.").
+			Example(`s3://my-bucket/events/${! meta("date") }/`).
+			Default("").
+			Advanced()).
+		Field(service.NewDurationField("registered_ttl").
+			Description("The period of time for which registered partitions are remembered, avoiding calls to the metastore for partitions that are known to exist.").
+			Default("1h").
+			Advanced()).
+		Field(service.NewObjectField("glue", glueFields...).
+			Description("Configuration for the `glue` metastore.").
+			Advanced()).
+		Field(service.NewObjectField("hive",
+			service.NewStringField("address").
+				Description("The address of the Thrift API of the Hive metastore.").
+				Default("localhost:9083"),
+			service.NewDurationField("timeout").
+				Description("The maximum period of time to wait for connections and calls to the metastore.").
+				Default("30s"),
+		).
+			Description("Configuration for the `hive` metastore.").
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be writing and registering in parallel at any given time.").
+			Default(1)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Athena Partitions", `
+Here we write batches of JSON documents to S3 partitioned by date, registering each new partition with the Glue Data Catalog so that it's immediately queryable by Athena:`,
+			`
+output:
+  metastore_partitions:
+    metastore: glue
+    database: logs
+    table: events
+    partition: dt=${! timestamp_unix().format_timestamp("2006-01-02", "UTC") }
+    glue:
+      region: eu-west-1
+    batching:
+      count: 1000
+      period: 1m
+      processors:
+        - archive:
+            format: lines
+    output:
+      aws_s3:
+        bucket: my-data-lake
+        path: events/dt=${! timestamp_unix().format_timestamp("2006-01-02", "UTC") }/${! timestamp_unix_nano() }.jsonl
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("metastore_partitions", metastorePartitionsOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newMetastorePartitionsOutputFromConfig(conf)
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// metastorePartition is a partition of a table, identified by its name in the
+// form `key1=value1/key2=value2`.
+type metastorePartition struct {
+	name     string
+	keys     []string
+	values   []string
+	location string
+}
+
+func parseMetastorePartition(name string) (metastorePartition, error) {
+	p := metastorePartition{name: name}
+	for _, pair := range strings.Split(name, "/") {
+		i := strings.Index(pair, "=")
+		if i <= 0 {
+			return p, fmt.Errorf("partition '%v' is not of the form key=value", name)
+		}
+		value, err := url.PathUnescape(pair[i+1:])
+		if err != nil {
+			return p, fmt.Errorf("partition '%v' contains an invalid value: %w", name, err)
+		}
+		if value == "" {
+			return p, fmt.Errorf("partition '%v' contains an empty value", name)
+		}
+		p.keys = append(p.keys, pair[:i])
+		p.values = append(p.values, value)
+	}
+	return p, nil
+}
+
+// partitionRegistrar registers partitions with a metastore, where partitions
+// that already exist are not considered an error.
+type partitionRegistrar interface {
+	register(ctx context.Context, partitions []metastorePartition) error
+	close() error
+}
+
+//------------------------------------------------------------------------------
+
+// glueMaxBatchCreatePartitions is the maximum number of partitions that can be
+// created with a single BatchCreatePartition call.
+const glueMaxBatchCreatePartitions = 100
+
+type gluePartitions struct {
+	client    glueiface.GlueAPI
+	catalogID *string
+	database  string
+	table     string
+
+	mut       sync.Mutex
+	tableDesc *glue.TableData
+}
+
+func (g *gluePartitions) getTable(ctx context.Context) (*glue.TableData, error) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	if g.tableDesc != nil {
+		return g.tableDesc, nil
+	}
+	res, err := g.client.GetTableWithContext(ctx, &glue.GetTableInput{
+		CatalogId:    g.catalogID,
+		DatabaseName: aws.String(g.database),
+		Name:         aws.String(g.table),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+	if res.Table == nil || res.Table.StorageDescriptor == nil {
+		return nil, fmt.Errorf("table %v.%v has no storage descriptor", g.database, g.table)
+	}
+	g.tableDesc = res.Table
+	return g.tableDesc, nil
+}
+
+func (g *gluePartitions) register(ctx context.Context, partitions []metastorePartition) error {
+	table, err := g.getTable(ctx)
+	if err != nil {
+		return err
+	}
+
+	inputs := make([]*glue.PartitionInput, 0, len(partitions))
+	for _, p := range partitions {
+		if err := checkPartitionKeys(p, table.PartitionKeys); err != nil {
+			return err
+		}
+
+		location := p.location
+		if location == "" {
+			location = strings.TrimSuffix(aws.StringValue(table.StorageDescriptor.Location), "/") + "/" + p.name
+		}
+
+		// Partitions inherit the format, serialization and columns of the
+		// table, and only differ by location.
+		desc := *table.StorageDescriptor
+		desc.Location = aws.String(location)
+
+		inputs = append(inputs, &glue.PartitionInput{
+			Values:            aws.StringSlice(p.values),
+			StorageDescriptor: &desc,
+		})
+	}
+
+	var errs []string
+	for len(inputs) > 0 {
+		chunk := inputs
+		if len(chunk) > glueMaxBatchCreatePartitions {
+			chunk = chunk[:glueMaxBatchCreatePartitions]
+		}
+		inputs = inputs[len(chunk):]
+
+		res, err := g.client.BatchCreatePartitionWithContext(ctx, &glue.BatchCreatePartitionInput{
+			CatalogId:          g.catalogID,
+			DatabaseName:       aws.String(g.database),
+			TableName:          aws.String(g.table),
+			PartitionInputList: chunk,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create partitions: %w", err)
+		}
+		for _, pErr := range res.Errors {
+			if pErr.ErrorDetail == nil || aws.StringValue(pErr.ErrorDetail.ErrorCode) == glue.ErrCodeAlreadyExistsException {
+				continue
+			}
+			errs = append(errs, fmt.Sprintf("%v: %v: %v",
+				aws.StringValueSlice(pErr.PartitionValues),
+				aws.StringValue(pErr.ErrorDetail.ErrorCode),
+				aws.StringValue(pErr.ErrorDetail.ErrorMessage),
+			))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to create partitions: %v", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (g *gluePartitions) close() error {
+	return nil
+}
+
+func checkPartitionKeys(p metastorePartition, columns []*glue.Column) error {
+	match := len(p.keys) == len(columns)
+	for i := 0; match && i < len(columns); i++ {
+		match = strings.EqualFold(p.keys[i], aws.StringValue(columns[i].Name))
+	}
+	if match {
+		return nil
+	}
+	keys := make([]string, len(columns))
+	for i, c := range columns {
+		keys[i] = aws.StringValue(c.Name)
+	}
+	return fmt.Errorf("partition '%v' does not match the partition keys of the table: %v", p.name, strings.Join(keys, ", "))
+}
+
+//------------------------------------------------------------------------------
+
+type hivePartitions struct {
+	client   *hiveMetastore
+	database string
+	table    string
+}
+
+func (h *hivePartitions) register(ctx context.Context, partitions []metastorePartition) error {
+	for _, p := range partitions {
+		if _, err := h.client.appendPartition(ctx, h.database, h.table, p.name); err != nil {
+			return fmt.Errorf("failed to create partition '%v': %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+func (h *hivePartitions) close() error {
+	return h.client.close()
+}
+
+//------------------------------------------------------------------------------
+
+type metastorePartitionsOutput struct {
+	partition     *service.InterpolatedString
+	location      *service.InterpolatedString
+	registeredTTL time.Duration
+	registrar     partitionRegistrar
+	child         *service.OwnedOutput
+
+	mut        sync.Mutex
+	registered map[string]time.Time
+	nowFn      func() time.Time
+}
+
+func newMetastorePartitionsOutputFromConfig(conf *service.ParsedConfig) (*metastorePartitionsOutput, error) {
+	m := &metastorePartitionsOutput{
+		registered: map[string]time.Time{},
+		nowFn:      time.Now,
+	}
+
+	var err error
+	if m.partition, err = conf.FieldInterpolatedString("partition"); err != nil {
+		return nil, err
+	}
+	locationStr, err := conf.FieldString("location")
+	if err != nil {
+		return nil, err
+	}
+	if locationStr != "" {
+		if m.location, err = conf.FieldInterpolatedString("location"); err != nil {
+			return nil, err
+		}
+	}
+	if m.registeredTTL, err = conf.FieldDuration("registered_ttl"); err != nil {
+		return nil, err
+	}
+
+	database, err := conf.FieldString("database")
+	if err != nil {
+		return nil, err
+	}
+	table, err := conf.FieldString("table")
+	if err != nil {
+		return nil, err
+	}
+
+	metastore, err := conf.FieldString("metastore")
+	if err != nil {
+		return nil, err
+	}
+	switch metastore {
+	case "glue":
+		catalogID, err := conf.FieldString("glue", "catalog_id")
+		if err != nil {
+			return nil, err
+		}
+		sess, err := getSession(conf.Namespace("glue"))
+		if err != nil {
+			return nil, err
+		}
+		g := &gluePartitions{
+			client:   glue.New(sess),
+			database: database,
+			table:    table,
+		}
+		if catalogID != "" {
+			g.catalogID = aws.String(catalogID)
+		}
+		m.registrar = g
+	case "hive":
+		if m.location != nil {
+			return nil, errors.New("the field location is not supported by the hive metastore")
+		}
+		address, err := conf.FieldString("hive", "address")
+		if err != nil {
+			return nil, err
+		}
+		timeout, err := conf.FieldDuration("hive", "timeout")
+		if err != nil {
+			return nil, err
+		}
+		m.registrar = &hivePartitions{
+			client:   newHiveMetastore(address, timeout),
+			database: database,
+			table:    table,
+		}
+	default:
+		return nil, fmt.Errorf("metastore not recognised: %v", metastore)
+	}
+
+	if m.child, err = conf.FieldOutput("output"); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *metastorePartitionsOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// unregistered returns the distinct partitions written to by a batch that
+// aren't known to have been registered.
+func (m *metastorePartitionsOutput) unregistered(batch service.MessageBatch) ([]metastorePartition, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	now := m.nowFn()
+	seen := map[string]struct{}{}
+
+	var partitions []metastorePartition
+	for i := range batch {
+		name := batch.InterpolatedString(i, m.partition)
+		if name == "" {
+			continue
+		}
+		if _, exists := seen[name]; exists {
+			continue
+		}
+		seen[name] = struct{}{}
+		if registeredAt, exists := m.registered[name]; exists && now.Sub(registeredAt) < m.registeredTTL {
+			continue
+		}
+
+		p, err := parseMetastorePartition(name)
+		if err != nil {
+			return nil, err
+		}
+		if m.location != nil {
+			p.location = batch.InterpolatedString(i, m.location)
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions, nil
+}
+
+func (m *metastorePartitionsOutput) markRegistered(partitions []metastorePartition) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	now := m.nowFn()
+	for name, registeredAt := range m.registered {
+		if now.Sub(registeredAt) >= m.registeredTTL {
+			delete(m.registered, name)
+		}
+	}
+	for _, p := range partitions {
+		m.registered[p.name] = now
+	}
+}
+
+func (m *metastorePartitionsOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	partitions, err := m.unregistered(batch)
+	if err != nil {
+		return err
+	}
+	if err := m.child.WriteBatch(ctx, batch); err != nil {
+		return err
+	}
+	if len(partitions) == 0 {
+		return nil
+	}
+	if err := m.registrar.register(ctx, partitions); err != nil {
+		return err
+	}
+	m.markRegistered(partitions)
+	return nil
+}
+
+func (m *metastorePartitionsOutput) Close(ctx context.Context) error {
+	err := m.child.Close(ctx)
+	if rErr := m.registrar.close(); err == nil {
+		err = rErr
+	}
+	return err
+}
@@ -0,0 +1,304 @@
+package aws
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/glue/glueiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mockGlue struct {
+	glueiface.GlueAPI
+
+	mut        sync.Mutex
+	getTables  int
+	partitions map[string]*glue.PartitionInput
+}
+
+func (m *mockGlue) GetTableWithContext(ctx aws.Context, input *glue.GetTableInput, opts ...request.Option) (*glue.GetTableOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.getTables++
+	return &glue.GetTableOutput{
+		Table: &glue.TableData{
+			Name: input.Name,
+			PartitionKeys: []*glue.Column{
+				{Name: aws.String("year")},
+				{Name: aws.String("month")},
+			},
+			StorageDescriptor: &glue.StorageDescriptor{
+				Location:     aws.String("s3://foo/bar/"),
+				InputFormat:  aws.String("org.apache.hadoop.mapred.TextInputFormat"),
+				OutputFormat: aws.String("org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat"),
+			},
+		},
+	}, nil
+}
+
+func (m *mockGlue) BatchCreatePartitionWithContext(ctx aws.Context, input *glue.BatchCreatePartitionInput, opts ...request.Option) (*glue.BatchCreatePartitionOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	res := &glue.BatchCreatePartitionOutput{}
+	for _, p := range input.PartitionInputList {
+		key := aws.StringValue(p.StorageDescriptor.Location)
+		if _, exists := m.partitions[key]; exists {
+			res.Errors = append(res.Errors, &glue.PartitionError{
+				PartitionValues: p.Values,
+				ErrorDetail: &glue.ErrorDetail{
+					ErrorCode:    aws.String(glue.ErrCodeAlreadyExistsException),
+					ErrorMessage: aws.String("partition already exists"),
+				},
+			})
+			continue
+		}
+		m.partitions[key] = p
+	}
+	return res, nil
+}
+
+func TestParseMetastorePartition(t *testing.T) {
+	p, err := parseMetastorePartition("year=2022/month=03/name=foo%2Fbar")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"year", "month", "name"}, p.keys)
+	assert.Equal(t, []string{"2022", "03", "foo/bar"}, p.values)
+
+	for _, name := range []string{"2022", "year=2022/", "=2022", "year="} {
+		_, err = parseMetastorePartition(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMetastorePartitionsGlue(t *testing.T) {
+	conf, err := metastorePartitionsOutputConfig().ParseYAML(`
+metastore: glue
+database: foo
+table: bar
+partition: year=${! meta("year") }/month=${! meta("month").or("") }
+output:
+  drop: {}
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newMetastorePartitionsOutputFromConfig(conf)
+	require.NoError(t, err)
+
+	client := &mockGlue{partitions: map[string]*glue.PartitionInput{
+		"s3://foo/bar/year=2022/month=02": {},
+	}}
+	out.registrar.(*gluePartitions).client = client
+
+	now := time.Unix(0, 0)
+	out.nowFn = func() time.Time {
+		return now
+	}
+
+	msg := func(year, month string) *service.Message {
+		m := service.NewMessage([]byte("hello world"))
+		m.MetaSet("year", year)
+		m.MetaSet("month", month)
+		return m
+	}
+
+	ctx := context.Background()
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		msg("2022", "02"),
+		msg("2022", "03"),
+		msg("2022", "03"),
+		msg("2022", "04"),
+	}))
+
+	assert.Equal(t, 1, client.getTables)
+	require.Len(t, client.partitions, 3)
+	p := client.partitions["s3://foo/bar/year=2022/month=03"]
+	require.NotNil(t, p)
+	assert.Equal(t, []string{"2022", "03"}, aws.StringValueSlice(p.Values))
+	assert.Equal(t, "org.apache.hadoop.mapred.TextInputFormat", aws.StringValue(p.StorageDescriptor.InputFormat))
+
+	// Known partitions aren't registered again until the TTL has passed.
+	delete(client.partitions, "s3://foo/bar/year=2022/month=03")
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{msg("2022", "03")}))
+	assert.Len(t, client.partitions, 2)
+
+	now = now.Add(time.Hour)
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{msg("2022", "03")}))
+	assert.Len(t, client.partitions, 3)
+	assert.Equal(t, 1, client.getTables)
+
+	require.EqualError(t, out.WriteBatch(ctx, service.MessageBatch{
+		msg("2022", ""),
+	}), "partition 'year=2022/month=' contains an empty value")
+
+	require.EqualError(t, out.WriteBatch(ctx, service.MessageBatch{
+		msg("2022", "03/day=01"),
+	}), "partition 'year=2022/month=03/day=01' does not match the partition keys of the table: year, month")
+
+	require.NoError(t, out.Close(ctx))
+}
+
+func TestMetastorePartitionsHiveLocation(t *testing.T) {
+	conf, err := metastorePartitionsOutputConfig().ParseYAML(`
+metastore: hive
+database: foo
+table: bar
+partition: year=${! meta("year") }
+location: s3://foo/bar/${! meta("year") }
+output:
+  drop: {}
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newMetastorePartitionsOutputFromConfig(conf)
+	require.EqualError(t, err, "the field location is not supported by the hive metastore")
+}
+
+// hiveReply writes a reply to an append_partition_by_name call, where a
+// non-zero exception ID results in an exception with a message.
+func hiveReply(ctx context.Context, prot thrift.TProtocol, seqID int32, excID int16) error {
+	if err := prot.WriteMessageBegin(ctx, "append_partition_by_name", thrift.REPLY, seqID); err != nil {
+		return err
+	}
+	if err := prot.WriteStructBegin(ctx, "append_partition_by_name_result"); err != nil {
+		return err
+	}
+	if excID == 0 {
+		// A partition struct with only the values field set.
+		if err := prot.WriteFieldBegin(ctx, "success", thrift.STRUCT, 0); err != nil {
+			return err
+		}
+		if err := prot.WriteStructBegin(ctx, "Partition"); err != nil {
+			return err
+		}
+		if err := prot.WriteFieldBegin(ctx, "values", thrift.LIST, 1); err != nil {
+			return err
+		}
+		if err := prot.WriteListBegin(ctx, thrift.STRING, 1); err != nil {
+			return err
+		}
+		if err := prot.WriteString(ctx, "2022"); err != nil {
+			return err
+		}
+		if err := prot.WriteListEnd(ctx); err != nil {
+			return err
+		}
+		if err := prot.WriteFieldEnd(ctx); err != nil {
+			return err
+		}
+	} else {
+		if err := prot.WriteFieldBegin(ctx, "", thrift.STRUCT, excID); err != nil {
+			return err
+		}
+		if err := prot.WriteStructBegin(ctx, "Exception"); err != nil {
+			return err
+		}
+		if err := prot.WriteFieldBegin(ctx, "message", thrift.STRING, 1); err != nil {
+			return err
+		}
+		if err := prot.WriteString(ctx, "nope"); err != nil {
+			return err
+		}
+		if err := prot.WriteFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+	if err := prot.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	if err := prot.WriteStructEnd(ctx); err != nil {
+		return err
+	}
+	if err := prot.WriteFieldEnd(ctx); err != nil {
+		return err
+	}
+	if err := prot.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	if err := prot.WriteStructEnd(ctx); err != nil {
+		return err
+	}
+	if err := prot.WriteMessageEnd(ctx); err != nil {
+		return err
+	}
+	return prot.Flush(ctx)
+}
+
+func TestHiveMetastoreAppendPartition(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	type call struct {
+		method string
+		args   []string
+	}
+	calls := make(chan call, 10)
+	excIDs := []int16{0, 2, 3}
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		ctx := context.Background()
+		conf := &thrift.TConfiguration{}
+		prot := thrift.NewTBinaryProtocolConf(thrift.NewTSocketFromConnConf(conn, conf), conf)
+		defer conn.Close()
+
+		for _, excID := range excIDs {
+			method, _, seqID, err := prot.ReadMessageBegin(ctx)
+			if err != nil {
+				return
+			}
+			c := call{method: method}
+			_, _ = prot.ReadStructBegin(ctx)
+			for {
+				_, fType, _, err := prot.ReadFieldBegin(ctx)
+				if err != nil || fType == thrift.STOP {
+					break
+				}
+				s, _ := prot.ReadString(ctx)
+				c.args = append(c.args, s)
+				_ = prot.ReadFieldEnd(ctx)
+			}
+			_ = prot.ReadStructEnd(ctx)
+			_ = prot.ReadMessageEnd(ctx)
+			calls <- c
+
+			if err := hiveReply(ctx, prot, seqID, excID); err != nil {
+				return
+			}
+		}
+	}()
+
+	client := newHiveMetastore(ln.Addr().String(), time.Second*5)
+	defer client.close()
+
+	ctx := context.Background()
+
+	created, err := client.appendPartition(ctx, "foo", "bar", "year=2022")
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, call{
+		method: "append_partition_by_name",
+		args:   []string{"foo", "bar", "year=2022"},
+	}, <-calls)
+
+	created, err = client.appendPartition(ctx, "foo", "bar", "year=2022")
+	require.NoError(t, err)
+	assert.False(t, created)
+	<-calls
+
+	_, err = client.appendPartition(ctx, "foo", "bar", "year=2023")
+	require.EqualError(t, err, "MetaException: nope")
+	<-calls
+}
@@ -2,10 +2,9 @@ package aws
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 
+	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -37,55 +36,64 @@ func sessionFields() []*service.ConfigField {
 				Default("").Advanced(),
 			service.NewStringField("role_external_id").
 				Description("An external ID to provide when assuming a role.").
-				Default("").Advanced()).
+				Default("").Advanced(),
+			service.NewObjectListField("role_chain",
+				service.NewStringField("role").
+					Description("A role ARN to assume.").
+					Default(""),
+				service.NewStringField("role_external_id").
+					Description("An external ID to provide when assuming the role.").
+					Default("")).
+				Description("A list of roles to assume in order after any role specified by `role`, where each role is assumed with the credentials of the previous role.").
+				Example([]interface{}{
+					map[string]interface{}{"role": "arn:aws:iam::123456789012:role/bar"},
+				}).
+				Default([]interface{}{}).Advanced(),
+			service.NewStringField("role_session_name").
+				Description("An optional name to identify the sessions of assumed roles, when empty a name is generated.").
+				Default("").Advanced(),
+			service.NewStringField("web_identity_token_file").
+				Description("The path to a file containing an OAuth 2.0 or OpenID Connect token with which to assume the role specified by `role`. The file is read each time the credentials are refreshed, allowing the token to be rotated.").
+				Example("/var/run/secrets/eks.amazonaws.com/serviceaccount/token").
+				Default("").Advanced(),
+			service.NewBoolField("sts_regional_endpoint").
+				Description("Whether to assume roles using the STS endpoint of the configured region rather than the global endpoint.").
+				Default(false).Advanced(),
+			service.NewStringField("expiry_window").
+				Description("The period of time before assumed role credentials expire at which they are refreshed.").
+				Default("1m").Advanced()).
 			Advanced().
 			Description("Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/cloud/aws)."),
+		service.NewHTTPProxyField("proxy"),
 	}
 }
 
 func getSession(parsedConf *service.ParsedConfig, opts ...func(*aws.Config)) (*session.Session, error) {
-	awsConf := aws.NewConfig()
-
-	if region, _ := parsedConf.FieldString("region"); region != "" {
-		awsConf = awsConf.WithRegion(region)
-	}
-	if endpoint, _ := parsedConf.FieldString("endpoint"); endpoint != "" {
-		awsConf = awsConf.WithEndpoint(endpoint)
-	}
-	if profile, _ := parsedConf.FieldString("credentials", "profile"); profile != "" {
-		awsConf = awsConf.WithCredentials(credentials.NewSharedCredentials(
-			"", profile,
-		))
-	} else if id, _ := parsedConf.FieldString("credentials", "id"); id != "" {
-		secret, _ := parsedConf.FieldString("credentials", "secret")
-		token, _ := parsedConf.FieldString("credentials", "token")
-		awsConf = awsConf.WithCredentials(credentials.NewStaticCredentials(
-			id, secret, token,
-		))
-	}
+	conf := sess.NewConfig()
+	conf.Region, _ = parsedConf.FieldString("region")
+	conf.Endpoint, _ = parsedConf.FieldString("endpoint")
+	conf.Proxy.URL, _ = parsedConf.FieldString("proxy", "url")
+	conf.Proxy.NoProxy, _ = parsedConf.FieldString("proxy", "no_proxy")
 
-	for _, opt := range opts {
-		opt(awsConf)
-	}
-
-	sess, err := session.NewSession(awsConf)
-	if err != nil {
-		return nil, err
-	}
+	creds := &conf.Credentials
+	creds.Profile, _ = parsedConf.FieldString("credentials", "profile")
+	creds.ID, _ = parsedConf.FieldString("credentials", "id")
+	creds.Secret, _ = parsedConf.FieldString("credentials", "secret")
+	creds.Token, _ = parsedConf.FieldString("credentials", "token")
+	creds.Role, _ = parsedConf.FieldString("credentials", "role")
+	creds.ExternalID, _ = parsedConf.FieldString("credentials", "role_external_id")
+	creds.RoleSessionName, _ = parsedConf.FieldString("credentials", "role_session_name")
+	creds.WebIdentityTokenFile, _ = parsedConf.FieldString("credentials", "web_identity_token_file")
+	creds.STSRegionalEndpoint, _ = parsedConf.FieldBool("credentials", "sts_regional_endpoint")
+	creds.ExpiryWindow, _ = parsedConf.FieldString("credentials", "expiry_window")
 
-	if role, _ := parsedConf.FieldString("credentials", "role"); role != "" {
-		var opts []func(*stscreds.AssumeRoleProvider)
-		if externalID, _ := parsedConf.FieldString("credentials", "role_external_id"); externalID != "" {
-			opts = []func(*stscreds.AssumeRoleProvider){
-				func(p *stscreds.AssumeRoleProvider) {
-					p.ExternalID = &externalID
-				},
-			}
-		}
-		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, role, opts...),
-		)
+	roleConfs, _ := parsedConf.FieldObjectList("credentials", "role_chain")
+	for _, rConf := range roleConfs {
+		var role sess.RoleConfig
+		role.Role, _ = rConf.FieldString("role")
+		role.ExternalID, _ = rConf.FieldString("role_external_id")
+		creds.RoleChain = append(creds.RoleChain, role)
 	}
 
-	return sess, nil
+	return conf.GetSession(opts...)
 }
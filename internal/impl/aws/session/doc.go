@@ -1,6 +1,9 @@
 package session
 
-import "github.com/benthosdev/benthos/v4/internal/docs"
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
+)
 
 // FieldSpecs returns documentation specs for AWS session fields.
 func FieldSpecs() docs.FieldSpecs {
@@ -16,6 +19,20 @@ func FieldSpecs() docs.FieldSpecs {
 				docs.FieldString("token", "The token for the credentials being used, required when using short term credentials.").HasDefault(""),
 				docs.FieldString("role", "A role ARN to assume.").HasDefault(""),
 				docs.FieldString("role_external_id", "An external ID to provide when assuming a role.").HasDefault(""),
+				docs.FieldObject(
+					"role_chain", "A list of roles to assume in order after any role specified by `role`, where each role is assumed with the credentials of the previous role.",
+					[]interface{}{
+						map[string]interface{}{"role": "arn:aws:iam::123456789012:role/bar"},
+					},
+				).Array().WithChildren(
+					docs.FieldString("role", "A role ARN to assume.").HasDefault(""),
+					docs.FieldString("role_external_id", "An external ID to provide when assuming the role.").HasDefault(""),
+				).HasDefault([]interface{}{}),
+				docs.FieldString("role_session_name", "An optional name to identify the sessions of assumed roles, when empty a name is generated.").HasDefault(""),
+				docs.FieldString("web_identity_token_file", "The path to a file containing an OAuth 2.0 or OpenID Connect token with which to assume the role specified by `role`. The file is read each time the credentials are refreshed, allowing the token to be rotated.", "/var/run/secrets/eks.amazonaws.com/serviceaccount/token").HasDefault(""),
+				docs.FieldBool("sts_regional_endpoint", "Whether to assume roles using the STS endpoint of the configured region rather than the global endpoint.").HasDefault(false),
+				docs.FieldString("expiry_window", "The period of time before assumed role credentials expire at which they are refreshed.").HasDefault("1m"),
 			),
+		proxy.FieldSpec(),
 	}
 }
@@ -1,52 +1,95 @@
 package session
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+
+	"github.com/benthosdev/benthos/v4/internal/proxy"
 )
 
 //------------------------------------------------------------------------------
 
-// CredentialsConfig contains configuration params for AWS credentials.
-type CredentialsConfig struct {
-	Profile    string `json:"profile" yaml:"profile"`
-	ID         string `json:"id" yaml:"id"`
-	Secret     string `json:"secret" yaml:"secret"`
-	Token      string `json:"token" yaml:"token"`
+// RoleConfig contains configuration params for a role assumed as part of a
+// role chain.
+type RoleConfig struct {
 	Role       string `json:"role" yaml:"role"`
 	ExternalID string `json:"role_external_id" yaml:"role_external_id"`
 }
 
+// CredentialsConfig contains configuration params for AWS credentials.
+type CredentialsConfig struct {
+	Profile              string       `json:"profile" yaml:"profile"`
+	ID                   string       `json:"id" yaml:"id"`
+	Secret               string       `json:"secret" yaml:"secret"`
+	Token                string       `json:"token" yaml:"token"`
+	Role                 string       `json:"role" yaml:"role"`
+	ExternalID           string       `json:"role_external_id" yaml:"role_external_id"`
+	RoleChain            []RoleConfig `json:"role_chain" yaml:"role_chain"`
+	RoleSessionName      string       `json:"role_session_name" yaml:"role_session_name"`
+	WebIdentityTokenFile string       `json:"web_identity_token_file" yaml:"web_identity_token_file"`
+	STSRegionalEndpoint  bool         `json:"sts_regional_endpoint" yaml:"sts_regional_endpoint"`
+	ExpiryWindow         string       `json:"expiry_window" yaml:"expiry_window"`
+}
+
 // Config contains configuration fields for an AWS session. This config is
 // common across any AWS components.
 type Config struct {
 	Credentials CredentialsConfig `json:"credentials" yaml:"credentials"`
 	Endpoint    string            `json:"endpoint" yaml:"endpoint"`
 	Region      string            `json:"region" yaml:"region"`
+	Proxy       proxy.Config      `json:"proxy" yaml:"proxy"`
 }
 
 // NewConfig returns a Config with default values.
 func NewConfig() Config {
 	return Config{
 		Credentials: CredentialsConfig{
-			Profile:    "",
-			ID:         "",
-			Secret:     "",
-			Token:      "",
-			Role:       "",
-			ExternalID: "",
+			Profile:              "",
+			ID:                   "",
+			Secret:               "",
+			Token:                "",
+			Role:                 "",
+			ExternalID:           "",
+			RoleChain:            []RoleConfig{},
+			RoleSessionName:      "",
+			WebIdentityTokenFile: "",
+			STSRegionalEndpoint:  false,
+			ExpiryWindow:         "1m",
 		},
 		Endpoint: "",
 		Region:   "",
+		Proxy:    proxy.NewConfig(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
 // GetSession attempts to create an AWS session based on Config.
+//
+// When roles are configured the credentials of the session are obtained by
+// assuming each role in turn, where the credentials of each role are used in
+// order to assume the next. These credentials are shared by all clients
+// created from the session and are refreshed automatically, including those of
+// each role earlier in the chain, once they are within the expiry window of
+// expiring.
 func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error) {
+	var expiryWindow time.Duration
+	if c.Credentials.ExpiryWindow != "" {
+		var err error
+		if expiryWindow, err = time.ParseDuration(c.Credentials.ExpiryWindow); err != nil {
+			return nil, fmt.Errorf("failed to parse expiry window: %w", err)
+		}
+	}
+
 	awsConf := aws.NewConfig()
 	if len(c.Region) > 0 {
 		awsConf = awsConf.WithRegion(c.Region)
@@ -56,6 +99,20 @@ func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error)
 		awsConf = awsConf.WithEndpoint(c.Endpoint)
 	}
 
+	proxyFn, err := c.Proxy.Func()
+	if err != nil {
+		return nil, err
+	}
+	if proxyFn != nil {
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.Proxy = proxyFn
+		awsConf = awsConf.WithHTTPClient(&http.Client{Transport: tr})
+	}
+
+	if c.Credentials.STSRegionalEndpoint {
+		awsConf = awsConf.WithSTSRegionalEndpoint(endpoints.RegionalSTSEndpoint)
+	}
+
 	if len(c.Credentials.Profile) > 0 {
 		awsConf = awsConf.WithCredentials(credentials.NewSharedCredentials(
 			"", c.Credentials.Profile,
@@ -77,21 +134,50 @@ func (c Config) GetSession(opts ...func(*aws.Config)) (*session.Session, error)
 		return nil, err
 	}
 
-	if len(c.Credentials.Role) > 0 {
-		var opts []func(*stscreds.AssumeRoleProvider)
-		if len(c.Credentials.ExternalID) > 0 {
-			opts = []func(*stscreds.AssumeRoleProvider){
-				func(p *stscreds.AssumeRoleProvider) {
-					p.ExternalID = &c.Credentials.ExternalID
-				},
-			}
+	if len(c.Credentials.WebIdentityTokenFile) > 0 {
+		if len(c.Credentials.Role) == 0 {
+			return nil, errors.New("a role must be specified in order to use a web identity token file")
 		}
+		// The token file is read each time the credentials are refreshed, which
+		// allows it to be rotated, as is the case with EKS service accounts.
+		provider := stscreds.NewWebIdentityRoleProviderWithOptions(
+			sts.New(sess), c.Credentials.Role, c.Credentials.RoleSessionName,
+			stscreds.FetchTokenPath(c.Credentials.WebIdentityTokenFile),
+			func(p *stscreds.WebIdentityRoleProvider) {
+				p.ExpiryWindow = expiryWindow
+			},
+		)
+		sess.Config = sess.Config.WithCredentials(credentials.NewCredentials(provider))
+	} else if len(c.Credentials.Role) > 0 {
+		sess.Config = sess.Config.WithCredentials(c.Credentials.assumeRole(
+			sess, RoleConfig{Role: c.Credentials.Role, ExternalID: c.Credentials.ExternalID}, expiryWindow,
+		))
+	}
+
+	for i, role := range c.Credentials.RoleChain {
+		if len(role.Role) == 0 {
+			return nil, fmt.Errorf("role chain index %v: a role must be specified", i)
+		}
+		// Each role is assumed by an STS client signed with the credentials of
+		// the previous role, which are refreshed independently.
 		sess.Config = sess.Config.WithCredentials(
-			stscreds.NewCredentials(sess, c.Credentials.Role, opts...),
+			c.Credentials.assumeRole(sess.Copy(), role, expiryWindow),
 		)
 	}
 
 	return sess, nil
 }
 
+func (c CredentialsConfig) assumeRole(sess *session.Session, role RoleConfig, expiryWindow time.Duration) *credentials.Credentials {
+	return stscreds.NewCredentials(sess, role.Role, func(p *stscreds.AssumeRoleProvider) {
+		if len(role.ExternalID) > 0 {
+			p.ExternalID = aws.String(role.ExternalID)
+		}
+		if len(c.RoleSessionName) > 0 {
+			p.RoleSessionName = c.RoleSessionName
+		}
+		p.ExpiryWindow = expiryWindow
+	})
+}
+
 //------------------------------------------------------------------------------
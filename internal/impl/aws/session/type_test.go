@@ -0,0 +1,182 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rolePrefix = "arn:aws:iam::123456789012:role/"
+
+type fakeSTSCall struct {
+	action, role, accessKey, token string
+}
+
+// fakeSTS responds to AssumeRole and AssumeRoleWithWebIdentity requests with
+// credentials that identify the role assumed, and records each call.
+func fakeSTS(t *testing.T, expiry time.Duration) (*httptest.Server, func() []fakeSTSCall) {
+	t.Helper()
+
+	var mut sync.Mutex
+	var calls []fakeSTSCall
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		call := fakeSTSCall{
+			action: r.Form.Get("Action"),
+			role:   r.Form.Get("RoleArn"),
+			token:  r.Form.Get("WebIdentityToken"),
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			call.accessKey = strings.Split(strings.SplitN(auth, "Credential=", 2)[1], "/")[0]
+		}
+
+		mut.Lock()
+		calls = append(calls, call)
+		mut.Unlock()
+
+		_, _ = fmt.Fprintf(w, `<%[1]vResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <%[1]vResult>
+    <Credentials>
+      <AccessKeyId>%[2]v</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>%[3]v</Expiration>
+    </Credentials>
+  </%[1]vResult>
+</%[1]vResponse>`, call.action, "key-"+strings.TrimPrefix(call.role, rolePrefix), time.Now().Add(expiry).UTC().Format(time.RFC3339))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []fakeSTSCall {
+		mut.Lock()
+		defer mut.Unlock()
+		return append([]fakeSTSCall(nil), calls...)
+	}
+}
+
+func TestSessionRoleChain(t *testing.T) {
+	srv, getCalls := fakeSTS(t, time.Hour)
+
+	conf := NewConfig()
+	conf.Region = "us-east-1"
+	conf.Endpoint = srv.URL
+	conf.Credentials.ID = "static"
+	conf.Credentials.Secret = "secret"
+	conf.Credentials.Role = rolePrefix + "a"
+	conf.Credentials.RoleChain = []RoleConfig{
+		{Role: rolePrefix + "b"},
+		{Role: rolePrefix + "c", ExternalID: "baz"},
+	}
+
+	sess, err := conf.GetSession()
+	require.NoError(t, err)
+
+	creds, err := sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "key-c", creds.AccessKeyID)
+
+	assert.Equal(t, []fakeSTSCall{
+		{action: "AssumeRole", role: rolePrefix + "a", accessKey: "static"},
+		{action: "AssumeRole", role: rolePrefix + "b", accessKey: "key-a"},
+		{action: "AssumeRole", role: rolePrefix + "c", accessKey: "key-b"},
+	}, getCalls())
+
+	// Credentials are cached until they are within the expiry window.
+	_, err = sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Len(t, getCalls(), 3)
+}
+
+func TestSessionRoleChainBadRole(t *testing.T) {
+	conf := NewConfig()
+	conf.Region = "us-east-1"
+	conf.Credentials.RoleChain = []RoleConfig{{}}
+
+	_, err := conf.GetSession()
+	require.EqualError(t, err, "role chain index 0: a role must be specified")
+}
+
+func TestSessionWebIdentityRefresh(t *testing.T) {
+	// Expiring within the expiry window forces a refresh on each retrieval.
+	srv, getCalls := fakeSTS(t, time.Second)
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("first"), 0o644))
+
+	conf := NewConfig()
+	conf.Region = "us-east-1"
+	conf.Endpoint = srv.URL
+	conf.Credentials.Role = rolePrefix + "a"
+	conf.Credentials.WebIdentityTokenFile = tokenPath
+	conf.Credentials.RoleChain = []RoleConfig{{Role: rolePrefix + "b"}}
+
+	sess, err := conf.GetSession()
+	require.NoError(t, err)
+
+	creds, err := sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "key-b", creds.AccessKeyID)
+
+	require.NoError(t, os.WriteFile(tokenPath, []byte("second"), 0o644))
+
+	creds, err = sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "key-b", creds.AccessKeyID)
+
+	assert.Equal(t, []fakeSTSCall{
+		{action: "AssumeRoleWithWebIdentity", role: rolePrefix + "a", token: "first"},
+		{action: "AssumeRole", role: rolePrefix + "b", accessKey: "key-a"},
+		{action: "AssumeRoleWithWebIdentity", role: rolePrefix + "a", token: "second"},
+		{action: "AssumeRole", role: rolePrefix + "b", accessKey: "key-a"},
+	}, getCalls())
+}
+
+func TestSessionWebIdentityNoRole(t *testing.T) {
+	conf := NewConfig()
+	conf.Region = "us-east-1"
+	conf.Credentials.WebIdentityTokenFile = "/tmp/nope"
+
+	_, err := conf.GetSession()
+	require.EqualError(t, err, "a role must be specified in order to use a web identity token file")
+}
+
+func TestSessionBadExpiryWindow(t *testing.T) {
+	conf := NewConfig()
+	conf.Credentials.ExpiryWindow = "nope"
+
+	_, err := conf.GetSession()
+	require.Error(t, err)
+}
+
+func TestSessionProxy(t *testing.T) {
+	srv, getCalls := fakeSTS(t, time.Hour)
+
+	// The fake STS server acts as the proxy, and therefore receives the
+	// requests sent to the unresolvable endpoint.
+	conf := NewConfig()
+	conf.Region = "us-east-1"
+	conf.Endpoint = "http://sts.benthos.invalid"
+	conf.Proxy.URL = srv.URL
+	conf.Credentials.ID = "static"
+	conf.Credentials.Secret = "secret"
+	conf.Credentials.Role = rolePrefix + "a"
+
+	sess, err := conf.GetSession()
+	require.NoError(t, err)
+
+	creds, err := sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "key-a", creds.AccessKeyID)
+	assert.Len(t, getCalls(), 1)
+}
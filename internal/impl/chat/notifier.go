@@ -0,0 +1,244 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func notifierCommonFields(mappingExample string) []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewBloblangField("mapping").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) that creates the payload of each notification. When omitted the raw contents of each message are sent as the text of the notification.").
+			Example(mappingExample).
+			Optional(),
+		service.NewDurationField("min_interval").
+			Description("The minimum period of time between notifications sent to the same destination, which prevents bursts of messages from hitting the rate limits of the platform. Set to `0s` in order to disable.").
+			Default("1s").
+			Advanced(),
+		service.NewIntField("max_retries").
+			Description("The maximum number of times a notification is retried after being rate limited by the platform, in which case the wait period given by the platform is respected.").
+			Default(3).
+			Advanced(),
+		service.NewDurationField("timeout").
+			Description("A timeout for each request.").
+			Default("10s").
+			Advanced(),
+		service.NewHTTPProxyField("proxy"),
+		service.NewIntField("max_in_flight").
+			Description("The maximum number of notifications to be sending in parallel at any given time. The default of one preserves the ordering of notifications.").
+			Default(1),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// errRateLimited is returned by a notifier request when the platform rejected
+// it due to rate limiting, along with the period to wait before retrying.
+type errRateLimited struct {
+	retryAfter time.Duration
+}
+
+func (e *errRateLimited) Error() string {
+	return fmt.Sprintf("rate limited, retry after %v", e.retryAfter)
+}
+
+// notifier holds the state shared by chat notification outputs, which is
+// mostly concerned with pacing requests to each destination and respecting the
+// rate limits of the platform.
+type notifier struct {
+	log *service.Logger
+
+	mapping     *bloblang.Executor
+	minInterval time.Duration
+	maxRetries  int
+	client      *http.Client
+
+	pacingMut sync.Mutex
+	nextSlot  map[string]time.Time
+}
+
+func newNotifierFromConfig(conf *service.ParsedConfig, log *service.Logger) (*notifier, error) {
+	n := &notifier{
+		log:      log,
+		nextSlot: map[string]time.Time{},
+	}
+
+	var err error
+	if conf.Contains("mapping") {
+		if n.mapping, err = conf.FieldBloblang("mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if n.minInterval, err = conf.FieldDuration("min_interval"); err != nil {
+		return nil, err
+	}
+	if n.maxRetries, err = conf.FieldInt("max_retries"); err != nil {
+		return nil, err
+	}
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	n.client = &http.Client{Timeout: timeout}
+
+	proxyFn, err := conf.FieldHTTPProxy("proxy")
+	if err != nil {
+		return nil, err
+	}
+	if proxyFn != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = proxyFn
+		n.client.Transport = transport
+	}
+	return n, nil
+}
+
+// payload returns the structured payload of a notification, either from the
+// mapping or by placing the raw message contents under the provided text key.
+func (n *notifier) payload(msg *service.Message, textKey string) (map[string]interface{}, error) {
+	if n.mapping == nil {
+		b, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{textKey: string(b)}, nil
+	}
+
+	res, err := msg.BloblangQuery(n.mapping)
+	if err != nil {
+		return nil, fmt.Errorf("mapping failed: %w", err)
+	}
+	if res == nil {
+		return nil, errors.New("mapping deleted the notification payload")
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		// Mappings that result in a string produce raw bytes.
+		b, err := res.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{textKey: string(b)}, nil
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		return obj, nil
+	}
+	return nil, fmt.Errorf("expected mapping to result in an object or string, got %T", v)
+}
+
+// await blocks until a notification can be sent to a destination without
+// exceeding the configured minimum interval.
+func (n *notifier) await(ctx context.Context, destination string) error {
+	if n.minInterval <= 0 {
+		return nil
+	}
+
+	n.pacingMut.Lock()
+	now := time.Now()
+	slot := n.nextSlot[destination]
+	if slot.Before(now) {
+		slot = now
+	}
+	n.nextSlot[destination] = slot.Add(n.minInterval)
+	n.pacingMut.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// send posts a JSON payload to a URL, retrying when the platform responds with
+// a rate limit error. The response body is returned when the request succeeds.
+func (n *notifier) send(ctx context.Context, destination, url string, headers map[string]string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := n.await(ctx, destination); err != nil {
+			return nil, err
+		}
+
+		resBody, err := n.post(ctx, url, headers, body)
+		var rlErr *errRateLimited
+		if err == nil || !errors.As(err, &rlErr) || attempt >= n.maxRetries {
+			return resBody, err
+		}
+
+		n.log.Warnf("Notification to '%v' was rate limited, retrying in %v", destination, rlErr.retryAfter)
+		select {
+		case <-time.After(rlErr.retryAfter):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (n *notifier) post(ctx context.Context, url string, headers map[string]string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		return nil, &errRateLimited{retryAfter: parseRetryAfter(res.Header)}
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, resBody)
+	}
+	return resBody, nil
+}
+
+// parseRetryAfter reads the period to wait before retrying from the standard
+// Retry-After header, falling back to one second when it's missing.
+func parseRetryAfter(h http.Header) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
+	}
+	return time.Second
+}
+
+func (n *notifier) close() {
+	n.client.CloseIdleConnections()
+}
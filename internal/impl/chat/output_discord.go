@@ -0,0 +1,117 @@
+package chat
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func discordOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Sends messages as notifications to [Discord](https://discord.com/) channels via webhooks.").
+		Description(`
+The payload of each notification can be created with a Bloblang mapping, which makes it possible to send rich messages with [embeds](https://discord.com/developers/docs/resources/channel#embed-object). The mapping may also result in a string, in which case it is sent as the content of the notification. Notifications can be sent as replies within a thread by setting ` + "`thread_id`" + `.
+
+### Rate Limits
+
+Discord webhooks are limited to a handful of messages per second, and therefore notifications sent to the same webhook and thread are spaced by at least ` + "`min_interval`" + `. When Discord responds with a rate limit error the notification is retried after the period it specifies.`).
+		Field(service.NewInterpolatedStringField("webhook_url").
+			Description("The webhook URL to send notifications to.").
+			Example("https://discord.com/api/webhooks/000/XXXX")).
+		Field(service.NewInterpolatedStringField("thread_id").
+			Description("An optional thread within the channel of the webhook to send notifications to.").
+			Example(`${! meta("discord_thread_id") }`).
+			Default("")).
+		Field(service.NewStringField("username").
+			Description("An optional username to override the default username of the webhook.").
+			Default(""))
+
+	for _, f := range notifierCommonFields(`root.content = "Alert **%v** is %v".format(this.name, this.status)
+root.embeds = [
+  { "title": this.name, "description": this.summary, "color": if this.status == "firing" { 15158332 } else { 3066993 } }
+]`) {
+		spec = spec.Field(f)
+	}
+	return spec
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"discord", discordOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt("max_in_flight")
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newDiscordWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type discordWriter struct {
+	*notifier
+
+	webhookURL *service.InterpolatedString
+	threadID   *service.InterpolatedString
+	username   string
+}
+
+func newDiscordWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*discordWriter, error) {
+	n, err := newNotifierFromConfig(conf, log)
+	if err != nil {
+		return nil, err
+	}
+	d := &discordWriter{notifier: n}
+	if d.webhookURL, err = conf.FieldInterpolatedString("webhook_url"); err != nil {
+		return nil, err
+	}
+	if d.threadID, err = conf.FieldInterpolatedString("thread_id"); err != nil {
+		return nil, err
+	}
+	if d.username, err = conf.FieldString("username"); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *discordWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (d *discordWriter) Write(ctx context.Context, msg *service.Message) error {
+	payload, err := d.payload(msg, "content")
+	if err != nil {
+		return err
+	}
+	if _, exists := payload["username"]; !exists && d.username != "" {
+		payload["username"] = d.username
+	}
+
+	webhookURL, err := url.Parse(d.webhookURL.String(msg))
+	if err != nil {
+		return err
+	}
+	query := webhookURL.Query()
+	if threadID := d.threadID.String(msg); threadID != "" {
+		query.Set("thread_id", threadID)
+	}
+	webhookURL.RawQuery = query.Encode()
+
+	urlStr := webhookURL.String()
+	_, err = d.send(ctx, urlStr, urlStr, nil, payload)
+	return err
+}
+
+func (d *discordWriter) Close(ctx context.Context) error {
+	d.close()
+	return nil
+}
@@ -0,0 +1,103 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func teamsOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Sends messages as notifications to [Microsoft Teams](https://www.microsoft.com/en/microsoft-teams) channels via incoming webhooks.").
+		Description(`
+The payload of each notification can be created with a Bloblang mapping. When the mapping results in an [Adaptive Card](https://adaptivecards.io/) (an object with the field ` + "`type`" + ` set to ` + "`AdaptiveCard`" + `) it is wrapped within the message envelope expected by Teams, otherwise the result is sent as is. The mapping may also result in a string, in which case it is sent as the text of the notification.
+
+Incoming webhooks do not support replying within threads.
+
+### Rate Limits
+
+Teams webhooks are limited to a handful of messages per second, and therefore notifications sent to the same webhook are spaced by at least ` + "`min_interval`" + `. When Teams responds with a rate limit error the notification is retried after the period it specifies.`).
+		Field(service.NewInterpolatedStringField("webhook_url").
+			Description("The incoming webhook URL to send notifications to.").
+			Example("https://example.webhook.office.com/webhookb2/XXXX"))
+
+	for _, f := range notifierCommonFields(`root.type = "AdaptiveCard"
+root.version = "1.4"
+root.body = [
+  { "type": "TextBlock", "size": "Medium", "weight": "Bolder", "text": this.name },
+  { "type": "TextBlock", "wrap": true, "text": this.summary }
+]`) {
+		spec = spec.Field(f)
+	}
+	return spec
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"teams", teamsOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt("max_in_flight")
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newTeamsWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type teamsWriter struct {
+	*notifier
+
+	webhookURL *service.InterpolatedString
+}
+
+func newTeamsWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*teamsWriter, error) {
+	n, err := newNotifierFromConfig(conf, log)
+	if err != nil {
+		return nil, err
+	}
+	t := &teamsWriter{notifier: n}
+	if t.webhookURL, err = conf.FieldInterpolatedString("webhook_url"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *teamsWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (t *teamsWriter) Write(ctx context.Context, msg *service.Message) error {
+	payload, err := t.payload(msg, "text")
+	if err != nil {
+		return err
+	}
+	if payload["type"] == "AdaptiveCard" {
+		payload = map[string]interface{}{
+			"type": "message",
+			"attachments": []interface{}{
+				map[string]interface{}{
+					"contentType": "application/vnd.microsoft.card.adaptive",
+					"content":     payload,
+				},
+			},
+		}
+	}
+
+	url := t.webhookURL.String(msg)
+	_, err = t.send(ctx, url, url, nil, payload)
+	return err
+}
+
+func (t *teamsWriter) Close(ctx context.Context) error {
+	t.close()
+	return nil
+}
@@ -0,0 +1,197 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type testRequest struct {
+	path    string
+	query   string
+	auth    string
+	payload map[string]interface{}
+}
+
+func testServer(t *testing.T, handler func(w http.ResponseWriter, req testRequest) bool) (*httptest.Server, func() []testRequest) {
+	t.Helper()
+
+	var mut sync.Mutex
+	var reqs []testRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		req := testRequest{
+			path:  r.URL.Path,
+			query: r.URL.RawQuery,
+			auth:  r.Header.Get("Authorization"),
+		}
+		require.NoError(t, json.Unmarshal(b, &req.payload))
+
+		if handler != nil && !handler(w, req) {
+			return
+		}
+
+		mut.Lock()
+		reqs = append(reqs, req)
+		mut.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() []testRequest {
+		mut.Lock()
+		defer mut.Unlock()
+		return append([]testRequest{}, reqs...)
+	}
+}
+
+func TestSlackBotToken(t *testing.T) {
+	srv, getReqs := testServer(t, func(w http.ResponseWriter, req testRequest) bool {
+		_, _ = w.Write([]byte(`{"ok":true}`))
+		return true
+	})
+
+	conf, err := slackOutputConfig().ParseYAML(`
+bot_token: xoxb-foo
+channel: ${! json("channel") }
+thread_ts: ${! json("ts") }
+api_url: `+srv.URL+`/api/chat.postMessage
+mapping: 'root = "hello " + this.name'
+min_interval: 0s
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newSlackWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(`{"channel":"#foo","ts":"123.456","name":"world"}`))))
+
+	reqs := getReqs()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "Bearer xoxb-foo", reqs[0].auth)
+	assert.Equal(t, map[string]interface{}{
+		"channel":   "#foo",
+		"thread_ts": "123.456",
+		"text":      "hello world",
+	}, reqs[0].payload)
+}
+
+func TestSlackAPIError(t *testing.T) {
+	srv, _ := testServer(t, func(w http.ResponseWriter, req testRequest) bool {
+		_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+		return true
+	})
+
+	conf, err := slackOutputConfig().ParseYAML(`
+bot_token: xoxb-foo
+channel: '#nope'
+api_url: `+srv.URL+`
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newSlackWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	err = w.Write(context.Background(), service.NewMessage([]byte(`hello`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "channel_not_found")
+}
+
+func TestSlackBadConfig(t *testing.T) {
+	for _, confStr := range []string{
+		``,
+		`bot_token: foo`,
+		`
+bot_token: foo
+webhook_url: http://example.com
+channel: bar
+`,
+	} {
+		conf, err := slackOutputConfig().ParseYAML(confStr, nil)
+		require.NoError(t, err)
+
+		_, err = newSlackWriterFromConfig(conf, nil)
+		assert.Error(t, err, confStr)
+	}
+}
+
+func TestTeamsAdaptiveCard(t *testing.T) {
+	srv, getReqs := testServer(t, nil)
+
+	conf, err := teamsOutputConfig().ParseYAML(`
+webhook_url: `+srv.URL+`
+mapping: |
+  root.type = "AdaptiveCard"
+  root.body = [ { "type": "TextBlock", "text": content().string() } ]
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newTeamsWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(`hello`))))
+
+	reqs := getReqs()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"type": "AdaptiveCard",
+					"body": []interface{}{
+						map[string]interface{}{"type": "TextBlock", "text": "hello"},
+					},
+				},
+			},
+		},
+	}, reqs[0].payload)
+}
+
+func TestDiscordRateLimitRetry(t *testing.T) {
+	var limited bool
+	srv, getReqs := testServer(t, func(w http.ResponseWriter, req testRequest) bool {
+		if !limited {
+			limited = true
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return false
+		}
+		return true
+	})
+
+	conf, err := discordOutputConfig().ParseYAML(`
+webhook_url: `+srv.URL+`/api/webhooks/1/foo
+thread_id: ${! meta("thread") }
+username: benthos
+min_interval: 0s
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newDiscordWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	msg := service.NewMessage([]byte(`hello`))
+	msg.MetaSet("thread", "123")
+	require.NoError(t, w.Write(context.Background(), msg))
+
+	reqs := getReqs()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "/api/webhooks/1/foo", reqs[0].path)
+	assert.Equal(t, "thread_id=123", reqs[0].query)
+	assert.Equal(t, map[string]interface{}{
+		"content":  "hello",
+		"username": "benthos",
+	}, reqs[0].payload)
+}
@@ -0,0 +1,401 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	dlFieldHost            = "host"
+	dlFieldContainers      = "containers"
+	dlFieldLabels          = "labels"
+	dlFieldFromBeginning   = "from_beginning"
+	dlFieldRefreshInterval = "refresh_interval"
+	dlFieldTLS             = "tls"
+)
+
+func dockerLogsInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Follows the logs of running Docker containers via the Docker Engine API, emitting each log line as a message.").
+		Description(`
+The containers to follow are listed when the input connects and then periodically every `+"`refresh_interval`"+`, and the logs of newly started containers are followed from their beginning. Containers can be selected by name or ID with `+"`containers`"+`, and by their labels with `+"`labels`"+`, otherwise the logs of all running containers are followed.
+
+Logs are read through the Docker Engine API rather than from the log files of containers, and therefore log files being rotated by the logging driver has no effect. When the logs of a container are interrupted, such as by the Docker daemon restarting, they are resumed from the last line read once the container is listed again.
+
+Log lines are emitted without the timestamp added by Docker, and the output of containers with a TTY allocated is emitted as if it were written to stdout. Since logs are read as they're written there is no way to redeliver them, and therefore messages that are rejected by the pipeline are retried indefinitely.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- docker_container_id
+- docker_container_name
+- docker_container_image
+- docker_stream
+- docker_timestamp
+- docker_label_*, for each label of the container
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField(dlFieldHost).
+			Description("The address of the Docker Engine API, which can be a unix socket with the scheme `unix`, or a TCP address with the scheme `tcp`, `http` or `https`.").
+			Default("unix:///var/run/docker.sock").
+			Example("tcp://localhost:2375")).
+		Field(service.NewStringListField(dlFieldContainers).
+			Description("An optional list of names or IDs of containers to follow. When empty all running containers that match `labels` are followed.").
+			Default([]string{}).
+			Example([]string{"nginx", "api"})).
+		Field(service.NewStringMapField(dlFieldLabels).
+			Description("An optional map of labels that containers must have in order to be followed, where an empty value matches any value of the label.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{"com.docker.compose.project": "shop"})).
+		Field(service.NewBoolField(dlFieldFromBeginning).
+			Description("Whether to read the logs of containers that are running when the input connects from their beginning, rather than only the lines written after the input connects.").
+			Default(false)).
+		Field(service.NewDurationField(dlFieldRefreshInterval).
+			Description("The period between each listing of containers, which determines how quickly new containers are followed.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewTLSToggledField(dlFieldTLS)).
+		Example("Collecting Compose Logs", `
+Here we follow the logs of the containers of a Docker Compose project and push them to Loki, where the service and stream of each line identifies its Loki stream:`,
+			`
+input:
+  docker_logs:
+    labels:
+      com.docker.compose.project: shop
+
+output:
+  loki:
+    url: http://localhost:3100/loki/api/v1/push
+    labels: |
+      root.service = meta("docker_label_com.docker.compose.service")
+      root.stream = meta("docker_stream")
+    timestamp: ${! meta("docker_timestamp") }
+    batching:
+      count: 100
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"docker_logs", dockerLogsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newDockerLogsInputFromConfig(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dockerContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type dockerLogsInput struct {
+	baseURL    string
+	client     *http.Client
+	containers []string
+	labels     map[string]string
+
+	tailer *logTailer
+}
+
+func newDockerLogsInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*dockerLogsInput, error) {
+	d := &dockerLogsInput{}
+
+	host, err := conf.FieldString(dlFieldHost)
+	if err != nil {
+		return nil, err
+	}
+	if d.containers, err = conf.FieldStringList(dlFieldContainers); err != nil {
+		return nil, err
+	}
+	if d.labels, err = conf.FieldStringMap(dlFieldLabels); err != nil {
+		return nil, err
+	}
+	fromBeginning, err := conf.FieldBool(dlFieldFromBeginning)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := conf.FieldDuration(dlFieldRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("refresh_interval must be larger than zero")
+	}
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(dlFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsEnabled {
+		transport.TLSClientConfig = tlsConf
+	}
+
+	scheme := "http"
+	if tlsEnabled {
+		scheme = "https"
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		d.baseURL = scheme + "://docker"
+	case "tcp", "http", "https":
+		if u.Scheme != "tcp" {
+			scheme = u.Scheme
+		}
+		d.baseURL = scheme + "://" + u.Host + strings.TrimSuffix(u.Path, "/")
+	default:
+		return nil, fmt.Errorf("host scheme '%v' is not supported", u.Scheme)
+	}
+
+	// Log streams are long lived and therefore requests have no timeout.
+	d.client = &http.Client{Transport: transport}
+	d.tailer = newLogTailer(d.list, "docker", interval, fromBeginning, log)
+	return d, nil
+}
+
+func (d *dockerLogsInput) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := d.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+	return res, nil
+}
+
+func (d *dockerLogsInput) getJSON(ctx context.Context, path string, query url.Values, v interface{}) error {
+	res, err := d.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// selected returns whether a container matches the containers field.
+func (d *dockerLogsInput) selected(c dockerContainer) bool {
+	if len(d.containers) == 0 {
+		return true
+	}
+	for _, want := range d.containers {
+		if strings.HasPrefix(c.ID, want) {
+			return true
+		}
+		for _, name := range c.Names {
+			if strings.TrimPrefix(name, "/") == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *dockerLogsInput) list(ctx context.Context) ([]logTarget, error) {
+	filters := map[string][]string{
+		"status": {"running"},
+	}
+	for k, v := range d.labels {
+		if v == "" {
+			filters["label"] = append(filters["label"], k)
+		} else {
+			filters["label"] = append(filters["label"], k+"="+v)
+		}
+	}
+	filtersBytes, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []dockerContainer
+	if err := d.getJSON(ctx, "/containers/json", url.Values{
+		"filters": []string{string(filtersBytes)},
+	}, &containers); err != nil {
+		return nil, err
+	}
+
+	var targets []logTarget
+	for _, c := range containers {
+		if !d.selected(c) {
+			continue
+		}
+
+		meta := map[string]string{
+			"docker_container_id":    c.ID,
+			"docker_container_image": c.Image,
+		}
+		if len(c.Names) > 0 {
+			meta["docker_container_name"] = strings.TrimPrefix(c.Names[0], "/")
+		}
+		for k, v := range c.Labels {
+			meta["docker_label_"+k] = v
+		}
+
+		id := c.ID
+		targets = append(targets, logTarget{
+			key:  id,
+			meta: meta,
+			stream: func(ctx context.Context, since time.Time, emit func(string, []byte) error) error {
+				return d.stream(ctx, id, since, emit)
+			},
+		})
+	}
+	return targets, nil
+}
+
+func (d *dockerLogsInput) stream(ctx context.Context, id string, since time.Time, emit func(string, []byte) error) error {
+	var info struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err := d.getJSON(ctx, "/containers/"+id+"/json", nil, &info); err != nil {
+		return err
+	}
+
+	query := url.Values{
+		"follow":     []string{"true"},
+		"stdout":     []string{"true"},
+		"stderr":     []string{"true"},
+		"timestamps": []string{"true"},
+	}
+	if !since.IsZero() {
+		query.Set("since", fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()))
+	}
+
+	res, err := d.get(ctx, "/containers/"+id+"/logs", query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if info.Config.Tty {
+		return readLines(res.Body, func(line []byte) error {
+			return emit("stdout", line)
+		})
+	}
+	return demuxDockerLogs(res.Body, emit)
+}
+
+// demuxDockerLogs reads the multiplexed stdout and stderr stream of a
+// container without a TTY, where each frame begins with an eight byte header
+// consisting of the stream type and the big-endian size of the frame.
+func demuxDockerLogs(r io.Reader, emit func(string, []byte) error) error {
+	var partial [3][]byte
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			for i, stream := range []string{"", "stdout", "stderr"} {
+				if len(partial[i]) == 0 {
+					continue
+				}
+				if err := emit(stream, bytes.TrimRight(partial[i], "\r")); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		streamType := header[0]
+		frame := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return err
+		}
+
+		var stream string
+		switch streamType {
+		case 1:
+			stream = "stdout"
+		case 2:
+			stream = "stderr"
+		default:
+			continue
+		}
+
+		// Lines that exceed the size of a frame are split across several.
+		data := append(partial[streamType], frame...)
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			if err := emit(stream, bytes.TrimRight(data[:i], "\r")); err != nil {
+				return err
+			}
+			data = data[i+1:]
+		}
+		partial[streamType] = append([]byte(nil), data...)
+	}
+}
+
+func (d *dockerLogsInput) Connect(ctx context.Context) error {
+	return d.tailer.Start(ctx)
+}
+
+func (d *dockerLogsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	msg, err := d.tailer.Read(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msg, func(ctx context.Context, err error) error {
+		return nil
+	}, nil
+}
+
+func (d *dockerLogsInput) Close(ctx context.Context) error {
+	return d.tailer.Close(ctx)
+}
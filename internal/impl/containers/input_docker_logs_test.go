@@ -0,0 +1,136 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func dockerFrame(stream byte, data string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, data...)
+}
+
+func readTestMessage(t *testing.T, read func(ctx context.Context) (*service.Message, service.AckFunc, error)) (string, map[string]string) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, _, err := read(ctx)
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+
+	meta := map[string]string{}
+	_ = msg.MetaWalk(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	return string(b), meta
+}
+
+func TestDockerLogsInput(t *testing.T) {
+	var filters map[string][]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/json":
+			require.NoError(t, json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters))
+			_, _ = w.Write([]byte(`[
+  {"Id":"aaa111","Names":["/foo"],"Image":"nginx","Labels":{"app":"shop"}},
+  {"Id":"bbb222","Names":["/bar"],"Image":"redis","Labels":{"app":"shop"}}
+]`))
+		case "/containers/aaa111/json":
+			_, _ = w.Write([]byte(`{"Config":{"Tty":false}}`))
+		case "/containers/aaa111/logs":
+			assert.Equal(t, "true", r.URL.Query().Get("follow"))
+			assert.Equal(t, "true", r.URL.Query().Get("timestamps"))
+			assert.Equal(t, "", r.URL.Query().Get("since"))
+
+			var buf bytes.Buffer
+			buf.Write(dockerFrame(1, "2022-06-01T10:00:00.000000001Z hello world\n"))
+			buf.Write(dockerFrame(2, "2022-06-01T10:00:00.000000002Z something went"))
+			buf.Write(dockerFrame(2, " wrong\n"))
+			_, _ = w.Write(buf.Bytes())
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			t.Errorf("unexpected request: %v", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conf, err := dockerLogsInputConfig().ParseYAML(`
+host: `+ts.URL+`
+containers: [ foo ]
+labels:
+  app: shop
+from_beginning: true
+`, nil)
+	require.NoError(t, err)
+
+	in, err := newDockerLogsInputFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, in.Connect(context.Background()))
+	defer func() {
+		require.NoError(t, in.Close(context.Background()))
+	}()
+
+	assert.Equal(t, map[string][]string{
+		"status": {"running"},
+		"label":  {"app=shop"},
+	}, filters)
+
+	content, meta := readTestMessage(t, in.Read)
+	assert.Equal(t, "hello world", content)
+	assert.Equal(t, map[string]string{
+		"docker_container_id":    "aaa111",
+		"docker_container_name":  "foo",
+		"docker_container_image": "nginx",
+		"docker_label_app":       "shop",
+		"docker_stream":          "stdout",
+		"docker_timestamp":       "2022-06-01T10:00:00.000000001Z",
+	}, meta)
+
+	content, meta = readTestMessage(t, in.Read)
+	assert.Equal(t, "something went wrong", content)
+	assert.Equal(t, "stderr", meta["docker_stream"])
+}
+
+func TestDockerLogsHost(t *testing.T) {
+	for _, test := range []struct {
+		host    string
+		baseURL string
+		err     string
+	}{
+		{host: "unix:///var/run/docker.sock", baseURL: "http://docker"},
+		{host: "tcp://localhost:2375", baseURL: "http://localhost:2375"},
+		{host: "https://localhost:2376/", baseURL: "https://localhost:2376"},
+		{host: "ftp://localhost", err: "host scheme 'ftp' is not supported"},
+	} {
+		conf, err := dockerLogsInputConfig().ParseYAML(`host: `+test.host, nil)
+		require.NoError(t, err)
+
+		in, err := newDockerLogsInputFromConfig(conf, nil)
+		if test.err != "" {
+			require.EqualError(t, err, test.err)
+			continue
+		}
+		require.NoError(t, err, test.host)
+		assert.Equal(t, test.baseURL, in.baseURL, test.host)
+	}
+}
@@ -0,0 +1,356 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	klFieldAPIURL          = "api_url"
+	klFieldTokenFile       = "token_file"
+	klFieldNamespaces      = "namespaces"
+	klFieldLabelSelector   = "label_selector"
+	klFieldContainers      = "containers"
+	klFieldFromBeginning   = "from_beginning"
+	klFieldRefreshInterval = "refresh_interval"
+	klFieldTLS             = "tls"
+)
+
+func kubernetesLogsInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Follows the logs of the containers of running Kubernetes pods via the Kubernetes API, emitting each log line as a message.").
+		Description(`
+The pods to follow are listed when the input connects and then periodically every `+"`refresh_interval`"+`, and the logs of containers of newly started pods are followed from their beginning. Pods can be selected by namespace with `+"`namespaces`"+` and by their labels with `+"`label_selector`"+`, and the containers of each pod to follow can be selected by name with `+"`containers`"+`.
+
+By default the input authenticates with the token of the service account of the pod that it runs within, which must be permitted to `+"`list`"+` pods and `+"`get`"+` the `+"`pods/log`"+` subresource in the selected namespaces. In order to verify the API server the field `+"`tls.root_cas_file`"+` should be set to the CA certificate of the service account, as shown in the example below.
+
+Logs are read through the Kubernetes API rather than from the log files of nodes, and therefore log files being rotated by the kubelet has no effect. When the logs of a container are interrupted, such as by the container restarting, they are resumed from the last line read once the pod is listed again.
+
+Log lines are emitted without the timestamp added by the container runtime. Since logs are read as they're written there is no way to redeliver them, and therefore messages that are rejected by the pipeline are retried indefinitely.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- kubernetes_namespace
+- kubernetes_pod_name
+- kubernetes_pod_uid
+- kubernetes_container_name
+- kubernetes_node_name
+- kubernetes_timestamp
+- kubernetes_label_*, for each label of the pod
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField(klFieldAPIURL).
+			Description("The URL of the Kubernetes API server.").
+			Default("https://kubernetes.default.svc")).
+		Field(service.NewStringField(klFieldTokenFile).
+			Description("The path of a file containing a bearer token to authenticate with, which is read before each request so that rotated tokens are used. Set this to an empty string in order to disable authentication, such as when connecting through `kubectl proxy`.").
+			Default("/var/run/secrets/kubernetes.io/serviceaccount/token")).
+		Field(service.NewStringListField(klFieldNamespaces).
+			Description("A list of namespaces of pods to follow. When empty the pods of all namespaces are followed.").
+			Default([]string{}).
+			Example([]string{"default", "shop"})).
+		Field(service.NewStringField(klFieldLabelSelector).
+			Description("An optional [label selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors) that pods must match in order to be followed.").
+			Default("").
+			Example("app=nginx").
+			Example("tier in (frontend,backend),environment!=dev")).
+		Field(service.NewStringListField(klFieldContainers).
+			Description("An optional list of names of the containers of each pod to follow. When empty all containers of each pod are followed.").
+			Default([]string{}).
+			Example([]string{"api"})).
+		Field(service.NewBoolField(klFieldFromBeginning).
+			Description("Whether to read the logs of containers that are running when the input connects from their beginning, rather than only the lines written after the input connects.").
+			Default(false)).
+		Field(service.NewDurationField(klFieldRefreshInterval).
+			Description("The period between each listing of pods, which determines how quickly new pods are followed.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewTLSField(klFieldTLS)).
+		Example("Collecting Namespace Logs", `
+Here we run Benthos as a pod with a service account that can read the logs of pods in the `+"`shop`"+` namespace, and forward the logs of every pod labelled `+"`tier=backend`"+` to Elasticsearch:`,
+			`
+input:
+  kubernetes_logs:
+    namespaces: [ shop ]
+    label_selector: tier=backend
+    tls:
+      root_cas_file: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+
+pipeline:
+  processors:
+    - bloblang: |
+        root.pod = meta("kubernetes_pod_name")
+        root.container = meta("kubernetes_container_name")
+        root.timestamp = meta("kubernetes_timestamp")
+        root.log = content().string()
+
+output:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: shop-logs
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"kubernetes_logs", kubernetesLogsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newKubernetesLogsInputFromConfig(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type kubernetesPodList struct {
+	Items []kubernetesPod `json:"items"`
+}
+
+type kubernetesPod struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		UID       string            `json:"uid"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName   string `json:"nodeName"`
+		Containers []struct {
+			Name string `json:"name"`
+		} `json:"containers"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+type kubernetesLogsInput struct {
+	apiURL        string
+	tokenFile     string
+	namespaces    []string
+	labelSelector string
+	containers    map[string]struct{}
+	client        *http.Client
+
+	tailer *logTailer
+}
+
+func newKubernetesLogsInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*kubernetesLogsInput, error) {
+	k := &kubernetesLogsInput{}
+
+	var err error
+	if k.apiURL, err = conf.FieldString(klFieldAPIURL); err != nil {
+		return nil, err
+	}
+	k.apiURL = strings.TrimSuffix(k.apiURL, "/")
+	if k.tokenFile, err = conf.FieldString(klFieldTokenFile); err != nil {
+		return nil, err
+	}
+	if k.namespaces, err = conf.FieldStringList(klFieldNamespaces); err != nil {
+		return nil, err
+	}
+	if k.labelSelector, err = conf.FieldString(klFieldLabelSelector); err != nil {
+		return nil, err
+	}
+
+	containers, err := conf.FieldStringList(klFieldContainers)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) > 0 {
+		k.containers = map[string]struct{}{}
+		for _, c := range containers {
+			k.containers[c] = struct{}{}
+		}
+	}
+
+	fromBeginning, err := conf.FieldBool(klFieldFromBeginning)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := conf.FieldDuration(klFieldRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("refresh_interval must be larger than zero")
+	}
+	tlsConf, err := conf.FieldTLS(klFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+
+	// Log streams are long lived and therefore requests have no timeout.
+	k.client = &http.Client{Transport: transport}
+	k.tailer = newLogTailer(k.list, "kubernetes", interval, fromBeginning, log)
+	return k, nil
+}
+
+func (k *kubernetesLogsInput) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := k.apiURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if k.tokenFile != "" {
+		token, err := os.ReadFile(k.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+string(bytes.TrimSpace(token)))
+	}
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+	return res, nil
+}
+
+func (k *kubernetesLogsInput) listPods(ctx context.Context, path string) ([]kubernetesPod, error) {
+	var query url.Values
+	if k.labelSelector != "" {
+		query = url.Values{"labelSelector": []string{k.labelSelector}}
+	}
+
+	res, err := k.get(ctx, path, query)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var pods kubernetesPodList
+	if err := json.NewDecoder(res.Body).Decode(&pods); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func (k *kubernetesLogsInput) list(ctx context.Context) ([]logTarget, error) {
+	var pods []kubernetesPod
+	if len(k.namespaces) == 0 {
+		var err error
+		if pods, err = k.listPods(ctx, "/api/v1/pods"); err != nil {
+			return nil, err
+		}
+	}
+	for _, ns := range k.namespaces {
+		nsPods, err := k.listPods(ctx, "/api/v1/namespaces/"+url.PathEscape(ns)+"/pods")
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, nsPods...)
+	}
+
+	var targets []logTarget
+	for _, pod := range pods {
+		if pod.Status.Phase != "Running" {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			if k.containers != nil {
+				if _, exists := k.containers[c.Name]; !exists {
+					continue
+				}
+			}
+
+			meta := map[string]string{
+				"kubernetes_namespace":      pod.Metadata.Namespace,
+				"kubernetes_pod_name":       pod.Metadata.Name,
+				"kubernetes_pod_uid":        pod.Metadata.UID,
+				"kubernetes_container_name": c.Name,
+				"kubernetes_node_name":      pod.Spec.NodeName,
+			}
+			for lk, lv := range pod.Metadata.Labels {
+				meta["kubernetes_label_"+lk] = lv
+			}
+
+			path := "/api/v1/namespaces/" + url.PathEscape(pod.Metadata.Namespace) +
+				"/pods/" + url.PathEscape(pod.Metadata.Name) + "/log"
+			container := c.Name
+			targets = append(targets, logTarget{
+				key:  pod.Metadata.UID + "/" + container,
+				meta: meta,
+				stream: func(ctx context.Context, since time.Time, emit func(string, []byte) error) error {
+					return k.stream(ctx, path, container, since, emit)
+				},
+			})
+		}
+	}
+	return targets, nil
+}
+
+func (k *kubernetesLogsInput) stream(ctx context.Context, path, container string, since time.Time, emit func(string, []byte) error) error {
+	query := url.Values{
+		"container":  []string{container},
+		"follow":     []string{"true"},
+		"timestamps": []string{"true"},
+	}
+	if !since.IsZero() {
+		query.Set("sinceTime", since.UTC().Format(time.RFC3339))
+	}
+
+	res, err := k.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return readLines(res.Body, func(line []byte) error {
+		return emit("", line)
+	})
+}
+
+func (k *kubernetesLogsInput) Connect(ctx context.Context) error {
+	return k.tailer.Start(ctx)
+}
+
+func (k *kubernetesLogsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	msg, err := k.tailer.Read(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msg, func(ctx context.Context, err error) error {
+		return nil
+	}, nil
+}
+
+func (k *kubernetesLogsInput) Close(ctx context.Context) error {
+	return k.tailer.Close(ctx)
+}
@@ -0,0 +1,103 @@
+package containers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetesLogsInput(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("foobar\n"), 0o644))
+
+	var mut sync.Mutex
+	var sinceTimes []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer foobar", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/api/v1/namespaces/shop/pods":
+			assert.Equal(t, "tier=backend", r.URL.Query().Get("labelSelector"))
+			_, _ = w.Write([]byte(`{"items":[
+  {
+    "metadata":{"name":"api-1","namespace":"shop","uid":"uid1","labels":{"tier":"backend"}},
+    "spec":{"nodeName":"node-a","containers":[{"name":"api"},{"name":"sidecar"}]},
+    "status":{"phase":"Running"}
+  },
+  {
+    "metadata":{"name":"api-2","namespace":"shop","uid":"uid2"},
+    "spec":{"nodeName":"node-b","containers":[{"name":"api"}]},
+    "status":{"phase":"Pending"}
+  }
+]}`))
+		case "/api/v1/namespaces/shop/pods/api-1/log":
+			assert.Equal(t, "api", r.URL.Query().Get("container"))
+			mut.Lock()
+			sinceTimes = append(sinceTimes, r.URL.Query().Get("sinceTime"))
+			first := len(sinceTimes) == 1
+			mut.Unlock()
+
+			if first {
+				// The first stream is interrupted after two lines.
+				_, _ = w.Write([]byte("2022-06-01T10:00:00.5Z first\n2022-06-01T10:00:01.5Z second\n"))
+				return
+			}
+			_, _ = w.Write([]byte("2022-06-01T10:00:01.5Z second\n2022-06-01T10:00:02.5Z third\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			t.Errorf("unexpected request: %v", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	conf, err := kubernetesLogsInputConfig().ParseYAML(`
+api_url: `+ts.URL+`
+token_file: `+tokenFile+`
+namespaces: [ shop ]
+label_selector: tier=backend
+containers: [ api ]
+from_beginning: true
+refresh_interval: 10ms
+`, nil)
+	require.NoError(t, err)
+
+	in, err := newKubernetesLogsInputFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, in.Connect(context.Background()))
+	defer func() {
+		require.NoError(t, in.Close(context.Background()))
+	}()
+
+	content, meta := readTestMessage(t, in.Read)
+	assert.Equal(t, "first", content)
+	assert.Equal(t, map[string]string{
+		"kubernetes_namespace":      "shop",
+		"kubernetes_pod_name":       "api-1",
+		"kubernetes_pod_uid":        "uid1",
+		"kubernetes_container_name": "api",
+		"kubernetes_node_name":      "node-a",
+		"kubernetes_label_tier":     "backend",
+		"kubernetes_timestamp":      "2022-06-01T10:00:00.5Z",
+	}, meta)
+
+	content, _ = readTestMessage(t, in.Read)
+	assert.Equal(t, "second", content)
+
+	// The resumed stream repeats the second line, which is skipped.
+	content, _ = readTestMessage(t, in.Read)
+	assert.Equal(t, "third", content)
+
+	mut.Lock()
+	assert.Equal(t, []string{"", "2022-06-01T10:00:01Z"}, sinceTimes)
+	mut.Unlock()
+}
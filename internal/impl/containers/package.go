@@ -0,0 +1,3 @@
+// Package containers contains components that collect the logs of containers
+// managed by Docker or Kubernetes.
+package containers
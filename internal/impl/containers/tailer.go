@@ -0,0 +1,276 @@
+package containers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// logTarget is a log stream of a single container.
+type logTarget struct {
+	// key uniquely identifies the container.
+	key string
+
+	// meta is added to each message of the container.
+	meta map[string]string
+
+	// stream follows the logs of the container written after since, and calls
+	// emit with each line, until either the logs end or the context is
+	// cancelled. Lines are expected to be prefixed with an RFC 3339 timestamp.
+	stream func(ctx context.Context, since time.Time, emit func(stream string, line []byte) error) error
+}
+
+// logLister lists the containers that logs should currently be collected
+// from.
+type logLister func(ctx context.Context) ([]logTarget, error)
+
+// logTailer follows the logs of each container found by a lister, and
+// periodically refreshes the list in order to follow new containers. The logs
+// of a container that end (due to the container stopping, or the connection
+// being interrupted) are resumed from the last line read when it is next
+// found by the lister.
+type logTailer struct {
+	list          logLister
+	metaPrefix    string
+	interval      time.Duration
+	fromBeginning bool
+	log           *service.Logger
+
+	msgChan chan *service.Message
+
+	mut       sync.Mutex
+	active    map[string]context.CancelFunc
+	positions map[string]time.Time
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	started bool
+	wg      sync.WaitGroup
+}
+
+func newLogTailer(list logLister, metaPrefix string, interval time.Duration, fromBeginning bool, log *service.Logger) *logTailer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &logTailer{
+		list:          list,
+		metaPrefix:    metaPrefix,
+		interval:      interval,
+		fromBeginning: fromBeginning,
+		log:           log,
+		msgChan:       make(chan *service.Message),
+		active:        map[string]context.CancelFunc{},
+		positions:     map[string]time.Time{},
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// Start lists the containers for the first time and begins following their
+// logs, an error is returned if the first list fails.
+func (l *logTailer) Start(ctx context.Context) error {
+	l.mut.Lock()
+	started := l.started
+	l.mut.Unlock()
+	if started {
+		return nil
+	}
+
+	targets, err := l.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if !l.fromBeginning {
+		now := time.Now()
+		for _, t := range targets {
+			l.positions[t.key] = now
+		}
+	}
+	l.reconcile(targets)
+
+	l.started = true
+	l.wg.Add(1)
+	go l.refreshLoop()
+	return nil
+}
+
+func (l *logTailer) refreshLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.ctx.Done():
+			return
+		}
+
+		targets, err := l.list(l.ctx)
+		if err != nil {
+			if l.ctx.Err() == nil {
+				l.log.Errorf("Failed to list containers: %v", err)
+			}
+			continue
+		}
+
+		l.mut.Lock()
+		l.reconcile(targets)
+		l.mut.Unlock()
+	}
+}
+
+// reconcile follows the logs of targets that aren't already being followed,
+// and stops following those that are no longer listed. The mutex must be held
+// by the caller.
+func (l *logTailer) reconcile(targets []logTarget) {
+	seen := map[string]struct{}{}
+	for _, t := range targets {
+		seen[t.key] = struct{}{}
+		if _, exists := l.active[t.key]; exists {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(l.ctx)
+		l.active[t.key] = cancel
+
+		l.wg.Add(1)
+		go l.follow(ctx, t, l.positions[t.key])
+	}
+
+	for k, cancel := range l.active {
+		if _, exists := seen[k]; !exists {
+			cancel()
+			delete(l.active, k)
+		}
+	}
+	for k := range l.positions {
+		if _, exists := seen[k]; !exists {
+			delete(l.positions, k)
+		}
+	}
+}
+
+func (l *logTailer) follow(ctx context.Context, t logTarget, since time.Time) {
+	defer func() {
+		l.mut.Lock()
+		if cancel, exists := l.active[t.key]; exists {
+			cancel()
+			delete(l.active, t.key)
+		}
+		l.mut.Unlock()
+		l.wg.Done()
+	}()
+
+	l.log.Debugf("Following logs of container %v", t.key)
+	err := t.stream(ctx, since, func(stream string, line []byte) error {
+		ts, content := splitTimestamp(line)
+		if !ts.IsZero() {
+			// Logs are resumed from the second of the last line read, and
+			// therefore lines that were already read are skipped.
+			if !ts.After(since) {
+				return nil
+			}
+			since = ts
+
+			l.mut.Lock()
+			if _, exists := l.active[t.key]; exists {
+				l.positions[t.key] = ts
+			}
+			l.mut.Unlock()
+		}
+
+		msg := service.NewMessage(content)
+		for k, v := range t.meta {
+			msg.MetaSet(k, v)
+		}
+		if stream != "" {
+			msg.MetaSet(l.metaPrefix+"_stream", stream)
+		}
+		if !ts.IsZero() {
+			msg.MetaSet(l.metaPrefix+"_timestamp", ts.UTC().Format(time.RFC3339Nano))
+		}
+
+		select {
+		case l.msgChan <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		l.log.Warnf("Logs of container %v were interrupted: %v", t.key, err)
+	}
+}
+
+// Read returns the next log line of any container.
+func (l *logTailer) Read(ctx context.Context) (*service.Message, error) {
+	select {
+	case msg := <-l.msgChan:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops following all logs.
+func (l *logTailer) Close(ctx context.Context) error {
+	l.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// splitTimestamp splits the RFC 3339 timestamp prefix from a log line, and
+// returns a zero time when the line has no such prefix.
+func splitTimestamp(line []byte) (time.Time, []byte) {
+	i := bytes.IndexByte(line, ' ')
+	if i <= 0 {
+		if ts, err := time.Parse(time.RFC3339Nano, string(line)); err == nil {
+			return ts, nil
+		}
+		return time.Time{}, line
+	}
+	ts, err := time.Parse(time.RFC3339Nano, string(line[:i]))
+	if err != nil {
+		return time.Time{}, line
+	}
+	return ts, line[i+1:]
+}
+
+// readLines calls fn with each line of r, without the trailing line break.
+func readLines(r io.Reader, fn func(line []byte) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if fErr := fn(bytes.TrimRight(line, "\r\n")); fErr != nil {
+				return fErr
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package containers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitTimestamp(t *testing.T) {
+	ts, content := splitTimestamp([]byte("2022-06-01T10:00:00.123Z hello world"))
+	assert.True(t, time.Date(2022, 6, 1, 10, 0, 0, 123000000, time.UTC).Equal(ts))
+	assert.Equal(t, "hello world", string(content))
+
+	ts, content = splitTimestamp([]byte("2022-06-01T10:00:00Z"))
+	assert.False(t, ts.IsZero())
+	assert.Empty(t, content)
+
+	ts, content = splitTimestamp([]byte("hello world"))
+	assert.True(t, ts.IsZero())
+	assert.Equal(t, "hello world", string(content))
+}
+
+func TestLogTailerFromNow(t *testing.T) {
+	sinceChan := make(chan time.Time, 1)
+	list := func(ctx context.Context) ([]logTarget, error) {
+		return []logTarget{{
+			key: "foo",
+			stream: func(ctx context.Context, since time.Time, emit func(string, []byte) error) error {
+				sinceChan <- since
+				<-ctx.Done()
+				return nil
+			},
+		}}, nil
+	}
+
+	before := time.Now()
+	tailer := newLogTailer(list, "test", time.Hour, false, nil)
+	require.NoError(t, tailer.Start(context.Background()))
+
+	select {
+	case since := <-sinceChan:
+		assert.False(t, since.Before(before))
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	require.NoError(t, tailer.Close(context.Background()))
+}
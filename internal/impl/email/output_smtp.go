@@ -0,0 +1,699 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func smtpOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Sends messages as emails via an SMTP server.").
+		Description(`
+The recipients, sender and subject of each email are interpolated from the message. By default the contents of the message become the plain text body of the email, but the text and HTML bodies can instead be created with [Bloblang mappings](/docs/guides/bloblang/about), which is useful for rendering templated reports and alerts from structured data.
+
+### Attachments
+
+When `+"`attach_batch`"+` is set to `+"`true`"+` each batch of messages is sent as a single email, where the first message of the batch is used in order to resolve the fields and bodies of the email, and all following messages are attached to it. The filename and content type of each attachment are interpolated from the attached message, which means they can be set with metadata by processors such as `+"[`parse_email`](/docs/components/processors/parse_email)"+`.
+
+### Connections
+
+Connections to the SMTP server are kept open and reused between emails, up to a maximum of `+"`max_in_flight`"+` connections. Connections that remain idle for longer than `+"`idle_timeout`"+` are closed.`).
+		Field(service.NewStringField("address").
+			Description("The address of the SMTP server.").
+			Example("smtp.example.com:587")).
+		Field(service.NewStringAnnotatedEnumField("tls_mode", map[string]string{
+			"none":     "Connect without TLS.",
+			"starttls": "Connect without TLS and upgrade the connection with the STARTTLS command, failing if the server does not support it.",
+			"implicit": "Connect with TLS from the start, as is common with port 465.",
+		}).Description("How to secure the connection to the server.").
+			Default("starttls")).
+		Field(service.NewTLSField("tls")).
+		Field(service.NewStringField("username").
+			Description("An optional username to authenticate with using the PLAIN mechanism.").
+			Default("")).
+		Field(service.NewStringField("password").
+			Description("An optional password to authenticate with using the PLAIN mechanism.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("from").
+			Description("The address to send emails from.").
+			Example(`Benthos <alerts@example.com>`)).
+		Field(service.NewInterpolatedStringField("to").
+			Description("A comma separated list of addresses to send emails to.").
+			Example(`ops@example.com, ${! json("owner_email") }`)).
+		Field(service.NewInterpolatedStringField("cc").
+			Description("An optional comma separated list of addresses to copy emails to.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("bcc").
+			Description("An optional comma separated list of addresses to blind copy emails to.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("subject").
+			Description("The subject of emails.").
+			Example(`Alert: ${! json("alert_name") }`)).
+		Field(service.NewStringMapField("headers").
+			Description("A map of additional headers to add to emails, values support [interpolation functions](/docs/configuration/interpolation#bloblang-queries).").
+			Default(map[string]interface{}{}).
+			Advanced()).
+		Field(service.NewBloblangField("text_mapping").
+			Description("An optional Bloblang mapping that creates the plain text body of emails. When neither this field nor `html_mapping` are set the raw contents of the message are used as the plain text body.").
+			Example(`root = "Alert %v fired at %v".format(this.alert_name, this.timestamp)`).
+			Optional()).
+		Field(service.NewBloblangField("html_mapping").
+			Description("An optional Bloblang mapping that creates the HTML body of emails.").
+			Example(`root = "<h1>%v</h1><p>%v</p>".format(this.alert_name.escape_html(), this.summary.escape_html())`).
+			Optional()).
+		Field(service.NewBoolField("attach_batch").
+			Description("Whether each batch should be sent as a single email with all messages after the first added as attachments.").
+			Default(false)).
+		Field(service.NewInterpolatedStringField("attachment_filename").
+			Description("The filename of attachments, interpolated from the attached message.").
+			Default(`attachment_${! batch_index() }`).
+			Example(`${! meta("email_attachment_filename") }`)).
+		Field(service.NewInterpolatedStringField("attachment_content_type").
+			Description("The content type of attachments, interpolated from the attached message.").
+			Default("application/octet-stream").
+			Example(`${! meta("email_attachment_content_type") }`)).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for an email to be sent.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewDurationField("idle_timeout").
+			Description("The maximum period of time a connection to the server remains idle before it is closed.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of emails to be sending in parallel at any given time, which is also the maximum number of open connections.").
+			Default(4)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Alert Emails", `
+Here we send an email for each alert consumed from Kafka to the owner of the alert, with a plain text and an HTML body rendered from the alert:`,
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ alerts ]
+    consumer_group: alert_mailer
+
+output:
+  smtp:
+    address: smtp.example.com:587
+    username: alerts
+    password: ${SMTP_PASSWORD}
+    from: Alerts <alerts@example.com>
+    to: ${! json("owner_email") }
+    subject: '[${! json("severity").uppercase() }] ${! json("name") }'
+    text_mapping: root = this.summary
+    html_mapping: |
+      root = "<h1>%v</h1><p>%v</p>".format(this.name.escape_html(), this.summary.escape_html())
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("smtp", smtpOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newSMTPWriterFromConfig(conf, maxInFlight, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type smtpHeader struct {
+	key   string
+	value *service.InterpolatedString
+}
+
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+type smtpWriter struct {
+	log *service.Logger
+
+	address  string
+	host     string
+	tlsMode  string
+	tlsConf  *tls.Config
+	username string
+	password string
+
+	from        *service.InterpolatedString
+	to          *service.InterpolatedString
+	cc          *service.InterpolatedString
+	bcc         *service.InterpolatedString
+	subject     *service.InterpolatedString
+	headers     []smtpHeader
+	textMapping *bloblang.Executor
+	htmlMapping *bloblang.Executor
+
+	attachBatch           bool
+	attachmentFilename    *service.InterpolatedString
+	attachmentContentType *service.InterpolatedString
+
+	timeout     time.Duration
+	idleTimeout time.Duration
+
+	poolMut sync.Mutex
+	pool    []*smtpConn
+	maxIdle int
+}
+
+func newSMTPWriterFromConfig(conf *service.ParsedConfig, maxIdle int, log *service.Logger) (*smtpWriter, error) {
+	s := &smtpWriter{
+		log:     log,
+		maxIdle: maxIdle,
+	}
+
+	var err error
+	if s.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+	if s.host, _, err = net.SplitHostPort(s.address); err != nil {
+		return nil, fmt.Errorf("failed to parse address: %w", err)
+	}
+	if s.tlsMode, err = conf.FieldString("tls_mode"); err != nil {
+		return nil, err
+	}
+	switch s.tlsMode {
+	case "none", "starttls", "implicit":
+	default:
+		return nil, fmt.Errorf("tls_mode not recognised: %v", s.tlsMode)
+	}
+	if s.tlsConf, err = conf.FieldTLS("tls"); err != nil {
+		return nil, err
+	}
+	if s.tlsConf == nil {
+		s.tlsConf = &tls.Config{}
+	}
+	if s.tlsConf.ServerName == "" {
+		s.tlsConf.ServerName = s.host
+	}
+	if s.username, err = conf.FieldString("username"); err != nil {
+		return nil, err
+	}
+	if s.password, err = conf.FieldString("password"); err != nil {
+		return nil, err
+	}
+
+	for _, f := range []struct {
+		target **service.InterpolatedString
+		name   string
+	}{
+		{&s.from, "from"},
+		{&s.to, "to"},
+		{&s.cc, "cc"},
+		{&s.bcc, "bcc"},
+		{&s.subject, "subject"},
+		{&s.attachmentFilename, "attachment_filename"},
+		{&s.attachmentContentType, "attachment_content_type"},
+	} {
+		if *f.target, err = conf.FieldInterpolatedString(f.name); err != nil {
+			return nil, err
+		}
+	}
+
+	headers, err := conf.FieldStringMap("headers")
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		value, err := service.NewInterpolatedString(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse header '%v' expression: %w", k, err)
+		}
+		s.headers = append(s.headers, smtpHeader{key: k, value: value})
+	}
+	sort.Slice(s.headers, func(i, j int) bool {
+		return s.headers[i].key < s.headers[j].key
+	})
+
+	if conf.Contains("text_mapping") {
+		if s.textMapping, err = conf.FieldBloblang("text_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("html_mapping") {
+		if s.htmlMapping, err = conf.FieldBloblang("html_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if s.attachBatch, err = conf.FieldBool("attach_batch"); err != nil {
+		return nil, err
+	}
+	if s.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	if s.idleTimeout, err = conf.FieldDuration("idle_timeout"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *smtpWriter) dial(ctx context.Context) (*smtpConn, error) {
+	dialer := &net.Dialer{}
+
+	var conn net.Conn
+	var err error
+	if s.tlsMode == "implicit" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.tlsConf}).DialContext(ctx, "tcp", s.address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", s.address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if s.tlsMode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("server does not support STARTTLS")
+		}
+		if err = client.StartTLS(s.tlsConf); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if s.username != "" {
+		if err = client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+// acquire returns an idle connection from the pool when one is available, or
+// dials a new connection otherwise. Idle connections are checked with a reset
+// outside of the pool lock and bounded by a deadline, so that an unresponsive
+// server doesn't block other writers.
+func (s *smtpWriter) acquire(ctx context.Context) (*smtpConn, error) {
+	for {
+		s.poolMut.Lock()
+		if len(s.pool) == 0 {
+			s.poolMut.Unlock()
+			break
+		}
+		c := s.pool[len(s.pool)-1]
+		s.pool = s.pool[:len(s.pool)-1]
+		s.poolMut.Unlock()
+
+		if time.Since(c.lastUsed) < s.idleTimeout {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				deadline = time.Now().Add(s.timeout)
+			}
+			_ = c.conn.SetDeadline(deadline)
+			if c.client.Reset() == nil {
+				_ = c.conn.SetDeadline(time.Time{})
+				return c, nil
+			}
+		}
+		_ = c.client.Close()
+	}
+	return s.dial(ctx)
+}
+
+func (s *smtpWriter) release(c *smtpConn) {
+	c.lastUsed = time.Now()
+	s.poolMut.Lock()
+	if len(s.pool) < s.maxIdle {
+		s.pool = append(s.pool, c)
+		c = nil
+	}
+	s.poolMut.Unlock()
+	if c != nil {
+		_ = c.client.Quit()
+	}
+}
+
+func (s *smtpWriter) Connect(ctx context.Context) error {
+	c, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	s.release(c)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type smtpEmail struct {
+	from       string
+	recipients []string
+	data       []byte
+}
+
+func parseAddresses(field, list string) ([]*mail.Address, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	addrs, err := mail.ParseAddressList(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %v addresses: %w", field, err)
+	}
+	return addrs, nil
+}
+
+func formatAddresses(addrs []*mail.Address) string {
+	strs := make([]string, len(addrs))
+	for i, a := range addrs {
+		strs[i] = a.String()
+	}
+	return strings.Join(strs, ", ")
+}
+
+func (s *smtpWriter) mapBody(batch service.MessageBatch, exec *bloblang.Executor) ([]byte, error) {
+	res, err := batch.BloblangQuery(0, exec)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, nil
+	}
+	return res.AsBytes()
+}
+
+func writeQuotedPrintable(w *multipart.Writer, contentType string, body []byte) error {
+	pw, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(pw)
+	if _, err = qw.Write(body); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// base64LineWriter splits base64 output into lines of 76 characters as
+// required by RFC 2045.
+type base64LineWriter struct {
+	w    *bytes.Buffer
+	line int
+}
+
+func (b *base64LineWriter) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if b.line == 76 {
+			b.w.WriteString("\r\n")
+			b.line = 0
+		}
+		b.w.WriteByte(c)
+		b.line++
+	}
+	return len(p), nil
+}
+
+func (s *smtpWriter) buildEmail(batch service.MessageBatch) (*smtpEmail, error) {
+	from, err := mail.ParseAddress(batch.InterpolatedString(0, s.from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse from address: %w", err)
+	}
+
+	var to, cc, bcc []*mail.Address
+	if to, err = parseAddresses("to", batch.InterpolatedString(0, s.to)); err != nil {
+		return nil, err
+	}
+	if cc, err = parseAddresses("cc", batch.InterpolatedString(0, s.cc)); err != nil {
+		return nil, err
+	}
+	if bcc, err = parseAddresses("bcc", batch.InterpolatedString(0, s.bcc)); err != nil {
+		return nil, err
+	}
+	if len(to)+len(cc)+len(bcc) == 0 {
+		return nil, errors.New("email has no recipients")
+	}
+
+	var textBody, htmlBody []byte
+	if s.textMapping == nil && s.htmlMapping == nil {
+		if textBody, err = batch[0].AsBytes(); err != nil {
+			return nil, err
+		}
+	}
+	if s.textMapping != nil {
+		if textBody, err = s.mapBody(batch, s.textMapping); err != nil {
+			return nil, fmt.Errorf("text mapping failed: %w", err)
+		}
+	}
+	if s.htmlMapping != nil {
+		if htmlBody, err = s.mapBody(batch, s.htmlMapping); err != nil {
+			return nil, fmt.Errorf("html mapping failed: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	writeHeader := func(k, v string) {
+		buf.WriteString(k + ": " + v + "\r\n")
+	}
+
+	writeHeader("From", from.String())
+	if len(to) > 0 {
+		writeHeader("To", formatAddresses(to))
+	}
+	if len(cc) > 0 {
+		writeHeader("Cc", formatAddresses(cc))
+	}
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", batch.InterpolatedString(0, s.subject)))
+	writeHeader("Date", time.Now().Format(time.RFC1123Z))
+	writeHeader("Message-ID", newMessageID(from.Address))
+	writeHeader("MIME-Version", "1.0")
+	for _, h := range s.headers {
+		writeHeader(h.key, mime.QEncoding.Encode("utf-8", batch.InterpolatedString(0, h.value)))
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mixed.Boundary()}))
+	buf.WriteString("\r\n")
+
+	altBoundary := multipart.NewWriter(nil).Boundary()
+	altPart, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": altBoundary})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	alternative := multipart.NewWriter(altPart)
+	if err = alternative.SetBoundary(altBoundary); err != nil {
+		return nil, err
+	}
+	if textBody != nil {
+		if err = writeQuotedPrintable(alternative, `text/plain; charset="utf-8"`, textBody); err != nil {
+			return nil, err
+		}
+	}
+	if htmlBody != nil {
+		if err = writeQuotedPrintable(alternative, `text/html; charset="utf-8"`, htmlBody); err != nil {
+			return nil, err
+		}
+	}
+	if err = alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	if s.attachBatch {
+		for i := 1; i < len(batch); i++ {
+			data, err := batch[i].AsBytes()
+			if err != nil {
+				return nil, err
+			}
+			filename := batch.InterpolatedString(i, s.attachmentFilename)
+			aw, err := mixed.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {mime.FormatMediaType(batch.InterpolatedString(i, s.attachmentContentType), map[string]string{"name": filename})},
+				"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filename})},
+				"Content-Transfer-Encoding": {"base64"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			var encoded bytes.Buffer
+			enc := base64.NewEncoder(base64.StdEncoding, &base64LineWriter{w: &encoded})
+			_, _ = enc.Write(data)
+			_ = enc.Close()
+			if _, err = aw.Write(encoded.Bytes()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err = mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	e := &smtpEmail{from: from.Address, data: buf.Bytes()}
+	for _, list := range [][]*mail.Address{to, cc, bcc} {
+		for _, a := range list {
+			e.recipients = append(e.recipients, a.Address)
+		}
+	}
+	return e, nil
+}
+
+func newMessageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return "<" + hex.EncodeToString(b[:]) + "@" + domain + ">"
+}
+
+//------------------------------------------------------------------------------
+
+func (s *smtpWriter) send(c *smtp.Client, e *smtpEmail) error {
+	if err := c.Mail(e.from); err != nil {
+		return err
+	}
+	for _, r := range e.recipients {
+		if err := c.Rcpt(r); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(e.data); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *smtpWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	if s.attachBatch {
+		e, err := s.buildEmail(batch)
+		if err != nil {
+			return err
+		}
+		return s.sendAll(ctx, []*smtpEmail{e}, nil)
+	}
+
+	// Each message is sent as its own email, and therefore each has its own
+	// error so that emails that were already delivered aren't sent again.
+	errs := make([]error, len(batch))
+	emails := make([]*smtpEmail, len(batch))
+	for i := range batch {
+		if emails[i], errs[i] = s.buildEmail(batch[i : i+1]); errs[i] != nil {
+			emails[i] = nil
+		}
+	}
+	_ = s.sendAll(ctx, emails, errs)
+
+	var bErr *service.BatchError
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if bErr == nil {
+			bErr = service.NewBatchError(batch, err)
+		}
+		bErr.Failed(i, err)
+	}
+	if bErr != nil {
+		return bErr
+	}
+	return nil
+}
+
+// sendAll sends emails over a single connection, skipping nil emails. When
+// errs is non-nil the error of each email that could not be sent is set at its
+// index, which includes all emails remaining after a failure as the state of
+// the connection is unknown at that point.
+func (s *smtpWriter) sendAll(ctx context.Context, emails []*smtpEmail, errs []error) error {
+	ctx, done := context.WithTimeout(ctx, s.timeout)
+	defer done()
+
+	setErrs := func(from int, err error) error {
+		for i := from; i < len(errs); i++ {
+			if emails[i] != nil {
+				errs[i] = err
+			}
+		}
+		return err
+	}
+
+	c, err := s.acquire(ctx)
+	if err != nil {
+		return setErrs(0, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.conn.SetDeadline(deadline)
+	}
+	for i, e := range emails {
+		if e == nil {
+			continue
+		}
+		if err := s.send(c.client, e); err != nil {
+			// The state of the connection is unknown after a failure.
+			_ = c.client.Close()
+			return setErrs(i, err)
+		}
+	}
+	_ = c.conn.SetDeadline(time.Time{})
+	s.release(c)
+	return nil
+}
+
+func (s *smtpWriter) Close(ctx context.Context) error {
+	s.poolMut.Lock()
+	pool := s.pool
+	s.pool = nil
+	s.poolMut.Unlock()
+	for _, c := range pool {
+		_ = c.client.Quit()
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type receivedEmail struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+// fakeSMTPServer accepts connections and records the emails sent to it, it
+// supports just enough of the protocol for net/smtp to deliver emails.
+type fakeSMTPServer struct {
+	ln net.Listener
+
+	mut       sync.Mutex
+	conns     int
+	emails    []receivedEmail
+	hangReset bool
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mut.Lock()
+			s.conns++
+			s.mut.Unlock()
+			go s.handle(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+	})
+	return s
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ready")
+
+	var current receivedEmail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 localhost")
+		case "MAIL":
+			current = receivedEmail{from: strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")}
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			if strings.Contains(line, "reject") {
+				_ = tp.PrintfLine("550 No such user")
+				continue
+			}
+			current.recipients = append(current.recipients, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 Go ahead")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			current.data = string(data)
+			s.mut.Lock()
+			s.emails = append(s.emails, current)
+			s.mut.Unlock()
+			_ = tp.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			s.mut.Lock()
+			hang := s.hangReset
+			s.mut.Unlock()
+			if hang {
+				// Never respond, the connection is closed by the client.
+				_, _ = io.Copy(io.Discard, conn)
+				return
+			}
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) received() ([]receivedEmail, int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]receivedEmail{}, s.emails...), s.conns
+}
+
+func testSMTPWriter(t *testing.T, confStr string) *smtpWriter {
+	t.Helper()
+
+	conf, err := smtpOutputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	w, err := newSMTPWriterFromConfig(conf, 2, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = w.Close(context.Background())
+	})
+	return w
+}
+
+func TestSMTPOutputBodies(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+
+	w := testSMTPWriter(t, `
+address: `+srv.ln.Addr().String()+`
+tls_mode: none
+from: Alerts <alerts@example.com>
+to: ${! json("to") }
+bcc: audit@example.com
+subject: 'Alert: ${! json("name") }'
+text_mapping: 'root = "text " + this.name'
+html_mapping: 'root = "<b>" + this.name + "</b>"'
+`)
+
+	require.NoError(t, w.Connect(context.Background()))
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"to":"a@example.com","name":"foo"}`)),
+		service.NewMessage([]byte(`{"to":"b@example.com","name":"bar"}`)),
+	}))
+
+	emails, conns := srv.received()
+	assert.Equal(t, 1, conns)
+	require.Len(t, emails, 2)
+
+	assert.Equal(t, "alerts@example.com", emails[0].from)
+	assert.Equal(t, []string{"a@example.com", "audit@example.com"}, emails[0].recipients)
+	assert.Equal(t, []string{"b@example.com", "audit@example.com"}, emails[1].recipients)
+
+	msg, err := mail.ReadMessage(strings.NewReader(emails[0].data))
+	require.NoError(t, err)
+	assert.Equal(t, "Alert: foo", msg.Header.Get("Subject"))
+	assert.Empty(t, msg.Header.Get("Bcc"))
+
+	var e parsedEmail
+	require.NoError(t, e.walkPart(msg.Header, msg.Body, 0))
+	assert.Equal(t, "text foo", e.text)
+	assert.Equal(t, "<b>foo</b>", e.html)
+	assert.Empty(t, e.attachments)
+}
+
+func TestSMTPOutputAttachBatch(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+
+	w := testSMTPWriter(t, `
+address: `+srv.ln.Addr().String()+`
+tls_mode: none
+from: reports@example.com
+to: a@example.com
+subject: Report
+attach_batch: true
+attachment_filename: ${! meta("filename") }
+attachment_content_type: text/csv
+`)
+
+	attachment := service.NewMessage([]byte(strings.Repeat("a,b\n1,2\n", 20)))
+	attachment.MetaSet("filename", "report.csv")
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`See attached`)),
+		attachment,
+	}))
+
+	emails, _ := srv.received()
+	require.Len(t, emails, 1)
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(emails[0].data)))
+	require.NoError(t, err)
+
+	var e parsedEmail
+	require.NoError(t, e.walkPart(msg.Header, msg.Body, 0))
+	assert.Equal(t, "See attached", e.text)
+	require.Len(t, e.attachments, 1)
+	assert.Equal(t, "report.csv", e.attachments[0].filename)
+	assert.Equal(t, "text/csv", e.attachments[0].contentType)
+	assert.Equal(t, strings.Repeat("a,b\n1,2\n", 20), string(e.attachments[0].data))
+}
+
+func TestSMTPOutputPartialFailure(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+
+	w := testSMTPWriter(t, `
+address: `+srv.ln.Addr().String()+`
+tls_mode: none
+from: reports@example.com
+to: ${! json("to") }
+subject: Report
+`)
+
+	err := w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"to":"a@example.com"}`)),
+		service.NewMessage([]byte(`{}`)),
+		service.NewMessage([]byte(`{"to":"b@example.com"}`)),
+		service.NewMessage([]byte(`{"to":"reject@example.com"}`)),
+		service.NewMessage([]byte(`{"to":"c@example.com"}`)),
+	})
+	require.Error(t, err)
+
+	// The message without recipients, the rejected message and the message
+	// after it that was never sent have failed.
+	var bErr *service.BatchError
+	require.True(t, errors.As(err, &bErr))
+	assert.Equal(t, 3, bErr.IndexedErrors())
+
+	emails, _ := srv.received()
+	require.Len(t, emails, 2)
+	assert.Equal(t, []string{"a@example.com"}, emails[0].recipients)
+	assert.Equal(t, []string{"b@example.com"}, emails[1].recipients)
+
+	// The failed connection is not reused.
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"to":"c@example.com"}`)),
+	}))
+	emails, conns := srv.received()
+	require.Len(t, emails, 3)
+	assert.Equal(t, 2, conns)
+}
+
+func TestSMTPOutputPooledConnHangs(t *testing.T) {
+	srv := newFakeSMTPServer(t)
+
+	w := testSMTPWriter(t, `
+address: `+srv.ln.Addr().String()+`
+tls_mode: none
+from: reports@example.com
+to: a@example.com
+subject: Report
+timeout: 200ms
+`)
+	require.NoError(t, w.Connect(context.Background()))
+
+	srv.mut.Lock()
+	srv.hangReset = true
+	srv.mut.Unlock()
+
+	// Resetting the pooled connection is bounded by the timeout, after which
+	// the connection is discarded and the next write dials a new one.
+	started := time.Now()
+	require.Error(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`hello`)),
+	}))
+	assert.Less(t, time.Since(started), time.Second*5)
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`hello`)),
+	}))
+	emails, conns := srv.received()
+	require.Len(t, emails, 1)
+	assert.Equal(t, 2, conns)
+}
+
+func TestSMTPOutputNoRecipients(t *testing.T) {
+	w := testSMTPWriter(t, `
+address: localhost:25
+from: reports@example.com
+to: ${! json("to") }
+subject: Report
+`)
+
+	_, err := w.buildEmail(service.MessageBatch{service.NewMessage([]byte(`{}`))})
+	require.Error(t, err)
+}
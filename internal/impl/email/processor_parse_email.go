@@ -0,0 +1,358 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func parseEmailProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Parsing").
+		Summary("Parses email messages in RFC 822 (MIME) format into structured JSON documents.").
+		Description(`
+Each message is replaced with a JSON object of the form:
+
+`+"```json"+`
+{
+  "message_id": "<abc@example.com>",
+  "subject": "Hello",
+  "from": [{"name":"Ash","address":"ash@example.com"}],
+  "to": [{"name":"","address":"bob@example.com"}],
+  "cc": [],
+  "bcc": [],
+  "reply_to": [],
+  "date": "2022-01-01T10:00:00Z",
+  "headers": {"X-Mailer":["Foo"]},
+  "text": "plain text body",
+  "html": "<p>html body</p>",
+  "attachments": [{"filename":"a.pdf","content_type":"application/pdf","content_id":"","size":1024}]
+}
+`+"```"+`
+
+Header values are decoded from their encoded word form, and bodies are decoded from their transfer encoding and converted to UTF-8 from their declared character set. Nested multipart structures are traversed, where the first text and HTML parts found become the bodies and all other parts with a filename or an attachment disposition are considered attachments.
+
+When `+"`attachments`"+` is set to `+"`split`"+` each attachment is added to the resulting batch as a separate message following the parsed email, containing the decoded contents of the attachment and with the following metadata fields:
+
+- email_message_id
+- email_attachment_index
+- email_attachment_filename
+- email_attachment_content_type
+- email_attachment_content_id
+
+This makes it possible to route attachments to object storage whilst processing the email itself separately, for example with a `+"[`switch` output](/docs/components/outputs/switch)"+` that checks for the metadata field `+"`email_attachment_index`"+`.`).
+		Field(service.NewStringAnnotatedEnumField("attachments", map[string]string{
+			"split":  "Add the decoded contents of each attachment to the batch as a separate message.",
+			"inline": "Include the base64 encoded contents of each attachment within the `attachments` array of the parsed email under the field `content`.",
+			"drop":   "Include only attachment metadata within the parsed email.",
+		}).Description("Determines how the contents of attachments are emitted.").
+			Default("split")).
+		Field(service.NewIntField("max_attachment_size").
+			Description("The maximum size in bytes of an attachment to emit the contents of, attachments exceeding this size are listed with metadata only. Set to zero in order to remove the limit.").
+			Default(0).
+			Advanced()).
+		Example("Store Attachments", `
+Here we parse inbound emails delivered to an S3 bucket by SES, writing each attachment to a separate bucket and the parsed email to Kafka:`,
+			`
+input:
+  aws_s3:
+    bucket: inbound-mail
+    prefix: messages/
+
+pipeline:
+  processors:
+    - parse_email:
+        attachments: split
+
+output:
+  switch:
+    cases:
+      - check: meta("email_attachment_index") != null
+        output:
+          aws_s3:
+            bucket: mail-attachments
+            path: ${! meta("email_message_id") }/${! meta("email_attachment_filename") }
+      - output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: emails
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"parse_email", parseEmailProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newParseEmailProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: charset.NewReaderLabel,
+}
+
+func decodeHeader(v string) string {
+	if dec, err := wordDecoder.DecodeHeader(v); err == nil {
+		return dec
+	}
+	return v
+}
+
+type parsedAttachment struct {
+	filename    string
+	contentType string
+	contentID   string
+	data        []byte
+}
+
+type parsedEmail struct {
+	text        string
+	html        string
+	attachments []parsedAttachment
+}
+
+// walkPart extracts the bodies and attachments of a MIME part, recursing into
+// multipart contents.
+func (e *parsedEmail) walkPart(header map[string][]string, body io.Reader, depth int) error {
+	h := mail.Header(header)
+
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") && depth < 32 {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("multipart content type %v is missing a boundary", mediaType)
+		}
+		mr := multipart.NewReader(body, boundary)
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := e.walkPart(p.Header, p, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransferEncoding(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("failed to decode %v part: %w", mediaType, err)
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	filename = decodeHeader(filename)
+
+	isAttachment := disposition == "attachment" || filename != ""
+	if !isAttachment {
+		switch {
+		case mediaType == "text/plain" && e.text == "":
+			e.text = decodeCharset(params["charset"], data)
+			return nil
+		case mediaType == "text/html" && e.html == "":
+			e.html = decodeCharset(params["charset"], data)
+			return nil
+		}
+	}
+
+	e.attachments = append(e.attachments, parsedAttachment{
+		filename:    filename,
+		contentType: mediaType,
+		contentID:   strings.Trim(h.Get("Content-ID"), "<>"),
+		data:        data,
+	})
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// Line breaks within base64 bodies are ignored by the decoder.
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+func decodeCharset(label string, data []byte) string {
+	if label == "" || strings.EqualFold(label, "utf-8") || strings.EqualFold(label, "us-ascii") {
+		return string(data)
+	}
+	r, err := charset.NewReaderLabel(label, bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+func addressList(h mail.Header, key string) []interface{} {
+	addrs := []interface{}{}
+	list, err := h.AddressList(key)
+	if err != nil {
+		// Fall back to the raw header value when it isn't a valid address list.
+		if v := h.Get(key); v != "" {
+			addrs = append(addrs, map[string]interface{}{
+				"name":    "",
+				"address": decodeHeader(v),
+			})
+		}
+		return addrs
+	}
+	for _, a := range list {
+		addrs = append(addrs, map[string]interface{}{
+			"name":    a.Name,
+			"address": a.Address,
+		})
+	}
+	return addrs
+}
+
+//------------------------------------------------------------------------------
+
+type parseEmailProcessor struct {
+	attachmentsMode   string
+	maxAttachmentSize int
+}
+
+func newParseEmailProcessorFromConfig(conf *service.ParsedConfig) (*parseEmailProcessor, error) {
+	p := &parseEmailProcessor{}
+
+	var err error
+	if p.attachmentsMode, err = conf.FieldString("attachments"); err != nil {
+		return nil, err
+	}
+	switch p.attachmentsMode {
+	case "split", "inline", "drop":
+	default:
+		return nil, fmt.Errorf("attachments mode not recognised: %v", p.attachmentsMode)
+	}
+	if p.maxAttachmentSize, err = conf.FieldInt("max_attachment_size"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parseEmailProcessor) emitContents(a parsedAttachment) bool {
+	return p.maxAttachmentSize <= 0 || len(a.data) <= p.maxAttachmentSize
+}
+
+func (p *parseEmailProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	mBytes, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := mail.ReadMessage(bytes.NewReader(mBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email: %w", err)
+	}
+
+	var e parsedEmail
+	if err := e.walkPart(m.Header, m.Body, 0); err != nil {
+		return nil, fmt.Errorf("failed to parse email body: %w", err)
+	}
+
+	headers := make(map[string]interface{}, len(m.Header))
+	for k, values := range m.Header {
+		decoded := make([]interface{}, len(values))
+		for i, v := range values {
+			decoded[i] = decodeHeader(v)
+		}
+		headers[k] = decoded
+	}
+
+	messageID := m.Header.Get("Message-Id")
+	doc := map[string]interface{}{
+		"message_id": messageID,
+		"subject":    decodeHeader(m.Header.Get("Subject")),
+		"from":       addressList(m.Header, "From"),
+		"to":         addressList(m.Header, "To"),
+		"cc":         addressList(m.Header, "Cc"),
+		"bcc":        addressList(m.Header, "Bcc"),
+		"reply_to":   addressList(m.Header, "Reply-To"),
+		"date":       nil,
+		"headers":    headers,
+		"text":       e.text,
+		"html":       e.html,
+	}
+	if date, err := m.Header.Date(); err == nil {
+		doc["date"] = date.UTC().Format(time.RFC3339)
+	}
+
+	attachments := make([]interface{}, 0, len(e.attachments))
+	for _, a := range e.attachments {
+		aObj := map[string]interface{}{
+			"filename":     a.filename,
+			"content_type": a.contentType,
+			"content_id":   a.contentID,
+			"size":         int64(len(a.data)),
+		}
+		if p.attachmentsMode == "inline" && p.emitContents(a) {
+			aObj["content"] = base64.StdEncoding.EncodeToString(a.data)
+		}
+		attachments = append(attachments, aObj)
+	}
+	doc["attachments"] = attachments
+
+	resMsg := msg.Copy()
+	resMsg.SetStructured(doc)
+	batch := service.MessageBatch{resMsg}
+
+	if p.attachmentsMode != "split" {
+		return batch, nil
+	}
+	for i, a := range e.attachments {
+		if !p.emitContents(a) {
+			continue
+		}
+		aMsg := msg.Copy()
+		aMsg.SetBytes(a.data)
+		aMsg.MetaSet("email_message_id", messageID)
+		aMsg.MetaSet("email_attachment_index", strconv.Itoa(i))
+		aMsg.MetaSet("email_attachment_filename", a.filename)
+		aMsg.MetaSet("email_attachment_content_type", a.contentType)
+		aMsg.MetaSet("email_attachment_content_id", a.contentID)
+		batch = append(batch, aMsg)
+	}
+	return batch, nil
+}
+
+func (p *parseEmailProcessor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+var testEmail = strings.ReplaceAll(`From: "Ash" <ash@example.com>
+To: bob@example.com, Carol <carol@example.com>
+Subject: =?UTF-8?B?SGVsbG8gd29ybGQg8J+Riw==?=
+Date: Sat, 01 Jan 2022 10:00:00 +0100
+Message-ID: <abc@example.com>
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain; charset="iso-8859-1"
+Content-Transfer-Encoding: quoted-printable
+
+Caf=E9 au lait
+--inner
+Content-Type: text/html; charset="utf-8"
+
+<p>Caf=C3=A9</p>
+--inner--
+--outer
+Content-Type: text/csv; name="data.csv"
+Content-Disposition: attachment; filename="data.csv"
+Content-Transfer-Encoding: base64
+
+YSxiCjEsMgo=
+--outer--
+`, "\n", "\r\n")
+
+func testParseEmail(t *testing.T, confStr string) service.MessageBatch {
+	t.Helper()
+
+	conf, err := parseEmailProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newParseEmailProcessorFromConfig(conf)
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(testEmail)))
+	require.NoError(t, err)
+	return batch
+}
+
+func TestParseEmailSplit(t *testing.T) {
+	batch := testParseEmail(t, ``)
+	require.Len(t, batch, 2)
+
+	v, err := batch[0].AsStructured()
+	require.NoError(t, err)
+
+	doc := v.(map[string]interface{})
+	assert.Equal(t, "<abc@example.com>", doc["message_id"])
+	assert.Equal(t, "Hello world 👋", doc["subject"])
+	assert.Equal(t, "2022-01-01T09:00:00Z", doc["date"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "Ash", "address": "ash@example.com"},
+	}, doc["from"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "", "address": "bob@example.com"},
+		map[string]interface{}{"name": "Carol", "address": "carol@example.com"},
+	}, doc["to"])
+	assert.Equal(t, "Café au lait", doc["text"])
+	assert.Equal(t, "<p>Caf=C3=A9</p>", doc["html"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"filename":     "data.csv",
+			"content_type": "text/csv",
+			"content_id":   "",
+			"size":         int64(8),
+		},
+	}, doc["attachments"])
+
+	b, err := batch[1].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(b))
+
+	for k, exp := range map[string]string{
+		"email_message_id":              "<abc@example.com>",
+		"email_attachment_index":        "0",
+		"email_attachment_filename":     "data.csv",
+		"email_attachment_content_type": "text/csv",
+	} {
+		v, _ := batch[1].MetaGet(k)
+		assert.Equal(t, exp, v, k)
+	}
+}
+
+func TestParseEmailInline(t *testing.T) {
+	batch := testParseEmail(t, `attachments: inline`)
+	require.Len(t, batch, 1)
+
+	v, err := batch[0].AsStructured()
+	require.NoError(t, err)
+
+	attachments := v.(map[string]interface{})["attachments"].([]interface{})
+	require.Len(t, attachments, 1)
+	assert.Equal(t, "YSxiCjEsMgo=", attachments[0].(map[string]interface{})["content"])
+}
+
+func TestParseEmailMaxAttachmentSize(t *testing.T) {
+	batch := testParseEmail(t, `max_attachment_size: 4`)
+	require.Len(t, batch, 1)
+}
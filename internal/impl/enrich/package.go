@@ -0,0 +1,3 @@
+// Package enrich contains processors that enrich messages with reference data
+// loaded from external sources.
+package enrich
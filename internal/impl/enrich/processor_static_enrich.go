@@ -0,0 +1,347 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	sepFieldURL             = "url"
+	sepFieldFormat          = "format"
+	sepFieldKeyField        = "key_field"
+	sepFieldKey             = "key"
+	sepFieldFields          = "fields"
+	sepFieldTarget          = "target"
+	sepFieldOnMissing       = "on_missing"
+	sepFieldRefreshInterval = "refresh_interval"
+	sepFieldHeaders         = "headers"
+	sepFieldS3              = "s3"
+	sepFieldS3Region        = "region"
+	sepFieldS3Endpoint      = "endpoint"
+)
+
+func staticEnrichProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Integration").
+		Summary("Joins fields from a lookup table held in memory into messages by key, where the table is loaded from a file, S3 object or URL and periodically reloaded.").
+		Description(`
+The lookup table is loaded from the `+"`url`"+`, which can either be a path on the local filesystem, an HTTP(S) URL, or an S3 object in the form `+"`s3://<bucket>/<key>`"+`. The table is indexed by the value of the `+"`key_field`"+` of each row, and for each message the row identified by the `+"`key`"+` is joined into the message. When multiple rows share a key the last row wins.
+
+The following formats are supported:
+
+- `+"`csv`"+`: A CSV document with a header row naming the fields of each row, where all values are strings.
+- `+"`json`"+`: A JSON array of objects.
+- `+"`ndjson`"+`: A JSON object on each line.
+
+### Refreshing
+
+The table is reloaded every `+"`refresh_interval`"+`, but is only downloaded and parsed again when it has changed, which is determined by the `+"`ETag`"+` of HTTP and S3 sources and by the modification time and size of files. Whilst a table is being reloaded messages are joined with the previous table, and when a reload fails the previous table continues to be used and the error is logged.
+
+Messages that arrive before the table has been loaded for the first time wait for the first attempt to complete, and if it fails they are flagged with an error, which can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+### Joining
+
+When `+"`target`"+` is empty the fields of the row are merged into the root of the message, which must be an object, otherwise the row is set at the path of the target. The `+"`fields`"+` of the row to join can be restricted, otherwise all fields of the row are joined.
+
+### Metrics
+
+This processor emits the gauges `+"`static_enrich_table_size`"+`, the number of rows of the current table, and `+"`static_enrich_last_refresh`"+`, the unix timestamp in seconds of the last successful refresh of the table, along with the counters `+"`static_enrich_refresh_error`"+` and `+"`static_enrich_missing`"+`.
+
+### Credentials
+
+S3 sources use a shared credentials file by default. You can find out more [in this document](/docs/guides/cloud/aws).`).
+		Field(service.NewStringField(sepFieldURL).
+			Description("The location of the lookup table.").
+			Example("./reference/users.csv").
+			Example("https://example.com/exports/users.ndjson").
+			Example("s3://foo/reference/users.json")).
+		Field(service.NewStringEnumField(sepFieldFormat, tableFormatCSV, tableFormatJSON, tableFormatNDJSON).
+			Description("The format of the lookup table.")).
+		Field(service.NewStringField(sepFieldKeyField).
+			Description("The field of each row of the table that identifies it.").
+			Example("id")).
+		Field(service.NewInterpolatedStringField(sepFieldKey).
+			Description("The key of the row to join into each message.").
+			Example(`${! json("user_id") }`).
+			Example(`${! meta("kafka_key") }`)).
+		Field(service.NewStringListField(sepFieldFields).
+			Description("An optional list of fields of the row to join into messages, when empty all fields are joined.").
+			Example([]string{"name", "tier"}).
+			Default([]interface{}{})).
+		Field(service.NewStringField(sepFieldTarget).
+			Description("A [dot path](/docs/configuration/field_paths) at which to set the row within each message, when empty the fields of the row are merged into the root of the message.").
+			Example("user").
+			Default("")).
+		Field(service.NewStringEnumField(sepFieldOnMissing, "skip", "error").
+			Description("What to do with messages that have a key that does not exist within the table, where `skip` leaves the message unchanged and `error` flags the message with an error.").
+			Default("skip")).
+		Field(service.NewDurationField(sepFieldRefreshInterval).
+			Description("The period between each reload of the table, where zero disables reloads.").
+			Default("5m")).
+		Field(service.NewStringMapField(sepFieldHeaders).
+			Description("A map of headers to add to requests for HTTP sources.").
+			Default(map[string]interface{}{}).
+			Advanced()).
+		Field(service.NewObjectField(sepFieldS3,
+			service.NewStringField(sepFieldS3Region).
+				Description("The AWS region of the bucket.").
+				Default(""),
+			service.NewStringField(sepFieldS3Endpoint).
+				Description("Allows you to specify a custom endpoint for the AWS API.").
+				Default(""),
+		).
+			Description("Options for S3 sources.").
+			Advanced()).
+		Example("Joining Customer Tiers", `
+Here we join the name and tier of the customer of each order from a CSV exported to S3 every night, reloading the table every hour:`,
+			`
+pipeline:
+  processors:
+    - static_enrich:
+        url: s3://reference/customers.csv
+        format: csv
+        key_field: customer_id
+        key: ${! json("customer_id") }
+        fields: [ name, tier ]
+        target: customer
+        refresh_interval: 1h
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"static_enrich", staticEnrichProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newStaticEnrichProcessorFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type staticEnrichProcessor struct {
+	source          tableSource
+	format          string
+	keyField        string
+	key             *service.InterpolatedString
+	fields          []string
+	target          string
+	onMissing       string
+	refreshInterval time.Duration
+
+	log          *service.Logger
+	mSize        *service.MetricGauge
+	mLastRefresh *service.MetricGauge
+	mRefreshErr  *service.MetricCounter
+	mMissing     *service.MetricCounter
+
+	tableMut sync.RWMutex
+	table    lookupTable
+	tag      string
+	loadErr  error
+	loaded   chan struct{}
+
+	shutSig *shutdown.Signaller
+}
+
+func newStaticEnrichProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*staticEnrichProcessor, error) {
+	s := &staticEnrichProcessor{
+		log:          mgr.Logger(),
+		mSize:        mgr.Metrics().NewGauge("static_enrich_table_size"),
+		mLastRefresh: mgr.Metrics().NewGauge("static_enrich_last_refresh"),
+		mRefreshErr:  mgr.Metrics().NewCounter("static_enrich_refresh_error"),
+		mMissing:     mgr.Metrics().NewCounter("static_enrich_missing"),
+		loaded:       make(chan struct{}),
+		shutSig:      shutdown.NewSignaller(),
+	}
+
+	var srcConf tableSourceConfig
+	var err error
+	if srcConf.url, err = conf.FieldString(sepFieldURL); err != nil {
+		return nil, err
+	}
+	if srcConf.headers, err = conf.FieldStringMap(sepFieldHeaders); err != nil {
+		return nil, err
+	}
+	if srcConf.s3Region, err = conf.FieldString(sepFieldS3, sepFieldS3Region); err != nil {
+		return nil, err
+	}
+	if srcConf.s3Endpoint, err = conf.FieldString(sepFieldS3, sepFieldS3Endpoint); err != nil {
+		return nil, err
+	}
+	if s.source, err = newTableSource(srcConf); err != nil {
+		return nil, err
+	}
+
+	if s.format, err = conf.FieldString(sepFieldFormat); err != nil {
+		return nil, err
+	}
+	if s.keyField, err = conf.FieldString(sepFieldKeyField); err != nil {
+		return nil, err
+	}
+	if s.key, err = conf.FieldInterpolatedString(sepFieldKey); err != nil {
+		return nil, err
+	}
+	if s.fields, err = conf.FieldStringList(sepFieldFields); err != nil {
+		return nil, err
+	}
+	if s.target, err = conf.FieldString(sepFieldTarget); err != nil {
+		return nil, err
+	}
+	if s.onMissing, err = conf.FieldString(sepFieldOnMissing); err != nil {
+		return nil, err
+	}
+	if s.refreshInterval, err = conf.FieldDuration(sepFieldRefreshInterval); err != nil {
+		return nil, err
+	}
+
+	go s.refreshLoop()
+	return s, nil
+}
+
+// refresh loads the table from the source when it has changed since the last
+// load.
+func (s *staticEnrichProcessor) refresh(ctx context.Context) error {
+	s.tableMut.RLock()
+	tag := s.tag
+	s.tableMut.RUnlock()
+
+	data, newTag, err := s.source.fetch(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to fetch table: %w", err)
+	}
+	if data != nil {
+		table, err := parseTable(s.format, data, s.keyField)
+		if err != nil {
+			return fmt.Errorf("failed to parse table: %w", err)
+		}
+
+		s.tableMut.Lock()
+		s.table = table
+		s.tag = newTag
+		s.tableMut.Unlock()
+
+		s.mSize.Set(int64(len(table)))
+		s.log.Debugf("Loaded lookup table with %v rows", len(table))
+	}
+	s.mLastRefresh.Set(time.Now().Unix())
+	return nil
+}
+
+func (s *staticEnrichProcessor) refreshLoop() {
+	defer s.shutSig.ShutdownComplete()
+
+	ctx, done := s.shutSig.CloseNowCtx(context.Background())
+	defer done()
+
+	err := s.refresh(ctx)
+	if err != nil {
+		s.mRefreshErr.Incr(1)
+		s.log.Errorf("Failed to load lookup table: %v", err)
+	}
+	s.tableMut.Lock()
+	s.loadErr = err
+	s.tableMut.Unlock()
+	close(s.loaded)
+
+	if s.refreshInterval <= 0 {
+		return
+	}
+	for {
+		select {
+		case <-time.After(s.refreshInterval):
+		case <-s.shutSig.CloseAtLeisureChan():
+			return
+		}
+		if err := s.refresh(ctx); err != nil {
+			s.mRefreshErr.Incr(1)
+			s.log.Errorf("Failed to refresh lookup table: %v", err)
+		}
+	}
+}
+
+func (s *staticEnrichProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	select {
+	case <-s.loaded:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.tableMut.RLock()
+	table, loadErr := s.table, s.loadErr
+	s.tableMut.RUnlock()
+	if table == nil {
+		return nil, fmt.Errorf("lookup table has not been loaded: %w", loadErr)
+	}
+
+	key := s.key.String(msg)
+	row, exists := table[key]
+	if !exists {
+		s.mMissing.Incr(1)
+		if s.onMissing == "error" {
+			return nil, fmt.Errorf("key not found in lookup table: %v", key)
+		}
+		return service.MessageBatch{msg}, nil
+	}
+
+	// Values are cloned as the rows of the table are shared by all messages.
+	joined := make(map[string]interface{}, len(row))
+	if len(s.fields) == 0 {
+		for k, v := range row {
+			joined[k] = query.IClone(v)
+		}
+	} else {
+		for _, f := range s.fields {
+			if v, exists := row[f]; exists {
+				joined[f] = query.IClone(v)
+			}
+		}
+	}
+
+	v, err := msg.AsStructuredMut()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.target == "" {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected message to be an object, got %T", v)
+		}
+		for k, v := range joined {
+			obj[k] = v
+		}
+		msg.SetStructured(obj)
+		return service.MessageBatch{msg}, nil
+	}
+
+	gObj := gabs.Wrap(v)
+	if _, err := gObj.SetP(joined, s.target); err != nil {
+		return nil, fmt.Errorf("failed to set target: %w", err)
+	}
+	msg.SetStructured(gObj.Data())
+	return service.MessageBatch{msg}, nil
+}
+
+func (s *staticEnrichProcessor) Close(ctx context.Context) error {
+	s.shutSig.CloseNow()
+	select {
+	case <-s.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
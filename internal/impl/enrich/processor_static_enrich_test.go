@@ -0,0 +1,150 @@
+package enrich
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testStaticEnrichProcessor(t *testing.T, confStr string) *staticEnrichProcessor {
+	t.Helper()
+
+	conf, err := staticEnrichProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newStaticEnrichProcessorFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+	return proc
+}
+
+func processString(t *testing.T, proc *staticEnrichProcessor, input string) (string, error) {
+	t.Helper()
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(input)))
+	if err != nil {
+		return "", err
+	}
+	require.Len(t, batch, 1)
+
+	mBytes, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	return string(mBytes), nil
+}
+
+func TestStaticEnrichFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,name,tier\n1,foo,gold\n2,bar,silver\n"), 0o644))
+
+	proc := testStaticEnrichProcessor(t, `
+url: `+path+`
+format: csv
+key_field: id
+key: ${! json("user_id") }
+fields: [ name, tier ]
+target: user
+on_missing: error
+`)
+
+	out, err := processString(t, proc, `{"user_id":"2","amount":10}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"amount":10,"user":{"name":"bar","tier":"silver"},"user_id":"2"}`, out)
+
+	_, err = processString(t, proc, `{"user_id":"3"}`)
+	assert.EqualError(t, err, "key not found in lookup table: 3")
+}
+
+func TestStaticEnrichMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte(`{"id":1,"name":"foo"}
+{"id":2,"name":"bar","tags":["a"]}
+`), 0o644))
+
+	proc := testStaticEnrichProcessor(t, `
+url: `+path+`
+format: ndjson
+key_field: id
+key: ${! json("user_id") }
+`)
+
+	out, err := processString(t, proc, `{"user_id":2}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":2,"name":"bar","tags":["a"],"user_id":2}`, out)
+
+	out, err = processString(t, proc, `{"user_id":3}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"user_id":3}`, out)
+}
+
+func TestStaticEnrichHTTPRefresh(t *testing.T) {
+	var mut sync.Mutex
+	etag, body := `"v1"`, `[{"id":"a","value":1}]`
+	notModified := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	proc := testStaticEnrichProcessor(t, `
+url: `+ts.URL+`
+format: json
+key_field: id
+key: ${! json("id") }
+target: ref
+refresh_interval: 10ms
+`)
+
+	out, err := processString(t, proc, `{"id":"a"}`)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"a","ref":{"id":"a","value":1}}`, out)
+
+	assert.Eventually(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return notModified > 0
+	}, time.Second*5, time.Millisecond*10)
+
+	mut.Lock()
+	etag, body = `"v2"`, `[{"id":"a","value":2}]`
+	mut.Unlock()
+
+	assert.Eventually(t, func() bool {
+		out, err := processString(t, proc, `{"id":"a"}`)
+		require.NoError(t, err)
+		return out == `{"id":"a","ref":{"id":"a","value":2}}`
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestStaticEnrichLoadFailure(t *testing.T) {
+	proc := testStaticEnrichProcessor(t, `
+url: `+filepath.Join(t.TempDir(), "nope.csv")+`
+format: csv
+key_field: id
+key: ${! json("id") }
+refresh_interval: 0s
+`)
+
+	_, err := processString(t, proc, `{"id":"a"}`)
+	assert.Error(t, err)
+}
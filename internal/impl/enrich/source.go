@@ -0,0 +1,181 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+)
+
+// tableSource is a location from which the contents of a lookup table can be
+// downloaded.
+type tableSource interface {
+	// fetch returns the contents of the source along with a tag identifying
+	// its version. When the version matches the provided tag the contents are
+	// nil, indicating that the source has not changed.
+	fetch(ctx context.Context, tag string) (data []byte, newTag string, err error)
+}
+
+type tableSourceConfig struct {
+	url        string
+	headers    map[string]string
+	s3Region   string
+	s3Endpoint string
+}
+
+func newTableSource(conf tableSourceConfig) (tableSource, error) {
+	u, err := url.Parse(conf.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	switch u.Scheme {
+	case "", "file":
+		path := conf.url
+		if u.Scheme == "file" {
+			path = u.Path
+		}
+		return &fileTableSource{path: path}, nil
+	case "http", "https":
+		return &httpTableSource{
+			url:     conf.url,
+			headers: conf.headers,
+			client:  &http.Client{},
+		}, nil
+	case "s3":
+		if u.Host == "" || strings.TrimPrefix(u.Path, "/") == "" {
+			return nil, fmt.Errorf("expected url of the form s3://<bucket>/<key>, got: %v", conf.url)
+		}
+		return newS3TableSource(u.Host, strings.TrimPrefix(u.Path, "/"), conf.s3Region, conf.s3Endpoint)
+	}
+	return nil, fmt.Errorf("unsupported url scheme: %v", u.Scheme)
+}
+
+//------------------------------------------------------------------------------
+
+// fileTableSource reads a table from the local filesystem, where the version
+// of the file is identified by its modification time and size.
+type fileTableSource struct {
+	path string
+}
+
+func (f *fileTableSource) fetch(ctx context.Context, tag string) ([]byte, string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, "", err
+	}
+	newTag := strconv.FormatInt(info.ModTime().UnixNano(), 10) + "-" + strconv.FormatInt(info.Size(), 10)
+	if newTag == tag {
+		return nil, tag, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, newTag, nil
+}
+
+//------------------------------------------------------------------------------
+
+// httpTableSource downloads a table over HTTP, using the ETag of the previous
+// download in order to avoid downloading a table that has not changed.
+type httpTableSource struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (h *httpTableSource) fetch(ctx context.Context, tag string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	if tag != "" {
+		req.Header.Set("If-None-Match", tag)
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, tag, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil, "", fmt.Errorf("received unexpected status code %v", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, res.Header.Get("ETag"), nil
+}
+
+//------------------------------------------------------------------------------
+
+// s3TableSource downloads a table from S3, using the ETag of the previous
+// download in order to avoid downloading a table that has not changed.
+type s3TableSource struct {
+	bucket string
+	key    string
+	s3     *s3.S3
+}
+
+func (s *s3TableSource) fetch(ctx context.Context, tag string) ([]byte, string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	}
+	if tag != "" {
+		input.IfNoneMatch = aws.String(tag)
+	}
+
+	out, err := s.s3.GetObjectWithContext(ctx, input)
+	if err != nil {
+		var rerr awserr.RequestFailure
+		if errors.As(err, &rerr) && rerr.StatusCode() == http.StatusNotModified {
+			return nil, tag, nil
+		}
+		return nil, "", err
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, aws.StringValue(out.ETag), nil
+}
+
+func newS3TableSource(bucket, key, region, endpoint string) (*s3TableSource, error) {
+	conf := sess.NewConfig()
+	conf.Region = region
+	conf.Endpoint = endpoint
+
+	session, err := conf.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	return &s3TableSource{
+		bucket: bucket,
+		key:    key,
+		s3:     s3.New(session),
+	}, nil
+}
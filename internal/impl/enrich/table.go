@@ -0,0 +1,100 @@
+package enrich
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+const (
+	tableFormatCSV    = "csv"
+	tableFormatJSON   = "json"
+	tableFormatNDJSON = "ndjson"
+)
+
+// lookupTable maps the key of each row to its fields.
+type lookupTable map[string]map[string]interface{}
+
+// parseTable parses the rows of a table in the given format and indexes them
+// by the value of the key field. When multiple rows share a key the last row
+// wins.
+func parseTable(format string, data []byte, keyField string) (lookupTable, error) {
+	var rows []map[string]interface{}
+	var err error
+	switch format {
+	case tableFormatCSV:
+		rows, err = parseCSVRows(data)
+	case tableFormatJSON:
+		rows, err = parseJSONRows(data)
+	case tableFormatNDJSON:
+		rows, err = parseNDJSONRows(data)
+	default:
+		err = fmt.Errorf("unrecognised format: %v", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := make(lookupTable, len(rows))
+	for i, row := range rows {
+		keyV, exists := row[keyField]
+		if !exists || keyV == nil {
+			return nil, fmt.Errorf("row %v is missing the key field %v", i, keyField)
+		}
+		t[query.IToString(keyV)] = row
+	}
+	return t, nil
+}
+
+func parseCSVRows(data []byte) ([]map[string]interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("csv table has no header row")
+	}
+
+	header := records[0]
+	rows := make([]map[string]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseJSONRows(data []byte) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("expected json table to be an array of objects: %w", err)
+	}
+	return rows, nil
+}
+
+func parseNDJSONRows(data []byte) ([]map[string]interface{}, error) {
+	var rows []map[string]interface{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		lineBytes := bytes.TrimSpace(scanner.Bytes())
+		if len(lineBytes) == 0 {
+			continue
+		}
+		var row map[string]interface{}
+		if err := json.Unmarshal(lineBytes, &row); err != nil {
+			return nil, fmt.Errorf("line %v: expected an object: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
@@ -0,0 +1,75 @@
+package enrich
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTable(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		format string
+		data   string
+		output lookupTable
+		err    string
+	}{
+		{
+			name:   "csv",
+			format: tableFormatCSV,
+			data:   "id,name\n1,foo\n2,bar\n1,baz\n",
+			output: lookupTable{
+				"1": {"id": "1", "name": "baz"},
+				"2": {"id": "2", "name": "bar"},
+			},
+		},
+		{
+			name:   "csv no header",
+			format: tableFormatCSV,
+			data:   "",
+			err:    "csv table has no header row",
+		},
+		{
+			name:   "json",
+			format: tableFormatJSON,
+			data:   `[{"id":1,"name":"foo"},{"id":"2","name":"bar"}]`,
+			output: lookupTable{
+				"1": {"id": float64(1), "name": "foo"},
+				"2": {"id": "2", "name": "bar"},
+			},
+		},
+		{
+			name:   "json missing key",
+			format: tableFormatJSON,
+			data:   `[{"id":1},{"name":"bar"}]`,
+			err:    "row 1 is missing the key field id",
+		},
+		{
+			name:   "ndjson",
+			format: tableFormatNDJSON,
+			data:   "{\"id\":true}\n\n{\"id\":\"b\",\"v\":[1]}\n",
+			output: lookupTable{
+				"true": {"id": true},
+				"b":    {"id": "b", "v": []interface{}{float64(1)}},
+			},
+		},
+		{
+			name:   "ndjson invalid line",
+			format: tableFormatNDJSON,
+			data:   "{\"id\":1}\n[1]\n",
+			err:    "line 2: expected an object: json: cannot unmarshal array into Go value of type map[string]interface {}",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			table, err := parseTable(test.format, []byte(test.data), "id")
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, table)
+		})
+	}
+}
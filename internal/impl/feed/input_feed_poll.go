@@ -0,0 +1,413 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	fpFieldURLs     = "urls"
+	fpFieldInterval = "interval"
+	fpFieldCache    = "cache"
+	fpFieldCacheTTL = "cache_ttl"
+	fpFieldHeaders  = "headers"
+	fpFieldTimeout  = "timeout"
+	fpFieldTLS      = "tls"
+)
+
+func feedPollInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Periodically polls RSS and Atom feeds and emits each entry that has not been seen before.").
+		Description(`
+Each entry of a feed is emitted as a JSON object of the following form, where fields that are absent from the entry are omitted:
+
+`+"```json"+`
+{
+  "id": "https://example.com/posts/1",
+  "title": "Hello world",
+  "link": "https://example.com/posts/1",
+  "published": "2022-06-01T10:00:00Z",
+  "updated": "2022-06-02T10:00:00Z",
+  "author": "foo",
+  "summary": "A short summary",
+  "content": "The full content",
+  "categories": [ "bar", "baz" ]
+}
+`+"```"+`
+
+RSS 2.0, RSS 1.0 and Atom feeds are supported. The ID of an entry is its RSS `+"`guid`"+` or Atom `+"`id`"+`, falling back to its link, and then to a hash of its title, publish date and summary. Dates are converted to RFC 3339 when they can be parsed.
+
+The new entries of all feeds found within a poll are emitted as a batch, with the entries of each feed in the reverse order to which they appear in the feed, as feeds conventionally list their newest entries first.
+
+### Deduplication
+
+The ID of each entry emitted is stored within the `+"`cache`"+` resource once the batch has been delivered, and entries with an ID found within the cache are skipped. A poll is not made whilst the entries of the previous poll are still pending, and therefore entries are delivered at least once. The `+"`cache_ttl`"+` should be longer than the period for which an entry remains within a feed in order to prevent it from being emitted again, and a cache that persists data, such as `+"`redis`"+` or `+"`file`"+`, should be used in order to avoid entries being emitted again after a restart.
+
+Requests are made with the `+"`ETag`"+` and `+"`Last-Modified`"+` headers of the previous response of each feed, allowing servers to respond with a `+"`304 Not Modified`"+` status when a feed has not changed.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- feed_url
+- feed_title
+- feed_entry_id
+- feed_entry_link
+- feed_entry_published
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringListField(fpFieldURLs).
+			Description("A list of URLs of feeds to poll.").
+			Example([]string{"https://blog.example.com/feed.xml", "https://www.reddit.com/r/golang/.rss"})).
+		Field(service.NewDurationField(fpFieldInterval).
+			Description("The period between each poll of the feeds.").
+			Default("5m")).
+		Field(service.NewStringField(fpFieldCache).
+			Description("A [cache resource](/docs/components/caches/about) in which to store the IDs of entries that have been emitted.")).
+		Field(service.NewDurationField(fpFieldCacheTTL).
+			Description("The period for which the IDs of emitted entries are stored within the cache. Not all caches support per-key TTLs.").
+			Default("720h").
+			Advanced()).
+		Field(service.NewStringMapField(fpFieldHeaders).
+			Description("A map of headers to add to requests.").
+			Default(map[string]interface{}{
+				"User-Agent": "Benthos",
+			}).
+			Advanced()).
+		Field(service.NewDurationField(fpFieldTimeout).
+			Description("A timeout for each request.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewTLSField(fpFieldTLS)).
+		Example("Release Notifications", `
+Here we poll the releases of a GitHub project every ten minutes, and post each new release to a Slack channel via a webhook:`,
+			`
+input:
+  feed_poll:
+    urls: [ https://github.com/benthosdev/benthos/releases.atom ]
+    interval: 10m
+    cache: seen
+
+pipeline:
+  processors:
+    - mapping: |
+        root.text = "New release: %v %v".format(this.title, this.link)
+
+output:
+  http_client:
+    url: https://hooks.slack.com/services/foo
+    verb: POST
+
+cache_resources:
+  - label: seen
+    file:
+      directory: /var/lib/benthos/feeds
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"feed_poll", feedPollInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newFeedPollInputFromConfig(conf, mgr, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cacheProvider interface {
+	AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error
+}
+
+// feedState holds the validators of the last response of a feed, which are
+// used in order to make conditional requests.
+type feedState struct {
+	etag         string
+	lastModified string
+}
+
+type feedPollInput struct {
+	urls     []string
+	interval time.Duration
+	cache    string
+	cacheTTL time.Duration
+	headers  map[string]string
+	client   *http.Client
+
+	mgr cacheProvider
+	log *service.Logger
+
+	mut      sync.Mutex
+	states   map[string]feedState
+	nextPoll time.Time
+	pending  chan struct{}
+}
+
+func newFeedPollInputFromConfig(conf *service.ParsedConfig, mgr cacheProvider, log *service.Logger) (*feedPollInput, error) {
+	f := &feedPollInput{
+		mgr:    mgr,
+		log:    log,
+		states: map[string]feedState{},
+	}
+
+	var err error
+	if f.urls, err = conf.FieldStringList(fpFieldURLs); err != nil {
+		return nil, err
+	}
+	if len(f.urls) == 0 {
+		return nil, errors.New("at least one url must be specified")
+	}
+	if f.interval, err = conf.FieldDuration(fpFieldInterval); err != nil {
+		return nil, err
+	}
+	if f.interval <= 0 {
+		return nil, errors.New("interval must be larger than zero")
+	}
+	if f.cache, err = conf.FieldString(fpFieldCache); err != nil {
+		return nil, err
+	}
+	if f.cacheTTL, err = conf.FieldDuration(fpFieldCacheTTL); err != nil {
+		return nil, err
+	}
+	if f.headers, err = conf.FieldStringMap(fpFieldHeaders); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration(fpFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS(fpFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	f.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	return f, nil
+}
+
+func (f *feedPollInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// fetch downloads a feed, returning a nil document when the feed has not
+// been modified since the previous request.
+func (f *feedPollInput) fetch(ctx context.Context, url string) (*document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range f.headers {
+		req.Header.Set(k, v)
+	}
+
+	f.mut.Lock()
+	state := f.states[url]
+	f.mut.Unlock()
+	if state.etag != "" {
+		req.Header.Set("If-None-Match", state.etag)
+	}
+	if state.lastModified != "" {
+		req.Header.Set("If-Modified-Since", state.lastModified)
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil, fmt.Errorf("received unexpected status code %v", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	f.mut.Lock()
+	f.states[url] = feedState{
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+	}
+	f.mut.Unlock()
+	return doc, nil
+}
+
+// unseen returns the entries of a feed with IDs that are not within the cache.
+func (f *feedPollInput) unseen(ctx context.Context, url string, doc *document) ([]entry, error) {
+	var entries []entry
+	var err error
+	if cerr := f.mgr.AccessCache(ctx, f.cache, func(c service.Cache) {
+		seen := map[string]struct{}{}
+		for i := len(doc.Entries) - 1; i >= 0; i-- {
+			e := doc.Entries[i]
+			if _, exists := seen[e.ID]; exists {
+				continue
+			}
+			seen[e.ID] = struct{}{}
+
+			if _, err = c.Get(ctx, cacheKey(url, e.ID)); err == nil {
+				continue
+			}
+			if !errors.Is(err, service.ErrKeyNotFound) {
+				return
+			}
+			err = nil
+			entries = append(entries, e)
+		}
+	}); cerr != nil {
+		return nil, cerr
+	}
+	return entries, err
+}
+
+func cacheKey(url, id string) string {
+	return url + "#" + id
+}
+
+// wait blocks until the entries of the previous poll have been delivered and
+// the next poll is due.
+func (f *feedPollInput) wait(ctx context.Context) error {
+	f.mut.Lock()
+	pending := f.pending
+	f.mut.Unlock()
+
+	if pending != nil {
+		select {
+		case <-pending:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	f.mut.Lock()
+	waitFor := time.Until(f.nextPoll)
+	f.mut.Unlock()
+
+	if waitFor > 0 {
+		select {
+		case <-time.After(waitFor):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (f *feedPollInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		if err := f.wait(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		var batch service.MessageBatch
+		var keys []string
+		var firstErr error
+		failed := 0
+		for _, url := range f.urls {
+			doc, err := f.fetch(ctx, url)
+			if err == nil && doc != nil {
+				var entries []entry
+				if entries, err = f.unseen(ctx, url, doc); err == nil {
+					for _, e := range entries {
+						var eBytes []byte
+						if eBytes, err = json.Marshal(e); err != nil {
+							break
+						}
+						msg := service.NewMessage(eBytes)
+						msg.MetaSet("feed_url", url)
+						msg.MetaSet("feed_title", doc.Title)
+						msg.MetaSet("feed_entry_id", e.ID)
+						msg.MetaSet("feed_entry_link", e.Link)
+						msg.MetaSet("feed_entry_published", e.Published)
+						batch = append(batch, msg)
+						keys = append(keys, cacheKey(url, e.ID))
+					}
+				}
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				failed++
+				f.log.Errorf("Failed to poll feed %v: %v", url, err)
+			}
+		}
+		if failed == len(f.urls) {
+			return nil, nil, fmt.Errorf("failed to poll all feeds: %w", firstErr)
+		}
+
+		f.mut.Lock()
+		f.nextPoll = time.Now().Add(f.interval)
+		f.mut.Unlock()
+
+		if len(batch) == 0 {
+			continue
+		}
+
+		pending := make(chan struct{})
+		f.mut.Lock()
+		f.pending = pending
+		f.mut.Unlock()
+
+		return batch, func(ctx context.Context, err error) error {
+			defer close(pending)
+			if err != nil {
+				// The entries are not marked as seen and the feeds are polled
+				// again immediately so that they are emitted again, which
+				// requires the feeds to be downloaded in full.
+				f.mut.Lock()
+				f.states = map[string]feedState{}
+				f.nextPoll = time.Time{}
+				f.mut.Unlock()
+				return nil
+			}
+			var setErr error
+			if cerr := f.mgr.AccessCache(ctx, f.cache, func(c service.Cache) {
+				for _, k := range keys {
+					if setErr = c.Set(ctx, k, []byte("t"), &f.cacheTTL); setErr != nil {
+						return
+					}
+				}
+			}); cerr != nil {
+				return cerr
+			}
+			return setErr
+		}, nil
+	}
+}
+
+func (f *feedPollInput) Close(ctx context.Context) error {
+	return nil
+}
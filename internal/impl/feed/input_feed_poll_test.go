@@ -0,0 +1,183 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mockCache struct {
+	mut   sync.Mutex
+	items map[string][]byte
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	v, exists := m.items[key]
+	if !exists {
+		return nil, service.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.items[key] = value
+	return nil
+}
+
+func (m *mockCache) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if _, exists := m.items[key]; exists {
+		return service.ErrKeyAlreadyExists
+	}
+	m.items[key] = value
+	return nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, key string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func (m *mockCache) Close(ctx context.Context) error {
+	return nil
+}
+
+type mockCacheProv struct {
+	caches map[string]service.Cache
+}
+
+func (m *mockCacheProv) AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error {
+	c, exists := m.caches[name]
+	if !exists {
+		return errors.New("cache not found")
+	}
+	fn(c)
+	return nil
+}
+
+func rssWithItems(ids ...string) string {
+	doc := `<rss version="2.0"><channel><title>Foo</title>`
+	for _, id := range ids {
+		doc += `<item><title>` + id + `</title><guid>` + id + `</guid></item>`
+	}
+	return doc + `</channel></rss>`
+}
+
+func TestFeedPollInput(t *testing.T) {
+	var mut sync.Mutex
+	body := rssWithItems("c", "b", "a")
+	conditional := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		etag := `"` + body + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	conf, err := feedPollInputConfig().ParseYAML(`
+urls: [ `+ts.URL+` ]
+interval: 1ms
+cache: foo
+`, nil)
+	require.NoError(t, err)
+
+	cache := &mockCache{items: map[string][]byte{}}
+	f, err := newFeedPollInputFromConfig(conf, &mockCacheProv{
+		caches: map[string]service.Cache{"foo": cache},
+	}, nil)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	require.NoError(t, f.Connect(ctx))
+
+	readIDs := func() ([]string, service.AckFunc) {
+		t.Helper()
+		batch, ackFn, err := f.ReadBatch(ctx)
+		require.NoError(t, err)
+		var ids []string
+		for _, msg := range batch {
+			id, _ := msg.MetaGet("feed_entry_id")
+			ids = append(ids, id)
+
+			title, _ := msg.MetaGet("feed_title")
+			assert.Equal(t, "Foo", title)
+			url, _ := msg.MetaGet("feed_url")
+			assert.Equal(t, ts.URL, url)
+		}
+		return ids, ackFn
+	}
+
+	ids, ackFn := readIDs()
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+
+	// A nack results in the entries being emitted again.
+	require.NoError(t, ackFn(ctx, errors.New("nope")))
+	ids, ackFn = readIDs()
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Contains(t, cache.items, ts.URL+"#a")
+
+	mut.Lock()
+	body = rssWithItems("e", "d", "c", "b")
+	mut.Unlock()
+
+	ids, ackFn = readIDs()
+	assert.Equal(t, []string{"d", "e"}, ids)
+	require.NoError(t, ackFn(ctx, nil))
+
+	// Polls of an unchanged feed are conditional and emit nothing.
+	tCtx, tDone := context.WithTimeout(ctx, time.Millisecond*50)
+	defer tDone()
+	_, _, err = f.ReadBatch(tCtx)
+	assert.Error(t, err)
+
+	mut.Lock()
+	assert.Greater(t, conditional, 0)
+	mut.Unlock()
+}
+
+func TestFeedPollInputFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	conf, err := feedPollInputConfig().ParseYAML(`
+urls: [ `+ts.URL+` ]
+cache: foo
+`, nil)
+	require.NoError(t, err)
+
+	f, err := newFeedPollInputFromConfig(conf, &mockCacheProv{
+		caches: map[string]service.Cache{"foo": &mockCache{items: map[string][]byte{}}},
+	}, nil)
+	require.NoError(t, err)
+
+	_, _, err = f.ReadBatch(context.Background())
+	assert.EqualError(t, err, "failed to poll all feeds: received unexpected status code 404")
+}
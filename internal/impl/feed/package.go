@@ -0,0 +1,3 @@
+// Package feed contains components that poll syndication feeds such as RSS
+// and Atom.
+package feed
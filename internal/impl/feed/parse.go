@@ -0,0 +1,228 @@
+package feed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// entry is a normalised item of an RSS or Atom feed.
+type entry struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Link       string   `json:"link"`
+	Published  string   `json:"published,omitempty"`
+	Updated    string   `json:"updated,omitempty"`
+	Author     string   `json:"author,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Content    string   `json:"content,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// document is a parsed feed with its entries in the order they appear.
+type document struct {
+	Title   string
+	Entries []entry
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	About       string   `xml:"about,attr"`
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+
+	// RSS 1.0 documents list items at the root rather than within the
+	// channel.
+	Items []rssItem `xml:"item"`
+}
+
+type atomText struct {
+	Body string `xml:",chardata"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Authors   []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Summary    atomText `xml:"summary"`
+	Content    atomText `xml:"content"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+type atomDocument struct {
+	Title   atomText    `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+var rssDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+}
+
+// normaliseDate converts a feed date into RFC 3339, leaving dates that cannot
+// be parsed as they are.
+func normaliseDate(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	for _, layout := range rssDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+// fallbackID derives an ID for entries that have neither an ID nor a link.
+func fallbackID(e entry) string {
+	h := sha256.Sum256([]byte(e.Title + "\x00" + e.Published + "\x00" + e.Summary))
+	return hex.EncodeToString(h[:])
+}
+
+func fromRSSItem(item rssItem) entry {
+	e := entry{
+		ID:         strings.TrimSpace(item.GUID),
+		Title:      strings.TrimSpace(item.Title),
+		Link:       strings.TrimSpace(item.Link),
+		Published:  normaliseDate(item.PubDate),
+		Author:     strings.TrimSpace(item.Author),
+		Summary:    strings.TrimSpace(item.Description),
+		Content:    strings.TrimSpace(item.Content),
+		Categories: item.Categories,
+	}
+	if e.Published == "" {
+		e.Published = normaliseDate(item.Date)
+	}
+	if e.Author == "" {
+		e.Author = strings.TrimSpace(item.Creator)
+	}
+	if e.ID == "" {
+		e.ID = strings.TrimSpace(item.About)
+	}
+	if e.ID == "" {
+		e.ID = e.Link
+	}
+	if e.ID == "" {
+		e.ID = fallbackID(e)
+	}
+	return e
+}
+
+func fromAtomEntry(ae atomEntry) entry {
+	e := entry{
+		ID:        strings.TrimSpace(ae.ID),
+		Title:     strings.TrimSpace(ae.Title.Body),
+		Published: normaliseDate(ae.Published),
+		Updated:   normaliseDate(ae.Updated),
+		Summary:   strings.TrimSpace(ae.Summary.Body),
+		Content:   strings.TrimSpace(ae.Content.Body),
+	}
+	for _, l := range ae.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			e.Link = l.Href
+			break
+		}
+	}
+	if len(ae.Authors) > 0 {
+		e.Author = strings.TrimSpace(ae.Authors[0].Name)
+	}
+	for _, c := range ae.Categories {
+		e.Categories = append(e.Categories, c.Term)
+	}
+	if e.ID == "" {
+		e.ID = e.Link
+	}
+	if e.ID == "" {
+		e.ID = fallbackID(e)
+	}
+	return e
+}
+
+// parseDocument parses an RSS 2.0, RSS 1.0 or Atom feed.
+func parseDocument(data []byte) (*document, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// Feeds commonly declare charsets that are supersets of ASCII, which
+		// are read as they are.
+		return input, nil
+	}
+
+	var root xml.StartElement
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find root element: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			root = start
+			break
+		}
+	}
+
+	doc := &document{}
+	switch strings.ToLower(root.Name.Local) {
+	case "rss", "rdf":
+		var rss rssDocument
+		if err := dec.DecodeElement(&rss, &root); err != nil {
+			return nil, err
+		}
+		doc.Title = strings.TrimSpace(rss.Channel.Title)
+		items := rss.Channel.Items
+		if len(items) == 0 {
+			items = rss.Items
+		}
+		for _, item := range items {
+			doc.Entries = append(doc.Entries, fromRSSItem(item))
+		}
+	case "feed":
+		var atom atomDocument
+		if err := dec.DecodeElement(&atom, &root); err != nil {
+			return nil, err
+		}
+		doc.Title = strings.TrimSpace(atom.Title.Body)
+		for _, ae := range atom.Entries {
+			doc.Entries = append(doc.Entries, fromAtomEntry(ae))
+		}
+	default:
+		return nil, errors.New("unrecognised feed format with root element: " + root.Name.Local)
+	}
+	return doc, nil
+}
@@ -0,0 +1,125 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRSS(t *testing.T) {
+	doc, err := parseDocument([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel>
+    <title>Foo Blog</title>
+    <item>
+      <title>Second</title>
+      <link>https://example.com/2</link>
+      <guid isPermaLink="false">post-2</guid>
+      <pubDate>Wed, 01 Jun 2022 10:00:00 +0100</pubDate>
+      <dc:creator>foo</dc:creator>
+      <category>a</category>
+      <category>b</category>
+      <description>Summary &amp; more</description>
+      <content:encoded><![CDATA[<p>Content</p>]]></content:encoded>
+    </item>
+    <item>
+      <title>First</title>
+      <link>https://example.com/1</link>
+      <pubDate>not a date</pubDate>
+    </item>
+  </channel>
+</rss>`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Foo Blog", doc.Title)
+	assert.Equal(t, []entry{
+		{
+			ID:         "post-2",
+			Title:      "Second",
+			Link:       "https://example.com/2",
+			Published:  "2022-06-01T09:00:00Z",
+			Author:     "foo",
+			Summary:    "Summary & more",
+			Content:    "<p>Content</p>",
+			Categories: []string{"a", "b"},
+		},
+		{
+			ID:        "https://example.com/1",
+			Title:     "First",
+			Link:      "https://example.com/1",
+			Published: "not a date",
+		},
+	}, doc.Entries)
+}
+
+func TestParseRDF(t *testing.T) {
+	doc, err := parseDocument([]byte(`<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://example.com/">
+    <title>Bar</title>
+  </channel>
+  <item rdf:about="https://example.com/a">
+    <title>A</title>
+    <link>https://example.com/a</link>
+    <dc:date>2022-06-01T10:00:00Z</dc:date>
+  </item>
+</rdf:RDF>`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bar", doc.Title)
+	assert.Equal(t, []entry{
+		{
+			ID:        "https://example.com/a",
+			Title:     "A",
+			Link:      "https://example.com/a",
+			Published: "2022-06-01T10:00:00Z",
+		},
+	}, doc.Entries)
+}
+
+func TestParseAtom(t *testing.T) {
+	doc, err := parseDocument([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="text">Baz Releases</title>
+  <entry>
+    <id>tag:example.com,2022:1</id>
+    <title>v1.0.0</title>
+    <link rel="self" href="https://example.com/self"/>
+    <link href="https://example.com/releases/v1.0.0"/>
+    <published>2022-06-01T10:00:00+02:00</published>
+    <updated>2022-06-02T10:00:00Z</updated>
+    <author><name>baz</name></author>
+    <category term="release"/>
+    <summary>First release</summary>
+    <content type="html">&lt;p&gt;Notes&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <title>No ID</title>
+  </entry>
+</feed>`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Baz Releases", doc.Title)
+	require.Len(t, doc.Entries, 2)
+	assert.Equal(t, entry{
+		ID:         "tag:example.com,2022:1",
+		Title:      "v1.0.0",
+		Link:       "https://example.com/releases/v1.0.0",
+		Published:  "2022-06-01T08:00:00Z",
+		Updated:    "2022-06-02T10:00:00Z",
+		Author:     "baz",
+		Summary:    "First release",
+		Content:    "<p>Notes</p>",
+		Categories: []string{"release"},
+	}, doc.Entries[0])
+	assert.Len(t, doc.Entries[1].ID, 64)
+}
+
+func TestParseUnknown(t *testing.T) {
+	_, err := parseDocument([]byte(`<html><body>nope</body></html>`))
+	assert.EqualError(t, err, "unrecognised feed format with root element: html")
+
+	_, err = parseDocument([]byte(`not xml`))
+	assert.Error(t, err)
+}
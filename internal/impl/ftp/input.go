@@ -0,0 +1,406 @@
+package ftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/ftp/shared"
+	"github.com/benthosdev/benthos/v4/internal/interop"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/input"
+	"github.com/benthosdev/benthos/v4/internal/old/input/reader"
+)
+
+func init() {
+	err := bundle.AllInputs.Add(bundle.InputConstructorFromSimple(func(c input.Config, nm bundle.NewManagement) (iinput.Streamed, error) {
+		r, err := newFTPReader(c.FTP, nm)
+		if err != nil {
+			return nil, err
+		}
+		return input.NewAsyncReader(
+			input.TypeFTP, true,
+			reader.NewAsyncPreserver(r),
+			nm.Logger(), nm.Metrics(),
+		)
+	}), docs.ComponentSpec{
+		Name:       input.TypeFTP,
+		Type:       docs.TypeInput,
+		Status:     docs.StatusExperimental,
+		Version:    "4.0.0",
+		Categories: []string{"Network"},
+		Summary:    `Consumes files from a server over FTP or FTPS.`,
+		Description: `
+Files matching the ` + "`paths`" + ` are downloaded sequentially over a single connection, and the contents of each file are broken into messages according to the ` + "[`codec`](#codec)" + `. Glob patterns are supported within the file name segment of each path.
+
+### Transfer Modes and TLS
+
+By default data connections are established in passive mode, where the client connects to a port opened by the server. Servers that are unable to accept incoming data connections can be used in active mode by setting ` + "`transfer_mode` to `active`" + `, in which case the server connects to a port opened by Benthos, which must therefore be reachable from the server.
+
+When ` + "`tls.enabled`" + ` is set the connection is secured with FTPS, where the mode of TLS negotiation is determined by the field ` + "`tls_mode`" + `.
+
+### Watching and Acknowledgements
+
+Files are only considered finished once every message consumed from them has been acknowledged downstream, at which point they are deleted when ` + "`delete_on_finish`" + ` is enabled, or moved into the directory specified by ` + "`move_on_finish`" + `. When the watcher is enabled the input periodically scans the target paths for new files, and the path of each finished file is stored within the watcher cache so that it is not consumed again.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- ftp_path
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
+		Config: docs.FieldComponent().WithChildren(shared.ConnectionDocs()...).WithChildren(
+			docs.FieldString(
+				"paths",
+				"A list of paths to consume sequentially. Glob patterns are supported within file names.",
+				[]string{"/uploads/*.csv"},
+			).Array(),
+			codec.ReaderDocs,
+			docs.FieldBool("delete_on_finish", "Whether to delete files from the server once they are processed.").Advanced(),
+			docs.FieldString("move_on_finish", "An optional directory to move files into once they are processed, the name of each file is preserved. This field cannot be combined with `delete_on_finish`. Make sure that the target directory is not matched by `paths`, otherwise moved files will be consumed again.", "/processed").Advanced(),
+			docs.FieldInt("max_buffer", "The largest token size expected when consuming delimited files.").Advanced(),
+			docs.FieldObject(
+				"watcher",
+				"An experimental mode whereby the input will periodically scan the target paths for new files and consume them, when all files are consumed the input will continue polling for new files.",
+			).WithChildren(input.WatcherFieldSpecs()...),
+		).ChildDefaultAndTypesFromStruct(input.NewFTPConfig()),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type ftpReader struct {
+	conf input.FTPConfig
+	opts shared.ClientOptions
+
+	log log.Modular
+	mgr interop.Manager
+
+	scannerCtor codec.ReaderConstructor
+
+	scannerMut  sync.Mutex
+	client      *shared.Client
+	paths       []string
+	scanner     codec.Reader
+	currentPath string
+
+	watcherPollInterval time.Duration
+	watcherMinAge       time.Duration
+
+	pendingMut sync.Mutex
+	pending    map[string]struct{}
+}
+
+func newFTPReader(conf input.FTPConfig, mgr interop.Manager) (*ftpReader, error) {
+	opts, err := conf.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	if conf.DeleteOnFinish && conf.MoveOnFinish != "" {
+		return nil, errors.New("cannot combine delete_on_finish with move_on_finish")
+	}
+
+	codecConf := codec.NewReaderConfig()
+	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	ctor, err := codec.GetReader(conf.Codec, codecConf)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &ftpReader{
+		conf:        conf,
+		opts:        opts,
+		log:         mgr.Logger(),
+		mgr:         mgr,
+		scannerCtor: ctor,
+		pending:     map[string]struct{}{},
+	}
+
+	if conf.Watcher.Enabled {
+		if f.watcherPollInterval, err = time.ParseDuration(conf.Watcher.PollInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse watcher poll interval: %w", err)
+		}
+		if f.watcherMinAge, err = time.ParseDuration(conf.Watcher.MinimumAge); err != nil {
+			return nil, fmt.Errorf("failed to parse watcher minimum age: %w", err)
+		}
+		if conf.Watcher.Cache == "" {
+			return nil, errors.New("a cache must be specified when watcher mode is enabled")
+		}
+		if !mgr.ProbeCache(conf.Watcher.Cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", conf.Watcher.Cache)
+		}
+	}
+	return f, nil
+}
+
+// ConnectWithContext attempts to establish a connection to the target FTP
+// server and opens the next file to consume.
+func (f *ftpReader) ConnectWithContext(ctx context.Context) error {
+	f.scannerMut.Lock()
+	defer f.scannerMut.Unlock()
+
+	if f.scanner != nil {
+		return nil
+	}
+
+	var err error
+	if f.client == nil {
+		if f.client, err = shared.Dial(f.opts); err != nil {
+			return err
+		}
+		if f.paths, err = f.getFilePaths(ctx); err != nil {
+			f.dropClient()
+			return err
+		}
+	}
+
+	if len(f.paths) == 0 {
+		if !f.conf.Watcher.Enabled {
+			f.dropClient()
+			f.log.Debugln("Paths exhausted, closing input")
+			return component.ErrTypeClosed
+		}
+		select {
+		case <-time.After(f.watcherPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if f.paths, err = f.getFilePaths(ctx); err != nil && shared.IsConnectionError(err) {
+			f.dropClient()
+		}
+		return err
+	}
+
+	nextPath := f.paths[0]
+
+	file, err := f.client.Retrieve(nextPath)
+	if err != nil {
+		if shared.IsConnectionError(err) {
+			f.dropClient()
+			return err
+		}
+		f.log.Warnf("Failed to open file %v: %v\n", nextPath, err)
+		f.paths = f.paths[1:]
+		return err
+	}
+
+	f.pendingMut.Lock()
+	f.pending[nextPath] = struct{}{}
+	f.pendingMut.Unlock()
+
+	if f.scanner, err = f.scannerCtor(nextPath, file, func(ctx context.Context, err error) error {
+		defer func() {
+			f.pendingMut.Lock()
+			delete(f.pending, nextPath)
+			f.pendingMut.Unlock()
+		}()
+		if err != nil {
+			return nil
+		}
+		return f.finishPath(ctx, nextPath)
+	}); err != nil {
+		file.Close()
+		f.pendingMut.Lock()
+		delete(f.pending, nextPath)
+		f.pendingMut.Unlock()
+		return err
+	}
+
+	f.currentPath = nextPath
+	f.paths = f.paths[1:]
+
+	f.log.Infof("Consuming from file '%v'\n", nextPath)
+	return nil
+}
+
+func (f *ftpReader) dropClient() {
+	if f.client != nil {
+		_ = f.client.Quit()
+		f.client = nil
+	}
+}
+
+// ReadWithContext attempts to read a new message from the target file(s) on
+// the server.
+func (f *ftpReader) ReadWithContext(ctx context.Context) (*message.Batch, reader.AsyncAckFn, error) {
+	f.scannerMut.Lock()
+	defer f.scannerMut.Unlock()
+
+	if f.scanner == nil || f.client == nil {
+		return nil, nil, component.ErrNotConnected
+	}
+
+	parts, codecAckFn, err := f.scanner.Next(ctx)
+	if err != nil {
+		if errors.Is(err, context.Canceled) ||
+			errors.Is(err, context.DeadlineExceeded) {
+			err = component.ErrTimeout
+		}
+		if err != component.ErrTimeout {
+			if closeErr := f.scanner.Close(ctx); closeErr != nil && shared.IsConnectionError(closeErr) {
+				f.dropClient()
+			}
+			f.scanner = nil
+		}
+		if errors.Is(err, io.EOF) {
+			err = component.ErrTimeout
+		}
+		return nil, nil, err
+	}
+
+	for _, part := range parts {
+		part.MetaSet("ftp_path", f.currentPath)
+	}
+	msg := message.QuickBatch(nil)
+	msg.Append(parts...)
+
+	return msg, func(ctx context.Context, res error) error {
+		return codecAckFn(ctx, res)
+	}, nil
+}
+
+// finishPath is called once all messages consumed from a file have been
+// acknowledged. The control connection of the reader might be busy with the
+// transfer of another file and therefore files are deleted or moved using a
+// separate connection.
+func (f *ftpReader) finishPath(ctx context.Context, filePath string) error {
+	if f.conf.DeleteOnFinish || f.conf.MoveOnFinish != "" {
+		client, err := shared.Dial(f.opts)
+		if err != nil {
+			return err
+		}
+		defer client.Quit()
+
+		if f.conf.DeleteOnFinish {
+			if err := client.Delete(filePath); err != nil {
+				return fmt.Errorf("failed to delete file %v: %w", filePath, err)
+			}
+		} else {
+			if err := client.MakeDirAll(f.conf.MoveOnFinish); err != nil {
+				return fmt.Errorf("failed to create directory %v: %w", f.conf.MoveOnFinish, err)
+			}
+			target := path.Join(f.conf.MoveOnFinish, path.Base(filePath))
+			if err := client.Rename(filePath, target); err != nil {
+				return fmt.Errorf("failed to move file %v to %v: %w", filePath, target, err)
+			}
+		}
+	}
+
+	if !f.conf.Watcher.Enabled {
+		return nil
+	}
+
+	var setErr error
+	if cerr := f.mgr.AccessCache(ctx, f.conf.Watcher.Cache, func(cache cache.V1) {
+		setErr = cache.Set(ctx, filePath, []byte("@"), nil)
+	}); cerr != nil {
+		return fmt.Errorf("failed to get the cache for ftp watcher mode: %v", cerr)
+	}
+	if setErr != nil {
+		return fmt.Errorf("failed to update path in cache %s: %v", filePath, setErr)
+	}
+	return nil
+}
+
+func (f *ftpReader) isPending(filePath string) bool {
+	f.pendingMut.Lock()
+	_, exists := f.pending[filePath]
+	f.pendingMut.Unlock()
+	return exists
+}
+
+func (f *ftpReader) getFilePaths(ctx context.Context) ([]string, error) {
+	var entries []shared.Entry
+	for _, p := range f.conf.Paths {
+		matches, err := f.client.Glob(p)
+		if err != nil {
+			if shared.IsConnectionError(err) {
+				return nil, err
+			}
+			f.log.Warnf("Failed to scan files from path %v: %v\n", p, err)
+			continue
+		}
+		entries = append(entries, matches...)
+	}
+
+	if !f.conf.Watcher.Enabled {
+		filePaths := make([]string, 0, len(entries))
+		for _, e := range entries {
+			filePaths = append(filePaths, e.Path)
+		}
+		return filePaths, nil
+	}
+
+	var filePaths []string
+	for _, e := range entries {
+		if f.isPending(e.Path) {
+			continue
+		}
+		modTime := e.ModTime
+		if modTime.IsZero() {
+			var err error
+			if modTime, err = f.client.ModTime(e.Path); err != nil {
+				if shared.IsConnectionError(err) {
+					return nil, err
+				}
+				f.log.Warnf("Failed to get modification time of path %v: %v\n", e.Path, err)
+				continue
+			}
+		}
+		if time.Since(modTime) < f.watcherMinAge {
+			continue
+		}
+		filePaths = append(filePaths, e.Path)
+	}
+
+	var unseen []string
+	if cerr := f.mgr.AccessCache(ctx, f.conf.Watcher.Cache, func(cache cache.V1) {
+		for _, p := range filePaths {
+			if _, err := cache.Get(ctx, p); err != nil {
+				unseen = append(unseen, p)
+			} else if err = cache.Set(ctx, p, []byte("@"), nil); err != nil { // Reset the TTL for the path
+				f.log.Warnf("Failed to set key in cache for path %v: %v\n", p, err)
+			}
+		}
+	}); cerr != nil {
+		return nil, fmt.Errorf("error getting cache in getFilePaths: %v", cerr)
+	}
+	return unseen, nil
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (f *ftpReader) CloseAsync() {
+	go func() {
+		f.scannerMut.Lock()
+		if f.scanner != nil {
+			f.scanner.Close(context.Background())
+			f.scanner = nil
+			f.paths = nil
+		}
+		f.dropClient()
+		f.scannerMut.Unlock()
+	}()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (f *ftpReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
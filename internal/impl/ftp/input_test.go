@@ -0,0 +1,174 @@
+package ftp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/impl/ftp/shared/ftptest"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/input"
+)
+
+func testServer(t testing.TB) *ftptest.Server {
+	t.Helper()
+
+	server, err := ftptest.NewServer("foo", "bar")
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testInputConfig(server *ftptest.Server) input.FTPConfig {
+	conf := input.NewFTPConfig()
+	conf.Address = server.Addr()
+	conf.Credentials.Username = "foo"
+	conf.Credentials.Password = "bar"
+	conf.Timeout = "5s"
+	return conf
+}
+
+func readAll(t testing.TB, r *ftpReader) (contents, paths []string) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	for {
+		if err := r.ConnectWithContext(ctx); err != nil {
+			if err == component.ErrTypeClosed {
+				return
+			}
+			require.NoError(t, err)
+		}
+
+		msg, ackFn, err := r.ReadWithContext(ctx)
+		if err == component.ErrTimeout || err == component.ErrNotConnected {
+			continue
+		}
+		require.NoError(t, err)
+
+		_ = msg.Iter(func(i int, p *message.Part) error {
+			contents = append(contents, string(p.Get()))
+			paths = append(paths, p.MetaGet("ftp_path"))
+			return nil
+		})
+		require.NoError(t, ackFn(ctx, nil))
+	}
+}
+
+func TestFTPInputDeleteOnFinish(t *testing.T) {
+	server := testServer(t)
+	old := time.Now().Add(-time.Hour)
+	server.SetFile("/a.txt", []byte("foo\nbar"), old)
+	server.SetFile("/b.txt", []byte("baz"), old)
+	server.SetFile("/c.csv", []byte("nope"), old)
+
+	conf := testInputConfig(server)
+	conf.Paths = []string{"/*.txt"}
+	conf.Codec = "lines"
+	conf.DeleteOnFinish = true
+
+	r, err := newFTPReader(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	contents, paths := readAll(t, r)
+	assert.ElementsMatch(t, []string{"foo", "bar", "baz"}, contents)
+	assert.ElementsMatch(t, []string{"/a.txt", "/a.txt", "/b.txt"}, paths)
+	assert.Equal(t, []string{"/c.csv"}, server.Files())
+}
+
+func TestFTPInputMoveOnFinish(t *testing.T) {
+	server := testServer(t)
+	server.SetFile("/in/a.txt", []byte("foo"), time.Now())
+
+	conf := testInputConfig(server)
+	conf.Paths = []string{"/in/*.txt"}
+	conf.MoveOnFinish = "/done/today"
+	conf.TransferMode = "active"
+
+	r, err := newFTPReader(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	contents, _ := readAll(t, r)
+	assert.Equal(t, []string{"foo"}, contents)
+	assert.Equal(t, []string{"/done/today/a.txt"}, server.Files())
+}
+
+func TestFTPInputWatcher(t *testing.T) {
+	server := testServer(t)
+	server.SetFile("/in/a.txt", []byte("foo"), time.Now().Add(-time.Hour))
+	server.SetFile("/in/b.txt", []byte("too new"), time.Now().Add(time.Hour))
+
+	mgr := mock.NewManager()
+	mgr.Caches["seen"] = map[string]mock.CacheItem{}
+
+	conf := testInputConfig(server)
+	conf.Paths = []string{"/in/*.txt"}
+	conf.Watcher.Enabled = true
+	conf.Watcher.Cache = "seen"
+	conf.Watcher.MinimumAge = "1m"
+	conf.Watcher.PollInterval = "10ms"
+
+	r, err := newFTPReader(conf, mgr)
+	require.NoError(t, err)
+	t.Cleanup(r.CloseAsync)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	var contents []string
+	readOne := func() {
+		for {
+			require.NoError(t, r.ConnectWithContext(ctx))
+			msg, ackFn, err := r.ReadWithContext(ctx)
+			if err == component.ErrTimeout || err == component.ErrNotConnected {
+				continue
+			}
+			require.NoError(t, err)
+			contents = append(contents, string(msg.Get(0).Get()))
+			require.NoError(t, ackFn(ctx, nil))
+			return
+		}
+	}
+
+	readOne()
+	assert.Equal(t, []string{"foo"}, contents)
+	assert.Contains(t, mgr.Caches["seen"], "/in/a.txt")
+
+	server.SetFile("/in/c.txt", []byte("bar"), time.Now().Add(-time.Hour))
+	readOne()
+	assert.Equal(t, []string{"foo", "bar"}, contents)
+
+	// Files remain on the server but are not consumed again.
+	assert.Equal(t, []string{"/in/a.txt", "/in/b.txt", "/in/c.txt"}, server.Files())
+}
+
+func TestFTPInputConfigErrors(t *testing.T) {
+	server := testServer(t)
+
+	conf := testInputConfig(server)
+	conf.DeleteOnFinish = true
+	conf.MoveOnFinish = "/foo"
+	_, err := newFTPReader(conf, mock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot combine")
+
+	conf = testInputConfig(server)
+	conf.Watcher.Enabled = true
+	conf.Watcher.Cache = "nope"
+	_, err = newFTPReader(conf, mock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache resource 'nope' was not found")
+
+	conf = testInputConfig(server)
+	conf.TransferMode = "sideways"
+	_, err = newFTPReader(conf, mock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised transfer mode")
+}
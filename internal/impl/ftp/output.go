@@ -0,0 +1,281 @@
+package ftp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/ftp/shared"
+	"github.com/benthosdev/benthos/v4/internal/interop"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output"
+	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
+)
+
+func init() {
+	err := bundle.AllOutputs.Add(bundle.OutputConstructorFromSimple(func(c output.Config, nm bundle.NewManagement) (ioutput.Streamed, error) {
+		f, err := newFTPWriter(c.FTP, nm, nm.Logger())
+		if err != nil {
+			return nil, err
+		}
+		w, err := output.NewAsyncWriter(output.TypeFTP, c.FTP.MaxInFlight, f, nm.Logger(), nm.Metrics())
+		if err != nil {
+			return nil, err
+		}
+		return output.OnlySinglePayloads(w), nil
+	}), docs.ComponentSpec{
+		Name:       output.TypeFTP,
+		Type:       docs.TypeOutput,
+		Status:     docs.StatusExperimental,
+		Version:    "4.0.0",
+		Categories: []string{"Network"},
+		Summary:    `Writes files to a server over FTP or FTPS.`,
+		Description: ioutput.Description(true, false, `
+In order to have a different path for each file you should use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries). Directories of each path are created when they do not already exist.
+
+Each message is encoded with the `+"[`codec`](#codec)"+` and uploaded with a separate transfer, where codecs that append to files (such as `+"`lines`"+`) append the encoded message to the file and all other codecs replace the file. Each message in flight uses its own connection to the server, and therefore `+"`max_in_flight`"+` also limits the number of connections that are opened.
+
+### Transfer Modes and TLS
+
+By default data connections are established in passive mode, where the client connects to a port opened by the server. Servers that are unable to accept incoming data connections can be used in active mode by setting `+"`transfer_mode` to `active`"+`, in which case the server connects to a port opened by Benthos, which must therefore be reachable from the server.
+
+When `+"`tls.enabled`"+` is set the connection is secured with FTPS, where the mode of TLS negotiation is determined by the field `+"`tls_mode`"+`.
+
+### Atomic Uploads
+
+When `+"`atomic_upload`"+` is enabled each file is first uploaded to a temporary file within the same directory, named after the target file with a random suffix ending in `+"`.tmp`"+`, which is then renamed to the target path once the upload completes. This ensures that partially written files are never observed by consumers of the server. Atomic uploads cannot be used with codecs that append to files.`),
+		Config: docs.FieldComponent().WithChildren(shared.ConnectionDocs()...).WithChildren(
+			docs.FieldString(
+				"path", "The path of each file to write.",
+				`/uploads/${!count("files")}-${!timestamp_unix_nano()}.txt`,
+				`/uploads/${!meta("kafka_key")}.json`,
+			).IsInterpolated(),
+			codec.WriterDocs,
+			docs.FieldBool("atomic_upload", "Whether to upload each file to a temporary path before renaming it to the target path, ensuring that partially written files are never observed.").Advanced(),
+			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time, each of which uses a separate connection to the server. Increase this to improve throughput."),
+		).ChildDefaultAndTypesFromStruct(output.NewFTPConfig()),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type ftpWriter struct {
+	conf output.FTPConfig
+	opts shared.ClientOptions
+
+	log log.Modular
+
+	path      *field.Expression
+	codec     codec.WriterConstructor
+	codecConf codec.WriterConfig
+
+	connMut sync.Mutex
+	clients chan *shared.Client
+}
+
+func newFTPWriter(conf output.FTPConfig, mgr interop.Manager, log log.Modular) (*ftpWriter, error) {
+	opts, err := conf.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	if conf.MaxInFlight < 1 {
+		return nil, errors.New("max_in_flight must be at least 1")
+	}
+
+	f := &ftpWriter{
+		conf: conf,
+		opts: opts,
+		log:  log,
+	}
+	if f.codec, f.codecConf, err = codec.GetWriter(conf.Codec); err != nil {
+		return nil, err
+	}
+	if conf.AtomicUpload && f.codecConf.Append {
+		return nil, fmt.Errorf("atomic uploads cannot be used with codec %v as it appends to files", conf.Codec)
+	}
+	if f.path, err = mgr.BloblEnvironment().NewField(conf.Path); err != nil {
+		return nil, fmt.Errorf("failed to parse path expression: %w", err)
+	}
+	return f, nil
+}
+
+// ConnectWithContext attempts to establish a connection to the target FTP
+// server.
+func (f *ftpWriter) ConnectWithContext(ctx context.Context) error {
+	f.connMut.Lock()
+	defer f.connMut.Unlock()
+
+	if f.clients != nil {
+		return nil
+	}
+
+	// Establish the first connection eagerly so that connection errors are
+	// surfaced here rather than during writes.
+	client, err := shared.Dial(f.opts)
+	if err != nil {
+		return err
+	}
+
+	f.clients = make(chan *shared.Client, f.conf.MaxInFlight)
+	f.clients <- client
+	for i := 1; i < f.conf.MaxInFlight; i++ {
+		f.clients <- nil
+	}
+
+	f.log.Infof("Writing files to FTP server: %v\n", f.conf.Address)
+	return nil
+}
+
+// WriteWithContext attempts to write message contents to a target file via an
+// FTP connection.
+func (f *ftpWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	f.connMut.Lock()
+	clients := f.clients
+	f.connMut.Unlock()
+
+	if clients == nil {
+		return component.ErrNotConnected
+	}
+
+	return writer.IterateBatchedSend(msg, func(i int, p *message.Part) error {
+		var buf bytes.Buffer
+		handle, err := f.codec(nopWriteCloser{&buf})
+		if err != nil {
+			return err
+		}
+		if err := handle.Write(ctx, p); err != nil {
+			return err
+		}
+		if err := handle.Close(ctx); err != nil {
+			return err
+		}
+
+		var client *shared.Client
+		select {
+		case client = <-clients:
+		case <-ctx.Done():
+			return component.ErrTimeout
+		}
+		if client == nil {
+			if client, err = shared.Dial(f.opts); err != nil {
+				clients <- nil
+				return err
+			}
+		}
+
+		err = f.upload(client, f.path.String(i, msg), buf.Bytes())
+		if shared.IsConnectionError(err) {
+			_ = client.Quit()
+			client = nil
+		}
+		clients <- client
+		return err
+	})
+}
+
+func (f *ftpWriter) upload(client *shared.Client, filePath string, data []byte) error {
+	if err := client.MakeDirAll(path.Dir(filePath)); err != nil {
+		return err
+	}
+
+	if f.codecConf.Append {
+		w, err := client.Append(filePath)
+		if err != nil {
+			return err
+		}
+		return writeAndClose(w, data)
+	}
+
+	writePath := filePath
+	if f.conf.AtomicUpload {
+		writePath = tempPath(filePath)
+	}
+
+	w, err := client.Store(writePath)
+	if err != nil {
+		return err
+	}
+	if err := writeAndClose(w, data); err != nil {
+		return err
+	}
+
+	if writePath == filePath {
+		return nil
+	}
+	if err := client.Rename(writePath, filePath); err != nil {
+		if shared.IsConnectionError(err) {
+			return err
+		}
+		// Some servers refuse to rename over an existing file.
+		_ = client.Delete(filePath)
+		if err := client.Rename(writePath, filePath); err != nil {
+			_ = client.Delete(writePath)
+			return fmt.Errorf("failed to rename temporary file %v to %v: %w", writePath, filePath, err)
+		}
+	}
+	return nil
+}
+
+func writeAndClose(w io.WriteCloser, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// tempPath returns a unique temporary path within the same directory as the
+// target path.
+func tempPath(filePath string) string {
+	var suffix [6]byte
+	_, _ = rand.Read(suffix[:])
+	return fmt.Sprintf("%v.%x.tmp", filePath, suffix)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (f *ftpWriter) CloseAsync() {
+	go func() {
+		f.connMut.Lock()
+		defer f.connMut.Unlock()
+
+		if f.clients == nil {
+			return
+		}
+		for i := 0; i < f.conf.MaxInFlight; i++ {
+			if client := <-f.clients; client != nil {
+				_ = client.Quit()
+			}
+		}
+		f.clients = nil
+	}()
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (f *ftpWriter) WaitForClose(time.Duration) error {
+	return nil
+}
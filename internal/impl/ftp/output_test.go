@@ -0,0 +1,117 @@
+package ftp
+
+import (
+	"context"
+	"crypto/tls"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/ftp/shared/ftptest"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output"
+)
+
+func testOutputConfig(server *ftptest.Server) output.FTPConfig {
+	conf := output.NewFTPConfig()
+	conf.Address = server.Addr()
+	conf.Credentials.Username = "foo"
+	conf.Credentials.Password = "bar"
+	conf.Timeout = "5s"
+	return conf
+}
+
+func writeMessages(t testing.TB, w *ftpWriter, contents ...string) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.ConnectWithContext(ctx))
+	for _, c := range contents {
+		msg := message.QuickBatch([][]byte{[]byte(c)})
+		msg.Get(0).MetaSet("id", c)
+		require.NoError(t, w.WriteWithContext(ctx, msg))
+	}
+}
+
+func TestFTPOutputAtomicUpload(t *testing.T) {
+	server := testServer(t)
+
+	conf := testOutputConfig(server)
+	conf.Path = `/out/${! meta("id") }.txt`
+	conf.AtomicUpload = true
+	conf.MaxInFlight = 2
+
+	w, err := newFTPWriter(conf, mock.NewManager(), log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(w.CloseAsync)
+
+	writeMessages(t, w, "foo", "bar")
+	assert.Equal(t, []string{"/out/bar.txt", "/out/foo.txt"}, server.Files())
+
+	f, ok := server.GetFile("/out/foo.txt")
+	require.True(t, ok)
+	assert.Equal(t, "foo", string(f.Data))
+}
+
+func TestFTPOutputAppendCodec(t *testing.T) {
+	server := testServer(t)
+
+	conf := testOutputConfig(server)
+	conf.Path = `/out/all.txt`
+	conf.Codec = "lines"
+	conf.TransferMode = "active"
+
+	w, err := newFTPWriter(conf, mock.NewManager(), log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(w.CloseAsync)
+
+	writeMessages(t, w, "foo", "bar", "baz")
+
+	f, ok := server.GetFile("/out/all.txt")
+	require.True(t, ok)
+	assert.Equal(t, "foo\nbar\nbaz\n", string(f.Data))
+}
+
+func TestFTPOutputTLS(t *testing.T) {
+	server := testServer(t)
+	_, err := server.EnableTLS()
+	require.NoError(t, err)
+
+	conf := testOutputConfig(server)
+	conf.Path = `/${! meta("id") }.txt`
+	conf.TLS.Enabled = true
+	conf.TLS.InsecureSkipVerify = true
+
+	w, err := newFTPWriter(conf, mock.NewManager(), log.Noop())
+	require.NoError(t, err)
+	t.Cleanup(w.CloseAsync)
+
+	writeMessages(t, w, "foo")
+	assert.Equal(t, []string{"/foo.txt"}, server.Files())
+	assert.Equal(t, uint16(tls.VersionTLS12), w.opts.TLS.MinVersion)
+}
+
+func TestFTPOutputConfigErrors(t *testing.T) {
+	server := testServer(t)
+
+	conf := testOutputConfig(server)
+	conf.Path = "/foo.txt"
+	conf.Codec = "lines"
+	conf.AtomicUpload = true
+	_, err := newFTPWriter(conf, mock.NewManager(), log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "atomic uploads cannot be used")
+
+	conf = testOutputConfig(server)
+	conf.Path = "/foo.txt"
+	conf.MaxInFlight = 0
+	_, err = newFTPWriter(conf, mock.NewManager(), log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max_in_flight")
+}
@@ -0,0 +1,3 @@
+// Package ftp will eventually contain all implementations of FTP components
+// (that are currently within ./internal/old)
+package ftp
@@ -0,0 +1,628 @@
+package shared
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transfer modes supported for FTP data connections.
+const (
+	TransferModePassive = "passive"
+	TransferModeActive  = "active"
+)
+
+// TLS modes supported for FTPS connections.
+const (
+	TLSModeExplicit = "explicit"
+	TLSModeImplicit = "implicit"
+)
+
+// ClientOptions describes how a Client connects to an FTP server.
+type ClientOptions struct {
+	Address       string
+	Username      string
+	Password      string
+	TransferMode  string
+	ActiveAddress string
+	TLS           *tls.Config
+	TLSMode       string
+	Timeout       time.Duration
+}
+
+// Entry describes a file or directory listed from an FTP server.
+type Entry struct {
+	Path    string
+	IsDir   bool
+	ModTime time.Time
+}
+
+// Client is a minimal FTP client supporting passive and active transfer modes
+// as well as explicit and implicit TLS. The control connection of FTP can only
+// serve a single command at a time and therefore calls to a Client must not be
+// made concurrently, and a file being read or written must be closed before
+// any other command is issued.
+type Client struct {
+	opts ClientOptions
+
+	conn net.Conn
+	text *textproto.Conn
+	host string
+
+	tlsConf *tls.Config
+
+	mlsdUnsupported bool
+}
+
+// Dial connects to and logs into an FTP server.
+func Dial(opts ClientOptions) (*Client, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second * 30
+	}
+	switch opts.TransferMode {
+	case "":
+		opts.TransferMode = TransferModePassive
+	case TransferModePassive, TransferModeActive:
+	default:
+		return nil, fmt.Errorf("unrecognised transfer mode: %v", opts.TransferMode)
+	}
+	switch opts.TLSMode {
+	case "":
+		opts.TLSMode = TLSModeExplicit
+	case TLSModeExplicit, TLSModeImplicit:
+	default:
+		return nil, fmt.Errorf("unrecognised tls mode: %v", opts.TLSMode)
+	}
+
+	host, _, err := net.SplitHostPort(opts.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse address: %w", err)
+	}
+
+	c := &Client{opts: opts, host: host}
+	if opts.TLS != nil {
+		c.tlsConf = opts.TLS.Clone()
+		if c.tlsConf.ServerName == "" {
+			c.tlsConf.ServerName = host
+		}
+		if c.tlsConf.ClientSessionCache == nil {
+			// Many servers require data connections to resume the TLS
+			// session of the control connection.
+			c.tlsConf.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+		}
+	}
+
+	conn, err := net.DialTimeout("tcp", opts.Address, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if c.tlsConf != nil && opts.TLSMode == TLSModeImplicit {
+		conn = tls.Client(conn, c.tlsConf)
+	}
+	c.setConn(conn)
+
+	if err := c.login(); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) setConn(conn net.Conn) {
+	c.conn = conn
+	c.text = textproto.NewConn(conn)
+}
+
+func (c *Client) login() error {
+	if _, _, err := c.readResponse(220); err != nil {
+		return err
+	}
+
+	if c.tlsConf != nil && c.opts.TLSMode == TLSModeExplicit {
+		if _, _, err := c.cmd(234, "AUTH TLS"); err != nil {
+			return err
+		}
+		c.setConn(tls.Client(c.conn, c.tlsConf))
+	}
+
+	code, msg, err := c.cmd(-1, "USER %s", c.opts.Username)
+	if err != nil {
+		return err
+	}
+	switch code {
+	case 230:
+	case 331:
+		if _, _, err := c.cmd(230, "PASS %s", c.opts.Password); err != nil {
+			return err
+		}
+	default:
+		return &textproto.Error{Code: code, Msg: msg}
+	}
+
+	if c.tlsConf != nil {
+		if _, _, err := c.cmd(200, "PBSZ 0"); err != nil {
+			return err
+		}
+		if _, _, err := c.cmd(200, "PROT P"); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = c.cmd(200, "TYPE I")
+	return err
+}
+
+func (c *Client) deadline() {
+	_ = c.conn.SetDeadline(time.Now().Add(c.opts.Timeout))
+}
+
+func (c *Client) readResponse(expectCode int) (int, string, error) {
+	c.deadline()
+	code, msg, err := c.text.ReadResponse(expectCode)
+	if expectCode < 0 {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) {
+			err = nil
+		}
+	}
+	return code, msg, err
+}
+
+// cmd sends a command and reads its response, a negative expected code
+// returns the response without checking it.
+func (c *Client) cmd(expectCode int, format string, args ...interface{}) (int, string, error) {
+	c.deadline()
+	if _, err := c.text.Cmd(format, args...); err != nil {
+		return 0, "", err
+	}
+	return c.readResponse(expectCode)
+}
+
+//------------------------------------------------------------------------------
+
+type dataConnOpener func() (net.Conn, error)
+
+// prepareDataConn negotiates a data connection ahead of a transfer command and
+// returns a function that establishes it once the command has been sent.
+func (c *Client) prepareDataConn() (dataConnOpener, func(), error) {
+	if c.opts.TransferMode == TransferModeActive {
+		return c.prepareActive()
+	}
+	return c.preparePassive()
+}
+
+func (c *Client) preparePassive() (dataConnOpener, func(), error) {
+	port, err := c.epsv()
+	if err != nil {
+		if port, err = c.pasv(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The host advertised by PASV is often an internal address of servers
+	// behind NAT and therefore we always dial the host of the control
+	// connection.
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(c.host, strconv.Itoa(port)), c.opts.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	return func() (net.Conn, error) {
+		return c.wrapDataConn(conn), nil
+	}, func() { conn.Close() }, nil
+}
+
+func (c *Client) epsv() (int, error) {
+	_, msg, err := c.cmd(229, "EPSV")
+	if err != nil {
+		return 0, err
+	}
+	start, end := strings.Index(msg, "|||"), strings.LastIndex(msg, "|")
+	if start == -1 || end <= start+3 {
+		return 0, fmt.Errorf("invalid EPSV response: %v", msg)
+	}
+	return strconv.Atoi(msg[start+3 : end])
+}
+
+func (c *Client) pasv() (int, error) {
+	_, msg, err := c.cmd(227, "PASV")
+	if err != nil {
+		return 0, err
+	}
+	start, end := strings.Index(msg, "("), strings.LastIndex(msg, ")")
+	if start == -1 || end <= start {
+		return 0, fmt.Errorf("invalid PASV response: %v", msg)
+	}
+	parts := strings.Split(msg[start+1:end], ",")
+	if len(parts) != 6 {
+		return 0, fmt.Errorf("invalid PASV response: %v", msg)
+	}
+	high, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return 0, fmt.Errorf("invalid PASV response: %v", msg)
+	}
+	low, err := strconv.Atoi(parts[5])
+	if err != nil {
+		return 0, fmt.Errorf("invalid PASV response: %v", msg)
+	}
+	return high<<8 | low, nil
+}
+
+func (c *Client) prepareActive() (dataConnOpener, func(), error) {
+	localIP := c.conn.LocalAddr().(*net.TCPAddr).IP
+
+	listenAddr := c.opts.ActiveAddress
+	if listenAddr == "" {
+		listenAddr = net.JoinHostPort(localIP.String(), "0")
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to listen for active data connection: %w", err)
+	}
+
+	addr := listener.Addr().(*net.TCPAddr)
+	advertiseIP := addr.IP
+	if advertiseIP.IsUnspecified() {
+		advertiseIP = localIP
+	}
+
+	if ip4 := advertiseIP.To4(); ip4 != nil {
+		_, _, err = c.cmd(200, "PORT %d,%d,%d,%d,%d,%d", ip4[0], ip4[1], ip4[2], ip4[3], addr.Port>>8, addr.Port&0xff)
+	} else {
+		_, _, err = c.cmd(200, "EPRT |2|%v|%d|", advertiseIP, addr.Port)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, nil, err
+	}
+
+	return func() (net.Conn, error) {
+			defer listener.Close()
+			if tcpListener, ok := listener.(*net.TCPListener); ok {
+				_ = tcpListener.SetDeadline(time.Now().Add(c.opts.Timeout))
+			}
+			conn, err := listener.Accept()
+			if err != nil {
+				return nil, fmt.Errorf("failed to accept active data connection: %w", err)
+			}
+			return c.wrapDataConn(conn), nil
+		}, func() {
+			listener.Close()
+		}, nil
+}
+
+func (c *Client) wrapDataConn(conn net.Conn) net.Conn {
+	if c.tlsConf == nil {
+		return conn
+	}
+	return tls.Client(conn, c.tlsConf)
+}
+
+// transfer issues a command that results in a data transfer and returns the
+// established data connection.
+func (c *Client) transfer(format string, args ...interface{}) (net.Conn, error) {
+	open, cancel, err := c.prepareDataConn()
+	if err != nil {
+		return nil, err
+	}
+
+	code, msg, err := c.cmd(-1, format, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if code != 125 && code != 150 {
+		cancel()
+		return nil, &textproto.Error{Code: code, Msg: msg}
+	}
+
+	conn, err := open()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+//------------------------------------------------------------------------------
+
+type dataReader struct {
+	c    *Client
+	conn net.Conn
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (r *dataReader) Read(p []byte) (int, error) {
+	return r.conn.Read(p)
+}
+
+func (r *dataReader) Close() error {
+	r.closeOnce.Do(func() {
+		if err := r.conn.Close(); err != nil {
+			r.closeErr = err
+			return
+		}
+		_, _, r.closeErr = r.c.readResponse(226)
+	})
+	return r.closeErr
+}
+
+type dataWriter struct {
+	c    *Client
+	conn net.Conn
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (w *dataWriter) Write(p []byte) (int, error) {
+	return w.conn.Write(p)
+}
+
+func (w *dataWriter) Close() error {
+	w.closeOnce.Do(func() {
+		if err := w.conn.Close(); err != nil {
+			w.closeErr = err
+			return
+		}
+		_, _, w.closeErr = w.c.readResponse(226)
+	})
+	return w.closeErr
+}
+
+// Retrieve opens a file from the server for reading, the returned reader must
+// be closed before any further commands are issued.
+func (c *Client) Retrieve(filePath string) (io.ReadCloser, error) {
+	conn, err := c.transfer("RETR %s", filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &dataReader{c: c, conn: conn}, nil
+}
+
+// Store opens a file on the server for writing, replacing any existing file.
+// The returned writer must be closed before any further commands are issued.
+func (c *Client) Store(filePath string) (io.WriteCloser, error) {
+	conn, err := c.transfer("STOR %s", filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &dataWriter{c: c, conn: conn}, nil
+}
+
+// Append opens a file on the server for appending, creating the file if it
+// does not exist. The returned writer must be closed before any further
+// commands are issued.
+func (c *Client) Append(filePath string) (io.WriteCloser, error) {
+	conn, err := c.transfer("APPE %s", filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &dataWriter{c: c, conn: conn}, nil
+}
+
+// Delete removes a file from the server.
+func (c *Client) Delete(filePath string) error {
+	_, _, err := c.cmd(250, "DELE %s", filePath)
+	return err
+}
+
+// Rename moves a file on the server.
+func (c *Client) Rename(from, to string) error {
+	if _, _, err := c.cmd(350, "RNFR %s", from); err != nil {
+		return err
+	}
+	_, _, err := c.cmd(250, "RNTO %s", to)
+	return err
+}
+
+// MakeDirAll creates a directory along with any missing parents. Errors from
+// creating each directory are ignored as they most likely already exist, and
+// any other problem surfaces when the directory is used.
+func (c *Client) MakeDirAll(dir string) error {
+	dir = path.Clean(dir)
+	if dir == "." || dir == "/" {
+		return nil
+	}
+
+	var current string
+	if strings.HasPrefix(dir, "/") {
+		current = "/"
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(dir, "/"), "/") {
+		current = path.Join(current, segment)
+		if _, _, err := c.cmd(-1, "MKD %s", current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ModTime returns the last modification time of a file.
+func (c *Client) ModTime(filePath string) (time.Time, error) {
+	_, msg, err := c.cmd(213, "MDTM %s", filePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseFTPTime(msg)
+}
+
+func parseFTPTime(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if i := strings.Index(v, "."); i != -1 {
+		v = v[:i]
+	}
+	return time.ParseInLocation("20060102150405", v, time.UTC)
+}
+
+// List returns the entries of a directory. Directories are only reported when
+// the server supports machine readable listings, otherwise all entries are
+// reported as files with a zero modification time.
+func (c *Client) List(dir string) ([]Entry, error) {
+	if !c.mlsdUnsupported {
+		entries, err := c.listMLSD(dir)
+		if err == nil {
+			return entries, nil
+		}
+		var protoErr *textproto.Error
+		if !errors.As(err, &protoErr) || (protoErr.Code != 500 && protoErr.Code != 501 && protoErr.Code != 502) {
+			return nil, err
+		}
+		c.mlsdUnsupported = true
+	}
+	return c.listNLST(dir)
+}
+
+func (c *Client) readLines(format string, args ...interface{}) ([]string, error) {
+	conn, err := c.transfer(format, args...)
+	if err != nil {
+		return nil, err
+	}
+	r := &dataReader{c: c, conn: conn}
+
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if err := r.Close(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func (c *Client) listMLSD(dir string) ([]Entry, error) {
+	lines, err := c.readLines("MLSD %s", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, line := range lines {
+		i := strings.Index(line, " ")
+		if i == -1 {
+			continue
+		}
+		facts, name := line[:i], line[i+1:]
+		if name == "." || name == ".." {
+			continue
+		}
+
+		e := Entry{Path: path.Join(dir, name)}
+		skip := false
+		for _, fact := range strings.Split(facts, ";") {
+			kv := strings.SplitN(fact, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch strings.ToLower(kv[0]) {
+			case "type":
+				switch strings.ToLower(kv[1]) {
+				case "dir":
+					e.IsDir = true
+				case "cdir", "pdir":
+					skip = true
+				}
+			case "modify":
+				if t, err := parseFTPTime(kv[1]); err == nil {
+					e.ModTime = t
+				}
+			}
+		}
+		if !skip {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (c *Client) listNLST(dir string) ([]Entry, error) {
+	lines, err := c.readLines("NLST %s", dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(lines))
+	for _, line := range lines {
+		// Some servers list names relative to the directory and others
+		// list full paths.
+		name := path.Base(line)
+		if name == "." || name == ".." {
+			continue
+		}
+		entries = append(entries, Entry{Path: path.Join(dir, name)})
+	}
+	return entries, nil
+}
+
+// Glob returns the paths of files matching a pattern, where wildcards are only
+// supported within the final segment of the pattern.
+func (c *Client) Glob(pattern string) ([]Entry, error) {
+	dir := path.Dir(pattern)
+	if strings.ContainsAny(dir, "*?[") {
+		return nil, fmt.Errorf("wildcards are only supported within file names: %v", pattern)
+	}
+
+	if !strings.ContainsAny(path.Base(pattern), "*?[") {
+		return []Entry{{Path: pattern}}, nil
+	}
+
+	entries, err := c.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Entry
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		if ok, _ := path.Match(pattern, e.Path); ok {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
+
+// NoOp sends a NOOP command in order to verify that the connection is alive.
+func (c *Client) NoOp() error {
+	_, _, err := c.cmd(200, "NOOP")
+	return err
+}
+
+// Quit logs out and closes the connection to the server.
+func (c *Client) Quit() error {
+	_, _, _ = c.cmd(-1, "QUIT")
+	return c.conn.Close()
+}
+
+// IsConnectionError returns true if an error returned by a Client indicates
+// that the connection is no longer usable.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		// 421 indicates that the server is closing the control connection.
+		return protoErr.Code == 421
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package shared_test
+
+import (
+	"crypto/tls"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/ftp/shared"
+	"github.com/benthosdev/benthos/v4/internal/impl/ftp/shared/ftptest"
+)
+
+func testServer(t testing.TB) *ftptest.Server {
+	t.Helper()
+
+	server, err := ftptest.NewServer("foo", "bar")
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dialTest(t testing.TB, opts shared.ClientOptions) *shared.Client {
+	t.Helper()
+
+	opts.Username, opts.Password = "foo", "bar"
+	opts.Timeout = time.Second * 5
+
+	client, err := shared.Dial(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = client.Quit()
+	})
+	return client
+}
+
+func writeFile(t testing.TB, w io.WriteCloser, data string) {
+	t.Helper()
+
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func readFile(t testing.TB, client *shared.Client, filePath string) string {
+	t.Helper()
+
+	r, err := client.Retrieve(filePath)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	return string(data)
+}
+
+func testClientRoundTrip(t *testing.T, server *ftptest.Server, client *shared.Client) {
+	t.Helper()
+
+	require.NoError(t, client.MakeDirAll("/in/nested"))
+
+	w, err := client.Store("/in/nested/a.txt")
+	require.NoError(t, err)
+	writeFile(t, w, "hello")
+
+	w, err = client.Append("/in/nested/a.txt")
+	require.NoError(t, err)
+	writeFile(t, w, " world")
+
+	w, err = client.Store("/in/nested/b.json")
+	require.NoError(t, err)
+	writeFile(t, w, `{"id":"b"}`)
+
+	assert.Equal(t, "hello world", readFile(t, client, "/in/nested/a.txt"))
+
+	entries, err := client.Glob("/in/nested/*.txt")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/in/nested/a.txt", entries[0].Path)
+
+	require.NoError(t, client.MakeDirAll("/done"))
+	require.NoError(t, client.Rename("/in/nested/a.txt", "/done/a.txt"))
+	require.NoError(t, client.Delete("/in/nested/b.json"))
+
+	assert.Equal(t, []string{"/done/a.txt"}, server.Files())
+
+	_, err = client.Retrieve("/in/nested/a.txt")
+	require.Error(t, err)
+	assert.False(t, shared.IsConnectionError(err))
+
+	require.NoError(t, client.NoOp())
+}
+
+func TestClientPassive(t *testing.T) {
+	server := testServer(t)
+	client := dialTest(t, shared.ClientOptions{
+		Address:      server.Addr(),
+		TransferMode: shared.TransferModePassive,
+	})
+	testClientRoundTrip(t, server, client)
+}
+
+func TestClientActive(t *testing.T) {
+	server := testServer(t)
+	client := dialTest(t, shared.ClientOptions{
+		Address:      server.Addr(),
+		TransferMode: shared.TransferModeActive,
+	})
+	testClientRoundTrip(t, server, client)
+}
+
+func TestClientExplicitTLS(t *testing.T) {
+	server := testServer(t)
+	pool, err := server.EnableTLS()
+	require.NoError(t, err)
+
+	client := dialTest(t, shared.ClientOptions{
+		Address: server.Addr(),
+		TLS:     &tls.Config{RootCAs: pool},
+	})
+	testClientRoundTrip(t, server, client)
+}
+
+func TestClientNLSTFallback(t *testing.T) {
+	server := testServer(t)
+	server.DisableMLSD = true
+
+	modTime := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	server.SetFile("/a.csv", []byte("a"), modTime)
+	server.SetFile("/b.csv", []byte("b"), modTime)
+	server.SetFile("/c.txt", []byte("c"), modTime)
+
+	client := dialTest(t, shared.ClientOptions{Address: server.Addr()})
+
+	entries, err := client.Glob("/*.csv")
+	require.NoError(t, err)
+
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	assert.Equal(t, []string{"/a.csv", "/b.csv"}, paths)
+
+	mod, err := client.ModTime("/a.csv")
+	require.NoError(t, err)
+	assert.Equal(t, modTime, mod)
+}
+
+func TestClientLoginFailure(t *testing.T) {
+	server := testServer(t)
+
+	_, err := shared.Dial(shared.ClientOptions{
+		Address:  server.Addr(),
+		Username: "foo",
+		Password: "nope",
+		Timeout:  time.Second * 5,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "login incorrect")
+}
+
+func TestConnectionConfigErrors(t *testing.T) {
+	conf := shared.NewConnectionConfig()
+	_, err := conf.ClientOptions()
+	assert.EqualError(t, err, "an address must be specified")
+
+	conf.Address = "localhost:21"
+	conf.TransferMode = "nope"
+	_, err = conf.ClientOptions()
+	assert.EqualError(t, err, "unrecognised transfer mode: nope")
+
+	conf.TransferMode = shared.TransferModeActive
+	conf.TLS.Enabled = true
+	opts, err := conf.ClientOptions()
+	require.NoError(t, err)
+	assert.NotNil(t, opts.TLS)
+	assert.Equal(t, time.Second*30, opts.Timeout)
+}
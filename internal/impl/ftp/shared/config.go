@@ -0,0 +1,127 @@
+// Package shared contains an FTP client and docs fields that need to be shared
+// across old and new component implementations, it needs to be separate from
+// the parent package in order to avoid circular dependencies (for now).
+package shared
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	btls "github.com/benthosdev/benthos/v4/internal/tls"
+)
+
+// ConnectionDocs returns documentation field specs for the connection fields
+// of an FTP component.
+func ConnectionDocs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString(
+			"address",
+			"The address of the server to connect to.",
+			"ftp.example.com:21",
+		),
+		docs.FieldObject(
+			"credentials",
+			"The credentials to use to log into the server.",
+		).WithChildren(
+			docs.FieldString("username", "The username to log into the server with."),
+			docs.FieldString("password", "The password for the username to log into the server with."),
+		),
+		docs.FieldString(
+			"transfer_mode",
+			"The mode used for establishing data connections. In `passive` mode the client connects to a port opened by the server, whereas in `active` mode the server connects to a port opened by the client.",
+		).HasOptions(TransferModePassive, TransferModeActive).Advanced(),
+		docs.FieldString(
+			"active_address",
+			"An optional address to listen on for data connections in `active` mode, where the IP address is also advertised to the server. By default the local address of the control connection is used with a random port.",
+			"0.0.0.0:40000",
+		).Advanced(),
+		btls.FieldSpec(),
+		docs.FieldString(
+			"tls_mode",
+			"When TLS is enabled this field determines whether the connection is upgraded with `AUTH TLS` after connecting (`explicit`), or whether TLS is used from the start of the connection (`implicit`), which usually requires connecting to port 990.",
+		).HasOptions(TLSModeExplicit, TLSModeImplicit).Advanced(),
+		docs.FieldString(
+			"timeout",
+			"The maximum period of time to wait for a response from the server before the connection is considered broken.",
+		).Advanced(),
+	}
+}
+
+// Credentials contains the credentials for logging into an FTP server.
+type Credentials struct {
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+}
+
+// ConnectionConfig contains configuration fields for connecting to an FTP
+// server.
+type ConnectionConfig struct {
+	Address       string      `json:"address" yaml:"address"`
+	Credentials   Credentials `json:"credentials" yaml:"credentials"`
+	TransferMode  string      `json:"transfer_mode" yaml:"transfer_mode"`
+	ActiveAddress string      `json:"active_address" yaml:"active_address"`
+	TLS           btls.Config `json:"tls" yaml:"tls"`
+	TLSMode       string      `json:"tls_mode" yaml:"tls_mode"`
+	Timeout       string      `json:"timeout" yaml:"timeout"`
+}
+
+// NewConnectionConfig creates a ConnectionConfig with default values.
+func NewConnectionConfig() ConnectionConfig {
+	return ConnectionConfig{
+		Address:       "",
+		Credentials:   Credentials{},
+		TransferMode:  TransferModePassive,
+		ActiveAddress: "",
+		TLS:           btls.NewConfig(),
+		TLSMode:       TLSModeExplicit,
+		Timeout:       "30s",
+	}
+}
+
+// ClientOptions returns the options for dialing a Client from the config.
+func (c ConnectionConfig) ClientOptions() (ClientOptions, error) {
+	opts := ClientOptions{
+		Address:       c.Address,
+		Username:      c.Credentials.Username,
+		Password:      c.Credentials.Password,
+		TransferMode:  c.TransferMode,
+		ActiveAddress: c.ActiveAddress,
+		TLSMode:       c.TLSMode,
+	}
+	if c.Address == "" {
+		return opts, errors.New("an address must be specified")
+	}
+
+	switch c.TransferMode {
+	case TransferModePassive, TransferModeActive:
+	default:
+		return opts, fmt.Errorf("unrecognised transfer mode: %v", c.TransferMode)
+	}
+	switch c.TLSMode {
+	case TLSModeExplicit, TLSModeImplicit:
+	default:
+		return opts, fmt.Errorf("unrecognised tls mode: %v", c.TLSMode)
+	}
+
+	if c.Timeout != "" {
+		var err error
+		if opts.Timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return opts, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+
+	if c.TLS.Enabled {
+		tlsConf, err := c.TLS.Get()
+		if err != nil {
+			return opts, err
+		}
+		if tlsConf == nil {
+			tlsConf = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		opts.TLS = tlsConf
+	}
+	return opts, nil
+}
@@ -0,0 +1,464 @@
+// Package ftptest provides an in-memory FTP server for testing FTP components.
+package ftptest
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is a file stored by a Server.
+type File struct {
+	Data    []byte
+	ModTime time.Time
+}
+
+// Server is a minimal in-memory FTP server supporting passive and active data
+// connections, explicit TLS and the commands required by the FTP client.
+type Server struct {
+	Username string
+	Password string
+
+	// DisableMLSD causes the server to reject MLSD commands.
+	DisableMLSD bool
+
+	listener net.Listener
+	tlsConf  *tls.Config
+
+	mut   sync.Mutex
+	files map[string]File
+	dirs  map[string]struct{}
+
+	wg sync.WaitGroup
+}
+
+// NewServer starts a server listening on a random local port.
+func NewServer(username, password string) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		Username: username,
+		Password: password,
+		listener: listener,
+		files:    map[string]File{},
+		dirs:     map[string]struct{}{"/": {}},
+	}
+	s.wg.Add(1)
+	go s.loop()
+	return s, nil
+}
+
+// EnableTLS generates a self-signed certificate and allows clients to upgrade
+// connections with AUTH TLS. The returned certificate pool trusts the server.
+func (s *Server) EnableTLS() (*x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	s.mut.Lock()
+	s.tlsConf = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	s.mut.Unlock()
+	return pool, nil
+}
+
+// Addr returns the address of the server.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// SetFile stores a file on the server.
+func (s *Server) SetFile(filePath string, data []byte, modTime time.Time) {
+	s.mut.Lock()
+	s.files[path.Clean(filePath)] = File{Data: data, ModTime: modTime}
+	s.mut.Unlock()
+}
+
+// GetFile returns a file stored on the server.
+func (s *Server) GetFile(filePath string) (File, bool) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	f, ok := s.files[path.Clean(filePath)]
+	return f, ok
+}
+
+// Files returns the sorted paths of all files stored on the server.
+func (s *Server) Files() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	paths := make([]string, 0, len(s.files))
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (s *Server) loop() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.serve(conn)
+		}()
+	}
+}
+
+type session struct {
+	s *Server
+
+	conn   net.Conn
+	reader *bufio.Reader
+
+	loggedIn   bool
+	user       string
+	protected  bool
+	renameFrom string
+
+	passive    net.Listener
+	activeAddr string
+}
+
+func (s *Server) serve(conn net.Conn) {
+	sess := &session{s: s, conn: conn, reader: bufio.NewReader(conn)}
+	defer func() {
+		if sess.passive != nil {
+			sess.passive.Close()
+		}
+		sess.conn.Close()
+	}()
+
+	sess.reply(220, "ftptest ready")
+	for {
+		line, err := sess.reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd, arg := line, ""
+		if i := strings.Index(line, " "); i != -1 {
+			cmd, arg = line[:i], line[i+1:]
+		}
+		if !sess.handle(strings.ToUpper(cmd), arg) {
+			return
+		}
+	}
+}
+
+func (c *session) reply(code int, msg string) {
+	fmt.Fprintf(c.conn, "%d %s\r\n", code, msg)
+}
+
+func (c *session) handle(cmd, arg string) bool {
+	switch cmd {
+	case "AUTH":
+		c.s.mut.Lock()
+		tlsConf := c.s.tlsConf
+		c.s.mut.Unlock()
+		if tlsConf == nil {
+			c.reply(502, "TLS not supported")
+			return true
+		}
+		c.reply(234, "AUTH TLS successful")
+		c.conn = tls.Server(c.conn, tlsConf)
+		c.reader = bufio.NewReader(c.conn)
+		return true
+	case "USER":
+		c.user = arg
+		c.reply(331, "password required")
+		return true
+	case "PASS":
+		if c.user != c.s.Username || arg != c.s.Password {
+			c.reply(530, "login incorrect")
+			return true
+		}
+		c.loggedIn = true
+		c.reply(230, "logged in")
+		return true
+	case "QUIT":
+		c.reply(221, "bye")
+		return false
+	}
+
+	if !c.loggedIn {
+		c.reply(530, "not logged in")
+		return true
+	}
+
+	switch cmd {
+	case "PBSZ":
+		c.reply(200, "PBSZ=0")
+	case "PROT":
+		c.protected = strings.EqualFold(arg, "P")
+		c.reply(200, "protection level set")
+	case "TYPE", "NOOP":
+		c.reply(200, "ok")
+	case "EPSV", "PASV":
+		c.openPassive(cmd)
+	case "PORT":
+		parts := strings.Split(arg, ",")
+		if len(parts) != 6 {
+			c.reply(501, "bad PORT")
+			return true
+		}
+		high, _ := strconv.Atoi(parts[4])
+		low, _ := strconv.Atoi(parts[5])
+		c.activeAddr = net.JoinHostPort(strings.Join(parts[:4], "."), strconv.Itoa(high<<8|low))
+		c.reply(200, "PORT ok")
+	case "EPRT":
+		parts := strings.Split(arg, "|")
+		if len(parts) != 5 {
+			c.reply(501, "bad EPRT")
+			return true
+		}
+		c.activeAddr = net.JoinHostPort(parts[2], parts[3])
+		c.reply(200, "EPRT ok")
+	case "RETR":
+		f, ok := c.s.GetFile(arg)
+		if !ok {
+			c.reply(550, "file not found")
+			return true
+		}
+		c.transfer(func(conn net.Conn) error {
+			_, err := conn.Write(f.Data)
+			return err
+		})
+	case "STOR", "APPE":
+		target := path.Clean(arg)
+		c.s.mut.Lock()
+		_, dirExists := c.s.dirs[path.Dir(target)]
+		c.s.mut.Unlock()
+		if !dirExists {
+			c.reply(550, "directory not found")
+			return true
+		}
+		c.transfer(func(conn net.Conn) error {
+			data, err := io.ReadAll(conn)
+			if err != nil {
+				return err
+			}
+			c.s.mut.Lock()
+			if cmd == "APPE" {
+				data = append(append([]byte{}, c.s.files[target].Data...), data...)
+			}
+			c.s.files[target] = File{Data: data, ModTime: time.Now()}
+			c.s.mut.Unlock()
+			return nil
+		})
+	case "DELE":
+		target := path.Clean(arg)
+		c.s.mut.Lock()
+		_, ok := c.s.files[target]
+		delete(c.s.files, target)
+		c.s.mut.Unlock()
+		if !ok {
+			c.reply(550, "file not found")
+			return true
+		}
+		c.reply(250, "deleted")
+	case "RNFR":
+		if _, ok := c.s.GetFile(arg); !ok {
+			c.reply(550, "file not found")
+			return true
+		}
+		c.renameFrom = path.Clean(arg)
+		c.reply(350, "ready for RNTO")
+	case "RNTO":
+		target := path.Clean(arg)
+		c.s.mut.Lock()
+		f, ok := c.s.files[c.renameFrom]
+		_, dirExists := c.s.dirs[path.Dir(target)]
+		if ok && dirExists {
+			delete(c.s.files, c.renameFrom)
+			c.s.files[target] = f
+		}
+		c.s.mut.Unlock()
+		if !ok || !dirExists {
+			c.reply(550, "rename failed")
+			return true
+		}
+		c.reply(250, "renamed")
+	case "MKD":
+		target := path.Clean(arg)
+		c.s.mut.Lock()
+		_, exists := c.s.dirs[target]
+		_, parentExists := c.s.dirs[path.Dir(target)]
+		if !exists && parentExists {
+			c.s.dirs[target] = struct{}{}
+		}
+		c.s.mut.Unlock()
+		if exists || !parentExists {
+			c.reply(550, "cannot create directory")
+			return true
+		}
+		c.reply(257, fmt.Sprintf("%q created", target))
+	case "MDTM":
+		f, ok := c.s.GetFile(arg)
+		if !ok {
+			c.reply(550, "file not found")
+			return true
+		}
+		c.reply(213, f.ModTime.UTC().Format("20060102150405"))
+	case "MLSD", "NLST":
+		if cmd == "MLSD" && c.s.DisableMLSD {
+			c.reply(500, "unknown command")
+			return true
+		}
+		lines := c.list(path.Clean(arg), cmd == "MLSD")
+		c.transfer(func(conn net.Conn) error {
+			for _, l := range lines {
+				if _, err := fmt.Fprintf(conn, "%s\r\n", l); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		c.reply(502, "command not implemented")
+	}
+	return true
+}
+
+func (c *session) list(dir string, machine bool) []string {
+	c.s.mut.Lock()
+	defer c.s.mut.Unlock()
+
+	var lines []string
+	if machine {
+		lines = append(lines, "type=cdir; .")
+	}
+	for p := range c.s.dirs {
+		if p != dir && path.Dir(p) == dir {
+			if machine {
+				lines = append(lines, "type=dir; "+path.Base(p))
+			} else {
+				lines = append(lines, path.Base(p))
+			}
+		}
+	}
+	for p, f := range c.s.files {
+		if path.Dir(p) != dir {
+			continue
+		}
+		if machine {
+			lines = append(lines, fmt.Sprintf("type=file;size=%d;modify=%s; %s", len(f.Data), f.ModTime.UTC().Format("20060102150405"), path.Base(p)))
+		} else {
+			lines = append(lines, p)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func (c *session) openPassive(cmd string) {
+	if c.passive != nil {
+		c.passive.Close()
+	}
+	var err error
+	if c.passive, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		c.reply(425, "cannot open passive connection")
+		return
+	}
+	port := c.passive.Addr().(*net.TCPAddr).Port
+	if cmd == "EPSV" {
+		c.reply(229, fmt.Sprintf("Entering Extended Passive Mode (|||%d|)", port))
+		return
+	}
+	c.reply(227, fmt.Sprintf("Entering Passive Mode (127,0,0,1,%d,%d)", port>>8, port&0xff))
+}
+
+func (c *session) dataConn() (net.Conn, error) {
+	var conn net.Conn
+	var err error
+	switch {
+	case c.passive != nil:
+		conn, err = c.passive.Accept()
+		c.passive.Close()
+		c.passive = nil
+	case c.activeAddr != "":
+		conn, err = net.DialTimeout("tcp", c.activeAddr, time.Second*5)
+		c.activeAddr = ""
+	default:
+		return nil, fmt.Errorf("no data connection negotiated")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.protected {
+		c.s.mut.Lock()
+		tlsConf := c.s.tlsConf
+		c.s.mut.Unlock()
+		conn = tls.Server(conn, tlsConf)
+	}
+	return conn, nil
+}
+
+func (c *session) transfer(fn func(conn net.Conn) error) {
+	c.reply(150, "opening data connection")
+	conn, err := c.dataConn()
+	if err != nil {
+		c.reply(425, err.Error())
+		return
+	}
+	err = fn(conn)
+	if closeErr := conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		c.reply(426, err.Error())
+		return
+	}
+	c.reply(226, "transfer complete")
+}
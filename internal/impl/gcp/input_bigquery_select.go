@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 
 	"cloud.google.com/go/bigquery"
 	"google.golang.org/api/iterator"
@@ -18,6 +20,7 @@ type bigQuerySelectInputConfig struct {
 	queryParts  *bqQueryParts
 	argsMapping *bloblang.Executor
 	jobLabels   map[string]string
+	proxyFn     func(*http.Request) (*url.URL, error)
 }
 
 func bigQuerySelectInputConfigFromParsed(inConf *service.ParsedConfig) (conf bigQuerySelectInputConfig, err error) {
@@ -38,6 +41,10 @@ func bigQuerySelectInputConfigFromParsed(inConf *service.ParsedConfig) (conf big
 		return
 	}
 
+	if conf.proxyFn, err = inConf.FieldHTTPProxy("proxy"); err != nil {
+		return
+	}
+
 	if queryParts.table, err = inConf.FieldString("table"); err != nil {
 		return
 	}
@@ -96,6 +103,7 @@ func newBigQuerySelectInputConfig() *service.ConfigSpec {
 		Field(service.NewStringField("suffix").
 			Description("An optional suffix to append to the select query.").
 			Optional()).
+		Field(service.NewHTTPProxyField("proxy")).
 		Example("Word counts",
 			`
 Here we query the public corpus of Shakespeare's works to generate a stream of the top 10 words that are 3 or more characters long:`,
@@ -149,7 +157,11 @@ func (inp *bigQuerySelectInput) Connect(ctx context.Context) error {
 	jobctx, _ := inp.shutdownSig.CloseAtLeisureCtx(context.Background())
 
 	if inp.client == nil {
-		client, err := bigquery.NewClient(jobctx, inp.config.project)
+		clientOpts, err := proxyClientOptions(inp.config.proxyFn)
+		if err != nil {
+			return fmt.Errorf("failed to create bigquery client: %w", err)
+		}
+		client, err := bigquery.NewClient(jobctx, inp.config.project, clientOpts...)
 		if err != nil {
 			return fmt.Errorf("failed to create bigquery client: %w", err)
 		}
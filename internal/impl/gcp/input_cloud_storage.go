@@ -1,6 +1,7 @@
 package gcp
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -19,15 +20,17 @@ import (
 	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/inventory"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/old/input"
 	"github.com/benthosdev/benthos/v4/internal/old/input/reader"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
 )
 
 func init() {
 	bundle.AllInputs.Add(bundle.InputConstructorFromSimple(func(c input.Config, nm bundle.NewManagement) (iinput.Streamed, error) {
-		r, err := newGCPCloudStorageInput(c.GCPCloudStorage, nm.Logger(), nm.Metrics())
+		r, err := newGCPCloudStorageInput(c.GCPCloudStorage, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -45,6 +48,12 @@ func init() {
 		Summary: `
 Downloads objects within a Google Cloud Storage bucket, optionally filtered by a prefix.`,
 		Description: `
+## Consuming Objects from an Inventory
+
+Walking a bucket containing millions of objects is slow as the objects can only be listed a page at a time. When a [Storage Insights inventory report](https://cloud.google.com/storage/docs/insights/inventory-reports) of the bucket is available the field ` + "`inventory.manifest`" + ` can instead be set to the name of its manifest object, in which case the objects listed by the report shards are consumed, and are downloaded ahead of time in parallel with up to ` + "`inventory.concurrency`" + ` objects held in memory at once. The manifest and its shards are downloaded from the bucket ` + "`inventory.bucket`" + `, or ` + "`bucket`" + ` when not set, and only reports in the CSV format with a header row are supported. The field ` + "`prefix`" + ` can be used in order to filter the objects of the report.
+
+When ` + "`inventory.checkpoint_cache`" + ` is set the position of the input within the report is stored in the cache, keyed by the location of the manifest, as objects are acknowledged. When the input is restarted it resumes from the last object where it and all prior objects were acknowledged.
+
 ## Downloading Large Files
 
 When downloading large files it's often necessary to process it in streamed parts in order to avoid loading the entire file in memory at a given time. In order to do this a ` + "[`codec`](#codec)" + ` can be specified that determines how to break the input into smaller individual messages.
@@ -74,6 +83,8 @@ services. You can find out more [in this document](/docs/guides/cloud/gcp).`,
 			docs.FieldString("prefix", "An optional path prefix, if set only objects with the prefix are consumed."),
 			codec.ReaderDocs,
 			docs.FieldBool("delete_objects", "Whether to delete downloaded objects from the bucket once they are processed.").Advanced(),
+			proxy.FieldSpec(),
+			inventory.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(input.NewGCPCloudStorageConfig()),
 	})
 }
@@ -83,7 +94,13 @@ const (
 )
 
 type gcpCloudStorageObjectTarget struct {
-	key   string
+	key    string
+	bucket string
+
+	// An optional object that has already been downloaded.
+	attrs *storage.ObjectAttrs
+	data  []byte
+
 	ackFn func(context.Context, error) error
 }
 
@@ -96,6 +113,11 @@ func newGCPCloudStorageObjectTarget(key string, ackFn codec.ReaderAckFn) *gcpClo
 	return &gcpCloudStorageObjectTarget{key: key, ackFn: ackFn}
 }
 
+type gcpCloudStorageObjectTargetReader interface {
+	Pop(ctx context.Context) (*gcpCloudStorageObjectTarget, error)
+	Close(ctx context.Context) error
+}
+
 //------------------------------------------------------------------------------
 
 func deleteGCPCloudStorageObjectAckFn(
@@ -201,30 +223,41 @@ type gcpCloudStorageInput struct {
 	conf input.GCPCloudStorageConfig
 
 	objectScannerCtor codec.ReaderConstructor
-	keyReader         *gcpCloudStorageTargetReader
+	keyReader         gcpCloudStorageObjectTargetReader
 
 	objectMut sync.Mutex
 	object    *gcpCloudStoragePendingObject
 
 	client *storage.Client
 
+	mgr   inventory.CacheAccessor
 	log   log.Modular
 	stats metrics.Type
 }
 
 // newGCPCloudStorageInput creates a new Google Cloud Storage input type.
-func newGCPCloudStorageInput(conf input.GCPCloudStorageConfig, log log.Modular, stats metrics.Type) (*gcpCloudStorageInput, error) {
+func newGCPCloudStorageInput(conf input.GCPCloudStorageConfig, mgr bundle.NewManagement) (*gcpCloudStorageInput, error) {
 	var objectScannerCtor codec.ReaderConstructor
 	var err error
 	if objectScannerCtor, err = codec.GetReader(conf.Codec, codec.NewReaderConfig()); err != nil {
 		return nil, fmt.Errorf("invalid google cloud storage codec: %v", err)
 	}
 
+	if conf.Inventory.Enabled() {
+		if conf.Inventory.ManifestBucket(conf.Bucket) == "" {
+			return nil, errors.New("either a bucket or an inventory.bucket must be specified in order to download the inventory manifest")
+		}
+		if c := conf.Inventory.CheckpointCache; c != "" && !mgr.ProbeCache(c) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", c)
+		}
+	}
+
 	g := &gcpCloudStorageInput{
 		conf:              conf,
 		objectScannerCtor: objectScannerCtor,
-		log:               log,
-		stats:             stats,
+		mgr:               mgr,
+		log:               mgr.Logger(),
+		stats:             mgr.Metrics(),
 	}
 
 	return g, nil
@@ -233,13 +266,27 @@ func newGCPCloudStorageInput(conf input.GCPCloudStorageConfig, log log.Modular,
 // ConnectWithContext attempts to establish a connection to the target Google
 // Cloud Storage bucket.
 func (g *gcpCloudStorageInput) ConnectWithContext(ctx context.Context) error {
-	var err error
-	g.client, err = storage.NewClient(context.Background())
+	proxyFn, err := g.conf.Proxy.Func()
+	if err != nil {
+		return err
+	}
+	clientOpts, err := proxyClientOptions(proxyFn)
+	if err != nil {
+		return err
+	}
+	g.client, err = storage.NewClient(context.Background(), clientOpts...)
 	if err != nil {
 		return err
 	}
 
-	g.keyReader, err = newGCPCloudStorageTargetReader(ctx, g.conf, g.log, g.client.Bucket(g.conf.Bucket))
+	if g.conf.Inventory.Enabled() {
+		g.keyReader, err = newGCPCloudStorageInventoryReader(ctx, g.conf, g.log, g.client, g.mgr)
+	} else {
+		g.keyReader, err = newGCPCloudStorageTargetReader(ctx, g.conf, g.log, g.client.Bucket(g.conf.Bucket))
+	}
+	if err != nil {
+		g.keyReader = nil
+	}
 	return err
 }
 
@@ -248,23 +295,35 @@ func (g *gcpCloudStorageInput) getObjectTarget(ctx context.Context) (*gcpCloudSt
 		return g.object, nil
 	}
 
+	if g.keyReader == nil {
+		return nil, component.ErrTypeClosed
+	}
+
 	target, err := g.keyReader.Pop(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	objReference := g.client.Bucket(g.conf.Bucket).Object(target.key)
+	objAttributes := target.attrs
+	var objReader io.ReadCloser
+	if objAttributes != nil {
+		objReader = io.NopCloser(bytes.NewReader(target.data))
+	} else {
+		bucket := target.bucket
+		if bucket == "" {
+			bucket = g.conf.Bucket
+		}
+		objReference := g.client.Bucket(bucket).Object(target.key)
 
-	objAttributes, err := objReference.Attrs(ctx)
-	if err != nil {
-		_ = target.ackFn(ctx, err)
-		return nil, err
-	}
+		if objAttributes, err = objReference.Attrs(ctx); err != nil {
+			_ = target.ackFn(ctx, err)
+			return nil, err
+		}
 
-	objReader, err := objReference.NewReader(context.Background())
-	if err != nil {
-		_ = target.ackFn(ctx, err)
-		return nil, err
+		if objReader, err = objReference.NewReader(context.Background()); err != nil {
+			_ = target.ackFn(ctx, err)
+			return nil, err
+		}
 	}
 
 	object := &gcpCloudStoragePendingObject{
@@ -358,6 +417,13 @@ func (g *gcpCloudStorageInput) CloseAsync() {
 			g.object = nil
 		}
 
+		if g.keyReader != nil {
+			if err := g.keyReader.Close(context.Background()); err != nil {
+				g.log.Warnf("Failed to close object target reader cleanly: %v\n", err)
+			}
+			g.keyReader = nil
+		}
+
 		if g.client != nil {
 			g.client.Close()
 			g.client = nil
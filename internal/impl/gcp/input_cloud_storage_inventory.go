@@ -0,0 +1,159 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/benthosdev/benthos/v4/internal/inventory"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/old/input"
+)
+
+type gcpCloudStorageFetchedObject struct {
+	attrs *storage.ObjectAttrs
+	data  []byte
+}
+
+// gcpCloudStorageInventoryReader walks the objects listed within a Cloud
+// Storage inventory report and downloads them in parallel ahead of them being
+// consumed.
+type gcpCloudStorageInventoryReader struct {
+	conf   input.GCPCloudStorageConfig
+	log    log.Modular
+	client *storage.Client
+
+	fetcher  *inventory.Fetcher
+	progress *inventory.Progress
+
+	retryMut sync.Mutex
+	retries  []*gcpCloudStorageObjectTarget
+}
+
+func newGCPCloudStorageInventoryReader(
+	ctx context.Context,
+	conf input.GCPCloudStorageConfig,
+	log log.Modular,
+	client *storage.Client,
+	mgr inventory.CacheAccessor,
+) (*gcpCloudStorageInventoryReader, error) {
+	manifestBucket := client.Bucket(conf.Inventory.ManifestBucket(conf.Bucket))
+
+	manifestReader, err := manifestBucket.Object(conf.Inventory.Manifest).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download inventory manifest: %w", err)
+	}
+	manifestBytes, err := io.ReadAll(manifestReader)
+	manifestReader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download inventory manifest: %w", err)
+	}
+
+	manifest, err := inventory.ParseGCSManifest(conf.Inventory.Manifest, manifestBytes)
+	if err != nil {
+		return nil, err
+	}
+	manifest.DefaultBucket = conf.Bucket
+
+	progress := inventory.NewProgress(mgr, conf.Inventory.CheckpointCache, "gs://"+conf.Inventory.ManifestBucket(conf.Bucket)+"/"+conf.Inventory.Manifest)
+	after, err := progress.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if after >= 0 {
+		log.Infof("Resuming inventory %v after object %v\n", conf.Inventory.Manifest, after)
+	}
+
+	lister := inventory.NewLister(manifest, func(ctx context.Context, key string) (io.ReadCloser, error) {
+		return manifestBucket.Object(key).NewReader(ctx)
+	}, conf.Prefix, after)
+
+	fetcher := inventory.NewFetcher(lister, conf.Inventory.Concurrency, func(ctx context.Context, obj inventory.Object) (interface{}, error) {
+		objReference := client.Bucket(obj.Bucket).Object(obj.Key)
+
+		attrs, err := objReference.Attrs(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		objReader, err := objReference.NewReader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(objReader)
+		objReader.Close()
+		if err != nil {
+			return nil, err
+		}
+		return gcpCloudStorageFetchedObject{attrs: attrs, data: data}, nil
+	})
+
+	return &gcpCloudStorageInventoryReader{
+		conf:     conf,
+		log:      log,
+		client:   client,
+		fetcher:  fetcher,
+		progress: progress,
+	}, nil
+}
+
+func (r *gcpCloudStorageInventoryReader) Pop(ctx context.Context) (*gcpCloudStorageObjectTarget, error) {
+	r.retryMut.Lock()
+	if len(r.retries) > 0 {
+		t := r.retries[0]
+		r.retries = r.retries[1:]
+		r.retryMut.Unlock()
+		return t, nil
+	}
+	r.retryMut.Unlock()
+
+	res, err := r.fetcher.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if res.Err != nil && res.Object.Key == "" {
+		return nil, res.Err
+	}
+
+	commitFn := r.progress.Track(res.Object)
+
+	target := newGCPCloudStorageObjectTarget(res.Object.Key, nil)
+	target.bucket = res.Object.Bucket
+	target.ackFn = deleteGCPCloudStorageObjectAckFn(
+		r.client.Bucket(target.bucket), target.key, r.conf.DeleteObjects,
+		func(ctx context.Context, err error) error {
+			if err == nil {
+				return commitFn(ctx)
+			}
+			if errors.Is(err, storage.ErrObjectNotExist) {
+				// Objects that no longer exist since the inventory was taken
+				// are skipped.
+				r.log.Warnf("Skipping inventory object %v that no longer exists\n", target.key)
+				return commitFn(ctx)
+			}
+			// The object failed to be downloaded, and so it is queued for
+			// another attempt.
+			target.attrs, target.data = nil, nil
+			r.retryMut.Lock()
+			r.retries = append(r.retries, target)
+			r.retryMut.Unlock()
+			return nil
+		},
+	)
+
+	if res.Err == nil {
+		fetched := res.Data.(gcpCloudStorageFetchedObject)
+		target.attrs, target.data = fetched.attrs, fetched.data
+	} else if !errors.Is(res.Err, storage.ErrObjectNotExist) {
+		r.log.Debugf("Failed to download inventory object %v, attempting again: %v\n", target.key, res.Err)
+	}
+	return target, nil
+}
+
+func (r *gcpCloudStorageInventoryReader) Close(context.Context) error {
+	return r.fetcher.Close()
+}
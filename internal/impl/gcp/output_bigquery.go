@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 
@@ -58,6 +59,7 @@ type gcpBigQueryOutputConfig struct {
 	AutoDetect          bool
 	IgnoreUnknownValues bool
 	MaxBadRecords       int
+	ProxyFn             func(*http.Request) (*url.URL, error)
 
 	// CSV options
 	CSVOptions gcpBigQueryCSVConfig
@@ -94,6 +96,9 @@ func gcpBigQueryOutputConfigFromParsed(conf *service.ParsedConfig) (gconf gcpBig
 	if gconf.AutoDetect, err = conf.FieldBool("auto_detect"); err != nil {
 		return
 	}
+	if gconf.ProxyFn, err = conf.FieldHTTPProxy("proxy"); err != nil {
+		return
+	}
 	if gconf.CSVOptions, err = gcpBigQueryCSVConfigFromParsed(conf.Namespace("csv")); err != nil {
 		return
 	}
@@ -102,9 +107,13 @@ func gcpBigQueryOutputConfigFromParsed(conf *service.ParsedConfig) (gconf gcpBig
 
 type gcpBQClientURL string
 
-func (g gcpBQClientURL) NewClient(ctx context.Context, projectID string) (*bigquery.Client, error) {
+func (g gcpBQClientURL) NewClient(ctx context.Context, projectID string, proxyFn func(*http.Request) (*url.URL, error)) (*bigquery.Client, error) {
 	if g == "" {
-		return bigquery.NewClient(ctx, projectID)
+		clientOpts, err := proxyClientOptions(proxyFn)
+		if err != nil {
+			return nil, err
+		}
+		return bigquery.NewClient(ctx, projectID, clientOpts...)
 	}
 	return bigquery.NewClient(ctx, projectID, option.WithoutAuthentication(), option.WithEndpoint(string(g)))
 }
@@ -204,6 +213,7 @@ For the CSV format when the field `+"`csv.header`"+` is specified a header row w
 				Advanced().
 				Default(1),
 		).Description("Specify how CSV data should be interpretted.")).
+		Field(service.NewHTTPProxyField("proxy")).
 		Field(service.NewBatchPolicyField("batching"))
 }
 
@@ -298,7 +308,7 @@ func (g *gcpBigQueryOutput) Connect(ctx context.Context) (err error) {
 	defer g.connMut.Unlock()
 
 	var client *bigquery.Client
-	if client, err = g.clientURL.NewClient(context.Background(), g.conf.ProjectID); err != nil {
+	if client, err = g.clientURL.NewClient(context.Background(), g.conf.ProjectID, g.conf.ProxyFn); err != nil {
 		err = fmt.Errorf("error creating big query client: %w", err)
 		return
 	}
@@ -0,0 +1,413 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func gcpBigtableOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("GCP", "Services").
+		Summary("Writes messages as rows of a [GCP Bigtable](https://cloud.google.com/bigtable) table.").
+		Description(`
+Each message is written to the row identified by the `+"`row_key`"+`, and the cells written to the row are determined by the `+"`mapping`"+`, which must result in an object of column families, where each family is an object of column qualifiers and their values:
+
+`+"```json"+`
+{
+  "profile": { "name": "foo", "age": 21 },
+  "stats": { "visits": 5 }
+}
+`+"```"+`
+
+String values are written as they are, and all other values are written as JSON. A column with a `+"`null`"+` value has all of its existing cells deleted instead. The column families must already exist within the table.
+
+The rows of a batch are written with a single bulk request and it is therefore recommended to configure a [batching policy](/docs/configuration/batching). If any of the rows of a batch fail to be written the entire batch is rejected and the input decides whether to redeliver it.
+
+### Cell Versions
+
+Bigtable keeps multiple versions of each cell, identified by their timestamp. Cells are given the time at which they are received by Bigtable as their timestamp unless the field `+"`timestamp`"+` is set, and writing a cell with the same timestamp as an existing version replaces that version, which makes redelivered messages idempotent when the timestamp is derived from the message. Setting `+"`replace_cells`"+` to `+"`true`"+` deletes all existing versions of each column written, so that only the latest value is kept.
+
+The versions retained by a column family are otherwise governed by its garbage collection policy, which can be set when the output connects with the field `+"`gc_policy`"+`. Garbage collection removes cells with a version number or age beyond the policy asynchronously, which is the means by which Bigtable expires cells after a TTL. Setting a garbage collection policy requires the permission `+"`bigtable.tables.update`"+`.`).
+		Field(service.NewStringField("project").
+			Description("The project ID of the Bigtable instance.")).
+		Field(service.NewStringField("instance").
+			Description("The ID of the Bigtable instance.")).
+		Field(service.NewStringField("table").
+			Description("The table to write rows to.")).
+		Field(service.NewInterpolatedStringField("row_key").
+			Description("The key of the row to write each message to.").
+			Example(`${! json("user.id") }`).
+			Example(`${! json("device") }#${! json("reported_at") }`)).
+		Field(service.NewBloblangField("mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of column families, where each family is an object of column qualifiers and their values.").
+			Example(`root.profile.name = this.name
+root.profile.age = this.age
+root.raw.doc = content().string()`).
+			Example(`root.data = this`)).
+		Field(service.NewInterpolatedStringField("timestamp").
+			Description("An optional timestamp of the cells written for each message, either as an RFC 3339 string or a number of seconds since the unix epoch. Timestamps are truncated to milliseconds.").
+			Example(`${! json("updated_at") }`).
+			Optional()).
+		Field(service.NewBoolField("replace_cells").
+			Description("Whether to delete all existing versions of each column written before writing the new cell.").
+			Default(false).
+			Advanced()).
+		Field(service.NewObjectField("gc_policy",
+			service.NewStringListField("families").
+				Description("A list of column families to set the garbage collection policy of when the output connects. When empty the policies of the table are left unchanged.").
+				Default([]interface{}{}),
+			service.NewIntField("max_versions").
+				Description("The maximum number of versions of each cell to retain, where zero means unlimited.").
+				Default(0),
+			service.NewStringField("max_age").
+				Description("The maximum age of cells to retain, where an empty string means unlimited.").
+				Example("72h").
+				Default(""),
+		).
+			Description("A garbage collection policy to set on column families of the table, which determines the versions of cells that are retained. When both `max_versions` and `max_age` are set cells that exceed either are removed.").
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("User Profiles", `
+Here we write the profile of each user to a row keyed by their ID, keeping only the latest value of each column and expiring cells of the `+"`events`"+` family after a week:`,
+			`
+output:
+  gcp_bigtable:
+    project: foo
+    instance: bar
+    table: users
+    row_key: ${! json("id") }
+    mapping: |
+      root.profile.name = this.name
+      root.profile.email = this.email
+      root.events.last_seen = this.seen_at
+    timestamp: ${! json("updated_at") }
+    replace_cells: true
+    gc_policy:
+      families: [ events ]
+      max_age: 168h
+    batching:
+      count: 100
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("gcp_bigtable", gcpBigtableOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newGCPBigtableOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type bigtableCell struct {
+	family string
+	column string
+	value  []byte
+	delete bool
+}
+
+type bigtableRow struct {
+	key   string
+	cells []bigtableCell
+}
+
+// mutation returns a mutation that writes the cells of the row with the
+// provided timestamp.
+func (r bigtableRow) mutation(ts bigtable.Timestamp, replace bool) *bigtable.Mutation {
+	mut := bigtable.NewMutation()
+	for _, c := range r.cells {
+		if c.delete || replace {
+			mut.DeleteCellsInColumn(c.family, c.column)
+		}
+		if !c.delete {
+			mut.Set(c.family, c.column, ts, c.value)
+		}
+	}
+	return mut
+}
+
+type bigtableApplier interface {
+	ApplyBulk(ctx context.Context, rowKeys []string, muts []*bigtable.Mutation, opts ...bigtable.ApplyOption) ([]error, error)
+}
+
+type gcpBigtableOutput struct {
+	log *service.Logger
+
+	project   string
+	instance  string
+	table     string
+	rowKey    *service.InterpolatedString
+	mapping   *bloblang.Executor
+	timestamp *service.InterpolatedString
+	replace   bool
+
+	gcFamilies []string
+	gcPolicy   bigtable.GCPolicy
+
+	connMut sync.RWMutex
+	client  *bigtable.Client
+	applier bigtableApplier
+}
+
+func newGCPBigtableOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*gcpBigtableOutput, error) {
+	g := &gcpBigtableOutput{
+		log: log,
+	}
+
+	var err error
+	if g.project, err = conf.FieldString("project"); err != nil {
+		return nil, err
+	}
+	if g.instance, err = conf.FieldString("instance"); err != nil {
+		return nil, err
+	}
+	if g.table, err = conf.FieldString("table"); err != nil {
+		return nil, err
+	}
+	if g.rowKey, err = conf.FieldInterpolatedString("row_key"); err != nil {
+		return nil, err
+	}
+	if g.mapping, err = conf.FieldBloblang("mapping"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("timestamp") {
+		if g.timestamp, err = conf.FieldInterpolatedString("timestamp"); err != nil {
+			return nil, err
+		}
+	}
+	if g.replace, err = conf.FieldBool("replace_cells"); err != nil {
+		return nil, err
+	}
+
+	gcConf := conf.Namespace("gc_policy")
+	if g.gcFamilies, err = gcConf.FieldStringList("families"); err != nil {
+		return nil, err
+	}
+	maxVersions, err := gcConf.FieldInt("max_versions")
+	if err != nil {
+		return nil, err
+	}
+	maxAgeStr, err := gcConf.FieldString("max_age")
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []bigtable.GCPolicy
+	if maxVersions < 0 {
+		return nil, errors.New("gc_policy.max_versions must not be negative")
+	}
+	if maxVersions > 0 {
+		policies = append(policies, bigtable.MaxVersionsPolicy(maxVersions))
+	}
+	if maxAgeStr != "" {
+		maxAge, err := time.ParseDuration(maxAgeStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse gc_policy.max_age: %w", err)
+		}
+		policies = append(policies, bigtable.MaxAgePolicy(maxAge))
+	}
+	switch len(policies) {
+	case 0:
+		if len(g.gcFamilies) > 0 {
+			return nil, errors.New("gc_policy.families requires either gc_policy.max_versions or gc_policy.max_age to be set")
+		}
+	case 1:
+		g.gcPolicy = policies[0]
+	default:
+		g.gcPolicy = bigtable.UnionPolicy(policies...)
+	}
+	return g, nil
+}
+
+func (g *gcpBigtableOutput) Connect(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if len(g.gcFamilies) > 0 {
+		admin, err := bigtable.NewAdminClient(ctx, g.project, g.instance)
+		if err != nil {
+			return fmt.Errorf("error creating bigtable admin client: %w", err)
+		}
+		for _, family := range g.gcFamilies {
+			if err = admin.SetGCPolicy(ctx, g.table, family, g.gcPolicy); err != nil {
+				err = fmt.Errorf("failed to set garbage collection policy of column family %v: %w", family, err)
+				break
+			}
+		}
+		admin.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := bigtable.NewClient(context.Background(), g.project, g.instance)
+	if err != nil {
+		return fmt.Errorf("error creating bigtable client: %w", err)
+	}
+
+	g.client = client
+	g.applier = client.Open(g.table)
+	g.log.Infof("Writing messages as rows to GCP Bigtable: %v:%v:%v\n", g.project, g.instance, g.table)
+	return nil
+}
+
+func parseBigtableTimestamp(s string) (time.Time, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return query.IGetTimestamp(f)
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func (g *gcpBigtableOutput) messageRow(batch service.MessageBatch, i int) (bigtableRow, error) {
+	row := bigtableRow{key: batch.InterpolatedString(i, g.rowKey)}
+	if row.key == "" {
+		return row, errors.New("row key is empty")
+	}
+
+	msg, err := batch.BloblangQuery(i, g.mapping)
+	if err != nil {
+		return row, fmt.Errorf("mapping failed: %w", err)
+	}
+	if msg == nil {
+		return row, errors.New("mapping deleted the message")
+	}
+	v, err := msg.AsStructured()
+	if err != nil {
+		return row, fmt.Errorf("mapping: %w", err)
+	}
+	families, ok := v.(map[string]interface{})
+	if !ok {
+		return row, fmt.Errorf("expected mapping to result in an object, got %T", v)
+	}
+
+	for family, columnsV := range families {
+		columns, ok := columnsV.(map[string]interface{})
+		if !ok {
+			return row, fmt.Errorf("expected column family %v to be an object, got %T", family, columnsV)
+		}
+		for column, value := range columns {
+			cell := bigtableCell{family: family, column: column}
+			switch t := value.(type) {
+			case nil:
+				cell.delete = true
+			case string:
+				cell.value = []byte(t)
+			case []byte:
+				cell.value = t
+			default:
+				if cell.value, err = json.Marshal(t); err != nil {
+					return row, fmt.Errorf("column %v:%v: %w", family, column, err)
+				}
+			}
+			row.cells = append(row.cells, cell)
+		}
+	}
+	if len(row.cells) == 0 {
+		return row, errors.New("mapping resulted in no cells")
+	}
+
+	// Sorted for the sake of deterministic mutations.
+	sort.Slice(row.cells, func(i, j int) bool {
+		if row.cells[i].family != row.cells[j].family {
+			return row.cells[i].family < row.cells[j].family
+		}
+		return row.cells[i].column < row.cells[j].column
+	})
+	return row, nil
+}
+
+func (g *gcpBigtableOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	g.connMut.RLock()
+	applier := g.applier
+	g.connMut.RUnlock()
+	if applier == nil {
+		return service.ErrNotConnected
+	}
+
+	rowKeys := make([]string, 0, len(batch))
+	muts := make([]*bigtable.Mutation, 0, len(batch))
+	for i := range batch {
+		row, err := g.messageRow(batch, i)
+		if err != nil {
+			return fmt.Errorf("message %v: %w", i, err)
+		}
+
+		ts := bigtable.ServerTime
+		if g.timestamp != nil {
+			t, err := parseBigtableTimestamp(batch.InterpolatedString(i, g.timestamp))
+			if err != nil {
+				return fmt.Errorf("message %v: timestamp: %w", i, err)
+			}
+			ts = bigtable.Time(t)
+		}
+
+		rowKeys = append(rowKeys, row.key)
+		muts = append(muts, row.mutation(ts, g.replace))
+	}
+
+	errs, err := applier.ApplyBulk(ctx, rowKeys, muts)
+	if err != nil {
+		return fmt.Errorf("failed to apply mutations: %w", err)
+	}
+
+	var firstErr error
+	failed := 0
+	for i, rowErr := range errs {
+		if rowErr == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("row %v: %w", rowKeys[i], rowErr)
+		}
+		failed++
+	}
+	if firstErr != nil {
+		return fmt.Errorf("failed to write %v of %v rows, first error: %w", failed, len(rowKeys), firstErr)
+	}
+	return nil
+}
+
+func (g *gcpBigtableOutput) Close(ctx context.Context) error {
+	g.connMut.Lock()
+	if g.client != nil {
+		g.client.Close()
+		g.client = nil
+	}
+	g.applier = nil
+	g.connMut.Unlock()
+	return nil
+}
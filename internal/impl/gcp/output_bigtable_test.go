@@ -0,0 +1,154 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/bigtable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testBigtableOutput(t *testing.T, confStr string) *gcpBigtableOutput {
+	t.Helper()
+
+	conf, err := gcpBigtableOutputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	out, err := newGCPBigtableOutputFromConfig(conf, nil)
+	require.NoError(t, err)
+	return out
+}
+
+type mockBigtableApplier struct {
+	rowKeys []string
+	muts    []*bigtable.Mutation
+	errs    []error
+}
+
+func (m *mockBigtableApplier) ApplyBulk(ctx context.Context, rowKeys []string, muts []*bigtable.Mutation, opts ...bigtable.ApplyOption) ([]error, error) {
+	m.rowKeys = append(m.rowKeys, rowKeys...)
+	m.muts = append(m.muts, muts...)
+	return m.errs, nil
+}
+
+func TestBigtableOutputRows(t *testing.T) {
+	out := testBigtableOutput(t, `
+project: foo
+instance: bar
+table: baz
+row_key: ${! json("id") }
+mapping: |
+  root.profile.name = this.name
+  root.profile.age = this.age
+  root.profile.email = this.email
+  root.raw.doc = content()
+`)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","name":"foo","age":21,"email":null}`)),
+	}
+
+	row, err := out.messageRow(batch, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, bigtableRow{
+		key: "a",
+		cells: []bigtableCell{
+			{family: "profile", column: "age", value: []byte(`21`)},
+			{family: "profile", column: "email", delete: true},
+			{family: "profile", column: "name", value: []byte(`foo`)},
+			{family: "raw", column: "doc", value: []byte(`{"id":"a","name":"foo","age":21,"email":null}`)},
+		},
+	}, row)
+}
+
+func TestBigtableOutputInvalidRows(t *testing.T) {
+	out := testBigtableOutput(t, `
+project: foo
+instance: bar
+table: baz
+row_key: ${! json("id").or("") }
+mapping: root = this.cells
+`)
+
+	for _, test := range []struct {
+		input string
+		err   string
+	}{
+		{input: `{"cells":{"a":{"b":"c"}}}`, err: "row key is empty"},
+		{input: `{"id":"a","cells":[]}`, err: "expected mapping to result in an object, got []interface {}"},
+		{input: `{"id":"a","cells":{"a":"nope"}}`, err: "expected column family a to be an object, got string"},
+		{input: `{"id":"a","cells":{}}`, err: "mapping resulted in no cells"},
+	} {
+		_, err := out.messageRow(service.MessageBatch{service.NewMessage([]byte(test.input))}, 0)
+		assert.EqualError(t, err, test.err, test.input)
+	}
+}
+
+func TestBigtableOutputWriteBatch(t *testing.T) {
+	out := testBigtableOutput(t, `
+project: foo
+instance: bar
+table: baz
+row_key: ${! json("id") }
+mapping: root.data = this
+timestamp: ${! json("ts") }
+`)
+
+	ctx := context.Background()
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","ts":1600000000}`)),
+		service.NewMessage([]byte(`{"id":"b","ts":"2020-09-13T12:26:40Z"}`)),
+	}
+
+	assert.Equal(t, service.ErrNotConnected, out.WriteBatch(ctx, batch))
+
+	applier := &mockBigtableApplier{}
+	out.applier = applier
+
+	require.NoError(t, out.WriteBatch(ctx, batch))
+	assert.Equal(t, []string{"a", "b"}, applier.rowKeys)
+	assert.Len(t, applier.muts, 2)
+
+	applier.errs = []error{nil, errors.New("nope")}
+	assert.EqualError(t, out.WriteBatch(ctx, batch), "failed to write 1 of 2 rows, first error: row b: nope")
+
+	err := out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","ts":"not a time"}`)),
+	})
+	assert.Error(t, err)
+}
+
+func TestBigtableOutputGCPolicy(t *testing.T) {
+	out := testBigtableOutput(t, `
+project: foo
+instance: bar
+table: baz
+row_key: ${! json("id") }
+mapping: root.data = this
+gc_policy:
+  families: [ data ]
+  max_versions: 2
+  max_age: 24h
+`)
+	assert.Equal(t, []string{"data"}, out.gcFamilies)
+	assert.Equal(t, "(versions() > 2 || age() > 1d)", out.gcPolicy.String())
+
+	conf, err := gcpBigtableOutputConfig().ParseYAML(`
+project: foo
+instance: bar
+table: baz
+row_key: ${! json("id") }
+mapping: root.data = this
+gc_policy:
+  families: [ data ]
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newGCPBigtableOutputFromConfig(conf, nil)
+	assert.Error(t, err)
+}
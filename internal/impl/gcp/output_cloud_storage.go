@@ -21,6 +21,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/old/output"
 	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
 )
 
 func init() {
@@ -118,7 +119,8 @@ output:
 			docs.FieldString("content_encoding", "An optional content encoding to set for each object.").IsInterpolated().Advanced(),
 			docs.FieldInt("chunk_size", "An optional chunk size which controls the maximum number of bytes of the object that the Writer will attempt to send to the server in a single request. If ChunkSize is set to zero, chunking will be disabled.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
+			proxy.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(output.NewGCPCloudStorageConfig()),
 	})
 }
@@ -174,8 +176,15 @@ func (g *gcpCloudStorageOutput) ConnectWithContext(ctx context.Context) error {
 	g.connMut.Lock()
 	defer g.connMut.Unlock()
 
-	var err error
-	g.client, err = storage.NewClient(context.Background())
+	proxyFn, err := g.conf.Proxy.Func()
+	if err != nil {
+		return err
+	}
+	clientOpts, err := proxyClientOptions(proxyFn)
+	if err != nil {
+		return err
+	}
+	g.client, err = storage.NewClient(context.Background(), clientOpts...)
 	if err != nil {
 		return err
 	}
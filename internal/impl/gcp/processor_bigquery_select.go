@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 
 	"cloud.google.com/go/bigquery"
 	"google.golang.org/api/iterator"
@@ -19,6 +21,7 @@ type bigQuerySelectProcessorConfig struct {
 	queryParts  *bqQueryParts
 	jobLabels   map[string]string
 	argsMapping *bloblang.Executor
+	proxyFn     func(*http.Request) (*url.URL, error)
 }
 
 func bigQuerySelectProcessorConfigFromParsed(inConf *service.ParsedConfig) (conf bigQuerySelectProcessorConfig, err error) {
@@ -39,6 +42,10 @@ func bigQuerySelectProcessorConfigFromParsed(inConf *service.ParsedConfig) (conf
 		return
 	}
 
+	if conf.proxyFn, err = inConf.FieldHTTPProxy("proxy"); err != nil {
+		return
+	}
+
 	if queryParts.table, err = inConf.FieldString("table"); err != nil {
 		return
 	}
@@ -95,6 +102,7 @@ func newBigQuerySelectProcessorConfig() *service.ConfigSpec {
 		Field(service.NewStringField("suffix").
 			Description("An optional suffix to append to the select query.").
 			Optional()).
+		Field(service.NewHTTPProxyField("proxy")).
 		Example("Word count",
 			`
 Given a stream of English terms, enrich the messages with the word count from Shakespeare's public works:`,
@@ -143,9 +151,15 @@ func newBigQuerySelectProcessor(inConf *service.ParsedConfig, options *bigQueryP
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	clientOpts, err := proxyClientOptions(conf.proxyFn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bigquery client: %w", err)
+	}
+	clientOpts = append(clientOpts, options.clientOptions...)
+
 	closeCtx, closeF := context.WithCancel(context.Background())
 
-	wrapped, err := bigquery.NewClient(closeCtx, conf.project, options.clientOptions...)
+	wrapped, err := bigquery.NewClient(closeCtx, conf.project, clientOpts...)
 	if err != nil {
 		closeF()
 		return nil, fmt.Errorf("failed to create bigquery client: %w", err)
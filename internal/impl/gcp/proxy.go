@@ -0,0 +1,35 @@
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+const gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// proxyClientOptions returns client options that route the requests of a GCP
+// client through a proxy, including the requests made in order to obtain and
+// refresh credentials. When proxyFn is nil no options are returned and the
+// proxy is selected according to the environment.
+func proxyClientOptions(proxyFn func(*http.Request) (*url.URL, error)) ([]option.ClientOption, error) {
+	if proxyFn == nil {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFn
+
+	// The credentials outlive any single request and are therefore obtained
+	// with a background context that carries the base client to use.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport})
+	client, err := google.DefaultClient(ctx, gcpCloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	return []option.ClientOption{option.WithHTTPClient(client)}, nil
+}
@@ -36,7 +36,7 @@ func memoryBufferConfig() *service.ConfigSpec {
 		Description(`
 This buffer is appropriate when consuming messages from inputs that do not gracefully handle back pressure and where delivery guarantees aren't critical.
 
-This buffer has a configurable limit, where consumption will be stopped with back pressure upstream if the total size of messages in the buffer reaches this amount. Since this calculation is only an estimate, and the real size of messages in RAM is always higher, it is recommended to set the limit significantly below the amount of RAM available.
+This buffer has a configurable limit, where consumption will be stopped with back pressure upstream if the total size of messages in the buffer reaches this amount. Since this calculation is only an estimate, and the real size of messages in RAM is always higher, it is recommended to set the limit significantly below the amount of RAM available. The percentage of the limit currently occupied is exported as the gauge metric ` + "`buffer_fill_percentage`" + `.
 
 ## Delivery Guarantees
 
@@ -265,6 +265,17 @@ func (m *memoryBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageB
 	return nil
 }
 
+// FillPercentage returns the percentage of the byte limit of the buffer that
+// is currently occupied by messages that are yet to be acknowledged.
+func (m *memoryBuffer) FillPercentage() int64 {
+	m.cond.L.Lock()
+	defer m.cond.L.Unlock()
+	if m.cap <= 0 {
+		return 0
+	}
+	return int64(m.bytes) * 100 / int64(m.cap)
+}
+
 func (m *memoryBuffer) EndOfInput() {
 	go func() {
 		m.cond.L.Lock()
@@ -0,0 +1,178 @@
+package generic
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Capture files begin with a magic header followed by a sequence of records.
+// Each record consists of uvarint encoded fields: the number of nanoseconds
+// since the previous record (or since the unix epoch for the first record),
+// the number of metadata pairs, each metadata key and value prefixed with its
+// length, and finally the message contents prefixed with its length.
+const captureMagic = "BCAP\x01"
+
+// captureMaxFieldLen limits the length of a field read from a capture file in
+// order to protect against allocating huge buffers for corrupt files.
+const captureMaxFieldLen = 1 << 30
+
+// captureRecord is a message recorded within a capture file.
+type captureRecord struct {
+	timestamp time.Time
+	metadata  map[string]string
+	content   []byte
+}
+
+type captureEncoder struct {
+	w      *bufio.Writer
+	lastTS int64
+	buf    [binary.MaxVarintLen64]byte
+}
+
+// newCaptureEncoder writes the capture header to w and returns an encoder for
+// writing records that follow it.
+func newCaptureEncoder(w io.Writer) (*captureEncoder, error) {
+	e := &captureEncoder{w: bufio.NewWriter(w)}
+	if _, err := e.w.WriteString(captureMagic); err != nil {
+		return nil, err
+	}
+	return e, e.w.Flush()
+}
+
+func (e *captureEncoder) writeUvarint(v uint64) error {
+	n := binary.PutUvarint(e.buf[:], v)
+	_, err := e.w.Write(e.buf[:n])
+	return err
+}
+
+func (e *captureEncoder) writeBytes(b []byte) error {
+	if err := e.writeUvarint(uint64(len(b))); err != nil {
+		return err
+	}
+	_, err := e.w.Write(b)
+	return err
+}
+
+// Encode writes a record, timestamps that precede the previous record are
+// treated as if they were simultaneous.
+func (e *captureEncoder) Encode(r captureRecord) error {
+	ts := r.timestamp.UnixNano()
+	delta := ts - e.lastTS
+	if delta < 0 {
+		delta = 0
+	} else {
+		e.lastTS = ts
+	}
+	if err := e.writeUvarint(uint64(delta)); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(r.metadata))
+	for k := range r.metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if err := e.writeUvarint(uint64(len(keys))); err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := e.writeBytes([]byte(k)); err != nil {
+			return err
+		}
+		if err := e.writeBytes([]byte(r.metadata[k])); err != nil {
+			return err
+		}
+	}
+	return e.writeBytes(r.content)
+}
+
+// Flush writes any buffered records to the underlying writer.
+func (e *captureEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+//------------------------------------------------------------------------------
+
+type captureDecoder struct {
+	r      *bufio.Reader
+	lastTS int64
+}
+
+// newCaptureDecoder reads and checks the capture header of r and returns a
+// decoder for reading the records that follow it.
+func newCaptureDecoder(r io.Reader) (*captureDecoder, error) {
+	d := &captureDecoder{r: bufio.NewReader(r)}
+
+	header := make([]byte, len(captureMagic))
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %w", err)
+	}
+	if string(header) != captureMagic {
+		return nil, errors.New("file is not a capture file")
+	}
+	return d, nil
+}
+
+func (d *captureDecoder) readBytes() ([]byte, error) {
+	l, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, err
+	}
+	if l > captureMaxFieldLen {
+		return nil, fmt.Errorf("record field length %v is too large", l)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Decode reads the next record, and returns io.EOF when there are no further
+// records. A record that is truncated results in io.ErrUnexpectedEOF.
+func (d *captureDecoder) Decode() (captureRecord, error) {
+	delta, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return captureRecord{}, err
+	}
+
+	r, err := d.decodeBody()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return captureRecord{}, err
+	}
+
+	d.lastTS += int64(delta)
+	r.timestamp = time.Unix(0, d.lastTS)
+	return r, nil
+}
+
+func (d *captureDecoder) decodeBody() (r captureRecord, err error) {
+	var nMeta uint64
+	if nMeta, err = binary.ReadUvarint(d.r); err != nil {
+		return
+	}
+	if nMeta > 0 {
+		r.metadata = map[string]string{}
+	}
+	for i := uint64(0); i < nMeta; i++ {
+		var k, v []byte
+		if k, err = d.readBytes(); err != nil {
+			return
+		}
+		if v, err = d.readBytes(); err != nil {
+			return
+		}
+		r.metadata[string(k)] = string(v)
+	}
+	r.content, err = d.readBytes()
+	return
+}
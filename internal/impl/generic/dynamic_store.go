@@ -0,0 +1,147 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/interop"
+)
+
+func dynamicPersistenceFieldSpec(defaultKey string) docs.FieldSpec {
+	return docs.FieldObject(
+		"persistence",
+		"Optionally persist the configurations of dynamic components added or removed via the REST API, so that they are restored when Benthos restarts. Configurations can be persisted either to a file or to a [cache resource](/docs/components/caches/about), but not both.",
+	).WithChildren(
+		docs.FieldString("path", "A file path to persist configurations to. The file is created when it does not exist.", "./dynamic_state.json").HasDefault(""),
+		docs.FieldString("cache", "The name of a cache resource to persist configurations to.").HasDefault(""),
+		docs.FieldString("key", "The key under which configurations are stored within the cache resource.").HasDefault(defaultKey),
+	).Advanced()
+}
+
+func newDynamicStore(mgr interop.Manager, path, cacheName, key string) (api.DynamicStore, error) {
+	if path != "" && cacheName != "" {
+		return nil, errors.New("persistence can be configured with either a path or a cache, but not both")
+	}
+	if path != "" {
+		return &dynamicFileStore{path: path}, nil
+	}
+	if cacheName != "" {
+		if key == "" {
+			return nil, errors.New("persistence key must not be empty")
+		}
+		if !mgr.ProbeCache(cacheName) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", cacheName)
+		}
+		return &dynamicCacheStore{mgr: mgr, cache: cacheName, key: key}, nil
+	}
+	return nil, nil
+}
+
+func marshalDynamicConfigs(configs map[string][]byte) ([]byte, error) {
+	strConfigs := make(map[string]string, len(configs))
+	for k, v := range configs {
+		strConfigs[k] = string(v)
+	}
+	return json.Marshal(strConfigs)
+}
+
+func unmarshalDynamicConfigs(b []byte) (map[string][]byte, error) {
+	var strConfigs map[string]string
+	if err := json.Unmarshal(b, &strConfigs); err != nil {
+		return nil, err
+	}
+	configs := make(map[string][]byte, len(strConfigs))
+	for k, v := range strConfigs {
+		configs[k] = []byte(v)
+	}
+	return configs, nil
+}
+
+//------------------------------------------------------------------------------
+
+// dynamicFileStore persists dynamic configs as a JSON document within a file.
+type dynamicFileStore struct {
+	path string
+}
+
+func (f *dynamicFileStore) Load(ctx context.Context) (map[string][]byte, error) {
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string][]byte{}, nil
+		}
+		return nil, err
+	}
+	return unmarshalDynamicConfigs(b)
+}
+
+func (f *dynamicFileStore) Save(ctx context.Context, configs map[string][]byte) error {
+	b, err := marshalDynamicConfigs(configs)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash mid-write never leaves
+	// a truncated state file behind.
+	tmpFile, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmpFile.Write(b); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), f.path)
+}
+
+//------------------------------------------------------------------------------
+
+// dynamicCacheStore persists dynamic configs as a JSON document within a cache
+// resource.
+type dynamicCacheStore struct {
+	mgr   interop.Manager
+	cache string
+	key   string
+}
+
+func (c *dynamicCacheStore) Load(ctx context.Context) (configs map[string][]byte, err error) {
+	var b []byte
+	if cerr := c.mgr.AccessCache(ctx, c.cache, func(ca cache.V1) {
+		b, err = ca.Get(ctx, c.key)
+	}); cerr != nil {
+		return nil, fmt.Errorf("failed to obtain cache resource '%v': %w", c.cache, cerr)
+	}
+	if err != nil {
+		if errors.Is(err, component.ErrKeyNotFound) {
+			return map[string][]byte{}, nil
+		}
+		return nil, err
+	}
+	return unmarshalDynamicConfigs(b)
+}
+
+func (c *dynamicCacheStore) Save(ctx context.Context, configs map[string][]byte) (err error) {
+	var b []byte
+	if b, err = marshalDynamicConfigs(configs); err != nil {
+		return err
+	}
+	if cerr := c.mgr.AccessCache(ctx, c.cache, func(ca cache.V1) {
+		err = ca.Set(ctx, c.key, b, nil)
+	}); cerr != nil {
+		return fmt.Errorf("failed to obtain cache resource '%v': %w", c.cache, cerr)
+	}
+	return err
+}
@@ -31,13 +31,30 @@ To GET a JSON map of input identifiers with their current uptimes use the
 To perform CRUD actions on the inputs themselves use POST, DELETE, and GET
 methods on the ` + "`/inputs/{input_id}`" + ` endpoint. When using POST the body
 of the request should be a YAML configuration for the input, if the input
-already exists it will be changed.`,
+already exists it will be changed.
+
+### Persistence
+
+When ` + "`persistence`" + ` is configured the inputs added or removed via
+the REST API are saved to either a file or a cache resource, and are restored
+when Benthos is restarted. Inputs defined statically in the config are not
+persisted.
+
+### Events
+
+A GET request to the ` + "`/inputs`" + ` endpoint with the header
+` + "`Accept: text/event-stream`" + ` opens a stream of
+[server-sent events](https://developer.mozilla.org/en-US/docs/Web/API/Server-sent_events)
+describing changes to inputs as they occur. Each event has a type of
+` + "`started`, `stopped`, `updated` or `deleted`" + ` and a JSON body of the
+form ` + "`{\"type\":\"started\",\"id\":\"foo\",\"timestamp\":\"...\"}`" + `.`,
 		Categories: []string{
 			"Utility",
 		},
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldInput("inputs", "A map of inputs to statically create.").Map().HasDefault(map[string]interface{}{}),
 			docs.FieldString("prefix", "A path prefix for HTTP endpoints that are registered.").HasDefault(""),
+			dynamicPersistenceFieldSpec("benthos_dynamic_inputs"),
 		),
 	})
 	if err != nil {
@@ -48,6 +65,12 @@ already exists it will be changed.`,
 func newDynamicInput(conf oinput.Config, mgr bundle.NewManagement, pipelines ...iprocessor.PipelineConstructorFunc) (input.Streamed, error) {
 	dynAPI := api.NewDynamic()
 
+	pConf := conf.Dynamic.Persistence
+	store, err := newDynamicStore(mgr, pConf.Path, pConf.Cache, pConf.Key)
+	if err != nil {
+		return nil, err
+	}
+
 	inputs := map[string]input.Streamed{}
 	for k, v := range conf.Dynamic.Inputs {
 		iMgr := mgr.IntoPath("dynamic", "inputs", k).(bundle.NewManagement)
@@ -122,6 +145,13 @@ func newDynamicInput(conf oinput.Config, mgr bundle.NewManagement, pipelines ...
 		return err
 	})
 
+	if store != nil {
+		dynAPI.SetStore(store)
+		if err := dynAPI.Restore(context.Background()); err != nil {
+			mgr.Logger().Errorf("Failed to restore dynamic inputs: %v", err)
+		}
+	}
+
 	mgr.RegisterEndpoint(
 		path.Join(conf.Dynamic.Prefix, "/inputs/{id}"),
 		"Perform CRUD operations on the configuration of dynamic inputs. For"+
@@ -130,7 +160,8 @@ func newDynamicInput(conf oinput.Config, mgr bundle.NewManagement, pipelines ...
 	)
 	mgr.RegisterEndpoint(
 		path.Join(conf.Dynamic.Prefix, "/inputs"),
-		"Get a map of running input identifiers with their current uptimes.",
+		"Get a map of running input identifiers with their current uptimes, or"+
+			" a stream of events when requested with the header `Accept: text/event-stream`.",
 		dynAPI.HandleList,
 	)
 
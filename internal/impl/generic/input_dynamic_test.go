@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	bmock "github.com/benthosdev/benthos/v4/internal/bundle/mock"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
 	oinput "github.com/benthosdev/benthos/v4/internal/old/input"
 )
 
@@ -75,3 +77,68 @@ generate:
 	i.CloseAsync()
 	require.NoError(t, i.WaitForClose(time.Second))
 }
+
+func TestDynamicInputPersistence(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+
+	newInput := func() (*mux.Router, input.Streamed) {
+		gMux := mux.NewRouter()
+
+		mgr := bmock.NewManager()
+		mgr.OnRegisterEndpoint = func(path string, h http.HandlerFunc) {
+			gMux.HandleFunc(path, h)
+		}
+
+		conf := oinput.NewConfig()
+		conf.Type = "dynamic"
+		conf.Dynamic.Persistence.Path = statePath
+
+		i, err := mgr.NewInput(conf)
+		require.NoError(t, err)
+		return gMux, i
+	}
+
+	readSource := func(i input.Streamed) {
+		t.Helper()
+		select {
+		case ts, open := <-i.TransactionChan():
+			require.True(t, open)
+			assert.Equal(t, `{"source":"foo"}`, string(ts.Payload.Get(0).Get()))
+			require.NoError(t, ts.Ack(ctx, nil))
+		case <-ctx.Done():
+			t.Fatal(ctx.Err())
+		}
+	}
+
+	gMux, i := newInput()
+
+	fooConf := `
+generate:
+  interval: 100ms
+  mapping: 'root.source = "foo"'
+`
+	req := httptest.NewRequest("POST", "/inputs/foo", bytes.NewBuffer([]byte(fooConf)))
+	res := httptest.NewRecorder()
+	gMux.ServeHTTP(res, req)
+	require.Equal(t, 200, res.Code)
+
+	readSource(i)
+	i.CloseAsync()
+	require.NoError(t, i.WaitForClose(time.Second))
+
+	// A new dynamic input with the same persistence path should restore the
+	// input that was previously added.
+	gMux, i = newInput()
+	readSource(i)
+
+	req = httptest.NewRequest("GET", "/inputs/foo", nil)
+	res = httptest.NewRecorder()
+	gMux.ServeHTTP(res, req)
+	assert.Equal(t, 200, res.Code)
+
+	i.CloseAsync()
+	require.NoError(t, i.WaitForClose(time.Second))
+}
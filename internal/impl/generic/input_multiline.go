@@ -0,0 +1,367 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	mlFieldInput     = "input"
+	mlFieldPattern   = "pattern"
+	mlFieldNegate    = "negate"
+	mlFieldGroupBy   = "group_by"
+	mlFieldTimeout   = "timeout"
+	mlFieldMaxLines  = "max_lines"
+	mlFieldSeparator = "separator"
+)
+
+func multilineInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Reads lines from a child input and joins continuation lines, such as those of stack traces, with the line that they follow into single messages.").
+		Description(`
+Each message consumed from the child input is treated as a single line, which either starts a new message or continues the message started by a previous line. By default lines that match the regular expression `+"`pattern`"+` start a new message and all other lines are continuations, and when `+"`negate`"+` is `+"`true`"+` the opposite applies, where lines that match the pattern are continuations and all other lines start a new message.
+
+A message is emitted once a line that starts a new message is read, once it reaches `+"`max_lines`"+` lines, or once no further line has been read for it within the `+"`timeout`"+`, which ensures that the last message of a source isn't held indefinitely. Lines are joined with the `+"`separator`"+` and the emitted message carries the metadata of its first line.
+
+When lines are consumed from multiple sources, such as several files or containers, the lines of each source can be joined separately by setting `+"`group_by`"+` to an interpolated string that identifies the source of a line, usually from its metadata.
+
+Lines are acknowledged with the child input once all of the messages that they were joined into have been delivered, and when any of those messages is rejected the lines are rejected with the child input.
+
+### Metadata
+
+Emitted messages have the metadata field `+"`multiline_line_count`"+` added, containing the number of lines joined into the message.`).
+		Field(service.NewInputField(mlFieldInput).
+			Description("The child input to consume lines from.")).
+		Field(service.NewStringField(mlFieldPattern).
+			Description("A regular expression that matches lines that start a new message, or that matches continuation lines when `negate` is `true`.").
+			Example(`^\d{4}-\d{2}-\d{2}`).
+			Example(`^\s`)).
+		Field(service.NewBoolField(mlFieldNegate).
+			Description("Whether lines that match the `pattern` are continuations rather than the start of a new message.").
+			Default(false)).
+		Field(service.NewInterpolatedStringField(mlFieldGroupBy).
+			Description("An optional interpolated string that identifies the source of each line, where the lines of each source are joined separately.").
+			Default("").
+			Example(`${! meta("path") }`).
+			Example(`${! meta("docker_container_id") }-${! meta("docker_stream") }`)).
+		Field(service.NewDurationField(mlFieldTimeout).
+			Description("The period of time after the last line of a message was read after which the message is emitted.").
+			Default("1s")).
+		Field(service.NewIntField(mlFieldMaxLines).
+			Description("The maximum number of lines of a message, after which a message is emitted even if continuation lines follow.").
+			Default(500).
+			Advanced()).
+		Field(service.NewStringField(mlFieldSeparator).
+			Description("The string used to join lines.").
+			Default("\n").
+			Advanced()).
+		Example("Java Stack Traces", `
+Here we read the log files of a Java service, where each log starts with a date and the lines of stack traces are joined with the log that precedes them:`,
+			`
+input:
+  multiline:
+    pattern: '^\d{4}-\d{2}-\d{2}'
+    group_by: ${! meta("path") }
+    input:
+      file:
+        paths: [ /var/log/app/*.log ]
+        codec: lines
+`,
+		).
+		Example("Indented Continuations", `
+Here we follow the logs of Docker containers, where lines starting with whitespace or `+"`Caused by:`"+` are continuations of the previous line, and join the lines of each container and stream separately:`,
+			`
+input:
+  multiline:
+    pattern: '^(\s|Caused by:)'
+    negate: true
+    group_by: ${! meta("docker_container_id") }-${! meta("docker_stream") }
+    input:
+      docker_logs: {}
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"multiline", multilineInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newMultilineInputFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// multilineReader is the subset of an owned input consumed by the multiline
+// input.
+type multilineReader interface {
+	ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error)
+	Close(ctx context.Context) error
+}
+
+// multilineTracker acknowledges a batch of the child input once every message
+// that its lines were joined into has been acknowledged.
+type multilineTracker struct {
+	ackFn service.AckFunc
+
+	mut    sync.Mutex
+	refs   int
+	sealed bool
+	err    error
+}
+
+func (t *multilineTracker) ref() {
+	t.mut.Lock()
+	t.refs++
+	t.mut.Unlock()
+}
+
+// unref releases a message that the lines of the batch were joined into, and
+// acknowledges the batch once it is sealed and no messages remain.
+func (t *multilineTracker) unref(ctx context.Context, err error) error {
+	t.mut.Lock()
+	t.refs--
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	done := t.sealed && t.refs == 0
+	t.mut.Unlock()
+
+	if done {
+		return t.ackFn(ctx, t.err)
+	}
+	return nil
+}
+
+// seal marks that all lines of the batch have been joined into messages.
+func (t *multilineTracker) seal(ctx context.Context) error {
+	t.mut.Lock()
+	t.sealed = true
+	done := t.refs == 0
+	t.mut.Unlock()
+
+	if done {
+		return t.ackFn(ctx, t.err)
+	}
+	return nil
+}
+
+// multilineGroup is a message of a source that is still being joined.
+type multilineGroup struct {
+	lines    [][]byte
+	first    *service.Message
+	trackers []*multilineTracker
+	deadline time.Time
+}
+
+type multilineReady struct {
+	msg      *service.Message
+	trackers []*multilineTracker
+}
+
+type multilineInput struct {
+	input     multilineReader
+	pattern   *regexp.Regexp
+	negate    bool
+	groupBy   *service.InterpolatedString
+	timeout   time.Duration
+	maxLines  int
+	separator []byte
+
+	nowFn func() time.Time
+
+	pending map[string]*multilineGroup
+	ready   []multilineReady
+}
+
+func newMultilineInputFromConfig(conf *service.ParsedConfig) (*multilineInput, error) {
+	m := &multilineInput{
+		nowFn:   time.Now,
+		pending: map[string]*multilineGroup{},
+	}
+
+	pattern, err := conf.FieldString(mlFieldPattern)
+	if err != nil {
+		return nil, err
+	}
+	if m.pattern, err = regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	if m.negate, err = conf.FieldBool(mlFieldNegate); err != nil {
+		return nil, err
+	}
+	if m.groupBy, err = conf.FieldInterpolatedString(mlFieldGroupBy); err != nil {
+		return nil, err
+	}
+	if m.timeout, err = conf.FieldDuration(mlFieldTimeout); err != nil {
+		return nil, err
+	}
+	if m.timeout <= 0 {
+		return nil, errors.New("timeout must be larger than zero")
+	}
+	if m.maxLines, err = conf.FieldInt(mlFieldMaxLines); err != nil {
+		return nil, err
+	}
+	if m.maxLines < 1 {
+		return nil, errors.New("max_lines must be at least 1")
+	}
+	separator, err := conf.FieldString(mlFieldSeparator)
+	if err != nil {
+		return nil, err
+	}
+	m.separator = []byte(separator)
+
+	if m.input, err = conf.FieldInput(mlFieldInput); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *multilineInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// flush moves the pending message of a source to the ready queue.
+func (m *multilineInput) flush(key string) {
+	g, exists := m.pending[key]
+	if !exists {
+		return
+	}
+	delete(m.pending, key)
+
+	msg := g.first.Copy()
+	msg.SetBytes(bytes.Join(g.lines, m.separator))
+	msg.MetaSet("multiline_line_count", strconv.Itoa(len(g.lines)))
+	m.ready = append(m.ready, multilineReady{msg: msg, trackers: g.trackers})
+}
+
+// flushExpired flushes the pending messages of sources that have timed out,
+// in the order that they timed out.
+func (m *multilineInput) flushExpired(now time.Time) {
+	var keys []string
+	for k, g := range m.pending {
+		if !g.deadline.After(now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return m.pending[keys[i]].deadline.Before(m.pending[keys[j]].deadline)
+	})
+	for _, k := range keys {
+		m.flush(k)
+	}
+}
+
+func (m *multilineInput) flushAll() {
+	m.flushExpired(time.Unix(1<<62, 0))
+}
+
+func (m *multilineInput) nextDeadline() (deadline time.Time) {
+	for _, g := range m.pending {
+		if deadline.IsZero() || g.deadline.Before(deadline) {
+			deadline = g.deadline
+		}
+	}
+	return
+}
+
+// add joins a line of the child input with the pending message of its source.
+func (m *multilineInput) add(batch service.MessageBatch, i int, tracker *multilineTracker) error {
+	line, err := batch[i].AsBytes()
+	if err != nil {
+		return err
+	}
+	key := batch.InterpolatedString(i, m.groupBy)
+
+	g := m.pending[key]
+	isStart := m.pattern.Match(line) != m.negate
+	if g != nil && (isStart || len(g.lines) >= m.maxLines) {
+		m.flush(key)
+		g = nil
+	}
+	if g == nil {
+		g = &multilineGroup{first: batch[i]}
+		m.pending[key] = g
+	}
+
+	g.lines = append(g.lines, line)
+	g.deadline = m.nowFn().Add(m.timeout)
+	if n := len(g.trackers); n == 0 || g.trackers[n-1] != tracker {
+		tracker.ref()
+		g.trackers = append(g.trackers, tracker)
+	}
+	return nil
+}
+
+func (m *multilineInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for len(m.ready) == 0 {
+		readCtx, done := ctx, func() {}
+		if deadline := m.nextDeadline(); !deadline.IsZero() {
+			readCtx, done = context.WithDeadline(ctx, deadline)
+		}
+
+		batch, ackFn, err := m.input.ReadBatch(readCtx)
+		timedOut := ctx.Err() == nil && readCtx.Err() != nil
+		done()
+		if err != nil {
+			if timedOut {
+				m.flushExpired(m.nowFn())
+				continue
+			}
+			if errors.Is(err, service.ErrEndOfInput) {
+				m.flushAll()
+				if len(m.ready) > 0 {
+					break
+				}
+			}
+			return nil, nil, err
+		}
+
+		tracker := &multilineTracker{ackFn: ackFn}
+		for i := range batch {
+			if err := m.add(batch, i, tracker); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := tracker.seal(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ready := m.ready
+	m.ready = nil
+
+	batch := make(service.MessageBatch, len(ready))
+	for i, r := range ready {
+		batch[i] = r.msg
+	}
+	return batch, func(ctx context.Context, err error) error {
+		var ackErr error
+		for _, r := range ready {
+			for _, t := range r.trackers {
+				if tErr := t.unref(ctx, err); tErr != nil && ackErr == nil {
+					ackErr = tErr
+				}
+			}
+		}
+		return ackErr
+	}, nil
+}
+
+func (m *multilineInput) Close(ctx context.Context) error {
+	return m.input.Close(ctx)
+}
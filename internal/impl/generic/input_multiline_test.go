@@ -0,0 +1,171 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type multilineTestBatch struct {
+	batch service.MessageBatch
+	ackFn service.AckFunc
+}
+
+type multilineTestReader struct {
+	batches chan multilineTestBatch
+}
+
+func (r *multilineTestReader) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	select {
+	case b, open := <-r.batches:
+		if !open {
+			return nil, nil, service.ErrEndOfInput
+		}
+		return b.batch, b.ackFn, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (r *multilineTestReader) Close(ctx context.Context) error {
+	return nil
+}
+
+func testMultilineInput(t *testing.T, confStr string) (*multilineInput, *multilineTestReader) {
+	t.Helper()
+
+	conf, err := multilineInputConfig().ParseYAML(confStr+`
+input:
+  generate:
+    mapping: 'root = "unused"'
+`, nil)
+	require.NoError(t, err)
+
+	m, err := newMultilineInputFromConfig(conf)
+	require.NoError(t, err)
+	require.NoError(t, m.input.Close(context.Background()))
+
+	reader := &multilineTestReader{batches: make(chan multilineTestBatch, 10)}
+	m.input = reader
+	return m, reader
+}
+
+func multilineLines(group string, lines ...string) service.MessageBatch {
+	var b service.MessageBatch
+	for _, l := range lines {
+		msg := service.NewMessage([]byte(l))
+		msg.MetaSet("source", group)
+		b = append(b, msg)
+	}
+	return b
+}
+
+func readMultiline(t *testing.T, m *multilineInput) ([]string, service.AckFunc) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	batch, ackFn, err := m.ReadBatch(ctx)
+	require.NoError(t, err)
+
+	var contents []string
+	for _, msg := range batch {
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		contents = append(contents, string(b))
+	}
+	return contents, ackFn
+}
+
+func TestMultilineInputStartPattern(t *testing.T) {
+	m, reader := testMultilineInput(t, `
+pattern: '^\d{4}-'
+`)
+
+	var acked []error
+	reader.batches <- multilineTestBatch{
+		batch: multilineLines("a",
+			"2022-06-01 first",
+			"  at foo",
+			"  at bar",
+			"2022-06-01 second",
+		),
+		ackFn: func(ctx context.Context, err error) error {
+			acked = append(acked, err)
+			return nil
+		},
+	}
+	reader.batches <- multilineTestBatch{
+		batch: multilineLines("a", "  at baz", "2022-06-01 third"),
+		ackFn: func(ctx context.Context, err error) error {
+			acked = append(acked, err)
+			return nil
+		},
+	}
+
+	contents, ackFn := readMultiline(t, m)
+	assert.Equal(t, []string{"2022-06-01 first\n  at foo\n  at bar"}, contents)
+	require.NoError(t, ackFn(context.Background(), nil))
+
+	// The first batch isn't acknowledged until its final line is delivered.
+	assert.Empty(t, acked)
+
+	contents, ackFn = readMultiline(t, m)
+	assert.Equal(t, []string{"2022-06-01 second\n  at baz"}, contents)
+	require.NoError(t, ackFn(context.Background(), nil))
+	assert.Equal(t, []error{nil}, acked)
+
+	// The final message is emitted once the timeout elapses.
+	start := time.Now()
+	contents, ackFn = readMultiline(t, m)
+	assert.Equal(t, []string{"2022-06-01 third"}, contents)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(time.Millisecond*500))
+
+	require.NoError(t, ackFn(context.Background(), errors.New("nope")))
+	assert.Equal(t, []error{nil, errors.New("nope")}, acked)
+}
+
+func TestMultilineInputNegateGroups(t *testing.T) {
+	m, reader := testMultilineInput(t, `
+pattern: '^\s'
+negate: true
+group_by: ${! meta("source") }
+max_lines: 3
+timeout: 10s
+`)
+
+	// Ensure that the deadlines of sources are distinct.
+	base, n := time.Now(), 0
+	m.nowFn = func() time.Time {
+		n++
+		return base.Add(time.Duration(n))
+	}
+
+	batch := append(multilineLines("a", "a1", " a2"), multilineLines("b", "b1")...)
+	batch = append(batch, multilineLines("a", " a3", " a4", " a5")...)
+	batch = append(batch, multilineLines("b", " b2")...)
+	reader.batches <- multilineTestBatch{
+		batch: batch,
+		ackFn: func(ctx context.Context, err error) error {
+			return nil
+		},
+	}
+	close(reader.batches)
+
+	contents, _ := readMultiline(t, m)
+	assert.Equal(t, []string{"a1\n a2\n a3"}, contents)
+
+	// The end of the input flushes all pending messages.
+	contents, _ = readMultiline(t, m)
+	assert.Equal(t, []string{" a4\n a5", "b1\n b2"}, contents)
+
+	_, _, err := m.ReadBatch(context.Background())
+	assert.Equal(t, service.ErrEndOfInput, err)
+}
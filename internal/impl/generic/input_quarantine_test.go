@@ -0,0 +1,165 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestQuarantineInput(t *testing.T) {
+	reader := &fakeShadowReader{
+		batches: make(chan service.MessageBatch, 10),
+		acks:    make(chan error, 10),
+	}
+	writer := &fakeShadowWriter{}
+
+	key, err := service.NewInterpolatedString(`${! content() }`)
+	require.NoError(t, err)
+
+	q, err := newQuarantineInput(reader, writer, key, 2, time.Hour, 10, service.MockResources())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	readAndAck := func(content string, ackErr error) error {
+		t.Helper()
+		reader.batches <- service.MessageBatch{service.NewMessage([]byte(content))}
+		batch, ackFn, err := q.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+		require.NoError(t, ackFn(ctx, ackErr))
+		return <-reader.acks
+	}
+
+	nope := errors.New("nope")
+
+	// The first rejection is passed to the child input.
+	assert.Equal(t, nope, readAndAck("foo", nope))
+	assert.Equal(t, nope, readAndAck("bar", nope))
+	assert.Empty(t, writer.writtenSoFar())
+
+	// The second rejection of the same message results in it being
+	// quarantined and acknowledged.
+	assert.NoError(t, readAndAck("foo", nope))
+	assert.Equal(t, []string{"foo"}, writer.writtenSoFar())
+
+	// Successful deliveries reset the attempts of a message.
+	assert.NoError(t, readAndAck("bar", nil))
+	assert.Equal(t, nope, readAndAck("bar", nope))
+	assert.Equal(t, []string{"foo"}, writer.writtenSoFar())
+
+	// Failing to quarantine results in a rejection.
+	writer.err = errors.New("quarantine failed")
+	assert.Equal(t, nope, readAndAck("bar", nope))
+	writer.err = nil
+	assert.NoError(t, readAndAck("bar", nope))
+	assert.Equal(t, []string{"foo", "bar"}, writer.writtenSoFar())
+
+	require.NoError(t, q.Close(ctx))
+	assert.True(t, writer.closed)
+}
+
+func TestQuarantineInputMetadata(t *testing.T) {
+	reader := &fakeShadowReader{
+		batches: make(chan service.MessageBatch, 1),
+		acks:    make(chan error, 1),
+	}
+
+	var quarantined service.MessageBatch
+	writer := &quarantineWriterFunc{fn: func(b service.MessageBatch) error {
+		quarantined = b
+		return nil
+	}}
+
+	key, err := service.NewInterpolatedString(`${! content() }`)
+	require.NoError(t, err)
+
+	q, err := newQuarantineInput(reader, writer, key, 1, time.Hour, 10, service.MockResources())
+	require.NoError(t, err)
+
+	reader.batches <- service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+	}
+	batch, ackFn, err := q.ReadBatch(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, ackFn(context.Background(), errors.New("nope")))
+	assert.NoError(t, <-reader.acks)
+
+	require.Len(t, quarantined, 2)
+	for i, exp := range []string{"foo", "bar"} {
+		b, err := quarantined[i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(b))
+
+		v, _ := quarantined[i].MetaGet("quarantine_error")
+		assert.Equal(t, "nope", v)
+		v, _ = quarantined[i].MetaGet("quarantine_attempts")
+		assert.Equal(t, "1", v)
+		_, exists := quarantined[i].MetaGet("quarantine_timestamp_unix")
+		assert.True(t, exists)
+
+		// The original messages are unchanged.
+		_, exists = batch[i].MetaGet("quarantine_error")
+		assert.False(t, exists)
+	}
+}
+
+func TestQuarantineInputKeyEviction(t *testing.T) {
+	key, err := service.NewInterpolatedString(`${! content() }`)
+	require.NoError(t, err)
+
+	q, err := newQuarantineInput(nil, nil, key, 10, time.Minute, 2, service.MockResources())
+	require.NoError(t, err)
+
+	now := time.Unix(1000, 0)
+	q.nowFn = func() time.Time {
+		return now
+	}
+
+	assert.Equal(t, 1, q.reject([]string{"foo"}))
+	assert.Equal(t, 1, q.reject([]string{"bar"}))
+	assert.Equal(t, 2, q.reject([]string{"foo"}))
+
+	// Exceeding the maximum number of keys evicts the least recently rejected
+	// key.
+	assert.Equal(t, 1, q.reject([]string{"baz"}))
+	assert.Len(t, q.attempts, 2)
+	assert.Equal(t, 1, q.reject([]string{"bar"}))
+	assert.Len(t, q.attempts, 2)
+
+	// Keys that haven't been rejected within the TTL are evicted.
+	now = now.Add(time.Second * 30)
+	assert.Equal(t, 2, q.reject([]string{"bar"}))
+	now = now.Add(time.Second * 45)
+	assert.Equal(t, 1, q.reject([]string{"foo"}))
+	assert.Len(t, q.attempts, 2)
+	assert.Contains(t, q.attempts, "bar")
+	assert.Contains(t, q.attempts, "foo")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 1, q.reject([]string{"qux"}))
+	assert.Len(t, q.attempts, 1)
+	assert.Equal(t, 1, q.lru.Len())
+
+	q.forget([]string{"qux"})
+	assert.Empty(t, q.attempts)
+	assert.Equal(t, 0, q.lru.Len())
+}
+
+type quarantineWriterFunc struct {
+	fn func(b service.MessageBatch) error
+}
+
+func (w *quarantineWriterFunc) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	return w.fn(b)
+}
+
+func (w *quarantineWriterFunc) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,228 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	riFieldPath  = "path"
+	riFieldSpeed = "speed"
+	riFieldLoop  = "loop"
+)
+
+func replayInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Replays messages recorded by the [`capture` output](/docs/components/outputs/capture), preserving or scaling the original timing between them.").
+		Description(`
+Each message of the capture file is emitted with its original contents and metadata, and the time between messages matches the time between them when they were captured, divided by `+"`speed`"+`. This makes it possible to reproduce incidents locally with traffic that has the same shape as it did in production.
+
+When the pipeline applies back pressure messages are emitted as soon as possible until the replay has caught up with the original timing, and therefore bursts are preserved but never compounded.
+
+### Metadata
+
+Replayed messages have the metadata field `+"`replay_timestamp`"+` added, which contains the time at which the message was originally captured as an RFC 3339 timestamp.`).
+		Field(service.NewStringField(riFieldPath).
+			Description("The path of the capture file to replay.").
+			Example("./incident.bcap")).
+		Field(service.NewFloatField(riFieldSpeed).
+			Description("A multiplier of the speed of the replay, where `2` replays messages twice as fast as they were captured and `0.5` replays them at half speed. A speed of `0` replays messages as fast as possible.").
+			Default(1.0)).
+		Field(service.NewBoolField(riFieldLoop).
+			Description("Whether to restart the replay from the beginning of the file once it has been fully read, rather than shutting down the input.").
+			Default(false).
+			Advanced()).
+		Example("Reproducing an Incident", `
+Here we replay a capture of traffic ten times faster than it was recorded in order to reproduce an incident against a local service:`,
+			`
+input:
+  replay:
+    path: ./orders.bcap
+    speed: 10
+
+output:
+  http_client:
+    url: http://localhost:8080/orders
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"replay", replayInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			r, err := newReplayInputFromConfig(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(r), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type replayInput struct {
+	path  string
+	speed float64
+	loop  bool
+
+	log   *service.Logger
+	nowFn func() time.Time
+
+	mut       sync.Mutex
+	file      *os.File
+	dec       *captureDecoder
+	firstTS   time.Time
+	startedAt time.Time
+}
+
+func newReplayInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*replayInput, error) {
+	r := &replayInput{
+		log:   log,
+		nowFn: time.Now,
+	}
+
+	var err error
+	if r.path, err = conf.FieldString(riFieldPath); err != nil {
+		return nil, err
+	}
+	if r.speed, err = conf.FieldFloat(riFieldSpeed); err != nil {
+		return nil, err
+	}
+	if r.speed < 0 {
+		return nil, errors.New("speed must not be negative")
+	}
+	if r.loop, err = conf.FieldBool(riFieldLoop); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the capture file and resets the replay timing, the mutex must be
+// held by the caller.
+func (r *replayInput) open() error {
+	file, err := os.Open(r.path)
+	if err != nil {
+		return err
+	}
+	dec, err := newCaptureDecoder(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.dec = file, dec
+	r.firstTS, r.startedAt = time.Time{}, time.Time{}
+	return nil
+}
+
+func (r *replayInput) closeFile() {
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.file, r.dec = nil, nil
+}
+
+func (r *replayInput) Connect(ctx context.Context) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.dec != nil {
+		return nil
+	}
+	return r.open()
+}
+
+// next reads the next record, restarting from the beginning of the file when
+// looping.
+func (r *replayInput) next() (captureRecord, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.dec == nil {
+		return captureRecord{}, service.ErrNotConnected
+	}
+
+	record, err := r.dec.Decode()
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		r.log.Warnf("Capture file '%v' ends with a truncated record, which was skipped", r.path)
+		err = io.EOF
+	}
+	if errors.Is(err, io.EOF) && r.loop {
+		r.closeFile()
+		if err = r.open(); err != nil {
+			return captureRecord{}, err
+		}
+		if record, err = r.dec.Decode(); errors.Is(err, io.EOF) {
+			// An empty capture file would otherwise loop forever.
+			return captureRecord{}, service.ErrEndOfInput
+		}
+	}
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return captureRecord{}, service.ErrEndOfInput
+		}
+		return captureRecord{}, err
+	}
+
+	if r.startedAt.IsZero() {
+		r.firstTS, r.startedAt = record.timestamp, r.nowFn()
+	}
+	return record, nil
+}
+
+// scheduledAt returns the time at which a record should be emitted.
+func (r *replayInput) scheduledAt(ts time.Time) time.Time {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	offset := float64(ts.Sub(r.firstTS)) / r.speed
+	return r.startedAt.Add(time.Duration(offset))
+}
+
+func (r *replayInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	record, err := r.next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.speed > 0 {
+		if wait := r.scheduledAt(record.timestamp).Sub(r.nowFn()); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+
+	msg := service.NewMessage(record.content)
+	for k, v := range record.metadata {
+		msg.MetaSet(k, v)
+	}
+	msg.MetaSet("replay_timestamp", record.timestamp.UTC().Format(time.RFC3339Nano))
+	return msg, func(ctx context.Context, err error) error {
+		return nil
+	}, nil
+}
+
+func (r *replayInput) Close(ctx context.Context) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.closeFile()
+	return nil
+}
@@ -0,0 +1,175 @@
+package generic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func writeTestCapture(t *testing.T, records ...captureRecord) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "capture.bcap")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	enc, err := newCaptureEncoder(file)
+	require.NoError(t, err)
+	for _, r := range records {
+		require.NoError(t, enc.Encode(r))
+	}
+	require.NoError(t, enc.Flush())
+	require.NoError(t, file.Close())
+	return path
+}
+
+func testReplayInput(t *testing.T, confStr string) *replayInput {
+	t.Helper()
+
+	conf, err := replayInputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	r, err := newReplayInputFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = r.Close(context.Background())
+	})
+	return r
+}
+
+func readReplayed(t *testing.T, r *replayInput) (string, map[string]string) {
+	t.Helper()
+
+	msg, _, err := r.Read(context.Background())
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+
+	meta := map[string]string{}
+	_ = msg.MetaWalk(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	return string(b), meta
+}
+
+func TestReplayInput(t *testing.T) {
+	path := writeTestCapture(t,
+		captureRecord{
+			timestamp: time.Unix(1600000000, 0),
+			metadata:  map[string]string{"foo": "bar"},
+			content:   []byte("first"),
+		},
+		captureRecord{
+			timestamp: time.Unix(1600003600, 0),
+			content:   []byte("second"),
+		},
+	)
+
+	r := testReplayInput(t, `
+path: `+path+`
+speed: 0
+`)
+
+	content, meta := readReplayed(t, r)
+	assert.Equal(t, "first", content)
+	assert.Equal(t, map[string]string{
+		"foo":              "bar",
+		"replay_timestamp": "2020-09-13T12:26:40Z",
+	}, meta)
+
+	content, meta = readReplayed(t, r)
+	assert.Equal(t, "second", content)
+	assert.Equal(t, map[string]string{
+		"replay_timestamp": "2020-09-13T13:26:40Z",
+	}, meta)
+
+	_, _, err := r.Read(context.Background())
+	assert.Equal(t, service.ErrEndOfInput, err)
+}
+
+func TestReplayInputTiming(t *testing.T) {
+	path := writeTestCapture(t,
+		captureRecord{timestamp: time.Unix(100, 0), content: []byte("a")},
+		captureRecord{timestamp: time.Unix(101, 0), content: []byte("b")},
+		captureRecord{timestamp: time.Unix(102, 0), content: []byte("c")},
+	)
+
+	r := testReplayInput(t, `
+path: `+path+`
+speed: 10
+`)
+
+	start := time.Now()
+	for _, exp := range []string{"a", "b", "c"} {
+		content, _ := readReplayed(t, r)
+		assert.Equal(t, exp, content)
+	}
+
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(time.Millisecond*200))
+	assert.Less(t, int64(elapsed), int64(time.Second))
+
+	ctx, done := context.WithCancel(context.Background())
+	done()
+	_, _, err := r.Read(ctx)
+	assert.Equal(t, service.ErrEndOfInput, err)
+}
+
+func TestReplayInputLoop(t *testing.T) {
+	path := writeTestCapture(t,
+		captureRecord{timestamp: time.Unix(100, 0), content: []byte("a")},
+		captureRecord{timestamp: time.Unix(101, 0), content: []byte("b")},
+	)
+
+	r := testReplayInput(t, `
+path: `+path+`
+speed: 0
+loop: true
+`)
+
+	var contents []string
+	for i := 0; i < 5; i++ {
+		content, _ := readReplayed(t, r)
+		contents = append(contents, content)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "b", "a"}, contents)
+}
+
+func TestReplayInputEmptyLoop(t *testing.T) {
+	r := testReplayInput(t, `
+path: `+writeTestCapture(t)+`
+loop: true
+`)
+
+	_, _, err := r.Read(context.Background())
+	assert.Equal(t, service.ErrEndOfInput, err)
+}
+
+func TestReplayInputCancelled(t *testing.T) {
+	path := writeTestCapture(t,
+		captureRecord{timestamp: time.Unix(100, 0), content: []byte("a")},
+		captureRecord{timestamp: time.Unix(200, 0), content: []byte("b")},
+	)
+
+	r := testReplayInput(t, `path: `+path)
+
+	content, _ := readReplayed(t, r)
+	assert.Equal(t, "a", content)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+
+	_, _, err := r.Read(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
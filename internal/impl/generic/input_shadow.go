@@ -0,0 +1,245 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func shadowInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Reads messages from a child input and mirrors a sample of them into a shadow output before they enter the pipeline, without affecting the delivery of the originals.").
+		Description(`
+This is useful for sending a copy of production traffic to a staging pipeline, such as one running a new version of a config, in order to test it with real data.
+
+Copies of the messages are written to the shadow output in the background on a fire-and-forget basis: the acknowledgement of messages consumed by the child input depends only on the primary pipeline, and errors from the shadow output are logged and otherwise ignored. When the shadow output is unable to keep up and `+"`max_in_flight`"+` batches are already pending the copies are dropped rather than applying back pressure to the input.
+
+Sampling is performed per batch, where `+"`sample_percentage`"+` determines the percentage of batches that are mirrored.
+
+### Metrics
+
+The counter metrics `+"`shadow_sent`, `shadow_dropped` and `shadow_error`"+` track the number of batches successfully written to the shadow output, dropped due to the shadow output being saturated and failed to be written respectively.`).
+		Field(service.NewInputField("input").
+			Description("The child input to consume messages from.")).
+		Field(service.NewOutputField("output").
+			Description("The shadow output to write copies of messages to.")).
+		Field(service.NewFloatField("sample_percentage").
+			Description("The percentage of batches, between 0 and 100, to mirror into the shadow output.").
+			Default(100.0)).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have pending or in flight to the shadow output at a given time, beyond which copies are dropped.").
+			Default(64)).
+		Example("Staging Shadow Traffic", `
+Here we consume messages from Kafka for our production pipeline, whilst mirroring ten percent of them into a Kafka topic consumed by a staging pipeline:`,
+			`
+input:
+  shadow:
+    sample_percentage: 10
+    input:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topics: [ orders ]
+        consumer_group: benthos_prod
+    output:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topic: orders_staging
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"shadow", shadowInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newShadowInputFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// shadowReader is the subset of an owned input consumed by the shadow input.
+type shadowReader interface {
+	ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error)
+	Close(ctx context.Context) error
+}
+
+// shadowWriter is the subset of an owned output written to by the shadow
+// input.
+type shadowWriter interface {
+	WriteBatch(ctx context.Context, b service.MessageBatch) error
+	Close(ctx context.Context) error
+}
+
+type shadowInput struct {
+	input      shadowReader
+	output     shadowWriter
+	percentage float64
+
+	log *service.Logger
+
+	mSent    *service.MetricCounter
+	mDropped *service.MetricCounter
+	mError   *service.MetricCounter
+
+	pendingMut  sync.RWMutex
+	pending     chan service.MessageBatch
+	closed      bool
+	closeWrites func()
+	writesDone  sync.WaitGroup
+}
+
+func newShadowInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*shadowInput, error) {
+	percentage, err := conf.FieldFloat("sample_percentage")
+	if err != nil {
+		return nil, err
+	}
+	maxInFlight, err := conf.FieldInt("max_in_flight")
+	if err != nil {
+		return nil, err
+	}
+	input, err := conf.FieldInput("input")
+	if err != nil {
+		return nil, err
+	}
+	output, err := conf.FieldOutput("output")
+	if err != nil {
+		return nil, err
+	}
+	return newShadowInput(input, output, percentage, maxInFlight, mgr)
+}
+
+func newShadowInput(input shadowReader, output shadowWriter, percentage float64, maxInFlight int, mgr *service.Resources) (*shadowInput, error) {
+	if percentage < 0 || percentage > 100 {
+		return nil, errors.New("sample_percentage must be between 0 and 100")
+	}
+	if maxInFlight < 1 {
+		return nil, errors.New("max_in_flight must be at least 1")
+	}
+
+	s := &shadowInput{
+		input:      input,
+		output:     output,
+		percentage: percentage,
+		log:        mgr.Logger(),
+		mSent:      mgr.Metrics().NewCounter("shadow_sent"),
+		mDropped:   mgr.Metrics().NewCounter("shadow_dropped"),
+		mError:     mgr.Metrics().NewCounter("shadow_error"),
+		pending:    make(chan service.MessageBatch, maxInFlight),
+	}
+
+	writeCtx, done := context.WithCancel(context.Background())
+	s.closeWrites = done
+
+	// A single writer drains the pending batches so that the shadow output
+	// receives them in the order they were consumed.
+	s.writesDone.Add(1)
+	go s.writeLoop(writeCtx)
+	return s, nil
+}
+
+func (s *shadowInput) writeLoop(ctx context.Context) {
+	defer s.writesDone.Done()
+	for {
+		var batch service.MessageBatch
+		var open bool
+		select {
+		case batch, open = <-s.pending:
+		case <-ctx.Done():
+			return
+		}
+		if !open {
+			return
+		}
+		if err := s.output.WriteBatch(ctx, batch); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.mError.Incr(1)
+			s.log.Debugf("Failed to write batch to shadow output: %v", err)
+			continue
+		}
+		s.mSent.Incr(1)
+	}
+}
+
+func (s *shadowInput) sampled() bool {
+	if s.percentage >= 100 {
+		return true
+	}
+	return rand.Float64()*100 < s.percentage
+}
+
+func (s *shadowInput) mirror(batch service.MessageBatch) {
+	if !s.sampled() {
+		return
+	}
+	cBatch := make(service.MessageBatch, len(batch))
+	for i, msg := range batch {
+		cBatch[i] = msg.Copy()
+	}
+
+	s.pendingMut.RLock()
+	defer s.pendingMut.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.pending <- cBatch:
+	default:
+		s.mDropped.Incr(1)
+	}
+}
+
+func (s *shadowInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *shadowInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	batch, ackFn, err := s.input.ReadBatch(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mirror(batch)
+	return batch, ackFn, nil
+}
+
+func (s *shadowInput) Close(ctx context.Context) error {
+	s.pendingMut.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.pending)
+	}
+	s.pendingMut.Unlock()
+
+	inErr := s.input.Close(ctx)
+
+	// Pending copies are flushed to the shadow output unless doing so outlasts
+	// the shutdown deadline.
+	flushed := make(chan struct{})
+	go func() {
+		s.writesDone.Wait()
+		close(flushed)
+	}()
+	select {
+	case <-flushed:
+	case <-ctx.Done():
+		s.closeWrites()
+		<-flushed
+	}
+	s.closeWrites()
+
+	if err := s.output.Close(ctx); err != nil {
+		return err
+	}
+	return inErr
+}
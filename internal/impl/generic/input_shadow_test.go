@@ -0,0 +1,212 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fakeShadowReader struct {
+	batches chan service.MessageBatch
+	acks    chan error
+}
+
+func (r *fakeShadowReader) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	select {
+	case b, open := <-r.batches:
+		if !open {
+			return nil, nil, service.ErrEndOfInput
+		}
+		return b, func(ctx context.Context, err error) error {
+			r.acks <- err
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (r *fakeShadowReader) Close(ctx context.Context) error {
+	return nil
+}
+
+type fakeShadowWriter struct {
+	mut     sync.Mutex
+	err     error
+	block   chan struct{}
+	written []string
+	closed  bool
+}
+
+func (w *fakeShadowWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	if w.block != nil {
+		select {
+		case <-w.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	for _, m := range b {
+		mBytes, _ := m.AsBytes()
+		w.written = append(w.written, string(mBytes))
+	}
+	return nil
+}
+
+func (w *fakeShadowWriter) Close(ctx context.Context) error {
+	w.mut.Lock()
+	w.closed = true
+	w.mut.Unlock()
+	return nil
+}
+
+func (w *fakeShadowWriter) writtenSoFar() []string {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return append([]string(nil), w.written...)
+}
+
+func TestShadowInputMirrors(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	reader := &fakeShadowReader{
+		batches: make(chan service.MessageBatch, 2),
+		acks:    make(chan error, 2),
+	}
+	writer := &fakeShadowWriter{}
+
+	s, err := newShadowInput(reader, writer, 100, 10, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, s.Connect(ctx))
+
+	reader.batches <- service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+	}
+	reader.batches <- service.MessageBatch{service.NewMessage([]byte("baz"))}
+	close(reader.batches)
+
+	for _, exp := range [][]string{{"foo", "bar"}, {"baz"}} {
+		b, ackFn, err := s.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.Len(t, b, len(exp))
+		for i, m := range b {
+			mBytes, err := m.AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, exp[i], string(mBytes))
+
+			// Modifications made by the pipeline must not leak into the
+			// mirrored copies.
+			m.SetBytes([]byte("changed"))
+		}
+		require.NoError(t, ackFn(ctx, nil))
+		assert.NoError(t, <-reader.acks)
+	}
+
+	_, _, err = s.ReadBatch(ctx)
+	assert.Equal(t, service.ErrEndOfInput, err)
+
+	require.NoError(t, s.Close(ctx))
+	assert.Equal(t, []string{"foo", "bar", "baz"}, writer.writtenSoFar())
+	assert.True(t, writer.closed)
+}
+
+func TestShadowInputFireAndForget(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	reader := &fakeShadowReader{
+		batches: make(chan service.MessageBatch, 5),
+		acks:    make(chan error, 5),
+	}
+	writer := &fakeShadowWriter{
+		err:   errors.New("nope"),
+		block: make(chan struct{}),
+	}
+
+	s, err := newShadowInput(reader, writer, 100, 1, service.MockResources())
+	require.NoError(t, err)
+
+	// The shadow output is blocked and therefore all but the batches being
+	// written and pending are dropped without blocking reads.
+	for i := 0; i < 5; i++ {
+		reader.batches <- service.MessageBatch{service.NewMessage([]byte("foo"))}
+		_, ackFn, err := s.ReadBatch(ctx)
+		require.NoError(t, err)
+		require.NoError(t, ackFn(ctx, nil))
+		assert.NoError(t, <-reader.acks)
+	}
+
+	close(writer.block)
+	require.NoError(t, s.Close(ctx))
+	assert.Empty(t, writer.writtenSoFar())
+}
+
+func TestShadowInputSampling(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	reader := &fakeShadowReader{
+		batches: make(chan service.MessageBatch, 1),
+		acks:    make(chan error, 1),
+	}
+	writer := &fakeShadowWriter{}
+
+	s, err := newShadowInput(reader, writer, 0, 10, service.MockResources())
+	require.NoError(t, err)
+
+	reader.batches <- service.MessageBatch{service.NewMessage([]byte("foo"))}
+	b, _, err := s.ReadBatch(ctx)
+	require.NoError(t, err)
+	assert.Len(t, b, 1)
+
+	require.NoError(t, s.Close(ctx))
+	assert.Empty(t, writer.writtenSoFar())
+
+	_, err = newShadowInput(reader, writer, 101, 10, service.MockResources())
+	require.EqualError(t, err, "sample_percentage must be between 0 and 100")
+}
+
+func TestShadowInputConfig(t *testing.T) {
+	conf, err := shadowInputConfig().ParseYAML(`
+sample_percentage: 50
+input:
+  generate:
+    mapping: 'root = "hello world"'
+    count: 1
+    interval: ""
+output:
+  drop: {}
+`, nil)
+	require.NoError(t, err)
+
+	s, err := newShadowInputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	assert.Equal(t, 50.0, s.percentage)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	b, ackFn, err := s.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, b, 1)
+	mBytes, err := b[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(mBytes))
+	require.NoError(t, ackFn(ctx, nil))
+
+	require.NoError(t, s.Close(ctx))
+}
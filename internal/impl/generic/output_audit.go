@@ -0,0 +1,248 @@
+package generic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/OneOfOne/xxhash"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	aoFieldOutput        = "output"
+	aoFieldAuditOutput   = "audit_output"
+	aoFieldComponent     = "component"
+	aoFieldKey           = "key"
+	aoFieldHash          = "hash"
+	aoFieldAuditRequired = "audit_required"
+	aoFieldMaxInFlight   = "max_in_flight"
+	aoFieldBatching      = "batching"
+)
+
+func auditOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Writes batches to a child output and emits an audit record for every message written, or that failed to be written, to a separate audit output.").
+		Description(`
+Each attempt to write a batch to the child output results in an audit record for each message of the batch, which is written to the `+"`audit_output`"+` independently of the data itself. This allows compliance pipelines to prove the delivery of data without storing the data twice, as the records only contain a digest of the contents of each message.
+
+Audit records are JSON documents of the following form:
+
+`+"```json"+`
+{
+  "timestamp": "2022-03-01T12:00:00.000000001Z",
+  "component": "s3_archive",
+  "key": "foo",
+  "content_hash": "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+  "status": "delivered"
+}
+`+"```"+`
+
+Where `+"`status`"+` is either `+"`delivered`"+` or `+"`failed`"+`, and failed records have an additional `+"`error`"+` field describing why the write failed. A batch that fails to be written is retried by the pipeline, and each attempt emits its own records.
+
+By default audit records that fail to be written are logged and don't affect the delivery of data. When `+"`audit_required`"+` is set to `+"`true`"+` a batch is instead only acknowledged once its audit records are written, and a failure to write them results in the batch being rejected and retried, including the write to the child output.`).
+		Field(service.NewOutputField(aoFieldOutput).
+			Description("The child output to write batches to.")).
+		Field(service.NewOutputField(aoFieldAuditOutput).
+			Description("An output to write audit records to.")).
+		Field(service.NewStringField(aoFieldComponent).
+			Description("A name identifying the child output within audit records. When empty the label of this output is used.").
+			Default("")).
+		Field(service.NewInterpolatedStringField(aoFieldKey).
+			Description("An identifier of each message to include in its audit record.").
+			Default("").
+			Example(`${! meta("kafka_key") }`).
+			Example(`${! json("id") }`)).
+		Field(service.NewStringEnumField(aoFieldHash, "sha256", "xxhash64").
+			Description("The algorithm used for the digests of message contents.").
+			Default("sha256").
+			Advanced()).
+		Field(service.NewBoolField(aoFieldAuditRequired).
+			Description("Whether batches should only be acknowledged once their audit records have been written.").
+			Default(false).
+			Advanced()).
+		Field(service.NewIntField(aoFieldMaxInFlight).
+			Description("The maximum number of batches to have in flight at a given time.").
+			Default(1)).
+		Field(service.NewBatchPolicyField(aoFieldBatching)).
+		Example("Auditing Deliveries", `
+Here we archive documents to S3 and record each delivery within a Kafka topic, keyed by the ID of each document:`,
+			`
+output:
+  label: s3_archive
+  audit:
+    key: ${! json("id") }
+    audit_required: true
+    output:
+      aws_s3:
+        bucket: my-bucket
+        path: ${! json("id") }.json
+    audit_output:
+      kafka:
+        addresses: [ localhost:9092 ]
+        topic: deliveries
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("audit", auditOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt(aoFieldMaxInFlight); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy(aoFieldBatching); err != nil {
+				return
+			}
+			output, err = newAuditOutputFromConfig(conf, mgr.Label(), mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type auditOutput struct {
+	child         *service.OwnedOutput
+	auditOut      *service.OwnedOutput
+	component     string
+	key           *service.InterpolatedString
+	hashName      string
+	newHash       func() hash.Hash
+	auditRequired bool
+	log           *service.Logger
+
+	nowFn func() time.Time
+}
+
+func newAuditOutputFromConfig(conf *service.ParsedConfig, label string, log *service.Logger) (*auditOutput, error) {
+	a := &auditOutput{
+		log:   log,
+		nowFn: time.Now,
+	}
+
+	var err error
+	if a.component, err = conf.FieldString(aoFieldComponent); err != nil {
+		return nil, err
+	}
+	if a.component == "" {
+		a.component = label
+	}
+	if a.key, err = conf.FieldInterpolatedString(aoFieldKey); err != nil {
+		return nil, err
+	}
+
+	if a.hashName, err = conf.FieldString(aoFieldHash); err != nil {
+		return nil, err
+	}
+	switch a.hashName {
+	case "sha256":
+		a.newHash = sha256.New
+	case "xxhash64":
+		a.newHash = func() hash.Hash {
+			return xxhash.New64()
+		}
+	default:
+		return nil, fmt.Errorf("hash algorithm not recognised: %v", a.hashName)
+	}
+
+	if a.auditRequired, err = conf.FieldBool(aoFieldAuditRequired); err != nil {
+		return nil, err
+	}
+	if a.child, err = conf.FieldOutput(aoFieldOutput); err != nil {
+		return nil, err
+	}
+	if a.auditOut, err = conf.FieldOutput(aoFieldAuditOutput); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *auditOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+type auditRecord struct {
+	Timestamp   string `json:"timestamp"`
+	Component   string `json:"component"`
+	Key         string `json:"key,omitempty"`
+	ContentHash string `json:"content_hash"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// auditBatch creates a batch of audit records for a batch of messages that
+// were written with a given result.
+func (a *auditOutput) auditBatch(batch service.MessageBatch, writeErr error) (service.MessageBatch, error) {
+	timestamp := a.nowFn().UTC().Format(time.RFC3339Nano)
+
+	records := make(service.MessageBatch, len(batch))
+	for i, m := range batch {
+		content, err := m.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		h := a.newHash()
+		_, _ = h.Write(content)
+
+		record := auditRecord{
+			Timestamp:   timestamp,
+			Component:   a.component,
+			Key:         batch.InterpolatedString(i, a.key),
+			ContentHash: a.hashName + ":" + hex.EncodeToString(h.Sum(nil)),
+			Status:      "delivered",
+		}
+		if writeErr != nil {
+			record.Status = "failed"
+			record.Error = writeErr.Error()
+		}
+
+		recordBytes, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = service.NewMessage(recordBytes)
+	}
+	return records, nil
+}
+
+func (a *auditOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	writeErr := a.child.WriteBatch(ctx, batch)
+
+	records, err := a.auditBatch(batch, writeErr)
+	if err == nil {
+		err = a.auditOut.WriteBatch(ctx, records)
+	}
+	if err != nil {
+		if a.auditRequired {
+			if writeErr != nil {
+				return writeErr
+			}
+			return fmt.Errorf("failed to write audit records: %w", err)
+		}
+		a.log.Errorf("Failed to write audit records: %v", err)
+	}
+	return writeErr
+}
+
+func (a *auditOutput) Close(ctx context.Context) error {
+	if err := a.child.Close(ctx); err != nil {
+		return err
+	}
+	return a.auditOut.Close(ctx)
+}
@@ -0,0 +1,143 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testAuditOutput(t *testing.T, confStr string) (*auditOutput, string) {
+	t.Helper()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	conf, err := auditOutputConfig().ParseYAML(confStr+`
+audit_output:
+  file:
+    path: `+auditPath+`
+    codec: lines
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newAuditOutputFromConfig(conf, "foo_label", nil)
+	require.NoError(t, err)
+	out.nowFn = func() time.Time {
+		return time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() {
+		require.NoError(t, out.Close(context.Background()))
+	})
+	return out, auditPath
+}
+
+func readAuditRecords(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var r map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &r))
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestAuditOutputDelivered(t *testing.T) {
+	out, auditPath := testAuditOutput(t, `
+key: ${! meta("id") }
+output:
+  drop: {}
+`)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+	}
+	batch[0].MetaSet("id", "a")
+	batch[1].MetaSet("id", "b")
+
+	require.NoError(t, out.WriteBatch(context.Background(), batch))
+
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"timestamp":    "2022-03-01T12:00:00Z",
+			"component":    "foo_label",
+			"key":          "a",
+			"content_hash": "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			"status":       "delivered",
+		},
+		{
+			"timestamp":    "2022-03-01T12:00:00Z",
+			"component":    "foo_label",
+			"key":          "b",
+			"content_hash": "sha256:fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9",
+			"status":       "delivered",
+		},
+	}, readAuditRecords(t, auditPath))
+}
+
+func TestAuditOutputFailed(t *testing.T) {
+	out, auditPath := testAuditOutput(t, `
+component: archive
+hash: xxhash64
+output:
+  reject: nope
+`)
+
+	err := out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+	})
+	require.Error(t, err)
+
+	records := readAuditRecords(t, auditPath)
+	require.Len(t, records, 1)
+	assert.Equal(t, "archive", records[0]["component"])
+	assert.NotContains(t, records[0], "key")
+	assert.Equal(t, "failed", records[0]["status"])
+	assert.Contains(t, records[0]["error"], "nope")
+	assert.True(t, strings.HasPrefix(records[0]["content_hash"].(string), "xxhash64:"))
+}
+
+func TestAuditOutputRequired(t *testing.T) {
+	conf, err := auditOutputConfig().ParseYAML(`
+audit_required: true
+output:
+  drop: {}
+audit_output:
+  reject: nope
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newAuditOutputFromConfig(conf, "", nil)
+	require.NoError(t, err)
+	defer out.Close(context.Background())
+
+	err = out.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("foo"))})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write audit records")
+
+	conf, err = auditOutputConfig().ParseYAML(`
+output:
+  drop: {}
+audit_output:
+  reject: nope
+`, nil)
+	require.NoError(t, err)
+
+	out, err = newAuditOutputFromConfig(conf, "", nil)
+	require.NoError(t, err)
+	defer out.Close(context.Background())
+
+	require.NoError(t, out.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("foo"))}))
+}
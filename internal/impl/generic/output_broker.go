@@ -90,7 +90,7 @@ outputs.`,
 				"fan_out", "fan_out_sequential", "round_robin", "greedy",
 			).HasDefault("fan_out"),
 			docs.FieldOutput("outputs", "A list of child outputs to broker.").Array().HasDefault([]interface{}{}),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		),
 		Categories: []string{
 			"Utility",
@@ -153,7 +153,7 @@ func newBroker(conf ooutput.Config, mgr bundle.NewManagement, pipelines ...proce
 					return nil, err
 				}
 			}
-			outputs[j*len(outputConfs)+i] = tmpOut
+			outputs[j*len(outputConfs)+i] = trackPendingOutput(tmpOut, oMgr.Metrics())
 		}
 	}
 
@@ -175,7 +175,7 @@ func newBroker(conf ooutput.Config, mgr bundle.NewManagement, pipelines ...proce
 	}
 
 	if !conf.Broker.Batching.IsNoop() {
-		policy, err := policy.New(conf.Broker.Batching, mgr.IntoPath("broker", "batching"))
+		policy, err := policy.NewOutput(conf.Broker.Batching, mgr.IntoPath("broker", "batching"))
 		if err != nil {
 			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
 		}
@@ -0,0 +1,92 @@
+package generic
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// pendingTrackedOutput wraps a child output of a broker and tracks the number
+// of transactions that have been sent to it and are yet to be acknowledged.
+type pendingTrackedOutput struct {
+	wrapped  output.Streamed
+	mPending metrics.StatGauge
+
+	transactionsIn  <-chan message.Transaction
+	transactionsOut chan message.Transaction
+
+	shutSig *shutdown.Signaller
+}
+
+func trackPendingOutput(wrapped output.Streamed, stats metrics.Type) *pendingTrackedOutput {
+	return &pendingTrackedOutput{
+		wrapped:         wrapped,
+		mPending:        stats.GetGauge("output_broker_pending"),
+		transactionsOut: make(chan message.Transaction),
+		shutSig:         shutdown.NewSignaller(),
+	}
+}
+
+func (p *pendingTrackedOutput) loop() {
+	defer close(p.transactionsOut)
+
+	for {
+		var ts message.Transaction
+		var open bool
+		select {
+		case ts, open = <-p.transactionsIn:
+			if !open {
+				return
+			}
+		case <-p.shutSig.CloseAtLeisureChan():
+			return
+		}
+
+		p.mPending.Incr(1)
+		var decrOnce sync.Once
+		tracked := message.NewTransactionFunc(ts.Payload, func(ctx context.Context, err error) error {
+			decrOnce.Do(func() {
+				p.mPending.Decr(1)
+			})
+			return ts.Ack(ctx, err)
+		})
+
+		select {
+		case p.transactionsOut <- tracked:
+		case <-p.shutSig.CloseAtLeisureChan():
+			p.mPending.Decr(1)
+			return
+		}
+	}
+}
+
+func (p *pendingTrackedOutput) Consume(ts <-chan message.Transaction) error {
+	if p.transactionsIn != nil {
+		return component.ErrAlreadyStarted
+	}
+	if err := p.wrapped.Consume(p.transactionsOut); err != nil {
+		return err
+	}
+	p.transactionsIn = ts
+	go p.loop()
+	return nil
+}
+
+func (p *pendingTrackedOutput) Connected() bool {
+	return p.wrapped.Connected()
+}
+
+func (p *pendingTrackedOutput) CloseAsync() {
+	p.shutSig.CloseAtLeisure()
+	p.wrapped.CloseAsync()
+}
+
+func (p *pendingTrackedOutput) WaitForClose(timeout time.Duration) error {
+	return p.wrapped.WaitForClose(timeout)
+}
@@ -0,0 +1,65 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestBrokerPendingGauge(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	stats := metrics.NewLocal()
+	child := &mock.OutputChanneled{}
+
+	out := trackPendingOutput(child, stats)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, out.Consume(tChan))
+
+	resChan := make(chan error, 2)
+	var received []message.Transaction
+	for i := 0; i < 2; i++ {
+		select {
+		case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+		select {
+		case tran := <-child.TChan:
+			received = append(received, tran)
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+	assert.Equal(t, int64(2), stats.GetCounters()["output_broker_pending"])
+
+	require.NoError(t, received[0].Ack(ctx, nil))
+	require.NoError(t, <-resChan)
+	assert.Equal(t, int64(1), stats.GetCounters()["output_broker_pending"])
+
+	// Acknowledging the same transaction twice only decrements the gauge once.
+	require.NoError(t, received[0].Ack(ctx, nil))
+	<-resChan
+	assert.Equal(t, int64(1), stats.GetCounters()["output_broker_pending"])
+
+	require.NoError(t, received[1].Ack(ctx, nil))
+	require.NoError(t, <-resChan)
+	assert.Equal(t, int64(0), stats.GetCounters()["output_broker_pending"])
+
+	close(tChan)
+	select {
+	case _, open := <-child.TChan:
+		assert.False(t, open)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+}
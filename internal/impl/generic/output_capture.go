@@ -0,0 +1,156 @@
+package generic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	coFieldPath = "path"
+)
+
+func captureOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Records messages along with the time at which they were written into a compact capture file, which can be replayed with the [`replay` input](/docs/components/inputs/replay).").
+		Description(`
+Capturing a sample of production traffic and replaying it locally with its original timing is a convenient way to reproduce incidents that depend on the shape of the traffic as well as its contents. This output records the contents and metadata of each message, along with the time at which it was written, in a binary capture file that is far more compact than a JSON representation of the same data.
+
+The capture file is truncated when the output connects, and records are flushed to the file as each message is written.`).
+		Field(service.NewStringField(coFieldPath).
+			Description("The path of the capture file to write.").
+			Example("./incident.bcap")).
+		Example("Capturing Traffic", `
+Here we record the traffic of a Kafka topic whilst also processing it as normal:`,
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ orders ]
+    consumer_group: benthos_orders
+
+output:
+  broker:
+    pattern: fan_out
+    outputs:
+      - http_client:
+          url: http://localhost:8080/orders
+      - capture:
+          path: ./orders.bcap
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"capture", captureOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.Output, maxInFlight int, err error) {
+			out, err = newCaptureOutputFromConfig(conf)
+			maxInFlight = 1
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type captureOutput struct {
+	path  string
+	nowFn func() time.Time
+
+	mut  sync.Mutex
+	file *os.File
+	enc  *captureEncoder
+}
+
+func newCaptureOutputFromConfig(conf *service.ParsedConfig) (*captureOutput, error) {
+	path, err := conf.FieldString(coFieldPath)
+	if err != nil {
+		return nil, err
+	}
+	return &captureOutput{
+		path:  path,
+		nowFn: time.Now,
+	}, nil
+}
+
+func (c *captureOutput) Connect(ctx context.Context) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.file != nil {
+		return nil
+	}
+
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(c.path)
+	if err != nil {
+		return err
+	}
+	enc, err := newCaptureEncoder(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	c.file, c.enc = file, enc
+	return nil
+}
+
+func (c *captureOutput) Write(ctx context.Context, msg *service.Message) error {
+	content, err := msg.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	record := captureRecord{
+		timestamp: c.nowFn(),
+		metadata:  map[string]string{},
+		content:   content,
+	}
+	_ = msg.MetaWalk(func(k, v string) error {
+		record.metadata[k] = v
+		return nil
+	})
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.enc == nil {
+		return service.ErrNotConnected
+	}
+	if err := c.enc.Encode(record); err != nil {
+		return err
+	}
+	return c.enc.Flush()
+}
+
+func (c *captureOutput) Close(ctx context.Context) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+
+	err := c.enc.Flush()
+	if cErr := c.file.Close(); err == nil {
+		err = cErr
+	}
+	c.file, c.enc = nil, nil
+	return err
+}
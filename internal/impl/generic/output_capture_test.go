@@ -0,0 +1,108 @@
+package generic
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestCaptureOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo", "capture.bcap")
+
+	conf, err := captureOutputConfig().ParseYAML(`path: `+path, nil)
+	require.NoError(t, err)
+
+	out, err := newCaptureOutputFromConfig(conf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.Equal(t, service.ErrNotConnected, out.Write(ctx, service.NewMessage([]byte("nope"))))
+	require.NoError(t, out.Connect(ctx))
+
+	now := time.Unix(1600000000, 0)
+	out.nowFn = func() time.Time {
+		now = now.Add(time.Millisecond * 250)
+		return now
+	}
+
+	msgA := service.NewMessage([]byte("hello world"))
+	msgA.MetaSet("foo", "bar")
+	msgA.MetaSet("baz", "buz")
+	require.NoError(t, out.Write(ctx, msgA))
+	require.NoError(t, out.Write(ctx, service.NewMessage(nil)))
+	require.NoError(t, out.Write(ctx, service.NewMessage([]byte("third"))))
+	require.NoError(t, out.Close(ctx))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	dec, err := newCaptureDecoder(file)
+	require.NoError(t, err)
+
+	var records []captureRecord
+	for {
+		r, err := dec.Decode()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		records = append(records, r)
+	}
+
+	require.Len(t, records, 3)
+
+	assert.Equal(t, "hello world", string(records[0].content))
+	assert.Equal(t, map[string]string{"foo": "bar", "baz": "buz"}, records[0].metadata)
+	assert.True(t, time.Unix(1600000000, 250000000).Equal(records[0].timestamp))
+
+	assert.Empty(t, records[1].content)
+	assert.Empty(t, records[1].metadata)
+	assert.True(t, time.Unix(1600000000, 500000000).Equal(records[1].timestamp))
+
+	assert.Equal(t, "third", string(records[2].content))
+	assert.True(t, time.Unix(1600000000, 750000000).Equal(records[2].timestamp))
+}
+
+func TestCaptureDecoderErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.bcap")
+
+	require.NoError(t, os.WriteFile(path, []byte("not a capture file"), 0o644))
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	_, err = newCaptureDecoder(file)
+	file.Close()
+	require.EqualError(t, err, "file is not a capture file")
+
+	file, err = os.Create(path)
+	require.NoError(t, err)
+	enc, err := newCaptureEncoder(file)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(captureRecord{
+		timestamp: time.Unix(10, 0),
+		content:   []byte("hello world"),
+	}))
+	require.NoError(t, enc.Flush())
+	require.NoError(t, file.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, b[:len(b)-3], 0o644))
+
+	file, err = os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	dec, err := newCaptureDecoder(file)
+	require.NoError(t, err)
+	_, err = dec.Decode()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,251 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func checkpointOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Writes batches to a child output and, only once a batch has been written successfully, persists the checkpoints of the batch to a cache.").
+		Description(`
+This output commits in two phases: each batch is first written to the child output, and once the write has succeeded the latest checkpoint of each key within the batch is persisted to a `+"[cache resource](/docs/components/caches/about)"+`. A checkpoint is therefore never persisted for data that hasn't been written, and inputs that resume from the persisted checkpoints after a restart don't skip any data.
+
+By default the key and value of each checkpoint are the topic partition and offset of messages consumed from Kafka, in the form `+"`<topic>:<partition>`"+` and `+"`<offset>`"+`, but any data can be checkpointed by changing the `+"`key`"+` and `+"`value`"+` fields.
+
+### Resuming Inputs
+
+The `+"[`kafka_franz` input](/docs/components/inputs/kafka_franz)"+` has a field `+"`checkpoint_cache`"+`, which when set to the same cache resumes the partitions assigned to it from the offsets persisted by this output rather than those committed to the consumer group. Other inputs can be resumed by reading checkpoints from the cache, for example with a `+"[`cache` processor](/docs/components/processors/cache)"+` or a `+"[`branch` processor](/docs/components/processors/branch)"+` on startup.
+
+### Delivery Guarantees
+
+Batches are written one at a time, and when either the write of a batch or the persisting of its checkpoints fails it's retried according to `+"`backoff`"+` before any subsequent batch is written. This guarantees that a checkpoint is never persisted before the data preceding it has been written, which would otherwise be possible when a failed batch is rejected and retried after later batches. If a maximum elapsed time is configured then batches that exceed it are rejected and this guarantee no longer holds.
+
+When the child output writes data with deterministic identifiers, such as object storage paths derived from the checkpoints of messages, writes that are repeated after a failure overwrite the same data and the pipeline is effectively-once.
+
+When a value is an integer it's only persisted when larger than the last value persisted for the same key, which prevents checkpoints from moving backwards when an input rewinds to data that has already been written. Other values are persisted in the order that batches are written.`).
+		Field(service.NewOutputField("output").
+			Description("The child output to write batches to.")).
+		Field(service.NewStringField("cache").
+			Description("A [cache resource](/docs/components/caches/about) to persist checkpoints to.")).
+		Field(service.NewInterpolatedStringField("key").
+			Description("The key of the checkpoint of each message. Messages resulting in an empty key are not checkpointed.").
+			Default(`${! meta("kafka_topic") }:${! meta("kafka_partition") }`)).
+		Field(service.NewInterpolatedStringField("value").
+			Description("The value of the checkpoint of each message, where the value of the last message of a batch with a given key is persisted. Messages resulting in an empty value are not checkpointed.").
+			Default(`${! meta("kafka_offset") }`)).
+		Field(service.NewBackOffField("backoff", true, &backoff.ExponentialBackOff{
+			InitialInterval: time.Millisecond * 500,
+			MaxInterval:     time.Second * 10,
+		}).Advanced()).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Kafka to S3", `
+Here we write messages consumed from Kafka to S3, naming each object by the topic partition and offset of the message. Offsets are persisted to Redis once each batch of objects has been written, and partitions are resumed from the persisted offsets when they're assigned, so that a restart neither skips data nor creates duplicate objects:`,
+			`
+input:
+  kafka_franz:
+    seed_brokers: [ localhost:9092 ]
+    topics: [ events ]
+    consumer_group: benthos_s3
+    checkpoint_cache: offsets
+
+output:
+  checkpoint:
+    cache: offsets
+    batching:
+      count: 100
+      period: 1s
+    output:
+      aws_s3:
+        bucket: my-bucket
+        path: events/${! meta("kafka_partition") }/${! meta("kafka_offset") }.json
+
+cache_resources:
+  - label: offsets
+    redis:
+      url: redis://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("checkpoint", checkpointOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			// Batches are written one at a time in order to guarantee that
+			// checkpoints are persisted in order.
+			maxInFlight = 1
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newCheckpointOutputFromConfig(conf, mgr, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type checkpointOutput struct {
+	mgr   cacheProvider
+	cache string
+	key   *service.InterpolatedString
+	value *service.InterpolatedString
+	child *service.OwnedOutput
+	log   *service.Logger
+
+	mut       sync.Mutex
+	backoff   *backoff.ExponentialBackOff
+	persisted map[string]string
+}
+
+func newCheckpointOutputFromConfig(conf *service.ParsedConfig, mgr cacheProvider, log *service.Logger) (*checkpointOutput, error) {
+	c := &checkpointOutput{
+		mgr:       mgr,
+		log:       log,
+		persisted: map[string]string{},
+	}
+
+	var err error
+	if c.cache, err = conf.FieldString("cache"); err != nil {
+		return nil, err
+	}
+	if c.key, err = conf.FieldInterpolatedString("key"); err != nil {
+		return nil, err
+	}
+	if c.value, err = conf.FieldInterpolatedString("value"); err != nil {
+		return nil, err
+	}
+	if c.backoff, err = conf.FieldBackOff("backoff"); err != nil {
+		return nil, err
+	}
+	if c.child, err = conf.FieldOutput("output"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// checkpointSupersedes returns whether a checkpoint value should replace a
+// previous value of the same key, which is always the case unless both values
+// are integers.
+func checkpointSupersedes(value, prev string) bool {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return true
+	}
+	p, err := strconv.ParseInt(prev, 10, 64)
+	if err != nil {
+		return true
+	}
+	return v > p
+}
+
+func (c *checkpointOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// persist writes checkpoints to the cache.
+func (c *checkpointOutput) persist(ctx context.Context, checkpoints map[string]string) error {
+	keys := make([]string, 0, len(checkpoints))
+	for k := range checkpoints {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := checkpoints[key]
+		if prev, exists := c.persisted[key]; exists && !checkpointSupersedes(value, prev) {
+			continue
+		}
+
+		var setErr error
+		if err := c.mgr.AccessCache(ctx, c.cache, func(cache service.Cache) {
+			setErr = cache.Set(ctx, key, []byte(value), nil)
+		}); err != nil {
+			return fmt.Errorf("unable to access cache '%v': %w", c.cache, err)
+		}
+		if setErr != nil {
+			return fmt.Errorf("failed to persist checkpoint '%v': %w", key, setErr)
+		}
+		c.persisted[key] = value
+	}
+	return nil
+}
+
+// retry attempts a function until it succeeds, the back off policy is
+// exhausted or the context is cancelled.
+func (c *checkpointOutput) retry(ctx context.Context, desc string, fn func() error) error {
+	c.backoff.Reset()
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		wait := c.backoff.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		c.log.Warnf("Failed to %v, retrying: %v", desc, err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (c *checkpointOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	checkpoints := map[string]string{}
+	for i := range batch {
+		key := batch.InterpolatedString(i, c.key)
+		if key == "" {
+			continue
+		}
+		value := batch.InterpolatedString(i, c.value)
+		if value == "" {
+			continue
+		}
+		if prev, exists := checkpoints[key]; exists && !checkpointSupersedes(value, prev) {
+			continue
+		}
+		checkpoints[key] = value
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if err := c.retry(ctx, "write batch", func() error {
+		return c.child.WriteBatch(ctx, batch)
+	}); err != nil {
+		return err
+	}
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	return c.retry(ctx, "persist checkpoints", func() error {
+		return c.persist(ctx, checkpoints)
+	})
+}
+
+func (c *checkpointOutput) Close(ctx context.Context) error {
+	return c.child.Close(ctx)
+}
@@ -0,0 +1,148 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type flakyCache struct {
+	service.Cache
+	failures int
+}
+
+func (f *flakyCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("nope")
+	}
+	return f.Cache.Set(ctx, key, value, ttl)
+}
+
+func testCheckpointOutput(t *testing.T, confStr string, caches map[string]service.Cache) *checkpointOutput {
+	t.Helper()
+
+	conf, err := checkpointOutputConfig().ParseYAML(confStr+`
+output:
+  drop: {}
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newCheckpointOutputFromConfig(conf, &mockCacheProv{caches: caches}, nil)
+	require.NoError(t, err)
+	return out
+}
+
+func TestCheckpointOutput(t *testing.T) {
+	cache := newMemCache(time.Minute, 0, 1, nil)
+	out := testCheckpointOutput(t, `
+cache: foo
+`, map[string]service.Cache{"foo": cache})
+
+	msg := func(topic string, partition, offset int64) *service.Message {
+		m := service.NewMessage([]byte("hello world"))
+		m.MetaSet("kafka_topic", topic)
+		m.MetaSetTyped("kafka_partition", partition)
+		m.MetaSetTyped("kafka_offset", offset)
+		return m
+	}
+
+	assertCheckpoint := func(key, exp string) {
+		t.Helper()
+		v, err := cache.Get(context.Background(), key)
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(v))
+	}
+
+	ctx := context.Background()
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		msg("foo", 0, 10),
+		msg("foo", 0, 12),
+		msg("foo", 1, 5),
+		msg("foo", 0, 11),
+	}))
+	assertCheckpoint("foo:0", "12")
+	assertCheckpoint("foo:1", "5")
+
+	// Checkpoints don't move backwards.
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		msg("foo", 0, 3),
+		msg("foo", 1, 6),
+	}))
+	assertCheckpoint("foo:0", "12")
+	assertCheckpoint("foo:1", "6")
+
+	// Messages without checkpoints are written without persisting anything.
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("no checkpoint")),
+	}))
+
+	require.NoError(t, out.Close(ctx))
+}
+
+func TestCheckpointOutputCustomValues(t *testing.T) {
+	cache := newMemCache(time.Minute, 0, 1, nil)
+	out := testCheckpointOutput(t, `
+cache: foo
+key: ${! json("file") }
+value: ${! json("line") }
+`, map[string]service.Cache{"foo": cache})
+
+	ctx := context.Background()
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"file":"a.txt","line":"first"}`)),
+		service.NewMessage([]byte(`{"file":"a.txt","line":"second"}`)),
+	}))
+
+	v, err := cache.Get(ctx, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(v))
+}
+
+func TestCheckpointOutputRetries(t *testing.T) {
+	cache := &flakyCache{
+		Cache:    newMemCache(time.Minute, 0, 1, nil),
+		failures: 2,
+	}
+	out := testCheckpointOutput(t, `
+cache: foo
+key: foo
+value: ${! content() }
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+`, map[string]service.Cache{"foo": cache})
+
+	ctx := context.Background()
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte("bar")),
+	}))
+	assert.Equal(t, 0, cache.failures)
+
+	v, err := cache.Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(v))
+}
+
+func TestCheckpointOutputRetriesExhausted(t *testing.T) {
+	out := testCheckpointOutput(t, `
+cache: bar
+key: foo
+value: ${! content() }
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+  max_elapsed_time: 10ms
+`, map[string]service.Cache{})
+
+	err := out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("bar")),
+	})
+	require.EqualError(t, err, "unable to access cache 'bar': cache not found")
+}
@@ -0,0 +1,258 @@
+package generic
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func compressionOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Compresses batches of messages before writing them to a child output.").
+		Description(`
+Compressing messages with a `+"[`compress` processor](/docs/components/processors/compress)"+` operates on messages individually, and therefore in order to compress a batch as a single object the batch would need to be archived first, which loses the original messages. This output instead serializes each batch it receives according to `+"`format`"+` and compresses the result just before it reaches the child output, which means the batching policy of this output determines the contents of each compressed object.
+
+The resulting message retains the metadata of the first message of the batch, and the metadata field `+"`content_encoding`"+` (configurable with `+"`metadata_key`"+`) is set to the content encoding of the algorithm, which is the standard HTTP token where one exists (`+"`gzip`, and `deflate` for `zlib`"+`) and otherwise the name of the algorithm. This can be used in order to set headers or object properties of the child output, such as the `+"`content_encoding`"+` field of the `+"[`aws_s3` output](/docs/components/outputs/aws_s3)"+`.`).
+		Field(service.NewStringEnumField("algorithm", "gzip", "zlib", "flate", "snappy", "lz4", "zstd").
+			Description("The compression algorithm to use.")).
+		Field(service.NewIntField("level").
+			Description("The level of compression to use. May not be applicable to all algorithms, and the default of `-1` uses the default level of the algorithm. The `lz4` algorithm supports levels from 1 to 9.").
+			Default(-1)).
+		Field(service.NewStringAnnotatedEnumField("format", map[string]string{
+			"lines":       "Join the messages of a batch with newline delimiters and compress the result as a single message.",
+			"concatenate": "Join the messages of a batch without delimiters and compress the result as a single message.",
+			"individual":  "Compress each message of a batch separately, preserving the batch.",
+		}).Description("How batches are serialized before compression.").
+			Default("lines")).
+		Field(service.NewStringField("metadata_key").
+			Description("The metadata key to store the content encoding of compressed messages in. Set to an empty string in order to disable.").
+			Default("content_encoding").
+			Advanced()).
+		Field(service.NewOutputField("output").
+			Description("The child output to write compressed messages to.")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be compressing and sending in parallel at any given time.").
+			Default(1)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Compressed Objects", `
+Here we write gzip compressed objects to S3, where each object contains a batch of up to 1000 newline delimited messages:`,
+			`
+output:
+  compression:
+    algorithm: gzip
+    format: lines
+    batching:
+      count: 1000
+      period: 30s
+    output:
+      aws_s3:
+        bucket: logs
+        path: ${! timestamp_unix_nano() }.jsonl.gz
+        content_encoding: ${! meta("content_encoding") }
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("compression", compressionOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newCompressionOutputFromConfig(conf)
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var lz4Levels = []lz4.CompressionLevel{
+	lz4.Level1, lz4.Level2, lz4.Level3, lz4.Level4, lz4.Level5,
+	lz4.Level6, lz4.Level7, lz4.Level8, lz4.Level9,
+}
+
+type compressionWriterFunc func(w io.Writer, level int) (io.WriteCloser, error)
+
+var compressionAlgorithms = map[string]struct {
+	encoding string
+	writer   compressionWriterFunc
+}{
+	"gzip": {"gzip", func(w io.Writer, level int) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, level)
+	}},
+	"zlib": {"deflate", func(w io.Writer, level int) (io.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, level)
+	}},
+	"flate": {"flate", func(w io.Writer, level int) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	}},
+	"snappy": {"snappy", func(w io.Writer, level int) (io.WriteCloser, error) {
+		return snappy.NewBufferedWriter(w), nil
+	}},
+	"lz4": {"lz4", func(w io.Writer, level int) (io.WriteCloser, error) {
+		zw := lz4.NewWriter(w)
+		if level > 0 {
+			if level > len(lz4Levels) {
+				return nil, fmt.Errorf("lz4 compression level must be between 1 and %v, got %v", len(lz4Levels), level)
+			}
+			if err := zw.Apply(lz4.CompressionLevelOption(lz4Levels[level-1])); err != nil {
+				return nil, err
+			}
+		}
+		return zw, nil
+	}},
+	"zstd": {"zstd", func(w io.Writer, level int) (io.WriteCloser, error) {
+		var opts []zstd.EOption
+		if level > 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	}},
+}
+
+type compressionOutput struct {
+	encoding    string
+	writer      compressionWriterFunc
+	level       int
+	format      string
+	metadataKey string
+	child       *service.OwnedOutput
+}
+
+func newCompressionOutputFromConfig(conf *service.ParsedConfig) (*compressionOutput, error) {
+	c := &compressionOutput{}
+
+	algorithm, err := conf.FieldString("algorithm")
+	if err != nil {
+		return nil, err
+	}
+	alg, exists := compressionAlgorithms[algorithm]
+	if !exists {
+		return nil, fmt.Errorf("compression algorithm not recognised: %v", algorithm)
+	}
+	c.encoding, c.writer = alg.encoding, alg.writer
+
+	if c.level, err = conf.FieldInt("level"); err != nil {
+		return nil, err
+	}
+	// Create a writer up front so that levels not supported by the algorithm
+	// are rejected at config time rather than when writing.
+	if _, err = c.writer(io.Discard, c.level); err != nil {
+		return nil, fmt.Errorf("invalid compression level %v: %w", c.level, err)
+	}
+	if c.format, err = conf.FieldString("format"); err != nil {
+		return nil, err
+	}
+	switch c.format {
+	case "lines", "concatenate", "individual":
+	default:
+		return nil, fmt.Errorf("format not recognised: %v", c.format)
+	}
+	if c.metadataKey, err = conf.FieldString("metadata_key"); err != nil {
+		return nil, err
+	}
+	if c.child, err = conf.FieldOutput("output"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *compressionOutput) compress(parts [][]byte, delim []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := c.writer(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range parts {
+		if i > 0 && len(delim) > 0 {
+			if _, err := w.Write(delim); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := w.Write(p); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *compressionOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (c *compressionOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	parts := make([][]byte, len(batch))
+	for i, m := range batch {
+		var err error
+		if parts[i], err = m.AsBytes(); err != nil {
+			return err
+		}
+	}
+
+	var outBatch service.MessageBatch
+	if c.format == "individual" {
+		for i, m := range batch {
+			b, err := c.compress(parts[i:i+1], nil)
+			if err != nil {
+				return fmt.Errorf("failed to compress message: %w", err)
+			}
+			outMsg := m.Copy()
+			outMsg.SetBytes(b)
+			outBatch = append(outBatch, outMsg)
+		}
+	} else {
+		var delim []byte
+		if c.format == "lines" {
+			delim = []byte("\n")
+		}
+		b, err := c.compress(parts, delim)
+		if err != nil {
+			return fmt.Errorf("failed to compress batch: %w", err)
+		}
+		outMsg := batch[0].Copy()
+		outMsg.SetBytes(b)
+		outBatch = service.MessageBatch{outMsg}
+	}
+
+	if c.metadataKey != "" {
+		for _, m := range outBatch {
+			m.MetaSet(c.metadataKey, c.encoding)
+		}
+	}
+	return c.child.WriteBatch(ctx, outBatch)
+}
+
+func (c *compressionOutput) Close(ctx context.Context) error {
+	return c.child.Close(ctx)
+}
@@ -0,0 +1,131 @@
+package generic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testCompressionOutput(t *testing.T, tmpDir, confStr string) *compressionOutput {
+	t.Helper()
+
+	conf, err := compressionOutputConfig().ParseYAML(fmt.Sprintf(confStr+`
+output:
+  file:
+    path: %v/${! count("%v") }.${! meta("content_encoding") }
+    codec: all-bytes
+`, tmpDir, tmpDir), nil)
+	require.NoError(t, err)
+
+	out, err := newCompressionOutputFromConfig(conf)
+	require.NoError(t, err)
+	return out
+}
+
+func TestCompressionOutputLines(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	out := testCompressionOutput(t, tmpDir, `
+algorithm: gzip
+`)
+	require.NoError(t, out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+		service.NewMessage([]byte("baz")),
+	}))
+	require.NoError(t, out.Close(context.Background()))
+
+	f, err := os.Open(filepath.Join(tmpDir, "1.gzip"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\nbaz", string(b))
+}
+
+func TestCompressionOutputIndividual(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	out := testCompressionOutput(t, tmpDir, `
+algorithm: zstd
+format: individual
+`)
+	require.NoError(t, out.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+	}))
+	require.NoError(t, out.Close(context.Background()))
+
+	for i, exp := range []string{"foo", "bar"} {
+		b, err := os.ReadFile(filepath.Join(tmpDir, fmt.Sprintf("%v.zstd", i+1)))
+		require.NoError(t, err)
+
+		r, err := zstd.NewReader(bytes.NewReader(b))
+		require.NoError(t, err)
+
+		act, err := io.ReadAll(r)
+		r.Close()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(act))
+	}
+}
+
+func TestCompressionOutputAlgorithms(t *testing.T) {
+	for name := range compressionAlgorithms {
+		out := &compressionOutput{
+			writer: compressionAlgorithms[name].writer,
+			level:  -1,
+		}
+		b, err := out.compress([][]byte{[]byte("hello"), []byte("world")}, []byte("\n"))
+		require.NoError(t, err, name)
+		assert.NotEmpty(t, b, name)
+	}
+}
+
+func TestCompressionOutputLZ4Levels(t *testing.T) {
+	for level := 1; level <= 9; level++ {
+		out := &compressionOutput{
+			writer: compressionAlgorithms["lz4"].writer,
+			level:  level,
+		}
+		b, err := out.compress([][]byte{[]byte("hello"), []byte("world")}, []byte("\n"))
+		require.NoError(t, err, level)
+
+		act, err := io.ReadAll(lz4.NewReader(bytes.NewReader(b)))
+		require.NoError(t, err, level)
+		assert.Equal(t, "hello\nworld", string(act), level)
+	}
+
+	for _, level := range []int{0, 10} {
+		conf, err := compressionOutputConfig().ParseYAML(fmt.Sprintf(`
+algorithm: lz4
+level: %v
+output:
+  drop: {}
+`, level), nil)
+		require.NoError(t, err)
+
+		_, err = newCompressionOutputFromConfig(conf)
+		if level == 0 {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
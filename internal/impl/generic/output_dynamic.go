@@ -32,10 +32,27 @@ To GET a JSON map of output identifiers with their current uptimes use the
 To perform CRUD actions on the outputs themselves use POST, DELETE, and GET
 methods on the ` + "`/outputs/{output_id}`" + ` endpoint. When using POST the
 body of the request should be a YAML configuration for the output, if the output
-already exists it will be changed.`,
+already exists it will be changed.
+
+### Persistence
+
+When ` + "`persistence`" + ` is configured the outputs added or removed via
+the REST API are saved to either a file or a cache resource, and are restored
+when Benthos is restarted. Outputs defined statically in the config are not
+persisted.
+
+### Events
+
+A GET request to the ` + "`/outputs`" + ` endpoint with the header
+` + "`Accept: text/event-stream`" + ` opens a stream of
+[server-sent events](https://developer.mozilla.org/en-US/docs/Web/API/Server-sent_events)
+describing changes to outputs as they occur. Each event has a type of
+` + "`started`, `stopped`, `updated` or `deleted`" + ` and a JSON body of the
+form ` + "`{\"type\":\"started\",\"id\":\"foo\",\"timestamp\":\"...\"}`" + `.`,
 			Config: docs.FieldComponent().WithChildren(
 				docs.FieldOutput("outputs", "A map of outputs to statically create.").Map().HasDefault(map[string]interface{}{}),
 				docs.FieldString("prefix", "A path prefix for HTTP endpoints that are registered.").HasDefault(""),
+				dynamicPersistenceFieldSpec("benthos_dynamic_outputs"),
 			),
 			Categories: []string{
 				"Utility",
@@ -49,6 +66,12 @@ already exists it will be changed.`,
 func newDynamicOutput(conf ooutput.Config, mgr bundle.NewManagement) (output.Streamed, error) {
 	dynAPI := api.NewDynamic()
 
+	pConf := conf.Dynamic.Persistence
+	store, err := newDynamicStore(mgr, pConf.Path, pConf.Cache, pConf.Key)
+	if err != nil {
+		return nil, err
+	}
+
 	outputs := map[string]output.Streamed{}
 	for k, v := range conf.Dynamic.Outputs {
 		oMgr := mgr.IntoPath("dynamic", "outputs", k)
@@ -127,6 +150,13 @@ func newDynamicOutput(conf ooutput.Config, mgr bundle.NewManagement) (output.Str
 		return err
 	})
 
+	if store != nil {
+		dynAPI.SetStore(store)
+		if err := dynAPI.Restore(context.Background()); err != nil {
+			mgr.Logger().Errorf("Failed to restore dynamic outputs: %v", err)
+		}
+	}
+
 	mgr.RegisterEndpoint(
 		path.Join(conf.Dynamic.Prefix, "/outputs/{id}"),
 		"Perform CRUD operations on the configuration of dynamic outputs. For"+
@@ -135,7 +165,8 @@ func newDynamicOutput(conf ooutput.Config, mgr bundle.NewManagement) (output.Str
 	)
 	mgr.RegisterEndpoint(
 		path.Join(conf.Dynamic.Prefix, "/outputs"),
-		"Get a map of running output identifiers with their current uptimes.",
+		"Get a map of running output identifiers with their current uptimes, or"+
+			" a stream of events when requested with the header `Accept: text/event-stream`.",
 		dynAPI.HandleList,
 	)
 
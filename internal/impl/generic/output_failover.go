@@ -0,0 +1,329 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func failoverOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Routes all messages to the highest priority healthy output of a list, where the health of each output is tracked with active probes, and switches back to a higher priority output once it has recovered.").
+		Description(`
+Unlike the `+"[`fallback` output](/docs/components/outputs/fallback)"+`, which attempts each output in turn for every message, this output sends all messages to a single active target, which is the first target of the list that is considered healthy. This is useful for directing traffic to a standby region when the primary region is unavailable without paying the cost of a failed attempt for each message.
+
+### Health
+
+Each target with a `+"`probe_url`"+` is probed with an HTTP GET request every `+"`probe_interval`"+`, where a response with a 2XX status code counts as a successful probe. Failed writes to a target also count as failed probes, and therefore targets without a probe URL are marked unhealthy based on their write errors alone. Since such targets cannot be probed, each probe interval in which they are unhealthy counts as a successful probe, which means they are attempted again once they have been unhealthy for `+"`healthy_threshold`"+` intervals.
+
+A healthy target is marked unhealthy after `+"`unhealthy_threshold`"+` consecutive failed probes, and an unhealthy target is marked healthy again after `+"`healthy_threshold`"+` consecutive successful probes. Setting the healthy threshold higher than the unhealthy threshold prevents traffic from flapping between targets when a target is intermittently available. When no targets are healthy the currently active target remains in use.
+
+Writes that fail are returned as errors and therefore messages are reattempted according to the delivery guarantees of the input, by which point they might be routed to a different target.
+
+### Events
+
+Each time the active target changes a log is emitted at the `+"`WARN`"+` level and the counter metric `+"`failover_transitions`"+` is incremented with the labels `+"`from` and `to`"+` set to the indexes of the targets. The gauge metric `+"`failover_active_target`"+` is set to the index of the currently active target.`).
+		Field(service.NewObjectListField("targets",
+			service.NewOutputField("output").
+				Description("The child output to write messages to."),
+			service.NewStringField("probe_url").
+				Description("An optional URL to send HTTP GET requests to in order to probe the health of the target.").
+				Example("http://primary.example.com:4195/ready").
+				Default(""),
+		).Description("A prioritized list of targets, where messages are routed to the first healthy target of the list.")).
+		Field(service.NewDurationField("probe_interval").
+			Description("The period of time between health probes.").
+			Default("5s")).
+		Field(service.NewDurationField("probe_timeout").
+			Description("The maximum period of time to wait for a probe response.").
+			Default("2s")).
+		Field(service.NewIntField("unhealthy_threshold").
+			Description("The number of consecutive failed probes or writes after which a target is marked unhealthy.").
+			Default(3)).
+		Field(service.NewIntField("healthy_threshold").
+			Description("The number of consecutive successful probes after which an unhealthy target is marked healthy.").
+			Default(5)).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have in flight at a given time.").
+			Default(64)).
+		Example("Multi-Region Failover", `
+Here we write messages to a Kafka cluster in our primary region and fail over to a cluster in a secondary region whenever the primary becomes unhealthy, returning to the primary once it has passed ten consecutive probes:`,
+			`
+output:
+  failover:
+    healthy_threshold: 10
+    targets:
+      - probe_url: http://kafka-proxy.eu-west-1.example.com/health
+        output:
+          kafka:
+            addresses: [ kafka.eu-west-1.example.com:9092 ]
+            topic: events
+      - probe_url: http://kafka-proxy.us-east-1.example.com/health
+        output:
+          kafka:
+            addresses: [ kafka.us-east-1.example.com:9092 ]
+            topic: events
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("failover", failoverOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			output, err = newFailoverOutputFromConfig(conf, mgr)
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// failoverWriter is the subset of an owned output used by failover targets.
+type failoverWriter interface {
+	WriteBatch(ctx context.Context, b service.MessageBatch) error
+	Close(ctx context.Context) error
+}
+
+type failoverTarget struct {
+	writer   failoverWriter
+	probeURL string
+
+	healthy             bool
+	failures, successes int
+}
+
+type failoverOutput struct {
+	targets            []*failoverTarget
+	probeInterval      time.Duration
+	unhealthyThreshold int
+	healthyThreshold   int
+
+	client *http.Client
+	log    *service.Logger
+
+	mTransitions  *service.MetricCounter
+	mActiveTarget *service.MetricGauge
+
+	mut    sync.Mutex
+	active int
+
+	probeOnce  sync.Once
+	closeProbe func()
+	probesDone chan struct{}
+}
+
+func newFailoverOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*failoverOutput, error) {
+	targetConfs, err := conf.FieldObjectList("targets")
+	if err != nil {
+		return nil, err
+	}
+	if len(targetConfs) == 0 {
+		return nil, errors.New("at least one target must be specified")
+	}
+
+	targets := make([]*failoverTarget, len(targetConfs))
+	for i, tConf := range targetConfs {
+		t := &failoverTarget{}
+		if t.probeURL, err = tConf.FieldString("probe_url"); err != nil {
+			return nil, err
+		}
+		if t.writer, err = tConf.FieldOutput("output"); err != nil {
+			return nil, fmt.Errorf("target %v: %w", i, err)
+		}
+		targets[i] = t
+	}
+
+	probeInterval, err := conf.FieldDuration("probe_interval")
+	if err != nil {
+		return nil, err
+	}
+	probeTimeout, err := conf.FieldDuration("probe_timeout")
+	if err != nil {
+		return nil, err
+	}
+	unhealthyThreshold, err := conf.FieldInt("unhealthy_threshold")
+	if err != nil {
+		return nil, err
+	}
+	healthyThreshold, err := conf.FieldInt("healthy_threshold")
+	if err != nil {
+		return nil, err
+	}
+	return newFailoverOutput(targets, probeInterval, probeTimeout, unhealthyThreshold, healthyThreshold, mgr)
+}
+
+func newFailoverOutput(
+	targets []*failoverTarget,
+	probeInterval, probeTimeout time.Duration,
+	unhealthyThreshold, healthyThreshold int,
+	mgr *service.Resources,
+) (*failoverOutput, error) {
+	if probeInterval <= 0 {
+		return nil, errors.New("probe_interval must be greater than zero")
+	}
+	if unhealthyThreshold < 1 || healthyThreshold < 1 {
+		return nil, errors.New("unhealthy_threshold and healthy_threshold must be at least 1")
+	}
+	for _, t := range targets {
+		t.healthy = true
+	}
+
+	f := &failoverOutput{
+		targets:            targets,
+		probeInterval:      probeInterval,
+		unhealthyThreshold: unhealthyThreshold,
+		healthyThreshold:   healthyThreshold,
+		client:             &http.Client{Timeout: probeTimeout},
+		log:                mgr.Logger(),
+		mTransitions:       mgr.Metrics().NewCounter("failover_transitions", "from", "to"),
+		mActiveTarget:      mgr.Metrics().NewGauge("failover_active_target"),
+		probesDone:         make(chan struct{}),
+	}
+	f.mActiveTarget.Set(0)
+	return f, nil
+}
+
+// report records the result of a probe or write to a target, and updates the
+// active target when the health of the target changes.
+func (f *failoverOutput) report(index int, ok bool) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	t := f.targets[index]
+	if ok {
+		t.failures = 0
+		t.successes++
+		if t.healthy || t.successes < f.healthyThreshold {
+			return
+		}
+		t.healthy = true
+		f.log.Infof("Failover target %v is healthy", index)
+	} else {
+		t.successes = 0
+		t.failures++
+		if !t.healthy || t.failures < f.unhealthyThreshold {
+			return
+		}
+		t.healthy = false
+		f.log.Warnf("Failover target %v is unhealthy", index)
+	}
+
+	next := f.active
+	for i, t := range f.targets {
+		if t.healthy {
+			next = i
+			break
+		}
+	}
+	if next == f.active {
+		return
+	}
+
+	f.log.Warnf("Failing over from target %v to target %v", f.active, next)
+	f.mTransitions.Incr(1, strconv.Itoa(f.active), strconv.Itoa(next))
+	f.mActiveTarget.Set(int64(next))
+	f.active = next
+}
+
+func (f *failoverOutput) probe(ctx context.Context, t *failoverTarget) bool {
+	req, err := http.NewRequestWithContext(ctx, "GET", t.probeURL, nil)
+	if err != nil {
+		return false
+	}
+	res, err := f.client.Do(req)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+	return res.StatusCode >= 200 && res.StatusCode < 300
+}
+
+func (f *failoverOutput) probeAll(ctx context.Context) {
+	for i, t := range f.targets {
+		if t.probeURL != "" {
+			f.report(i, f.probe(ctx, t))
+			continue
+		}
+
+		f.mut.Lock()
+		healthy := t.healthy
+		f.mut.Unlock()
+		if !healthy {
+			f.report(i, true)
+		}
+	}
+}
+
+func (f *failoverOutput) probeLoop(ctx context.Context) {
+	defer close(f.probesDone)
+
+	ticker := time.NewTicker(f.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		f.probeAll(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (f *failoverOutput) Connect(ctx context.Context) error {
+	f.probeOnce.Do(func() {
+		probeCtx, done := context.WithCancel(context.Background())
+		f.closeProbe = done
+		go f.probeLoop(probeCtx)
+	})
+	return nil
+}
+
+func (f *failoverOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	f.mut.Lock()
+	index := f.active
+	f.mut.Unlock()
+
+	err := f.targets[index].writer.WriteBatch(ctx, batch)
+	f.report(index, err == nil)
+	return err
+}
+
+func (f *failoverOutput) Close(ctx context.Context) error {
+	f.probeOnce.Do(func() {
+		close(f.probesDone)
+	})
+	if f.closeProbe != nil {
+		f.closeProbe()
+	}
+	select {
+	case <-f.probesDone:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	for _, t := range f.targets {
+		if err := t.writer.Close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fakeFailoverWriter struct {
+	mut     sync.Mutex
+	err     error
+	written int
+}
+
+func (w *fakeFailoverWriter) setErr(err error) {
+	w.mut.Lock()
+	w.err = err
+	w.mut.Unlock()
+}
+
+func (w *fakeFailoverWriter) count() int {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	return w.written
+}
+
+func (w *fakeFailoverWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.written += len(b)
+	return nil
+}
+
+func (w *fakeFailoverWriter) Close(ctx context.Context) error {
+	return nil
+}
+
+func testFailoverWrite(t *testing.T, f *failoverOutput) error {
+	t.Helper()
+	return f.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))})
+}
+
+func TestFailoverWriteErrors(t *testing.T) {
+	primary, secondary := &fakeFailoverWriter{}, &fakeFailoverWriter{}
+
+	f, err := newFailoverOutput([]*failoverTarget{
+		{writer: primary},
+		{writer: secondary},
+	}, time.Hour, time.Second, 2, 3, service.MockResources())
+	require.NoError(t, err)
+
+	require.NoError(t, testFailoverWrite(t, f))
+	assert.Equal(t, 1, primary.count())
+
+	primary.setErr(errors.New("nope"))
+	require.Error(t, testFailoverWrite(t, f))
+	require.Error(t, testFailoverWrite(t, f))
+
+	require.NoError(t, testFailoverWrite(t, f))
+	require.NoError(t, testFailoverWrite(t, f))
+	assert.Equal(t, 1, primary.count())
+	assert.Equal(t, 2, secondary.count())
+
+	// Unprobed targets recover after healthy_threshold probe intervals.
+	primary.setErr(nil)
+	f.probeAll(context.Background())
+	f.probeAll(context.Background())
+	require.NoError(t, testFailoverWrite(t, f))
+	assert.Equal(t, 3, secondary.count())
+
+	f.probeAll(context.Background())
+	require.NoError(t, testFailoverWrite(t, f))
+	assert.Equal(t, 2, primary.count())
+	assert.Equal(t, 3, secondary.count())
+}
+
+func TestFailoverProbes(t *testing.T) {
+	var primaryDown int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&primaryDown) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	primary, secondary, tertiary := &fakeFailoverWriter{}, &fakeFailoverWriter{}, &fakeFailoverWriter{}
+
+	f, err := newFailoverOutput([]*failoverTarget{
+		{writer: primary, probeURL: server.URL},
+		{writer: secondary, probeURL: server.URL + "/nope"},
+		{writer: tertiary},
+	}, time.Hour, time.Second, 1, 2, service.MockResources())
+	require.NoError(t, err)
+
+	atomic.StoreInt32(&primaryDown, 1)
+	f.probeAll(context.Background())
+
+	// Both probed targets fail and therefore the first target without a
+	// probe is active.
+	require.NoError(t, testFailoverWrite(t, f))
+	assert.Equal(t, 1, tertiary.count())
+
+	atomic.StoreInt32(&primaryDown, 0)
+	f.probeAll(context.Background())
+	require.NoError(t, testFailoverWrite(t, f))
+	assert.Equal(t, 2, tertiary.count())
+
+	f.probeAll(context.Background())
+	require.NoError(t, testFailoverWrite(t, f))
+	assert.Equal(t, 1, primary.count())
+	assert.Equal(t, 0, secondary.count())
+}
+
+func TestFailoverOutputConfig(t *testing.T) {
+	conf, err := failoverOutputConfig().ParseYAML(`
+probe_interval: 10ms
+targets:
+  - output:
+      drop: {}
+  - output:
+      drop: {}
+`, nil)
+	require.NoError(t, err)
+
+	f, err := newFailoverOutputFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	require.Len(t, f.targets, 2)
+
+	require.NoError(t, f.Connect(context.Background()))
+	require.NoError(t, testFailoverWrite(t, f))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	require.NoError(t, f.Close(ctx))
+
+	conf, err = failoverOutputConfig().ParseYAML(`
+targets: []
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newFailoverOutputFromConfig(conf, service.MockResources())
+	require.EqualError(t, err, "at least one target must be specified")
+}
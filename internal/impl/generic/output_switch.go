@@ -4,14 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/gabs/v2"
+	"github.com/OneOfOne/xxhash"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -90,6 +94,40 @@ behavior is false, which will drop the message.`,
 					`this.type == "foo"`,
 					`this.contents.urls.contains("https://benthos.dev/")`,
 				).HasDefault(""),
+				docs.FieldObject(
+					"rollout",
+					"Restricts the case to a percentage of the messages that pass its check, which is useful for gradually rolling out a new output. When a `key` is specified each message is assigned to the rollout by a hash of its key, and therefore messages that share a key are consistently routed the same way.",
+				).WithChildren(
+					docs.FieldFloat(
+						"percentage", "The percentage of messages, between 0 and 100, to route to the case. When set to 0 the rollout is disabled and all messages that pass the check are routed to the case.",
+						5, 50,
+					).HasDefault(0),
+					docs.FieldInterpolatedString(
+						"key", "An optional key to hash in order to decide deterministically whether a message is within the rollout. If left empty messages are selected at random.",
+						`${! meta("kafka_key") }`, `${! json("user.id") }`,
+					).HasDefault(""),
+				).Advanced(),
+				docs.FieldObject(
+					"window",
+					"Restricts the case to a window of time, outside of which it does not match any messages, which is useful for redirecting messages during maintenance windows. The `start` and `end` of the window can either be absolute timestamps in RFC 3339 format, or times of the day in the format `15:04` for a window that recurs daily. A daily window with an end earlier than its start spans midnight.",
+				).WithChildren(
+					docs.FieldString(
+						"start", "The beginning of the window, inclusive. When left empty the window is disabled.",
+						"22:00", "2022-03-01T02:00:00Z",
+					).HasDefault(""),
+					docs.FieldString(
+						"end", "The end of the window, exclusive.",
+						"23:30", "2022-03-01T04:00:00Z",
+					).HasDefault(""),
+					docs.FieldString(
+						"days", "An optional list of days of the week on which a daily window begins. If left empty the window recurs every day.",
+						[]string{"sat", "sun"},
+					).Array().HasDefault([]string{}),
+					docs.FieldString(
+						"timezone", "The timezone of a daily window in IANA format.",
+						"UTC", "Europe/London",
+					).HasDefault("UTC"),
+				).Advanced(),
 				docs.FieldOutput(
 					"output", "An [output](/docs/components/outputs/about/) for messages that pass the check to be routed to.",
 				).HasDefault(map[string]interface{}{}),
@@ -176,6 +214,38 @@ output:
           gcp_pubsub:
             project: people
             topic: that_i_dont_want_to_hang_with
+`,
+			},
+			{
+				Title: "Canary Rollout",
+				Summary: `
+The ` + "`rollout`" + ` field of a case allows a percentage of messages to be routed to a new output. In the following example ten percent of users have their messages sent to a new Kafka cluster, where the ` + "`key`" + ` ensures that all messages of a user are routed consistently, and the remaining messages are sent to the existing cluster. During a maintenance window of the existing cluster on Sunday mornings all messages are instead written to files.`,
+				Config: `
+output:
+  switch:
+    cases:
+      - window:
+          start: "02:00"
+          end: "04:00"
+          days: [ sun ]
+          timezone: Europe/London
+        output:
+          file:
+            path: ./backlog/${! timestamp_unix() }.jsonl
+            codec: lines
+
+      - rollout:
+          percentage: 10
+          key: ${! json("user.id") }
+        output:
+          kafka:
+            addresses: [ new-cluster:9092 ]
+            topic: events
+
+      - output:
+          kafka:
+            addresses: [ old-cluster:9092 ]
+            topic: events
 `,
 			},
 		},
@@ -191,9 +261,12 @@ type switchOutput struct {
 	outputTSChans []chan message.Transaction
 	outputs       []output.Streamed
 	checks        []*mapping.Executor
+	rollouts      []*switchRollout
+	windows       []*switchWindow
 	continues     []bool
 	fallthroughs  []bool
 
+	nowFn   func() time.Time
 	shutSig *shutdown.Signaller
 }
 
@@ -202,6 +275,7 @@ func newSwitchOutput(conf ooutput.SwitchConfig, mgr bundle.NewManagement) (outpu
 		logger:       mgr.Logger(),
 		transactions: nil,
 		strictMode:   conf.StrictMode,
+		nowFn:        time.Now,
 		shutSig:      shutdown.NewSignaller(),
 	}
 
@@ -212,6 +286,8 @@ func newSwitchOutput(conf ooutput.SwitchConfig, mgr bundle.NewManagement) (outpu
 	if lCases > 0 {
 		o.outputs = make([]output.Streamed, lCases)
 		o.checks = make([]*mapping.Executor, lCases)
+		o.rollouts = make([]*switchRollout, lCases)
+		o.windows = make([]*switchWindow, lCases)
 		o.continues = make([]bool, lCases)
 		o.fallthroughs = make([]bool, lCases)
 	}
@@ -232,6 +308,12 @@ func newSwitchOutput(conf ooutput.SwitchConfig, mgr bundle.NewManagement) (outpu
 				return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", i, err)
 			}
 		}
+		if o.rollouts[i], err = newSwitchRollout(cConf.Rollout, mgr); err != nil {
+			return nil, fmt.Errorf("failed to parse case '%v' rollout: %v", i, err)
+		}
+		if o.windows[i], err = newSwitchWindow(cConf.Window); err != nil {
+			return nil, fmt.Errorf("failed to parse case '%v' window: %v", i, err)
+		}
 		o.continues[i] = cConf.Continue
 	}
 
@@ -408,10 +490,19 @@ func (o *switchOutput) loop() {
 
 		group, trackedMsg := message.NewSortGroup(ts.Payload)
 
+		now := o.nowFn()
+		activeCases := make([]bool, len(o.windows))
+		for j, w := range o.windows {
+			activeCases[j] = w == nil || w.contains(now)
+		}
+
 		outputTargets := make([][]*message.Part, len(o.checks))
 		if checksErr := trackedMsg.Iter(func(i int, p *message.Part) error {
 			routedAtLeastOnce := false
 			for j, exe := range o.checks {
+				if !activeCases[j] {
+					continue
+				}
 				test := true
 				if exe != nil {
 					var err error
@@ -420,6 +511,9 @@ func (o *switchOutput) loop() {
 						o.logger.Errorf("Failed to test case %v: %v\n", j, err)
 					}
 				}
+				if test && o.rollouts[j] != nil {
+					test = o.rollouts[j].includes(i, trackedMsg)
+				}
 				if test {
 					routedAtLeastOnce = true
 					outputTargets[j] = append(outputTargets[j], p.Copy())
@@ -464,3 +558,150 @@ func (o *switchOutput) WaitForClose(timeout time.Duration) error {
 	}
 	return nil
 }
+
+//------------------------------------------------------------------------------
+
+// switchRollout selects a percentage of messages for a switch case.
+type switchRollout struct {
+	percentage float64
+	key        *field.Expression
+}
+
+func newSwitchRollout(conf ooutput.SwitchConfigRollout, mgr bundle.NewManagement) (*switchRollout, error) {
+	if conf.Percentage == 0 {
+		return nil, nil
+	}
+	if conf.Percentage < 0 || conf.Percentage > 100 {
+		return nil, fmt.Errorf("percentage must be between 0 and 100, got %v", conf.Percentage)
+	}
+	r := &switchRollout{percentage: conf.Percentage}
+	if conf.Key != "" {
+		var err error
+		if r.key, err = mgr.BloblEnvironment().NewField(conf.Key); err != nil {
+			return nil, fmt.Errorf("failed to parse key expression: %v", err)
+		}
+	}
+	return r, nil
+}
+
+func (r *switchRollout) includes(index int, msg *message.Batch) bool {
+	if r.key == nil {
+		return rand.Float64()*100 < r.percentage
+	}
+	bucket := xxhash.ChecksumString64(r.key.String(index, msg)) % 10000
+	return float64(bucket) < r.percentage*100
+}
+
+// switchWindow restricts a switch case to a window of time, which is either
+// absolute or recurs daily.
+type switchWindow struct {
+	absStart, absEnd time.Time
+
+	daily              bool
+	startMins, endMins int
+	days               map[time.Weekday]struct{}
+	location           *time.Location
+}
+
+func parseDayMinutes(str string) (int, error) {
+	t, err := time.Parse("15:04", str)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func parseWeekday(str string) (time.Weekday, error) {
+	str = strings.ToLower(str)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if str == name || str == name[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("day not recognised: %v", str)
+}
+
+func newSwitchWindow(conf ooutput.SwitchConfigWindow) (*switchWindow, error) {
+	if conf.Start == "" && conf.End == "" {
+		return nil, nil
+	}
+	if conf.Start == "" || conf.End == "" {
+		return nil, errors.New("both a start and an end must be specified")
+	}
+
+	w := &switchWindow{}
+
+	absStart, startErr := time.Parse(time.RFC3339, conf.Start)
+	absEnd, endErr := time.Parse(time.RFC3339, conf.End)
+	if startErr == nil && endErr == nil {
+		if !absEnd.After(absStart) {
+			return nil, errors.New("end must be after start")
+		}
+		if len(conf.Days) > 0 {
+			return nil, errors.New("days cannot be specified for a window of absolute timestamps")
+		}
+		w.absStart, w.absEnd = absStart, absEnd
+		return w, nil
+	}
+
+	w.daily = true
+
+	var err error
+	if w.startMins, err = parseDayMinutes(conf.Start); err != nil {
+		return nil, fmt.Errorf("failed to parse start: %v", err)
+	}
+	if w.endMins, err = parseDayMinutes(conf.End); err != nil {
+		return nil, fmt.Errorf("failed to parse end: %v", err)
+	}
+	if w.startMins == w.endMins {
+		return nil, errors.New("end must differ from start")
+	}
+
+	w.location = time.UTC
+	if conf.Timezone != "" {
+		if w.location, err = time.LoadLocation(conf.Timezone); err != nil {
+			return nil, fmt.Errorf("failed to parse timezone: %v", err)
+		}
+	}
+
+	if len(conf.Days) > 0 {
+		w.days = map[time.Weekday]struct{}{}
+		for _, dStr := range conf.Days {
+			d, err := parseWeekday(dStr)
+			if err != nil {
+				return nil, err
+			}
+			w.days[d] = struct{}{}
+		}
+	}
+	return w, nil
+}
+
+func (w *switchWindow) contains(t time.Time) bool {
+	if !w.daily {
+		return !t.Before(w.absStart) && t.Before(w.absEnd)
+	}
+
+	t = t.In(w.location)
+	mins := t.Hour()*60 + t.Minute()
+
+	// The day on which the window began, which is the previous day for the
+	// portion of a window after midnight.
+	startDay := t.Weekday()
+	if w.startMins < w.endMins {
+		if mins < w.startMins || mins >= w.endMins {
+			return false
+		}
+	} else if mins < w.endMins {
+		startDay = (startDay + 6) % 7
+	} else if mins < w.startMins {
+		return false
+	}
+
+	if w.days == nil {
+		return true
+	}
+	_, exists := w.days[startDay]
+	return exists
+}
@@ -1035,3 +1035,200 @@ bpLoop:
 	close(doneChan)
 	wg.Wait()
 }
+
+func TestSwitchWindowContains(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		conf    ooutput.SwitchConfigWindow
+		inside  []time.Time
+		outside []time.Time
+	}{
+		{
+			name: "absolute",
+			conf: ooutput.SwitchConfigWindow{
+				Start: "2022-03-01T02:00:00Z",
+				End:   "2022-03-01T04:00:00Z",
+			},
+			inside: []time.Time{
+				time.Date(2022, 3, 1, 2, 0, 0, 0, time.UTC),
+				time.Date(2022, 3, 1, 3, 59, 59, 0, time.UTC),
+			},
+			outside: []time.Time{
+				time.Date(2022, 3, 1, 1, 59, 59, 0, time.UTC),
+				time.Date(2022, 3, 1, 4, 0, 0, 0, time.UTC),
+				time.Date(2022, 3, 2, 3, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "daily",
+			conf: ooutput.SwitchConfigWindow{
+				Start: "09:30",
+				End:   "17:00",
+			},
+			inside: []time.Time{
+				time.Date(2022, 3, 1, 9, 30, 0, 0, time.UTC),
+				time.Date(2022, 3, 5, 16, 59, 0, 0, time.UTC),
+			},
+			outside: []time.Time{
+				time.Date(2022, 3, 1, 9, 29, 0, 0, time.UTC),
+				time.Date(2022, 3, 1, 17, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "over midnight on days with timezone",
+			conf: ooutput.SwitchConfigWindow{
+				Start:    "23:00",
+				End:      "01:00",
+				Days:     []string{"Sat", "sunday"},
+				Timezone: "Europe/London",
+			},
+			inside: []time.Time{
+				// Saturday 23:30 and Sunday 00:30 within the window starting
+				// on Saturday.
+				time.Date(2022, 7, 2, 23, 30, 0, 0, london),
+				time.Date(2022, 7, 3, 0, 30, 0, 0, london),
+				// Monday 00:30 within the window starting on Sunday.
+				time.Date(2022, 7, 4, 0, 30, 0, 0, london),
+				time.Date(2022, 7, 2, 22, 30, 0, 0, time.UTC),
+			},
+			outside: []time.Time{
+				// Saturday 00:30 within the window starting on Friday.
+				time.Date(2022, 7, 2, 0, 30, 0, 0, london),
+				time.Date(2022, 7, 4, 23, 30, 0, 0, london),
+				time.Date(2022, 7, 2, 22, 30, 0, 0, london),
+				time.Date(2022, 7, 2, 21, 30, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			w, err := newSwitchWindow(test.conf)
+			require.NoError(t, err)
+			require.NotNil(t, w)
+
+			for _, ts := range test.inside {
+				assert.True(t, w.contains(ts), ts.String())
+			}
+			for _, ts := range test.outside {
+				assert.False(t, w.contains(ts), ts.String())
+			}
+		})
+	}
+
+	w, err := newSwitchWindow(ooutput.NewSwitchConfigWindow())
+	require.NoError(t, err)
+	assert.Nil(t, w)
+
+	for _, conf := range []ooutput.SwitchConfigWindow{
+		{Start: "10:00"},
+		{Start: "10:00", End: "10:00"},
+		{Start: "10:00", End: "nope"},
+		{Start: "10:00", End: "11:00", Days: []string{"someday"}},
+		{Start: "10:00", End: "11:00", Timezone: "Nowhere/Nope"},
+		{Start: "2022-03-01T04:00:00Z", End: "2022-03-01T02:00:00Z"},
+	} {
+		_, err := newSwitchWindow(conf)
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestSwitchRollout(t *testing.T) {
+	mgr := bmock.NewManager()
+
+	r, err := newSwitchRollout(ooutput.SwitchConfigRollout{
+		Percentage: 25,
+		Key:        `${! json("id") }`,
+	}, mgr)
+	require.NoError(t, err)
+
+	included := 0
+	for i := 0; i < 10000; i++ {
+		msg := message.QuickBatch([][]byte{[]byte(fmt.Sprintf(`{"id":"user-%v"}`, i))})
+		res := r.includes(0, msg)
+		if res {
+			included++
+		}
+		// Repeated messages with the same key are routed consistently.
+		assert.Equal(t, res, r.includes(0, msg))
+	}
+	assert.InDelta(t, 2500, included, 250)
+
+	r, err = newSwitchRollout(ooutput.NewSwitchConfigRollout(), mgr)
+	require.NoError(t, err)
+	assert.Nil(t, r)
+
+	_, err = newSwitchRollout(ooutput.SwitchConfigRollout{Percentage: 150}, mgr)
+	require.Error(t, err)
+}
+
+func TestSwitchWindowRouting(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	mockOutputs := []*mock.OutputChanneled{{}, {}}
+
+	conf := ooutput.NewConfig()
+	for i := 0; i < len(mockOutputs); i++ {
+		conf.Switch.Cases = append(conf.Switch.Cases, ooutput.NewSwitchConfigCase())
+	}
+	conf.Switch.Cases[0].Window.Start = "2022-03-01T02:00:00Z"
+	conf.Switch.Cases[0].Window.End = "2022-03-01T04:00:00Z"
+
+	s := newSwitch(t, conf, mockOutputs)
+
+	var nowMut sync.Mutex
+	now := time.Date(2022, 3, 1, 3, 0, 0, 0, time.UTC)
+	s.nowFn = func() time.Time {
+		nowMut.Lock()
+		defer nowMut.Unlock()
+		return now
+	}
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error, 1)
+	require.NoError(t, s.Consume(readChan))
+
+	sendAndExpect := func(expOutput int) {
+		t.Helper()
+
+		select {
+		case readChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for output send")
+		}
+
+		var ts message.Transaction
+		select {
+		case ts = <-mockOutputs[0].TChan:
+			assert.Equal(t, 0, expOutput)
+		case ts = <-mockOutputs[1].TChan:
+			assert.Equal(t, 1, expOutput)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for output to propagate")
+		}
+		require.NoError(t, ts.Ack(ctx, nil))
+
+		select {
+		case res := <-resChan:
+			require.NoError(t, res)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out responding to output")
+		}
+	}
+
+	sendAndExpect(0)
+
+	nowMut.Lock()
+	now = time.Date(2022, 3, 1, 5, 0, 0, 0, time.UTC)
+	nowMut.Unlock()
+
+	sendAndExpect(1)
+
+	s.CloseAsync()
+	assert.NoError(t, s.WaitForClose(time.Second*5))
+}
@@ -0,0 +1,288 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func alertProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Evaluates a numeric value of each message against thresholds and emits alerts when the state of a series changes between firing and resolved.").
+		Description(`
+The value of each message is obtained with the `+"[Bloblang query](/docs/guides/bloblang/about)"+` `+"`check`"+`, and messages are grouped into separate series with the `+"`key`"+`, such as the host of a metric. When `+"`mode`"+` is `+"`threshold`"+` the value itself is compared with the thresholds `+"`above`"+` and `+"`below`"+`, and when `+"`mode`"+` is `+"`rate_of_change`"+` the change per second of the value since the oldest value seen within the `+"`window`"+` is compared instead.
+
+Messages do not pass through this processor, instead an alert message is emitted only when a series transitions from resolved to firing or from firing to resolved. While a series remains firing the alert is repeated every `+"`renotify_interval`"+` when it is set. Alert messages retain the metadata of the message that triggered them, have the metadata field `+"`alert_state`"+` set to either `+"`firing`"+` or `+"`resolved`"+`, and contain a JSON document of the form:
+
+`+"```json"+`
+{
+  "key": "host-a",
+  "state": "firing",
+  "value": 93.5,
+  "mode": "threshold",
+  "above": 90,
+  "below": null,
+  "since": "2022-01-01T10:00:00Z",
+  "repeat": false
+}
+`+"```"+`
+
+Where `+"`since`"+` is the time at which the series entered its current state. The state of each series is held in memory and is therefore lost when Benthos restarts.`).
+		Field(service.NewBloblangField("check").
+			Description("A [Bloblang query](/docs/guides/bloblang/about) that results in the numeric value to evaluate for each message.").
+			Example("this.cpu_percent").
+			Example(`meta("latency_ms").number()`)).
+		Field(service.NewInterpolatedStringField("key").
+			Description("An identifier of the series that a message belongs to, each series is alerted on independently.").
+			Example(`${! json("host") }`).
+			Default("")).
+		Field(service.NewStringAnnotatedEnumField("mode", map[string]string{
+			"threshold":      "Compare the value with the thresholds.",
+			"rate_of_change": "Compare the change per second of the value over the window with the thresholds.",
+		}).Description("Determines what is compared with the thresholds.").
+			Default("threshold")).
+		Field(service.NewFloatField("above").
+			Description("An alert fires when the compared value is greater than this threshold.").
+			Optional()).
+		Field(service.NewFloatField("below").
+			Description("An alert fires when the compared value is less than this threshold.").
+			Optional()).
+		Field(service.NewDurationField("window").
+			Description("The period of time over which the rate of change is calculated when the mode is `rate_of_change`.").
+			Default("1m")).
+		Field(service.NewDurationField("renotify_interval").
+			Description("The period of time after which an alert is repeated while a series remains firing. Set to `0s` in order to only alert on state transitions.").
+			Default("0s")).
+		Example("CPU Alerts", `
+Here we send a Slack notification when the CPU usage of a host exceeds 90%, and another when it recovers, with reminders every hour while it remains high:`,
+			`
+pipeline:
+  processors:
+    - alert:
+        check: this.cpu_percent
+        key: ${! json("host") }
+        above: 90
+        renotify_interval: 1h
+
+output:
+  slack:
+    webhook_url: ${SLACK_WEBHOOK_URL}
+    mapping: 'root.text = "CPU of %s is %s: %.1f%%".format(this.key, this.state, this.value)'
+`,
+		).
+		Example("Falling Queue Depth", `
+Here we alert when the depth of a queue falls by more than 100 messages per second over a five minute window:`,
+			`
+pipeline:
+  processors:
+    - alert:
+        check: this.depth
+        key: ${! json("queue") }
+        mode: rate_of_change
+        window: 5m
+        below: -100
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"alert", alertProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newAlertProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type alertSample struct {
+	t     time.Time
+	value float64
+}
+
+// alertSeries is the state of a single series of values.
+type alertSeries struct {
+	firing       bool
+	since        time.Time
+	lastNotified time.Time
+	samples      []alertSample
+}
+
+type alertProcessor struct {
+	check            *bloblang.Executor
+	key              *service.InterpolatedString
+	mode             string
+	above, below     *float64
+	window           time.Duration
+	renotifyInterval time.Duration
+
+	nowFn func() time.Time
+
+	mut    sync.Mutex
+	series map[string]*alertSeries
+}
+
+func newAlertProcessorFromConfig(conf *service.ParsedConfig) (*alertProcessor, error) {
+	a := &alertProcessor{
+		nowFn:  time.Now,
+		series: map[string]*alertSeries{},
+	}
+
+	var err error
+	if a.check, err = conf.FieldBloblang("check"); err != nil {
+		return nil, err
+	}
+	if a.key, err = conf.FieldInterpolatedString("key"); err != nil {
+		return nil, err
+	}
+	if a.mode, err = conf.FieldString("mode"); err != nil {
+		return nil, err
+	}
+	switch a.mode {
+	case "threshold", "rate_of_change":
+	default:
+		return nil, fmt.Errorf("mode not recognised: %v", a.mode)
+	}
+	for _, t := range []struct {
+		name   string
+		target **float64
+	}{{"above", &a.above}, {"below", &a.below}} {
+		if !conf.Contains(t.name) {
+			continue
+		}
+		v, err := conf.FieldFloat(t.name)
+		if err != nil {
+			return nil, err
+		}
+		*t.target = &v
+	}
+	if a.above == nil && a.below == nil {
+		return nil, errors.New("at least one of above or below must be set")
+	}
+	if a.window, err = conf.FieldDuration("window"); err != nil {
+		return nil, err
+	}
+	if a.mode == "rate_of_change" && a.window <= 0 {
+		return nil, errors.New("window must be greater than zero when the mode is rate_of_change")
+	}
+	if a.renotifyInterval, err = conf.FieldDuration("renotify_interval"); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *alertProcessor) breaches(v float64) bool {
+	return (a.above != nil && v > *a.above) || (a.below != nil && v < *a.below)
+}
+
+// compared returns the value to compare with the thresholds, which is false
+// when there isn't enough data within the window to calculate a rate of change.
+func (a *alertProcessor) compared(s *alertSeries, now time.Time, v float64) (float64, bool) {
+	if a.mode != "rate_of_change" {
+		return v, true
+	}
+
+	cutoff := now.Add(-a.window)
+	i := 0
+	for i < len(s.samples) && s.samples[i].t.Before(cutoff) {
+		i++
+	}
+	s.samples = append(s.samples[i:], alertSample{t: now, value: v})
+
+	oldest := s.samples[0]
+	elapsed := now.Sub(oldest.t).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return (v - oldest.value) / elapsed, true
+}
+
+func floatOrNil(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func (a *alertProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	res, err := msg.BloblangQuery(a.check)
+	if err != nil {
+		return nil, fmt.Errorf("check failed: %w", err)
+	}
+	if res == nil {
+		return nil, errors.New("check deleted the message")
+	}
+	raw, err := res.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("check result: %w", err)
+	}
+	v, err := query.IGetNumber(raw)
+	if err != nil {
+		return nil, fmt.Errorf("check result: %w", err)
+	}
+
+	key := a.key.String(msg)
+	now := a.nowFn()
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	s, exists := a.series[key]
+	if !exists {
+		s = &alertSeries{since: now}
+		a.series[key] = s
+	}
+
+	compared, ok := a.compared(s, now, v)
+	if !ok {
+		return nil, nil
+	}
+
+	firing := a.breaches(compared)
+	repeat := false
+	switch {
+	case firing != s.firing:
+		s.firing = firing
+		s.since = now
+	case firing && a.renotifyInterval > 0 && now.Sub(s.lastNotified) >= a.renotifyInterval:
+		repeat = true
+	default:
+		return nil, nil
+	}
+	s.lastNotified = now
+
+	state := "resolved"
+	if firing {
+		state = "firing"
+	}
+
+	alertMsg := msg.Copy()
+	alertMsg.SetStructured(map[string]interface{}{
+		"key":    key,
+		"state":  state,
+		"value":  compared,
+		"mode":   a.mode,
+		"above":  floatOrNil(a.above),
+		"below":  floatOrNil(a.below),
+		"since":  s.since.UTC().Format(time.RFC3339),
+		"repeat": repeat,
+	})
+	alertMsg.MetaSet("alert_state", state)
+	return service.MessageBatch{alertMsg}, nil
+}
+
+func (a *alertProcessor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,119 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testAlertProcessor(t *testing.T, confStr string) (*alertProcessor, *time.Time) {
+	t.Helper()
+
+	conf, err := alertProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newAlertProcessorFromConfig(conf)
+	require.NoError(t, err)
+
+	now := time.Unix(1600000000, 0)
+	proc.nowFn = func() time.Time {
+		return now
+	}
+	return proc, &now
+}
+
+func alertStates(t *testing.T, proc *alertProcessor, docs ...string) []string {
+	t.Helper()
+
+	var states []string
+	for _, doc := range docs {
+		batch, err := proc.Process(context.Background(), service.NewMessage([]byte(doc)))
+		require.NoError(t, err)
+		for _, m := range batch {
+			v, err := m.AsStructured()
+			require.NoError(t, err)
+
+			obj := v.(map[string]interface{})
+			state, _ := m.MetaGet("alert_state")
+			assert.Equal(t, obj["state"], state)
+			if obj["repeat"].(bool) {
+				state += " repeat"
+			}
+			states = append(states, obj["key"].(string)+" "+state)
+		}
+	}
+	return states
+}
+
+func TestAlertProcessorThreshold(t *testing.T) {
+	proc, _ := testAlertProcessor(t, `
+check: this.value
+key: ${! json("host") }
+above: 90
+below: 10
+`)
+
+	assert.Equal(t, []string{
+		"a firing",
+		"b firing",
+		"a resolved",
+		"a firing",
+	}, alertStates(t, proc,
+		`{"host":"a","value":50}`,
+		`{"host":"a","value":95}`,
+		`{"host":"a","value":99}`,
+		`{"host":"b","value":5}`,
+		`{"host":"a","value":50}`,
+		`{"host":"b","value":1}`,
+		`{"host":"a","value":5}`,
+	))
+}
+
+func TestAlertProcessorRenotify(t *testing.T) {
+	proc, now := testAlertProcessor(t, `
+check: this.value
+above: 90
+renotify_interval: 1m
+`)
+
+	assert.Equal(t, []string{" firing"}, alertStates(t, proc, `{"value":95}`))
+
+	*now = now.Add(30 * time.Second)
+	assert.Empty(t, alertStates(t, proc, `{"value":95}`))
+
+	*now = now.Add(30 * time.Second)
+	assert.Equal(t, []string{" firing repeat"}, alertStates(t, proc, `{"value":95}`))
+
+	*now = now.Add(time.Second)
+	assert.Equal(t, []string{" resolved"}, alertStates(t, proc, `{"value":50}`))
+}
+
+func TestAlertProcessorRateOfChange(t *testing.T) {
+	proc, now := testAlertProcessor(t, `
+check: this.value
+mode: rate_of_change
+window: 10s
+above: 5
+`)
+
+	values := []string{`{"value":0}`, `{"value":10}`, `{"value":70}`, `{"value":80}`, `{"value":90}`, `{"value":90}`}
+	var states []string
+	for _, v := range values {
+		states = append(states, alertStates(t, proc, v)...)
+		*now = now.Add(5 * time.Second)
+	}
+	assert.Equal(t, []string{" firing", " resolved"}, states)
+}
+
+func TestAlertProcessorConfigErrors(t *testing.T) {
+	conf, err := alertProcessorConfig().ParseYAML(`check: this.value`, nil)
+	require.NoError(t, err)
+
+	_, err = newAlertProcessorFromConfig(conf)
+	require.Error(t, err)
+}
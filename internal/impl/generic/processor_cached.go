@@ -0,0 +1,262 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	cpFieldResource   = "resource"
+	cpFieldKey        = "key"
+	cpFieldTTL        = "ttl"
+	cpFieldProcessors = "processors"
+)
+
+func cachedProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Caches the results of a list of child processors in a cache resource, and skips executing them for messages with a key that already has a cached result.").
+		Description(`
+For each message the `+"`key`"+` is resolved and looked up within the `+"[`cache` resource](/docs/components/caches/about)"+`. When a result exists the message is replaced with the cached contents and metadata without executing the child processors. Otherwise the child processors are executed on the message and the resulting messages, including their metadata, are stored in the cache under the key for subsequent messages. This is useful for avoiding repetitive calls to expensive enrichments such as `+"[`http`](/docs/components/processors/http)"+` or `+"[`sql_select`](/docs/components/processors/sql_select)"+` processors for messages with the same key.
+
+Results are only cached when all resulting messages were processed successfully, and child processors that filter a message cache the empty result, and therefore messages of the same key are also filtered.
+
+If reading from or writing to the cache fails the error is logged and the child processors are executed as normal, and therefore an unavailable cache does not prevent messages from being processed.`).
+		Field(service.NewStringField(cpFieldResource).
+			Description("The name of the [`cache` resource](/docs/components/caches/about) to store results in.")).
+		Field(service.NewInterpolatedStringField(cpFieldKey).
+			Description("The key that results are cached under, messages that resolve to the same key share a result.").
+			Example(`${! json("user_id") }`).
+			Example(`${! meta("kafka_key") }-${! json("locale") }`)).
+		Field(service.NewStringField(cpFieldTTL).
+			Description("An optional TTL of cached results, overriding the default TTL of the cache when set. Caches that do not support per key TTLs ignore this field.").
+			Example("60s").
+			Example("1h").
+			Optional()).
+		Field(service.NewProcessorListField(cpFieldProcessors).
+			Description("A list of processors to execute on messages without a cached result.")).
+		Example("Caching HTTP Enrichments", `
+Here we enrich documents with the profile of a user obtained from an HTTP service, where the profiles of users are cached for five minutes in order to avoid requesting the same profile repeatedly:`,
+			`
+pipeline:
+  processors:
+    - branch:
+        request_map: 'root = ""'
+        processors:
+          - cached:
+              resource: profiles
+              key: ${! json("user_id") }
+              ttl: 5m
+              processors:
+                - http:
+                    url: http://localhost:4195/users/${! json("user_id") }
+                    verb: GET
+        result_map: 'root.profile = this'
+
+cache_resources:
+  - label: profiles
+    memory:
+      default_ttl: 5m
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"cached", cachedProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newCachedProcessorFromConfig(conf, mgr, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cachedProcessor struct {
+	resource string
+	key      *service.InterpolatedString
+	ttl      *time.Duration
+	children []*service.OwnedProcessor
+
+	mgr cacheProvider
+	log *service.Logger
+}
+
+func newCachedProcessorFromConfig(conf *service.ParsedConfig, mgr cacheProvider, log *service.Logger) (*cachedProcessor, error) {
+	c := &cachedProcessor{mgr: mgr, log: log}
+
+	var err error
+	if c.resource, err = conf.FieldString(cpFieldResource); err != nil {
+		return nil, err
+	}
+	if c.key, err = conf.FieldInterpolatedString(cpFieldKey); err != nil {
+		return nil, err
+	}
+	if conf.Contains(cpFieldTTL) {
+		ttl, err := conf.FieldDuration(cpFieldTTL)
+		if err != nil {
+			return nil, err
+		}
+		c.ttl = &ttl
+	}
+	if c.children, err = conf.FieldProcessorList(cpFieldProcessors); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// cachedMessage is the serialised form of a message stored within the cache.
+type cachedMessage struct {
+	Content  []byte            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func encodeCachedResult(batch service.MessageBatch) ([]byte, error) {
+	res := make([]cachedMessage, len(batch))
+	for i, m := range batch {
+		var err error
+		if res[i].Content, err = m.AsBytes(); err != nil {
+			return nil, err
+		}
+		_ = m.MetaWalk(func(k, v string) error {
+			if res[i].Metadata == nil {
+				res[i].Metadata = map[string]string{}
+			}
+			res[i].Metadata[k] = v
+			return nil
+		})
+	}
+	return json.Marshal(res)
+}
+
+func decodeCachedResult(msg *service.Message, b []byte) (service.MessageBatch, error) {
+	var res []cachedMessage
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+
+	batch := make(service.MessageBatch, len(res))
+	for i, r := range res {
+		// Copying the original message preserves its context, and the metadata
+		// is replaced entirely with that of the cached result.
+		m := msg.Copy()
+		var keys []string
+		_ = m.MetaWalk(func(k, _ string) error {
+			keys = append(keys, k)
+			return nil
+		})
+		for _, k := range keys {
+			m.MetaDelete(k)
+		}
+		m.SetBytes(r.Content)
+		for k, v := range r.Metadata {
+			m.MetaSet(k, v)
+		}
+		batch[i] = m
+	}
+	return batch, nil
+}
+
+func (c *cachedProcessor) getCached(ctx context.Context, key string) (b []byte, err error) {
+	if cerr := c.mgr.AccessCache(ctx, c.resource, func(cache service.Cache) {
+		b, err = cache.Get(ctx, key)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (c *cachedProcessor) setCached(ctx context.Context, key string, b []byte) (err error) {
+	if cerr := c.mgr.AccessCache(ctx, c.resource, func(cache service.Cache) {
+		err = cache.Set(ctx, key, b, c.ttl)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (c *cachedProcessor) processMessage(ctx context.Context, batch service.MessageBatch, i int) (service.MessageBatch, error) {
+	msg := batch[i]
+	key := batch.InterpolatedString(i, c.key)
+
+	cachedBytes, err := c.getCached(ctx, key)
+	if err == nil {
+		res, err := decodeCachedResult(msg, cachedBytes)
+		if err == nil {
+			return res, nil
+		}
+		c.log.Warnf("Failed to decode cached result of key '%v': %v", key, err)
+	} else if !errors.Is(err, service.ErrKeyNotFound) {
+		c.log.Warnf("Failed to read cached result of key '%v': %v", key, err)
+	}
+
+	batches := []service.MessageBatch{{msg}}
+	for j, child := range c.children {
+		var nextBatches []service.MessageBatch
+		for _, b := range batches {
+			if len(b) == 0 {
+				continue
+			}
+			res, err := child.ProcessBatch(ctx, b)
+			if err != nil {
+				return nil, fmt.Errorf("child processor [%v]: %w", j, err)
+			}
+			nextBatches = append(nextBatches, res...)
+		}
+		batches = nextBatches
+	}
+
+	var results service.MessageBatch
+	for _, b := range batches {
+		results = append(results, b...)
+	}
+	for _, m := range results {
+		if m.GetError() != nil {
+			return results, nil
+		}
+	}
+
+	resBytes, err := encodeCachedResult(results)
+	if err != nil {
+		c.log.Warnf("Failed to encode result of key '%v': %v", key, err)
+		return results, nil
+	}
+	if err := c.setCached(ctx, key, resBytes); err != nil {
+		c.log.Warnf("Failed to cache result of key '%v': %v", key, err)
+	}
+	return results, nil
+}
+
+func (c *cachedProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	var results service.MessageBatch
+	for i, msg := range batch {
+		res, err := c.processMessage(ctx, batch, i)
+		if err != nil {
+			msg.SetError(err)
+			res = service.MessageBatch{msg}
+		}
+		results = append(results, res...)
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return []service.MessageBatch{results}, nil
+}
+
+func (c *cachedProcessor) Close(ctx context.Context) error {
+	for _, child := range c.children {
+		if err := child.Close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
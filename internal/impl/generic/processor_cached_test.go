@@ -0,0 +1,143 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testCachedProcessor(t *testing.T, confStr string, caches map[string]service.Cache) *cachedProcessor {
+	t.Helper()
+
+	conf, err := cachedProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newCachedProcessorFromConfig(conf, &mockCacheProv{caches: caches}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+	return proc
+}
+
+func processCached(t *testing.T, proc *cachedProcessor, content string, meta map[string]string) service.MessageBatch {
+	t.Helper()
+
+	msg := service.NewMessage([]byte(content))
+	for k, v := range meta {
+		msg.MetaSet(k, v)
+	}
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{msg})
+	require.NoError(t, err)
+	if len(batches) == 0 {
+		return nil
+	}
+	require.Len(t, batches, 1)
+	return batches[0]
+}
+
+func TestCachedProcessor(t *testing.T) {
+	proc := testCachedProcessor(t, `
+resource: foo
+key: ${! json("id") }
+processors:
+  - bloblang: |
+      meta result = "computed"
+      meta source = deleted()
+      root.id = this.id
+      root.value = this.value.uppercase()
+`, map[string]service.Cache{"foo": newMemCache(time.Hour, 0, 1, nil)})
+
+	res := processCached(t, proc, `{"id":"a","value":"first"}`, map[string]string{"source": "x"})
+	require.Len(t, res, 1)
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"a","value":"FIRST"}`, string(b))
+
+	// A hit returns the cached result without executing the children, and the
+	// metadata is replaced with that of the result.
+	res = processCached(t, proc, `{"id":"a","value":"second"}`, map[string]string{"source": "y", "other": "z"})
+	require.Len(t, res, 1)
+	b, err = res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"a","value":"FIRST"}`, string(b))
+
+	v, exists := res[0].MetaGet("result")
+	assert.True(t, exists)
+	assert.Equal(t, "computed", v)
+	_, exists = res[0].MetaGet("source")
+	assert.False(t, exists)
+	_, exists = res[0].MetaGet("other")
+	assert.False(t, exists)
+
+	res = processCached(t, proc, `{"id":"b","value":"third"}`, nil)
+	require.Len(t, res, 1)
+	b, err = res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"b","value":"THIRD"}`, string(b))
+}
+
+func TestCachedProcessorMultipleAndFiltered(t *testing.T) {
+	proc := testCachedProcessor(t, `
+resource: foo
+key: ${! json("id") }
+processors:
+  - bloblang: 'root = if this.drop.or(false) { deleted() } else { this.values }'
+  - unarchive:
+      format: json_array
+`, map[string]service.Cache{"foo": newMemCache(time.Hour, 0, 1, nil)})
+
+	res := processCached(t, proc, `{"id":"a","values":[1,2,3]}`, nil)
+	require.Len(t, res, 3)
+
+	res = processCached(t, proc, `{"id":"a","values":[4]}`, nil)
+	require.Len(t, res, 3)
+	for i, exp := range []string{"1", "2", "3"} {
+		b, err := res[i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(b))
+	}
+
+	assert.Empty(t, processCached(t, proc, `{"id":"b","drop":true}`, nil))
+	assert.Empty(t, processCached(t, proc, `{"id":"b","values":[1]}`, nil))
+}
+
+func TestCachedProcessorErrorsNotCached(t *testing.T) {
+	proc := testCachedProcessor(t, `
+resource: foo
+key: ${! json("id") }
+processors:
+  - bloblang: 'root = this.value.number()'
+`, map[string]service.Cache{"foo": newMemCache(time.Hour, 0, 1, nil)})
+
+	res := processCached(t, proc, `{"id":"a","value":"nope"}`, nil)
+	require.Len(t, res, 1)
+	assert.Error(t, res[0].GetError())
+
+	res = processCached(t, proc, `{"id":"a","value":"5"}`, nil)
+	require.Len(t, res, 1)
+	require.NoError(t, res[0].GetError())
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(b))
+}
+
+func TestCachedProcessorMissingCache(t *testing.T) {
+	proc := testCachedProcessor(t, `
+resource: bar
+key: ${! json("id") }
+processors:
+  - bloblang: 'root = this.value'
+`, map[string]service.Cache{})
+
+	res := processCached(t, proc, `{"id":"a","value":"foo"}`, nil)
+	require.Len(t, res, 1)
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(b))
+}
@@ -0,0 +1,276 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+	"strconv"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func chunkProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Splits the payload of each message into a batch of smaller chunks, allowing large objects to be pushed through transports with limited message sizes.").
+		Description(`
+When `+"`algorithm`"+` is `+"`fastcdc`"+` chunk boundaries are chosen from the contents of the payload using [FastCDC](https://www.usenix.org/conference/atc16/technical-sessions/presentation/xia), which results in chunks of between `+"`min_size`"+` and `+"`max_size`"+` bytes that are `+"`size`"+` bytes on average. Since boundaries depend only on nearby content, payloads that differ slightly share most of their chunks, which is useful when chunks are deduplicated or cached downstream. When `+"`algorithm`"+` is `+"`fixed`"+` each chunk is exactly `+"`size`"+` bytes (except for the last), and consecutive chunks share `+"`overlap`"+` bytes.
+
+Each chunk retains the metadata of the original message and has the following metadata fields added:
+
+`+"```text"+`
+- chunk_id
+- chunk_index
+- chunk_count
+- chunk_offset
+`+"```"+`
+
+Where `+"`chunk_offset`"+` is the position in bytes of the chunk within the original payload. The original messages can be reconstructed from these chunks with the `+"[`unchunk` processor](/docs/components/processors/unchunk)"+`.`).
+		Field(service.NewStringAnnotatedEnumField("algorithm", map[string]string{
+			"fastcdc": "Choose chunk boundaries from the content of the payload.",
+			"fixed":   "Split the payload into chunks of a fixed size.",
+		}).Description("The algorithm used to determine chunk boundaries.").
+			Default("fastcdc")).
+		Field(service.NewIntField("size").
+			Description("The size in bytes of each chunk when the algorithm is `fixed`, or the average size of chunks when the algorithm is `fastcdc`.").
+			Default(262144)).
+		Field(service.NewIntField("min_size").
+			Description("The minimum size in bytes of chunks when the algorithm is `fastcdc`. When set to zero a quarter of `size` is used.").
+			Default(0).
+			Advanced()).
+		Field(service.NewIntField("max_size").
+			Description("The maximum size in bytes of chunks when the algorithm is `fastcdc`. When set to zero four times `size` is used.").
+			Default(0).
+			Advanced()).
+		Field(service.NewIntField("overlap").
+			Description("The number of bytes shared by consecutive chunks when the algorithm is `fixed`, must be less than `size`.").
+			Default(0).
+			Advanced()).
+		Field(service.NewInterpolatedStringField("id").
+			Description("An identifier shared by all chunks of a message, used in order to reassemble them. This should be unique for each message.").
+			Default(`${! uuid_v4() }`).
+			Advanced()).
+		Example("Splitting Large Objects", `
+Here we read large files from S3 and split them into chunks no larger than 256KiB in order to publish them to NATS, which has a default payload limit of 1MB:`,
+			`
+input:
+  aws_s3:
+    bucket: my-bucket
+
+pipeline:
+  processors:
+    - chunk:
+        algorithm: fixed
+        size: 262144
+        id: ${! meta("s3_key") }
+
+output:
+  nats:
+    urls: [ nats://127.0.0.1:4222 ]
+    subject: objects
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"chunk", chunkProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newChunkProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// gearTable contains the random values used by the FastCDC rolling hash,
+// generated deterministically so that chunk boundaries are stable across
+// instances.
+var gearTable = func() (t [256]uint64) {
+	seed := uint64(0x6a09e667f3bcc908)
+	for i := range t {
+		// splitmix64
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		t[i] = z ^ (z >> 31)
+	}
+	return
+}()
+
+// fastCDC finds content-defined chunk boundaries using normalized chunking,
+// where a stricter mask is used before the average size is reached and a
+// looser mask after, which narrows the distribution of chunk sizes.
+type fastCDC struct {
+	minSize, avgSize, maxSize int
+	maskS, maskL              uint64
+}
+
+func newFastCDC(minSize, avgSize, maxSize int) (*fastCDC, error) {
+	if minSize <= 0 || minSize >= avgSize || avgSize >= maxSize {
+		return nil, errors.New("chunk sizes must satisfy 0 < min_size < size < max_size")
+	}
+	avgBits := bits.Len(uint(avgSize)) - 1
+	if avgBits < 2 {
+		return nil, errors.New("size must be at least 4 bytes")
+	}
+	// The hash is shifted left per byte and therefore the high bits depend on
+	// a window of the previous 64 bytes, so masks are taken from those.
+	topMask := func(n int) uint64 {
+		return ^uint64(0) << (64 - n)
+	}
+	return &fastCDC{
+		minSize: minSize,
+		avgSize: avgSize,
+		maxSize: maxSize,
+		maskS:   topMask(avgBits + 1),
+		maskL:   topMask(avgBits - 1),
+	}, nil
+}
+
+// next returns the length of the next chunk at the beginning of data.
+func (f *fastCDC) next(data []byte) int {
+	n := len(data)
+	if n <= f.minSize {
+		return n
+	}
+	if n > f.maxSize {
+		n = f.maxSize
+	}
+	normal := f.avgSize
+	if normal > n {
+		normal = n
+	}
+
+	var fp uint64
+	i := f.minSize
+	for ; i < normal; i++ {
+		if fp = (fp << 1) + gearTable[data[i]]; fp&f.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		if fp = (fp << 1) + gearTable[data[i]]; fp&f.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// chunkSpan is the location of a chunk within a payload.
+type chunkSpan struct {
+	offset, length int
+}
+
+type chunkProcessor struct {
+	id    *service.InterpolatedString
+	split func(data []byte) []chunkSpan
+}
+
+func newChunkProcessorFromConfig(conf *service.ParsedConfig) (*chunkProcessor, error) {
+	algorithm, err := conf.FieldString("algorithm")
+	if err != nil {
+		return nil, err
+	}
+	size, err := conf.FieldInt("size")
+	if err != nil {
+		return nil, err
+	}
+	if size <= 0 {
+		return nil, errors.New("size must be greater than zero")
+	}
+
+	c := &chunkProcessor{}
+	if c.id, err = conf.FieldInterpolatedString("id"); err != nil {
+		return nil, err
+	}
+
+	switch algorithm {
+	case "fastcdc":
+		minSize, err := conf.FieldInt("min_size")
+		if err != nil {
+			return nil, err
+		}
+		if minSize == 0 {
+			minSize = size / 4
+		}
+		maxSize, err := conf.FieldInt("max_size")
+		if err != nil {
+			return nil, err
+		}
+		if maxSize == 0 {
+			maxSize = size * 4
+		}
+		cdc, err := newFastCDC(minSize, size, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		c.split = func(data []byte) (spans []chunkSpan) {
+			for offset := 0; offset < len(data); {
+				n := cdc.next(data[offset:])
+				spans = append(spans, chunkSpan{offset: offset, length: n})
+				offset += n
+			}
+			return
+		}
+	case "fixed":
+		overlap, err := conf.FieldInt("overlap")
+		if err != nil {
+			return nil, err
+		}
+		if overlap < 0 || overlap >= size {
+			return nil, errors.New("overlap must be at least zero and less than size")
+		}
+		c.split = func(data []byte) (spans []chunkSpan) {
+			for offset := 0; ; offset += size - overlap {
+				n := len(data) - offset
+				if n > size {
+					n = size
+				}
+				spans = append(spans, chunkSpan{offset: offset, length: n})
+				if offset+n >= len(data) {
+					return
+				}
+			}
+		}
+	default:
+		return nil, errors.New("algorithm not recognised: " + algorithm)
+	}
+	return c, nil
+}
+
+func (c *chunkProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	data, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	spans := c.split(data)
+	if len(spans) == 0 {
+		spans = []chunkSpan{{}}
+	}
+
+	id := c.id.String(msg)
+	count := strconv.Itoa(len(spans))
+
+	batch := make(service.MessageBatch, len(spans))
+	for i, s := range spans {
+		chunk := msg.Copy()
+		chunk.SetBytes(data[s.offset : s.offset+s.length])
+		chunk.MetaSet("chunk_id", id)
+		chunk.MetaSet("chunk_index", strconv.Itoa(i))
+		chunk.MetaSet("chunk_count", count)
+		chunk.MetaSet("chunk_offset", strconv.Itoa(s.offset))
+		batch[i] = chunk
+	}
+	return batch, nil
+}
+
+func (c *chunkProcessor) Close(ctx context.Context) error {
+	return nil
+}
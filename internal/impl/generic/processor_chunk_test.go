@@ -0,0 +1,167 @@
+package generic
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testChunkProcessor(t *testing.T, confStr string) *chunkProcessor {
+	t.Helper()
+
+	conf, err := chunkProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newChunkProcessorFromConfig(conf)
+	require.NoError(t, err)
+	return proc
+}
+
+func chunkPayloads(t *testing.T, batch service.MessageBatch) []string {
+	t.Helper()
+
+	var payloads []string
+	for _, m := range batch {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		payloads = append(payloads, string(b))
+	}
+	return payloads
+}
+
+func TestChunkProcessorFixed(t *testing.T) {
+	proc := testChunkProcessor(t, `
+algorithm: fixed
+size: 4
+overlap: 1
+id: ${! meta("name") }
+`)
+
+	msg := service.NewMessage([]byte("abcdefghij"))
+	msg.MetaSet("name", "foo")
+
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abcd", "defg", "ghij"}, chunkPayloads(t, batch))
+
+	for i, m := range batch {
+		for k, exp := range map[string]string{
+			"name":         "foo",
+			"chunk_id":     "foo",
+			"chunk_index":  []string{"0", "1", "2"}[i],
+			"chunk_count":  "3",
+			"chunk_offset": []string{"0", "3", "6"}[i],
+		} {
+			v, _ := m.MetaGet(k)
+			assert.Equal(t, exp, v, k)
+		}
+	}
+
+	batch, err = proc.Process(context.Background(), service.NewMessage(nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, chunkPayloads(t, batch))
+}
+
+func TestChunkProcessorFastCDC(t *testing.T) {
+	proc := testChunkProcessor(t, `
+size: 1024
+`)
+
+	data := make([]byte, 64*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage(data))
+	require.NoError(t, err)
+	require.Greater(t, len(batch), 16)
+
+	var joined []byte
+	for i, p := range chunkPayloads(t, batch) {
+		if i < len(batch)-1 {
+			assert.GreaterOrEqual(t, len(p), 256, i)
+		}
+		assert.LessOrEqual(t, len(p), 4096, i)
+		joined = append(joined, p...)
+	}
+	assert.Equal(t, data, joined)
+
+	// Boundaries depend on content, so inserting data near the start of the
+	// payload leaves the chunks that follow unchanged.
+	modified := append([]byte("some extra data"), data...)
+	modBatch, err := proc.Process(context.Background(), service.NewMessage(modified))
+	require.NoError(t, err)
+
+	original := map[string]bool{}
+	for _, p := range chunkPayloads(t, batch) {
+		original[p] = true
+	}
+	var shared int
+	for _, p := range chunkPayloads(t, modBatch) {
+		if original[p] {
+			shared++
+		}
+	}
+	assert.GreaterOrEqual(t, shared, len(batch)-2)
+}
+
+func TestChunkProcessorBadConfig(t *testing.T) {
+	for _, confStr := range []string{
+		`size: 0`,
+		`size: 1024
+min_size: 2048`,
+		`algorithm: fixed
+size: 10
+overlap: 10`,
+	} {
+		conf, err := chunkProcessorConfig().ParseYAML(confStr, nil)
+		require.NoError(t, err)
+
+		_, err = newChunkProcessorFromConfig(conf)
+		assert.Error(t, err, confStr)
+	}
+}
+
+func TestChunkProcessorRoundTrip(t *testing.T) {
+	data := make([]byte, 32*1024)
+	rand.New(rand.NewSource(2)).Read(data)
+
+	for _, confStr := range []string{
+		`size: 512`,
+		`algorithm: fixed
+size: 1000
+overlap: 100`,
+	} {
+		proc := testChunkProcessor(t, confStr)
+
+		msg := service.NewMessage(data)
+		msg.MetaSet("foo", "bar")
+
+		chunks, err := proc.Process(context.Background(), msg)
+		require.NoError(t, err)
+
+		// Shuffle the chunks and include a duplicate.
+		rand.New(rand.NewSource(3)).Shuffle(len(chunks), func(i, j int) {
+			chunks[i], chunks[j] = chunks[j], chunks[i]
+		})
+		chunks = append(chunks, chunks[0])
+
+		res, err := (&unchunkProcessor{}).ProcessBatch(context.Background(), chunks)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Len(t, res[0], 1, confStr)
+
+		b, err := res[0][0].AsBytes()
+		require.NoError(t, err)
+		assert.True(t, bytes.Equal(data, b), confStr)
+
+		v, _ := res[0][0].MetaGet("foo")
+		assert.Equal(t, "bar", v)
+		_, exists := res[0][0].MetaGet("chunk_id")
+		assert.False(t, exists)
+	}
+}
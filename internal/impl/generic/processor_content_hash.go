@@ -0,0 +1,385 @@
+package generic
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/golang/snappy"
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func contentHashProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Computes a stable hash of the contents of each message and stores it as metadata, for use as an idempotency key by outputs.").
+		Description(`
+When `+"`format`"+` is `+"`json`"+` the message is parsed and hashed in a canonical form, where whitespace is removed and strings are consistently escaped, and therefore documents that differ only in their formatting result in the same hash. The canonical form can be further relaxed with `+"`sort_keys`, so that the order of object keys is ignored, and `normalize_floats`"+`, so that numbers with equal values such as `+"`1.50`, `1.5` and `15e-1`"+` are considered equal. When `+"`format`"+` is `+"`raw`"+` the raw bytes of the message are hashed.
+
+Messages that are compressed can be decompressed before they are hashed with the field `+"`decompress`"+`, which allows the same content to result in the same hash regardless of how it was compressed. The contents of messages are not modified. When set to `+"`auto`"+` the compression algorithm is detected from the first bytes of the message, which is supported for `+"`gzip`, `zlib` and `lz4`"+`, and messages that are not recognised as compressed are hashed as they are.
+
+The hash is encoded as a hexadecimal string and stored within the metadata key `+"`meta_key`"+`, from which it can be referenced by outputs with [interpolation functions](/docs/configuration/interpolation#bloblang-queries), such as the `+"`key`"+` of the `+"[`kafka` output](/docs/components/outputs/kafka)"+` or an `+"`Idempotency-Key`"+` header of the `+"[`http_client` output](/docs/components/outputs/http_client)"+`.
+
+Messages that fail to be decompressed or parsed are flagged as failed, and can be handled with [error handling patterns](/docs/configuration/error_handling).`).
+		Field(service.NewStringEnumField("algorithm", "sha256", "xxhash64").
+			Description("The hashing algorithm to use.").
+			Default("sha256")).
+		Field(service.NewStringEnumField("format", "json", "raw").
+			Description("Whether to hash the canonical form of the message parsed as JSON, or its raw bytes.").
+			Default("json")).
+		Field(service.NewBoolField("sort_keys").
+			Description("Whether to sort the keys of objects within the canonical form of the message, in which case the order of object keys does not change the hash. Only applies when `format` is `json`.").
+			Default(true)).
+		Field(service.NewBoolField("normalize_floats").
+			Description("Whether to normalise numbers with fractions or exponents into the shortest form of their value within the canonical form of the message, where integral values are written as integers. Only applies when `format` is `json`.").
+			Default(true)).
+		Field(service.NewStringEnumField("decompress", "none", "auto", "gzip", "zlib", "flate", "snappy", "lz4").
+			Description("An optional compression algorithm to decompress messages with before hashing them.").
+			Default("none").
+			Advanced()).
+		Field(service.NewStringField("meta_key").
+			Description("The metadata key to store the hash within.").
+			Default("content_hash")).
+		Example("Idempotent HTTP Requests", `
+Here we compute a hash of each JSON document that ignores its formatting and the order of its keys, and send it as the `+"`Idempotency-Key`"+` header of requests so that documents delivered more than once are only processed once by the receiving service:`,
+			`
+pipeline:
+  processors:
+    - content_hash:
+        meta_key: idempotency_key
+
+output:
+  http_client:
+    url: http://localhost:4195/events
+    verb: POST
+    headers:
+      Idempotency-Key: ${! meta("idempotency_key") }
+`,
+		).
+		Example("Deduplicating Compressed Payloads", `
+Here we consume messages that might be compressed with gzip, and use an `+"`xxhash64`"+` hash of their decompressed bytes as the key of messages written to Kafka, allowing a compacted topic to deduplicate them:`,
+			`
+pipeline:
+  processors:
+    - content_hash:
+        algorithm: xxhash64
+        format: raw
+        decompress: auto
+
+output:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topic: events
+    key: ${! meta("content_hash") }
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"content_hash", contentHashProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newContentHashProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type contentHashProcessor struct {
+	newHash         func() hash.Hash
+	jsonFormat      bool
+	sortKeys        bool
+	normalizeFloats bool
+	decompress      func([]byte) ([]byte, error)
+	metaKey         string
+}
+
+func newContentHashProcessorFromConfig(conf *service.ParsedConfig) (*contentHashProcessor, error) {
+	c := &contentHashProcessor{}
+
+	algorithm, err := conf.FieldString("algorithm")
+	if err != nil {
+		return nil, err
+	}
+	switch algorithm {
+	case "sha256":
+		c.newHash = sha256.New
+	case "xxhash64":
+		c.newHash = func() hash.Hash {
+			return xxhash.New64()
+		}
+	default:
+		return nil, fmt.Errorf("algorithm not recognised: %v", algorithm)
+	}
+
+	format, err := conf.FieldString("format")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		c.jsonFormat = true
+	case "raw":
+	default:
+		return nil, fmt.Errorf("format not recognised: %v", format)
+	}
+
+	if c.sortKeys, err = conf.FieldBool("sort_keys"); err != nil {
+		return nil, err
+	}
+	if c.normalizeFloats, err = conf.FieldBool("normalize_floats"); err != nil {
+		return nil, err
+	}
+
+	decompress, err := conf.FieldString("decompress")
+	if err != nil {
+		return nil, err
+	}
+	if c.decompress, err = contentHashDecompressor(decompress); err != nil {
+		return nil, err
+	}
+
+	if c.metaKey, err = conf.FieldString("meta_key"); err != nil {
+		return nil, err
+	}
+	if c.metaKey == "" {
+		return nil, errors.New("meta_key must not be empty")
+	}
+	return c, nil
+}
+
+func (c *contentHashProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	content, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+	if c.decompress != nil {
+		if content, err = c.decompress(content); err != nil {
+			return nil, fmt.Errorf("failed to decompress message: %w", err)
+		}
+	}
+	if c.jsonFormat {
+		if content, err = canonicalJSON(content, c.sortKeys, c.normalizeFloats); err != nil {
+			return nil, fmt.Errorf("failed to canonicalise message: %w", err)
+		}
+	}
+
+	h := c.newHash()
+	_, _ = h.Write(content)
+	msg.MetaSet(c.metaKey, hex.EncodeToString(h.Sum(nil)))
+	return service.MessageBatch{msg}, nil
+}
+
+func (c *contentHashProcessor) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func readAllAndClose(r io.ReadCloser) ([]byte, error) {
+	b, err := io.ReadAll(r)
+	if cErr := r.Close(); err == nil {
+		err = cErr
+	}
+	return b, err
+}
+
+func contentHashDecompressor(algorithm string) (func([]byte) ([]byte, error), error) {
+	gzipFn := func(b []byte) ([]byte, error) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return readAllAndClose(r)
+	}
+	zlibFn := func(b []byte) ([]byte, error) {
+		r, err := zlib.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return readAllAndClose(r)
+	}
+	lz4Fn := func(b []byte) ([]byte, error) {
+		return io.ReadAll(lz4.NewReader(bytes.NewReader(b)))
+	}
+
+	switch algorithm {
+	case "none":
+		return nil, nil
+	case "auto":
+		return func(b []byte) ([]byte, error) {
+			switch {
+			case bytes.HasPrefix(b, []byte{0x1f, 0x8b}):
+				return gzipFn(b)
+			case bytes.HasPrefix(b, []byte{0x04, 0x22, 0x4d, 0x18}):
+				return lz4Fn(b)
+			case len(b) >= 2 && b[0]&0x0f == 0x08 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0:
+				return zlibFn(b)
+			}
+			return b, nil
+		}, nil
+	case "gzip":
+		return gzipFn, nil
+	case "zlib":
+		return zlibFn, nil
+	case "flate":
+		return func(b []byte) ([]byte, error) {
+			return readAllAndClose(flate.NewReader(bytes.NewReader(b)))
+		}, nil
+	case "snappy":
+		return func(b []byte) ([]byte, error) {
+			return snappy.Decode(nil, b)
+		}, nil
+	case "lz4":
+		return lz4Fn, nil
+	}
+	return nil, fmt.Errorf("decompression algorithm not recognised: %v", algorithm)
+}
+
+//------------------------------------------------------------------------------
+
+// canonicalJSON returns a compact serialisation of a JSON document where
+// strings are consistently escaped, object keys are optionally sorted and
+// numbers are optionally normalised.
+func canonicalJSON(b []byte, sortKeys, normalizeFloats bool) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var buf bytes.Buffer
+	if err := writeCanonicalJSON(dec, &buf, sortKeys, normalizeFloats); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON document")
+	}
+	return buf.Bytes(), nil
+}
+
+func writeCanonicalJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+
+	// Encode appends a newline which is trimmed.
+	buf.Truncate(buf.Len() - 1)
+}
+
+func canonicalJSONNumber(n json.Number, normalizeFloats bool) (string, error) {
+	s := n.String()
+	if !normalizeFloats || !strings.ContainsAny(s, ".eE") {
+		return s, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return "", err
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64), nil
+}
+
+func writeCanonicalJSON(dec *json.Decoder, buf *bytes.Buffer, sortKeys, normalizeFloats bool) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := t.(type) {
+	case json.Delim:
+		switch v {
+		case '[':
+			buf.WriteByte('[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if err := writeCanonicalJSON(dec, buf, sortKeys, normalizeFloats); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+			buf.WriteByte(']')
+		case '{':
+			type field struct {
+				key   string
+				value []byte
+			}
+			var fields []field
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := kt.(string)
+
+				var vBuf bytes.Buffer
+				if err := writeCanonicalJSON(dec, &vBuf, sortKeys, normalizeFloats); err != nil {
+					return err
+				}
+				fields = append(fields, field{key: key, value: vBuf.Bytes()})
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+			if sortKeys {
+				sort.SliceStable(fields, func(i, j int) bool {
+					return fields[i].key < fields[j].key
+				})
+			}
+			buf.WriteByte('{')
+			for i, f := range fields {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				writeCanonicalJSONString(buf, f.key)
+				buf.WriteByte(':')
+				buf.Write(f.value)
+			}
+			buf.WriteByte('}')
+		default:
+			return fmt.Errorf("unexpected delimiter: %v", v)
+		}
+	case string:
+		writeCanonicalJSONString(buf, v)
+	case json.Number:
+		s, err := canonicalJSONNumber(v, normalizeFloats)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("unexpected token: %v", v)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package generic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testContentHashProcessor(t *testing.T, confStr string) *contentHashProcessor {
+	t.Helper()
+
+	conf, err := contentHashProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newContentHashProcessorFromConfig(conf)
+	require.NoError(t, err)
+	return proc
+}
+
+func contentHash(t *testing.T, proc *contentHashProcessor, content []byte) string {
+	t.Helper()
+
+	batch, err := proc.Process(context.Background(), service.NewMessage(content))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	v, exists := batch[0].MetaGet(proc.metaKey)
+	require.True(t, exists)
+	return v
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		sortKeys        bool
+		normalizeFloats bool
+		output          string
+	}{
+		{
+			name:     "whitespace and key order",
+			input:    "{ \"b\" : [ 1, 2 ],\n \"a\": {\"d\": null, \"c\": true} }",
+			sortKeys: true,
+			output:   `{"a":{"c":true,"d":null},"b":[1,2]}`,
+		},
+		{
+			name:   "keys unsorted",
+			input:  `{ "b": 1, "a": 2 }`,
+			output: `{"b":1,"a":2}`,
+		},
+		{
+			name:   "string escapes",
+			input:  `"A<b>\/"`,
+			output: `"A<b>/"`,
+		},
+		{
+			name:            "floats normalized",
+			input:           `[1.50, 15e-1, 1.0, 1e2, -0.0, 12345678901234567890, 1.5e300]`,
+			normalizeFloats: true,
+			output:          `[1.5,1.5,1,100,0,12345678901234567890,1.5e+300]`,
+		},
+		{
+			name:   "floats verbatim",
+			input:  `[1.50, 15e-1, 1.0]`,
+			output: `[1.50,15e-1,1.0]`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			out, err := canonicalJSON([]byte(test.input), test.sortKeys, test.normalizeFloats)
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(out))
+		})
+	}
+}
+
+func TestCanonicalJSONErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"a":`,
+		`{"a":1} {"b":2}`,
+		`not json`,
+	} {
+		_, err := canonicalJSON([]byte(input), true, true)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestContentHashJSON(t *testing.T) {
+	proc := testContentHashProcessor(t, ``)
+
+	a := contentHash(t, proc, []byte(`{"id":"foo","price":1.50,"tags":["a","b"]}`))
+	b := contentHash(t, proc, []byte(`{ "tags": [ "a", "b" ], "price": 1.5, "id": "foo" }`))
+	c := contentHash(t, proc, []byte(`{"id":"foo","price":1.5,"tags":["b","a"]}`))
+
+	assert.Len(t, a, 64)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+
+	_, err := proc.Process(context.Background(), service.NewMessage([]byte(`not json`)))
+	assert.Error(t, err)
+}
+
+func TestContentHashRawXXHash(t *testing.T) {
+	proc := testContentHashProcessor(t, `
+algorithm: xxhash64
+format: raw
+meta_key: idempotency_key
+`)
+
+	a := contentHash(t, proc, []byte(`{"a":1,"b":2}`))
+	b := contentHash(t, proc, []byte(`{"b":2,"a":1}`))
+
+	assert.Len(t, a, 16)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, a, contentHash(t, proc, []byte(`{"a":1,"b":2}`)))
+}
+
+func TestContentHashDecompress(t *testing.T) {
+	var gzipped bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	_, err := w.Write([]byte(`{"b":2,"a":1}`))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	auto := testContentHashProcessor(t, `decompress: auto`)
+	exp := contentHash(t, auto, []byte(`{"a":1,"b":2}`))
+	assert.Equal(t, exp, contentHash(t, auto, gzipped.Bytes()))
+
+	snappyProc := testContentHashProcessor(t, `decompress: snappy`)
+	assert.Equal(t, exp, contentHash(t, snappyProc, snappy.Encode(nil, []byte(`{"a":1,"b":2}`))))
+
+	gzipProc := testContentHashProcessor(t, `decompress: gzip`)
+	_, err = gzipProc.Process(context.Background(), service.NewMessage([]byte(`{"a":1}`)))
+	assert.Error(t, err)
+}
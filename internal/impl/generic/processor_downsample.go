@@ -0,0 +1,383 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+var downsampleAggregations = []string{"mean", "min", "max", "last", "first", "sum", "count"}
+
+func downsampleProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Downsamples high frequency numerical readings into one summary message per key per interval, reducing the volume of writes to downstream time series databases.").
+		Description(`
+Each message provides a numerical reading via the `+"[`value`](#value)"+` mapping, which is aggregated into a bucket identified by the `+"[`key`](#key)"+` of the message and the interval its timestamp falls within. Intervals are aligned to the unix epoch, meaning an interval of `+"`1m`"+` produces buckets starting at the turn of each minute. Messages are removed from the pipeline as they are aggregated, and once a bucket closes a single message is emitted in its place containing a JSON summary:
+
+`+"```json"+`
+{
+  "key": "sensor-1",
+  "start": "2022-03-01T09:00:00Z",
+  "end": "2022-03-01T09:01:00Z",
+  "count": 60,
+  "mean": 21.4,
+  "min": 20.9,
+  "max": 22.1,
+  "last": 21.8
+}
+`+"```"+`
+
+The fields included in the summary are chosen with `+"[`aggregations`](#aggregations)"+`, and the emitted message carries the metadata of the most recent message added to the bucket.
+
+A bucket closes when a message for the same key arrives with a timestamp within a later interval, or when the system clock passes the end of the bucket. The latter is checked each time a message is processed, and therefore an idle bucket is only emitted once a subsequent message (of any key) arrives. Messages with a timestamp older than the open bucket of their key are dropped.
+
+Partial buckets are stored in the cache `+"`resource`"+` under the key of each series, and therefore survive restarts when the cache is persistent. However, buckets of idle keys restored from a cache are only emitted once a message of the same key arrives, and a key must not be processed concurrently by multiple instances.`).
+		Field(service.NewStringField("resource").
+			Description("The [`cache` resource](/docs/components/caches/about) to store partial buckets in.")).
+		Field(service.NewInterpolatedStringField("key").
+			Description("The key identifying the series a message belongs to, each key is downsampled independently.").
+			Example(`${! meta("sensor_id") }`).
+			Example(`${! json("device") }-${! json("metric") }`)).
+		Field(service.NewBloblangField("value").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the numerical reading of a message.").
+			Default("root = this.value")).
+		Field(service.NewBloblangField("timestamp_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the timestamp of a message, which must either be a numerical unix time in seconds or a string in ISO 8601 format. By default the processing time is used.").
+			Default("root = now()").
+			Example("root = this.created_at")).
+		Field(service.NewDurationField("interval").
+			Description("The period of time summarised by each bucket.").
+			Default("1m")).
+		Field(service.NewStringListField("aggregations").
+			Description("The aggregations to include within each summary, options are: `"+strings.Join(downsampleAggregations, "`, `")+"`.").
+			Default([]string{"mean", "min", "max", "last"})).
+		Example("Downsampling Sensor Readings", `
+Here we reduce readings published every second by a fleet of sensors into a summary per sensor per minute, with partial minutes persisted to Redis so that they aren't lost during restarts:`,
+			`
+pipeline:
+  processors:
+    - downsample:
+        resource: buckets
+        key: ${! json("sensor_id") }
+        value: root = this.reading.temperature
+        timestamp_mapping: root = this.timestamp
+        interval: 1m
+        aggregations: [ mean, max ]
+
+cache_resources:
+  - label: buckets
+    redis:
+      url: tcp://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"downsample", downsampleProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newDownsampleProcessorFromConfig(conf, mgr, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// downsampleBucket is stored in the cache for each key with an open bucket.
+type downsampleBucket struct {
+	Start int64             `json:"start"`
+	Count int64             `json:"count"`
+	Sum   float64           `json:"sum"`
+	Min   float64           `json:"min"`
+	Max   float64           `json:"max"`
+	First float64           `json:"first"`
+	Last  float64           `json:"last"`
+	Meta  map[string]string `json:"meta"`
+}
+
+func (b *downsampleBucket) add(v float64) {
+	if b.Count == 0 {
+		b.Min, b.Max, b.First = v, v, v
+	}
+	if v < b.Min {
+		b.Min = v
+	}
+	if v > b.Max {
+		b.Max = v
+	}
+	b.Sum += v
+	b.Last = v
+	b.Count++
+}
+
+type downsampleProcessor struct {
+	resource string
+	mgr      cacheProvider
+	log      *service.Logger
+
+	key          *service.InterpolatedString
+	value        *bloblang.Executor
+	timestamp    *bloblang.Executor
+	interval     time.Duration
+	aggregations []string
+
+	nowFn func() time.Time
+
+	mut     sync.Mutex
+	pending map[string]time.Time
+}
+
+func newDownsampleProcessorFromConfig(conf *service.ParsedConfig, mgr cacheProvider, log *service.Logger) (*downsampleProcessor, error) {
+	d := &downsampleProcessor{
+		mgr:     mgr,
+		log:     log,
+		nowFn:   time.Now,
+		pending: map[string]time.Time{},
+	}
+
+	var err error
+	if d.resource, err = conf.FieldString("resource"); err != nil {
+		return nil, err
+	}
+	if d.key, err = conf.FieldInterpolatedString("key"); err != nil {
+		return nil, err
+	}
+	if d.value, err = conf.FieldBloblang("value"); err != nil {
+		return nil, err
+	}
+	if d.timestamp, err = conf.FieldBloblang("timestamp_mapping"); err != nil {
+		return nil, err
+	}
+	if d.interval, err = conf.FieldDuration("interval"); err != nil {
+		return nil, err
+	}
+	if d.interval <= 0 {
+		return nil, errors.New("interval must be greater than zero")
+	}
+	if d.aggregations, err = conf.FieldStringList("aggregations"); err != nil {
+		return nil, err
+	}
+	if len(d.aggregations) == 0 {
+		return nil, errors.New("at least one aggregation must be specified")
+	}
+	for _, a := range d.aggregations {
+		var found bool
+		for _, s := range downsampleAggregations {
+			if a == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unrecognised aggregation: %v", a)
+		}
+	}
+	return d, nil
+}
+
+func (d *downsampleProcessor) queryStructured(msg *service.Message, exec *bloblang.Executor) (interface{}, error) {
+	res, err := msg.BloblangQuery(exec)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.New("mapping returned deleted()")
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		if b, _ := res.AsBytes(); len(b) > 0 {
+			return string(b), nil
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+func (d *downsampleProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	key := d.key.String(msg)
+	if key == "" {
+		return nil, errors.New("series key is empty")
+	}
+
+	rawValue, err := d.queryStructured(msg, d.value)
+	if err != nil {
+		return nil, fmt.Errorf("value mapping failed: %w", err)
+	}
+	value, err := query.IGetNumber(rawValue)
+	if err != nil {
+		return nil, fmt.Errorf("value mapping failed: %w", err)
+	}
+
+	rawTS, err := d.queryStructured(msg, d.timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("timestamp mapping failed: %w", err)
+	}
+	ts, err := query.IGetTimestamp(rawTS)
+	if err != nil {
+		return nil, fmt.Errorf("timestamp mapping failed: %w", err)
+	}
+	start := ts.Truncate(d.interval)
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	var cErr error
+	var batch service.MessageBatch
+	if err := d.mgr.AccessCache(ctx, d.resource, func(c service.Cache) {
+		if batch, cErr = d.expire(ctx, c, key); cErr != nil {
+			return
+		}
+
+		var closed *service.Message
+		if closed, cErr = d.addValue(ctx, c, key, start, value, msg); closed != nil {
+			batch = append(batch, closed)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if cErr != nil {
+		return nil, cErr
+	}
+	return batch, nil
+}
+
+// addValue aggregates a value into the open bucket of a key and returns the
+// summary of the previously open bucket when the value belongs to a later
+// interval.
+func (d *downsampleProcessor) addValue(ctx context.Context, c service.Cache, key string, start time.Time, value float64, msg *service.Message) (*service.Message, error) {
+	bucket, err := d.getBucket(ctx, c, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var closed *service.Message
+	if bucket != nil {
+		bucketStart := time.Unix(0, bucket.Start)
+		if start.Before(bucketStart) {
+			d.log.Debugf("Dropping late value for key %v with timestamp before open bucket %v", key, bucketStart.Format(time.RFC3339))
+			return nil, nil
+		}
+		if start.After(bucketStart) {
+			closed = d.summarise(key, bucket)
+			bucket = nil
+		}
+	}
+	if bucket == nil {
+		bucket = &downsampleBucket{Start: start.UnixNano()}
+	}
+
+	bucket.add(value)
+	bucket.Meta = map[string]string{}
+	_ = msg.MetaWalk(func(k, v string) error {
+		bucket.Meta[k] = v
+		return nil
+	})
+
+	bBytes, err := json.Marshal(bucket)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Set(ctx, key, bBytes, nil); err != nil {
+		return nil, err
+	}
+	d.pending[key] = start.Add(d.interval)
+	return closed, nil
+}
+
+func (d *downsampleProcessor) getBucket(ctx context.Context, c service.Cache, key string) (*downsampleBucket, error) {
+	bBytes, err := c.Get(ctx, key)
+	if errors.Is(err, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bucket downsampleBucket
+	if err := json.Unmarshal(bBytes, &bucket); err != nil {
+		return nil, fmt.Errorf("failed to parse bucket of %v: %w", key, err)
+	}
+	return &bucket, nil
+}
+
+func (d *downsampleProcessor) summarise(key string, bucket *downsampleBucket) *service.Message {
+	start := time.Unix(0, bucket.Start).UTC()
+	summary := map[string]interface{}{
+		"key":   key,
+		"start": start.Format(time.RFC3339Nano),
+		"end":   start.Add(d.interval).Format(time.RFC3339Nano),
+	}
+	for _, a := range d.aggregations {
+		switch a {
+		case "mean":
+			summary[a] = bucket.Sum / float64(bucket.Count)
+		case "min":
+			summary[a] = bucket.Min
+		case "max":
+			summary[a] = bucket.Max
+		case "first":
+			summary[a] = bucket.First
+		case "last":
+			summary[a] = bucket.Last
+		case "sum":
+			summary[a] = bucket.Sum
+		case "count":
+			summary[a] = bucket.Count
+		}
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(summary)
+	for k, v := range bucket.Meta {
+		msg.MetaSet(k, v)
+	}
+	return msg
+}
+
+// expire emits the buckets of keys other than the one being processed whose
+// interval has passed according to the system clock.
+func (d *downsampleProcessor) expire(ctx context.Context, c service.Cache, current string) (service.MessageBatch, error) {
+	var expired []string
+	now := d.nowFn()
+	for key, end := range d.pending {
+		if key != current && !now.Before(end) {
+			expired = append(expired, key)
+		}
+	}
+	sort.Strings(expired)
+
+	var batch service.MessageBatch
+	for _, key := range expired {
+		delete(d.pending, key)
+
+		bucket, err := d.getBucket(ctx, c, key)
+		if err != nil {
+			return nil, err
+		}
+		if bucket == nil {
+			continue
+		}
+		if err := c.Delete(ctx, key); err != nil && !errors.Is(err, service.ErrKeyNotFound) {
+			return nil, err
+		}
+		batch = append(batch, d.summarise(key, bucket))
+	}
+	return batch, nil
+}
+
+func (d *downsampleProcessor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,173 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testDownsampleProcessor(t *testing.T, confStr string) (*downsampleProcessor, *time.Time, service.Cache) {
+	t.Helper()
+
+	conf, err := downsampleProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	memCache := newMemCache(time.Hour, 0, 1, nil)
+	proc, err := newDownsampleProcessorFromConfig(conf, &mockCacheProv{
+		caches: map[string]service.Cache{"foo": memCache},
+	}, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	now := time.Unix(1600000000, 0)
+	proc.nowFn = func() time.Time {
+		return now
+	}
+	return proc, &now, memCache
+}
+
+func testReading(t *testing.T, proc *downsampleProcessor, id string, ts int64, value float64) service.MessageBatch {
+	t.Helper()
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(map[string]interface{}{
+		"id": id, "ts": ts, "value": value,
+	})
+	msg.MetaSet("reading_ts", time.Unix(ts, 0).UTC().Format(time.RFC3339))
+
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	return batch
+}
+
+func summaryOf(t *testing.T, msg *service.Message) map[string]interface{} {
+	t.Helper()
+
+	v, err := msg.AsStructured()
+	require.NoError(t, err)
+	return v.(map[string]interface{})
+}
+
+func TestDownsampleProcessor(t *testing.T) {
+	proc, _, memCache := testDownsampleProcessor(t, `
+resource: foo
+key: ${! json("id") }
+timestamp_mapping: root = this.ts
+interval: 1m
+aggregations: [ mean, min, max, first, last, sum, count ]
+`)
+
+	for i, v := range []float64{3, 1, 5, 3} {
+		assert.Empty(t, testReading(t, proc, "a", 1599999960+int64(i*10), v))
+	}
+	assert.Empty(t, testReading(t, proc, "b", 1599999965, 10))
+
+	_, err := memCache.Get(context.Background(), "a")
+	require.NoError(t, err)
+
+	// Late readings are dropped.
+	assert.Empty(t, testReading(t, proc, "a", 1599999000, 100))
+
+	batch := testReading(t, proc, "a", 1600000020, 7)
+	require.Len(t, batch, 1)
+	assert.Equal(t, map[string]interface{}{
+		"key":   "a",
+		"start": "2020-09-13T12:26:00Z",
+		"end":   "2020-09-13T12:27:00Z",
+		"mean":  3.0,
+		"min":   1.0,
+		"max":   5.0,
+		"first": 3.0,
+		"last":  3.0,
+		"sum":   12.0,
+		"count": int64(4),
+	}, summaryOf(t, batch[0]))
+
+	v, _ := batch[0].MetaGet("reading_ts")
+	assert.Equal(t, "2020-09-13T12:26:30Z", v)
+
+	batch = testReading(t, proc, "a", 1600000030, 9)
+	assert.Empty(t, batch)
+}
+
+func TestDownsampleProcessorExpiry(t *testing.T) {
+	proc, now, memCache := testDownsampleProcessor(t, `
+resource: foo
+key: ${! json("id") }
+timestamp_mapping: root = this.ts
+interval: 1m
+aggregations: [ count, last ]
+`)
+
+	assert.Empty(t, testReading(t, proc, "a", 1600000000, 1))
+	assert.Empty(t, testReading(t, proc, "b", 1600000000, 2))
+
+	*now = now.Add(time.Minute)
+
+	batch := testReading(t, proc, "c", 1600000060, 3)
+	require.Len(t, batch, 2)
+	assert.Equal(t, map[string]interface{}{
+		"key":   "a",
+		"start": "2020-09-13T12:26:00Z",
+		"end":   "2020-09-13T12:27:00Z",
+		"count": int64(1),
+		"last":  1.0,
+	}, summaryOf(t, batch[0]))
+	assert.Equal(t, "b", summaryOf(t, batch[1])["key"])
+
+	for _, k := range []string{"a", "b"} {
+		_, err := memCache.Get(context.Background(), k)
+		assert.Equal(t, service.ErrKeyNotFound, err, k)
+	}
+}
+
+func TestDownsampleProcessorPersisted(t *testing.T) {
+	proc, _, memCache := testDownsampleProcessor(t, `
+resource: foo
+key: ${! json("id") }
+timestamp_mapping: root = this.ts
+aggregations: [ mean ]
+`)
+	assert.Empty(t, testReading(t, proc, "a", 1600000000, 2))
+
+	// A new processor sharing the cache continues the partial bucket.
+	conf, err := downsampleProcessorConfig().ParseYAML(`
+resource: foo
+key: ${! json("id") }
+timestamp_mapping: root = this.ts
+aggregations: [ mean ]
+`, nil)
+	require.NoError(t, err)
+	restarted, err := newDownsampleProcessorFromConfig(conf, &mockCacheProv{
+		caches: map[string]service.Cache{"foo": memCache},
+	}, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	assert.Empty(t, testReading(t, restarted, "a", 1600000010, 4))
+	batch := testReading(t, restarted, "a", 1600000060, 0)
+	require.Len(t, batch, 1)
+	assert.Equal(t, 3.0, summaryOf(t, batch[0])["mean"])
+}
+
+func TestDownsampleProcessorErrors(t *testing.T) {
+	conf, err := downsampleProcessorConfig().ParseYAML(`
+resource: foo
+key: foo
+aggregations: [ median ]
+`, nil)
+	require.NoError(t, err)
+	_, err = newDownsampleProcessorFromConfig(conf, &mockCacheProv{}, service.MockResources().Logger())
+	require.EqualError(t, err, "unrecognised aggregation: median")
+
+	proc, _, _ := testDownsampleProcessor(t, `
+resource: foo
+key: foo
+`)
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"value":"nope"}`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "value mapping failed")
+}
@@ -0,0 +1,161 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func forEachElementProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Composition").
+		Summary("Applies a list of child processors to each element of an array field within a message, and replaces the array with the results.").
+		Description(`
+The elements of the array at `+"`path`"+` are extracted as a batch of messages, one per element, each carrying the metadata of the original message. The child processors are executed on this batch and the structured contents of the resulting messages are then written back to the array in the order that they were emitted, where messages that are not valid JSON are written as strings, leaving the remaining fields of the message untouched. This removes the need to extract an array with `+"[`unarchive`](/docs/components/processors/unarchive)"+` and reassemble it with `+"[`archive`](/docs/components/processors/archive)"+`, which discards the sibling fields of the array.
+
+Child processors that filter messages remove the respective elements from the array, and child processors that produce multiple messages from one element add each of them to the array. Since the child processors are executed on the elements as a single batch, processors that operate on an entire batch such as `+"[`dedupe`](/docs/components/processors/dedupe)"+` are scoped to the elements of a single message. Changes made to the metadata of the elements are discarded.
+
+If the field at `+"`path`"+` does not exist or is not an array, or if any element fails to be processed, the message is left unchanged and flagged as failed, which can be handled with [error handling patterns](/docs/configuration/error_handling).`).
+		Field(service.NewStringField("path").
+			Description("A [dot path](/docs/configuration/field_paths) pointing to the array field to process. An empty path processes the root of the message, which must be an array.").
+			Example("items").
+			Example("order.line_items")).
+		Field(service.NewProcessorListField("processors").
+			Description("A list of processors to apply to each element of the array.")).
+		Example("Enriching Array Elements", `
+Here we have documents containing an array of line items, where each item is enriched with a price obtained from an HTTP service whilst the remaining fields of the order are kept as they are:`,
+			`
+pipeline:
+  processors:
+    - for_each_element:
+        path: order.items
+        processors:
+          - branch:
+              request_map: 'root.sku = this.sku'
+              processors:
+                - http:
+                    url: http://localhost:4195/prices
+                    verb: POST
+              result_map: 'root.price = this.price'
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"for_each_element", forEachElementProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newForEachElementProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type forEachElementProcessor struct {
+	path     []string
+	children []*service.OwnedProcessor
+}
+
+func newForEachElementProcessorFromConfig(conf *service.ParsedConfig) (*forEachElementProcessor, error) {
+	pathStr, err := conf.FieldString("path")
+	if err != nil {
+		return nil, err
+	}
+	f := &forEachElementProcessor{}
+	if pathStr != "" {
+		f.path = gabs.DotPathToSlice(pathStr)
+	}
+	if f.children, err = conf.FieldProcessorList("processors"); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *forEachElementProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	doc, err := msg.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message as structured: %w", err)
+	}
+
+	arr, ok := gabs.Wrap(doc).Search(f.path...).Data().([]interface{})
+	if !ok {
+		return nil, errors.New("field at path is not an array")
+	}
+
+	batch := make(service.MessageBatch, len(arr))
+	for i, v := range arr {
+		element := msg.Copy()
+		element.SetStructured(v)
+		batch[i] = element
+	}
+
+	batches := []service.MessageBatch{batch}
+	for i, child := range f.children {
+		var nextBatches []service.MessageBatch
+		for _, b := range batches {
+			if len(b) == 0 {
+				continue
+			}
+			res, err := child.ProcessBatch(ctx, b)
+			if err != nil {
+				return nil, fmt.Errorf("child processor [%v]: %w", i, err)
+			}
+			nextBatches = append(nextBatches, res...)
+		}
+		batches = nextBatches
+	}
+
+	results := []interface{}{}
+	for _, b := range batches {
+		for _, m := range b {
+			if err := m.GetError(); err != nil {
+				return nil, fmt.Errorf("element %v: %w", len(results), err)
+			}
+			v, err := m.AsStructured()
+			if err != nil {
+				var raw []byte
+				if raw, err = m.AsBytes(); err != nil {
+					return nil, fmt.Errorf("element %v: %w", len(results), err)
+				}
+				v = string(raw)
+			}
+			results = append(results, v)
+		}
+	}
+
+	if len(f.path) == 0 {
+		msg.SetStructured(results)
+		return service.MessageBatch{msg}, nil
+	}
+
+	// The elements were extracted from the immutable view of the document, and
+	// therefore the array is replaced within a mutable copy.
+	mutDoc, err := msg.AsStructuredMut()
+	if err != nil {
+		return nil, err
+	}
+	gObj := gabs.Wrap(mutDoc)
+	if _, err := gObj.Set(results, f.path...); err != nil {
+		return nil, err
+	}
+	msg.SetStructured(gObj.Data())
+	return service.MessageBatch{msg}, nil
+}
+
+func (f *forEachElementProcessor) Close(ctx context.Context) error {
+	for _, c := range f.children {
+		if err := c.Close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package generic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testForEachElementProcessor(t *testing.T, confStr string) *forEachElementProcessor {
+	t.Helper()
+
+	conf, err := forEachElementProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newForEachElementProcessorFromConfig(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+	return proc
+}
+
+func TestForEachElementProcessor(t *testing.T) {
+	proc := testForEachElementProcessor(t, `
+path: order.items
+processors:
+  - bloblang: |
+      root = this
+      root.total = this.qty * this.price
+      root.source = meta("source")
+  - bloblang: 'root = if this.qty == 0 { deleted() }'
+  - split:
+      size: 1
+`)
+
+	msg := service.NewMessage([]byte(`{"id":"foo","order":{"items":[{"qty":2,"price":3},{"qty":0,"price":5},{"qty":1,"price":4}],"note":"keep me"}}`))
+	msg.MetaSet("source", "bar")
+
+	batch, err := proc.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"foo","order":{"items":[{"price":3,"qty":2,"source":"bar","total":6},{"price":4,"qty":1,"source":"bar","total":4}],"note":"keep me"}}`, string(b))
+}
+
+func TestForEachElementProcessorRoot(t *testing.T) {
+	proc := testForEachElementProcessor(t, `
+path: ""
+processors:
+  - bloblang: 'root = this.uppercase()'
+`)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`["a","b"]`)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `["A","B"]`, string(b))
+}
+
+func TestForEachElementProcessorErrors(t *testing.T) {
+	proc := testForEachElementProcessor(t, `
+path: items
+processors:
+  - bloblang: 'root = this.number()'
+`)
+
+	_, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"items":"nope"}`)))
+	require.EqualError(t, err, "field at path is not an array")
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte(`{"items":["1","nope"]}`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "element 1:")
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"items":[]}`)))
+	require.NoError(t, err)
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"items":[]}`, string(b))
+}
@@ -0,0 +1,254 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	krlpFieldKey      = "key"
+	krlpFieldCount    = "count"
+	krlpFieldInterval = "interval"
+	krlpFieldCache    = "cache"
+	krlpFieldOnExceed = "on_exceed"
+)
+
+func keyedRateLimitProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Throttles messages according to a rate limit that is tracked separately for each key, allowing the throughput of individual tenants, users or devices to be limited without affecting the others.").
+		Description(`
+Each unique value of the `+"`key`"+` is permitted `+"`count`"+` messages for each `+"`interval`"+`. By default the state of each key is held in memory and therefore the limits apply to each instance of Benthos individually.
+
+When a `+"`cache`"+` resource is specified the number of messages of each key is instead counted within the cache for fixed windows of the interval, allowing multiple instances sharing a cache such as `+"`redis`"+` to enforce a limit collectively. Caches do not provide atomic increments and therefore limits enforced this way are approximate when instances process messages of the same key concurrently. The following key is used within the cache for each window:
+
+`+"```text"+`
+<key>/<window start unix nanoseconds>
+`+"```"+`
+
+### Exceeding the Limit
+
+The field `+"`on_exceed`"+` determines what happens to a message that exceeds the limit of its key:
+
+- `+"`delay`"+`: The message is held until the limit permits it.
+- `+"`drop`"+`: The message is filtered from the pipeline.
+- `+"`error`"+`: The message continues down the pipeline flagged with an error, which can be handled with [error handling patterns](/docs/configuration/error_handling), such as routing it to a separate output with a `+"[`switch`](/docs/components/outputs/switch)"+`.`).
+		Field(service.NewInterpolatedStringField(krlpFieldKey).
+			Description("The key by which messages are limited, messages that resolve to the same key share a limit.").
+			Example(`${! meta("tenant_id") }`).
+			Example(`${! json("user.id") }`)).
+		Field(service.NewIntField(krlpFieldCount).
+			Description("The maximum number of messages to allow for each key within the interval.").
+			Default(1000)).
+		Field(service.NewDurationField(krlpFieldInterval).
+			Description("The time window to limit messages by.").
+			Default("1s")).
+		Field(service.NewStringField(krlpFieldCache).
+			Description("An optional [`cache` resource](/docs/components/caches/about) in which to count messages for each key, allowing limits to be shared across instances.").
+			Default("").
+			Advanced()).
+		Field(service.NewStringEnumField(krlpFieldOnExceed, "delay", "drop", "error").
+			Description("What to do with messages that exceed the limit of their key.").
+			Default("delay")).
+		Example("Per-Tenant Limits", `
+Here we limit each tenant to 100 messages per second, and messages that exceed their limit are routed to a separate topic to be processed later:`,
+			`
+pipeline:
+  processors:
+    - keyed_rate_limit:
+        key: ${! json("tenant_id") }
+        count: 100
+        interval: 1s
+        on_exceed: error
+
+output:
+  switch:
+    cases:
+      - check: errored()
+        output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: throttled
+      - output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: events
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"keyed_rate_limit", keyedRateLimitProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newKeyedRateLimitProcessorFromConfig(conf, mgr, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type keyedRateLimitProcessor struct {
+	key      *service.InterpolatedString
+	count    int
+	interval time.Duration
+	onExceed string
+
+	cache string
+	mgr   cacheProvider
+	log   *service.Logger
+
+	nowFn func() time.Time
+
+	mut       sync.Mutex
+	limits    map[string]*localRatelimit
+	lastPrune time.Time
+}
+
+func newKeyedRateLimitProcessorFromConfig(conf *service.ParsedConfig, mgr cacheProvider, log *service.Logger) (*keyedRateLimitProcessor, error) {
+	k := &keyedRateLimitProcessor{
+		mgr:    mgr,
+		log:    log,
+		nowFn:  time.Now,
+		limits: map[string]*localRatelimit{},
+	}
+
+	var err error
+	if k.key, err = conf.FieldInterpolatedString(krlpFieldKey); err != nil {
+		return nil, err
+	}
+	if k.count, err = conf.FieldInt(krlpFieldCount); err != nil {
+		return nil, err
+	}
+	if k.count <= 0 {
+		return nil, errors.New("count must be larger than zero")
+	}
+	if k.interval, err = conf.FieldDuration(krlpFieldInterval); err != nil {
+		return nil, err
+	}
+	if k.interval <= 0 {
+		return nil, errors.New("interval must be larger than zero")
+	}
+	if k.cache, err = conf.FieldString(krlpFieldCache); err != nil {
+		return nil, err
+	}
+	if k.onExceed, err = conf.FieldString(krlpFieldOnExceed); err != nil {
+		return nil, err
+	}
+	k.lastPrune = time.Now()
+	return k, nil
+}
+
+// accessLocal consumes a token from the in memory limit of a key, returning
+// the period to wait before a token is available when the limit is exceeded.
+func (k *keyedRateLimitProcessor) accessLocal(ctx context.Context, key string) (time.Duration, error) {
+	k.mut.Lock()
+	now := time.Now()
+
+	// Limits that have not been refreshed for longer than an interval are
+	// equivalent to a new limit and can therefore be discarded.
+	if now.Sub(k.lastPrune) >= k.interval {
+		for lKey, l := range k.limits {
+			l.mut.Lock()
+			idle := now.Sub(l.lastRefresh) >= k.interval
+			l.mut.Unlock()
+			if idle {
+				delete(k.limits, lKey)
+			}
+		}
+		k.lastPrune = now
+	}
+
+	limit, exists := k.limits[key]
+	if !exists {
+		limit, _ = newLocalRatelimit(k.count, k.interval)
+		k.limits[key] = limit
+	}
+	k.mut.Unlock()
+
+	return limit.Access(ctx)
+}
+
+// accessCache consumes a token from the limit of a key stored within a cache,
+// returning the period to wait before a token is available when the limit is
+// exceeded.
+func (k *keyedRateLimitProcessor) accessCache(ctx context.Context, key string) (waitFor time.Duration, err error) {
+	now := k.nowFn()
+	window := now.Truncate(k.interval)
+	cacheKey := key + "/" + strconv.FormatInt(window.UnixNano(), 10)
+	ttl := k.interval * 2
+
+	k.mut.Lock()
+	defer k.mut.Unlock()
+
+	if cerr := k.mgr.AccessCache(ctx, k.cache, func(c service.Cache) {
+		if err = c.Add(ctx, cacheKey, []byte("1"), &ttl); err == nil || !errors.Is(err, service.ErrKeyAlreadyExists) {
+			return
+		}
+
+		var countBytes []byte
+		if countBytes, err = c.Get(ctx, cacheKey); err != nil {
+			return
+		}
+		var count int
+		if count, err = strconv.Atoi(string(countBytes)); err != nil {
+			return
+		}
+		if count >= k.count {
+			waitFor = window.Add(k.interval).Sub(now)
+			return
+		}
+		err = c.Set(ctx, cacheKey, []byte(strconv.Itoa(count+1)), &ttl)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+func (k *keyedRateLimitProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	key := k.key.String(msg)
+
+	for {
+		var waitFor time.Duration
+		var err error
+		if k.cache != "" {
+			waitFor, err = k.accessCache(ctx, key)
+		} else {
+			waitFor, err = k.accessLocal(ctx, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to access rate limit: %w", err)
+		}
+		if waitFor <= 0 {
+			return service.MessageBatch{msg}, nil
+		}
+
+		switch k.onExceed {
+		case "drop":
+			k.log.Debugf("Dropping message that exceeded the rate limit of key: %v", key)
+			return nil, nil
+		case "error":
+			return nil, fmt.Errorf("rate limit exceeded for key: %v", key)
+		}
+
+		select {
+		case <-time.After(waitFor):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (k *keyedRateLimitProcessor) Close(ctx context.Context) error {
+	return nil
+}
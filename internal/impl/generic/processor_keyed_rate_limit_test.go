@@ -0,0 +1,163 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testKeyedRateLimitProcessor(t *testing.T, confStr string) *keyedRateLimitProcessor {
+	t.Helper()
+
+	conf, err := keyedRateLimitProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newKeyedRateLimitProcessorFromConfig(conf, &mockCacheProv{
+		caches: map[string]service.Cache{"foo": newMemCache(time.Hour, 0, 1, nil)},
+	}, nil)
+	require.NoError(t, err)
+	return proc
+}
+
+func tenantMsg(tenant string) *service.Message {
+	msg := service.NewMessage([]byte("hello world"))
+	msg.MetaSet("tenant", tenant)
+	return msg
+}
+
+func TestKeyedRateLimitDrop(t *testing.T) {
+	proc := testKeyedRateLimitProcessor(t, `
+key: ${! meta("tenant") }
+count: 2
+interval: 1h
+on_exceed: drop
+`)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		tenant  string
+		allowed bool
+	}{
+		{tenant: "a", allowed: true},
+		{tenant: "a", allowed: true},
+		{tenant: "b", allowed: true},
+		{tenant: "a", allowed: false},
+		{tenant: "b", allowed: true},
+		{tenant: "b", allowed: false},
+		{tenant: "c", allowed: true},
+	} {
+		batch, err := proc.Process(ctx, tenantMsg(test.tenant))
+		require.NoError(t, err)
+		if test.allowed {
+			assert.Len(t, batch, 1, test.tenant)
+		} else {
+			assert.Empty(t, batch, test.tenant)
+		}
+	}
+}
+
+func TestKeyedRateLimitError(t *testing.T) {
+	proc := testKeyedRateLimitProcessor(t, `
+key: ${! meta("tenant") }
+count: 1
+interval: 1h
+on_exceed: error
+`)
+	ctx := context.Background()
+
+	_, err := proc.Process(ctx, tenantMsg("a"))
+	require.NoError(t, err)
+
+	_, err = proc.Process(ctx, tenantMsg("a"))
+	assert.EqualError(t, err, "rate limit exceeded for key: a")
+
+	_, err = proc.Process(ctx, tenantMsg("b"))
+	require.NoError(t, err)
+}
+
+func TestKeyedRateLimitDelay(t *testing.T) {
+	proc := testKeyedRateLimitProcessor(t, `
+key: ${! meta("tenant") }
+count: 1
+interval: 50ms
+`)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		batch, err := proc.Process(ctx, tenantMsg("a"))
+		require.NoError(t, err)
+		assert.Len(t, batch, 1)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*100)
+
+	start = time.Now()
+	_, err := proc.Process(ctx, tenantMsg("b"))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Millisecond*50)
+
+	tCtx, done := context.WithTimeout(ctx, time.Millisecond)
+	defer done()
+	_, err = proc.Process(tCtx, tenantMsg("b"))
+	assert.Error(t, err)
+}
+
+func TestKeyedRateLimitPrune(t *testing.T) {
+	proc := testKeyedRateLimitProcessor(t, `
+key: ${! meta("tenant") }
+count: 10
+interval: 10ms
+`)
+	ctx := context.Background()
+
+	for _, tenant := range []string{"a", "b", "c"} {
+		_, err := proc.Process(ctx, tenantMsg(tenant))
+		require.NoError(t, err)
+	}
+	assert.Len(t, proc.limits, 3)
+
+	<-time.After(time.Millisecond * 20)
+
+	_, err := proc.Process(ctx, tenantMsg("d"))
+	require.NoError(t, err)
+	assert.Len(t, proc.limits, 1)
+}
+
+func TestKeyedRateLimitCache(t *testing.T) {
+	proc := testKeyedRateLimitProcessor(t, `
+key: ${! meta("tenant") }
+count: 2
+interval: 1m
+cache: foo
+on_exceed: error
+`)
+	ctx := context.Background()
+
+	now := time.Unix(1599999960, 0).Add(time.Second * 15)
+	proc.nowFn = func() time.Time {
+		return now
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := proc.Process(ctx, tenantMsg("a"))
+		require.NoError(t, err)
+	}
+	_, err := proc.Process(ctx, tenantMsg("a"))
+	require.Error(t, err)
+
+	_, err = proc.Process(ctx, tenantMsg("b"))
+	require.NoError(t, err)
+
+	waitFor, err := proc.accessCache(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, time.Second*45, waitFor)
+
+	now = now.Add(time.Minute)
+	_, err = proc.Process(ctx, tenantMsg("a"))
+	require.NoError(t, err)
+}
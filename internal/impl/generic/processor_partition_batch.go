@@ -0,0 +1,137 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/OneOfOne/xxhash"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	pbFieldKey        = "key"
+	pbFieldPartitions = "partitions"
+)
+
+func partitionBatchProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Splits a batch into a fixed number of smaller batches by the hash of a key obtained from each message, where messages with the same key always belong to the same partition.").
+		Description(`
+The `+"`key`"+` of each message is hashed with xxHash64 and the message is placed in the partition given by the hash modulo the number of `+"`partitions`"+`. Messages keep their original order within each partition, and therefore messages that share a key remain in order relative to each other. Partitions are emitted as separate batches in ascending order of their index, and partitions without any messages are omitted.
+
+Since the partition of a key depends only on the key and the number of partitions it remains the same across batches, restarts and instances of Benthos, which allows a following [`+"`switch`"+` output](/docs/components/outputs/switch) to route each partition to its own output, writing partitions in parallel whilst keeping the order of messages of each key.
+
+### Metadata
+
+Messages have the metadata field `+"`partition_batch_index`"+` added, containing the index of their partition starting from zero.`).
+		Field(service.NewInterpolatedStringField(pbFieldKey).
+			Description("An interpolated string that resolves the key of each message.").
+			Example(`${! json("customer_id") }`).
+			Example(`${! meta("kafka_key") }`)).
+		Field(service.NewIntField(pbFieldPartitions).
+			Description("The number of partitions to split batches into.").
+			Example(4)).
+		Example("Parallel Ordered Writes", `
+Here we consume orders from Kafka in batches and write them to three instances of a service in parallel, where all orders of a customer are always sent to the same instance in the order that they were consumed:`,
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ orders ]
+    consumer_group: benthos
+    batching:
+      count: 300
+      period: 1s
+      processors:
+        - partition_batch:
+            key: ${! json("customer_id") }
+            partitions: 3
+
+output:
+  switch:
+    cases:
+      - check: meta("partition_batch_index") == "0"
+        output:
+          http_client:
+            url: http://orders-0:8080/orders
+            batch_as_multipart: true
+      - check: meta("partition_batch_index") == "1"
+        output:
+          http_client:
+            url: http://orders-1:8080/orders
+            batch_as_multipart: true
+      - check: meta("partition_batch_index") == "2"
+        output:
+          http_client:
+            url: http://orders-2:8080/orders
+            batch_as_multipart: true
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"partition_batch", partitionBatchProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newPartitionBatchProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type partitionBatchProcessor struct {
+	key        *service.InterpolatedString
+	partitions int
+}
+
+func newPartitionBatchProcessorFromConfig(conf *service.ParsedConfig) (*partitionBatchProcessor, error) {
+	p := &partitionBatchProcessor{}
+
+	var err error
+	if p.key, err = conf.FieldInterpolatedString(pbFieldKey); err != nil {
+		return nil, err
+	}
+	if p.partitions, err = conf.FieldInt(pbFieldPartitions); err != nil {
+		return nil, err
+	}
+	if p.partitions < 1 {
+		return nil, errors.New("partitions must be at least 1")
+	}
+	return p, nil
+}
+
+// partitionOf returns the partition that a key belongs to.
+func (p *partitionBatchProcessor) partitionOf(key string) int {
+	return int(xxhash.ChecksumString64(key) % uint64(p.partitions))
+}
+
+func (p *partitionBatchProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	partitions := make([]service.MessageBatch, p.partitions)
+	for i, msg := range batch {
+		index := p.partitionOf(batch.InterpolatedString(i, p.key))
+
+		msg = msg.Copy()
+		msg.MetaSet("partition_batch_index", strconv.Itoa(index))
+		partitions[index] = append(partitions[index], msg)
+	}
+
+	var output []service.MessageBatch
+	for _, b := range partitions {
+		if len(b) > 0 {
+			output = append(output, b)
+		}
+	}
+	return output, nil
+}
+
+func (p *partitionBatchProcessor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testPartitionBatchProcessor(t *testing.T, confStr string) *partitionBatchProcessor {
+	t.Helper()
+
+	conf, err := partitionBatchProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newPartitionBatchProcessorFromConfig(conf)
+	require.NoError(t, err)
+	return proc
+}
+
+func TestPartitionBatchProcessor(t *testing.T) {
+	proc := testPartitionBatchProcessor(t, `
+key: ${! meta("key") }
+partitions: 4
+`)
+
+	var input service.MessageBatch
+	for i := 0; i < 100; i++ {
+		msg := service.NewMessage([]byte(strconv.Itoa(i)))
+		msg.MetaSet("key", fmt.Sprintf("key%v", i%10))
+		input = append(input, msg)
+	}
+
+	batches, err := proc.ProcessBatch(context.Background(), input)
+	require.NoError(t, err)
+	require.Greater(t, len(batches), 1)
+
+	var total int
+	lastIndex := -1
+	keyPartitions := map[string]string{}
+	lastN := map[string]int{}
+	for _, b := range batches {
+		require.NotEmpty(t, b)
+
+		index, _ := b[0].MetaGet("partition_batch_index")
+		i, err := strconv.Atoi(index)
+		require.NoError(t, err)
+		assert.Greater(t, i, lastIndex, "partitions are emitted in ascending order")
+		lastIndex = i
+
+		for _, msg := range b {
+			total++
+
+			msgIndex, _ := msg.MetaGet("partition_batch_index")
+			assert.Equal(t, index, msgIndex)
+
+			key, _ := msg.MetaGet("key")
+			if prev, exists := keyPartitions[key]; exists {
+				assert.Equal(t, prev, index, "key %v spans partitions", key)
+			}
+			keyPartitions[key] = index
+
+			nBytes, err := msg.AsBytes()
+			require.NoError(t, err)
+			n, err := strconv.Atoi(string(nBytes))
+			require.NoError(t, err)
+			if prev, exists := lastN[key]; exists {
+				assert.Greater(t, n, prev, "order of key %v not preserved", key)
+			}
+			lastN[key] = n
+		}
+	}
+	assert.Equal(t, 100, total)
+	assert.Len(t, keyPartitions, 10)
+}
+
+func TestPartitionBatchProcessorStable(t *testing.T) {
+	proc := testPartitionBatchProcessor(t, `
+key: ${! content() }
+partitions: 3
+`)
+
+	// The partition of a key must not change between versions as it determines
+	// the routing of keys by downstream outputs.
+	for key, exp := range map[string]int{
+		"foo": 2,
+		"bar": 1,
+		"baz": 1,
+		"qux": 1,
+	} {
+		assert.Equal(t, exp, proc.partitionOf(key), key)
+	}
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("foo")),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0], 2)
+}
+
+func TestPartitionBatchProcessorBadConfig(t *testing.T) {
+	conf, err := partitionBatchProcessorConfig().ParseYAML(`
+key: foo
+partitions: 0
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newPartitionBatchProcessorFromConfig(conf)
+	require.Error(t, err)
+}
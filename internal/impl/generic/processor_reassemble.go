@@ -0,0 +1,345 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func reassembleProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Buffers the fragments of messages in a cache resource and emits the reassembled payload once all fragments have arrived, for protocols that split events across multiple messages.").
+		Description(`
+Each message is a fragment identified by the `+"`id`"+` of the event it belongs to, along with its `+"`index`"+` within the event and the total `+"`count`"+` of fragments. Fragments are written to the cache `+"`resource`"+` and removed from the pipeline until the final fragment of an event arrives, at which point a single message is emitted containing the payloads of all fragments concatenated in index order. Duplicate fragments are dropped.
+
+The emitted message carries the metadata of the first fragment received for the event. When an event remains incomplete for longer than the `+"`timeout`"+` the fragments received so far are emitted as a single message flagged as failed, which can be handled with [error handling patterns](/docs/configuration/error_handling). Timeouts are checked each time a message is processed, and therefore an expired event is only emitted once a subsequent message arrives.
+
+Since fragments are stored in a cache they survive restarts when the cache is persistent, and fragments of an event can be consumed by any instance sharing a cache such as `+"`redis`"+`. However, only the instance that received the first fragment of an event tracks its timeout, and fragments of the same event must not be processed concurrently by multiple instances.
+
+The following keys are used within the cache for each event:
+
+`+"```text"+`
+<id>/manifest
+<id>/<index>
+`+"```"+``).
+		Field(service.NewStringField("resource").
+			Description("The [`cache` resource](/docs/components/caches/about) to store fragments in.")).
+		Field(service.NewInterpolatedStringField("id").
+			Description("An identifier shared by all fragments of an event.").
+			Default(`${! meta("fragment_id") }`)).
+		Field(service.NewInterpolatedStringField("index").
+			Description("The index of a fragment within its event, starting from zero.").
+			Default(`${! meta("fragment_index") }`)).
+		Field(service.NewInterpolatedStringField("count").
+			Description("The total number of fragments of the event.").
+			Default(`${! meta("fragment_count") }`)).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for all fragments of an event to arrive before emitting those received as a failed message. Set to `0s` in order to wait indefinitely.").
+			Default("1m")).
+		Example("Reassembling Fragments", `
+Here we reassemble events that are published to a Kafka topic as fragments, where each fragment is a JSON document containing a base64 encoded piece of the event:`,
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ fragments ]
+    consumer_group: benthos
+
+pipeline:
+  processors:
+    - bloblang: |
+        meta fragment_id = this.event_id
+        meta fragment_index = this.part
+        meta fragment_count = this.parts
+        root = this.data.decode("base64")
+    - reassemble:
+        resource: fragments
+        timeout: 5m
+
+cache_resources:
+  - label: fragments
+    redis:
+      url: tcp://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"reassemble", reassembleProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newReassembleProcessorFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// reassembleManifest is stored in the cache for each incomplete event.
+type reassembleManifest struct {
+	Count    int               `json:"count"`
+	Received []int             `json:"received"`
+	Created  int64             `json:"created"`
+	Meta     map[string]string `json:"meta"`
+}
+
+func (m *reassembleManifest) has(index int) bool {
+	for _, i := range m.Received {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+type reassembleProcessor struct {
+	resource string
+	mgr      cacheProvider
+
+	id, index, count *service.InterpolatedString
+	timeout          time.Duration
+
+	nowFn func() time.Time
+
+	mut     sync.Mutex
+	pending map[string]time.Time
+}
+
+func newReassembleProcessorFromConfig(conf *service.ParsedConfig, mgr cacheProvider) (*reassembleProcessor, error) {
+	r := &reassembleProcessor{
+		mgr:     mgr,
+		nowFn:   time.Now,
+		pending: map[string]time.Time{},
+	}
+
+	var err error
+	if r.resource, err = conf.FieldString("resource"); err != nil {
+		return nil, err
+	}
+	if r.id, err = conf.FieldInterpolatedString("id"); err != nil {
+		return nil, err
+	}
+	if r.index, err = conf.FieldInterpolatedString("index"); err != nil {
+		return nil, err
+	}
+	if r.count, err = conf.FieldInterpolatedString("count"); err != nil {
+		return nil, err
+	}
+	if r.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func manifestKey(id string) string {
+	return id + "/manifest"
+}
+
+func fragmentKey(id string, index int) string {
+	return id + "/" + strconv.Itoa(index)
+}
+
+func (r *reassembleProcessor) ttl() *time.Duration {
+	if r.timeout <= 0 {
+		return nil
+	}
+	// Entries outlive the timeout so that they can still be emitted once it
+	// expires, but are eventually cleaned up when that doesn't happen.
+	ttl := r.timeout * 2
+	return &ttl
+}
+
+func (r *reassembleProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	id := r.id.String(msg)
+	if id == "" {
+		return nil, errors.New("fragment id is empty")
+	}
+	index, err := strconv.Atoi(r.index.String(msg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fragment index: %w", err)
+	}
+	count, err := strconv.Atoi(r.count.String(msg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fragment count: %w", err)
+	}
+	if count <= 0 || index < 0 || index >= count {
+		return nil, fmt.Errorf("fragment index %v out of range of count %v", index, count)
+	}
+	data, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	var cErr error
+	var batch service.MessageBatch
+	if err := r.mgr.AccessCache(ctx, r.resource, func(c service.Cache) {
+		batch, cErr = r.expire(ctx, c, msg)
+		if cErr != nil {
+			return
+		}
+
+		var reassembled *service.Message
+		if reassembled, cErr = r.addFragment(ctx, c, id, index, count, data, msg); reassembled != nil {
+			batch = append(batch, reassembled)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if cErr != nil {
+		return nil, cErr
+	}
+	return batch, nil
+}
+
+// addFragment stores a fragment and returns the reassembled message when it
+// completes its event.
+func (r *reassembleProcessor) addFragment(ctx context.Context, c service.Cache, id string, index, count int, data []byte, msg *service.Message) (*service.Message, error) {
+	manifest, err := r.getManifest(ctx, c, id)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		manifest = &reassembleManifest{
+			Count:   count,
+			Created: r.nowFn().UnixNano(),
+			Meta:    map[string]string{},
+		}
+		_ = msg.MetaWalk(func(k, v string) error {
+			manifest.Meta[k] = v
+			return nil
+		})
+		if r.timeout > 0 {
+			r.pending[id] = time.Unix(0, manifest.Created)
+		}
+	}
+	if manifest.Count != count {
+		return nil, fmt.Errorf("fragment count %v does not match count %v of previous fragments", count, manifest.Count)
+	}
+	if manifest.has(index) {
+		return nil, nil
+	}
+
+	if err := c.Set(ctx, fragmentKey(id, index), data, r.ttl()); err != nil {
+		return nil, err
+	}
+	manifest.Received = append(manifest.Received, index)
+	if len(manifest.Received) < manifest.Count {
+		mBytes, err := json.Marshal(manifest)
+		if err != nil {
+			return nil, err
+		}
+		return nil, c.Set(ctx, manifestKey(id), mBytes, r.ttl())
+	}
+	return r.collect(ctx, c, id, manifest, msg)
+}
+
+func (r *reassembleProcessor) getManifest(ctx context.Context, c service.Cache, id string) (*reassembleManifest, error) {
+	mBytes, err := c.Get(ctx, manifestKey(id))
+	if errors.Is(err, service.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest reassembleManifest
+	if err := json.Unmarshal(mBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %v: %w", id, err)
+	}
+	return &manifest, nil
+}
+
+// collect reads the received fragments of an event from the cache, removes
+// them, and returns them concatenated in index order as a message derived from
+// the message being processed.
+func (r *reassembleProcessor) collect(ctx context.Context, c service.Cache, id string, manifest *reassembleManifest, from *service.Message) (*service.Message, error) {
+	sort.Ints(manifest.Received)
+
+	var payload []byte
+	for _, i := range manifest.Received {
+		data, err := c.Get(ctx, fragmentKey(id, i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fragment %v of %v: %w", i, id, err)
+		}
+		payload = append(payload, data...)
+	}
+	for _, i := range manifest.Received {
+		if err := c.Delete(ctx, fragmentKey(id, i)); err != nil && !errors.Is(err, service.ErrKeyNotFound) {
+			return nil, err
+		}
+	}
+	if err := c.Delete(ctx, manifestKey(id)); err != nil && !errors.Is(err, service.ErrKeyNotFound) {
+		return nil, err
+	}
+	delete(r.pending, id)
+
+	msg := from.Copy()
+	msg.SetBytes(payload)
+
+	var keys []string
+	_ = msg.MetaWalk(func(k, _ string) error {
+		keys = append(keys, k)
+		return nil
+	})
+	for _, k := range keys {
+		msg.MetaDelete(k)
+	}
+	for k, v := range manifest.Meta {
+		msg.MetaSet(k, v)
+	}
+	return msg, nil
+}
+
+// expire emits the fragments of events that have exceeded the timeout.
+func (r *reassembleProcessor) expire(ctx context.Context, c service.Cache, from *service.Message) (service.MessageBatch, error) {
+	if r.timeout <= 0 {
+		return nil, nil
+	}
+
+	var expired []string
+	now := r.nowFn()
+	for id, created := range r.pending {
+		if now.Sub(created) >= r.timeout {
+			expired = append(expired, id)
+		}
+	}
+	sort.Strings(expired)
+
+	var batch service.MessageBatch
+	for _, id := range expired {
+		manifest, err := r.getManifest(ctx, c, id)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			// Completed or expired by another instance.
+			delete(r.pending, id)
+			continue
+		}
+		msg, err := r.collect(ctx, c, id, manifest, from)
+		if err != nil {
+			return nil, err
+		}
+		msg.SetError(fmt.Errorf("timed out waiting for fragments of %v, received %v of %v", id, len(manifest.Received), manifest.Count))
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+func (r *reassembleProcessor) Close(ctx context.Context) error {
+	return nil
+}
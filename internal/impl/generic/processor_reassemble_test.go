@@ -0,0 +1,125 @@
+package generic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testReassembleProcessor(t *testing.T, confStr string) (*reassembleProcessor, *time.Time, service.Cache) {
+	t.Helper()
+
+	conf, err := reassembleProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	memCache := newMemCache(time.Hour, 0, 1, nil)
+	proc, err := newReassembleProcessorFromConfig(conf, &mockCacheProv{
+		caches: map[string]service.Cache{"foo": memCache},
+	})
+	require.NoError(t, err)
+
+	now := time.Unix(1600000000, 0)
+	proc.nowFn = func() time.Time {
+		return now
+	}
+	return proc, &now, memCache
+}
+
+func testFragment(id, index, count, data string) *service.Message {
+	msg := service.NewMessage([]byte(data))
+	msg.MetaSet("fragment_id", id)
+	msg.MetaSet("fragment_index", index)
+	msg.MetaSet("fragment_count", count)
+	return msg
+}
+
+func TestReassembleProcessor(t *testing.T) {
+	proc, _, memCache := testReassembleProcessor(t, `
+resource: foo
+`)
+	ctx := context.Background()
+
+	first := testFragment("a", "2", "3", "ghi")
+	first.MetaSet("source", "first")
+
+	for _, msg := range []*service.Message{
+		first,
+		testFragment("b", "0", "2", "123"),
+		testFragment("a", "0", "3", "abc"),
+		testFragment("a", "0", "3", "abc"),
+	} {
+		batch, err := proc.Process(ctx, msg)
+		require.NoError(t, err)
+		assert.Empty(t, batch)
+	}
+
+	batch, err := proc.Process(ctx, testFragment("a", "1", "3", "def"))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "abcdefghi", string(b))
+
+	v, _ := batch[0].MetaGet("source")
+	assert.Equal(t, "first", v)
+	v, _ = batch[0].MetaGet("fragment_index")
+	assert.Equal(t, "2", v)
+
+	for _, k := range []string{"a/manifest", "a/0", "a/1", "a/2"} {
+		_, err := memCache.Get(ctx, k)
+		assert.Equal(t, service.ErrKeyNotFound, err, k)
+	}
+	_, err = memCache.Get(ctx, "b/manifest")
+	assert.NoError(t, err)
+
+	_, err = proc.Process(ctx, testFragment("c", "3", "3", "nope"))
+	assert.Error(t, err)
+
+	_, err = proc.Process(ctx, testFragment("b", "1", "3", "nope"))
+	assert.Error(t, err)
+}
+
+func TestReassembleProcessorTimeout(t *testing.T) {
+	proc, now, _ := testReassembleProcessor(t, `
+resource: foo
+timeout: 1m
+`)
+	ctx := context.Background()
+
+	for _, msg := range []*service.Message{
+		testFragment("a", "0", "3", "abc"),
+		testFragment("a", "2", "3", "ghi"),
+	} {
+		batch, err := proc.Process(ctx, msg)
+		require.NoError(t, err)
+		assert.Empty(t, batch)
+	}
+
+	*now = now.Add(time.Minute)
+
+	batch, err := proc.Process(ctx, testFragment("b", "0", "1", "123"))
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "abcghi", string(b))
+	require.Error(t, batch[0].GetError())
+	assert.Equal(t, "timed out waiting for fragments of a, received 2 of 3", batch[0].GetError().Error())
+
+	b, err = batch[1].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "123", string(b))
+	assert.NoError(t, batch[1].GetError())
+
+	// A late fragment starts a new event.
+	batch, err = proc.Process(ctx, testFragment("a", "1", "3", "def"))
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+}
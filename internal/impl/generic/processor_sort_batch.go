@@ -0,0 +1,254 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func sortBatchProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Sorts the messages of a batch by a value obtained from each message with a Bloblang query, and optionally drops adjacent duplicates.").
+		Description(`
+The `+"`by`"+` query is executed for each message of the batch and the messages are sorted by the results. The sort is stable, and therefore messages that resolve to equal values keep their original order.
+
+Values are compared according to their type, where numbers are compared numerically, strings are compared lexicographically and `+"`false`"+` is ordered before `+"`true`"+`. When values of different types are compared they are ordered by type, with `+"`null`"+` first followed by booleans, numbers, strings, arrays and finally objects. Arrays are compared element by element, which allows sorting by multiple values, and objects are compared by their JSON serialisation. Timestamps that are not formatted consistently should be converted into numbers, such as with the method `+"[`format_timestamp_unix`](/docs/guides/bloblang/methods#format_timestamp_unix)"+`.
+
+Messages for which the query fails are flagged as failed and placed at the end of the batch in their original order, and can be handled with [error handling patterns](/docs/configuration/error_handling).`).
+		Field(service.NewBloblangField("by").
+			Description("A [Bloblang query](/docs/guides/bloblang/about) that resolves the value to sort each message by.").
+			Example("this.timestamp").
+			Example(`meta("kafka_offset").number()`)).
+		Field(service.NewStringEnumField("direction", "ascending", "descending").
+			Description("The direction in which to sort messages.").
+			Default("ascending")).
+		Field(service.NewBoolField("deduplicate").
+			Description("Whether to drop messages that resolve to a value equal to that of the preceding message after sorting, keeping only the first message of each run of equal values.").
+			Default(false)).
+		Example("Ordered Window Results", `
+Here we consume events from Kafka and group them into windows of ten seconds, emitting the events of each window sorted by their timestamp and then by their sequence number, with duplicate events removed:`,
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ events ]
+    consumer_group: benthos
+    batching:
+      period: 10s
+      processors:
+        - sort_batch:
+            by: '[ this.timestamp.format_timestamp_unix_nano(), this.sequence ]'
+            deduplicate: true
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"sort_batch", sortBatchProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newSortBatchProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type sortBatchProcessor struct {
+	by          *bloblang.Executor
+	descending  bool
+	deduplicate bool
+}
+
+func newSortBatchProcessorFromConfig(conf *service.ParsedConfig) (*sortBatchProcessor, error) {
+	s := &sortBatchProcessor{}
+
+	var err error
+	if s.by, err = conf.FieldBloblang("by"); err != nil {
+		return nil, err
+	}
+	direction, err := conf.FieldString("direction")
+	if err != nil {
+		return nil, err
+	}
+	switch direction {
+	case "ascending":
+	case "descending":
+		s.descending = true
+	default:
+		return nil, fmt.Errorf("direction not recognised: %v", direction)
+	}
+	if s.deduplicate, err = conf.FieldBool("deduplicate"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sortBatchProcessor) sortKey(batch service.MessageBatch, index int) (interface{}, error) {
+	res, err := batch.BloblangQuery(index, s.by)
+	if err != nil || res == nil {
+		return nil, err
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		var raw []byte
+		if raw, err = res.AsBytes(); err != nil {
+			return nil, err
+		}
+		v = string(raw)
+	}
+	return v, nil
+}
+
+func (s *sortBatchProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	type keyedMessage struct {
+		msg *service.Message
+		key interface{}
+	}
+
+	keyed := make([]keyedMessage, 0, len(batch))
+	var failed service.MessageBatch
+
+	for i, msg := range batch {
+		key, err := s.sortKey(batch, i)
+		if err != nil {
+			msg = msg.Copy()
+			msg.SetError(fmt.Errorf("failed to resolve sort value: %w", err))
+			failed = append(failed, msg)
+			continue
+		}
+		keyed = append(keyed, keyedMessage{msg: msg, key: key})
+	}
+
+	sort.SliceStable(keyed, func(i, j int) bool {
+		c := compareSortValues(keyed[i].key, keyed[j].key)
+		if s.descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	output := make(service.MessageBatch, 0, len(batch))
+	for i, k := range keyed {
+		if s.deduplicate && i > 0 && compareSortValues(keyed[i-1].key, k.key) == 0 {
+			continue
+		}
+		output = append(output, k.msg)
+	}
+	output = append(output, failed...)
+
+	if len(output) == 0 {
+		return nil, nil
+	}
+	return []service.MessageBatch{output}, nil
+}
+
+func (s *sortBatchProcessor) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// sortValueRank returns the position of the type of a value within the order
+// used when comparing values of different types.
+func sortValueRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case json.Number, int64, float64:
+		return 2
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	}
+	return 5
+}
+
+func sortValueNumber(v interface{}) (i int64, f float64, isInt bool) {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, float64(i), true
+		}
+		f, err := t.Float64()
+		if err != nil {
+			f = math.NaN()
+		}
+		return 0, f, false
+	case int64:
+		return t, float64(t), true
+	case float64:
+		return 0, t, false
+	}
+	return 0, math.NaN(), false
+}
+
+// compareSortValues returns a negative number when l is ordered before r, a
+// positive number when l is ordered after r, and zero when they are equal.
+func compareSortValues(l, r interface{}) int {
+	lRank, rRank := sortValueRank(l), sortValueRank(r)
+	if lRank != rRank {
+		return lRank - rRank
+	}
+
+	switch lRank {
+	case 0:
+		return 0
+	case 1:
+		lb, rb := l.(bool), r.(bool)
+		switch {
+		case lb == rb:
+			return 0
+		case !lb:
+			return -1
+		}
+		return 1
+	case 2:
+		li, lf, lIsInt := sortValueNumber(l)
+		ri, rf, rIsInt := sortValueNumber(r)
+		if lIsInt && rIsInt {
+			switch {
+			case li < ri:
+				return -1
+			case li > ri:
+				return 1
+			}
+			return 0
+		}
+		switch {
+		case lf < rf:
+			return -1
+		case lf > rf:
+			return 1
+		}
+		return 0
+	case 3:
+		return strings.Compare(l.(string), r.(string))
+	case 4:
+		la, ra := l.([]interface{}), r.([]interface{})
+		for i := 0; i < len(la) && i < len(ra); i++ {
+			if c := compareSortValues(la[i], ra[i]); c != 0 {
+				return c
+			}
+		}
+		return len(la) - len(ra)
+	}
+
+	lBytes, _ := json.Marshal(l)
+	rBytes, _ := json.Marshal(r)
+	return strings.Compare(string(lBytes), string(rBytes))
+}
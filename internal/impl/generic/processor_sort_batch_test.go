@@ -0,0 +1,107 @@
+package generic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testSortBatchProcessor(t *testing.T, confStr string) *sortBatchProcessor {
+	t.Helper()
+
+	conf, err := sortBatchProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newSortBatchProcessorFromConfig(conf)
+	require.NoError(t, err)
+	return proc
+}
+
+func sortBatchInput(contents ...string) service.MessageBatch {
+	batch := make(service.MessageBatch, len(contents))
+	for i, c := range contents {
+		batch[i] = service.NewMessage([]byte(c))
+	}
+	return batch
+}
+
+func TestSortBatchProcessor(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   string
+		input  []string
+		output []string
+	}{
+		{
+			name:   "numbers ascending",
+			conf:   `by: this.n`,
+			input:  []string{`{"n":10}`, `{"n":2}`, `{"n":-1.5}`, `{"n":2.5}`},
+			output: []string{`{"n":-1.5}`, `{"n":2}`, `{"n":2.5}`, `{"n":10}`},
+		},
+		{
+			name: "strings descending",
+			conf: `
+by: this.s
+direction: descending
+`,
+			input:  []string{`{"s":"b"}`, `{"s":"c"}`, `{"s":"a"}`},
+			output: []string{`{"s":"c"}`, `{"s":"b"}`, `{"s":"a"}`},
+		},
+		{
+			name:   "stable with mixed types",
+			conf:   `by: this.v`,
+			input:  []string{`{"v":"a","i":0}`, `{"v":1}`, `{"v":true}`, `{"i":1}`, `{"v":"a","i":2}`, `{"v":false}`},
+			output: []string{`{"i":1}`, `{"v":false}`, `{"v":true}`, `{"v":1}`, `{"v":"a","i":0}`, `{"v":"a","i":2}`},
+		},
+		{
+			name:   "arrays",
+			conf:   `by: '[ this.a, this.b ]'`,
+			input:  []string{`{"a":2,"b":1}`, `{"a":1,"b":10}`, `{"a":1,"b":9}`},
+			output: []string{`{"a":1,"b":9}`, `{"a":1,"b":10}`, `{"a":2,"b":1}`},
+		},
+		{
+			name: "deduplicate",
+			conf: `
+by: meta("id").number()
+deduplicate: true
+`,
+			input:  []string{`3`, `1`, `2`, `1`, `3`},
+			output: []string{`1`, `2`, `3`},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			proc := testSortBatchProcessor(t, test.conf)
+
+			batch := sortBatchInput(test.input...)
+			for _, m := range batch {
+				b, _ := m.AsBytes()
+				m.MetaSet("id", string(b))
+			}
+
+			res, err := proc.ProcessBatch(context.Background(), batch)
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+			assert.Equal(t, test.output, chunkPayloads(t, res[0]))
+		})
+	}
+}
+
+func TestSortBatchProcessorErrors(t *testing.T) {
+	proc := testSortBatchProcessor(t, `by: this.n.number()`)
+
+	res, err := proc.ProcessBatch(context.Background(), sortBatchInput(`{"n":"nope"}`, `{"n":2}`, `{"n":1}`))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`, `{"n":"nope"}`}, chunkPayloads(t, res[0]))
+
+	assert.NoError(t, res[0][0].GetError())
+	require.Error(t, res[0][2].GetError())
+	assert.Contains(t, res[0][2].GetError().Error(), "failed to resolve sort value")
+}
@@ -0,0 +1,230 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	tpFieldOutput       = "output"
+	tpFieldPercentage   = "percentage"
+	tpFieldMaxPerSecond = "max_per_second"
+	tpFieldMaxPending   = "max_pending"
+)
+
+func tapProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Asynchronously copies a sampled subset of messages to an output resource without modifying them or affecting the latency and acknowledgements of the pipeline.").
+		Description(`
+Messages pass through this processor unchanged, and a copy of each sampled message is sent to the `+"[output resource](/docs/components/outputs/about#resources)"+` named by `+"`output`"+` in the background. This is useful for continuously sampling data for quality checks or debugging, where the sampled copies must never hold up or fail the main pipeline.
+
+Messages are sampled randomly according to `+"`percentage`"+`, and the number of copies can additionally be capped with `+"`max_per_second`"+`. Copies of a batch are written to the output as a batch.
+
+Copies are queued in memory whilst the output is busy, and when more than `+"`max_pending`"+` batches of copies are queued further copies are dropped rather than applying back pressure. Since copies are written independently of the pipeline they are not acknowledged with the original messages, and copies that fail to be written are logged and dropped.
+
+### Metrics
+
+This processor emits the counters `+"`tap_sent`"+`, `+"`tap_dropped`"+` and `+"`tap_error`"+`, which count the copies written, dropped due to a full queue, and that failed to be written respectively.`).
+		Field(service.NewStringField(tpFieldOutput).
+			Description("The name of an [output resource](/docs/components/outputs/about#resources) to write copies of messages to.")).
+		Field(service.NewFloatField(tpFieldPercentage).
+			Description("The percentage of messages to sample, between 0 and 100.").
+			Default(100.0).
+			Example(1.0).
+			Example(0.1)).
+		Field(service.NewIntField(tpFieldMaxPerSecond).
+			Description("An optional maximum number of messages to sample each second, where zero means unlimited.").
+			Default(0)).
+		Field(service.NewIntField(tpFieldMaxPending).
+			Description("The maximum number of batches of copies to queue whilst the output is busy, after which copies are dropped.").
+			Default(100).
+			Advanced()).
+		Example("Data Quality Sampling", `
+Here we send one percent of messages, and no more than ten each second, to a separate Kafka topic where their quality is checked by another service:`,
+			`
+pipeline:
+  processors:
+    - tap:
+        output: quality_samples
+        percentage: 1
+        max_per_second: 10
+
+output_resources:
+  - label: quality_samples
+    kafka:
+      addresses: [ localhost:9092 ]
+      topic: quality_samples
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"tap", tapProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newTapProcessorFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type tapProcessor struct {
+	output       string
+	percentage   float64
+	maxPerSecond int
+
+	mgr *service.Resources
+	log *service.Logger
+
+	mSent    *service.MetricCounter
+	mDropped *service.MetricCounter
+	mError   *service.MetricCounter
+
+	nowFn  func() time.Time
+	randFn func() float64
+
+	mut         sync.Mutex
+	windowStart time.Time
+	windowCount int
+
+	pending   chan service.MessageBatch
+	closeOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func newTapProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*tapProcessor, error) {
+	t := &tapProcessor{
+		mgr:      mgr,
+		log:      mgr.Logger(),
+		mSent:    mgr.Metrics().NewCounter("tap_sent"),
+		mDropped: mgr.Metrics().NewCounter("tap_dropped"),
+		mError:   mgr.Metrics().NewCounter("tap_error"),
+		nowFn:    time.Now,
+		randFn:   rand.Float64,
+		done:     make(chan struct{}),
+	}
+
+	var err error
+	if t.output, err = conf.FieldString(tpFieldOutput); err != nil {
+		return nil, err
+	}
+	if !mgr.HasOutput(t.output) {
+		return nil, errors.New("output resource '" + t.output + "' was not found")
+	}
+	if t.percentage, err = conf.FieldFloat(tpFieldPercentage); err != nil {
+		return nil, err
+	}
+	if t.percentage < 0 || t.percentage > 100 {
+		return nil, errors.New("percentage must be between 0 and 100")
+	}
+	if t.maxPerSecond, err = conf.FieldInt(tpFieldMaxPerSecond); err != nil {
+		return nil, err
+	}
+	if t.maxPerSecond < 0 {
+		return nil, errors.New("max_per_second must not be negative")
+	}
+
+	maxPending, err := conf.FieldInt(tpFieldMaxPending)
+	if err != nil {
+		return nil, err
+	}
+	if maxPending < 1 {
+		return nil, errors.New("max_pending must be at least 1")
+	}
+	t.pending = make(chan service.MessageBatch, maxPending)
+
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	go t.loop()
+	return t, nil
+}
+
+// sample returns whether a message should be copied.
+func (t *tapProcessor) sample() bool {
+	if t.percentage < 100 && t.randFn()*100 >= t.percentage {
+		return false
+	}
+	if t.maxPerSecond == 0 {
+		return true
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	now := t.nowFn()
+	if now.Sub(t.windowStart) >= time.Second {
+		t.windowStart = now
+		t.windowCount = 0
+	}
+	if t.windowCount >= t.maxPerSecond {
+		return false
+	}
+	t.windowCount++
+	return true
+}
+
+func (t *tapProcessor) loop() {
+	defer close(t.done)
+
+	for batch := range t.pending {
+		var writeErr error
+		if err := t.mgr.AccessOutput(t.ctx, t.output, func(o *service.ResourceOutput) {
+			writeErr = o.WriteBatch(t.ctx, batch)
+		}); err != nil {
+			writeErr = err
+		}
+		if writeErr != nil {
+			t.mError.Incr(int64(len(batch)))
+			t.log.Debugf("Failed to write sampled messages to output '%v': %v", t.output, writeErr)
+			continue
+		}
+		t.mSent.Incr(int64(len(batch)))
+	}
+}
+
+func (t *tapProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	var copies service.MessageBatch
+	for _, m := range batch {
+		if t.sample() {
+			copies = append(copies, m.Copy())
+		}
+	}
+
+	if len(copies) > 0 {
+		select {
+		case t.pending <- copies:
+		default:
+			t.mDropped.Incr(int64(len(copies)))
+		}
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (t *tapProcessor) Close(ctx context.Context) error {
+	t.closeOnce.Do(func() {
+		close(t.pending)
+	})
+
+	// Copies that are pending once the processor is closed are still written,
+	// unless closing exceeds its deadline.
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		t.cancel()
+		return ctx.Err()
+	}
+	t.cancel()
+	return nil
+}
@@ -0,0 +1,154 @@
+package generic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestTapProcessorStream(t *testing.T) {
+	tapPath := filepath.Join(t.TempDir(), "tap.txt")
+
+	strmBuilder := service.NewStreamBuilder()
+	require.NoError(t, strmBuilder.SetLoggerYAML(`level: NONE`))
+	require.NoError(t, strmBuilder.AddInputYAML(`
+generate:
+  interval: 1ns
+  count: 5
+  mapping: 'root = "hello world " + count("tap_test").string()'
+`))
+	require.NoError(t, strmBuilder.AddProcessorYAML(`
+tap:
+  output: samples
+`))
+	require.NoError(t, strmBuilder.AddResourcesYAML(`
+output_resources:
+  - label: samples
+    file:
+      path: `+tapPath+`
+      codec: lines
+`))
+
+	var outMut sync.Mutex
+	var out []string
+	require.NoError(t, strmBuilder.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+		outMut.Lock()
+		out = append(out, string(b))
+		outMut.Unlock()
+		return nil
+	}))
+
+	strm, err := strmBuilder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+
+	exp := []string{
+		"hello world 1",
+		"hello world 2",
+		"hello world 3",
+		"hello world 4",
+		"hello world 5",
+	}
+	assert.Equal(t, exp, out)
+
+	tapBytes, err := os.ReadFile(tapPath)
+	require.NoError(t, err)
+	assert.Equal(t, exp, strings.Split(strings.TrimSpace(string(tapBytes)), "\n"))
+}
+
+func TestTapProcessorMissingOutput(t *testing.T) {
+	strmBuilder := service.NewStreamBuilder()
+	require.NoError(t, strmBuilder.SetYAML(`
+input:
+  generate:
+    count: 1
+    mapping: 'root = "hello world"'
+
+pipeline:
+  processors:
+    - tap:
+        output: nope
+
+output:
+  drop: {}
+
+logger:
+  level: NONE
+`))
+
+	strm, err := strmBuilder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	err = strm.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "output resource 'nope' was not found")
+}
+
+func TestTapProcessorSample(t *testing.T) {
+	now := time.Unix(100, 0)
+	tp := &tapProcessor{
+		percentage:   50,
+		maxPerSecond: 2,
+		nowFn:        func() time.Time { return now },
+	}
+
+	randValues := []float64{0.1, 0.9, 0.2, 0.3, 0.4}
+	tp.randFn = func() float64 {
+		v := randValues[0]
+		randValues = randValues[1:]
+		return v
+	}
+
+	assert.True(t, tp.sample())
+	assert.False(t, tp.sample(), "above percentage")
+	assert.True(t, tp.sample())
+	assert.False(t, tp.sample(), "above max per second")
+
+	now = now.Add(time.Second)
+	assert.True(t, tp.sample())
+}
+
+func TestTapProcessorDropsWhenFull(t *testing.T) {
+	tp := &tapProcessor{
+		percentage: 100,
+		mDropped:   service.MockResources().Metrics().NewCounter("tap_dropped"),
+		pending:    make(chan service.MessageBatch, 1),
+	}
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+	}
+	for i := 0; i < 2; i++ {
+		res, err := tp.ProcessBatch(context.Background(), batch)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		assert.Equal(t, batch, res[0])
+	}
+
+	require.Len(t, tp.pending, 1)
+	copies := <-tp.pending
+	require.Len(t, copies, 2)
+	b, err := copies[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(b))
+}
@@ -0,0 +1,339 @@
+package generic
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func textAnalysisProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Parsing").
+		Summary("Normalizes the text of messages and annotates them with their detected language and token counts, which is useful for preparing documents for search indexes.").
+		Description(`
+The contents of each message are treated as UTF-8 text and are processed in the following order:
+
+1. The text is normalized to the Unicode form chosen by `+"[`normalization`](#normalization)"+`.
+2. When `+"[`transliterate`](#transliterate)"+` is enabled the text is converted to its closest ASCII representation by removing diacritics and replacing common ligatures and letters without an ASCII decomposition, characters that cannot be transliterated are kept.
+3. When `+"[`detect_language`](#detect_language)"+` is enabled the language of the text is detected and added as the metadata field `+"`text_language`"+`.
+4. When `+"[`count_tokens`](#count_tokens)"+` is enabled the number of words in the text is added as the metadata field `+"`text_token_count`"+`, and the number of characters as `+"`text_char_count`"+`.
+
+### Language Detection
+
+Languages are detected with a lightweight heuristic rather than a statistical model, and the result is an [ISO 639-1](https://en.wikipedia.org/wiki/List_of_ISO_639-1_codes) code. Texts in languages with a distinctive script are identified by the script alone, these are: `+"`ar`, `el`, `he`, `hi`, `ja`, `ko`, `ru`, `th`, `uk` and `zh`"+`. Texts written in the Latin script are identified by their most common words, and the languages recognised are: `+"`cs`, `da`, `de`, `en`, `es`, `fi`, `fr`, `it`, `nl`, `no`, `pl`, `pt`, `ro`, `sv` and `tr`"+`. When a language cannot be determined the value `+"`und`"+` is used, which is also likely for very short texts.
+
+### Token Counts
+
+Words are sequences of letters, marks and numbers separated by any other character, with the exception of characters of the Han, Hiragana and Katakana scripts, which are each counted as an individual token as these languages are written without spaces.`).
+		Field(service.NewStringAnnotatedEnumField("normalization", map[string]string{
+			"none": "Do not normalize the text.",
+			"nfc":  "Canonical decomposition followed by canonical composition.",
+			"nfd":  "Canonical decomposition.",
+			"nfkc": "Compatibility decomposition followed by canonical composition, which also replaces compatibility characters such as ligatures and full width forms with their canonical equivalents.",
+			"nfkd": "Compatibility decomposition.",
+		}).
+			Description("The [Unicode normalization form](https://unicode.org/reports/tr15/) to convert text to.").
+			Default("nfc")).
+		Field(service.NewBoolField("transliterate").
+			Description("Whether to convert the text to its closest ASCII representation.").
+			Default(false)).
+		Field(service.NewBoolField("detect_language").
+			Description("Whether to detect the language of the text and add it as the metadata field `text_language`.").
+			Default(true)).
+		Field(service.NewBoolField("count_tokens").
+			Description("Whether to add the number of words and characters of the text as the metadata fields `text_token_count` and `text_char_count`.").
+			Default(true)).
+		Example("Preparing Documents for Elasticsearch", `
+Here we normalize the body of articles and route them to an index per language:`,
+			`
+pipeline:
+  processors:
+    - branch:
+        request_map: root = this.body
+        processors:
+          - text_analysis:
+              normalization: nfkc
+        result_map: |
+          root.body = content().string()
+          root.language = meta("text_language")
+          root.word_count = meta("text_token_count").number()
+
+output:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: articles-${! json("language") }
+    id: ${! json("id") }
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"text_analysis", textAnalysisProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newTextAnalysisProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type textAnalysisProcessor struct {
+	form           *norm.Form
+	transliterate  bool
+	detectLanguage bool
+	countTokens    bool
+}
+
+func newTextAnalysisProcessorFromConfig(conf *service.ParsedConfig) (*textAnalysisProcessor, error) {
+	normStr, err := conf.FieldString("normalization")
+	if err != nil {
+		return nil, err
+	}
+
+	p := &textAnalysisProcessor{}
+	switch normStr {
+	case "nfc":
+		f := norm.NFC
+		p.form = &f
+	case "nfd":
+		f := norm.NFD
+		p.form = &f
+	case "nfkc":
+		f := norm.NFKC
+		p.form = &f
+	case "nfkd":
+		f := norm.NFKD
+		p.form = &f
+	}
+
+	if p.transliterate, err = conf.FieldBool("transliterate"); err != nil {
+		return nil, err
+	}
+	if p.detectLanguage, err = conf.FieldBool("detect_language"); err != nil {
+		return nil, err
+	}
+	if p.countTokens, err = conf.FieldBool("count_tokens"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (t *textAnalysisProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	b, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(b)
+	if t.form != nil {
+		text = t.form.String(text)
+	}
+	if t.transliterate {
+		text = transliterateASCII(text)
+	}
+
+	msg.SetBytes([]byte(text))
+	if t.detectLanguage {
+		msg.MetaSet("text_language", detectLanguage(text))
+	}
+	if t.countTokens {
+		tokens, chars := countTextTokens(text)
+		msg.MetaSet("text_token_count", strconv.Itoa(tokens))
+		msg.MetaSet("text_char_count", strconv.Itoa(chars))
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (t *textAnalysisProcessor) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Letters that have no canonical decomposition into an ASCII base letter.
+var asciiReplacements = map[rune]string{
+	'ß': "ss", 'ẞ': "SS",
+	'æ': "ae", 'Æ': "AE",
+	'œ': "oe", 'Œ': "OE",
+	'ø': "o", 'Ø': "O",
+	'ł': "l", 'Ł': "L",
+	'đ': "d", 'Đ': "D",
+	'ð': "d", 'Ð': "D",
+	'þ': "th", 'Þ': "TH",
+	'ı': "i",
+	'ħ': "h", 'Ħ': "H",
+	'ŋ': "ng", 'Ŋ': "NG",
+	'‘': "'", '’': "'", '‚': "'",
+	'“': "\"", '”': "\"", '„': "\"",
+	'–': "-", '—': "-",
+	'…': "...",
+	'«': "<<", '»': ">>",
+	' ': " ",
+}
+
+func transliterateASCII(text string) string {
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for _, r := range norm.NFKD.String(text) {
+		if r < unicode.MaxASCII {
+			sb.WriteRune(r)
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if s, exists := asciiReplacements[r]; exists {
+			sb.WriteString(s)
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	// Characters that could not be transliterated are recomposed.
+	return norm.NFC.String(sb.String())
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r)
+}
+
+func countTextTokens(text string) (tokens, chars int) {
+	inWord := false
+	for _, r := range text {
+		chars++
+		switch {
+		case isIdeographic(r):
+			tokens++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r):
+			if !inWord {
+				tokens++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return
+}
+
+//------------------------------------------------------------------------------
+
+// Languages identified by a script that is (mostly) unique to them.
+var languageScripts = []struct {
+	lang  string
+	table *unicode.RangeTable
+}{
+	{"ar", unicode.Arabic},
+	{"el", unicode.Greek},
+	{"he", unicode.Hebrew},
+	{"hi", unicode.Devanagari},
+	{"ko", unicode.Hangul},
+	{"th", unicode.Thai},
+	{"ru", unicode.Cyrillic},
+}
+
+// Common words of languages written in the Latin script.
+var languageStopWords = map[string][]string{
+	"cs": {"a", "je", "se", "na", "že", "to", "v", "jsem", "jak", "ale", "jsou", "také", "který", "pro", "není"},
+	"da": {"og", "det", "er", "en", "til", "på", "jeg", "ikke", "af", "med", "som", "har", "for", "den", "de"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ich", "zu", "den", "mit", "sich", "ein", "eine", "auf", "auch"},
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "was", "for", "with", "you", "this", "are", "have"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "se", "del", "las", "por", "un", "una", "con", "es"},
+	"fi": {"ja", "on", "ei", "se", "että", "hän", "oli", "ole", "mutta", "kun", "niin", "myös", "tämä", "ovat", "joka"},
+	"fr": {"le", "la", "les", "de", "et", "est", "un", "une", "des", "du", "que", "pas", "pour", "dans", "qui"},
+	"it": {"il", "di", "che", "la", "e", "non", "per", "un", "una", "sono", "della", "gli", "con", "del", "è"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "dat", "ik", "op", "zijn", "te", "met", "voor", "ook"},
+	"no": {"og", "det", "er", "en", "til", "på", "jeg", "ikke", "av", "med", "som", "har", "for", "den", "ble"},
+	"pl": {"i", "w", "nie", "na", "się", "jest", "z", "że", "do", "to", "jak", "ale", "są", "o", "dla"},
+	"pt": {"o", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "não", "os", "com", "no", "é"},
+	"ro": {"și", "în", "de", "la", "este", "cu", "nu", "pe", "care", "un", "o", "să", "pentru", "mai", "sunt"},
+	"sv": {"och", "det", "att", "är", "en", "som", "på", "jag", "inte", "av", "med", "för", "den", "har", "till"},
+	"tr": {"ve", "bir", "bu", "da", "de", "için", "ile", "ne", "çok", "değil", "olarak", "gibi", "daha", "ama", "ben"},
+}
+
+var stopWordLanguages = func() map[string][]string {
+	m := map[string][]string{}
+	for lang, words := range languageStopWords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+func detectLanguage(text string) string {
+	scriptCounts := map[string]int{}
+	var kana, han, latin, total int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, s := range languageScripts {
+				if unicode.Is(s.table, r) {
+					scriptCounts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return "und"
+	}
+
+	// Japanese mixes kana with Han characters, and so any meaningful amount
+	// of kana indicates Japanese.
+	if kana > 0 && kana*10 >= kana+han {
+		return "ja"
+	}
+	if han > 0 && han >= latin {
+		return "zh"
+	}
+
+	bestScript, bestScriptCount := "", 0
+	for lang, count := range scriptCounts {
+		if count > bestScriptCount || (count == bestScriptCount && lang < bestScript) {
+			bestScript, bestScriptCount = lang, count
+		}
+	}
+	if bestScriptCount > latin {
+		if bestScript == "ru" && strings.ContainsAny(text, "ієїґІЄЇҐ") {
+			return "uk"
+		}
+		return bestScript
+	}
+
+	scores := map[string]int{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for _, lang := range stopWordLanguages[w] {
+			scores[lang]++
+		}
+	}
+	best, bestScore := "und", 0
+	for lang, score := range scores {
+		if score > bestScore || (score == bestScore && lang < best) {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
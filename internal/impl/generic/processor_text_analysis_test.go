@@ -0,0 +1,105 @@
+package generic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestTextAnalysisProcessor(t *testing.T) {
+	conf, err := textAnalysisProcessorConfig().ParseYAML(`
+normalization: nfkc
+transliterate: true
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newTextAnalysisProcessorFromConfig(conf)
+	require.NoError(t, err)
+
+	batch, err := proc.Process(context.Background(), service.NewMessage([]byte("Die Straße ist schön und der ﬁsch ist nicht teuer – Grüße")))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "Die Strasse ist schon und der fisch ist nicht teuer - Grusse", string(b))
+
+	for k, exp := range map[string]string{
+		"text_language":    "de",
+		"text_token_count": "11",
+		"text_char_count":  "60",
+	} {
+		v, _ := batch[0].MetaGet(k)
+		assert.Equal(t, exp, v, k)
+	}
+}
+
+func TestTextAnalysisNormalization(t *testing.T) {
+	decomposed := "Cafe\u0301"
+	for form, exp := range map[string]string{
+		"none": decomposed,
+		"nfc":  "Caf\u00e9",
+		"nfd":  decomposed,
+		"nfkc": "Caf\u00e9",
+	} {
+		conf, err := textAnalysisProcessorConfig().ParseYAML(`
+normalization: `+form+`
+detect_language: false
+count_tokens: false
+`, nil)
+		require.NoError(t, err)
+
+		proc, err := newTextAnalysisProcessorFromConfig(conf)
+		require.NoError(t, err)
+
+		batch, err := proc.Process(context.Background(), service.NewMessage([]byte(decomposed)))
+		require.NoError(t, err)
+
+		b, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(b), form)
+
+		_, exists := batch[0].MetaGet("text_language")
+		assert.False(t, exists)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for text, exp := range map[string]string{
+		"The quick brown fox jumps over the lazy dog and it was fine": "en",
+		"El perro de la casa es muy grande y los niños juegan con él": "es",
+		"Le chat est sur la table et les enfants sont dans le jardin": "fr",
+		"Il gatto è sul tavolo e non vuole scendere per la cena":      "it",
+		"O gato está em cima da mesa e não quer descer para o jantar": "pt",
+		"De kat zit op de tafel en het is niet van mij":               "nl",
+		"Jag är hemma och det är inte kallt i dag":                    "sv",
+		"Кошка сидит на столе":                                        "ru",
+		"Кішка сидить на столі і їсть":                                "uk",
+		"Η γάτα κάθεται στο τραπέζι":                                  "el",
+		"猫はテーブルの上に座っています":                                             "ja",
+		"猫坐在桌子上":                 "zh",
+		"고양이가 탁자 위에 앉아 있다":       "ko",
+		"القطة تجلس على الطاولة": "ar",
+		"Xyzzy plugh":            "und",
+		"12345 !!":               "und",
+	} {
+		assert.Equal(t, exp, detectLanguage(text), text)
+	}
+}
+
+func TestCountTextTokens(t *testing.T) {
+	for text, exp := range map[string][2]int{
+		"":                         {0, 0},
+		"hello world":              {2, 11},
+		"  it's 2022, isn't it?  ": {6, 24},
+		"猫はテーブル":                   {6, 6},
+		"東京 is big":                {4, 9},
+	} {
+		tokens, chars := countTextTokens(text)
+		assert.Equal(t, exp, [2]int{tokens, chars}, text)
+	}
+}
@@ -0,0 +1,227 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func unchunkProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Reassembles messages that were split by the `chunk` processor from the chunks of a batch.").
+		Description(`
+Chunks are grouped by their `+"`chunk_id`"+` metadata field and, once all chunks of a group are present within the batch, are replaced by a single message containing the original payload along with the metadata of the first chunk, minus the `+"`chunk_*`"+` fields. The reassembled message takes the position of the first chunk of its group within the batch. Duplicate chunks are ignored, and messages without chunk metadata pass through unchanged.
+
+Chunks of groups that are incomplete are placed unchanged at the end of the batch and flagged as failed, and can therefore be handled with [error handling patterns](/docs/configuration/error_handling). All chunks of a message must be consumed within the same batch, which can be achieved with a [batching policy](/docs/configuration/batching) as shown in the example below.`).
+		Example("Reassembling Objects", `
+Here we consume chunks of objects from NATS, written in order with the `+"`chunk`"+` processor, and reassemble them before writing each object to a file. The batching policy closes a batch once the last chunk of an object arrives, and objects that fail to be reassembled are logged and dropped:`,
+			`
+input:
+  broker:
+    inputs:
+      - nats:
+          urls: [ nats://127.0.0.1:4222 ]
+          subject: objects
+    batching:
+      check: meta("chunk_index").number() + 1 == meta("chunk_count").number()
+      processors:
+        - unchunk: {}
+
+pipeline:
+  processors:
+    - switch:
+        - check: errored()
+          processors:
+            - log:
+                message: 'Failed to reassemble object: ${! error() }'
+            - bloblang: root = deleted()
+
+output:
+  file:
+    path: ./objects/${! meta("s3_key") }
+    codec: all-bytes
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"unchunk", unchunkProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return &unchunkProcessor{}, nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// chunkMeta is the chunk metadata of a message as written by the chunk
+// processor.
+type chunkMeta struct {
+	id           string
+	index, count int
+	offset       int
+}
+
+func parseChunkMeta(msg *service.Message) (m chunkMeta, ok bool, err error) {
+	if m.id, ok = msg.MetaGet("chunk_id"); !ok {
+		return
+	}
+	for _, f := range []struct {
+		key    string
+		target *int
+	}{
+		{"chunk_index", &m.index},
+		{"chunk_count", &m.count},
+		{"chunk_offset", &m.offset},
+	} {
+		v, _ := msg.MetaGet(f.key)
+		if *f.target, err = strconv.Atoi(v); err != nil || *f.target < 0 {
+			err = fmt.Errorf("invalid %v metadata value: %q", f.key, v)
+			return
+		}
+	}
+	if m.count == 0 || m.index >= m.count {
+		err = fmt.Errorf("chunk index %v out of range of count %v", m.index, m.count)
+	}
+	return
+}
+
+// reassembleChunks concatenates the payloads of a complete set of chunks,
+// where chunks may overlap.
+func reassembleChunks(chunks []*service.Message, metas []chunkMeta) (*service.Message, error) {
+	var size int
+	for i, c := range chunks {
+		data, err := c.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		if end := metas[i].offset + len(data); end > size {
+			size = end
+		}
+	}
+
+	payload := make([]byte, size)
+	for i, c := range chunks {
+		data, _ := c.AsBytes()
+		copy(payload[metas[i].offset:], data)
+	}
+
+	msg := chunks[0].Copy()
+	msg.SetBytes(payload)
+	for _, k := range []string{"chunk_id", "chunk_index", "chunk_count", "chunk_offset"} {
+		msg.MetaDelete(k)
+	}
+	return msg, nil
+}
+
+type unchunkProcessor struct{}
+
+func (u *unchunkProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	type chunkGroup struct {
+		chunks   []*service.Message
+		metas    []chunkMeta
+		rejected []*service.Message
+		err      error
+	}
+
+	var order []string
+	groups := map[string]*chunkGroup{}
+
+	output := make(service.MessageBatch, 0, len(batch))
+	positions := map[string]int{}
+
+	for _, msg := range batch {
+		meta, ok, err := parseChunkMeta(msg)
+		if !ok {
+			output = append(output, msg)
+			continue
+		}
+		if err != nil {
+			msg = msg.Copy()
+			msg.SetError(err)
+			output = append(output, msg)
+			continue
+		}
+
+		g, exists := groups[meta.id]
+		if !exists {
+			g = &chunkGroup{
+				chunks: make([]*service.Message, meta.count),
+				metas:  make([]chunkMeta, meta.count),
+			}
+			groups[meta.id] = g
+			order = append(order, meta.id)
+
+			// Reserve the position of the first chunk for the result.
+			positions[meta.id] = len(output)
+			output = append(output, nil)
+		}
+		if len(g.chunks) != meta.count {
+			g.err = fmt.Errorf("chunks of %v disagree on the chunk count", meta.id)
+		}
+		if meta.index >= len(g.chunks) {
+			g.rejected = append(g.rejected, msg)
+			continue
+		}
+		if g.chunks[meta.index] != nil {
+			continue
+		}
+		g.chunks[meta.index] = msg
+		g.metas[meta.index] = meta
+	}
+
+	var failed service.MessageBatch
+	for _, id := range order {
+		g := groups[id]
+		if g.err == nil {
+			missing := 0
+			for _, c := range g.chunks {
+				if c == nil {
+					missing++
+				}
+			}
+			if missing > 0 {
+				g.err = fmt.Errorf("missing %v of %v chunks of %v", missing, len(g.chunks), id)
+			}
+		}
+		if g.err == nil {
+			var msg *service.Message
+			if msg, g.err = reassembleChunks(g.chunks, g.metas); g.err == nil {
+				output[positions[id]] = msg
+				continue
+			}
+		}
+		for _, c := range append(g.chunks, g.rejected...) {
+			if c == nil {
+				continue
+			}
+			c = c.Copy()
+			c.SetError(g.err)
+			failed = append(failed, c)
+		}
+	}
+
+	result := make(service.MessageBatch, 0, len(output)+len(failed))
+	for _, m := range output {
+		if m != nil {
+			result = append(result, m)
+		}
+	}
+	result = append(result, failed...)
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return []service.MessageBatch{result}, nil
+}
+
+func (u *unchunkProcessor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package generic
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testChunk(id string, index, count, offset int, data string) *service.Message {
+	msg := service.NewMessage([]byte(data))
+	msg.MetaSet("chunk_id", id)
+	msg.MetaSet("chunk_index", strconv.Itoa(index))
+	msg.MetaSet("chunk_count", strconv.Itoa(count))
+	msg.MetaSet("chunk_offset", strconv.Itoa(offset))
+	return msg
+}
+
+func TestUnchunkProcessor(t *testing.T) {
+	badMeta := testChunk("baz", 0, 1, 0, "bad")
+	badMeta.MetaSet("chunk_index", "nope")
+
+	res, err := (&unchunkProcessor{}).ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("plain")),
+		testChunk("foo", 1, 2, 3, "def"),
+		testChunk("bar", 0, 3, 0, "abc"),
+		badMeta,
+		testChunk("bar", 2, 3, 6, "ghi"),
+		testChunk("foo", 0, 2, 0, "abc"),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	var payloads, errs []string
+	for _, m := range res[0] {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		payloads = append(payloads, string(b))
+
+		var errStr string
+		if err := m.GetError(); err != nil {
+			errStr = err.Error()
+		}
+		errs = append(errs, errStr)
+	}
+
+	assert.Equal(t, []string{"plain", "abcdef", "bad", "abc", "ghi"}, payloads)
+	assert.Equal(t, []string{
+		"",
+		"",
+		`invalid chunk_index metadata value: "nope"`,
+		"missing 1 of 3 chunks of bar",
+		"missing 1 of 3 chunks of bar",
+	}, errs)
+}
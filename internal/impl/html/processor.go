@@ -0,0 +1,381 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/microcosm-cc/bluemonday"
+	nethtml "golang.org/x/net/html"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func processorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Parsing").
+		Summary("Parses HTML documents and extracts structured data, plain text or links from them.").
+		Description(`
+The operator `+"`extract`"+` replaces the message with a JSON object containing a key for each configured field, where the value is extracted from the document with either a CSS selector or an XPath expression. When a field has `+"`all`"+` set to `+"`true`"+` the value is an array of every match, otherwise it is the first match or `+"`null`"+` when nothing matched.
+
+The operator `+"`text`"+` replaces the message with the readable text of the document, with all tags, scripts and styles removed and whitespace collapsed.
+
+The operator `+"`sanitize`"+` removes all elements and attributes from the document that could be used for cross site scripting, leaving content that is safe to render, such as user generated content or emails.
+
+The operator `+"`links`"+` replaces the message with a JSON object containing the links and assets referenced by the document, in the form:
+
+`+"```json"+`
+{
+  "links": [{"url":"https://example.com/foo","text":"Foo"}],
+  "images": ["https://example.com/logo.png"],
+  "scripts": ["https://example.com/main.js"],
+  "stylesheets": ["https://example.com/style.css"]
+}
+`+"```"+`
+
+Relative URLs are resolved against the `+"`base_url`"+` when it is set, or against the `+"`<base>`"+` element of the document when present.`).
+		Field(service.NewStringAnnotatedEnumField("operator", map[string]string{
+			"extract":  "Extract fields from the document into a JSON object.",
+			"text":     "Convert the document into plain text.",
+			"sanitize": "Remove unsafe elements and attributes from the document.",
+			"links":    "Extract links and assets referenced by the document into a JSON object.",
+		}).Description("The operation to perform on messages.")).
+		Field(service.NewObjectListField("fields",
+			service.NewStringField("name").
+				Description("The key of the field within the resulting object."),
+			service.NewStringField("selector").
+				Description("A CSS selector that identifies the elements to extract.").
+				Example("div.article > h1").
+				Default(""),
+			service.NewStringField("xpath").
+				Description("An XPath expression that identifies the elements to extract, used as an alternative to `selector`.").
+				Example("//div[@class='article']/h1").
+				Default(""),
+			service.NewStringField("attribute").
+				Description("An optional attribute to extract from matched elements instead of their text.").
+				Example("href").
+				Default(""),
+			service.NewBoolField("all").
+				Description("Whether to extract an array of all matched elements rather than only the first.").
+				Default(false),
+		).Description("A list of fields to extract when the operator is `extract`.").
+			Default([]interface{}{})).
+		Field(service.NewStringField("base_url").
+			Description("An optional URL used to resolve relative links when the operator is `links`.").
+			Default("").
+			Example("https://example.com/")).
+		Example("Scrape Articles", `
+Here we extract the title, author and tags of articles from scraped web pages:`,
+			`
+pipeline:
+  processors:
+    - html:
+        operator: extract
+        fields:
+          - name: title
+            selector: article h1
+          - name: author
+            xpath: //meta[@name='author']
+            attribute: content
+          - name: tags
+            selector: article ul.tags > li
+            all: true
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"html", processorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type fieldMatcher func(root *nethtml.Node) []*nethtml.Node
+
+type extractField struct {
+	name      string
+	attribute string
+	all       bool
+	match     fieldMatcher
+}
+
+func newExtractField(conf *service.ParsedConfig) (f extractField, err error) {
+	if f.name, err = conf.FieldString("name"); err != nil {
+		return
+	}
+	if f.attribute, err = conf.FieldString("attribute"); err != nil {
+		return
+	}
+	if f.all, err = conf.FieldBool("all"); err != nil {
+		return
+	}
+
+	var selector, xpathStr string
+	if selector, err = conf.FieldString("selector"); err != nil {
+		return
+	}
+	if xpathStr, err = conf.FieldString("xpath"); err != nil {
+		return
+	}
+	if (selector == "") == (xpathStr == "") {
+		err = fmt.Errorf("field '%v' must have exactly one of selector or xpath set", f.name)
+		return
+	}
+
+	if selector != "" {
+		var sel cascadia.Sel
+		if sel, err = cascadia.Parse(selector); err != nil {
+			err = fmt.Errorf("field '%v' selector: %w", f.name, err)
+			return
+		}
+		f.match = func(root *nethtml.Node) []*nethtml.Node {
+			return cascadia.QueryAll(root, sel)
+		}
+		return
+	}
+
+	var expr *xpath.Expr
+	if expr, err = xpath.Compile(xpathStr); err != nil {
+		err = fmt.Errorf("field '%v' xpath: %w", f.name, err)
+		return
+	}
+	f.match = func(root *nethtml.Node) []*nethtml.Node {
+		return htmlquery.QuerySelectorAll(root, expr)
+	}
+	return
+}
+
+func (f extractField) value(n *nethtml.Node) interface{} {
+	if f.attribute != "" {
+		for _, attr := range n.Attr {
+			if attr.Key == f.attribute {
+				return attr.Val
+			}
+		}
+		return nil
+	}
+	return nodeText(n)
+}
+
+func (f extractField) extract(root *nethtml.Node) interface{} {
+	nodes := f.match(root)
+	if !f.all {
+		if len(nodes) == 0 {
+			return nil
+		}
+		return f.value(nodes[0])
+	}
+	values := make([]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		if v := f.value(n); v != nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+//------------------------------------------------------------------------------
+
+// nodeText returns the human readable text of a node, ignoring the contents of
+// scripts and styles, and collapsing whitespace.
+func nodeText(n *nethtml.Node) string {
+	var buf bytes.Buffer
+	var walk func(n *nethtml.Node)
+	walk = func(n *nethtml.Node) {
+		switch n.Type {
+		case nethtml.TextNode:
+			buf.WriteString(n.Data)
+			buf.WriteByte(' ')
+			return
+		case nethtml.ElementNode:
+			switch n.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+		case nethtml.CommentNode:
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+func getAttr(n *nethtml.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+var baseSelector = cascadia.MustCompile("base[href]")
+
+func extractLinks(root *nethtml.Node, base *url.URL) map[string]interface{} {
+	if base == nil {
+		if b := cascadia.Query(root, baseSelector); b != nil {
+			base, _ = url.Parse(getAttr(b, "href"))
+		}
+	}
+	resolve := func(ref string) string {
+		ref = strings.TrimSpace(ref)
+		if base == nil {
+			return ref
+		}
+		refURL, err := url.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return base.ResolveReference(refURL).String()
+	}
+
+	links := []interface{}{}
+	images := []interface{}{}
+	scripts := []interface{}{}
+	stylesheets := []interface{}{}
+
+	var walk func(n *nethtml.Node)
+	walk = func(n *nethtml.Node) {
+		if n.Type == nethtml.ElementNode {
+			switch n.Data {
+			case "a":
+				if href := getAttr(n, "href"); href != "" {
+					links = append(links, map[string]interface{}{
+						"url":  resolve(href),
+						"text": nodeText(n),
+					})
+				}
+			case "img":
+				if src := getAttr(n, "src"); src != "" {
+					images = append(images, resolve(src))
+				}
+			case "script":
+				if src := getAttr(n, "src"); src != "" {
+					scripts = append(scripts, resolve(src))
+				}
+			case "link":
+				if href := getAttr(n, "href"); href != "" && strings.EqualFold(getAttr(n, "rel"), "stylesheet") {
+					stylesheets = append(stylesheets, resolve(href))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	return map[string]interface{}{
+		"links":       links,
+		"images":      images,
+		"scripts":     scripts,
+		"stylesheets": stylesheets,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type processor struct {
+	operator string
+	fields   []extractField
+	baseURL  *url.URL
+	policy   *bluemonday.Policy
+}
+
+func newProcessorFromConfig(conf *service.ParsedConfig) (*processor, error) {
+	p := &processor{}
+
+	var err error
+	if p.operator, err = conf.FieldString("operator"); err != nil {
+		return nil, err
+	}
+
+	fieldConfs, err := conf.FieldObjectList("fields")
+	if err != nil {
+		return nil, err
+	}
+	for _, fConf := range fieldConfs {
+		f, err := newExtractField(fConf)
+		if err != nil {
+			return nil, err
+		}
+		p.fields = append(p.fields, f)
+	}
+
+	baseURLStr, err := conf.FieldString("base_url")
+	if err != nil {
+		return nil, err
+	}
+	if baseURLStr != "" {
+		if p.baseURL, err = url.Parse(baseURLStr); err != nil {
+			return nil, fmt.Errorf("failed to parse base_url: %w", err)
+		}
+	}
+
+	switch p.operator {
+	case "extract":
+		if len(p.fields) == 0 {
+			return nil, errors.New("at least one field must be specified with the extract operator")
+		}
+	case "sanitize":
+		p.policy = bluemonday.UGCPolicy()
+	case "text", "links":
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", p.operator)
+	}
+	return p, nil
+}
+
+func (p *processor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	mBytes, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	resMsg := msg.Copy()
+	if p.operator == "sanitize" {
+		resMsg.SetBytes(p.policy.SanitizeBytes(mBytes))
+		return service.MessageBatch{resMsg}, nil
+	}
+
+	root, err := nethtml.Parse(bytes.NewReader(mBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML document: %w", err)
+	}
+
+	switch p.operator {
+	case "extract":
+		obj := make(map[string]interface{}, len(p.fields))
+		for _, f := range p.fields {
+			obj[f.name] = f.extract(root)
+		}
+		resMsg.SetStructured(obj)
+	case "text":
+		resMsg.SetBytes([]byte(nodeText(root)))
+	case "links":
+		resMsg.SetStructured(extractLinks(root, p.baseURL))
+	}
+	return service.MessageBatch{resMsg}, nil
+}
+
+func (p *processor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,128 @@
+package html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testDocument = `<html>
+<head>
+  <base href="https://example.com/blog/">
+  <meta name="author" content="Ash">
+  <link rel="stylesheet" href="/style.css">
+  <script src="main.js"></script>
+  <style>body { color: red; }</style>
+</head>
+<body>
+  <article>
+    <h1>  Hello
+      world </h1>
+    <ul class="tags"><li>foo</li><li>bar</li></ul>
+    <p>Read <a href="next.html">the <b>next</b> post</a>.<img src="cat.png" onerror="alert(1)"></p>
+  </article>
+  <script>alert("hi")</script>
+</body>
+</html>`
+
+func testProcess(t *testing.T, confStr string) *service.Message {
+	t.Helper()
+
+	conf, err := processorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newProcessorFromConfig(conf)
+	require.NoError(t, err)
+
+	res, err := proc.Process(context.Background(), service.NewMessage([]byte(testDocument)))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	return res[0]
+}
+
+func TestHTMLExtract(t *testing.T) {
+	msg := testProcess(t, `
+operator: extract
+fields:
+  - name: title
+    selector: article h1
+  - name: author
+    xpath: //meta[@name='author']
+    attribute: content
+  - name: tags
+    selector: ul.tags > li
+    all: true
+  - name: missing
+    selector: div.nope
+`)
+
+	v, err := msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"title":   "Hello world",
+		"author":  "Ash",
+		"tags":    []interface{}{"foo", "bar"},
+		"missing": nil,
+	}, v)
+}
+
+func TestHTMLText(t *testing.T) {
+	msg := testProcess(t, `operator: text`)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "Hello world foo bar Read the next post .", string(b))
+}
+
+func TestHTMLSanitize(t *testing.T) {
+	msg := testProcess(t, `operator: sanitize`)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "<script")
+	assert.NotContains(t, string(b), "onerror")
+	assert.Contains(t, string(b), `<img src="cat.png">`)
+}
+
+func TestHTMLLinks(t *testing.T) {
+	msg := testProcess(t, `operator: links`)
+
+	v, err := msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"links": []interface{}{
+			map[string]interface{}{"url": "https://example.com/blog/next.html", "text": "the next post"},
+		},
+		"images":      []interface{}{"https://example.com/blog/cat.png"},
+		"scripts":     []interface{}{"https://example.com/blog/main.js"},
+		"stylesheets": []interface{}{"https://example.com/style.css"},
+	}, v)
+}
+
+func TestHTMLBadConfig(t *testing.T) {
+	for _, confStr := range []string{
+		`operator: extract`,
+		`
+operator: extract
+fields: [ { name: foo } ]
+`,
+		`
+operator: extract
+fields: [ { name: foo, selector: a, xpath: //a } ]
+`,
+		`
+operator: extract
+fields: [ { name: foo, selector: "a[" } ]
+`,
+	} {
+		conf, err := processorConfig().ParseYAML(confStr, nil)
+		require.NoError(t, err, confStr)
+
+		_, err = newProcessorFromConfig(conf)
+		assert.Error(t, err, confStr)
+	}
+}
@@ -0,0 +1,505 @@
+package journald
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	jiFieldUnits           = "units"
+	jiFieldPriority        = "priority"
+	jiFieldMatches         = "matches"
+	jiFieldDirectory       = "directory"
+	jiFieldFromBeginning   = "from_beginning"
+	jiFieldCursorCache     = "cursor_cache"
+	jiFieldCursorKey       = "cursor_key"
+	jiFieldRawFields       = "raw_fields"
+	jiFieldCheckpointLimit = "checkpoint_limit"
+	jiFieldJournalctlPath  = "journalctl_path"
+)
+
+var journaldPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+func journaldInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Local").
+		Summary("Reads entries of the systemd journal, emitting each entry as a JSON object.").
+		Description(`
+Entries are read by following the journal with `+"`journalctl`"+`, which must be installed on the host and readable by the user that Benthos runs as (typically by being a member of the `+"`systemd-journal`"+` group).
+
+Each entry is emitted as a JSON object of its fields. By default the names of fields are converted to lower case and stripped of leading underscores, so that `+"`MESSAGE`"+` becomes `+"`message`"+` and `+"`_SYSTEMD_UNIT`"+` becomes `+"`systemd_unit`"+`, and when a trusted field (prefixed with an underscore) and a user field convert to the same name the trusted field is kept. The field `+"`timestamp`"+` is set to the time of the entry as an RFC 3339 string, and fields with binary values are emitted as strings. Set `+"`raw_fields`"+` to `+"`true`"+` in order to keep the original field names instead.
+
+### Checkpointing
+
+When `+"`cursor_cache`"+` is set the cursor of the latest entry that has been delivered, along with all entries before it, is stored in the cache under the key `+"`cursor_key`"+`, and after a restart the journal is read from the entry following it. Without a cursor the journal is read from the entries written after the input starts, or from the beginning of the journal when `+"`from_beginning`"+` is `+"`true`"+`.
+
+The cursor is also reported as the checkpoint position of the partition `+"`journal`"+`, which can be inspected via the `+"`/checkpoints`"+` HTTP endpoint.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- journald_cursor
+- journald_unit
+- journald_priority
+- journald_timestamp
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringListField(jiFieldUnits).
+			Description("An optional list of systemd units to read the entries of. When empty the entries of all units are read.").
+			Default([]string{}).
+			Example([]string{"nginx.service", "sshd.service"})).
+		Field(service.NewStringField(jiFieldPriority).
+			Description("An optional maximum priority of entries to read, either as a name or a number from `0` (`emerg`) to `7` (`debug`).").
+			Default("").
+			Example("warning").
+			Example("3")).
+		Field(service.NewStringListField(jiFieldMatches).
+			Description("An optional list of [journal field matches](https://www.freedesktop.org/software/systemd/man/journalctl.html#Description) of the form `FIELD=value` that entries must match. Matches of different fields must all match, whereas matches of the same field match when any of them match.").
+			Default([]string{}).
+			Example([]string{"_TRANSPORT=kernel"}).
+			Advanced()).
+		Field(service.NewStringField(jiFieldDirectory).
+			Description("An optional directory of journal files to read instead of the journal of the host, such as the journal of a container mounted from the host.").
+			Default("").
+			Advanced()).
+		Field(service.NewBoolField(jiFieldFromBeginning).
+			Description("Whether to read the journal from its beginning when there is no stored cursor, rather than only the entries written after the input starts.").
+			Default(false)).
+		Field(service.NewStringField(jiFieldCursorCache).
+			Description("An optional [cache resource](/docs/components/caches/about) in which to store the cursor of the latest delivered entry, a cache that persists data such as `file` or `redis` should be used.").
+			Default("")).
+		Field(service.NewStringField(jiFieldCursorKey).
+			Description("The key under which the cursor is stored in the cache.").
+			Default("journald_cursor").
+			Advanced()).
+		Field(service.NewBoolField(jiFieldRawFields).
+			Description("Whether to keep the original names of the fields of entries.").
+			Default(false).
+			Advanced()).
+		Field(service.NewIntField(jiFieldCheckpointLimit).
+			Description("The maximum number of entries that can be pending delivery at any given time before applying back pressure.").
+			Default(1024).
+			Advanced()).
+		Field(service.NewStringField(jiFieldJournalctlPath).
+			Description("The path of the `journalctl` executable.").
+			Default("journalctl").
+			Advanced()).
+		Example("Forwarding Errors", `
+Here we read the entries of the journal with a priority of `+"`err`"+` or more severe from two units, storing the cursor in a file cache so that entries aren't read again after a restart:`,
+			`
+input:
+  journald:
+    units: [ nginx.service, postgresql.service ]
+    priority: err
+    cursor_cache: cursors
+
+output:
+  http_client:
+    url: http://localhost:8080/alerts
+    verb: POST
+
+cache_resources:
+  - label: cursors
+    file:
+      directory: /var/lib/benthos
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"journald", journaldInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newJournaldInputFromConfig(conf, mgr, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// journaldResources is the subset of resources used by the journald input.
+type journaldResources interface {
+	AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error
+	ReportCheckpoint(partition, offset string)
+}
+
+type journaldInput struct {
+	units          []string
+	priority       string
+	matches        []string
+	directory      string
+	fromBeginning  bool
+	cursorCache    string
+	cursorKey      string
+	rawFields      bool
+	journalctlPath string
+
+	mgr journaldResources
+	log *service.Logger
+
+	checkpointer *checkpoint.Capped
+
+	mut        sync.Mutex
+	cmd        *exec.Cmd
+	cancel     context.CancelFunc
+	reader     *bufio.Reader
+	lastCursor string
+	loaded     bool
+}
+
+func parsePriority(p string) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+	if n, err := strconv.Atoi(p); err == nil {
+		if n < 0 || n >= len(journaldPriorities) {
+			return "", fmt.Errorf("priority %v is out of range", n)
+		}
+		return p, nil
+	}
+	p = strings.ToLower(p)
+	for i, name := range journaldPriorities {
+		if name == p {
+			return strconv.Itoa(i), nil
+		}
+	}
+	return "", fmt.Errorf("priority '%v' is not recognised", p)
+}
+
+func newJournaldInputFromConfig(conf *service.ParsedConfig, mgr journaldResources, log *service.Logger) (*journaldInput, error) {
+	j := &journaldInput{mgr: mgr, log: log}
+
+	var err error
+	if j.units, err = conf.FieldStringList(jiFieldUnits); err != nil {
+		return nil, err
+	}
+	priority, err := conf.FieldString(jiFieldPriority)
+	if err != nil {
+		return nil, err
+	}
+	if j.priority, err = parsePriority(priority); err != nil {
+		return nil, err
+	}
+	if j.matches, err = conf.FieldStringList(jiFieldMatches); err != nil {
+		return nil, err
+	}
+	for _, m := range j.matches {
+		if !strings.Contains(m, "=") {
+			return nil, fmt.Errorf("match '%v' must be of the form FIELD=value", m)
+		}
+	}
+	if j.directory, err = conf.FieldString(jiFieldDirectory); err != nil {
+		return nil, err
+	}
+	if j.fromBeginning, err = conf.FieldBool(jiFieldFromBeginning); err != nil {
+		return nil, err
+	}
+	if j.cursorCache, err = conf.FieldString(jiFieldCursorCache); err != nil {
+		return nil, err
+	}
+	if j.cursorKey, err = conf.FieldString(jiFieldCursorKey); err != nil {
+		return nil, err
+	}
+	if j.rawFields, err = conf.FieldBool(jiFieldRawFields); err != nil {
+		return nil, err
+	}
+	if j.journalctlPath, err = conf.FieldString(jiFieldJournalctlPath); err != nil {
+		return nil, err
+	}
+
+	limit, err := conf.FieldInt(jiFieldCheckpointLimit)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 {
+		return nil, errors.New("checkpoint_limit must be at least 1")
+	}
+	j.checkpointer = checkpoint.NewCapped(int64(limit))
+	return j, nil
+}
+
+// args returns the arguments of journalctl for reading the journal from a
+// cursor, or from the start position when the cursor is empty.
+func (j *journaldInput) args(cursor string) []string {
+	args := []string{"--output=json", "--follow", "--all", "--no-pager"}
+	switch {
+	case cursor != "":
+		args = append(args, "--lines=all", "--after-cursor="+cursor)
+	case j.fromBeginning:
+		args = append(args, "--lines=all")
+	default:
+		args = append(args, "--lines=0")
+	}
+	if j.directory != "" {
+		args = append(args, "--directory="+j.directory)
+	}
+	for _, u := range j.units {
+		args = append(args, "--unit="+u)
+	}
+	if j.priority != "" {
+		args = append(args, "--priority="+j.priority)
+	}
+	return append(args, j.matches...)
+}
+
+// loadCursor reads the stored cursor from the cache, returning an empty string
+// when there is none.
+func (j *journaldInput) loadCursor(ctx context.Context) (string, error) {
+	if j.cursorCache == "" {
+		return "", nil
+	}
+	var cursor []byte
+	var err error
+	if cerr := j.mgr.AccessCache(ctx, j.cursorCache, func(c service.Cache) {
+		cursor, err = c.Get(ctx, j.cursorKey)
+	}); cerr != nil {
+		return "", cerr
+	}
+	if errors.Is(err, service.ErrKeyNotFound) {
+		return "", nil
+	}
+	return string(cursor), err
+}
+
+func (j *journaldInput) storeCursor(ctx context.Context, cursor string) error {
+	j.mgr.ReportCheckpoint("journal", cursor)
+	if j.cursorCache == "" {
+		return nil
+	}
+	var err error
+	if cerr := j.mgr.AccessCache(ctx, j.cursorCache, func(c service.Cache) {
+		err = c.Set(ctx, j.cursorKey, []byte(cursor), nil)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+func (j *journaldInput) Connect(ctx context.Context) error {
+	j.mut.Lock()
+	defer j.mut.Unlock()
+
+	if j.cmd != nil {
+		return nil
+	}
+
+	// The stored cursor is only loaded once, after which journalctl is
+	// restarted from the last entry read.
+	if !j.loaded {
+		cursor, err := j.loadCursor(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load cursor: %w", err)
+		}
+		j.lastCursor, j.loaded = cursor, true
+	}
+
+	cmdCtx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(cmdCtx, j.journalctlPath, j.args(j.lastCursor)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return err
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			j.log.Warnf("journalctl: %s", scanner.Text())
+		}
+	}()
+
+	j.cmd, j.cancel = cmd, cancel
+	j.reader = bufio.NewReader(stdout)
+	return nil
+}
+
+// disconnect stops journalctl, the mutex must be held by the caller.
+func (j *journaldInput) disconnect() {
+	if j.cmd == nil {
+		return
+	}
+	j.cancel()
+	_ = j.cmd.Wait()
+	j.cmd, j.cancel, j.reader = nil, nil, nil
+}
+
+// fieldValue converts a field value of the JSON output of journalctl, where
+// binary values are arrays of bytes and fields with multiple values are
+// arrays, into a string or array of strings.
+func fieldValue(v interface{}) interface{} {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+
+	isBytes := true
+	for _, e := range arr {
+		if _, ok := e.(float64); !ok {
+			isBytes = false
+			break
+		}
+	}
+	if isBytes {
+		b := make([]byte, len(arr))
+		for i, e := range arr {
+			b[i] = byte(e.(float64))
+		}
+		return string(b)
+	}
+
+	values := make([]interface{}, len(arr))
+	for i, e := range arr {
+		values[i] = fieldValue(e)
+	}
+	return values
+}
+
+func firstString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []interface{}:
+		if len(t) > 0 {
+			return firstString(t[0])
+		}
+	}
+	return ""
+}
+
+// entryMessage converts an entry of the JSON output of journalctl into a
+// message.
+func (j *journaldInput) entryMessage(line []byte) (*service.Message, string, error) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return nil, "", fmt.Errorf("failed to parse entry: %w", err)
+	}
+
+	cursor := firstString(entry["__CURSOR"])
+	if cursor == "" {
+		return nil, "", errors.New("entry is missing a cursor")
+	}
+
+	var timestamp string
+	if usec, err := strconv.ParseInt(firstString(entry["__REALTIME_TIMESTAMP"]), 10, 64); err == nil {
+		timestamp = time.Unix(0, usec*int64(time.Microsecond)).UTC().Format(time.RFC3339Nano)
+	}
+
+	fields := map[string]interface{}{}
+	if j.rawFields {
+		for k, v := range entry {
+			fields[k] = fieldValue(v)
+		}
+	} else {
+		for k, v := range entry {
+			if strings.HasPrefix(k, "__") {
+				continue
+			}
+			name := strings.ToLower(strings.TrimLeft(k, "_"))
+			if _, exists := fields[name]; exists && !strings.HasPrefix(k, "_") {
+				continue
+			}
+			fields[name] = fieldValue(v)
+		}
+		if timestamp != "" {
+			fields["timestamp"] = timestamp
+		}
+	}
+
+	content, err := json.Marshal(fields)
+	if err != nil {
+		return nil, "", err
+	}
+
+	msg := service.NewMessage(content)
+	msg.MetaSet("journald_cursor", cursor)
+	msg.MetaSet("journald_unit", firstString(entry["_SYSTEMD_UNIT"]))
+	msg.MetaSet("journald_priority", firstString(entry["PRIORITY"]))
+	msg.MetaSet("journald_timestamp", timestamp)
+	return msg, cursor, nil
+}
+
+func (j *journaldInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	j.mut.Lock()
+	reader := j.reader
+	j.mut.Unlock()
+
+	if reader == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			j.mut.Lock()
+			j.disconnect()
+			j.mut.Unlock()
+			if errors.Is(err, io.EOF) {
+				j.log.Warnf("journalctl exited, restarting")
+			}
+			return nil, nil, service.ErrNotConnected
+		}
+
+		msg, cursor, err := j.entryMessage(line)
+		if err != nil {
+			j.log.Errorf("Skipping journal entry: %v", err)
+			continue
+		}
+
+		resolveFn, err := j.checkpointer.Track(ctx, cursor, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		j.mut.Lock()
+		j.lastCursor = cursor
+		j.mut.Unlock()
+
+		return msg, func(ctx context.Context, err error) error {
+			highest := resolveFn()
+			if highest == nil {
+				return nil
+			}
+			return j.storeCursor(ctx, highest.(string))
+		}, nil
+	}
+}
+
+func (j *journaldInput) Close(ctx context.Context) error {
+	j.mut.Lock()
+	defer j.mut.Unlock()
+
+	j.disconnect()
+	return nil
+}
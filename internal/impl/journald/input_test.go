@@ -0,0 +1,190 @@
+package journald
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mockCache struct {
+	mut   sync.Mutex
+	items map[string][]byte
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	v, exists := m.items[key]
+	if !exists {
+		return nil, service.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.items[key] = value
+	return nil
+}
+
+func (m *mockCache) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	return m.Set(ctx, key, value, ttl)
+}
+
+func (m *mockCache) Delete(ctx context.Context, key string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func (m *mockCache) Close(ctx context.Context) error {
+	return nil
+}
+
+type mockResources struct {
+	cache *mockCache
+
+	mut         sync.Mutex
+	checkpoints []string
+}
+
+func (m *mockResources) AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error {
+	fn(m.cache)
+	return nil
+}
+
+func (m *mockResources) ReportCheckpoint(partition, offset string) {
+	m.mut.Lock()
+	m.checkpoints = append(m.checkpoints, partition+"="+offset)
+	m.mut.Unlock()
+}
+
+// writeFakeJournalctl writes a script that records its arguments and prints
+// the entries of the journal following the cursor provided to it.
+func writeFakeJournalctl(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "journalctl")
+	require.NoError(t, os.WriteFile(path, []byte(`#!/bin/sh
+echo "$@" >> `+filepath.Join(dir, "args")+`
+case "$*" in
+  *--after-cursor=c2*)
+    exec sleep 10
+    ;;
+  *--after-cursor=c1*)
+    echo '{"__CURSOR":"c2","__REALTIME_TIMESTAMP":"1654077601000000","MESSAGE":"second","PRIORITY":"3","_SYSTEMD_UNIT":"nginx.service"}'
+    ;;
+  *)
+    echo '{"__CURSOR":"c1","__REALTIME_TIMESTAMP":"1654077600500000","MESSAGE":"first","PRIORITY":"6","_SYSTEMD_UNIT":"nginx.service","_PID":"10","PID":"forged","BINARY":[104,105],"MULTI":["a","b"]}'
+    echo 'not json'
+    ;;
+esac
+`), 0o755))
+	return path
+}
+
+func TestJournaldInput(t *testing.T) {
+	dir := t.TempDir()
+	journalctl := writeFakeJournalctl(t, dir)
+
+	conf, err := journaldInputConfig().ParseYAML(`
+units: [ nginx.service ]
+priority: info
+cursor_cache: foo
+journalctl_path: `+journalctl+`
+`, nil)
+	require.NoError(t, err)
+
+	res := &mockResources{cache: &mockCache{items: map[string][]byte{}}}
+	in, err := newJournaldInputFromConfig(conf, res, nil)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, in.Connect(ctx))
+
+	msg, ackFn, err := in.Read(ctx)
+	require.NoError(t, err)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "message": "first",
+  "priority": "6",
+  "systemd_unit": "nginx.service",
+  "pid": "10",
+  "binary": "hi",
+  "multi": ["a","b"],
+  "timestamp": "2022-06-01T10:00:00.5Z"
+}`, string(b))
+
+	cursor, _ := msg.MetaGet("journald_cursor")
+	assert.Equal(t, "c1", cursor)
+	unit, _ := msg.MetaGet("journald_unit")
+	assert.Equal(t, "nginx.service", unit)
+
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Equal(t, "c1", string(res.cache.items["journald_cursor"]))
+
+	// The invalid entry is skipped and journalctl exiting results in a
+	// reconnect from the last cursor read.
+	_, _, err = in.Read(ctx)
+	require.Equal(t, service.ErrNotConnected, err)
+	require.NoError(t, in.Connect(ctx))
+
+	msg, ackFn, err = in.Read(ctx)
+	require.NoError(t, err)
+	b, err = msg.AsBytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"message":"second"`)
+
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Equal(t, "c2", string(res.cache.items["journald_cursor"]))
+	assert.Equal(t, []string{"journal=c1", "journal=c2"}, res.checkpoints)
+
+	require.NoError(t, in.Close(ctx))
+
+	// A new input resumes from the stored cursor.
+	in, err = newJournaldInputFromConfig(conf, res, nil)
+	require.NoError(t, err)
+	require.NoError(t, in.Connect(ctx))
+	assert.Equal(t, "c2", in.lastCursor)
+	require.NoError(t, in.Close(ctx))
+
+	argsBytes, err := os.ReadFile(filepath.Join(dir, "args"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--output=json --follow --all --no-pager --lines=0 --unit=nginx.service --priority=6",
+		"--output=json --follow --all --no-pager --lines=all --after-cursor=c1 --unit=nginx.service --priority=6",
+	}, strings.Split(strings.TrimSpace(string(argsBytes)), "\n")[:2])
+}
+
+func TestJournaldPriority(t *testing.T) {
+	for in, exp := range map[string]string{
+		"":        "",
+		"3":       "3",
+		"warning": "4",
+		"DEBUG":   "7",
+	} {
+		p, err := parsePriority(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, exp, p, in)
+	}
+
+	_, err := parsePriority("8")
+	assert.Error(t, err)
+	_, err = parsePriority("nope")
+	assert.Error(t, err)
+}
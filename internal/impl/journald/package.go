@@ -0,0 +1,2 @@
+// Package journald contains components that read the systemd journal.
+package journald
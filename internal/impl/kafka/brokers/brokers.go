@@ -0,0 +1,50 @@
+// Package brokers provides utilities for observing the brokers that a Kafka
+// client is connected to.
+package brokers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// ConnectedMetric is the name of the gauge metric labelled by broker address
+// that is set to 1 when the client is connected to the broker and 0 otherwise.
+const ConnectedMetric = "kafka_broker_connected"
+
+// ReportConnectivity sets the connectivity metric of each broker known to a
+// client every period until the context is cancelled, at which point all
+// brokers are reported as disconnected.
+func ReportConnectivity(ctx context.Context, client sarama.Client, stats metrics.Type, period time.Duration) {
+	gauge := stats.GetGaugeVec(ConnectedMetric, "broker")
+
+	seen := map[string]struct{}{}
+	report := func() {
+		for _, b := range client.Brokers() {
+			seen[b.Addr()] = struct{}{}
+			if connected, _ := b.Connected(); connected {
+				gauge.With(b.Addr()).Set(1)
+			} else {
+				gauge.With(b.Addr()).Set(0)
+			}
+		}
+	}
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		report()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			for addr := range seen {
+				gauge.With(addr).Set(0)
+			}
+			return
+		}
+	}
+}
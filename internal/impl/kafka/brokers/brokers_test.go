@@ -0,0 +1,49 @@
+package brokers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+func TestReportConnectivity(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()),
+	})
+
+	client, err := sarama.NewClient([]string{broker.Addr()}, sarama.NewConfig())
+	require.NoError(t, err)
+	defer client.Close()
+
+	// Obtaining a broker from the client opens a connection to it.
+	_, err = client.Broker(broker.BrokerID())
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	path := ConnectedMetric + `{broker="` + broker.Addr() + `"}`
+
+	ctx, done := context.WithCancel(context.Background())
+	reportDone := make(chan struct{})
+	go func() {
+		ReportConnectivity(ctx, client, stats, time.Millisecond*10)
+		close(reportDone)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()[path] == 1
+	}, time.Second*5, time.Millisecond*10)
+
+	done()
+	<-reportDone
+	assert.Equal(t, int64(0), stats.GetCounters()[path])
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -60,6 +61,10 @@ This input adds the following metadata fields to each message:
 			Description("Determines how many messages of the same partition can be processed in parallel before applying back pressure. When a message of a given offset is delivered to the output the offset is only allowed to be committed when all messages of prior offsets have also been delivered, this ensures at-least-once delivery guarantees. However, this mechanism also increases the likelihood of duplicates in the event of crashes or server faults, reducing the checkpoint limit will mitigate this.").
 			Default(1024).
 			Advanced()).
+		Field(service.NewStringField("checkpoint_cache").
+			Description("An optional [cache resource](/docs/components/caches/about) from which the offsets of partitions are resumed when they're assigned, taking precedence over the offsets committed to the consumer group. The offset of each partition is read from the key `<topic>:<partition>`, as written by the [`checkpoint` output](/docs/components/outputs/checkpoint) by default, and consumption resumes from the offset following it. Partitions without a key in the cache are resumed from the offset committed to the consumer group.").
+			Default("").
+			Advanced()).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField)
 }
@@ -67,7 +72,7 @@ This input adds the following metadata fields to each message:
 func init() {
 	err := service.RegisterInput("kafka_franz", franzKafkaInputConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr.Logger())
+			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr)
 			if err != nil {
 				return nil, err
 			}
@@ -93,8 +98,10 @@ type franzKafkaReader struct {
 	tlsConf         *tls.Config
 	saslConfs       []sasl.Mechanism
 	checkpointLimit int
+	checkpointCache string
 
 	msgChan atomic.Value
+	res     *service.Resources
 	log     *service.Logger
 	shutSig *shutdown.Signaller
 }
@@ -108,9 +115,10 @@ func (f *franzKafkaReader) storeMsgChan(c chan msgWithAckFn) {
 	f.msgChan.Store(c)
 }
 
-func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logger) (*franzKafkaReader, error) {
+func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, res *service.Resources) (*franzKafkaReader, error) {
 	f := franzKafkaReader{
-		log:     log,
+		res:     res,
+		log:     res.Logger(),
 		shutSig: shutdown.NewSignaller(),
 	}
 
@@ -138,6 +146,10 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if f.checkpointCache, err = conf.FieldString("checkpoint_cache"); err != nil {
+		return nil, err
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -243,6 +255,49 @@ func (c *checkpointTracker) removeTopicPartitions(m map[string][]int32) {
 
 //------------------------------------------------------------------------------
 
+// removeCheckpoints discards the checkpoints reported for topic partitions that
+// are no longer assigned to this consumer.
+func (f *franzKafkaReader) removeCheckpoints(m map[string][]int32) {
+	for topic, parts := range m {
+		for _, part := range parts {
+			f.res.RemoveCheckpoint(topic + ":" + strconv.Itoa(int(part)))
+		}
+	}
+}
+
+// resumeFromCache overrides the offsets that assigned partitions are consumed
+// from with the offsets persisted to the checkpoint cache.
+func (f *franzKafkaReader) resumeFromCache(ctx context.Context, offsets map[string]map[int32]kgo.Offset) (map[string]map[int32]kgo.Offset, error) {
+	var getErr error
+	if err := f.res.AccessCache(ctx, f.checkpointCache, func(c service.Cache) {
+		for topic, partitions := range offsets {
+			for partition := range partitions {
+				key := topic + ":" + strconv.Itoa(int(partition))
+				b, err := c.Get(ctx, key)
+				if errors.Is(err, service.ErrKeyNotFound) {
+					continue
+				}
+				if err != nil {
+					getErr = fmt.Errorf("failed to read checkpoint '%v': %w", key, err)
+					return
+				}
+				offset, err := strconv.ParseInt(string(b), 10, 64)
+				if err != nil {
+					getErr = fmt.Errorf("failed to parse checkpoint '%v': %w", key, err)
+					return
+				}
+				partitions[partition] = kgo.NewOffset().At(offset + 1)
+			}
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("unable to access cache '%v': %w", f.checkpointCache, err)
+	}
+	if getErr != nil {
+		return nil, getErr
+	}
+	return offsets, nil
+}
+
 func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	if f.getMsgChan() != nil {
 		return nil
@@ -285,10 +340,12 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				f.log.Errorf("Commit error on partition revoke: %v", commitErr)
 			})
 			checkpoints.removeTopicPartitions(m)
+			f.removeCheckpoints(m)
 		}),
 		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
 			// No point trying to commit our offsets, just clean up our topic map
 			checkpoints.removeTopicPartitions(m)
+			f.removeCheckpoints(m)
 		}),
 		kgo.AutoCommitMarks(),
 		kgo.WithLogger(&kgoLogger{f.log}),
@@ -296,6 +353,9 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 	if f.tlsConf != nil {
 		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
 	}
+	if f.checkpointCache != "" {
+		clientOpts = append(clientOpts, kgo.AdjustFetchOffsetsFn(f.resumeFromCache))
+	}
 
 	cl, err := kgo.NewClient(clientOpts...)
 	if err != nil {
@@ -376,6 +436,12 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 					onAck: func() {
 						if maxRec := releaseFn(); maxRec != nil {
 							cl.MarkCommitRecords(maxRec)
+							// Report the next offset to consume, which is
+							// consistent with the offsets committed to Kafka.
+							f.res.ReportCheckpoint(
+								maxRec.Topic+":"+strconv.Itoa(int(maxRec.Partition)),
+								strconv.FormatInt(maxRec.Offset+1, 10),
+							)
 						}
 					},
 				}:
@@ -411,9 +477,9 @@ func recordToMessage(record *kgo.Record) *service.Message {
 	msg := service.NewMessage(record.Value)
 	msg.MetaSet("kafka_key", string(record.Key))
 	msg.MetaSet("kafka_topic", record.Topic)
-	msg.MetaSet("kafka_partition", strconv.Itoa(int(record.Partition)))
-	msg.MetaSet("kafka_offset", strconv.Itoa(int(record.Offset)))
-	msg.MetaSet("kafka_timestamp_unix", strconv.FormatInt(record.Timestamp.Unix(), 10))
+	msg.MetaSetTyped("kafka_partition", int64(record.Partition))
+	msg.MetaSetTyped("kafka_offset", record.Offset)
+	msg.MetaSetTyped("kafka_timestamp_unix", record.Timestamp.Unix())
 	for _, hdr := range record.Headers {
 		msg.MetaSet(hdr.Key, string(hdr.Value))
 	}
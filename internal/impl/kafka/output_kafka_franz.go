@@ -3,9 +3,11 @@ package kafka
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 
 	"github.com/dustin/go-humanize"
 	"github.com/twmb/franz-go/pkg/kgo"
@@ -29,6 +31,14 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 - You like shiny new stuff
 - You are experiencing issues with the existing ` + "`kafka`" + ` output
 - Someone told you to
+
+### Ordering
+
+By default batches are written in parallel up to the limit of ` + "`max_in_flight`" + `, and therefore messages of different batches may be written to a partition in a different order than they were received by the output. When ` + "`preserve_order`" + ` is set to ` + "`true`" + ` the records of each batch are enqueued with the client one batch at a time in the order that batches are dispatched to the output, and up to ` + "`max_in_flight`" + ` batches are then awaiting acknowledgement in parallel. With idempotent writes the client preserves the order of enqueued records for each partition whilst up to five produce requests are in flight for each broker, even when requests are retried. When a record cannot be written the client fails all records buffered after it for the same partition too, which prevents later messages from being written ahead of a batch that is retried.
+
+In order to provide this guarantee the output refuses to start with ` + "`preserve_order`" + ` enabled unless ` + "`idempotent_write`" + ` is also enabled and ` + "`max_in_flight`" + ` is five or less, matching the requirements of the Java client for ` + "`max.in.flight.requests.per.connection`" + ` with ` + "`enable.idempotence`" + `.
+
+The client retries transient errors itself, but when a batch fails with an error that can't be retried it is rejected, and the input it was consumed from decides whether it is delivered again. Since the following batch may be dispatched before that happens, ordering across such failures also requires an input that delivers rejected messages again before continuing, or a [` + "`retry`" + ` output](/docs/components/outputs/retry) wrapping this one. Messages are only ordered relative to each other when they share a partition, and therefore the default partitioner should be used with a ` + "`key`" + ` that identifies each stream of ordered messages.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -51,6 +61,14 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 		Field(service.NewIntField("max_in_flight").
 			Description("The maximum number of batches to be sending in parallel at any given time.").
 			Default(10)).
+		Field(service.NewBoolField("idempotent_write").
+			Description("Enable the idempotent write producer option, which prevents records from being duplicated when produce requests are retried. This requires the `IDEMPOTENT_WRITE` permission on `CLUSTER` for Kafka versions prior to 3.0, and can be disabled when that permission is not available.").
+			Advanced().
+			Default(true)).
+		Field(service.NewBoolField("preserve_order").
+			Description("Whether to preserve the order of messages written to each partition across batches that are in flight in parallel. Requires `idempotent_write` to be enabled and `max_in_flight` to be five or less.").
+			Advanced().
+			Default(false)).
 		Field(service.NewBatchPolicyField("batching")).
 		Field(service.NewStringField("max_message_bytes").
 			Description("The maximum space in bytes than an individual message may take, messages larger than this value will be rejected. This field corresponds to Kafka's `max.message.bytes`.").
@@ -80,7 +98,14 @@ func init() {
 			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
 				return
 			}
-			output, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger())
+			var w *franzKafkaWriter
+			if w, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger()); err != nil {
+				return
+			}
+			output = w
+			if w.preserveOrder {
+				output = &franzOrderedKafkaWriter{w}
+			}
 			return
 		})
 
@@ -91,6 +116,10 @@ func init() {
 
 //------------------------------------------------------------------------------
 
+// franzMaxOrderedInFlight is the maximum number of produce requests per broker
+// for which idempotent writes preserve ordering.
+const franzMaxOrderedInFlight = 5
+
 type franzKafkaWriter struct {
 	seedBrokers      []string
 	topicStr         string
@@ -102,6 +131,8 @@ type franzKafkaWriter struct {
 	partitioner      kgo.Partitioner
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
+	idempotentWrite  bool
+	preserveOrder    bool
 
 	client *kgo.Client
 
@@ -187,6 +218,25 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		}
 	}
 
+	if f.idempotentWrite, err = conf.FieldBool("idempotent_write"); err != nil {
+		return nil, err
+	}
+	if f.preserveOrder, err = conf.FieldBool("preserve_order"); err != nil {
+		return nil, err
+	}
+	if f.preserveOrder {
+		if !f.idempotentWrite {
+			return nil, errors.New("idempotent_write must be enabled when preserve_order is enabled")
+		}
+		maxInFlight, err := conf.FieldInt("max_in_flight")
+		if err != nil {
+			return nil, err
+		}
+		if maxInFlight > franzMaxOrderedInFlight {
+			return nil, fmt.Errorf("max_in_flight must not exceed %v when preserve_order is enabled", franzMaxOrderedInFlight)
+		}
+	}
+
 	if conf.Contains("metadata") {
 		if f.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
 			return nil, err
@@ -230,6 +280,9 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	if len(f.compressionPrefs) > 0 {
 		clientOpts = append(clientOpts, kgo.ProducerBatchCompression(f.compressionPrefs...))
 	}
+	if !f.idempotentWrite {
+		clientOpts = append(clientOpts, kgo.DisableIdempotentWrite())
+	}
 
 	cl, err := kgo.NewClient(clientOpts...)
 	if err != nil {
@@ -241,12 +294,8 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	return nil
 }
 
-func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) (err error) {
-	if f.client == nil {
-		return service.ErrNotConnected
-	}
-
-	records := make([]*kgo.Record, 0, len(b))
+func (f *franzKafkaWriter) toRecords(b service.MessageBatch) (records []*kgo.Record, err error) {
+	records = make([]*kgo.Record, 0, len(b))
 	for i, msg := range b {
 		record := &kgo.Record{Topic: b.InterpolatedString(i, f.topic)}
 		if record.Value, err = msg.AsBytes(); err != nil {
@@ -264,11 +313,73 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		})
 		records = append(records, record)
 	}
+	return
+}
+
+func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	if f.client == nil {
+		return service.ErrNotConnected
+	}
+
+	records, err := f.toRecords(b)
+	if err != nil {
+		return err
+	}
 
 	// TODO: This is very cool and allows us to easily return granular errors,
 	// so we should honor travis by doing it.
-	err = f.client.ProduceSync(ctx, records...).FirstErr()
-	return
+	return f.client.ProduceSync(ctx, records...).FirstErr()
+}
+
+// franzOrderedKafkaWriter enqueues the records of each batch with the client in
+// the order that batches are dispatched to the output.
+type franzOrderedKafkaWriter struct {
+	*franzKafkaWriter
+}
+
+func (f *franzOrderedKafkaWriter) EnqueueBatch(ctx context.Context, b service.MessageBatch) (func(context.Context) error, error) {
+	if f.client == nil {
+		return nil, service.ErrNotConnected
+	}
+
+	records, err := f.toRecords(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(records))
+
+	var errMut sync.Mutex
+	var firstErr error
+	promise := func(_ *kgo.Record, err error) {
+		if err != nil {
+			errMut.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			errMut.Unlock()
+		}
+		wg.Done()
+	}
+	for _, r := range records {
+		f.client.Produce(ctx, r, promise)
+	}
+
+	// As with ProduceSync we wait for the promises of all records, which are
+	// called once the records are either written or failed.
+	return func(context.Context) error {
+		wg.Wait()
+		return firstErr
+	}, nil
+}
+
+func (f *franzOrderedKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	wait, err := f.EnqueueBatch(ctx, b)
+	if err != nil {
+		return err
+	}
+	return wait(ctx)
 }
 
 func (f *franzKafkaWriter) disconnect() {
@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+var _ service.OrderedBatchOutput = &franzOrderedKafkaWriter{}
+
+func TestFranzKafkaOutputPreserveOrderConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        string
+		errContains string
+	}{
+		{
+			name: "defaults",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+`,
+		},
+		{
+			name: "preserve order",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+max_in_flight: 5
+preserve_order: true
+`,
+		},
+		{
+			name: "preserve order without idempotency",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+max_in_flight: 1
+idempotent_write: false
+preserve_order: true
+`,
+			errContains: "idempotent_write must be enabled",
+		},
+		{
+			name: "preserve order with too many in flight",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+preserve_order: true
+`,
+			errContains: "max_in_flight must not exceed 5",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := franzKafkaOutputConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			_, err = newFranzKafkaWriterFromConfig(conf, nil)
+			if test.errContains == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
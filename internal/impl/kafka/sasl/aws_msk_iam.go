@@ -0,0 +1,131 @@
+package sasl
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+)
+
+// AWSMSKIAMConfig contains configuration for obtaining OAUTHBEARER tokens
+// signed with AWS credentials, as accepted by AWS MSK clusters with IAM access
+// control enabled.
+type AWSMSKIAMConfig struct {
+	Enabled        bool `json:"enabled" yaml:"enabled"`
+	session.Config `json:",inline" yaml:",inline"`
+}
+
+// NewAWSMSKIAMConfig returns an AWSMSKIAMConfig with default values.
+func NewAWSMSKIAMConfig() AWSMSKIAMConfig {
+	return AWSMSKIAMConfig{
+		Enabled: false,
+		Config:  session.NewConfig(),
+	}
+}
+
+func awsMSKIAMFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("aws_msk_iam", "Obtain `"+sarama.SASLTypeOAuth+"` tokens by signing requests with AWS credentials, as required by AWS MSK clusters (including MSK Serverless) with IAM access control enabled. Tokens are refreshed automatically before they expire.").WithChildren(
+		append(docs.FieldSpecs{
+			docs.FieldBool("enabled", "Whether tokens should be obtained by signing with AWS credentials.").HasDefault(false),
+		}, session.FieldSpecs()...)...,
+	).Advanced()
+}
+
+const (
+	mskIAMService     = "kafka-cluster"
+	mskIAMAction      = "kafka-cluster:Connect"
+	mskIAMTokenExpiry = 15 * time.Minute
+	mskIAMUserAgent   = "benthos"
+)
+
+// newAWSMSKIAMTokenFetcher returns a function that creates tokens in the form
+// expected by AWS MSK, which is a base64 encoded URL of a request to connect to
+// the cluster presigned with AWS credentials.
+func newAWSMSKIAMTokenFetcher(conf AWSMSKIAMConfig) (func(now time.Time) (*sarama.AccessToken, time.Time, error), error) {
+	sess, err := conf.GetSession()
+	if err != nil {
+		return nil, err
+	}
+	region := ""
+	if sess.Config.Region != nil {
+		region = *sess.Config.Region
+	}
+	if region == "" {
+		return nil, errors.New("a region must be specified in order to sign tokens for AWS MSK")
+	}
+	return newMSKIAMTokenFetcher(sess.Config.Credentials, region), nil
+}
+
+func newMSKIAMTokenFetcher(creds *credentials.Credentials, region string) func(now time.Time) (*sarama.AccessToken, time.Time, error) {
+	signer := v4.NewSigner(creds)
+	return func(now time.Time) (*sarama.AccessToken, time.Time, error) {
+		query := url.Values{"Action": []string{mskIAMAction}}
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://kafka.%v.amazonaws.com/?%v", region, query.Encode()), nil)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if _, err := signer.Presign(req, nil, mskIAMService, region, mskIAMTokenExpiry, now.UTC()); err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+		}
+
+		signed := req.URL.Query()
+		signed.Set("User-Agent", mskIAMUserAgent)
+		req.URL.RawQuery = signed.Encode()
+
+		return &sarama.AccessToken{
+			Token: base64.RawURLEncoding.EncodeToString([]byte(req.URL.String())),
+		}, now.Add(mskIAMTokenExpiry), nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// refreshingAccessTokenProvider caches tokens that expire, and obtains a new
+// token once the current one is within a margin of expiring. Tokens are only
+// requested when a connection to a broker is established, and so a token is
+// never handed out that expires shortly after.
+type refreshingAccessTokenProvider struct {
+	fetch  func(now time.Time) (*sarama.AccessToken, time.Time, error)
+	margin time.Duration
+
+	mut     sync.Mutex
+	token   *sarama.AccessToken
+	expires time.Time
+
+	nowFn func() time.Time
+}
+
+func newRefreshingAccessTokenProvider(fetch func(now time.Time) (*sarama.AccessToken, time.Time, error), margin time.Duration) *refreshingAccessTokenProvider {
+	return &refreshingAccessTokenProvider{
+		fetch:  fetch,
+		margin: margin,
+		nowFn:  time.Now,
+	}
+}
+
+func (r *refreshingAccessTokenProvider) Token() (*sarama.AccessToken, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	now := r.nowFn()
+	if r.token != nil && now.Add(r.margin).Before(r.expires) {
+		return r.token, nil
+	}
+
+	token, expires, err := r.fetch(now)
+	if err != nil {
+		return nil, err
+	}
+	r.token, r.expires = token, expires
+	return token, nil
+}
@@ -0,0 +1,77 @@
+package sasl
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMSKIAMTokenFetcher(t *testing.T) {
+	fetch := newMSKIAMTokenFetcher(credentials.NewStaticCredentials("foo", "bar", "baz"), "eu-west-1")
+
+	now := time.Unix(1600000000, 0)
+	token, expires, err := fetch(now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(15*time.Minute), expires)
+
+	rawURL, err := base64.RawURLEncoding.DecodeString(token.Token)
+	require.NoError(t, err)
+
+	u, err := url.Parse(string(rawURL))
+	require.NoError(t, err)
+	assert.Equal(t, "https", u.Scheme)
+	assert.Equal(t, "kafka.eu-west-1.amazonaws.com", u.Host)
+
+	q := u.Query()
+	assert.Equal(t, "kafka-cluster:Connect", q.Get("Action"))
+	assert.Equal(t, "AWS4-HMAC-SHA256", q.Get("X-Amz-Algorithm"))
+	assert.Equal(t, "foo/20200913/eu-west-1/kafka-cluster/aws4_request", q.Get("X-Amz-Credential"))
+	assert.Equal(t, "20200913T122640Z", q.Get("X-Amz-Date"))
+	assert.Equal(t, "900", q.Get("X-Amz-Expires"))
+	assert.Equal(t, "baz", q.Get("X-Amz-Security-Token"))
+	assert.Equal(t, "benthos", q.Get("User-Agent"))
+	assert.NotEmpty(t, q.Get("X-Amz-Signature"))
+}
+
+func TestRefreshingAccessTokenProvider(t *testing.T) {
+	var fetches int
+	var fetchErr error
+	p := newRefreshingAccessTokenProvider(func(now time.Time) (*sarama.AccessToken, time.Time, error) {
+		if fetchErr != nil {
+			return nil, time.Time{}, fetchErr
+		}
+		fetches++
+		return &sarama.AccessToken{Token: now.Format(time.RFC3339)}, now.Add(15 * time.Minute), nil
+	}, time.Minute)
+
+	now := time.Unix(1600000000, 0).UTC()
+	p.nowFn = func() time.Time { return now }
+
+	tok, err := p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "2020-09-13T12:26:40Z", tok.Token)
+
+	now = now.Add(13 * time.Minute)
+	tok, err = p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "2020-09-13T12:26:40Z", tok.Token)
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(time.Minute)
+	tok, err = p.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "2020-09-13T12:40:40Z", tok.Token)
+	assert.Equal(t, 2, fetches)
+
+	fetchErr = errors.New("nope")
+	now = now.Add(time.Hour)
+	_, err = p.Token()
+	require.EqualError(t, err, "nope")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Shopify/sarama"
 
@@ -19,18 +20,20 @@ var (
 
 // Config contains configuration for SASL based authentication.
 type Config struct {
-	Mechanism   string `json:"mechanism" yaml:"mechanism"`
-	User        string `json:"user" yaml:"user"`
-	Password    string `json:"password" yaml:"password"`
-	AccessToken string `json:"access_token" yaml:"access_token"`
-	TokenCache  string `json:"token_cache" yaml:"token_cache"`
-	TokenKey    string `json:"token_key" yaml:"token_key"`
+	Mechanism   string          `json:"mechanism" yaml:"mechanism"`
+	User        string          `json:"user" yaml:"user"`
+	Password    string          `json:"password" yaml:"password"`
+	AccessToken string          `json:"access_token" yaml:"access_token"`
+	TokenCache  string          `json:"token_cache" yaml:"token_cache"`
+	TokenKey    string          `json:"token_key" yaml:"token_key"`
+	AWSMSKIAM   AWSMSKIAMConfig `json:"aws_msk_iam" yaml:"aws_msk_iam"`
 }
 
 // NewConfig returns a new SASL config for Kafka with default values.
 func NewConfig() Config {
 	return Config{
 		Mechanism: "none",
+		AWSMSKIAM: NewAWSMSKIAMConfig(),
 	}
 }
 
@@ -49,6 +52,7 @@ func FieldSpec() docs.FieldSpec {
 		docs.FieldString("access_token", "A static `"+sarama.SASLTypeOAuth+"` access token"),
 		docs.FieldString("token_cache", "Instead of using a static `access_token` allows you to query a [`cache`](/docs/components/caches/about) resource to fetch `"+sarama.SASLTypeOAuth+"` tokens from"),
 		docs.FieldString("token_key", "Required when using a `token_cache`, the key to query the cache with for tokens."),
+		awsMSKIAMFieldSpec(),
 	).Advanced()
 }
 
@@ -59,7 +63,13 @@ func (s Config) Apply(mgr interop.Manager, conf *sarama.Config) error {
 		var tp sarama.AccessTokenProvider
 		var err error
 
-		if s.TokenCache != "" {
+		if s.AWSMSKIAM.Enabled {
+			fetch, err := newAWSMSKIAMTokenFetcher(s.AWSMSKIAM)
+			if err != nil {
+				return err
+			}
+			tp = newRefreshingAccessTokenProvider(fetch, time.Minute)
+		} else if s.TokenCache != "" {
 			tp, err = newCacheAccessTokenProvider(mgr, s.TokenCache, s.TokenKey)
 			if err != nil {
 				return err
@@ -144,3 +144,25 @@ func TestApplyUnknownMechanism(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestApplyOAuthBearerAWSMSKIAMProvider(t *testing.T) {
+	conf := &sarama.Config{}
+
+	saslConf := sasl.NewConfig()
+	saslConf.Mechanism = sarama.SASLTypeOAuth
+	saslConf.AWSMSKIAM.Enabled = true
+	saslConf.AWSMSKIAM.Region = "us-east-1"
+	saslConf.AWSMSKIAM.Credentials.ID = "foo"
+	saslConf.AWSMSKIAM.Credentials.Secret = "bar"
+
+	require.NoError(t, saslConf.Apply(mock.NewManager(), conf))
+	require.True(t, conf.Net.SASL.Enable)
+
+	token, err := conf.Net.SASL.TokenProvider.Token()
+	require.NoError(t, err)
+	require.NotEmpty(t, token.Token)
+
+	again, err := conf.Net.SASL.TokenProvider.Token()
+	require.NoError(t, err)
+	require.Equal(t, token.Token, again.Token)
+}
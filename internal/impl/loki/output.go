@@ -0,0 +1,397 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func lokiOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Pushes messages as log lines to [Grafana Loki](https://grafana.com/oss/loki/).").
+		Description(`
+The raw contents of each message are pushed as a log line to the stream identified by the labels resulting from the `+"`labels`"+` mapping, which must be an object of string values. Labels with empty values are omitted, and a message resulting in no labels at all is rejected as Loki requires at least one label for each stream.
+
+The lines of a batch are grouped by stream and sent as a single push request, and therefore it is recommended to configure a [batching policy](/docs/configuration/batching). Loki requires the lines of a stream to be pushed in timestamp order, therefore the lines of each stream are ordered by timestamp before being sent, and the default `+"`max_in_flight`"+` of one prevents batches from arriving out of order.
+
+Lines are given the time at which they are written as their timestamp unless the field `+"`timestamp`"+` is set, lines of a batch without a timestamp keep their relative order.
+
+### Retries
+
+Requests that fail due to a connection error, a 5XX status code or a 429 status code are retried with a back off, and for a 429 the period given by the `+"`Retry-After`"+` header is respected. Once the retries of a request are exhausted the batch is rejected and the input decides whether to redeliver it. Requests rejected with any other 4XX status code are not retried, as sending the same lines again would result in the same error.`).
+		Field(service.NewStringField("url").
+			Description("The URL of the Loki push API.").
+			Example("http://localhost:3100/loki/api/v1/push")).
+		Field(service.NewBloblangField("labels").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of labels identifying the stream of a message.").
+			Example(`root.app = "benthos"
+root.env = meta("env")`).
+			Example(`root = this.kubernetes.labels`)).
+		Field(service.NewInterpolatedStringField("timestamp").
+			Description("An optional timestamp of each line, either as an RFC 3339 string or a number of seconds since the unix epoch.").
+			Example(`${! json("time") }`).
+			Optional()).
+		Field(service.NewStringField("tenant_id").
+			Description("An optional tenant to push to when Loki is running in multi-tenant mode, which is set as the `X-Scope-OrgID` header of requests.").
+			Default("")).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to requests.").
+			Default(map[string]interface{}{}).
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("A timeout for each request.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewIntField("max_retries").
+			Description("The maximum number of retry attempts for a request before the batch is rejected.").
+			Default(3).
+			Advanced()).
+		Field(service.NewBackOffField("backoff", false, nil).Advanced()).
+		Field(service.NewTLSField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time. Increasing this value can result in lines of a stream arriving out of order.").
+			Default(1)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Structured Logs", `
+Here we push structured logs to Loki, where the service and level of each log identifies its stream and the time of each log is used as the timestamp of its line:`,
+			`
+output:
+  loki:
+    url: http://loki:3100/loki/api/v1/push
+    tenant_id: platform
+    labels: |
+      root.service = this.service
+      root.level = this.level.lowercase()
+    timestamp: ${! json("time") }
+    batching:
+      count: 1000
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("loki", lokiOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newLokiOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type lokiEntry struct {
+	timestamp time.Time
+	line      string
+}
+
+type lokiStream struct {
+	labels  map[string]string
+	entries []lokiEntry
+}
+
+// MarshalJSON encodes a stream in the format expected by the push API, where
+// each entry is a tuple of a timestamp in nanoseconds as a string and a line.
+func (s *lokiStream) MarshalJSON() ([]byte, error) {
+	values := make([][2]string, len(s.entries))
+	for i, e := range s.entries {
+		values[i] = [2]string{strconv.FormatInt(e.timestamp.UnixNano(), 10), e.line}
+	}
+	return json.Marshal(map[string]interface{}{
+		"stream": s.labels,
+		"values": values,
+	})
+}
+
+func streamKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(0xff)
+		b.WriteString(labels[k])
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return query.IGetTimestamp(f)
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+//------------------------------------------------------------------------------
+
+type lokiRateLimited struct {
+	retryAfter time.Duration
+}
+
+func (e *lokiRateLimited) Error() string {
+	return "received status code 429 (too many requests)"
+}
+
+type lokiOutput struct {
+	log *service.Logger
+
+	url         string
+	labels      *bloblang.Executor
+	timestamp   *service.InterpolatedString
+	headers     map[string]string
+	maxRetries  int
+	backoffConf backoff.ExponentialBackOff
+	client      *http.Client
+
+	nowFn func() time.Time
+}
+
+func newLokiOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*lokiOutput, error) {
+	l := &lokiOutput{
+		log:   log,
+		nowFn: time.Now,
+	}
+
+	var err error
+	if l.url, err = conf.FieldString("url"); err != nil {
+		return nil, err
+	}
+	if l.labels, err = conf.FieldBloblang("labels"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("timestamp") {
+		if l.timestamp, err = conf.FieldInterpolatedString("timestamp"); err != nil {
+			return nil, err
+		}
+	}
+	if l.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+	tenantID, err := conf.FieldString("tenant_id")
+	if err != nil {
+		return nil, err
+	}
+	if tenantID != "" {
+		l.headers["X-Scope-OrgID"] = tenantID
+	}
+	if l.maxRetries, err = conf.FieldInt("max_retries"); err != nil {
+		return nil, err
+	}
+	boff, err := conf.FieldBackOff("backoff")
+	if err != nil {
+		return nil, err
+	}
+	l.backoffConf = *boff
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS("tls")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	l.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	return l, nil
+}
+
+func (l *lokiOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (l *lokiOutput) streamLabels(batch service.MessageBatch, i int) (map[string]string, error) {
+	msg, err := batch.BloblangQuery(i, l.labels)
+	if err != nil {
+		return nil, fmt.Errorf("labels mapping failed: %w", err)
+	}
+	if msg == nil {
+		return nil, errors.New("labels mapping deleted the message")
+	}
+
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("labels mapping: %w", err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected labels mapping to result in an object, got %T", v)
+	}
+
+	labels := map[string]string{}
+	for k, v := range obj {
+		if !labelNameRegexp.MatchString(k) {
+			return nil, fmt.Errorf("invalid label name: %q", k)
+		}
+		if vStr := query.IToString(v); vStr != "" {
+			labels[k] = vStr
+		}
+	}
+	if len(labels) == 0 {
+		return nil, errors.New("labels mapping resulted in no labels")
+	}
+	return labels, nil
+}
+
+func (l *lokiOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	now := l.nowFn()
+
+	streams := map[string]*lokiStream{}
+	var ordered []*lokiStream
+	for i, msg := range batch {
+		labels, err := l.streamLabels(batch, i)
+		if err != nil {
+			return fmt.Errorf("message %v: %w", i, err)
+		}
+
+		ts := now
+		if l.timestamp != nil {
+			if ts, err = parseTimestamp(batch.InterpolatedString(i, l.timestamp)); err != nil {
+				return fmt.Errorf("message %v: timestamp: %w", i, err)
+			}
+		}
+
+		line, err := msg.AsBytes()
+		if err != nil {
+			return fmt.Errorf("message %v: %w", i, err)
+		}
+
+		key := streamKey(labels)
+		s, exists := streams[key]
+		if !exists {
+			s = &lokiStream{labels: labels}
+			streams[key] = s
+			ordered = append(ordered, s)
+		}
+		s.entries = append(s.entries, lokiEntry{timestamp: ts, line: string(line)})
+	}
+	for _, s := range ordered {
+		sort.SliceStable(s.entries, func(i, j int) bool {
+			return s.entries[i].timestamp.Before(s.entries[j].timestamp)
+		})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"streams": ordered,
+	})
+	if err != nil {
+		return err
+	}
+	return l.send(ctx, body)
+}
+
+func (l *lokiOutput) send(ctx context.Context, body []byte) error {
+	boff := l.backoffConf
+	boff.Reset()
+
+	for attempt := 0; ; attempt++ {
+		err := l.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		var pErr *backoff.PermanentError
+		if errors.As(err, &pErr) {
+			return pErr.Err
+		}
+		if attempt >= l.maxRetries {
+			return err
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		var rlErr *lokiRateLimited
+		if errors.As(err, &rlErr) && rlErr.retryAfter > 0 {
+			wait = rlErr.retryAfter
+		}
+
+		l.log.Debugf("Retrying Loki push request in %v after error: %v", wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *lokiOutput) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", l.url, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Benthos")
+	for k, v := range l.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		return nil
+	case res.StatusCode == http.StatusTooManyRequests:
+		rlErr := &lokiRateLimited{}
+		if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && secs > 0 {
+			rlErr.retryAfter = time.Duration(secs) * time.Second
+		}
+		return rlErr
+	case res.StatusCode >= 500:
+		return fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, resBody)
+	}
+	return backoff.Permanent(fmt.Errorf("lines rejected with status code %v: %s", res.StatusCode, resBody))
+}
+
+func (l *lokiOutput) Close(ctx context.Context) error {
+	l.client.CloseIdleConnections()
+	return nil
+}
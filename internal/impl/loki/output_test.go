@@ -0,0 +1,197 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testLokiOutput(t *testing.T, confStr string) *lokiOutput {
+	t.Helper()
+
+	conf, err := lokiOutputConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	l, err := newLokiOutputFromConfig(conf, nil)
+	require.NoError(t, err)
+	return l
+}
+
+type pushStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiServer struct {
+	mut      sync.Mutex
+	requests [][]pushStream
+	tenants  []string
+	statuses []int
+}
+
+func (s *lokiServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req struct {
+			Streams []pushStream `json:"streams"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		s.requests = append(s.requests, req.Streams)
+		s.tenants = append(s.tenants, r.Header.Get("X-Scope-OrgID"))
+		if len(s.statuses) > 0 {
+			w.WriteHeader(s.statuses[0])
+			s.statuses = s.statuses[1:]
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestLokiOutputStreams(t *testing.T) {
+	srv := &lokiServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	l := testLokiOutput(t, `
+url: `+ts.URL+`
+tenant_id: foo
+labels: |
+  root.app = this.app
+  root.empty = ""
+timestamp: ${! json("ts") }
+`)
+
+	require.NoError(t, l.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"app":"a","ts":20}`)),
+		service.NewMessage([]byte(`{"app":"b","ts":"1970-01-01T00:00:05Z"}`)),
+		service.NewMessage([]byte(`{"app":"a","ts":10.5}`)),
+		service.NewMessage([]byte(`{"app":"a","ts":30}`)),
+	}))
+
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	assert.Equal(t, []string{"foo"}, srv.tenants)
+	assert.Equal(t, [][]pushStream{{
+		{
+			Stream: map[string]string{"app": "a"},
+			Values: [][2]string{
+				{"10500000000", `{"app":"a","ts":10.5}`},
+				{"20000000000", `{"app":"a","ts":20}`},
+				{"30000000000", `{"app":"a","ts":30}`},
+			},
+		},
+		{
+			Stream: map[string]string{"app": "b"},
+			Values: [][2]string{
+				{"5000000000", `{"app":"b","ts":"1970-01-01T00:00:05Z"}`},
+			},
+		},
+	}}, srv.requests)
+}
+
+func TestLokiOutputDefaultTimestamp(t *testing.T) {
+	srv := &lokiServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	l := testLokiOutput(t, `
+url: `+ts.URL+`
+labels: 'root.app = "static"'
+`)
+	l.nowFn = func() time.Time {
+		return time.Unix(1, 0)
+	}
+
+	require.NoError(t, l.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`first`)),
+		service.NewMessage([]byte(`second`)),
+	}))
+
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	assert.Equal(t, []string{""}, srv.tenants)
+	assert.Equal(t, [][]pushStream{{
+		{
+			Stream: map[string]string{"app": "static"},
+			Values: [][2]string{
+				{"1000000000", `first`},
+				{"1000000000", `second`},
+			},
+		},
+	}}, srv.requests)
+}
+
+func TestLokiOutputInvalidLabels(t *testing.T) {
+	l := testLokiOutput(t, `
+url: http://localhost:1
+labels: 'root = this'
+`)
+
+	for _, test := range []struct {
+		content     string
+		errContains string
+	}{
+		{content: `["nope"]`, errContains: "expected labels mapping to result in an object"},
+		{content: `{}`, errContains: "no labels"},
+		{content: `{"foo":""}`, errContains: "no labels"},
+		{content: `{"foo-bar":"baz"}`, errContains: "invalid label name"},
+	} {
+		err := l.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(test.content)),
+		})
+		require.Error(t, err, test.content)
+		assert.Contains(t, err.Error(), test.errContains, test.content)
+	}
+}
+
+func TestLokiOutputRetries(t *testing.T) {
+	srv := &lokiServer{
+		statuses: []int{http.StatusTooManyRequests, http.StatusBadGateway},
+	}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	l := testLokiOutput(t, `
+url: `+ts.URL+`
+labels: 'root.app = "static"'
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+`)
+
+	require.NoError(t, l.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`hello world`)),
+	}))
+
+	srv.mut.Lock()
+	assert.Len(t, srv.requests, 3)
+	srv.statuses = []int{http.StatusBadRequest}
+	srv.mut.Unlock()
+
+	err := l.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`hello world`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status code 400")
+
+	srv.mut.Lock()
+	assert.Len(t, srv.requests, 4)
+	srv.mut.Unlock()
+}
@@ -0,0 +1,196 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Modbus function codes of the read requests supported by the client.
+const (
+	funcReadCoils            byte = 0x01
+	funcReadDiscreteInputs   byte = 0x02
+	funcReadHoldingRegisters byte = 0x03
+	funcReadInputRegisters   byte = 0x04
+)
+
+// modbusException is an exception response returned by a device.
+type modbusException struct {
+	function byte
+	code     byte
+}
+
+func (e *modbusException) Error() string {
+	desc := "unknown exception"
+	switch e.code {
+	case 0x01:
+		desc = "illegal function"
+	case 0x02:
+		desc = "illegal data address"
+	case 0x03:
+		desc = "illegal data value"
+	case 0x04:
+		desc = "server device failure"
+	case 0x06:
+		desc = "server device busy"
+	case 0x0A:
+		desc = "gateway path unavailable"
+	case 0x0B:
+		desc = "gateway target device failed to respond"
+	}
+	return fmt.Sprintf("modbus exception %v (%v) for function %v", e.code, desc, e.function)
+}
+
+// modbusClient performs read requests over a connection using either Modbus
+// TCP framing, or RTU framing tunnelled over TCP.
+type modbusClient struct {
+	conn    net.Conn
+	rtu     bool
+	unitID  byte
+	timeout time.Duration
+
+	transactionID uint16
+}
+
+func newModbusClient(conn net.Conn, rtu bool, unitID byte, timeout time.Duration) *modbusClient {
+	return &modbusClient{
+		conn:    conn,
+		rtu:     rtu,
+		unitID:  unitID,
+		timeout: timeout,
+	}
+}
+
+// read performs a read request and returns the data of the response.
+func (c *modbusClient) read(function byte, address, quantity uint16) ([]byte, error) {
+	pdu := make([]byte, 5)
+	pdu[0] = function
+	binary.BigEndian.PutUint16(pdu[1:], address)
+	binary.BigEndian.PutUint16(pdu[3:], quantity)
+
+	if c.timeout > 0 {
+		if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+			return nil, err
+		}
+	}
+
+	var res []byte
+	var err error
+	if c.rtu {
+		res, err = c.sendRTU(pdu)
+	} else {
+		res, err = c.sendTCP(pdu)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) < 2 {
+		return nil, errors.New("modbus response is too short")
+	}
+	if res[0] == function|0x80 {
+		return nil, &modbusException{function: function, code: res[1]}
+	}
+	if res[0] != function {
+		return nil, fmt.Errorf("modbus response has function %v, expected %v", res[0], function)
+	}
+	if int(res[1]) != len(res)-2 {
+		return nil, fmt.Errorf("modbus response has byte count %v but contains %v bytes", res[1], len(res)-2)
+	}
+	return res[2:], nil
+}
+
+// sendTCP sends a PDU with a Modbus application protocol header and returns
+// the PDU of the response.
+func (c *modbusClient) sendTCP(pdu []byte) ([]byte, error) {
+	c.transactionID++
+
+	req := make([]byte, 7+len(pdu))
+	binary.BigEndian.PutUint16(req[0:], c.transactionID)
+	binary.BigEndian.PutUint16(req[2:], 0)
+	binary.BigEndian.PutUint16(req[4:], uint16(len(pdu)+1))
+	req[6] = c.unitID
+	copy(req[7:], pdu)
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint16(header[4:])
+	if length < 2 || length > 254 {
+		return nil, fmt.Errorf("modbus response has invalid length %v", length)
+	}
+	res := make([]byte, length-1)
+	if _, err := io.ReadFull(c.conn, res); err != nil {
+		return nil, err
+	}
+
+	if id := binary.BigEndian.Uint16(header[0:]); id != c.transactionID {
+		return nil, fmt.Errorf("modbus response has transaction ID %v, expected %v", id, c.transactionID)
+	}
+	if protocol := binary.BigEndian.Uint16(header[2:]); protocol != 0 {
+		return nil, fmt.Errorf("modbus response has protocol ID %v, expected 0", protocol)
+	}
+	if header[6] != c.unitID {
+		return nil, fmt.Errorf("modbus response has unit ID %v, expected %v", header[6], c.unitID)
+	}
+	return res, nil
+}
+
+// sendRTU sends a PDU within an RTU frame and returns the PDU of the response.
+func (c *modbusClient) sendRTU(pdu []byte) ([]byte, error) {
+	req := make([]byte, 0, len(pdu)+3)
+	req = append(req, c.unitID)
+	req = append(req, pdu...)
+	crc := crc16(req)
+	req = append(req, byte(crc), byte(crc>>8))
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	// The length of the frame is determined by the function code: exceptions
+	// contain a single byte, and read responses are prefixed by a byte count.
+	frame := make([]byte, 3)
+	if _, err := io.ReadFull(c.conn, frame); err != nil {
+		return nil, err
+	}
+	remaining := 2
+	if frame[1]&0x80 == 0 {
+		remaining += int(frame[2])
+	}
+	frame = append(frame, make([]byte, remaining)...)
+	if _, err := io.ReadFull(c.conn, frame[3:]); err != nil {
+		return nil, err
+	}
+
+	body := frame[:len(frame)-2]
+	if crc := binary.LittleEndian.Uint16(frame[len(frame)-2:]); crc != crc16(body) {
+		return nil, errors.New("modbus response has an invalid CRC")
+	}
+	if body[0] != c.unitID {
+		return nil, fmt.Errorf("modbus response has unit ID %v, expected %v", body[0], c.unitID)
+	}
+	return body[1:], nil
+}
+
+// crc16 calculates the Modbus CRC of an RTU frame.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
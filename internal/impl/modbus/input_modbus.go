@@ -0,0 +1,493 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func modbusInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Polls the registers and coils of a Modbus device over TCP on an interval.").
+		Description(`
+Each poll reads every configured register and results in a single JSON object containing the value of each register under its `+"`name`"+`. The raw registers are converted to typed values according to the `+"`data_type`"+`, `+"`byte_order`"+` and `+"`word_order`"+` of each register, which allows reading values that span multiple registers such as 32-bit floats, and optionally scaled.
+
+Both Modbus TCP and Modbus RTU frames tunnelled over TCP, as supported by many serial gateways, can be used by setting the field `+"`framing`"+`.
+
+### Bit Fields
+
+Registers that contain a set of flags can be read by setting `+"`bits`"+`, in which case the value of the register is an object containing a boolean for each named bit, where bit 0 is the least significant bit.
+
+### Errors
+
+When the device responds with an exception, such as for an illegal data address, the poll fails and is retried after the interval. When the connection fails it's reestablished.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"``` text"+`
+- modbus_address
+- modbus_unit_id
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("address").
+			Description("The address of the Modbus device or gateway.").
+			Example("localhost:502")).
+		Field(service.NewStringAnnotatedEnumField("framing", map[string]string{
+			"tcp":          "Modbus TCP framing.",
+			"rtu_over_tcp": "Modbus RTU framing, including a CRC, tunnelled over TCP.",
+		}).
+			Description("The framing of requests and responses.").
+			Default("tcp")).
+		Field(service.NewIntField("unit_id").
+			Description("The unit identifier of the device, also known as the slave ID.").
+			Default(1)).
+		Field(service.NewDurationField("interval").
+			Description("The interval at which registers are polled.").
+			Default("1s")).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for a response to each request.").
+			Default("5s").
+			Advanced()).
+		Field(service.NewObjectListField("registers",
+			service.NewStringField("name").
+				Description("The name of the field to add the value of the register to."),
+			service.NewStringAnnotatedEnumField("type", map[string]string{
+				"coil":             "A read-write single bit.",
+				"discrete_input":   "A read-only single bit.",
+				"holding_register": "A read-write 16-bit register.",
+				"input_register":   "A read-only 16-bit register.",
+			}).
+				Description("The type of the register.").
+				Default("holding_register"),
+			service.NewIntField("address").
+				Description("The zero-based address of the register, or the first register of a value spanning multiple registers."),
+			service.NewStringEnumField("data_type", "uint16", "int16", "uint32", "int32", "float32", "uint64", "int64", "float64").
+				Description("The data type of the value, which determines how many consecutive registers are read. Ignored for coils and discrete inputs, which are read as booleans.").
+				Default("uint16"),
+			service.NewStringEnumField("byte_order", "big", "little").
+				Description("The order of the bytes within each register.").
+				Default("big").
+				Advanced(),
+			service.NewStringEnumField("word_order", "big", "little").
+				Description("The order of the registers of values spanning multiple registers, where `big` means the first register contains the most significant word.").
+				Default("big").
+				Advanced(),
+			service.NewFloatField("scale").
+				Description("A factor to multiply the value by, resulting in a float when not 1.").
+				Default(1.0),
+			service.NewFloatField("offset").
+				Description("An offset to add to the value after scaling, resulting in a float when not 0.").
+				Default(0.0),
+			service.NewObjectListField("bits",
+				service.NewStringField("name").
+					Description("The name of the bit."),
+				service.NewIntField("bit").
+					Description("The position of the bit, where 0 is the least significant bit."),
+			).
+				Description("Reads the register as a set of named bits rather than a number. Only supported by unsigned integer data types.").
+				Default([]interface{}{}).
+				Advanced(),
+		).
+			Description("The registers to read on each poll.")).
+		Example("Temperature Controller", `
+Here we poll the temperature, set point and status flags of a temperature controller every five seconds, where the temperature is a 32-bit float spanning two input registers and the set point is stored in tenths of a degree:`,
+			`
+input:
+  modbus:
+    address: 192.168.1.10:502
+    unit_id: 1
+    interval: 5s
+    registers:
+      - name: temperature
+        type: input_register
+        address: 0
+        data_type: float32
+      - name: set_point
+        address: 100
+        data_type: int16
+        scale: 0.1
+      - name: status
+        address: 200
+        bits:
+          - name: heating
+            bit: 0
+          - name: alarm
+            bit: 3
+      - name: enabled
+        type: coil
+        address: 0
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"modbus", modbusInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newModbusInputFromConfig(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type modbusBit struct {
+	name string
+	bit  int
+}
+
+type modbusRegister struct {
+	name      string
+	function  byte
+	address   uint16
+	dataType  string
+	quantity  uint16
+	byteOrder binary.ByteOrder
+	wordOrder binary.ByteOrder
+	scale     float64
+	offset    float64
+	bits      []modbusBit
+}
+
+// registerQuantities are the number of 16-bit registers of each data type.
+var registerQuantities = map[string]uint16{
+	"uint16":  1,
+	"int16":   1,
+	"uint32":  2,
+	"int32":   2,
+	"float32": 2,
+	"uint64":  4,
+	"int64":   4,
+	"float64": 4,
+}
+
+func modbusRegisterFromParsed(conf *service.ParsedConfig) (*modbusRegister, error) {
+	r := &modbusRegister{}
+
+	var err error
+	if r.name, err = conf.FieldString("name"); err != nil {
+		return nil, err
+	}
+	if r.name == "" {
+		return nil, errors.New("register name must not be empty")
+	}
+
+	typeStr, err := conf.FieldString("type")
+	if err != nil {
+		return nil, err
+	}
+	switch typeStr {
+	case "coil":
+		r.function = funcReadCoils
+	case "discrete_input":
+		r.function = funcReadDiscreteInputs
+	case "holding_register":
+		r.function = funcReadHoldingRegisters
+	case "input_register":
+		r.function = funcReadInputRegisters
+	default:
+		return nil, fmt.Errorf("register type %v was not recognised", typeStr)
+	}
+
+	address, err := conf.FieldInt("address")
+	if err != nil {
+		return nil, err
+	}
+	if address < 0 || address > math.MaxUint16 {
+		return nil, fmt.Errorf("register %v has an address out of range: %v", r.name, address)
+	}
+	r.address = uint16(address)
+
+	if r.function == funcReadCoils || r.function == funcReadDiscreteInputs {
+		r.quantity = 1
+		return r, nil
+	}
+
+	if r.dataType, err = conf.FieldString("data_type"); err != nil {
+		return nil, err
+	}
+	var exists bool
+	if r.quantity, exists = registerQuantities[r.dataType]; !exists {
+		return nil, fmt.Errorf("data type %v was not recognised", r.dataType)
+	}
+
+	byteOrder, err := conf.FieldString("byte_order")
+	if err != nil {
+		return nil, err
+	}
+	r.byteOrder = binary.BigEndian
+	if byteOrder == "little" {
+		r.byteOrder = binary.LittleEndian
+	}
+	wordOrder, err := conf.FieldString("word_order")
+	if err != nil {
+		return nil, err
+	}
+	r.wordOrder = binary.BigEndian
+	if wordOrder == "little" {
+		r.wordOrder = binary.LittleEndian
+	}
+
+	if r.scale, err = conf.FieldFloat("scale"); err != nil {
+		return nil, err
+	}
+	if r.offset, err = conf.FieldFloat("offset"); err != nil {
+		return nil, err
+	}
+
+	bitConfs, err := conf.FieldObjectList("bits")
+	if err != nil {
+		return nil, err
+	}
+	if len(bitConfs) > 0 {
+		switch r.dataType {
+		case "uint16", "uint32", "uint64":
+		default:
+			return nil, fmt.Errorf("register %v has bits but data type %v, expected an unsigned integer", r.name, r.dataType)
+		}
+	}
+	for _, bc := range bitConfs {
+		var b modbusBit
+		if b.name, err = bc.FieldString("name"); err != nil {
+			return nil, err
+		}
+		if b.bit, err = bc.FieldInt("bit"); err != nil {
+			return nil, err
+		}
+		if b.bit < 0 || b.bit >= int(r.quantity)*16 {
+			return nil, fmt.Errorf("register %v has bit %v out of range for data type %v", r.name, b.bit, r.dataType)
+		}
+		r.bits = append(r.bits, b)
+	}
+	return r, nil
+}
+
+// decode converts the data of a read response into the value of the register.
+func (r *modbusRegister) decode(data []byte) (interface{}, error) {
+	if r.function == funcReadCoils || r.function == funcReadDiscreteInputs {
+		if len(data) < 1 {
+			return nil, fmt.Errorf("register %v response contains no data", r.name)
+		}
+		return data[0]&1 == 1, nil
+	}
+
+	if len(data) != int(r.quantity)*2 {
+		return nil, fmt.Errorf("register %v response contains %v bytes, expected %v", r.name, len(data), r.quantity*2)
+	}
+
+	// Normalise the data to big endian, first by ordering the bytes of each
+	// register and then by ordering the registers.
+	words := make([]uint16, r.quantity)
+	for i := range words {
+		words[i] = r.byteOrder.Uint16(data[i*2:])
+	}
+	if r.wordOrder == binary.LittleEndian {
+		for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+			words[i], words[j] = words[j], words[i]
+		}
+	}
+	var raw uint64
+	for _, w := range words {
+		raw = raw<<16 | uint64(w)
+	}
+
+	if len(r.bits) > 0 {
+		obj := make(map[string]interface{}, len(r.bits))
+		for _, b := range r.bits {
+			obj[b.name] = raw&(1<<uint(b.bit)) != 0
+		}
+		return obj, nil
+	}
+
+	var v interface{}
+	switch r.dataType {
+	case "uint16", "uint32", "uint64":
+		v = raw
+	case "int16":
+		v = int64(int16(raw))
+	case "int32":
+		v = int64(int32(raw))
+	case "int64":
+		v = int64(raw)
+	case "float32":
+		v = float64(math.Float32frombits(uint32(raw)))
+	case "float64":
+		v = math.Float64frombits(raw)
+	}
+
+	if r.scale == 1 && r.offset == 0 {
+		return v, nil
+	}
+	var f float64
+	switch t := v.(type) {
+	case uint64:
+		f = float64(t)
+	case int64:
+		f = float64(t)
+	case float64:
+		f = t
+	}
+	return f*r.scale + r.offset, nil
+}
+
+//------------------------------------------------------------------------------
+
+type modbusInput struct {
+	address   string
+	rtu       bool
+	unitID    byte
+	interval  time.Duration
+	timeout   time.Duration
+	registers []*modbusRegister
+	log       *service.Logger
+
+	connMut  sync.Mutex
+	client   *modbusClient
+	lastPoll time.Time
+}
+
+func newModbusInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*modbusInput, error) {
+	m := &modbusInput{log: log}
+
+	var err error
+	if m.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+
+	framing, err := conf.FieldString("framing")
+	if err != nil {
+		return nil, err
+	}
+	m.rtu = framing == "rtu_over_tcp"
+
+	unitID, err := conf.FieldInt("unit_id")
+	if err != nil {
+		return nil, err
+	}
+	if unitID < 0 || unitID > 255 {
+		return nil, fmt.Errorf("unit_id must be between 0 and 255, got %v", unitID)
+	}
+	m.unitID = byte(unitID)
+
+	if m.interval, err = conf.FieldDuration("interval"); err != nil {
+		return nil, err
+	}
+	if m.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+
+	regConfs, err := conf.FieldObjectList("registers")
+	if err != nil {
+		return nil, err
+	}
+	if len(regConfs) == 0 {
+		return nil, errors.New("at least one register must be specified")
+	}
+	names := map[string]struct{}{}
+	for _, rc := range regConfs {
+		r, err := modbusRegisterFromParsed(rc)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := names[r.name]; exists {
+			return nil, fmt.Errorf("duplicate register name: %v", r.name)
+		}
+		names[r.name] = struct{}{}
+		m.registers = append(m.registers, r)
+	}
+	return m, nil
+}
+
+func (m *modbusInput) Connect(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client != nil {
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: m.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", m.address)
+	if err != nil {
+		return err
+	}
+	m.client = newModbusClient(conn, m.rtu, m.unitID, m.timeout)
+	m.log.Infof("Polling Modbus device at %v", m.address)
+	return nil
+}
+
+func (m *modbusInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	m.connMut.Lock()
+	nextPoll := m.lastPoll.Add(m.interval)
+	m.connMut.Unlock()
+
+	select {
+	case <-time.After(time.Until(nextPoll)):
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+	m.lastPoll = time.Now()
+
+	obj := make(map[string]interface{}, len(m.registers))
+	for _, r := range m.registers {
+		data, err := m.client.read(r.function, r.address, r.quantity)
+		if err != nil {
+			var mErr *modbusException
+			if errors.As(err, &mErr) {
+				return nil, nil, fmt.Errorf("failed to read register %v: %w", r.name, err)
+			}
+			m.log.Errorf("Failed to read register %v: %v", r.name, err)
+			_ = m.client.conn.Close()
+			m.client = nil
+			return nil, nil, service.ErrNotConnected
+		}
+		if obj[r.name], err = r.decode(data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(obj)
+	msg.MetaSet("modbus_address", m.address)
+	msg.MetaSet("modbus_unit_id", fmt.Sprintf("%v", m.unitID))
+	return msg, func(context.Context, error) error {
+		return nil
+	}, nil
+}
+
+func (m *modbusInput) Close(ctx context.Context) error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
+	if m.client != nil {
+		_ = m.client.conn.Close()
+		m.client = nil
+	}
+	return nil
+}
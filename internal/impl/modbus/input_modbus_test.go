@@ -0,0 +1,413 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeModbusServer serves read requests from a map of register values, where
+// coils and discrete inputs are true when their value is non-zero.
+type fakeModbusServer struct {
+	ln        net.Listener
+	rtu       bool
+	registers map[byte]map[uint16]uint16
+}
+
+func newFakeModbusServer(t *testing.T, rtu bool, registers map[byte]map[uint16]uint16) *fakeModbusServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	s := &fakeModbusServer{ln: ln, rtu: rtu, registers: registers}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeModbusServer) respond(pdu []byte) []byte {
+	function := pdu[0]
+	address := binary.BigEndian.Uint16(pdu[1:])
+	quantity := binary.BigEndian.Uint16(pdu[3:])
+
+	values, exists := s.registers[function]
+	if !exists {
+		return []byte{function | 0x80, 0x01}
+	}
+	for i := uint16(0); i < quantity; i++ {
+		if _, exists := values[address+i]; !exists {
+			return []byte{function | 0x80, 0x02}
+		}
+	}
+
+	if function == funcReadCoils || function == funcReadDiscreteInputs {
+		data := make([]byte, (quantity+7)/8)
+		for i := uint16(0); i < quantity; i++ {
+			if values[address+i] != 0 {
+				data[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append([]byte{function, byte(len(data))}, data...)
+	}
+
+	res := []byte{function, byte(quantity * 2)}
+	for i := uint16(0); i < quantity; i++ {
+		res = append(res, byte(values[address+i]>>8), byte(values[address+i]))
+	}
+	return res
+}
+
+func (s *fakeModbusServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		if s.rtu {
+			req := make([]byte, 8)
+			if _, err := io.ReadFull(conn, req); err != nil {
+				return
+			}
+			if crc16(req[:6]) != binary.LittleEndian.Uint16(req[6:]) {
+				return
+			}
+			res := append([]byte{req[0]}, s.respond(req[1:6])...)
+			crc := crc16(res)
+			res = append(res, byte(crc), byte(crc>>8))
+			if _, err := conn.Write(res); err != nil {
+				return
+			}
+			continue
+		}
+
+		req := make([]byte, 12)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		pdu := s.respond(req[7:])
+		res := make([]byte, 7, 7+len(pdu))
+		copy(res, req[:4])
+		binary.BigEndian.PutUint16(res[4:], uint16(len(pdu)+1))
+		res[6] = req[6]
+		res = append(res, pdu...)
+		if _, err := conn.Write(res); err != nil {
+			return
+		}
+	}
+}
+
+func testModbusInput(t *testing.T, confStr string) *modbusInput {
+	t.Helper()
+
+	conf, err := modbusInputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	m, err := newModbusInputFromConfig(conf, nil)
+	require.NoError(t, err)
+	return m
+}
+
+func TestModbusInput(t *testing.T) {
+	f32 := math.Float32bits(21.5)
+	registers := map[byte]map[uint16]uint16{
+		funcReadCoils: {0: 1},
+		funcReadHoldingRegisters: {
+			100: uint16(0xFFFF - 204), // -205
+			200: 0x0009,
+		},
+		funcReadInputRegisters: {
+			0: uint16(f32 >> 16),
+			1: uint16(f32),
+		},
+	}
+
+	for _, framing := range []string{"tcp", "rtu_over_tcp"} {
+		framing := framing
+		t.Run(framing, func(t *testing.T) {
+			s := newFakeModbusServer(t, framing == "rtu_over_tcp", registers)
+
+			m := testModbusInput(t, `
+address: `+s.ln.Addr().String()+`
+framing: `+framing+`
+unit_id: 3
+interval: 10ms
+registers:
+  - name: temperature
+    type: input_register
+    address: 0
+    data_type: float32
+  - name: set_point
+    address: 100
+    data_type: int16
+    scale: 0.1
+  - name: status
+    address: 200
+    bits:
+      - name: heating
+        bit: 0
+      - name: cooling
+        bit: 1
+      - name: alarm
+        bit: 3
+  - name: enabled
+    type: coil
+    address: 0
+`)
+
+			ctx := context.Background()
+			require.NoError(t, m.Connect(ctx))
+
+			for i := 0; i < 2; i++ {
+				msg, ackFn, err := m.Read(ctx)
+				require.NoError(t, err)
+				require.NoError(t, ackFn(ctx, nil))
+
+				v, err := msg.AsStructured()
+				require.NoError(t, err)
+				obj := v.(map[string]interface{})
+				assert.Equal(t, 21.5, obj["temperature"])
+				assert.InDelta(t, -20.5, obj["set_point"], 0.0001)
+				assert.Equal(t, map[string]interface{}{
+					"heating": true,
+					"cooling": false,
+					"alarm":   true,
+				}, obj["status"])
+				assert.Equal(t, true, obj["enabled"])
+
+				unitID, _ := msg.MetaGet("modbus_unit_id")
+				assert.Equal(t, "3", unitID)
+			}
+
+			require.NoError(t, m.Close(ctx))
+		})
+	}
+}
+
+func TestModbusInputException(t *testing.T) {
+	s := newFakeModbusServer(t, false, map[byte]map[uint16]uint16{
+		funcReadHoldingRegisters: {0: 1},
+	})
+
+	m := testModbusInput(t, `
+address: `+s.ln.Addr().String()+`
+interval: 1ms
+registers:
+  - name: foo
+    address: 5
+`)
+
+	ctx := context.Background()
+	require.NoError(t, m.Connect(ctx))
+
+	_, _, err := m.Read(ctx)
+	require.EqualError(t, err, "failed to read register foo: modbus exception 2 (illegal data address) for function 3")
+
+	// The connection remains usable after an exception.
+	_, _, err = m.Read(ctx)
+	require.Error(t, err)
+	assert.NotEqual(t, service.ErrNotConnected, err)
+}
+
+func TestModbusInputConnectionLost(t *testing.T) {
+	s := newFakeModbusServer(t, false, map[byte]map[uint16]uint16{
+		funcReadHoldingRegisters: {0: 1},
+	})
+
+	m := testModbusInput(t, `
+address: `+s.ln.Addr().String()+`
+interval: 1ms
+timeout: 100ms
+registers:
+  - name: foo
+    address: 0
+`)
+
+	ctx := context.Background()
+	require.NoError(t, m.Connect(ctx))
+	_, _, err := m.Read(ctx)
+	require.NoError(t, err)
+
+	_ = m.client.conn.Close()
+	_, _, err = m.Read(ctx)
+	assert.Equal(t, service.ErrNotConnected, err)
+
+	require.NoError(t, m.Connect(ctx))
+	_, _, err = m.Read(ctx)
+	require.NoError(t, err)
+}
+
+func TestModbusRegisterDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		data []byte
+		exp  interface{}
+	}{
+		{
+			name: "uint32 big endian",
+			conf: `data_type: uint32`,
+			data: []byte{0x00, 0x01, 0x00, 0x02},
+			exp:  uint64(0x00010002),
+		},
+		{
+			name: "uint32 little endian words",
+			conf: `
+data_type: uint32
+word_order: little`,
+			data: []byte{0x00, 0x01, 0x00, 0x02},
+			exp:  uint64(0x00020001),
+		},
+		{
+			name: "uint32 little endian bytes and words",
+			conf: `
+data_type: uint32
+byte_order: little
+word_order: little`,
+			data: []byte{0x01, 0x00, 0x02, 0x00},
+			exp:  uint64(0x00020001),
+		},
+		{
+			name: "int64",
+			conf: `data_type: int64`,
+			data: []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE},
+			exp:  int64(-2),
+		},
+		{
+			name: "float64",
+			conf: `data_type: float64`,
+			data: func() []byte {
+				b := make([]byte, 8)
+				binary.BigEndian.PutUint64(b, math.Float64bits(-1.25))
+				return b
+			}(),
+			exp: -1.25,
+		},
+		{
+			name: "uint16 scaled with offset",
+			conf: `
+scale: 0.5
+offset: -10`,
+			data: []byte{0x00, 0x64},
+			exp:  40.0,
+		},
+	}
+
+	for _, test := range tests {
+		r := testModbusRegister(t, "name: foo\naddress: 0\n"+test.conf)
+		v, err := r.decode(test.data)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.exp, v, test.name)
+	}
+}
+
+func testModbusRegister(t *testing.T, regConf string) *modbusRegister {
+	t.Helper()
+
+	conf, err := modbusInputConfig().ParseYAML(`
+address: localhost:502
+registers:
+  - `+strings.ReplaceAll(regConf, "\n", "\n    ")+`
+`, nil)
+	require.NoError(t, err)
+
+	regConfs, err := conf.FieldObjectList("registers")
+	require.NoError(t, err)
+
+	r, err := modbusRegisterFromParsed(regConfs[0])
+	require.NoError(t, err)
+	return r
+}
+
+func TestModbusInputConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		conf string
+		err  string
+	}{
+		{
+			conf: `registers: []`,
+			err:  "at least one register must be specified",
+		},
+		{
+			conf: `
+registers:
+  - name: foo
+    address: 0
+  - name: foo
+    address: 1`,
+			err: "duplicate register name: foo",
+		},
+		{
+			conf: `
+registers:
+  - name: foo
+    address: 0
+    data_type: int16
+    bits: [ { name: bar, bit: 0 } ]`,
+			err: "register foo has bits but data type int16, expected an unsigned integer",
+		},
+		{
+			conf: `
+registers:
+  - name: foo
+    address: 0
+    bits: [ { name: bar, bit: 16 } ]`,
+			err: "register foo has bit 16 out of range for data type uint16",
+		},
+		{
+			conf: `
+registers:
+  - name: foo
+    address: 70000`,
+			err: "register foo has an address out of range: 70000",
+		},
+	} {
+		conf, err := modbusInputConfig().ParseYAML("address: localhost:502\n"+test.conf, nil)
+		require.NoError(t, err)
+
+		_, err = newModbusInputFromConfig(conf, nil)
+		assert.EqualError(t, err, test.err)
+	}
+}
+
+func TestModbusInputCloseDuringInterval(t *testing.T) {
+	s := newFakeModbusServer(t, false, map[byte]map[uint16]uint16{
+		funcReadHoldingRegisters: {0: 1},
+	})
+
+	m := testModbusInput(t, `
+address: `+s.ln.Addr().String()+`
+interval: 1h
+registers:
+  - name: foo
+    address: 0
+`)
+
+	ctx := context.Background()
+	require.NoError(t, m.Connect(ctx))
+	_, _, err := m.Read(ctx)
+	require.NoError(t, err)
+
+	readCtx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer cancel()
+	_, _, err = m.Read(readCtx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	require.NoError(t, m.Close(ctx))
+}
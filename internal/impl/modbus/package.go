@@ -0,0 +1,3 @@
+// Package modbus contains components that poll devices such as PLCs over the
+// Modbus protocol.
+package modbus
@@ -77,7 +77,7 @@ func init() {
 				docs.FieldInt(
 					"max_in_flight",
 					"The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-				policy.FieldSpec(),
+				policy.OutputFieldSpec(),
 			).Merge(retries.FieldSpecs())...,
 		).ChildDefaultAndTypesFromStruct(output.NewMongoDBConfig()),
 	})
@@ -35,7 +35,7 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
-		return iprocessor.NewV2BatchedToV1Processor("", v2Proc, nm.Metrics()), nil
+		return iprocessor.NewV2BatchedToV1Processor("", v2Proc, nm), nil
 	}, docs.ComponentSpec{
 		Name:       processor.TypeMongoDB,
 		Type:       docs.TypeProcessor,
@@ -95,9 +95,10 @@ func init() {
 // Processor stores or retrieves data from a mongo db for each message of a
 // batch
 type Processor struct {
-	conf  processor.MongoDBConfig
-	log   log.Modular
-	stats metrics.Type
+	conf      processor.MongoDBConfig
+	log       log.Modular
+	stats     metrics.Type
+	errSource string
 
 	client                       *mongo.Client
 	collection                   *field.Expression
@@ -123,9 +124,10 @@ func NewProcessor(
 	}
 
 	m := &Processor{
-		conf:  conf.MongoDB,
-		log:   log,
-		stats: stats,
+		conf:      conf.MongoDB,
+		log:       log,
+		stats:     stats,
+		errSource: iprocessor.ErrSource(mgr),
 
 		operation: operation,
 
@@ -230,7 +232,7 @@ func (m *Processor) ProcessBatch(ctx context.Context, spans []*tracing.Span, bat
 	newBatch := batch.Copy()
 
 	writeModelsMap := map[*mongo.Collection][]mongo.WriteModel{}
-	processor.IteratePartsWithSpanV2("mongodb", nil, newBatch, func(i int, s *tracing.Span, p *message.Part) error {
+	processor.IteratePartsWithSpanV2("mongodb", m.errSource, nil, newBatch, func(i int, s *tracing.Span, p *message.Part) error {
 		var err error
 		var filterVal, documentVal *message.Part
 		var upsertVal, filterValWanted, documentValWanted bool
@@ -349,7 +351,7 @@ func (m *Processor) ProcessBatch(ctx context.Context, spans []*tracing.Span, bat
 			if _, err := collection.BulkWrite(context.Background(), writeModels); err != nil {
 				m.log.Errorf("Bulk write failed in mongodb processor: %v", err)
 				_ = newBatch.Iter(func(i int, p *message.Part) error {
-					iprocessor.MarkErr(p, spans[i], err)
+					iprocessor.MarkErr(p, spans[i], m.errSource, err)
 					return nil
 				})
 			}
@@ -0,0 +1,425 @@
+package opcua
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func opcuaInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Subscribes to value changes of nodes on an OPC UA server.").
+		Description(`
+Each value change reported by the server results in a JSON message of the following form:
+
+`+"```json"+`
+{
+  "node_id": "ns=2;s=Temperature",
+  "value": 21.5,
+  "type": "Double",
+  "status": "OK",
+  "source_timestamp": "2022-03-01T12:00:00.000Z",
+  "server_timestamp": "2022-03-01T12:00:00.010Z"
+}
+`+"```"+`
+
+Values are converted to their closest JSON representation, where integers and floats remain numbers, date times are formatted as RFC 3339 strings, and arrays of values are converted to arrays. The field `+"`type`"+` contains the OPC UA type of the value, such as `+"`Boolean`"+`, `+"`Int32`"+`, `+"`Double`"+`, `+"`String`"+` or `+"`DateTime`"+`, and the field `+"`status`"+` contains the status of the value, which is `+"`OK`"+` for good values and otherwise describes why the value is uncertain or bad.
+
+Value changes reported together by the server are consumed as a batch.
+
+### Security
+
+The endpoint of the server is selected by the `+"`security_policy`"+` and `+"`security_mode`"+` fields, and when either requires signing or encryption a certificate and private key must be provided with `+"`certificate_file`"+` and `+"`private_key_file`"+`. The certificate must be trusted by the server.
+
+### Delivery Guarantees
+
+OPC UA subscriptions do not support redelivery, and therefore value changes that occur whilst Benthos is disconnected from the server are not consumed. Messages that are rejected by the pipeline are retried until they're delivered.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"``` text"+`
+- opcua_node_id
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("endpoint").
+			Description("The endpoint URL of the OPC UA server.").
+			Example("opc.tcp://localhost:4840")).
+		Field(service.NewStringListField("nodes").
+			Description("A list of node IDs to subscribe to the values of.").
+			Example([]string{"ns=2;s=Temperature", "ns=3;i=1001"})).
+		Field(service.NewStringEnumField("security_policy", "None", "Basic128Rsa15", "Basic256", "Basic256Sha256", "Aes128_Sha256_RsaOaep", "Aes256_Sha256_RsaPss").
+			Description("The security policy of the endpoint to connect to.").
+			Default("None")).
+		Field(service.NewStringEnumField("security_mode", "None", "Sign", "SignAndEncrypt").
+			Description("The security mode of the endpoint to connect to.").
+			Default("None")).
+		Field(service.NewStringField("certificate_file").
+			Description("The path of a PEM or DER encoded client certificate, required when the security mode is not `None`.").
+			Default("")).
+		Field(service.NewStringField("private_key_file").
+			Description("The path of a PEM encoded private key of the client certificate, required when the security mode is not `None`.").
+			Default("")).
+		Field(service.NewStringField("username").
+			Description("A username to authenticate with, when empty the connection is anonymous.").
+			Default("").
+			Advanced()).
+		Field(service.NewStringField("password").
+			Description("A password to authenticate with.").
+			Default("").
+			Advanced()).
+		Field(service.NewDurationField("subscription_interval").
+			Description("The interval at which the server publishes value changes.").
+			Default("1s")).
+		Example("Sensors to MQTT", `
+Here we subscribe to the values of two sensors and publish each value change to an MQTT topic named after the node:`,
+			`
+input:
+  opcua:
+    endpoint: opc.tcp://localhost:4840
+    nodes: [ "ns=2;s=Temperature", "ns=2;s=Pressure" ]
+    subscription_interval: 500ms
+
+output:
+  mqtt:
+    urls: [ tcp://localhost:1883 ]
+    topic: sensors/${! meta("opcua_node_id") }
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"opcua", opcuaInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newOPCUAInputFromConfig(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type opcuaInput struct {
+	endpoint     string
+	nodes        []*ua.NodeID
+	policy       string
+	mode         string
+	certFile     string
+	keyFile      string
+	username     string
+	password     string
+	subInterval  time.Duration
+	log          *service.Logger
+	clientHandle map[uint32]*ua.NodeID
+
+	connMut  sync.Mutex
+	client   *opcua.Client
+	sub      *opcua.Subscription
+	notifyCh chan *opcua.PublishNotificationData
+}
+
+func newOPCUAInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*opcuaInput, error) {
+	o := &opcuaInput{
+		log:          log,
+		clientHandle: map[uint32]*ua.NodeID{},
+	}
+
+	var err error
+	if o.endpoint, err = conf.FieldString("endpoint"); err != nil {
+		return nil, err
+	}
+
+	nodes, err := conf.FieldStringList("nodes")
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("at least one node must be specified")
+	}
+	for i, n := range nodes {
+		id, err := ua.ParseNodeID(n)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node ID '%v': %w", n, err)
+		}
+		o.nodes = append(o.nodes, id)
+		o.clientHandle[uint32(i)] = id
+	}
+
+	if o.policy, err = conf.FieldString("security_policy"); err != nil {
+		return nil, err
+	}
+	if o.mode, err = conf.FieldString("security_mode"); err != nil {
+		return nil, err
+	}
+	if o.certFile, err = conf.FieldString("certificate_file"); err != nil {
+		return nil, err
+	}
+	if o.keyFile, err = conf.FieldString("private_key_file"); err != nil {
+		return nil, err
+	}
+	if o.mode != "None" && (o.certFile == "" || o.keyFile == "") {
+		return nil, fmt.Errorf("a certificate_file and private_key_file must be specified with the security mode %v", o.mode)
+	}
+	if o.username, err = conf.FieldString("username"); err != nil {
+		return nil, err
+	}
+	if o.password, err = conf.FieldString("password"); err != nil {
+		return nil, err
+	}
+	if o.subInterval, err = conf.FieldDuration("subscription_interval"); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *opcuaInput) clientOptions(ep *ua.EndpointDescription) []opcua.Option {
+	opts := []opcua.Option{
+		opcua.SecurityPolicy(o.policy),
+		opcua.SecurityModeString(o.mode),
+		opcua.AutoReconnect(false),
+	}
+	if o.certFile != "" {
+		opts = append(opts, opcua.CertificateFile(o.certFile), opcua.PrivateKeyFile(o.keyFile))
+	}
+	if o.username != "" {
+		opts = append(opts,
+			opcua.AuthUsername(o.username, o.password),
+			opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeUserName),
+		)
+	} else {
+		opts = append(opts,
+			opcua.AuthAnonymous(),
+			opcua.SecurityFromEndpoint(ep, ua.UserTokenTypeAnonymous),
+		)
+	}
+	return opts
+}
+
+func (o *opcuaInput) Connect(ctx context.Context) error {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.client != nil {
+		return nil
+	}
+
+	endpoints, err := opcua.GetEndpoints(ctx, o.endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to get endpoints: %w", err)
+	}
+	ep := opcua.SelectEndpoint(endpoints, o.policy, ua.MessageSecurityModeFromString(o.mode))
+	if ep == nil {
+		return fmt.Errorf("no endpoint found with security policy %v and mode %v", o.policy, o.mode)
+	}
+
+	client := opcua.NewClient(ep.EndpointURL, o.clientOptions(ep)...)
+	if err := client.Connect(ctx); err != nil {
+		return err
+	}
+
+	notifyCh := make(chan *opcua.PublishNotificationData)
+	sub, err := client.SubscribeWithContext(ctx, &opcua.SubscriptionParameters{
+		Interval: o.subInterval,
+	}, notifyCh)
+	if err != nil {
+		_ = client.CloseWithContext(ctx)
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	reqs := make([]*ua.MonitoredItemCreateRequest, len(o.nodes))
+	for i, id := range o.nodes {
+		reqs[i] = opcua.NewMonitoredItemCreateRequestWithDefaults(id, ua.AttributeIDValue, uint32(i))
+	}
+	res, err := sub.MonitorWithContext(ctx, ua.TimestampsToReturnBoth, reqs...)
+	if err == nil {
+		for i, r := range res.Results {
+			if r.StatusCode != ua.StatusOK {
+				err = fmt.Errorf("failed to monitor node '%v': %w", o.nodes[i], r.StatusCode)
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = sub.Cancel(ctx)
+		_ = client.CloseWithContext(ctx)
+		return err
+	}
+
+	o.client = client
+	o.sub = sub
+	o.notifyCh = notifyCh
+	o.log.Infof("Subscribed to nodes on OPC UA server %v: %v", o.endpoint, o.nodes)
+	return nil
+}
+
+func (o *opcuaInput) disconnect(ctx context.Context) {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.client == nil {
+		return
+	}
+	_ = o.sub.Cancel(ctx)
+	_ = o.client.CloseWithContext(ctx)
+	o.client = nil
+	o.sub = nil
+	o.notifyCh = nil
+}
+
+func (o *opcuaInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	o.connMut.Lock()
+	client, notifyCh := o.client, o.notifyCh
+	o.connMut.Unlock()
+
+	if client == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	for {
+		var res *opcua.PublishNotificationData
+		select {
+		case res = <-notifyCh:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+
+		if res.Error != nil {
+			if client.State() != opcua.Connected {
+				o.log.Errorf("Connection to OPC UA server lost: %v", res.Error)
+				o.disconnect(ctx)
+				return nil, nil, service.ErrNotConnected
+			}
+			o.log.Warnf("Subscription error: %v", res.Error)
+			continue
+		}
+
+		change, ok := res.Value.(*ua.DataChangeNotification)
+		if !ok {
+			continue
+		}
+
+		batch := make(service.MessageBatch, 0, len(change.MonitoredItems))
+		for _, item := range change.MonitoredItems {
+			id, exists := o.clientHandle[item.ClientHandle]
+			if !exists {
+				continue
+			}
+			batch = append(batch, newValueMessage(id, item.Value))
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		return batch, func(context.Context, error) error {
+			return nil
+		}, nil
+	}
+}
+
+func (o *opcuaInput) Close(ctx context.Context) error {
+	o.disconnect(ctx)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func newValueMessage(id *ua.NodeID, dv *ua.DataValue) *service.Message {
+	obj := map[string]interface{}{
+		"node_id": id.String(),
+		"value":   nil,
+		"type":    "Null",
+		"status":  statusName(dv.Status),
+	}
+	if dv.Value != nil {
+		obj["value"] = jsonValue(dv.Value.Value())
+		obj["type"] = strings.TrimPrefix(dv.Value.Type().String(), "TypeID")
+	}
+	if !dv.SourceTimestamp.IsZero() {
+		obj["source_timestamp"] = dv.SourceTimestamp.UTC().Format(time.RFC3339Nano)
+	}
+	if !dv.ServerTimestamp.IsZero() {
+		obj["server_timestamp"] = dv.ServerTimestamp.UTC().Format(time.RFC3339Nano)
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(obj)
+	msg.MetaSet("opcua_node_id", id.String())
+	return msg
+}
+
+// statusName returns the name of a status code, such as OK or
+// BadNodeIdUnknown.
+func statusName(c ua.StatusCode) string {
+	if d, exists := ua.StatusCodes[c]; exists {
+		return strings.TrimPrefix(d.Name, "Status")
+	}
+	return fmt.Sprintf("0x%X", uint32(c))
+}
+
+// jsonValue converts the value of a variant into its closest JSON compatible
+// representation.
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil, bool, string, []byte:
+		return t
+	case int8:
+		return int64(t)
+	case int16:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case int64:
+		return t
+	case uint8:
+		return uint64(t)
+	case uint16:
+		return uint64(t)
+	case uint32:
+		return uint64(t)
+	case uint64:
+		return t
+	case float32:
+		return float64(t)
+	case float64:
+		return t
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case ua.StatusCode:
+		return statusName(t)
+	case *ua.LocalizedText:
+		return t.Text
+	case *ua.QualifiedName:
+		return t.Name
+	case fmt.Stringer:
+		return t.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		arr := make([]interface{}, rv.Len())
+		for i := range arr {
+			arr[i] = jsonValue(rv.Index(i).Interface())
+		}
+		return arr
+	}
+	return fmt.Sprintf("%v", v)
+}
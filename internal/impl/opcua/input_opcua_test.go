@@ -0,0 +1,116 @@
+package opcua
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOPCUAInputConfig(t *testing.T) {
+	conf, err := opcuaInputConfig().ParseYAML(`
+endpoint: opc.tcp://localhost:4840
+nodes: [ "ns=2;s=Temperature", "i=2258" ]
+`, nil)
+	require.NoError(t, err)
+
+	i, err := newOPCUAInputFromConfig(conf, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ns=2;s=Temperature", i.clientHandle[0].String())
+	assert.Equal(t, "i=2258", i.clientHandle[1].String())
+	assert.Equal(t, time.Second, i.subInterval)
+
+	for _, test := range []struct {
+		conf string
+		err  string
+	}{
+		{
+			conf: `
+endpoint: opc.tcp://localhost:4840
+nodes: []
+`,
+			err: "at least one node must be specified",
+		},
+		{
+			conf: `
+endpoint: opc.tcp://localhost:4840
+nodes: [ "ns=foo;i=1" ]
+`,
+			err: "failed to parse node ID 'ns=foo;i=1'",
+		},
+		{
+			conf: `
+endpoint: opc.tcp://localhost:4840
+nodes: [ "i=2258" ]
+security_policy: Basic256Sha256
+security_mode: SignAndEncrypt
+`,
+			err: "a certificate_file and private_key_file must be specified with the security mode SignAndEncrypt",
+		},
+	} {
+		conf, err := opcuaInputConfig().ParseYAML(test.conf, nil)
+		require.NoError(t, err)
+
+		_, err = newOPCUAInputFromConfig(conf, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+}
+
+func TestOPCUAValueMessage(t *testing.T) {
+	id, err := ua.ParseNodeID("ns=2;s=Temperature")
+	require.NoError(t, err)
+
+	ts := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	msg := newValueMessage(id, &ua.DataValue{
+		Value:           ua.MustVariant(float32(21.5)),
+		Status:          ua.StatusOK,
+		SourceTimestamp: ts,
+	})
+
+	v, err := msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"node_id":          "ns=2;s=Temperature",
+		"value":            float64(21.5),
+		"type":             "Float",
+		"status":           "OK",
+		"source_timestamp": "2022-03-01T12:00:00Z",
+	}, v)
+
+	nodeID, _ := msg.MetaGet("opcua_node_id")
+	assert.Equal(t, "ns=2;s=Temperature", nodeID)
+
+	msg = newValueMessage(id, &ua.DataValue{
+		Status: ua.StatusBadNodeIDUnknown,
+	})
+	v, err = msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"node_id": "ns=2;s=Temperature",
+		"value":   nil,
+		"type":    "Null",
+		"status":  "BadNodeIDUnknown",
+	}, v)
+}
+
+func TestOPCUAJSONValue(t *testing.T) {
+	ts := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		in  interface{}
+		out interface{}
+	}{
+		{in: true, out: true},
+		{in: int16(-5), out: int64(-5)},
+		{in: uint32(5), out: uint64(5)},
+		{in: "foo", out: "foo"},
+		{in: ts, out: "2022-03-01T12:00:00Z"},
+		{in: []int32{1, 2}, out: []interface{}{int64(1), int64(2)}},
+		{in: &ua.LocalizedText{Text: "hello"}, out: "hello"},
+		{in: ua.NewNumericNodeID(0, 2258), out: "i=2258"},
+	} {
+		assert.Equal(t, test.out, jsonValue(test.in), "%#v", test.in)
+	}
+}
@@ -0,0 +1,3 @@
+// Package opcua contains components that interact with OPC UA servers, which
+// are commonly used for exposing industrial equipment and sensor data.
+package opcua
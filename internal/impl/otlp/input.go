@@ -0,0 +1,681 @@
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	// Allows clients to export with gzip compression over gRPC.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+func otlpInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Receives OpenTelemetry log records and spans by implementing the gRPC and HTTP endpoints of an [OTLP](https://opentelemetry.io/docs/reference/specification/protocol/) receiver.").
+		Description(`
+Each log record or span of an export request is consumed as a JSON message of a batch, and the response to the request is only sent once the batch has been delivered, with a failed delivery resulting in an error that instructs the exporter to retry. Only requests with protobuf encoding are supported over HTTP, with or without gzip compression. Metrics are not currently supported.
+
+Log records are consumed as objects of the form:
+
+`+"```json"+`
+{
+  "timestamp": "2022-04-01T12:00:00Z",
+  "observed_timestamp": "2022-04-01T12:00:00.1Z",
+  "severity_number": 9,
+  "severity_text": "INFO",
+  "body": "user logged in",
+  "attributes": { "user.id": "foo" },
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174"
+}
+`+"```"+`
+
+And spans are consumed as objects of the form:
+
+`+"```json"+`
+{
+  "name": "GET /users",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "parent_span_id": "eee19b7ec3c1b173",
+  "kind": "SERVER",
+  "start_time": "2022-04-01T12:00:00Z",
+  "end_time": "2022-04-01T12:00:01Z",
+  "status": { "code": "ERROR", "message": "oh no" },
+  "attributes": { "http.status_code": 500 },
+  "events": [ { "name": "retry", "time": "2022-04-01T12:00:00.5Z", "attributes": {} } ]
+}
+`+"```"+`
+
+Fields without a value are omitted, which matches the records expected by the `+"[`otlp` output](/docs/components/outputs/otlp)"+`.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- otlp_signal
+- otlp_scope_name
+- otlp_scope_version
+- All resource attributes
+`+"```"+`
+
+Where `+"`otlp_signal`"+` is either `+"`logs` or `traces`"+`, and resource attributes that aren't strings are encoded as JSON. You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField("grpc_address").
+			Description("The address to listen for gRPC export requests on. Set to an empty string in order to disable the gRPC receiver.").
+			Default("0.0.0.0:4317")).
+		Field(service.NewStringField("http_address").
+			Description("The address to listen for HTTP export requests on. Set to an empty string in order to disable the HTTP receiver.").
+			Default("0.0.0.0:4318")).
+		Field(service.NewStringListField("signals").
+			Description("The signals to receive, requests of any other signal are rejected as unimplemented. Supported signals are `logs` and `traces`.").
+			Default([]interface{}{"logs"}).
+			Example([]string{"logs", "traces"})).
+		Field(service.NewStringField("cert_file").
+			Description("An optional certificate file for enabling TLS on both receivers.").
+			Advanced().
+			Default("")).
+		Field(service.NewStringField("key_file").
+			Description("An optional key file for enabling TLS on both receivers.").
+			Advanced().
+			Default("")).
+		Example("Log Gateway", `
+Here we receive logs from OpenTelemetry SDKs and collectors, drop debug logs and write the rest to Elasticsearch with an index per service:`,
+			`
+input:
+  otlp:
+    signals: [ logs ]
+
+pipeline:
+  processors:
+    - bloblang: |
+        root = if this.severity_number < 9 { deleted() }
+
+output:
+  elasticsearch:
+    urls: [ http://localhost:9200 ]
+    index: 'logs-${! meta("service.name") }'
+    id: ${! uuid_v4() }
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput("otlp", otlpInputConfig(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+		return newOTLPInputFromConfig(conf, mgr.Logger())
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type otlpRequest struct {
+	batch   service.MessageBatch
+	resChan chan error
+}
+
+type otlpInput struct {
+	log *service.Logger
+
+	grpcAddress string
+	httpAddress string
+	signals     map[string]bool
+	certFile    string
+	keyFile     string
+
+	reqChan chan otlpRequest
+
+	srvMut   sync.Mutex
+	grpcSrv  *grpc.Server
+	grpcLn   net.Listener
+	httpSrv  *http.Server
+	httpLn   net.Listener
+	shutChan chan struct{}
+}
+
+func newOTLPInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*otlpInput, error) {
+	i := &otlpInput{
+		log:      log,
+		signals:  map[string]bool{},
+		reqChan:  make(chan otlpRequest),
+		shutChan: make(chan struct{}),
+	}
+
+	var err error
+	if i.grpcAddress, err = conf.FieldString("grpc_address"); err != nil {
+		return nil, err
+	}
+	if i.httpAddress, err = conf.FieldString("http_address"); err != nil {
+		return nil, err
+	}
+	if i.grpcAddress == "" && i.httpAddress == "" {
+		return nil, errors.New("at least one of grpc_address and http_address must be set")
+	}
+
+	signals, err := conf.FieldStringList("signals")
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range signals {
+		if s != "logs" && s != "traces" {
+			return nil, fmt.Errorf("unsupported signal: %v", s)
+		}
+		i.signals[s] = true
+	}
+
+	if i.certFile, err = conf.FieldString("cert_file"); err != nil {
+		return nil, err
+	}
+	if i.keyFile, err = conf.FieldString("key_file"); err != nil {
+		return nil, err
+	}
+	if (i.certFile == "") != (i.keyFile == "") {
+		return nil, errors.New("both cert_file and key_file must be set in order to enable TLS")
+	}
+	return i, nil
+}
+
+func (i *otlpInput) Connect(ctx context.Context) error {
+	i.srvMut.Lock()
+	defer i.srvMut.Unlock()
+
+	if i.grpcLn != nil || i.httpLn != nil {
+		return nil
+	}
+
+	var grpcLn, httpLn net.Listener
+	var err error
+	if i.grpcAddress != "" {
+		if grpcLn, err = net.Listen("tcp", i.grpcAddress); err != nil {
+			return err
+		}
+	}
+	if i.httpAddress != "" {
+		if httpLn, err = net.Listen("tcp", i.httpAddress); err != nil {
+			if grpcLn != nil {
+				grpcLn.Close()
+			}
+			return err
+		}
+	}
+
+	if grpcLn != nil {
+		opts := []grpc.ServerOption{
+			grpc.UnknownServiceHandler(i.handleGRPC),
+			grpc.ForceServerCodec(rawCodec{}),
+		}
+		if i.certFile != "" {
+			creds, err := credentials.NewServerTLSFromFile(i.certFile, i.keyFile)
+			if err != nil {
+				grpcLn.Close()
+				if httpLn != nil {
+					httpLn.Close()
+				}
+				return err
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		i.grpcSrv = grpc.NewServer(opts...)
+		i.grpcLn = grpcLn
+
+		go func() {
+			if err := i.grpcSrv.Serve(grpcLn); err != nil {
+				i.log.Errorf("OTLP gRPC receiver error: %v", err)
+			}
+		}()
+		i.log.Infof("Receiving OTLP gRPC export requests at: %v", grpcLn.Addr())
+	}
+
+	if httpLn != nil {
+		mux := http.NewServeMux()
+		mux.HandleFunc(httpLogsPath, i.httpHandler("logs"))
+		mux.HandleFunc(httpTracesPath, i.httpHandler("traces"))
+		i.httpSrv = &http.Server{Handler: mux}
+		i.httpLn = httpLn
+
+		go func() {
+			var err error
+			if i.certFile != "" {
+				err = i.httpSrv.ServeTLS(httpLn, i.certFile, i.keyFile)
+			} else {
+				err = i.httpSrv.Serve(httpLn)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				i.log.Errorf("OTLP HTTP receiver error: %v", err)
+			}
+		}()
+		i.log.Infof("Receiving OTLP HTTP export requests at: %v", httpLn.Addr())
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var errUnimplemented = errors.New("signal not implemented")
+
+// deliver decodes an export request and blocks until the resulting batch has
+// either been delivered or rejected.
+func (i *otlpInput) deliver(ctx context.Context, signal string, body []byte) error {
+	if !i.signals[signal] {
+		return errUnimplemented
+	}
+
+	var batch service.MessageBatch
+	var err error
+	if signal == "traces" {
+		batch, err = decodeTracesRequest(body)
+	} else {
+		batch, err = decodeLogsRequest(body)
+	}
+	if err != nil {
+		return err
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	req := otlpRequest{
+		batch:   batch,
+		resChan: make(chan error, 1),
+	}
+	select {
+	case i.reqChan <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-i.shutChan:
+		return service.ErrNotConnected
+	}
+
+	select {
+	case err := <-req.resChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-i.shutChan:
+		return service.ErrNotConnected
+	}
+}
+
+var grpcSignals = map[string]string{
+	grpcLogsMethod:   "logs",
+	grpcTracesMethod: "traces",
+}
+
+func (i *otlpInput) handleGRPC(_ interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	signal, exists := grpcSignals[method]
+	if !exists {
+		return status.Errorf(codes.Unimplemented, "unknown method: %v", method)
+	}
+
+	var req []byte
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+
+	if err := i.deliver(stream.Context(), signal, req); err != nil {
+		if errors.Is(err, errUnimplemented) {
+			return status.Error(codes.Unimplemented, err.Error())
+		}
+		var pErr *protoDecodeError
+		if errors.As(err, &pErr) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return stream.SendMsg([]byte{})
+}
+
+func (i *otlpInput) httpHandler(signal string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/x-protobuf") {
+			http.Error(w, "Only protobuf encoded requests are supported", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var bodyReader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, "Bad request", http.StatusBadRequest)
+				return
+			}
+			defer gr.Close()
+			bodyReader = gr
+		}
+
+		body, err := io.ReadAll(bodyReader)
+		if err != nil {
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		if err := i.deliver(r.Context(), signal, body); err != nil {
+			var pErr *protoDecodeError
+			switch {
+			case errors.Is(err, errUnimplemented):
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case errors.As(err, &pErr):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (i *otlpInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	select {
+	case req := <-i.reqChan:
+		return req.batch, func(ctx context.Context, err error) error {
+			req.resChan <- err
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-i.shutChan:
+		return nil, nil, service.ErrEndOfInput
+	}
+}
+
+func (i *otlpInput) Close(ctx context.Context) error {
+	i.srvMut.Lock()
+	defer i.srvMut.Unlock()
+
+	select {
+	case <-i.shutChan:
+		return nil
+	default:
+		close(i.shutChan)
+	}
+
+	if i.grpcSrv != nil {
+		i.grpcSrv.Stop()
+	}
+	if i.httpSrv != nil {
+		return i.httpSrv.Shutdown(ctx)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type protoDecodeError struct {
+	err error
+}
+
+func (e *protoDecodeError) Error() string {
+	return fmt.Sprintf("failed to decode request: %v", e.err)
+}
+
+func (e *protoDecodeError) Unwrap() error {
+	return e.err
+}
+
+func formatTime(n uint64) string {
+	return time.Unix(0, int64(n)).UTC().Format(time.RFC3339Nano)
+}
+
+func enumName(names map[string]uint64, n uint64) interface{} {
+	for k, v := range names {
+		if v == n {
+			return k
+		}
+	}
+	return int64(n)
+}
+
+// decodeResource decodes a Resource message into metadata.
+func decodeResource(b []byte, meta map[string]string) error {
+	attrs := map[string]interface{}{}
+	if err := decodeAttributes(attrs, b, 1); err != nil {
+		return err
+	}
+	for k, v := range attrs {
+		meta[k] = query.IToString(v)
+	}
+	return nil
+}
+
+// decodeScope decodes an InstrumentationScope message into metadata.
+func decodeScope(b []byte, meta map[string]string) error {
+	return rangeFields(b, func(num protowire.Number, v []byte, _ uint64) error {
+		switch num {
+		case 1:
+			meta["otlp_scope_name"] = string(v)
+		case 2:
+			meta["otlp_scope_version"] = string(v)
+		}
+		return nil
+	})
+}
+
+// decodeRequest walks the resources and scopes of an export request, calling
+// fn with the records of each scope along with their metadata.
+func decodeRequest(b []byte, signal string, fn func(record []byte, meta map[string]string) error) error {
+	err := rangeFields(b, func(num protowire.Number, resourceRecords []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+
+		meta := map[string]string{"otlp_signal": signal}
+		var scopeRecords [][]byte
+		if err := rangeFields(resourceRecords, func(num protowire.Number, v []byte, _ uint64) error {
+			switch num {
+			case 1:
+				return decodeResource(v, meta)
+			case 2:
+				scopeRecords = append(scopeRecords, v)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, sr := range scopeRecords {
+			scopeMeta := make(map[string]string, len(meta)+2)
+			for k, v := range meta {
+				scopeMeta[k] = v
+			}
+			var records [][]byte
+			if err := rangeFields(sr, func(num protowire.Number, v []byte, _ uint64) error {
+				switch num {
+				case 1:
+					return decodeScope(v, scopeMeta)
+				case 2:
+					records = append(records, v)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+			for _, r := range records {
+				if err := fn(r, scopeMeta); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &protoDecodeError{err: err}
+	}
+	return nil
+}
+
+func newRecordMessage(obj map[string]interface{}, meta map[string]string) *service.Message {
+	msg := service.NewMessage(nil)
+	msg.SetStructured(obj)
+	for k, v := range meta {
+		msg.MetaSet(k, v)
+	}
+	return msg
+}
+
+// decodeLogRecord decodes a LogRecord message into an object.
+func decodeLogRecord(b []byte) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	err := rangeFields(b, func(num protowire.Number, v []byte, n uint64) (err error) {
+		switch num {
+		case 1:
+			obj["timestamp"] = formatTime(n)
+		case 2:
+			obj["severity_number"] = int64(n)
+		case 3:
+			obj["severity_text"] = string(v)
+		case 5:
+			obj["body"], err = decodeAnyValue(v)
+		case 8:
+			obj["flags"] = int64(n)
+		case 9:
+			obj["trace_id"] = hex.EncodeToString(v)
+		case 10:
+			obj["span_id"] = hex.EncodeToString(v)
+		case 11:
+			obj["observed_timestamp"] = formatTime(n)
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]interface{}{}
+	if err := decodeAttributes(attrs, b, 6); err != nil {
+		return nil, err
+	}
+	obj["attributes"] = attrs
+	return obj, nil
+}
+
+func decodeLogsRequest(b []byte) (batch service.MessageBatch, err error) {
+	err = decodeRequest(b, "logs", func(record []byte, meta map[string]string) error {
+		obj, err := decodeLogRecord(record)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, newRecordMessage(obj, meta))
+		return nil
+	})
+	return
+}
+
+// decodeSpanEvent decodes a Span.Event message into an object.
+func decodeSpanEvent(b []byte) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	if err := rangeFields(b, func(num protowire.Number, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			obj["time"] = formatTime(n)
+		case 2:
+			obj["name"] = string(v)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]interface{}{}
+	if err := decodeAttributes(attrs, b, 3); err != nil {
+		return nil, err
+	}
+	obj["attributes"] = attrs
+	return obj, nil
+}
+
+// decodeSpan decodes a Span message into an object.
+func decodeSpan(b []byte) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	var events []interface{}
+	err := rangeFields(b, func(num protowire.Number, v []byte, n uint64) (err error) {
+		switch num {
+		case 1:
+			obj["trace_id"] = hex.EncodeToString(v)
+		case 2:
+			obj["span_id"] = hex.EncodeToString(v)
+		case 3:
+			obj["trace_state"] = string(v)
+		case 4:
+			obj["parent_span_id"] = hex.EncodeToString(v)
+		case 5:
+			obj["name"] = string(v)
+		case 6:
+			obj["kind"] = enumName(spanKinds, n)
+		case 7:
+			obj["start_time"] = formatTime(n)
+		case 8:
+			obj["end_time"] = formatTime(n)
+		case 11:
+			var event map[string]interface{}
+			if event, err = decodeSpanEvent(v); err == nil {
+				events = append(events, event)
+			}
+		case 15:
+			statusObj := map[string]interface{}{"code": "UNSET"}
+			err = rangeFields(v, func(num protowire.Number, v []byte, n uint64) error {
+				switch num {
+				case 2:
+					statusObj["message"] = string(v)
+				case 3:
+					statusObj["code"] = enumName(statusCodes, n)
+				}
+				return nil
+			})
+			obj["status"] = statusObj
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string]interface{}{}
+	if err := decodeAttributes(attrs, b, 9); err != nil {
+		return nil, err
+	}
+	obj["attributes"] = attrs
+	if len(events) > 0 {
+		obj["events"] = events
+	}
+	return obj, nil
+}
+
+func decodeTracesRequest(b []byte) (batch service.MessageBatch, err error) {
+	err = decodeRequest(b, "traces", func(record []byte, meta map[string]string) error {
+		obj, err := decodeSpan(record)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, newRecordMessage(obj, meta))
+		return nil
+	})
+	return
+}
@@ -0,0 +1,271 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testOTLPInput(t *testing.T, confStr string) *otlpInput {
+	t.Helper()
+
+	conf, err := otlpInputConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	i, err := newOTLPInputFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, i.Connect(context.Background()))
+	t.Cleanup(func() {
+		require.NoError(t, i.Close(context.Background()))
+	})
+	return i
+}
+
+type readResult struct {
+	batch service.MessageBatch
+	ack   service.AckFunc
+}
+
+func readAsync(t *testing.T, i *otlpInput) <-chan readResult {
+	t.Helper()
+
+	resChan := make(chan readResult, 1)
+	go func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+		defer done()
+
+		batch, ack, err := i.ReadBatch(ctx)
+		assert.NoError(t, err)
+		resChan <- readResult{batch: batch, ack: ack}
+	}()
+	return resChan
+}
+
+func structuredBatch(t *testing.T, batch service.MessageBatch) []interface{} {
+	t.Helper()
+
+	var objs []interface{}
+	for _, m := range batch {
+		v, err := m.AsStructured()
+		require.NoError(t, err)
+		objs = append(objs, v)
+	}
+	return objs
+}
+
+func TestOTLPInputGRPCLogs(t *testing.T) {
+	i := testOTLPInput(t, `
+grpc_address: 127.0.0.1:0
+http_address: ""
+`)
+
+	o := testOTLPOutput(t, `
+endpoint: `+i.grpcLn.Addr().String()+`
+mapping: |
+  root.body = this.msg
+  root.severity_text = "warn"
+  root.timestamp = 10
+  root.trace_id = "5b8efff798038103d269b633813fc60c"
+  root.attributes.count = 3
+resource_attributes: |
+  root."service.name" = "foo"
+  root.replicas = 2
+`)
+
+	readChan := readAsync(t, i)
+	writeErrChan := make(chan error, 1)
+	go func() {
+		writeErrChan <- o.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`{"msg":"hello"}`)),
+			service.NewMessage([]byte(`{"msg":{"structured":true}}`)),
+		})
+	}()
+
+	res := <-readChan
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"timestamp":          "1970-01-01T00:00:10Z",
+			"observed_timestamp": "1970-01-01T00:01:40Z",
+			"severity_number":    int64(13),
+			"severity_text":      "warn",
+			"body":               "hello",
+			"attributes":         map[string]interface{}{"count": int64(3)},
+			"trace_id":           "5b8efff798038103d269b633813fc60c",
+		},
+		map[string]interface{}{
+			"timestamp":          "1970-01-01T00:00:10Z",
+			"observed_timestamp": "1970-01-01T00:01:40Z",
+			"severity_number":    int64(13),
+			"severity_text":      "warn",
+			"body":               map[string]interface{}{"structured": true},
+			"attributes":         map[string]interface{}{"count": int64(3)},
+			"trace_id":           "5b8efff798038103d269b633813fc60c",
+		},
+	}, structuredBatch(t, res.batch))
+
+	for _, m := range res.batch {
+		for k, exp := range map[string]string{
+			"otlp_signal":     "logs",
+			"otlp_scope_name": "benthos",
+			"service.name":    "foo",
+			"replicas":        "2",
+		} {
+			v, _ := m.MetaGet(k)
+			assert.Equal(t, exp, v, k)
+		}
+	}
+
+	select {
+	case <-writeErrChan:
+		t.Fatal("write returned before the batch was acknowledged")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	require.NoError(t, res.ack(context.Background(), nil))
+	require.NoError(t, <-writeErrChan)
+
+	// Rejected batches result in an error for the exporter.
+	readChan = readAsync(t, i)
+	go func() {
+		writeErrChan <- o.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`{"msg":"hello"}`)),
+		})
+	}()
+	res = <-readChan
+	require.NoError(t, res.ack(context.Background(), errors.New("nope")))
+	err := <-writeErrChan
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unavailable")
+}
+
+func TestOTLPInputHTTPTraces(t *testing.T) {
+	i := testOTLPInput(t, `
+grpc_address: ""
+http_address: 127.0.0.1:0
+signals: [ traces ]
+`)
+
+	o := testOTLPOutput(t, `
+protocol: http
+endpoint: http://`+i.httpLn.Addr().String()+`
+signal: traces
+`)
+
+	readChan := readAsync(t, i)
+	writeErrChan := make(chan error, 1)
+	go func() {
+		writeErrChan <- o.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`{
+  "name": "GET /users",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "parent_span_id": "eee19b7ec3c1b173",
+  "kind": "server",
+  "start_time": "1970-01-01T00:00:01Z",
+  "end_time": 2,
+  "status": { "code": "ERROR", "message": "oh no" },
+  "attributes": { "http.status_code": 500, "http.path": [ "users" ] }
+}`)),
+		})
+	}()
+
+	res := <-readChan
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":           "GET /users",
+			"trace_id":       "5b8efff798038103d269b633813fc60c",
+			"span_id":        "eee19b7ec3c1b174",
+			"parent_span_id": "eee19b7ec3c1b173",
+			"kind":           "SERVER",
+			"start_time":     "1970-01-01T00:00:01Z",
+			"end_time":       "1970-01-01T00:00:02Z",
+			"status":         map[string]interface{}{"code": "ERROR", "message": "oh no"},
+			"attributes": map[string]interface{}{
+				"http.status_code": int64(500),
+				"http.path":        []interface{}{"users"},
+			},
+		},
+	}, structuredBatch(t, res.batch))
+
+	signal, _ := res.batch[0].MetaGet("otlp_signal")
+	assert.Equal(t, "traces", signal)
+
+	require.NoError(t, res.ack(context.Background(), nil))
+	require.NoError(t, <-writeErrChan)
+
+	// Logs aren't enabled and must be rejected.
+	res2, err := http.Post("http://"+i.httpLn.Addr().String()+"/v1/logs", "application/x-protobuf", nil)
+	require.NoError(t, err)
+	res2.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res2.StatusCode)
+}
+
+func TestOTLPInputHTTPGzip(t *testing.T) {
+	i := testOTLPInput(t, `
+grpc_address: ""
+http_address: 127.0.0.1:0
+`)
+
+	o := testOTLPOutput(t, `
+protocol: http
+endpoint: http://localhost:1
+`)
+	body, err := o.encodeRequest(service.MessageBatch{
+		service.NewMessage([]byte(`hello world`)),
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	req, err := http.NewRequest("POST", "http://"+i.httpLn.Addr().String()+"/v1/logs", &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	readChan := readAsync(t, i)
+	resChan := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resChan <- res
+	}()
+
+	readRes := <-readChan
+	require.Len(t, readRes.batch, 1)
+
+	obj, err := readRes.batch[0].AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", obj.(map[string]interface{})["body"])
+
+	require.NoError(t, readRes.ack(context.Background(), nil))
+	res := <-resChan
+	require.NotNil(t, res)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Invalid protobuf is rejected.
+	res, err = http.Post("http://"+i.httpLn.Addr().String()+"/v1/logs", "application/x-protobuf", bytes.NewReader([]byte{0xff, 0xff}))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// JSON encoding isn't supported.
+	res, err = http.Post("http://"+i.httpLn.Addr().String()+"/v1/logs", "application/json", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+}
@@ -0,0 +1,670 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func otlpOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Exports messages as OpenTelemetry log records or spans to an [OTLP](https://opentelemetry.io/docs/reference/specification/protocol/) endpoint, such as an OpenTelemetry collector.").
+		Description(`
+Each message is converted into a record of the configured `+"`signal`"+`, and the records of a batch are exported as a single request over either gRPC or HTTP with protobuf encoding. When exporting over HTTP the path of the signal (`+"`/v1/logs` or `/v1/traces`"+`) is appended to the endpoint.
+
+### Logs
+
+When exporting logs each message must either be or be mapped with `+"`mapping`"+` into an object of the form:
+
+`+"```json"+`
+{
+  "body": "user logged in",
+  "severity_text": "INFO",
+  "timestamp": "2022-04-01T12:00:00Z",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "attributes": { "user.id": "foo" }
+}
+`+"```"+`
+
+Where all fields are optional. The body can be any structured value, the `+"`severity_number`"+` is derived from the `+"`severity_text`"+` when not specified, and the timestamp is either an RFC 3339 string or a number of seconds since the unix epoch. When `+"`mapping`"+` is not set the raw contents of each message are used as the body and the metadata of each message is added as attributes.
+
+### Traces
+
+When exporting traces each message must either be or be mapped with `+"`mapping`"+` into an object of the form:
+
+`+"```json"+`
+{
+  "name": "GET /users",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "parent_span_id": "eee19b7ec3c1b173",
+  "kind": "SERVER",
+  "start_time": "2022-04-01T12:00:00Z",
+  "end_time": "2022-04-01T12:00:01Z",
+  "status": { "code": "ERROR", "message": "oh no" },
+  "attributes": { "http.status_code": 500 }
+}
+`+"```"+`
+
+Where the fields `+"`name`, `trace_id` and `span_id`"+` are required.
+
+### Resources
+
+The attributes of the resource a record belongs to are determined by the `+"`resource_attributes`"+` mapping, which is executed for each message. Records of a batch with the same resource attributes are grouped under the same resource.`).
+		Field(service.NewStringEnumField("protocol", "grpc", "http").
+			Description("The protocol to export with.").
+			Default("grpc")).
+		Field(service.NewStringField("endpoint").
+			Description("The endpoint to export to, which is a host and port when exporting over gRPC and a URL when exporting over HTTP.").
+			Example("localhost:4317").
+			Example("http://localhost:4318")).
+		Field(service.NewStringEnumField("signal", "logs", "traces").
+			Description("The type of record to export messages as.").
+			Default("logs")).
+		Field(service.NewBloblangField("mapping").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) that converts each message into a record object.").
+			Example(`root.body = this.message
+root.severity_text = this.level.uppercase()
+root.attributes.user_id = this.user.id`).
+			Optional()).
+		Field(service.NewBloblangField("resource_attributes").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of the attributes of the resource of each record.").
+			Default(`root = {"service.name": "benthos"}`).
+			Example(`root."service.name" = meta("service")
+root."deployment.environment" = "production"`)).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to requests, which are sent as metadata when exporting over gRPC.").
+			Default(map[string]interface{}{})).
+		Field(service.NewDurationField("timeout").
+			Description("A timeout for each export request.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Log Shipping", `
+Here we ship the lines of a log file to an OpenTelemetry collector as log records of the resource of the service that wrote them:`,
+			`
+input:
+  file:
+    paths: [ /var/log/checkout/*.log ]
+    codec: lines
+
+output:
+  otlp:
+    endpoint: otel-collector:4317
+    signal: logs
+    resource_attributes: |
+      root."service.name" = "checkout"
+      root."host.name" = hostname()
+    batching:
+      count: 100
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("otlp", otlpOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newOTLPOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var severityNumbers = map[string]uint64{
+	"TRACE": 1,
+	"DEBUG": 5,
+	"INFO":  9,
+	"WARN":  13,
+	"ERROR": 17,
+	"FATAL": 21,
+}
+
+var spanKinds = map[string]uint64{
+	"INTERNAL": 1,
+	"SERVER":   2,
+	"CLIENT":   3,
+	"PRODUCER": 4,
+	"CONSUMER": 5,
+}
+
+var statusCodes = map[string]uint64{
+	"UNSET": 0,
+	"OK":    1,
+	"ERROR": 2,
+}
+
+// rawCodec is a gRPC codec for requests and responses that have already been
+// encoded.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return v.([]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*[]byte)) = data
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+type otlpOutput struct {
+	log *service.Logger
+
+	protocol   string
+	endpoint   string
+	signal     string
+	mapping    *bloblang.Executor
+	resource   *bloblang.Executor
+	headers    map[string]string
+	timeout    time.Duration
+	tlsConf    *tls.Config
+	tlsEnabled bool
+
+	nowFn func() time.Time
+
+	connMut    sync.RWMutex
+	grpcConn   *grpc.ClientConn
+	httpClient *http.Client
+}
+
+func newOTLPOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*otlpOutput, error) {
+	o := &otlpOutput{
+		log:   log,
+		nowFn: time.Now,
+	}
+
+	var err error
+	if o.protocol, err = conf.FieldString("protocol"); err != nil {
+		return nil, err
+	}
+	if o.endpoint, err = conf.FieldString("endpoint"); err != nil {
+		return nil, err
+	}
+	if o.signal, err = conf.FieldString("signal"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("mapping") {
+		if o.mapping, err = conf.FieldBloblang("mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if o.resource, err = conf.FieldBloblang("resource_attributes"); err != nil {
+		return nil, err
+	}
+	if o.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+	if o.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	if o.tlsConf, o.tlsEnabled, err = conf.FieldTLSToggled("tls"); err != nil {
+		return nil, err
+	}
+	if o.protocol == "http" {
+		o.endpoint = strings.TrimSuffix(o.endpoint, "/")
+	}
+	return o, nil
+}
+
+func (o *otlpOutput) Connect(ctx context.Context) error {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.grpcConn != nil || o.httpClient != nil {
+		return nil
+	}
+
+	if o.protocol == "http" {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if o.tlsEnabled {
+			transport.TLSClientConfig = o.tlsConf
+		}
+		o.httpClient = &http.Client{
+			Timeout:   o.timeout,
+			Transport: transport,
+		}
+		return nil
+	}
+
+	creds := insecure.NewCredentials()
+	if o.tlsEnabled {
+		creds = credentials.NewTLS(o.tlsConf)
+	}
+	conn, err := grpc.DialContext(ctx, o.endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	o.grpcConn = conn
+	o.log.Infof("Exporting OTLP %v to gRPC endpoint: %v", o.signal, o.endpoint)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// toObject returns the structured form of a message, or the result of the
+// record mapping when configured.
+func (o *otlpOutput) toObject(batch service.MessageBatch, i int) (map[string]interface{}, error) {
+	msg := batch[i]
+	if o.mapping != nil {
+		var err error
+		if msg, err = batch.BloblangQuery(i, o.mapping); err != nil {
+			return nil, fmt.Errorf("mapping failed: %w", err)
+		}
+		if msg == nil {
+			return nil, errors.New("mapping deleted the record")
+		}
+	} else if o.signal == "logs" {
+		body, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		attrs := map[string]interface{}{}
+		_ = msg.MetaWalk(func(k, v string) error {
+			attrs[k] = v
+			return nil
+		})
+		return map[string]interface{}{
+			"body":       string(body),
+			"attributes": attrs,
+		}, nil
+	}
+
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a record object, got %T", v)
+	}
+	return obj, nil
+}
+
+func (o *otlpOutput) resourceAttributes(batch service.MessageBatch, i int) (map[string]interface{}, error) {
+	msg, err := batch.BloblangQuery(i, o.resource)
+	if err != nil {
+		return nil, fmt.Errorf("resource attributes mapping failed: %w", err)
+	}
+	if msg == nil {
+		return nil, nil
+	}
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("resource attributes mapping: %w", err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected resource attributes mapping to result in an object, got %T", v)
+	}
+	return obj, nil
+}
+
+func getAttributes(obj map[string]interface{}) (map[string]interface{}, error) {
+	v, exists := obj["attributes"]
+	if !exists || v == nil {
+		return nil, nil
+	}
+	attrs, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected attributes to be an object, got %T", v)
+	}
+	return attrs, nil
+}
+
+func getTimestamp(obj map[string]interface{}, key string) (uint64, error) {
+	v, exists := obj[key]
+	if !exists || v == nil {
+		return 0, nil
+	}
+	t, err := query.IGetTimestamp(v)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", key, err)
+	}
+	return uint64(t.UnixNano()), nil
+}
+
+func getID(obj map[string]interface{}, key string, size int) ([]byte, error) {
+	v, exists := obj[key]
+	if !exists || v == nil {
+		return nil, nil
+	}
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected %v to be a hex string, got %T", key, v)
+	}
+	id, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", key, err)
+	}
+	if len(id) != size {
+		return nil, fmt.Errorf("expected %v to be %v bytes, got %v", key, size, len(id))
+	}
+	return id, nil
+}
+
+// getEnum returns the value of an enum field given either by name or number.
+func getEnum(obj map[string]interface{}, key string, names map[string]uint64) (uint64, error) {
+	v, exists := obj[key]
+	if !exists || v == nil {
+		return 0, nil
+	}
+	if str, ok := v.(string); ok {
+		n, exists := names[strings.ToUpper(str)]
+		if !exists {
+			return 0, fmt.Errorf("unrecognised %v: %v", key, str)
+		}
+		return n, nil
+	}
+	n, err := query.IGetInt(v)
+	if err != nil {
+		return 0, fmt.Errorf("%v: %w", key, err)
+	}
+	return uint64(n), nil
+}
+
+// encodeLogRecord encodes an object as a LogRecord message.
+func encodeLogRecord(obj map[string]interface{}, now time.Time) ([]byte, error) {
+	var b []byte
+
+	ts, err := getTimestamp(obj, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+	b = appendFixed64(b, 1, ts)
+
+	severityNum, err := getEnum(obj, "severity_number", nil)
+	if err != nil {
+		return nil, err
+	}
+	severityText, _ := obj["severity_text"].(string)
+	if severityNum == 0 {
+		severityNum = severityNumbers[strings.ToUpper(severityText)]
+	}
+	b = appendVarint(b, 2, severityNum)
+	b = appendString(b, 3, severityText)
+
+	if body, exists := obj["body"]; exists && body != nil {
+		b = appendMessage(b, 5, encodeAnyValue(body))
+	}
+
+	attrs, err := getAttributes(obj)
+	if err != nil {
+		return nil, err
+	}
+	b = appendAttributes(b, 6, attrs)
+
+	traceID, err := getID(obj, "trace_id", 16)
+	if err != nil {
+		return nil, err
+	}
+	b = appendBytes(b, 9, traceID)
+
+	spanID, err := getID(obj, "span_id", 8)
+	if err != nil {
+		return nil, err
+	}
+	b = appendBytes(b, 10, spanID)
+
+	b = appendFixed64(b, 11, uint64(now.UnixNano()))
+	return b, nil
+}
+
+// encodeSpan encodes an object as a Span message.
+func encodeSpan(obj map[string]interface{}) ([]byte, error) {
+	var b []byte
+
+	traceID, err := getID(obj, "trace_id", 16)
+	if err != nil {
+		return nil, err
+	}
+	if traceID == nil {
+		return nil, errors.New("a trace_id is required")
+	}
+	b = appendBytes(b, 1, traceID)
+
+	spanID, err := getID(obj, "span_id", 8)
+	if err != nil {
+		return nil, err
+	}
+	if spanID == nil {
+		return nil, errors.New("a span_id is required")
+	}
+	b = appendBytes(b, 2, spanID)
+
+	parentSpanID, err := getID(obj, "parent_span_id", 8)
+	if err != nil {
+		return nil, err
+	}
+	b = appendBytes(b, 4, parentSpanID)
+
+	name, _ := obj["name"].(string)
+	if name == "" {
+		return nil, errors.New("a name is required")
+	}
+	b = appendString(b, 5, name)
+
+	kind, err := getEnum(obj, "kind", spanKinds)
+	if err != nil {
+		return nil, err
+	}
+	b = appendVarint(b, 6, kind)
+
+	startTime, err := getTimestamp(obj, "start_time")
+	if err != nil {
+		return nil, err
+	}
+	b = appendFixed64(b, 7, startTime)
+
+	endTime, err := getTimestamp(obj, "end_time")
+	if err != nil {
+		return nil, err
+	}
+	b = appendFixed64(b, 8, endTime)
+
+	attrs, err := getAttributes(obj)
+	if err != nil {
+		return nil, err
+	}
+	b = appendAttributes(b, 9, attrs)
+
+	if v, exists := obj["status"]; exists && v != nil {
+		statusObj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected status to be an object, got %T", v)
+		}
+		var status []byte
+		msg, _ := statusObj["message"].(string)
+		status = appendString(status, 2, msg)
+		code, err := getEnum(statusObj, "code", statusCodes)
+		if err != nil {
+			return nil, err
+		}
+		status = appendVarint(status, 3, code)
+		b = appendMessage(b, 15, status)
+	}
+	return b, nil
+}
+
+// encodeRequest converts a batch into an ExportLogsServiceRequest or
+// ExportTraceServiceRequest message depending on the signal.
+func (o *otlpOutput) encodeRequest(batch service.MessageBatch) ([]byte, error) {
+	now := o.nowFn()
+
+	type resourceRecords struct {
+		resource []byte
+		records  []byte
+	}
+	resources := map[string]*resourceRecords{}
+	var ordered []*resourceRecords
+
+	for i := range batch {
+		obj, err := o.toObject(batch, i)
+		if err != nil {
+			return nil, fmt.Errorf("message %v: %w", i, err)
+		}
+
+		var record []byte
+		if o.signal == "traces" {
+			record, err = encodeSpan(obj)
+		} else {
+			record, err = encodeLogRecord(obj, now)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("message %v: %w", i, err)
+		}
+
+		attrs, err := o.resourceAttributes(batch, i)
+		if err != nil {
+			return nil, fmt.Errorf("message %v: %w", i, err)
+		}
+		resource := appendAttributes(nil, 1, attrs)
+
+		r, exists := resources[string(resource)]
+		if !exists {
+			r = &resourceRecords{resource: resource}
+			resources[string(resource)] = r
+			ordered = append(ordered, r)
+		}
+		r.records = appendMessage(r.records, 2, record)
+	}
+
+	var req []byte
+	for _, r := range ordered {
+		var scope []byte
+		scope = appendString(scope, 1, "benthos")
+
+		var scopeRecords []byte
+		scopeRecords = appendMessage(scopeRecords, 1, scope)
+		scopeRecords = append(scopeRecords, r.records...)
+
+		var resourceRecords []byte
+		resourceRecords = appendMessage(resourceRecords, 1, r.resource)
+		resourceRecords = appendMessage(resourceRecords, 2, scopeRecords)
+
+		req = appendMessage(req, 1, resourceRecords)
+	}
+	return req, nil
+}
+
+func (o *otlpOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	o.connMut.RLock()
+	grpcConn, httpClient := o.grpcConn, o.httpClient
+	o.connMut.RUnlock()
+
+	if grpcConn == nil && httpClient == nil {
+		return service.ErrNotConnected
+	}
+
+	req, err := o.encodeRequest(batch)
+	if err != nil {
+		return err
+	}
+
+	if httpClient != nil {
+		return o.exportHTTP(ctx, httpClient, req)
+	}
+
+	ctx, done := context.WithTimeout(ctx, o.timeout)
+	defer done()
+
+	for k, v := range o.headers {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+
+	method := grpcLogsMethod
+	if o.signal == "traces" {
+		method = grpcTracesMethod
+	}
+	var res []byte
+	return grpcConn.Invoke(ctx, method, req, &res, grpc.ForceCodec(rawCodec{}))
+}
+
+func (o *otlpOutput) exportHTTP(ctx context.Context, client *http.Client, body []byte) error {
+	url := o.endpoint + httpLogsPath
+	if o.signal == "traces" {
+		url = o.endpoint + httpTracesPath
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "Benthos")
+	for k, v := range o.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, resBody)
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+func (o *otlpOutput) Close(ctx context.Context) error {
+	o.connMut.Lock()
+	defer o.connMut.Unlock()
+
+	if o.httpClient != nil {
+		o.httpClient.CloseIdleConnections()
+		o.httpClient = nil
+	}
+	if o.grpcConn != nil {
+		err := o.grpcConn.Close()
+		o.grpcConn = nil
+		return err
+	}
+	return nil
+}
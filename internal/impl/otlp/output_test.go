@@ -0,0 +1,338 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// pbFields decodes the fields of a protobuf message, where the values of
+// length delimited fields are byte slices and all others are uint64s.
+func pbFields(t *testing.T, b []byte) map[protowire.Number][]interface{} {
+	t.Helper()
+
+	fields := map[protowire.Number][]interface{}{}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+
+		var v interface{}
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		case protowire.Fixed64Type:
+			v, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v32 uint32
+			v32, n = protowire.ConsumeFixed32(b)
+			v = uint64(v32)
+		default:
+			v, n = protowire.ConsumeVarint(b)
+		}
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		fields[num] = append(fields[num], v)
+	}
+	return fields
+}
+
+func pbMessages(t *testing.T, b []byte, num protowire.Number) [][]byte {
+	t.Helper()
+
+	var msgs [][]byte
+	for _, v := range pbFields(t, b)[num] {
+		msgs = append(msgs, v.([]byte))
+	}
+	return msgs
+}
+
+// pbAttributes decodes repeated KeyValue fields with string, int or double
+// values into a map.
+func pbAttributes(t *testing.T, b []byte, num protowire.Number) map[string]interface{} {
+	t.Helper()
+
+	attrs := map[string]interface{}{}
+	for _, kv := range pbMessages(t, b, num) {
+		kvFields := pbFields(t, kv)
+		anyFields := pbFields(t, kvFields[2][0].([]byte))
+
+		var v interface{}
+		if s, ok := anyFields[1]; ok {
+			v = string(s[0].([]byte))
+		} else if i, ok := anyFields[3]; ok {
+			v = int64(i[0].(uint64))
+		} else if f, ok := anyFields[4]; ok {
+			v = math.Float64frombits(f[0].(uint64))
+		}
+		attrs[string(kvFields[1][0].([]byte))] = v
+	}
+	return attrs
+}
+
+func testOTLPOutput(t *testing.T, confStr string) *otlpOutput {
+	t.Helper()
+
+	conf, err := otlpOutputConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	o, err := newOTLPOutputFromConfig(conf, nil)
+	require.NoError(t, err)
+	o.nowFn = func() time.Time {
+		return time.Unix(100, 0)
+	}
+
+	require.NoError(t, o.Connect(context.Background()))
+	t.Cleanup(func() {
+		require.NoError(t, o.Close(context.Background()))
+	})
+	return o
+}
+
+type otlpHTTPServer struct {
+	mut      sync.Mutex
+	paths    []string
+	requests [][]byte
+}
+
+func (s *otlpHTTPServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.requests = append(s.requests, body)
+	}
+}
+
+func TestOTLPOutputHTTPLogs(t *testing.T) {
+	srv := &otlpHTTPServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	o := testOTLPOutput(t, `
+protocol: http
+endpoint: `+ts.URL+`/
+resource_attributes: 'root."service.name" = meta("service")'
+`)
+
+	msgA := service.NewMessage([]byte(`first`))
+	msgA.MetaSet("service", "a")
+	msgA.MetaSet("foo", "bar")
+
+	msgB := service.NewMessage([]byte(`second`))
+	msgB.MetaSet("service", "b")
+
+	msgC := service.NewMessage([]byte(`third`))
+	msgC.MetaSet("service", "a")
+
+	require.NoError(t, o.WriteBatch(context.Background(), service.MessageBatch{msgA, msgB, msgC}))
+
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	require.Equal(t, []string{"/v1/logs"}, srv.paths)
+
+	resourceLogs := pbMessages(t, srv.requests[0], 1)
+	require.Len(t, resourceLogs, 2)
+
+	type logRecord struct {
+		body  string
+		attrs map[string]interface{}
+	}
+	for i, test := range []struct {
+		service string
+		records []logRecord
+	}{
+		{
+			service: "a",
+			records: []logRecord{
+				{body: "first", attrs: map[string]interface{}{"foo": "bar", "service": "a"}},
+				{body: "third", attrs: map[string]interface{}{"service": "a"}},
+			},
+		},
+		{
+			service: "b",
+			records: []logRecord{
+				{body: "second", attrs: map[string]interface{}{"service": "b"}},
+			},
+		},
+	} {
+		resource := pbMessages(t, resourceLogs[i], 1)[0]
+		assert.Equal(t, map[string]interface{}{"service.name": test.service}, pbAttributes(t, resource, 1))
+
+		scopeLogs := pbMessages(t, resourceLogs[i], 2)
+		require.Len(t, scopeLogs, 1)
+
+		scope := pbMessages(t, scopeLogs[0], 1)[0]
+		assert.Equal(t, "benthos", string(pbMessages(t, scope, 1)[0]))
+
+		records := pbMessages(t, scopeLogs[0], 2)
+		require.Len(t, records, len(test.records))
+		for j, exp := range test.records {
+			body := pbMessages(t, records[j], 5)[0]
+			assert.Equal(t, exp.body, string(pbMessages(t, body, 1)[0]))
+			assert.Equal(t, exp.attrs, pbAttributes(t, records[j], 6))
+			assert.Equal(t, []interface{}{uint64(100 * time.Second)}, pbFields(t, records[j])[11])
+		}
+	}
+}
+
+func TestOTLPOutputLogMapping(t *testing.T) {
+	srv := &otlpHTTPServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	o := testOTLPOutput(t, `
+protocol: http
+endpoint: `+ts.URL+`
+mapping: |
+  root.body = this.msg
+  root.severity_text = this.level
+  root.timestamp = this.ts
+  root.trace_id = "5b8efff798038103d269b633813fc60c"
+  root.span_id = "eee19b7ec3c1b174"
+  root.attributes.count = this.count
+  root.attributes.ratio = this.ratio
+`)
+
+	require.NoError(t, o.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"msg":"hello","level":"warn","ts":10,"count":3,"ratio":0.5}`)),
+	}))
+
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+
+	resourceLogs := pbMessages(t, srv.requests[0], 1)
+	require.Len(t, resourceLogs, 1)
+
+	resource := pbMessages(t, resourceLogs[0], 1)[0]
+	assert.Equal(t, map[string]interface{}{"service.name": "benthos"}, pbAttributes(t, resource, 1))
+
+	record := pbMessages(t, pbMessages(t, resourceLogs[0], 2)[0], 2)[0]
+	fields := pbFields(t, record)
+	assert.Equal(t, []interface{}{uint64(10 * time.Second)}, fields[1])
+	assert.Equal(t, []interface{}{uint64(13)}, fields[2])
+	assert.Equal(t, []interface{}{[]byte("warn")}, fields[3])
+	assert.Equal(t, map[string]interface{}{"count": int64(3), "ratio": 0.5}, pbAttributes(t, record, 6))
+	assert.Len(t, fields[9][0], 16)
+	assert.Len(t, fields[10][0], 8)
+}
+
+func TestOTLPOutputInvalidRecords(t *testing.T) {
+	o := testOTLPOutput(t, `
+protocol: http
+endpoint: http://localhost:1
+signal: traces
+`)
+
+	for _, test := range []struct {
+		content     string
+		errContains string
+	}{
+		{content: `["nope"]`, errContains: "expected a record object"},
+		{content: `{"name":"foo","span_id":"eee19b7ec3c1b174"}`, errContains: "trace_id is required"},
+		{content: `{"name":"foo","trace_id":"abc","span_id":"eee19b7ec3c1b174"}`, errContains: "trace_id"},
+		{content: `{"name":"foo","trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee1"}`, errContains: "expected span_id to be 8 bytes"},
+		{content: `{"trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee19b7ec3c1b174"}`, errContains: "name is required"},
+		{content: `{"name":"foo","kind":"nope","trace_id":"5b8efff798038103d269b633813fc60c","span_id":"eee19b7ec3c1b174"}`, errContains: "unrecognised kind"},
+	} {
+		err := o.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(test.content)),
+		})
+		require.Error(t, err, test.content)
+		assert.Contains(t, err.Error(), test.errContains, test.content)
+	}
+}
+
+func TestOTLPOutputGRPCTraces(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var mut sync.Mutex
+	var methods []string
+	var requests [][]byte
+	var tenants []string
+
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+
+		var req []byte
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		md, _ := metadata.FromIncomingContext(stream.Context())
+
+		mut.Lock()
+		methods = append(methods, method)
+		requests = append(requests, req)
+		tenants = append(tenants, md.Get("x-tenant")...)
+		mut.Unlock()
+		return stream.SendMsg([]byte{})
+	}), grpc.ForceServerCodec(rawCodec{}))
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	defer srv.Stop()
+
+	o := testOTLPOutput(t, `
+endpoint: `+ln.Addr().String()+`
+signal: traces
+headers:
+  x-tenant: foo
+`)
+
+	require.NoError(t, o.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{
+  "name": "GET /users",
+  "trace_id": "5b8efff798038103d269b633813fc60c",
+  "span_id": "eee19b7ec3c1b174",
+  "parent_span_id": "eee19b7ec3c1b173",
+  "kind": "server",
+  "start_time": "1970-01-01T00:00:01Z",
+  "end_time": 2,
+  "status": { "code": "ERROR", "message": "oh no" },
+  "attributes": { "http.status_code": 500 }
+}`)),
+	}))
+
+	mut.Lock()
+	defer mut.Unlock()
+	assert.Equal(t, []string{grpcTracesMethod}, methods)
+	assert.Equal(t, []string{"foo"}, tenants)
+
+	resourceSpans := pbMessages(t, requests[0], 1)
+	require.Len(t, resourceSpans, 1)
+
+	span := pbMessages(t, pbMessages(t, resourceSpans[0], 2)[0], 2)[0]
+	fields := pbFields(t, span)
+	assert.Len(t, fields[1][0], 16)
+	assert.Len(t, fields[2][0], 8)
+	assert.Len(t, fields[4][0], 8)
+	assert.Equal(t, []interface{}{[]byte("GET /users")}, fields[5])
+	assert.Equal(t, []interface{}{uint64(2)}, fields[6])
+	assert.Equal(t, []interface{}{uint64(time.Second)}, fields[7])
+	assert.Equal(t, []interface{}{uint64(2 * time.Second)}, fields[8])
+	assert.Equal(t, map[string]interface{}{"http.status_code": int64(500)}, pbAttributes(t, span, 9))
+
+	status := pbFields(t, fields[15][0].([]byte))
+	assert.Equal(t, []interface{}{[]byte("oh no")}, status[2])
+	assert.Equal(t, []interface{}{uint64(2)}, status[3])
+}
@@ -0,0 +1,225 @@
+package otlp
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The OTLP protobuf messages are encoded by hand in order to avoid depending on
+// the generated packages of the opentelemetry-proto repo, field numbers are
+// taken from version 0.16.0 of the protocol.
+
+const (
+	grpcLogsMethod   = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+	grpcTracesMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+
+	httpLogsPath   = "/v1/logs"
+	httpTracesPath = "/v1/traces"
+)
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendFixed64(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// encodeAnyValue encodes a structured value as an AnyValue message.
+func encodeAnyValue(v interface{}) []byte {
+	var b []byte
+	switch t := v.(type) {
+	case string:
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, t)
+	case bool:
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(t))
+	case int:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(t))
+	case int64:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(t))
+	case uint64:
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, t)
+	case float64:
+		b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(t))
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return encodeAnyValue(i)
+		}
+		f, _ := t.Float64()
+		return encodeAnyValue(f)
+	case []interface{}:
+		var arr []byte
+		for _, e := range t {
+			arr = appendMessage(arr, 1, encodeAnyValue(e))
+		}
+		b = appendMessage(b, 5, arr)
+	case map[string]interface{}:
+		b = appendMessage(b, 6, appendAttributes(nil, 1, t))
+	case []byte:
+		b = protowire.AppendTag(b, 7, protowire.BytesType)
+		b = protowire.AppendBytes(b, t)
+	}
+	return b
+}
+
+// appendAttributes appends the fields of an object as repeated KeyValue
+// fields with a given field number. Keys are sorted so that equal objects
+// result in equal encodings.
+func appendAttributes(b []byte, num protowire.Number, obj map[string]interface{}) []byte {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var kv []byte
+		kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+		kv = protowire.AppendString(kv, k)
+		kv = appendMessage(kv, 2, encodeAnyValue(obj[k]))
+		b = appendMessage(b, num, kv)
+	}
+	return b
+}
+
+//------------------------------------------------------------------------------
+
+// rangeFields calls fn for each field of a protobuf message, where v is the
+// contents of length delimited fields and n is the value of all others.
+func rangeFields(b []byte, fn func(num protowire.Number, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		var v []byte
+		var n uint64
+		switch typ {
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var n32 uint32
+			n32, l = protowire.ConsumeFixed32(b)
+			n = uint64(n32)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		if err := fn(num, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeAnyValue decodes an AnyValue message into a structured value.
+func decodeAnyValue(b []byte) (interface{}, error) {
+	var value interface{}
+	err := rangeFields(b, func(num protowire.Number, v []byte, n uint64) (err error) {
+		switch num {
+		case 1:
+			value = string(v)
+		case 2:
+			value = protowire.DecodeBool(n)
+		case 3:
+			value = int64(n)
+		case 4:
+			value = math.Float64frombits(n)
+		case 5:
+			arr := []interface{}{}
+			err = rangeFields(v, func(num protowire.Number, v []byte, _ uint64) error {
+				if num != 1 {
+					return nil
+				}
+				e, err := decodeAnyValue(v)
+				if err != nil {
+					return err
+				}
+				arr = append(arr, e)
+				return nil
+			})
+			value = arr
+		case 6:
+			obj := map[string]interface{}{}
+			err = decodeAttributes(obj, v, 1)
+			value = obj
+		case 7:
+			value = append([]byte(nil), v...)
+		}
+		return
+	})
+	return value, err
+}
+
+// decodeAttributes decodes the repeated KeyValue fields of a message with a
+// given field number into an object.
+func decodeAttributes(obj map[string]interface{}, b []byte, attrNum protowire.Number) error {
+	return rangeFields(b, func(num protowire.Number, v []byte, _ uint64) error {
+		if num != attrNum {
+			return nil
+		}
+		var key string
+		var value interface{}
+		if err := rangeFields(v, func(num protowire.Number, v []byte, _ uint64) (err error) {
+			switch num {
+			case 1:
+				key = string(v)
+			case 2:
+				value, err = decodeAnyValue(v)
+			}
+			return
+		}); err != nil {
+			return err
+		}
+		obj[key] = value
+		return nil
+	})
+}
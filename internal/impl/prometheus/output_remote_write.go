@@ -0,0 +1,506 @@
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func remoteWriteOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Sends metrics to an endpoint implementing the [Prometheus remote write protocol](https://prometheus.io/docs/concepts/remote_write_spec/), such as Mimir, Thanos, Cortex or VictoriaMetrics.").
+		Description(`
+Each message is converted into a sample of a time series, and must either be or be mapped into an object of the form:
+
+`+"```json"+`
+{
+  "name": "http_requests_total",
+  "labels": { "method": "GET", "code": "200" },
+  "value": 1027,
+  "timestamp": 1650000000000
+}
+`+"```"+`
+
+Where `+"`timestamp`"+` is optional and is either a number of milliseconds since the unix epoch or an RFC 3339 string, defaulting to the time at which the message is written. Labels with empty values are omitted.
+
+The samples of a batch are grouped by series and sent as a single snappy compressed protobuf write request, and therefore it is recommended to configure a [batching policy](/docs/configuration/batching). Since remote write endpoints reject samples that are older than the latest sample of a series the default `+"`max_in_flight`"+` of one preserves the order of writes.
+
+### Retries
+
+Requests that fail due to a connection error, a 5XX status code or a 429 status code are retried with a back off, and for a 429 the period given by the `+"`Retry-After`"+` header is respected. There is no write ahead log, once the retries of a request are exhausted the batch is rejected and the input decides whether to redeliver it. Requests rejected with any other 4XX status code are not retried, as sending the same samples again would result in the same error.
+
+### Staleness
+
+Prometheus compatible backends continue to show the last value of a series for up to five minutes after its final sample. When `+"`stale_after`"+` is set any series that has not been written for that period of time is sent a [staleness marker](https://prometheus.io/docs/prometheus/latest/querying/basics/#staleness) along with the next write request, indicating that the series has ended.`).
+		Field(service.NewStringField("url").
+			Description("The URL of the remote write endpoint.").
+			Example("http://localhost:9009/api/v1/push")).
+		Field(service.NewBloblangField("mapping").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) that converts each message into a metric object. When omitted each message must already be a metric object.").
+			Example(`root.name = "orders_total"
+root.labels.region = this.region
+root.value = this.count`).
+			Optional()).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to requests, which can be used for authentication or in order to set a tenant ID.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"X-Scope-OrgID": "tenant-1",
+			})).
+		Field(service.NewDurationField("timeout").
+			Description("A timeout for each request.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewIntField("max_retries").
+			Description("The maximum number of retry attempts for a request before the batch is rejected.").
+			Default(3).
+			Advanced()).
+		Field(service.NewBackOffField("backoff", false, nil).Advanced()).
+		Field(service.NewDurationField("stale_after").
+			Description("The period of time after which a series that has not been written is sent a staleness marker. Set to `0s` in order to disable staleness markers.").
+			Default("0s").
+			Example("5m").
+			Advanced()).
+		Field(service.NewTLSField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time.").
+			Default(1)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Metrics From Events", `
+Here we convert reports of the number of orders of each region into samples of a series per region, which are sent to a tenant of Mimir:`,
+			`
+output:
+  prometheus_remote_write:
+    url: http://mimir:9009/api/v1/push
+    headers:
+      X-Scope-OrgID: shop
+    mapping: |
+      root.name = "orders_total"
+      root.labels.region = this.region
+      root.value = this.orders_count
+      root.timestamp = this.reported_at
+    batching:
+      count: 500
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("prometheus_remote_write", remoteWriteOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newRemoteWriteOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// staleNaN is the special NaN value used by Prometheus to mark a series as
+// stale.
+var staleNaN = math.Float64frombits(0x7ff0000000000002)
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type rwLabel struct {
+	name, value string
+}
+
+type rwSample struct {
+	value     float64
+	timestamp int64
+}
+
+type rwSeries struct {
+	labels  []rwLabel
+	samples []rwSample
+}
+
+func seriesKey(labels []rwLabel) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(l.name)
+		b.WriteByte(0xff)
+		b.WriteString(l.value)
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// encodeWriteRequest encodes series as a prometheus.WriteRequest protobuf
+// message.
+func encodeWriteRequest(series []*rwSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, l.value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, lb)
+		}
+		for _, smp := range s.samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(smp.value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(smp.timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, sb)
+		}
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
+
+//------------------------------------------------------------------------------
+
+type rwRateLimited struct {
+	retryAfter time.Duration
+}
+
+func (e *rwRateLimited) Error() string {
+	return "received status code 429 (too many requests)"
+}
+
+type rwTrackedSeries struct {
+	labels      []rwLabel
+	lastWritten time.Time
+}
+
+type remoteWriteOutput struct {
+	log *service.Logger
+
+	url         string
+	mapping     *bloblang.Executor
+	headers     map[string]string
+	maxRetries  int
+	backoffConf backoff.ExponentialBackOff
+	staleAfter  time.Duration
+	client      *http.Client
+
+	nowFn func() time.Time
+
+	trackedMut sync.Mutex
+	tracked    map[string]rwTrackedSeries
+}
+
+func newRemoteWriteOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*remoteWriteOutput, error) {
+	r := &remoteWriteOutput{
+		log:     log,
+		nowFn:   time.Now,
+		tracked: map[string]rwTrackedSeries{},
+	}
+
+	var err error
+	if r.url, err = conf.FieldString("url"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("mapping") {
+		if r.mapping, err = conf.FieldBloblang("mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if r.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+	if r.maxRetries, err = conf.FieldInt("max_retries"); err != nil {
+		return nil, err
+	}
+	boff, err := conf.FieldBackOff("backoff")
+	if err != nil {
+		return nil, err
+	}
+	r.backoffConf = *boff
+	if r.staleAfter, err = conf.FieldDuration("stale_after"); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS("tls")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	r.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	return r, nil
+}
+
+func (r *remoteWriteOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+// toSample converts a message into the labels of a series and a sample.
+func (r *remoteWriteOutput) toSample(msg *service.Message, now time.Time) ([]rwLabel, rwSample, error) {
+	if r.mapping != nil {
+		var err error
+		if msg, err = msg.BloblangQuery(r.mapping); err != nil {
+			return nil, rwSample{}, fmt.Errorf("mapping failed: %w", err)
+		}
+		if msg == nil {
+			return nil, rwSample{}, errors.New("mapping deleted the metric")
+		}
+	}
+
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, rwSample{}, err
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, rwSample{}, fmt.Errorf("expected a metric object, got %T", v)
+	}
+
+	name, _ := obj["name"].(string)
+	if !metricNameRegexp.MatchString(name) {
+		return nil, rwSample{}, fmt.Errorf("invalid metric name: %q", name)
+	}
+	labels := []rwLabel{{name: "__name__", value: name}}
+	if lV := obj["labels"]; lV != nil {
+		lObj, ok := lV.(map[string]interface{})
+		if !ok {
+			return nil, rwSample{}, fmt.Errorf("expected labels to be an object, got %T", lV)
+		}
+		for k, v := range lObj {
+			if k == "__name__" || !labelNameRegexp.MatchString(k) {
+				return nil, rwSample{}, fmt.Errorf("invalid label name: %q", k)
+			}
+			if vStr := query.IToString(v); vStr != "" {
+				labels = append(labels, rwLabel{name: k, value: vStr})
+			}
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+
+	var sample rwSample
+	if sample.value, err = query.IGetNumber(obj["value"]); err != nil {
+		return nil, rwSample{}, fmt.Errorf("value: %w", err)
+	}
+	switch t := obj["timestamp"].(type) {
+	case nil:
+		sample.timestamp = now.UnixNano() / int64(time.Millisecond)
+	case string:
+		ts, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return nil, rwSample{}, fmt.Errorf("timestamp: %w", err)
+		}
+		sample.timestamp = ts.UnixNano() / int64(time.Millisecond)
+	default:
+		if sample.timestamp, err = query.IGetInt(t); err != nil {
+			return nil, rwSample{}, fmt.Errorf("timestamp: %w", err)
+		}
+	}
+	return labels, sample, nil
+}
+
+// staleSeries returns the tracked series that haven't been written within the
+// stale period and aren't present in the series about to be written.
+func (r *remoteWriteOutput) staleSeries(now time.Time, writing map[string]*rwSeries) map[string]*rwSeries {
+	if r.staleAfter <= 0 {
+		return nil
+	}
+
+	r.trackedMut.Lock()
+	defer r.trackedMut.Unlock()
+
+	stale := map[string]*rwSeries{}
+	for key, t := range r.tracked {
+		if _, exists := writing[key]; exists || now.Sub(t.lastWritten) < r.staleAfter {
+			continue
+		}
+		stale[key] = &rwSeries{
+			labels: t.labels,
+			samples: []rwSample{{
+				value:     staleNaN,
+				timestamp: now.UnixNano() / int64(time.Millisecond),
+			}},
+		}
+	}
+	return stale
+}
+
+func (r *remoteWriteOutput) markWritten(now time.Time, written, stale map[string]*rwSeries) {
+	if r.staleAfter <= 0 {
+		return
+	}
+
+	r.trackedMut.Lock()
+	defer r.trackedMut.Unlock()
+
+	for key := range stale {
+		delete(r.tracked, key)
+	}
+	for key, s := range written {
+		r.tracked[key] = rwTrackedSeries{labels: s.labels, lastWritten: now}
+	}
+}
+
+func (r *remoteWriteOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	now := r.nowFn()
+
+	writing := map[string]*rwSeries{}
+	var ordered []*rwSeries
+	for i, msg := range batch {
+		labels, sample, err := r.toSample(msg, now)
+		if err != nil {
+			return fmt.Errorf("message %v: %w", i, err)
+		}
+		key := seriesKey(labels)
+		s, exists := writing[key]
+		if !exists {
+			s = &rwSeries{labels: labels}
+			writing[key] = s
+			ordered = append(ordered, s)
+		}
+		s.samples = append(s.samples, sample)
+	}
+	for _, s := range ordered {
+		sort.SliceStable(s.samples, func(i, j int) bool {
+			return s.samples[i].timestamp < s.samples[j].timestamp
+		})
+	}
+
+	stale := r.staleSeries(now, writing)
+	staleKeys := make([]string, 0, len(stale))
+	for key := range stale {
+		staleKeys = append(staleKeys, key)
+	}
+	sort.Strings(staleKeys)
+	for _, key := range staleKeys {
+		ordered = append(ordered, stale[key])
+	}
+
+	if err := r.send(ctx, snappy.Encode(nil, encodeWriteRequest(ordered))); err != nil {
+		return err
+	}
+	r.markWritten(now, writing, stale)
+	return nil
+}
+
+func (r *remoteWriteOutput) send(ctx context.Context, body []byte) error {
+	boff := r.backoffConf
+	boff.Reset()
+
+	for attempt := 0; ; attempt++ {
+		err := r.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		var pErr *backoff.PermanentError
+		if errors.As(err, &pErr) {
+			return pErr.Err
+		}
+		if attempt >= r.maxRetries {
+			return err
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		var rlErr *rwRateLimited
+		if errors.As(err, &rlErr) && rlErr.retryAfter > 0 {
+			wait = rlErr.retryAfter
+		}
+
+		r.log.Debugf("Retrying remote write request in %v after error: %v", wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *remoteWriteOutput) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", r.url, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "Benthos")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		return nil
+	case res.StatusCode == http.StatusTooManyRequests:
+		rlErr := &rwRateLimited{}
+		if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && secs > 0 {
+			rlErr.retryAfter = time.Duration(secs) * time.Second
+		}
+		return rlErr
+	case res.StatusCode >= 500:
+		return fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, resBody)
+	}
+	return backoff.Permanent(fmt.Errorf("samples rejected with status code %v: %s", res.StatusCode, resBody))
+}
+
+func (r *remoteWriteOutput) Close(ctx context.Context) error {
+	r.client.CloseIdleConnections()
+	return nil
+}
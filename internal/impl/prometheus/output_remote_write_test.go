@@ -0,0 +1,262 @@
+package prometheus
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testRemoteWriteOutput(t *testing.T, confStr string) *remoteWriteOutput {
+	t.Helper()
+
+	conf, err := remoteWriteOutputConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	r, err := newRemoteWriteOutputFromConfig(conf, nil)
+	require.NoError(t, err)
+	return r
+}
+
+// decodeWriteRequest decodes a snappy compressed prometheus.WriteRequest into
+// a more easily compared form.
+func decodeWriteRequest(t *testing.T, body []byte) []rwSeries {
+	t.Helper()
+
+	b, err := snappy.Decode(nil, body)
+	require.NoError(t, err)
+
+	consumeFields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) int) {
+		for len(b) > 0 {
+			num, typ, n := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+			n = fn(num, typ, b)
+			require.GreaterOrEqual(t, n, 0)
+			b = b[n:]
+		}
+	}
+
+	var series []rwSeries
+	consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		tsBytes, n := protowire.ConsumeBytes(b)
+
+		var s rwSeries
+		consumeFields(tsBytes, func(num protowire.Number, typ protowire.Type, b []byte) int {
+			msgBytes, n := protowire.ConsumeBytes(b)
+			switch num {
+			case 1:
+				var l rwLabel
+				consumeFields(msgBytes, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					v, n := protowire.ConsumeString(b)
+					if num == 1 {
+						l.name = v
+					} else {
+						l.value = v
+					}
+					return n
+				})
+				s.labels = append(s.labels, l)
+			case 2:
+				var smp rwSample
+				consumeFields(msgBytes, func(num protowire.Number, typ protowire.Type, b []byte) int {
+					if num == 1 {
+						v, n := protowire.ConsumeFixed64(b)
+						smp.value = math.Float64frombits(v)
+						return n
+					}
+					v, n := protowire.ConsumeVarint(b)
+					smp.timestamp = int64(v)
+					return n
+				})
+				s.samples = append(s.samples, smp)
+			}
+			return n
+		})
+		series = append(series, s)
+		return n
+	})
+	return series
+}
+
+type remoteWriteServer struct {
+	mut      sync.Mutex
+	requests [][]rwSeries
+	statuses []int
+}
+
+func (s *remoteWriteServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		s.requests = append(s.requests, decodeWriteRequest(t, body))
+		if len(s.statuses) > 0 {
+			w.WriteHeader(s.statuses[0])
+			s.statuses = s.statuses[1:]
+		}
+	}
+}
+
+func TestRemoteWriteGroupsSeries(t *testing.T) {
+	srv := &remoteWriteServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	r := testRemoteWriteOutput(t, `
+url: `+ts.URL+`
+mapping: |
+  root.name = "orders_total"
+  root.labels.region = this.region
+  root.labels.empty = ""
+  root.value = this.count
+  root.timestamp = this.ts
+`)
+
+	require.NoError(t, r.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"region":"eu","count":3,"ts":2000}`)),
+		service.NewMessage([]byte(`{"region":"us","count":5,"ts":1000}`)),
+		service.NewMessage([]byte(`{"region":"eu","count":2,"ts":1000}`)),
+		service.NewMessage([]byte(`{"region":"us","count":7,"ts":"1970-01-01T00:00:03Z"}`)),
+	}))
+
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	assert.Equal(t, [][]rwSeries{{
+		{
+			labels:  []rwLabel{{"__name__", "orders_total"}, {"region", "eu"}},
+			samples: []rwSample{{2, 1000}, {3, 2000}},
+		},
+		{
+			labels:  []rwLabel{{"__name__", "orders_total"}, {"region", "us"}},
+			samples: []rwSample{{5, 1000}, {7, 3000}},
+		},
+	}}, srv.requests)
+}
+
+func TestRemoteWriteInvalidMetrics(t *testing.T) {
+	r := testRemoteWriteOutput(t, `
+url: http://localhost:1
+`)
+
+	for _, test := range []struct {
+		content     string
+		errContains string
+	}{
+		{content: `"nope"`, errContains: "expected a metric object"},
+		{content: `{"name":"foo bar","value":1}`, errContains: "invalid metric name"},
+		{content: `{"name":"foo","labels":{"__name__":"bar"},"value":1}`, errContains: "invalid label name"},
+		{content: `{"name":"foo","labels":"bar","value":1}`, errContains: "expected labels to be an object"},
+		{content: `{"name":"foo"}`, errContains: "value"},
+		{content: `{"name":"foo","value":1,"timestamp":"yesterday"}`, errContains: "timestamp"},
+	} {
+		err := r.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(test.content)),
+		})
+		require.Error(t, err, test.content)
+		assert.Contains(t, err.Error(), test.errContains, test.content)
+	}
+}
+
+func TestRemoteWriteRetries(t *testing.T) {
+	srv := &remoteWriteServer{
+		statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+	}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	r := testRemoteWriteOutput(t, `
+url: `+ts.URL+`
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+`)
+
+	require.NoError(t, r.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"foo","value":1,"timestamp":1000}`)),
+	}))
+
+	srv.mut.Lock()
+	assert.Len(t, srv.requests, 3)
+	srv.statuses = []int{http.StatusBadRequest, http.StatusOK}
+	srv.mut.Unlock()
+
+	err := r.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"foo","value":1,"timestamp":1000}`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status code 400")
+
+	srv.mut.Lock()
+	assert.Len(t, srv.requests, 4)
+	srv.mut.Unlock()
+}
+
+func TestRemoteWriteStaleness(t *testing.T) {
+	srv := &remoteWriteServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	r := testRemoteWriteOutput(t, `
+url: `+ts.URL+`
+stale_after: 1m
+`)
+
+	now := time.Unix(100, 0)
+	r.nowFn = func() time.Time {
+		return now
+	}
+
+	write := func(contents ...string) {
+		t.Helper()
+		var batch service.MessageBatch
+		for _, c := range contents {
+			batch = append(batch, service.NewMessage([]byte(c)))
+		}
+		require.NoError(t, r.WriteBatch(context.Background(), batch))
+	}
+
+	write(`{"name":"a","value":1}`, `{"name":"b","value":1}`)
+
+	now = now.Add(time.Second * 30)
+	write(`{"name":"a","value":2}`)
+
+	now = now.Add(time.Second * 40)
+	write(`{"name":"a","value":3}`)
+
+	now = now.Add(time.Second * 40)
+	write(`{"name":"a","value":4}`)
+
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	require.Len(t, srv.requests, 4)
+
+	// The series b was last written 70 seconds before the third request.
+	third := srv.requests[2]
+	require.Len(t, third, 2)
+	assert.Equal(t, []rwLabel{{"__name__", "a"}}, third[0].labels)
+	assert.Equal(t, []rwLabel{{"__name__", "b"}}, third[1].labels)
+	require.Len(t, third[1].samples, 1)
+	assert.Equal(t, math.Float64bits(staleNaN), math.Float64bits(third[1].samples[0].value))
+	assert.Equal(t, int64(170000), third[1].samples[0].timestamp)
+
+	// Stale series are no longer tracked.
+	assert.Len(t, srv.requests[3], 1)
+}
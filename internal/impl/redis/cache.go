@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -34,6 +35,11 @@ func redisCacheConfig() *service.ConfigSpec {
 			Optional().
 			Advanced()).
 		Field(service.NewBackOffField("retries", false, retriesDefaults).
+			Advanced()).
+		Field(clientCacheField()).
+		Field(service.NewBoolField("shared_client").
+			Description("Whether the client should be shared with other components that enable this field and target the same server with the same connection settings, reducing the number of connections opened when many caches or streams use the same Redis instance. The client is closed once the last component using it is closed.").
+			Default(false).
 			Advanced())
 
 	return spec
@@ -43,7 +49,7 @@ func init() {
 	err := service.RegisterCache(
 		"redis", redisCacheConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Cache, error) {
-			return newRedisCacheFromConfig(conf)
+			return newRedisCacheFromConfig(conf, mgr)
 		})
 
 	if err != nil {
@@ -51,12 +57,24 @@ func init() {
 	}
 }
 
-func newRedisCacheFromConfig(conf *service.ParsedConfig) (*redisCache, error) {
-	client, err := getClient(conf)
+func newRedisCacheFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*redisCache, error) {
+	shared, err := conf.FieldBool("shared_client")
 	if err != nil {
 		return nil, err
 	}
 
+	var client redis.UniversalClient
+	var release func() error
+	if shared {
+		if client, release, err = getSharedClient(conf, mgr); err != nil {
+			return nil, err
+		}
+	} else {
+		if client, err = getClient(conf); err != nil {
+			return nil, err
+		}
+	}
+
 	var prefix string
 	if conf.Contains("prefix") {
 		if prefix, err = conf.FieldString("prefix"); err != nil {
@@ -77,7 +95,28 @@ func newRedisCacheFromConfig(conf *service.ParsedConfig) (*redisCache, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newRedisCache(ttl, prefix, client, backOff)
+	c, err := newRedisCache(ttl, prefix, client, backOff)
+	if err != nil {
+		return nil, err
+	}
+	c.release = release
+
+	ccConf, err := clientCacheConfigFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
+	if ccConf.enabled {
+		opts, kind, err := clientOptions(conf)
+		if err != nil {
+			return nil, err
+		}
+		if kind != "simple" {
+			return nil, fmt.Errorf("client side caching is not supported by the %v client kind", kind)
+		}
+		c.local = newLocalCache(ccConf)
+		c.listener = newInvalidationListener(opts, prefix, c.local, mgr.Logger())
+	}
+	return c, nil
 }
 
 //------------------------------------------------------------------------------
@@ -87,6 +126,13 @@ type redisCache struct {
 	defaultTTL time.Duration
 	prefix     string
 
+	// When the client is shared this releases it rather than closing it.
+	release func() error
+
+	// Only set when client side caching is enabled.
+	local    *localCache
+	listener *invalidationListener
+
 	boffPool sync.Pool
 }
 
@@ -118,9 +164,22 @@ func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	}()
 
 	key = r.prefix + key
+
+	var seq uint64
+	if r.local != nil {
+		v, s, exists := r.local.Get(key)
+		if exists {
+			return v, nil
+		}
+		seq = s
+	}
+
 	for {
 		res, err := r.client.Get(key).Result()
 		if err == nil {
+			if r.local != nil {
+				r.local.Store(key, []byte(res), seq)
+			}
 			return []byte(res), nil
 		}
 		if err == redis.Nil {
@@ -147,6 +206,9 @@ func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl *tim
 	}()
 
 	key = r.prefix + key
+	if r.local != nil {
+		defer r.local.Invalidate([]string{key})
+	}
 
 	var t time.Duration
 	if ttl != nil {
@@ -181,6 +243,9 @@ func (r *redisCache) Add(ctx context.Context, key string, value []byte, ttl *tim
 	}()
 
 	key = r.prefix + key
+	if r.local != nil {
+		defer r.local.Invalidate([]string{key})
+	}
 
 	var t time.Duration
 	if ttl != nil {
@@ -218,6 +283,9 @@ func (r *redisCache) Delete(ctx context.Context, key string) error {
 	}()
 
 	key = r.prefix + key
+	if r.local != nil {
+		defer r.local.Invalidate([]string{key})
+	}
 
 	for {
 		_, err := r.client.Del(key).Result()
@@ -238,5 +306,13 @@ func (r *redisCache) Delete(ctx context.Context, key string) error {
 }
 
 func (r *redisCache) Close(ctx context.Context) error {
+	if r.listener != nil {
+		if err := r.listener.Close(ctx); err != nil {
+			return err
+		}
+	}
+	if r.release != nil {
+		return r.release()
+	}
 	return r.client.Close()
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func TestIntegrationRedisCache(t *testing.T) {
@@ -39,7 +40,7 @@ func TestIntegrationRedisCache(t *testing.T) {
 			return cErr
 		}
 
-		r, cErr := newRedisCacheFromConfig(pConf)
+		r, cErr := newRedisCacheFromConfig(pConf, service.MockResources())
 		if cErr != nil {
 			return cErr
 		}
@@ -66,6 +67,22 @@ cache_resources:
 		t, template,
 		integration.CacheTestOptPort(resource.GetPort("6379/tcp")),
 	)
+
+	t.Run("with client cache", func(t *testing.T) {
+		template := `
+cache_resources:
+  - label: testcache
+    redis:
+      url: tcp://localhost:$PORT/1
+      prefix: $ID
+      client_cache:
+        enabled: true
+`
+		suite.Run(
+			t, template,
+			integration.CacheTestOptPort(resource.GetPort("6379/tcp")),
+		)
+	})
 }
 
 func TestIntegrationRedisClusterCache(t *testing.T) {
@@ -131,7 +148,7 @@ kind: cluster
 			return cErr
 		}
 
-		r, cErr := newRedisCacheFromConfig(pConf)
+		r, cErr := newRedisCacheFromConfig(pConf, service.MockResources())
 		if cErr != nil {
 			return cErr
 		}
@@ -243,7 +260,7 @@ master: mymaster
 			return cErr
 		}
 
-		r, cErr := newRedisCacheFromConfig(pConf)
+		r, cErr := newRedisCacheFromConfig(pConf, service.MockResources())
 		if cErr != nil {
 			return cErr
 		}
@@ -0,0 +1,387 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func clientCacheField() *service.ConfigField {
+	return service.NewObjectField("client_cache",
+		service.NewBoolField("enabled").
+			Description("Whether client side caching should be enabled.").
+			Default(false),
+		service.NewDurationField("ttl").
+			Description("The maximum period of time that an item is kept in the local cache, which bounds how stale an item can become should an invalidation be missed.").
+			Default("1m"),
+		service.NewIntField("max_items").
+			Description("The maximum number of items kept in the local cache, once reached the local cache is emptied.").
+			Default(10000),
+	).
+		Description("Keep a local copy of items that have been read in order to serve subsequent reads without a round trip to the server, which can greatly reduce latency for read heavy workloads such as enrichments. Local copies are invalidated by the server using the [client side caching](https://redis.io/docs/manual/client-side-caching/) feature of Redis 6 in broadcasting mode, where a dedicated connection is opened for receiving invalidations of keys with the configured `prefix`. Whilst that connection is down items are read from the server directly. This is only supported by the `simple` client kind.").
+		Advanced()
+}
+
+type clientCacheConfig struct {
+	enabled  bool
+	ttl      time.Duration
+	maxItems int
+}
+
+func clientCacheConfigFromParsed(pConf *service.ParsedConfig) (conf clientCacheConfig, err error) {
+	pConf = pConf.Namespace("client_cache")
+	if conf.enabled, err = pConf.FieldBool("enabled"); err != nil {
+		return
+	}
+	if conf.ttl, err = pConf.FieldDuration("ttl"); err != nil {
+		return
+	}
+	conf.maxItems, err = pConf.FieldInt("max_items")
+	return
+}
+
+//------------------------------------------------------------------------------
+
+type localItem struct {
+	value   []byte
+	expires time.Time
+}
+
+// localCache holds local copies of items that are invalidated by a tracking
+// connection. Items are only served whilst tracking is active, and each
+// invalidation increments a sequence number such that values read from the
+// server concurrently with an invalidation are not stored.
+type localCache struct {
+	ttl      time.Duration
+	maxItems int
+
+	mut    sync.Mutex
+	items  map[string]localItem
+	seq    uint64
+	active bool
+
+	nowFn func() time.Time
+}
+
+func newLocalCache(conf clientCacheConfig) *localCache {
+	return &localCache{
+		ttl:      conf.ttl,
+		maxItems: conf.maxItems,
+		items:    map[string]localItem{},
+		nowFn:    time.Now,
+	}
+}
+
+// Get returns a local copy of an item if one exists, otherwise the current
+// sequence number that must be provided when storing the item.
+func (l *localCache) Get(key string) (value []byte, seq uint64, exists bool) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if !l.active {
+		return nil, l.seq, false
+	}
+	item, exists := l.items[key]
+	if !exists {
+		return nil, l.seq, false
+	}
+	if !l.nowFn().Before(item.expires) {
+		delete(l.items, key)
+		return nil, l.seq, false
+	}
+	return item.value, l.seq, true
+}
+
+// Store adds a local copy of an item read from the server, unless an
+// invalidation has occurred since the read began.
+func (l *localCache) Store(key string, value []byte, seq uint64) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if !l.active || seq != l.seq {
+		return
+	}
+	if len(l.items) >= l.maxItems {
+		l.items = map[string]localItem{}
+	}
+	l.items[key] = localItem{value: value, expires: l.nowFn().Add(l.ttl)}
+}
+
+// Invalidate removes local copies of keys, where a nil slice of keys removes
+// all local copies.
+func (l *localCache) Invalidate(keys []string) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.seq++
+	if keys == nil {
+		l.items = map[string]localItem{}
+		return
+	}
+	for _, k := range keys {
+		delete(l.items, k)
+	}
+}
+
+// SetActive sets whether invalidations are currently being received, all
+// local copies are removed when this changes.
+func (l *localCache) SetActive(active bool) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.seq++
+	l.active = active
+	l.items = map[string]localItem{}
+}
+
+//------------------------------------------------------------------------------
+
+const invalidateChannel = "__redis__:invalidate"
+
+// invalidationListener maintains a connection to a Redis server that enables
+// key tracking in broadcasting mode, redirected to itself, and subscribes to
+// invalidation messages. A dedicated connection is used rather than a client
+// as invalidation messages carry an array of keys, which the pub/sub
+// implementation of the client library is unable to parse.
+type invalidationListener struct {
+	addr     string
+	username string
+	password string
+	tlsConf  *tls.Config
+	prefix   string
+
+	cache *localCache
+	log   *service.Logger
+
+	ctx    context.Context
+	cancel func()
+
+	connMut sync.Mutex
+	conn    net.Conn
+
+	done chan struct{}
+}
+
+func newInvalidationListener(opts *redis.UniversalOptions, prefix string, cache *localCache, log *service.Logger) *invalidationListener {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &invalidationListener{
+		addr:     opts.Addrs[0],
+		username: opts.Username,
+		password: opts.Password,
+		tlsConf:  opts.TLSConfig,
+		prefix:   prefix,
+		cache:    cache,
+		log:      log,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go l.loop()
+	return l
+}
+
+func (l *invalidationListener) loop() {
+	defer close(l.done)
+
+	for {
+		err := l.listen()
+		l.cache.SetActive(false)
+		if l.ctx.Err() != nil {
+			return
+		}
+		l.log.Errorf("Client cache invalidations interrupted, reading directly from the server: %v", err)
+
+		select {
+		case <-time.After(time.Second):
+		case <-l.ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *invalidationListener) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if l.tlsConf != nil {
+		return tls.DialWithDialer(dialer, "tcp", l.addr, l.tlsConf)
+	}
+	return dialer.DialContext(l.ctx, "tcp", l.addr)
+}
+
+func (l *invalidationListener) listen() error {
+	conn, err := l.dial()
+	if err != nil {
+		return err
+	}
+
+	l.connMut.Lock()
+	if l.ctx.Err() != nil {
+		l.connMut.Unlock()
+		_ = conn.Close()
+		return l.ctx.Err()
+	}
+	l.conn = conn
+	l.connMut.Unlock()
+
+	defer func() {
+		l.connMut.Lock()
+		l.conn = nil
+		l.connMut.Unlock()
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	call := func(args ...string) (interface{}, error) {
+		if err := writeRESPCommand(conn, args...); err != nil {
+			return nil, err
+		}
+		return readRESPReply(r)
+	}
+
+	if l.password != "" {
+		args := []string{"AUTH", l.password}
+		if l.username != "" {
+			args = []string{"AUTH", l.username, l.password}
+		}
+		if _, err := call(args...); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	res, err := call("CLIENT", "ID")
+	if err != nil {
+		return fmt.Errorf("client id: %w", err)
+	}
+	id, ok := res.(int64)
+	if !ok {
+		return fmt.Errorf("unexpected client id reply: %v", res)
+	}
+
+	args := []string{"CLIENT", "TRACKING", "ON", "REDIRECT", strconv.FormatInt(id, 10), "BCAST"}
+	if l.prefix != "" {
+		args = append(args, "PREFIX", l.prefix)
+	}
+	if _, err := call(args...); err != nil {
+		return fmt.Errorf("client tracking: %w", err)
+	}
+	if _, err := call("SUBSCRIBE", invalidateChannel); err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+
+	l.cache.SetActive(true)
+	l.log.Debugf("Receiving client cache invalidations from %v", l.addr)
+
+	for {
+		res, err := readRESPReply(r)
+		if err != nil {
+			return err
+		}
+		msg, ok := res.([]interface{})
+		if !ok || len(msg) != 3 || msg[0] != "message" || msg[1] != invalidateChannel {
+			continue
+		}
+		// A nil array of keys indicates that the database was flushed.
+		rawKeys, _ := msg[2].([]interface{})
+		var keys []string
+		if rawKeys != nil {
+			keys = make([]string, 0, len(rawKeys))
+			for _, k := range rawKeys {
+				if s, ok := k.(string); ok {
+					keys = append(keys, s)
+				}
+			}
+		}
+		l.cache.Invalidate(keys)
+	}
+}
+
+func (l *invalidationListener) Close(ctx context.Context) error {
+	l.connMut.Lock()
+	l.cancel()
+	if l.conn != nil {
+		_ = l.conn.Close()
+	}
+	l.connMut.Unlock()
+
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func writeRESPCommand(w io.Writer, args ...string) error {
+	b := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		b = append(b, "$"+strconv.Itoa(len(a))+"\r\n"...)
+		b = append(b, a...)
+		b = append(b, "\r\n"...)
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// readRESPReply reads a single RESP2 reply, where bulk strings are returned as
+// strings, integers as int64 values, arrays as slices, and null bulk strings
+// and arrays as nil. Error replies are returned as errors.
+func readRESPReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply: %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, errors.New(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk string length: %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed array length: %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		elements := make([]interface{}, n)
+		for i := range elements {
+			if elements[i], err = readRESPReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return elements, nil
+	}
+	return nil, fmt.Errorf("unsupported reply type: %q", kind)
+}
@@ -0,0 +1,155 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestLocalCache(t *testing.T) {
+	l := newLocalCache(clientCacheConfig{ttl: time.Minute, maxItems: 2})
+
+	now := time.Unix(1600000000, 0)
+	l.nowFn = func() time.Time { return now }
+
+	_, seq, _ := l.Get("foo")
+	l.Store("foo", []byte("inactive"), seq)
+	_, _, exists := l.Get("foo")
+	assert.False(t, exists, "items are not stored whilst inactive")
+
+	l.SetActive(true)
+
+	_, seq, _ = l.Get("foo")
+	l.Store("foo", []byte("foo1"), seq)
+	v, _, exists := l.Get("foo")
+	require.True(t, exists)
+	assert.Equal(t, "foo1", string(v))
+
+	// A value read concurrently with an invalidation is not stored.
+	_, seq, _ = l.Get("bar")
+	l.Invalidate([]string{"baz"})
+	l.Store("bar", []byte("bar1"), seq)
+	_, _, exists = l.Get("bar")
+	assert.False(t, exists)
+
+	l.Invalidate([]string{"foo"})
+	_, seq, exists = l.Get("foo")
+	assert.False(t, exists)
+
+	l.Store("foo", []byte("foo2"), seq)
+	l.Store("bar", []byte("bar2"), seq)
+	now = now.Add(time.Minute)
+	_, _, exists = l.Get("foo")
+	assert.False(t, exists, "items expire after the ttl")
+
+	_, seq, _ = l.Get("baz")
+	l.Store("baz", []byte("baz1"), seq)
+	l.Invalidate(nil)
+	_, _, exists = l.Get("baz")
+	assert.False(t, exists)
+}
+
+func TestInvalidationListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	commands := make(chan []interface{}, 10)
+	pushes := make(chan string)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			cmd, err := readRESPReply(r)
+			if err != nil {
+				return
+			}
+			commands <- cmd.([]interface{})
+
+			var reply string
+			switch cmd.([]interface{})[0] {
+			case "CLIENT":
+				if cmd.([]interface{})[1] == "ID" {
+					reply = ":7\r\n"
+				} else {
+					reply = "+OK\r\n"
+				}
+			case "SUBSCRIBE":
+				reply = "*3\r\n$9\r\nsubscribe\r\n$20\r\n__redis__:invalidate\r\n:1\r\n"
+			default:
+				reply = "+OK\r\n"
+			}
+			if _, err := conn.Write([]byte(reply)); err != nil {
+				return
+			}
+			if cmd.([]interface{})[0] == "SUBSCRIBE" {
+				break
+			}
+		}
+		for p := range pushes {
+			if _, err := conn.Write([]byte(p)); err != nil {
+				return
+			}
+		}
+	}()
+
+	cache := newLocalCache(clientCacheConfig{ttl: time.Minute, maxItems: 10})
+	l := newInvalidationListener(&redis.UniversalOptions{
+		Addrs:    []string{ln.Addr().String()},
+		Username: "foouser",
+		Password: "foopass",
+	}, "pre_", cache, service.MockResources().Logger())
+
+	assert.Equal(t, []interface{}{"AUTH", "foouser", "foopass"}, <-commands)
+	assert.Equal(t, []interface{}{"CLIENT", "ID"}, <-commands)
+	assert.Equal(t, []interface{}{"CLIENT", "TRACKING", "ON", "REDIRECT", "7", "BCAST", "PREFIX", "pre_"}, <-commands)
+	assert.Equal(t, []interface{}{"SUBSCRIBE", "__redis__:invalidate"}, <-commands)
+
+	require.Eventually(t, func() bool {
+		_, seq, _ := cache.Get("pre_foo")
+		cache.Store("pre_foo", []byte("foo"), seq)
+		_, _, exists := cache.Get("pre_foo")
+		return exists
+	}, time.Second, time.Millisecond*10)
+
+	pushes <- "*3\r\n$7\r\nmessage\r\n$20\r\n__redis__:invalidate\r\n*1\r\n$7\r\npre_foo\r\n"
+	assert.Eventually(t, func() bool {
+		_, _, exists := cache.Get("pre_foo")
+		return !exists
+	}, time.Second, time.Millisecond*10)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+	require.NoError(t, l.Close(ctx))
+	close(pushes)
+
+	_, _, exists := cache.Get("pre_foo")
+	assert.False(t, exists)
+}
+
+func TestReadRESPReply(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("*4\r\n+OK\r\n:-3\r\n$-1\r\n*2\r\n$3\r\nfoo\r\n$0\r\n\r\n-ERR nope\r\n"))
+
+	v, err := readRESPReply(r)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"OK", int64(-3), nil, []interface{}{"foo", ""}}, v)
+
+	_, err = readRESPReply(r)
+	require.EqualError(t, err, "ERR nope")
+}
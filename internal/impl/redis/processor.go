@@ -28,7 +28,7 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
-		return processor.NewV2BatchedToV1Processor("redis", p, mgr.Metrics()), nil
+		return processor.NewV2BatchedToV1Processor("redis", p, mgr), nil
 	}, docs.ComponentSpec{
 		Name:   "redis",
 		Type:   docs.TypeProcessor,
@@ -13,7 +13,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/integration"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
@@ -83,7 +82,7 @@ func testRedisKeys(t *testing.T, client *redis.Client, url string) {
 	rp, err := newRedisProc(conf, mock.NewManager())
 	require.NoError(t, err)
 
-	r := processor.NewV2BatchedToV1Processor("redis", rp, metrics.Noop())
+	r := processor.NewV2BatchedToV1Processor("redis", rp, mock.NewManager())
 
 	for _, key := range []string{
 		"bar1", "bar2", "fooa", "foob", "baz1", "fooc",
@@ -126,7 +125,7 @@ func testRedisSAdd(t *testing.T, client *redis.Client, url string) {
 	rp, err := newRedisProc(conf, mock.NewManager())
 	require.NoError(t, err)
 
-	r := processor.NewV2BatchedToV1Processor("redis", rp, metrics.Noop())
+	r := processor.NewV2BatchedToV1Processor("redis", rp, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{
 		[]byte(`foo`),
@@ -188,7 +187,7 @@ func testRedisSCard(t *testing.T, client *redis.Client, url string) {
 	rp, err := newRedisProc(conf, mock.NewManager())
 	require.NoError(t, err)
 
-	r := processor.NewV2BatchedToV1Processor("redis", rp, metrics.Noop())
+	r := processor.NewV2BatchedToV1Processor("redis", rp, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{
 		[]byte(`doesntexist`),
@@ -222,7 +221,7 @@ func testRedisIncrby(t *testing.T, client *redis.Client, url string) {
 	rp, err := newRedisProc(conf, mock.NewManager())
 	require.NoError(t, err)
 
-	r := processor.NewV2BatchedToV1Processor("redis", rp, metrics.Noop())
+	r := processor.NewV2BatchedToV1Processor("redis", rp, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{
 		[]byte(`2`),
@@ -0,0 +1,134 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// ErrPoolClosed is returned when attempting to acquire a client from a pool
+// that has been closed.
+var ErrPoolClosed = errors.New("sftp client pool is closed")
+
+// ClientPool maintains a bounded pool of SFTP clients connected to the same
+// server, clients are created lazily and reused across calls.
+type ClientPool struct {
+	dial func() (*sftp.Client, error)
+
+	tokens chan struct{}
+
+	mut    sync.Mutex
+	idle   []*sftp.Client
+	open   map[*sftp.Client]struct{}
+	closed bool
+}
+
+// NewClientPool creates a pool that holds at most size clients at any given
+// time, new clients are created with the provided dial func.
+func NewClientPool(size int, dial func() (*sftp.Client, error)) *ClientPool {
+	if size < 1 {
+		size = 1
+	}
+	tokens := make(chan struct{}, size)
+	for i := 0; i < size; i++ {
+		tokens <- struct{}{}
+	}
+	return &ClientPool{
+		dial:   dial,
+		tokens: tokens,
+		open:   map[*sftp.Client]struct{}{},
+	}
+}
+
+// Acquire obtains a client from the pool, blocking until either a client is
+// available or the context is cancelled. Each acquired client must be returned
+// with Release.
+func (p *ClientPool) Acquire(ctx context.Context) (*sftp.Client, error) {
+	select {
+	case <-p.tokens:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mut.Lock()
+	if p.closed {
+		p.mut.Unlock()
+		p.tokens <- struct{}{}
+		return nil, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		client := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mut.Unlock()
+		return client, nil
+	}
+	p.mut.Unlock()
+
+	client, err := p.dial()
+	if err != nil {
+		p.tokens <- struct{}{}
+		return nil, err
+	}
+
+	p.mut.Lock()
+	if p.closed {
+		p.mut.Unlock()
+		client.Close()
+		p.tokens <- struct{}{}
+		return nil, ErrPoolClosed
+	}
+	p.open[client] = struct{}{}
+	p.mut.Unlock()
+	return client, nil
+}
+
+// Release returns a client to the pool along with the error (if any) from the
+// last operation performed with it. Clients that have lost their connection
+// are closed and replaced on a subsequent Acquire.
+func (p *ClientPool) Release(client *sftp.Client, err error) {
+	defer func() {
+		p.tokens <- struct{}{}
+	}()
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if p.closed || IsConnectionError(err) {
+		delete(p.open, client)
+		client.Close()
+		return
+	}
+	p.idle = append(p.idle, client)
+}
+
+// Close shuts down all clients of the pool. Clients that are currently
+// acquired are closed, and subsequent calls to Acquire return ErrPoolClosed.
+func (p *ClientPool) Close() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.closed = true
+	for client := range p.open {
+		client.Close()
+	}
+	p.open = map[*sftp.Client]struct{}{}
+	p.idle = nil
+}
+
+// IsConnectionError returns true if an error returned by an SFTP client
+// indicates that the underlying connection is no longer usable.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) ||
+		errors.Is(err, sftp.ErrSSHFxNoConnection) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, net.ErrClosed)
+}
@@ -0,0 +1,102 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func memDialer(t testing.TB) (func() (*sftp.Client, error), *int32) {
+	t.Helper()
+
+	handlers := sftp.InMemHandler()
+	var dials int32
+	return func() (*sftp.Client, error) {
+		atomic.AddInt32(&dials, 1)
+		serverConn, clientConn := net.Pipe()
+		server := sftp.NewRequestServer(serverConn, handlers)
+		go func() {
+			_ = server.Serve()
+		}()
+		t.Cleanup(func() {
+			server.Close()
+		})
+		return sftp.NewClientPipe(clientConn, clientConn)
+	}, &dials
+}
+
+func TestClientPoolReuse(t *testing.T) {
+	dial, dials := memDialer(t)
+	pool := NewClientPool(2, dial)
+	t.Cleanup(pool.Close)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	a, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	pool.Release(a, nil)
+
+	b, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	assert.Equal(t, int32(1), atomic.LoadInt32(dials))
+
+	c, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, b, c)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+
+	blockedCtx, blockedDone := context.WithTimeout(ctx, time.Millisecond*50)
+	defer blockedDone()
+	_, err = pool.Acquire(blockedCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	pool.Release(b, nil)
+	pool.Release(c, sftp.ErrSSHFxConnectionLost)
+
+	d, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, b, d)
+
+	e, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, c, e)
+	assert.Equal(t, int32(3), atomic.LoadInt32(dials))
+
+	pool.Release(d, nil)
+	pool.Release(e, nil)
+}
+
+func TestClientPoolClosed(t *testing.T) {
+	dial, _ := memDialer(t)
+	pool := NewClientPool(1, dial)
+
+	client, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	pool.Release(client, nil)
+
+	pool.Close()
+
+	_, err = pool.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestClientPoolDialError(t *testing.T) {
+	pool := NewClientPool(1, func() (*sftp.Client, error) {
+		return nil, errors.New("nope")
+	})
+	t.Cleanup(pool.Close)
+
+	for i := 0; i < 3; i++ {
+		_, err := pool.Acquire(context.Background())
+		assert.EqualError(t, err, "nope")
+	}
+}
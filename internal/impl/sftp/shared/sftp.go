@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
 
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
@@ -22,6 +25,17 @@ func CredentialsDocs() docs.FieldSpecs {
 		docs.FieldString("password", "The password for the username to connect to the SFTP server."),
 		docs.FieldString("private_key_file", "The private key for the username to connect to the SFTP server."),
 		docs.FieldString("private_key_pass", "Optional passphrase for private key."),
+		docs.FieldString(
+			"host_public_key",
+			"An optional public key that the server must present, either in authorized keys format or as a SHA256 fingerprint as printed by `ssh-keygen -l`. When set, connections to servers presenting any other key are rejected.",
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl",
+			"SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8",
+		).AtVersion("4.0.0").Advanced(),
+		docs.FieldString(
+			"known_hosts_file",
+			"An optional path to a known hosts file used to verify the key presented by the server. When set, connections to servers that are not listed or present a different key are rejected.",
+			"~/.ssh/known_hosts",
+		).AtVersion("4.0.0").Advanced(),
 	}
 }
 
@@ -31,6 +45,8 @@ type Credentials struct {
 	Password       string `json:"password" yaml:"password"`
 	PrivateKeyFile string `json:"private_key_file" yaml:"private_key_file"`
 	PrivateKeyPass string `json:"private_key_pass" yaml:"private_key_pass"`
+	HostPublicKey  string `json:"host_public_key" yaml:"host_public_key"`
+	KnownHostsFile string `json:"known_hosts_file" yaml:"known_hosts_file"`
 }
 
 // GetClient establishes a fresh sftp client from a set of credentials and an
@@ -47,16 +63,15 @@ func (c Credentials) GetClient(address string) (*sftp.Client, error) {
 		Port: port,
 	}
 
-	certCheck := &ssh.CertChecker{
-		IsHostAuthority: HostAuthCallback(),
-		IsRevoked:       CertCallback(server),
-		HostKeyFallback: HostCallback(server),
+	hostKeyCallback, err := c.hostKeyCallback(server)
+	if err != nil {
+		return nil, err
 	}
 
 	config := &ssh.ClientConfig{
 		User:            c.Username,
 		Auth:            []ssh.AuthMethod{},
-		HostKeyCallback: certCheck.CheckHostKey,
+		HostKeyCallback: hostKeyCallback,
 	}
 
 	// set password auth when provided
@@ -101,6 +116,82 @@ func (c Credentials) GetClient(address string) (*sftp.Client, error) {
 	return client, nil
 }
 
+// hostKeyCallback returns a callback for verifying the key presented by the
+// server. When neither a pinned key nor a known hosts file are configured any
+// key is accepted.
+func (c Credentials) hostKeyCallback(server *Server) (ssh.HostKeyCallback, error) {
+	var callbacks []ssh.HostKeyCallback
+
+	if c.HostPublicKey != "" {
+		pinned, err := PinnedHostKeyCallback(c.HostPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		callbacks = append(callbacks, pinned)
+	}
+
+	if c.KnownHostsFile != "" {
+		knownHostsPath, err := expandHome(c.KnownHostsFile)
+		if err != nil {
+			return nil, err
+		}
+		known, err := knownhosts.New(knownHostsPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read known hosts file: %w", err)
+		}
+		callbacks = append(callbacks, known)
+	}
+
+	if len(callbacks) == 0 {
+		certCheck := &ssh.CertChecker{
+			IsHostAuthority: HostAuthCallback(),
+			IsRevoked:       CertCallback(server),
+			HostKeyFallback: HostCallback(server),
+		}
+		return certCheck.CheckHostKey, nil
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		for _, cb := range callbacks {
+			if err := cb(hostname, remote, key); err != nil {
+				return err
+			}
+		}
+		return HostCallback(server)(hostname, remote, key)
+	}, nil
+}
+
+// PinnedHostKeyCallback returns a host key callback that only accepts a single
+// key, specified either in authorized keys format or as a SHA256 fingerprint.
+func PinnedHostKeyCallback(pinned string) (ssh.HostKeyCallback, error) {
+	pinned = strings.TrimSpace(pinned)
+	if strings.HasPrefix(pinned, "SHA256:") {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if fp := ssh.FingerprintSHA256(key); fp != pinned {
+				return fmt.Errorf("host key fingerprint %v does not match pinned fingerprint", fp)
+			}
+			return nil
+		}, nil
+	}
+
+	pinnedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pinned))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse host public key: %w", err)
+	}
+	return ssh.FixedHostKey(pinnedKey), nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
 // Server contains connection data for connecting to an SFTP server
 type Server struct {
 	Address   string          // host:port
@@ -0,0 +1,64 @@
+package shared
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func testHostKey(t testing.TB) ssh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	key, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	return key
+}
+
+func TestPinnedHostKeyCallback(t *testing.T) {
+	key, otherKey := testHostKey(t), testHostKey(t)
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}
+
+	for _, pinned := range []string{
+		string(ssh.MarshalAuthorizedKey(key)),
+		ssh.FingerprintSHA256(key),
+	} {
+		cb, err := PinnedHostKeyCallback(pinned)
+		require.NoError(t, err)
+
+		assert.NoError(t, cb("localhost:22", addr, key))
+		assert.Error(t, cb("localhost:22", addr, otherKey))
+	}
+
+	_, err := PinnedHostKeyCallback("not a key")
+	assert.Error(t, err)
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	key, otherKey := testHostKey(t), testHostKey(t)
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}
+
+	knownHostsPath := filepath.Join(t.TempDir(), "known_hosts")
+	require.NoError(t, os.WriteFile(knownHostsPath, []byte(knownhosts.Line([]string{"[localhost]:2222"}, key)+"\n"), 0o600))
+
+	cb, err := Credentials{KnownHostsFile: knownHostsPath}.hostKeyCallback(&Server{})
+	require.NoError(t, err)
+
+	assert.NoError(t, cb("localhost:2222", addr, key))
+	assert.Error(t, cb("localhost:2222", addr, otherKey))
+	assert.Error(t, cb("otherhost:2222", addr, key))
+
+	cb, err = Credentials{}.hostKeyCallback(&Server{})
+	require.NoError(t, err)
+	assert.NoError(t, cb("otherhost:2222", addr, otherKey))
+}
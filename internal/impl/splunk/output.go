@@ -0,0 +1,685 @@
+package splunk
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func splunkHECOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Sends messages as events or metrics to a [Splunk HTTP Event Collector](https://docs.splunk.com/Documentation/Splunk/latest/Data/UsetheHTTPEventCollector).").
+		Description(`
+The messages of a batch are sent as a single request to the event endpoint of the collector, and therefore it is recommended to configure a [batching policy](/docs/configuration/batching).
+
+When `+"`type`"+` is `+"`event`"+` the contents of each message become the event, where messages that are valid JSON are sent as structured events and all other messages are sent as strings. When `+"`type`"+` is `+"`metric`"+` each message must be a JSON object, where numeric values are sent as measurements named after their key and string values are sent as dimensions, following the multiple metric format of Splunk 8.0 and newer.
+
+### Indexer Acknowledgement
+
+By default a batch is acknowledged once the collector has accepted it, at which point the events have not necessarily been indexed. When the token of the collector has indexer acknowledgement enabled the field `+"`indexer_acknowledgement.enabled`"+` should be set to `+"`true`"+`, in which case requests are sent with a channel and the output polls the collector until the events of each request have been indexed before acknowledging the batch. The acknowledgements of all batches in flight are polled with a single request every `+"`poll_interval`"+`.
+
+A batch that isn't indexed within the `+"`indexer_acknowledgement.timeout`"+` is rejected and the input decides whether to redeliver it, which can result in duplicate events when the events were indexed after all.
+
+### Retries
+
+Requests that fail due to a connection error, a 5XX status code or a 429 status code are retried with a back off, and for 429 and 503 status codes the period given by the `+"`Retry-After`"+` header is respected. This includes the 503 status code returned by collectors that are busy. Once the retries of a request are exhausted the batch is rejected and the input decides whether to redeliver it. Requests rejected with any other 4XX status code are not retried, as sending the same events again would result in the same error.`).
+		Field(service.NewStringField("url").
+			Description("The base URL of the HTTP Event Collector.").
+			Example("https://localhost:8088")).
+		Field(service.NewStringField("token").
+			Description("The token of the HTTP Event Collector to authenticate with.")).
+		Field(service.NewStringEnumField("type", "event", "metric").
+			Description("Whether to send messages as events or metrics.").
+			Default("event")).
+		Field(service.NewInterpolatedStringField("index").
+			Description("An optional index to send events to, otherwise the default index of the token is used.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("host").
+			Description("An optional host of each event.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("source").
+			Description("An optional source of each event.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("sourcetype").
+			Description("An optional source type of each event.").
+			Default("").
+			Example("_json")).
+		Field(service.NewInterpolatedStringField("time").
+			Description("An optional time of each event, either as an RFC 3339 string or a number of seconds since the unix epoch. When not set the time at which the collector receives an event is used.").
+			Example(`${! json("timestamp") }`).
+			Optional()).
+		Field(service.NewBloblangField("fields").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) that results in an object of indexed fields of each event, which are added as dimensions of metrics. Fields with null values are omitted.").
+			Example(`root.region = meta("region")`).
+			Optional()).
+		Field(service.NewStringEnumField("compression", "none", "gzip").
+			Description("The compression to apply to the body of requests.").
+			Default("none").
+			Advanced()).
+		Field(service.NewObjectField("indexer_acknowledgement",
+			service.NewBoolField("enabled").
+				Description("Whether to wait for the events of each batch to be indexed before acknowledging it.").
+				Default(false),
+			service.NewStringField("channel").
+				Description("The channel identifier to send requests with, which must be a UUID. When empty a random channel is generated when the output starts.").
+				Default(""),
+			service.NewDurationField("poll_interval").
+				Description("The period between each poll of the acknowledgements of batches in flight.").
+				Default("1s"),
+			service.NewDurationField("timeout").
+				Description("The maximum period to wait for the events of a batch to be indexed before rejecting the batch.").
+				Default("2m"),
+		).Description("Wait for events to be indexed before acknowledging batches.").Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("A timeout for each request.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewIntField("max_retries").
+			Description("The maximum number of retry attempts for a request before the batch is rejected.").
+			Default(3).
+			Advanced()).
+		Field(service.NewBackOffField("backoff", false, nil).Advanced()).
+		Field(service.NewTLSField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Security Events", `
+Here we send security events to an index of Splunk, where the time of each event is taken from the event and each batch is only acknowledged once it has been indexed:`,
+			`
+output:
+  splunk_hec:
+    url: https://splunk:8088
+    token: ${SPLUNK_HEC_TOKEN}
+    index: security
+    sourcetype: _json
+    time: ${! json("timestamp") }
+    compression: gzip
+    indexer_acknowledgement:
+      enabled: true
+    batching:
+      count: 500
+      period: 1s
+`,
+		).
+		Example("Host Metrics", `
+Here we send host metrics consumed from Kafka as Splunk metrics, where the host of each message is a dimension and its numeric values are measurements:`,
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ host_metrics ]
+    consumer_group: benthos
+
+pipeline:
+  processors:
+    - bloblang: |
+        root."cpu.idle" = this.cpu.idle
+        root."mem.used" = this.mem.used
+        root.host = this.host
+
+output:
+  splunk_hec:
+    url: https://splunk:8088
+    token: ${SPLUNK_HEC_TOKEN}
+    type: metric
+    index: host_metrics
+    batching:
+      count: 1000
+      period: 5s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput("splunk_hec", splunkHECOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newSplunkHECOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type splunkRetryAfter struct {
+	statusCode int
+	retryAfter time.Duration
+	body       []byte
+}
+
+func (e *splunkRetryAfter) Error() string {
+	return fmt.Sprintf("received status code %v: %s", e.statusCode, e.body)
+}
+
+type splunkHECOutput struct {
+	log *service.Logger
+
+	eventURL    string
+	ackURL      string
+	token       string
+	isMetric    bool
+	index       *service.InterpolatedString
+	host        *service.InterpolatedString
+	source      *service.InterpolatedString
+	sourcetype  *service.InterpolatedString
+	time        *service.InterpolatedString
+	fields      *bloblang.Executor
+	gzip        bool
+	maxRetries  int
+	backoffConf backoff.ExponentialBackOff
+	client      *http.Client
+
+	ackEnabled      bool
+	channel         string
+	ackPollInterval time.Duration
+	ackTimeout      time.Duration
+
+	ackMut     sync.Mutex
+	ackPending map[int64]chan struct{}
+	pollCtx    context.Context
+	pollCancel func()
+	pollOnce   sync.Once
+}
+
+func newSplunkHECOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*splunkHECOutput, error) {
+	s := &splunkHECOutput{
+		log:        log,
+		ackPending: map[int64]chan struct{}{},
+	}
+	s.pollCtx, s.pollCancel = context.WithCancel(context.Background())
+
+	baseURL, err := conf.FieldString("url")
+	if err != nil {
+		return nil, err
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	s.eventURL = baseURL + "/services/collector/event"
+	s.ackURL = baseURL + "/services/collector/ack"
+
+	if s.token, err = conf.FieldString("token"); err != nil {
+		return nil, err
+	}
+	eventType, err := conf.FieldString("type")
+	if err != nil {
+		return nil, err
+	}
+	s.isMetric = eventType == "metric"
+
+	for _, f := range []struct {
+		name   string
+		target **service.InterpolatedString
+	}{
+		{"index", &s.index},
+		{"host", &s.host},
+		{"source", &s.source},
+		{"sourcetype", &s.sourcetype},
+	} {
+		if *f.target, err = conf.FieldInterpolatedString(f.name); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("time") {
+		if s.time, err = conf.FieldInterpolatedString("time"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("fields") {
+		if s.fields, err = conf.FieldBloblang("fields"); err != nil {
+			return nil, err
+		}
+	}
+
+	compression, err := conf.FieldString("compression")
+	if err != nil {
+		return nil, err
+	}
+	s.gzip = compression == "gzip"
+
+	if s.ackEnabled, err = conf.FieldBool("indexer_acknowledgement", "enabled"); err != nil {
+		return nil, err
+	}
+	if s.channel, err = conf.FieldString("indexer_acknowledgement", "channel"); err != nil {
+		return nil, err
+	}
+	if s.channel == "" {
+		s.channel = uuid.Must(uuid.NewV4()).String()
+	} else if _, err := uuid.FromString(s.channel); err != nil {
+		return nil, fmt.Errorf("indexer_acknowledgement.channel must be a UUID: %w", err)
+	}
+	if s.ackPollInterval, err = conf.FieldDuration("indexer_acknowledgement", "poll_interval"); err != nil {
+		return nil, err
+	}
+	if s.ackPollInterval <= 0 {
+		return nil, errors.New("indexer_acknowledgement.poll_interval must be larger than zero")
+	}
+	if s.ackTimeout, err = conf.FieldDuration("indexer_acknowledgement", "timeout"); err != nil {
+		return nil, err
+	}
+
+	if s.maxRetries, err = conf.FieldInt("max_retries"); err != nil {
+		return nil, err
+	}
+	boff, err := conf.FieldBackOff("backoff")
+	if err != nil {
+		return nil, err
+	}
+	s.backoffConf = *boff
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS("tls")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	s.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	return s, nil
+}
+
+func (s *splunkHECOutput) Connect(ctx context.Context) error {
+	if s.ackEnabled {
+		s.pollOnce.Do(func() {
+			go s.pollLoop()
+		})
+	}
+	return nil
+}
+
+func parseTime(str string) (float64, error) {
+	if f, err := strconv.ParseFloat(str, 64); err == nil {
+		return f, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return 0, err
+	}
+	return float64(t.UnixNano()) / float64(time.Second), nil
+}
+
+// indexedFields returns the indexed fields of a message resulting from the
+// fields mapping.
+func (s *splunkHECOutput) indexedFields(batch service.MessageBatch, i int) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if s.fields == nil {
+		return fields, nil
+	}
+
+	msg, err := batch.BloblangQuery(i, s.fields)
+	if err != nil {
+		return nil, fmt.Errorf("fields mapping failed: %w", err)
+	}
+	if msg == nil {
+		return fields, nil
+	}
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("fields mapping: %w", err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected fields mapping to result in an object, got %T", v)
+	}
+	for k, v := range obj {
+		if v != nil {
+			fields[k] = query.IToString(v)
+		}
+	}
+	return fields, nil
+}
+
+// metricFields adds the measurements and dimensions of a metric message to the
+// fields of its event.
+func metricFields(msg *service.Message, fields map[string]interface{}) error {
+	v, err := msg.AsStructured()
+	if err != nil {
+		return fmt.Errorf("metric must be a JSON object: %w", err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("metric must be a JSON object, got %T", v)
+	}
+
+	var measurements int
+	for k, v := range obj {
+		switch t := v.(type) {
+		case json.Number, float64, int64:
+			fields["metric_name:"+k] = t
+			measurements++
+		case string:
+			fields[k] = t
+		default:
+			return fmt.Errorf("metric value %q must be a number or string, got %T", k, v)
+		}
+	}
+	if measurements == 0 {
+		return errors.New("metric contains no numeric measurements")
+	}
+	return nil
+}
+
+func (s *splunkHECOutput) event(batch service.MessageBatch, i int) (map[string]interface{}, error) {
+	event := map[string]interface{}{}
+	for _, f := range []struct {
+		key   string
+		value *service.InterpolatedString
+	}{
+		{"index", s.index},
+		{"host", s.host},
+		{"source", s.source},
+		{"sourcetype", s.sourcetype},
+	} {
+		if v := batch.InterpolatedString(i, f.value); v != "" {
+			event[f.key] = v
+		}
+	}
+	if s.time != nil {
+		t, err := parseTime(batch.InterpolatedString(i, s.time))
+		if err != nil {
+			return nil, fmt.Errorf("time: %w", err)
+		}
+		event["time"] = t
+	}
+
+	fields, err := s.indexedFields(batch, i)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.isMetric {
+		if err := metricFields(batch[i], fields); err != nil {
+			return nil, err
+		}
+		event["event"] = "metric"
+	} else {
+		raw, err := batch[i].AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		if json.Valid(raw) {
+			event["event"] = json.RawMessage(raw)
+		} else {
+			event["event"] = string(raw)
+		}
+	}
+	if len(fields) > 0 {
+		event["fields"] = fields
+	}
+	return event, nil
+}
+
+func (s *splunkHECOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	var body bytes.Buffer
+	var w io.Writer = &body
+
+	var zw *gzip.Writer
+	if s.gzip {
+		zw = gzip.NewWriter(&body)
+		w = zw
+	}
+
+	enc := json.NewEncoder(w)
+	for i := range batch {
+		event, err := s.event(batch, i)
+		if err != nil {
+			return fmt.Errorf("message %v: %w", i, err)
+		}
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("message %v: %w", i, err)
+		}
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	ackID, err := s.send(ctx, body.Bytes())
+	if err != nil {
+		return err
+	}
+	if !s.ackEnabled {
+		return nil
+	}
+	if ackID == nil {
+		return errors.New("collector did not return an acknowledgement identifier, ensure that indexer acknowledgement is enabled for the token")
+	}
+	return s.awaitAck(ctx, *ackID)
+}
+
+//------------------------------------------------------------------------------
+
+func (s *splunkHECOutput) newRequest(ctx context.Context, url string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Splunk "+s.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Benthos")
+	req.Header.Set("X-Splunk-Request-Channel", s.channel)
+	return req, nil
+}
+
+func (s *splunkHECOutput) send(ctx context.Context, body []byte) (*int64, error) {
+	boff := s.backoffConf
+	boff.Reset()
+
+	for attempt := 0; ; attempt++ {
+		ackID, err := s.post(ctx, body)
+		if err == nil {
+			return ackID, nil
+		}
+		var pErr *backoff.PermanentError
+		if errors.As(err, &pErr) {
+			return nil, pErr.Err
+		}
+		if attempt >= s.maxRetries {
+			return nil, err
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return nil, err
+		}
+		var raErr *splunkRetryAfter
+		if errors.As(err, &raErr) && raErr.retryAfter > 0 {
+			wait = raErr.retryAfter
+		}
+
+		s.log.Debugf("Retrying Splunk HEC request in %v after error: %v", wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *splunkHECOutput) post(ctx context.Context, body []byte) (*int64, error) {
+	req, err := s.newRequest(ctx, s.eventURL, body)
+	if err != nil {
+		return nil, backoff.Permanent(err)
+	}
+	if s.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		var resObj struct {
+			AckID *int64 `json:"ackId"`
+		}
+		_ = json.Unmarshal(resBody, &resObj)
+		return resObj.AckID, nil
+	case res.StatusCode == http.StatusTooManyRequests, res.StatusCode == http.StatusServiceUnavailable:
+		raErr := &splunkRetryAfter{statusCode: res.StatusCode, body: bytes.TrimSpace(resBody)}
+		if secs, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && secs > 0 {
+			raErr.retryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, raErr
+	case res.StatusCode >= 500:
+		return nil, fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, bytes.TrimSpace(resBody))
+	}
+	return nil, backoff.Permanent(fmt.Errorf("events rejected with status code %v: %s", res.StatusCode, bytes.TrimSpace(resBody)))
+}
+
+//------------------------------------------------------------------------------
+
+// awaitAck blocks until the events of a request have been indexed according to
+// the poll loop.
+func (s *splunkHECOutput) awaitAck(ctx context.Context, ackID int64) error {
+	indexed := make(chan struct{})
+	s.ackMut.Lock()
+	s.ackPending[ackID] = indexed
+	s.ackMut.Unlock()
+
+	defer func() {
+		s.ackMut.Lock()
+		delete(s.ackPending, ackID)
+		s.ackMut.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+	if s.ackTimeout > 0 {
+		timer := time.NewTimer(s.ackTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-indexed:
+		return nil
+	case <-timeout:
+		return fmt.Errorf("timed out waiting for events of acknowledgement %v to be indexed", ackID)
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.pollCtx.Done():
+		return errors.New("output closed before events were indexed")
+	}
+}
+
+func (s *splunkHECOutput) pollLoop() {
+	ticker := time.NewTicker(s.ackPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.pollCtx.Done():
+			return
+		}
+		if err := s.pollAcks(s.pollCtx); err != nil && s.pollCtx.Err() == nil {
+			s.log.Debugf("Failed to poll Splunk HEC indexer acknowledgements: %v", err)
+		}
+	}
+}
+
+// pollAcks queries the acknowledgements of all requests in flight with a
+// single request, releasing those that have been indexed.
+func (s *splunkHECOutput) pollAcks(ctx context.Context) error {
+	s.ackMut.Lock()
+	ids := make([]int64, 0, len(s.ackPending))
+	for id := range s.ackPending {
+		ids = append(ids, id)
+	}
+	s.ackMut.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	reqBody, err := json.Marshal(map[string]interface{}{"acks": ids})
+	if err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, s.ackURL, reqBody)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("received unexpected status code %v: %s", res.StatusCode, bytes.TrimSpace(resBody))
+	}
+
+	var resObj struct {
+		Acks map[string]bool `json:"acks"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&resObj); err != nil {
+		return err
+	}
+
+	s.ackMut.Lock()
+	for idStr, indexed := range resObj.Acks {
+		if !indexed {
+			continue
+		}
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if ch, exists := s.ackPending[id]; exists {
+			close(ch)
+			delete(s.ackPending, id)
+		}
+	}
+	s.ackMut.Unlock()
+	return nil
+}
+
+func (s *splunkHECOutput) Close(ctx context.Context) error {
+	s.pollCancel()
+	s.client.CloseIdleConnections()
+	return nil
+}
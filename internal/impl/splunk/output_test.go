@@ -0,0 +1,284 @@
+package splunk
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testSplunkHECOutput(t *testing.T, confStr string) *splunkHECOutput {
+	t.Helper()
+
+	conf, err := splunkHECOutputConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	s, err := newSplunkHECOutputFromConfig(conf, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = s.Close(context.Background())
+	})
+	return s
+}
+
+type hecServer struct {
+	mut      sync.Mutex
+	events   [][]map[string]interface{}
+	channels []string
+	statuses []int
+	nextAck  int64
+	indexed  map[int64]bool
+	polls    int
+}
+
+func (h *hecServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Splunk footoken", r.Header.Get("Authorization"))
+
+		h.mut.Lock()
+		defer h.mut.Unlock()
+
+		if r.URL.Path == "/services/collector/ack" {
+			h.polls++
+			var req struct {
+				Acks []int64 `json:"acks"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			res := map[string]bool{}
+			for _, id := range req.Acks {
+				res[jsonInt(id)] = h.indexed[id]
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"acks": res})
+			return
+		}
+
+		require.Equal(t, "/services/collector/event", r.URL.Path)
+		h.channels = append(h.channels, r.Header.Get("X-Splunk-Request-Channel"))
+
+		if len(h.statuses) > 0 {
+			status := h.statuses[0]
+			h.statuses = h.statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = zr
+		}
+
+		var events []map[string]interface{}
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			var e map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+			events = append(events, e)
+		}
+		h.events = append(h.events, events)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"text":  "Success",
+			"code":  0,
+			"ackId": h.nextAck,
+		})
+		h.nextAck++
+	}
+}
+
+func jsonInt(i int64) string {
+	b, _ := json.Marshal(i)
+	return string(b)
+}
+
+func TestSplunkHECOutputEvents(t *testing.T) {
+	srv := &hecServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s := testSplunkHECOutput(t, `
+url: `+ts.URL+`
+token: footoken
+index: security
+sourcetype: _json
+time: ${! meta("time") }
+fields: 'root.region = meta("region")'
+compression: gzip
+`)
+	require.NoError(t, s.Connect(context.Background()))
+
+	first := service.NewMessage([]byte(`{"user":"foo"}`))
+	first.MetaSet("time", "1600000000.5")
+	first.MetaSet("region", "eu")
+
+	second := service.NewMessage([]byte(`not json`))
+	second.MetaSet("time", "2020-09-13T12:26:40Z")
+
+	require.NoError(t, s.WriteBatch(context.Background(), service.MessageBatch{first, second}))
+
+	require.Len(t, srv.events, 1)
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"index":      "security",
+			"sourcetype": "_json",
+			"time":       1600000000.5,
+			"event":      map[string]interface{}{"user": "foo"},
+			"fields":     map[string]interface{}{"region": "eu"},
+		},
+		{
+			"index":      "security",
+			"sourcetype": "_json",
+			"time":       1600000000.0,
+			"event":      "not json",
+		},
+	}, srv.events[0])
+}
+
+func TestSplunkHECOutputMetrics(t *testing.T) {
+	srv := &hecServer{}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s := testSplunkHECOutput(t, `
+url: `+ts.URL+`
+token: footoken
+type: metric
+`)
+
+	require.NoError(t, s.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"cpu.idle":95.5,"mem.used":1024,"host":"foo"}`)),
+	}))
+	require.Len(t, srv.events, 1)
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"event": "metric",
+			"fields": map[string]interface{}{
+				"metric_name:cpu.idle": 95.5,
+				"metric_name:mem.used": 1024.0,
+				"host":                 "foo",
+			},
+		},
+	}, srv.events[0])
+
+	err := s.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"host":"foo"}`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no numeric measurements")
+}
+
+func TestSplunkHECOutputRetries(t *testing.T) {
+	srv := &hecServer{
+		statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+	}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s := testSplunkHECOutput(t, `
+url: `+ts.URL+`
+token: footoken
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+`)
+
+	require.NoError(t, s.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`foo`)),
+	}))
+	assert.Len(t, srv.channels, 3)
+	assert.Len(t, srv.events, 1)
+
+	srv.statuses = []int{http.StatusBadRequest}
+	err := s.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`foo`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected with status code 400")
+	assert.Len(t, srv.channels, 4)
+}
+
+func TestSplunkHECOutputIndexerAck(t *testing.T) {
+	srv := &hecServer{
+		indexed: map[int64]bool{},
+	}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s := testSplunkHECOutput(t, `
+url: `+ts.URL+`
+token: footoken
+indexer_acknowledgement:
+  enabled: true
+  channel: 0ce1d2a6-9d51-4f1b-a2a4-7f3f6a2f0b1e
+  poll_interval: 5ms
+`)
+	require.NoError(t, s.Connect(context.Background()))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.WriteBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`foo`)),
+		})
+	}()
+
+	// The batch must not be acknowledged until its events are indexed.
+	assert.Eventually(t, func() bool {
+		srv.mut.Lock()
+		defer srv.mut.Unlock()
+		return srv.polls >= 2
+	}, time.Second, time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("batch acknowledged before being indexed: %v", err)
+	default:
+	}
+
+	srv.mut.Lock()
+	srv.indexed[0] = true
+	srv.mut.Unlock()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for batch to be acknowledged")
+	}
+	assert.Equal(t, []string{"0ce1d2a6-9d51-4f1b-a2a4-7f3f6a2f0b1e"}, srv.channels)
+}
+
+func TestSplunkHECOutputIndexerAckTimeout(t *testing.T) {
+	srv := &hecServer{
+		indexed: map[int64]bool{},
+	}
+	ts := httptest.NewServer(srv.handler(t))
+	defer ts.Close()
+
+	s := testSplunkHECOutput(t, `
+url: `+ts.URL+`
+token: footoken
+indexer_acknowledgement:
+  enabled: true
+  poll_interval: 5ms
+  timeout: 50ms
+`)
+	require.NoError(t, s.Connect(context.Background()))
+
+	err := s.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`foo`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+}
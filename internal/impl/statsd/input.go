@@ -0,0 +1,512 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func statsdInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Listens for [StatsD](https://github.com/statsd/statsd) and [DogStatsD](https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/) metrics over UDP.").
+		Description(`
+Each metric of a datagram is parsed into a JSON object of the form:
+
+`+"```json"+`
+{
+  "name": "page.views",
+  "type": "counter",
+  "value": 1,
+  "sample_rate": 0.5,
+  "tags": { "env": "prod", "canary": "" }
+}
+`+"```"+`
+
+Where `+"`type`"+` is one of `+"`counter`, `gauge`, `timer`, `histogram`, `distribution` or `set`"+`, the value of a set is a string and the value of all other types is a number. Gauges that are relative (prefixed with `+"`+` or `-`"+`) have the field `+"`relative`"+` set to `+"`true`"+`. Tags are parsed from the DogStatsD format, and tags without a value are given an empty string. DogStatsD events and service checks are ignored.
+
+### Aggregation
+
+When `+"`flush_period`"+` is set metrics are aggregated by their name, type and tags and a batch of the aggregated metrics is emitted at the end of each period, in the same way as a StatsD server would before forwarding them:
+
+- Counters are summed, taking into account their sample rates
+- Gauges have their latest value, with relative gauges being added to it
+- Sets have a value of the number of unique values received
+- Timers, histograms and distributions have the fields `+"`count`, `min`, `max`, `sum` and `mean`"+` instead of a value
+
+Metrics that aren't received during a period are not emitted.`).
+		Field(service.NewStringField("address").
+			Description("The address to listen for datagrams on.").
+			Default("0.0.0.0:8125")).
+		Field(service.NewDurationField("flush_period").
+			Description("The period of time over which to aggregate metrics before flushing them as a batch. Set to `0s` in order to emit each metric as it is received, where the metrics of each datagram are a batch.").
+			Default("0s").
+			Example("10s")).
+		Example("Relabelling Metrics", `
+Here we aggregate StatsD metrics over ten second periods, add a tag to each and forward them on to a StatsD server:`,
+			`
+input:
+  statsd:
+    address: 0.0.0.0:8125
+    flush_period: 10s
+
+pipeline:
+  processors:
+    - bloblang: |
+        root = this
+        root.tags.region = "eu-west-1"
+        root.value = this.value | this.mean
+
+output:
+  socket:
+    network: udp
+    address: statsd:8125
+    codec: lines
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput("statsd", statsdInputConfig(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+		i, err := newStatsdInputFromConfig(conf, mgr.Logger())
+		if err != nil {
+			return nil, err
+		}
+		return service.AutoRetryNacksBatched(i), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type statsdMetric struct {
+	name       string
+	mType      string
+	value      float64
+	setValue   string
+	relative   bool
+	sampleRate float64
+	tags       map[string]string
+}
+
+var metricTypes = map[string]string{
+	"c":  "counter",
+	"g":  "gauge",
+	"ms": "timer",
+	"h":  "histogram",
+	"d":  "distribution",
+	"s":  "set",
+}
+
+func parseTags(s string) map[string]string {
+	tags := map[string]string{}
+	for _, t := range strings.Split(s, ",") {
+		if t == "" {
+			continue
+		}
+		if i := strings.IndexByte(t, ':'); i >= 0 {
+			tags[t[:i]] = t[i+1:]
+		} else {
+			tags[t] = ""
+		}
+	}
+	return tags
+}
+
+// parseLine parses a line of a StatsD datagram, which can contain multiple
+// values in the DogStatsD format.
+func parseLine(line string) ([]statsdMetric, error) {
+	sections := strings.Split(line, "|")
+	if len(sections) < 2 {
+		return nil, errors.New("expected a metric type")
+	}
+
+	nameEnd := strings.IndexByte(sections[0], ':')
+	if nameEnd <= 0 {
+		return nil, errors.New("expected a metric name followed by a value")
+	}
+	name, values := sections[0][:nameEnd], sections[0][nameEnd+1:]
+
+	mType, exists := metricTypes[sections[1]]
+	if !exists {
+		return nil, fmt.Errorf("unrecognised metric type: %v", sections[1])
+	}
+
+	sampleRate := 1.0
+	tags := map[string]string{}
+	for _, s := range sections[2:] {
+		switch {
+		case strings.HasPrefix(s, "@"):
+			var err error
+			if sampleRate, err = strconv.ParseFloat(s[1:], 64); err != nil || sampleRate <= 0 || sampleRate > 1 {
+				return nil, fmt.Errorf("invalid sample rate: %v", s[1:])
+			}
+		case strings.HasPrefix(s, "#"):
+			tags = parseTags(s[1:])
+		}
+	}
+
+	var metrics []statsdMetric
+	for _, v := range strings.Split(values, ":") {
+		m := statsdMetric{
+			name:       name,
+			mType:      mType,
+			sampleRate: sampleRate,
+			tags:       tags,
+		}
+		if mType == "set" {
+			m.setValue = v
+		} else {
+			if mType == "gauge" && (strings.HasPrefix(v, "+") || strings.HasPrefix(v, "-")) {
+				m.relative = true
+			}
+			var err error
+			if m.value, err = strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("invalid metric value: %v", v)
+			}
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (m statsdMetric) toObject() map[string]interface{} {
+	tags := make(map[string]interface{}, len(m.tags))
+	for k, v := range m.tags {
+		tags[k] = v
+	}
+	obj := map[string]interface{}{
+		"name":        m.name,
+		"type":        m.mType,
+		"sample_rate": m.sampleRate,
+		"tags":        tags,
+	}
+	if m.mType == "set" {
+		obj["value"] = m.setValue
+	} else {
+		obj["value"] = m.value
+	}
+	if m.relative {
+		obj["relative"] = true
+	}
+	return obj
+}
+
+//------------------------------------------------------------------------------
+
+type aggregate struct {
+	metric statsdMetric
+
+	set   map[string]struct{}
+	count float64
+	min   float64
+	max   float64
+	sum   float64
+}
+
+func metricKey(m statsdMetric) string {
+	keys := make([]string, 0, len(m.tags))
+	for k := range m.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(m.mType)
+	b.WriteByte('|')
+	b.WriteString(m.name)
+	for _, k := range keys {
+		b.WriteByte('|')
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(m.tags[k])
+	}
+	return b.String()
+}
+
+// aggregator accumulates metrics by their name, type and tags.
+type aggregator struct {
+	aggs    map[string]*aggregate
+	ordered []*aggregate
+	gauges  map[string]float64
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		aggs:   map[string]*aggregate{},
+		gauges: map[string]float64{},
+	}
+}
+
+func (a *aggregator) add(m statsdMetric) {
+	key := metricKey(m)
+	agg, exists := a.aggs[key]
+	if !exists {
+		agg = &aggregate{
+			metric: m,
+			min:    math.Inf(1),
+			max:    math.Inf(-1),
+		}
+		if m.mType == "gauge" {
+			// Relative gauges modify the last value of the previous period.
+			agg.metric.value = a.gauges[key]
+		}
+		a.aggs[key] = agg
+		a.ordered = append(a.ordered, agg)
+	}
+
+	switch m.mType {
+	case "counter":
+		agg.sum += m.value / m.sampleRate
+	case "gauge":
+		if m.relative {
+			agg.metric.value += m.value
+		} else {
+			agg.metric.value = m.value
+		}
+		a.gauges[key] = agg.metric.value
+	case "set":
+		if agg.set == nil {
+			agg.set = map[string]struct{}{}
+		}
+		agg.set[m.setValue] = struct{}{}
+	default:
+		agg.count += 1 / m.sampleRate
+		agg.sum += m.value
+		agg.min = math.Min(agg.min, m.value)
+		agg.max = math.Max(agg.max, m.value)
+	}
+}
+
+// flush returns the aggregated metrics as objects and resets the aggregator.
+func (a *aggregator) flush() []map[string]interface{} {
+	objs := make([]map[string]interface{}, 0, len(a.ordered))
+	for _, agg := range a.ordered {
+		tags := make(map[string]interface{}, len(agg.metric.tags))
+		for k, v := range agg.metric.tags {
+			tags[k] = v
+		}
+		obj := map[string]interface{}{
+			"name": agg.metric.name,
+			"type": agg.metric.mType,
+			"tags": tags,
+		}
+		switch agg.metric.mType {
+		case "counter":
+			obj["value"] = agg.sum
+		case "gauge":
+			obj["value"] = agg.metric.value
+		case "set":
+			obj["value"] = len(agg.set)
+		default:
+			obj["count"] = agg.count
+			obj["min"] = agg.min
+			obj["max"] = agg.max
+			obj["sum"] = agg.sum
+			obj["mean"] = agg.sum / agg.count
+		}
+		objs = append(objs, obj)
+	}
+	a.aggs = map[string]*aggregate{}
+	a.ordered = nil
+	return objs
+}
+
+//------------------------------------------------------------------------------
+
+type statsdInput struct {
+	log *service.Logger
+
+	address     string
+	flushPeriod time.Duration
+
+	connMut   sync.Mutex
+	conn      net.PacketConn
+	batchChan chan service.MessageBatch
+	shutChan  chan struct{}
+	closeOnce sync.Once
+}
+
+func newStatsdInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*statsdInput, error) {
+	s := &statsdInput{
+		log:       log,
+		batchChan: make(chan service.MessageBatch),
+		shutChan:  make(chan struct{}),
+	}
+
+	var err error
+	if s.address, err = conf.FieldString("address"); err != nil {
+		return nil, err
+	}
+	if s.flushPeriod, err = conf.FieldDuration("flush_period"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func objsToBatch(objs []map[string]interface{}) service.MessageBatch {
+	batch := make(service.MessageBatch, 0, len(objs))
+	for _, obj := range objs {
+		msg := service.NewMessage(nil)
+		msg.SetStructured(obj)
+		batch = append(batch, msg)
+	}
+	return batch
+}
+
+func (s *statsdInput) Connect(ctx context.Context) error {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.conn != nil {
+		return nil
+	}
+
+	conn, err := net.ListenPacket("udp", s.address)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	metricsChan := make(chan []statsdMetric)
+	go s.readLoop(conn, metricsChan)
+	if s.flushPeriod > 0 {
+		go s.aggregateLoop(metricsChan)
+	} else {
+		go s.passthroughLoop(metricsChan)
+	}
+
+	s.log.Infof("Receiving StatsD metrics at: %v", conn.LocalAddr())
+	return nil
+}
+
+func (s *statsdInput) readLoop(conn net.PacketConn, metricsChan chan<- []statsdMetric) {
+	defer close(metricsChan)
+
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.shutChan:
+			default:
+				s.log.Errorf("Failed to read datagram: %v", err)
+			}
+			return
+		}
+
+		var metrics []statsdMetric
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "_e{") || strings.HasPrefix(line, "_sc|") {
+				continue
+			}
+			lineMetrics, err := parseLine(line)
+			if err != nil {
+				s.log.Debugf("Failed to parse metric '%v': %v", line, err)
+				continue
+			}
+			metrics = append(metrics, lineMetrics...)
+		}
+		if len(metrics) == 0 {
+			continue
+		}
+
+		select {
+		case metricsChan <- metrics:
+		case <-s.shutChan:
+			return
+		}
+	}
+}
+
+func (s *statsdInput) passthroughLoop(metricsChan <-chan []statsdMetric) {
+	for metrics := range metricsChan {
+		objs := make([]map[string]interface{}, 0, len(metrics))
+		for _, m := range metrics {
+			objs = append(objs, m.toObject())
+		}
+		select {
+		case s.batchChan <- objsToBatch(objs):
+		case <-s.shutChan:
+			return
+		}
+	}
+}
+
+func (s *statsdInput) aggregateLoop(metricsChan <-chan []statsdMetric) {
+	agg := newAggregator()
+
+	ticker := time.NewTicker(s.flushPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case metrics, open := <-metricsChan:
+			if !open {
+				return
+			}
+			for _, m := range metrics {
+				agg.add(m)
+			}
+		case <-ticker.C:
+			objs := agg.flush()
+			if len(objs) == 0 {
+				continue
+			}
+			select {
+			case s.batchChan <- objsToBatch(objs):
+			case <-s.shutChan:
+				return
+			}
+		case <-s.shutChan:
+			return
+		}
+	}
+}
+
+func (s *statsdInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	s.connMut.Lock()
+	connected := s.conn != nil
+	s.connMut.Unlock()
+
+	if !connected {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case batch := <-s.batchChan:
+		return batch, func(ctx context.Context, err error) error {
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case <-s.shutChan:
+		return nil, nil, service.ErrEndOfInput
+	}
+}
+
+func (s *statsdInput) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		close(s.shutChan)
+	})
+
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestStatsdParseLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected []map[string]interface{}
+		errs     bool
+	}{
+		{
+			line: "page.views:1|c",
+			expected: []map[string]interface{}{
+				{"name": "page.views", "type": "counter", "value": 1.0, "sample_rate": 1.0, "tags": map[string]interface{}{}},
+			},
+		},
+		{
+			line: "page.views:2|c|@0.5|#env:prod,canary",
+			expected: []map[string]interface{}{
+				{"name": "page.views", "type": "counter", "value": 2.0, "sample_rate": 0.5, "tags": map[string]interface{}{"env": "prod", "canary": ""}},
+			},
+		},
+		{
+			line: "queue.size:-3|g",
+			expected: []map[string]interface{}{
+				{"name": "queue.size", "type": "gauge", "value": -3.0, "sample_rate": 1.0, "relative": true, "tags": map[string]interface{}{}},
+			},
+		},
+		{
+			line: "request.time:10:20.5|ms|#url:/foo:bar",
+			expected: []map[string]interface{}{
+				{"name": "request.time", "type": "timer", "value": 10.0, "sample_rate": 1.0, "tags": map[string]interface{}{"url": "/foo:bar"}},
+				{"name": "request.time", "type": "timer", "value": 20.5, "sample_rate": 1.0, "tags": map[string]interface{}{"url": "/foo:bar"}},
+			},
+		},
+		{
+			line: "users:foo|s|c:abc123",
+			expected: []map[string]interface{}{
+				{"name": "users", "type": "set", "value": "foo", "sample_rate": 1.0, "tags": map[string]interface{}{}},
+			},
+		},
+		{line: "page.views:1", errs: true},
+		{line: "page.views|c", errs: true},
+		{line: "page.views:1|x", errs: true},
+		{line: "page.views:nope|c", errs: true},
+		{line: "page.views:1|c|@2", errs: true},
+	}
+
+	for _, test := range tests {
+		metrics, err := parseLine(test.line)
+		if test.errs {
+			assert.Error(t, err, test.line)
+			continue
+		}
+		require.NoError(t, err, test.line)
+
+		var objs []map[string]interface{}
+		for _, m := range metrics {
+			objs = append(objs, m.toObject())
+		}
+		assert.Equal(t, test.expected, objs, test.line)
+	}
+}
+
+func TestStatsdAggregator(t *testing.T) {
+	agg := newAggregator()
+	for _, line := range []string{
+		"hits:1|c|#a:b",
+		"hits:2|c|@0.5|#a:b",
+		"hits:1|c|#a:c",
+		"temp:10|g",
+		"temp:+5|g",
+		"users:foo|s",
+		"users:bar|s",
+		"users:foo|s",
+		"latency:10:30|ms",
+		"latency:20|ms|@0.5",
+	} {
+		metrics, err := parseLine(line)
+		require.NoError(t, err, line)
+		for _, m := range metrics {
+			agg.add(m)
+		}
+	}
+
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "hits", "type": "counter", "value": 5.0, "tags": map[string]interface{}{"a": "b"}},
+		{"name": "hits", "type": "counter", "value": 1.0, "tags": map[string]interface{}{"a": "c"}},
+		{"name": "temp", "type": "gauge", "value": 15.0, "tags": map[string]interface{}{}},
+		{"name": "users", "type": "set", "value": 2, "tags": map[string]interface{}{}},
+		{
+			"name": "latency", "type": "timer", "tags": map[string]interface{}{},
+			"count": 4.0, "min": 10.0, "max": 30.0, "sum": 60.0, "mean": 15.0,
+		},
+	}, agg.flush())
+
+	assert.Empty(t, agg.flush())
+
+	// Relative gauges continue from the value of the previous period.
+	metrics, err := parseLine("temp:-2|g")
+	require.NoError(t, err)
+	agg.add(metrics[0])
+	assert.Equal(t, []map[string]interface{}{
+		{"name": "temp", "type": "gauge", "value": 13.0, "tags": map[string]interface{}{}},
+	}, agg.flush())
+}
+
+func testStatsdInput(t *testing.T, confStr string) (*statsdInput, net.Conn) {
+	t.Helper()
+
+	conf, err := statsdInputConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	s, err := newStatsdInputFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Connect(context.Background()))
+	t.Cleanup(func() {
+		require.NoError(t, s.Close(context.Background()))
+	})
+
+	conn, err := net.Dial("udp", s.conn.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return s, conn
+}
+
+func readStructured(t *testing.T, s *statsdInput) []interface{} {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	batch, ack, err := s.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ack(ctx, nil))
+
+	var objs []interface{}
+	for _, m := range batch {
+		v, err := m.AsStructured()
+		require.NoError(t, err)
+		objs = append(objs, v)
+	}
+	return objs
+}
+
+func TestStatsdInputPassthrough(t *testing.T) {
+	s, conn := testStatsdInput(t, `
+address: 127.0.0.1:0
+`)
+
+	_, err := conn.Write([]byte("foo:1|c\n_e{5,4}:title|text\nnope\nbar:2|g|#env:prod\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "foo", "type": "counter", "value": 1.0, "sample_rate": 1.0, "tags": map[string]interface{}{}},
+		map[string]interface{}{"name": "bar", "type": "gauge", "value": 2.0, "sample_rate": 1.0, "tags": map[string]interface{}{"env": "prod"}},
+	}, readStructured(t, s))
+}
+
+func TestStatsdInputAggregate(t *testing.T) {
+	s, conn := testStatsdInput(t, `
+address: 127.0.0.1:0
+flush_period: 100ms
+`)
+
+	for i := 0; i < 3; i++ {
+		_, err := conn.Write([]byte("foo:1|c"))
+		require.NoError(t, err)
+	}
+
+	var total float64
+	for total < 3 {
+		objs := readStructured(t, s)
+		require.Len(t, objs, 1)
+		obj := objs[0].(map[string]interface{})
+		assert.Equal(t, "foo", obj["name"])
+		total += obj["value"].(float64)
+	}
+	assert.Equal(t, 3.0, total)
+}
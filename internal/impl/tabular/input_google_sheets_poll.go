@@ -0,0 +1,130 @@
+package tabular
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func googleSheetsPollInputConfig() *service.ConfigSpec {
+	return withPollerFields(service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Periodically reads a range of a [Google Sheets](https://www.google.com/sheets/about/) spreadsheet and emits the rows that have been added or changed since the previous poll.").
+		Description(`
+The first row of the range must be a header naming each column. Values are read as they are displayed within the spreadsheet, and empty cells at the end of a row are read as empty strings. This input is intended for reference data that is maintained by hand, where only the rows that change are of interest.
+
+### Credentials
+
+By default Benthos will use a shared credentials file when connecting to the Sheets API. The spreadsheet must be shared with the account of the credentials. You can find out more [in this document](/docs/guides/cloud/gcp).
+`+pollerDescription)).
+		Field(service.NewStringField("spreadsheet_id").
+			Description("The ID of the spreadsheet, which can be found within its URL.").
+			Example("1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms")).
+		Field(service.NewStringField("range").
+			Description("The range of the spreadsheet to read in [A1 notation](https://developers.google.com/sheets/api/guides/concepts#cell). A sheet name on its own reads the entire sheet.").
+			Example("Sheet1").
+			Example("Prices!A1:D")).
+		Example("Feature Flags", `
+Here we poll a sheet of feature flags maintained by an operations team every minute, and publish each flag that is added or changed to a NATS subject:`,
+			`
+input:
+  google_sheets_poll:
+    spreadsheet_id: 1BxiMVs0XRA5nFMdKvBdBZjgmUUqptlbs74OgvE2upms
+    range: Flags
+    key_columns: [ flag ]
+    interval: 1m
+    cache: snapshots
+
+output:
+  nats:
+    urls: [ nats://localhost:4222 ]
+    subject: flags.${! json("flag") }
+
+cache_resources:
+  - label: snapshots
+    file:
+      directory: /var/lib/benthos/snapshots
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"google_sheets_poll", googleSheetsPollInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newGoogleSheetsPollInputFromConfig(conf, mgr, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type googleSheetsFetcher struct {
+	spreadsheetID string
+	readRange     string
+	opts          []option.ClientOption
+
+	srvMut sync.Mutex
+	srv    *sheets.Service
+}
+
+func newGoogleSheetsPollInputFromConfig(conf *service.ParsedConfig, mgr cacheProvider, log *service.Logger) (*poller, error) {
+	f := &googleSheetsFetcher{
+		opts: []option.ClientOption{option.WithScopes(sheets.SpreadsheetsReadonlyScope)},
+	}
+
+	var err error
+	if f.spreadsheetID, err = conf.FieldString("spreadsheet_id"); err != nil {
+		return nil, err
+	}
+	if f.readRange, err = conf.FieldString("range"); err != nil {
+		return nil, err
+	}
+	return newPollerFromConfig(conf, "google_sheets_poll:"+f.spreadsheetID+":"+f.readRange, f.fetch, mgr, log)
+}
+
+// sheetRecords converts the values of a range into records, where the values
+// are formatted strings as the range is read with the default value render
+// option.
+func sheetRecords(values [][]interface{}) [][]string {
+	records := make([][]string, len(values))
+	for i, row := range values {
+		record := make([]string, len(row))
+		for j, v := range row {
+			if v != nil {
+				record[j] = fmt.Sprint(v)
+			}
+		}
+		records[i] = record
+	}
+	return records
+}
+
+func (f *googleSheetsFetcher) fetch(ctx context.Context) ([][]string, error) {
+	f.srvMut.Lock()
+	if f.srv == nil {
+		srv, err := sheets.NewService(context.Background(), f.opts...)
+		if err != nil {
+			f.srvMut.Unlock()
+			return nil, fmt.Errorf("failed to create sheets client: %w", err)
+		}
+		f.srv = srv
+	}
+	srv := f.srv
+	f.srvMut.Unlock()
+
+	res, err := srv.Spreadsheets.Values.Get(f.spreadsheetID, f.readRange).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spreadsheet range: %w", err)
+	}
+	return sheetRecords(res.Values), nil
+}
@@ -0,0 +1,43 @@
+package tabular
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestGoogleSheetsFetch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v4/spreadsheets/foo/values/Prices!A1:C", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+  "range": "Prices!A1:C3",
+  "majorDimension": "ROWS",
+  "values": [
+    ["sku", "price", "stock"],
+    ["a", "$10.00", 5],
+    ["b", "$12.50"]
+  ]
+}`))
+	}))
+	defer ts.Close()
+
+	f := &googleSheetsFetcher{
+		spreadsheetID: "foo",
+		readRange:     "Prices!A1:C",
+		opts:          []option.ClientOption{option.WithEndpoint(ts.URL), option.WithoutAuthentication()},
+	}
+
+	records, err := f.fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"sku", "price", "stock"},
+		{"a", "$10.00", "5"},
+		{"b", "$12.50"},
+	}, records)
+}
@@ -0,0 +1,152 @@
+package tabular
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func httpCSVPollInputConfig() *service.ConfigSpec {
+	return withPollerFields(service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Periodically downloads a CSV document over HTTP and emits the rows that have been added or changed since the previous poll.").
+		Description(`
+The first row of the document must be a header naming each column. This input is intended for reference data that is maintained elsewhere, such as a CSV file published by another team or exported from a spreadsheet, where only the rows that change are of interest.
+`+pollerDescription)).
+		Field(service.NewStringField("url").
+			Description("The URL of the CSV document.").
+			Example("https://example.com/exports/prices.csv")).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to requests.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"Authorization": "Bearer foo",
+			})).
+		Field(service.NewStringField("delimiter").
+			Description("The delimiter that separates the values of a row.").
+			Default(",").
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("A timeout for each request.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewTLSField("tls")).
+		Example("Reference Data", `
+Here we poll a CSV of product prices every ten minutes, writing each product that is added or changed to a Redis hash:`,
+			`
+input:
+  http_csv_poll:
+    url: https://example.com/exports/prices.csv
+    key_columns: [ sku ]
+    interval: 10m
+    cache: snapshots
+
+output:
+  redis_hash:
+    url: tcp://localhost:6379
+    key: products:${! json("sku") }
+    walk_json_object: true
+
+cache_resources:
+  - label: snapshots
+    redis:
+      url: tcp://localhost:6379
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"http_csv_poll", httpCSVPollInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newHTTPCSVPollInputFromConfig(conf, mgr, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type httpCSVFetcher struct {
+	url       string
+	headers   map[string]string
+	delimiter rune
+	client    *http.Client
+}
+
+func newHTTPCSVPollInputFromConfig(conf *service.ParsedConfig, mgr cacheProvider, log *service.Logger) (*poller, error) {
+	f := &httpCSVFetcher{}
+
+	var err error
+	if f.url, err = conf.FieldString("url"); err != nil {
+		return nil, err
+	}
+	if f.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+	delimStr, err := conf.FieldString("delimiter")
+	if err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(delimStr) != 1 {
+		return nil, errors.New("delimiter must be a single character")
+	}
+	f.delimiter, _ = utf8.DecodeRuneInString(delimStr)
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS("tls")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	f.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+
+	return newPollerFromConfig(conf, "http_csv_poll:"+f.url, f.fetch, mgr, log)
+}
+
+func (f *httpCSVFetcher) fetch(ctx context.Context) ([][]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range f.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		_, _ = io.Copy(io.Discard, res.Body)
+		return nil, fmt.Errorf("received unexpected status code %v", res.StatusCode)
+	}
+
+	r := csv.NewReader(res.Body)
+	r.Comma = f.delimiter
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,190 @@
+package tabular
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type mockCache struct {
+	mut   sync.Mutex
+	items map[string][]byte
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	v, exists := m.items[key]
+	if !exists {
+		return nil, service.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	m.items[key] = value
+	return nil
+}
+
+func (m *mockCache) Add(ctx context.Context, key string, value []byte, ttl *time.Duration) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if _, exists := m.items[key]; exists {
+		return service.ErrKeyAlreadyExists
+	}
+	m.items[key] = value
+	return nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, key string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	delete(m.items, key)
+	return nil
+}
+
+func (m *mockCache) Close(ctx context.Context) error {
+	return nil
+}
+
+type mockCacheProv struct {
+	caches map[string]service.Cache
+}
+
+func (m *mockCacheProv) AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error {
+	c, exists := m.caches[name]
+	if !exists {
+		return errors.New("cache not found")
+	}
+	fn(c)
+	return nil
+}
+
+func testHTTPCSVPollInput(t *testing.T, confStr string, cache *mockCache) *poller {
+	t.Helper()
+
+	conf, err := httpCSVPollInputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	p, err := newHTTPCSVPollInputFromConfig(conf, &mockCacheProv{
+		caches: map[string]service.Cache{"foo": cache},
+	}, nil)
+	require.NoError(t, err)
+	return p
+}
+
+func TestHTTPCSVPollInput(t *testing.T) {
+	var docMut sync.Mutex
+	doc := "id;name\n1;foo\n2;bar\n"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+		docMut.Lock()
+		_, _ = w.Write([]byte(doc))
+		docMut.Unlock()
+	}))
+	defer ts.Close()
+
+	cache := &mockCache{items: map[string][]byte{}}
+	p := testHTTPCSVPollInput(t, `
+url: `+ts.URL+`
+headers:
+  X-Foo: bar
+delimiter: ;
+key_columns: [ id ]
+interval: 1ms
+cache: foo
+`, cache)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, p.Connect(ctx))
+
+	batch, ackFn, err := p.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	mBytes, err := batch[1].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"2","name":"bar"}`, string(mBytes))
+
+	change, _ := batch[1].MetaGet("row_change")
+	assert.Equal(t, "added", change)
+	key, _ := batch[1].MetaGet("row_key")
+	assert.Equal(t, "2", key)
+	number, _ := batch[1].MetaGet("row_number")
+	assert.Equal(t, "2", number)
+
+	// A nack results in the rows being emitted again.
+	require.NoError(t, ackFn(ctx, errors.New("nope")))
+	batch, ackFn, err = p.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	require.NoError(t, ackFn(ctx, nil))
+	assert.Contains(t, cache.items, "http_csv_poll:"+ts.URL)
+
+	docMut.Lock()
+	doc = "id;name\n1;foo\n2;baz\n3;buz\n"
+	docMut.Unlock()
+
+	batch, ackFn, err = p.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+
+	change, _ = batch[0].MetaGet("row_change")
+	assert.Equal(t, "changed", change)
+	change, _ = batch[1].MetaGet("row_change")
+	assert.Equal(t, "added", change)
+	require.NoError(t, ackFn(ctx, nil))
+
+	// A new input resumes from the snapshot stored in the cache.
+	p = testHTTPCSVPollInput(t, `
+url: `+ts.URL+`
+delimiter: ;
+headers:
+  X-Foo: bar
+key_columns: [ id ]
+interval: 1ms
+cache: foo
+`, cache)
+	require.NoError(t, p.Connect(ctx))
+
+	docMut.Lock()
+	doc = "id;name\n1;foo\n2;baz\n3;qux\n"
+	docMut.Unlock()
+
+	batch, _, err = p.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	key, _ = batch[0].MetaGet("row_key")
+	assert.Equal(t, "3", key)
+}
+
+func TestHTTPCSVPollInputBadStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	p := testHTTPCSVPollInput(t, `
+url: `+ts.URL+`
+cache: foo
+`, &mockCache{items: map[string][]byte{}})
+
+	ctx := context.Background()
+	require.NoError(t, p.Connect(ctx))
+
+	_, _, err := p.ReadBatch(ctx)
+	assert.EqualError(t, err, "received unexpected status code 403")
+}
@@ -0,0 +1,3 @@
+// Package tabular contains inputs that poll tabular sources such as
+// spreadsheets and CSV documents, emitting the rows that change between polls.
+package tabular
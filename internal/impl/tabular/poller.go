@@ -0,0 +1,275 @@
+package tabular
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	tpFieldKeyColumns = "key_columns"
+	tpFieldInterval   = "interval"
+	tpFieldCache      = "cache"
+	tpFieldCacheKey   = "cache_key"
+)
+
+// pollerDescription describes the behaviour shared by all tabular poll
+// inputs.
+const pollerDescription = `
+### Change Detection
+
+Rows are identified by the values of their ` + "`key_columns`" + `, and a row is emitted when its key was not present in the previous poll, or when any of its values have changed since. When no key columns are specified rows are identified by their entire contents, and therefore a row that is modified is emitted as an added row. Rows with a key that has already been seen within the same poll are skipped with a warning, and rows that are removed from the source are not emitted.
+
+A snapshot of the rows as of the last poll is stored within the ` + "`cache`" + ` resource, which allows only rows that change to be emitted across restarts. The snapshot is only updated once the rows emitted by a poll have been delivered, and a poll is not made whilst the rows of the previous poll are still pending, and therefore changes are delivered at least once. A cache that persists data, such as ` + "`redis`" + ` or ` + "`file`" + `, should be used in order to avoid all rows being emitted after a restart.
+
+### Metadata
+
+Each row is emitted as a JSON object of column names to values, and all of the rows of a poll are emitted as a batch. This input adds the following metadata fields to each message:
+
+` + "```text" + `
+- row_change
+- row_key
+- row_number
+` + "```" + `
+
+Where ` + "`row_change`" + ` is either ` + "`added`" + ` or ` + "`changed`" + `, ` + "`row_key`" + ` is the value of the key column, or a JSON array of the values of multiple key columns, and ` + "`row_number`" + ` is the position of the row within the source where the first row after the header is ` + "`1`" + `.
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`
+
+// withPollerFields adds the fields shared by all tabular poll inputs to a
+// spec.
+func withPollerFields(spec *service.ConfigSpec) *service.ConfigSpec {
+	return spec.
+		Field(service.NewStringListField(tpFieldKeyColumns).
+			Description("A list of columns that uniquely identify each row. When empty rows are identified by their entire contents.").
+			Example([]string{"id"}).
+			Example([]string{"region", "sku"}).
+			Default([]interface{}{})).
+		Field(service.NewDurationField(tpFieldInterval).
+			Description("The period between each poll of the source.").
+			Default("5m")).
+		Field(service.NewStringField(tpFieldCache).
+			Description("A [cache resource](/docs/components/caches/about) in which to store the snapshot of rows as of the last poll.")).
+		Field(service.NewStringField(tpFieldCacheKey).
+			Description("The key under which the snapshot is stored within the cache. When empty a key derived from the source is used.").
+			Default("").
+			Advanced())
+}
+
+type cacheProvider interface {
+	AccessCache(ctx context.Context, name string, fn func(c service.Cache)) error
+}
+
+// fetchFn fetches the current records of a source, where the first record is
+// the header.
+type fetchFn func(ctx context.Context) ([][]string, error)
+
+// poller is a batch input that periodically fetches a table and emits the
+// rows that have changed since the previous poll.
+type poller struct {
+	fetch      fetchFn
+	keyColumns []string
+	interval   time.Duration
+	cache      string
+	cacheKey   string
+
+	mgr cacheProvider
+	log *service.Logger
+
+	mut      sync.Mutex
+	prev     snapshot
+	nextPoll time.Time
+	pending  chan struct{}
+}
+
+func newPollerFromConfig(conf *service.ParsedConfig, defaultCacheKey string, fetch fetchFn, mgr cacheProvider, log *service.Logger) (*poller, error) {
+	p := &poller{
+		fetch: fetch,
+		mgr:   mgr,
+		log:   log,
+	}
+
+	var err error
+	if p.keyColumns, err = conf.FieldStringList(tpFieldKeyColumns); err != nil {
+		return nil, err
+	}
+	if p.interval, err = conf.FieldDuration(tpFieldInterval); err != nil {
+		return nil, err
+	}
+	if p.interval <= 0 {
+		return nil, errors.New("interval must be larger than zero")
+	}
+	if p.cache, err = conf.FieldString(tpFieldCache); err != nil {
+		return nil, err
+	}
+	if p.cacheKey, err = conf.FieldString(tpFieldCacheKey); err != nil {
+		return nil, err
+	}
+	if p.cacheKey == "" {
+		p.cacheKey = defaultCacheKey
+	}
+	return p, nil
+}
+
+func (p *poller) Connect(ctx context.Context) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if p.prev != nil {
+		return nil
+	}
+
+	var snapBytes []byte
+	var err error
+	if cerr := p.mgr.AccessCache(ctx, p.cache, func(c service.Cache) {
+		snapBytes, err = c.Get(ctx, p.cacheKey)
+	}); cerr != nil {
+		return cerr
+	}
+
+	prev := snapshot{}
+	if err != nil {
+		if !errors.Is(err, service.ErrKeyNotFound) {
+			return err
+		}
+	} else if err = json.Unmarshal(snapBytes, &prev); err != nil {
+		p.log.Warnf("Discarding snapshot stored under cache key %v that failed to parse: %v", p.cacheKey, err)
+		prev = snapshot{}
+	}
+	p.prev = prev
+	return nil
+}
+
+func (p *poller) storeSnapshot(ctx context.Context, s snapshot) error {
+	snapBytes, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if cerr := p.mgr.AccessCache(ctx, p.cache, func(c service.Cache) {
+		err = c.Set(ctx, p.cacheKey, snapBytes, nil)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// wait blocks until the rows of the previous poll have been delivered and the
+// next poll is due.
+func (p *poller) wait(ctx context.Context) error {
+	p.mut.Lock()
+	pending := p.pending
+	p.mut.Unlock()
+
+	if pending != nil {
+		select {
+		case <-pending:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	p.mut.Lock()
+	waitFor := time.Until(p.nextPoll)
+	p.mut.Unlock()
+
+	if waitFor > 0 {
+		select {
+		case <-time.After(waitFor):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (p *poller) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		if err := p.wait(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		records, err := p.fetch(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		t, err := newTable(records)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		p.mut.Lock()
+		prev := p.prev
+		p.mut.Unlock()
+		if prev == nil {
+			return nil, nil, service.ErrNotConnected
+		}
+
+		changes, next, duplicates, err := t.diff(prev, p.keyColumns)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(duplicates) > 0 {
+			p.log.Warnf("Skipped %v rows with duplicate keys, the first being row %v", len(duplicates), duplicates[0])
+		}
+
+		p.mut.Lock()
+		p.nextPoll = time.Now().Add(p.interval)
+		p.mut.Unlock()
+
+		if len(changes) == 0 {
+			// Rows may still have been removed, in which case the snapshot
+			// is updated immediately.
+			if !next.equal(prev) {
+				if err := p.storeSnapshot(ctx, next); err != nil {
+					return nil, nil, err
+				}
+				p.mut.Lock()
+				p.prev = next
+				p.mut.Unlock()
+			}
+			continue
+		}
+
+		batch := make(service.MessageBatch, len(changes))
+		for i, c := range changes {
+			msg := service.NewMessage(c.body)
+			msg.MetaSet("row_change", c.kind)
+			msg.MetaSet("row_key", c.key)
+			msg.MetaSet("row_number", strconv.Itoa(c.number))
+			batch[i] = msg
+		}
+
+		pending := make(chan struct{})
+		p.mut.Lock()
+		p.pending = pending
+		p.mut.Unlock()
+
+		return batch, func(ctx context.Context, err error) error {
+			defer close(pending)
+			if err != nil {
+				// The snapshot is left unchanged and the source is polled again
+				// immediately so that the rows are emitted again.
+				p.mut.Lock()
+				p.nextPoll = time.Time{}
+				p.mut.Unlock()
+				return nil
+			}
+			if err := p.storeSnapshot(ctx, next); err != nil {
+				return err
+			}
+			p.mut.Lock()
+			p.prev = next
+			p.mut.Unlock()
+			return nil
+		}, nil
+	}
+}
+
+func (p *poller) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,166 @@
+package tabular
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+)
+
+const (
+	rowAdded   = "added"
+	rowChanged = "changed"
+)
+
+// table is the result of fetching a tabular source, where the values of each
+// row correspond to the columns of the header.
+type table struct {
+	columns []string
+	rows    [][]string
+}
+
+// newTable creates a table from a list of records where the first record is
+// the header. Records shorter than the header are padded with empty values,
+// as spreadsheets commonly omit trailing empty cells.
+func newTable(records [][]string) (*table, error) {
+	if len(records) == 0 {
+		return nil, errors.New("table has no header row")
+	}
+	t := &table{columns: records[0]}
+
+	seen := map[string]struct{}{}
+	for _, c := range t.columns {
+		if _, exists := seen[c]; exists {
+			return nil, fmt.Errorf("duplicate column: %q", c)
+		}
+		seen[c] = struct{}{}
+	}
+
+	for i, r := range records[1:] {
+		if len(r) > len(t.columns) {
+			return nil, fmt.Errorf("row %v has %v values but the header only has %v columns", i+1, len(r), len(t.columns))
+		}
+		row := make([]string, len(t.columns))
+		copy(row, r)
+		t.rows = append(t.rows, row)
+	}
+	return t, nil
+}
+
+// snapshot maps the key of each row to a hash of its contents as of the last
+// poll.
+type snapshot map[string]string
+
+// rowChange is a row that was added or changed since the previous snapshot.
+type rowChange struct {
+	kind   string
+	key    string
+	number int
+	body   []byte
+}
+
+// keyIndexes returns the indexes of the key columns within a table.
+func (t *table) keyIndexes(keyColumns []string) ([]int, error) {
+	indexes := make([]int, 0, len(keyColumns))
+	for _, k := range keyColumns {
+		index := -1
+		for i, c := range t.columns {
+			if c == k {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return nil, fmt.Errorf("key column %q not found in header", k)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+func hashRow(body []byte) string {
+	h := fnv.New64a()
+	_, _ = h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// diff compares the rows of a table with a previous snapshot, returning the
+// rows that were added or changed along with the snapshot of the table, and
+// the numbers of rows skipped due to having a duplicate key. When no key
+// columns are given rows are identified by their contents, and therefore rows
+// can only ever be added.
+func (t *table) diff(prev snapshot, keyColumns []string) (changes []rowChange, next snapshot, duplicates []int, err error) {
+	keyIndexes, err := t.keyIndexes(keyColumns)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	next = snapshot{}
+	for i, row := range t.rows {
+		obj := make(map[string]string, len(t.columns))
+		for j, c := range t.columns {
+			obj[c] = row[j]
+		}
+		body, err := json.Marshal(obj)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		hash := hashRow(body)
+
+		var key string
+		switch len(keyIndexes) {
+		case 0:
+			key = hash
+		case 1:
+			key = row[keyIndexes[0]]
+		default:
+			keyValues := make([]string, len(keyIndexes))
+			for j, index := range keyIndexes {
+				keyValues[j] = row[index]
+			}
+			keyBytes, err := json.Marshal(keyValues)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			key = string(keyBytes)
+		}
+
+		if _, exists := next[key]; exists {
+			if len(keyIndexes) > 0 {
+				duplicates = append(duplicates, i+1)
+			}
+			continue
+		}
+		next[key] = hash
+
+		prevHash, existed := prev[key]
+		if existed && prevHash == hash {
+			continue
+		}
+		kind := rowAdded
+		if existed {
+			kind = rowChanged
+		}
+		changes = append(changes, rowChange{
+			kind:   kind,
+			key:    key,
+			number: i + 1,
+			body:   body,
+		})
+	}
+	return changes, next, duplicates, nil
+}
+
+// equal returns whether two snapshots contain the same rows.
+func (s snapshot) equal(other snapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for k, v := range s {
+		if ov, exists := other[k]; !exists || ov != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package tabular
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTable(t *testing.T) {
+	tbl, err := newTable([][]string{
+		{"id", "name", "price"},
+		{"1", "foo", "10"},
+		{"2", "bar"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"1", "foo", "10"},
+		{"2", "bar", ""},
+	}, tbl.rows)
+
+	_, err = newTable(nil)
+	assert.EqualError(t, err, "table has no header row")
+
+	_, err = newTable([][]string{{"id", "id"}})
+	assert.EqualError(t, err, `duplicate column: "id"`)
+
+	_, err = newTable([][]string{{"id"}, {"1", "2"}})
+	assert.EqualError(t, err, "row 1 has 2 values but the header only has 1 columns")
+}
+
+func changeSummary(changes []rowChange) (summary []string) {
+	for _, c := range changes {
+		summary = append(summary, c.kind+":"+c.key+":"+string(c.body))
+	}
+	return
+}
+
+func TestTableDiffKeyed(t *testing.T) {
+	tbl, err := newTable([][]string{
+		{"id", "name"},
+		{"1", "foo"},
+		{"2", "bar"},
+		{"3", "baz"},
+		{"2", "dupe"},
+	})
+	require.NoError(t, err)
+
+	changes, first, duplicates, err := tbl.diff(snapshot{}, []string{"id"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`added:1:{"id":"1","name":"foo"}`,
+		`added:2:{"id":"2","name":"bar"}`,
+		`added:3:{"id":"3","name":"baz"}`,
+	}, changeSummary(changes))
+	assert.Equal(t, []int{4}, duplicates)
+	assert.Len(t, first, 3)
+
+	tbl, err = newTable([][]string{
+		{"id", "name"},
+		{"4", "buz"},
+		{"1", "foo"},
+		{"3", "qux"},
+	})
+	require.NoError(t, err)
+
+	changes, second, duplicates, err := tbl.diff(first, []string{"id"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`added:4:{"id":"4","name":"buz"}`,
+		`changed:3:{"id":"3","name":"qux"}`,
+	}, changeSummary(changes))
+	assert.Equal(t, []int{1, 3}, []int{changes[0].number, changes[1].number})
+	assert.Empty(t, duplicates)
+	assert.False(t, second.equal(first))
+
+	changes, third, _, err := tbl.diff(second, []string{"id"})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.True(t, third.equal(second))
+
+	_, _, _, err = tbl.diff(second, []string{"nope"})
+	assert.EqualError(t, err, `key column "nope" not found in header`)
+}
+
+func TestTableDiffCompositeKey(t *testing.T) {
+	tbl, err := newTable([][]string{
+		{"region", "sku", "price"},
+		{"eu", "a", "10"},
+		{"us", "a", "12"},
+	})
+	require.NoError(t, err)
+
+	changes, _, _, err := tbl.diff(snapshot{}, []string{"region", "sku"})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, `["eu","a"]`, changes[0].key)
+	assert.Equal(t, `["us","a"]`, changes[1].key)
+}
+
+func TestTableDiffUnkeyed(t *testing.T) {
+	tbl, err := newTable([][]string{
+		{"id", "name"},
+		{"1", "foo"},
+		{"2", "bar"},
+	})
+	require.NoError(t, err)
+
+	_, first, _, err := tbl.diff(snapshot{}, nil)
+	require.NoError(t, err)
+
+	tbl, err = newTable([][]string{
+		{"id", "name"},
+		{"1", "foo"},
+		{"2", "baz"},
+	})
+	require.NoError(t, err)
+
+	changes, _, _, err := tbl.diff(first, nil)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, rowAdded, changes[0].kind)
+	assert.Equal(t, `{"id":"2","name":"baz"}`, string(changes[0].body))
+}
@@ -0,0 +1,256 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// recordFields returns the fields shared by vector store outputs for mapping
+// messages into records.
+func recordFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewBloblangField("id").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the ID of the record of each message, records with an existing ID are replaced.").
+			Example("root = this.id").
+			Example(`root = meta("kafka_key")`),
+		service.NewBloblangField("vector").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the vector of the record of each message, which must be an array of numbers.").
+			Default("root = this.embedding"),
+		service.NewBloblangField("payload").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) that provides data stored alongside the vector of each record, which must be an object.").
+			Optional().
+			Example(`root = this.without("embedding")`),
+	}
+}
+
+func outputFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have in flight at a given time. Increase this to improve throughput.").
+			Default(64),
+		service.NewBatchPolicyField("batching"),
+	}
+}
+
+func httpFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for a request to complete.").
+			Default("30s").
+			Advanced(),
+	}
+}
+
+func registerVectorOutput(name string, spec *service.ConfigSpec, ctor func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, error)) {
+	err := service.RegisterBatchOutput(
+		name, spec,
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = ctor(conf, mgr)
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type vectorRecord struct {
+	ID      interface{}
+	Vector  []float64
+	Payload map[string]interface{}
+}
+
+type recordMapper struct {
+	id      *bloblang.Executor
+	vector  *bloblang.Executor
+	payload *bloblang.Executor
+}
+
+func recordMapperFromConfig(conf *service.ParsedConfig) (*recordMapper, error) {
+	m := &recordMapper{}
+
+	var err error
+	if m.id, err = conf.FieldBloblang("id"); err != nil {
+		return nil, err
+	}
+	if m.vector, err = conf.FieldBloblang("vector"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("payload") {
+		if m.payload, err = conf.FieldBloblang("payload"); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func queryStructured(batch service.MessageBatch, i int, exec *bloblang.Executor) (interface{}, error) {
+	res, err := batch.BloblangQuery(i, exec)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.New("mapping resulted in a deleted message")
+	}
+	return res.AsStructured()
+}
+
+// queryID executes an ID mapping, where mappings that result in strings are
+// raw bytes rather than valid JSON documents and are used as they are.
+func queryID(batch service.MessageBatch, i int, exec *bloblang.Executor) (interface{}, error) {
+	res, err := batch.BloblangQuery(i, exec)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.New("mapping resulted in a deleted message")
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		b, err := res.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	}
+	return v, nil
+}
+
+func (m *recordMapper) mapRecord(batch service.MessageBatch, i int) (r vectorRecord, err error) {
+	if r.ID, err = queryID(batch, i, m.id); err != nil {
+		return r, fmt.Errorf("id mapping failed: %w", err)
+	}
+	switch t := r.ID.(type) {
+	case string, json.Number, int64, uint64, float64:
+	default:
+		return r, fmt.Errorf("id mapping failed: expected a string or number, got %T", t)
+	}
+
+	vec, err := queryStructured(batch, i, m.vector)
+	if err != nil {
+		return r, fmt.Errorf("vector mapping failed: %w", err)
+	}
+	arr, ok := vec.([]interface{})
+	if !ok {
+		return r, fmt.Errorf("vector mapping failed: expected an array, got %T", vec)
+	}
+	r.Vector = make([]float64, len(arr))
+	for j, v := range arr {
+		if r.Vector[j], err = query.IGetNumber(v); err != nil {
+			return r, fmt.Errorf("vector mapping failed: element %v: %w", j, err)
+		}
+	}
+
+	if m.payload != nil {
+		p, err := queryStructured(batch, i, m.payload)
+		if err != nil {
+			return r, fmt.Errorf("payload mapping failed: %w", err)
+		}
+		if r.Payload, ok = p.(map[string]interface{}); !ok {
+			return r, fmt.Errorf("payload mapping failed: expected an object, got %T", p)
+		}
+	}
+	return r, nil
+}
+
+// recordGroup is a set of records targeting the same collection.
+type recordGroup struct {
+	collection string
+	records    []vectorRecord
+}
+
+// mapBatch maps a batch of messages into records grouped by their collection,
+// in order of appearance. When an ID appears multiple times within a
+// collection only the last record is kept, as stores commonly reject
+// upserting the same ID twice within a request.
+func (m *recordMapper) mapBatch(batch service.MessageBatch, collection *service.InterpolatedString) ([]recordGroup, error) {
+	var groups []recordGroup
+	groupIndexes := map[string]int{}
+	recordIndexes := map[string]map[string]int{}
+
+	for i := range batch {
+		r, err := m.mapRecord(batch, i)
+		if err != nil {
+			return nil, err
+		}
+
+		var c string
+		if collection != nil {
+			c = batch.InterpolatedString(i, collection)
+		}
+		gi, exists := groupIndexes[c]
+		if !exists {
+			gi = len(groups)
+			groupIndexes[c] = gi
+			groups = append(groups, recordGroup{collection: c})
+			recordIndexes[c] = map[string]int{}
+		}
+
+		idKey := fmt.Sprintf("%v", r.ID)
+		if ri, exists := recordIndexes[c][idKey]; exists {
+			groups[gi].records[ri] = r
+			continue
+		}
+		recordIndexes[c][idKey] = len(groups[gi].records)
+		groups[gi].records = append(groups[gi].records, r)
+	}
+	return groups, nil
+}
+
+//------------------------------------------------------------------------------
+
+// doJSONRequest sends a JSON body to a URL and decodes the JSON response into
+// resBody when it is non-nil.
+func doJSONRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, reqBody, resBody interface{}) error {
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("request returned status %v: %s", res.StatusCode, bytes.TrimSpace(resBytes))
+	}
+	if resBody == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resBytes, resBody); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
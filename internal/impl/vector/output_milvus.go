@@ -0,0 +1,160 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func milvusOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Upserts entities into [Milvus](https://milvus.io/) collections using the RESTful API.").
+		Description(`
+Each message is mapped into an entity with an ` + "[`id`](#id)" + ` stored in the primary key field ` + "[`id_field`](#id_field)" + `, a ` + "[`vector`](#vector)" + ` stored in the field ` + "[`vector_field`](#vector_field)" + `, and an optional ` + "[`payload`](#payload)" + ` object where each key is stored as a field of the entity, which must match the schema of the collection. Entities are upserted in a single request per collection for each batch.`).
+		Field(service.NewStringField("url").
+			Description("The URL of the Milvus server.").
+			Example("http://localhost:19530")).
+		Field(service.NewStringField("token").
+			Description("An optional token to authenticate with, which is either an API key or a username and password of the form `username:password`.").
+			Default("")).
+		Field(service.NewStringField("database").
+			Description("An optional database containing the collection, the default database is used when empty.").
+			Default("").
+			Advanced()).
+		Field(service.NewInterpolatedStringField("collection").
+			Description("The collection to upsert entities into.").
+			Example("documents")).
+		Field(service.NewStringField("id_field").
+			Description("The name of the primary key field of the collection.").
+			Default("id")).
+		Field(service.NewStringField("vector_field").
+			Description("The name of the vector field of the collection.").
+			Default("vector"))
+
+	for _, f := range recordFields() {
+		spec = spec.Field(f)
+	}
+	for _, f := range httpFields() {
+		spec = spec.Field(f)
+	}
+	for _, f := range outputFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec
+}
+
+func init() {
+	registerVectorOutput("milvus", milvusOutputConfig(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, error) {
+		return newMilvusOutputFromConfig(conf)
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type milvusOutput struct {
+	url         string
+	headers     map[string]string
+	database    string
+	collection  *service.InterpolatedString
+	idField     string
+	vectorField string
+	mapper      *recordMapper
+	client      *http.Client
+}
+
+func newMilvusOutputFromConfig(conf *service.ParsedConfig) (*milvusOutput, error) {
+	m := &milvusOutput{headers: map[string]string{}}
+
+	var err error
+	if m.url, err = conf.FieldString("url"); err != nil {
+		return nil, err
+	}
+	m.url = strings.TrimSuffix(m.url, "/")
+
+	token, err := conf.FieldString("token")
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		m.headers["Authorization"] = "Bearer " + token
+	}
+
+	if m.database, err = conf.FieldString("database"); err != nil {
+		return nil, err
+	}
+	if m.collection, err = conf.FieldInterpolatedString("collection"); err != nil {
+		return nil, err
+	}
+	if m.idField, err = conf.FieldString("id_field"); err != nil {
+		return nil, err
+	}
+	if m.vectorField, err = conf.FieldString("vector_field"); err != nil {
+		return nil, err
+	}
+	if m.mapper, err = recordMapperFromConfig(conf); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	m.client = &http.Client{Timeout: timeout}
+	return m, nil
+}
+
+func (m *milvusOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (m *milvusOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	groups, err := m.mapper.mapBatch(batch, m.collection)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		data := make([]map[string]interface{}, len(g.records))
+		for i, r := range g.records {
+			entity := make(map[string]interface{}, len(r.Payload)+2)
+			for k, v := range r.Payload {
+				entity[k] = v
+			}
+			entity[m.idField] = r.ID
+			entity[m.vectorField] = r.Vector
+			data[i] = entity
+		}
+
+		reqBody := map[string]interface{}{
+			"collectionName": g.collection,
+			"data":           data,
+		}
+		if m.database != "" {
+			reqBody["dbName"] = m.database
+		}
+
+		// Errors are reported with a successful status code and a non-zero
+		// code within the response body.
+		var res struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := doJSONRequest(ctx, m.client, http.MethodPost, m.url+"/v2/vectordb/entities/upsert", m.headers, reqBody, &res); err != nil {
+			return fmt.Errorf("failed to upsert entities into collection %v: %w", g.collection, err)
+		}
+		if res.Code != 0 {
+			return fmt.Errorf("failed to upsert entities into collection %v: code %v: %v", g.collection, res.Code, res.Message)
+		}
+	}
+	return nil
+}
+
+func (m *milvusOutput) Close(ctx context.Context) error {
+	return nil
+}
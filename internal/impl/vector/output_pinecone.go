@@ -0,0 +1,123 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func pineconeOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Upserts vectors into a [Pinecone](https://www.pinecone.io/) index.").
+		Description(`
+Each message is mapped into a vector with an ` + "[`id`](#id)" + `, ` + "[`vector`](#vector)" + ` values and optional ` + "[`payload`](#payload)" + ` metadata. Vectors are upserted in a single request per namespace for each batch, note that Pinecone limits the size of upsert requests and therefore batches should be kept reasonably small (around 100 vectors). IDs are converted to strings.`).
+		Field(service.NewStringField("host").
+			Description("The host URL of the index, which can be found in the Pinecone console.").
+			Example("https://documents-abc123.svc.us-east-1-aws.pinecone.io")).
+		Field(service.NewStringField("api_key").
+			Description("The API key to authenticate with.")).
+		Field(service.NewInterpolatedStringField("namespace").
+			Description("The namespace of the index to upsert vectors into.").
+			Default("").
+			Example(`${! meta("tenant") }`))
+
+	for _, f := range recordFields() {
+		spec = spec.Field(f)
+	}
+	for _, f := range httpFields() {
+		spec = spec.Field(f)
+	}
+	for _, f := range outputFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec
+}
+
+func init() {
+	registerVectorOutput("pinecone", pineconeOutputConfig(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, error) {
+		return newPineconeOutputFromConfig(conf)
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type pineconeOutput struct {
+	host      string
+	headers   map[string]string
+	namespace *service.InterpolatedString
+	mapper    *recordMapper
+	client    *http.Client
+}
+
+func newPineconeOutputFromConfig(conf *service.ParsedConfig) (*pineconeOutput, error) {
+	p := &pineconeOutput{}
+
+	var err error
+	if p.host, err = conf.FieldString("host"); err != nil {
+		return nil, err
+	}
+	p.host = strings.TrimSuffix(p.host, "/")
+
+	apiKey, err := conf.FieldString("api_key")
+	if err != nil {
+		return nil, err
+	}
+	p.headers = map[string]string{"Api-Key": apiKey}
+
+	if p.namespace, err = conf.FieldInterpolatedString("namespace"); err != nil {
+		return nil, err
+	}
+	if p.mapper, err = recordMapperFromConfig(conf); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	p.client = &http.Client{Timeout: timeout}
+	return p, nil
+}
+
+func (p *pineconeOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+type pineconeVector struct {
+	ID       string                 `json:"id"`
+	Values   []float64              `json:"values"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+func (p *pineconeOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	groups, err := p.mapper.mapBatch(batch, p.namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		vectors := make([]pineconeVector, len(g.records))
+		for i, r := range g.records {
+			vectors[i] = pineconeVector{ID: fmt.Sprintf("%v", r.ID), Values: r.Vector, Metadata: r.Payload}
+		}
+
+		reqBody := map[string]interface{}{"vectors": vectors}
+		if g.collection != "" {
+			reqBody["namespace"] = g.collection
+		}
+		if err := doJSONRequest(ctx, p.client, http.MethodPost, p.host+"/vectors/upsert", p.headers, reqBody, nil); err != nil {
+			return fmt.Errorf("failed to upsert vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *pineconeOutput) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,147 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func qdrantOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Upserts points into [Qdrant](https://qdrant.tech/) collections.").
+		Description(`
+Each message is mapped into a point with an ` + "[`id`](#id)" + `, a ` + "[`vector`](#vector)" + ` and an optional ` + "[`payload`](#payload)" + `. Points are upserted in a single request per collection for each batch, and requests wait for the changes to be applied before the batch is acknowledged. IDs of points must either be unsigned integers or UUIDs.`).
+		Field(service.NewStringField("url").
+			Description("The URL of the Qdrant REST API.").
+			Example("http://localhost:6333")).
+		Field(service.NewStringField("api_key").
+			Description("An optional API key to authenticate with.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("collection").
+			Description("The collection to upsert points into.").
+			Example("documents").
+			Example(`${! meta("tenant") }-documents`))
+
+	for _, f := range recordFields() {
+		spec = spec.Field(f)
+	}
+	for _, f := range httpFields() {
+		spec = spec.Field(f)
+	}
+	for _, f := range outputFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.Example("Upserting Embeddings", `
+Here we compute embeddings of documents and upsert them into a collection along with their titles:`,
+		`
+pipeline:
+  processors:
+    - embeddings:
+        api_key: ${OPENAI_API_KEY}
+        model: text-embedding-3-small
+        text: root = this.body
+
+output:
+  qdrant:
+    url: http://localhost:6333
+    collection: documents
+    id: root = this.id
+    vector: root = this.embedding
+    payload: 'root.title = this.title'
+    batching:
+      count: 100
+      period: 1s
+`,
+	)
+}
+
+func init() {
+	registerVectorOutput("qdrant", qdrantOutputConfig(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, error) {
+		return newQdrantOutputFromConfig(conf)
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type qdrantOutput struct {
+	url        string
+	headers    map[string]string
+	collection *service.InterpolatedString
+	mapper     *recordMapper
+	client     *http.Client
+}
+
+func newQdrantOutputFromConfig(conf *service.ParsedConfig) (*qdrantOutput, error) {
+	q := &qdrantOutput{headers: map[string]string{}}
+
+	var err error
+	if q.url, err = conf.FieldString("url"); err != nil {
+		return nil, err
+	}
+	q.url = strings.TrimSuffix(q.url, "/")
+
+	apiKey, err := conf.FieldString("api_key")
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		q.headers["api-key"] = apiKey
+	}
+
+	if q.collection, err = conf.FieldInterpolatedString("collection"); err != nil {
+		return nil, err
+	}
+	if q.mapper, err = recordMapperFromConfig(conf); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	q.client = &http.Client{Timeout: timeout}
+	return q, nil
+}
+
+func (q *qdrantOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+type qdrantPoint struct {
+	ID      interface{}            `json:"id"`
+	Vector  []float64              `json:"vector"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+func (q *qdrantOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	groups, err := q.mapper.mapBatch(batch, q.collection)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		points := make([]qdrantPoint, len(g.records))
+		for i, r := range g.records {
+			points[i] = qdrantPoint{ID: r.ID, Vector: r.Vector, Payload: r.Payload}
+		}
+
+		reqURL := fmt.Sprintf("%v/collections/%v/points?wait=true", q.url, url.PathEscape(g.collection))
+		if err := doJSONRequest(ctx, q.client, http.MethodPut, reqURL, q.headers, map[string]interface{}{
+			"points": points,
+		}, nil); err != nil {
+			return fmt.Errorf("failed to upsert points into collection %v: %w", g.collection, err)
+		}
+	}
+	return nil
+}
+
+func (q *qdrantOutput) Close(ctx context.Context) error {
+	return nil
+}
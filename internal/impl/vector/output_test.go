@@ -0,0 +1,283 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type capturedRequest struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   map[string]interface{}
+}
+
+type vectorServer struct {
+	mut      sync.Mutex
+	requests []capturedRequest
+	response string
+}
+
+func (s *vectorServer) serve(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		s.mut.Lock()
+		s.requests = append(s.requests, capturedRequest{
+			Method: r.Method,
+			Path:   r.URL.RequestURI(),
+			Header: r.Header.Clone(),
+			Body:   body,
+		})
+		res := s.response
+		s.mut.Unlock()
+
+		if res == "" {
+			res = "{}"
+		}
+		_, _ = w.Write([]byte(res))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testBatch(metaKey string, contents ...string) service.MessageBatch {
+	var batch service.MessageBatch
+	for i, c := range contents {
+		msg := service.NewMessage([]byte(c))
+		if metaKey != "" {
+			msg.MetaSet(metaKey, []string{"a", "b"}[i%2])
+		}
+		batch = append(batch, msg)
+	}
+	return batch
+}
+
+func TestRecordMapperBatch(t *testing.T) {
+	conf, err := qdrantOutputConfig().ParseYAML(`
+url: http://localhost:6333
+collection: ${! meta("c") }
+id: root = this.id
+payload: root = this.without("id", "embedding")
+`, nil)
+	require.NoError(t, err)
+
+	mapper, err := recordMapperFromConfig(conf)
+	require.NoError(t, err)
+
+	collection, err := conf.FieldInterpolatedString("collection")
+	require.NoError(t, err)
+
+	groups, err := mapper.mapBatch(testBatch("c",
+		`{"id":1,"embedding":[1,2],"title":"first"}`,
+		`{"id":"x","embedding":[3.5],"title":"second"}`,
+		`{"id":1,"embedding":[4,5],"title":"replaced"}`,
+	), collection)
+	require.NoError(t, err)
+
+	require.Len(t, groups, 2)
+	assert.Equal(t, "a", groups[0].collection)
+	require.Len(t, groups[0].records, 1)
+	assert.Equal(t, []float64{4, 5}, groups[0].records[0].Vector)
+	assert.Equal(t, map[string]interface{}{"title": "replaced"}, groups[0].records[0].Payload)
+
+	assert.Equal(t, "b", groups[1].collection)
+	require.Len(t, groups[1].records, 1)
+	assert.Equal(t, "x", groups[1].records[0].ID)
+	assert.Equal(t, []float64{3.5}, groups[1].records[0].Vector)
+
+	for _, input := range []string{
+		`{"id":true,"embedding":[1]}`,
+		`{"id":"a","embedding":"nope"}`,
+		`{"id":"a","embedding":["nope"]}`,
+		`{"id":"a"}`,
+	} {
+		_, err = mapper.mapBatch(testBatch("", input), collection)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestQdrantOutput(t *testing.T) {
+	s := &vectorServer{}
+	srv := s.serve(t)
+
+	conf, err := qdrantOutputConfig().ParseYAML(`
+url: `+srv.URL+`/
+api_key: foo
+collection: docs-${! meta("c") }
+id: root = this.id
+payload: root.title = this.title
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newQdrantOutputFromConfig(conf)
+	require.NoError(t, err)
+	require.NoError(t, out.Connect(context.Background()))
+
+	require.NoError(t, out.WriteBatch(context.Background(), testBatch("c",
+		`{"id":1,"embedding":[1,2],"title":"first"}`,
+		`{"id":2,"embedding":[3,4],"title":"second"}`,
+		`{"id":3,"embedding":[5,6],"title":"third"}`,
+	)))
+
+	require.Len(t, s.requests, 2)
+	assert.Equal(t, http.MethodPut, s.requests[0].Method)
+	assert.Equal(t, "/collections/docs-a/points?wait=true", s.requests[0].Path)
+	assert.Equal(t, "foo", s.requests[0].Header.Get("api-key"))
+	assert.Equal(t, map[string]interface{}{
+		"points": []interface{}{
+			map[string]interface{}{"id": 1.0, "vector": []interface{}{1.0, 2.0}, "payload": map[string]interface{}{"title": "first"}},
+			map[string]interface{}{"id": 3.0, "vector": []interface{}{5.0, 6.0}, "payload": map[string]interface{}{"title": "third"}},
+		},
+	}, s.requests[0].Body)
+	assert.Equal(t, "/collections/docs-b/points?wait=true", s.requests[1].Path)
+
+	require.NoError(t, out.Close(context.Background()))
+}
+
+func TestPineconeOutput(t *testing.T) {
+	s := &vectorServer{}
+	srv := s.serve(t)
+
+	conf, err := pineconeOutputConfig().ParseYAML(`
+host: `+srv.URL+`
+api_key: foo
+namespace: ${! meta("c") }
+id: root = this.id
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newPineconeOutputFromConfig(conf)
+	require.NoError(t, err)
+
+	require.NoError(t, out.WriteBatch(context.Background(), testBatch("c",
+		`{"id":1,"embedding":[1,2]}`,
+		`{"id":"b","embedding":[3,4]}`,
+	)))
+
+	require.Len(t, s.requests, 2)
+	assert.Equal(t, http.MethodPost, s.requests[0].Method)
+	assert.Equal(t, "/vectors/upsert", s.requests[0].Path)
+	assert.Equal(t, "foo", s.requests[0].Header.Get("Api-Key"))
+	assert.Equal(t, map[string]interface{}{
+		"namespace": "a",
+		"vectors": []interface{}{
+			map[string]interface{}{"id": "1", "values": []interface{}{1.0, 2.0}},
+		},
+	}, s.requests[0].Body)
+	assert.Equal(t, "b", s.requests[1].Body["namespace"])
+}
+
+func TestPineconeOutputDefaultNamespace(t *testing.T) {
+	s := &vectorServer{}
+	srv := s.serve(t)
+
+	conf, err := pineconeOutputConfig().ParseYAML(`
+host: `+srv.URL+`
+api_key: foo
+id: root = this.id
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newPineconeOutputFromConfig(conf)
+	require.NoError(t, err)
+
+	require.NoError(t, out.WriteBatch(context.Background(), testBatch("", `{"id":"a","embedding":[1]}`)))
+
+	require.Len(t, s.requests, 1)
+	_, exists := s.requests[0].Body["namespace"]
+	assert.False(t, exists)
+}
+
+func TestMilvusOutput(t *testing.T) {
+	s := &vectorServer{}
+	srv := s.serve(t)
+
+	conf, err := milvusOutputConfig().ParseYAML(`
+url: `+srv.URL+`
+token: root:Milvus
+database: foo
+collection: docs
+id: root = this.id
+vector_field: embedding
+payload: root.title = this.title
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newMilvusOutputFromConfig(conf)
+	require.NoError(t, err)
+
+	require.NoError(t, out.WriteBatch(context.Background(), testBatch("",
+		`{"id":1,"embedding":[1,2],"title":"first"}`,
+	)))
+
+	require.Len(t, s.requests, 1)
+	assert.Equal(t, "/v2/vectordb/entities/upsert", s.requests[0].Path)
+	assert.Equal(t, "Bearer root:Milvus", s.requests[0].Header.Get("Authorization"))
+	assert.Equal(t, map[string]interface{}{
+		"collectionName": "docs",
+		"dbName":         "foo",
+		"data": []interface{}{
+			map[string]interface{}{"id": 1.0, "embedding": []interface{}{1.0, 2.0}, "title": "first"},
+		},
+	}, s.requests[0].Body)
+
+	s.response = `{"code":1100,"message":"collection not found"}`
+	err = out.WriteBatch(context.Background(), testBatch("", `{"id":1,"embedding":[1,2]}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "collection not found")
+}
+
+func TestPGVectorUpsertStatement(t *testing.T) {
+	conf, err := pgvectorOutputConfig().ParseYAML(`
+dsn: postgres://localhost:5432/foo
+table: public.docs
+id: root = this.id
+payload: root.title = this.title
+payload_column: meta
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newPGVectorOutputFromConfig(conf)
+	require.NoError(t, err)
+
+	stmt, args, err := out.upsertStatement(recordGroup{
+		collection: "public.docs",
+		records: []vectorRecord{
+			{ID: "a", Vector: []float64{1, 0.5}, Payload: map[string]interface{}{"title": "first"}},
+			{ID: "b", Vector: []float64{-2}, Payload: map[string]interface{}{"title": "second"}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `INSERT INTO "public"."docs" ("id", "embedding", "meta") VALUES ($1, $2::vector, $3::jsonb), ($4, $5::vector, $6::jsonb) ON CONFLICT ("id") DO UPDATE SET "embedding" = EXCLUDED."embedding", "meta" = EXCLUDED."meta"`, stmt)
+	assert.Equal(t, []interface{}{
+		"a", "[1,0.5]", `{"title":"first"}`,
+		"b", "[-2]", `{"title":"second"}`,
+	}, args)
+}
+
+func TestPGVectorPayloadColumnRequired(t *testing.T) {
+	conf, err := pgvectorOutputConfig().ParseYAML(`
+dsn: postgres://localhost:5432/foo
+table: docs
+id: root = this.id
+payload: root.title = this.title
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newPGVectorOutputFromConfig(conf)
+	require.Error(t, err)
+}
@@ -0,0 +1,4 @@
+// Package vector contains components for building pipelines around vector
+// embeddings of messages, such as computing embeddings with model endpoints
+// and upserting them into vector stores.
+package vector
@@ -0,0 +1,483 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func embeddingsProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Integration").
+		Summary("Computes vector embeddings of the text of messages by calling a model endpoint, and adds them to the messages.").
+		Description(`
+The text of each message is obtained with the `+"[`text`](#text)"+` mapping, and the resulting embedding (an array of numbers) is written to the field `+"[`result_path`](#result_path)"+` of the message, which must be a JSON object. When `+"`result_path`"+` is empty the contents of the message are replaced with the embedding instead.
+
+Texts of a batch are sent to the endpoint in requests of up to `+"[`batch_size`](#batch_size)"+` texts, and the same text appearing multiple times within a batch is only sent once. When a `+"[`cache`](#cache)"+` is configured embeddings are stored in it, keyed by a hash of the model and text, and texts with a cached embedding are not sent at all. Requests to the endpoint can be throttled with a `+"[`rate_limit`](#rate_limit)"+` resource.
+
+When the embedding of a message cannot be obtained the message is flagged as having failed, and can be handled with [error handling patterns](/docs/configuration/error_handling).
+
+### Providers
+
+The `+"`openai`"+` provider sends requests in the format of the [OpenAI embeddings API](https://platform.openai.com/docs/api-reference/embeddings), which is also served by many self hosted model servers.
+
+The `+"`http`"+` provider sends a JSON object of the form `+"`{\"inputs\":[\"text\"]}`"+` and expects a JSON array of embeddings in response, which is the format of the [Hugging Face Text Embeddings Inference](https://github.com/huggingface/text-embeddings-inference) server. This can be used in order to compute embeddings locally with ONNX models, as Benthos does not run models itself.`).
+		Field(service.NewStringAnnotatedEnumField("provider", map[string]string{
+			"openai": "An OpenAI compatible embeddings API.",
+			"http":   "A Text Embeddings Inference compatible HTTP API.",
+		}).
+			Description("The format of requests made to the endpoint.").
+			Default("openai")).
+		Field(service.NewStringField("url").
+			Description("The URL of the embeddings endpoint.").
+			Default("https://api.openai.com/v1/embeddings").
+			Example("http://localhost:8080/embed")).
+		Field(service.NewStringField("api_key").
+			Description("An optional key sent as a bearer token with each request.").
+			Default("")).
+		Field(service.NewStringField("model").
+			Description("The model to compute embeddings with, which is sent with requests of the `openai` provider and is part of the cache key of embeddings.").
+			Default("").
+			Example("text-embedding-3-small")).
+		Field(service.NewBloblangField("text").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) that provides the text to compute the embedding of, which must result in a string.").
+			Default("root = this.text")).
+		Field(service.NewStringField("result_path").
+			Description("The dot separated path of the field to write the embedding to. When empty the contents of the message are replaced with the embedding.").
+			Default("embedding")).
+		Field(service.NewIntField("batch_size").
+			Description("The maximum number of texts to send within a single request.").
+			Default(32)).
+		Field(service.NewStringField("rate_limit").
+			Description("An optional [`rate_limit` resource](/docs/components/rate_limits/about) to throttle requests by.").
+			Default("").
+			Advanced()).
+		Field(service.NewStringField("cache").
+			Description("An optional [`cache` resource](/docs/components/caches/about) to store embeddings in, in order to avoid computing the embedding of the same text repeatedly.").
+			Default("")).
+		Field(service.NewDurationField("cache_ttl").
+			Description("An optional TTL of cached embeddings, when omitted the default TTL of the cache is used.").
+			Optional().
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for a request to complete.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewStringMapField("headers").
+			Description("Optional headers to add to each request.").
+			Default(map[string]interface{}{}).
+			Advanced()).
+		Example("Embedding Documents", `
+Here we add embeddings to documents using an OpenAI compatible API, caching the embeddings of repeated texts in memory:`,
+			`
+pipeline:
+  processors:
+    - embeddings:
+        api_key: ${OPENAI_API_KEY}
+        model: text-embedding-3-small
+        text: root = this.title + "\n" + this.body
+        result_path: vector
+        cache: embeddings_cache
+
+cache_resources:
+  - label: embeddings_cache
+    memory:
+      default_ttl: 24h
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"embeddings", embeddingsProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newEmbeddingsProcessorFromConfig(conf, mgr)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type embeddingsProcessor struct {
+	provider   string
+	url        string
+	apiKey     string
+	model      string
+	headers    map[string]string
+	text       *bloblang.Executor
+	resultPath []string
+	batchSize  int
+	rateLimit  string
+	cache      string
+	cacheTTL   *time.Duration
+
+	client *http.Client
+	mgr    *service.Resources
+	log    *service.Logger
+}
+
+func newEmbeddingsProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*embeddingsProcessor, error) {
+	e := &embeddingsProcessor{
+		mgr: mgr,
+		log: mgr.Logger(),
+	}
+
+	var err error
+	if e.provider, err = conf.FieldString("provider"); err != nil {
+		return nil, err
+	}
+	if e.url, err = conf.FieldString("url"); err != nil {
+		return nil, err
+	}
+	if e.apiKey, err = conf.FieldString("api_key"); err != nil {
+		return nil, err
+	}
+	if e.model, err = conf.FieldString("model"); err != nil {
+		return nil, err
+	}
+	if e.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+	if e.text, err = conf.FieldBloblang("text"); err != nil {
+		return nil, err
+	}
+
+	resultPath, err := conf.FieldString("result_path")
+	if err != nil {
+		return nil, err
+	}
+	if resultPath != "" {
+		e.resultPath = gabs.DotPathToSlice(resultPath)
+	}
+
+	if e.batchSize, err = conf.FieldInt("batch_size"); err != nil {
+		return nil, err
+	}
+	if e.batchSize < 1 {
+		return nil, errors.New("batch_size must be greater than zero")
+	}
+
+	if e.rateLimit, err = conf.FieldString("rate_limit"); err != nil {
+		return nil, err
+	}
+	if e.rateLimit != "" && !mgr.HasRateLimit(e.rateLimit) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", e.rateLimit)
+	}
+
+	if e.cache, err = conf.FieldString("cache"); err != nil {
+		return nil, err
+	}
+	if e.cache != "" && !mgr.HasCache(e.cache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", e.cache)
+	}
+	if conf.Contains("cache_ttl") {
+		ttl, err := conf.FieldDuration("cache_ttl")
+		if err != nil {
+			return nil, err
+		}
+		e.cacheTTL = &ttl
+	}
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	e.client = &http.Client{Timeout: timeout}
+	return e, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (e *embeddingsProcessor) cacheKey(text string) string {
+	sum := sha256.Sum256([]byte(e.model + "\x00" + text))
+	return "embeddings_" + hex.EncodeToString(sum[:])
+}
+
+func (e *embeddingsProcessor) cacheGet(ctx context.Context, text string) ([]float64, bool) {
+	var vec []float64
+	var found bool
+	if err := e.mgr.AccessCache(ctx, e.cache, func(c service.Cache) {
+		b, err := c.Get(ctx, e.cacheKey(text))
+		if err != nil {
+			if !errors.Is(err, service.ErrKeyNotFound) {
+				e.log.Warnf("Failed to read embedding from cache: %v", err)
+			}
+			return
+		}
+		if err := json.Unmarshal(b, &vec); err != nil {
+			e.log.Warnf("Failed to parse cached embedding: %v", err)
+			return
+		}
+		found = true
+	}); err != nil {
+		e.log.Warnf("Failed to access cache: %v", err)
+	}
+	return vec, found
+}
+
+func (e *embeddingsProcessor) cacheSet(ctx context.Context, text string, vec []float64) {
+	b, err := json.Marshal(vec)
+	if err != nil {
+		return
+	}
+	if err := e.mgr.AccessCache(ctx, e.cache, func(c service.Cache) {
+		if err := c.Set(ctx, e.cacheKey(text), b, e.cacheTTL); err != nil {
+			e.log.Warnf("Failed to write embedding to cache: %v", err)
+		}
+	}); err != nil {
+		e.log.Warnf("Failed to access cache: %v", err)
+	}
+}
+
+func (e *embeddingsProcessor) waitForAccess(ctx context.Context) error {
+	if e.rateLimit == "" {
+		return nil
+	}
+	for {
+		var period time.Duration
+		var err error
+		if rerr := e.mgr.AccessRateLimit(ctx, e.rateLimit, func(rl service.RateLimit) {
+			period, err = rl.Access(ctx)
+		}); rerr != nil {
+			err = rerr
+		}
+		if err != nil {
+			e.log.Errorf("Rate limit error: %v", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(period):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (e *embeddingsProcessor) request(ctx context.Context, texts []string) ([][]float64, error) {
+	var reqBody interface{}
+	if e.provider == "openai" {
+		reqBody = map[string]interface{}{
+			"model": e.model,
+			"input": texts,
+		}
+	} else {
+		reqBody = map[string]interface{}{
+			"inputs": texts,
+		}
+	}
+
+	reqBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("request returned status %v: %s", res.StatusCode, bytes.TrimSpace(resBytes))
+	}
+
+	var vecs [][]float64
+	if e.provider == "openai" {
+		var openaiRes struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(resBytes, &openaiRes); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		vecs = make([][]float64, len(texts))
+		for _, d := range openaiRes.Data {
+			if d.Index < 0 || d.Index >= len(vecs) {
+				return nil, fmt.Errorf("response contained an embedding with unexpected index %v", d.Index)
+			}
+			vecs[d.Index] = d.Embedding
+		}
+	} else if err := json.Unmarshal(resBytes, &vecs); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(vecs) != len(texts) {
+		return nil, fmt.Errorf("response contained %v embeddings, expected %v", len(vecs), len(texts))
+	}
+	for i, v := range vecs {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("response is missing the embedding of text %v", i)
+		}
+	}
+	return vecs, nil
+}
+
+func (e *embeddingsProcessor) setResult(msg *service.Message, vec []float64) error {
+	arr := make([]interface{}, len(vec))
+	for i, v := range vec {
+		arr[i] = v
+	}
+	if len(e.resultPath) == 0 {
+		msg.SetStructured(arr)
+		return nil
+	}
+
+	v, err := msg.AsStructuredMut()
+	if err != nil {
+		return fmt.Errorf("failed to parse message as a JSON object: %w", err)
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return fmt.Errorf("expected message to be a JSON object, got %T", v)
+	}
+	gObj := gabs.Wrap(v)
+	if _, err := gObj.Set(arr, e.resultPath...); err != nil {
+		return fmt.Errorf("failed to set embedding at '%v': %w", strings.Join(e.resultPath, "."), err)
+	}
+	msg.SetStructured(gObj.Data())
+	return nil
+}
+
+func (e *embeddingsProcessor) queryText(batch service.MessageBatch, i int) (string, error) {
+	res, err := batch.BloblangQuery(i, e.text)
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", errors.New("mapping resulted in a deleted message")
+	}
+	// Mappings that result in raw bytes (such as content()) are not valid
+	// JSON documents, and are used as they are.
+	v, err := res.AsStructured()
+	if err != nil {
+		b, err := res.AsBytes()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected mapping to result in a string, got %T", v)
+	}
+	return s, nil
+}
+
+func (e *embeddingsProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	batch = batch.Copy()
+
+	texts := make([]string, len(batch))
+	failed := make([]bool, len(batch))
+	vecs := map[string][]float64{}
+
+	var pending []string
+	pendingSet := map[string]struct{}{}
+	for i := range batch {
+		text, err := e.queryText(batch, i)
+		if err != nil {
+			e.log.Errorf("Text mapping failed: %v", err)
+			batch[i].SetError(fmt.Errorf("text mapping failed: %w", err))
+			failed[i] = true
+			continue
+		}
+		texts[i] = text
+
+		if _, exists := pendingSet[texts[i]]; exists {
+			continue
+		}
+		if _, exists := vecs[texts[i]]; exists {
+			continue
+		}
+		if e.cache != "" {
+			if vec, found := e.cacheGet(ctx, texts[i]); found {
+				vecs[texts[i]] = vec
+				continue
+			}
+		}
+		pendingSet[texts[i]] = struct{}{}
+		pending = append(pending, texts[i])
+	}
+
+	requestErrs := map[string]error{}
+	for start := 0; start < len(pending); start += e.batchSize {
+		end := start + e.batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		chunk := pending[start:end]
+
+		err := e.waitForAccess(ctx)
+		var res [][]float64
+		if err == nil {
+			res, err = e.request(ctx, chunk)
+		}
+		if err != nil {
+			e.log.Errorf("Failed to obtain embeddings: %v", err)
+			for _, t := range chunk {
+				requestErrs[t] = err
+			}
+			continue
+		}
+		for i, t := range chunk {
+			vecs[t] = res[i]
+			if e.cache != "" {
+				e.cacheSet(ctx, t, res[i])
+			}
+		}
+	}
+
+	for i, msg := range batch {
+		if failed[i] {
+			continue
+		}
+		if err, exists := requestErrs[texts[i]]; exists {
+			msg.SetError(fmt.Errorf("failed to obtain embedding: %w", err))
+			continue
+		}
+		if err := e.setResult(msg, vecs[texts[i]]); err != nil {
+			e.log.Errorf("Failed to add embedding: %v", err)
+			msg.SetError(err)
+		}
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (e *embeddingsProcessor) Close(ctx context.Context) error {
+	return nil
+}
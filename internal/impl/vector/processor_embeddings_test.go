@@ -0,0 +1,207 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/generic"
+	_ "github.com/benthosdev/benthos/v4/public/components/legacy"
+)
+
+type embeddingsServer struct {
+	mut      sync.Mutex
+	requests []map[string]interface{}
+	headers  []http.Header
+}
+
+func (s *embeddingsServer) serve(t *testing.T, openai bool) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		s.mut.Lock()
+		s.requests = append(s.requests, body)
+		s.headers = append(s.headers, r.Header.Clone())
+		s.mut.Unlock()
+
+		key := "inputs"
+		if openai {
+			key = "input"
+		}
+
+		// Each embedding is the length of the text followed by the index.
+		var vecs [][]float64
+		for i, t := range body[key].([]interface{}) {
+			if t == "fail" {
+				http.Error(w, "nope", http.StatusBadRequest)
+				return
+			}
+			vecs = append(vecs, []float64{float64(len(t.(string))), float64(i)})
+		}
+
+		if !openai {
+			_ = json.NewEncoder(w).Encode(vecs)
+			return
+		}
+		var data []interface{}
+		for i := len(vecs) - 1; i >= 0; i-- {
+			data = append(data, map[string]interface{}{"index": i, "embedding": vecs[i]})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestEmbeddingsProcessor(t *testing.T, confStr string) *embeddingsProcessor {
+	t.Helper()
+
+	conf, err := embeddingsProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newEmbeddingsProcessorFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	return proc
+}
+
+func TestEmbeddingsOpenAI(t *testing.T) {
+	s := &embeddingsServer{}
+	srv := s.serve(t, true)
+
+	proc := newTestEmbeddingsProcessor(t, `
+url: `+srv.URL+`
+api_key: fookey
+model: foomodel
+result_path: doc.vector
+batch_size: 2
+`)
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"text":"a"}`)),
+		service.NewMessage([]byte(`{"text":"bb"}`)),
+		service.NewMessage([]byte(`{"text":"a"}`)),
+		service.NewMessage([]byte(`{"text":"ccc"}`)),
+		service.NewMessage([]byte(`{"nope":"ccc"}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 5)
+
+	for i, exp := range []string{
+		`{"doc":{"vector":[1,0]},"text":"a"}`,
+		`{"doc":{"vector":[2,1]},"text":"bb"}`,
+		`{"doc":{"vector":[1,0]},"text":"a"}`,
+		`{"doc":{"vector":[3,0]},"text":"ccc"}`,
+	} {
+		require.NoError(t, batches[0][i].GetError())
+		b, err := batches[0][i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(b), i)
+	}
+	require.Error(t, batches[0][4].GetError())
+	assert.Contains(t, batches[0][4].GetError().Error(), "text mapping failed")
+
+	require.Len(t, s.requests, 2)
+	assert.Equal(t, map[string]interface{}{
+		"model": "foomodel",
+		"input": []interface{}{"a", "bb"},
+	}, s.requests[0])
+	assert.Equal(t, []interface{}{"ccc"}, s.requests[1]["input"])
+	assert.Equal(t, "Bearer fookey", s.headers[0].Get("Authorization"))
+}
+
+func TestEmbeddingsHTTP(t *testing.T) {
+	s := &embeddingsServer{}
+	srv := s.serve(t, false)
+
+	proc := newTestEmbeddingsProcessor(t, `
+provider: http
+url: `+srv.URL+`
+text: root = content()
+result_path: ""
+batch_size: 1
+headers:
+  X-Foo: bar
+`)
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`hello`)),
+		service.NewMessage([]byte(`fail`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches[0], 2)
+
+	require.NoError(t, batches[0][0].GetError())
+	b, err := batches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `[5,0]`, string(b))
+
+	require.Error(t, batches[0][1].GetError())
+	assert.Contains(t, batches[0][1].GetError().Error(), "request returned status 400: nope")
+
+	require.Len(t, s.requests, 2)
+	assert.Equal(t, map[string]interface{}{"inputs": []interface{}{"hello"}}, s.requests[0])
+	assert.Equal(t, "bar", s.headers[0].Get("X-Foo"))
+}
+
+func TestEmbeddingsCache(t *testing.T) {
+	s := &embeddingsServer{}
+	srv := s.serve(t, true)
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.AddCacheYAML(`
+label: foocache
+memory: {}
+`))
+	require.NoError(t, builder.AddProcessorYAML(`
+embeddings:
+  url: `+srv.URL+`
+  model: foomodel
+  cache: foocache
+`))
+
+	produce, err := builder.AddProducerFunc()
+	require.NoError(t, err)
+
+	var mut sync.Mutex
+	var results []string
+	require.NoError(t, builder.AddConsumerFunc(func(ctx context.Context, msg *service.Message) error {
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		mut.Lock()
+		results = append(results, string(b))
+		mut.Unlock()
+		return nil
+	}))
+
+	stream, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	go func() {
+		require.NoError(t, produce(ctx, service.NewMessage([]byte(`{"text":"foo"}`))))
+		require.NoError(t, produce(ctx, service.NewMessage([]byte(`{"text":"foo"}`))))
+		require.NoError(t, stream.StopWithin(time.Second*5))
+	}()
+	require.NoError(t, stream.Run(ctx))
+
+	assert.Equal(t, []string{
+		`{"embedding":[3,0],"text":"foo"}`,
+		`{"embedding":[3,0],"text":"foo"}`,
+	}, results)
+	assert.Len(t, s.requests, 1)
+}
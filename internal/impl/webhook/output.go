@@ -0,0 +1,620 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func webhookOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Delivers messages to webhook endpoints, with rate limiting, retries and circuit breaking tracked separately for each endpoint.").
+		Description(`
+This output is designed for fanning out events to a large number of third party endpoints, such as customer webhooks, where one slow or broken endpoint must not affect delivery to the others. The URL of each message is interpolated, and all rate limiting, retry and circuit breaker state is keyed by the resulting URL, including its path and query, since distinct webhooks commonly share a host.
+
+The state of an endpoint that has not been written to for the duration of ` + "`endpoint_idle_timeout`" + ` is discarded in order to bound memory usage when URLs are highly variable. The state of an endpoint with an open circuit breaker is retained until the breaker resets.
+
+Messages of a batch are grouped by their resolved URL, and each group is delivered in parallel with the other groups. Within a group messages are delivered in order as individual requests, or as a single request containing a JSON array when ` + "`batch_as_array`" + ` is set.
+
+A request is considered successful when the endpoint returns a 2XX status code. Failed requests are retried with an exponential back off that includes random jitter, and after the configured number of consecutive failures the circuit breaker of an endpoint opens, at which point messages for that endpoint are rejected immediately until the reset timeout has passed. Only the messages that could not be delivered are rejected, and therefore messages delivered successfully within the same batch are not sent again.
+
+### Signatures
+
+When a ` + "`signature.secret`" + ` is configured each request includes a header containing a hex encoded HMAC of the request body, allowing receivers to verify that the request originated from you. When a ` + "`signature.timestamp_header`" + ` is also configured the current unix timestamp is added as a header and the signed payload becomes ` + "`<timestamp>.<body>`" + `, which allows receivers to reject replayed requests.`).
+		Field(service.NewInterpolatedStringField("url").
+			Description("The URL to deliver each message to.").
+			Example(`${! meta("webhook_url") }`).
+			Example(`https://example.com/hooks/${! json("customer_id") }`)).
+		Field(service.NewStringField("verb").
+			Description("A verb to use for requests.").
+			Default("POST").
+			Example("PUT")).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to requests, values support [interpolation functions](/docs/configuration/interpolation#bloblang-queries).").
+			Default(map[string]interface{}{
+				"Content-Type": "application/json",
+			}).
+			Example(map[string]interface{}{
+				"Content-Type":  "application/json",
+				"X-Customer-ID": `${! json("customer_id") }`,
+			})).
+		Field(service.NewDurationField("timeout").
+			Description("A timeout for each individual request.").
+			Default("5s")).
+		Field(service.NewBloblangField("rate_limit").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) executed for each message that returns the maximum number of requests per second permitted for the endpoint of the message. The limit of an endpoint is updated each time the mapping returns a different value, and a value of zero or less removes the limit.").
+			Example(`root = if meta("webhook_url").has_prefix("https://slow.example.com") { 1 } else { 50 }`).
+			Example(`root = this.customer.tier == "premium" ? 100 : 10`).
+			Optional()).
+		Field(service.NewObjectField("signature",
+			service.NewStringField("secret").
+				Description("A secret used to sign requests, when empty requests are not signed.").
+				Default(""),
+			service.NewStringEnumField("algorithm", "sha1", "sha256", "sha512").
+				Description("The hashing algorithm of the HMAC.").
+				Default("sha256"),
+			service.NewStringField("header").
+				Description("The header to place the signature in.").
+				Default("X-Webhook-Signature"),
+			service.NewStringField("prefix").
+				Description("An optional prefix to add to the signature value.").
+				Default("").
+				Example("sha256="),
+			service.NewStringField("timestamp_header").
+				Description("An optional header to place the unix timestamp of the request in, when set the timestamp is included in the signed payload.").
+				Default("").
+				Example("X-Webhook-Timestamp"),
+		).Description("Sign requests with an HMAC of their body.").Advanced()).
+		Field(service.NewIntField("max_retries").
+			Description("The maximum number of retry attempts for a request before it is considered failed.").
+			Default(3)).
+		Field(service.NewBackOffField("backoff", false, nil).Advanced()).
+		Field(service.NewObjectField("circuit_breaker",
+			service.NewIntField("failure_threshold").
+				Description("The number of consecutive failed requests to an endpoint before its circuit breaker opens. Set to zero in order to disable circuit breaking.").
+				Default(5),
+			service.NewDurationField("reset_timeout").
+				Description("The period of time an open circuit breaker waits before allowing a trial request through to the endpoint.").
+				Default("30s"),
+		).Description("Stop sending requests to endpoints that are consistently failing.").Advanced()).
+		Field(service.NewDurationField("endpoint_idle_timeout").
+			Description("The period of time after which the rate limiting and circuit breaker state of an endpoint that has not been written to is discarded.").
+			Default("10m").
+			Advanced()).
+		Field(service.NewBoolField("batch_as_array").
+			Description("Whether messages of a batch that share the same URL should be sent as a single request containing a JSON array of their contents, rather than as individual requests. Messages that are not valid JSON are added to the array as strings.").
+			Default(false)).
+		Field(service.NewTLSField("tls")).
+		Field(service.NewHTTPProxyField("proxy")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to be sending in parallel at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching"))
+}
+
+func init() {
+	err := service.RegisterBatchOutput("webhook", webhookOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newWebhookWriterFromConfig(conf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// endpoint holds the delivery state of a single webhook URL.
+type endpoint struct {
+	mut sync.Mutex
+
+	// Rate limiting
+	rate     float64
+	nextSlot time.Time
+
+	// Circuit breaking
+	failures  int
+	openUntil time.Time
+
+	// Eviction, protected by the endpoints mutex of the writer.
+	inUse    int
+	lastUsed time.Time
+}
+
+// idle returns whether the endpoint can be discarded without losing state that
+// is still relevant.
+func (e *endpoint) idle(now time.Time, timeout time.Duration) bool {
+	if e.inUse > 0 || now.Sub(e.lastUsed) < timeout {
+		return false
+	}
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	return !e.openUntil.After(now)
+}
+
+// awaitSlot blocks until the endpoint permits another request according to
+// its current rate limit.
+func (e *endpoint) awaitSlot(ctx context.Context) error {
+	e.mut.Lock()
+	if e.rate <= 0 {
+		e.mut.Unlock()
+		return nil
+	}
+	now := time.Now()
+	slot := e.nextSlot
+	if slot.Before(now) {
+		slot = now
+	}
+	e.nextSlot = slot.Add(time.Duration(float64(time.Second) / e.rate))
+	e.mut.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (e *endpoint) setRate(rate float64) {
+	e.mut.Lock()
+	e.rate = rate
+	e.mut.Unlock()
+}
+
+// allow returns whether a request may be attempted against the endpoint. Once
+// the reset timeout of an open circuit has passed a trial request is permitted.
+func (e *endpoint) allow(threshold int, resetTimeout time.Duration) bool {
+	if threshold <= 0 {
+		return true
+	}
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	if e.failures < threshold {
+		return true
+	}
+	now := time.Now()
+	if now.Before(e.openUntil) {
+		return false
+	}
+	// Half open: let one request through and keep others out until it either
+	// succeeds or the timeout passes again.
+	e.openUntil = now.Add(resetTimeout)
+	return true
+}
+
+func (e *endpoint) record(err error, threshold int, resetTimeout time.Duration) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	if err == nil {
+		e.failures = 0
+		e.openUntil = time.Time{}
+		return
+	}
+	e.failures++
+	if threshold > 0 && e.failures == threshold {
+		e.openUntil = time.Now().Add(resetTimeout)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type webhookHeader struct {
+	key   string
+	value *service.InterpolatedString
+}
+
+type webhookWriter struct {
+	log *service.Logger
+
+	url          *service.InterpolatedString
+	verb         string
+	headers      []webhookHeader
+	rateLimit    *bloblang.Executor
+	batchAsArray bool
+
+	sigSecret          []byte
+	sigHashFn          func() hash.Hash
+	sigHeader          string
+	sigPrefix          string
+	sigTimestampHeader string
+
+	maxRetries   int
+	backoffConf  backoff.ExponentialBackOff
+	cbThreshold  int
+	cbResetAfter time.Duration
+
+	client *http.Client
+
+	endpointsMut  sync.Mutex
+	endpoints     map[string]*endpoint
+	idleTimeout   time.Duration
+	nextIdleSweep time.Time
+}
+
+func newWebhookWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*webhookWriter, error) {
+	w := &webhookWriter{
+		log:       log,
+		endpoints: map[string]*endpoint{},
+	}
+
+	var err error
+	if w.url, err = conf.FieldInterpolatedString("url"); err != nil {
+		return nil, err
+	}
+	if w.verb, err = conf.FieldString("verb"); err != nil {
+		return nil, err
+	}
+
+	headers, err := conf.FieldStringMap("headers")
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		value, err := service.NewInterpolatedString(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse header '%v' expression: %w", k, err)
+		}
+		w.headers = append(w.headers, webhookHeader{key: k, value: value})
+	}
+
+	if conf.Contains("rate_limit") {
+		if w.rateLimit, err = conf.FieldBloblang("rate_limit"); err != nil {
+			return nil, err
+		}
+	}
+	if w.batchAsArray, err = conf.FieldBool("batch_as_array"); err != nil {
+		return nil, err
+	}
+
+	secret, err := conf.FieldString("signature", "secret")
+	if err != nil {
+		return nil, err
+	}
+	if secret != "" {
+		w.sigSecret = []byte(secret)
+		algorithm, err := conf.FieldString("signature", "algorithm")
+		if err != nil {
+			return nil, err
+		}
+		switch algorithm {
+		case "sha1":
+			w.sigHashFn = sha1.New
+		case "sha256":
+			w.sigHashFn = sha256.New
+		case "sha512":
+			w.sigHashFn = sha512.New
+		default:
+			return nil, fmt.Errorf("signature algorithm not recognised: %v", algorithm)
+		}
+		if w.sigHeader, err = conf.FieldString("signature", "header"); err != nil {
+			return nil, err
+		}
+		if w.sigPrefix, err = conf.FieldString("signature", "prefix"); err != nil {
+			return nil, err
+		}
+		if w.sigTimestampHeader, err = conf.FieldString("signature", "timestamp_header"); err != nil {
+			return nil, err
+		}
+	}
+
+	if w.maxRetries, err = conf.FieldInt("max_retries"); err != nil {
+		return nil, err
+	}
+	boff, err := conf.FieldBackOff("backoff")
+	if err != nil {
+		return nil, err
+	}
+	w.backoffConf = *boff
+
+	if w.cbThreshold, err = conf.FieldInt("circuit_breaker", "failure_threshold"); err != nil {
+		return nil, err
+	}
+	if w.cbResetAfter, err = conf.FieldDuration("circuit_breaker", "reset_timeout"); err != nil {
+		return nil, err
+	}
+	if w.idleTimeout, err = conf.FieldDuration("endpoint_idle_timeout"); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	tlsConf, err := conf.FieldTLS("tls")
+	if err != nil {
+		return nil, err
+	}
+	proxyFn, err := conf.FieldHTTPProxy("proxy")
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConf
+	if proxyFn != nil {
+		transport.Proxy = proxyFn
+	}
+	w.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	return w, nil
+}
+
+func (w *webhookWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+// getEndpoint returns the endpoint of a URL, which must be released once the
+// caller has finished with it.
+func (w *webhookWriter) getEndpoint(url string) *endpoint {
+	w.endpointsMut.Lock()
+	defer w.endpointsMut.Unlock()
+
+	now := time.Now()
+	if w.idleTimeout > 0 && !now.Before(w.nextIdleSweep) {
+		for k, e := range w.endpoints {
+			if e.idle(now, w.idleTimeout) {
+				delete(w.endpoints, k)
+			}
+		}
+		w.nextIdleSweep = now.Add(w.idleTimeout)
+	}
+
+	e, exists := w.endpoints[url]
+	if !exists {
+		e = &endpoint{}
+		w.endpoints[url] = e
+	}
+	e.inUse++
+	e.lastUsed = now
+	return e
+}
+
+func (w *webhookWriter) releaseEndpoint(e *endpoint) {
+	w.endpointsMut.Lock()
+	e.inUse--
+	e.lastUsed = time.Now()
+	w.endpointsMut.Unlock()
+}
+
+func (w *webhookWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	var urls []string
+	groups := map[string][]int{}
+	for i := range batch {
+		url := batch.InterpolatedString(i, w.url)
+		if _, exists := groups[url]; !exists {
+			urls = append(urls, url)
+		}
+		groups[url] = append(groups[url], i)
+	}
+
+	// Each message has its own error so that a failed delivery doesn't
+	// result in messages that were already delivered being sent again.
+	var wg sync.WaitGroup
+	errs := make([]error, len(batch))
+	for _, url := range urls {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			w.writeGroup(ctx, url, batch, groups[url], errs)
+		}(url)
+	}
+	wg.Wait()
+
+	var bErr *service.BatchError
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if bErr == nil {
+			bErr = service.NewBatchError(batch, err)
+		}
+		bErr.Failed(i, err)
+	}
+	if bErr != nil {
+		return bErr
+	}
+	return nil
+}
+
+// writeGroup delivers the messages of a batch that share a URL, setting the
+// error of each message that could not be delivered. Delivery continues after
+// a failure.
+func (w *webhookWriter) writeGroup(ctx context.Context, url string, batch service.MessageBatch, indexes []int, errs []error) {
+	e := w.getEndpoint(url)
+	defer w.releaseEndpoint(e)
+
+	failAll := func(err error) {
+		err = fmt.Errorf("failed to deliver to '%v': %w", url, err)
+		for _, index := range indexes {
+			errs[index] = err
+		}
+	}
+
+	if w.rateLimit != nil {
+		rate, err := w.resolveRate(batch, indexes[0])
+		if err != nil {
+			failAll(err)
+			return
+		}
+		e.setRate(rate)
+	}
+
+	if w.batchAsArray {
+		body, err := w.arrayBody(batch, indexes)
+		if err == nil {
+			err = w.deliver(ctx, e, url, body, batch, indexes[0])
+		}
+		if err != nil {
+			failAll(err)
+		}
+		return
+	}
+	for _, index := range indexes {
+		body, err := batch[index].AsBytes()
+		if err == nil {
+			err = w.deliver(ctx, e, url, body, batch, index)
+		}
+		if err != nil {
+			errs[index] = fmt.Errorf("failed to deliver to '%v': %w", url, err)
+		}
+	}
+}
+
+func (w *webhookWriter) resolveRate(batch service.MessageBatch, index int) (float64, error) {
+	res, err := batch.BloblangQuery(index, w.rateLimit)
+	if err != nil {
+		return 0, fmt.Errorf("rate limit mapping failed: %w", err)
+	}
+	if res == nil {
+		return 0, nil
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		return 0, fmt.Errorf("rate limit mapping failed: %w", err)
+	}
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case int64:
+		return float64(t), nil
+	case uint64:
+		return float64(t), nil
+	case json.Number:
+		return t.Float64()
+	}
+	return 0, fmt.Errorf("rate limit mapping returned non-numerical type: %T", v)
+}
+
+func (w *webhookWriter) arrayBody(batch service.MessageBatch, indexes []int) ([]byte, error) {
+	items := make([]interface{}, 0, len(indexes))
+	for _, index := range indexes {
+		v, err := batch[index].AsStructured()
+		if err != nil {
+			b, berr := batch[index].AsBytes()
+			if berr != nil {
+				return nil, berr
+			}
+			v = string(b)
+		}
+		items = append(items, v)
+	}
+	return json.Marshal(items)
+}
+
+func (w *webhookWriter) deliver(ctx context.Context, e *endpoint, url string, body []byte, batch service.MessageBatch, index int) error {
+	boff := w.backoffConf
+	boff.Reset()
+
+	for attempt := 0; ; attempt++ {
+		if !e.allow(w.cbThreshold, w.cbResetAfter) {
+			return errCircuitOpen
+		}
+		if err := e.awaitSlot(ctx); err != nil {
+			return err
+		}
+
+		err := w.send(ctx, url, body, batch, index)
+		e.record(err, w.cbThreshold, w.cbResetAfter)
+		if err == nil {
+			return nil
+		}
+		if attempt >= w.maxRetries {
+			return err
+		}
+
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			return err
+		}
+		w.log.Debugf("Retrying request to '%v' in %v after error: %v", url, wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (w *webhookWriter) send(ctx context.Context, url string, body []byte, batch service.MessageBatch, index int) error {
+	req, err := http.NewRequestWithContext(ctx, w.verb, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for _, h := range w.headers {
+		req.Header.Set(h.key, batch.InterpolatedString(index, h.value))
+	}
+	if w.sigSecret != nil {
+		req.Header.Set(w.sigHeader, w.sign(req.Header, body, time.Now()))
+	}
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("received unexpected status code: %v", res.StatusCode)
+	}
+	return nil
+}
+
+// sign calculates the signature of a request body, adding a timestamp header
+// when configured to.
+func (w *webhookWriter) sign(header http.Header, body []byte, ts time.Time) string {
+	mac := hmac.New(w.sigHashFn, w.sigSecret)
+	if w.sigTimestampHeader != "" {
+		tsStr := strconv.FormatInt(ts.Unix(), 10)
+		header.Set(w.sigTimestampHeader, tsStr)
+		_, _ = mac.Write([]byte(tsStr + "."))
+	}
+	_, _ = mac.Write(body)
+	return w.sigPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *webhookWriter) Close(ctx context.Context) error {
+	w.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,258 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testWebhookWriter(t *testing.T, confStr string) *webhookWriter {
+	t.Helper()
+
+	conf, err := webhookOutputConfig().ParseYAML(confStr, service.NewEnvironment())
+	require.NoError(t, err)
+
+	w, err := newWebhookWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+	return w
+}
+
+func TestWebhookGroupsByURL(t *testing.T) {
+	var mut sync.Mutex
+	received := map[string][]string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mut.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], string(b))
+		mut.Unlock()
+	}))
+	defer ts.Close()
+
+	w := testWebhookWriter(t, `
+url: `+ts.URL+`/${! json("id") }
+batch_as_array: true
+`)
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"a","v":1}`)),
+		service.NewMessage([]byte(`{"id":"b","v":2}`)),
+		service.NewMessage([]byte(`{"id":"a","v":3}`)),
+	}))
+
+	mut.Lock()
+	defer mut.Unlock()
+	assert.Equal(t, map[string][]string{
+		"/a": {`[{"id":"a","v":1},{"id":"a","v":3}]`},
+		"/b": {`[{"id":"b","v":2}]`},
+	}, received)
+}
+
+func TestWebhookPartialFailure(t *testing.T) {
+	var mut sync.Mutex
+	var received []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if string(b) == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mut.Lock()
+		received = append(received, r.URL.Path+":"+string(b))
+		mut.Unlock()
+	}))
+	defer ts.Close()
+
+	w := testWebhookWriter(t, `
+url: `+ts.URL+`/${! meta("id") }
+max_retries: 0
+`)
+
+	newMsg := func(id, content string) *service.Message {
+		m := service.NewMessage([]byte(content))
+		m.MetaSet("id", id)
+		return m
+	}
+
+	err := w.WriteBatch(context.Background(), service.MessageBatch{
+		newMsg("a", "first"),
+		newMsg("a", "fail"),
+		newMsg("a", "third"),
+		newMsg("b", "fourth"),
+	})
+	require.Error(t, err)
+
+	var bErr *service.BatchError
+	require.ErrorAs(t, err, &bErr)
+	assert.Equal(t, 1, bErr.IndexedErrors())
+
+	mut.Lock()
+	defer mut.Unlock()
+	assert.ElementsMatch(t, []string{"/a:first", "/a:third", "/b:fourth"}, received)
+}
+
+func TestWebhookSignature(t *testing.T) {
+	var sig, ts string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig = r.Header.Get("X-Sig")
+		ts = r.Header.Get("X-Ts")
+	}))
+	defer srv.Close()
+
+	w := testWebhookWriter(t, `
+url: `+srv.URL+`
+signature:
+  secret: foo
+  header: X-Sig
+  prefix: sha256=
+  timestamp_header: X-Ts
+`)
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`hello world`)),
+	}))
+
+	mac := hmac.New(sha256.New, []byte("foo"))
+	_, _ = mac.Write([]byte(ts + ".hello world"))
+	assert.NotEmpty(t, ts)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), sig)
+}
+
+func TestWebhookRetriesAndCircuitBreaker(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	w := testWebhookWriter(t, `
+url: `+srv.URL+`
+max_retries: 2
+backoff:
+  initial_interval: 1ms
+  max_interval: 1ms
+circuit_breaker:
+  failure_threshold: 3
+  reset_timeout: 1h
+`)
+
+	batch := service.MessageBatch{service.NewMessage([]byte(`hello world`))}
+
+	require.Error(t, w.WriteBatch(context.Background(), batch))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	err := w.WriteBatch(context.Background(), batch)
+	require.ErrorIs(t, err, errCircuitOpen)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookRateLimit(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer srv.Close()
+
+	w := testWebhookWriter(t, `
+url: `+srv.URL+`
+rate_limit: 'root = 20'
+`)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`a`)),
+		service.NewMessage([]byte(`b`)),
+		service.NewMessage([]byte(`c`)),
+	}
+
+	start := time.Now()
+	require.NoError(t, w.WriteBatch(context.Background(), batch))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookProxy(t *testing.T) {
+	var mut sync.Mutex
+	var received []string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		received = append(received, r.URL.String())
+		mut.Unlock()
+	}))
+	defer proxy.Close()
+
+	w := testWebhookWriter(t, `
+url: http://hooks.example.com/foo
+proxy:
+  url: `+proxy.URL+`
+`)
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`hello world`)),
+	}))
+
+	mut.Lock()
+	defer mut.Unlock()
+	assert.Equal(t, []string{"http://hooks.example.com/foo"}, received)
+}
+
+func TestWebhookEndpointEviction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	w := testWebhookWriter(t, `
+url: `+srv.URL+`${! meta("path") }
+max_retries: 0
+circuit_breaker:
+  failure_threshold: 1
+  reset_timeout: 1h
+endpoint_idle_timeout: 10ms
+`)
+
+	writeTo := func(path string) error {
+		msg := service.NewMessage([]byte(`hello world`))
+		msg.MetaSet("path", path)
+		return w.WriteBatch(context.Background(), service.MessageBatch{msg})
+	}
+
+	require.Error(t, writeTo("/broken"))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, writeTo("/hook/"+strconv.Itoa(i)))
+	}
+	w.endpointsMut.Lock()
+	assert.Len(t, w.endpoints, 11)
+	w.endpointsMut.Unlock()
+
+	<-time.After(time.Millisecond * 20)
+	require.NoError(t, writeTo("/hook/new"))
+
+	// Idle endpoints are discarded but the open circuit breaker is retained.
+	w.endpointsMut.Lock()
+	assert.Len(t, w.endpoints, 2)
+	w.endpointsMut.Unlock()
+	require.ErrorIs(t, writeTo("/broken"), errCircuitOpen)
+}
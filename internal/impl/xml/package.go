@@ -6,16 +6,22 @@ package xml
 
 import (
 	"encoding/xml"
+	"strings"
 
 	"github.com/clbanning/mxj/v2"
 	"golang.org/x/net/html/charset"
 )
 
+// DefaultAttrPrefix is the prefix added to the keys of attributes when parsing
+// XML documents.
+const DefaultAttrPrefix = "-"
+
 func init() {
 	dec := xml.NewDecoder(nil)
 	dec.Strict = false
 	dec.CharsetReader = charset.NewReaderLabel
 	mxj.CustomDecoder = dec
+	mxj.XMLEscapeChars(true)
 }
 
 // ToMap parses a byte slice as XML and returns a generic structure that can be
@@ -27,3 +33,58 @@ func ToMap(xmlBytes []byte, cast bool) (map[string]interface{}, error) {
 	}
 	return map[string]interface{}(root), nil
 }
+
+// ToMapWithAttrPrefix parses a byte slice as XML and returns a generic
+// structure where the keys of attributes are given a custom prefix.
+func ToMapWithAttrPrefix(xmlBytes []byte, cast bool, attrPrefix string) (map[string]interface{}, error) {
+	root, err := ToMap(xmlBytes, cast)
+	if err != nil {
+		return nil, err
+	}
+	if attrPrefix == DefaultAttrPrefix {
+		return root, nil
+	}
+	return renameAttrs(root, DefaultAttrPrefix, attrPrefix).(map[string]interface{}), nil
+}
+
+// FromMap serializes a generic structure as an XML document, where keys
+// beginning with attrPrefix are serialized as attributes of their parent
+// element. When the structure contains a single key it is used as the root
+// element, otherwise rootTag is used. An empty indent results in a compact
+// document.
+func FromMap(root map[string]interface{}, rootTag, attrPrefix, indent string) ([]byte, error) {
+	if attrPrefix != DefaultAttrPrefix {
+		root = renameAttrs(root, attrPrefix, DefaultAttrPrefix).(map[string]interface{})
+	}
+	var tags []string
+	if rootTag != "" {
+		tags = append(tags, rootTag)
+	}
+	if indent == "" {
+		return mxj.Map(root).Xml(tags...)
+	}
+	return mxj.Map(root).XmlIndent("", indent, tags...)
+}
+
+// renameAttrs returns a copy of a generic structure where object keys
+// beginning with one prefix instead begin with another.
+func renameAttrs(v interface{}, from, to string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			if from != "" && strings.HasPrefix(k, from) && len(k) > len(from) {
+				k = to + strings.TrimPrefix(k, from)
+			}
+			m[k] = renameAttrs(v, from, to)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, v := range t {
+			a[i] = renameAttrs(v, from, to)
+		}
+		return a
+	}
+	return v
+}
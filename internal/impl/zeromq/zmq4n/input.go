@@ -0,0 +1,225 @@
+package zmq4n
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/zeromq/zmtp"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func zmqnInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Consumes messages from a ZeroMQ socket using a pure Go implementation of the ZeroMQ protocol.").
+		Description(`
+Unlike the ` + "[`zmq4` input](/docs/components/inputs/zmq4)" + ` this input does not depend on C bindings and is therefore available in all builds of Benthos. It speaks version 3 of the ZeroMQ message transport protocol (ZMTP) with the NULL security mechanism over the ` + "`tcp`" + ` and ` + "`ipc`" + ` transports, and is able to communicate with libzmq peers from version 4 onwards.
+
+Each frame of a multipart ZeroMQ message is consumed as a message of a batch.
+
+### Sockets
+
+This input supports PULL, SUB and REP sockets. A SUB socket only receives messages where a filter of ` + "`sub_filters`" + ` is a prefix of the first frame.
+
+A REP socket replies to each request with an empty frame once the resulting messages have been successfully delivered.
+`).
+		Field(service.NewStringListField("urls").
+			Description("A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").
+			Example([]string{"tcp://localhost:5555"}).
+			Example([]string{"ipc:///tmp/benthos.sock"})).
+		Field(service.NewBoolField("bind").
+			Description("Whether to bind to the specified URLs (otherwise they are connected to).").
+			Default(false)).
+		Field(service.NewStringEnumField("socket_type", "PULL", "SUB", "REP").
+			Description("The socket type to connect as.")).
+		Field(service.NewStringListField("sub_filters").
+			Description("A list of subscription topic filters to use when consuming from a SUB socket. Specifying a single sub_filter of `''` will subscribe to everything.").
+			Default([]interface{}{})).
+		Field(service.NewIntField("high_water_mark").
+			Description("The number of received messages to buffer before reading from peers is paused.").
+			Default(0).
+			Advanced()).
+		Field(service.NewIntField("max_message_size").
+			Description("The maximum size in bytes of a message received from a peer, including all of its frames. Peers that send larger messages are disconnected. Set to `-1` in order to only enforce the maximum frame size of 2GiB.").
+			Default(16777216).
+			Advanced())
+}
+
+func init() {
+	err := service.RegisterBatchInput("zmq4n", zmqnInputConfig(), func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+		r, err := zmqnInputFromConfig(conf, mgr)
+		if err != nil {
+			return nil, err
+		}
+		return service.AutoRetryNacksBatched(r), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type zmqnInput struct {
+	log *service.Logger
+
+	urls       []string
+	socketType zmtp.Type
+	hwm        int
+	maxMsgSize int64
+	bind       bool
+	subFilters []string
+
+	socketMut sync.Mutex
+	socket    *zmtp.Socket
+}
+
+func zmqnInputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*zmqnInput, error) {
+	z := zmqnInput{
+		log: mgr.Logger(),
+	}
+
+	urlStrs, err := conf.FieldStringList("urls")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range urlStrs {
+		for _, splitU := range strings.Split(u, ",") {
+			if len(splitU) > 0 {
+				z.urls = append(z.urls, splitU)
+			}
+		}
+	}
+
+	if z.bind, err = conf.FieldBool("bind"); err != nil {
+		return nil, err
+	}
+
+	socketType, err := conf.FieldString("socket_type")
+	if err != nil {
+		return nil, err
+	}
+	z.socketType = zmtp.Type(socketType)
+
+	if z.subFilters, err = conf.FieldStringList("sub_filters"); err != nil {
+		return nil, err
+	}
+
+	if z.socketType == zmtp.Sub && len(z.subFilters) == 0 {
+		return nil, errors.New("must provide at least one sub filter when connecting with a SUB socket, in order to subscribe to all messages add an empty string")
+	}
+
+	if z.hwm, err = conf.FieldInt("high_water_mark"); err != nil {
+		return nil, err
+	}
+
+	maxMsgSize, err := conf.FieldInt("max_message_size")
+	if err != nil {
+		return nil, err
+	}
+	z.maxMsgSize = int64(maxMsgSize)
+	return &z, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (z *zmqnInput) Connect(ctx context.Context) (err error) {
+	z.socketMut.Lock()
+	defer z.socketMut.Unlock()
+
+	if z.socket != nil {
+		return nil
+	}
+
+	var socket *zmtp.Socket
+	if socket, err = zmtp.NewSocket(z.socketType, z.hwm); err != nil {
+		return err
+	}
+	socket.SetMaxMessageSize(z.maxMsgSize)
+
+	defer func() {
+		if err != nil {
+			socket.Close()
+		}
+	}()
+
+	for _, filter := range z.subFilters {
+		if err = socket.Subscribe([]byte(filter)); err != nil {
+			return err
+		}
+	}
+
+	for _, address := range z.urls {
+		if z.bind {
+			err = socket.Bind(address)
+		} else {
+			err = socket.Connect(address)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	z.socket = socket
+	if z.bind {
+		z.log.Infof("Receiving ZMQ messages on bound URLs: %s\n", z.urls)
+	} else {
+		z.log.Infof("Receiving ZMQ messages on connected URLs: %s\n", z.urls)
+	}
+	return nil
+}
+
+func (z *zmqnInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	z.socketMut.Lock()
+	socket := z.socket
+	z.socketMut.Unlock()
+
+	if socket == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	msg, err := socket.Recv(ctx)
+	if err != nil {
+		if errors.Is(err, zmtp.ErrClosed) {
+			err = service.ErrNotConnected
+		}
+		return nil, nil, err
+	}
+
+	if z.socketType != zmtp.Rep {
+		var batch service.MessageBatch
+		for _, d := range msg.Frames {
+			batch = append(batch, service.NewMessage(d))
+		}
+		return batch, func(ctx context.Context, err error) error {
+			return nil
+		}, nil
+	}
+
+	var batch service.MessageBatch
+	for _, d := range msg.Frames {
+		batch = append(batch, service.NewMessage(d))
+	}
+	return batch, func(ctx context.Context, err error) error {
+		var reply [][]byte
+		if err != nil {
+			reply = append(reply, []byte(err.Error()))
+		}
+		return socket.Reply(msg, reply)
+	}, nil
+}
+
+func (z *zmqnInput) Close(ctx context.Context) error {
+	z.socketMut.Lock()
+	defer z.socketMut.Unlock()
+
+	if z.socket != nil {
+		z.socket.Close()
+		z.socket = nil
+	}
+	return nil
+}
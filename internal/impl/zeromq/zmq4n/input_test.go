@@ -0,0 +1,145 @@
+package zmq4n_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+// reqPeer is a minimal ZMTP 3.0 REQ peer that only sends single frame
+// requests.
+type reqPeer struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialReqPeer(t *testing.T, addr string) *reqPeer {
+	t.Helper()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("tcp", addr)
+		return err == nil
+	}, time.Second*5, time.Millisecond*50)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	_ = conn.SetDeadline(time.Now().Add(time.Second * 10))
+
+	greeting := make([]byte, 64)
+	greeting[0], greeting[9], greeting[10] = 0xff, 0x7f, 3
+	copy(greeting[12:], "NULL")
+
+	ready := append([]byte{5}, "READY"...)
+	ready = append(ready, 11)
+	ready = append(ready, "Socket-Type"...)
+	ready = append(ready, 0, 0, 0, 3)
+	ready = append(ready, "REQ"...)
+
+	_, err := conn.Write(append(append(greeting, 0x04, byte(len(ready))), ready...))
+	require.NoError(t, err)
+
+	p := &reqPeer{conn: conn, r: bufio.NewReader(conn)}
+	_, err = io.ReadFull(p.r, make([]byte, 64))
+	require.NoError(t, err)
+
+	flags, body := p.readFrame(t)
+	require.Equal(t, byte(0x04), flags)
+	require.Contains(t, string(body), "READY")
+	return p
+}
+
+func (p *reqPeer) readFrame(t *testing.T) (byte, []byte) {
+	t.Helper()
+
+	hdr := make([]byte, 2)
+	_, err := io.ReadFull(p.r, hdr)
+	require.NoError(t, err)
+	require.Zero(t, hdr[0]&0x02, "long frames are not supported by the test peer")
+
+	body := make([]byte, hdr[1])
+	_, err = io.ReadFull(p.r, body)
+	require.NoError(t, err)
+	return hdr[0], body
+}
+
+func (p *reqPeer) request(t *testing.T, content string) []string {
+	t.Helper()
+
+	_, err := p.conn.Write(append([]byte{0x01, 0, 0x00, byte(len(content))}, content...))
+	require.NoError(t, err)
+
+	var reply []string
+	for {
+		flags, body := p.readFrame(t)
+		reply = append(reply, string(body))
+		if flags&0x01 == 0 {
+			return reply
+		}
+	}
+}
+
+func TestZMQ4NInputRep(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.SetLoggerYAML(`level: none`))
+	require.NoError(t, builder.AddInputYAML(fmt.Sprintf(`
+zmq4n:
+  urls: [ tcp://%v ]
+  bind: true
+  socket_type: REP
+`, addr)))
+
+	var receivedMut sync.Mutex
+	var received []string
+	require.NoError(t, builder.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		receivedMut.Lock()
+		received = append(received, string(b))
+		receivedMut.Unlock()
+		return nil
+	}))
+	getReceived := func() []string {
+		receivedMut.Lock()
+		defer receivedMut.Unlock()
+		return append([]string{}, received...)
+	}
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	go func() {
+		assert.NoError(t, strm.Run(ctx))
+	}()
+	defer func() {
+		require.NoError(t, strm.StopWithin(time.Second*10))
+	}()
+
+	peer := dialReqPeer(t, addr)
+	assert.Equal(t, []string{"", ""}, peer.request(t, "hello"))
+	assert.Equal(t, []string{"hello"}, getReceived())
+
+	assert.Equal(t, []string{"", ""}, peer.request(t, "world"))
+	assert.Equal(t, []string{"hello", "world"}, getReceived())
+}
@@ -0,0 +1,183 @@
+// Package zmtp implements the ZeroMQ Message Transport Protocol (ZMTP 3.0)
+// with the NULL security mechanism, which allows sockets to communicate with
+// libzmq peers without the need for C bindings.
+package zmtp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	flagMore    byte = 0x01
+	flagLong    byte = 0x02
+	flagCommand byte = 0x04
+)
+
+const greetingLen = 64
+
+// greeting returns the greeting sent by this implementation, which is version
+// 3.0 of the protocol using the NULL mechanism.
+func greeting() []byte {
+	g := make([]byte, greetingLen)
+	g[0] = 0xff
+	g[9] = 0x7f
+	g[10] = 3
+	g[11] = 0
+	copy(g[12:32], "NULL")
+	return g
+}
+
+func readGreeting(r io.Reader) error {
+	g := make([]byte, greetingLen)
+	if _, err := io.ReadFull(r, g); err != nil {
+		return err
+	}
+	if g[0] != 0xff || g[9] != 0x7f {
+		return errors.New("invalid greeting signature")
+	}
+	if g[10] < 3 {
+		return fmt.Errorf("unsupported protocol version: %v.%v", g[10], g[11])
+	}
+	if mech := string(bytes.TrimRight(g[12:32], "\x00")); mech != "NULL" {
+		return fmt.Errorf("unsupported security mechanism: %v", mech)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type frame struct {
+	command bool
+	more    bool
+	body    []byte
+}
+
+const (
+	// maxFrameSize is the largest message frame accepted from a peer
+	// regardless of the maximum message size of a socket.
+	maxFrameSize = math.MaxInt32
+
+	// maxCommandSize is the largest command frame accepted from a peer, the
+	// commands supported are far smaller than this.
+	maxCommandSize = 64 * 1024
+)
+
+// readFrame reads a frame from a peer, where the size of a message frame is
+// checked against maxSize before its body is allocated.
+func readFrame(r *bufio.Reader, maxSize uint64) (frame, error) {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return frame{}, err
+	}
+
+	var size uint64
+	if flags&flagLong != 0 {
+		var b [8]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return frame{}, err
+		}
+		size = binary.BigEndian.Uint64(b[:])
+	} else {
+		b, err := r.ReadByte()
+		if err != nil {
+			return frame{}, err
+		}
+		size = uint64(b)
+	}
+	if flags&flagCommand != 0 {
+		maxSize = maxCommandSize
+	} else if maxSize > maxFrameSize {
+		maxSize = maxFrameSize
+	}
+	if size > maxSize {
+		return frame{}, fmt.Errorf("frame size %v exceeds maximum of %v", size, maxSize)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return frame{}, err
+	}
+	return frame{
+		command: flags&flagCommand != 0,
+		more:    flags&flagMore != 0,
+		body:    body,
+	}, nil
+}
+
+func writeFrame(w io.Writer, flags byte, body []byte) error {
+	var hdr []byte
+	if len(body) > math.MaxUint8 {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(body)))
+		hdr = append([]byte{flags | flagLong}, size[:]...)
+	} else {
+		hdr = []byte{flags, byte(len(body))}
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+func writeCommand(w io.Writer, name string, data []byte) error {
+	body := make([]byte, 0, 1+len(name)+len(data))
+	body = append(body, byte(len(name)))
+	body = append(body, name...)
+	body = append(body, data...)
+	return writeFrame(w, flagCommand, body)
+}
+
+func parseCommand(body []byte) (name string, data []byte, err error) {
+	if len(body) == 0 || len(body) < 1+int(body[0]) {
+		return "", nil, errors.New("malformed command")
+	}
+	return string(body[1 : 1+body[0]]), body[1+body[0]:], nil
+}
+
+// encodeProperties encodes a list of name and value pairs as the metadata of
+// a READY command.
+func encodeProperties(kvs ...string) []byte {
+	var b []byte
+	for i := 0; i < len(kvs)-1; i += 2 {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(kvs[i+1])))
+		b = append(b, byte(len(kvs[i])))
+		b = append(b, kvs[i]...)
+		b = append(b, size[:]...)
+		b = append(b, kvs[i+1]...)
+	}
+	return b
+}
+
+// parseProperties decodes the metadata of a READY command, property names are
+// case insensitive and are therefore returned in lower case.
+func parseProperties(data []byte) (map[string]string, error) {
+	props := map[string]string{}
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 1+nameLen+4 {
+			return nil, errors.New("malformed metadata")
+		}
+		name := strings.ToLower(string(data[1 : 1+nameLen]))
+		data = data[1+nameLen:]
+
+		valueLen := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(len(data)) < uint64(valueLen) {
+			return nil, errors.New("malformed metadata")
+		}
+		props[name] = string(data[:valueLen])
+		data = data[valueLen:]
+	}
+	return props, nil
+}
@@ -0,0 +1,496 @@
+package zmtp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrClosed is returned when attempting to receive from a closed socket.
+var ErrClosed = errors.New("socket closed")
+
+const (
+	handshakeTimeout  = 10 * time.Second
+	reconnectInterval = 100 * time.Millisecond
+)
+
+// Type is the type of a socket, which determines the types of peer that it can
+// communicate with and how messages are routed.
+type Type string
+
+// Socket types that are supported.
+const (
+	Sub  Type = "SUB"
+	Pull Type = "PULL"
+	Rep  Type = "REP"
+)
+
+func (t Type) compatible(peer string) bool {
+	switch t {
+	case Sub:
+		return peer == "PUB" || peer == "XPUB"
+	case Pull:
+		return peer == "PUSH"
+	case Rep:
+		return peer == "REQ" || peer == "DEALER"
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+type conn struct {
+	netConn net.Conn
+	r       *bufio.Reader
+
+	wMut sync.Mutex
+	w    *bufio.Writer
+}
+
+func newConn(nc net.Conn) *conn {
+	return &conn{
+		netConn: nc,
+		r:       bufio.NewReader(nc),
+		w:       bufio.NewWriter(nc),
+	}
+}
+
+func (c *conn) writeMessage(frames [][]byte) error {
+	c.wMut.Lock()
+	defer c.wMut.Unlock()
+	for i, f := range frames {
+		var flags byte
+		if i < len(frames)-1 {
+			flags = flagMore
+		}
+		if err := writeFrame(c.w, flags, f); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
+
+// handshake exchanges greetings and READY commands with a peer and returns
+// the socket type of the peer.
+func handshake(c *conn, t Type) (string, error) {
+	if _, err := c.w.Write(greeting()); err != nil {
+		return "", err
+	}
+	if err := writeCommand(c.w, "READY", encodeProperties("Socket-Type", string(t))); err != nil {
+		return "", err
+	}
+	if err := c.w.Flush(); err != nil {
+		return "", err
+	}
+
+	if err := readGreeting(c.r); err != nil {
+		return "", err
+	}
+	// Only a command is expected from the peer at this point.
+	f, err := readFrame(c.r, 0)
+	if err != nil {
+		return "", err
+	}
+	if !f.command {
+		return "", errors.New("expected a READY command from peer")
+	}
+	name, data, err := parseCommand(f.body)
+	if err != nil {
+		return "", err
+	}
+	switch name {
+	case "READY":
+	case "ERROR":
+		return "", fmt.Errorf("peer rejected handshake: %s", data)
+	default:
+		return "", fmt.Errorf("expected a READY command from peer, received: %v", name)
+	}
+	props, err := parseProperties(data)
+	if err != nil {
+		return "", err
+	}
+	return props["socket-type"], nil
+}
+
+//------------------------------------------------------------------------------
+
+// Message is a multipart message received by a socket.
+type Message struct {
+	Frames [][]byte
+
+	conn     *conn
+	envelope [][]byte
+}
+
+// Socket is a ZeroMQ socket that can be bound to and connected to any number of
+// endpoints, and receives messages from all of its peers.
+type Socket struct {
+	socketType Type
+
+	maxMsgSize       int64
+	handshakeTimeout time.Duration
+
+	mut           sync.Mutex
+	subscriptions [][]byte
+	conns         map[*conn]bool
+	listeners     []net.Listener
+
+	msgs chan Message
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSocket creates a socket of a given type, where highWaterMark is the
+// number of received messages buffered before reading from peers is paused.
+func NewSocket(t Type, highWaterMark int) (*Socket, error) {
+	switch t {
+	case Sub, Pull, Rep:
+	default:
+		return nil, fmt.Errorf("socket type not supported: %v", t)
+	}
+	if highWaterMark < 0 {
+		highWaterMark = 0
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Socket{
+		socketType:       t,
+		maxMsgSize:       -1,
+		handshakeTimeout: handshakeTimeout,
+		conns:            map[*conn]bool{},
+		msgs:             make(chan Message, highWaterMark),
+		ctx:              ctx,
+		cancel:           cancel,
+	}, nil
+}
+
+// SetMaxMessageSize sets the maximum size in bytes of a message received from
+// a peer, including all of its frames, where peers that exceed it are
+// disconnected. A size less than zero means that only the maximum frame size
+// of the implementation is enforced. It must be called before Bind or Connect.
+func (s *Socket) SetMaxMessageSize(size int64) {
+	s.maxMsgSize = size
+}
+
+// frameLimit returns the largest frame that can be read when the frames of
+// the current message received so far total msgSize bytes.
+func (s *Socket) frameLimit(msgSize uint64) uint64 {
+	if s.maxMsgSize < 0 {
+		return maxFrameSize
+	}
+	if msgSize >= uint64(s.maxMsgSize) {
+		return 0
+	}
+	return uint64(s.maxMsgSize) - msgSize
+}
+
+func subscribeMessage(topic []byte) [][]byte {
+	return [][]byte{append([]byte{1}, topic...)}
+}
+
+// Subscribe adds a topic filter to a SUB socket, a message is only received
+// when a filter is a prefix of its first frame. An empty topic subscribes to
+// all messages.
+func (s *Socket) Subscribe(topic []byte) error {
+	if s.socketType != Sub {
+		return errors.New("subscriptions are only supported by SUB sockets")
+	}
+
+	s.mut.Lock()
+	s.subscriptions = append(s.subscriptions, topic)
+	var ready []*conn
+	for c, isReady := range s.conns {
+		if isReady {
+			ready = append(ready, c)
+		}
+	}
+	s.mut.Unlock()
+
+	// Write failures are detected and dealt with by the read loop of the
+	// connection.
+	for _, c := range ready {
+		_ = c.writeMessage(subscribeMessage(topic))
+	}
+	return nil
+}
+
+func (s *Socket) subscribed(firstFrame []byte) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for _, sub := range s.subscriptions {
+		if len(firstFrame) >= len(sub) && string(firstFrame[:len(sub)]) == string(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseEndpoint(endpoint string, bind bool) (network, address string, err error) {
+	i := strings.Index(endpoint, "://")
+	if i == -1 {
+		return "", "", fmt.Errorf("invalid endpoint: %v", endpoint)
+	}
+	transport, address := endpoint[:i], endpoint[i+3:]
+	switch transport {
+	case "tcp":
+		if bind && strings.HasPrefix(address, "*:") {
+			address = address[1:]
+		}
+		return "tcp", address, nil
+	case "ipc":
+		return "unix", address, nil
+	}
+	return "", "", fmt.Errorf("transport not supported: %v", transport)
+}
+
+// Bind listens for peers on an endpoint of the form tcp://host:port, where the
+// host can be `*` in order to listen on all interfaces, or ipc:///path.
+func (s *Socket) Bind(endpoint string) error {
+	network, address, err := parseEndpoint(endpoint, true)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	if s.ctx.Err() != nil {
+		s.mut.Unlock()
+		ln.Close()
+		return ErrClosed
+	}
+	s.listeners = append(s.listeners, ln)
+	s.mut.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				select {
+				case <-s.ctx.Done():
+					return
+				case <-time.After(reconnectInterval):
+				}
+				continue
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(nc)
+			}()
+		}
+	}()
+	return nil
+}
+
+// Connect connects to a peer at an endpoint of the form tcp://host:port or
+// ipc:///path. The connection is established in the background and is
+// reestablished whenever it is lost until the socket is closed.
+func (s *Socket) Connect(endpoint string) error {
+	network, address, err := parseEndpoint(endpoint, false)
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var dialer net.Dialer
+		for {
+			if nc, err := dialer.DialContext(s.ctx, network, address); err == nil {
+				s.serve(nc)
+			}
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(reconnectInterval):
+			}
+		}
+	}()
+	return nil
+}
+
+// serve performs the handshake with a peer and then reads its messages until
+// either the connection is lost or the socket is closed.
+func (s *Socket) serve(nc net.Conn) {
+	c := newConn(nc)
+	defer nc.Close()
+
+	s.mut.Lock()
+	if s.ctx.Err() != nil {
+		s.mut.Unlock()
+		return
+	}
+	s.conns[c] = false
+	s.mut.Unlock()
+
+	defer func() {
+		s.mut.Lock()
+		delete(s.conns, c)
+		s.mut.Unlock()
+	}()
+
+	// Peers that never complete the handshake would otherwise hold on to the
+	// connection and this goroutine indefinitely.
+	_ = nc.SetDeadline(time.Now().Add(s.handshakeTimeout))
+	peerType, err := handshake(c, s.socketType)
+	if err != nil {
+		return
+	}
+	_ = nc.SetDeadline(time.Time{})
+	if !s.socketType.compatible(peerType) {
+		_ = writeCommand(c.w, "ERROR", []byte("incompatible socket type"))
+		_ = c.w.Flush()
+		return
+	}
+
+	s.mut.Lock()
+	s.conns[c] = true
+	subs := make([][]byte, len(s.subscriptions))
+	copy(subs, s.subscriptions)
+	s.mut.Unlock()
+
+	for _, sub := range subs {
+		if err := c.writeMessage(subscribeMessage(sub)); err != nil {
+			return
+		}
+	}
+	s.readMessages(c)
+}
+
+func (s *Socket) readMessages(c *conn) {
+	var frames [][]byte
+	var msgSize uint64
+	for {
+		f, err := readFrame(c.r, s.frameLimit(msgSize))
+		if err != nil {
+			return
+		}
+		if f.command {
+			if err := s.handleCommand(c, f.body); err != nil {
+				return
+			}
+			continue
+		}
+
+		frames = append(frames, f.body)
+		msgSize += uint64(len(f.body))
+		if f.more {
+			continue
+		}
+
+		msg, ok := s.route(c, frames)
+		frames, msgSize = nil, 0
+		if !ok {
+			continue
+		}
+		select {
+		case s.msgs <- msg:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Socket) handleCommand(c *conn, body []byte) error {
+	name, data, err := parseCommand(body)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "PING":
+		if len(data) < 2 {
+			return errors.New("malformed PING command")
+		}
+		c.wMut.Lock()
+		defer c.wMut.Unlock()
+		if err := writeCommand(c.w, "PONG", data[2:]); err != nil {
+			return err
+		}
+		return c.w.Flush()
+	case "ERROR":
+		return fmt.Errorf("peer error: %s", data)
+	}
+	return nil
+}
+
+// route returns the message to be received from the frames sent by a peer,
+// which is false when the message should be dropped.
+func (s *Socket) route(c *conn, frames [][]byte) (Message, bool) {
+	switch s.socketType {
+	case Sub:
+		if !s.subscribed(frames[0]) {
+			return Message{}, false
+		}
+	case Rep:
+		// Requests begin with an envelope that ends with an empty delimiter
+		// frame, which must be returned with the reply.
+		for i, f := range frames {
+			if len(f) == 0 {
+				return Message{
+					Frames:   frames[i+1:],
+					conn:     c,
+					envelope: frames[:i+1],
+				}, true
+			}
+		}
+		return Message{}, false
+	}
+	return Message{Frames: frames}, true
+}
+
+// Recv blocks until a message is received from any peer, the context is
+// cancelled or the socket is closed.
+func (s *Socket) Recv(ctx context.Context) (Message, error) {
+	select {
+	case msg := <-s.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	case <-s.ctx.Done():
+		return Message{}, ErrClosed
+	}
+}
+
+// Reply sends a reply to a request received by a REP socket to the peer that
+// sent it.
+func (s *Socket) Reply(req Message, frames [][]byte) error {
+	if s.socketType != Rep || req.conn == nil {
+		return errors.New("message is not a request received by a REP socket")
+	}
+	if len(frames) == 0 {
+		frames = [][]byte{{}}
+	}
+	msg := make([][]byte, 0, len(req.envelope)+len(frames))
+	msg = append(msg, req.envelope...)
+	msg = append(msg, frames...)
+	return req.conn.writeMessage(msg)
+}
+
+// Close stops listening on all bound endpoints, closes the connections to all
+// peers and blocks until all background goroutines have finished.
+func (s *Socket) Close() error {
+	s.mut.Lock()
+	s.cancel()
+	for _, ln := range s.listeners {
+		ln.Close()
+	}
+	s.listeners = nil
+	for c := range s.conns {
+		c.netConn.Close()
+	}
+	s.mut.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
@@ -0,0 +1,259 @@
+package zmtp
+
+import (
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bindSocket(t *testing.T, st Type) (*Socket, string) {
+	t.Helper()
+
+	s, err := NewSocket(st, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+
+	require.NoError(t, s.Bind("tcp://127.0.0.1:0"))
+	return s, s.listeners[0].Addr().String()
+}
+
+func dialPeer(t *testing.T, addr string, peerType Type) *conn {
+	t.Helper()
+
+	nc, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		nc.Close()
+	})
+
+	c := newConn(nc)
+	_, err = handshake(c, peerType)
+	require.NoError(t, err)
+	return c
+}
+
+func readPeerMessage(t *testing.T, c *conn) [][]byte {
+	t.Helper()
+
+	var frames [][]byte
+	for {
+		f, err := readFrame(c.r, maxFrameSize)
+		require.NoError(t, err)
+		require.False(t, f.command)
+		frames = append(frames, f.body)
+		if !f.more {
+			return frames
+		}
+	}
+}
+
+func recv(t *testing.T, s *Socket) Message {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, err := s.Recv(ctx)
+	require.NoError(t, err)
+	return msg
+}
+
+func TestSocketPull(t *testing.T) {
+	s, addr := bindSocket(t, Pull)
+	peer := dialPeer(t, addr, "PUSH")
+
+	longFrame := make([]byte, 1000)
+	for i := range longFrame {
+		longFrame[i] = 'x'
+	}
+
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("foo")}))
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("bar"), {}, longFrame}))
+
+	assert.Equal(t, [][]byte{[]byte("foo")}, recv(t, s).Frames)
+	assert.Equal(t, [][]byte{[]byte("bar"), {}, longFrame}, recv(t, s).Frames)
+}
+
+func TestSocketSub(t *testing.T) {
+	s, addr := bindSocket(t, Sub)
+	require.NoError(t, s.Subscribe([]byte("foo")))
+
+	peer := dialPeer(t, addr, "PUB")
+	assert.Equal(t, [][]byte{[]byte("\x01foo")}, readPeerMessage(t, peer))
+
+	require.NoError(t, s.Subscribe([]byte("bar")))
+	assert.Equal(t, [][]byte{[]byte("\x01bar")}, readPeerMessage(t, peer))
+
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("baz"), []byte("nope")}))
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("foo1"), []byte("first")}))
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("bar2"), []byte("second")}))
+
+	assert.Equal(t, [][]byte{[]byte("foo1"), []byte("first")}, recv(t, s).Frames)
+	assert.Equal(t, [][]byte{[]byte("bar2"), []byte("second")}, recv(t, s).Frames)
+}
+
+func TestSocketRep(t *testing.T) {
+	s, addr := bindSocket(t, Rep)
+	peerA := dialPeer(t, addr, "REQ")
+	peerB := dialPeer(t, addr, "DEALER")
+
+	require.NoError(t, peerA.writeMessage([][]byte{{}, []byte("from a")}))
+	reqA := recv(t, s)
+	assert.Equal(t, [][]byte{[]byte("from a")}, reqA.Frames)
+
+	require.NoError(t, peerB.writeMessage([][]byte{[]byte("id"), {}, []byte("from b")}))
+	reqB := recv(t, s)
+	assert.Equal(t, [][]byte{[]byte("from b")}, reqB.Frames)
+
+	require.NoError(t, s.Reply(reqB, [][]byte{[]byte("to b")}))
+	require.NoError(t, s.Reply(reqA, nil))
+
+	assert.Equal(t, [][]byte{[]byte("id"), {}, []byte("to b")}, readPeerMessage(t, peerB))
+	assert.Equal(t, [][]byte{{}, {}}, readPeerMessage(t, peerA))
+}
+
+func TestSocketIncompatiblePeer(t *testing.T) {
+	_, addr := bindSocket(t, Pull)
+
+	nc, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer nc.Close()
+
+	peer := newConn(nc)
+	_, err = handshake(peer, "PUB")
+	require.NoError(t, err)
+
+	_ = nc.SetReadDeadline(time.Now().Add(time.Second * 5))
+	f, err := readFrame(peer.r, maxFrameSize)
+	require.NoError(t, err)
+	require.True(t, f.command)
+
+	name, data, err := parseCommand(f.body)
+	require.NoError(t, err)
+	assert.Equal(t, "ERROR", name)
+	assert.Equal(t, "incompatible socket type", string(data))
+}
+
+func assertPeerClosed(t *testing.T, nc net.Conn) {
+	t.Helper()
+
+	_ = nc.SetReadDeadline(time.Now().Add(time.Second * 5))
+	_, err := io.Copy(io.Discard, nc)
+	require.NoError(t, err, "expected the connection to be closed by the socket")
+}
+
+func TestSocketMaxMessageSize(t *testing.T) {
+	s, err := NewSocket(Pull, 1)
+	require.NoError(t, err)
+	s.SetMaxMessageSize(10)
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+	require.NoError(t, s.Bind("tcp://127.0.0.1:0"))
+	addr := s.listeners[0].Addr().String()
+
+	peer := dialPeer(t, addr, "PUSH")
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("foo"), []byte("barbaz")}))
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("barbaz")}, recv(t, s).Frames)
+
+	// A single frame that claims a size larger than the maximum is rejected
+	// from its header alone.
+	peer = dialPeer(t, addr, "PUSH")
+	_, err = peer.netConn.Write([]byte{flagLong, 0, 0, 0, 0, 0x7f, 0xff, 0xff, 0xff})
+	require.NoError(t, err)
+	assertPeerClosed(t, peer.netConn)
+
+	// Frames of a multipart message count towards the same maximum.
+	peer = dialPeer(t, addr, "PUSH")
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("foobar"), []byte("bazbuz")}))
+	assertPeerClosed(t, peer.netConn)
+}
+
+func TestSocketHandshakeTimeout(t *testing.T) {
+	s, err := NewSocket(Pull, 0)
+	require.NoError(t, err)
+	s.handshakeTimeout = time.Millisecond * 50
+	t.Cleanup(func() {
+		require.NoError(t, s.Close())
+	})
+	require.NoError(t, s.Bind("tcp://127.0.0.1:0"))
+
+	nc, err := net.Dial("tcp", s.listeners[0].Addr().String())
+	require.NoError(t, err)
+	defer nc.Close()
+
+	// The peer never sends a greeting.
+	assertPeerClosed(t, nc)
+}
+
+func TestSocketConnectIPC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sock")
+
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	s, err := NewSocket(Pull, 1)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Connect("ipc://"+path))
+
+	nc, err := ln.Accept()
+	require.NoError(t, err)
+	defer nc.Close()
+
+	peer := newConn(nc)
+	peerType, err := handshake(peer, "PUSH")
+	require.NoError(t, err)
+	assert.Equal(t, "PULL", peerType)
+
+	require.NoError(t, peer.writeMessage([][]byte{[]byte("hello")}))
+	assert.Equal(t, [][]byte{[]byte("hello")}, recv(t, s).Frames)
+}
+
+func TestSocketClose(t *testing.T) {
+	s, err := NewSocket(Pull, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Bind("tcp://127.0.0.1:0"))
+	require.NoError(t, s.Connect("tcp://127.0.0.1:1"))
+	require.NoError(t, s.Close())
+
+	_, err = s.Recv(context.Background())
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		bind     bool
+		network  string
+		address  string
+		errs     bool
+	}{
+		{endpoint: "tcp://*:5555", bind: true, network: "tcp", address: ":5555"},
+		{endpoint: "tcp://localhost:5555", network: "tcp", address: "localhost:5555"},
+		{endpoint: "ipc:///tmp/foo.sock", network: "unix", address: "/tmp/foo.sock"},
+		{endpoint: "inproc://foo", errs: true},
+		{endpoint: "localhost:5555", errs: true},
+	}
+
+	for _, test := range tests {
+		network, address, err := parseEndpoint(test.endpoint, test.bind)
+		if test.errs {
+			assert.Error(t, err, test.endpoint)
+			continue
+		}
+		require.NoError(t, err, test.endpoint)
+		assert.Equal(t, test.network, network, test.endpoint)
+		assert.Equal(t, test.address, address, test.endpoint)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/clientpool"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -62,4 +63,21 @@ type Manager interface {
 	GetPipe(name string) (<-chan message.Transaction, error)
 	SetPipe(name string, t <-chan message.Transaction)
 	UnsetPipe(name string, t <-chan message.Transaction)
+
+	// ReportCheckpoint allows inputs to report the latest checkpointed offset
+	// of a partition of the data they consume (a topic partition, shard, file
+	// path, etc) so that it can be inspected centrally. Offsets should identify
+	// the next position to consume, such as the next Kafka offset.
+	ReportCheckpoint(partition, offset string)
+
+	// RemoveCheckpoint allows inputs to discard the checkpoint previously
+	// reported for a partition once they no longer consume it, such as when a
+	// Kafka topic partition is revoked.
+	RemoveCheckpoint(partition string)
+
+	// AcquireSharedClient allows components connecting to the same target to
+	// share a single client, which is created by the provided constructor
+	// when no client exists under the key, and closed once all components
+	// holding it have called the returned release function.
+	AcquireSharedClient(key string, ctor clientpool.Ctor) (client interface{}, release func() error, err error)
 }
@@ -0,0 +1,24 @@
+package inventory
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// FieldSpec returns a spec for a common inventory field.
+func FieldSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"inventory", "Discover objects from an inventory report rather than by listing the bucket, which is far faster for buckets containing millions of objects. When a manifest is specified the objects it references are downloaded in parallel.",
+	).WithChildren(
+		docs.FieldString(
+			"manifest", "The key of an inventory report manifest. When set, objects are consumed from the listing files referenced by the manifest instead of by walking the bucket.",
+			"inventory/source-bucket/daily/2022-01-01T01-00Z/manifest.json",
+		).HasDefault(""),
+		docs.FieldString(
+			"bucket", "The bucket containing the manifest and its listing files. Defaults to the field `bucket` when empty.",
+		).HasDefault(""),
+		docs.FieldInt(
+			"concurrency", "The maximum number of objects to download in parallel. Downloaded objects are held in memory until they are consumed, and therefore this also bounds the number of objects held in memory at a given time.",
+		).HasDefault(16),
+		docs.FieldString(
+			"checkpoint_cache", "An optional [cache resource](/docs/components/caches/about) used to store the progress of the input through the inventory. When set, a restarted input resumes from the last object that was fully acknowledged rather than from the beginning of the inventory.",
+		).HasDefault(""),
+	).Advanced()
+}
@@ -0,0 +1,147 @@
+package inventory
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// FetchFn downloads an object referenced by an inventory and returns its
+// contents in whichever form is useful to the caller.
+type FetchFn func(ctx context.Context, obj Object) (interface{}, error)
+
+// Fetched is the result of downloading an object referenced by an inventory.
+type Fetched struct {
+	Object Object
+	Data   interface{}
+	Err    error
+}
+
+type fetchFuture struct {
+	res      chan Fetched
+	acquired bool
+}
+
+// Fetcher downloads the objects of a Lister in parallel with a bounded
+// concurrency, and returns the results in the order that they were listed.
+//
+// The concurrency limit bounds the total number of objects that are either
+// being downloaded or have been downloaded and are yet to be consumed with
+// Next.
+type Fetcher struct {
+	lister *Lister
+	fetch  FetchFn
+
+	sem     chan struct{}
+	futures chan fetchFuture
+	current *fetchFuture
+
+	listRetry time.Duration
+
+	ctx    context.Context
+	cancel func()
+	done   chan struct{}
+}
+
+// NewFetcher creates a new fetcher of the objects listed by a Lister and
+// begins downloading them in the background.
+func NewFetcher(lister *Lister, concurrency int, fetch FetchFn) *Fetcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	f := &Fetcher{
+		lister:    lister,
+		fetch:     fetch,
+		sem:       make(chan struct{}, concurrency),
+		futures:   make(chan fetchFuture, concurrency),
+		listRetry: time.Second,
+		done:      make(chan struct{}),
+	}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+	go f.loop()
+	return f
+}
+
+func (f *Fetcher) loop() {
+	defer close(f.done)
+	defer close(f.futures)
+
+	for {
+		obj, err := f.lister.Next(f.ctx)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			// Listing errors are reported to the consumer and the listing is
+			// attempted again after a short delay.
+			fut := fetchFuture{res: make(chan Fetched, 1)}
+			fut.res <- Fetched{Err: err}
+			select {
+			case f.futures <- fut:
+			case <-f.ctx.Done():
+				return
+			}
+			select {
+			case <-time.After(f.listRetry):
+			case <-f.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		select {
+		case f.sem <- struct{}{}:
+		case <-f.ctx.Done():
+			return
+		}
+
+		fut := fetchFuture{res: make(chan Fetched, 1), acquired: true}
+		go func() {
+			data, err := f.fetch(f.ctx, obj)
+			fut.res <- Fetched{Object: obj, Data: data, Err: err}
+		}()
+
+		select {
+		case f.futures <- fut:
+		case <-f.ctx.Done():
+			return
+		}
+	}
+}
+
+// Next returns the result of the next object in the inventory, blocking until
+// it has been downloaded. Once all objects have been returned io.EOF is
+// returned. An error returned from downloading an object is returned within
+// the result along with the object itself, whereas an error returned from
+// listing the inventory is returned within a result without an object.
+func (f *Fetcher) Next(ctx context.Context) (Fetched, error) {
+	if f.current == nil {
+		select {
+		case fut, open := <-f.futures:
+			if !open {
+				return Fetched{}, io.EOF
+			}
+			f.current = &fut
+		case <-ctx.Done():
+			return Fetched{}, ctx.Err()
+		}
+	}
+
+	select {
+	case res := <-f.current.res:
+		if f.current.acquired {
+			<-f.sem
+		}
+		f.current = nil
+		return res, nil
+	case <-ctx.Done():
+		return Fetched{}, ctx.Err()
+	}
+}
+
+// Close stops any pending downloads and closes the underlying lister.
+func (f *Fetcher) Close() error {
+	f.cancel()
+	<-f.done
+	return f.lister.Close()
+}
@@ -0,0 +1,121 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLister(t *testing.T, n int) *Lister {
+	t.Helper()
+
+	var rows strings.Builder
+	for i := 0; i < n; i++ {
+		rows.WriteString("obj" + strconv.Itoa(i) + "\n")
+	}
+	m := &Manifest{
+		Files:     []string{"a.csv"},
+		Columns:   []string{"Key"},
+		KeyColumn: "Key",
+	}
+	return NewLister(m, filesOpenFn(map[string][]byte{
+		"a.csv": []byte(rows.String()),
+	}), "", -1)
+}
+
+func TestFetcherOrderedAndBounded(t *testing.T) {
+	var inFlight, maxInFlight int32
+	f := NewFetcher(testLister(t, 50), 4, func(ctx context.Context, obj Object) (interface{}, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		// Later objects complete sooner in order to shuffle completion.
+		time.Sleep(time.Duration(50-obj.Index) * time.Microsecond * 20)
+		if obj.Index == 7 {
+			return nil, errors.New("nope")
+		}
+		return obj.Key, nil
+	})
+	defer f.Close()
+
+	for i := 0; i < 50; i++ {
+		res, err := f.Next(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int64(i), res.Object.Index)
+		if i == 7 {
+			assert.EqualError(t, res.Err, "nope")
+		} else {
+			require.NoError(t, res.Err)
+			assert.Equal(t, "obj"+strconv.Itoa(i), res.Data)
+		}
+		atomic.AddInt32(&inFlight, -1)
+	}
+
+	_, err := f.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(4))
+}
+
+func TestFetcherListError(t *testing.T) {
+	m := &Manifest{
+		Files:     []string{"a.csv", "b.csv"},
+		Columns:   []string{"Key"},
+		KeyColumn: "Key",
+	}
+
+	var attempts int32
+	l := NewLister(m, func(ctx context.Context, key string) (io.ReadCloser, error) {
+		if key == "b.csv" && atomic.AddInt32(&attempts, 1) == 1 {
+			return nil, errors.New("unavailable")
+		}
+		return io.NopCloser(strings.NewReader(strings.TrimSuffix(key, ".csv") + "\n")), nil
+	}, "", -1)
+
+	f := NewFetcher(l, 2, func(ctx context.Context, obj Object) (interface{}, error) {
+		return obj.Key, nil
+	})
+	f.listRetry = time.Millisecond
+	defer f.Close()
+
+	res, err := f.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "a", res.Data)
+
+	res, err = f.Next(context.Background())
+	require.NoError(t, err)
+	assert.Error(t, res.Err)
+	assert.Equal(t, "", res.Object.Key)
+
+	res, err = f.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "b", res.Data)
+
+	_, err = f.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFetcherClose(t *testing.T) {
+	f := NewFetcher(testLister(t, 10), 2, func(ctx context.Context, obj Object) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer done()
+	_, err := f.Next(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	require.NoError(t, f.Close())
+}
@@ -0,0 +1,173 @@
+package inventory
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Object is a reference to an object listed within an inventory report.
+type Object struct {
+	// Index is the position of the object within the inventory, which is
+	// stable for a given manifest and is therefore used to track progress.
+	Index  int64
+	Bucket string
+	Key    string
+}
+
+// OpenFn opens a listing file of an inventory report by its key.
+type OpenFn func(ctx context.Context, key string) (io.ReadCloser, error)
+
+// Lister walks the rows of the listing files referenced by a manifest.
+type Lister struct {
+	manifest *Manifest
+	open     OpenFn
+	prefix   string
+
+	fileIndex int
+	fileStart int64
+	index     int64
+	next      int64
+
+	file      io.ReadCloser
+	rows      *csv.Reader
+	bucketCol int
+	keyCol    int
+}
+
+// NewLister creates a lister of the objects referenced by a manifest. Objects
+// with a key that does not begin with the provided prefix are skipped, as are
+// objects with an index lower than or equal to after, which allows a lister to
+// resume from a previous checkpoint. An after value of -1 skips nothing.
+func NewLister(manifest *Manifest, open OpenFn, prefix string, after int64) *Lister {
+	return &Lister{
+		manifest: manifest,
+		open:     open,
+		prefix:   prefix,
+		next:     after + 1,
+	}
+}
+
+func (l *Lister) openFile(ctx context.Context) error {
+	key := l.manifest.Files[l.fileIndex]
+	file, err := l.open(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to open inventory file %v: %w", key, err)
+	}
+
+	var r io.Reader = file
+	if strings.HasSuffix(key, ".gz") {
+		if r, err = gzip.NewReader(file); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to decompress inventory file %v: %w", key, err)
+		}
+	}
+
+	rows := csv.NewReader(r)
+	rows.FieldsPerRecord = -1
+	rows.ReuseRecord = true
+
+	columns := l.manifest.Columns
+	if len(columns) == 0 {
+		header, err := rows.Read()
+		if err != nil && err != io.EOF {
+			file.Close()
+			return fmt.Errorf("failed to read header of inventory file %v: %w", key, err)
+		}
+		columns = append([]string(nil), header...)
+	}
+
+	l.file, l.rows = file, rows
+	l.fileStart = l.index
+	l.bucketCol = columnIndex(columns, l.manifest.BucketColumn)
+	l.keyCol = columnIndex(columns, l.manifest.KeyColumn)
+	if l.keyCol == -1 && len(columns) > 0 {
+		l.closeFile()
+		return fmt.Errorf("inventory file %v does not contain a %v column", key, l.manifest.KeyColumn)
+	}
+	return nil
+}
+
+func (l *Lister) closeFile() {
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file, l.rows = nil, nil
+}
+
+// Next returns the next object of the inventory, or io.EOF once all listing
+// files have been read. If an error is returned when opening or reading a
+// listing file then a subsequent call will attempt to open it again, skipping
+// the rows that were already read.
+func (l *Lister) Next(ctx context.Context) (Object, error) {
+	for {
+		if l.rows == nil {
+			if l.fileIndex >= len(l.manifest.Files) {
+				return Object{}, io.EOF
+			}
+			if err := l.openFile(ctx); err != nil {
+				return Object{}, err
+			}
+		}
+
+		row, err := l.rows.Read()
+		if err == io.EOF {
+			l.closeFile()
+			l.fileIndex++
+			continue
+		}
+		key := l.manifest.Files[l.fileIndex]
+		var pErr *csv.ParseError
+		if errors.As(err, &pErr) {
+			// Malformed rows are skipped but still occupy an index.
+			if l.index++; l.index > l.next {
+				l.next = l.index
+			}
+			return Object{}, fmt.Errorf("failed to parse row of inventory file %v: %w", key, err)
+		}
+		if err != nil {
+			l.closeFile()
+			l.index = l.fileStart
+			return Object{}, fmt.Errorf("failed to read inventory file %v: %w", key, err)
+		}
+
+		index := l.index
+		l.index++
+		if index < l.next {
+			continue
+		}
+		l.next = l.index
+		if l.keyCol < 0 || l.keyCol >= len(row) {
+			continue
+		}
+
+		obj := Object{
+			Index:  index,
+			Bucket: l.manifest.DefaultBucket,
+			Key:    row[l.keyCol],
+		}
+		if l.bucketCol >= 0 && l.bucketCol < len(row) && row[l.bucketCol] != "" {
+			obj.Bucket = row[l.bucketCol]
+		}
+		if l.manifest.EscapedKeys {
+			if obj.Key, err = url.QueryUnescape(obj.Key); err != nil {
+				return Object{}, fmt.Errorf("failed to unescape inventory key: %w", err)
+			}
+		}
+		if !strings.HasPrefix(obj.Key, l.prefix) {
+			continue
+		}
+		return obj, nil
+	}
+}
+
+// Close the lister and any listing file that is currently open.
+func (l *Lister) Close() error {
+	l.closeFile()
+	return nil
+}
@@ -0,0 +1,115 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Manifest describes the listing files of an inventory report and how to
+// extract the bucket and key of each object from the rows within them.
+type Manifest struct {
+	// Files contains the keys of the listing files in the order they should be
+	// read, relative to the bucket that contains the manifest.
+	Files []string
+
+	// Columns contains the names of the columns of each row. When empty the
+	// first row of each listing file is expected to be a header that names
+	// the columns.
+	Columns []string
+
+	// BucketColumn and KeyColumn are the names of the columns that contain the
+	// bucket and key of each object.
+	BucketColumn string
+	KeyColumn    string
+
+	// DefaultBucket is the bucket used for objects when the rows of a listing
+	// file do not contain a bucket.
+	DefaultBucket string
+
+	// EscapedKeys indicates that the keys of objects are URL encoded.
+	EscapedKeys bool
+}
+
+type s3Manifest struct {
+	SourceBucket string `json:"sourceBucket"`
+	FileFormat   string `json:"fileFormat"`
+	FileSchema   string `json:"fileSchema"`
+	Files        []struct {
+		Key string `json:"key"`
+	} `json:"files"`
+}
+
+// ParseS3Manifest parses the manifest.json of an Amazon S3 Inventory report.
+// Only reports in the CSV format are supported.
+func ParseS3Manifest(data []byte) (*Manifest, error) {
+	var m s3Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory manifest: %w", err)
+	}
+	if !strings.EqualFold(m.FileFormat, "CSV") {
+		return nil, fmt.Errorf("inventory file format %v is not supported, only CSV is supported", m.FileFormat)
+	}
+
+	var columns []string
+	for _, c := range strings.Split(m.FileSchema, ",") {
+		columns = append(columns, strings.TrimSpace(c))
+	}
+
+	manifest := &Manifest{
+		Columns:       columns,
+		BucketColumn:  "Bucket",
+		KeyColumn:     "Key",
+		DefaultBucket: m.SourceBucket,
+		EscapedKeys:   true,
+	}
+	if columnIndex(columns, manifest.KeyColumn) == -1 {
+		return nil, fmt.Errorf("inventory file schema does not contain a %v column: %v", manifest.KeyColumn, m.FileSchema)
+	}
+	for _, f := range m.Files {
+		manifest.Files = append(manifest.Files, f.Key)
+	}
+	return manifest, nil
+}
+
+type gcsManifest struct {
+	ReportShardsFileNames []string `json:"report_shards_file_names"`
+}
+
+// ParseGCSManifest parses the manifest of a Google Cloud Storage inventory
+// report, where the listing files (shards) are expected to reside alongside the
+// manifest. Only reports in the CSV format that include a header row are
+// supported.
+func ParseGCSManifest(manifestKey string, data []byte) (*Manifest, error) {
+	var m gcsManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory manifest: %w", err)
+	}
+
+	manifest := &Manifest{
+		BucketColumn: "bucket",
+		KeyColumn:    "name",
+	}
+	dir := path.Dir(manifestKey)
+	for _, name := range m.ReportShardsFileNames {
+		if strings.HasSuffix(name, ".parquet") {
+			return nil, errors.New("inventory file format parquet is not supported, only CSV is supported")
+		}
+		if dir != "." && dir != "/" {
+			name = dir + "/" + name
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+	return manifest, nil
+}
+
+func columnIndex(columns []string, name string) int {
+	for i, c := range columns {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,197 @@
+package inventory
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func filesOpenFn(files map[string][]byte) OpenFn {
+	return func(ctx context.Context, key string) (io.ReadCloser, error) {
+		data, exists := files[key]
+		if !exists {
+			return nil, errors.New("file not found")
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+func listAll(t *testing.T, l *Lister) (objs []Object) {
+	t.Helper()
+	for {
+		obj, err := l.Next(context.Background())
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+		objs = append(objs, obj)
+	}
+}
+
+func TestParseS3Manifest(t *testing.T) {
+	m, err := ParseS3Manifest([]byte(`{
+  "sourceBucket": "source",
+  "destinationBucket": "arn:aws:s3:::inventory",
+  "version": "2016-11-30",
+  "fileFormat": "CSV",
+  "fileSchema": "Bucket, Key, Size, LastModifiedDate",
+  "files": [
+    {"key": "source/daily/data/a.csv.gz", "size": 10, "MD5checksum": "foo"},
+    {"key": "source/daily/data/b.csv.gz", "size": 10, "MD5checksum": "bar"}
+  ]
+}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"source/daily/data/a.csv.gz", "source/daily/data/b.csv.gz"}, m.Files)
+	assert.Equal(t, []string{"Bucket", "Key", "Size", "LastModifiedDate"}, m.Columns)
+	assert.Equal(t, "source", m.DefaultBucket)
+	assert.True(t, m.EscapedKeys)
+
+	_, err = ParseS3Manifest([]byte(`{"fileFormat":"ORC","fileSchema":"Bucket, Key"}`))
+	assert.Error(t, err)
+
+	_, err = ParseS3Manifest([]byte(`{"fileFormat":"CSV","fileSchema":"Bucket, Size"}`))
+	assert.Error(t, err)
+}
+
+func TestParseGCSManifest(t *testing.T) {
+	m, err := ParseGCSManifest("reports/foo/manifest.json", []byte(`{
+  "records_processed": 2,
+  "shard_count": 2,
+  "report_shards_file_names": ["shard_0.csv", "shard_1.csv"]
+}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"reports/foo/shard_0.csv", "reports/foo/shard_1.csv"}, m.Files)
+	assert.Empty(t, m.Columns)
+	assert.False(t, m.EscapedKeys)
+
+	m, err = ParseGCSManifest("manifest.json", []byte(`{"report_shards_file_names":["shard_0.csv"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shard_0.csv"}, m.Files)
+
+	_, err = ParseGCSManifest("manifest.json", []byte(`{"report_shards_file_names":["shard_0.parquet"]}`))
+	assert.Error(t, err)
+}
+
+func TestListerS3(t *testing.T) {
+	m := &Manifest{
+		Files:         []string{"a.csv.gz", "b.csv.gz"},
+		Columns:       []string{"Bucket", "Key", "Size"},
+		BucketColumn:  "Bucket",
+		KeyColumn:     "Key",
+		DefaultBucket: "source",
+		EscapedKeys:   true,
+	}
+	open := filesOpenFn(map[string][]byte{
+		"a.csv.gz": gzipBytes(t, "\"source\",\"foo/a%20b.json\",\"10\"\n\"source\",\"bar/c.json\",\"10\"\n"),
+		"b.csv.gz": gzipBytes(t, "\"other\",\"foo/d.json\",\"10\"\n"),
+	})
+
+	assert.Equal(t, []Object{
+		{Index: 0, Bucket: "source", Key: "foo/a b.json"},
+		{Index: 1, Bucket: "source", Key: "bar/c.json"},
+		{Index: 2, Bucket: "other", Key: "foo/d.json"},
+	}, listAll(t, NewLister(m, open, "", -1)))
+
+	assert.Equal(t, []Object{
+		{Index: 0, Bucket: "source", Key: "foo/a b.json"},
+		{Index: 2, Bucket: "other", Key: "foo/d.json"},
+	}, listAll(t, NewLister(m, open, "foo/", -1)))
+
+	assert.Equal(t, []Object{
+		{Index: 2, Bucket: "other", Key: "foo/d.json"},
+	}, listAll(t, NewLister(m, open, "", 1)))
+}
+
+func TestListerHeader(t *testing.T) {
+	m := &Manifest{
+		Files:         []string{"shard_0.csv", "shard_1.csv"},
+		BucketColumn:  "bucket",
+		KeyColumn:     "name",
+		DefaultBucket: "default",
+	}
+	open := filesOpenFn(map[string][]byte{
+		"shard_0.csv": []byte("name,size,bucket\na.json,10,source\nb.json,10,source\n"),
+		"shard_1.csv": []byte("name,size\nc.json,10\n"),
+	})
+
+	assert.Equal(t, []Object{
+		{Index: 0, Bucket: "source", Key: "a.json"},
+		{Index: 1, Bucket: "source", Key: "b.json"},
+		{Index: 2, Bucket: "default", Key: "c.json"},
+	}, listAll(t, NewLister(m, open, "", -1)))
+
+	open = filesOpenFn(map[string][]byte{
+		"shard_0.csv": []byte("bucket,size\nsource,10\n"),
+	})
+	_, err := NewLister(m, open, "", -1).Next(context.Background())
+	assert.Error(t, err)
+}
+
+type failingReader struct {
+	data   []byte
+	failAt int
+	read   int
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.read >= f.failAt {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, f.data[f.read:f.failAt])
+	f.read += n
+	return n, nil
+}
+
+func (f *failingReader) Close() error {
+	return nil
+}
+
+func TestListerReopenAfterReadError(t *testing.T) {
+	data := []byte("a.json\nb.json\nc.json\n")
+	m := &Manifest{
+		Files:     []string{"a.csv"},
+		Columns:   []string{"Key"},
+		KeyColumn: "Key",
+	}
+
+	attempts := 0
+	l := NewLister(m, func(ctx context.Context, key string) (io.ReadCloser, error) {
+		attempts++
+		if attempts == 1 {
+			return &failingReader{data: data, failAt: 9}, nil
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}, "", -1)
+
+	obj, err := l.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Object{Index: 0, Key: "a.json"}, obj)
+
+	_, err = l.Next(context.Background())
+	require.Error(t, err)
+
+	assert.Equal(t, []Object{
+		{Index: 1, Key: "b.json"},
+		{Index: 2, Key: "c.json"},
+	}, listAll(t, l))
+	assert.Equal(t, 2, attempts)
+}
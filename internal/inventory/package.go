@@ -0,0 +1,5 @@
+// Package inventory provides a mechanism for discovering the objects of a
+// bucket from an inventory report (S3 Inventory or Cloud Storage inventory
+// reports) rather than listing the bucket, and for downloading the referenced
+// objects in parallel whilst tracking resumable progress.
+package inventory
@@ -0,0 +1,128 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+)
+
+// CacheAccessor provides access to cache resources.
+type CacheAccessor interface {
+	AccessCache(ctx context.Context, name string, fn func(cache.V1)) error
+}
+
+// Progress tracks the objects of an inventory that are being consumed, and
+// stores the index of the highest object where it and all prior objects have
+// been acknowledged within a cache, allowing consumption to resume from that
+// point after a restart.
+type Progress struct {
+	mgr       CacheAccessor
+	cacheName string
+	key       string
+
+	mut          sync.Mutex
+	checkpointer *checkpoint.Type
+	committed    int64
+}
+
+// NewProgress creates a new progress tracker that stores progress under a key
+// within a cache resource. If the cache name is empty then progress is tracked
+// but not stored.
+func NewProgress(mgr CacheAccessor, cacheName, key string) *Progress {
+	return &Progress{
+		mgr:          mgr,
+		cacheName:    cacheName,
+		key:          key,
+		checkpointer: checkpoint.New(),
+		committed:    -1,
+	}
+}
+
+// Load returns the index of the last object that was stored, or -1 if progress
+// has not yet been stored.
+func (p *Progress) Load(ctx context.Context) (int64, error) {
+	if p.cacheName == "" {
+		return -1, nil
+	}
+
+	var value []byte
+	var getErr error
+	if err := p.mgr.AccessCache(ctx, p.cacheName, func(c cache.V1) {
+		value, getErr = c.Get(ctx, p.key)
+	}); err != nil {
+		return -1, fmt.Errorf("failed to access checkpoint cache: %w", err)
+	}
+	if errors.Is(getErr, component.ErrKeyNotFound) {
+		return -1, nil
+	}
+	if getErr != nil {
+		return -1, fmt.Errorf("failed to read checkpoint %v: %w", p.key, getErr)
+	}
+
+	index, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("failed to parse checkpoint %v: %w", p.key, err)
+	}
+
+	p.mut.Lock()
+	p.committed = index
+	p.mut.Unlock()
+	return index, nil
+}
+
+// Track an object that is about to be consumed, objects must be tracked in
+// the order of their index. The returned function must be called once the
+// object has been fully acknowledged, at which point the stored progress is
+// updated if possible.
+func (p *Progress) Track(obj Object) func(ctx context.Context) error {
+	p.mut.Lock()
+	resolveFn := p.checkpointer.Track(obj.Index, 1)
+	p.mut.Unlock()
+
+	var once sync.Once
+	return func(ctx context.Context) (err error) {
+		once.Do(func() {
+			p.mut.Lock()
+			defer p.mut.Unlock()
+
+			highest, ok := resolveFn().(int64)
+			if !ok || highest <= p.committed {
+				return
+			}
+			if err = p.store(ctx, highest); err == nil {
+				p.committed = highest
+			}
+		})
+		return
+	}
+}
+
+// Committed returns the index of the highest object that has been stored.
+func (p *Progress) Committed() int64 {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.committed
+}
+
+func (p *Progress) store(ctx context.Context, index int64) error {
+	if p.cacheName == "" {
+		return nil
+	}
+
+	var setErr error
+	if err := p.mgr.AccessCache(ctx, p.cacheName, func(c cache.V1) {
+		setErr = c.Set(ctx, p.key, []byte(strconv.FormatInt(index, 10)), nil)
+	}); err != nil {
+		return fmt.Errorf("failed to access checkpoint cache: %w", err)
+	}
+	if setErr != nil {
+		return fmt.Errorf("failed to store checkpoint %v: %w", p.key, setErr)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package inventory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+func TestProgressStoresContiguousAcks(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["foo"] = map[string]mock.CacheItem{}
+
+	p := NewProgress(mgr, "foo", "s3://bucket/manifest.json")
+	after, err := p.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), after)
+
+	ctx := context.Background()
+	ack0 := p.Track(Object{Index: 0})
+	ack2 := p.Track(Object{Index: 2})
+	ack5 := p.Track(Object{Index: 5})
+
+	require.NoError(t, ack2(ctx))
+	assert.Equal(t, int64(-1), p.Committed())
+	_, exists := mgr.Caches["foo"]["s3://bucket/manifest.json"]
+	assert.False(t, exists)
+
+	require.NoError(t, ack0(ctx))
+	assert.Equal(t, int64(2), p.Committed())
+	assert.Equal(t, "2", mgr.Caches["foo"]["s3://bucket/manifest.json"].Value)
+
+	require.NoError(t, ack5(ctx))
+	assert.Equal(t, "5", mgr.Caches["foo"]["s3://bucket/manifest.json"].Value)
+
+	p = NewProgress(mgr, "foo", "s3://bucket/manifest.json")
+	after, err = p.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), after)
+}
+
+func TestProgressWithoutCache(t *testing.T) {
+	p := NewProgress(mock.NewManager(), "", "foo")
+
+	after, err := p.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), after)
+
+	require.NoError(t, p.Track(Object{Index: 3})(context.Background()))
+	assert.Equal(t, int64(3), p.Committed())
+}
+
+func TestProgressBadCheckpoint(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["foo"] = map[string]mock.CacheItem{
+		"bar": {Value: "nope"},
+	}
+
+	_, err := NewProgress(mgr, "foo", "bar").Load(context.Background())
+	assert.Error(t, err)
+
+	_, err = NewProgress(mgr, "baz", "bar").Load(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package inventory
+
+// Config contains configuration params for consuming the objects of a bucket
+// from an inventory report.
+type Config struct {
+	Manifest        string `json:"manifest" yaml:"manifest"`
+	Bucket          string `json:"bucket" yaml:"bucket"`
+	Concurrency     int    `json:"concurrency" yaml:"concurrency"`
+	CheckpointCache string `json:"checkpoint_cache" yaml:"checkpoint_cache"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		Manifest:        "",
+		Bucket:          "",
+		Concurrency:     16,
+		CheckpointCache: "",
+	}
+}
+
+// Enabled returns true if a manifest has been specified.
+func (c Config) Enabled() bool {
+	return c.Manifest != ""
+}
+
+// ManifestBucket returns the bucket that contains the manifest, falling back
+// to the provided default bucket when one has not been specified explicitly.
+func (c Config) ManifestBucket(defaultBucket string) string {
+	if c.Bucket != "" {
+		return c.Bucket
+	}
+	return defaultBucket
+}
@@ -3,8 +3,10 @@ package mock
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/clientpool"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
@@ -27,6 +29,14 @@ type Manager struct {
 	Processors map[string]Processor
 	Pipes      map[string]<-chan message.Transaction
 
+	// Checkpoints contains the latest offsets reported by components keyed by
+	// their partition.
+	Checkpoints    map[string]string
+	checkpointsMut sync.Mutex
+
+	// SharedClients holds the clients acquired by components.
+	SharedClients *clientpool.Registry
+
 	// OnRegisterEndpoint can be set in order to intercept endpoints registered
 	// by components.
 	OnRegisterEndpoint func(path string, h http.HandlerFunc)
@@ -41,6 +51,9 @@ func NewManager() *Manager {
 		Outputs:    map[string]OutputWriter{},
 		Processors: map[string]Processor{},
 		Pipes:      map[string]<-chan message.Transaction{},
+
+		Checkpoints:   map[string]string{},
+		SharedClients: clientpool.NewRegistry(),
 	}
 }
 
@@ -177,3 +190,31 @@ func (m *Manager) SetPipe(name string, t <-chan message.Transaction) {
 func (m *Manager) UnsetPipe(name string, t <-chan message.Transaction) {
 	delete(m.Pipes, name)
 }
+
+// ReportCheckpoint stores the latest offset reported for a partition.
+func (m *Manager) ReportCheckpoint(partition, offset string) {
+	m.checkpointsMut.Lock()
+	m.Checkpoints[partition] = offset
+	m.checkpointsMut.Unlock()
+}
+
+// RemoveCheckpoint removes the latest offset reported for a partition.
+func (m *Manager) RemoveCheckpoint(partition string) {
+	m.checkpointsMut.Lock()
+	delete(m.Checkpoints, partition)
+	m.checkpointsMut.Unlock()
+}
+
+// GetCheckpoint returns the latest offset reported for a partition.
+func (m *Manager) GetCheckpoint(partition string) (string, bool) {
+	m.checkpointsMut.Lock()
+	defer m.checkpointsMut.Unlock()
+	offset, exists := m.Checkpoints[partition]
+	return offset, exists
+}
+
+// AcquireSharedClient returns a client from the shared client registry of the
+// mock manager.
+func (m *Manager) AcquireSharedClient(key string, ctor clientpool.Ctor) (interface{}, func() error, error) {
+	return m.SharedClients.Acquire(key, ctor)
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/clientpool"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
@@ -26,6 +29,8 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/old/input"
 	"github.com/benthosdev/benthos/v4/internal/old/output"
 	"github.com/benthosdev/benthos/v4/internal/old/processor"
+	"github.com/benthosdev/benthos/v4/internal/pause"
+	"github.com/benthosdev/benthos/v4/internal/sampling"
 )
 
 // ErrResourceNotFound represents an error where a named resource could not be
@@ -82,6 +87,11 @@ type Type struct {
 
 	pipes    map[string]<-chan message.Transaction
 	pipeLock *sync.RWMutex
+
+	checkpoints *checkpoint.Registry
+	sampling    *sampling.Registry
+	clients     *clientpool.Registry
+	pauses      *pause.Registry
 }
 
 // OptFunc is an opt setting for a manager type.
@@ -125,6 +135,11 @@ func NewV2(conf ResourceConfig, apiReg APIReg, log log.Modular, stats *metrics.N
 
 		pipes:    map[string]<-chan message.Transaction{},
 		pipeLock: &sync.RWMutex{},
+
+		checkpoints: checkpoint.NewRegistry(),
+		sampling:    sampling.NewRegistry(),
+		clients:     clientpool.NewRegistry(),
+		pauses:      pause.NewRegistry(),
 	}
 
 	for _, opt := range opts {
@@ -283,8 +298,46 @@ func (t *Type) WithAddedMetrics(m metrics.Type) interop.Manager {
 	return &newT
 }
 
+// WithBloblangEnvironment returns a variant of this manager where components
+// are constructed with the provided Bloblang environment.
+func (t *Type) WithBloblangEnvironment(env *bloblang.Environment) interop.Manager {
+	newT := *t
+	newT.bloblEnv = env
+	return &newT
+}
+
 //------------------------------------------------------------------------------
 
+// RegisterComponentEndpoints registers the /checkpoints, /pause, /resume and
+// /sampling endpoints with the API of the manager. These endpoints change the
+// state of components or expose message contents, and are therefore only
+// registered by callers that have the API enabled.
+func (t *Type) RegisterComponentEndpoints() {
+	if t.apiReg == nil {
+		return
+	}
+	t.apiReg.RegisterEndpoint(
+		"/checkpoints",
+		"Returns a JSON array of the latest checkpoint positions reported by inputs. Results can be filtered with the query parameter `stream`.",
+		t.checkpoints.HandleList,
+	)
+	t.apiReg.RegisterEndpoint(
+		"/pause",
+		"POST: Pauses the labelled input or output matching the query parameter `label` or `path`, and optionally `stream`, until it is resumed. Inputs stop consuming and outputs stop writing, applying back pressure upstream. GET: Returns a JSON array of labelled inputs and outputs and whether they are paused.",
+		t.pauses.HandlePause,
+	)
+	t.apiReg.RegisterEndpoint(
+		"/resume",
+		"POST: Resumes the paused input or output matching the query parameter `label` or `path`, and optionally `stream`.",
+		t.pauses.HandleResume,
+	)
+	t.apiReg.RegisterEndpoint(
+		"/sampling",
+		"Lists the component paths of processors that can be sampled on GET requests. On POST requests messages entering and leaving the processor at the component path given by the query parameter `path` are captured and returned as a JSON array with sensitive fields redacted.",
+		t.sampling.HandleSample,
+	)
+}
+
 // RegisterEndpoint registers a server wide HTTP endpoint.
 func (t *Type) RegisterEndpoint(apiPath, desc string, h http.HandlerFunc) {
 	if len(t.stream) > 0 {
@@ -322,6 +375,61 @@ func (t *Type) UnsetPipe(name string, tran <-chan message.Transaction) {
 	t.pipeLock.Unlock()
 }
 
+// ReportCheckpoint stores the latest checkpointed offset of a partition
+// consumed by the component holding the manager. When the offset is numeric it
+// is also exported as the gauge metric `input_checkpoint_offset`, which is
+// reset to zero once the checkpoint is removed.
+func (t *Type) ReportCheckpoint(partition, offset string) {
+	var onRemove func()
+	if i, err := strconv.ParseInt(offset, 10, 64); err == nil {
+		gauge := t.stats.GetGaugeVec("input_checkpoint_offset", "partition").With(partition)
+		gauge.Set(i)
+		onRemove = func() {
+			gauge.Set(0)
+		}
+	}
+	t.checkpoints.Set(checkpoint.Position{
+		Stream:    t.stream,
+		Path:      "root." + query.SliceToDotPath(t.componentPath...),
+		Label:     t.label,
+		Partition: partition,
+		Offset:    offset,
+		Updated:   time.Now(),
+	}, onRemove)
+}
+
+// RemoveCheckpoint removes the checkpoint previously reported for a partition
+// consumed by the component holding the manager.
+func (t *Type) RemoveCheckpoint(partition string) {
+	t.checkpoints.Remove(t.stream, "root."+query.SliceToDotPath(t.componentPath...), partition)
+}
+
+// ResetCheckpoints removes all checkpoints reported by the components of a
+// stream, and should be called once the stream has been torn down.
+func (t *Type) ResetCheckpoints(stream string) {
+	t.checkpoints.Reset(stream)
+}
+
+// AcquireSharedClient returns a client shared by all components of the
+// instance that acquire it under the same key, creating it with the provided
+// constructor when it does not yet exist. The returned release function must
+// be called once the component no longer uses the client, and the client is
+// closed when the last component holding it releases it.
+func (t *Type) AcquireSharedClient(key string, ctor clientpool.Ctor) (client interface{}, release func() error, err error) {
+	return t.clients.Acquire(key, func() (interface{}, func() error, error) {
+		t.logger.Debugf("Creating shared client: %v", key)
+		return ctor()
+	})
+}
+
+func (t *Type) pauseComponent(label string) pause.Component {
+	return pause.Component{
+		Stream: t.stream,
+		Path:   "root." + query.SliceToDotPath(t.componentPath...),
+		Label:  label,
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // WithMetricsMapping returns a manager with the stored metrics exporter wrapped
@@ -478,7 +586,13 @@ func (t *Type) AccessInput(ctx context.Context, name string, fn func(iinput.Stre
 
 // NewInput attempts to create a new input component from a config.
 func (t *Type) NewInput(conf input.Config, pipelines ...iprocessor.PipelineConstructorFunc) (iinput.Streamed, error) {
-	return t.env.InputInit(conf, t.forLabel(conf.Label), pipelines...)
+	i, err := t.env.InputInit(conf, t.forLabel(conf.Label), pipelines...)
+	if err != nil || i == nil || conf.Label == "" {
+		return i, err
+	}
+	// Only labelled inputs can be paused, which avoids the overhead of gating
+	// components that cannot be easily referenced.
+	return t.pauses.WrapInput(t.pauseComponent(conf.Label), i), nil
 }
 
 // StoreInput attempts to store a new input resource. If an existing resource
@@ -546,7 +660,17 @@ func (t *Type) AccessProcessor(ctx context.Context, name string, fn func(iproces
 }
 
 // NewProcessor attempts to create a new processor component from a config.
+// Processors within a component path can be sampled with the `/sampling`
+// endpoint.
 func (t *Type) NewProcessor(conf processor.Config) (iprocessor.V1, error) {
+	p, err := t.initProcessor(conf)
+	if err != nil || len(t.componentPath) == 0 {
+		return p, err
+	}
+	return t.sampling.WrapProcessor(t.stream, "root."+query.SliceToDotPath(t.componentPath...), p), nil
+}
+
+func (t *Type) initProcessor(conf processor.Config) (iprocessor.V1, error) {
 	return t.env.ProcessorInit(conf, t.forLabel(conf.Label))
 }
 
@@ -571,7 +695,9 @@ func (t *Type) StoreProcessor(ctx context.Context, name string, conf processor.C
 		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 	}
 
-	newProcessor, err := t.intoPath("processor_resources").NewProcessor(conf)
+	// Resources are not wrapped for sampling as components such as workflow
+	// need access to the underlying processor.
+	newProcessor, err := t.intoPath("processor_resources").initProcessor(conf)
 	if err != nil {
 		return err
 	}
@@ -611,7 +737,13 @@ func (t *Type) AccessOutput(ctx context.Context, name string, fn func(ioutput.Sy
 
 // NewOutput attempts to create a new output component from a config.
 func (t *Type) NewOutput(conf output.Config, pipelines ...iprocessor.PipelineConstructorFunc) (ioutput.Streamed, error) {
-	return t.env.OutputInit(conf, t.forLabel(conf.Label), pipelines...)
+	o, err := t.env.OutputInit(conf, t.forLabel(conf.Label), pipelines...)
+	if err != nil || o == nil || conf.Label == "" {
+		return o, err
+	}
+	// Only labelled outputs can be paused, which avoids the overhead of gating
+	// components that cannot be easily referenced.
+	return t.pauses.WrapOutput(t.pauseComponent(conf.Label), o), nil
 }
 
 // StoreOutput attempts to store a new output resource. If an existing resource
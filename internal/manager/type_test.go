@@ -2,6 +2,7 @@ package manager_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -473,3 +474,71 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerProcessorErrorSource(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), noopStats())
+	require.NoError(t, err)
+
+	conf := processor.NewConfig()
+	conf.Type = processor.TypeBloblang
+	conf.Bloblang = `root = throw("nope")`
+
+	labelledConf := conf
+	labelledConf.Label = "foo"
+
+	tests := []struct {
+		mgr    *manager.Type
+		conf   processor.Config
+		source string
+	}{
+		{mgr: mgr, conf: labelledConf, source: "foo"},
+		{mgr: mgr.IntoPath("pipeline", "processors", "0").(*manager.Type), conf: conf, source: "root.pipeline.processors.0"},
+		{mgr: mgr, conf: conf, source: ""},
+	}
+
+	for _, test := range tests {
+		p, err := test.mgr.NewProcessor(test.conf)
+		require.NoError(t, err)
+
+		msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{[]byte("hello world")}))
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+
+		part := msgs[0].Get(0)
+		assert.Contains(t, part.MetaGet(message.FailFlagKey), "nope")
+		assert.Equal(t, test.source, part.MetaGet(message.ErrorSourceKey))
+		assert.Equal(t, "", part.MetaGet(message.ErrorClassKey))
+
+		p.CloseAsync()
+	}
+}
+
+func TestManagerCheckpointMetrics(t *testing.T) {
+	stats := metrics.NewLocal()
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), nil, log.Noop(), metrics.NewNamespaced(stats))
+	require.NoError(t, err)
+
+	fooMgr := mgr.ForStream("foo").IntoPath("input")
+	fooMgr.ReportCheckpoint("bar:0", "10")
+	fooMgr.ReportCheckpoint("bar:1", "20")
+
+	getOffset := func(partition string) int64 {
+		t.Helper()
+		for k, v := range stats.GetCounters() {
+			if strings.HasPrefix(k, "input_checkpoint_offset") && strings.Contains(k, partition) {
+				return v
+			}
+		}
+		t.Fatalf("metric for partition %v not found", partition)
+		return 0
+	}
+	assert.Equal(t, int64(10), getOffset("bar:0"))
+	assert.Equal(t, int64(20), getOffset("bar:1"))
+
+	fooMgr.RemoveCheckpoint("bar:0")
+	assert.Equal(t, int64(0), getOffset("bar:0"))
+	assert.Equal(t, int64(20), getOffset("bar:1"))
+
+	mgr.ResetCheckpoints("foo")
+	assert.Equal(t, int64(0), getOffset("bar:1"))
+}
@@ -0,0 +1,160 @@
+package message
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// BatchInfo describes the batch that a message part belongs to. Batch info is
+// attached to each message part and therefore survives the part being copied
+// into new batches, which means that once a batch has been split each
+// resulting message part still references the batch it originated from.
+type BatchInfo struct {
+	// ID is a unique identifier of the batch.
+	ID string
+
+	// Origin is the name of the component that created the batch, which is
+	// either the type of an input or "batching" for batches formed by a batch
+	// policy.
+	Origin string
+
+	// Size is the number of messages within the batch at the point at which
+	// it was created.
+	Size int
+
+	// Created is the time at which the batch was created.
+	Created time.Time
+
+	// ParentIDs contains the distinct IDs of the batches that were merged in
+	// order to form this batch, and is empty for batches created by inputs.
+	ParentIDs []string
+}
+
+// NewBatchInfo creates batch info with a unique ID for a new batch of a given
+// size.
+func NewBatchInfo(origin string, size int) BatchInfo {
+	return BatchInfo{
+		ID:      newBatchID(),
+		Origin:  origin,
+		Size:    size,
+		Created: time.Now(),
+	}
+}
+
+func newBatchID() string {
+	if u4, err := uuid.NewV4(); err == nil {
+		return u4.String()
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
+
+// batchRef is shared by all message parts of a batch. Generating the ID of a
+// batch and collecting the IDs of its parents is deferred until the batch info
+// is first read, as the vast majority of batches are never inspected.
+type batchRef struct {
+	origin  string
+	size    int
+	created time.Time
+
+	// parents contains the batch of each merged message part, and is released
+	// once the batch info has been resolved.
+	parents []*batchRef
+
+	once sync.Once
+	info BatchInfo
+}
+
+func newBatchRef(origin string, size int) *batchRef {
+	return &batchRef{
+		origin:  origin,
+		size:    size,
+		created: time.Now(),
+	}
+}
+
+func (b *batchRef) resolve() BatchInfo {
+	b.once.Do(func() {
+		if b.info.ID != "" {
+			return
+		}
+		b.info = BatchInfo{
+			ID:      newBatchID(),
+			Origin:  b.origin,
+			Size:    b.size,
+			Created: b.created,
+		}
+		seen := map[*batchRef]struct{}{}
+		for _, p := range b.parents {
+			if _, dupe := seen[p]; dupe {
+				continue
+			}
+			seen[p] = struct{}{}
+			b.info.ParentIDs = append(b.info.ParentIDs, p.resolve().ID)
+		}
+		b.parents = nil
+	})
+	return b.info
+}
+
+//------------------------------------------------------------------------------
+
+// GetBatchInfo returns the batch info attached to a message part, and a
+// boolean indicating whether it exists.
+func GetBatchInfo(p *Part) (BatchInfo, bool) {
+	if p.batch == nil {
+		return BatchInfo{}, false
+	}
+	return p.batch.resolve(), true
+}
+
+// WithBatchInfo returns the message part with batch info attached.
+func WithBatchInfo(info BatchInfo, p *Part) *Part {
+	newP := *p
+	newP.batch = &batchRef{info: info}
+	return &newP
+}
+
+// InitBatchInfo attaches new batch info to each message part of a batch that
+// does not already have batch info, which is the case for batches freshly
+// created by an input. Parts that already carry batch info, such as those
+// formed by a batch policy within an input, are left unchanged.
+//
+// The parts of a freshly created batch are owned by the caller and are
+// therefore modified in place.
+func InitBatchInfo(origin string, msg *Batch) {
+	var ref *batchRef
+	_ = msg.Iter(func(i int, p *Part) error {
+		if p.batch != nil {
+			return nil
+		}
+		if ref == nil {
+			ref = newBatchRef(origin, msg.Len())
+		}
+		p.batch = ref
+		return nil
+	})
+}
+
+// MergeBatchInfo attaches new batch info to all message parts of a batch that
+// has been formed from the parts of other batches. The distinct IDs of the
+// batches that the parts previously belonged to are recorded as the parents of
+// the new batch.
+func MergeBatchInfo(origin string, msg *Batch) {
+	ref := newBatchRef(origin, msg.Len())
+
+	parts := make([]*Part, msg.Len())
+	_ = msg.Iter(func(i int, p *Part) error {
+		if p.batch != nil {
+			ref.parents = append(ref.parents, p.batch)
+		}
+		newP := *p
+		newP.batch = ref
+		parts[i] = &newP
+		return nil
+	})
+	msg.SetAll(parts)
+}
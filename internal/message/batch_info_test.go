@@ -0,0 +1,91 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchInfoInit(t *testing.T) {
+	msg := QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+
+	_, exists := GetBatchInfo(msg.Get(0))
+	assert.False(t, exists)
+
+	InitBatchInfo("foo", msg)
+
+	infoA, exists := GetBatchInfo(msg.Get(0))
+	require.True(t, exists)
+	infoB, exists := GetBatchInfo(msg.Get(1))
+	require.True(t, exists)
+
+	assert.Equal(t, infoA, infoB)
+	assert.NotEmpty(t, infoA.ID)
+	assert.Equal(t, "foo", infoA.Origin)
+	assert.Equal(t, 2, infoA.Size)
+	assert.False(t, infoA.Created.IsZero())
+	assert.Empty(t, infoA.ParentIDs)
+
+	// Existing batch info is retained.
+	InitBatchInfo("bar", msg)
+	infoC, _ := GetBatchInfo(msg.Get(0))
+	assert.Equal(t, infoA, infoC)
+
+	// Batch info survives splitting the batch.
+	split := QuickBatch(nil)
+	split.Append(msg.Get(1).Copy())
+	infoD, exists := GetBatchInfo(split.Get(0))
+	require.True(t, exists)
+	assert.Equal(t, infoA, infoD)
+}
+
+func TestBatchInfoMerge(t *testing.T) {
+	msgA := QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+	InitBatchInfo("foo", msgA)
+	infoA, _ := GetBatchInfo(msgA.Get(0))
+
+	msgB := QuickBatch([][]byte{[]byte("baz")})
+	InitBatchInfo("foo", msgB)
+	infoB, _ := GetBatchInfo(msgB.Get(0))
+
+	require.NotEqual(t, infoA.ID, infoB.ID)
+
+	merged := QuickBatch(nil)
+	merged.Append(msgA.Get(0), msgB.Get(0), msgA.Get(1), NewPart([]byte("buz")))
+	MergeBatchInfo("batching", merged)
+
+	info, exists := GetBatchInfo(merged.Get(0))
+	require.True(t, exists)
+	assert.Equal(t, "batching", info.Origin)
+	assert.Equal(t, 4, info.Size)
+	assert.Equal(t, []string{infoA.ID, infoB.ID}, info.ParentIDs)
+	assert.NotContains(t, []string{infoA.ID, infoB.ID}, info.ID)
+
+	for i := 1; i < merged.Len(); i++ {
+		other, _ := GetBatchInfo(merged.Get(i))
+		assert.Equal(t, info, other)
+	}
+
+	// The original parts are unchanged.
+	infoC, _ := GetBatchInfo(msgA.Get(0))
+	assert.Equal(t, infoA, infoC)
+}
+
+func TestBatchInfoLazy(t *testing.T) {
+	msgA := QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+	InitBatchInfo("foo", msgA)
+
+	merged := QuickBatch(nil)
+	merged.Append(msgA.Get(0), msgA.Get(1))
+	MergeBatchInfo("batching", merged)
+
+	// Nothing is generated until the batch info is read.
+	assert.Empty(t, msgA.Get(0).batch.info.ID)
+	assert.Empty(t, merged.Get(0).batch.info.ID)
+
+	info, _ := GetBatchInfo(merged.Get(1))
+	infoA, _ := GetBatchInfo(msgA.Get(1))
+	assert.Equal(t, []string{infoA.ID}, info.ParentIDs)
+	assert.Nil(t, merged.Get(0).batch.parents)
+}
@@ -0,0 +1,70 @@
+package message
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// sanitizeMetaValue converts a value into one of the types supported by typed
+// metadata.
+func sanitizeMetaValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string, []byte, int64, uint64, float64, bool, time.Time:
+		return v
+	case int:
+		return int64(t)
+	case int8:
+		return int64(t)
+	case int16:
+		return int64(t)
+	case int32:
+		return int64(t)
+	case uint:
+		return uint64(t)
+	case uint8:
+		return uint64(t)
+	case uint16:
+		return uint64(t)
+	case uint32:
+		return uint64(t)
+	case float32:
+		return float64(t)
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case nil:
+		return "null"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// MetaValueToString returns the string form of a typed metadata value.
+func MetaValueToString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	case uint64:
+		return strconv.FormatUint(t, 10)
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	return MetaValueToString(sanitizeMetaValue(v))
+}
@@ -6,3 +6,23 @@ package message
 //
 // TODO: V4 stop hiding this as a metadata field
 var FailFlagKey = "benthos_processing_failed"
+
+// Metadata keys that describe the processor error of a message part that has
+// failed, which are set alongside FailFlagKey.
+var (
+	// ErrorSourceKey contains the label of the processor that failed, or its
+	// path within the config when it has no label.
+	ErrorSourceKey = "benthos_error_source"
+
+	// ErrorClassKey contains the class of the error, such as "schema" or
+	// "transient", and is absent when the class is unknown.
+	ErrorClassKey = "benthos_error_class"
+
+	// ErrorCodeKey contains a code identifying the specific error, such as an
+	// HTTP status code, when one is known.
+	ErrorCodeKey = "benthos_error_code"
+
+	// ErrorRetriableKey contains "true" when retrying the processor might
+	// succeed, and is absent otherwise.
+	ErrorRetriableKey = "benthos_error_retriable"
+)
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"sync/atomic"
 )
 
 var useNumber = true
@@ -19,16 +20,65 @@ func init() {
 
 //------------------------------------------------------------------------------
 
+// sharedFlag marks data that is referenced by shallow copies of a part. The
+// flag is allocated along with the data and is referenced by every part that
+// shares it, which allows a copy to mark the data as shared without writing to
+// the part it was copied from. Once set the flag is never cleared.
+type sharedFlag struct {
+	v int32
+}
+
+func (f *sharedFlag) set() {
+	if f != nil && atomic.LoadInt32(&f.v) == 0 {
+		atomic.StoreInt32(&f.v, 1)
+	}
+}
+
+func (f *sharedFlag) isSet() bool {
+	return f != nil && atomic.LoadInt32(&f.v) == 1
+}
+
 type rwData struct {
 	rawBytes  []byte
 	jsonCache interface{}
-	metadata  map[string]string
+	metadata  map[string]interface{}
+
+	// metaShared is set when the metadata map is shared with a shallow copy
+	// of the part, in which case it must be cloned before being modified. It
+	// is allocated whenever a new metadata map is assigned.
+	metaShared *sharedFlag
+
+	// jsonShared is set when the structured contents are shared with a
+	// shallow copy of the part, in which case they must be cloned before being
+	// mutated. It is allocated whenever new structured contents are assigned.
+	jsonShared *sharedFlag
+}
+
+func cloneMeta(meta map[string]interface{}) map[string]interface{} {
+	if meta == nil {
+		return nil
+	}
+	clonedMeta := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		clonedMeta[k] = v
+	}
+	return clonedMeta
+}
+
+// writeableMeta clones the metadata map if it is shared with other copies of
+// the part, and must be called before modifying it.
+func (d *rwData) writeableMeta() {
+	if d.metaShared.isSet() {
+		d.metadata = cloneMeta(d.metadata)
+		d.metaShared = &sharedFlag{}
+	}
 }
 
 // Part represents a single Benthos message.
 type Part struct {
-	data *rwData
-	ctx  context.Context
+	data  *rwData
+	ctx   context.Context
+	batch *batchRef
 }
 
 // NewPart initializes a new message part.
@@ -43,34 +93,32 @@ func NewPart(data []byte) *Part {
 
 //------------------------------------------------------------------------------
 
-// Copy creates a shallow copy of the message part.
+// Copy creates a shallow copy of the message part. The payload and metadata of
+// the copy are shared with the original until either of them are modified,
+// where metadata is copied on write, the payload is replaced rather than
+// mutated by Set and SetJSON, and structured contents are cloned by JSONMut.
+//
+// The original part is only read from, and therefore multiple copies of a part
+// can be created in parallel.
 func (p *Part) Copy() *Part {
-	var clonedMeta map[string]string
-	if p.data.metadata != nil {
-		clonedMeta = make(map[string]string, len(p.data.metadata))
-		for k, v := range p.data.metadata {
-			clonedMeta[k] = v
-		}
-	}
+	p.data.metaShared.set()
+	p.data.jsonShared.set()
 	return &Part{
 		data: &rwData{
-			rawBytes:  p.data.rawBytes,
-			metadata:  clonedMeta,
-			jsonCache: p.data.jsonCache,
+			rawBytes:   p.data.rawBytes,
+			metadata:   p.data.metadata,
+			metaShared: p.data.metaShared,
+			jsonCache:  p.data.jsonCache,
+			jsonShared: p.data.jsonShared,
 		},
-		ctx: p.ctx,
+		ctx:   p.ctx,
+		batch: p.batch,
 	}
 }
 
 // DeepCopy creates a new deep copy of the message part.
 func (p *Part) DeepCopy() *Part {
-	var clonedMeta map[string]string
-	if p.data.metadata != nil {
-		clonedMeta = make(map[string]string, len(p.data.metadata))
-		for k, v := range p.data.metadata {
-			clonedMeta[k] = v
-		}
-	}
+	clonedMeta := cloneMeta(p.data.metadata)
 	var clonedJSON interface{}
 	if p.data.jsonCache != nil {
 		var err error
@@ -83,13 +131,21 @@ func (p *Part) DeepCopy() *Part {
 		np = make([]byte, len(p.data.rawBytes))
 		copy(np, p.data.rawBytes)
 	}
+	d := &rwData{
+		rawBytes:  np,
+		metadata:  clonedMeta,
+		jsonCache: clonedJSON,
+	}
+	if clonedMeta != nil {
+		d.metaShared = &sharedFlag{}
+	}
+	if clonedJSON != nil {
+		d.jsonShared = &sharedFlag{}
+	}
 	return &Part{
-		data: &rwData{
-			rawBytes:  np,
-			metadata:  clonedMeta,
-			jsonCache: clonedJSON,
-		},
-		ctx: p.ctx,
+		data:  d,
+		ctx:   p.ctx,
+		batch: p.batch,
 	}
 }
 
@@ -161,6 +217,7 @@ func (p *Part) JSON() (interface{}, error) {
 
 	var dummy json.RawMessage
 	if err = dec.Decode(&dummy); err == io.EOF {
+		p.data.jsonShared = &sharedFlag{}
 		return p.data.jsonCache, nil
 	}
 
@@ -171,10 +228,34 @@ func (p *Part) JSON() (interface{}, error) {
 	return nil, err
 }
 
+// JSONMut attempts to parse the message part as a JSON document and returns a
+// result that is safe to mutate. The structured contents are only cloned when
+// they are shared with other copies of the part, and the result becomes the
+// live contents of the part. Any raw bytes cached by Get are discarded, and the
+// contents are serialized lazily when next read as raw bytes. Mutations made
+// after the raw bytes have since been read again are therefore not reflected
+// by them until JSONMut is called again.
+func (p *Part) JSONMut() (interface{}, error) {
+	jObj, err := p.JSON()
+	if err != nil {
+		return nil, err
+	}
+	if p.data.jsonShared.isSet() {
+		if jObj, err = cloneGeneric(jObj); err != nil {
+			return nil, err
+		}
+	}
+	// Setting the contents also discards any cached raw bytes, which would
+	// otherwise become stale once the result is mutated.
+	p.SetJSON(jObj)
+	return jObj, nil
+}
+
 // Set the value of the message part.
 func (p *Part) Set(data []byte) *Part {
 	p.data.rawBytes = data
 	p.data.jsonCache = nil
+	p.data.jsonShared = nil
 	return p
 }
 
@@ -186,26 +267,57 @@ func (p *Part) SetJSON(jObj interface{}) {
 		p.data.rawBytes = []byte(`null`)
 	}
 	p.data.jsonCache = jObj
+	p.data.jsonShared = &sharedFlag{}
 }
 
 //------------------------------------------------------------------------------
 
 // MetaGet returns a metadata value if a key exists, otherwise an empty string.
+// Typed metadata values are returned in their string form.
 func (p *Part) MetaGet(key string) string {
 	if p.data.metadata == nil {
 		return ""
 	}
-	return p.data.metadata[key]
+	v, exists := p.data.metadata[key]
+	if !exists {
+		return ""
+	}
+	return MetaValueToString(v)
+}
+
+// MetaGetTyped returns a metadata value in its typed form, which is one of
+// string, []byte, int64, uint64, float64, bool or time.Time, and whether the
+// key exists.
+func (p *Part) MetaGetTyped(key string) (interface{}, bool) {
+	if p.data.metadata == nil {
+		return nil, false
+	}
+	v, exists := p.data.metadata[key]
+	return v, exists
 }
 
 // MetaSet sets the value of a metadata key.
 func (p *Part) MetaSet(key, value string) {
+	p.metaSet(key, value)
+}
+
+// MetaSetTyped sets the value of a metadata key to a typed value. Values are
+// stored as one of string, []byte, int64, uint64, float64, bool or time.Time,
+// where other integer and float types are widened and any other values are
+// stored as their JSON serialized form.
+func (p *Part) MetaSetTyped(key string, value interface{}) {
+	p.metaSet(key, sanitizeMetaValue(value))
+}
+
+func (p *Part) metaSet(key string, value interface{}) {
 	if p.data.metadata == nil {
-		p.data.metadata = map[string]string{
+		p.data.metadata = map[string]interface{}{
 			key: value,
 		}
+		p.data.metaShared = &sharedFlag{}
 		return
 	}
+	p.data.writeableMeta()
 	p.data.metadata[key] = value
 }
 
@@ -214,15 +326,29 @@ func (p *Part) MetaDelete(key string) {
 	if p.data.metadata == nil {
 		return
 	}
+	if _, exists := p.data.metadata[key]; !exists {
+		return
+	}
+	p.data.writeableMeta()
 	delete(p.data.metadata, key)
 }
 
-// MetaIter iterates each metadata key/value pair.
+// MetaIter iterates each metadata key/value pair, where typed metadata values
+// are provided in their string form.
 func (p *Part) MetaIter(f func(k, v string) error) error {
+	return p.MetaIterTyped(func(k string, v interface{}) error {
+		return f(k, MetaValueToString(v))
+	})
+}
+
+// MetaIterTyped iterates each metadata key/value pair, where values are
+// provided in their typed form.
+func (p *Part) MetaIterTyped(f func(k string, v interface{}) error) error {
 	if p.data.metadata == nil {
 		// Warning: If we remove this we need to compensate with a way to force
 		// initialisation
-		p.data.metadata = map[string]string{}
+		p.data.metadata = map[string]interface{}{}
+		p.data.metaShared = &sharedFlag{}
 		return nil
 	}
 	for ak, av := range p.data.metadata {
@@ -1,8 +1,13 @@
 package message
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestPartBasic(t *testing.T) {
@@ -176,3 +181,289 @@ func TestPartDeepCopy(t *testing.T) {
 		t.Errorf("Metadata changed after copy: %v != %v", act, exp)
 	}
 }
+
+func TestPartCopyOnWriteMeta(t *testing.T) {
+	p := NewPart([]byte(`hello world`))
+	p.MetaSet("foo", "bar")
+
+	p2 := p.Copy()
+	p3 := p.Copy()
+
+	p2.MetaSet("foo", "p2")
+	p3.MetaDelete("foo")
+	p.MetaSet("baz", "p")
+
+	if exp, act := "bar", p.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := "p2", p2.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := "", p3.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := "", p2.MetaGet("baz"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := "", p3.MetaGet("baz"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+
+	p2.Set([]byte(`changed`))
+	if exp, act := `hello world`, string(p.Get()); exp != act {
+		t.Errorf("Payload changed after copy: %v != %v", act, exp)
+	}
+}
+
+func TestPartCopyParallel(t *testing.T) {
+	p := NewPart([]byte(`{"foo":"bar"}`))
+	p.MetaSet("foo", "bar")
+	if _, err := p.JSON(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Copies of a shared part are created in parallel by brokers and
+	// workflows, and must not write to the original part.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p2 := p.Copy()
+			p2.MetaSet("foo", strconv.Itoa(i))
+			jObj, err := p2.JSONMut()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			jObj.(map[string]interface{})["foo"] = i
+		}(i)
+	}
+	wg.Wait()
+
+	if exp, act := "bar", p.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := `{"foo":"bar"}`, string(p.Get()); exp != act {
+		t.Errorf("Wrong contents: %v != %v", act, exp)
+	}
+
+	// Modifying the original after it was copied must not affect copies.
+	p2 := p.Copy()
+	p.MetaSet("foo", "baz")
+	jObj, err := p.JSONMut()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jObj.(map[string]interface{})["foo"] = "baz"
+	if exp, act := "bar", p2.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := `{"foo":"bar"}`, string(p2.Get()); exp != act {
+		t.Errorf("Wrong contents: %v != %v", act, exp)
+	}
+}
+
+func BenchmarkPartCopy(b *testing.B) {
+	p := NewPart([]byte(`hello world`))
+	for i := 0; i < 10; i++ {
+		p.MetaSet(fmt.Sprintf("key%v", i), "value")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p2 := p.Copy()
+		if p2.MetaGet("key0") != "value" {
+			b.Fatal("wrong metadata")
+		}
+	}
+}
+
+func TestPartJSONMut(t *testing.T) {
+	p := NewPart([]byte(`{"hello":"world"}`))
+
+	jObj, err := p.JSONMut()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2 := p.Copy()
+
+	jObj.(map[string]interface{})["hello"] = "mutated"
+	if exp, act := `{"hello":"mutated"}`, string(p.Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	jObj2, err := p2.JSONMut()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jObj2.(map[string]interface{})["hello"] = "p2"
+	if exp, act := `{"hello":"p2"}`, string(p2.Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := `{"hello":"mutated"}`, string(p.Get()); exp != act {
+		t.Errorf("Copy mutated the original: %v != %v", act, exp)
+	}
+}
+
+func TestPartJSONMutDiscardsCachedBytes(t *testing.T) {
+	p := NewPart(nil)
+	p.SetJSON(map[string]interface{}{"hello": "world"})
+
+	if exp, act := `{"hello":"world"}`, string(p.Get()); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	jObj, err := p.JSONMut()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jObj.(map[string]interface{})["hello"] = "mutated"
+	if exp, act := `{"hello":"mutated"}`, string(p.Get()); exp != act {
+		t.Errorf("Stale cached bytes: %v != %v", act, exp)
+	}
+}
+
+var benchJSONChainDoc = []byte(`{"id":"foo","user":{"name":"bar","tags":["a","b","c"]},"counts":[1,2,3,4,5]}`)
+
+// BenchmarkJSONChainReparse emulates a chain of processors that each parse,
+// mutate and then serialize the contents of a message.
+func BenchmarkJSONChainReparse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewPart(benchJSONChainDoc)
+		for j := 0; j < 5; j++ {
+			p = p.Copy()
+			jObj, err := p.JSON()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if jObj, err = CopyJSON(jObj); err != nil {
+				b.Fatal(err)
+			}
+			jObj.(map[string]interface{})["step"] = j
+			rawBytes, err := json.Marshal(jObj)
+			if err != nil {
+				b.Fatal(err)
+			}
+			p.Set(rawBytes)
+		}
+		_ = p.Get()
+	}
+}
+
+// BenchmarkJSONChainStructured emulates a chain of processors that each mutate
+// the structured contents of a message, which are serialized once at the end.
+func BenchmarkJSONChainStructured(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewPart(benchJSONChainDoc)
+		for j := 0; j < 5; j++ {
+			p = p.Copy()
+			jObj, err := p.JSONMut()
+			if err != nil {
+				b.Fatal(err)
+			}
+			jObj.(map[string]interface{})["step"] = j
+		}
+		_ = p.Get()
+	}
+}
+
+// BenchmarkJSONMutOwned measures obtaining mutable structured contents that
+// were parsed by the part itself, and therefore aren't cloned.
+func BenchmarkJSONMutOwned(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewPart(benchJSONChainDoc)
+		if _, err := p.JSONMut(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkJSONCopyOwned is the equivalent of BenchmarkJSONMutOwned where the
+// contents are always cloned.
+func BenchmarkJSONCopyOwned(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		p := NewPart(benchJSONChainDoc)
+		jObj, err := p.JSON()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := CopyJSON(jObj); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPartTypedMeta(t *testing.T) {
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	p := NewPart(nil)
+	p.MetaSet("str", "hello")
+	p.MetaSetTyped("int", 5)
+	p.MetaSetTyped("uint", uint32(6))
+	p.MetaSetTyped("float", float32(1.5))
+	p.MetaSetTyped("bool", true)
+	p.MetaSetTyped("bytes", []byte("raw"))
+	p.MetaSetTyped("ts", ts)
+	p.MetaSetTyped("number", json.Number("12"))
+	p.MetaSetTyped("obj", map[string]interface{}{"foo": "bar"})
+
+	expTyped := map[string]interface{}{
+		"str":    "hello",
+		"int":    int64(5),
+		"uint":   uint64(6),
+		"float":  float64(1.5),
+		"bool":   true,
+		"bytes":  []byte("raw"),
+		"ts":     ts,
+		"number": int64(12),
+		"obj":    `{"foo":"bar"}`,
+	}
+	expStrings := map[string]string{
+		"str":    "hello",
+		"int":    "5",
+		"uint":   "6",
+		"float":  "1.5",
+		"bool":   "true",
+		"bytes":  "raw",
+		"ts":     "2022-01-02T03:04:05.000000006Z",
+		"number": "12",
+		"obj":    `{"foo":"bar"}`,
+	}
+
+	for k, exp := range expTyped {
+		act, exists := p.MetaGetTyped(k)
+		if !exists {
+			t.Errorf("Metadata key %v missing", k)
+		}
+		if !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong typed metadata for %v: %#v != %#v", k, act, exp)
+		}
+		if exp, act := expStrings[k], p.MetaGet(k); exp != act {
+			t.Errorf("Wrong metadata for %v: %v != %v", k, act, exp)
+		}
+	}
+
+	actStrings := map[string]string{}
+	_ = p.MetaIter(func(k, v string) error {
+		actStrings[k] = v
+		return nil
+	})
+	if !reflect.DeepEqual(expStrings, actStrings) {
+		t.Errorf("Wrong iterated metadata: %v != %v", actStrings, expStrings)
+	}
+
+	p2 := p.Copy()
+	p2.MetaSetTyped("int", false)
+	if v, _ := p.MetaGetTyped("int"); v != int64(5) {
+		t.Errorf("Metadata changed after copy: %v", v)
+	}
+	if v, _ := p2.MetaGetTyped("int"); v != false {
+		t.Errorf("Wrong metadata after copy: %v", v)
+	}
+}
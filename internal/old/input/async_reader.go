@@ -170,6 +170,7 @@ func (r *AsyncReader) loop() {
 
 		resChan := make(chan error)
 		tracing.InitSpans("input_"+r.typeStr, msg)
+		message.InitBatchInfo(r.typeStr, msg)
 		select {
 		case r.transactions <- message.NewTransaction(msg, resChan):
 		case <-r.shutSig.CloseAtLeisureChan():
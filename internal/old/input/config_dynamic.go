@@ -2,14 +2,34 @@ package input
 
 // DynamicConfig contains configuration for the Dynamic input type.
 type DynamicConfig struct {
-	Inputs map[string]Config `json:"inputs" yaml:"inputs"`
-	Prefix string            `json:"prefix" yaml:"prefix"`
+	Inputs      map[string]Config        `json:"inputs" yaml:"inputs"`
+	Prefix      string                   `json:"prefix" yaml:"prefix"`
+	Persistence DynamicPersistenceConfig `json:"persistence" yaml:"persistence"`
 }
 
 // NewDynamicConfig creates a new DynamicConfig with default values.
 func NewDynamicConfig() DynamicConfig {
 	return DynamicConfig{
-		Inputs: map[string]Config{},
-		Prefix: "",
+		Inputs:      map[string]Config{},
+		Prefix:      "",
+		Persistence: NewDynamicPersistenceConfig(),
+	}
+}
+
+// DynamicPersistenceConfig contains configuration fields for persisting the
+// inputs added to a Dynamic input via its API.
+type DynamicPersistenceConfig struct {
+	Path  string `json:"path" yaml:"path"`
+	Cache string `json:"cache" yaml:"cache"`
+	Key   string `json:"key" yaml:"key"`
+}
+
+// NewDynamicPersistenceConfig creates a new DynamicPersistenceConfig with
+// default values.
+func NewDynamicPersistenceConfig() DynamicPersistenceConfig {
+	return DynamicPersistenceConfig{
+		Path:  "",
+		Cache: "",
+		Key:   "benthos_dynamic_inputs",
 	}
 }
@@ -0,0 +1,34 @@
+package input
+
+import (
+	ftpSetup "github.com/benthosdev/benthos/v4/internal/impl/ftp/shared"
+)
+
+// FTPConfig contains configuration fields for the FTP input type.
+type FTPConfig struct {
+	ftpSetup.ConnectionConfig `json:",inline" yaml:",inline"`
+	Paths                     []string      `json:"paths" yaml:"paths"`
+	Codec                     string        `json:"codec" yaml:"codec"`
+	DeleteOnFinish            bool          `json:"delete_on_finish" yaml:"delete_on_finish"`
+	MoveOnFinish              string        `json:"move_on_finish" yaml:"move_on_finish"`
+	MaxBuffer                 int           `json:"max_buffer" yaml:"max_buffer"`
+	Watcher                   watcherConfig `json:"watcher" yaml:"watcher"`
+}
+
+// NewFTPConfig creates a new FTPConfig with default values.
+func NewFTPConfig() FTPConfig {
+	return FTPConfig{
+		ConnectionConfig: ftpSetup.NewConnectionConfig(),
+		Paths:            []string{},
+		Codec:            "all-bytes",
+		DeleteOnFinish:   false,
+		MoveOnFinish:     "",
+		MaxBuffer:        1000000,
+		Watcher: watcherConfig{
+			Enabled:      false,
+			MinimumAge:   "1s",
+			PollInterval: "1s",
+			Cache:        "",
+		},
+	}
+}
@@ -1,18 +1,27 @@
 package input
 
+import (
+	"github.com/benthosdev/benthos/v4/internal/inventory"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
+)
+
 // GCPCloudStorageConfig contains configuration fields for the Google Cloud
 // Storage input type.
 type GCPCloudStorageConfig struct {
-	Bucket        string `json:"bucket" yaml:"bucket"`
-	Prefix        string `json:"prefix" yaml:"prefix"`
-	Codec         string `json:"codec" yaml:"codec"`
-	DeleteObjects bool   `json:"delete_objects" yaml:"delete_objects"`
+	Bucket        string           `json:"bucket" yaml:"bucket"`
+	Prefix        string           `json:"prefix" yaml:"prefix"`
+	Codec         string           `json:"codec" yaml:"codec"`
+	DeleteObjects bool             `json:"delete_objects" yaml:"delete_objects"`
+	Proxy         proxy.Config     `json:"proxy" yaml:"proxy"`
+	Inventory     inventory.Config `json:"inventory" yaml:"inventory"`
 }
 
 // NewGCPCloudStorageConfig creates a new GCPCloudStorageConfig with default
 // values.
 func NewGCPCloudStorageConfig() GCPCloudStorageConfig {
 	return GCPCloudStorageConfig{
-		Codec: "all-bytes",
+		Codec:     "all-bytes",
+		Proxy:     proxy.NewConfig(),
+		Inventory: inventory.NewConfig(),
 	}
 }
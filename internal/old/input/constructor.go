@@ -115,6 +115,7 @@ const (
 	TypeCSVFile           = "csv"
 	TypeDynamic           = "dynamic"
 	TypeFile              = "file"
+	TypeFTP               = "ftp"
 	TypeGCPCloudStorage   = "gcp_cloud_storage"
 	TypeGCPPubSub         = "gcp_pubsub"
 	TypeGenerate          = "generate"
@@ -163,10 +164,11 @@ type Config struct {
 	CSVFile           CSVFileConfig             `json:"csv" yaml:"csv"`
 	Dynamic           DynamicConfig             `json:"dynamic" yaml:"dynamic"`
 	File              FileConfig                `json:"file" yaml:"file"`
+	FTP               FTPConfig                 `json:"ftp" yaml:"ftp"`
 	GCPCloudStorage   GCPCloudStorageConfig     `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
 	GCPPubSub         reader.GCPPubSubConfig    `json:"gcp_pubsub" yaml:"gcp_pubsub"`
 	Generate          BloblangConfig            `json:"generate" yaml:"generate"`
-	HDFS              reader.HDFSConfig         `json:"hdfs" yaml:"hdfs"`
+	HDFS              HDFSConfig                `json:"hdfs" yaml:"hdfs"`
 	HTTPClient        HTTPClientConfig          `json:"http_client" yaml:"http_client"`
 	HTTPServer        HTTPServerConfig          `json:"http_server" yaml:"http_server"`
 	Inproc            InprocConfig              `json:"inproc" yaml:"inproc"`
@@ -210,10 +212,11 @@ func NewConfig() Config {
 		CSVFile:           NewCSVFileConfig(),
 		Dynamic:           NewDynamicConfig(),
 		File:              NewFileConfig(),
+		FTP:               NewFTPConfig(),
 		GCPCloudStorage:   NewGCPCloudStorageConfig(),
 		GCPPubSub:         reader.NewGCPPubSubConfig(),
 		Generate:          NewBloblangConfig(),
-		HDFS:              reader.NewHDFSConfig(),
+		HDFS:              NewHDFSConfig(),
 		HTTPClient:        NewHTTPClientConfig(),
 		HTTPServer:        NewHTTPServerConfig(),
 		Inproc:            NewInprocConfig(),
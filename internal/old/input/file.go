@@ -5,9 +5,11 @@ import (
 	"errors"
 	"io"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
@@ -86,7 +88,7 @@ func NewFileConfig() FileConfig {
 
 // NewFile creates a new File input type.
 func NewFile(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Type) (input.Streamed, error) {
-	rdr, err := newFileConsumer(conf.File, log)
+	rdr, err := newFileConsumer(conf.File, mgr, log)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +99,7 @@ func NewFile(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Ty
 
 type fileConsumer struct {
 	log log.Modular
+	mgr interop.Manager
 
 	paths       []string
 	scannerCtor codec.ReaderConstructor
@@ -105,10 +108,16 @@ type fileConsumer struct {
 	scanner     codec.Reader
 	currentPath string
 
+	// Tracks the number of messages consumed from the current file, and the
+	// highest count where all prior messages have been acknowledged.
+	checkpointMut sync.Mutex
+	checkpointer  *checkpoint.Type
+	consumed      int64
+
 	delete bool
 }
 
-func newFileConsumer(conf FileConfig, log log.Modular) (*fileConsumer, error) {
+func newFileConsumer(conf FileConfig, mgr interop.Manager, log log.Modular) (*fileConsumer, error) {
 	expandedPaths, err := filepath.Globs(conf.Paths)
 	if err != nil {
 		return nil, err
@@ -123,6 +132,7 @@ func newFileConsumer(conf FileConfig, log log.Modular) (*fileConsumer, error) {
 
 	return &fileConsumer{
 		log:         log,
+		mgr:         mgr,
 		scannerCtor: ctor,
 		paths:       expandedPaths,
 		delete:      conf.DeleteOnFinish,
@@ -167,6 +177,11 @@ func (f *fileConsumer) getReader(ctx context.Context) (codec.Reader, string, err
 	f.currentPath = nextPath
 	f.paths = f.paths[1:]
 
+	f.checkpointMut.Lock()
+	f.checkpointer = checkpoint.New()
+	f.consumed = 0
+	f.checkpointMut.Unlock()
+
 	f.log.Infof("Consuming from file '%v'\n", nextPath)
 	return f.scanner, f.currentPath, nil
 }
@@ -207,7 +222,22 @@ func (f *fileConsumer) ReadWithContext(ctx context.Context) (*message.Batch, rea
 			return nil, nil, component.ErrTimeout
 		}
 
+		f.checkpointMut.Lock()
+		f.consumed += int64(msg.Len())
+		resolveFn := f.checkpointer.Track(f.consumed, int64(msg.Len()))
+		checkpointer := f.checkpointer
+		f.checkpointMut.Unlock()
+
 		return msg, func(rctx context.Context, res error) error {
+			if res == nil {
+				f.checkpointMut.Lock()
+				highest := resolveFn()
+				isCurrent := checkpointer == f.checkpointer
+				f.checkpointMut.Unlock()
+				if highest != nil && isCurrent {
+					f.mgr.ReportCheckpoint(currentPath, strconv.FormatInt(highest.(int64), 10))
+				}
+			}
 			return codecAckFn(rctx, res)
 		}, nil
 	}
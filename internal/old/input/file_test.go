@@ -12,6 +12,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 )
 
 func TestFileDirectory(t *testing.T) {
@@ -51,7 +52,7 @@ func TestFileDirectory(t *testing.T) {
 	}
 	conf.Codec = "all-bytes"
 
-	f, err := newFileConsumer(conf, log.Noop())
+	f, err := newFileConsumer(conf, mock.NewManager(), log.Noop())
 	require.NoError(t, err)
 
 	err = f.ConnectWithContext(context.Background())
@@ -84,3 +85,37 @@ func TestFileDirectory(t *testing.T) {
 		t.Errorf("Wrong result: %v != %v", act, exp)
 	}
 }
+
+func TestFileCheckpoints(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmpPath := tmpDir + "/foo.txt"
+	require.NoError(t, os.WriteFile(tmpPath, []byte("foo\nbar\nbaz"), 0o644))
+
+	conf := NewFileConfig()
+	conf.Paths = []string{tmpPath}
+	conf.Codec = "lines"
+
+	mgr := mock.NewManager()
+	f, err := newFileConsumer(conf, mgr, log.Noop())
+	require.NoError(t, err)
+	require.NoError(t, f.ConnectWithContext(context.Background()))
+
+	var ackFns []func(context.Context, error) error
+	for i := 0; i < 3; i++ {
+		_, aFn, err := f.ReadWithContext(context.Background())
+		require.NoError(t, err)
+		ackFns = append(ackFns, aFn)
+	}
+
+	require.NoError(t, ackFns[1](context.Background(), nil))
+	_, exists := mgr.GetCheckpoint(tmpPath)
+	assert.False(t, exists)
+
+	require.NoError(t, ackFns[0](context.Background(), nil))
+	offset, _ := mgr.GetCheckpoint(tmpPath)
+	assert.Equal(t, "2", offset)
+
+	require.NoError(t, ackFns[2](context.Background(), nil))
+	offset, _ = mgr.GetCheckpoint(tmpPath)
+	assert.Equal(t, "3", offset)
+}
@@ -30,7 +30,14 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Exactly-Once Delivery
+
+When [exactly-once delivery](https://cloud.google.com/pubsub/docs/exactly-once-delivery)
+is enabled on the subscription the acknowledgement of each message is confirmed
+with the Pub/Sub service, and a failure to do so is logged as an error. Messages
+that fail to be acknowledged will be redelivered.`,
 		Categories: []string{
 			"Services",
 			"GCP",
@@ -1,13 +1,25 @@
 package input
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
 
+	"github.com/colinmarc/hdfs"
+
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/old/input/reader"
 )
 
@@ -20,6 +32,20 @@ func init() {
 Reads files from a HDFS directory, where each discrete file will be consumed as
 a single message payload.`,
 		Description: `
+The way in which the contents of each file are broken into messages can be
+customised with the ` + "`codec`" + ` field, which by default consumes each file as
+a single message.
+
+### Watching and Acknowledgements
+
+When the watcher is enabled the input periodically scans the directory for new
+files and consumes them, continuing to poll for new files once all files are
+consumed. Files are only considered finished once every message consumed from
+them has been acknowledged downstream, at which point they are deleted when
+` + "`delete_on_finish`" + ` is enabled, or moved into the directory specified
+by ` + "`move_on_finish`" + `, and their paths are stored within the watcher
+cache so that they are not consumed again.
+
 ### Metadata
 
 This input adds the following metadata fields to each message:
@@ -38,25 +64,344 @@ You can access these metadata fields using
 			docs.FieldString("hosts", "A list of target host addresses to connect to.").Array(),
 			docs.FieldString("user", "A user ID to connect as."),
 			docs.FieldString("directory", "The directory to consume from."),
+			codec.ReaderDocs.AtVersion("4.0.0"),
+			docs.FieldBool("delete_on_finish", "Whether to delete files from the directory once they are processed.").AtVersion("4.0.0").Advanced(),
+			docs.FieldString("move_on_finish", "An optional directory to move files into once they are processed, the name of each file is preserved. Files are only moved once all messages consumed from them have been acknowledged, and this field cannot be combined with `delete_on_finish`.", "/processed").AtVersion("4.0.0").Advanced(),
+			docs.FieldInt("max_buffer", "The largest token size expected when consuming delimited files.").AtVersion("4.0.0").Advanced(),
+			docs.FieldObject(
+				"watcher",
+				"An experimental mode whereby the input will periodically scan the target directory for new files and consume them, when all files are consumed the input will continue polling for new files.",
+			).WithChildren(WatcherFieldSpecs()...).AtVersion("4.0.0"),
 		),
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// HDFSConfig contains configuration fields for the HDFS input type.
+type HDFSConfig struct {
+	Hosts          []string      `json:"hosts" yaml:"hosts"`
+	User           string        `json:"user" yaml:"user"`
+	Directory      string        `json:"directory" yaml:"directory"`
+	Codec          string        `json:"codec" yaml:"codec"`
+	DeleteOnFinish bool          `json:"delete_on_finish" yaml:"delete_on_finish"`
+	MoveOnFinish   string        `json:"move_on_finish" yaml:"move_on_finish"`
+	MaxBuffer      int           `json:"max_buffer" yaml:"max_buffer"`
+	Watcher        watcherConfig `json:"watcher" yaml:"watcher"`
+}
+
+// NewHDFSConfig creates a new Config with default values.
+func NewHDFSConfig() HDFSConfig {
+	return HDFSConfig{
+		Hosts:          []string{},
+		User:           "",
+		Directory:      "",
+		Codec:          "all-bytes",
+		DeleteOnFinish: false,
+		MoveOnFinish:   "",
+		MaxBuffer:      1000000,
+		Watcher: watcherConfig{
+			Enabled:      false,
+			MinimumAge:   "1s",
+			PollInterval: "1s",
+			Cache:        "",
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
 // NewHDFS creates a new Files input type.
 func NewHDFS(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Type) (input.Streamed, error) {
-	if conf.HDFS.Directory == "" {
-		return nil, errors.New("invalid directory (cannot be empty)")
+	r, err := newHDFSReader(conf.HDFS, mgr, log)
+	if err != nil {
+		return nil, err
 	}
 	return NewAsyncReader(
 		TypeHDFS,
 		true,
-		reader.NewAsyncPreserver(
-			reader.NewHDFS(conf.HDFS, log, stats),
-		),
+		reader.NewAsyncPreserver(r),
 		log, stats,
 	)
 }
 
 //------------------------------------------------------------------------------
+
+type hdfsReader struct {
+	conf HDFSConfig
+
+	log log.Modular
+	mgr interop.Manager
+
+	client *hdfs.Client
+
+	targets     []string
+	scannerCtor codec.ReaderConstructor
+
+	scannerMut  sync.Mutex
+	scanner     codec.Reader
+	currentName string
+
+	watcherPollInterval time.Duration
+	watcherMinAge       time.Duration
+
+	pendingMut sync.Mutex
+	pending    map[string]struct{}
+}
+
+func newHDFSReader(conf HDFSConfig, mgr interop.Manager, log log.Modular) (*hdfsReader, error) {
+	if conf.Directory == "" {
+		return nil, errors.New("invalid directory (cannot be empty)")
+	}
+	if conf.DeleteOnFinish && conf.MoveOnFinish != "" {
+		return nil, errors.New("cannot combine delete_on_finish with move_on_finish")
+	}
+
+	codecConf := codec.NewReaderConfig()
+	codecConf.MaxScanTokenSize = conf.MaxBuffer
+	ctor, err := codec.GetReader(conf.Codec, codecConf)
+	if err != nil {
+		return nil, err
+	}
+
+	var watcherPollInterval, watcherMinAge time.Duration
+	if conf.Watcher.Enabled {
+		if watcherPollInterval, err = time.ParseDuration(conf.Watcher.PollInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse watcher poll interval: %w", err)
+		}
+		if watcherMinAge, err = time.ParseDuration(conf.Watcher.MinimumAge); err != nil {
+			return nil, fmt.Errorf("failed to parse watcher minimum age: %w", err)
+		}
+		if conf.Watcher.Cache == "" {
+			return nil, errors.New("a cache must be specified when watcher mode is enabled")
+		}
+		if !mgr.ProbeCache(conf.Watcher.Cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", conf.Watcher.Cache)
+		}
+	}
+
+	return &hdfsReader{
+		conf:                conf,
+		log:                 log,
+		mgr:                 mgr,
+		scannerCtor:         ctor,
+		watcherPollInterval: watcherPollInterval,
+		watcherMinAge:       watcherMinAge,
+		pending:             map[string]struct{}{},
+	}, nil
+}
+
+// ConnectWithContext attempts to establish a connection to the target HDFS
+// host and opens the next file to consume.
+func (h *hdfsReader) ConnectWithContext(ctx context.Context) error {
+	h.scannerMut.Lock()
+	defer h.scannerMut.Unlock()
+
+	if h.scanner != nil {
+		return nil
+	}
+
+	var err error
+	if h.client == nil {
+		if h.client, err = hdfs.NewClient(hdfs.ClientOptions{
+			Addresses: h.conf.Hosts,
+			User:      h.conf.User,
+		}); err != nil {
+			return err
+		}
+		if h.targets, err = h.getTargets(ctx); err != nil {
+			return err
+		}
+		h.log.Infof("Receiving files from HDFS directory: %v\n", h.conf.Directory)
+	}
+
+	if len(h.targets) == 0 {
+		if !h.conf.Watcher.Enabled {
+			h.client.Close()
+			h.client = nil
+			h.log.Debugln("Files exhausted, closing input")
+			return component.ErrTypeClosed
+		}
+		select {
+		case <-time.After(h.watcherPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		h.targets, err = h.getTargets(ctx)
+		return err
+	}
+
+	nextName := h.targets[0]
+	nextPath := path.Join(h.conf.Directory, nextName)
+
+	file, err := h.client.Open(nextPath)
+	if err != nil {
+		return err
+	}
+
+	h.pendingMut.Lock()
+	h.pending[nextName] = struct{}{}
+	h.pendingMut.Unlock()
+
+	client := h.client
+	if h.scanner, err = h.scannerCtor(nextPath, file, func(ctx context.Context, err error) error {
+		defer func() {
+			h.pendingMut.Lock()
+			delete(h.pending, nextName)
+			h.pendingMut.Unlock()
+		}()
+		if err != nil {
+			return nil
+		}
+		return h.finishFile(ctx, client, nextName)
+	}); err != nil {
+		file.Close()
+		h.pendingMut.Lock()
+		delete(h.pending, nextName)
+		h.pendingMut.Unlock()
+		return err
+	}
+
+	h.currentName = nextName
+	h.targets = h.targets[1:]
+
+	h.log.Debugf("Consuming from file '%v'\n", nextPath)
+	return nil
+}
+
+// ReadWithContext reads a new HDFS message.
+func (h *hdfsReader) ReadWithContext(ctx context.Context) (*message.Batch, reader.AsyncAckFn, error) {
+	h.scannerMut.Lock()
+	defer h.scannerMut.Unlock()
+
+	if h.scanner == nil || h.client == nil {
+		return nil, nil, component.ErrNotConnected
+	}
+
+	parts, codecAckFn, err := h.scanner.Next(ctx)
+	if err != nil {
+		if errors.Is(err, context.Canceled) ||
+			errors.Is(err, context.DeadlineExceeded) {
+			err = component.ErrTimeout
+		}
+		if err != component.ErrTimeout {
+			h.scanner.Close(ctx)
+			h.scanner = nil
+		}
+		if errors.Is(err, io.EOF) {
+			err = component.ErrTimeout
+		}
+		return nil, nil, err
+	}
+
+	filePath := path.Join(h.conf.Directory, h.currentName)
+	for _, part := range parts {
+		part.MetaSet("hdfs_name", h.currentName)
+		part.MetaSet("hdfs_path", filePath)
+	}
+	msg := message.QuickBatch(nil)
+	msg.Append(parts...)
+
+	return msg, func(ctx context.Context, res error) error {
+		return codecAckFn(ctx, res)
+	}, nil
+}
+
+// finishFile is called once all messages consumed from a file have been
+// acknowledged, and deletes or moves the file according to the config before
+// marking it as consumed within the watcher cache.
+func (h *hdfsReader) finishFile(ctx context.Context, client *hdfs.Client, name string) error {
+	filePath := path.Join(h.conf.Directory, name)
+	if h.conf.DeleteOnFinish {
+		if err := client.Remove(filePath); err != nil {
+			return fmt.Errorf("failed to delete file %v: %w", filePath, err)
+		}
+	} else if h.conf.MoveOnFinish != "" {
+		if err := client.MkdirAll(h.conf.MoveOnFinish, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory %v: %w", h.conf.MoveOnFinish, err)
+		}
+		target := path.Join(h.conf.MoveOnFinish, name)
+		if err := client.Rename(filePath, target); err != nil {
+			return fmt.Errorf("failed to move file %v to %v: %w", filePath, target, err)
+		}
+	}
+
+	if !h.conf.Watcher.Enabled {
+		return nil
+	}
+
+	var setErr error
+	if cerr := h.mgr.AccessCache(ctx, h.conf.Watcher.Cache, func(cache cache.V1) {
+		setErr = cache.Set(ctx, filePath, []byte("@"), nil)
+	}); cerr != nil {
+		return fmt.Errorf("failed to get the cache for hdfs watcher mode: %v", cerr)
+	}
+	if setErr != nil {
+		return fmt.Errorf("failed to update path in cache %s: %v", filePath, setErr)
+	}
+	return nil
+}
+
+func (h *hdfsReader) getTargets(ctx context.Context) ([]string, error) {
+	infos, err := h.client.ReadDir(h.conf.Directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	if !h.conf.Watcher.Enabled {
+		for _, info := range infos {
+			if !info.IsDir() {
+				targets = append(targets, info.Name())
+			}
+		}
+		return targets, nil
+	}
+
+	if cerr := h.mgr.AccessCache(ctx, h.conf.Watcher.Cache, func(cache cache.V1) {
+		for _, info := range infos {
+			if info.IsDir() || time.Since(info.ModTime()) < h.watcherMinAge {
+				continue
+			}
+
+			h.pendingMut.Lock()
+			_, isPending := h.pending[info.Name()]
+			h.pendingMut.Unlock()
+			if isPending {
+				continue
+			}
+
+			filePath := path.Join(h.conf.Directory, info.Name())
+			if _, err := cache.Get(ctx, filePath); err != nil {
+				targets = append(targets, info.Name())
+			} else if err = cache.Set(ctx, filePath, []byte("@"), nil); err != nil { // Reset the TTL for the path
+				h.log.Warnf("Failed to set key in cache for path %v: %v\n", filePath, err)
+			}
+		}
+	}); cerr != nil {
+		return nil, fmt.Errorf("error getting cache in getTargets: %v", cerr)
+	}
+	return targets, nil
+}
+
+// CloseAsync shuts down the HDFS input and stops processing requests.
+func (h *hdfsReader) CloseAsync() {
+	go func() {
+		h.scannerMut.Lock()
+		if h.scanner != nil {
+			h.scanner.Close(context.Background())
+			h.scanner = nil
+			h.targets = nil
+		}
+		if h.client != nil {
+			h.client.Close()
+			h.client = nil
+		}
+		h.scannerMut.Unlock()
+	}()
+}
+
+// WaitForClose blocks until the HDFS input has closed down.
+func (h *hdfsReader) WaitForClose(timeout time.Duration) error {
+	return nil
+}
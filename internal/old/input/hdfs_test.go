@@ -0,0 +1,82 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+func TestHDFSReaderConfigErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(c *HDFSConfig)
+		errContains string
+	}{
+		{
+			name:        "no directory",
+			modify:      func(c *HDFSConfig) { c.Directory = "" },
+			errContains: "invalid directory",
+		},
+		{
+			name: "delete and move",
+			modify: func(c *HDFSConfig) {
+				c.DeleteOnFinish = true
+				c.MoveOnFinish = "/processed"
+			},
+			errContains: "cannot combine",
+		},
+		{
+			name: "watcher without cache",
+			modify: func(c *HDFSConfig) {
+				c.Watcher.Enabled = true
+			},
+			errContains: "a cache must be specified",
+		},
+		{
+			name: "watcher with missing cache",
+			modify: func(c *HDFSConfig) {
+				c.Watcher.Enabled = true
+				c.Watcher.Cache = "nope"
+			},
+			errContains: "cache resource 'nope' was not found",
+		},
+		{
+			name:        "bad codec",
+			modify:      func(c *HDFSConfig) { c.Codec = "not-a-codec" },
+			errContains: "not-a-codec",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewHDFSConfig()
+			conf.Directory = "/foo"
+			test.modify(&conf)
+
+			_, err := newHDFSReader(conf, mock.NewManager(), log.Noop())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func TestHDFSReaderWatcherConfig(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	conf := NewHDFSConfig()
+	conf.Directory = "/foo"
+	conf.MoveOnFinish = "/foo_done"
+	conf.Watcher.Enabled = true
+	conf.Watcher.Cache = "foocache"
+	conf.Watcher.PollInterval = "5s"
+
+	r, err := newHDFSReader(conf, mgr, log.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, "5s", r.watcherPollInterval.String())
+}
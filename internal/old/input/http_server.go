@@ -375,6 +375,7 @@ func (h *HTTPServer) extractMessageFromRequest(r *http.Request) (*message.Batch,
 	}
 
 	_ = tracing.InitSpansFromParentTextMap("input_http_server_post", textMapGeneric, msg)
+	message.InitBatchInfo("http_server", msg)
 	return msg, nil
 }
 
@@ -623,6 +624,7 @@ func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 			part.MetaSet(c.Name, c.Value)
 		}
 		tracing.InitSpans("input_http_server_websocket", msg)
+		message.InitBatchInfo("http_server", msg)
 
 		store := transaction.NewResultStore()
 		transaction.AddResultStore(msg, store)
@@ -115,6 +115,18 @@ The field ` + "`kafka_lag`" + ` is the calculated difference between the high wa
 
 You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).
 
+### Metrics
+
+This input emits the following metrics in addition to the standard input metrics:
+
+` + "``` text" + `
+- kafka_lag: A gauge of the lag of each topic partition as of the last consumed message, labelled by topic and partition.
+- kafka_offset_current: A gauge of the offset of the last consumed message of each topic partition, labelled by topic and partition.
+- kafka_offset_committed: A gauge of the latest offset of each topic partition marked for commit, labelled by topic and partition.
+- kafka_rebalances: A counter incremented each time a consumer group session begins, which happens on startup and after each rebalance.
+- kafka_broker_connected: A gauge set to 1 for each broker the client is connected to and 0 otherwise, labelled by broker.
+` + "```" + `
+
 ### Ordering
 
 By default messages of a topic partition can be processed in parallel, up to a limit determined by the field ` + "`checkpoint_limit`" + `. However, if strict ordered processing is required then this value must be set to 1 in order to process shard messages in lock-step. When doing so it is recommended that you perform batching at this component for performance as it will not be possible to batch lock-stepped messages at the output level.
@@ -225,6 +237,11 @@ type kafkaReader struct {
 	log   log.Modular
 	mgr   interop.Manager
 
+	mLag             metrics.StatGaugeVec
+	mOffsetCurrent   metrics.StatGaugeVec
+	mOffsetCommitted metrics.StatGaugeVec
+	mRebalances      metrics.StatCounter
+
 	closeOnce  sync.Once
 	closedChan chan struct{}
 }
@@ -275,6 +292,11 @@ func newKafkaReader(
 		mgr:             mgr,
 		closedChan:      make(chan struct{}),
 		topicPartitions: map[string][]int32{},
+
+		mLag:             stats.GetGaugeVec("kafka_lag", "topic", "partition"),
+		mOffsetCurrent:   stats.GetGaugeVec("kafka_offset_current", "topic", "partition"),
+		mOffsetCommitted: stats.GetGaugeVec("kafka_offset_committed", "topic", "partition"),
+		mRebalances:      stats.GetCounter("kafka_rebalances"),
 	}
 	if conf.TLS.Enabled {
 		var err error
@@ -386,6 +408,7 @@ func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(cont
 				if k.session != nil {
 					k.log.Debugf("Marking offset for topic '%v' partition '%v'.\n", topic, partition)
 					k.session.MarkOffset(topic, partition, maxOffset.(int64), "")
+					k.reportCheckpoint(topic, partition, maxOffset.(int64))
 				} else {
 					k.log.Debugf("Unable to mark offset for topic '%v' partition '%v'.\n", topic, partition)
 				}
@@ -416,6 +439,7 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 					if k.session != nil {
 						k.log.Debugf("Marking offset for topic '%v' partition '%v'.\n", topic, partition)
 						k.session.MarkOffset(topic, partition, offset, "")
+						k.reportCheckpoint(topic, partition, offset)
 					} else {
 						k.log.Debugf("Unable to mark offset for topic '%v' partition '%v'.\n", topic, partition)
 					}
@@ -444,6 +468,28 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 	}
 }
 
+func (k *kafkaReader) reportCheckpoint(topic string, partition int32, offset int64) {
+	partStr := strconv.Itoa(int(partition))
+	k.mOffsetCommitted.With(topic, partStr).Set(offset)
+	k.mgr.ReportCheckpoint(topic+":"+partStr, strconv.FormatInt(offset, 10))
+}
+
+// reportConsumed updates the offset and lag metrics of a topic partition with
+// a message that has been consumed.
+func (k *kafkaReader) reportConsumed(highestOffset int64, data *sarama.ConsumerMessage) {
+	partStr := strconv.Itoa(int(data.Partition))
+	k.mOffsetCurrent.With(data.Topic, partStr).Set(data.Offset)
+	k.mLag.With(data.Topic, partStr).Set(kafkaLag(highestOffset, data.Offset))
+}
+
+func kafkaLag(highestOffset, offset int64) int64 {
+	lag := highestOffset - offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	return lag
+}
+
 func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) *message.Part {
 	part := message.NewPart(data.Value)
 
@@ -451,17 +497,14 @@ func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) *message.Part
 		part.MetaSet(string(hdr.Key), string(hdr.Value))
 	}
 
-	lag := highestOffset - data.Offset - 1
-	if lag < 0 {
-		lag = 0
-	}
+	lag := kafkaLag(highestOffset, data.Offset)
 
 	part.MetaSet("kafka_key", string(data.Key))
-	part.MetaSet("kafka_partition", strconv.Itoa(int(data.Partition)))
+	part.MetaSetTyped("kafka_partition", int64(data.Partition))
 	part.MetaSet("kafka_topic", data.Topic)
-	part.MetaSet("kafka_offset", strconv.Itoa(int(data.Offset)))
-	part.MetaSet("kafka_lag", strconv.FormatInt(lag, 10))
-	part.MetaSet("kafka_timestamp_unix", strconv.FormatInt(data.Timestamp.Unix(), 10))
+	part.MetaSetTyped("kafka_offset", data.Offset)
+	part.MetaSetTyped("kafka_lag", lag)
+	part.MetaSetTyped("kafka_timestamp_unix", data.Timestamp.Unix())
 
 	return part
 }
@@ -3,16 +3,23 @@ package input
 import (
 	"context"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/Shopify/sarama"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
+	"github.com/benthosdev/benthos/v4/internal/impl/kafka/brokers"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
+// brokerReportPeriod is the period between updates of the broker connectivity
+// metrics.
+const brokerReportPeriod = time.Second * 10
+
 // Setup is run at the beginning of a new session, before ConsumeClaim.
 func (k *kafkaReader) Setup(sesh sarama.ConsumerGroupSession) error {
+	k.mRebalances.Incr(1)
 	k.cMut.Lock()
 	k.session = sesh
 	k.cMut.Unlock()
@@ -25,6 +32,11 @@ func (k *kafkaReader) Cleanup(sesh sarama.ConsumerGroupSession) error {
 	k.cMut.Lock()
 	k.session = nil
 	k.cMut.Unlock()
+	for topic, parts := range sesh.Claims() {
+		for _, part := range parts {
+			k.mgr.RemoveCheckpoint(topic + ":" + strconv.Itoa(int(part)))
+		}
+	}
 	return nil
 }
 
@@ -73,6 +85,7 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 			}
 
 			latestOffset = data.Offset
+			k.reportConsumed(claim.HighWaterMarkOffset(), data)
 			part := dataToPart(claim.HighWaterMarkOffset(), data)
 
 			if batchPolicy.Add(part) {
@@ -90,12 +103,21 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 //------------------------------------------------------------------------------
 
 func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.Config) error {
+	client, err := sarama.NewClient(k.addresses, config)
+	if err != nil {
+		return err
+	}
+
 	// Start a new consumer group
-	group, err := sarama.NewConsumerGroup(k.addresses, k.conf.ConsumerGroup, config)
+	group, err := sarama.NewConsumerGroupFromClient(k.conf.ConsumerGroup, client)
 	if err != nil {
+		client.Close()
 		return err
 	}
 
+	brokersCtx, brokersDone := context.WithCancel(context.Background())
+	go brokers.ReportConnectivity(brokersCtx, client, k.stats, brokerReportPeriod)
+
 	// Handle errors
 	go func() {
 		for {
@@ -144,6 +166,8 @@ func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.
 		k.log.Debugln("Closing consumer group")
 
 		group.Close()
+		brokersDone()
+		client.Close()
 
 		k.cMut.Lock()
 		if k.msgChan != nil {
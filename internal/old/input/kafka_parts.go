@@ -12,6 +12,7 @@ import (
 	"github.com/Shopify/sarama"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
+	"github.com/benthosdev/benthos/v4/internal/impl/kafka/brokers"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
@@ -75,6 +76,7 @@ partMsgLoop:
 			k.log.Tracef("Received message from topic %v partition %v\n", topic, partition)
 
 			latestOffset = data.Offset
+			k.reportConsumed(consumer.HighWaterMarkOffset(), data)
 			part := dataToPart(consumer.HighWaterMarkOffset(), data)
 
 			if batchPolicy.Add(part) {
@@ -239,6 +241,9 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 		k.log.Infof("Consuming kafka topic %v, partitions %v from brokers %s as group '%v'\n", topic, partitions, k.addresses, k.conf.ConsumerGroup)
 	}
 
+	brokersCtx, brokersDone := context.WithCancel(context.Background())
+	go brokers.ReportConnectivity(brokersCtx, client, k.stats, brokerReportPeriod)
+
 	doneCtx, doneFn := context.WithCancel(context.Background())
 	go func() {
 		defer doneFn()
@@ -274,6 +279,7 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 		if coordinator != nil {
 			coordinator.Close()
 		}
+		brokersDone()
 		client.Close()
 	}()
 
@@ -3,7 +3,9 @@ package input
 import (
 	"testing"
 
+	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -46,3 +48,27 @@ func TestKafkaBadParams(t *testing.T) {
 		})
 	}
 }
+
+func TestKafkaReaderMetrics(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Addresses = []string{"localhost:9092"}
+	conf.Topics = []string{"foo:0-1"}
+
+	stats := metrics.NewLocal()
+	k, err := newKafkaReader(conf, mock.NewManager(), log.Noop(), stats)
+	require.NoError(t, err)
+
+	k.reportConsumed(10, &sarama.ConsumerMessage{Topic: "foo", Partition: 0, Offset: 4})
+	k.reportConsumed(20, &sarama.ConsumerMessage{Topic: "foo", Partition: 1, Offset: 19})
+	k.reportCheckpoint("foo", 0, 3)
+	require.NoError(t, k.Setup(nil))
+
+	assert.Equal(t, map[string]int64{
+		`kafka_lag{partition="0",topic="foo"}`:              5,
+		`kafka_lag{partition="1",topic="foo"}`:              0,
+		`kafka_offset_current{partition="0",topic="foo"}`:   4,
+		`kafka_offset_current{partition="1",topic="foo"}`:   19,
+		`kafka_offset_committed{partition="0",topic="foo"}`: 3,
+		`kafka_rebalances`: 1,
+	}, stats.GetCounters())
+}
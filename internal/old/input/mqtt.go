@@ -32,7 +32,17 @@ This input adds the following metadata fields to each message:
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Delivery Guarantees
+
+Messages received with a QoS of 1 or 2 are only acknowledged once they have been successfully delivered by the pipeline, and acknowledgements are sent in the order that messages were received as required by the MQTT protocol. Messages are therefore never lost when Benthos is restarted, and messages of each topic are passed into the pipeline in the order that they are received from the broker.
+
+With a QoS of 2 redeliveries of a message by the broker after a reconnect are detected and acknowledged without being passed into the pipeline again, giving exactly-once delivery for as long as Benthos is running. Messages that were processed but not yet acknowledged when Benthos was stopped might still be redelivered once.
+
+### Persistent Sessions
+
+When ` + "`clean_session`" + ` is set to ` + "`false`" + ` the broker retains the subscriptions of the client and queues messages whilst it is offline, which are consumed once Benthos reconnects with the same ` + "`client_id`" + `. When the broker resumes an existing session the topics are not subscribed to again, and therefore changes to ` + "`topics`" + ` only take effect for new sessions.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("urls", "A list of URLs to connect to. If an item of the list contains commas it will be expanded into multiple URLs.").Array(),
 			docs.FieldString("topics", "A list of topics to consume from.").Array(),
@@ -41,7 +51,7 @@ You can access these metadata fields using
 				"nanoid", "append a nanoid of length 21 characters",
 			),
 			docs.FieldInt("qos", "The level of delivery guarantee to enforce.").HasOptions("0", "1", "2").Advanced(),
-			docs.FieldBool("clean_session", "Set whether the connection is non-persistent.").Advanced(),
+			docs.FieldBool("clean_session", "Set whether the connection is non-persistent. When `false` a persistent session is used, which requires a `client_id`, and messages sent whilst Benthos is offline are consumed once it reconnects.").Advanced(),
 			mqttconf.WillFieldSpec(),
 			docs.FieldString("connect_timeout", "The maximum amount of time to wait in order to establish a connection before the attempt is abandoned.", "1s", "500ms").HasDefault("30s").AtVersion("3.58.0"),
 			docs.FieldString("user", "A username to assume for the connection.").Advanced(),
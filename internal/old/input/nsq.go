@@ -17,6 +17,12 @@ func init() {
 		constructor: fromSimpleConstructor(NewNSQ),
 		Summary: `
 Subscribe to an NSQ instance topic and channel.`,
+		Description: `
+### Requeues and Dead Letters
+
+Messages that are rejected by the pipeline are requeued with a delay of ` + "`requeue_delay`" + ` multiplied by the number of attempts of the message, up to ` + "`max_requeue_delay`" + `. By default each requeue also causes the consumer to back off by pausing consumption for a period that grows with consecutive failures according to ` + "`backoff_strategy`" + `, which can be set to ` + "`none`" + ` in order to requeue messages without pausing consumption.
+
+Once a message has been attempted ` + "`max_attempts`" + ` times and fails again it is published to the topic ` + "`dead_letter_topic`" + ` of the nsqd that it was consumed from and removed from the channel. When a dead letter topic is not configured the message is dropped instead, and when the message cannot be published to the dead letter topic it is requeued.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("nsqd_tcp_addresses", "A list of nsqd addresses to connect to.").Array(),
 			docs.FieldString("lookupd_http_addresses", "A list of nsqlookupd addresses to connect to.").Array(),
@@ -25,6 +31,17 @@ Subscribe to an NSQ instance topic and channel.`,
 			docs.FieldString("channel", "The channel to consume from."),
 			docs.FieldString("user_agent", "A user agent to assume when connecting."),
 			docs.FieldInt("max_in_flight", "The maximum number of pending messages to consume at any given time."),
+			docs.FieldInt("sample_rate", "An optional percentage of the messages of the channel to deliver to this consumer, where messages that are not sampled are skipped by this consumer. Set to `0` in order to receive all messages.", 10).AtVersion("4.0.0").Advanced(),
+			docs.FieldString("backoff_strategy", "The strategy used to pause consumption after messages are requeued.").HasAnnotatedOptions(
+				"exponential", "Back off for a period that doubles with each consecutive failure.",
+				"full_jitter", "Back off for a random period of up to the period of the `exponential` strategy.",
+				"none", "Requeue messages without backing off.",
+			).AtVersion("4.0.0").Advanced(),
+			docs.FieldString("max_backoff", "The maximum period to back off for.").AtVersion("4.0.0").Advanced(),
+			docs.FieldString("requeue_delay", "The delay of a requeued message, which is multiplied by the number of attempts of the message.").AtVersion("4.0.0").Advanced(),
+			docs.FieldString("max_requeue_delay", "The maximum delay of a requeued message.").AtVersion("4.0.0").Advanced(),
+			docs.FieldInt("max_attempts", "The maximum number of attempts of a message before it is sent to the `dead_letter_topic`, or dropped when no dead letter topic is configured. Set to `0` in order to retry messages indefinitely.").AtVersion("4.0.0").Advanced(),
+			docs.FieldString("dead_letter_topic", "An optional topic to publish messages to once they have exhausted `max_attempts`.", "orders_dlq").AtVersion("4.0.0").Advanced(),
 		),
 		Categories: []string{
 			"Services",
@@ -151,11 +151,21 @@ func (c *GCPPubSub) ReadWithContext(ctx context.Context) (*message.Batch, AsyncA
 	msg.Append(part)
 
 	return msg, func(ctx context.Context, res error) error {
+		// The results of acks and nacks are only tracked when exactly-once
+		// delivery is enabled on the subscription, otherwise the result is
+		// immediately successful and the ack or nack is only sent by the
+		// following plain call, which is a no-op when already done.
+		var ackRes *pubsub.AckResult
 		if res != nil {
+			ackRes = gmsg.NackWithResult()
 			gmsg.Nack()
 		} else {
+			ackRes = gmsg.AckWithResult()
 			gmsg.Ack()
 		}
+		if _, err := ackRes.Get(ctx); err != nil {
+			return err
+		}
 		return nil
 	}, nil
 }
@@ -2,8 +2,8 @@ package reader
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -67,6 +67,8 @@ type MQTT struct {
 
 	interruptChan chan struct{}
 
+	acks *mqttAckQueue
+
 	urls []string
 
 	stats metrics.Type
@@ -80,6 +82,7 @@ func NewMQTT(
 	m := &MQTT{
 		conf:          conf,
 		interruptChan: make(chan struct{}),
+		acks:          newMQTTAckQueue(),
 		stats:         stats,
 		log:           log,
 	}
@@ -105,6 +108,10 @@ func NewMQTT(
 		return nil, err
 	}
 
+	if !m.conf.CleanSession && m.conf.ClientID == "" {
+		return nil, errors.New("a client_id must be specified when clean_session is false")
+	}
+
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
 			if len(splitURL) > 0 {
@@ -141,39 +148,41 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 		return chanOpen
 	}
 
+	onMessage := func(c mqtt.Client, msg mqtt.Message) {
+		if !m.acks.deliverable(msg) {
+			return
+		}
+		sent := false
+		msgMut.Lock()
+		if msgChan != nil {
+			select {
+			case msgChan <- msg:
+				sent = true
+			case <-m.interruptChan:
+			}
+		}
+		msgMut.Unlock()
+		if !sent {
+			m.acks.drop(msg)
+		}
+	}
+
 	conf := mqtt.NewClientOptions().
 		SetAutoReconnect(false).
+		SetAutoAckDisabled(true).
+		SetOrderMatters(true).
 		SetClientID(m.conf.ClientID).
 		SetCleanSession(m.conf.CleanSession).
 		SetConnectTimeout(m.connectTimeout).
 		SetKeepAlive(time.Duration(m.conf.KeepAlive) * time.Second).
+		// Messages queued by a persistent session can be received before the
+		// topics are subscribed to, or without subscribing at all when the
+		// session is resumed, and are therefore handled by default.
+		SetDefaultPublishHandler(onMessage).
 		SetConnectionLostHandler(func(client mqtt.Client, reason error) {
 			client.Disconnect(0)
 			closeMsgChan()
 			m.log.Errorf("Connection lost due to: %v\n", reason)
-		}).
-		SetOnConnectHandler(func(c mqtt.Client) {
-			topics := make(map[string]byte)
-			for _, topic := range m.conf.Topics {
-				topics[topic] = m.conf.QoS
-			}
-
-			tok := c.SubscribeMultiple(topics, func(c mqtt.Client, msg mqtt.Message) {
-				msgMut.Lock()
-				if msgChan != nil {
-					select {
-					case msgChan <- msg:
-					case <-m.interruptChan:
-					}
-				}
-				msgMut.Unlock()
-			})
-			tok.Wait()
-			if err := tok.Error(); err != nil {
-				m.log.Errorf("Failed to subscribe to topics '%v': %v\n", m.conf.Topics, err)
-				m.log.Errorln("Shutting connection down.")
-				closeMsgChan()
-			}
 		})
 
 	if m.conf.Will.Enabled {
@@ -208,6 +217,25 @@ func (m *MQTT) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
+	// When a persistent session is resumed the broker retains our
+	// subscriptions, and subscribing again would cause retained messages to
+	// be redelivered.
+	if m.conf.CleanSession || !tok.(*mqtt.ConnectToken).SessionPresent() {
+		topics := make(map[string]byte)
+		for _, topic := range m.conf.Topics {
+			topics[topic] = m.conf.QoS
+		}
+
+		subTok := client.SubscribeMultiple(topics, onMessage)
+		subTok.Wait()
+		if err := subTok.Error(); err != nil {
+			client.Disconnect(0)
+			return fmt.Errorf("failed to subscribe to topics '%v': %w", m.conf.Topics, err)
+		}
+	} else {
+		m.log.Debugln("Resumed persistent session, skipping subscription to topics.")
+	}
+
 	m.log.Infof("Receiving MQTT messages from topics: %v\n", m.conf.Topics)
 	go func() {
 		for {
@@ -253,15 +281,15 @@ func (m *MQTT) ReadWithContext(ctx context.Context) (*message.Batch, AsyncAckFn,
 		message := message.QuickBatch([][]byte{msg.Payload()})
 
 		p := message.Get(0)
-		p.MetaSet("mqtt_duplicate", strconv.FormatBool(msg.Duplicate()))
-		p.MetaSet("mqtt_qos", strconv.Itoa(int(msg.Qos())))
-		p.MetaSet("mqtt_retained", strconv.FormatBool(msg.Retained()))
+		p.MetaSetTyped("mqtt_duplicate", msg.Duplicate())
+		p.MetaSetTyped("mqtt_qos", int64(msg.Qos()))
+		p.MetaSetTyped("mqtt_retained", msg.Retained())
 		p.MetaSet("mqtt_topic", msg.Topic())
-		p.MetaSet("mqtt_message_id", strconv.Itoa(int(msg.MessageID())))
+		p.MetaSetTyped("mqtt_message_id", int64(msg.MessageID()))
 
 		return message, func(ctx context.Context, res error) error {
 			if res == nil {
-				msg.Ack()
+				m.acks.ack(msg)
 			}
 			return nil
 		}, nil
@@ -289,3 +317,158 @@ func (m *MQTT) WaitForClose(timeout time.Duration) error {
 }
 
 //------------------------------------------------------------------------------
+
+// mqttAckQueue acknowledges messages in the order that they were received,
+// which is required by the MQTT protocol, regardless of the order in which
+// they're processed. It also ensures that QoS 2 messages are delivered exactly
+// once when the broker redelivers them after a reconnect.
+type mqttAckQueue struct {
+	mut     sync.Mutex
+	pending []*mqttAckEntry
+
+	// QoS 2 messages by ID that are either being processed or have been
+	// processed, but for which the broker might not have received our
+	// acknowledgement.
+	inFlight  map[uint16]*mqttAckEntry
+	processed map[uint16]struct{}
+
+	ackFn func(mqtt.Message)
+}
+
+type mqttAckEntry struct {
+	msg  mqtt.Message
+	done bool
+
+	// Redeliveries of the message that are acknowledged along with it.
+	dups []*mqttAckEntry
+}
+
+func newMQTTAckQueue() *mqttAckQueue {
+	return &mqttAckQueue{
+		inFlight:  map[uint16]*mqttAckEntry{},
+		processed: map[uint16]struct{}{},
+		ackFn:     ackMQTTMessage,
+	}
+}
+
+// ackMQTTMessage acknowledges a message, which panics within the client when
+// the connection the message was received on has since closed. In that case
+// the broker redelivers the message when we reconnect.
+func ackMQTTMessage(msg mqtt.Message) {
+	defer func() {
+		_ = recover()
+	}()
+	msg.Ack()
+}
+
+// deliverable registers a received message and returns whether it should be
+// delivered to the pipeline, which is not the case for redeliveries of QoS 2
+// messages that have already been delivered.
+func (q *mqttAckQueue) deliverable(msg mqtt.Message) bool {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	e := &mqttAckEntry{msg: msg}
+	q.pending = append(q.pending, e)
+	if msg.Qos() < 2 {
+		return true
+	}
+
+	id := msg.MessageID()
+	if !msg.Duplicate() {
+		// The ID has been released by the broker and reused.
+		delete(q.processed, id)
+		q.inFlight[id] = e
+		return true
+	}
+	if prev, exists := q.inFlight[id]; exists {
+		prev.dups = append(prev.dups, e)
+		return false
+	}
+	if _, exists := q.processed[id]; exists {
+		e.done = true
+		q.flush()
+		return false
+	}
+	q.inFlight[id] = e
+	return true
+}
+
+// ack marks a message as processed and acknowledges it along with any
+// messages received before it that have also been processed.
+func (q *mqttAckQueue) ack(msg mqtt.Message) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	for _, e := range q.pending {
+		if e.msg != msg {
+			continue
+		}
+		e.done = true
+		for _, d := range e.dups {
+			d.done = true
+		}
+		if msg.Qos() == 2 {
+			if q.inFlight[msg.MessageID()] == e {
+				delete(q.inFlight, msg.MessageID())
+			}
+			q.processed[msg.MessageID()] = struct{}{}
+		}
+		break
+	}
+	q.flush()
+}
+
+// drop removes a message that could not be delivered to the pipeline without
+// acknowledging it, and therefore the broker redelivers it when we reconnect.
+func (q *mqttAckQueue) drop(msg mqtt.Message) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	var dropped *mqttAckEntry
+	for _, e := range q.pending {
+		if e.msg == msg {
+			dropped = e
+			break
+		}
+	}
+	if dropped == nil {
+		return
+	}
+	if msg.Qos() == 2 && q.inFlight[msg.MessageID()] == dropped {
+		delete(q.inFlight, msg.MessageID())
+	}
+
+	// Redeliveries that were waiting on the dropped message are also left
+	// unacknowledged.
+	remaining := q.pending[:0]
+	for _, e := range q.pending {
+		if e == dropped {
+			continue
+		}
+		isDup := false
+		for _, d := range dropped.dups {
+			if e == d {
+				isDup = true
+				break
+			}
+		}
+		if !isDup {
+			remaining = append(remaining, e)
+		}
+	}
+	for i := len(remaining); i < len(q.pending); i++ {
+		q.pending[i] = nil
+	}
+	q.pending = remaining
+	q.flush()
+}
+
+func (q *mqttAckQueue) flush() {
+	i := 0
+	for ; i < len(q.pending) && q.pending[i].done; i++ {
+		q.ackFn(q.pending[i].msg)
+		q.pending[i] = nil
+	}
+	q.pending = q.pending[i:]
+}
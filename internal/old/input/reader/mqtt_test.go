@@ -9,6 +9,7 @@ import (
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
@@ -182,3 +183,98 @@ func testMQTTDisconnect(urls []string, t *testing.T) {
 
 	wg.Wait()
 }
+
+type fakeMQTTMsg struct {
+	mqtt.Message
+
+	id  uint16
+	qos byte
+	dup bool
+}
+
+func (f *fakeMQTTMsg) MessageID() uint16 { return f.id }
+func (f *fakeMQTTMsg) Qos() byte         { return f.qos }
+func (f *fakeMQTTMsg) Duplicate() bool   { return f.dup }
+
+func TestMQTTAckQueueOrder(t *testing.T) {
+	q := newMQTTAckQueue()
+
+	var acked []mqtt.Message
+	q.ackFn = func(m mqtt.Message) {
+		acked = append(acked, m)
+	}
+
+	a := &fakeMQTTMsg{id: 1, qos: 1}
+	b := &fakeMQTTMsg{id: 2, qos: 1}
+	c := &fakeMQTTMsg{id: 3, qos: 1}
+	for _, m := range []mqtt.Message{a, b, c} {
+		assert.True(t, q.deliverable(m))
+	}
+
+	q.ack(c)
+	q.ack(b)
+	assert.Empty(t, acked)
+
+	q.ack(a)
+	assert.Equal(t, []mqtt.Message{a, b, c}, acked)
+}
+
+func TestMQTTAckQueueDrop(t *testing.T) {
+	q := newMQTTAckQueue()
+
+	var acked []mqtt.Message
+	q.ackFn = func(m mqtt.Message) {
+		acked = append(acked, m)
+	}
+
+	a := &fakeMQTTMsg{id: 1, qos: 2}
+	b := &fakeMQTTMsg{id: 2, qos: 2}
+	assert.True(t, q.deliverable(a))
+	assert.True(t, q.deliverable(b))
+
+	q.ack(b)
+	q.drop(a)
+	assert.Equal(t, []mqtt.Message{b}, acked)
+
+	// The dropped message is delivered again when redelivered.
+	aDup := &fakeMQTTMsg{id: 1, qos: 2, dup: true}
+	assert.True(t, q.deliverable(aDup))
+}
+
+func TestMQTTAckQueueQoS2Redelivery(t *testing.T) {
+	q := newMQTTAckQueue()
+
+	var acked []mqtt.Message
+	q.ackFn = func(m mqtt.Message) {
+		acked = append(acked, m)
+	}
+
+	// A message is redelivered after a reconnect whilst still being
+	// processed, and is acknowledged once processed without being delivered
+	// again.
+	a := &fakeMQTTMsg{id: 1, qos: 2}
+	aDup := &fakeMQTTMsg{id: 1, qos: 2, dup: true}
+	assert.True(t, q.deliverable(a))
+	assert.False(t, q.deliverable(aDup))
+
+	q.ack(a)
+	assert.Equal(t, []mqtt.Message{a, aDup}, acked)
+
+	// A message is redelivered after it was processed, which is acknowledged
+	// immediately.
+	aDupAgain := &fakeMQTTMsg{id: 1, qos: 2, dup: true}
+	assert.False(t, q.deliverable(aDupAgain))
+	assert.Equal(t, []mqtt.Message{a, aDup, aDupAgain}, acked)
+
+	// The ID is reused for a new message, which is delivered.
+	b := &fakeMQTTMsg{id: 1, qos: 2}
+	assert.True(t, q.deliverable(b))
+	q.ack(b)
+	assert.Equal(t, []mqtt.Message{a, aDup, aDupAgain, b}, acked)
+
+	// QoS 1 redeliveries are always delivered.
+	c := &fakeMQTTMsg{id: 2, qos: 1}
+	cDup := &fakeMQTTMsg{id: 2, qos: 1, dup: true}
+	assert.True(t, q.deliverable(c))
+	assert.True(t, q.deliverable(cDup))
+}
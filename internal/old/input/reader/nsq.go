@@ -3,6 +3,7 @@ package reader
 import (
 	"context"
 	"crypto/tls"
+	"fmt"
 	"io"
 	llog "log"
 	"strings"
@@ -29,6 +30,13 @@ type NSQConfig struct {
 	UserAgent       string      `json:"user_agent" yaml:"user_agent"`
 	TLS             btls.Config `json:"tls" yaml:"tls"`
 	MaxInFlight     int         `json:"max_in_flight" yaml:"max_in_flight"`
+	SampleRate      int         `json:"sample_rate" yaml:"sample_rate"`
+	BackoffStrategy string      `json:"backoff_strategy" yaml:"backoff_strategy"`
+	MaxBackoff      string      `json:"max_backoff" yaml:"max_backoff"`
+	RequeueDelay    string      `json:"requeue_delay" yaml:"requeue_delay"`
+	MaxRequeueDelay string      `json:"max_requeue_delay" yaml:"max_requeue_delay"`
+	MaxAttempts     int         `json:"max_attempts" yaml:"max_attempts"`
+	DeadLetterTopic string      `json:"dead_letter_topic" yaml:"dead_letter_topic"`
 }
 
 // NewNSQConfig creates a new NSQConfig with default values.
@@ -41,6 +49,13 @@ func NewNSQConfig() NSQConfig {
 		UserAgent:       "",
 		TLS:             btls.NewConfig(),
 		MaxInFlight:     100,
+		SampleRate:      0,
+		BackoffStrategy: "exponential",
+		MaxBackoff:      "2m",
+		RequeueDelay:    "90s",
+		MaxRequeueDelay: "15m",
+		MaxAttempts:     5,
+		DeadLetterTopic: "",
 	}
 }
 
@@ -53,6 +68,15 @@ type NSQ struct {
 
 	unAckMsgs []*nsq.Message
 
+	// Producers for publishing to the dead letter topic, keyed by the address
+	// of the nsqd that a message was consumed from.
+	dlProducers map[string]*nsq.Producer
+	dlMut       sync.Mutex
+
+	maxBackoff      time.Duration
+	requeueDelay    time.Duration
+	maxRequeueDelay time.Duration
+
 	tlsConf         *tls.Config
 	addresses       []string
 	lookupAddresses []string
@@ -93,14 +117,111 @@ func NewNSQ(conf NSQConfig, log log.Modular, stats metrics.Type) (*NSQ, error) {
 			return nil, err
 		}
 	}
+	if conf.SampleRate < 0 || conf.SampleRate > 99 {
+		return nil, fmt.Errorf("sample_rate must be between 0 and 99, got %v", conf.SampleRate)
+	}
+	switch conf.BackoffStrategy {
+	case "exponential", "full_jitter", "none":
+	default:
+		return nil, fmt.Errorf("backoff_strategy not recognised: %v", conf.BackoffStrategy)
+	}
+	if conf.MaxAttempts < 0 || conf.MaxAttempts > 65535 {
+		return nil, fmt.Errorf("max_attempts must be between 0 and 65535, got %v", conf.MaxAttempts)
+	}
+	for _, d := range []struct {
+		name   string
+		str    string
+		target *time.Duration
+	}{
+		{"max_backoff", conf.MaxBackoff, &n.maxBackoff},
+		{"requeue_delay", conf.RequeueDelay, &n.requeueDelay},
+		{"max_requeue_delay", conf.MaxRequeueDelay, &n.maxRequeueDelay},
+	} {
+		var err error
+		if *d.target, err = time.ParseDuration(d.str); err != nil {
+			return nil, fmt.Errorf("failed to parse %v string: %v", d.name, err)
+		}
+	}
 	return &n, nil
 }
 
+// newConfig returns an NSQ config common to consumers and dead letter
+// producers.
+func (n *NSQ) newConfig() *nsq.Config {
+	cfg := nsq.NewConfig()
+	cfg.UserAgent = n.conf.UserAgent
+	if n.tlsConf != nil {
+		cfg.TlsV1 = true
+		cfg.TlsConfig = n.tlsConf
+	}
+	return cfg
+}
+
 //------------------------------------------------------------------------------
 
+// exhausted returns whether a message has been attempted the maximum number of
+// times.
+func (n *NSQ) exhausted(msg *nsq.Message) bool {
+	return n.conf.MaxAttempts > 0 && int(msg.Attempts) >= n.conf.MaxAttempts
+}
+
+// requeue returns a message to NSQ according to the backoff strategy.
+func (n *NSQ) requeue(msg *nsq.Message) {
+	if n.conf.BackoffStrategy == "none" {
+		msg.RequeueWithoutBackoff(-1)
+		return
+	}
+	msg.Requeue(-1)
+}
+
+// deadLetter publishes a message that has exhausted its attempts to the dead
+// letter topic via the nsqd that it was consumed from, or drops it when no
+// dead letter topic is configured. Messages that fail to be published are
+// requeued.
+func (n *NSQ) deadLetter(msg *nsq.Message) {
+	if n.conf.DeadLetterTopic == "" {
+		n.log.Warnf("Dropping NSQ message %s after %v failed attempts\n", msg.ID, msg.Attempts)
+		msg.Finish()
+		return
+	}
+
+	n.dlMut.Lock()
+	prod, exists := n.dlProducers[msg.NSQDAddress]
+	if !exists {
+		var err error
+		if prod, err = nsq.NewProducer(msg.NSQDAddress, n.newConfig()); err != nil {
+			n.dlMut.Unlock()
+			n.log.Errorf("Failed to create dead letter producer: %v\n", err)
+			n.requeue(msg)
+			return
+		}
+		prod.SetLogger(llog.New(io.Discard, "", llog.Flags()), nsq.LogLevelError)
+		if n.dlProducers == nil {
+			n.dlProducers = map[string]*nsq.Producer{}
+		}
+		n.dlProducers[msg.NSQDAddress] = prod
+	}
+	n.dlMut.Unlock()
+
+	if err := prod.Publish(n.conf.DeadLetterTopic, msg.Body); err != nil {
+		n.log.Errorf("Failed to publish NSQ message %s to dead letter topic: %v\n", msg.ID, err)
+		n.requeue(msg)
+		return
+	}
+	msg.Finish()
+}
+
 // HandleMessage handles an NSQ message.
 func (n *NSQ) HandleMessage(message *nsq.Message) error {
 	message.DisableAutoResponse()
+
+	// Messages that timed out on their final attempt are redelivered with
+	// their attempts exceeding the maximum.
+	if n.conf.MaxAttempts > 0 && int(message.Attempts) > n.conf.MaxAttempts {
+		n.deadLetter(message)
+		return nil
+	}
+
 	select {
 	case n.internalMessages <- message:
 	case <-n.interruptChan:
@@ -121,14 +242,22 @@ func (n *NSQ) ConnectWithContext(ctx context.Context) (err error) {
 		return nil
 	}
 
-	cfg := nsq.NewConfig()
-	cfg.UserAgent = n.conf.UserAgent
+	cfg := n.newConfig()
 	cfg.MaxInFlight = n.conf.MaxInFlight
-	if n.tlsConf != nil {
-		cfg.TlsV1 = true
-		cfg.TlsConfig = n.tlsConf
+	cfg.SampleRate = int32(n.conf.SampleRate)
+	cfg.DefaultRequeueDelay = n.requeueDelay
+	cfg.MaxRequeueDelay = n.maxRequeueDelay
+	cfg.MaxBackoffDuration = n.maxBackoff
+	if n.conf.BackoffStrategy == "full_jitter" {
+		if err = cfg.Set("backoff_strategy", "full_jitter"); err != nil {
+			return
+		}
 	}
 
+	// Attempts are checked by HandleMessage in order to support dead
+	// lettering.
+	cfg.MaxAttempts = 0
+
 	var consumer *nsq.Consumer
 	if consumer, err = nsq.NewConsumer(n.conf.Topic, n.conf.Channel, cfg); err != nil {
 		return
@@ -160,6 +289,13 @@ func (n *NSQ) disconnect() error {
 		n.consumer.Stop()
 		n.consumer = nil
 	}
+
+	n.dlMut.Lock()
+	for _, p := range n.dlProducers {
+		p.Stop()
+	}
+	n.dlProducers = nil
+	n.dlMut.Unlock()
 	return nil
 }
 
@@ -192,7 +328,11 @@ func (n *NSQ) ReadWithContext(ctx context.Context) (*message.Batch, AsyncAckFn,
 	n.unAckMsgs = append(n.unAckMsgs, msg)
 	return message.QuickBatch([][]byte{msg.Body}), func(rctx context.Context, res error) error {
 		if res != nil {
-			msg.Requeue(-1)
+			if n.exhausted(msg) {
+				n.deadLetter(msg)
+				return nil
+			}
+			n.requeue(msg)
 		}
 		msg.Finish()
 		return nil
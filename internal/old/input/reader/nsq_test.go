@@ -0,0 +1,51 @@
+package reader
+
+import (
+	"testing"
+	"time"
+
+	nsq "github.com/nsqio/go-nsq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestNSQConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(conf *NSQConfig)
+	}{
+		{name: "sample rate", modify: func(conf *NSQConfig) { conf.SampleRate = 100 }},
+		{name: "backoff strategy", modify: func(conf *NSQConfig) { conf.BackoffStrategy = "nope" }},
+		{name: "max attempts", modify: func(conf *NSQConfig) { conf.MaxAttempts = -1 }},
+		{name: "requeue delay", modify: func(conf *NSQConfig) { conf.RequeueDelay = "nope" }},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewNSQConfig()
+			test.modify(&conf)
+			_, err := NewNSQ(conf, log.Noop(), metrics.Noop())
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNSQExhausted(t *testing.T) {
+	conf := NewNSQConfig()
+	conf.MaxAttempts = 3
+	conf.RequeueDelay = "5s"
+
+	n, err := NewNSQ(conf, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, n.requeueDelay)
+
+	assert.False(t, n.exhausted(&nsq.Message{Attempts: 2}))
+	assert.True(t, n.exhausted(&nsq.Message{Attempts: 3}))
+
+	n.conf.MaxAttempts = 0
+	assert.False(t, n.exhausted(&nsq.Message{Attempts: 1000}))
+}
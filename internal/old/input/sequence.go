@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"sync"
 	"time"
@@ -31,7 +32,17 @@ Reads messages from a sequence of child inputs, starting with the first and once
 that input gracefully terminates starts consuming from the next, and so on.`,
 		Description: `
 This input is useful for consuming from inputs that have an explicit end but
-must not be consumed in parallel.`,
+must not be consumed in parallel.
+
+### Interleaving
+
+When ` + "`interleave.enabled`" + ` is set to ` + "`true`" + ` all child inputs
+are instead consumed in parallel, and messages are read from each child
+according to a weighted fair distribution. This allows sources such as a
+historical dataset and a live feed to both make progress. A child that has no
+data available doesn't build up a share whilst idle, and therefore can't starve
+other children once data arrives. The input only terminates once all children
+have terminated.`,
 		Examples: []docs.AnnotatedExample{
 			{
 				Title:   "End of Stream Message",
@@ -137,6 +148,27 @@ input:
           - bloblang: |
               root.uuid = this.document.uuid
               root.hobbies = this.document.hobbies.map_each(this.type)
+`,
+			},
+			{
+				Title:   "Interleaving Historical and Live Data",
+				Summary: "In this example we consume a backlog of historical data from files in parallel with a live Kafka topic, reading three messages from the backlog for every message from the live topic whenever both have data available. Each message is given a metadata field `source` labelling which input it came from.",
+				Config: `
+input:
+  sequence:
+    interleave:
+      enabled: true
+      weights: [ 3, 1 ]
+    label_meta: source
+    inputs:
+      - label: historical
+        file:
+          paths: [ ./backlog/*.jsonl ]
+      - label: live
+        kafka:
+          addresses: [ localhost:9092 ]
+          topics: [ events ]
+          consumer_group: benthos_events
 `,
 			},
 		},
@@ -157,6 +189,14 @@ Each message must be structured (JSON or otherwise processed into a structured f
 					"The chosen strategy to use when a data join would otherwise result in a collision of field values. The strategy `array` means non-array colliding values are placed into an array and colliding arrays are merged. The strategy `replace` replaces old values with new values. The strategy `keep` keeps the old value.",
 				).HasOptions("array", "replace", "keep"),
 			).AtVersion("3.40.0").Advanced(),
+			docs.FieldObject(
+				"interleave",
+				"Provides a way to consume all child inputs in parallel rather than one after the other. This is useful when merging sources that progress at different rates, such as a historical backfill combined with a live feed, where neither should starve the other.",
+			).WithChildren(
+				docs.FieldBool("enabled", "Whether to consume child inputs in parallel."),
+				docs.FieldInt("weights", "An optional list of weights, one for each child input, that determines the share of messages consumed from each child when more than one has data available. Children without a weight default to `1`.", []int{3, 1}).Array(),
+			).Advanced(),
+			docs.FieldString("label_meta", "An optional metadata key to set on each message with the label of the child input it was consumed from, or the index of the child when it has no label. When left empty no metadata is added.", "sequence_source").Advanced(),
 			docs.FieldInput("inputs", "An array of inputs to read from sequentially.").Array(),
 		),
 		Categories: []string{
@@ -230,9 +270,28 @@ func (s SequenceShardedJoinConfig) validate() (*messageJoiner, error) {
 	}, nil
 }
 
+// SequenceInterleaveConfig describes an optional mode where the child inputs of
+// a sequence are consumed in parallel, with the share of messages consumed from
+// each child determined by a weighting.
+type SequenceInterleaveConfig struct {
+	Enabled bool  `json:"enabled" yaml:"enabled"`
+	Weights []int `json:"weights" yaml:"weights"`
+}
+
+// NewSequenceInterleaveConfig creates a new sequence interleave configuration
+// with default values.
+func NewSequenceInterleaveConfig() SequenceInterleaveConfig {
+	return SequenceInterleaveConfig{
+		Enabled: false,
+		Weights: []int{},
+	}
+}
+
 // SequenceConfig contains configuration values for the Sequence input type.
 type SequenceConfig struct {
 	ShardedJoin SequenceShardedJoinConfig `json:"sharded_join" yaml:"sharded_join"`
+	Interleave  SequenceInterleaveConfig  `json:"interleave" yaml:"interleave"`
+	LabelMeta   string                    `json:"label_meta" yaml:"label_meta"`
 	Inputs      []Config                  `json:"inputs" yaml:"inputs"`
 }
 
@@ -240,6 +299,8 @@ type SequenceConfig struct {
 func NewSequenceConfig() SequenceConfig {
 	return SequenceConfig{
 		ShardedJoin: NewSequenceShardedJoinConfig(),
+		Interleave:  NewSequenceInterleaveConfig(),
+		LabelMeta:   "",
 		Inputs:      []Config{},
 	}
 }
@@ -388,10 +449,12 @@ func (m *messageJoiner) Empty(fn func(*message.Batch)) bool {
 type Sequence struct {
 	conf SequenceConfig
 
-	targetMut sync.Mutex
-	target    input.Streamed
-	remaining []sequenceTarget
-	spent     []sequenceTarget
+	targetMut   sync.Mutex
+	target      input.Streamed
+	targetLabel string
+	remaining   []sequenceTarget
+	spent       []sequenceTarget
+	children    []*sequenceChild
 
 	joiner *messageJoiner
 
@@ -409,6 +472,13 @@ type sequenceTarget struct {
 	config Config
 }
 
+func (t sequenceTarget) label() string {
+	if t.config.Label != "" {
+		return t.config.Label
+	}
+	return strconv.Itoa(t.index)
+}
+
 // NewSequence creates a new Sequence input type.
 func NewSequence(
 	conf Config,
@@ -444,6 +514,18 @@ func NewSequence(
 		return nil, fmt.Errorf("invalid sharded join config: %w", err)
 	}
 
+	if rdr.conf.Interleave.Enabled {
+		if rdr.joiner != nil {
+			return nil, errors.New("sharded joins cannot be combined with interleaved consumption")
+		}
+		children, err := rdr.createInterleavedChildren()
+		if err != nil {
+			return nil, err
+		}
+		go rdr.loopInterleaved(children)
+		return rdr, nil
+	}
+
 	if target, _, err := rdr.createNextTarget(); err != nil {
 		return nil, err
 	} else if target == nil {
@@ -483,6 +565,7 @@ func (r *Sequence) createNextTarget() (input.Streamed, bool, error) {
 	if target != nil {
 		r.log.Debugf("Initialized sequence input %v.", len(r.spent)-1)
 		r.target = target
+		r.targetLabel = r.spent[len(r.spent)-1].label()
 	}
 	final := len(r.remaining) == 0
 	r.targetMut.Unlock()
@@ -607,6 +690,8 @@ runLoop:
 			return
 		}
 
+		r.setLabelMeta(tran.Payload, r.targetLabel)
+
 		if r.joiner != nil {
 			r.joiner.Add(tran.Payload.DeepCopy(), finalInSequence, func(msg *message.Batch) {
 				r.dispatchJoinedMessage(&shardJoinWG, msg)
@@ -624,6 +709,209 @@ runLoop:
 	}
 }
 
+func (r *Sequence) setLabelMeta(msg *message.Batch, label string) {
+	if r.conf.LabelMeta == "" {
+		return
+	}
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		p.MetaSet(r.conf.LabelMeta, label)
+		return nil
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type sequenceChild struct {
+	label   string
+	weight  int
+	current int
+	input   input.Streamed
+
+	// pending is a transaction read from the child whilst checking whether it
+	// had anything available, which is yet to be selected.
+	pending *message.Transaction
+}
+
+func (r *Sequence) createInterleavedChildren() ([]*sequenceChild, error) {
+	weights := r.conf.Interleave.Weights
+	if len(weights) > len(r.remaining) {
+		return nil, fmt.Errorf("number of interleave weights (%v) exceeds the number of child inputs (%v)", len(weights), len(r.remaining))
+	}
+
+	children := make([]*sequenceChild, 0, len(r.remaining))
+	closeChildren := func() {
+		for _, c := range children {
+			c.input.CloseAsync()
+		}
+	}
+
+	for i, t := range r.remaining {
+		weight := 1
+		if i < len(weights) {
+			if weight = weights[i]; weight <= 0 {
+				closeChildren()
+				return nil, fmt.Errorf("interleave weight for input index %v must be greater than zero, got %v", t.index, weight)
+			}
+		}
+		wMgr := r.mgr.IntoPath("sequence", "inputs", strconv.Itoa(t.index))
+		in, err := New(t.config, wMgr, wMgr.Logger(), wMgr.Metrics())
+		if err != nil {
+			closeChildren()
+			return nil, fmt.Errorf("failed to initialize input index %v: %w", t.index, err)
+		}
+		children = append(children, &sequenceChild{
+			label:  t.label(),
+			weight: weight,
+			input:  in,
+		})
+	}
+
+	r.targetMut.Lock()
+	r.spent = append(r.spent, r.remaining...)
+	r.remaining = nil
+	r.children = children
+	r.targetMut.Unlock()
+	return children, nil
+}
+
+// creditInterleaved applies a round of smooth weighted round robin to the
+// children that had a transaction available this round and returns the child
+// selected. Children that had nothing available are excluded so that idle
+// children don't accumulate credit.
+func creditInterleaved(eligible []*sequenceChild) *sequenceChild {
+	total := 0
+	for _, c := range eligible {
+		c.current += c.weight
+		total += c.weight
+	}
+	chosen := eligible[0]
+	for _, c := range eligible[1:] {
+		if c.current > chosen.current {
+			chosen = c
+		}
+	}
+	chosen.current -= total
+	return chosen
+}
+
+// nextInterleavedChild selects the child to read from next using a smooth
+// weighted round robin. Every child is checked for an available transaction,
+// which is held until the child is selected, and only the children that had one
+// available are credited. If none of the children have a transaction available
+// we block until any of them do.
+func (r *Sequence) nextInterleavedChild(active []*sequenceChild) (*sequenceChild, message.Transaction, bool, bool) {
+	eligible := make([]*sequenceChild, 0, len(active))
+	for _, c := range active {
+		if c.pending == nil {
+			select {
+			case tran, open := <-c.input.TransactionChan():
+				if !open {
+					return c, message.Transaction{}, false, true
+				}
+				c.pending = &tran
+			default:
+			}
+		}
+		if c.pending != nil {
+			eligible = append(eligible, c)
+		}
+	}
+
+	if len(eligible) > 0 {
+		c := creditInterleaved(eligible)
+		tran := *c.pending
+		c.pending = nil
+		return c, tran, true, true
+	}
+
+	cases := make([]reflect.SelectCase, 0, len(active)+1)
+	for _, c := range active {
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(c.input.TransactionChan()),
+		})
+	}
+	cases = append(cases, reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(r.shutSig.CloseAtLeisureChan()),
+	})
+
+	// None of the children were eligible this round, and therefore the
+	// weightings are left unchanged regardless of which child unblocks first.
+	chosen, v, open := reflect.Select(cases)
+	if chosen == len(active) {
+		return nil, message.Transaction{}, false, false
+	}
+
+	c := active[chosen]
+	if !open {
+		return c, message.Transaction{}, false, true
+	}
+	return c, v.Interface().(message.Transaction), true, true
+}
+
+func (r *Sequence) loopInterleaved(children []*sequenceChild) {
+	defer func() {
+		for _, c := range children {
+			c.input.CloseAsync()
+		}
+		go func() {
+			select {
+			case <-r.shutSig.CloseNowChan():
+				for _, c := range children {
+					_ = c.input.WaitForClose(0)
+				}
+			case <-r.shutSig.HasClosedChan():
+			}
+		}()
+		for _, c := range children {
+			_ = c.input.WaitForClose(shutdown.MaximumShutdownWait())
+		}
+		close(r.transactions)
+		r.shutSig.ShutdownComplete()
+	}()
+
+	active := make([]*sequenceChild, len(children))
+	copy(active, children)
+
+	for len(active) > 0 {
+		child, tran, open, ok := r.nextInterleavedChild(active)
+		if !ok {
+			return
+		}
+		if !open {
+			r.log.Debugf("Sequence input %v has terminated.", child.label)
+			child.input.CloseAsync()
+			for i, c := range active {
+				if c == child {
+					active = append(active[:i], active[i+1:]...)
+					break
+				}
+			}
+			r.targetMut.Lock()
+			r.children = append([]*sequenceChild{}, active...)
+			r.targetMut.Unlock()
+			// Reset weightings of the remaining children so that the loss of
+			// a child doesn't skew the distribution.
+			for _, c := range active {
+				c.current = 0
+			}
+			continue
+		}
+
+		r.setLabelMeta(tran.Payload, child.label)
+
+		select {
+		case r.transactions <- tran:
+		case <-r.shutSig.CloseNowChan():
+			return
+		}
+	}
+	r.log.Infoln("Exhausted all sequence inputs, shutting down.")
+}
+
+//------------------------------------------------------------------------------
+
 // TransactionChan returns a transactions channel for consuming messages from
 // this input type.
 func (r *Sequence) TransactionChan() <-chan message.Transaction {
@@ -633,6 +921,19 @@ func (r *Sequence) TransactionChan() <-chan message.Transaction {
 // Connected returns a boolean indicating whether this input is currently
 // connected to its target.
 func (r *Sequence) Connected() bool {
+	r.targetMut.Lock()
+	children := r.children
+	r.targetMut.Unlock()
+	if len(children) > 0 {
+		// Children that have terminated are removed, and so we're connected as
+		// long as any of the remaining children are.
+		for _, c := range children {
+			if c.input.Connected() {
+				return true
+			}
+		}
+		return false
+	}
 	if t, _ := r.getTarget(); t != nil {
 		return t.Connected()
 	}
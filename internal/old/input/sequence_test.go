@@ -15,6 +15,8 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
 )
 
 func writeFiles(t *testing.T, dir string, nameToContent map[string]string) {
@@ -79,6 +81,223 @@ consumeLoop:
 	assert.NoError(t, rdr.WaitForClose(time.Second))
 }
 
+func TestSequenceInterleaved(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"f1": "foo\nbar\nbaz",
+		"f2": "buz\nbev\nbif\n",
+		"f3": "qux\nquz\nqev",
+	}
+
+	writeFiles(t, tmpDir, files)
+
+	conf := NewConfig()
+	conf.Type = TypeSequence
+	conf.Sequence.Interleave.Enabled = true
+	conf.Sequence.Interleave.Weights = []int{2, 1}
+	conf.Sequence.LabelMeta = "source"
+
+	for _, k := range []string{"f1", "f2", "f3"} {
+		inConf := NewConfig()
+		inConf.Type = TypeFile
+		if k != "f3" {
+			inConf.Label = "label_" + k
+		}
+		inConf.File.Paths = []string{filepath.Join(tmpDir, k)}
+		conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+	}
+
+	rdr, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	exp, act := map[string][]string{
+		"label_f1": {"foo", "bar", "baz"},
+		"label_f2": {"buz", "bev", "bif"},
+		"2":        {"qux", "quz", "qev"},
+	}, map[string][]string{}
+
+consumeLoop:
+	for {
+		select {
+		case tran, open := <-rdr.TransactionChan():
+			if !open {
+				break consumeLoop
+			}
+			assert.Equal(t, 1, tran.Payload.Len())
+			src := tran.Payload.Get(0).MetaGet("source")
+			act[src] = append(act[src], string(tran.Payload.Get(0).Get()))
+			require.NoError(t, tran.Ack(tCtx, nil))
+		case <-time.After(time.Minute):
+			t.Fatalf("Failed to consume message after: %v", act)
+		}
+	}
+
+	assert.Equal(t, exp, act)
+
+	rdr.CloseAsync()
+	assert.NoError(t, rdr.WaitForClose(time.Second))
+}
+
+func TestSequenceInterleavedIdleChild(t *testing.T) {
+	t.Parallel()
+
+	r := &Sequence{shutSig: shutdown.NewSignaller()}
+
+	fill := func(c chan message.Transaction, n int) {
+		for i := 0; i < n; i++ {
+			c <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), nil)
+		}
+	}
+
+	historicChan := make(chan message.Transaction, 200)
+	liveChan := make(chan message.Transaction, 200)
+	historic := &sequenceChild{label: "historic", weight: 1, input: &mock.Input{TChan: historicChan}}
+	live := &sequenceChild{label: "live", weight: 3, input: &mock.Input{TChan: liveChan}}
+	active := []*sequenceChild{historic, live}
+
+	// Whilst the live input is idle the historic input is read exclusively.
+	fill(historicChan, 200)
+	for i := 0; i < 100; i++ {
+		c, _, open, ok := r.nextInterleavedChild(active)
+		require.True(t, ok)
+		require.True(t, open)
+		require.Equal(t, historic, c)
+	}
+
+	// Once the live input has data the weighting must apply immediately
+	// rather than the live input having built up credit whilst idle.
+	fill(liveChan, 200)
+	counts := map[string]int{}
+	for i := 0; i < 40; i++ {
+		c, _, open, ok := r.nextInterleavedChild(active)
+		require.True(t, ok)
+		require.True(t, open)
+		counts[c.label]++
+	}
+	assert.InDelta(t, 30, counts["live"], 2)
+	assert.InDelta(t, 10, counts["historic"], 2)
+}
+
+func TestSequenceInterleavedIdleLowerWeightChild(t *testing.T) {
+	t.Parallel()
+
+	r := &Sequence{shutSig: shutdown.NewSignaller()}
+
+	fill := func(c chan message.Transaction, n int) {
+		for i := 0; i < n; i++ {
+			c <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), nil)
+		}
+	}
+
+	liveChan := make(chan message.Transaction, 200)
+	historicChan := make(chan message.Transaction, 200)
+	live := &sequenceChild{label: "live", weight: 3, input: &mock.Input{TChan: liveChan}}
+	historic := &sequenceChild{label: "historic", weight: 1, input: &mock.Input{TChan: historicChan}}
+	active := []*sequenceChild{live, historic}
+
+	// The historic input is idle whilst the live input, which is preferred,
+	// is read exclusively.
+	fill(liveChan, 200)
+	for i := 0; i < 100; i++ {
+		c, _, open, ok := r.nextInterleavedChild(active)
+		require.True(t, ok)
+		require.True(t, open)
+		require.Equal(t, live, c)
+	}
+
+	// The historic input must not have built up credit whilst idle.
+	fill(historicChan, 200)
+	counts := map[string]int{}
+	for i := 0; i < 40; i++ {
+		c, _, open, ok := r.nextInterleavedChild(active)
+		require.True(t, ok)
+		require.True(t, open)
+		counts[c.label]++
+	}
+	assert.InDelta(t, 30, counts["live"], 2)
+	assert.InDelta(t, 10, counts["historic"], 2)
+}
+
+type sequenceConnInput struct {
+	mock.Input
+	connected bool
+}
+
+func (s *sequenceConnInput) Connected() bool {
+	return s.connected
+}
+
+func TestSequenceInterleavedConnected(t *testing.T) {
+	t.Parallel()
+
+	r := &Sequence{
+		log:          log.Noop(),
+		shutSig:      shutdown.NewSignaller(),
+		transactions: make(chan message.Transaction),
+	}
+
+	doneChan := make(chan message.Transaction)
+	liveChan := make(chan message.Transaction)
+	done := &sequenceChild{label: "done", weight: 1, input: &sequenceConnInput{Input: mock.Input{TChan: doneChan}}}
+	live := &sequenceChild{label: "live", weight: 1, input: &sequenceConnInput{Input: mock.Input{TChan: liveChan}, connected: true}}
+	r.children = []*sequenceChild{done, live}
+
+	go r.loopInterleaved(r.children)
+
+	// A child that is still connected is enough.
+	assert.True(t, r.Connected())
+
+	done.input.CloseAsync()
+	assert.Eventually(t, func() bool {
+		r.targetMut.Lock()
+		defer r.targetMut.Unlock()
+		return len(r.children) == 1
+	}, time.Second, time.Millisecond)
+	assert.True(t, r.Connected())
+
+	live.input.(*sequenceConnInput).connected = false
+	assert.False(t, r.Connected())
+
+	r.CloseAsync()
+	assert.NoError(t, r.WaitForClose(time.Second))
+}
+
+func TestSequenceInterleavedBadConfig(t *testing.T) {
+	t.Parallel()
+
+	inConf := NewConfig()
+	inConf.Type = TypeGenerate
+	inConf.Generate.Mapping = `root = "hello"`
+
+	conf := NewConfig()
+	conf.Type = TypeSequence
+	conf.Sequence.Interleave.Enabled = true
+	conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+
+	conf.Sequence.Interleave.Weights = []int{1, 2}
+	_, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the number of child inputs")
+
+	conf.Sequence.Interleave.Weights = []int{0}
+	_, err = New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be greater than zero")
+
+	conf.Sequence.Interleave.Weights = nil
+	conf.Sequence.ShardedJoin.Type = "full-outter"
+	conf.Sequence.ShardedJoin.IDPath = "id"
+	_, err = New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined")
+}
+
 func TestSequenceJoins(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
 	defer done()
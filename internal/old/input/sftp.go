@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	pathpkg "path"
 	"sync"
 	"time"
 
@@ -24,27 +25,6 @@ import (
 )
 
 func init() {
-	watcherDocs := docs.FieldSpecs{
-		docs.FieldBool(
-			"enabled",
-			"Whether file watching is enabled.",
-		),
-		docs.FieldString(
-			"minimum_age",
-			"The minimum period of time since a file was last updated before attempting to consume it. Increasing this period decreases the likelihood that a file will be consumed whilst it is still being written to.",
-			"10s", "1m", "10m",
-		),
-		docs.FieldString(
-			"poll_interval",
-			"The interval between each attempt to scan the target paths for new files.",
-			"100ms", "1s",
-		),
-		docs.FieldString(
-			"cache",
-			"A [cache resource](/docs/components/caches/about) for storing the paths of files already consumed.",
-		),
-	}
-
 	Constructors[TypeSFTP] = TypeSpec{
 		constructor: fromSimpleConstructor(func(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Type) (input.Streamed, error) {
 			r, err := newSFTPReader(conf.SFTP, mgr, log, stats)
@@ -62,6 +42,10 @@ func init() {
 		Version: "3.39.0",
 		Summary: `Consumes files from a server over SFTP.`,
 		Description: `
+## Watching and Acknowledgements
+
+Files are only considered finished once every message consumed from them has been acknowledged downstream, at which point they are deleted when ` + "`delete_on_finish`" + ` is enabled, or moved into the directory specified by ` + "`move_on_finish`" + `. When the watcher is enabled the path of each finished file is also stored within the watcher cache so that it is not consumed again, and files that are still being processed are skipped by subsequent scans.
+
 ## Metadata
 
 This input adds the following metadata fields to each message:
@@ -86,11 +70,12 @@ You can access these metadata fields using [function interpolation](/docs/config
 			).Array(),
 			codec.ReaderDocs,
 			docs.FieldBool("delete_on_finish", "Whether to delete files from the server once they are processed.").Advanced(),
+			docs.FieldString("move_on_finish", "An optional directory to move files into once they are processed, the name of each file is preserved. Files are only moved once all messages consumed from them have been acknowledged, and this field cannot be combined with `delete_on_finish`. Make sure that the target directory is not matched by `paths`, otherwise moved files will be consumed again.", "/processed").AtVersion("4.0.0").Advanced(),
 			docs.FieldInt("max_buffer", "The largest token size expected when consuming delimited files.").Advanced(),
 			docs.FieldObject(
 				"watcher",
 				"An experimental mode whereby the input will periodically scan the target paths for new files and consume them, when all files are consumed the input will continue polling for new files.",
-			).WithChildren(watcherDocs...).AtVersion("3.42.0"),
+			).WithChildren(WatcherFieldSpecs()...).AtVersion("3.42.0"),
 		),
 		Categories: []string{
 			"Network",
@@ -100,6 +85,31 @@ You can access these metadata fields using [function interpolation](/docs/config
 
 //------------------------------------------------------------------------------
 
+// WatcherFieldSpecs returns documentation field specs for the watcher fields
+// of inputs that poll for new files.
+func WatcherFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBool(
+			"enabled",
+			"Whether file watching is enabled.",
+		),
+		docs.FieldString(
+			"minimum_age",
+			"The minimum period of time since a file was last updated before attempting to consume it. Increasing this period decreases the likelihood that a file will be consumed whilst it is still being written to.",
+			"10s", "1m", "10m",
+		),
+		docs.FieldString(
+			"poll_interval",
+			"The interval between each attempt to scan the target paths for new files.",
+			"100ms", "1s",
+		),
+		docs.FieldString(
+			"cache",
+			"A [cache resource](/docs/components/caches/about) for storing the paths of files already consumed.",
+		),
+	}
+}
+
 type watcherConfig struct {
 	Enabled      bool   `json:"enabled" yaml:"enabled"`
 	MinimumAge   string `json:"minimum_age" yaml:"minimum_age"`
@@ -114,6 +124,7 @@ type SFTPConfig struct {
 	Paths          []string              `json:"paths" yaml:"paths"`
 	Codec          string                `json:"codec" yaml:"codec"`
 	DeleteOnFinish bool                  `json:"delete_on_finish" yaml:"delete_on_finish"`
+	MoveOnFinish   string                `json:"move_on_finish" yaml:"move_on_finish"`
 	MaxBuffer      int                   `json:"max_buffer" yaml:"max_buffer"`
 	Watcher        watcherConfig         `json:"watcher" yaml:"watcher"`
 }
@@ -126,6 +137,7 @@ func NewSFTPConfig() SFTPConfig {
 		Paths:          []string{},
 		Codec:          "all-bytes",
 		DeleteOnFinish: false,
+		MoveOnFinish:   "",
 		MaxBuffer:      1000000,
 		Watcher: watcherConfig{
 			Enabled:      false,
@@ -156,6 +168,9 @@ type sftpReader struct {
 
 	watcherPollInterval time.Duration
 	watcherMinAge       time.Duration
+
+	pendingMut sync.Mutex
+	pending    map[string]struct{}
 }
 
 func newSFTPReader(conf SFTPConfig, mgr interop.Manager, log log.Modular, stats metrics.Type) (*sftpReader, error) {
@@ -166,6 +181,10 @@ func newSFTPReader(conf SFTPConfig, mgr interop.Manager, log log.Modular, stats
 		return nil, err
 	}
 
+	if conf.DeleteOnFinish && conf.MoveOnFinish != "" {
+		return nil, errors.New("cannot combine delete_on_finish with move_on_finish")
+	}
+
 	var watcherPollInterval, watcherMinAge time.Duration
 	if conf.Watcher.Enabled {
 		if watcherPollInterval, err = time.ParseDuration(conf.Watcher.PollInterval); err != nil {
@@ -193,6 +212,7 @@ func newSFTPReader(conf SFTPConfig, mgr interop.Manager, log log.Modular, stats
 		scannerCtor:         ctor,
 		watcherPollInterval: watcherPollInterval,
 		watcherMinAge:       watcherMinAge,
+		pending:             map[string]struct{}{},
 	}
 
 	return s, err
@@ -243,13 +263,26 @@ func (s *sftpReader) ConnectWithContext(ctx context.Context) error {
 		return err
 	}
 
+	s.pendingMut.Lock()
+	s.pending[nextPath] = struct{}{}
+	s.pendingMut.Unlock()
+
+	client := s.client
 	if s.scanner, err = s.scannerCtor(nextPath, file, func(ctx context.Context, err error) error {
-		if err == nil && s.conf.DeleteOnFinish {
-			return s.client.Remove(nextPath)
+		defer func() {
+			s.pendingMut.Lock()
+			delete(s.pending, nextPath)
+			s.pendingMut.Unlock()
+		}()
+		if err != nil {
+			return nil
 		}
-		return nil
+		return s.finishPath(ctx, client, nextPath)
 	}); err != nil {
 		file.Close()
+		s.pendingMut.Lock()
+		delete(s.pending, nextPath)
+		s.pendingMut.Unlock()
 		return err
 	}
 
@@ -276,17 +309,6 @@ func (s *sftpReader) ReadWithContext(ctx context.Context) (*message.Batch, reade
 			err = component.ErrTimeout
 		}
 		if err != component.ErrTimeout {
-			if s.conf.Watcher.Enabled {
-				var setErr error
-				if cerr := s.mgr.AccessCache(ctx, s.conf.Watcher.Cache, func(cache cache.V1) {
-					setErr = cache.Set(ctx, s.currentPath, []byte("@"), nil)
-				}); cerr != nil {
-					return nil, nil, fmt.Errorf("failed to get the cache for sftp watcher mode: %v", cerr)
-				}
-				if setErr != nil {
-					return nil, nil, fmt.Errorf("failed to update path in cache %s: %v", s.currentPath, err)
-				}
-			}
 			s.scanner.Close(ctx)
 			s.scanner = nil
 		}
@@ -330,6 +352,54 @@ func (s *sftpReader) WaitForClose(timeout time.Duration) error {
 	return nil
 }
 
+// finishPath is called once all messages consumed from a file have been
+// acknowledged, and deletes or moves the file according to the config before
+// marking it as consumed within the watcher cache.
+func (s *sftpReader) finishPath(ctx context.Context, client *sftp.Client, path string) error {
+	if s.conf.DeleteOnFinish {
+		if err := client.Remove(path); err != nil {
+			return fmt.Errorf("failed to delete file %v: %w", path, err)
+		}
+	} else if s.conf.MoveOnFinish != "" {
+		if err := client.MkdirAll(s.conf.MoveOnFinish); err != nil {
+			return fmt.Errorf("failed to create directory %v: %w", s.conf.MoveOnFinish, err)
+		}
+		target := sftpMoveTarget(s.conf.MoveOnFinish, path)
+		if err := client.Rename(path, target); err != nil {
+			return fmt.Errorf("failed to move file %v to %v: %w", path, target, err)
+		}
+	}
+
+	if !s.conf.Watcher.Enabled {
+		return nil
+	}
+
+	var setErr error
+	if cerr := s.mgr.AccessCache(ctx, s.conf.Watcher.Cache, func(cache cache.V1) {
+		setErr = cache.Set(ctx, path, []byte("@"), nil)
+	}); cerr != nil {
+		return fmt.Errorf("failed to get the cache for sftp watcher mode: %v", cerr)
+	}
+	if setErr != nil {
+		return fmt.Errorf("failed to update path in cache %s: %v", path, setErr)
+	}
+	return nil
+}
+
+// sftpMoveTarget returns the path that a finished file should be moved to
+// within a target directory. SFTP paths are always slash separated regardless
+// of the local OS.
+func sftpMoveTarget(dir, filePath string) string {
+	return pathpkg.Join(dir, pathpkg.Base(filePath))
+}
+
+func (s *sftpReader) isPending(path string) bool {
+	s.pendingMut.Lock()
+	_, exists := s.pending[path]
+	s.pendingMut.Unlock()
+	return exists
+}
+
 func (s *sftpReader) getFilePaths(ctx context.Context) ([]string, error) {
 	var filepaths []string
 	if !s.conf.Watcher.Enabled {
@@ -353,6 +423,9 @@ func (s *sftpReader) getFilePaths(ctx context.Context) ([]string, error) {
 			}
 
 			for _, path := range paths {
+				if s.isPending(path) {
+					continue
+				}
 				info, err := s.client.Stat(path)
 				if err != nil {
 					s.log.Warnf("Failed to stat path %v: %v\n", path, err)
@@ -0,0 +1,12 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSFTPMoveTarget(t *testing.T) {
+	assert.Equal(t, "/processed/foo.txt", sftpMoveTarget("/processed", "/data/in/foo.txt"))
+	assert.Equal(t, "done/bar.json", sftpMoveTarget("done/", "bar.json"))
+}
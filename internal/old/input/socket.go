@@ -31,7 +31,7 @@ Connects to a tcp or unix socket and consumes a continuous stream of messages.`,
 			docs.FieldString("network", "A network type to assume (unix|tcp).").HasOptions(
 				"unix", "tcp",
 			),
-			docs.FieldString("address", "The address to connect to.", "/tmp/benthos.sock", "127.0.0.1:6000"),
+			docs.FieldString("address", "The address to connect to, on Linux a unix socket address beginning with `@` refers to a socket within the abstract namespace.", "/tmp/benthos.sock", "@benthos", "127.0.0.1:6000"),
 			codec.ReaderDocs.AtVersion("3.42.0"),
 			docs.FieldInt("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed.").Advanced(),
 		),
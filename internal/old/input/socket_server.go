@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"os/user"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -24,16 +27,25 @@ import (
 func init() {
 	Constructors[TypeSocketServer] = TypeSpec{
 		constructor: fromSimpleConstructor(NewSocketServer),
-		Summary:     `Creates a server that receives a stream of messages over a tcp, udp or unix socket.`,
+		Summary:     `Creates a server that receives a stream of messages over a tcp, udp, unix or unixgram socket.`,
 		Description: `
-The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to allocate _per connection_ for buffering lines of data. If a line of data from a connection exceeds this value then the connection will be closed.`,
+The field ` + "`max_buffer`" + ` specifies the maximum amount of memory to allocate _per connection_ for buffering lines of data. If a line of data from a connection exceeds this value then the connection will be closed.
+
+### Unix Sockets
+
+The networks ` + "`unix`" + ` and ` + "`unixgram`" + ` listen on a stream and datagram unix domain socket respectively. On Linux an address beginning with ` + "`@`" + ` creates a socket within the abstract namespace, which does not exist on the filesystem.
+
+Otherwise the socket is created as a file, where the fields ` + "`file_mode`" + `, ` + "`file_owner`" + ` and ` + "`file_group`" + ` can be used in order to control which users are able to connect to it. The file is removed when the input is closed.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("network", "A network type to accept (unix|tcp|udp).").HasOptions(
-				"unix", "tcp", "udp",
+			docs.FieldString("network", "A network type to accept.").HasOptions(
+				"unix", "unixgram", "tcp", "udp",
 			),
-			docs.FieldString("address", "The address to listen from.", "/tmp/benthos.sock", "0.0.0.0:6000"),
+			docs.FieldString("address", "The address to listen from.", "/tmp/benthos.sock", "@benthos", "0.0.0.0:6000"),
 			codec.ReaderDocs.AtVersion("3.42.0"),
 			docs.FieldInt("max_buffer", "The maximum message buffer size. Must exceed the largest message to be consumed.").Advanced(),
+			docs.FieldString("file_mode", "The file permissions to set on a created unix socket file, as an octal number. When empty the permissions are determined by the umask of the process.", "0660").Advanced(),
+			docs.FieldString("file_owner", "The user name or ID to set as the owner of a created unix socket file. When empty the owner is not changed.", "benthos").Advanced(),
+			docs.FieldString("file_group", "The group name or ID to set as the group of a created unix socket file. When empty the group is not changed.", "benthos").Advanced(),
 		),
 		Categories: []string{
 			"Network",
@@ -49,6 +61,9 @@ type SocketServerConfig struct {
 	Address   string `json:"address" yaml:"address"`
 	Codec     string `json:"codec" yaml:"codec"`
 	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
+	FileMode  string `json:"file_mode" yaml:"file_mode"`
+	FileOwner string `json:"file_owner" yaml:"file_owner"`
+	FileGroup string `json:"file_group" yaml:"file_group"`
 }
 
 // NewSocketServerConfig creates a new SocketServerConfig with default values.
@@ -58,11 +73,74 @@ func NewSocketServerConfig() SocketServerConfig {
 		Address:   "",
 		Codec:     "lines",
 		MaxBuffer: 1000000,
+		FileMode:  "",
+		FileOwner: "",
+		FileGroup: "",
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// isUnixSocketFile returns true when a network and address results in the
+// creation of a unix socket file, as opposed to a socket within the abstract
+// namespace.
+func isUnixSocketFile(network, address string) bool {
+	return (network == "unix" || network == "unixgram") && !strings.HasPrefix(address, "@")
+}
+
+func lookupSocketFileID(name string, lookup func(string) (string, error)) (int, error) {
+	if name == "" {
+		return -1, nil
+	}
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	idStr, err := lookup(name)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(idStr)
+}
+
+// setSocketFileAttributes applies the configured permissions and ownership to
+// a created unix socket file.
+func setSocketFileAttributes(conf SocketServerConfig) error {
+	if conf.FileMode != "" {
+		mode, err := strconv.ParseUint(conf.FileMode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("failed to parse file_mode: %w", err)
+		}
+		if err := os.Chmod(conf.Address, os.FileMode(mode)); err != nil {
+			return err
+		}
+	}
+
+	uid, err := lookupSocketFileID(conf.FileOwner, func(name string) (string, error) {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return u.Uid, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to resolve file_owner: %w", err)
+	}
+	gid, err := lookupSocketFileID(conf.FileGroup, func(name string) (string, error) {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return "", err
+		}
+		return g.Gid, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to resolve file_group: %w", err)
+	}
+	if uid != -1 || gid != -1 {
+		return os.Chown(conf.Address, uid, gid)
+	}
+	return nil
+}
+
 type wrapPacketConn struct {
 	net.PacketConn
 }
@@ -112,7 +190,7 @@ func NewSocketServer(conf Config, mgr interop.Manager, log log.Modular, stats me
 	switch sconf.Network {
 	case "tcp", "unix":
 		ln, err = net.Listen(sconf.Network, sconf.Address)
-	case "udp":
+	case "udp", "unixgram":
 		cn, err = net.ListenPacket(sconf.Network, sconf.Address)
 	default:
 		return nil, fmt.Errorf("socket network '%v' is not supported by this input", sconf.Network)
@@ -121,6 +199,18 @@ func NewSocketServer(conf Config, mgr interop.Manager, log log.Modular, stats me
 		return nil, err
 	}
 
+	if isUnixSocketFile(sconf.Network, sconf.Address) {
+		if err := setSocketFileAttributes(sconf); err != nil {
+			if ln != nil {
+				ln.Close()
+			} else {
+				cn.Close()
+				_ = os.Remove(sconf.Address)
+			}
+			return nil, err
+		}
+	}
+
 	t := SocketServer{
 		conf:  conf.SocketServer,
 		stats: stats,
@@ -324,9 +414,15 @@ func (t *SocketServer) udpLoop() {
 		<-t.ctx.Done()
 		codec.Close(context.Background())
 		t.conn.Close()
+
+		// Unlike listeners, packet connections do not remove the socket file
+		// they created when closed.
+		if isUnixSocketFile(t.conf.Network, t.conf.Address) {
+			_ = os.Remove(t.conf.Address)
+		}
 	}()
 
-	t.log.Infof("Receiving udp socket messages from address: %v\n", t.conn.LocalAddr())
+	t.log.Infof("Receiving %v socket messages from address: %v\n", t.conf.Network, t.conn.LocalAddr())
 
 	for {
 		parts, ackFn, err := codec.Next(t.ctx)
@@ -3,9 +3,13 @@ package input
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -534,6 +538,120 @@ func TestSocketUDPServerBasic(t *testing.T) {
 	conn.Close()
 }
 
+func TestSocketUnixgramServerBasic(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
+	defer done()
+
+	conf := NewConfig()
+	conf.SocketServer.Network = "unixgram"
+	conf.SocketServer.Address = filepath.Join(t.TempDir(), "benthos.sock")
+
+	rdr, err := NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	conn, err := net.Dial("unixgram", conf.SocketServer.Address)
+	require.NoError(t, err)
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Write([]byte("foo\n"))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("bar\n"))
+	require.NoError(t, err)
+
+	for _, exp := range []string{"foo", "bar"} {
+		select {
+		case tran := <-rdr.TransactionChan():
+			assert.Equal(t, [][]byte{[]byte(exp)}, message.GetAllBytes(tran.Payload))
+			require.NoError(t, tran.Ack(tCtx, nil))
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+	conn.Close()
+
+	rdr.CloseAsync()
+	require.NoError(t, rdr.WaitForClose(time.Second*5))
+
+	_, err = os.Stat(conf.SocketServer.Address)
+	assert.True(t, os.IsNotExist(err), "socket file should be removed on close")
+}
+
+func TestSocketServerUnixFileAttributes(t *testing.T) {
+	for _, network := range []string{"unix", "unixgram"} {
+		network := network
+		t.Run(network, func(t *testing.T) {
+			conf := NewConfig()
+			conf.SocketServer.Network = network
+			conf.SocketServer.Address = filepath.Join(t.TempDir(), "benthos.sock")
+			conf.SocketServer.FileMode = "0600"
+			conf.SocketServer.FileOwner = strconv.Itoa(os.Getuid())
+			conf.SocketServer.FileGroup = strconv.Itoa(os.Getgid())
+
+			rdr, err := NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+			defer func() {
+				rdr.CloseAsync()
+				assert.NoError(t, rdr.WaitForClose(time.Second*5))
+			}()
+
+			info, err := os.Stat(conf.SocketServer.Address)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+		})
+	}
+}
+
+func TestSocketServerUnixFileModeBad(t *testing.T) {
+	conf := NewConfig()
+	conf.SocketServer.Network = "unixgram"
+	conf.SocketServer.Address = filepath.Join(t.TempDir(), "benthos.sock")
+	conf.SocketServer.FileMode = "rw-rw----"
+
+	_, err := NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file_mode")
+
+	_, err = os.Stat(conf.SocketServer.Address)
+	assert.True(t, os.IsNotExist(err), "socket file should be removed on failure")
+}
+
+func TestSocketServerUnixAbstract(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract unix sockets are only supported on linux")
+	}
+
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
+	defer done()
+
+	conf := NewConfig()
+	conf.SocketServer.Network = "unix"
+	conf.SocketServer.Address = fmt.Sprintf("@benthos-test-%v", time.Now().UnixNano())
+	conf.SocketServer.FileMode = "0600"
+
+	rdr, err := NewSocketServer(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	defer func() {
+		rdr.CloseAsync()
+		assert.NoError(t, rdr.WaitForClose(time.Second*5))
+	}()
+
+	conn, err := net.Dial("unix", conf.SocketServer.Address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(time.Second * 5))
+	_, err = conn.Write([]byte("foo\n"))
+	require.NoError(t, err)
+
+	select {
+	case tran := <-rdr.TransactionChan():
+		assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(tran.Payload))
+		require.NoError(t, tran.Ack(tCtx, nil))
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+}
+
 func TestSocketUDPServerRetries(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
 	defer done()
@@ -44,6 +44,18 @@ type AsyncSink interface {
 	WaitForClose(timeout time.Duration) error
 }
 
+// AsyncEnqueuer is an optional interface implemented by an AsyncSink that is
+// able to dispatch a message to the sink without waiting for it to be
+// acknowledged. When implemented messages are dispatched in the order that
+// they are consumed, and up to the maximum in flight are awaiting
+// acknowledgement in parallel.
+type AsyncEnqueuer interface {
+	// EnqueueWithContext dispatches a message to the sink and returns a
+	// function that blocks until the message has been acknowledged, or a
+	// transport specific error has occurred, or the Type is closed.
+	EnqueueWithContext(ctx context.Context, msg *message.Batch) (func(ctx context.Context) error, error)
+}
+
 // AsyncWriter is an output type that writes messages to a writer.Type.
 type AsyncWriter struct {
 	isConnected int32
@@ -116,7 +128,11 @@ func (w *AsyncWriter) SetNoCancel() {
 
 //------------------------------------------------------------------------------
 
-func (w *AsyncWriter) latencyMeasuringWrite(msg *message.Batch) (latencyNs int64, err error) {
+// latencyMeasuringWrite writes a message to the writer. When the writer is an
+// AsyncEnqueuer and dispatched is not nil then dispatched is called once the
+// message has been enqueued successfully, before waiting for it to be
+// acknowledged.
+func (w *AsyncWriter) latencyMeasuringWrite(msg *message.Batch, dispatched func()) (latencyNs int64, err error) {
 	t0 := time.Now()
 	var ctx context.Context
 	if w.noCancel {
@@ -126,7 +142,15 @@ func (w *AsyncWriter) latencyMeasuringWrite(msg *message.Batch) (latencyNs int64
 		ctx, done = w.shutSig.CloseAtLeisureCtx(context.Background())
 		defer done()
 	}
-	err = w.writer.WriteWithContext(ctx, msg)
+	if e, ok := w.writer.(AsyncEnqueuer); ok && dispatched != nil {
+		var wait func(context.Context) error
+		if wait, err = e.EnqueueWithContext(ctx, msg); err == nil {
+			dispatched()
+			err = wait(ctx)
+		}
+	} else {
+		err = w.writer.WriteWithContext(ctx, msg)
+	}
 	latencyNs = time.Since(t0).Nanoseconds()
 	return latencyNs, err
 }
@@ -175,6 +199,7 @@ func (w *AsyncWriter) loop() {
 		mConn       = w.stats.GetCounter("output_connection_up")
 		mFailedConn = w.stats.GetCounter("output_connection_failed")
 		mLostConn   = w.stats.GetCounter("output_connection_lost")
+		mInFlight   = w.stats.GetGauge("output_in_flight")
 	)
 
 	defer func() {
@@ -233,7 +258,7 @@ func (w *AsyncWriter) loop() {
 		// If another goroutine got here first and we're able to send over the
 		// connection, then we gracefully accept defeat.
 		if atomic.LoadInt32(&w.isConnected) == 1 {
-			if latency, err = w.latencyMeasuringWrite(msg); err != component.ErrNotConnected {
+			if latency, err = w.latencyMeasuringWrite(msg, nil); err != component.ErrNotConnected {
 				return
 			} else if err != nil {
 				mError.Incr(1)
@@ -247,7 +272,7 @@ func (w *AsyncWriter) loop() {
 				err = component.ErrTypeClosed
 				return
 			}
-			if latency, err = w.latencyMeasuringWrite(msg); err != component.ErrNotConnected {
+			if latency, err = w.latencyMeasuringWrite(msg, nil); err != component.ErrNotConnected {
 				atomic.StoreInt32(&w.isConnected, 1)
 				mConn.Incr(1)
 				return
@@ -257,26 +282,49 @@ func (w *AsyncWriter) loop() {
 		}
 	}
 
+	// When the writer is able to enqueue messages then the dispatch mutex is
+	// held from consuming a transaction until it has been enqueued, which
+	// preserves the order of messages whilst they are awaiting acknowledgement
+	// in parallel.
+	_, ordered := w.writer.(AsyncEnqueuer)
+	dispatchMut := sync.Mutex{}
+
 	writerLoop := func() {
 		defer wg.Done()
 
 		for {
+			dispatching := false
+			dispatched := func() {
+				if dispatching {
+					dispatching = false
+					dispatchMut.Unlock()
+				}
+			}
+			if ordered {
+				dispatchMut.Lock()
+				dispatching = true
+			}
+
 			var ts message.Transaction
 			var open bool
 			select {
 			case ts, open = <-w.transactions:
 				if !open {
+					dispatched()
 					return
 				}
 			case <-w.shutSig.CloseAtLeisureChan():
+				dispatched()
 				return
 			}
 
 			w.log.Tracef("Attempting to write %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
+			inFlight := int64(ts.Payload.Len())
+			mInFlight.Incr(inFlight)
 			spans := tracing.CreateChildSpans("output_"+w.typeStr, ts.Payload)
 			ts.Payload = w.injectSpans(ts.Payload, spans)
 
-			latency, err := w.latencyMeasuringWrite(ts.Payload)
+			latency, err := w.latencyMeasuringWrite(ts.Payload, dispatched)
 
 			// If our writer says it is not connected.
 			if err == component.ErrNotConnected {
@@ -284,6 +332,9 @@ func (w *AsyncWriter) loop() {
 			} else if err != nil {
 				mError.Incr(1)
 			}
+			dispatched()
+
+			mInFlight.Decr(inFlight)
 
 			// Close immediately if our writer is closed.
 			if err == component.ErrTypeClosed {
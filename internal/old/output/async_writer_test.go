@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -664,3 +665,141 @@ func TestAsyncWriterSadPath(t *testing.T) {
 		t.Errorf("Wrong message sent: %v != %v", act, exp)
 	}
 }
+
+func TestAsyncWriterInFlightGauge(t *testing.T) {
+	t.Parallel()
+
+	writerImpl := newAsyncMockWriter()
+	stats := metrics.NewLocal()
+
+	w, err := NewAsyncWriter("foo", 1, writerImpl, log.Noop(), stats)
+	require.NoError(t, err)
+
+	msgChan := make(chan message.Transaction)
+	resChan := make(chan error)
+	require.NoError(t, w.Consume(msgChan))
+
+	go func() {
+		select {
+		case msgChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")}), resChan):
+		case <-time.After(time.Second):
+			t.Error("Timed out")
+		}
+	}()
+
+	select {
+	case writerImpl.connChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	require.Eventually(t, func() bool {
+		return stats.GetCounters()["output_in_flight"] == 2
+	}, time.Second, time.Millisecond*10)
+
+	select {
+	case writerImpl.writeChan <- nil:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		require.NoError(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	require.Equal(t, int64(0), stats.GetCounters()["output_in_flight"])
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+}
+
+//------------------------------------------------------------------------------
+
+type mockAsyncEnqueuer struct {
+	mut      sync.Mutex
+	enqueued []string
+	ackChan  chan error
+}
+
+func (w *mockAsyncEnqueuer) ConnectWithContext(ctx context.Context) error {
+	return nil
+}
+func (w *mockAsyncEnqueuer) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	wait, err := w.EnqueueWithContext(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return wait(ctx)
+}
+func (w *mockAsyncEnqueuer) EnqueueWithContext(ctx context.Context, msg *message.Batch) (func(context.Context) error, error) {
+	// Give other writers an opportunity to jump the queue.
+	time.Sleep(time.Millisecond)
+
+	w.mut.Lock()
+	w.enqueued = append(w.enqueued, string(msg.Get(0).Get()))
+	w.mut.Unlock()
+	return func(ctx context.Context) error {
+		return <-w.ackChan
+	}, nil
+}
+func (w *mockAsyncEnqueuer) CloseAsync() {}
+func (w *mockAsyncEnqueuer) WaitForClose(time.Duration) error {
+	return nil
+}
+
+func TestAsyncWriterEnqueueOrdered(t *testing.T) {
+	t.Parallel()
+
+	writerImpl := &mockAsyncEnqueuer{ackChan: make(chan error)}
+
+	w, err := NewAsyncWriter(
+		"foo", 5, writerImpl,
+		log.Noop(), metrics.Noop(),
+	)
+	require.NoError(t, err)
+
+	msgChan := make(chan message.Transaction)
+	resChan := make(chan error)
+	require.NoError(t, w.Consume(msgChan))
+
+	var exp []string
+	for i := 0; i < 5; i++ {
+		content := strconv.Itoa(i)
+		exp = append(exp, content)
+		select {
+		case msgChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	// All messages are awaiting acknowledgement in parallel.
+	require.Eventually(t, func() bool {
+		writerImpl.mut.Lock()
+		defer writerImpl.mut.Unlock()
+		return len(writerImpl.enqueued) == 5
+	}, time.Second, time.Millisecond*10)
+
+	for i := 0; i < 5; i++ {
+		select {
+		case writerImpl.ackChan <- nil:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case res := <-resChan:
+			require.NoError(t, res)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+
+	writerImpl.mut.Lock()
+	require.Equal(t, exp, writerImpl.enqueued)
+	writerImpl.mut.Unlock()
+}
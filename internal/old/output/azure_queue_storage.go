@@ -35,7 +35,7 @@ In order to set the ` + "`queue_name`" + ` you can use function interpolations d
 				"60s", "5m", "36h",
 			).IsInterpolated().Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").AtVersion("3.45.0"),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		),
 		Categories: []string{
 			"Services",
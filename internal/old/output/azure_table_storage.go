@@ -86,7 +86,7 @@ properties:
 			docs.FieldInt("max_in_flight",
 				"The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldString("timeout", "The maximum period to wait on an upload before abandoning it and reattempting.").Advanced(),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		),
 		Categories: []string{
 			"Services",
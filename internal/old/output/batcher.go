@@ -24,6 +24,8 @@ type Batcher struct {
 	child   output.Streamed
 	batcher *policy.Batcher
 
+	mCoalesced metrics.StatCounter
+
 	messagesIn  <-chan message.Transaction
 	messagesOut chan message.Transaction
 
@@ -33,13 +35,13 @@ type Batcher struct {
 // NewBatcherFromConfig creates a new output preceded by a batching mechanism
 // that enforces a given batching policy configuration.
 func NewBatcherFromConfig(
-	conf policy.Config,
+	conf policy.OutputConfig,
 	child output.Streamed, mgr interop.Manager,
 	log log.Modular,
 	stats metrics.Type,
 ) (output.Streamed, error) {
 	if !conf.IsNoop() {
-		policy, err := policy.New(conf, mgr.IntoPath("batching"))
+		policy, err := policy.NewOutput(conf, mgr.IntoPath("batching"))
 		if err != nil {
 			return nil, fmt.Errorf("failed to construct batch policy: %v", err)
 		}
@@ -60,6 +62,7 @@ func NewBatcher(
 		log:         log,
 		child:       child,
 		batcher:     batcher,
+		mCoalesced:  stats.GetCounter("batch_coalesced"),
 		messagesOut: make(chan message.Transaction),
 		shutSig:     shutdown.NewSignaller(),
 	}
@@ -86,6 +89,7 @@ func (m *Batcher) loop() {
 	}
 
 	var pendingTrans []*transaction.Tracked
+	inputClosed, flushNext, coalesced := false, false, false
 	for !m.shutSig.ShouldCloseAtLeisure() {
 		if nextTimedBatchChan == nil {
 			if tNext := m.batcher.UntilNext(); tNext >= 0 {
@@ -93,36 +97,34 @@ func (m *Batcher) loop() {
 			}
 		}
 
-		var flushBatch bool
-		select {
-		case tran, open := <-m.messagesIn:
-			if !open {
-				if flushBatch = m.batcher.Count() > 0; !flushBatch {
-					return
-				}
+		flushBatch := flushNext
+		flushNext = false
+		if !flushBatch {
+			select {
+			case tran, open := <-m.messagesIn:
+				if !open {
+					if flushBatch = m.batcher.Count() > 0; !flushBatch {
+						return
+					}
 
-				// If we're waiting for a timed batch then we will respect it.
-				if nextTimedBatchChan != nil {
-					select {
-					case <-nextTimedBatchChan:
-					case <-m.shutSig.CloseAtLeisureChan():
+					// If we're waiting for a timed batch then we will respect it.
+					if nextTimedBatchChan != nil {
+						select {
+						case <-nextTimedBatchChan:
+						case <-m.shutSig.CloseAtLeisureChan():
+						}
 					}
+				} else {
+					var tracked *transaction.Tracked
+					tracked, flushBatch = m.addTransaction(tran)
+					pendingTrans = append(pendingTrans, tracked)
 				}
-			} else {
-				trackedTran := transaction.NewTracked(tran.Payload, tran.Ack)
-				_ = trackedTran.Message().Iter(func(i int, p *message.Part) error {
-					if m.batcher.Add(p) {
-						flushBatch = true
-					}
-					return nil
-				})
-				pendingTrans = append(pendingTrans, trackedTran)
+			case <-nextTimedBatchChan:
+				flushBatch = true
+				nextTimedBatchChan = nil
+			case <-m.shutSig.CloseAtLeisureChan():
+				flushBatch = true
 			}
-		case <-nextTimedBatchChan:
-			flushBatch = true
-			nextTimedBatchChan = nil
-		case <-m.shutSig.CloseAtLeisureChan():
-			flushBatch = true
 		}
 
 		if !flushBatch {
@@ -133,12 +135,42 @@ func (m *Batcher) loop() {
 		if sendMsg == nil {
 			continue
 		}
+		if coalesced {
+			m.mCoalesced.Incr(1)
+			coalesced = false
+		}
+		sendTrans := pendingTrans
+		pendingTrans = nil
 
 		resChan := make(chan error)
-		select {
-		case m.messagesOut <- message.NewTransaction(sendMsg, resChan):
-		case <-m.shutSig.CloseAtLeisureChan():
-			return
+	sendLoop:
+		for {
+			// While the child output is busy we continue to add incoming
+			// messages to the batch policy as long as they remain within its
+			// limits, until the policy is triggered. The flush is deferred until
+			// the current batch is sent, which coalesces the raw messages of what
+			// would otherwise be several batches before the batch processors are
+			// applied to them once.
+			var coalesceInChan <-chan message.Transaction
+			if m.batcher.Coalesce() && !inputClosed && !flushNext && m.batcher.Fits(1, 1) {
+				coalesceInChan = m.messagesIn
+			}
+
+			select {
+			case m.messagesOut <- message.NewTransaction(sendMsg, resChan):
+				break sendLoop
+			case tran, open := <-coalesceInChan:
+				if !open {
+					inputClosed = true
+					continue
+				}
+				var tracked *transaction.Tracked
+				tracked, flushNext = m.addTransaction(tran)
+				pendingTrans = append(pendingTrans, tracked)
+				coalesced = true
+			case <-m.shutSig.CloseAtLeisureChan():
+				return
+			}
 		}
 
 		go func(rChan chan error, upstreamTrans []*transaction.Tracked) {
@@ -158,11 +190,24 @@ func (m *Batcher) loop() {
 				}
 				done()
 			}
-		}(resChan, pendingTrans)
-		pendingTrans = nil
+		}(resChan, sendTrans)
 	}
 }
 
+// addTransaction adds the messages of a transaction to the batch policy and
+// returns the tracked transaction along with whether the policy was triggered.
+func (m *Batcher) addTransaction(tran message.Transaction) (*transaction.Tracked, bool) {
+	var triggered bool
+	trackedTran := transaction.NewTracked(tran.Payload, tran.Ack)
+	_ = trackedTran.Message().Iter(func(i int, p *message.Part) error {
+		if m.batcher.Add(p) {
+			triggered = true
+		}
+		return nil
+	})
+	return trackedTran, triggered
+}
+
 // Connected returns a boolean indicating whether this output is currently
 // connected to its target.
 func (m *Batcher) Connected() bool {
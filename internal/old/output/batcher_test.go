@@ -18,6 +18,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
 )
 
 //------------------------------------------------------------------------------
@@ -315,4 +316,168 @@ func TestBatcherTimed(t *testing.T) {
 	close(resChan)
 }
 
+func TestBatcherCoalesce(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	tInChan := make(chan message.Transaction)
+	resChan := make(chan error)
+
+	policyConf := policy.NewOutputConfig()
+	policyConf.Count = 10
+	policyConf.Check = `content().has_prefix("end")`
+	policyConf.Coalesce = true
+
+	procConf := processor.NewConfig()
+	procConf.Type = processor.TypeArchive
+	procConf.Archive.Format = "lines"
+	policyConf.Processors = append(policyConf.Processors, procConf)
+
+	batcher, err := policy.NewOutput(policyConf, mock.NewManager())
+	require.NoError(t, err)
+
+	out := &mockOutput{}
+	stats := metrics.NewLocal()
+
+	b := NewBatcher(batcher, out, log.Noop(), stats)
+	require.NoError(t, b.Consume(tInChan))
+
+	newTran := func(parts ...string) message.Transaction {
+		var rawParts [][]byte
+		for _, p := range parts {
+			rawParts = append(rawParts, []byte(p))
+		}
+		return message.NewTransaction(message.QuickBatch(rawParts), resChan)
+	}
+	sendTran := func(parts ...string) {
+		t.Helper()
+		select {
+		case tInChan <- newTran(parts...):
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	// The output isn't reading, therefore the first batch waits to be sent
+	// whilst the following messages are coalesced up until the check is
+	// triggered, after which no more messages are consumed.
+	sendTran("a1", "end1")
+	sendTran("a2")
+	sendTran("a3")
+	sendTran("b1", "end2")
+
+	select {
+	case tInChan <- newTran("c1"):
+		t.Fatal("message consumed after the policy was triggered")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	receiveBatch := func(exp []string, acks int) {
+		t.Helper()
+		var outTr message.Transaction
+		select {
+		case outTr = <-out.ts:
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+		var act []string
+		for _, p := range message.GetAllBytes(outTr.Payload) {
+			act = append(act, string(p))
+		}
+		assert.Equal(t, exp, act)
+
+		go func() {
+			require.NoError(t, outTr.Ack(ctx, nil))
+		}()
+		for i := 0; i < acks; i++ {
+			select {
+			case res := <-resChan:
+				assert.NoError(t, res)
+			case <-ctx.Done():
+				t.Fatal("timed out")
+			}
+		}
+	}
+
+	receiveBatch([]string{"a1\nend1"}, 1)
+	receiveBatch([]string{"a2\na3\nb1\nend2"}, 3)
+
+	sendTran("c1", "end3")
+	receiveBatch([]string{"c1\nend3"}, 1)
+
+	assert.Equal(t, int64(1), stats.GetCounters()["batch_coalesced"])
+
+	close(tInChan)
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+}
+
+func TestBatcherCoalesceCountLimit(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	tInChan := make(chan message.Transaction)
+	resChan := make(chan error)
+
+	policyConf := policy.NewOutputConfig()
+	policyConf.Count = 2
+	policyConf.Coalesce = true
+
+	batcher, err := policy.NewOutput(policyConf, mock.NewManager())
+	require.NoError(t, err)
+
+	out := &mockOutput{}
+
+	b := NewBatcher(batcher, out, log.Noop(), metrics.Noop())
+	require.NoError(t, b.Consume(tInChan))
+
+	sendTran := func(part string) {
+		t.Helper()
+		select {
+		case tInChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(part)}), resChan):
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	sendTran("a1")
+	sendTran("a2")
+	sendTran("b1")
+	sendTran("b2")
+
+	select {
+	case tInChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("c1")}), resChan):
+		t.Fatal("message consumed beyond the count of the policy")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	for _, exp := range [][]string{{"a1", "a2"}, {"b1", "b2"}} {
+		var outTr message.Transaction
+		select {
+		case outTr = <-out.ts:
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+		assert.Equal(t, exp, []string{
+			string(outTr.Payload.Get(0).Get()),
+			string(outTr.Payload.Get(1).Get()),
+		})
+		go func() {
+			require.NoError(t, outTr.Ack(ctx, nil))
+		}()
+		for i := 0; i < 2; i++ {
+			select {
+			case res := <-resChan:
+				assert.NoError(t, res)
+			case <-ctx.Done():
+				t.Fatal("timed out")
+			}
+		}
+	}
+
+	close(tInChan)
+	b.CloseAsync()
+	require.NoError(t, b.WaitForClose(time.Second))
+}
+
 //------------------------------------------------------------------------------
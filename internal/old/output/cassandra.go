@@ -129,7 +129,7 @@ output:
 			docs.FieldString("timeout", "The client connection timeout.").AtVersion("3.63.0"),
 		).WithChildren(
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		),
 	}
 }
@@ -156,8 +156,8 @@ type CassandraConfig struct {
 	Timeout                  string                `json:"timeout" yaml:"timeout"`
 	// TODO: V4 Remove this and replace with explicit values.
 	retries.Config `json:",inline" yaml:",inline"`
-	MaxInFlight    int           `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching       policy.Config `json:"batching" yaml:"batching"`
+	MaxInFlight    int                 `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching       policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewCassandraConfig creates a new CassandraConfig with default values.
@@ -183,7 +183,7 @@ func NewCassandraConfig() CassandraConfig {
 		Timeout:                  "600ms",
 		Config:                   rConf,
 		MaxInFlight:              64,
-		Batching:                 policy.NewConfig(),
+		Batching:                 policy.NewOutputConfig(),
 	}
 }
 
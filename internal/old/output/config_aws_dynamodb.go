@@ -13,14 +13,23 @@ type sessionConfig struct {
 // DynamoDBConfig contains config fields for the DynamoDB output type.
 type DynamoDBConfig struct {
 	sessionConfig  `json:",inline" yaml:",inline"`
-	Table          string            `json:"table" yaml:"table"`
-	StringColumns  map[string]string `json:"string_columns" yaml:"string_columns"`
-	JSONMapColumns map[string]string `json:"json_map_columns" yaml:"json_map_columns"`
-	TTL            string            `json:"ttl" yaml:"ttl"`
-	TTLKey         string            `json:"ttl_key" yaml:"ttl_key"`
-	MaxInFlight    int               `json:"max_in_flight" yaml:"max_in_flight"`
+	Table          string                  `json:"table" yaml:"table"`
+	StringColumns  map[string]string       `json:"string_columns" yaml:"string_columns"`
+	JSONMapColumns map[string]string       `json:"json_map_columns" yaml:"json_map_columns"`
+	TTL            string                  `json:"ttl" yaml:"ttl"`
+	TTLKey         string                  `json:"ttl_key" yaml:"ttl_key"`
+	MaxInFlight    int                     `json:"max_in_flight" yaml:"max_in_flight"`
+	Condition      DynamoDBConditionConfig `json:"condition" yaml:"condition"`
 	retries.Config `json:",inline" yaml:",inline"`
-	Batching       policy.Config `json:"batching" yaml:"batching"`
+	Batching       policy.OutputConfig `json:"batching" yaml:"batching"`
+}
+
+// DynamoDBConditionConfig contains config fields for writing items to
+// DynamoDB only when a condition is met.
+type DynamoDBConditionConfig struct {
+	Expression      string            `json:"expression" yaml:"expression"`
+	AttributeNames  map[string]string `json:"attribute_names" yaml:"attribute_names"`
+	AttributeValues map[string]string `json:"attribute_values" yaml:"attribute_values"`
 }
 
 // NewDynamoDBConfig creates a DynamoDBConfig populated with default values.
@@ -40,7 +49,12 @@ func NewDynamoDBConfig() DynamoDBConfig {
 		TTL:            "",
 		TTLKey:         "",
 		MaxInFlight:    64,
-		Config:         rConf,
-		Batching:       policy.NewConfig(),
+		Condition: DynamoDBConditionConfig{
+			Expression:      "",
+			AttributeNames:  map[string]string{},
+			AttributeValues: map[string]string{},
+		},
+		Config:   rConf,
+		Batching: policy.NewOutputConfig(),
 	}
 }
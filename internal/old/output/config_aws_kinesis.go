@@ -14,7 +14,7 @@ type KinesisConfig struct {
 	PartitionKey   string `json:"partition_key" yaml:"partition_key"`
 	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config `json:",inline" yaml:",inline"`
-	Batching       policy.Config `json:"batching" yaml:"batching"`
+	Batching       policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewKinesisConfig creates a new Config with default values.
@@ -32,6 +32,6 @@ func NewKinesisConfig() KinesisConfig {
 		PartitionKey: "",
 		MaxInFlight:  64,
 		Config:       rConf,
-		Batching:     policy.NewConfig(),
+		Batching:     policy.NewOutputConfig(),
 	}
 }
@@ -12,7 +12,7 @@ type KinesisFirehoseConfig struct {
 	Stream         string `json:"stream" yaml:"stream"`
 	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config `json:",inline" yaml:",inline"`
-	Batching       policy.Config `json:"batching" yaml:"batching"`
+	Batching       policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewKinesisFirehoseConfig creates a new Config with default values.
@@ -29,6 +29,6 @@ func NewKinesisFirehoseConfig() KinesisFirehoseConfig {
 		Stream:      "",
 		MaxInFlight: 64,
 		Config:      rConf,
-		Batching:    policy.NewConfig(),
+		Batching:    policy.NewOutputConfig(),
 	}
 }
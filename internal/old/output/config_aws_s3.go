@@ -25,7 +25,7 @@ type AmazonS3Config struct {
 	KMSKeyID                string                       `json:"kms_key_id" yaml:"kms_key_id"`
 	ServerSideEncryption    string                       `json:"server_side_encryption" yaml:"server_side_encryption"`
 	MaxInFlight             int                          `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching                policy.Config                `json:"batching" yaml:"batching"`
+	Batching                policy.OutputConfig          `json:"batching" yaml:"batching"`
 }
 
 // NewAmazonS3Config creates a new Config with default values.
@@ -48,6 +48,6 @@ func NewAmazonS3Config() AmazonS3Config {
 		KMSKeyID:                "",
 		ServerSideEncryption:    "",
 		MaxInFlight:             64,
-		Batching:                policy.NewConfig(),
+		Batching:                policy.NewOutputConfig(),
 	}
 }
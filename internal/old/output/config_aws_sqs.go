@@ -16,7 +16,7 @@ type AmazonSQSConfig struct {
 	Metadata               metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
 	MaxInFlight            int                          `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config         `json:",inline" yaml:",inline"`
-	Batching               policy.Config `json:"batching" yaml:"batching"`
+	Batching               policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewAmazonSQSConfig creates a new Config with default values.
@@ -36,6 +36,6 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 		Metadata:               metadata.NewExcludeFilterConfig(),
 		MaxInFlight:            64,
 		Config:                 rConf,
-		Batching:               policy.NewConfig(),
+		Batching:               policy.NewOutputConfig(),
 	}
 }
@@ -6,10 +6,10 @@ import (
 
 // BrokerConfig contains configuration fields for the Broker output type.
 type BrokerConfig struct {
-	Copies   int           `json:"copies" yaml:"copies"`
-	Pattern  string        `json:"pattern" yaml:"pattern"`
-	Outputs  []Config      `json:"outputs" yaml:"outputs"`
-	Batching policy.Config `json:"batching" yaml:"batching"`
+	Copies   int                 `json:"copies" yaml:"copies"`
+	Pattern  string              `json:"pattern" yaml:"pattern"`
+	Outputs  []Config            `json:"outputs" yaml:"outputs"`
+	Batching policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
@@ -18,6 +18,6 @@ func NewBrokerConfig() BrokerConfig {
 		Copies:   1,
 		Pattern:  "fan_out",
 		Outputs:  []Config{},
-		Batching: policy.NewConfig(),
+		Batching: policy.NewOutputConfig(),
 	}
 }
@@ -2,14 +2,34 @@ package output
 
 // DynamicConfig contains configuration fields for the Dynamic output type.
 type DynamicConfig struct {
-	Outputs map[string]Config `json:"outputs" yaml:"outputs"`
-	Prefix  string            `json:"prefix" yaml:"prefix"`
+	Outputs     map[string]Config        `json:"outputs" yaml:"outputs"`
+	Prefix      string                   `json:"prefix" yaml:"prefix"`
+	Persistence DynamicPersistenceConfig `json:"persistence" yaml:"persistence"`
 }
 
 // NewDynamicConfig creates a new DynamicConfig with default values.
 func NewDynamicConfig() DynamicConfig {
 	return DynamicConfig{
-		Outputs: map[string]Config{},
-		Prefix:  "",
+		Outputs:     map[string]Config{},
+		Prefix:      "",
+		Persistence: NewDynamicPersistenceConfig(),
+	}
+}
+
+// DynamicPersistenceConfig contains configuration fields for persisting the
+// outputs added to a Dynamic output via its API.
+type DynamicPersistenceConfig struct {
+	Path  string `json:"path" yaml:"path"`
+	Cache string `json:"cache" yaml:"cache"`
+	Key   string `json:"key" yaml:"key"`
+}
+
+// NewDynamicPersistenceConfig creates a new DynamicPersistenceConfig with
+// default values.
+func NewDynamicPersistenceConfig() DynamicPersistenceConfig {
+	return DynamicPersistenceConfig{
+		Path:  "",
+		Cache: "",
+		Key:   "benthos_dynamic_outputs",
 	}
 }
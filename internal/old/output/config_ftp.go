@@ -0,0 +1,25 @@
+package output
+
+import (
+	ftpSetup "github.com/benthosdev/benthos/v4/internal/impl/ftp/shared"
+)
+
+// FTPConfig contains configuration fields for the FTP output type.
+type FTPConfig struct {
+	ftpSetup.ConnectionConfig `json:",inline" yaml:",inline"`
+	Path                      string `json:"path" yaml:"path"`
+	Codec                     string `json:"codec" yaml:"codec"`
+	AtomicUpload              bool   `json:"atomic_upload" yaml:"atomic_upload"`
+	MaxInFlight               int    `json:"max_in_flight" yaml:"max_in_flight"`
+}
+
+// NewFTPConfig creates a new FTPConfig with default values.
+func NewFTPConfig() FTPConfig {
+	return FTPConfig{
+		ConnectionConfig: ftpSetup.NewConnectionConfig(),
+		Path:             "",
+		Codec:            "all-bytes",
+		AtomicUpload:     false,
+		MaxInFlight:      1,
+	}
+}
@@ -18,16 +18,56 @@ func NewSwitchConfig() SwitchConfig {
 
 // SwitchConfigCase contains configuration fields per output of a switch type.
 type SwitchConfigCase struct {
-	Check    string `json:"check" yaml:"check"`
-	Continue bool   `json:"continue" yaml:"continue"`
-	Output   Config `json:"output" yaml:"output"`
+	Check    string              `json:"check" yaml:"check"`
+	Rollout  SwitchConfigRollout `json:"rollout" yaml:"rollout"`
+	Window   SwitchConfigWindow  `json:"window" yaml:"window"`
+	Continue bool                `json:"continue" yaml:"continue"`
+	Output   Config              `json:"output" yaml:"output"`
 }
 
 // NewSwitchConfigCase creates a new switch output config with default values.
 func NewSwitchConfigCase() SwitchConfigCase {
 	return SwitchConfigCase{
 		Check:    "",
+		Rollout:  NewSwitchConfigRollout(),
+		Window:   NewSwitchConfigWindow(),
 		Continue: false,
 		Output:   NewConfig(),
 	}
 }
+
+// SwitchConfigRollout contains configuration fields for routing a percentage
+// of messages to a switch case.
+type SwitchConfigRollout struct {
+	Percentage float64 `json:"percentage" yaml:"percentage"`
+	Key        string  `json:"key" yaml:"key"`
+}
+
+// NewSwitchConfigRollout creates a new switch case rollout config with default
+// values.
+func NewSwitchConfigRollout() SwitchConfigRollout {
+	return SwitchConfigRollout{
+		Percentage: 0,
+		Key:        "",
+	}
+}
+
+// SwitchConfigWindow contains configuration fields for restricting a switch
+// case to a window of time.
+type SwitchConfigWindow struct {
+	Start    string   `json:"start" yaml:"start"`
+	End      string   `json:"end" yaml:"end"`
+	Days     []string `json:"days" yaml:"days"`
+	Timezone string   `json:"timezone" yaml:"timezone"`
+}
+
+// NewSwitchConfigWindow creates a new switch case window config with default
+// values.
+func NewSwitchConfigWindow() SwitchConfigWindow {
+	return SwitchConfigWindow{
+		Start:    "",
+		End:      "",
+		Days:     []string{},
+		Timezone: "UTC",
+	}
+}
@@ -133,6 +133,7 @@ const (
 	TypeElasticsearch      = "elasticsearch"
 	TypeFallback           = "fallback"
 	TypeFile               = "file"
+	TypeFTP                = "ftp"
 	TypeGCPCloudStorage    = "gcp_cloud_storage"
 	TypeGCPPubSub          = "gcp_pubsub"
 	TypeHDFS               = "hdfs"
@@ -191,6 +192,7 @@ type Config struct {
 	Elasticsearch      writer.ElasticsearchConfig     `json:"elasticsearch" yaml:"elasticsearch"`
 	Fallback           TryConfig                      `json:"fallback" yaml:"fallback"`
 	File               FileConfig                     `json:"file" yaml:"file"`
+	FTP                FTPConfig                      `json:"ftp" yaml:"ftp"`
 	GCPCloudStorage    GCPCloudStorageConfig          `json:"gcp_cloud_storage" yaml:"gcp_cloud_storage"`
 	GCPPubSub          writer.GCPPubSubConfig         `json:"gcp_pubsub" yaml:"gcp_pubsub"`
 	HDFS               writer.HDFSConfig              `json:"hdfs" yaml:"hdfs"`
@@ -249,6 +251,7 @@ func NewConfig() Config {
 		Elasticsearch:      writer.NewElasticsearchConfig(),
 		Fallback:           NewTryConfig(),
 		File:               NewFileConfig(),
+		FTP:                NewFTPConfig(),
 		GCPCloudStorage:    NewGCPCloudStorageConfig(),
 		GCPPubSub:          writer.NewGCPPubSubConfig(),
 		HDFS:               writer.NewHDFSConfig(),
@@ -11,6 +11,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
 	"github.com/benthosdev/benthos/v4/internal/old/util/retries"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
 	"github.com/benthosdev/benthos/v4/internal/tls"
 )
 
@@ -46,10 +47,11 @@ false for connections to succeed.`,
 			docs.FieldBool("healthcheck", "Whether to enable healthchecks.").Advanced(),
 			docs.FieldString("timeout", "The maximum time to wait before abandoning a request (and trying again).").Advanced(),
 			tls.FieldSpec(),
+			proxy.FieldSpec(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 		).WithChildren(retries.FieldSpecs()...).WithChildren(
 			auth.BasicAuthFieldSpec(),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 			docs.FieldObject("aws", "Enables and customises connectivity to Amazon Elastic Service.").WithChildren(
 				docs.FieldSpecs{
 					docs.FieldBool("enabled", "Whether to connect to Amazon Elastic Service."),
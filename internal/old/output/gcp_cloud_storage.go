@@ -4,6 +4,7 @@ import (
 	"google.golang.org/api/googleapi"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
 )
 
 const (
@@ -23,14 +24,15 @@ const (
 // GCPCloudStorageConfig contains configuration fields for the GCP Cloud Storage
 // output type.
 type GCPCloudStorageConfig struct {
-	Bucket          string        `json:"bucket" yaml:"bucket"`
-	Path            string        `json:"path" yaml:"path"`
-	ContentType     string        `json:"content_type" yaml:"content_type"`
-	ContentEncoding string        `json:"content_encoding" yaml:"content_encoding"`
-	ChunkSize       int           `json:"chunk_size" yaml:"chunk_size"`
-	MaxInFlight     int           `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching        policy.Config `json:"batching" yaml:"batching"`
-	CollisionMode   string        `json:"collision_mode" yaml:"collision_mode"`
+	Bucket          string              `json:"bucket" yaml:"bucket"`
+	Path            string              `json:"path" yaml:"path"`
+	ContentType     string              `json:"content_type" yaml:"content_type"`
+	ContentEncoding string              `json:"content_encoding" yaml:"content_encoding"`
+	ChunkSize       int                 `json:"chunk_size" yaml:"chunk_size"`
+	MaxInFlight     int                 `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching        policy.OutputConfig `json:"batching" yaml:"batching"`
+	CollisionMode   string              `json:"collision_mode" yaml:"collision_mode"`
+	Proxy           proxy.Config        `json:"proxy" yaml:"proxy"`
 }
 
 // NewGCPCloudStorageConfig creates a new Config with default values.
@@ -42,7 +44,8 @@ func NewGCPCloudStorageConfig() GCPCloudStorageConfig {
 		ContentEncoding: "",
 		ChunkSize:       googleapi.DefaultUploadChunkSize,
 		MaxInFlight:     64,
-		Batching:        policy.NewConfig(),
+		Batching:        policy.NewOutputConfig(),
 		CollisionMode:   GCPCloudStorageOverwriteCollisionMode,
+		Proxy:           proxy.NewConfig(),
 	}
 }
@@ -46,7 +46,17 @@ pipeline:
 			docs.FieldString("topic", "The topic to publish to.").IsInterpolated(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldString("publish_timeout", "The maximum length of time to wait before abandoning a publish attempt for a message.", "10s", "5m", "60m").Advanced(),
-			docs.FieldString("ordering_key", "The ordering key to use for publishing messages.").IsInterpolated().Advanced(),
+			docs.FieldString("ordering_key", "The ordering key to use for publishing messages. Messages with the same ordering key are delivered in the order they were published when message ordering is enabled on the subscription. When a message fails to be published the publishing of its ordering key is resumed so that it can be retried.").IsInterpolated().Advanced(),
+			docs.FieldObject("flow_control", "Controls the number of messages buffered by the publisher before they are sent.").WithChildren(
+				docs.FieldInt("max_outstanding_messages", "The maximum number of messages to buffer, set to a value of zero or below in order to remove the limit."),
+				docs.FieldInt("max_outstanding_bytes", "The maximum size in bytes of messages to buffer, set to a value of zero or below in order to remove the limit."),
+				docs.FieldString("limit_exceeded_behavior", "The behavior when the limits are exceeded.").HasAnnotatedOptions(
+					"ignore", "Ignore the limits.",
+					"block", "Block publishing until buffered messages have been sent.",
+					"signal_error", "Fail publishing the message, which will then be retried.",
+				),
+			).Advanced(),
+			docs.FieldString("compression", "The compression of requests to the Pub/Sub service.").HasOptions("none", "gzip").Advanced(),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are sent as attributes.").WithChildren(metadata.ExcludeFilterFields()...),
 		),
 		Categories: []string{
@@ -31,7 +31,7 @@ described [here](/docs/configuration/interpolation#bloblang-queries).`,
 				`${!count("files")}-${!timestamp_unix_nano()}.txt`,
 			).IsInterpolated(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		),
 		Categories: []string{
 			"Services",
@@ -34,14 +34,27 @@ behaviour can be disabled by setting the field ` + "[`batch_as_multipart`](#batc
 It's possible to propagate the response from each HTTP request back to the input
 source by setting ` + "`propagate_response` to `true`" + `. Only inputs that
 support [synchronous responses](/docs/guides/sync_responses) are able to make use of
-these propagated responses.`,
+these propagated responses.
+
+### Idempotency
+
+Requests that are retried, either due to a failed attempt or because the message
+is redelivered, may result in duplicates when the server processed an attempt
+that appeared to fail. Servers that support idempotency keys can prevent this
+when given a key that is consistent across attempts, which can be sent with the
+field ` + "[`idempotency.key`](#idempotencykey)" + `. The mapping should
+resolve the same key for a redelivered message, such as from an ID field of the
+message or from a hash computed with the ` + "[`content_hash` processor](/docs/components/processors/content_hash)" + `.
+
+Servers that reject a key that was already used can have those responses treated
+as successes with the fields ` + "`idempotency.already_processed_codes` and `idempotency.already_processed_body`" + `.`,
 		Async:   true,
 		Batches: true,
 		Config: ihttpdocs.ClientFieldSpec(true,
 			docs.FieldBool("batch_as_multipart", "Send message batches as a single request using [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html). If disabled messages in batches will be sent as individual requests.").Advanced(),
 			docs.FieldBool("propagate_response", "Whether responses from the server should be [propagated back](/docs/guides/sync_responses) to the input.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 			docs.FieldObject(
 				"multipart", "EXPERIMENTAL: Create explicit multipart HTTP requests by specifying an array of parts to add to the request, each part specified consists of content headers and a data field that can be populated dynamically. If this field is populated it will override the default request creation behaviour.",
 			).Array().Advanced().HasDefault([]interface{}{}).WithChildren(
@@ -49,6 +62,14 @@ these propagated responses.`,
 				docs.FieldInterpolatedString("content_disposition", "The content disposition of the individual message part.", `form-data; name="bin"; filename='${! meta("AttachmentName") }`).HasDefault(""),
 				docs.FieldInterpolatedString("body", "The body of the individual message part.", `${! json("data.part1") }`).HasDefault(""),
 			).AtVersion("3.63.0"),
+			docs.FieldObject(
+				"idempotency", "Options for sending an idempotency key with each request, and for treating responses that indicate a request was already processed as successful.",
+			).Advanced().WithChildren(
+				docs.FieldBloblang("key", "A [Bloblang mapping](/docs/guides/bloblang/about) that resolves an idempotency key for each request. The mapping is executed once for each request against its first message, and the key is reused for all retries of that request. When empty an idempotency key is not sent.", `meta("content_hash")`, `this.id`).HasDefault(""),
+				docs.FieldString("header", "The header to send the idempotency key within.").HasDefault("Idempotency-Key"),
+				docs.FieldInt("already_processed_codes", "A list of status codes that, for requests that would otherwise fail, indicate that the request has already been processed by the server. When `already_processed_body` is also set both must match.", []int{409}).Array().HasDefault([]interface{}{}),
+				docs.FieldString("already_processed_body", "A regular expression that, for requests that would otherwise fail, indicates that the request has already been processed by the server when it matches the response body. When `already_processed_codes` is also set both must match.", "already processed").HasDefault(""),
+			),
 		),
 		Categories: []string{
 			"Network",
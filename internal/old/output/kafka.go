@@ -36,6 +36,20 @@ You must also ensure that failed batches are never rerouted back to the same out
 
 However, this also means that manual intervention will eventually be required in cases where the batch cannot be sent due to configuration problems such as an incorrect ` + "`max_msg_bytes`" + ` estimate. A less strict but automated alternative would be to route failed batches to a dead letter queue using a ` + "[`fallback` broker](/docs/components/outputs/fallback)" + `, but this would allow subsequent batches to be delivered in the meantime whilst those failed batches are dealt with.
 
+### Throughput
+
+Messages of each batch are sent to the brokers as soon as possible by default, and therefore the size of requests is determined by the [batching policy](#batching). When the output is configured with a ` + "`max_in_flight`" + ` larger than ` + "`1`" + ` the field ` + "`linger`" + ` can be used in order to combine messages of concurrent batches into larger requests, which improves both the throughput and compression ratio of small messages without holding back the acknowledgement of batches for longer than the ` + "`linger`" + ` period.
+
+### Metrics
+
+This output emits the following metrics in addition to the standard output metrics:
+
+` + "``` text" + `
+- kafka_produce_latency_ns: A timer of the time taken for each batch of messages to be acknowledged by the brokers.
+- kafka_produce_queue_depth: A gauge of the number of messages currently awaiting acknowledgement from the brokers.
+- kafka_broker_connected: A gauge set to 1 for each broker the client is connected to and 0 otherwise, labelled by broker.
+` + "```" + `
+
 ### Troubleshooting
 
 If you're seeing issues writing to or reading from Kafka with this component then it's worth trying out the newer ` + "[`kafka_franz` output](/docs/components/outputs/kafka_franz)" + `.
@@ -57,6 +71,9 @@ Unfortunately this error message will appear for a wide range of connection prob
 			docs.FieldString("partitioner", "The partitioning algorithm to use.").HasOptions("fnv1a_hash", "murmur2_hash", "random", "round_robin", "manual"),
 			docs.FieldString("partition", "The manually-specified partition to publish messages to, relevant only when the field `partitioner` is set to `manual`. Must be able to parse as a 32-bit integer.").IsInterpolated().Advanced(),
 			docs.FieldString("compression", "The compression algorithm to use.").HasOptions("none", "snappy", "lz4", "gzip", "zstd"),
+			docs.FieldInt("compression_level", "The level of compression to use with the algorithms `gzip`, `lz4` and `zstd`, where higher levels produce smaller requests at the cost of more CPU. Set to `-1` in order to use the default level of the algorithm.", 3, 9).AtVersion("4.0.0").Advanced(),
+			docs.FieldString("linger", "An optional period of time to wait for further messages before sending a request to a broker, allowing messages of concurrent batches to be combined into larger requests. This is equivalent to the `linger.ms` setting of the Java client.", "5ms", "100ms").AtVersion("4.0.0").Advanced(),
+			docs.FieldInt("batch_bytes", "The best-effort number of bytes of messages awaiting a request to a broker at which the request is sent before the `linger` period elapses, equivalent to the `batch.size` setting of the Java client. Set to `0` in order to only send requests once the `linger` period elapses. Requires `linger` to be set.", 1048576).AtVersion("4.0.0").Advanced(),
 			docs.FieldString("static_headers", "An optional map of static headers that should be added to messages in addition to metadata.", map[string]string{"first-static-header": "value-1", "second-static-header": "value-2"}).Map(),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are sent with messages as headers.").WithChildren(metadata.ExcludeFilterFields()...),
 			output.InjectTracingSpanMappingDocs,
@@ -65,7 +82,7 @@ Unfortunately this error message will appear for a wide range of connection prob
 			docs.FieldInt("max_msg_bytes", "The maximum size in bytes of messages sent to the target topic.").Advanced(),
 			docs.FieldString("timeout", "The maximum period of time to wait for message sends before abandoning the request and retrying.").Advanced(),
 			docs.FieldBool("retry_as_batch", "When enabled forces an entire batch of messages to be retried if any individual message fails on a send, otherwise only the individual messages that failed are retried. Disabling this helps to reduce message duplicates during intermittent errors, but also makes it impossible to guarantee strict ordering of messages.").Advanced(),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		).WithChildren(retries.FieldSpecs()...),
 		Categories: []string{
 			"Services",
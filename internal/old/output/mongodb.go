@@ -18,10 +18,10 @@ type MongoDBConfig struct {
 	HintMap     string `json:"hint_map" yaml:"hint_map"`
 
 	// DeleteEmptyValue bool `json:"delete_empty_value" yaml:"delete_empty_value"`
-	Upsert      bool           `json:"upsert" yaml:"upsert"`
-	MaxInFlight int            `json:"max_in_flight" yaml:"max_in_flight"`
-	RetryConfig retries.Config `json:",inline" yaml:",inline"`
-	Batching    policy.Config  `json:"batching" yaml:"batching"`
+	Upsert      bool                `json:"upsert" yaml:"upsert"`
+	MaxInFlight int                 `json:"max_in_flight" yaml:"max_in_flight"`
+	RetryConfig retries.Config      `json:",inline" yaml:",inline"`
+	Batching    policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewMongoDBConfig creates a MongoDB populated with default values.
@@ -37,7 +37,7 @@ func NewMongoDBConfig() MongoDBConfig {
 		Operation:    "update-one",
 		MaxInFlight:  64,
 		RetryConfig:  rConf,
-		Batching:     policy.NewConfig(),
+		Batching:     policy.NewOutputConfig(),
 		WriteConcern: client.WriteConcern{},
 	}
 }
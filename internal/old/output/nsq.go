@@ -20,11 +20,14 @@ Publish to an NSQ topic.`,
 		Description: `
 The ` + "`topic`" + ` field can be dynamically set using function interpolations
 described [here](/docs/configuration/interpolation#bloblang-queries). When sending
-batched messages these interpolations are performed per message part.`,
+batched messages these interpolations are performed per message part.
+
+Messages can be published with a delay by setting the field ` + "`defer`" + ` to a duration, after which nsqd delivers them to consumers. Since the delay is interpolated per message it can be derived from the contents or metadata of each message.`,
 		Async: true,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("nsqd_tcp_address", "The address of the target NSQD server."),
 			docs.FieldString("topic", "The topic to publish to.").IsInterpolated(),
+			docs.FieldString("defer", "An optional duration to delay the delivery of messages by, up to the maximum delay configured on nsqd with `--max-req-timeout`, which is one hour by default. Messages are delivered immediately when empty or when the duration is zero.", "30s", `${! meta("retry_delay") }`).IsInterpolated().AtVersion("4.0.0").Advanced(),
 			docs.FieldString("user_agent", "A user agent string to connect with."),
 			tls.FieldSpec(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
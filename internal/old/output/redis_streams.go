@@ -37,7 +37,7 @@ a metadata item and the body then the body takes precedence.`,
 			docs.FieldInt("max_length", "When greater than zero enforces a rough cap on the length of the target stream."),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are included in the message body.").WithChildren(metadata.ExcludeFilterFields()...),
-			policy.FieldSpec(),
+			policy.OutputFieldSpec(),
 		),
 		Categories: []string{
 			"Services",
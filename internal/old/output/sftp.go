@@ -2,6 +2,8 @@ package output
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -46,6 +48,14 @@ func init() {
 		Description: `
 In order to have a different path for each object you should use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries).
 
+### Connection Pooling
+
+Files written with codecs that close the file after each write (such as ` + "`all-bytes`" + `) are uploaded over a pool of up to ` + "`max_connections`" + ` connections, which are created lazily and reused across writes. Codecs that append to files keep a single file handle open and therefore use a single connection.
+
+### Atomic Uploads
+
+When ` + "`atomic_upload`" + ` is enabled each file is first written to a temporary file within the same directory, named after the target file with a random suffix ending in ` + "`.tmp`" + `, which is then renamed to the target path once the upload completes. This ensures that partially written files are never observed by consumers of the server. Atomic uploads can only be used with codecs that close the file after each write.
+
 ` + multipartCodecDoc,
 		Async: true,
 		Config: docs.FieldComponent().WithChildren(
@@ -63,6 +73,8 @@ In order to have a different path for each object you should use function interp
 				"The credentials to use to log into the server.",
 			).WithChildren(sftpSetup.CredentialsDocs()...),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldInt("max_connections", "The maximum number of connections to open to the server for uploading files concurrently.").AtVersion("4.0.0").Advanced(),
+			docs.FieldBool("atomic_upload", "Whether to upload each file to a temporary path before renaming it to the target path, ensuring that partially written files are never observed.").AtVersion("4.0.0").Advanced(),
 		),
 		Categories: []string{
 			"Network",
@@ -74,11 +86,13 @@ In order to have a different path for each object you should use function interp
 
 // SFTPConfig contains configuration fields for the SFTP output type.
 type SFTPConfig struct {
-	Address     string                `json:"address" yaml:"address"`
-	Path        string                `json:"path" yaml:"path"`
-	Codec       string                `json:"codec" yaml:"codec"`
-	Credentials sftpSetup.Credentials `json:"credentials" yaml:"credentials"`
-	MaxInFlight int                   `json:"max_in_flight" yaml:"max_in_flight"`
+	Address        string                `json:"address" yaml:"address"`
+	Path           string                `json:"path" yaml:"path"`
+	Codec          string                `json:"codec" yaml:"codec"`
+	Credentials    sftpSetup.Credentials `json:"credentials" yaml:"credentials"`
+	MaxInFlight    int                   `json:"max_in_flight" yaml:"max_in_flight"`
+	MaxConnections int                   `json:"max_connections" yaml:"max_connections"`
+	AtomicUpload   bool                  `json:"atomic_upload" yaml:"atomic_upload"`
 }
 
 // NewSFTPConfig creates a new Config with default values.
@@ -91,15 +105,15 @@ func NewSFTPConfig() SFTPConfig {
 			Username: "",
 			Password: "",
 		},
-		MaxInFlight: 64,
+		MaxInFlight:    64,
+		MaxConnections: 1,
+		AtomicUpload:   false,
 	}
 }
 
 type sftpWriter struct {
 	conf SFTPConfig
 
-	client *sftp.Client
-
 	log   log.Modular
 	stats metrics.Type
 
@@ -107,9 +121,12 @@ type sftpWriter struct {
 	codec     codec.WriterConstructor
 	codecConf codec.WriterConfig
 
-	handleMut  sync.Mutex
-	handlePath string
-	handle     codec.Writer
+	pool *sftpSetup.ClientPool
+
+	handleMut    sync.Mutex
+	handleClient *sftp.Client
+	handlePath   string
+	handle       codec.Writer
 }
 
 func newSFTPWriter(
@@ -128,10 +145,16 @@ func newSFTPWriter(
 	if s.codec, s.codecConf, err = codec.GetWriter(conf.Codec); err != nil {
 		return nil, err
 	}
+	if conf.AtomicUpload && !s.codecConf.CloseAfter {
+		return nil, fmt.Errorf("atomic uploads cannot be used with codec %v as it appends to files", conf.Codec)
+	}
+	if conf.MaxConnections < 1 {
+		return nil, errors.New("max_connections must be at least 1")
+	}
+
 	if s.path, err = mgr.BloblEnvironment().NewField(conf.Path); err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %w", err)
 	}
-
 	return s, nil
 }
 
@@ -140,75 +163,197 @@ func (s *sftpWriter) ConnectWithContext(ctx context.Context) error {
 	s.handleMut.Lock()
 	defer s.handleMut.Unlock()
 
-	if s.client != nil {
+	if s.pool != nil {
 		return nil
 	}
 
-	var err error
-	s.client, err = s.conf.Credentials.GetClient(s.conf.Address)
-	return err
+	pool := sftpSetup.NewClientPool(s.conf.MaxConnections, func() (*sftp.Client, error) {
+		return s.conf.Credentials.GetClient(s.conf.Address)
+	})
+
+	// Establish the first connection eagerly so that connection errors are
+	// surfaced here rather than during writes.
+	client, err := pool.Acquire(ctx)
+	if err != nil {
+		pool.Close()
+		return err
+	}
+	pool.Release(client, nil)
+
+	s.pool = pool
+	return nil
 }
 
 // WriteWithContext attempts to write message contents to a target file via an SFTP connection.
 func (s *sftpWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
 	s.handleMut.Lock()
-	client := s.client
+	pool := s.pool
 	s.handleMut.Unlock()
-	if client == nil {
+
+	if pool == nil {
 		return component.ErrNotConnected
 	}
 
 	return writer.IterateBatchedSend(msg, func(i int, p *message.Part) error {
 		path := s.path.String(i, msg)
-
-		s.handleMut.Lock()
-		defer s.handleMut.Unlock()
-
-		if s.handle != nil && path == s.handlePath {
-			// TODO: Detect underlying connection failure here and drop client.
-			return s.handle.Write(ctx, p)
+		if s.codecConf.CloseAfter {
+			return s.writeFile(ctx, pool, path, p)
 		}
-		if s.handle != nil {
-			if err := s.handle.Close(ctx); err != nil {
-				return err
-			}
+		return s.writeToHandle(ctx, pool, path, p)
+	})
+}
+
+// writeFile uploads a message as the full contents of a file using a client
+// from the pool, optionally via a temporary file that is renamed once the
+// upload is complete.
+func (s *sftpWriter) writeFile(ctx context.Context, pool *sftpSetup.ClientPool, path string, p *message.Part) (err error) {
+	client, err := pool.Acquire(ctx)
+	if err != nil {
+		if errors.Is(err, sftpSetup.ErrPoolClosed) {
+			return component.ErrNotConnected
 		}
+		return err
+	}
+	defer func() {
+		pool.Release(client, err)
+	}()
+
+	if err = client.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	writePath := path
+	if s.conf.AtomicUpload {
+		writePath = sftpTempPath(path)
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY
+	if s.codecConf.Truncate {
+		flag |= os.O_TRUNC
+	}
 
-		flag := os.O_CREATE | os.O_WRONLY
-		if s.codecConf.Append {
-			flag |= os.O_APPEND
+	var file *sftp.File
+	if file, err = client.OpenFile(writePath, flag); err != nil {
+		return err
+	}
+
+	var handle codec.Writer
+	if handle, err = s.codec(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err = handle.Write(ctx, p); err != nil {
+		handle.Close(ctx)
+		if writePath != path {
+			_ = client.Remove(writePath)
 		}
-		if s.codecConf.Truncate {
-			flag |= os.O_TRUNC
+		return err
+	}
+	if err = handle.Close(ctx); err != nil {
+		if writePath != path {
+			_ = client.Remove(writePath)
 		}
+		return err
+	}
 
-		if err := s.client.MkdirAll(filepath.Dir(path)); err != nil {
-			return err
+	if writePath != path {
+		if err = sftpRenameOverwrite(client, writePath, path); err != nil {
+			_ = client.Remove(writePath)
+			return fmt.Errorf("failed to rename temporary file %v to %v: %w", writePath, path, err)
 		}
+	}
+	return nil
+}
 
-		file, err := s.client.OpenFile(path, flag)
-		if err != nil {
+// writeToHandle writes a message to a file handle that is kept open between
+// writes to the same path, which is required by codecs that append to files.
+func (s *sftpWriter) writeToHandle(ctx context.Context, pool *sftpSetup.ClientPool, path string, p *message.Part) (err error) {
+	s.handleMut.Lock()
+	defer s.handleMut.Unlock()
+
+	if s.handleClient == nil {
+		if s.handleClient, err = pool.Acquire(ctx); err != nil {
+			s.handleClient = nil
+			if errors.Is(err, sftpSetup.ErrPoolClosed) {
+				return component.ErrNotConnected
+			}
 			return err
 		}
+	}
+	defer func() {
+		if sftpSetup.IsConnectionError(err) {
+			if s.handle != nil {
+				s.handle.Close(ctx)
+				s.handle = nil
+			}
+			pool.Release(s.handleClient, err)
+			s.handleClient = nil
+			err = component.ErrNotConnected
+		}
+	}()
 
-		s.handlePath = path
-		handle, err := s.codec(file)
+	if s.handle != nil && path == s.handlePath {
+		return s.handle.Write(ctx, p)
+	}
+	if s.handle != nil {
+		err = s.handle.Close(ctx)
+		s.handle = nil
 		if err != nil {
 			return err
 		}
+	}
 
-		if err = handle.Write(ctx, p); err != nil {
-			handle.Close(ctx)
-			return err
-		}
+	flag := os.O_CREATE | os.O_WRONLY
+	if s.codecConf.Append {
+		flag |= os.O_APPEND
+	}
+	if s.codecConf.Truncate {
+		flag |= os.O_TRUNC
+	}
 
-		if !s.codecConf.CloseAfter {
-			s.handle = handle
-		} else {
-			handle.Close(ctx)
-		}
+	if err = s.handleClient.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	var file *sftp.File
+	if file, err = s.handleClient.OpenFile(path, flag); err != nil {
+		return err
+	}
+
+	var handle codec.Writer
+	if handle, err = s.codec(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err = handle.Write(ctx, p); err != nil {
+		handle.Close(ctx)
+		return err
+	}
+
+	s.handlePath = path
+	s.handle = handle
+	return nil
+}
+
+// sftpTempPath returns a unique temporary path within the same directory as
+// the target path.
+func sftpTempPath(path string) string {
+	var suffix [6]byte
+	_, _ = rand.Read(suffix[:])
+	return fmt.Sprintf("%v.%x.tmp", path, suffix)
+}
+
+// sftpRenameOverwrite renames a file, replacing the target if it already
+// exists. The posix-rename extension is used when the server supports it,
+// otherwise the target is removed before a standard rename.
+func sftpRenameOverwrite(client *sftp.Client, from, to string) error {
+	if err := client.PosixRename(from, to); err == nil {
 		return nil
-	})
+	}
+	if err := client.Remove(to); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return client.Rename(from, to)
 }
 
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
@@ -219,9 +364,13 @@ func (s *sftpWriter) CloseAsync() {
 			s.handle.Close(context.Background())
 			s.handle = nil
 		}
-		if s.client != nil {
-			s.client.Close()
-			s.client = nil
+		if s.handleClient != nil {
+			s.pool.Release(s.handleClient, nil)
+			s.handleClient = nil
+		}
+		if s.pool != nil {
+			s.pool.Close()
+			s.pool = nil
 		}
 		s.handleMut.Unlock()
 	}()
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sftpSetup "github.com/benthosdev/benthos/v4/internal/impl/sftp/shared"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func memSFTPPool(t testing.TB, size int) (*sftpSetup.ClientPool, *sftp.Client) {
+	t.Helper()
+
+	handlers := sftp.InMemHandler()
+	dial := func() (*sftp.Client, error) {
+		serverConn, clientConn := net.Pipe()
+		server := sftp.NewRequestServer(serverConn, handlers)
+		go func() {
+			_ = server.Serve()
+		}()
+		t.Cleanup(func() {
+			server.Close()
+		})
+		return sftp.NewClientPipe(clientConn, clientConn)
+	}
+
+	inspector, err := dial()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		inspector.Close()
+	})
+
+	pool := sftpSetup.NewClientPool(size, dial)
+	t.Cleanup(pool.Close)
+	return pool, inspector
+}
+
+func readSFTPFile(t testing.TB, client *sftp.Client, path string) string {
+	t.Helper()
+
+	f, err := client.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestSFTPWriterAtomicUpload(t *testing.T) {
+	conf := NewSFTPConfig()
+	conf.Path = `/foo/${! meta("name") }.txt`
+	conf.AtomicUpload = true
+	conf.MaxConnections = 2
+
+	w, err := newSFTPWriter(conf, mock.NewManager(), log.Noop(), nil)
+	require.NoError(t, err)
+
+	var inspector *sftp.Client
+	w.pool, inspector = memSFTPPool(t, conf.MaxConnections)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	msg := message.QuickBatch([][]byte{[]byte("hello world")})
+	msg.Get(0).MetaSet("name", "a")
+	require.NoError(t, w.WriteWithContext(ctx, msg))
+
+	msg = message.QuickBatch([][]byte{[]byte("overwritten")})
+	msg.Get(0).MetaSet("name", "a")
+	require.NoError(t, w.WriteWithContext(ctx, msg))
+
+	msg = message.QuickBatch([][]byte{[]byte("second")})
+	msg.Get(0).MetaSet("name", "b")
+	require.NoError(t, w.WriteWithContext(ctx, msg))
+
+	assert.Equal(t, "overwritten", readSFTPFile(t, inspector, "/foo/a.txt"))
+	assert.Equal(t, "second", readSFTPFile(t, inspector, "/foo/b.txt"))
+
+	infos, err := inspector.ReadDir("/foo")
+	require.NoError(t, err)
+
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+		assert.False(t, strings.HasSuffix(info.Name(), ".tmp"), info.Name())
+	}
+	assert.ElementsMatch(t, []string{"a.txt", "b.txt"}, names)
+}
+
+func TestSFTPWriterAppendCodec(t *testing.T) {
+	conf := NewSFTPConfig()
+	conf.Path = `/foo/bar.txt`
+	conf.Codec = "lines"
+
+	w, err := newSFTPWriter(conf, mock.NewManager(), log.Noop(), nil)
+	require.NoError(t, err)
+
+	var inspector *sftp.Client
+	w.pool, inspector = memSFTPPool(t, 1)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, w.WriteWithContext(ctx, message.QuickBatch([][]byte{[]byte("first")})))
+	require.NoError(t, w.WriteWithContext(ctx, message.QuickBatch([][]byte{[]byte("second")})))
+
+	w.CloseAsync()
+	require.NoError(t, w.WaitForClose(time.Second))
+
+	assert.Eventually(t, func() bool {
+		f, err := inspector.Open("/foo/bar.txt")
+		if err != nil {
+			return false
+		}
+		defer f.Close()
+		b, _ := io.ReadAll(f)
+		return string(b) == "first\nsecond\n"
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestSFTPWriterConfigErrors(t *testing.T) {
+	conf := NewSFTPConfig()
+	conf.Path = "/foo.txt"
+	conf.Codec = "lines"
+	conf.AtomicUpload = true
+
+	_, err := newSFTPWriter(conf, mock.NewManager(), log.Noop(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "atomic uploads cannot be used")
+
+	conf = NewSFTPConfig()
+	conf.Path = "/foo.txt"
+	conf.MaxConnections = 0
+
+	_, err = newSFTPWriter(conf, mock.NewManager(), log.Noop(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "max_connections")
+}
@@ -16,13 +16,17 @@ func init() {
 	Constructors[TypeSocket] = TypeSpec{
 		constructor: fromSimpleConstructor(NewSocket),
 		Summary: `
-Connects to a (tcp/udp/unix) server and sends a continuous stream of data, dividing messages according to the specified codec.`,
-		Description: multipartCodecDoc,
+Connects to a (tcp/udp/unix/unixgram) server and sends a continuous stream of data, dividing messages according to the specified codec.`,
+		Description: multipartCodecDoc + `
+
+### Unix Sockets
+
+The networks ` + "`unix`" + ` and ` + "`unixgram`" + ` connect to a stream and datagram unix domain socket respectively. On Linux an address beginning with ` + "`@`" + ` refers to a socket within the abstract namespace.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("network", "The network type to connect as.").HasOptions(
-				"unix", "tcp", "udp",
+				"unix", "unixgram", "tcp", "udp",
 			),
-			docs.FieldString("address", "The address (or path) to connect to.", "/tmp/benthos.sock", "localhost:9000"),
+			docs.FieldString("address", "The address (or path) to connect to.", "/tmp/benthos.sock", "@benthos", "localhost:9000"),
 			codec.WriterDocs,
 		),
 		Categories: []string{
@@ -1,8 +1,15 @@
 package output
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/codec"
@@ -12,6 +19,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	imetadata "github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 )
@@ -44,9 +52,35 @@ func init() {
 		constructor: fromSimpleConstructor(NewSTDOUT),
 		Summary: `
 Prints messages to stdout as a continuous stream of data, dividing messages according to the specified codec.`,
-		Description: multipartCodecDoc,
+		Description: multipartCodecDoc + `
+
+## Sidecars
+
+When the output of Benthos is consumed by another agent, such as a log shipper tailing the stdout of a container, the ` + "`envelope`" + ` field can be used in order to wrap each message in a JSON object along with selected metadata. The contents of a message are embedded within the envelope as-is when they are valid JSON, and as a string otherwise, where messages are then written as a single line when using the ` + "`lines`" + ` codec:
+
+` + "```json" + `
+{"message":{"id":"foo"},"metadata":{"kafka_topic":"bar"}}
+` + "```" + `
+
+Each message, including its delimiter, is written with a single write call, and therefore lines are never interleaved with the writes of other processes sharing the same file, or split when a file opened for appending is rotated.`,
 		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString(
+				"target", "The destination to write messages to, which can be `stdout`, `stderr`, or the number of an open file descriptor inherited from the parent process.",
+				"stdout", "stderr", "3",
+			).AtVersion("4.0.0"),
 			codec.WriterDocs.AtVersion("3.46.0"),
+			docs.FieldObject(
+				"envelope", "Wrap each message in a JSON object containing the message under the key `message` and selected metadata under the key `metadata`.",
+			).WithChildren(
+				docs.FieldBool("enabled", "Whether to wrap messages in an envelope."),
+				docs.FieldObject("metadata", "Specify criteria for which metadata values are added to the envelope.").WithChildren(imetadata.IncludeFilterDocs()...),
+			).AtVersion("4.0.0"),
+			docs.FieldBool(
+				"sync", "Whether to flush each write to the underlying storage with an fsync before it is acknowledged. This has no effect when the target is a pipe or terminal.",
+			).AtVersion("4.0.0").Advanced(),
+			docs.FieldInt(
+				"max_bytes_per_second", "The maximum number of bytes written per second, where writes are delayed in order to not exceed the limit. Set to `0` in order to disable the limit.",
+			).AtVersion("4.0.0").Advanced(),
 		),
 		Categories: []string{
 			"Local",
@@ -58,13 +92,31 @@ Prints messages to stdout as a continuous stream of data, dividing messages acco
 
 // STDOUTConfig contains configuration fields for the stdout based output type.
 type STDOUTConfig struct {
-	Codec string `json:"codec" yaml:"codec"`
+	Target            string               `json:"target" yaml:"target"`
+	Codec             string               `json:"codec" yaml:"codec"`
+	Envelope          STDOUTEnvelopeConfig `json:"envelope" yaml:"envelope"`
+	Sync              bool                 `json:"sync" yaml:"sync"`
+	MaxBytesPerSecond int                  `json:"max_bytes_per_second" yaml:"max_bytes_per_second"`
+}
+
+// STDOUTEnvelopeConfig contains configuration fields for wrapping messages
+// written by the stdout output in a JSON envelope.
+type STDOUTEnvelopeConfig struct {
+	Enabled  bool                          `json:"enabled" yaml:"enabled"`
+	Metadata imetadata.IncludeFilterConfig `json:"metadata" yaml:"metadata"`
 }
 
 // NewSTDOUTConfig creates a new STDOUTConfig with default values.
 func NewSTDOUTConfig() STDOUTConfig {
 	return STDOUTConfig{
-		Codec: "lines",
+		Target: "stdout",
+		Codec:  "lines",
+		Envelope: STDOUTEnvelopeConfig{
+			Enabled:  false,
+			Metadata: imetadata.NewIncludeFilterConfig(),
+		},
+		Sync:              false,
+		MaxBytesPerSecond: 0,
 	}
 }
 
@@ -72,7 +124,7 @@ func NewSTDOUTConfig() STDOUTConfig {
 
 // NewSTDOUT creates a new STDOUT output type.
 func NewSTDOUT(conf Config, mgr interop.Manager, log log.Modular, stats metrics.Type) (output.Streamed, error) {
-	f, err := newStdoutWriter(conf.STDOUT.Codec, log, stats)
+	f, err := newStdoutWriter(conf.STDOUT, log, stats)
 	if err != nil {
 		return nil, err
 	}
@@ -86,26 +138,170 @@ func NewSTDOUT(conf Config, mgr interop.Manager, log log.Modular, stats metrics.
 	return w, nil
 }
 
+// stdoutTarget returns the file that a target of the stdout output refers to.
+func stdoutTarget(target string) (*os.File, error) {
+	switch target {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	fd, err := strconv.ParseUint(target, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("target '%v' is not stdout, stderr or a file descriptor", target)
+	}
+	f := os.NewFile(uintptr(fd), "fd"+target)
+	if _, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("file descriptor %v is not open: %w", target, err)
+	}
+	return f, nil
+}
+
+// stdoutFrame buffers the writes of a codec in order that each message,
+// including its delimiter, is written to the target with a single call.
+type stdoutFrame struct {
+	buf    bytes.Buffer
+	w      io.Writer
+	syncFn func() error
+
+	bytesPerSec float64
+	nextWrite   time.Time
+	nowFn       func() time.Time
+}
+
+func (f *stdoutFrame) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+// Close does nothing as the target is shared with the rest of the process.
+func (f *stdoutFrame) Close() error {
+	return nil
+}
+
+// throttle blocks until n bytes can be written without exceeding the byte rate
+// limit.
+func (f *stdoutFrame) throttle(ctx context.Context, n int) error {
+	if f.bytesPerSec <= 0 {
+		return nil
+	}
+
+	now := f.nowFn()
+	if f.nextWrite.Before(now) {
+		f.nextWrite = now
+	}
+	wait := f.nextWrite.Sub(now)
+	f.nextWrite = f.nextWrite.Add(time.Duration(float64(n) / f.bytesPerSec * float64(time.Second)))
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// flush writes the buffered bytes to the target.
+func (f *stdoutFrame) flush(ctx context.Context) error {
+	if f.buf.Len() == 0 {
+		return nil
+	}
+	defer f.buf.Reset()
+
+	if err := f.throttle(ctx, f.buf.Len()); err != nil {
+		return err
+	}
+	if _, err := f.w.Write(f.buf.Bytes()); err != nil {
+		return err
+	}
+	if f.syncFn != nil {
+		// Pipes and terminals do not support fsync.
+		if err := f.syncFn(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTSUP) {
+			return err
+		}
+	}
+	return nil
+}
+
 type stdoutWriter struct {
-	handle  codec.Writer
-	shutSig *shutdown.Signaller
+	handle   codec.Writer
+	frame    *stdoutFrame
+	envelope *imetadata.IncludeFilter
+	shutSig  *shutdown.Signaller
 }
 
-func newStdoutWriter(codecStr string, log log.Modular, stats metrics.Type) (*stdoutWriter, error) {
-	codec, _, err := codec.GetWriter(codecStr)
+func newStdoutWriter(conf STDOUTConfig, log log.Modular, stats metrics.Type) (*stdoutWriter, error) {
+	target, err := stdoutTarget(conf.Target)
 	if err != nil {
 		return nil, err
 	}
+	if conf.MaxBytesPerSecond < 0 {
+		return nil, errors.New("max_bytes_per_second must not be negative")
+	}
+
+	frame := &stdoutFrame{
+		w:           target,
+		bytesPerSec: float64(conf.MaxBytesPerSecond),
+		nowFn:       time.Now,
+	}
+	if conf.Sync {
+		frame.syncFn = target.Sync
+	}
+	return newStdoutWriterFromFrame(conf, frame)
+}
 
-	handle, err := codec(os.Stdout)
+func newStdoutWriterFromFrame(conf STDOUTConfig, frame *stdoutFrame) (*stdoutWriter, error) {
+	codec, _, err := codec.GetWriter(conf.Codec)
 	if err != nil {
 		return nil, err
 	}
 
-	return &stdoutWriter{
+	handle, err := codec(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &stdoutWriter{
 		handle:  handle,
+		frame:   frame,
 		shutSig: shutdown.NewSignaller(),
-	}, nil
+	}
+	if conf.Envelope.Enabled {
+		if w.envelope, err = conf.Envelope.Metadata.CreateFilter(); err != nil {
+			return nil, fmt.Errorf("failed to construct envelope metadata filter: %w", err)
+		}
+	}
+	return w, nil
+}
+
+// wrap returns a message part containing the envelope of a message.
+func (w *stdoutWriter) wrap(p *message.Part) (*message.Part, error) {
+	var env struct {
+		Message  interface{}       `json:"message"`
+		Metadata map[string]string `json:"metadata"`
+	}
+
+	if raw := p.Get(); json.Valid(raw) {
+		env.Message = json.RawMessage(raw)
+	} else {
+		env.Message = string(raw)
+	}
+
+	env.Metadata = map[string]string{}
+	_ = w.envelope.Iter(p, func(k, v string) error {
+		env.Metadata[k] = v
+		return nil
+	})
+
+	envBytes, err := json.Marshal(env)
+	if err != nil {
+		return nil, err
+	}
+	return message.NewPart(envBytes), nil
 }
 
 func (w *stdoutWriter) ConnectWithContext(ctx context.Context) error {
@@ -114,17 +310,31 @@ func (w *stdoutWriter) ConnectWithContext(ctx context.Context) error {
 
 func (w *stdoutWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
 	err := writer.IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		return w.handle.Write(ctx, p)
+		if w.envelope != nil {
+			var err error
+			if p, err = w.wrap(p); err != nil {
+				return err
+			}
+		}
+		if err := w.handle.Write(ctx, p); err != nil {
+			w.frame.buf.Reset()
+			return err
+		}
+		// The delimiter of a batch is written along with its last message.
+		if msg.Len() > 1 && i == msg.Len()-1 {
+			return nil
+		}
+		return w.frame.flush(ctx)
 	})
-	if err != nil {
-		return err
-	}
 	if msg.Len() > 1 {
 		if w.handle != nil {
 			w.handle.EndBatch()
 		}
 	}
-	return nil
+	if flushErr := w.frame.flush(ctx); err == nil {
+		err = flushErr
+	}
+	return err
 }
 
 func (w *stdoutWriter) CloseAsync() {
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type stdoutRecorder struct {
+	writes []string
+}
+
+func (r *stdoutRecorder) Write(p []byte) (int, error) {
+	r.writes = append(r.writes, string(p))
+	return len(p), nil
+}
+
+func TestSTDOUTFraming(t *testing.T) {
+	rec := &stdoutRecorder{}
+	w, err := newStdoutWriterFromFrame(NewSTDOUTConfig(), &stdoutFrame{
+		w:     rec,
+		nowFn: time.Now,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, w.WriteWithContext(ctx, message.QuickBatch([][]byte{[]byte("foo")})))
+	require.NoError(t, w.WriteWithContext(ctx, message.QuickBatch([][]byte{
+		[]byte("bar"), []byte("baz"),
+	})))
+
+	assert.Equal(t, []string{"foo\n", "bar\n", "baz\n\n"}, rec.writes)
+}
+
+func TestSTDOUTEnvelope(t *testing.T) {
+	conf := NewSTDOUTConfig()
+	conf.Envelope.Enabled = true
+	conf.Envelope.Metadata.IncludePrefixes = []string{"kafka_"}
+
+	var buf bytes.Buffer
+	w, err := newStdoutWriterFromFrame(conf, &stdoutFrame{
+		w:     &buf,
+		nowFn: time.Now,
+	})
+	require.NoError(t, err)
+
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{
+  "id": "foo"
+}`),
+		[]byte("not json"),
+	})
+	msg.Get(0).MetaSet("kafka_topic", "bar")
+	msg.Get(0).MetaSet("other", "baz")
+
+	require.NoError(t, w.WriteWithContext(context.Background(), msg))
+	assert.Equal(t, `{"message":{"id":"foo"},"metadata":{"kafka_topic":"bar"}}
+{"message":"not json","metadata":{}}
+
+`, buf.String())
+}
+
+func TestSTDOUTRateLimit(t *testing.T) {
+	now := time.Unix(100, 0)
+	frame := &stdoutFrame{
+		w:           &bytes.Buffer{},
+		bytesPerSec: 1000,
+		nowFn: func() time.Time {
+			return now
+		},
+	}
+
+	ctx := context.Background()
+	require.NoError(t, frame.throttle(ctx, 500))
+	assert.Equal(t, now.Add(500*time.Millisecond), frame.nextWrite)
+
+	// The limit is reached and therefore the next write must wait.
+	tCtx, done := context.WithTimeout(ctx, time.Millisecond*10)
+	defer done()
+	require.Error(t, frame.throttle(tCtx, 500))
+	assert.Equal(t, now.Add(time.Second), frame.nextWrite)
+
+	// Idle time does not accumulate into a burst.
+	now = now.Add(time.Minute)
+	require.NoError(t, frame.throttle(ctx, 100))
+	assert.Equal(t, now.Add(100*time.Millisecond), frame.nextWrite)
+}
+
+func TestSTDOUTTarget(t *testing.T) {
+	_, err := stdoutTarget("stderr")
+	require.NoError(t, err)
+
+	_, err = stdoutTarget("nope")
+	require.Error(t, err)
+
+	_, err = stdoutTarget("9999")
+	require.Error(t, err)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/old/util/retries"
+	"github.com/benthosdev/benthos/v4/internal/proxy"
 	btls "github.com/benthosdev/benthos/v4/internal/tls"
 )
 
@@ -50,12 +51,13 @@ type ElasticsearchConfig struct {
 	Type            string               `json:"type" yaml:"type"`
 	Timeout         string               `json:"timeout" yaml:"timeout"`
 	TLS             btls.Config          `json:"tls" yaml:"tls"`
+	Proxy           proxy.Config         `json:"proxy" yaml:"proxy"`
 	Auth            auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
 	AWS             OptionalAWSConfig    `json:"aws" yaml:"aws"`
 	GzipCompression bool                 `json:"gzip_compression" yaml:"gzip_compression"`
 	MaxInFlight     int                  `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config  `json:",inline" yaml:",inline"`
-	Batching        policy.Config `json:"batching" yaml:"batching"`
+	Batching        policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewElasticsearchConfig creates a new ElasticsearchConfig with default values.
@@ -77,6 +79,7 @@ func NewElasticsearchConfig() ElasticsearchConfig {
 		Routing:     "",
 		Timeout:     "5s",
 		TLS:         btls.NewConfig(),
+		Proxy:       proxy.NewConfig(),
 		Auth:        auth.NewBasicAuthConfig(),
 		AWS: OptionalAWSConfig{
 			Enabled: false,
@@ -85,7 +88,7 @@ func NewElasticsearchConfig() ElasticsearchConfig {
 		GzipCompression: false,
 		MaxInFlight:     64,
 		Config:          rConf,
-		Batching:        policy.NewConfig(),
+		Batching:        policy.NewOutputConfig(),
 	}
 }
 
@@ -195,18 +198,16 @@ func (e *Elasticsearch) Connect() error {
 		))
 	}
 
+	transport, err := e.conf.Proxy.Transport()
+	if err != nil {
+		return err
+	}
 	if e.conf.TLS.Enabled {
-		opts = append(opts, elastic.SetHttpClient(&http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: e.tlsConf,
-			},
-			Timeout: e.timeout,
-		}))
-
-	} else {
-		opts = append(opts, elastic.SetHttpClient(&http.Client{
-			Timeout: e.timeout,
-		}))
+		transport.TLSClientConfig = e.tlsConf
+	}
+	httpClient := &http.Client{
+		Transport: transport,
+		Timeout:   e.timeout,
 	}
 
 	if e.conf.AWS.Enabled {
@@ -214,9 +215,9 @@ func (e *Elasticsearch) Connect() error {
 		if err != nil {
 			return err
 		}
-		signingClient := aws.NewV4SigningClient(tsess.Config.Credentials, e.conf.AWS.Region)
-		opts = append(opts, elastic.SetHttpClient(signingClient))
+		httpClient = aws.NewV4SigningClientWithHTTPClient(tsess.Config.Credentials, e.conf.AWS.Region, httpClient)
 	}
+	opts = append(opts, elastic.SetHttpClient(httpClient))
 
 	if e.conf.GzipCompression {
 		opts = append(opts, elastic.SetGzip(true))
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
@@ -20,6 +23,14 @@ import (
 
 //------------------------------------------------------------------------------
 
+// GCPPubSubFlowControlConfig contains configuration fields for the flow
+// control of messages buffered by the publisher.
+type GCPPubSubFlowControlConfig struct {
+	MaxOutstandingMessages int    `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
+	MaxOutstandingBytes    int    `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
+	LimitExceededBehavior  string `json:"limit_exceeded_behavior" yaml:"limit_exceeded_behavior"`
+}
+
 // GCPPubSubConfig contains configuration fields for the output GCPPubSub type.
 type GCPPubSubConfig struct {
 	ProjectID      string                       `json:"project" yaml:"project"`
@@ -28,6 +39,8 @@ type GCPPubSubConfig struct {
 	PublishTimeout string                       `json:"publish_timeout" yaml:"publish_timeout"`
 	Metadata       metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
 	OrderingKey    string                       `json:"ordering_key" yaml:"ordering_key"`
+	FlowControl    GCPPubSubFlowControlConfig   `json:"flow_control" yaml:"flow_control"`
+	Compression    string                       `json:"compression" yaml:"compression"`
 }
 
 // NewGCPPubSubConfig creates a new Config with default values.
@@ -39,7 +52,31 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 		PublishTimeout: "60s",
 		Metadata:       metadata.NewExcludeFilterConfig(),
 		OrderingKey:    "",
+		FlowControl: GCPPubSubFlowControlConfig{
+			MaxOutstandingMessages: pubsub.DefaultPublishSettings.FlowControlSettings.MaxOutstandingMessages,
+			MaxOutstandingBytes:    pubsub.DefaultPublishSettings.FlowControlSettings.MaxOutstandingBytes,
+			LimitExceededBehavior:  "ignore",
+		},
+		Compression: "none",
+	}
+}
+
+func (c GCPPubSubFlowControlConfig) settings() (pubsub.FlowControlSettings, error) {
+	settings := pubsub.FlowControlSettings{
+		MaxOutstandingMessages: c.MaxOutstandingMessages,
+		MaxOutstandingBytes:    c.MaxOutstandingBytes,
+	}
+	switch c.LimitExceededBehavior {
+	case "ignore":
+		settings.LimitExceededBehavior = pubsub.FlowControlIgnore
+	case "block":
+		settings.LimitExceededBehavior = pubsub.FlowControlBlock
+	case "signal_error":
+		settings.LimitExceededBehavior = pubsub.FlowControlSignalError
+	default:
+		return settings, fmt.Errorf("unrecognised limit exceeded behavior: %v", c.LimitExceededBehavior)
 	}
+	return settings, nil
 }
 
 //------------------------------------------------------------------------------
@@ -51,6 +88,7 @@ type GCPPubSub struct {
 
 	client         *pubsub.Client
 	publishTimeout time.Duration
+	flowControl    pubsub.FlowControlSettings
 	metaFilter     *metadata.ExcludeFilter
 
 	orderingEnabled bool
@@ -71,7 +109,19 @@ func NewGCPPubSubV2(
 	log log.Modular,
 	stats metrics.Type,
 ) (*GCPPubSub, error) {
-	client, err := pubsub.NewClient(context.Background(), conf.ProjectID)
+	var opts []option.ClientOption
+	switch conf.Compression {
+	case "none":
+	case "gzip":
+		opts = append(opts, option.WithGRPCDialOption(grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name))))
+	default:
+		return nil, fmt.Errorf("unrecognised compression: %v", conf.Compression)
+	}
+	flowControl, err := conf.FlowControl.settings()
+	if err != nil {
+		return nil, err
+	}
+	client, err := pubsub.NewClient(context.Background(), conf.ProjectID, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +147,7 @@ func NewGCPPubSubV2(
 		metaFilter:      metaFilter,
 		client:          client,
 		publishTimeout:  pubTimeout,
+		flowControl:     flowControl,
 		stats:           stats,
 		topicID:         topic,
 		orderingKey:     orderingKey,
@@ -137,6 +188,7 @@ func (c *GCPPubSub) getTopic(ctx context.Context, t string) (*pubsub.Topic, erro
 		return nil, fmt.Errorf("topic '%v' does not exist", t)
 	}
 	topic.PublishSettings.Timeout = c.publishTimeout
+	topic.PublishSettings.FlowControlSettings = c.flowControl
 	topic.EnableMessageOrdering = c.orderingEnabled
 	c.topics[t] = topic
 	return topic, nil
@@ -161,6 +213,7 @@ func (c *GCPPubSub) WriteWithContext(ctx context.Context, msg *message.Batch) er
 	}
 
 	results := make([]*pubsub.PublishResult, msg.Len())
+	orderingKeys := make([]string, msg.Len())
 	_ = msg.Iter(func(i int, part *message.Part) error {
 		topic := topics[i]
 		attr := map[string]string{}
@@ -173,6 +226,7 @@ func (c *GCPPubSub) WriteWithContext(ctx context.Context, msg *message.Batch) er
 		}
 		if c.orderingEnabled {
 			gmsg.OrderingKey = c.orderingKey.String(i, msg)
+			orderingKeys[i] = gmsg.OrderingKey
 		}
 		if len(attr) > 0 {
 			gmsg.Attributes = attr
@@ -188,6 +242,12 @@ func (c *GCPPubSub) WriteWithContext(ctx context.Context, msg *message.Batch) er
 				batchErr = batch.NewError(msg, err)
 			}
 			batchErr.Failed(i, err)
+
+			// Publishing is paused for an ordering key after an error, and
+			// therefore needs resuming in order for the message to be retried.
+			if orderingKeys[i] != "" {
+				topics[i].ResumePublish(orderingKeys[i])
+			}
 		}
 	}
 	if batchErr != nil {
@@ -0,0 +1,84 @@
+package writer_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "google.golang.org/genproto/googleapis/pubsub/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output/writer"
+)
+
+func TestGCPPubSubOrderingKeyResume(t *testing.T) {
+	srv := pstest.NewServer()
+	defer srv.Close()
+
+	require.NoError(t, os.Setenv("PUBSUB_EMULATOR_HOST", srv.Addr))
+	defer os.Unsetenv("PUBSUB_EMULATOR_HOST")
+
+	ctx := context.Background()
+
+	client, err := pubsub.NewClient(ctx, "foo")
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.CreateTopic(ctx, "bar")
+	require.NoError(t, err)
+
+	conf := writer.NewGCPPubSubConfig()
+	conf.ProjectID = "foo"
+	conf.TopicID = "bar"
+	conf.OrderingKey = `${! meta("key") }`
+	conf.Compression = "gzip"
+	conf.FlowControl.LimitExceededBehavior = "block"
+
+	w, err := writer.NewGCPPubSubV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, w.ConnectWithContext(ctx))
+	defer w.CloseAsync()
+
+	srv.SetAutoPublishResponse(false)
+	srv.AddPublishResponse(nil, status.Error(codes.InvalidArgument, "nope"))
+	srv.AddPublishResponse(&pb.PublishResponse{MessageIds: []string{"1"}}, nil)
+
+	newMsg := func() *message.Batch {
+		msg := message.QuickBatch([][]byte{[]byte("hello world")})
+		msg.Get(0).MetaSet("key", "baz")
+		return msg
+	}
+
+	err = w.WriteWithContext(ctx, newMsg())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nope")
+
+	// Without resuming the ordering key the retry would be rejected by the
+	// client.
+	require.NoError(t, w.WriteWithContext(ctx, newMsg()))
+}
+
+func TestGCPPubSubBadConfig(t *testing.T) {
+	conf := writer.NewGCPPubSubConfig()
+	conf.Compression = "nope"
+
+	_, err := writer.NewGCPPubSubV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised compression")
+
+	conf = writer.NewGCPPubSubConfig()
+	conf.FlowControl.LimitExceededBehavior = "nope"
+
+	_, err = writer.NewGCPPubSubV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised limit exceeded behavior")
+}
@@ -22,12 +22,12 @@ import (
 
 // HDFSConfig contains configuration fields for the HDFS output type.
 type HDFSConfig struct {
-	Hosts       []string      `json:"hosts" yaml:"hosts"`
-	User        string        `json:"user" yaml:"user"`
-	Directory   string        `json:"directory" yaml:"directory"`
-	Path        string        `json:"path" yaml:"path"`
-	MaxInFlight int           `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching    policy.Config `json:"batching" yaml:"batching"`
+	Hosts       []string            `json:"hosts" yaml:"hosts"`
+	User        string              `json:"user" yaml:"user"`
+	Directory   string              `json:"directory" yaml:"directory"`
+	Path        string              `json:"path" yaml:"path"`
+	MaxInFlight int                 `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching    policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewHDFSConfig creates a new Config with default values.
@@ -38,7 +38,7 @@ func NewHDFSConfig() HDFSConfig {
 		Directory:   "",
 		Path:        `${!count("files")}-${!timestamp_unix_nano()}.txt`,
 		MaxInFlight: 64,
-		Batching:    policy.NewConfig(),
+		Batching:    policy.NewOutputConfig(),
 	}
 }
 
@@ -2,7 +2,9 @@ package writer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
@@ -27,6 +29,27 @@ type HTTPClientMultipartExpression struct {
 	Body               string `json:"body" yaml:"body"`
 }
 
+// HTTPClientIdempotencyConfig contains configuration fields for sending
+// idempotency keys with requests and detecting requests that were already
+// processed.
+type HTTPClientIdempotencyConfig struct {
+	Key                   string `json:"key" yaml:"key"`
+	Header                string `json:"header" yaml:"header"`
+	AlreadyProcessedCodes []int  `json:"already_processed_codes" yaml:"already_processed_codes"`
+	AlreadyProcessedBody  string `json:"already_processed_body" yaml:"already_processed_body"`
+}
+
+// NewHTTPClientIdempotencyConfig creates a new HTTPClientIdempotencyConfig with
+// default values.
+func NewHTTPClientIdempotencyConfig() HTTPClientIdempotencyConfig {
+	return HTTPClientIdempotencyConfig{
+		Key:                   "",
+		Header:                "Idempotency-Key",
+		AlreadyProcessedCodes: []int{},
+		AlreadyProcessedBody:  "",
+	}
+}
+
 // HTTPClientConfig contains configuration fields for the HTTPClient output
 // type.
 type HTTPClientConfig struct {
@@ -34,8 +57,9 @@ type HTTPClientConfig struct {
 	BatchAsMultipart  bool                            `json:"batch_as_multipart" yaml:"batch_as_multipart"`
 	MaxInFlight       int                             `json:"max_in_flight" yaml:"max_in_flight"`
 	PropagateResponse bool                            `json:"propagate_response" yaml:"propagate_response"`
-	Batching          policy.Config                   `json:"batching" yaml:"batching"`
+	Batching          policy.OutputConfig             `json:"batching" yaml:"batching"`
 	Multipart         []HTTPClientMultipartExpression `json:"multipart" yaml:"multipart"`
+	Idempotency       HTTPClientIdempotencyConfig     `json:"idempotency" yaml:"idempotency"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
@@ -45,7 +69,8 @@ func NewHTTPClientConfig() HTTPClientConfig {
 		BatchAsMultipart:  false,
 		MaxInFlight:       64,
 		PropagateResponse: false,
-		Batching:          policy.NewConfig(),
+		Batching:          policy.NewOutputConfig(),
+		Idempotency:       NewHTTPClientIdempotencyConfig(),
 	}
 }
 
@@ -102,6 +127,26 @@ func NewHTTPClient(
 		opts = append(opts, http.OptSetMultiPart(parts))
 	}
 
+	if conf.Idempotency.Key != "" {
+		if conf.Idempotency.Header == "" {
+			return nil, errors.New("an idempotency header must be specified when an idempotency key is set")
+		}
+		key, err := mgr.BloblEnvironment().NewMapping(conf.Idempotency.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse idempotency key mapping: %v", err)
+		}
+		opts = append(opts, http.OptSetIdempotencyKey(conf.Idempotency.Header, key))
+	}
+
+	var alreadyProcessedBody *regexp.Regexp
+	if conf.Idempotency.AlreadyProcessedBody != "" {
+		var err error
+		if alreadyProcessedBody, err = regexp.Compile(conf.Idempotency.AlreadyProcessedBody); err != nil {
+			return nil, fmt.Errorf("failed to parse already_processed_body pattern: %v", err)
+		}
+	}
+	opts = append(opts, http.OptSetAlreadyProcessed(conf.Idempotency.AlreadyProcessedCodes, alreadyProcessedBody))
+
 	var err error
 	if h.client, err = http.NewClient(conf.Config, opts...); err != nil {
 		return nil, err
@@ -469,3 +469,132 @@ func TestHTTPOutputClientMultipartHeaders(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestHTTPClientIdempotencyKey(t *testing.T) {
+	var reqCount uint32
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if atomic.AddUint32(&reqCount, 1) < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.Retry = "1ms"
+	conf.NumRetries = 3
+	conf.Idempotency.Key = `uuid_v4()`
+
+	h, err := NewHTTPClient(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, h.Write(message.QuickBatch([][]byte{[]byte("test")})))
+
+	require.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+	assert.Equal(t, keys[0], keys[2])
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second))
+}
+
+func TestHTTPClientIdempotencyKeyFromMessage(t *testing.T) {
+	keyChan := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyChan <- r.Header.Get("X-Request-Key")
+	}))
+	defer ts.Close()
+
+	conf := NewHTTPClientConfig()
+	conf.URL = ts.URL + "/testpost"
+	conf.Idempotency.Key = `this.id`
+	conf.Idempotency.Header = "X-Request-Key"
+
+	h, err := NewHTTPClient(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, h.Write(message.QuickBatch([][]byte{[]byte(`{"id":"foo"}`)})))
+	assert.Equal(t, "foo", <-keyChan)
+
+	assert.Error(t, h.Write(message.QuickBatch([][]byte{[]byte(`{"nope":"foo"}`)})))
+
+	h.CloseAsync()
+	require.NoError(t, h.WaitForClose(time.Second))
+}
+
+func TestHTTPClientAlreadyProcessed(t *testing.T) {
+	tests := []struct {
+		name       string
+		codes      []int
+		body       string
+		resCode    int
+		resBody    string
+		errContain string
+	}{
+		{
+			name:    "matching code",
+			codes:   []int{409},
+			resCode: http.StatusConflict,
+			resBody: "conflict",
+		},
+		{
+			name:       "non matching code",
+			codes:      []int{409},
+			resCode:    http.StatusBadRequest,
+			resBody:    "bad",
+			errContain: "bad",
+		},
+		{
+			name:    "matching body",
+			body:    "already (processed|seen)",
+			resCode: http.StatusUnprocessableEntity,
+			resBody: "request was already seen",
+		},
+		{
+			name:       "matching code but not body",
+			codes:      []int{409},
+			body:       "already processed",
+			resCode:    http.StatusConflict,
+			resBody:    "key in use by a different request",
+			errContain: "key in use by a different request",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var reqCount uint32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddUint32(&reqCount, 1)
+				http.Error(w, test.resBody, test.resCode)
+			}))
+			defer ts.Close()
+
+			conf := NewHTTPClientConfig()
+			conf.URL = ts.URL + "/testpost"
+			conf.Retry = "1ms"
+			conf.NumRetries = 2
+			conf.Idempotency.AlreadyProcessedCodes = test.codes
+			conf.Idempotency.AlreadyProcessedBody = test.body
+
+			h, err := NewHTTPClient(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+			require.NoError(t, err)
+
+			err = h.Write(message.QuickBatch([][]byte{[]byte("test")}))
+			if test.errContain != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContain)
+				assert.Equal(t, uint32(3), atomic.LoadUint32(&reqCount))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, uint32(1), atomic.LoadUint32(&reqCount))
+			}
+
+			h.CloseAsync()
+			require.NoError(t, h.WaitForClose(time.Second))
+		})
+	}
+}
@@ -3,9 +3,9 @@ package writer
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"hash"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,8 +16,10 @@ import (
 	batchInternal "github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/impl/kafka/brokers"
 	"github.com/benthosdev/benthos/v4/internal/impl/kafka/sasl"
 	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -39,6 +41,9 @@ type KafkaConfig struct {
 	Partition        string      `json:"partition" yaml:"partition"`
 	Topic            string      `json:"topic" yaml:"topic"`
 	Compression      string      `json:"compression" yaml:"compression"`
+	CompressionLevel int         `json:"compression_level" yaml:"compression_level"`
+	Linger           string      `json:"linger" yaml:"linger"`
+	BatchBytes       int         `json:"batch_bytes" yaml:"batch_bytes"`
 	MaxMsgBytes      int         `json:"max_msg_bytes" yaml:"max_msg_bytes"`
 	Timeout          string      `json:"timeout" yaml:"timeout"`
 	AckReplicas      bool        `json:"ack_replicas" yaml:"ack_replicas"`
@@ -48,7 +53,7 @@ type KafkaConfig struct {
 	MaxInFlight      int         `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config   `json:",inline" yaml:",inline"`
 	RetryAsBatch     bool                         `json:"retry_as_batch" yaml:"retry_as_batch"`
-	Batching         policy.Config                `json:"batching" yaml:"batching"`
+	Batching         policy.OutputConfig          `json:"batching" yaml:"batching"`
 	StaticHeaders    map[string]string            `json:"static_headers" yaml:"static_headers"`
 	Metadata         metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
 	InjectTracingMap string                       `json:"inject_tracing_map" yaml:"inject_tracing_map"`
@@ -62,26 +67,29 @@ func NewKafkaConfig() KafkaConfig {
 	rConf.Backoff.MaxElapsedTime = "30s"
 
 	return KafkaConfig{
-		Addresses:     []string{},
-		ClientID:      "benthos",
-		RackID:        "",
-		Key:           "",
-		Partitioner:   "fnv1a_hash",
-		Partition:     "",
-		Topic:         "",
-		Compression:   "none",
-		MaxMsgBytes:   1000000,
-		Timeout:       "5s",
-		AckReplicas:   false,
-		TargetVersion: sarama.V1_0_0_0.String(),
-		StaticHeaders: map[string]string{},
-		Metadata:      metadata.NewExcludeFilterConfig(),
-		TLS:           btls.NewConfig(),
-		SASL:          sasl.NewConfig(),
-		MaxInFlight:   64,
-		Config:        rConf,
-		RetryAsBatch:  false,
-		Batching:      policy.NewConfig(),
+		Addresses:        []string{},
+		ClientID:         "benthos",
+		RackID:           "",
+		Key:              "",
+		Partitioner:      "fnv1a_hash",
+		Partition:        "",
+		Topic:            "",
+		Compression:      "none",
+		CompressionLevel: -1,
+		MaxMsgBytes:      1000000,
+		Linger:           "",
+		BatchBytes:       0,
+		Timeout:          "5s",
+		AckReplicas:      false,
+		TargetVersion:    sarama.V1_0_0_0.String(),
+		StaticHeaders:    map[string]string{},
+		Metadata:         metadata.NewExcludeFilterConfig(),
+		TLS:              btls.NewConfig(),
+		SASL:             sasl.NewConfig(),
+		MaxInFlight:      64,
+		Config:           rConf,
+		RetryAsBatch:     false,
+		Batching:         policy.NewOutputConfig(),
 	}
 }
 
@@ -97,6 +105,7 @@ type Kafka struct {
 
 	tlsConf *tls.Config
 	timeout time.Duration
+	linger  time.Duration
 
 	addresses []string
 	version   sarama.KafkaVersion
@@ -106,13 +115,18 @@ type Kafka struct {
 	topic     *field.Expression
 	partition *field.Expression
 
+	client      sarama.Client
 	producer    sarama.SyncProducer
+	brokersDone func()
 	compression sarama.CompressionCodec
 	partitioner sarama.PartitionerConstructor
 
 	staticHeaders map[string]string
 	metaFilter    *metadata.ExcludeFilter
 
+	mLatency    metrics.StatTimer
+	mQueueDepth metrics.StatGauge
+
 	connMut sync.RWMutex
 }
 
@@ -143,6 +157,9 @@ func NewKafka(conf KafkaConfig, mgr interop.Manager, log log.Modular, stats metr
 		compression:   compression,
 		partitioner:   partitioner,
 		staticHeaders: conf.StaticHeaders,
+
+		mLatency:    stats.GetTimer("kafka_produce_latency_ns"),
+		mQueueDepth: stats.GetGauge("kafka_produce_queue_depth"),
 	}
 
 	if k.metaFilter, err = conf.Metadata.Filter(); err != nil {
@@ -169,6 +186,18 @@ func NewKafka(conf KafkaConfig, mgr interop.Manager, log log.Modular, stats metr
 		}
 	}
 
+	if len(conf.Linger) > 0 {
+		if k.linger, err = time.ParseDuration(conf.Linger); err != nil {
+			return nil, fmt.Errorf("failed to parse linger string: %v", err)
+		}
+	}
+	if conf.BatchBytes < 0 {
+		return nil, errors.New("batch_bytes must not be negative")
+	}
+	if conf.BatchBytes > 0 && k.linger <= 0 {
+		return nil, errors.New("a linger period is required when batch_bytes is set")
+	}
+
 	if conf.TLS.Enabled {
 		var err error
 		if k.tlsConf, err = conf.TLS.Get(); err != nil {
@@ -293,6 +322,11 @@ func (k *Kafka) Connect() error {
 	config.Version = k.version
 
 	config.Producer.Compression = k.compression
+	if k.conf.CompressionLevel >= 0 {
+		config.Producer.CompressionLevel = k.conf.CompressionLevel
+	}
+	config.Producer.Flush.Frequency = k.linger
+	config.Producer.Flush.Bytes = k.conf.BatchBytes
 	config.Producer.Partitioner = k.partitioner
 	config.Producer.MaxMessageBytes = k.conf.MaxMsgBytes
 	config.Producer.Timeout = k.timeout
@@ -312,12 +346,33 @@ func (k *Kafka) Connect() error {
 		config.Producer.RequiredAcks = sarama.WaitForLocal
 	}
 
-	var err error
-	k.producer, err = sarama.NewSyncProducer(k.addresses, config)
-
-	if err == nil {
-		k.log.Infof("Sending Kafka messages to addresses: %s\n", k.addresses)
+	client, err := sarama.NewClient(k.addresses, config)
+	if err != nil {
+		return err
 	}
+	if k.producer, err = sarama.NewSyncProducerFromClient(client); err != nil {
+		client.Close()
+		return err
+	}
+	k.client = client
+
+	brokersCtx, brokersDone := context.WithCancel(context.Background())
+	go brokers.ReportConnectivity(brokersCtx, client, k.stats, time.Second*10)
+	k.brokersDone = brokersDone
+
+	k.log.Infof("Sending Kafka messages to addresses: %s\n", k.addresses)
+	return nil
+}
+
+// sendMessages sends a slice of messages with a producer and records the
+// latency of the send and the number of messages awaiting acknowledgement.
+func (k *Kafka) sendMessages(producer sarama.SyncProducer, msgs []*sarama.ProducerMessage) error {
+	k.mQueueDepth.Incr(int64(len(msgs)))
+	defer k.mQueueDepth.Decr(int64(len(msgs)))
+
+	tStarted := time.Now()
+	err := producer.SendMessages(msgs)
+	k.mLatency.Timing(time.Since(tStarted).Nanoseconds())
 	return err
 }
 
@@ -360,12 +415,12 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg *message.Batch) error
 		// field when not using a manual partitioner, we should only set it when
 		// we explicitly want that.
 		if k.conf.Partitioner == "manual" {
-			partitionString := k.partition.String(i, msg)
-			if partitionString == "" {
+			partitionValue := k.partition.Value(i, msg)
+			if partitionValue == "" {
 				return fmt.Errorf("partition expression failed to produce a value")
 			}
 
-			partitionInt, err := strconv.Atoi(partitionString)
+			partitionInt, err := query.IToInt(partitionValue)
 			if err != nil {
 				return fmt.Errorf("failed to parse valid integer from partition expression: %w", err)
 			}
@@ -383,7 +438,7 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg *message.Batch) error
 		return err
 	}
 
-	err = producer.SendMessages(msgs)
+	err = k.sendMessages(producer, msgs)
 	for err != nil {
 		if pErrs, ok := err.(sarama.ProducerErrors); !k.conf.RetryAsBatch && ok {
 			if len(pErrs) == 0 {
@@ -428,7 +483,7 @@ func (k *Kafka) WriteWithContext(ctx context.Context, msg *message.Batch) error
 		if producer == nil {
 			return component.ErrNotConnected
 		}
-		err = producer.SendMessages(msgs)
+		err = k.sendMessages(producer, msgs)
 	}
 
 	return nil
@@ -442,6 +497,14 @@ func (k *Kafka) CloseAsync() {
 			k.producer.Close()
 			k.producer = nil
 		}
+		if k.brokersDone != nil {
+			k.brokersDone()
+			k.brokersDone = nil
+		}
+		if k.client != nil {
+			k.client.Close()
+			k.client = nil
+		}
 		k.connMut.Unlock()
 	}()
 }
@@ -1,10 +1,98 @@
 package writer
 
 import (
+	"context"
 	"strconv"
 	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
+func TestKafkaProduceMetrics(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Addresses = []string{"localhost:9092"}
+	conf.Topic = "foo"
+
+	stats := metrics.NewLocal()
+	k, err := NewKafka(conf, mock.NewManager(), log.Noop(), stats)
+	require.NoError(t, err)
+
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+	k.producer = producer
+
+	require.NoError(t, k.WriteWithContext(context.Background(), message.QuickBatch([][]byte{
+		[]byte("hello"),
+		[]byte("world"),
+	})))
+	require.NoError(t, producer.Close())
+
+	assert.Equal(t, int64(0), stats.GetCounters()["kafka_produce_queue_depth"])
+	assert.Contains(t, stats.GetTimings(), "kafka_produce_latency_ns")
+}
+
+func TestKafkaTypedMetadataPartition(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Addresses = []string{"localhost:9092"}
+	conf.Topic = "foo"
+	conf.Partitioner = "manual"
+	conf.Partition = `${! metadata("kafka_partition") }`
+
+	k, err := NewKafka(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var partitions []int32
+	producerConf := mocks.NewTestConfig()
+	producerConf.Producer.Partitioner = sarama.NewManualPartitioner
+	producer := mocks.NewSyncProducer(t, producerConf)
+	for i := 0; i < 2; i++ {
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			partitions = append(partitions, msg.Partition)
+			return nil
+		})
+	}
+	k.producer = producer
+
+	// Partitions are typed when consumed by the kafka input, and string values
+	// set by other components are still parsed.
+	msg := message.QuickBatch([][]byte{[]byte("hello"), []byte("world")})
+	msg.Get(0).MetaSetTyped("kafka_partition", int64(3))
+	msg.Get(1).MetaSet("kafka_partition", "5")
+
+	require.NoError(t, k.WriteWithContext(context.Background(), msg))
+	require.NoError(t, producer.Close())
+	assert.Equal(t, []int32{3, 5}, partitions)
+}
+
+func TestKafkaLingerConfig(t *testing.T) {
+	conf := NewKafkaConfig()
+	conf.Addresses = []string{"localhost:9092"}
+	conf.Topic = "foo"
+	conf.BatchBytes = 1024
+
+	_, err := NewKafka(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.Linger = "nope"
+	_, err = NewKafka(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+
+	conf.Linger = "10ms"
+	k, err := NewKafka(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Millisecond, k.linger)
+}
+
 func TestMurmur2SanityCheck(t *testing.T) {
 	tests := []struct {
 		data     []string
@@ -3,7 +3,6 @@ package writer
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -12,6 +11,7 @@ import (
 	gonanoid "github.com/matoous/go-nanoid/v2"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	mqttconf "github.com/benthosdev/benthos/v4/internal/impl/mqtt/shared"
@@ -207,6 +207,19 @@ func (m *MQTT) WriteWithContext(ctx context.Context, msg *message.Batch) error {
 	return m.Write(msg)
 }
 
+// isRetained returns whether a message of a batch should be retained, where
+// typed boolean values such as the metadata of the mqtt input are used as is.
+func (m *MQTT) isRetained(i int, msg *message.Batch) bool {
+	if m.retained == nil {
+		return m.conf.Retained
+	}
+	retained, err := query.IToBool(m.retained.Value(i, msg))
+	if err != nil {
+		m.log.Errorf("Error parsing boolean value from retained flag: %v \n", err)
+	}
+	return retained
+}
+
 // Write attempts to write a message by pushing it to an MQTT broker.
 func (m *MQTT) Write(msg *message.Batch) error {
 	m.connMut.RLock()
@@ -218,15 +231,7 @@ func (m *MQTT) Write(msg *message.Batch) error {
 	}
 
 	return IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		retained := m.conf.Retained
-		if m.retained != nil {
-			var parseErr error
-			retained, parseErr = strconv.ParseBool(m.retained.String(i, msg))
-			if parseErr != nil {
-				m.log.Errorf("Error parsing boolean value from retained flag: %v \n", parseErr)
-			}
-		}
-		mtok := client.Publish(m.topic.String(i, msg), m.conf.QoS, retained, p.Get())
+		mtok := client.Publish(m.topic.String(i, msg), m.conf.QoS, m.isRetained(i, msg), p.Get())
 		mtok.Wait()
 		sendErr := mtok.Error()
 		if sendErr == mqtt.ErrNotConnected {
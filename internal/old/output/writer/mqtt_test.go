@@ -0,0 +1,34 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestMQTTRetainedTypedMetadata(t *testing.T) {
+	conf := NewMQTTConfig()
+	conf.URLs = []string{"tcp://localhost:1883"}
+	conf.Topic = "foo"
+	conf.RetainedInterpolated = `${! metadata("mqtt_retained") }`
+
+	m, err := NewMQTTV2(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	// The mqtt input sets the retained flag as a typed boolean, and string
+	// values set by other components are still parsed.
+	msg := message.QuickBatch([][]byte{[]byte("a"), []byte("b"), []byte("c")})
+	msg.Get(0).MetaSetTyped("mqtt_retained", true)
+	msg.Get(1).MetaSet("mqtt_retained", "true")
+	msg.Get(2).MetaSetTyped("mqtt_retained", false)
+
+	assert.True(t, m.isRetained(0, msg))
+	assert.True(t, m.isRetained(1, msg))
+	assert.False(t, m.isRetained(2, msg))
+}
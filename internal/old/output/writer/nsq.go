@@ -26,6 +26,7 @@ import (
 type NSQConfig struct {
 	Address     string      `json:"nsqd_tcp_address" yaml:"nsqd_tcp_address"`
 	Topic       string      `json:"topic" yaml:"topic"`
+	Defer       string      `json:"defer" yaml:"defer"`
 	UserAgent   string      `json:"user_agent" yaml:"user_agent"`
 	TLS         btls.Config `json:"tls" yaml:"tls"`
 	MaxInFlight int         `json:"max_in_flight" yaml:"max_in_flight"`
@@ -36,6 +37,7 @@ func NewNSQConfig() NSQConfig {
 	return NSQConfig{
 		Address:     "",
 		Topic:       "",
+		Defer:       "",
 		UserAgent:   "",
 		TLS:         btls.NewConfig(),
 		MaxInFlight: 64,
@@ -49,6 +51,7 @@ type NSQ struct {
 	log log.Modular
 
 	topicStr *field.Expression
+	deferStr *field.Expression
 
 	tlsConf  *tls.Config
 	connMut  sync.RWMutex
@@ -67,6 +70,9 @@ func NewNSQV2(conf NSQConfig, mgr interop.Manager, log log.Modular, stats metric
 	if n.topicStr, err = mgr.BloblEnvironment().NewField(conf.Topic); err != nil {
 		return nil, fmt.Errorf("failed to parse topic expression: %v", err)
 	}
+	if n.deferStr, err = mgr.BloblEnvironment().NewField(conf.Defer); err != nil {
+		return nil, fmt.Errorf("failed to parse defer expression: %v", err)
+	}
 	if conf.TLS.Enabled {
 		if n.tlsConf, err = conf.TLS.Get(); err != nil {
 			return nil, err
@@ -125,7 +131,17 @@ func (n *NSQ) Write(msg *message.Batch) error {
 	}
 
 	return IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		return prod.Publish(n.topicStr.String(i, msg), p.Get())
+		topic := n.topicStr.String(i, msg)
+		if deferStr := n.deferStr.String(i, msg); deferStr != "" {
+			delay, err := time.ParseDuration(deferStr)
+			if err != nil {
+				return fmt.Errorf("failed to parse defer duration: %w", err)
+			}
+			if delay > 0 {
+				return prod.DeferredPublish(topic, delay, p.Get())
+			}
+		}
+		return prod.Publish(topic, p.Get())
 	})
 }
 
@@ -24,9 +24,9 @@ import (
 // RedisListConfig contains configuration fields for the RedisList output type.
 type RedisListConfig struct {
 	bredis.Config `json:",inline" yaml:",inline"`
-	Key           string        `json:"key" yaml:"key"`
-	MaxInFlight   int           `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching      policy.Config `json:"batching" yaml:"batching"`
+	Key           string              `json:"key" yaml:"key"`
+	MaxInFlight   int                 `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewRedisListConfig creates a new RedisListConfig with default values.
@@ -35,7 +35,7 @@ func NewRedisListConfig() RedisListConfig {
 		Config:      bredis.NewConfig(),
 		Key:         "",
 		MaxInFlight: 64,
-		Batching:    policy.NewConfig(),
+		Batching:    policy.NewOutputConfig(),
 	}
 }
 
@@ -25,9 +25,9 @@ import (
 // type.
 type RedisPubSubConfig struct {
 	bredis.Config `json:",inline" yaml:",inline"`
-	Channel       string        `json:"channel" yaml:"channel"`
-	MaxInFlight   int           `json:"max_in_flight" yaml:"max_in_flight"`
-	Batching      policy.Config `json:"batching" yaml:"batching"`
+	Channel       string              `json:"channel" yaml:"channel"`
+	MaxInFlight   int                 `json:"max_in_flight" yaml:"max_in_flight"`
+	Batching      policy.OutputConfig `json:"batching" yaml:"batching"`
 }
 
 // NewRedisPubSubConfig creates a new RedisPubSubConfig with default values.
@@ -36,7 +36,7 @@ func NewRedisPubSubConfig() RedisPubSubConfig {
 		Config:      bredis.NewConfig(),
 		Channel:     "",
 		MaxInFlight: 64,
-		Batching:    policy.NewConfig(),
+		Batching:    policy.NewOutputConfig(),
 	}
 }
 
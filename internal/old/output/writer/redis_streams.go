@@ -28,7 +28,7 @@ type RedisStreamsConfig struct {
 	MaxLenApprox  int64                        `json:"max_length" yaml:"max_length"`
 	MaxInFlight   int                          `json:"max_in_flight" yaml:"max_in_flight"`
 	Metadata      metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
-	Batching      policy.Config                `json:"batching" yaml:"batching"`
+	Batching      policy.OutputConfig          `json:"batching" yaml:"batching"`
 }
 
 // NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
@@ -40,7 +40,7 @@ func NewRedisStreamsConfig() RedisStreamsConfig {
 		MaxLenApprox: 0,
 		MaxInFlight:  64,
 		Metadata:     metadata.NewExcludeFilterConfig(),
-		Batching:     policy.NewConfig(),
+		Batching:     policy.NewOutputConfig(),
 	}
 }
 
@@ -58,7 +58,7 @@ func NewSocket(
 	stats metrics.Type,
 ) (*Socket, error) {
 	switch conf.Network {
-	case "tcp", "udp", "unix":
+	case "tcp", "udp", "unix", "unixgram":
 	default:
 		return nil, fmt.Errorf("socket network '%v' is not supported by this output", conf.Network)
 	}
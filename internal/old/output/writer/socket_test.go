@@ -208,6 +208,57 @@ func TestUDPSocketBasic(t *testing.T) {
 	conn.Close()
 }
 
+func TestUnixgramSocketBasic(t *testing.T) {
+	conn, err := net.ListenPacket("unixgram", filepath.Join(t.TempDir(), "benthos.sock"))
+	if err != nil {
+		t.Fatalf("failed to listen on a socket: %v", err)
+	}
+	defer conn.Close()
+
+	conf := NewSocketConfig()
+	conf.Network = "unixgram"
+	conf.Address = conn.LocalAddr().String()
+
+	wtr, err := NewSocket(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		wtr.CloseAsync()
+		if err := wtr.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if cerr := wtr.Connect(); cerr != nil {
+		t.Fatal(cerr)
+	}
+
+	if err = wtr.Write(message.QuickBatch([][]byte{[]byte("foo")})); err != nil {
+		t.Error(err)
+	}
+	if err = wtr.Write(message.QuickBatch([][]byte{[]byte("bar")})); err != nil {
+		t.Error(err)
+	}
+
+	var buf bytes.Buffer
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	for buf.Len() < len("foo\nbar\n") {
+		b := make([]byte, 1024)
+		n, _, rerr := conn.ReadFrom(b)
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		buf.Write(b[:n])
+	}
+
+	exp := "foo\nbar\n"
+	if act := buf.String(); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
 func TestUDPSocketMultipart(t *testing.T) {
 	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
 	if err != nil {
@@ -5,8 +5,18 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"os"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
@@ -29,7 +39,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("archive", p, stats), nil
+			return processor.NewV2BatchedToV1Processor("archive", p, mgr), nil
 		},
 		Summary: `
 Archives all the messages of a batch into a single message according to the
@@ -43,7 +53,67 @@ interpolations on the 'path' field as described
 (such as binary) the file field is ignored.
 
 The resulting archived message adopts the metadata of the _first_ message part
-of the batch.`,
+of the batch.
+
+### Directories
+
+Paths may contain directories, such as ` + "`logs/2022/foo.json`" + `. When the field
+` + "`directories`" + ` is set to ` + "`true`" + ` the tar and zip formats also include an explicit
+directory entry for each parent directory of a path, which is written before
+the first file within it. Some tools require these entries in order to extract
+nested paths.
+
+### Manifest
+
+When the field ` + "`manifest.enabled`" + ` is set to ` + "`true`" + ` the tar and zip formats
+prepend a JSON manifest entry to the archive, allowing consumers to validate an
+archive by reading only its first file. The manifest is of the form:
+
+` + "```json" + `
+{
+  "file_count": 2,
+  "total_size": 26,
+  "files": [
+    {"path": "logs/a.json", "size": 12, "sha256": "..."},
+    {"path": "logs/b.json", "size": 14, "sha256": "..."}
+  ]
+}
+` + "```" + `
+
+Where the hash key of each file is the name of the configured hash algorithm,
+and is omitted when the algorithm is ` + "`none`" + `.
+
+### Size Limits
+
+When ` + "`max_size`" + ` is set the messages of a batch are packed in order into as few
+archives as possible with each archive within the size limit, and the resulting
+batch contains a message for each archive. The size of each message within an
+archive is estimated in order to pack them, and an archive that exceeds the
+limit regardless is split in half until each archive is within the limit. A
+message that exceeds the limit alone is archived by itself.
+
+Each archive adopts the metadata of the first message within it, and the
+metadata fields ` + "`archive_part_index`" + ` (starting from zero) and
+` + "`archive_part_count`" + ` are added to each archive, which can be used in order to
+generate unique object keys:
+
+` + "```yaml" + `
+pipeline:
+  processors:
+    - archive:
+        format: tar
+        path: ${!json("id")}.json
+        max_size: 5000000000
+
+output:
+  aws_s3:
+    bucket: foo
+    path: ${!timestamp_unix()}-${!meta("archive_part_index")}.tar
+` + "```" + `
+
+Since batches are processed as a whole it is recommended to also use a
+[batching policy](/docs/configuration/batching) with a ` + "`byte_size`" + ` in order to
+bound the memory used.`,
 		Categories: []string{
 			"Parsing", "Utility",
 		},
@@ -54,6 +124,13 @@ of the batch.`,
 				"path", "The path to set for each message in the archive (when applicable).",
 				"${!count(\"files\")}-${!timestamp_unix_nano()}.txt", "${!meta(\"kafka_key\")}-${!json(\"id\")}.json",
 			).IsInterpolated(),
+			docs.FieldInt("max_size", "The maximum size in bytes of each archive, where zero means no limit. When exceeded the messages of a batch are split across [multiple archives](#size-limits).").HasDefault(0),
+			docs.FieldBool("directories", "Whether to add an explicit entry for each parent directory of the paths of messages, applies to the `tar` and `zip` formats.").Advanced().HasDefault(false),
+			docs.FieldObject("manifest", "Optionally prepend a manifest entry describing the files of the archive, applies to the `tar` and `zip` formats.").WithChildren(
+				docs.FieldBool("enabled", "Whether to prepend a manifest entry.").HasDefault(false),
+				docs.FieldString("path", "The path of the manifest entry within the archive.").HasDefault("manifest.json"),
+				docs.FieldString("hash", "The hash algorithm used to calculate a checksum of each file.").HasOptions("sha256", "sha1", "md5", "none").HasDefault("sha256"),
+			).Advanced(),
 		),
 		Footnotes: `
 ## Formats
@@ -112,44 +189,67 @@ pipeline:
 
 //------------------------------------------------------------------------------
 
+// ArchiveManifestConfig contains configuration fields for the manifest entry
+// of the Archive processor.
+type ArchiveManifestConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Path    string `json:"path" yaml:"path"`
+	Hash    string `json:"hash" yaml:"hash"`
+}
+
 // ArchiveConfig contains configuration fields for the Archive processor.
 type ArchiveConfig struct {
-	Format string `json:"format" yaml:"format"`
-	Path   string `json:"path" yaml:"path"`
+	Format      string                `json:"format" yaml:"format"`
+	Path        string                `json:"path" yaml:"path"`
+	MaxSize     int                   `json:"max_size" yaml:"max_size"`
+	Directories bool                  `json:"directories" yaml:"directories"`
+	Manifest    ArchiveManifestConfig `json:"manifest" yaml:"manifest"`
 }
 
 // NewArchiveConfig returns a ArchiveConfig with default values.
 func NewArchiveConfig() ArchiveConfig {
 	return ArchiveConfig{
-		Format: "",
-		Path:   ``,
+		Format:      "",
+		Path:        ``,
+		MaxSize:     0,
+		Directories: false,
+		Manifest: ArchiveManifestConfig{
+			Enabled: false,
+			Path:    "manifest.json",
+			Hash:    "sha256",
+		},
 	}
 }
 
 //------------------------------------------------------------------------------
 
-type archiveFunc func(hFunc headerFunc, msg *message.Batch) (*message.Part, error)
+// archiveEntry is a file or directory to be written to a file based archive.
+type archiveEntry struct {
+	info fakeInfo
+	data []byte
+}
 
-type headerFunc func(index int, body *message.Part) os.FileInfo
+type archiveFunc func(entries []archiveEntry, msg *message.Batch) (*message.Part, error)
 
-func tarArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+func tarArchive(entries []archiveEntry, msg *message.Batch) (*message.Part, error) {
 	buf := &bytes.Buffer{}
 	tw := tar.NewWriter(buf)
 
-	// Iterate through the parts of the message.
-	err := msg.Iter(func(i int, part *message.Part) error {
-		hdr, err := tar.FileInfoHeader(hFunc(i, part), "")
-		if err != nil {
-			return err
-		}
-		if err := tw.WriteHeader(hdr); err != nil {
-			return err
-		}
-		if _, err := tw.Write(part.Get()); err != nil {
-			return err
+	err := func() error {
+		for _, e := range entries {
+			hdr, err := tar.FileInfoHeader(e.info, "")
+			if err != nil {
+				return err
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := tw.Write(e.data); err != nil {
+				return err
+			}
 		}
 		return nil
-	})
+	}()
 	tw.Close()
 
 	if err != nil {
@@ -160,27 +260,35 @@ func tarArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
 	return newPart, nil
 }
 
-func zipArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+func zipArchive(entries []archiveEntry, msg *message.Batch) (*message.Part, error) {
 	buf := &bytes.Buffer{}
 	zw := zip.NewWriter(buf)
 
-	// Iterate through the parts of the message.
-	err := msg.Iter(func(i int, part *message.Part) error {
-		h, err := zip.FileInfoHeader(hFunc(i, part))
-		if err != nil {
-			return err
-		}
-		h.Method = zip.Deflate
+	err := func() error {
+		for _, e := range entries {
+			h, err := zip.FileInfoHeader(e.info)
+			if err != nil {
+				return err
+			}
+			if e.info.IsDir() {
+				h.Name += "/"
+				if _, err = zw.CreateHeader(h); err != nil {
+					return err
+				}
+				continue
+			}
+			h.Method = zip.Deflate
 
-		w, err := zw.CreateHeader(h)
-		if err != nil {
-			return err
-		}
-		if _, err = w.Write(part.Get()); err != nil {
-			return err
+			w, err := zw.CreateHeader(h)
+			if err != nil {
+				return err
+			}
+			if _, err = w.Write(e.data); err != nil {
+				return err
+			}
 		}
 		return nil
-	})
+	}()
 	zw.Close()
 
 	if err != nil {
@@ -191,13 +299,13 @@ func zipArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
 	return newPart, nil
 }
 
-func binaryArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+func binaryArchive(_ []archiveEntry, msg *message.Batch) (*message.Part, error) {
 	newPart := msg.Get(0).Copy()
 	newPart.Set(message.ToBytes(msg))
 	return newPart, nil
 }
 
-func linesArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+func linesArchive(_ []archiveEntry, msg *message.Batch) (*message.Part, error) {
 	tmpParts := make([][]byte, msg.Len())
 	_ = msg.Iter(func(i int, part *message.Part) error {
 		tmpParts[i] = part.Get()
@@ -208,7 +316,7 @@ func linesArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
 	return newPart, nil
 }
 
-func concatenateArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+func concatenateArchive(_ []archiveEntry, msg *message.Batch) (*message.Part, error) {
 	var buf bytes.Buffer
 	_ = msg.Iter(func(i int, part *message.Part) error {
 		buf.Write(part.Get())
@@ -219,7 +327,7 @@ func concatenateArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, er
 	return newPart, nil
 }
 
-func jsonArrayArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, error) {
+func jsonArrayArchive(_ []archiveEntry, msg *message.Batch) (*message.Part, error) {
 	var array []interface{}
 
 	// Iterate through the parts of the message.
@@ -240,30 +348,56 @@ func jsonArrayArchive(hFunc headerFunc, msg *message.Batch) (*message.Part, erro
 	return newPart, nil
 }
 
-func strToArchiver(str string) (archiveFunc, error) {
+// strToArchiver returns the archive func of a format, and whether the format
+// is file based.
+func strToArchiver(str string) (archiveFunc, bool, error) {
 	switch str {
 	case "tar":
-		return tarArchive, nil
+		return tarArchive, true, nil
 	case "zip":
-		return zipArchive, nil
+		return zipArchive, true, nil
 	case "binary":
-		return binaryArchive, nil
+		return binaryArchive, false, nil
 	case "lines":
-		return linesArchive, nil
+		return linesArchive, false, nil
 	case "json_array":
-		return jsonArrayArchive, nil
+		return jsonArrayArchive, false, nil
 	case "concatenate":
-		return concatenateArchive, nil
+		return concatenateArchive, false, nil
+	}
+	return nil, false, fmt.Errorf("archive format not recognised: %v", str)
+}
+
+func strToManifestHash(str string) (func() hash.Hash, error) {
+	switch str {
+	case "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "md5":
+		return md5.New, nil
+	case "none":
+		return nil, nil
 	}
-	return nil, fmt.Errorf("archive format not recognised: %v", str)
+	return nil, fmt.Errorf("manifest hash algorithm not recognised: %v", str)
 }
 
 //------------------------------------------------------------------------------
 
 type archive struct {
-	archive archiveFunc
-	path    *field.Expression
-	log     log.Modular
+	archive     archiveFunc
+	format      string
+	fileBased   bool
+	maxSize     int64
+	path        *field.Expression
+	directories bool
+
+	manifest     bool
+	manifestPath string
+	manifestHash string
+	manifestFn   func() hash.Hash
+
+	log log.Modular
 }
 
 func newArchive(conf ArchiveConfig, mgr interop.Manager) (processor.V2Batched, error) {
@@ -271,16 +405,36 @@ func newArchive(conf ArchiveConfig, mgr interop.Manager) (processor.V2Batched, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %v", err)
 	}
-	archiver, err := strToArchiver(conf.Format)
+	archiver, fileBased, err := strToArchiver(conf.Format)
 	if err != nil {
 		return nil, err
 	}
 
-	return &archive{
-		archive: archiver,
-		path:    path,
-		log:     mgr.Logger(),
-	}, nil
+	if conf.MaxSize < 0 {
+		return nil, errors.New("max_size must not be negative")
+	}
+
+	a := &archive{
+		archive:     archiver,
+		format:      conf.Format,
+		fileBased:   fileBased,
+		maxSize:     int64(conf.MaxSize),
+		path:        path,
+		directories: conf.Directories,
+		log:         mgr.Logger(),
+	}
+	if conf.Manifest.Enabled {
+		if conf.Manifest.Path == "" {
+			return nil, errors.New("manifest path must not be empty")
+		}
+		if a.manifestFn, err = strToManifestHash(conf.Manifest.Hash); err != nil {
+			return nil, err
+		}
+		a.manifest = true
+		a.manifestPath = conf.Manifest.Path
+		a.manifestHash = conf.Manifest.Hash
+	}
+	return a, nil
 }
 
 //------------------------------------------------------------------------------
@@ -304,24 +458,223 @@ func (f fakeInfo) ModTime() time.Time {
 	return time.Now()
 }
 func (f fakeInfo) IsDir() bool {
-	return false
+	return f.mode.IsDir()
 }
 func (f fakeInfo) Sys() interface{} {
 	return nil
 }
 
-func (d *archive) createHeaderFunc(msg *message.Batch) func(int, *message.Part) os.FileInfo {
-	return func(index int, body *message.Part) os.FileInfo {
-		return fakeInfo{
-			name: d.path.String(index, msg),
-			size: int64(len(body.Get())),
-			mode: 0o666,
+// archiveManifest describes the files of an archive.
+type archiveManifest struct {
+	FileCount int                      `json:"file_count"`
+	TotalSize int64                    `json:"total_size"`
+	Files     []map[string]interface{} `json:"files"`
+}
+
+// parentDirs returns the parent directories of a path that have not yet been
+// seen, from the outermost inwards.
+func parentDirs(p string, seen map[string]struct{}) []string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+
+	var dirs []string
+	for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, exists := seen[dir]; exists {
+			break
 		}
+		seen[dir] = struct{}{}
+		dirs = append([]string{dir}, dirs...)
 	}
+	return dirs
+}
+
+// fileEntries returns an archive entry for each message of a batch.
+func (d *archive) fileEntries(msg *message.Batch) []archiveEntry {
+	files := make([]archiveEntry, msg.Len())
+	_ = msg.Iter(func(i int, part *message.Part) error {
+		files[i] = archiveEntry{
+			info: fakeInfo{
+				name: d.path.String(i, msg),
+				size: int64(len(part.Get())),
+				mode: 0o666,
+			},
+			data: part.Get(),
+		}
+		return nil
+	})
+	return files
+}
+
+// createEntries adds the manifest and directory entries to the file entries of
+// an archive when configured.
+func (d *archive) createEntries(files []archiveEntry) ([]archiveEntry, error) {
+	if d.manifest {
+		m := archiveManifest{
+			FileCount: len(files),
+			Files:     make([]map[string]interface{}, len(files)),
+		}
+		for i, f := range files {
+			m.TotalSize += f.info.size
+			m.Files[i] = map[string]interface{}{
+				"path": f.info.name,
+				"size": f.info.size,
+			}
+			if d.manifestFn != nil {
+				h := d.manifestFn()
+				_, _ = h.Write(f.data)
+				m.Files[i][d.manifestHash] = hex.EncodeToString(h.Sum(nil))
+			}
+		}
+		mBytes, err := json.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		files = append([]archiveEntry{{
+			info: fakeInfo{
+				name: d.manifestPath,
+				size: int64(len(mBytes)),
+				mode: 0o666,
+			},
+			data: mBytes,
+		}}, files...)
+	}
+
+	if !d.directories {
+		return files, nil
+	}
+
+	entries := make([]archiveEntry, 0, len(files))
+	seenDirs := map[string]struct{}{}
+	for _, f := range files {
+		for _, dir := range parentDirs(f.info.name, seenDirs) {
+			entries = append(entries, archiveEntry{
+				info: fakeInfo{
+					name: dir,
+					mode: os.ModeDir | 0o755,
+				},
+			})
+		}
+		entries = append(entries, f)
+	}
+	return entries, nil
 }
 
 //------------------------------------------------------------------------------
 
+// createArchive archives the messages of a batch within the range [start, end).
+func (d *archive) createArchive(msg *message.Batch, files []archiveEntry, start, end int) (*message.Part, error) {
+	parts := make([]*message.Part, 0, end-start)
+	for i := start; i < end; i++ {
+		parts = append(parts, msg.Get(i))
+	}
+	subMsg := message.QuickBatch(nil)
+	subMsg.SetAll(parts)
+
+	var entries []archiveEntry
+	if d.fileBased {
+		var err error
+		if entries, err = d.createEntries(files[start:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	newPart, err := d.archive(entries, subMsg)
+	if err != nil {
+		return nil, err
+	}
+	return batch.WithCollapsedCount(newPart, end-start), nil
+}
+
+// createBoundedArchives archives the messages of a batch within the range
+// [start, end), splitting the range in half whilst the archive exceeds the
+// maximum size.
+func (d *archive) createBoundedArchives(msg *message.Batch, files []archiveEntry, start, end int) ([]*message.Part, error) {
+	newPart, err := d.createArchive(msg, files, start, end)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(len(newPart.Get()))
+	if size <= d.maxSize {
+		return []*message.Part{newPart}, nil
+	}
+	if end-start == 1 {
+		d.log.Warnf("Archive of a single message exceeds the max_size with %v bytes\n", size)
+		return []*message.Part{newPart}, nil
+	}
+
+	mid := start + (end-start)/2
+	left, err := d.createBoundedArchives(msg, files, start, mid)
+	if err != nil {
+		return nil, err
+	}
+	right, err := d.createBoundedArchives(msg, files, mid, end)
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+// estimateOverhead returns an estimate of the number of bytes of an archive
+// that are not attributed to any message.
+func (d *archive) estimateOverhead() int64 {
+	switch d.format {
+	case "tar":
+		return 1024
+	case "zip":
+		return 22
+	case "binary":
+		return 4
+	case "json_array":
+		return 2
+	}
+	return 0
+}
+
+// estimateSize returns an estimate of the number of bytes that a message adds
+// to an archive.
+func (d *archive) estimateSize(data []byte, name string) int64 {
+	size := int64(len(data))
+	switch d.format {
+	case "tar":
+		size = 512 + (size+511)/512*512
+		if len(name) > 100 {
+			// Long names are written as an additional PAX header.
+			size += 1024
+		}
+	case "zip":
+		// Deflate can expand incompressible data by five bytes per block.
+		size += (size/16000+1)*5 + 100 + 2*int64(len(name))
+	case "binary":
+		size += 4
+	case "lines", "json_array":
+		size++
+	}
+	if d.manifest {
+		size += 128 + int64(len(name))
+	}
+	return size
+}
+
+// pack greedily groups the messages of a batch into contiguous ranges that are
+// estimated to be within the maximum archive size.
+func (d *archive) pack(msg *message.Batch, files []archiveEntry) [][2]int {
+	var ranges [][2]int
+	start, size := 0, d.estimateOverhead()
+	_ = msg.Iter(func(i int, part *message.Part) error {
+		var name string
+		if d.fileBased {
+			name = files[i].info.name
+		}
+		partSize := d.estimateSize(part.Get(), name)
+		if i > start && size+partSize > d.maxSize {
+			ranges = append(ranges, [2]int{start, i})
+			start, size = i, d.estimateOverhead()
+		}
+		size += partSize
+		return nil
+	})
+	return append(ranges, [2]int{start, msg.Len()})
+}
+
 func (d *archive) ProcessBatch(ctx context.Context, _ []*tracing.Span, msg *message.Batch) ([]*message.Batch, error) {
 	if msg.Len() == 0 {
 		return nil, nil
@@ -329,13 +682,37 @@ func (d *archive) ProcessBatch(ctx context.Context, _ []*tracing.Span, msg *mess
 
 	newMsg := msg.Copy()
 
-	newPart, err := d.archive(d.createHeaderFunc(msg), msg)
-	if err != nil {
-		d.log.Errorf("Failed to create archive: %v\n", err)
-		return nil, err
+	var files []archiveEntry
+	if d.fileBased {
+		files = d.fileEntries(msg)
+	}
+
+	if d.maxSize == 0 {
+		newPart, err := d.createArchive(msg, files, 0, msg.Len())
+		if err != nil {
+			d.log.Errorf("Failed to create archive: %v\n", err)
+			return nil, err
+		}
+		newMsg.SetAll([]*message.Part{newPart})
+
+		msgs := [1]*message.Batch{newMsg}
+		return msgs[:], nil
+	}
+
+	var newParts []*message.Part
+	for _, r := range d.pack(msg, files) {
+		parts, err := d.createBoundedArchives(msg, files, r[0], r[1])
+		if err != nil {
+			d.log.Errorf("Failed to create archive: %v\n", err)
+			return nil, err
+		}
+		newParts = append(newParts, parts...)
+	}
+	for i, p := range newParts {
+		p.MetaSet("archive_part_index", strconv.Itoa(i))
+		p.MetaSet("archive_part_count", strconv.Itoa(len(newParts)))
 	}
-	newPart = batch.WithCollapsedCount(newPart, msg.Len())
-	newMsg.SetAll([]*message.Part{newPart})
+	newMsg.SetAll(newParts)
 
 	msgs := [1]*message.Batch{newMsg}
 	return msgs[:], nil
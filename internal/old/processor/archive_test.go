@@ -8,8 +8,11 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
@@ -293,3 +296,220 @@ func TestArchiveEmpty(t *testing.T) {
 		t.Error("Expected failure with zero part message")
 	}
 }
+
+func TestArchiveDirectoriesAndManifest(t *testing.T) {
+	for _, format := range []string{"tar", "zip"} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Archive.Format = format
+			conf.Archive.Path = `${!meta("path")}`
+			conf.Archive.Directories = true
+			conf.Archive.Manifest.Enabled = true
+			conf.Archive.Manifest.Path = "meta/manifest.json"
+
+			proc, err := newArchive(conf.Archive, mock.NewManager())
+			require.NoError(t, err)
+
+			msg := message.QuickBatch([][]byte{
+				[]byte("foo"),
+				[]byte("bar"),
+				[]byte("baz"),
+			})
+			for i, p := range []string{"a/b/foo.txt", "a/bar.txt", "c/baz.txt"} {
+				msg.Get(i).MetaSet("path", p)
+			}
+
+			msgs, err := proc.ProcessBatch(context.Background(), nil, msg)
+			require.NoError(t, err)
+			require.Len(t, msgs, 1)
+			require.Equal(t, 1, msgs[0].Len())
+
+			type entry struct {
+				name string
+				data string
+			}
+			var entries []entry
+			archiveBytes := msgs[0].Get(0).Get()
+			if format == "tar" {
+				tr := tar.NewReader(bytes.NewReader(archiveBytes))
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					require.NoError(t, err)
+					data, err := io.ReadAll(tr)
+					require.NoError(t, err)
+					assert.Equal(t, hdr.Typeflag == tar.TypeDir, strings.HasSuffix(hdr.Name, "/"))
+					entries = append(entries, entry{name: hdr.Name, data: string(data)})
+				}
+			} else {
+				zr, err := zip.NewReader(bytes.NewReader(archiveBytes), int64(len(archiveBytes)))
+				require.NoError(t, err)
+				for _, f := range zr.File {
+					fr, err := f.Open()
+					require.NoError(t, err)
+					data, err := io.ReadAll(fr)
+					require.NoError(t, err)
+					assert.Equal(t, f.FileInfo().IsDir(), strings.HasSuffix(f.Name, "/"))
+					entries = append(entries, entry{name: f.Name, data: string(data)})
+				}
+			}
+
+			require.Len(t, entries, 8)
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.name)
+			}
+			assert.Equal(t, []string{
+				"meta/", "meta/manifest.json",
+				"a/", "a/b/", "a/b/foo.txt",
+				"a/bar.txt",
+				"c/", "c/baz.txt",
+			}, names)
+			assert.Equal(t, "bar", entries[5].data)
+
+			assert.JSONEq(t, `{
+  "file_count": 3,
+  "total_size": 9,
+  "files": [
+    {"path":"a/b/foo.txt","size":3,"sha256":"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"},
+    {"path":"a/bar.txt","size":3,"sha256":"fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"},
+    {"path":"c/baz.txt","size":3,"sha256":"baa5a0964d3320fbc0c6a922140453c8513ea24ab8fd0577034804a967248096"}
+  ]
+}`, entries[1].data)
+
+			// Directory entries are skipped when unarchiving.
+			uConf := NewConfig()
+			uConf.Unarchive.Format = format
+			uProc, err := newUnarchive(uConf.Unarchive, mock.NewManager())
+			require.NoError(t, err)
+
+			uParts, err := uProc.Process(context.Background(), msgs[0].Get(0))
+			require.NoError(t, err)
+			require.Len(t, uParts, 4)
+			assert.Equal(t, "a/b/foo.txt", uParts[1].MetaGet("archive_filename"))
+		})
+	}
+}
+
+func TestArchiveManifestBadHash(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "tar"
+	conf.Archive.Manifest.Enabled = true
+	conf.Archive.Manifest.Hash = "nope"
+
+	_, err := newArchive(conf.Archive, mock.NewManager())
+	require.EqualError(t, err, "manifest hash algorithm not recognised: nope")
+}
+
+func TestArchiveMaxSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		maxSize   int
+		sizes     []int
+		expCounts []int
+	}{
+		{
+			name:      "lines",
+			format:    "lines",
+			maxSize:   350,
+			sizes:     []int{100, 100, 100, 100, 100, 100, 100, 100, 100, 100},
+			expCounts: []int{3, 3, 3, 1},
+		},
+		{
+			name:      "tar",
+			format:    "tar",
+			maxSize:   10000,
+			sizes:     []int{1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000},
+			expCounts: []int{5, 5, 2},
+		},
+		{
+			name:      "oversized message",
+			format:    "concatenate",
+			maxSize:   100,
+			sizes:     []int{50, 200, 50, 30, 30},
+			expCounts: []int{1, 1, 2, 1},
+		},
+		{
+			name:      "within limit",
+			format:    "zip",
+			maxSize:   1000000,
+			sizes:     []int{10, 10, 10},
+			expCounts: []int{3},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Archive.Format = test.format
+			conf.Archive.Path = `${!count("files")}.txt`
+			conf.Archive.MaxSize = test.maxSize
+
+			proc, err := newArchive(conf.Archive, mock.NewManager())
+			require.NoError(t, err)
+
+			var parts [][]byte
+			for i, s := range test.sizes {
+				parts = append(parts, bytes.Repeat([]byte{byte('a' + i)}, s))
+			}
+			msg := message.QuickBatch(parts)
+			_ = msg.Iter(func(i int, p *message.Part) error {
+				p.MetaSet("index", strconv.Itoa(i))
+				return nil
+			})
+
+			msgs, err := proc.ProcessBatch(context.Background(), nil, msg)
+			require.NoError(t, err)
+			require.Len(t, msgs, 1)
+			require.Equal(t, len(test.expCounts), msgs[0].Len())
+
+			first := 0
+			_ = msgs[0].Iter(func(i int, p *message.Part) error {
+				assert.Equal(t, test.expCounts[i], batch.CollapsedCount(p))
+				assert.Equal(t, strconv.Itoa(i), p.MetaGet("archive_part_index"))
+				assert.Equal(t, strconv.Itoa(len(test.expCounts)), p.MetaGet("archive_part_count"))
+				assert.Equal(t, strconv.Itoa(first), p.MetaGet("index"))
+				if test.expCounts[i] > 1 {
+					assert.LessOrEqual(t, len(p.Get()), test.maxSize)
+				}
+				first += test.expCounts[i]
+				return nil
+			})
+		})
+	}
+}
+
+func TestArchiveMaxSizeSplitsUnderestimate(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "json_array"
+	conf.Archive.MaxSize = 40
+
+	proc, err := newArchive(conf.Archive, mock.NewManager())
+	require.NoError(t, err)
+
+	// Replacing invalid UTF-8 expands the documents beyond their estimated
+	// size, and therefore archives are split after being created.
+	doc := []byte("{\"a\":\"\xff\xff\"}")
+	msg := message.QuickBatch([][]byte{doc, doc, doc, doc})
+
+	msgs, err := proc.ProcessBatch(context.Background(), nil, msg)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	var docs []string
+	_ = msgs[0].Iter(func(i int, p *message.Part) error {
+		assert.LessOrEqual(t, len(p.Get()), 40)
+		docs = append(docs, string(p.Get()))
+		return nil
+	})
+	assert.Equal(t, []string{
+		`[{"a":"��"}]`,
+		`[{"a":"��"},{"a":"��"}]`,
+		`[{"a":"��"}]`,
+	}, docs)
+}
@@ -33,7 +33,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("awk", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("awk", p, mgr), nil
 		},
 		Categories: []string{
 			"Mapping",
@@ -676,10 +676,7 @@ func (a *awkProc) Process(ctx context.Context, msg *message.Part) ([]*message.Pa
 		var err error
 		jsonPart := mutableJSONPart
 		if jsonPart == nil {
-			if jsonPart, err = part.JSON(); err == nil {
-				jsonPart, err = message.CopyJSON(jsonPart)
-			}
-			if err == nil {
+			if jsonPart, err = part.JSONMut(); err == nil {
 				mutableJSONPart = jsonPart
 			}
 		}
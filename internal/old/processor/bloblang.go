@@ -24,7 +24,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("bloblang", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("bloblang", p, mgr), nil
 		},
 		Categories: []string{
 			"Mapping",
@@ -36,7 +36,9 @@ Executes a [Bloblang](/docs/guides/bloblang/about) mapping on messages.`,
 		Description: `
 Bloblang is a powerful language that enables a wide range of mapping, transformation and filtering tasks. For more information [check out the docs](/docs/guides/bloblang/about).
 
-If your mapping is large and you'd prefer for it to live in a separate file then you can execute a mapping directly from a file with the expression ` + "`from \"<path>\"`" + `, where the path must be absolute, or relative from the location that Benthos is executed from.`,
+If your mapping is large and you'd prefer for it to live in a separate file then you can execute a mapping directly from a file with the expression ` + "`from \"<path>\"`" + `, where the path must be absolute, or relative from the location that Benthos is executed from.
+
+Batches of messages are mapped with state shared across the batch, meaning functions that read the current time such as ` + "`now()`" + ` result in the same value for every message of a batch, and methods with dynamic arguments, such as ` + "`re_match(this.pattern)`" + `, are only instantiated once for each distinct set of arguments within a batch, which avoids compiling the same regular expression for each message.`,
 		Footnotes: `
 ## Error Handling
 
@@ -138,8 +140,9 @@ func NewBloblangConfig() BloblangConfig {
 //------------------------------------------------------------------------------
 
 type bloblangProc struct {
-	exec *mapping.Executor
-	log  log.Modular
+	exec      *mapping.Executor
+	errSource string
+	log       log.Modular
 }
 
 func newBloblang(conf BloblangConfig, mgr interop.Manager) (processor.V2Batched, error) {
@@ -150,7 +153,11 @@ func newBloblang(conf BloblangConfig, mgr interop.Manager) (processor.V2Batched,
 		}
 		return nil, err
 	}
-	return NewBloblangFromExecutor(exec, mgr.Logger()), nil
+	return &bloblangProc{
+		exec:      exec,
+		errSource: processor.ErrSource(mgr),
+		log:       mgr.Logger(),
+	}, nil
 }
 
 // NewBloblangFromExecutor returns a new bloblang processor from an executor.
@@ -165,17 +172,15 @@ func NewBloblangFromExecutor(exec *mapping.Executor, log log.Modular) processor.
 
 func (b *bloblangProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg *message.Batch) ([]*message.Batch, error) {
 	newParts := make([]*message.Part, 0, msg.Len())
-	_ = msg.Iter(func(i int, part *message.Part) error {
-		p, err := b.exec.MapPart(i, msg)
+	b.exec.MapBatch(msg, func(i int, p *message.Part, err error) {
 		if err != nil {
-			p = part.Copy()
+			p = msg.Get(i).Copy()
 			b.log.Errorf("%v\n", err)
-			processor.MarkErr(p, spans[i], err)
+			processor.MarkErr(p, spans[i], b.errSource, err)
 		}
 		if p != nil {
 			newParts = append(newParts, p)
 		}
-		return nil
 	})
 	if len(newParts) == 0 {
 		return nil, nil
@@ -22,7 +22,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("bounds_check", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("bounds_check", p, mgr), nil
 		},
 		Categories: []string{
 			"Utility",
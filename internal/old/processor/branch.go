@@ -209,7 +209,8 @@ func NewBranchConfig() BranchConfig {
 // a subset of request messages, and mapping results from those requests back
 // into the original message batch.
 type Branch struct {
-	log log.Modular
+	log       log.Modular
+	errSource string
 
 	requestMap *mapping.Executor
 	resultMap  *mapping.Executor
@@ -240,8 +241,9 @@ func newBranch(conf BranchConfig, mgr interop.Manager) (*Branch, error) {
 
 	stats := mgr.Metrics()
 	b := &Branch{
-		children: children,
-		log:      mgr.Logger(),
+		children:  children,
+		log:       mgr.Logger(),
+		errSource: processor.ErrSource(mgr),
 
 		mReceived:      stats.GetCounter("processor_received"),
 		mBatchReceived: stats.GetCounter("processor_batch_received"),
@@ -349,12 +351,12 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 		result := msg.Copy()
 		// Add general error to all messages.
 		_ = result.Iter(func(i int, p *message.Part) error {
-			FlagErr(p, err)
+			FlagErr(p, b.errSource, err)
 			return nil
 		})
 		// And override with mapping specific errors where appropriate.
 		for _, e := range mapErrs {
-			FlagErr(result.Get(e.index), e.err)
+			FlagErr(result.Get(e.index), b.errSource, e.err)
 		}
 		msgs := [1]*message.Batch{result}
 		return msgs[:], nil
@@ -362,20 +364,20 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 
 	result := msg.DeepCopy()
 	for _, e := range mapErrs {
-		FlagErr(result.Get(e.index), e.err)
+		FlagErr(result.Get(e.index), b.errSource, e.err)
 		b.log.Errorf("Branch error: %v", e.err)
 	}
 
 	if mapErrs, err = b.overlayResult(result, resultParts); err != nil {
 		_ = result.Iter(func(i int, p *message.Part) error {
-			FlagErr(p, err)
+			FlagErr(p, b.errSource, err)
 			return nil
 		})
 		msgs := [1]*message.Batch{result}
 		return msgs[:], nil
 	}
 	for _, e := range mapErrs {
-		FlagErr(result.Get(e.index), e.err)
+		FlagErr(result.Get(e.index), b.errSource, e.err)
 		b.log.Errorf("Branch error: %v", e.err)
 	}
 
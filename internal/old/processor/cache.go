@@ -27,7 +27,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("cache", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("cache", p, mgr), nil
 		},
 		Categories: []string{
 			"Integration",
@@ -179,6 +179,7 @@ type cacheProc struct {
 	ttl   *field.Expression
 
 	mgr       interop.Manager
+	errSource string
 	cacheName string
 	operator  cacheOperator
 }
@@ -219,6 +220,7 @@ func newCache(conf CacheConfig, mgr interop.Manager) (*cacheProc, error) {
 		ttl:   ttl,
 
 		mgr:       mgr,
+		errSource: processor.ErrSource(mgr),
 		cacheName: cacheName,
 		operator:  op,
 	}, nil
@@ -283,7 +285,7 @@ func (c *cacheProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 			td, err := time.ParseDuration(ttls)
 			if err != nil {
 				c.mgr.Logger().Debugf("TTL must be a duration: %v\n", err)
-				processor.MarkErr(part, spans[index], err)
+				processor.MarkErr(part, spans[index], c.errSource, err)
 				return nil
 			}
 			ttl = &td
@@ -303,7 +305,7 @@ func (c *cacheProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 			} else {
 				c.mgr.Logger().Debugf("Key already exists: %v\n", key)
 			}
-			processor.MarkErr(part, spans[index], err)
+			processor.MarkErr(part, spans[index], c.errSource, err)
 			return nil
 		}
 
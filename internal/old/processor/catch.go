@@ -23,7 +23,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("catch", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("catch", p, mgr), nil
 		},
 		Categories: []string{
 			"Composition",
@@ -56,6 +56,24 @@ When messages leave the catch block their fail flags are cleared. This processor
 is useful for when it's possible to recover failed messages, or when special
 actions (such as logging/metrics) are required before dropping them.
 
+Different types of errors can be handled differently by placing a
+` + "[`switch`](/docs/components/processors/switch)" + ` processor within the catch block
+with cases that match the ` + "`error_classes`" + ` of failed messages:
+
+` + "```yaml" + `
+pipeline:
+  processors:
+    - resource: foo
+    - catch:
+      - switch:
+        - error_classes: [ schema ]
+          processors:
+            - bloblang: root = deleted()
+        - error_classes: [ transient ]
+          processors:
+            - resource: bar
+` + "```" + `
+
 More information about error handing can be found [here](/docs/configuration/error_handling).`,
 		Config: docs.FieldProcessor("", "").Array().
 			Linter(func(ctx docs.LintContext, line, col int, value interface{}) []docs.Lint {
@@ -1,12 +1,15 @@
 package processor
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -270,3 +273,55 @@ func TestCatchFilterAll(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestCatchSwitchErrorClasses(t *testing.T) {
+	schemaConf := NewConfig()
+	schemaConf.Type = TypeBloblang
+	schemaConf.Bloblang = `root = deleted()`
+
+	transientConf := NewConfig()
+	transientConf.Type = TypeBloblang
+	transientConf.Bloblang = `root = "retried: " + content().string() + " " + error_class()`
+
+	switchConf := NewConfig()
+	switchConf.Type = TypeSwitch
+	switchConf.Switch = append(switchConf.Switch, SwitchCaseConfig{
+		ErrorClasses: []string{"schema"},
+		Processors:   []Config{schemaConf},
+	}, SwitchCaseConfig{
+		ErrorClasses: []string{"transient"},
+		Processors:   []Config{transientConf},
+	})
+
+	conf := NewConfig()
+	conf.Type = TypeCatch
+	conf.Catch = append(conf.Catch, switchConf)
+
+	proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msg := message.QuickBatch([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+		[]byte("baz"),
+		[]byte("buz"),
+	})
+	FlagErr(msg.Get(0), "", iprocessor.NewError(iprocessor.ErrorClassSchema, "json_syntax", false, errors.New("bad json")))
+	FlagErr(msg.Get(1), "", iprocessor.NewError(iprocessor.ErrorClassTransient, "timeout", true, errors.New("timed out")))
+	FlagErr(msg.Get(2), "", errors.New("no idea"))
+
+	msgs, res := proc.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, [][]byte{
+		[]byte("retried: bar transient"),
+		[]byte("baz"),
+		[]byte("buz"),
+	}, message.GetAllBytes(msgs[0]))
+	_ = msgs[0].Iter(func(i int, p *message.Part) error {
+		assert.False(t, HasFailed(p), i)
+		assert.Empty(t, p.MetaGet("benthos_error_class"), i)
+		return nil
+	})
+}
@@ -28,7 +28,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("compress", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("compress", p, mgr), nil
 		},
 		Categories: []string{
 			"Parsing",
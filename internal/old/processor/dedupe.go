@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -26,7 +28,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("dedupe", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("dedupe", p, mgr), nil
 		},
 		Categories: []string{
 			"Utility",
@@ -35,6 +37,12 @@ func init() {
 		Description: `
 Caches must be configured as resources, for more information check out the [cache documentation here](/docs/components/caches/about).
 
+## Counting Duplicates
+
+When the field ` + "`mode`" + ` is set to ` + "`count`" + ` duplicates are no longer dropped, instead the number of times that the key of each message has been seen (including the message itself) is tracked within the cache and added to the message as a metadata field named by ` + "`count_meta`" + `. The first occurrence of a key therefore has a count of ` + "`1`" + `. The window over which occurrences are counted is determined by the TTL of the cache, and since each occurrence updates the cached count some cache implementations will refresh the TTL each time a key is seen.
+
+Counts are incremented with a read followed by a write, and therefore when multiple Benthos instances share a cache the counts of a key observed concurrently by different instances may be lower than the true number of occurrences.
+
 When using this processor with an output target that might fail you should always wrap the output within an indefinite ` + "[`retry`](/docs/components/outputs/retry)" + ` block. This ensures that during outages your messages aren't reprocessed after failures, which would result in messages being dropped.
 
 ## Batch Deduplication
@@ -50,6 +58,8 @@ This problem can be mitigated by using an in-memory cache and distributing messa
 			docs.FieldString("cache", "The [`cache` resource](/docs/components/caches/about) to target with this processor."),
 			docs.FieldString("key", "An interpolated string yielding the key to deduplicate by for each message.", `${! meta("kafka_key") }`, `${! content().hash("xxhash64") }`).IsInterpolated(),
 			docs.FieldBool("drop_on_err", "Whether messages should be dropped when the cache returns a general error such as a network issue."),
+			docs.FieldString("mode", "Determines whether duplicate messages are dropped, or whether all messages are kept and annotated with the number of times their key has been seen.").HasOptions("drop", "count").Advanced(),
+			docs.FieldString("count_meta", "When `mode` is `count`, the name of the metadata field to store the number of times the key of a message has been seen.").Advanced(),
 		),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -66,6 +76,27 @@ cache_resources:
   - label: keycache
     memory:
       default_ttl: 60s
+`,
+			},
+			{
+				Title:   "Counting repeated events",
+				Summary: "Rather than dropping duplicates it's possible to annotate each message with the number of times its key has been seen within the TTL of the cache, which can then be used to flag frequently repeated events.",
+				Config: `
+pipeline:
+  processors:
+    - dedupe:
+        cache: keycache
+        key: ${! json("user_id") }-${! json("event") }
+        mode: count
+        count_meta: repeat_count
+    - bloblang: |
+        root = this
+        root.repeated = meta("repeat_count").number() > 1
+
+cache_resources:
+  - label: keycache
+    memory:
+      default_ttl: 5m
 `,
 			},
 		},
@@ -79,6 +110,8 @@ type DedupeConfig struct {
 	Cache          string `json:"cache" yaml:"cache"`
 	Key            string `json:"key" yaml:"key"`
 	DropOnCacheErr bool   `json:"drop_on_err" yaml:"drop_on_err"`
+	Mode           string `json:"mode" yaml:"mode"`
+	CountMeta      string `json:"count_meta" yaml:"count_meta"`
 }
 
 // NewDedupeConfig returns a DedupeConfig with default values.
@@ -87,6 +120,8 @@ func NewDedupeConfig() DedupeConfig {
 		Cache:          "",
 		Key:            "",
 		DropOnCacheErr: true,
+		Mode:           "drop",
+		CountMeta:      "dedupe_count",
 	}
 }
 
@@ -98,7 +133,12 @@ type dedupeProc struct {
 	dropOnErr bool
 	key       *field.Expression
 	mgr       interop.Manager
+	errSource string
 	cacheName string
+
+	count     bool
+	countMeta string
+	countMut  sync.Mutex
 }
 
 func newDedupe(conf DedupeConfig, mgr interop.Manager) (*dedupeProc, error) {
@@ -114,12 +154,27 @@ func newDedupe(conf DedupeConfig, mgr interop.Manager) (*dedupeProc, error) {
 		return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
 	}
 
+	var count bool
+	switch conf.Mode {
+	case "drop", "":
+	case "count":
+		if conf.CountMeta == "" {
+			return nil, errors.New("count_meta must not be empty when mode is count")
+		}
+		count = true
+	default:
+		return nil, fmt.Errorf("dedupe mode not recognised: %v", conf.Mode)
+	}
+
 	return &dedupeProc{
 		log:       mgr.Logger(),
 		dropOnErr: conf.DropOnCacheErr,
 		key:       key,
 		mgr:       mgr,
+		errSource: processor.ErrSource(mgr),
 		cacheName: conf.Cache,
+		count:     count,
+		countMeta: conf.CountMeta,
 	}, nil
 }
 
@@ -130,6 +185,27 @@ func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, ba
 	_ = batch.Iter(func(i int, p *message.Part) error {
 		key := d.key.String(i, batch)
 
+		if d.count {
+			n, err := d.incrCount(key)
+			if err != nil {
+				d.log.Errorf("Cache error: %v\n", err)
+				if d.dropOnErr {
+					spans[i].LogKV(
+						"event", "dropped",
+						"type", "cache_error",
+					)
+					return nil
+				}
+				p = p.Copy()
+				processor.MarkErr(p, spans[i], d.errSource, err)
+			} else {
+				p = p.Copy()
+				p.MetaSet(d.countMeta, strconv.FormatInt(n, 10))
+			}
+			newBatch.Append(p)
+			return nil
+		}
+
 		var err error
 		if cerr := d.mgr.AccessCache(context.Background(), d.cacheName, func(cache cache.V1) {
 			err = cache.Add(context.Background(), key, []byte{'t'}, nil)
@@ -155,7 +231,7 @@ func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, ba
 			}
 
 			p = p.Copy()
-			processor.MarkErr(p, spans[i], err)
+			processor.MarkErr(p, spans[i], d.errSource, err)
 		}
 
 		newBatch.Append(p)
@@ -168,6 +244,30 @@ func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, ba
 	return []*message.Batch{newBatch}, nil
 }
 
+// incrCount increments the number of times a key has been seen within the
+// cache and returns the new count.
+func (d *dedupeProc) incrCount(key string) (n int64, err error) {
+	d.countMut.Lock()
+	defer d.countMut.Unlock()
+
+	if cerr := d.mgr.AccessCache(context.Background(), d.cacheName, func(cache cache.V1) {
+		var v []byte
+		if v, err = cache.Get(context.Background(), key); err != nil {
+			if err != component.ErrKeyNotFound {
+				return
+			}
+		} else if n, err = strconv.ParseInt(string(v), 10, 64); err != nil {
+			err = fmt.Errorf("failed to parse cached count of key %v: %w", key, err)
+			return
+		}
+		n++
+		err = cache.Set(context.Background(), key, []byte(strconv.FormatInt(n, 10)), nil)
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
 func (d *dedupeProc) Close(context.Context) error {
 	return nil
 }
@@ -95,3 +95,63 @@ func TestDedupeCacheErrors(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, msgs, 1)
 }
+
+func TestDedupeCountMode(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "dedupe"
+	conf.Dedupe.Cache = "foocache"
+	conf.Dedupe.Key = "${! content() }"
+	conf.Dedupe.Mode = "count"
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	proc, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, err := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("foo"),
+	}))
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 3, msgs[0].Len())
+	assert.Equal(t, "1", msgs[0].Get(0).MetaGet("dedupe_count"))
+	assert.Equal(t, "1", msgs[0].Get(1).MetaGet("dedupe_count"))
+	assert.Equal(t, "2", msgs[0].Get(2).MetaGet("dedupe_count"))
+
+	msgs, err = proc.ProcessMessage(message.QuickBatch([][]byte{[]byte("foo")}))
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "3", msgs[0].Get(0).MetaGet("dedupe_count"))
+	assert.Equal(t, "3", mgr.Caches["foocache"]["foo"].Value)
+
+	mgr.Caches["foocache"]["bar"] = mock.CacheItem{Value: "nope"}
+	conf.Dedupe.DropOnCacheErr = false
+	conf.Dedupe.CountMeta = "seen"
+
+	proc, err = New(conf, mgr, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	msgs, err = proc.ProcessMessage(message.QuickBatch([][]byte{[]byte("bar"), []byte("baz")}))
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 2, msgs[0].Len())
+	assert.Contains(t, msgs[0].Get(0).MetaGet(message.FailFlagKey), "failed to parse cached count")
+	assert.Equal(t, "", msgs[0].Get(0).MetaGet("seen"))
+	assert.Equal(t, "1", msgs[0].Get(1).MetaGet("seen"))
+}
+
+func TestDedupeBadMode(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "dedupe"
+	conf.Dedupe.Cache = "foocache"
+	conf.Dedupe.Key = "${! content() }"
+	conf.Dedupe.Mode = "nope"
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	_, err := New(conf, mgr, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dedupe mode not recognised")
+}
@@ -26,7 +26,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("group_by", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("group_by", p, mgr), nil
 		},
 		Categories: []string{
 			"Composition",
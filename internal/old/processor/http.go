@@ -27,7 +27,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("http", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("http", p, mgr), nil
 		},
 		Categories: []string{
 			"Integration",
@@ -122,12 +122,14 @@ type httpProc struct {
 	asMultipart bool
 	parallel    bool
 	rawURL      string
+	errSource   string
 	log         log.Modular
 }
 
 func newHTTPProc(conf HTTPConfig, mgr interop.Manager) (processor.V2Batched, error) {
 	g := &httpProc{
 		rawURL:      conf.URL,
+		errSource:   processor.ErrSource(mgr),
 		log:         mgr.Logger(),
 		asMultipart: conf.BatchAsMultipart,
 		parallel:    conf.Parallel,
@@ -163,7 +165,7 @@ func (h *httpProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 				if len(codeStr) > 0 {
 					p.MetaSet("http_status_code", codeStr)
 				}
-				FlagErr(p, err)
+				FlagErr(p, h.errSource, err)
 				return nil
 			})
 		} else {
@@ -199,7 +201,7 @@ func (h *httpProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 				if ok := errors.As(err, &hErr); ok {
 					errPart.MetaSet("http_status_code", strconv.Itoa(hErr.Code))
 				}
-				FlagErr(errPart, err)
+				FlagErr(errPart, h.errSource, err)
 				_ = responseMsg.Append(errPart)
 				return nil
 			}
@@ -245,7 +247,7 @@ func (h *httpProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 						if ok := errors.As(err, &hErr); ok {
 							results[index].MetaSet("http_status_code", strconv.Itoa(hErr.Code))
 						}
-						FlagErr(results[index], err)
+						FlagErr(results[index], h.errSource, err)
 					}
 					resChan <- err
 				}
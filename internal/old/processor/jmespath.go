@@ -24,7 +24,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("jmespath", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("jmespath", p, mgr), nil
 		},
 		Categories: []string{
 			"Mapping",
@@ -23,7 +23,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("jq", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("jq", p, mgr), nil
 		},
 		Status: docs.StatusStable,
 		Categories: []string{
@@ -165,11 +165,7 @@ func (j *jqProc) getPartValue(part *message.Part, raw bool) (obj interface{}, er
 	if raw {
 		return string(part.Get()), nil
 	}
-	obj, err = part.JSON()
-	if err == nil {
-		obj, err = message.CopyJSON(obj)
-	}
-	if err != nil {
+	if obj, err = part.JSONMut(); err != nil {
 		j.log.Debugf("Failed to parse part into json: %v\n", err)
 		return nil, err
 	}
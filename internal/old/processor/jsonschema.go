@@ -25,7 +25,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("json_schema", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("json_schema", p, mgr), nil
 		},
 		Categories: []string{
 			"Mapping",
@@ -156,7 +156,7 @@ func (s *jsonSchemaProc) Process(ctx context.Context, part *message.Part) ([]*me
 	jsonPart, err := part.JSON()
 	if err != nil {
 		s.log.Debugf("Failed to parse part into json: %v", err)
-		return nil, err
+		return nil, processor.NewError(processor.ErrorClassSchema, "json_parse", false, err)
 	}
 
 	partLoader := jsonschema.NewGoLoader(jsonPart)
@@ -179,7 +179,7 @@ func (s *jsonSchemaProc) Process(ctx context.Context, part *message.Part) ([]*me
 			}
 			errStr += desc.Field() + " " + description
 		}
-		return nil, errors.New(errStr)
+		return nil, processor.NewError(processor.ErrorClassSchema, "json_schema_invalid", false, errors.New(errStr))
 	}
 
 	s.log.Debugf("The document is valid")
@@ -23,7 +23,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("rate_limit", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("rate_limit", p, mgr), nil
 		},
 		Categories: []string{
 			"Utility",
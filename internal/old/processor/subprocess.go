@@ -33,7 +33,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("subprocess", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("subprocess", p, mgr), nil
 		},
 		Categories: []string{
 			"Integration",
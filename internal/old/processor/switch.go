@@ -27,7 +27,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("switch", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("switch", p, mgr), nil
 		},
 		Categories: []string{
 			"Composition",
@@ -35,7 +35,9 @@ func init() {
 		Summary: `
 Conditionally processes messages based on their contents.`,
 		Description: `
-For each switch case a [Bloblang query](/docs/guides/bloblang/about/) is checked and, if the result is true (or the check is empty) the child processors are executed on the message.`,
+For each switch case a [Bloblang query](/docs/guides/bloblang/about/) is checked and, if the result is true (or the check is empty) the child processors are executed on the message.
+
+Cases can also match messages that have failed a prior processing step by the [class of their error](/docs/configuration/error_handling#handle-errors-by-class) with the field ` + "`error_classes`" + `, which when placed within a ` + "[`catch`](/docs/components/processors/catch)" + ` block allows different types of errors to be handled differently.`,
 		Footnotes: `
 ## Batching
 
@@ -49,6 +51,12 @@ At the end of switch processing the resulting batch will follow the same orderin
 				`this.type == "foo"`,
 				`this.contents.urls.contains("https://benthos.dev/")`,
 			).HasDefault(""),
+			docs.FieldString(
+				"error_classes",
+				"A list of error classes, where a message only passes this case if it has failed a prior processing step with an error of one of the classes. The classes are `schema`, `transient`, `rejected` and `unknown`. When combined with a `check` both must pass. If left empty messages are not tested for errors.",
+				[]string{"schema"},
+				[]string{"transient", "rejected"},
+			).Array().HasDefault([]interface{}{}),
 			docs.FieldProcessor(
 				"processors",
 				"A list of [processors](/docs/components/processors/about/) to execute on a message.",
@@ -81,6 +89,30 @@ pipeline:
                 name: GeorgesAnger
                 value: ${! json("user.anger") }
             - bloblang: root = deleted()
+`,
+			},
+			{
+				Title: "Route Errors by Class",
+				Summary: `
+Messages that fail to be parsed will never succeed when retried and so we drop them, whereas messages that failed due to a temporary problem are retried with a different resource. All other failures are logged.`,
+				Config: `
+pipeline:
+  processors:
+    - resource: foo # Processor that might fail
+    - catch:
+        - switch:
+            - error_classes: [ schema ]
+              processors:
+                - bloblang: root = deleted()
+
+            - error_classes: [ transient ]
+              processors:
+                - resource: bar
+
+            - processors:
+                - log:
+                    level: ERROR
+                    message: '${! error_source() } failed: ${! error() }'
 `,
 			},
 		},
@@ -92,17 +124,19 @@ pipeline:
 // SwitchCaseConfig contains a condition, processors and other fields for an
 // individual case in the Switch processor.
 type SwitchCaseConfig struct {
-	Check       string   `json:"check" yaml:"check"`
-	Processors  []Config `json:"processors" yaml:"processors"`
-	Fallthrough bool     `json:"fallthrough" yaml:"fallthrough"`
+	Check        string   `json:"check" yaml:"check"`
+	ErrorClasses []string `json:"error_classes" yaml:"error_classes"`
+	Processors   []Config `json:"processors" yaml:"processors"`
+	Fallthrough  bool     `json:"fallthrough" yaml:"fallthrough"`
 }
 
 // NewSwitchCaseConfig returns a new SwitchCaseConfig with default values.
 func NewSwitchCaseConfig() SwitchCaseConfig {
 	return SwitchCaseConfig{
-		Check:       "",
-		Processors:  []Config{},
-		Fallthrough: false,
+		Check:        "",
+		ErrorClasses: []string{},
+		Processors:   []Config{},
+		Fallthrough:  false,
 	}
 }
 
@@ -149,14 +183,30 @@ func NewSwitchConfig() SwitchConfig {
 // switchCase contains a condition, processors and other fields for an
 // individual case in the Switch processor.
 type switchCase struct {
-	check       *mapping.Executor
-	processors  []processor.V1
-	fallThrough bool
+	check        *mapping.Executor
+	errorClasses map[string]struct{}
+	processors   []processor.V1
+	fallThrough  bool
+}
+
+// matchesErrorClass returns whether a message part has failed with an error of
+// one of the classes of the case, or true if the case has no error classes.
+func (s switchCase) matchesErrorClass(p *message.Part) bool {
+	if len(s.errorClasses) == 0 {
+		return true
+	}
+	class := processor.GetFailClass(p)
+	if class == "" {
+		return false
+	}
+	_, exists := s.errorClasses[class]
+	return exists
 }
 
 type switchProc struct {
-	cases []switchCase
-	log   log.Modular
+	cases     []switchCase
+	errSource string
+	log       log.Modular
 }
 
 func newSwitch(conf SwitchConfig, mgr interop.Manager) (*switchProc, error) {
@@ -166,6 +216,19 @@ func newSwitch(conf SwitchConfig, mgr interop.Manager) (*switchProc, error) {
 		var check *mapping.Executor
 		var procs []processor.V1
 
+		var errorClasses map[string]struct{}
+		if len(caseConf.ErrorClasses) > 0 {
+			errorClasses = make(map[string]struct{}, len(caseConf.ErrorClasses))
+			for _, class := range caseConf.ErrorClasses {
+				switch class {
+				case processor.ErrorClassSchema, processor.ErrorClassTransient, processor.ErrorClassRejected, processor.ErrorClassUnknown:
+				default:
+					return nil, fmt.Errorf("case [%v] error class not recognised: %v", i, class)
+				}
+				errorClasses[class] = struct{}{}
+			}
+		}
+
 		if len(caseConf.Check) > 0 {
 			if check, err = mgr.BloblEnvironment().NewMapping(caseConf.Check); err != nil {
 				return nil, fmt.Errorf("failed to parse case %v check: %w", i, err)
@@ -186,14 +249,16 @@ func newSwitch(conf SwitchConfig, mgr interop.Manager) (*switchProc, error) {
 		}
 
 		cases = append(cases, switchCase{
-			check:       check,
-			processors:  procs,
-			fallThrough: caseConf.Fallthrough,
+			check:        check,
+			errorClasses: errorClasses,
+			processors:   procs,
+			fallThrough:  caseConf.Fallthrough,
 		})
 	}
 	return &switchProc{
-		cases: cases,
-		log:   mgr.Logger(),
+		cases:     cases,
+		errSource: processor.ErrSource(mgr),
+		log:       mgr.Logger(),
 	}, nil
 }
 
@@ -238,12 +303,16 @@ func (s *switchProc) ProcessBatch(ctx context.Context, _ []*tracing.Span, msg *m
 		testMsg.Append(remaining...)
 
 		for j, p := range remaining {
+			if !switchCase.matchesErrorClass(p) {
+				failed = append(failed, p)
+				continue
+			}
 			test := switchCase.check == nil
 			if !test {
 				var err error
 				if test, err = switchCase.check.QueryPart(j, testMsg); err != nil {
 					s.log.Errorf("Failed to test case %v: %v\n", i, err)
-					processor.MarkErr(p, nil, err)
+					processor.MarkErr(p, nil, s.errSource, err)
 					result = append(result, p)
 					continue
 				}
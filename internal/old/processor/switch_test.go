@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -189,6 +191,95 @@ func TestSwitchError(t *testing.T) {
 	}, resStrs)
 }
 
+func TestSwitchErrorClasses(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSwitch
+
+	procConf := NewConfig()
+	procConf.Type = TypeBloblang
+	procConf.Bloblang = `root = "Hit schema: " + content().string()`
+
+	conf.Switch = append(conf.Switch, SwitchCaseConfig{
+		ErrorClasses: []string{"schema"},
+		Processors:   []Config{procConf},
+	})
+
+	procConf = NewConfig()
+	procConf.Type = TypeBloblang
+	procConf.Bloblang = `root = "Hit transient foo: " + content().string()`
+
+	conf.Switch = append(conf.Switch, SwitchCaseConfig{
+		Check:        `content().contains("foo")`,
+		ErrorClasses: []string{"transient", "rejected"},
+		Processors:   []Config{procConf},
+	})
+
+	procConf = NewConfig()
+	procConf.Type = TypeBloblang
+	procConf.Bloblang = `root = "Hit unknown: " + content().string()`
+
+	conf.Switch = append(conf.Switch, SwitchCaseConfig{
+		ErrorClasses: []string{"unknown"},
+		Processors:   []Config{procConf},
+	})
+
+	c, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	defer func() {
+		c.CloseAsync()
+		assert.NoError(t, c.WaitForClose(time.Second))
+	}()
+
+	msg := message.QuickBatch([][]byte{
+		[]byte("a"),
+		[]byte("b"),
+		[]byte("c foo"),
+		[]byte("d"),
+		[]byte("e"),
+	})
+	FlagErr(msg.Get(0), "", iprocessor.NewError(iprocessor.ErrorClassSchema, "", false, errors.New("bad schema")))
+	FlagErr(msg.Get(2), "", iprocessor.NewError(iprocessor.ErrorClassTransient, "", true, errors.New("timed out")))
+	FlagErr(msg.Get(3), "", iprocessor.NewError(iprocessor.ErrorClassTransient, "", true, errors.New("timed out")))
+	FlagErr(msg.Get(4), "", errors.New("no idea"))
+
+	msgs, res := c.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	resStrs := []string{}
+	for _, b := range message.GetAllBytes(msgs[0]) {
+		resStrs = append(resStrs, string(b))
+	}
+	assert.Equal(t, []string{
+		"Hit schema: a",
+		"b",
+		"Hit transient foo: c foo",
+		"d",
+		"Hit unknown: e",
+	}, resStrs)
+}
+
+func TestSwitchBadErrorClass(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = TypeSwitch
+
+	procConf := NewConfig()
+	procConf.Type = TypeNoop
+
+	conf.Switch = append(conf.Switch, SwitchCaseConfig{
+		ErrorClasses: []string{"schema"},
+		Processors:   []Config{procConf},
+	}, SwitchCaseConfig{
+		ErrorClasses: []string{"transient", "timeout"},
+		Processors:   []Config{procConf},
+	})
+
+	_, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "case [1] error class not recognised: timeout")
+}
+
 func BenchmarkSwitch10(b *testing.B) {
 	conf := NewConfig()
 	conf.Type = TypeSwitch
@@ -21,7 +21,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2BatchedToV1Processor("try", p, mgr.Metrics()), nil
+			return processor.NewV2BatchedToV1Processor("try", p, mgr), nil
 		},
 		Categories: []string{
 			"Composition",
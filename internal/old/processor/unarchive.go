@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -28,7 +29,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("unarchive", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("unarchive", p, mgr), nil
 		},
 		Categories: []string{
 			"Parsing", "Utility",
@@ -44,11 +45,27 @@ allowing you to [error handle them](/docs/configuration/error_handling).
 
 For the unarchive formats that contain file information (tar, zip), a metadata
 field is added to each message called ` + "`archive_filename`" + ` with the
-extracted filename.`,
+extracted filename. Directory entries of these formats are skipped.
+
+### Large Archives
+
+The files of tar archives are not copied when they're extracted, and instead the
+extracted messages reference the contents of the archive. However, a processor
+results in all of the messages extracted from an archive at once, and therefore
+the entire archive is held in memory until all of them have been processed. In
+order to avoid exhausting memory the field ` + "`max_entry_size`" + ` can be
+used to reject archives containing files that exceed a given size.
+
+When consuming very large tar archives, such as multi-gigabyte objects from S3,
+it's recommended to instead extract files as they're read by the input with the
+` + "`codec`" + ` field, e.g. ` + "`gzip/tar`" + ` or ` + "`tar:x`" + `, where
+files are streamed from the archive and only the file being consumed is held
+in memory.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("format", "The unarchive [format](#formats) to use.").HasOptions(
 				"tar", "zip", "binary", "lines", "json_documents", "json_array", "json_map", "csv",
 			),
+			docs.FieldInt("max_entry_size", "The maximum size in bytes of each file extracted from an archive with a format that contains file information (tar, zip). Archives containing larger files fail to unarchive. Set to zero to disable this limit.").HasDefault(0).Advanced(),
 		),
 		Footnotes: `
 ## Formats
@@ -102,13 +119,15 @@ the file expands its contents into a json object in a new message.`,
 
 // UnarchiveConfig contains configuration fields for the Unarchive processor.
 type UnarchiveConfig struct {
-	Format string `json:"format" yaml:"format"`
+	Format       string `json:"format" yaml:"format"`
+	MaxEntrySize int64  `json:"max_entry_size" yaml:"max_entry_size"`
 }
 
 // NewUnarchiveConfig returns a UnarchiveConfig with default values.
 func NewUnarchiveConfig() UnarchiveConfig {
 	return UnarchiveConfig{
-		Format: "",
+		Format:       "",
+		MaxEntrySize: 0,
 	}
 }
 
@@ -116,65 +135,128 @@ func NewUnarchiveConfig() UnarchiveConfig {
 
 type unarchiveFunc func(part *message.Part) ([]*message.Part, error)
 
-func tarUnarchive(part *message.Part) ([]*message.Part, error) {
-	buf := bytes.NewBuffer(part.Get())
-	tr := tar.NewReader(buf)
+// readEntry reads an extracted file of an archive, returning an error if its
+// declared size or the number of bytes read exceeds a maximum.
+func readEntry(r io.Reader, name string, size, maxSize int64) ([]byte, error) {
+	if maxSize > 0 {
+		if size > maxSize {
+			return nil, fmt.Errorf("archive file %v of size %v exceeds the maximum size of %v", name, size, maxSize)
+		}
+		r = io.LimitReader(r, maxSize+1)
+	}
 
-	var newParts []*message.Part
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && int64(buf.Len()) > maxSize {
+		return nil, fmt.Errorf("archive file %v exceeds the maximum size of %v", name, maxSize)
+	}
+	return buf.Bytes(), nil
+}
 
-	// Iterate through the files in the archive.
-	for {
-		h, err := tr.Next()
-		if err == io.EOF {
-			// end of tar archive
-			break
-		}
-		if err != nil {
-			return nil, err
+// isSparse returns whether the header of a tar file describes a sparse file,
+// the contents of which are not stored contiguously within the archive.
+func isSparse(h *tar.Header) bool {
+	if h.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for k := range h.PAXRecords {
+		if strings.HasPrefix(k, "GNU.sparse.") {
+			return true
 		}
+	}
+	return false
+}
 
-		newPartBuf := bytes.Buffer{}
-		if _, err = newPartBuf.ReadFrom(tr); err != nil {
-			return nil, err
-		}
+func tarUnarchive(maxEntrySize int64) unarchiveFunc {
+	return func(part *message.Part) ([]*message.Part, error) {
+		archive := part.Get()
+		buf := bytes.NewReader(archive)
+		tr := tar.NewReader(buf)
 
-		newPart := part.Copy()
-		newPart.Set(newPartBuf.Bytes())
-		newPart.MetaSet("archive_filename", h.Name)
-		newParts = append(newParts, newPart)
-	}
+		var newParts []*message.Part
 
-	return newParts, nil
-}
+		// Iterate through the files in the archive.
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				// end of tar archive
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if h.Typeflag == tar.TypeDir {
+				continue
+			}
 
-func zipUnarchive(part *message.Part) ([]*message.Part, error) {
-	buf := bytes.NewReader(part.Get())
-	zr, err := zip.NewReader(buf, int64(buf.Len()))
-	if err != nil {
-		return nil, err
-	}
+			var data []byte
+			if (h.Typeflag == tar.TypeReg || h.Typeflag == tar.TypeRegA) && !isSparse(h) {
+				if maxEntrySize > 0 && h.Size > maxEntrySize {
+					return nil, fmt.Errorf("archive file %v of size %v exceeds the maximum size of %v", h.Name, h.Size, maxEntrySize)
+				}
+
+				// The contents of a regular file directly follow its header,
+				// and are therefore referenced within the archive rather
+				// than copied. The capacity is capped so that appending to
+				// the contents can't overwrite the rest of the archive.
+				start := int64(len(archive)) - int64(buf.Len())
+				if h.Size > int64(buf.Len()) {
+					return nil, io.ErrUnexpectedEOF
+				}
+				end := start + h.Size
+				data = archive[start:end:end]
+			} else if data, err = readEntry(tr, h.Name, h.Size, maxEntrySize); err != nil {
+				return nil, err
+			}
 
-	var newParts []*message.Part
+			newPart := part.Copy()
+			newPart.Set(data)
+			newPart.MetaSet("archive_filename", h.Name)
+			newParts = append(newParts, newPart)
+		}
+
+		return newParts, nil
+	}
+}
 
-	// Iterate through the files in the archive.
-	for _, f := range zr.File {
-		fr, err := f.Open()
+func zipUnarchive(maxEntrySize int64) unarchiveFunc {
+	return func(part *message.Part) ([]*message.Part, error) {
+		buf := bytes.NewReader(part.Get())
+		zr, err := zip.NewReader(buf, int64(buf.Len()))
 		if err != nil {
 			return nil, err
 		}
 
-		newPartBuf := bytes.Buffer{}
-		if _, err = newPartBuf.ReadFrom(fr); err != nil {
-			return nil, err
+		var newParts []*message.Part
+
+		// Iterate through the files in the archive.
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			fr, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+
+			// The declared size of a zip file can't be trusted and therefore
+			// the limit is also enforced whilst reading.
+			data, err := readEntry(fr, f.Name, int64(f.UncompressedSize64), maxEntrySize)
+			_ = fr.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			newPart := part.Copy()
+			newPart.Set(data)
+			newPart.MetaSet("archive_filename", f.Name)
+			newParts = append(newParts, newPart)
 		}
 
-		newPart := part.Copy()
-		newPart.Set(newPartBuf.Bytes())
-		newPart.MetaSet("archive_filename", f.Name)
-		newParts = append(newParts, newPart)
+		return newParts, nil
 	}
-
-	return newParts, nil
 }
 
 func binaryUnarchive(part *message.Part) ([]*message.Part, error) {
@@ -316,12 +398,12 @@ func csvUnarchive(part *message.Part) ([]*message.Part, error) {
 	return newParts, nil
 }
 
-func strToUnarchiver(str string) (unarchiveFunc, error) {
+func strToUnarchiver(str string, maxEntrySize int64) (unarchiveFunc, error) {
 	switch str {
 	case "tar":
-		return tarUnarchive, nil
+		return tarUnarchive(maxEntrySize), nil
 	case "zip":
-		return zipUnarchive, nil
+		return zipUnarchive(maxEntrySize), nil
 	case "binary":
 		return binaryUnarchive, nil
 	case "lines":
@@ -346,7 +428,7 @@ type unarchiveProc struct {
 }
 
 func newUnarchive(conf UnarchiveConfig, mgr interop.Manager) (*unarchiveProc, error) {
-	dcor, err := strToUnarchiver(conf.Format)
+	dcor, err := strToUnarchiver(conf.Format, conf.MaxEntrySize)
 	if err != nil {
 		return nil, err
 	}
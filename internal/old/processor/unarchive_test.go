@@ -97,6 +97,55 @@ func TestUnarchiveTar(t *testing.T) {
 	}
 }
 
+func TestUnarchiveTarReferencesArchive(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i, content := range []string{"first", "second"} {
+		if err := tw.WriteHeader(&tar.Header{
+			// Long names result in additional headers before the file.
+			Name: strings.Repeat("a", 150) + fmt.Sprintf("%v", i),
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	unarchive, err := strToUnarchiver("tar", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts, err := unarchive(message.NewPart(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("Wrong count of parts: %v", len(parts))
+	}
+
+	// Appending to the contents of a file must not modify the archive.
+	_ = append(parts[0].Get(), "nope"...)
+	if exp, act := "first", string(parts[0].Get()); exp != act {
+		t.Errorf("Wrong contents: %v != %v", act, exp)
+	}
+	if exp, act := "second", string(parts[1].Get()); exp != act {
+		t.Errorf("Wrong contents: %v != %v", act, exp)
+	}
+
+	// A file that claims more data than the archive contains fails.
+	truncated := buf.Bytes()[:bytes.Index(buf.Bytes(), []byte("first"))+2]
+	if _, err = unarchive(message.NewPart(truncated)); err == nil {
+		t.Error("Expected error from truncated archive")
+	}
+}
+
 func TestUnarchiveZip(t *testing.T) {
 	conf := NewConfig()
 	conf.Type = "unarchive"
@@ -161,6 +210,81 @@ func TestUnarchiveZip(t *testing.T) {
 	}
 }
 
+func TestUnarchiveMaxEntrySize(t *testing.T) {
+	files := []string{"small", "too large"}
+
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for i, content := range files {
+		name := fmt.Sprintf("testfile%v", i)
+		if err := tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0o600,
+			Size: int64(len(content)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if fw, err := zw.Create(name); err != nil {
+			t.Fatal(err)
+		} else if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for format, archive := range map[string][]byte{
+		"tar": tarBuf.Bytes(),
+		"zip": zipBuf.Bytes(),
+	} {
+		for _, test := range []struct {
+			maxSize  int64
+			expFail  bool
+			expFiles int
+		}{
+			{maxSize: 0, expFiles: 2},
+			{maxSize: 9, expFiles: 2},
+			{maxSize: 8, expFail: true, expFiles: 1},
+		} {
+			conf := NewConfig()
+			conf.Type = "unarchive"
+			conf.Unarchive.Format = format
+			conf.Unarchive.MaxEntrySize = test.maxSize
+
+			proc, err := New(conf, mock.NewManager(), log.Noop(), metrics.Noop())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{archive}))
+			if len(msgs) != 1 {
+				t.Fatalf("%v %v: unarchive failed: %v", format, test.maxSize, res)
+			}
+			if test.expFail {
+				if msgs[0].Len() != 1 {
+					t.Errorf("%v %v: expected original message, got %v", format, test.maxSize, msgs[0].Len())
+				}
+				if errStr := GetFail(msgs[0].Get(0)); !strings.Contains(errStr, "exceeds the maximum size of 8") {
+					t.Errorf("%v %v: unexpected error: %v", format, test.maxSize, errStr)
+				}
+				continue
+			}
+			if act := message.GetAllBytes(msgs[0]); len(act) != test.expFiles {
+				t.Errorf("%v %v: unexpected output: %s", format, test.maxSize, act)
+			}
+		}
+	}
+}
+
 func TestUnarchiveLines(t *testing.T) {
 	conf := NewConfig()
 	conf.Type = "unarchive"
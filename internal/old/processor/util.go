@@ -121,10 +121,11 @@ func FlagFail(part *message.Part) {
 }
 
 // FlagErr marks a message part as having failed at a processing step with an
-// error message. If the error is nil the message part remains unchanged.
-func FlagErr(part *message.Part, err error) {
+// error message and the source of the error. If the error is nil the message
+// part remains unchanged.
+func FlagErr(part *message.Part, source string, err error) {
 	if err != nil {
-		part.MetaSet(FailFlagKey, err.Error())
+		processor.SetErrMetadata(part, source, err)
 	}
 }
 
@@ -141,7 +142,7 @@ func HasFailed(part *message.Part) bool {
 
 // ClearFail removes any existing failure flags from a message part.
 func ClearFail(part *message.Part) {
-	part.MetaDelete(FailFlagKey)
+	processor.ClearErrMetadata(part)
 }
 
 //------------------------------------------------------------------------------
@@ -172,9 +173,9 @@ func iterateParts(
 // IteratePartsWithSpanV2 iterates the parts of a message according to a slice
 // of indexes (if empty all parts are iterated) and calls a func for each part
 // along with a tracing span for that part. If an error is returned the part is
-// flagged as failed and the span has the error logged.
+// flagged as failed at the error source and the span has the error logged.
 func IteratePartsWithSpanV2(
-	operationName string, parts []int, msg *message.Batch,
+	operationName, errSource string, parts []int, msg *message.Batch,
 	iter func(int, *tracing.Span, *message.Part) error,
 ) {
 	exec := func(i int) {
@@ -182,7 +183,7 @@ func IteratePartsWithSpanV2(
 		span := tracing.CreateChildSpan(operationName, part)
 
 		if err := iter(i, span, part); err != nil {
-			FlagErr(part, err)
+			FlagErr(part, errSource, err)
 			span.SetTag("error", "true")
 			span.LogKV(
 				"event", "error",
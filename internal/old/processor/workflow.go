@@ -288,7 +288,8 @@ func NewWorkflowConfig() WorkflowConfig {
 // payload mapped from the original, and after processing attempts to overlay
 // the results back onto the original payloads according to more mappings.
 type Workflow struct {
-	log log.Modular
+	log       log.Modular
+	errSource string
 
 	children  *workflowBranchMap
 	allStages map[string]struct{}
@@ -308,6 +309,7 @@ func NewWorkflow(conf WorkflowConfig, mgr interop.Manager) (*Workflow, error) {
 	stats := mgr.Metrics()
 	w := &Workflow{
 		log:       mgr.Logger(),
+		errSource: processor.ErrSource(mgr),
 		metaPath:  nil,
 		allStages: map[string]struct{}{},
 
@@ -476,7 +478,7 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 		w.log.Errorf("Failed to establish workflow: %v\n", err)
 
 		_ = payload.Iter(func(i int, p *message.Part) error {
-			FlagErr(p, err)
+			FlagErr(p, w.errSource, err)
 			return nil
 		})
 		w.mSent.Incr(int64(payload.Len()))
@@ -570,7 +572,7 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 			if err != nil {
 				w.mError.Incr(1)
 				w.log.Errorf("Failed to parse message for meta update: %v\n", err)
-				FlagErr(p, err)
+				FlagErr(p, w.errSource, err)
 				return nil
 			}
 
@@ -593,7 +595,7 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 					failed = append(failed, k)
 				}
 				sort.Strings(failed)
-				FlagErr(p, fmt.Errorf("workflow branches failed: %v", failed))
+				FlagErr(p, w.errSource, fmt.Errorf("workflow branches failed: %v", failed))
 			}
 			return nil
 		})
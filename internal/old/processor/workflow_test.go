@@ -392,6 +392,8 @@ func TestWorkflows(t *testing.T) {
 					`not even a json object`,
 					processor.FailFlagKey,
 					"invalid character 'o' in literal null (expecting 'u')",
+					message.ErrorClassKey, "schema",
+					message.ErrorCodeKey, "json_syntax",
 				),
 			},
 		},
@@ -20,7 +20,7 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return processor.NewV2ToV1Processor("xml", p, mgr.Metrics()), nil
+			return processor.NewV2ToV1Processor("xml", p, mgr), nil
 		},
 		Status: docs.StatusBeta,
 		Categories: []string{
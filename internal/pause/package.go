@@ -0,0 +1,4 @@
+// Package pause implements a mechanism for pausing the flow of transactions
+// through individual inputs and outputs at runtime, which applies back pressure
+// upstream without closing any components.
+package pause
@@ -0,0 +1,240 @@
+package pause
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Component describes an input or output that can be paused.
+type Component struct {
+	Stream string `json:"stream,omitempty"`
+	Path   string `json:"path"`
+	Label  string `json:"label"`
+	Kind   string `json:"kind"`
+	Paused bool   `json:"paused"`
+}
+
+// Gate blocks the flow of transactions through a component whilst it is
+// paused.
+type Gate struct {
+	info Component
+
+	mut     sync.Mutex
+	paused  bool
+	resumed chan struct{}
+}
+
+func newGate(info Component) *Gate {
+	return &Gate{info: info}
+}
+
+// Pause the gate, subsequent calls to Wait block until the gate is resumed.
+func (g *Gate) Pause() {
+	g.mut.Lock()
+	if !g.paused {
+		g.paused = true
+		g.resumed = make(chan struct{})
+	}
+	g.mut.Unlock()
+}
+
+// Resume the gate, unblocking any pending calls to Wait.
+func (g *Gate) Resume() {
+	g.mut.Lock()
+	if g.paused {
+		g.paused = false
+		close(g.resumed)
+	}
+	g.mut.Unlock()
+}
+
+// Paused returns whether the gate is currently paused.
+func (g *Gate) Paused() bool {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	return g.paused
+}
+
+// Wait blocks whilst the gate is paused. Returns false if the done channel is
+// closed before the gate is resumed.
+func (g *Gate) Wait(done <-chan struct{}) bool {
+	g.mut.Lock()
+	if !g.paused {
+		g.mut.Unlock()
+		return true
+	}
+	resumed := g.resumed
+	g.mut.Unlock()
+
+	select {
+	case <-resumed:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type gateKey struct {
+	stream string
+	path   string
+}
+
+// Registry keeps track of the gates of inputs and outputs across a Benthos
+// instance so that they can be paused and resumed centrally.
+type Registry struct {
+	mut   sync.RWMutex
+	gates map[gateKey]*Gate
+}
+
+// NewRegistry returns an empty pause registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		gates: map[gateKey]*Gate{},
+	}
+}
+
+// Add creates a gate for a component, replacing any prior gate registered for
+// the same stream and path.
+func (r *Registry) Add(info Component) *Gate {
+	info.Paused = false
+	g := newGate(info)
+
+	r.mut.Lock()
+	r.gates[gateKey{stream: info.Stream, path: info.Path}] = g
+	r.mut.Unlock()
+	return g
+}
+
+// Remove a gate from the registry, which is a no-op if the gate has since
+// been replaced.
+func (r *Registry) Remove(g *Gate) {
+	key := gateKey{stream: g.info.Stream, path: g.info.Path}
+
+	r.mut.Lock()
+	if r.gates[key] == g {
+		delete(r.gates, key)
+	}
+	r.mut.Unlock()
+}
+
+// Components returns all registered components ordered by stream and path.
+// When a non-empty stream is provided only the components of that stream are
+// returned.
+func (r *Registry) Components(stream string) []Component {
+	r.mut.RLock()
+	components := make([]Component, 0, len(r.gates))
+	for _, g := range r.gates {
+		if stream != "" && g.info.Stream != stream {
+			continue
+		}
+		c := g.info
+		c.Paused = g.Paused()
+		components = append(components, c)
+	}
+	r.mut.RUnlock()
+
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Stream != components[j].Stream {
+			return components[i].Stream < components[j].Stream
+		}
+		return components[i].Path < components[j].Path
+	})
+	return components
+}
+
+// find returns the gates of a stream matching a label or path.
+func (r *Registry) find(stream, label, path string) []*Gate {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+
+	var gates []*Gate
+	for k, g := range r.gates {
+		if k.stream != stream {
+			continue
+		}
+		if (label != "" && g.info.Label == label) || (path != "" && g.info.Path == path) {
+			gates = append(gates, g)
+		}
+	}
+	return gates
+}
+
+// HandleList is an http.HandlerFunc that writes all registered components as a
+// JSON array. The optional query parameter `stream` filters components by
+// stream.
+func (r *Registry) HandleList(w http.ResponseWriter, req *http.Request) {
+	resBytes, err := json.Marshal(r.Components(req.URL.Query().Get("stream")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resBytes)
+}
+
+func (r *Registry) handleSet(pause bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			r.HandleList(w, req)
+			return
+		}
+		if req.Method != http.MethodPost {
+			http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := req.URL.Query()
+		label, path := query.Get("label"), query.Get("path")
+		if label == "" && path == "" {
+			http.Error(w, "Query parameter label or path is required", http.StatusBadRequest)
+			return
+		}
+
+		gates := r.find(query.Get("stream"), label, path)
+		if len(gates) == 0 {
+			http.Error(w, fmt.Sprintf("Component not found: %v%v", label, path), http.StatusNotFound)
+			return
+		}
+
+		components := make([]Component, 0, len(gates))
+		for _, g := range gates {
+			if pause {
+				g.Pause()
+			} else {
+				g.Resume()
+			}
+			c := g.info
+			c.Paused = g.Paused()
+			components = append(components, c)
+		}
+
+		resBytes, err := json.Marshal(components)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
+
+// HandlePause is an http.HandlerFunc that pauses the components matching the
+// query parameter `label` or `path`, and the optional query parameter `stream`,
+// and writes the paused components as a JSON array. GET requests list all
+// components instead.
+func (r *Registry) HandlePause(w http.ResponseWriter, req *http.Request) {
+	r.handleSet(true)(w, req)
+}
+
+// HandleResume is an http.HandlerFunc that resumes the components matching the
+// query parameter `label` or `path`, and the optional query parameter
+// `stream`, and writes the resumed components as a JSON array. GET requests
+// list all components instead.
+func (r *Registry) HandleResume(w http.ResponseWriter, req *http.Request) {
+	r.handleSet(false)(w, req)
+}
@@ -0,0 +1,223 @@
+package pause
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestGateWait(t *testing.T) {
+	g := newGate(Component{})
+	assert.True(t, g.Wait(nil))
+
+	g.Pause()
+	assert.True(t, g.Paused())
+
+	done := make(chan struct{})
+	close(done)
+	assert.False(t, g.Wait(done))
+
+	resultChan := make(chan bool)
+	go func() {
+		resultChan <- g.Wait(nil)
+	}()
+
+	select {
+	case <-resultChan:
+		t.Fatal("wait returned whilst paused")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	g.Resume()
+	assert.False(t, g.Paused())
+	select {
+	case res := <-resultChan:
+		assert.True(t, res)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestRegistryHandlers(t *testing.T) {
+	r := NewRegistry()
+	r.Add(Component{Stream: "a", Path: "root.input", Label: "foo", Kind: "input"})
+	r.Add(Component{Stream: "a", Path: "root.output", Label: "bar", Kind: "output"})
+	r.Add(Component{Stream: "b", Path: "root.input", Label: "foo", Kind: "input"})
+
+	w := httptest.NewRecorder()
+	r.HandlePause(w, httptest.NewRequest("POST", "/pause?stream=a&label=foo", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var components []Component
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &components))
+	require.Len(t, components, 1)
+	assert.Equal(t, "a", components[0].Stream)
+	assert.True(t, components[0].Paused)
+
+	w = httptest.NewRecorder()
+	r.HandleList(w, httptest.NewRequest("GET", "/pause", nil))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	components = nil
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &components))
+	var summary []string
+	for _, c := range components {
+		summary = append(summary, c.Stream+"/"+c.Path+"="+map[bool]string{true: "paused", false: "running"}[c.Paused])
+	}
+	assert.Equal(t, []string{
+		"a/root.input=paused",
+		"a/root.output=running",
+		"b/root.input=running",
+	}, summary)
+
+	w = httptest.NewRecorder()
+	r.HandleResume(w, httptest.NewRequest("POST", "/resume?stream=a&path=root.input", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	for _, c := range r.Components("a") {
+		assert.False(t, c.Paused)
+	}
+
+	w = httptest.NewRecorder()
+	r.HandlePause(w, httptest.NewRequest("POST", "/pause?label=nope", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.HandlePause(w, httptest.NewRequest("POST", "/pause", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	r.HandlePause(w, httptest.NewRequest("DELETE", "/pause?label=foo", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestWrapInput(t *testing.T) {
+	r := NewRegistry()
+
+	inChan := make(chan message.Transaction)
+	in := r.WrapInput(Component{Path: "root.input", Label: "foo"}, &mock.Input{TChan: inChan})
+
+	components := r.Components("")
+	require.Len(t, components, 1)
+	assert.Equal(t, "input", components[0].Kind)
+
+	resChan := make(chan error, 2)
+	sendTran := func(content string) {
+		select {
+		case inChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+	readTran := func() message.Transaction {
+		select {
+		case tran := <-in.TransactionChan():
+			return tran
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return message.Transaction{}
+	}
+
+	// The child input is only read from once the wrapper is consumed.
+	_ = in.TransactionChan()
+
+	sendTran("first")
+	assert.Equal(t, "first", string(readTran().Payload.Get(0).Get()))
+
+	r.find("", "foo", "")[0].Pause()
+
+	// A paused input holds at most one transaction read from the child input,
+	// and therefore applies back pressure.
+	go func() {
+		inChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("second")}), resChan)
+	}()
+	select {
+	case <-in.TransactionChan():
+		t.Fatal("expected no transactions whilst paused")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	r.find("", "foo", "")[0].Resume()
+	assert.Equal(t, "second", string(readTran().Payload.Get(0).Get()))
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+	assert.Empty(t, r.Components(""))
+}
+
+func TestWrapInputNotConsumed(t *testing.T) {
+	r := NewRegistry()
+
+	in := r.WrapInput(Component{Path: "root.input", Label: "foo"}, &mock.Input{TChan: make(chan message.Transaction)})
+	require.Len(t, r.Components(""), 1)
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+	assert.Empty(t, r.Components(""))
+
+	_, open := <-in.TransactionChan()
+	assert.False(t, open)
+}
+
+func TestWrapOutput(t *testing.T) {
+	r := NewRegistry()
+
+	child := &mock.OutputChanneled{}
+	out := r.WrapOutput(Component{Path: "root.output", Label: "bar"}, child)
+
+	tranChan := make(chan message.Transaction)
+	require.NoError(t, out.Consume(tranChan))
+
+	resChan := make(chan error, 2)
+	sendTran := func(content string) {
+		go func() {
+			tranChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan)
+		}()
+	}
+	readTran := func() message.Transaction {
+		select {
+		case tran := <-child.TChan:
+			return tran
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return message.Transaction{}
+	}
+
+	sendTran("first")
+	assert.Equal(t, "first", string(readTran().Payload.Get(0).Get()))
+
+	gates := r.find("", "", "root.output")
+	require.Len(t, gates, 1)
+	gates[0].Pause()
+
+	sendTran("second")
+	select {
+	case <-child.TChan:
+		t.Fatal("expected no transactions whilst paused")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	gates[0].Resume()
+	assert.Equal(t, "second", string(readTran().Payload.Get(0).Get()))
+
+	close(tranChan)
+	select {
+	case _, open := <-child.TChan:
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	out.CloseAsync()
+	require.NoError(t, out.WaitForClose(time.Second))
+	assert.Empty(t, r.Components(""))
+}
@@ -0,0 +1,212 @@
+package pause
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// nackClosed rejects a transaction that was read but not dispatched before the
+// component was closed.
+func nackClosed(shutSig *shutdown.Signaller, tran message.Transaction) {
+	ctx, done := shutSig.CloseNowCtx(context.Background())
+	_ = tran.Ack(ctx, component.ErrTypeClosed)
+	done()
+}
+
+//------------------------------------------------------------------------------
+
+var _ input.Streamed = &pausableInput{}
+
+type pausableInput struct {
+	input input.Streamed
+	gate  *Gate
+	reg   *Registry
+
+	startOnce sync.Once
+	tranChan  chan message.Transaction
+	shutSig   *shutdown.Signaller
+}
+
+// WrapInput returns an input that stops reading transactions from a child
+// input whilst the gate registered for it is paused.
+func (r *Registry) WrapInput(info Component, i input.Streamed) input.Streamed {
+	info.Kind = "input"
+	return &pausableInput{
+		input:    i,
+		gate:     r.Add(info),
+		reg:      r,
+		tranChan: make(chan message.Transaction),
+		shutSig:  shutdown.NewSignaller(),
+	}
+}
+
+func (p *pausableInput) stopped() {
+	p.reg.Remove(p.gate)
+	close(p.tranChan)
+	p.shutSig.ShutdownComplete()
+}
+
+func (p *pausableInput) loop(inChan <-chan message.Transaction) {
+	defer p.stopped()
+
+	closeChan := p.shutSig.CloseAtLeisureChan()
+	for {
+		// The gate is checked before reading a transaction so that at most
+		// one transaction is held whilst paused.
+		if !p.gate.Wait(closeChan) {
+			return
+		}
+
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-inChan:
+			if !open {
+				return
+			}
+		case <-closeChan:
+			return
+		}
+
+		// The gate may have been paused whilst waiting for the transaction.
+		if !p.gate.Wait(closeChan) {
+			nackClosed(p.shutSig, tran)
+			return
+		}
+
+		select {
+		case p.tranChan <- tran:
+		case <-closeChan:
+			nackClosed(p.shutSig, tran)
+			return
+		}
+	}
+}
+
+// TransactionChan starts reading from the child input on the first call, which
+// avoids touching the child before the input is actually consumed.
+func (p *pausableInput) TransactionChan() <-chan message.Transaction {
+	p.startOnce.Do(func() {
+		go p.loop(p.input.TransactionChan())
+	})
+	return p.tranChan
+}
+
+func (p *pausableInput) Connected() bool {
+	return p.input.Connected()
+}
+
+func (p *pausableInput) CloseAsync() {
+	p.reg.Remove(p.gate)
+	p.input.CloseAsync()
+	p.shutSig.CloseAtLeisure()
+
+	// If the input was never consumed then there is no loop to shut down.
+	p.startOnce.Do(p.stopped)
+}
+
+func (p *pausableInput) WaitForClose(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	if err := p.input.WaitForClose(timeout); err != nil {
+		return err
+	}
+	select {
+	case <-p.shutSig.HasClosedChan():
+	case <-time.After(time.Until(deadline)):
+		return component.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var _ output.Streamed = &pausableOutput{}
+
+type pausableOutput struct {
+	output output.Streamed
+	gate   *Gate
+	reg    *Registry
+
+	shutSig *shutdown.Signaller
+}
+
+// WrapOutput returns an output that stops dispatching transactions to a child
+// output whilst the gate registered for it is paused.
+func (r *Registry) WrapOutput(info Component, o output.Streamed) output.Streamed {
+	info.Kind = "output"
+	return &pausableOutput{
+		output:  o,
+		gate:    r.Add(info),
+		reg:     r,
+		shutSig: shutdown.NewSignaller(),
+	}
+}
+
+func (p *pausableOutput) loop(inChan <-chan message.Transaction, outChan chan<- message.Transaction) {
+	defer func() {
+		p.reg.Remove(p.gate)
+		close(outChan)
+		p.shutSig.ShutdownComplete()
+	}()
+
+	closeChan := p.shutSig.CloseAtLeisureChan()
+	for {
+		if !p.gate.Wait(closeChan) {
+			return
+		}
+
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-inChan:
+			if !open {
+				return
+			}
+		case <-closeChan:
+			return
+		}
+
+		// The gate may have been paused whilst waiting for the transaction.
+		if !p.gate.Wait(closeChan) {
+			nackClosed(p.shutSig, tran)
+			return
+		}
+
+		select {
+		case outChan <- tran:
+		case <-closeChan:
+			nackClosed(p.shutSig, tran)
+			return
+		}
+	}
+}
+
+func (p *pausableOutput) Consume(ts <-chan message.Transaction) error {
+	outChan := make(chan message.Transaction)
+	if err := p.output.Consume(outChan); err != nil {
+		return err
+	}
+	go p.loop(ts, outChan)
+	return nil
+}
+
+func (p *pausableOutput) Connected() bool {
+	return p.output.Connected()
+}
+
+func (p *pausableOutput) CloseAsync() {
+	p.reg.Remove(p.gate)
+	p.shutSig.CloseAtLeisure()
+	p.output.CloseAsync()
+}
+
+func (p *pausableOutput) WaitForClose(timeout time.Duration) error {
+	return p.output.WaitForClose(timeout)
+}
@@ -0,0 +1,422 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// AutoscaleConfig contains configuration fields for scaling the number of
+// processing threads of a pipeline with its load.
+type AutoscaleConfig struct {
+	Enabled       bool   `json:"enabled" yaml:"enabled"`
+	MinThreads    int    `json:"min_threads" yaml:"min_threads"`
+	MaxThreads    int    `json:"max_threads" yaml:"max_threads"`
+	TargetLatency string `json:"target_latency" yaml:"target_latency"`
+	Interval      string `json:"interval" yaml:"interval"`
+}
+
+// NewAutoscaleConfig returns an AutoscaleConfig with default values.
+func NewAutoscaleConfig() AutoscaleConfig {
+	return AutoscaleConfig{
+		Enabled:       false,
+		MinThreads:    1,
+		MaxThreads:    -1,
+		TargetLatency: "10ms",
+		Interval:      "10s",
+	}
+}
+
+// autoscaleIdleUtilisation is the proportion of time that threads spend
+// processing below which a thread is removed from the pool, and at or above
+// which a thread may be added.
+const autoscaleIdleUtilisation = 0.5
+
+//------------------------------------------------------------------------------
+
+// processingTimer accumulates the time that a thread spends executing
+// processors, which excludes time spent blocked sending results downstream.
+type processingTimer struct {
+	mut     sync.Mutex
+	busy    time.Duration
+	started time.Time
+}
+
+func (t *processingTimer) start() {
+	t.mut.Lock()
+	t.started = time.Now()
+	t.mut.Unlock()
+}
+
+func (t *processingTimer) stop() {
+	t.mut.Lock()
+	t.busy += time.Since(t.started)
+	t.started = time.Time{}
+	t.mut.Unlock()
+}
+
+// collect returns the time spent processing since the last call, including
+// the time so far of a processor that is still executing.
+func (t *processingTimer) collect() time.Duration {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	busy := t.busy
+	t.busy = 0
+	if !t.started.IsZero() {
+		now := time.Now()
+		busy += now.Sub(t.started)
+		t.started = now
+	}
+	return busy
+}
+
+// sharedProcessor wraps a processor that is shared by the threads of an
+// autoscaling pool, where removing a thread must not close the processor, and
+// measures the time that the thread spends executing it.
+type sharedProcessor struct {
+	iprocessor.V1
+	timer *processingTimer
+}
+
+func (s sharedProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	s.timer.start()
+	defer s.timer.stop()
+	return s.V1.ProcessMessage(msg)
+}
+
+func (s sharedProcessor) CloseAsync() {}
+
+func (s sharedProcessor) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type autoscaleWorker struct {
+	proc  *Processor
+	timer *processingTimer
+	stop  chan struct{}
+}
+
+// AutoscalePool is a pool of processing threads that all read from a shared
+// transaction channel, where the number of threads grows when transactions
+// wait longer than a target latency for a thread to become available while
+// threads are busy processing, and shrinks when threads are mostly idle.
+type AutoscalePool struct {
+	msgProcessors []iprocessor.V1
+
+	minThreads    int
+	maxThreads    int
+	targetLatency time.Duration
+	interval      time.Duration
+
+	log      log.Modular
+	mThreads metrics.StatGauge
+	mLatency metrics.StatTimer
+
+	latencyNanos int64
+	latencyCount int64
+
+	workersMut sync.Mutex
+	workers    []*autoscaleWorker
+	workersWG  sync.WaitGroup
+
+	messagesIn  <-chan message.Transaction
+	workChan    chan message.Transaction
+	messagesOut chan message.Transaction
+
+	shutSig *shutdown.Signaller
+}
+
+func newAutoscalePool(threads int, conf AutoscaleConfig, log log.Modular, stats metrics.Type, msgProcessors ...iprocessor.V1) (*AutoscalePool, error) {
+	p := &AutoscalePool{
+		msgProcessors: msgProcessors,
+		minThreads:    conf.MinThreads,
+		maxThreads:    conf.MaxThreads,
+		log:           log,
+		mThreads:      stats.GetGauge("pipeline_threads"),
+		mLatency:      stats.GetTimer("pipeline_queue_latency_ns"),
+		workChan:      make(chan message.Transaction),
+		messagesOut:   make(chan message.Transaction),
+		shutSig:       shutdown.NewSignaller(),
+	}
+	if p.maxThreads <= 0 {
+		p.maxThreads = runtime.NumCPU()
+	}
+	if p.minThreads <= 0 {
+		return nil, errors.New("autoscale min_threads must be greater than zero")
+	}
+	if p.minThreads > p.maxThreads {
+		return nil, fmt.Errorf("autoscale min_threads (%v) must not be greater than max_threads (%v)", p.minThreads, p.maxThreads)
+	}
+
+	var err error
+	if p.targetLatency, err = time.ParseDuration(conf.TargetLatency); err != nil {
+		return nil, fmt.Errorf("failed to parse autoscale target_latency: %w", err)
+	}
+	if p.interval, err = time.ParseDuration(conf.Interval); err != nil {
+		return nil, fmt.Errorf("failed to parse autoscale interval: %w", err)
+	}
+	if p.interval <= 0 {
+		return nil, errors.New("autoscale interval must be greater than zero")
+	}
+
+	// The number of threads configured for the pipeline is used as the initial
+	// number of threads.
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	if threads < p.minThreads {
+		threads = p.minThreads
+	}
+	if threads > p.maxThreads {
+		threads = p.maxThreads
+	}
+	for i := 0; i < threads; i++ {
+		p.addWorker()
+	}
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Threads returns the current number of processing threads of the pool.
+func (p *AutoscalePool) Threads() int {
+	p.workersMut.Lock()
+	defer p.workersMut.Unlock()
+	return len(p.workers)
+}
+
+func (p *AutoscalePool) addWorker() {
+	timer := &processingTimer{}
+	procs := make([]iprocessor.V1, len(p.msgProcessors))
+	for i, proc := range p.msgProcessors {
+		procs[i] = sharedProcessor{V1: proc, timer: timer}
+	}
+
+	w := &autoscaleWorker{
+		proc:  NewProcessor(procs...),
+		timer: timer,
+		stop:  make(chan struct{}),
+	}
+
+	p.workersMut.Lock()
+	p.workers = append(p.workers, w)
+	p.mThreads.Set(int64(len(p.workers)))
+	p.workersMut.Unlock()
+
+	p.workersWG.Add(2)
+	go p.runWorker(w)
+	go p.forwardWorker(w)
+}
+
+func (p *AutoscalePool) removeWorker() {
+	p.workersMut.Lock()
+	w := p.workers[len(p.workers)-1]
+	p.workers = p.workers[:len(p.workers)-1]
+	p.mThreads.Set(int64(len(p.workers)))
+	p.workersMut.Unlock()
+
+	close(w.stop)
+}
+
+// runWorker executes transactions from the shared work channel until the
+// worker is stopped, which only happens between transactions.
+func (p *AutoscalePool) runWorker(w *autoscaleWorker) {
+	defer func() {
+		close(w.proc.messagesOut)
+		w.proc.shutSig.ShutdownComplete()
+		p.workersWG.Done()
+	}()
+
+	closeCtx, done := w.proc.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-p.workChan:
+			if !open {
+				return
+			}
+		case <-w.stop:
+			return
+		case <-w.proc.shutSig.CloseAtLeisureChan():
+			return
+		}
+
+		if !w.proc.dispatchTransaction(closeCtx, tran) {
+			return
+		}
+	}
+}
+
+// forwardWorker sends the transactions dispatched by a worker to the output
+// channel of the pool.
+func (p *AutoscalePool) forwardWorker(w *autoscaleWorker) {
+	defer p.workersWG.Done()
+	for {
+		select {
+		case t, open := <-w.proc.messagesOut:
+			if !open {
+				return
+			}
+			select {
+			case p.messagesOut <- t:
+			case <-p.shutSig.CloseAtLeisureChan():
+				return
+			}
+		case <-p.shutSig.CloseAtLeisureChan():
+			return
+		}
+	}
+}
+
+// scale adds a thread when transactions waited longer than the target latency
+// on average over the last interval, and removes a thread when the threads
+// were mostly idle. A transaction that is still waiting for a thread is
+// included in the average with the time waited so far.
+//
+// Utilisation only counts the time that threads spend executing processors,
+// and therefore threads that are blocked by a slow output do not cause the
+// pool to grow, as adding threads would not increase throughput.
+func (p *AutoscalePool) scale(waiting time.Duration) {
+	latencyNanos := atomic.SwapInt64(&p.latencyNanos, 0)
+	latencyCount := atomic.SwapInt64(&p.latencyCount, 0)
+	if waiting > 0 {
+		latencyNanos += int64(waiting)
+		latencyCount++
+	}
+
+	p.workersMut.Lock()
+	threads := len(p.workers)
+	var busy time.Duration
+	for _, w := range p.workers {
+		busy += w.timer.collect()
+	}
+	p.workersMut.Unlock()
+
+	utilisation := float64(busy) / float64(int64(threads)*int64(p.interval))
+
+	switch {
+	case utilisation >= autoscaleIdleUtilisation &&
+		latencyCount > 0 && time.Duration(latencyNanos/latencyCount) > p.targetLatency:
+		if threads < p.maxThreads {
+			p.log.Debugf("Increasing pipeline threads to %v\n", threads+1)
+			p.addWorker()
+		}
+	case utilisation < autoscaleIdleUtilisation:
+		if threads > p.minThreads {
+			p.log.Debugf("Decreasing pipeline threads to %v\n", threads-1)
+			p.removeWorker()
+		}
+	}
+}
+
+// loop dispatches transactions to the threads of the pool, measuring the time
+// taken for a thread to become available, and scales the pool periodically.
+func (p *AutoscalePool) loop() {
+	defer func() {
+		p.shutSig.CloseAtLeisure()
+
+		p.workersMut.Lock()
+		for _, w := range p.workers {
+			w.proc.shutSig.CloseAtLeisure()
+		}
+		p.workersMut.Unlock()
+		p.workersWG.Wait()
+
+		for _, c := range p.msgProcessors {
+			c.CloseAsync()
+		}
+		for _, c := range p.msgProcessors {
+			_ = c.WaitForClose(shutdown.MaximumShutdownWait())
+		}
+
+		close(p.messagesOut)
+		p.shutSig.ShutdownComplete()
+	}()
+
+	scaleTicker := time.NewTicker(p.interval)
+	defer scaleTicker.Stop()
+
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-p.messagesIn:
+			if !open {
+				// Allow the workers to finish their current transactions.
+				close(p.workChan)
+				p.workersWG.Wait()
+				return
+			}
+		case <-scaleTicker.C:
+			p.scale(0)
+			continue
+		case <-p.shutSig.CloseAtLeisureChan():
+			return
+		}
+
+		waitStarted := time.Now()
+	dispatch:
+		for {
+			select {
+			case p.workChan <- tran:
+				break dispatch
+			case <-scaleTicker.C:
+				p.scale(time.Since(waitStarted))
+			case <-p.shutSig.CloseAtLeisureChan():
+				return
+			}
+		}
+		waited := time.Since(waitStarted)
+		p.mLatency.Timing(waited.Nanoseconds())
+		atomic.AddInt64(&p.latencyNanos, int64(waited))
+		atomic.AddInt64(&p.latencyCount, 1)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a messages channel for the pipeline to read.
+func (p *AutoscalePool) Consume(msgs <-chan message.Transaction) error {
+	if p.messagesIn != nil {
+		return component.ErrAlreadyStarted
+	}
+	p.messagesIn = msgs
+	go p.loop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages from this
+// pipeline.
+func (p *AutoscalePool) TransactionChan() <-chan message.Transaction {
+	return p.messagesOut
+}
+
+// CloseAsync shuts down the pipeline and stops processing messages.
+func (p *AutoscalePool) CloseAsync() {
+	p.shutSig.CloseAtLeisure()
+}
+
+// WaitForClose blocks until the pipeline has closed down.
+func (p *AutoscalePool) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-p.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
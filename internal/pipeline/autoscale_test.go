@@ -0,0 +1,177 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestAutoscalePoolScaling(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	conf := NewAutoscaleConfig()
+	conf.Enabled = true
+	conf.MinThreads = 1
+	conf.MaxThreads = 3
+	conf.TargetLatency = "1ms"
+	conf.Interval = "20ms"
+
+	mockProc := &mockMsgProcessor{dropChan: make(chan bool)}
+
+	pool, err := newAutoscalePool(1, conf, log.Noop(), metrics.Noop(), mockProc)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pool.Threads())
+
+	tChan, resChan := make(chan message.Transaction), make(chan error)
+	require.NoError(t, pool.Consume(tChan))
+
+	// Each transaction blocks a thread until the processor is released, and
+	// therefore the pool should grow until all three are being processed.
+	go func() {
+		for i := 0; i < 3; i++ {
+			select {
+			case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), resChan):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	require.Eventually(t, func() bool {
+		return pool.Threads() == 3
+	}, time.Second*5, time.Millisecond*5)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case mockProc.dropChan <- false:
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case tran := <-pool.TransactionChan():
+			assert.Equal(t, "foo", string(tran.Payload.Get(0).Get()))
+			go func() {
+				require.NoError(t, tran.Ack(ctx, nil))
+			}()
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			assert.NoError(t, res)
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	// Without any load the pool should shrink back to the minimum.
+	require.Eventually(t, func() bool {
+		return pool.Threads() == 1
+	}, time.Second*5, time.Millisecond*5)
+
+	pool.CloseAsync()
+	require.NoError(t, pool.WaitForClose(time.Second*5))
+
+	mockProc.mut.Lock()
+	assert.True(t, mockProc.hasClosedAsync)
+	assert.True(t, mockProc.hasWaitedForClose)
+	mockProc.mut.Unlock()
+}
+
+func TestAutoscalePoolSlowOutput(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	conf := NewAutoscaleConfig()
+	conf.Enabled = true
+	conf.MinThreads = 1
+	conf.MaxThreads = 3
+	conf.TargetLatency = "1ms"
+	conf.Interval = "20ms"
+
+	mockProc := &mockMsgProcessor{dropChan: make(chan bool)}
+	go func() {
+		for {
+			select {
+			case mockProc.dropChan <- false:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	pool, err := newAutoscalePool(1, conf, log.Noop(), metrics.Noop(), mockProc)
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, pool.Consume(tChan))
+
+	go func() {
+		for {
+			select {
+			case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), make(chan error, 1)):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Processing is instant but the output only reads a transaction every
+	// 50ms, which blocks the thread and therefore transactions wait longer
+	// than the target latency, but adding threads would not help.
+	for i := 0; i < 10; i++ {
+		select {
+		case tran := <-pool.TransactionChan():
+			require.NoError(t, tran.Ack(ctx, nil))
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+		assert.Equal(t, 1, pool.Threads())
+		<-time.After(time.Millisecond * 50)
+	}
+
+	pool.CloseAsync()
+	require.NoError(t, pool.WaitForClose(time.Second*5))
+}
+
+func TestAutoscalePoolInputClosed(t *testing.T) {
+	conf := NewAutoscaleConfig()
+	conf.Enabled = true
+	conf.MaxThreads = 2
+
+	mockProc := &mockMsgProcessor{dropChan: make(chan bool)}
+
+	pool, err := newAutoscalePool(2, conf, log.Noop(), metrics.Noop(), mockProc)
+	require.NoError(t, err)
+	assert.Equal(t, 2, pool.Threads())
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, pool.Consume(tChan))
+	close(tChan)
+
+	select {
+	case _, open := <-pool.TransactionChan():
+		assert.False(t, open)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	require.NoError(t, pool.WaitForClose(time.Second*5))
+}
+
+func TestAutoscalePoolBadConfig(t *testing.T) {
+	conf := NewAutoscaleConfig()
+	conf.MinThreads = 3
+	conf.MaxThreads = 2
+
+	_, err := newAutoscalePool(1, conf, log.Noop(), metrics.Noop())
+	require.Error(t, err)
+}
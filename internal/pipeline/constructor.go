@@ -18,6 +18,7 @@ import (
 // threads, or use a memory buffer.
 type Config struct {
 	Threads    int                `json:"threads" yaml:"threads"`
+	Autoscale  AutoscaleConfig    `json:"autoscale" yaml:"autoscale"`
 	Processors []processor.Config `json:"processors" yaml:"processors"`
 }
 
@@ -25,6 +26,7 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Threads:    -1,
+		Autoscale:  NewAutoscaleConfig(),
 		Processors: []processor.Config{},
 	}
 }
@@ -42,6 +44,9 @@ func New(conf Config, mgr interop.Manager) (Type, error) {
 			return nil, err
 		}
 	}
+	if conf.Autoscale.Enabled {
+		return newAutoscalePool(conf.Threads, conf.Autoscale, mgr.Logger(), mgr.Metrics(), processors...)
+	}
 	if conf.Threads == 1 {
 		return NewProcessor(processors...), nil
 	}
@@ -68,24 +68,34 @@ func (p *Processor) loop() {
 			return
 		}
 
-		resultMsgs, resultRes := processor.ExecuteAll(p.msgProcessors, tran.Payload)
-		if len(resultMsgs) == 0 {
-			if err := tran.Ack(closeCtx, resultRes); err != nil && closeCtx.Err() != nil {
-				return
-			}
-			continue
+		if !p.dispatchTransaction(closeCtx, tran) {
+			return
 		}
+	}
+}
 
-		if len(resultMsgs) > 1 {
-			p.dispatchMessages(closeCtx, resultMsgs, tran.Ack)
-		} else {
-			select {
-			case p.messagesOut <- message.NewTransactionFunc(resultMsgs[0], tran.Ack):
-			case <-p.shutSig.CloseAtLeisureChan():
-				return
-			}
+// dispatchTransaction executes the processors of the pipeline on the payload
+// of a transaction and dispatches the results, returning false if the pipeline
+// is closing.
+func (p *Processor) dispatchTransaction(ctx context.Context, tran message.Transaction) bool {
+	resultMsgs, resultRes := processor.ExecuteAll(p.msgProcessors, tran.Payload)
+	if len(resultMsgs) == 0 {
+		if err := tran.Ack(ctx, resultRes); err != nil && ctx.Err() != nil {
+			return false
 		}
+		return true
+	}
+
+	if len(resultMsgs) > 1 {
+		p.dispatchMessages(ctx, resultMsgs, tran.Ack)
+		return true
+	}
+	select {
+	case p.messagesOut <- message.NewTransactionFunc(resultMsgs[0], tran.Ack):
+	case <-p.shutSig.CloseAtLeisureChan():
+		return false
 	}
+	return true
 }
 
 // dispatchMessages attempts to send a multiple messages results of processors
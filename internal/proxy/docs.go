@@ -0,0 +1,19 @@
+package proxy
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// FieldSpec returns a spec for a common proxy field.
+func FieldSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"proxy", "Custom proxy settings for HTTP requests, which take precedence over the environment variables `HTTP_PROXY`, `HTTPS_PROXY` and `NO_PROXY`. Requests to localhost are never proxied.",
+	).WithChildren(
+		docs.FieldString(
+			"url", "An optional URL of a proxy to route all HTTP and HTTPS requests through, which can be an HTTP, HTTPS or SOCKS5 proxy.",
+			"http://proxy.example.com:3128", "socks5://proxy.example.com:1080",
+		).HasDefault(""),
+		docs.FieldString(
+			"no_proxy", "An optional comma separated list of hosts, domains, IP addresses or CIDR ranges that should not be proxied, following the same format as the environment variable `NO_PROXY`.",
+			"localhost,.internal.example.com,10.0.0.0/8",
+		).HasDefault(""),
+	).Advanced()
+}
@@ -0,0 +1,3 @@
+// Package proxy provides Benthos configuration fields for routing the requests
+// of HTTP clients through a proxy.
+package proxy
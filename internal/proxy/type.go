@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Config contains configuration params for routing the requests of an HTTP
+// client through a proxy.
+type Config struct {
+	URL     string `json:"url" yaml:"url"`
+	NoProxy string `json:"no_proxy" yaml:"no_proxy"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		URL:     "",
+		NoProxy: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Func returns a function that selects the proxy for a request, which can be
+// used as the Proxy field of an *http.Transport. If none of the config fields
+// are set then a nil function is returned, in which case the defaults of the
+// transport should be left as they are.
+//
+// The environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY are read each
+// time the function is created, and any fields that are set take precedence
+// over them. Requests to localhost and loopback addresses are never proxied.
+func (c Config) Func() (func(*http.Request) (*url.URL, error), error) {
+	if c.URL == "" && c.NoProxy == "" {
+		return nil, nil
+	}
+
+	pConf := httpproxy.FromEnvironment()
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("proxy url scheme must be one of http, https, socks5 or socks5h, got: %v", u.Scheme)
+		}
+		pConf.HTTPProxy = c.URL
+		pConf.HTTPSProxy = c.URL
+	}
+	if c.NoProxy != "" {
+		pConf.NoProxy = c.NoProxy
+	}
+
+	proxyFn := pConf.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFn(req.URL)
+	}, nil
+}
+
+// Transport returns a clone of the default HTTP transport that routes requests
+// through the configured proxy.
+func (c Config) Transport() (*http.Transport, error) {
+	proxyFn, err := c.Func()
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if proxyFn != nil {
+		tr.Proxy = proxyFn
+	}
+	return tr, nil
+}
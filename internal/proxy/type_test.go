@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://env.example.com:3128")
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("NO_PROXY", "env-bypass.example.com")
+
+	tests := []struct {
+		name     string
+		conf     Config
+		target   string
+		expected string
+	}{
+		{
+			name:     "url overrides env",
+			conf:     Config{URL: "socks5://socks.example.com:1080"},
+			target:   "https://foo.example.com/bar",
+			expected: "socks5://socks.example.com:1080",
+		},
+		{
+			name:     "env no proxy with url",
+			conf:     Config{URL: "http://proxy.example.com:3128"},
+			target:   "http://env-bypass.example.com",
+			expected: "",
+		},
+		{
+			name:     "no proxy overrides env",
+			conf:     Config{URL: "http://proxy.example.com:3128", NoProxy: ".internal.example.com"},
+			target:   "http://env-bypass.example.com",
+			expected: "http://proxy.example.com:3128",
+		},
+		{
+			name:     "no proxy domain",
+			conf:     Config{URL: "http://proxy.example.com:3128", NoProxy: ".internal.example.com"},
+			target:   "http://foo.internal.example.com",
+			expected: "",
+		},
+		{
+			name:     "no proxy cidr",
+			conf:     Config{URL: "http://proxy.example.com:3128", NoProxy: "10.0.0.0/8"},
+			target:   "http://10.1.2.3:8080",
+			expected: "",
+		},
+		{
+			name:     "no proxy with env url",
+			conf:     Config{NoProxy: "foo.example.com"},
+			target:   "http://bar.example.com",
+			expected: "http://env.example.com:3128",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			proxyFn, err := test.conf.Func()
+			require.NoError(t, err)
+			require.NotNil(t, proxyFn)
+
+			req, err := http.NewRequest("GET", test.target, nil)
+			require.NoError(t, err)
+
+			u, err := proxyFn(req)
+			require.NoError(t, err)
+			if test.expected == "" {
+				assert.Nil(t, u)
+			} else {
+				require.NotNil(t, u)
+				assert.Equal(t, test.expected, u.String())
+			}
+		})
+	}
+}
+
+func TestProxyFuncDefaults(t *testing.T) {
+	proxyFn, err := NewConfig().Func()
+	require.NoError(t, err)
+	assert.Nil(t, proxyFn)
+}
+
+func TestProxyFuncBadScheme(t *testing.T) {
+	_, err := Config{URL: "ftp://proxy.example.com"}.Func()
+	require.EqualError(t, err, "proxy url scheme must be one of http, https, socks5 or socks5h, got: ftp")
+}
@@ -0,0 +1,44 @@
+package sampling
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// sampledProcessor wraps a processor and offers the batches it receives and
+// produces to any sampling sessions targeting its path.
+type sampledProcessor struct {
+	reg    *Registry
+	target Target
+	tap    *tap
+	p      processor.V1
+
+	releaseOnce sync.Once
+}
+
+func (s *sampledProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	if atomic.LoadInt32(&s.tap.active) == 0 {
+		return s.p.ProcessMessage(msg)
+	}
+	s.reg.capture(s.target.Stream, s.target.Path, "input", msg)
+	msgs, res := s.p.ProcessMessage(msg)
+	for _, m := range msgs {
+		s.reg.capture(s.target.Stream, s.target.Path, "output", m)
+	}
+	return msgs, res
+}
+
+func (s *sampledProcessor) CloseAsync() {
+	s.releaseOnce.Do(func() {
+		s.reg.release(s.target)
+	})
+	s.p.CloseAsync()
+}
+
+func (s *sampledProcessor) WaitForClose(timeout time.Duration) error {
+	return s.p.WaitForClose(timeout)
+}
@@ -0,0 +1,414 @@
+// Package sampling provides a mechanism for temporarily capturing the messages
+// that enter and leave processors at a given component path, which can be used
+// in order to debug a running pipeline without modifying its config.
+package sampling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// DefaultRedactedKeys are the metadata keys and JSON fields that are always
+// redacted from samples, matched case insensitively on substrings.
+var DefaultRedactedKeys = []string{
+	"password", "secret", "token", "authorization", "api_key", "apikey", "credential",
+}
+
+const redactedValue = "<redacted>"
+
+const (
+	maxSampleCount   = 100
+	maxSampleTimeout = time.Minute
+)
+
+// textPairRegex matches key and value pairs within unstructured text such as
+// logfmt lines, form bodies, headers and error messages.
+var textPairRegex = regexp.MustCompile(`([\w.\-]+)("?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s&;,"{}\[\]]+)`)
+
+// Sample is a single message captured at a component path.
+type Sample struct {
+	Stream    string            `json:"stream,omitempty"`
+	Path      string            `json:"path"`
+	Direction string            `json:"direction"`
+	Content   interface{}       `json:"content"`
+	Metadata  map[string]string `json:"metadata"`
+	Error     string            `json:"error,omitempty"`
+	Time      time.Time         `json:"time"`
+}
+
+// Request describes the messages to be captured by a sampling session.
+type Request struct {
+	Stream string
+	Path   string
+
+	// Direction is either input, output or both.
+	Direction string
+
+	// Count is the maximum number of samples to capture.
+	Count int
+
+	// RedactKeys are redacted from metadata and JSON contents in addition to
+	// DefaultRedactedKeys.
+	RedactKeys []string
+
+	// IncludeRaw includes the contents of messages that aren't JSON, which are
+	// otherwise omitted as their fields can only be redacted on a best effort
+	// basis.
+	IncludeRaw bool
+
+	// MaxContentBytes truncates the contents of messages that aren't JSON.
+	MaxContentBytes int
+}
+
+type session struct {
+	req Request
+
+	mut     sync.Mutex
+	samples []Sample
+	full    chan struct{}
+}
+
+func (s *session) matches(stream, path, direction string) bool {
+	return s.req.Stream == stream && s.req.Path == path &&
+		(s.req.Direction == "both" || s.req.Direction == direction)
+}
+
+func (s *session) redacted(key string) bool {
+	key = strings.ToLower(key)
+	for _, r := range DefaultRedactedKeys {
+		if strings.Contains(key, r) {
+			return true
+		}
+	}
+	for _, r := range s.req.RedactKeys {
+		if r != "" && strings.Contains(key, strings.ToLower(r)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *session) redact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			if s.redacted(k) {
+				obj[k] = redactedValue
+			} else {
+				obj[k] = s.redact(v)
+			}
+		}
+		return obj
+	case []interface{}:
+		arr := make([]interface{}, len(t))
+		for i, v := range t {
+			arr[i] = s.redact(v)
+		}
+		return arr
+	}
+	return v
+}
+
+// redactText redacts the values of key and value pairs within unstructured
+// text where the key is redacted, this is a best effort as the format of the
+// text is unknown.
+func (s *session) redactText(text string) string {
+	return textPairRegex.ReplaceAllStringFunc(text, func(pair string) string {
+		groups := textPairRegex.FindStringSubmatch(pair)
+		if !s.redacted(groups[1]) {
+			return pair
+		}
+		return groups[1] + groups[2] + redactedValue
+	})
+}
+
+func (s *session) sample(stream, path, direction string, part *message.Part) Sample {
+	smp := Sample{
+		Stream:    stream,
+		Path:      path,
+		Direction: direction,
+		Metadata:  map[string]string{},
+		Error:     s.redactText(processor.GetFail(part)),
+		Time:      time.Now(),
+	}
+	_ = part.MetaIter(func(k, v string) error {
+		if k == message.FailFlagKey {
+			return nil
+		}
+		if s.redacted(k) {
+			v = redactedValue
+		}
+		smp.Metadata[k] = v
+		return nil
+	})
+	if jObj, err := part.JSON(); err == nil {
+		smp.Content = s.redact(jObj)
+	} else if s.req.IncludeRaw {
+		raw := part.Get()
+		if s.req.MaxContentBytes > 0 && len(raw) > s.req.MaxContentBytes {
+			raw = raw[:s.req.MaxContentBytes]
+		}
+		smp.Content = s.redactText(string(raw))
+	}
+	return smp
+}
+
+// add captures the messages of a batch until the session is full.
+func (s *session) add(stream, path, direction string, batch *message.Batch) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	_ = batch.Iter(func(i int, part *message.Part) error {
+		if len(s.samples) >= s.req.Count {
+			return errors.New("full")
+		}
+		s.samples = append(s.samples, s.sample(stream, path, direction, part))
+		return nil
+	})
+	if len(s.samples) >= s.req.Count {
+		select {
+		case <-s.full:
+		default:
+			close(s.full)
+		}
+	}
+}
+
+func (s *session) result() []Sample {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]Sample{}, s.samples...)
+}
+
+//------------------------------------------------------------------------------
+
+// Target is a component path of a stream that can be sampled.
+type Target struct {
+	Stream string `json:"stream,omitempty"`
+	Path   string `json:"path"`
+}
+
+// tap tracks the processors wrapped at a target and the number of sampling
+// sessions currently targeting it, processors skip capturing entirely whilst
+// active is zero.
+type tap struct {
+	refs   int
+	active int32
+}
+
+// Registry keeps track of the component paths that can be sampled and the
+// sampling sessions currently active across a Benthos instance.
+type Registry struct {
+	mut      sync.RWMutex
+	taps     map[Target]*tap
+	sessions map[*session]struct{}
+}
+
+// NewRegistry returns an empty sampling registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		taps:     map[Target]*tap{},
+		sessions: map[*session]struct{}{},
+	}
+}
+
+// tapFor returns the tap of a target, creating it if it doesn't yet exist. The
+// write lock must be held by the caller.
+func (r *Registry) tapFor(target Target) *tap {
+	t, exists := r.taps[target]
+	if !exists {
+		t = &tap{}
+		r.taps[target] = t
+	}
+	return t
+}
+
+// pruneTap removes the tap of a target once it has neither processors nor
+// sessions. The write lock must be held by the caller.
+func (r *Registry) pruneTap(target Target) {
+	if t := r.taps[target]; t != nil && t.refs <= 0 && atomic.LoadInt32(&t.active) <= 0 {
+		delete(r.taps, target)
+	}
+}
+
+func (r *Registry) capture(stream, path, direction string, batch *message.Batch) {
+	r.mut.RLock()
+	defer r.mut.RUnlock()
+	for s := range r.sessions {
+		if s.matches(stream, path, direction) {
+			s.add(stream, path, direction, batch)
+		}
+	}
+}
+
+// Sample captures messages matching a request until either the requested count
+// is reached or the context is cancelled, and returns the samples captured.
+func (r *Registry) Sample(ctx context.Context, req Request) []Sample {
+	s := &session{req: req, full: make(chan struct{})}
+	target := Target{Stream: req.Stream, Path: req.Path}
+
+	r.mut.Lock()
+	r.sessions[s] = struct{}{}
+	t := r.tapFor(target)
+	atomic.AddInt32(&t.active, 1)
+	r.mut.Unlock()
+
+	select {
+	case <-s.full:
+	case <-ctx.Done():
+	}
+
+	r.mut.Lock()
+	delete(r.sessions, s)
+	atomic.AddInt32(&t.active, -1)
+	r.pruneTap(target)
+	r.mut.Unlock()
+
+	return s.result()
+}
+
+// Targets returns the component paths that can currently be sampled, ordered
+// by stream and path. When a non-empty stream is provided only the paths of
+// that stream are returned.
+func (r *Registry) Targets(stream string) []Target {
+	r.mut.RLock()
+	targets := make([]Target, 0, len(r.taps))
+	for t, tp := range r.taps {
+		if tp.refs <= 0 || (stream != "" && t.Stream != stream) {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	r.mut.RUnlock()
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Stream != targets[j].Stream {
+			return targets[i].Stream < targets[j].Stream
+		}
+		return targets[i].Path < targets[j].Path
+	})
+	return targets
+}
+
+// WrapProcessor returns a processor that captures the messages entering and
+// leaving the provided processor whenever a sampling session targets its path.
+// Whilst no session targets the path the processor is called directly after a
+// single atomic check.
+func (r *Registry) WrapProcessor(stream, path string, p processor.V1) processor.V1 {
+	target := Target{Stream: stream, Path: path}
+	r.mut.Lock()
+	t := r.tapFor(target)
+	t.refs++
+	r.mut.Unlock()
+	return &sampledProcessor{reg: r, target: target, tap: t, p: p}
+}
+
+func (r *Registry) release(target Target) {
+	r.mut.Lock()
+	if t := r.taps[target]; t != nil {
+		t.refs--
+		r.pruneTap(target)
+	}
+	r.mut.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+// HandleSample is an http.HandlerFunc that lists the component paths that can
+// be sampled on GET requests, and on POST requests captures messages at the
+// path specified by the query parameter `path`, responding with the captured
+// samples as a JSON array once either `count` samples were captured or the
+// `timeout` elapses. Contents that aren't JSON are only returned when the query
+// parameter `raw` is true.
+func (r *Registry) HandleSample(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	stream := query.Get("stream")
+
+	var res interface{}
+	switch req.Method {
+	case "GET":
+		res = r.Targets(stream)
+	case "POST":
+		sReq := Request{
+			Stream:          stream,
+			Path:            query.Get("path"),
+			Direction:       "both",
+			Count:           10,
+			MaxContentBytes: 1024,
+		}
+		if sReq.Path == "" {
+			http.Error(w, "Query parameter `path` must be set", http.StatusBadRequest)
+			return
+		}
+		if d := query.Get("direction"); d != "" {
+			switch d {
+			case "input", "output", "both":
+				sReq.Direction = d
+			default:
+				http.Error(w, "Query parameter `direction` must be one of `input`, `output` or `both`", http.StatusBadRequest)
+				return
+			}
+		}
+		if c := query.Get("count"); c != "" {
+			count, err := strconv.Atoi(c)
+			if err != nil || count <= 0 || count > maxSampleCount {
+				http.Error(w, "Query parameter `count` must be a positive integer no greater than "+strconv.Itoa(maxSampleCount), http.StatusBadRequest)
+				return
+			}
+			sReq.Count = count
+		}
+		if m := query.Get("max_content_bytes"); m != "" {
+			maxBytes, err := strconv.Atoi(m)
+			if err != nil {
+				http.Error(w, "Query parameter `max_content_bytes` must be an integer", http.StatusBadRequest)
+				return
+			}
+			sReq.MaxContentBytes = maxBytes
+		}
+		if raw := query.Get("raw"); raw != "" {
+			var err error
+			if sReq.IncludeRaw, err = strconv.ParseBool(raw); err != nil {
+				http.Error(w, "Query parameter `raw` must be a boolean", http.StatusBadRequest)
+				return
+			}
+		}
+		if rKeys := query.Get("redact"); rKeys != "" {
+			sReq.RedactKeys = strings.Split(rKeys, ",")
+		}
+		timeout := 10 * time.Second
+		if t := query.Get("timeout"); t != "" {
+			var err error
+			if timeout, err = time.ParseDuration(t); err != nil || timeout <= 0 || timeout > maxSampleTimeout {
+				http.Error(w, "Query parameter `timeout` must be a positive duration no greater than "+maxSampleTimeout.String(), http.StatusBadRequest)
+				return
+			}
+		}
+		ctx, done := context.WithTimeout(req.Context(), timeout)
+		res = r.Sample(ctx, sReq)
+		done()
+	default:
+		http.Error(w, "Method must be GET or POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resBytes)
+}
@@ -0,0 +1,230 @@
+package sampling
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func upperProcessor() mock.Processor {
+	return func(b *message.Batch) ([]*message.Batch, error) {
+		out := b.Copy()
+		_ = out.Iter(func(i int, p *message.Part) error {
+			p.Set(bytes.ToUpper(p.Get()))
+			return nil
+		})
+		return []*message.Batch{out}, nil
+	}
+}
+
+func TestRegistryTargets(t *testing.T) {
+	r := NewRegistry()
+
+	pA := r.WrapProcessor("a", "root.pipeline.processors.1", upperProcessor())
+	_ = r.WrapProcessor("a", "root.pipeline.processors.0", upperProcessor())
+	_ = r.WrapProcessor("b", "root.pipeline.processors.0", upperProcessor())
+
+	assert.Equal(t, []Target{
+		{Stream: "a", Path: "root.pipeline.processors.0"},
+		{Stream: "a", Path: "root.pipeline.processors.1"},
+		{Stream: "b", Path: "root.pipeline.processors.0"},
+	}, r.Targets(""))
+
+	pA.CloseAsync()
+	assert.Equal(t, []Target{
+		{Stream: "a", Path: "root.pipeline.processors.0"},
+	}, r.Targets("a"))
+}
+
+func TestRegistrySample(t *testing.T) {
+	r := NewRegistry()
+	p := r.WrapProcessor("", "root.pipeline.processors.0", upperProcessor())
+	other := r.WrapProcessor("", "root.pipeline.processors.1", upperProcessor())
+
+	resChan := make(chan []Sample)
+	go func() {
+		resChan <- r.Sample(context.Background(), Request{
+			Path:       "root.pipeline.processors.0",
+			Direction:  "both",
+			Count:      3,
+			IncludeRaw: true,
+		})
+	}()
+
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{"user":"foo","password":"bar","nested":[{"api_token":"baz"}]}`),
+		[]byte(`user=foo password=bar msg="hello world"`),
+	})
+	msg.Get(0).MetaSet("Authorization", "Bearer nope")
+	msg.Get(0).MetaSet("topic", "foo")
+
+	var samples []Sample
+	require.Eventually(t, func() bool {
+		_, _ = other.ProcessMessage(msg)
+		_, _ = p.ProcessMessage(msg)
+		select {
+		case samples = <-resChan:
+			return true
+		default:
+		}
+		return false
+	}, time.Second*5, time.Millisecond*10)
+
+	require.Len(t, samples, 3)
+	for _, s := range samples {
+		assert.Equal(t, "root.pipeline.processors.0", s.Path)
+	}
+
+	assert.Equal(t, "input", samples[0].Direction)
+	assert.Equal(t, map[string]interface{}{
+		"user":     "foo",
+		"password": "<redacted>",
+		"nested": []interface{}{
+			map[string]interface{}{"api_token": "<redacted>"},
+		},
+	}, samples[0].Content)
+	assert.Equal(t, map[string]string{
+		"Authorization": "<redacted>",
+		"topic":         "foo",
+	}, samples[0].Metadata)
+
+	assert.Equal(t, "input", samples[1].Direction)
+	assert.Equal(t, `user=foo password=<redacted> msg="hello world"`, samples[1].Content)
+
+	assert.Equal(t, "output", samples[2].Direction)
+	assert.Equal(t, map[string]interface{}{
+		"USER":     "FOO",
+		"PASSWORD": "<redacted>",
+		"NESTED": []interface{}{
+			map[string]interface{}{"API_TOKEN": "<redacted>"},
+		},
+	}, samples[2].Content)
+}
+
+func TestRegistryHandleSample(t *testing.T) {
+	r := NewRegistry()
+	p := r.WrapProcessor("", "root.pipeline.processors.0", upperProcessor())
+
+	w := httptest.NewRecorder()
+	r.HandleSample(w, httptest.NewRequest("GET", "/sampling", nil))
+	assert.Equal(t, `[{"path":"root.pipeline.processors.0"}]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.HandleSample(w, httptest.NewRequest("POST", "/sampling", nil))
+	assert.Equal(t, 400, w.Code)
+
+	done := make(chan struct{})
+	w = httptest.NewRecorder()
+	go func() {
+		r.HandleSample(w, httptest.NewRequest("POST", "/sampling?path=root.pipeline.processors.0&direction=output&count=1&redact=user&timeout=5s", nil))
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, _ = p.ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"user":"foo","id":"bar"}`)}))
+		select {
+		case <-done:
+			return true
+		default:
+		}
+		return false
+	}, time.Second*5, time.Millisecond*10)
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var samples []Sample
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &samples))
+	require.Len(t, samples, 1)
+	assert.Equal(t, "output", samples[0].Direction)
+	assert.Equal(t, map[string]interface{}{"USER": "<redacted>", "ID": "BAR"}, samples[0].Content)
+}
+
+func TestRegistrySampleRawAndErrors(t *testing.T) {
+	r := NewRegistry()
+	p := r.WrapProcessor("", "root.pipeline.processors.0", upperProcessor())
+
+	resChan := make(chan []Sample)
+	go func() {
+		resChan <- r.Sample(context.Background(), Request{
+			Path:      "root.pipeline.processors.0",
+			Direction: "input",
+			Count:     1,
+		})
+	}()
+
+	msg := message.QuickBatch([][]byte{[]byte(`user=foo&password=bar`)})
+	msg.Get(0).MetaSet(message.FailFlagKey, `request failed: {"api_key":"nope","id":"foo"}`)
+
+	var samples []Sample
+	require.Eventually(t, func() bool {
+		_, _ = p.ProcessMessage(msg)
+		select {
+		case samples = <-resChan:
+			return true
+		default:
+		}
+		return false
+	}, time.Second*5, time.Millisecond*10)
+
+	require.Len(t, samples, 1)
+	assert.Nil(t, samples[0].Content)
+	assert.Equal(t, `request failed: {"api_key":<redacted>,"id":"foo"}`, samples[0].Error)
+}
+
+func TestRegistryHandleSampleLimits(t *testing.T) {
+	r := NewRegistry()
+	_ = r.WrapProcessor("", "root.pipeline.processors.0", upperProcessor())
+
+	for _, query := range []string{
+		"count=0",
+		"count=101",
+		"timeout=0s",
+		"timeout=2m",
+		"raw=nah",
+	} {
+		w := httptest.NewRecorder()
+		r.HandleSample(w, httptest.NewRequest("POST", "/sampling?path=root.pipeline.processors.0&"+query, nil))
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
+
+func BenchmarkSampledProcessorIdle(b *testing.B) {
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{"user":"foo","id":"bar"}`),
+		[]byte(`hello world`),
+	})
+
+	b.Run("unwrapped", func(b *testing.B) {
+		p := upperProcessor()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = p.ProcessMessage(msg)
+		}
+	})
+
+	b.Run("wrapped", func(b *testing.B) {
+		r := NewRegistry()
+		p := r.WrapProcessor("", "root.pipeline.processors.0", upperProcessor())
+
+		// A session targeting a different path must not activate this one.
+		ctx, done := context.WithCancel(context.Background())
+		defer done()
+		go r.Sample(ctx, Request{Path: "root.pipeline.processors.1", Direction: "both", Count: 1})
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = p.ProcessMessage(msg)
+		}
+	})
+}
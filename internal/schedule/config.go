@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/old/input"
+)
+
+// Config describes a named schedule that triggers either the injection of a
+// generated message or a run of an input.
+type Config struct {
+	Name         string        `json:"name" yaml:"name"`
+	Cron         string        `json:"cron" yaml:"cron"`
+	Mapping      string        `json:"mapping" yaml:"mapping"`
+	Input        *input.Config `json:"input,omitempty" yaml:"input,omitempty"`
+	AllowOverlap bool          `json:"allow_overlap" yaml:"allow_overlap"`
+	Cache        string        `json:"cache" yaml:"cache"`
+}
+
+// NewConfig returns a schedule configuration with default values.
+func NewConfig() Config {
+	return Config{
+		Name:         "",
+		Cron:         "",
+		Mapping:      "",
+		Input:        nil,
+		AllowOverlap: false,
+		Cache:        "",
+	}
+}
+
+// Spec returns the field specs of a schedule.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("name", "A unique name for the schedule. Messages injected by the schedule have the metadata field `schedule_name` set to this value, along with `schedule_time` set to the RFC 3339 time at which the schedule triggered.").HasDefault(""),
+		docs.FieldString(
+			"cron", "A cron expression describing when the schedule triggers, where seconds are optional. A timezone can be specified by prefixing the expression with `TZ=<location name>`, where the location name corresponds to a file within the IANA Time Zone database, otherwise UTC is used.",
+			"0 */5 * * * *", "@every 1h", "TZ=Europe/London 30 3 * * *",
+		).HasDefault(""),
+		docs.FieldBloblang("mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) used to generate the message injected each time the schedule triggers. The metadata fields `schedule_name` and `schedule_time` can be referenced within the mapping. When empty an empty message is injected.", `root.query = "SELECT * FROM jobs WHERE created_at > %v".format(meta("schedule_time"))`).HasDefault(""),
+		docs.FieldInput("input", "An optional input to run each time the schedule triggers instead of injecting a generated message. The input is created when the schedule triggers and is consumed until it closes, and therefore should be an input that finishes once all data has been read, such as `sql_select` or `aws_s3`.").Optional(),
+		docs.FieldBool("allow_overlap", "Whether the schedule is allowed to trigger while a previous run has not yet finished. A run of a generated message finishes once the message is acknowledged, and a run of an input finishes once the input has closed and all of its messages are acknowledged. When set to `false` triggers that occur during a run are skipped.").HasDefault(false),
+		docs.FieldString("cache", "An optional [cache resource](/docs/components/caches/about) used to persist the time of the last successful run under the key of the schedule `name`. When set, a schedule that missed a trigger whilst the stream was not running is triggered immediately once the stream starts.").HasDefault(""),
+	}
+}
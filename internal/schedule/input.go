@@ -0,0 +1,409 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+func parseCronExpression(cronExpression string) (cron.Schedule, *time.Location, error) {
+	// If time zone is not included, set default to UTC
+	if !strings.HasPrefix(cronExpression, "TZ=") {
+		cronExpression = fmt.Sprintf("TZ=%s %s", "UTC", cronExpression)
+	}
+
+	end := strings.Index(cronExpression, " ")
+	if end == -1 {
+		return nil, nil, errors.New("missing cron expression after timezone")
+	}
+	loc, err := time.LoadLocation(cronExpression[len("TZ="):end])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parser := cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	schedule, err := parser.Parse(cronExpression)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schedule, loc, nil
+}
+
+//------------------------------------------------------------------------------
+
+type schedule struct {
+	conf     Config
+	mgr      bundle.NewManagement
+	log      log.Modular
+	cron     cron.Schedule
+	location *time.Location
+	exec     *mapping.Executor
+
+	runningMut sync.Mutex
+	running    int
+}
+
+func newSchedule(conf Config, mgr bundle.NewManagement) (*schedule, error) {
+	if conf.Name == "" {
+		return nil, errors.New("a schedule name is required")
+	}
+
+	s := &schedule{
+		conf: conf,
+		mgr:  mgr,
+		log:  mgr.Logger(),
+	}
+
+	var err error
+	if s.cron, s.location, err = parseCronExpression(conf.Cron); err != nil {
+		return nil, fmt.Errorf("schedule '%v': failed to parse cron expression: %w", conf.Name, err)
+	}
+	if conf.Input != nil && conf.Mapping != "" {
+		return nil, fmt.Errorf("schedule '%v': cannot set both a mapping and an input", conf.Name)
+	}
+	if conf.Mapping != "" {
+		if s.exec, err = mgr.BloblEnvironment().NewMapping(conf.Mapping); err != nil {
+			return nil, fmt.Errorf("schedule '%v': failed to parse mapping: %w", conf.Name, err)
+		}
+	}
+	if conf.Cache != "" && !mgr.ProbeCache(conf.Cache) {
+		return nil, fmt.Errorf("schedule '%v': cache resource '%v' was not found", conf.Name, conf.Cache)
+	}
+	return s, nil
+}
+
+// next returns the next time the schedule triggers after t.
+func (s *schedule) next(t time.Time) time.Time {
+	return s.cron.Next(t.In(s.location))
+}
+
+// lastRun returns the persisted time of the last successful run, or a zero
+// time if unknown.
+func (s *schedule) lastRun(ctx context.Context) (t time.Time) {
+	if s.conf.Cache == "" {
+		return
+	}
+	var cErr error
+	if err := s.mgr.AccessCache(ctx, s.conf.Cache, func(c cache.V1) {
+		var b []byte
+		if b, cErr = c.Get(ctx, s.conf.Name); cErr == nil {
+			t, cErr = time.Parse(time.RFC3339Nano, string(b))
+		}
+	}); err != nil {
+		cErr = err
+	}
+	if cErr != nil && !errors.Is(cErr, component.ErrKeyNotFound) {
+		s.log.Errorf("Failed to read last run of schedule '%v': %v\n", s.conf.Name, cErr)
+	}
+	return
+}
+
+func (s *schedule) setLastRun(ctx context.Context, t time.Time) {
+	if s.conf.Cache == "" {
+		return
+	}
+	var cErr error
+	if err := s.mgr.AccessCache(ctx, s.conf.Cache, func(c cache.V1) {
+		cErr = c.Set(ctx, s.conf.Name, []byte(t.Format(time.RFC3339Nano)), nil)
+	}); err != nil {
+		cErr = err
+	}
+	if cErr != nil {
+		s.log.Errorf("Failed to persist last run of schedule '%v': %v\n", s.conf.Name, cErr)
+	}
+}
+
+// tryStart marks the schedule as running, returning false if a previous run
+// is yet to finish and overlapping runs aren't allowed.
+func (s *schedule) tryStart() bool {
+	s.runningMut.Lock()
+	defer s.runningMut.Unlock()
+	if s.running > 0 && !s.conf.AllowOverlap {
+		return false
+	}
+	s.running++
+	return true
+}
+
+func (s *schedule) done() {
+	s.runningMut.Lock()
+	s.running--
+	s.runningMut.Unlock()
+}
+
+// addMetadata sets the schedule metadata fields on all messages of a batch.
+func (s *schedule) addMetadata(b *message.Batch, t time.Time) {
+	_ = b.Iter(func(i int, p *message.Part) error {
+		p.MetaSet("schedule_name", s.conf.Name)
+		p.MetaSet("schedule_time", t.Format(time.RFC3339Nano))
+		return nil
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// scheduledInput wraps the input of a stream and merges the transactions of
+// the input with those created by schedules.
+type scheduledInput struct {
+	wrapped   iinput.Streamed
+	schedules []*schedule
+
+	transactions chan message.Transaction
+	wg           sync.WaitGroup
+	shutSig      *shutdown.Signaller
+}
+
+// NewInput wraps an input with a list of schedules, where messages created by
+// the schedules are merged with those of the input. The schedules stop once
+// the wrapped input closes.
+func NewInput(confs []Config, wrapped iinput.Streamed, mgr bundle.NewManagement) (iinput.Streamed, error) {
+	s := &scheduledInput{
+		wrapped:      wrapped,
+		transactions: make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+
+	names := map[string]struct{}{}
+	for i, conf := range confs {
+		if _, exists := names[conf.Name]; exists {
+			return nil, fmt.Errorf("schedule name '%v' is not unique", conf.Name)
+		}
+		names[conf.Name] = struct{}{}
+
+		sched, err := newSchedule(conf, mgr.IntoPath("schedules", fmt.Sprintf("%v", i)).(bundle.NewManagement))
+		if err != nil {
+			return nil, err
+		}
+		s.schedules = append(s.schedules, sched)
+	}
+
+	s.wg.Add(1)
+	go s.forwardInput()
+	for _, sched := range s.schedules {
+		s.wg.Add(1)
+		go s.loop(sched)
+	}
+	go func() {
+		s.wg.Wait()
+		close(s.transactions)
+		s.shutSig.ShutdownComplete()
+	}()
+	return s, nil
+}
+
+func (s *scheduledInput) forwardInput() {
+	defer s.wg.Done()
+	defer s.shutSig.CloseAtLeisure()
+
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-s.wrapped.TransactionChan():
+			if !open {
+				return
+			}
+		case <-s.shutSig.CloseNowChan():
+			return
+		}
+		select {
+		case s.transactions <- tran:
+		case <-s.shutSig.CloseNowChan():
+			return
+		}
+	}
+}
+
+func (s *scheduledInput) loop(sched *schedule) {
+	defer s.wg.Done()
+
+	ctx, done := s.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	now := time.Now()
+	next := sched.next(now)
+	if last := sched.lastRun(ctx); !last.IsZero() {
+		if missed := sched.next(last); !missed.After(now) {
+			sched.log.Infof("Schedule '%v' missed a run at %v, triggering now\n", sched.conf.Name, missed.Format(time.RFC3339))
+			next = now
+		}
+	}
+
+	for {
+		select {
+		case <-time.After(next.Sub(time.Now())):
+		case <-s.shutSig.CloseAtLeisureChan():
+			return
+		}
+
+		triggered := time.Now()
+		if sched.tryStart() {
+			s.wg.Add(1)
+			go func(t time.Time) {
+				defer s.wg.Done()
+				defer sched.done()
+				s.run(sched, t)
+			}(triggered)
+		} else {
+			sched.log.Warnf("Skipping trigger of schedule '%v' as the previous run has not finished\n", sched.conf.Name)
+		}
+		next = sched.next(triggered)
+	}
+}
+
+// run executes a single run of a schedule and persists the time of the run
+// when it succeeds.
+func (s *scheduledInput) run(sched *schedule, t time.Time) {
+	var err error
+	if sched.conf.Input != nil {
+		err = s.runInput(sched, t)
+	} else {
+		err = s.runMapping(sched, t)
+	}
+	if err != nil {
+		if !s.shutSig.ShouldCloseAtLeisure() {
+			sched.log.Errorf("Run of schedule '%v' failed: %v\n", sched.conf.Name, err)
+		}
+		return
+	}
+
+	ctx, done := s.shutSig.CloseNowCtx(context.Background())
+	defer done()
+	sched.setLastRun(ctx, t)
+}
+
+func (s *scheduledInput) runMapping(sched *schedule, t time.Time) error {
+	msg := message.QuickBatch([][]byte{nil})
+	sched.addMetadata(msg, t)
+
+	if sched.exec != nil {
+		p, err := sched.exec.MapPart(0, msg)
+		if err != nil {
+			return fmt.Errorf("failed to execute mapping: %w", err)
+		}
+		if p == nil {
+			// The message was deleted by the mapping.
+			return nil
+		}
+		msg = message.QuickBatch(nil)
+		msg.Append(p)
+	}
+
+	resChan := make(chan error)
+	select {
+	case s.transactions <- message.NewTransaction(msg, resChan):
+	case <-s.shutSig.CloseAtLeisureChan():
+		return component.ErrTypeClosed
+	}
+	select {
+	case err := <-resChan:
+		return err
+	case <-s.shutSig.CloseNowChan():
+		return component.ErrTypeClosed
+	}
+}
+
+func (s *scheduledInput) runInput(sched *schedule, t time.Time) error {
+	in, err := sched.mgr.IntoPath("input").(bundle.NewManagement).NewInput(*sched.conf.Input)
+	if err != nil {
+		return fmt.Errorf("failed to create input: %w", err)
+	}
+	defer func() {
+		in.CloseAsync()
+		for in.WaitForClose(time.Second) != nil {
+		}
+	}()
+
+	var ackWG sync.WaitGroup
+	var ackErrMut sync.Mutex
+	var ackErr error
+
+runLoop:
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-in.TransactionChan():
+			if !open {
+				break runLoop
+			}
+		case <-s.shutSig.CloseAtLeisureChan():
+			return component.ErrTypeClosed
+		}
+
+		sched.addMetadata(tran.Payload, t)
+
+		ackWG.Add(1)
+		wrapped := message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+			defer ackWG.Done()
+			if err != nil {
+				ackErrMut.Lock()
+				ackErr = err
+				ackErrMut.Unlock()
+			}
+			return tran.Ack(ctx, err)
+		})
+		select {
+		case s.transactions <- wrapped:
+		case <-s.shutSig.CloseNowChan():
+			return component.ErrTypeClosed
+		}
+	}
+
+	acked := make(chan struct{})
+	go func() {
+		ackWG.Wait()
+		close(acked)
+	}()
+	select {
+	case <-acked:
+	case <-s.shutSig.CloseNowChan():
+		return component.ErrTypeClosed
+	}
+	return ackErr
+}
+
+// TransactionChan returns a transactions channel for consuming messages from
+// the wrapped input and schedules.
+func (s *scheduledInput) TransactionChan() <-chan message.Transaction {
+	return s.transactions
+}
+
+// Connected returns a boolean indicating whether the wrapped input is
+// currently connected to its target.
+func (s *scheduledInput) Connected() bool {
+	return s.wrapped.Connected()
+}
+
+// CloseAsync shuts down the wrapped input and stops the schedules.
+func (s *scheduledInput) CloseAsync() {
+	s.shutSig.CloseAtLeisure()
+	s.wrapped.CloseAsync()
+}
+
+// WaitForClose blocks until the wrapped input and all schedules have closed
+// down. If the timeout is reached then runs waiting for acknowledgements are
+// abandoned.
+func (s *scheduledInput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		s.shutSig.CloseNow()
+		return component.ErrTimeout
+	}
+	return nil
+}
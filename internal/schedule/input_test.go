@@ -0,0 +1,195 @@
+package schedule_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/schedule"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func testScheduledInput(t *testing.T, confStr string) (input.Streamed, *manager.Type) {
+	t.Helper()
+
+	var confs []schedule.Config
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &confs))
+
+	resConf := manager.NewResourceConfig()
+	cacheConf := cache.NewConfig()
+	cacheConf.Label = "foo"
+	cacheConf.Type = "memory"
+	resConf.ResourceCaches = append(resConf.ResourceCaches, cacheConf)
+
+	mgr, err := manager.NewV2(resConf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	in, err := schedule.NewInput(confs, &mock.Input{TChan: make(chan message.Transaction)}, mgr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		in.CloseAsync()
+		require.NoError(t, in.WaitForClose(time.Second*5))
+	})
+	return in, mgr
+}
+
+func readTran(t *testing.T, in input.Streamed, timeout time.Duration) (message.Transaction, bool) {
+	t.Helper()
+
+	select {
+	case tran, open := <-in.TransactionChan():
+		require.True(t, open)
+		return tran, true
+	case <-time.After(timeout):
+	}
+	return message.Transaction{}, false
+}
+
+func lastRun(t *testing.T, mgr bundle.NewManagement, name string) (v string) {
+	t.Helper()
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "foo", func(c cache.V1) {
+		b, _ := c.Get(context.Background(), name)
+		v = string(b)
+	}))
+	return
+}
+
+func TestScheduleMapping(t *testing.T) {
+	in, mgr := testScheduledInput(t, `
+- name: every_second
+  cron: '* * * * * *'
+  mapping: 'root.name = meta("schedule_name")'
+  cache: foo
+`)
+
+	tran, ok := readTran(t, in, time.Second*3)
+	require.True(t, ok)
+	require.Equal(t, 1, tran.Payload.Len())
+
+	part := tran.Payload.Get(0)
+	assert.Equal(t, `{"name":"every_second"}`, string(part.Get()))
+	assert.Equal(t, "every_second", part.MetaGet("schedule_name"))
+
+	schedTime, err := time.Parse(time.RFC3339Nano, part.MetaGet("schedule_time"))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), schedTime, time.Second*2)
+
+	assert.Equal(t, "", lastRun(t, mgr, "every_second"))
+	require.NoError(t, tran.Ack(context.Background(), nil))
+	assert.Eventually(t, func() bool {
+		return lastRun(t, mgr, "every_second") == part.MetaGet("schedule_time")
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestScheduleOverlap(t *testing.T) {
+	in, _ := testScheduledInput(t, `
+- name: every_second
+  cron: '* * * * * *'
+`)
+
+	tran, ok := readTran(t, in, time.Second*3)
+	require.True(t, ok)
+	assert.Equal(t, "", string(tran.Payload.Get(0).Get()))
+
+	// Triggers are skipped until the previous run is acknowledged.
+	_, ok = readTran(t, in, time.Millisecond*1500)
+	require.False(t, ok)
+
+	require.NoError(t, tran.Ack(context.Background(), nil))
+	tran, ok = readTran(t, in, time.Second*3)
+	require.True(t, ok)
+	require.NoError(t, tran.Ack(context.Background(), nil))
+}
+
+func TestScheduleMissedRun(t *testing.T) {
+	resConf := manager.NewResourceConfig()
+	cacheConf := cache.NewConfig()
+	cacheConf.Label = "foo"
+	cacheConf.Type = "memory"
+	resConf.ResourceCaches = append(resConf.ResourceCaches, cacheConf)
+
+	mgr, err := manager.NewV2(resConf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "foo", func(c cache.V1) {
+		lastRun := time.Now().Add(-time.Hour * 2).Format(time.RFC3339Nano)
+		require.NoError(t, c.Set(context.Background(), "hourly", []byte(lastRun), nil))
+	}))
+
+	conf := schedule.NewConfig()
+	conf.Name = "hourly"
+	conf.Cron = "@hourly"
+	conf.Cache = "foo"
+
+	in, err := schedule.NewInput([]schedule.Config{conf}, &mock.Input{TChan: make(chan message.Transaction)}, mgr)
+	require.NoError(t, err)
+
+	tran, ok := readTran(t, in, time.Second)
+	require.True(t, ok)
+	require.NoError(t, tran.Ack(context.Background(), nil))
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second*5))
+}
+
+func TestScheduleInput(t *testing.T) {
+	in, mgr := testScheduledInput(t, `
+- name: batch_job
+  cron: '* * * * * *'
+  cache: foo
+  input:
+    generate:
+      count: 2
+      interval: ""
+      mapping: 'root = "hello"'
+`)
+
+	var trans []message.Transaction
+	for i := 0; i < 2; i++ {
+		tran, ok := readTran(t, in, time.Second*3)
+		require.True(t, ok)
+		assert.Equal(t, "hello", string(tran.Payload.Get(0).Get()))
+		assert.Equal(t, "batch_job", tran.Payload.Get(0).MetaGet("schedule_name"))
+		trans = append(trans, tran)
+	}
+
+	for _, tran := range trans {
+		require.NoError(t, tran.Ack(context.Background(), nil))
+	}
+	assert.Eventually(t, func() bool {
+		return lastRun(t, mgr, "batch_job") != ""
+	}, time.Second*2, time.Millisecond*10)
+}
+
+func TestScheduleBadConfig(t *testing.T) {
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	for _, confStr := range []string{
+		`[ { cron: '* * * * *' } ]`,
+		`[ { name: foo, cron: 'nope' } ]`,
+		`[ { name: foo, cron: '@hourly' }, { name: foo, cron: '@daily' } ]`,
+		`[ { name: foo, cron: '@hourly', cache: nope } ]`,
+		`[ { name: foo, cron: '@hourly', mapping: 'root = "a"', input: { generate: { mapping: 'root = "b"' } } } ]`,
+	} {
+		var confs []schedule.Config
+		require.NoError(t, yaml.Unmarshal([]byte(confStr), &confs))
+
+		_, err := schedule.NewInput(confs, &mock.Input{TChan: make(chan message.Transaction)}, mgr)
+		assert.Error(t, err, confStr)
+	}
+}
@@ -0,0 +1,4 @@
+// Package schedule implements named cron schedules within a stream config that
+// inject messages into the stream, or run a batch input, each time they
+// trigger.
+package schedule
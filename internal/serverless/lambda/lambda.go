@@ -48,8 +48,11 @@ func Run() {
 	conf.Output.Switch.Cases = append(conf.Output.Switch.Cases, errorCase, responseCase)
 
 	if confStr := os.Getenv("BENTHOS_CONFIG"); len(confStr) > 0 {
-		confBytes := config.ReplaceEnvVariables([]byte(confStr))
-		if err := yaml.Unmarshal(confBytes, &conf); err != nil {
+		confBytes, err := config.ReplaceEnvVariables([]byte(confStr))
+		if err == nil {
+			err = yaml.Unmarshal(confBytes, &conf)
+		}
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
 			os.Exit(1)
 		}
@@ -8,17 +8,23 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/old/input"
 	"github.com/benthosdev/benthos/v4/internal/old/output"
 	"github.com/benthosdev/benthos/v4/internal/pipeline"
+	"github.com/benthosdev/benthos/v4/internal/schedule"
 )
 
 //------------------------------------------------------------------------------
 
 // Config is a configuration struct representing all four layers of a Benthos
-// stream.
+// stream, along with schedules that inject messages into it and hooks that
+// execute at points within its lifecycle.
 type Config struct {
 	Input    input.Config    `json:"input" yaml:"input"`
 	Buffer   buffer.Config   `json:"buffer" yaml:"buffer"`
 	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
 	Output   output.Config   `json:"output" yaml:"output"`
+
+	Schedules []schedule.Config    `json:"schedules,omitempty" yaml:"schedules,omitempty"`
+	Hooks     HooksConfig          `json:"hooks,omitempty" yaml:"hooks,omitempty"`
+	Vars      map[string]VarConfig `json:"vars,omitempty" yaml:"vars,omitempty"`
 }
 
 // NewConfig returns a new configuration with default values.
@@ -28,6 +34,10 @@ func NewConfig() Config {
 		Buffer:   buffer.NewConfig(),
 		Pipeline: pipeline.NewConfig(),
 		Output:   output.NewConfig(),
+
+		Schedules: nil,
+		Hooks:     NewHooksConfig(),
+		Vars:      nil,
 	}
 }
 
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/schedule"
 )
 
 // Spec returns a docs.FieldSpec for a stream configuration.
@@ -10,9 +11,19 @@ func Spec() docs.FieldSpecs {
 		docs.FieldInput("input", "An input to source messages from."),
 		docs.FieldBuffer("buffer", "An optional buffer to store messages during transit."),
 		docs.FieldObject("pipeline", "Describes optional processing pipelines used for mutating messages.").WithChildren(
-			docs.FieldInt("threads", "The number of threads to execute processing pipelines across.").HasDefault(1),
+			docs.FieldInt("threads", "The number of threads to execute processing pipelines across. When autoscaling is enabled this is the initial number of threads.").HasDefault(1),
+			docs.FieldObject("autoscale", "Scales the number of processing threads with load, where a thread is added when messages wait longer than `target_latency` on average for a thread to become available while threads spend at least half of their time executing processors, and a thread is removed when threads spend less than half of their time executing processors. The current number of threads is emitted as the gauge metric `pipeline_threads`, and the time that messages wait for a thread as the timing metric `pipeline_queue_latency_ns`.").WithChildren(
+				docs.FieldBool("enabled", "Whether to scale the number of threads with load.").HasDefault(false),
+				docs.FieldInt("min_threads", "The minimum number of threads.").HasDefault(1),
+				docs.FieldInt("max_threads", "The maximum number of threads. When set to `-1` this matches the number of logical CPUs available.").HasDefault(-1),
+				docs.FieldString("target_latency", "The average time that messages may wait for a thread to become available before a thread is added.").HasDefault("10ms"),
+				docs.FieldString("interval", "The period of time over which load is measured before the number of threads is adjusted.").HasDefault("10s"),
+			).Advanced(),
 			docs.FieldProcessor("processors", "A list of processors to apply to messages.").Array(),
 		),
 		docs.FieldOutput("output", "An output to sink messages to."),
+		docs.FieldObject("schedules", "A list of named cron schedules that inject messages into the stream, or run an input, each time they trigger. Messages from schedules are merged with those of the `input` and pass through the buffer and pipeline of the stream. Schedules stop when the `input` of the stream closes.").Array().WithChildren(schedule.Spec()...).HasDefault([]interface{}{}).Advanced(),
+		docs.FieldObject("hooks", "Pipelines executed once at points within the lifecycle of the stream, such as sending a notification when the stream starts or writing a marker object once it has finished. Messages of hooks have the metadata field `hook_event` set to either `start` or `close`, along with `stream_start_time` set to the RFC 3339 time at which the stream started. Messages of the `on_close` hook also have the fields `stream_close_time` and `stream_uptime`.").WithChildren(HooksSpec()...).Advanced(),
+		VarsSpec(),
 	}
 }
@@ -0,0 +1,211 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
+)
+
+// HookConfig describes a pipeline that is executed once at a point within the
+// lifecycle of a stream.
+type HookConfig struct {
+	Mapping    string             `json:"mapping" yaml:"mapping"`
+	Processors []processor.Config `json:"processors" yaml:"processors"`
+	Output     *output.Config     `json:"output,omitempty" yaml:"output,omitempty"`
+	Timeout    string             `json:"timeout" yaml:"timeout"`
+}
+
+// NewHookConfig returns a hook configuration with default values.
+func NewHookConfig() HookConfig {
+	return HookConfig{
+		Mapping:    "",
+		Processors: []processor.Config{},
+		Output:     nil,
+		Timeout:    "30s",
+	}
+}
+
+// UnmarshalYAML ensures that when parsing hook configs the default values are
+// still applied.
+func (h *HookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias HookConfig
+	aliased := confAlias(NewHookConfig())
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+	*h = HookConfig(aliased)
+	return nil
+}
+
+// HooksConfig contains the lifecycle hooks of a stream.
+type HooksConfig struct {
+	OnStart *HookConfig `json:"on_start,omitempty" yaml:"on_start,omitempty"`
+	OnClose *HookConfig `json:"on_close,omitempty" yaml:"on_close,omitempty"`
+}
+
+// NewHooksConfig returns a hooks configuration with default values.
+func NewHooksConfig() HooksConfig {
+	return HooksConfig{
+		OnStart: nil,
+		OnClose: nil,
+	}
+}
+
+func hookFieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBloblang("mapping", "A [Bloblang mapping](/docs/guides/bloblang/about) used to generate the message of the hook, where the lifecycle metadata fields of the stream can be referenced. When empty an empty message is generated.", `root.text = "Stream started at %v".format(meta("stream_start_time"))`).HasDefault(""),
+		docs.FieldProcessor("processors", "A list of processors to apply to the message of the hook.").Array().HasDefault([]interface{}{}),
+		docs.FieldOutput("output", "An optional output to send the message of the hook to once it has been processed.").Optional(),
+		docs.FieldString("timeout", "The maximum period of time to wait for the hook to complete, after which it is abandoned.").HasDefault("30s"),
+	}
+}
+
+// HooksSpec returns the field specs of the lifecycle hooks of a stream.
+func HooksSpec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldObject("on_start", "A hook executed once when the stream starts, before the input is created.").WithChildren(hookFieldSpecs()...).Optional(),
+		docs.FieldObject("on_close", "A hook executed once when the stream closes gracefully, after all messages have been flushed through the output. The hook is not executed when the stream is forced to stop.").WithChildren(hookFieldSpecs()...).Optional(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type hook struct {
+	event   string
+	exec    *mapping.Executor
+	procs   []iprocessor.V1
+	output  ioutput.Streamed
+	timeout time.Duration
+}
+
+func newHook(event string, conf HookConfig, mgr bundle.NewManagement) (*hook, error) {
+	h := &hook{event: event}
+
+	var err error
+	if conf.Mapping != "" {
+		if h.exec, err = mgr.BloblEnvironment().NewMapping(conf.Mapping); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping: %w", err)
+		}
+	}
+	if conf.Timeout != "" {
+		if h.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+	for i, pConf := range conf.Processors {
+		pMgr := mgr.IntoPath("processors", strconv.Itoa(i)).(bundle.NewManagement)
+		proc, err := pMgr.NewProcessor(pConf)
+		if err != nil {
+			h.close()
+			return nil, err
+		}
+		h.procs = append(h.procs, proc)
+	}
+	if conf.Output != nil {
+		if h.output, err = mgr.IntoPath("output").(bundle.NewManagement).NewOutput(*conf.Output); err != nil {
+			h.close()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// run executes the hook with a message carrying the provided metadata.
+func (h *hook) run(meta map[string]string) error {
+	ctx := context.Background()
+	if h.timeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, h.timeout)
+		defer done()
+	}
+
+	part := message.NewPart(nil)
+	part.MetaSet("hook_event", h.event)
+	for k, v := range meta {
+		part.MetaSet(k, v)
+	}
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	if h.exec != nil {
+		p, err := h.exec.MapPart(0, msg)
+		if err != nil {
+			return fmt.Errorf("failed to execute mapping: %w", err)
+		}
+		if p == nil {
+			// The message was deleted by the mapping.
+			return nil
+		}
+		msg = message.QuickBatch(nil)
+		msg.Append(p)
+	}
+
+	batches, err := processor.ExecuteAll(h.procs, msg)
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		if err := b.Iter(func(i int, p *message.Part) error {
+			if fail := processor.GetFail(p); fail != "" {
+				return errors.New(fail)
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("processors failed: %w", err)
+		}
+	}
+
+	if h.output == nil {
+		return nil
+	}
+
+	tChan := make(chan message.Transaction)
+	if err := h.output.Consume(tChan); err != nil {
+		return err
+	}
+	for _, b := range batches {
+		resChan := make(chan error)
+		select {
+		case tChan <- message.NewTransaction(b, resChan):
+		case <-ctx.Done():
+			return component.ErrTimeout
+		}
+		select {
+		case err := <-resChan:
+			if err != nil {
+				return fmt.Errorf("failed to send message: %w", err)
+			}
+		case <-ctx.Done():
+			return component.ErrTimeout
+		}
+	}
+	return nil
+}
+
+// close shuts down the processors and output of the hook.
+func (h *hook) close() {
+	for _, p := range h.procs {
+		p.CloseAsync()
+	}
+	if h.output != nil {
+		h.output.CloseAsync()
+	}
+	for _, p := range h.procs {
+		_ = p.WaitForClose(time.Second)
+	}
+	if h.output != nil {
+		_ = h.output.WaitForClose(time.Second)
+	}
+}
@@ -0,0 +1,178 @@
+package stream_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+func testHooksStream(t *testing.T, confStr string, opts ...func(*stream.Type)) (*stream.Type, *manager.Type) {
+	t.Helper()
+
+	conf := stream.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &conf))
+
+	resConf := manager.NewResourceConfig()
+	cacheConf := cache.NewConfig()
+	cacheConf.Label = "events"
+	cacheConf.Type = "memory"
+	resConf.ResourceCaches = append(resConf.ResourceCaches, cacheConf)
+
+	mgr, err := manager.NewV2(resConf, mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, mgr, opts...)
+	require.NoError(t, err)
+	return strm, mgr
+}
+
+func hookEvent(t *testing.T, mgr *manager.Type, key string) (v string) {
+	t.Helper()
+
+	require.NoError(t, mgr.AccessCache(context.Background(), "events", func(c cache.V1) {
+		b, _ := c.Get(context.Background(), key)
+		v = string(b)
+	}))
+	return
+}
+
+const hooksConf = `
+hooks:
+  on_start:
+    mapping: 'root = meta("hook_event")'
+    processors:
+      - cache:
+          resource: events
+          operator: set
+          key: start
+          value: '${! content() }'
+  on_close:
+    mapping: 'root = "%v %v".format(meta("hook_event"), meta("stream_uptime") != null)'
+    processors:
+      - cache:
+          resource: events
+          operator: set
+          key: close
+          value: '${! content() }'
+output:
+  drop: {}
+`
+
+func TestHooksInputFinished(t *testing.T) {
+	closed := make(chan struct{})
+	_, mgr := testHooksStream(t, `
+input:
+  generate:
+    count: 1
+    interval: ""
+    mapping: 'root = "hello world"'
+`+hooksConf, stream.OptOnClose(func() {
+		close(closed)
+	}))
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for stream to close")
+	}
+
+	assert.Equal(t, "start", hookEvent(t, mgr, "start"))
+	assert.Equal(t, "close true", hookEvent(t, mgr, "close"))
+}
+
+func TestHooksStopGracefully(t *testing.T) {
+	strm, mgr := testHooksStream(t, `
+input:
+  http_server:
+    path: /hooks/graceful
+`+hooksConf)
+
+	assert.Equal(t, "start", hookEvent(t, mgr, "start"))
+	assert.Equal(t, "", hookEvent(t, mgr, "close"))
+
+	require.NoError(t, strm.StopGracefully(time.Second*5))
+	assert.Equal(t, "close true", hookEvent(t, mgr, "close"))
+}
+
+func TestHooksStopUnordered(t *testing.T) {
+	strm, mgr := testHooksStream(t, `
+input:
+  http_server:
+    path: /hooks/unordered
+`+hooksConf)
+
+	assert.Equal(t, "start", hookEvent(t, mgr, "start"))
+
+	require.NoError(t, strm.StopUnordered(time.Second*5))
+	<-time.After(time.Millisecond * 100)
+	assert.Equal(t, "", hookEvent(t, mgr, "close"))
+}
+
+func TestHooksOutput(t *testing.T) {
+	closed := make(chan struct{})
+	_, mgr := testHooksStream(t, `
+input:
+  generate:
+    count: 1
+    interval: ""
+    mapping: 'root = "hello world"'
+output:
+  drop: {}
+hooks:
+  on_close:
+    timeout: 1s
+    output:
+      cache:
+        target: events
+        key: '${! meta("hook_event") }'
+`, stream.OptOnClose(func() {
+		close(closed)
+	}))
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for stream to close")
+	}
+	assert.Equal(t, "", hookEvent(t, mgr, "start"))
+
+	// The hook message is empty, and therefore we only check the key exists.
+	require.NoError(t, mgr.AccessCache(context.Background(), "events", func(c cache.V1) {
+		_, err := c.Get(context.Background(), "close")
+		assert.NoError(t, err)
+	}))
+}
+
+func TestHooksBadConfig(t *testing.T) {
+	conf := stream.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+hooks:
+  on_start:
+    mapping: 'root = '
+`), &conf))
+
+	mgr, err := manager.NewV2(manager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	_, err = stream.New(conf, mgr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "on_start")
+
+	conf = stream.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+hooks:
+  on_close: {}
+`), &conf))
+	assert.Equal(t, "30s", conf.Hooks.OnClose.Timeout)
+}
@@ -28,6 +28,12 @@ import (
 
 //------------------------------------------------------------------------------
 
+// componentEndpointsReg is implemented by managers that provide endpoints for
+// inspecting and controlling the components of streams.
+type componentEndpointsReg interface {
+	RegisterComponentEndpoints()
+}
+
 func (m *Type) registerEndpoints(enableCrud bool) {
 	m.manager.RegisterEndpoint(
 		"/ready",
@@ -37,6 +43,9 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 	if !enableCrud {
 		return
 	}
+	if r, ok := m.manager.(componentEndpointsReg); ok {
+		r.RegisterComponentEndpoints()
+	}
 	m.manager.RegisterEndpoint(
 		"/streams",
 		"GET: List all streams along with their status and uptimes."+
@@ -44,6 +53,13 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 			" streams will be replaced by this new set.",
 		m.HandleStreamsCRUD,
 	)
+	m.manager.RegisterEndpoint(
+		"/streams_bulk",
+		"POST: Post an object of stream ids to stream configs, all streams will"+
+			" be replaced by this new set atomically, where either all changes"+
+			" are applied or none are, and a report of each stream is returned.",
+		m.HandleStreamsBulk,
+	)
 	m.manager.RegisterEndpoint(
 		"/streams/{id}",
 		"Perform CRUD operations on streams, supporting POST (Create),"+
@@ -257,6 +273,105 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandleStreamsBulk is an http.HandleFunc for atomically replacing the entire
+// set of streams, where either all changes are applied or none are, and
+// responding with a report of the lint errors and changes of each stream.
+func (m *Type) HandleStreamsBulk(w http.ResponseWriter, r *http.Request) {
+	var serverErr, requestErr error
+	defer func() {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		if serverErr != nil {
+			m.manager.Logger().Errorf("Streams bulk Error: %v\n", serverErr)
+			http.Error(w, fmt.Sprintf("Error: %v", serverErr), http.StatusBadGateway)
+			return
+		}
+		if requestErr != nil {
+			m.manager.Logger().Debugf("Streams bulk request Error: %v\n", requestErr)
+			http.Error(w, fmt.Sprintf("Error: %v", requestErr), http.StatusBadRequest)
+			return
+		}
+	}()
+
+	if r.Method != "POST" {
+		requestErr = errors.New("method not supported")
+		return
+	}
+
+	var setBytes []byte
+	if setBytes, requestErr = io.ReadAll(r.Body); requestErr != nil {
+		return
+	}
+
+	type streamReport struct {
+		Action     StreamAction `json:"action,omitempty"`
+		LintErrors []string     `json:"lint_errors,omitempty"`
+	}
+	type bulkReport struct {
+		Applied bool                     `json:"applied"`
+		Error   string                   `json:"error,omitempty"`
+		Streams map[string]*streamReport `json:"streams"`
+	}
+	report := bulkReport{Streams: map[string]*streamReport{}}
+
+	writeReport := func(status int) {
+		var resBytes []byte
+		if resBytes, serverErr = json.Marshal(report); serverErr != nil {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(resBytes)
+	}
+
+	nodeSet := map[string]yaml.Node{}
+	if requestErr = yaml.Unmarshal(setBytes, &nodeSet); requestErr != nil {
+		return
+	}
+
+	var linted bool
+	for k, n := range nodeSet {
+		sReport := &streamReport{}
+		report.Streams[k] = sReport
+		for _, l := range lintStreamConfigNode(&n) {
+			sReport.LintErrors = append(sReport.LintErrors, l)
+			m.manager.Logger().Debugf("Streams bulk request linting error: stream '%v': %v\n", k, l)
+			linted = true
+		}
+	}
+	if linted && r.URL.Query().Get("chilled") != "true" {
+		report.Error = "stream configs contain linting errors"
+		writeReport(http.StatusBadRequest)
+		return
+	}
+
+	newSet := ConfigSet{}
+	if requestErr = yaml.Unmarshal(setBytes, &newSet); requestErr != nil {
+		return
+	}
+
+	// TODO: Replace with context
+	plan, err := m.ApplyConfigSet(newSet, time.Second*5)
+	for id, action := range plan {
+		sReport, exists := report.Streams[id]
+		if !exists {
+			sReport = &streamReport{}
+			report.Streams[id] = sReport
+		}
+		sReport.Action = action
+	}
+	if err != nil {
+		m.manager.Logger().Debugf("Streams bulk request Error: %v\n", err)
+		report.Error = err.Error()
+		writeReport(http.StatusBadRequest)
+		return
+	}
+
+	report.Applied = true
+	writeReport(http.StatusOK)
+}
+
 // HandleStreamCRUD is an http.HandleFunc for performing CRUD operations on
 // individual streams.
 func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
@@ -288,7 +403,9 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		if confBytes, err = io.ReadAll(r.Body); err != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
+		if confBytes, err = config.ReplaceEnvVariables(confBytes); err != nil {
+			return
+		}
 
 		if r.URL.Query().Get("chilled") != "true" {
 			var node yaml.Node
@@ -513,7 +630,9 @@ func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
 		if confBytes, requestErr = io.ReadAll(r.Body); requestErr != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
+		if confBytes, requestErr = config.ReplaceEnvVariables(confBytes); requestErr != nil {
+			return
+		}
 
 		var node yaml.Node
 		if requestErr = yaml.Unmarshal(confBytes, &node); requestErr != nil {
@@ -35,6 +35,7 @@ import (
 func router(m *manager.Type) *mux.Router {
 	router := mux.NewRouter()
 	router.HandleFunc("/streams", m.HandleStreamsCRUD)
+	router.HandleFunc("/streams_bulk", m.HandleStreamsBulk)
 	router.HandleFunc("/streams/{id}", m.HandleStreamCRUD)
 	router.HandleFunc("/streams/{id}/stats", m.HandleStreamStats)
 	router.HandleFunc("/resources/{type}/{id}", m.HandleResourceCRUD)
@@ -132,6 +133,7 @@ func TestTypeAPIDisabled(t *testing.T) {
 		manager.OptAPIEnabled(true),
 	)
 	assert.Greater(t, len(r.endpoints), 1)
+	assert.Contains(t, r.endpoints, "/pause")
 
 	r = &endpointReg{endpoints: map[string]http.HandlerFunc{}}
 	rMgr, err = bmanager.NewV2(bmanager.NewResourceConfig(), r, log.Noop(), metrics.Noop())
@@ -171,6 +173,43 @@ func harmlessConf() stream.Config {
 	return c
 }
 
+func TestTypeAPIDeleteResetsCheckpoints(t *testing.T) {
+	r := &endpointReg{endpoints: map[string]http.HandlerFunc{}}
+	rMgr, err := bmanager.NewV2(bmanager.NewResourceConfig(), r, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(rMgr)
+	require.Contains(t, r.endpoints, "/checkpoints")
+
+	require.NoError(t, mgr.Create("foo", harmlessConf()))
+	require.NoError(t, mgr.Create("bar", harmlessConf()))
+
+	rMgr.ForStream("foo").IntoPath("input").ReportCheckpoint("baz:0", "10")
+	rMgr.ForStream("bar").IntoPath("input").ReportCheckpoint("baz:0", "20")
+
+	listStreams := func() (streams []string) {
+		t.Helper()
+		response := httptest.NewRecorder()
+		r.endpoints["/checkpoints"](response, genRequest("GET", "/checkpoints", nil))
+		require.Equal(t, http.StatusOK, response.Code)
+
+		var positions []struct {
+			Stream string `json:"stream"`
+		}
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &positions))
+		for _, p := range positions {
+			streams = append(streams, p.Stream)
+		}
+		return
+	}
+	assert.Equal(t, []string{"bar", "foo"}, listStreams())
+
+	require.NoError(t, mgr.Delete("foo", time.Second))
+	assert.Equal(t, []string{"bar"}, listStreams())
+
+	require.NoError(t, mgr.Stop(time.Second))
+}
+
 func TestTypeAPIBasicOperations(t *testing.T) {
 	res, err := bmanager.NewV2(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
 	require.NoError(t, err)
@@ -599,6 +638,107 @@ func TestTypeAPIStreamsLinting(t *testing.T) {
 	assert.Equal(t, http.StatusOK, response.Code)
 }
 
+func TestTypeAPIStreamsBulk(t *testing.T) {
+	res, err := bmanager.NewV2(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+
+	r := router(mgr)
+
+	require.NoError(t, mgr.Create("foo", harmlessConf()))
+	require.NoError(t, mgr.Create("bar", harmlessConf()))
+
+	request := genRequest("GET", "/streams_bulk", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	body := []byte(`{
+	"bar": {
+		"input": {
+			"http_server": { "path": "/bar" }
+		},
+		"output": {
+			"type": "drop",
+			"file": {}
+		}
+	},
+	"baz": {
+		"input": {
+			"http_server": {}
+		},
+		"output": {
+			"drop": {}
+		}
+	}
+}`)
+
+	request, err = http.NewRequest("POST", "/streams_bulk", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{
+	"applied": false,
+	"error": "stream configs contain linting errors",
+	"streams": {
+		"bar": {"lint_errors": ["line 8: field file is invalid when the component type is drop (output)"]},
+		"baz": {}
+	}
+}`, response.Body.String())
+
+	_, err = mgr.Read("foo")
+	require.NoError(t, err)
+	_, err = mgr.Read("baz")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	body = []byte(`{
+	"bar": {
+		"input": {
+			"http_server": { "path": "/bar" }
+		},
+		"output": {
+			"drop": {}
+		}
+	},
+	"baz": {
+		"input": {
+			"http_server": {}
+		},
+		"output": {
+			"drop": {}
+		}
+	}
+}`)
+
+	request, err = http.NewRequest("POST", "/streams_bulk", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{
+	"applied": true,
+	"streams": {
+		"foo": {"action": "delete"},
+		"bar": {"action": "update"},
+		"baz": {"action": "create"}
+	}
+}`, response.Body.String())
+
+	_, err = mgr.Read("foo")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	status, err := mgr.Read("bar")
+	require.NoError(t, err)
+	assert.Equal(t, "/bar", status.Config().Input.HTTPServer.Path)
+
+	_, err = mgr.Read("baz")
+	require.NoError(t, err)
+}
+
 func TestTypeAPIDefaultConf(t *testing.T) {
 	res, err := bmanager.NewV2(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
 	require.NoError(t, err)
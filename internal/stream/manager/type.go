@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -94,7 +96,8 @@ type Type struct {
 	manager    bundle.NewManagement
 	apiEnabled bool
 
-	lock sync.Mutex
+	lock      sync.Mutex
+	applyLock sync.Mutex
 }
 
 // New creates a new stream manager.Type.
@@ -228,11 +231,148 @@ func (m *Type) Delete(id string, timeout time.Duration) error {
 	delete(m.streams, id)
 	m.lock.Unlock()
 
+	if cr, ok := m.manager.(interface {
+		ResetCheckpoints(stream string)
+	}); ok {
+		cr.ResetCheckpoints(id)
+	}
 	return nil
 }
 
 //------------------------------------------------------------------------------
 
+// StreamAction describes the change made to a stream when a set of stream
+// configs is applied.
+type StreamAction string
+
+// The changes that can be made to a stream when a set of stream configs is
+// applied.
+var (
+	StreamActionCreate    StreamAction = "create"
+	StreamActionUpdate    StreamAction = "update"
+	StreamActionDelete    StreamAction = "delete"
+	StreamActionUnchanged StreamAction = "unchanged"
+)
+
+// planConfigSet returns the actions required in order for the set of active
+// streams to match a set of stream configs. The lock must be held by the
+// caller.
+func (m *Type) planConfigSet(set ConfigSet) map[string]StreamAction {
+	plan := make(map[string]StreamAction, len(set))
+	for id, wrapper := range m.streams {
+		newConf, exists := set[id]
+		switch {
+		case !exists:
+			plan[id] = StreamActionDelete
+		case reflect.DeepEqual(wrapper.config, newConf):
+			plan[id] = StreamActionUnchanged
+		default:
+			plan[id] = StreamActionUpdate
+		}
+	}
+	for id := range set {
+		if _, exists := m.streams[id]; !exists {
+			plan[id] = StreamActionCreate
+		}
+	}
+	return plan
+}
+
+// ApplyConfigSet replaces the set of active streams with a new set of stream
+// configs atomically, where streams missing from the set are deleted, streams
+// with a changed config are updated and new streams are created. If any of
+// these changes fail then the changes already made are reverted and an error
+// is returned, which means either all changes are applied or none are.
+//
+// The actions that were planned for each stream are returned regardless of
+// whether they were successfully applied.
+func (m *Type) ApplyConfigSet(set ConfigSet, timeout time.Duration) (map[string]StreamAction, error) {
+	m.applyLock.Lock()
+	defer m.applyLock.Unlock()
+
+	m.lock.Lock()
+	closed := m.closed
+	prevConfs := make(map[string]stream.Config, len(m.streams))
+	for id, wrapper := range m.streams {
+		prevConfs[id] = wrapper.config
+	}
+	plan := m.planConfigSet(set)
+	m.lock.Unlock()
+
+	if closed {
+		return nil, component.ErrTypeClosed
+	}
+
+	// Changes are applied in a deterministic order with deletions first so
+	// that streams being removed release any shared resources, such as HTTP
+	// paths, before the streams that replace them are created.
+	var ids []string
+	for id, action := range plan {
+		if action != StreamActionUnchanged {
+			ids = append(ids, id)
+		}
+	}
+	actionOrder := map[StreamAction]int{
+		StreamActionDelete: 0,
+		StreamActionUpdate: 1,
+		StreamActionCreate: 2,
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if oi, oj := actionOrder[plan[ids[i]]], actionOrder[plan[ids[j]]]; oi != oj {
+			return oi < oj
+		}
+		return ids[i] < ids[j]
+	})
+
+	var applied []string
+	for _, id := range ids {
+		var err error
+		switch plan[id] {
+		case StreamActionDelete:
+			err = m.Delete(id, timeout)
+		case StreamActionUpdate:
+			err = m.Update(id, set[id], timeout)
+		case StreamActionCreate:
+			err = m.Create(id, set[id])
+		}
+		if err == nil {
+			applied = append(applied, id)
+			continue
+		}
+
+		errs := []string{fmt.Sprintf("failed to %v stream '%v': %v", plan[id], id, err)}
+		if plan[id] == StreamActionUpdate {
+			// A failed update may have already removed the previous version
+			// of the stream.
+			applied = append(applied, id)
+		}
+		for i := len(applied) - 1; i >= 0; i-- {
+			rID := applied[i]
+			if rErr := m.revert(rID, plan[rID], prevConfs[rID], timeout); rErr != nil {
+				errs = append(errs, fmt.Sprintf("failed to revert %v of stream '%v': %v", plan[rID], rID, rErr))
+			}
+		}
+		return plan, errors.New(strings.Join(errs, "\n"))
+	}
+	return plan, nil
+}
+
+// revert undoes an action applied to a stream by restoring its previous
+// config.
+func (m *Type) revert(id string, action StreamAction, prevConf stream.Config, timeout time.Duration) error {
+	if action != StreamActionDelete {
+		if err := m.Delete(id, timeout); err != nil && !errors.Is(err, ErrStreamDoesNotExist) {
+			return err
+		}
+	}
+	if action == StreamActionCreate {
+		return nil
+	}
+	return m.Create(id, prevConf)
+}
+
+//------------------------------------------------------------------------------
+
 // Stop attempts to gracefully shut down all active streams and close the
 // stream manager.
 func (m *Type) Stop(timeout time.Duration) error {
@@ -13,6 +13,7 @@ import (
 	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/old/output"
+	"github.com/benthosdev/benthos/v4/internal/old/processor"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -103,3 +104,84 @@ func TestTypeBasicClose(t *testing.T) {
 		t.Errorf("Unexpected error: %v != %v", act, exp)
 	}
 }
+
+func TestTypeApplyConfigSet(t *testing.T) {
+	res, err := bmanager.NewV2(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := New(res)
+
+	fooConf := harmlessConf()
+	fooConf.Input.HTTPServer.Path = "/foo"
+	barConf := harmlessConf()
+	barConf.Input.HTTPServer.Path = "/bar"
+
+	require.NoError(t, mgr.Create("foo", fooConf))
+	require.NoError(t, mgr.Create("bar", barConf))
+
+	newBarConf := harmlessConf()
+	newBarConf.Input.HTTPServer.Path = "/bar2"
+	bazConf := harmlessConf()
+	bazConf.Input.HTTPServer.Path = "/baz"
+
+	plan, err := mgr.ApplyConfigSet(ConfigSet{
+		"foo": fooConf,
+		"bar": newBarConf,
+		"baz": bazConf,
+	}, time.Second*5)
+	require.NoError(t, err)
+	require.Equal(t, map[string]StreamAction{
+		"foo": StreamActionUnchanged,
+		"bar": StreamActionUpdate,
+		"baz": StreamActionCreate,
+	}, plan)
+
+	status, err := mgr.Read("bar")
+	require.NoError(t, err)
+	require.Equal(t, "/bar2", status.Config().Input.HTTPServer.Path)
+
+	badConf := harmlessConf()
+	badProc := processor.NewConfig()
+	badProc.Type = "bloblang"
+	badProc.Bloblang = "root = this.("
+	badConf.Pipeline.Processors = append(badConf.Pipeline.Processors, badProc)
+
+	plan, err = mgr.ApplyConfigSet(ConfigSet{
+		"bar":  barConf,
+		"baz":  bazConf,
+		"buz":  harmlessConf(),
+		"quz":  badConf,
+		"quz2": harmlessConf(),
+	}, time.Second*5)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to create stream 'quz'")
+	require.Equal(t, map[string]StreamAction{
+		"foo":  StreamActionDelete,
+		"bar":  StreamActionUpdate,
+		"baz":  StreamActionUnchanged,
+		"buz":  StreamActionCreate,
+		"quz":  StreamActionCreate,
+		"quz2": StreamActionCreate,
+	}, plan)
+
+	// All changes made before the failure are reverted.
+	for id, path := range map[string]string{
+		"foo": "/foo",
+		"bar": "/bar2",
+		"baz": "/baz",
+	} {
+		status, err := mgr.Read(id)
+		require.NoError(t, err, id)
+		require.Equal(t, path, status.Config().Input.HTTPServer.Path, id)
+		require.True(t, status.IsRunning(), id)
+	}
+	for _, id := range []string{"buz", "quz", "quz2"} {
+		_, err := mgr.Read(id)
+		require.Equal(t, ErrStreamDoesNotExist, err, id)
+	}
+
+	require.NoError(t, mgr.Stop(time.Second*5))
+
+	_, err = mgr.ApplyConfigSet(ConfigSet{}, time.Second)
+	require.Equal(t, component.ErrTypeClosed, err)
+}
@@ -2,17 +2,23 @@ package stream
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
 	"runtime/pprof"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	ibuffer "github.com/benthosdev/benthos/v4/internal/component/buffer"
 	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
 	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/interop"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/pipeline"
+	"github.com/benthosdev/benthos/v4/internal/schedule"
 )
 
 //------------------------------------------------------------------------------
@@ -28,20 +34,37 @@ type Type struct {
 
 	manager bundle.NewManagement
 
+	startedAt     time.Time
+	onStartHook   *hook
+	onCloseHook   *hook
+	closeHookOnce sync.Once
+	closeHookDone chan struct{}
+	forcedStop    int32
+
 	onClose func()
 }
 
 // New creates a new stream.Type.
 func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, error) {
 	t := &Type{
-		conf:    conf,
-		manager: mgr,
-		onClose: func() {},
+		conf:          conf,
+		manager:       mgr,
+		closeHookDone: make(chan struct{}),
+		onClose:       func() {},
 	}
 	for _, opt := range opts {
 		opt(t)
 	}
+	if err := t.initVars(); err != nil {
+		return nil, err
+	}
+	if err := t.initHooks(); err != nil {
+		return nil, err
+	}
 	if err := t.start(); err != nil {
+		if t.onCloseHook != nil {
+			t.onCloseHook.close()
+		}
 		return nil, err
 	}
 
@@ -86,12 +109,95 @@ func (t *Type) IsReady() bool {
 	return t.inputLayer.Connected() && t.outputLayer.Connected()
 }
 
+// bloblEnvManager is implemented by managers that can be scoped to a custom
+// Bloblang environment.
+type bloblEnvManager interface {
+	WithBloblangEnvironment(env *bloblang.Environment) interop.Manager
+}
+
+// initVars exposes the variables of the stream to Bloblang mappings of its
+// components via the function `var`.
+func (t *Type) initVars() error {
+	if len(t.conf.Vars) == 0 {
+		return nil
+	}
+	values, err := ResolveVars(t.conf.Vars)
+	if err != nil {
+		return err
+	}
+	if m, ok := t.manager.(bloblEnvManager); ok {
+		t.manager = m.WithBloblangEnvironment(t.manager.BloblEnvironment().WithVars(values)).(bundle.NewManagement)
+	}
+	return nil
+}
+
+func (t *Type) initHooks() (err error) {
+	hMgr := t.manager.IntoPath("hooks").(bundle.NewManagement)
+	if t.conf.Hooks.OnStart != nil {
+		if t.onStartHook, err = newHook("start", *t.conf.Hooks.OnStart, hMgr.IntoPath("on_start").(bundle.NewManagement)); err != nil {
+			return fmt.Errorf("failed to create on_start hook: %w", err)
+		}
+	}
+	if t.conf.Hooks.OnClose != nil {
+		if t.onCloseHook, err = newHook("close", *t.conf.Hooks.OnClose, hMgr.IntoPath("on_close").(bundle.NewManagement)); err != nil {
+			if t.onStartHook != nil {
+				t.onStartHook.close()
+			}
+			return fmt.Errorf("failed to create on_close hook: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *Type) runStartHook() {
+	if t.onStartHook == nil {
+		return
+	}
+	defer t.onStartHook.close()
+	if err := t.onStartHook.run(map[string]string{
+		"stream_start_time": t.startedAt.Format(time.RFC3339Nano),
+	}); err != nil {
+		t.manager.Logger().Errorf("Failed to execute on_start hook: %v\n", err)
+	}
+}
+
+// runCloseHook executes the on_close hook at most once, unless the stream was
+// forced to stop.
+func (t *Type) runCloseHook() {
+	t.closeHookOnce.Do(func() {
+		defer close(t.closeHookDone)
+		if t.onCloseHook == nil {
+			return
+		}
+		defer t.onCloseHook.close()
+		if atomic.LoadInt32(&t.forcedStop) == 1 {
+			return
+		}
+		closedAt := time.Now()
+		if err := t.onCloseHook.run(map[string]string{
+			"stream_start_time": t.startedAt.Format(time.RFC3339Nano),
+			"stream_close_time": closedAt.Format(time.RFC3339Nano),
+			"stream_uptime":     closedAt.Sub(t.startedAt).String(),
+		}); err != nil {
+			t.manager.Logger().Errorf("Failed to execute on_close hook: %v\n", err)
+		}
+	})
+}
+
 func (t *Type) start() (err error) {
+	t.startedAt = time.Now()
+	t.runStartHook()
+
 	// Constructors
 	iMgr := t.manager.IntoPath("input").(bundle.NewManagement)
 	if t.inputLayer, err = iMgr.NewInput(t.conf.Input); err != nil {
 		return
 	}
+	if len(t.conf.Schedules) > 0 {
+		if t.inputLayer, err = schedule.NewInput(t.conf.Schedules, t.inputLayer, t.manager); err != nil {
+			return
+		}
+	}
 	if t.conf.Buffer.Type != "none" {
 		bMgr := t.manager.IntoPath("buffer").(bundle.NewManagement)
 		if t.bufferLayer, err = bMgr.NewBuffer(t.conf.Buffer); err != nil {
@@ -132,6 +238,7 @@ func (t *Type) start() (err error) {
 	go func(out ioutput.Streamed) {
 		for {
 			if err := out.WaitForClose(time.Second); err == nil {
+				t.runCloseHook()
 				t.onClose()
 				return
 			}
@@ -188,6 +295,14 @@ func (t *Type) StopGracefully(timeout time.Duration) (err error) {
 		return
 	}
 
+	if t.onCloseHook != nil {
+		remaining = timeout - time.Since(started)
+		select {
+		case <-t.closeHookDone:
+		case <-time.After(remaining):
+			return component.ErrTimeout
+		}
+	}
 	return nil
 }
 
@@ -240,8 +355,10 @@ func (t *Type) StopOrdered(timeout time.Duration) (err error) {
 
 // StopUnordered attempts to close all components in parallel without allowing
 // the stream to gracefully wind down in the order of component layers. This
-// should only be attempted if both stopGracefully and stopOrdered failed.
+// should only be attempted if both stopGracefully and stopOrdered failed. The
+// on_close hook is not executed when it hasn't already started.
 func (t *Type) StopUnordered(timeout time.Duration) (err error) {
+	atomic.StoreInt32(&t.forcedStop, 1)
 	t.inputLayer.CloseAsync()
 	if t.bufferLayer != nil {
 		t.bufferLayer.CloseAsync()
@@ -0,0 +1,126 @@
+package stream
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// VarConfig describes a typed variable that can be referenced throughout the
+// config of a stream with `${vars.<name>}`, and within Bloblang mappings of the
+// stream with `var("<name>")`.
+type VarConfig struct {
+	Type        string      `json:"type" yaml:"type"`
+	Default     interface{} `json:"default,omitempty" yaml:"default,omitempty"`
+	Description string      `json:"description" yaml:"description"`
+}
+
+// NewVarConfig returns a variable configuration with default values.
+func NewVarConfig() VarConfig {
+	return VarConfig{
+		Type:        "string",
+		Default:     nil,
+		Description: "",
+	}
+}
+
+// UnmarshalYAML ensures that when parsing variable configs the default values
+// are still applied.
+func (v *VarConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias VarConfig
+	aliased := confAlias(NewVarConfig())
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+	*v = VarConfig(aliased)
+	return nil
+}
+
+// VarsSpec returns the field spec of the variables of a stream.
+func VarsSpec() docs.FieldSpec {
+	return docs.FieldObject("vars", "A map of typed variables that can be referenced throughout the config with the interpolation `${vars.<name>}`, and within Bloblang mappings of the stream with the function `var(\"<name>\")`. The value of a variable can be overridden at runtime with the CLI flag `--var <name>=<value>`, or from an environment variable by using an interpolation within its `default`, e.g. `${REGION:eu-west-1}`.").Map().WithChildren(
+		docs.FieldString("type", "The type of the variable, values provided to the variable are checked against the type when the config is read.").HasOptions("string", "int", "float", "bool").HasDefault("string"),
+		docs.FieldAnything("default", "The value of the variable when it is not overridden. When omitted the variable must be provided a value with the `--var` flag.").HasDefault(nil).Optional(),
+		docs.FieldString("description", "An optional description of the variable.").HasDefault(""),
+	).HasDefault(map[string]interface{}{}).Advanced()
+}
+
+// Resolve returns the value of a variable converted to its type.
+func (v VarConfig) Resolve() (interface{}, error) {
+	if v.Default == nil {
+		return nil, fmt.Errorf("a value of type %v is required", v.Type)
+	}
+	switch v.Type {
+	case "string":
+		switch t := v.Default.(type) {
+		case string:
+			return t, nil
+		case int, int64, float64, bool:
+			return fmt.Sprintf("%v", t), nil
+		}
+	case "int":
+		switch t := v.Default.(type) {
+		case int:
+			return int64(t), nil
+		case int64:
+			return t, nil
+		case string:
+			i, err := strconv.ParseInt(t, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse '%v' as int", t)
+			}
+			return i, nil
+		}
+	case "float":
+		switch t := v.Default.(type) {
+		case int:
+			return float64(t), nil
+		case int64:
+			return float64(t), nil
+		case float64:
+			return t, nil
+		case string:
+			f, err := strconv.ParseFloat(t, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse '%v' as float", t)
+			}
+			return f, nil
+		}
+	case "bool":
+		switch t := v.Default.(type) {
+		case bool:
+			return t, nil
+		case string:
+			b, err := strconv.ParseBool(t)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse '%v' as bool", t)
+			}
+			return b, nil
+		}
+	default:
+		return nil, fmt.Errorf("unrecognised type: %v", v.Type)
+	}
+	return nil, fmt.Errorf("expected value of type %v, got %T", v.Type, v.Default)
+}
+
+// ResolveVars returns the values of a map of variables converted to their
+// types.
+func ResolveVars(vars map[string]VarConfig) (map[string]interface{}, error) {
+	names := make([]string, 0, len(vars))
+	for k := range vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	values := make(map[string]interface{}, len(vars))
+	for _, k := range names {
+		v, err := vars[k].Resolve()
+		if err != nil {
+			return nil, fmt.Errorf("variable %v: %w", k, err)
+		}
+		values[k] = v
+	}
+	return values, nil
+}
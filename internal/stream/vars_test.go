@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestVarsResolve(t *testing.T) {
+	var conf Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+vars:
+  a:
+    default: foo
+  b:
+    type: int
+    default: "10"
+  c:
+    type: float
+    default: 5
+  d:
+    type: bool
+    default: "false"
+  e:
+    default: 12
+`), &conf))
+
+	values, err := ResolveVars(conf.Vars)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": "foo",
+		"b": int64(10),
+		"c": 5.0,
+		"d": false,
+		"e": "12",
+	}, values)
+}
+
+func TestVarsResolveErrors(t *testing.T) {
+	tests := map[string]struct {
+		conf VarConfig
+		err  string
+	}{
+		"missing value": {
+			conf: VarConfig{Type: "int"},
+			err:  "a value of type int is required",
+		},
+		"bad type": {
+			conf: VarConfig{Type: "duration", Default: "1s"},
+			err:  "unrecognised type: duration",
+		},
+		"bad int": {
+			conf: VarConfig{Type: "int", Default: 1.5},
+			err:  "expected value of type int, got float64",
+		},
+		"bad bool": {
+			conf: VarConfig{Type: "bool", Default: "nah"},
+			err:  "failed to parse 'nah' as bool",
+		},
+	}
+
+	for name, test := range tests {
+		_, err := test.conf.Resolve()
+		assert.EqualError(t, err, test.err, name)
+	}
+}
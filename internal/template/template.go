@@ -128,9 +128,86 @@ func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
 
 //------------------------------------------------------------------------------
 
+var (
+	registeredMut sync.RWMutex
+	registered    = map[docs.Type]map[string]*compiled{}
+)
+
+// ExpandedHeadCommentPrefix prefixes the head comment of component configs
+// expanded from a template, followed by the name of the template.
+const ExpandedHeadCommentPrefix = "expanded from template "
+
+// ExpandComponentYAML expands a component config node when it is of a template
+// type, returning a nil node when it isn't. The resulting node is the config of
+// the component the template expands into, with the label and processors of
+// the original config retained, and a head comment naming the template.
+func ExpandComponentYAML(cType docs.Type, name string, node *yaml.Node) (*yaml.Node, error) {
+	registeredMut.RLock()
+	tmpl, exists := registered[cType][name]
+	registeredMut.RUnlock()
+	if !exists {
+		return nil, nil
+	}
+
+	body := &yaml.Node{Kind: yaml.MappingNode}
+	var label, procs *yaml.Node
+	for i := 0; i < len(node.Content)-1; i += 2 {
+		switch node.Content[i].Value {
+		case name, "plugin":
+			body = node.Content[i+1]
+		case "label":
+			label = node.Content[i+1]
+		case "processors":
+			procs = node.Content[i+1]
+		}
+	}
+
+	expanded, err := tmpl.ExpandToNode(body)
+	if err != nil {
+		return nil, fmt.Errorf("template %v: %w", name, err)
+	}
+
+	var newContent []*yaml.Node
+	if label != nil {
+		newContent = append(newContent, &yaml.Node{Kind: yaml.ScalarNode, Value: "label"}, label)
+	}
+	for i := 0; i < len(expanded.Content)-1; i += 2 {
+		if expanded.Content[i].Value == "label" {
+			continue
+		}
+		if expanded.Content[i].Value == "processors" && procs != nil {
+			// Template processors are inserted before the configured processors
+			// of inputs and after those of outputs.
+			if cType == docs.TypeOutput {
+				expanded.Content[i+1].Content = append(procs.Content, expanded.Content[i+1].Content...)
+			} else {
+				expanded.Content[i+1].Content = append(expanded.Content[i+1].Content, procs.Content...)
+			}
+			procs = nil
+		}
+		newContent = append(newContent, expanded.Content[i], expanded.Content[i+1])
+	}
+	if procs != nil {
+		newContent = append(newContent, &yaml.Node{Kind: yaml.ScalarNode, Value: "processors"}, procs)
+	}
+
+	return &yaml.Node{
+		Kind:        yaml.MappingNode,
+		Content:     newContent,
+		HeadComment: ExpandedHeadCommentPrefix + name,
+	}, nil
+}
+
 // RegisterTemplate attempts to add a template component to the global list of
 // component types.
 func registerTemplate(tmpl *compiled) error {
+	registeredMut.Lock()
+	if registered[tmpl.spec.Type] == nil {
+		registered[tmpl.spec.Type] = map[string]*compiled{}
+	}
+	registered[tmpl.spec.Type][tmpl.spec.Name] = tmpl
+	registeredMut.Unlock()
+
 	switch tmpl.spec.Type {
 	case docs.TypeCache:
 		return registerCacheTemplate(tmpl, bundle.AllCaches)
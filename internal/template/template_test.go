@@ -7,7 +7,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/template"
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
 )
@@ -29,3 +31,42 @@ func TestTemplateTesting(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandComponentYAML(t *testing.T) {
+	tmplPath := filepath.Join(t.TempDir(), "tmpl.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(`
+name: test_expand_upper
+type: processor
+fields:
+  - name: prefix
+    type: string
+mapping: |
+  root.bloblang = "root = \"%v\" + content().uppercase()".format(this.prefix)
+`), 0o644))
+
+	_, err := template.InitTemplates(tmplPath)
+	require.NoError(t, err)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+label: foo
+test_expand_upper:
+  prefix: bar
+`), &node))
+
+	expanded, err := template.ExpandComponentYAML(docs.TypeProcessor, "test_expand_upper", node.Content[0])
+	require.NoError(t, err)
+	require.NotNil(t, expanded)
+	assert.Equal(t, template.ExpandedHeadCommentPrefix+"test_expand_upper", expanded.HeadComment)
+
+	var res map[string]interface{}
+	require.NoError(t, expanded.Decode(&res))
+	assert.Equal(t, map[string]interface{}{
+		"label":    "foo",
+		"bloblang": `root = "bar" + content().uppercase()`,
+	}, res)
+
+	expanded, err = template.ExpandComponentYAML(docs.TypeProcessor, "bloblang", node.Content[0])
+	require.NoError(t, err)
+	assert.Nil(t, expanded)
+}
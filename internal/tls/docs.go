@@ -47,5 +47,17 @@ func FieldSpec() docs.FieldSpec {
 			docs.FieldString("cert_file", "The path to a certificate to use.").HasDefault(""),
 			docs.FieldString("key_file", "The path of a certificate key to use.").HasDefault(""),
 		),
+
+		docs.FieldString(
+			"reload_interval", "An optional period of time after which the files of `root_cas_file` and `client_certs` are checked for modifications during TLS handshakes, and reloaded when they have changed. This allows short lived certificates to be rotated without restarting the component. When empty the files are only read once.", "30s",
+		).Advanced().HasDefault(""),
+
+		docs.FieldObject(
+			"spiffe", "Obtain the client certificate and the root certificate authorities from a [SPIFFE Workload API](https://spiffe.io/docs/latest/spiffe-about/overview/), such as a SPIRE agent, which rotates them automatically. Server certificates are verified against the trust bundles of the workload, and cannot be combined with the fields `root_cas`, `root_cas_file` and `client_certs`.",
+		).WithChildren(
+			docs.FieldBool("enabled", "Whether to obtain certificates from a SPIFFE Workload API.").HasDefault(false),
+			docs.FieldString("workload_api_address", "The address of the Workload API, when empty the environment variable `SPIFFE_ENDPOINT_SOCKET` is used.", "unix:///run/spire/sockets/agent.sock").HasDefault(""),
+			docs.FieldString("authorized_ids", "An optional list of SPIFFE IDs that servers are permitted to present, when empty any SPIFFE ID within the trust bundles is permitted.", []interface{}{"spiffe://example.org/kafka"}).Array().HasDefault([]interface{}{}),
+		).Advanced(),
 	).Advanced()
 }
@@ -0,0 +1,160 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileReloader keeps the certificates and root certificate authorities of a
+// config that are loaded from files up to date. Rather than watching the files
+// in the background they're checked for modifications during TLS handshakes, at
+// most once per interval, which means a *tls.Config using the reloader does not
+// need to be closed.
+type fileReloader struct {
+	conf     Config
+	interval time.Duration
+
+	mut         sync.Mutex
+	lastChecked time.Time
+	modTimes    map[string]time.Time
+	rootCAs     *x509.CertPool
+	certs       []tls.Certificate
+}
+
+func newFileReloader(conf *Config, interval time.Duration) (*fileReloader, error) {
+	r := &fileReloader{
+		conf:     *conf,
+		interval: interval,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.lastChecked = time.Now()
+	return r, nil
+}
+
+func (r *fileReloader) statFiles() (map[string]time.Time, error) {
+	paths := []string{r.conf.RootCAsFile}
+	for _, cc := range r.conf.ClientCertificates {
+		paths = append(paths, cc.CertFile, cc.KeyFile)
+	}
+
+	modTimes := map[string]time.Time{}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[p] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// load reads all certificates and root certificate authorities, replacing the
+// current ones only when all of them were read successfully.
+func (r *fileReloader) load() error {
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return err
+	}
+
+	var rootCAs *x509.CertPool
+	if len(r.conf.RootCAsFile) > 0 {
+		caCert, err := os.ReadFile(r.conf.RootCAsFile)
+		if err != nil {
+			return err
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caCert) {
+			return errors.New("no certificates found in root_cas_file")
+		}
+	}
+
+	certs := make([]tls.Certificate, 0, len(r.conf.ClientCertificates))
+	for _, conf := range r.conf.ClientCertificates {
+		cert, err := conf.Load()
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	r.modTimes, r.rootCAs, r.certs = modTimes, rootCAs, certs
+	return nil
+}
+
+// current returns the loaded root certificate authorities and certificates,
+// reloading them first when their files have been modified since they were last
+// loaded. Files that fail to load, which can happen whilst they're only
+// partially written, are attempted again at the next check and in the meantime
+// the previously loaded certificates remain in use.
+func (r *fileReloader) current() (*x509.CertPool, []tls.Certificate) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if time.Since(r.lastChecked) < r.interval {
+		return r.rootCAs, r.certs
+	}
+	r.lastChecked = time.Now()
+
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return r.rootCAs, r.certs
+	}
+	for p, t := range modTimes {
+		if !t.Equal(r.modTimes[p]) {
+			_ = r.load()
+			break
+		}
+	}
+	return r.rootCAs, r.certs
+}
+
+// apply hooks the reloader into a *tls.Config, where verify determines whether
+// server certificates are verified against reloaded root certificate
+// authorities.
+func (r *fileReloader) apply(tlsConf *tls.Config, verify bool) {
+	if len(r.certs) > 0 {
+		tlsConf.GetClientCertificate = func(cri *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			_, certs := r.current()
+			for i := range certs {
+				if cri.SupportsCertificate(&certs[i]) == nil {
+					return &certs[i], nil
+				}
+			}
+			return &tls.Certificate{}, nil
+		}
+	}
+
+	if r.rootCAs == nil || !verify {
+		return
+	}
+
+	// The root certificate authorities of a *tls.Config cannot be swapped once
+	// in use, and therefore server certificates are verified manually in place
+	// of the standard verification.
+	tlsConf.InsecureSkipVerify = true
+	tlsConf.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("server did not provide a certificate")
+		}
+		rootCAs, _ := r.current()
+		opts := x509.VerifyOptions{
+			DNSName:       cs.ServerName,
+			Roots:         rootCAs,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+}
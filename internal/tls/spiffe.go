@@ -0,0 +1,75 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+)
+
+// spiffeFetchTimeout is the maximum period to wait for the initial SVID and
+// bundles from a Workload API.
+const spiffeFetchTimeout = 30 * time.Second
+
+// Sources watch a Workload API in the background for rotated SVIDs and
+// bundles. Since a *tls.Config cannot be closed they are shared by all configs
+// targeting the same address and live for the remainder of the process.
+var (
+	spiffeSourcesMut sync.Mutex
+	spiffeSources    = map[string]*workloadapi.X509Source{}
+)
+
+func getSPIFFESource(addr string) (*workloadapi.X509Source, error) {
+	spiffeSourcesMut.Lock()
+	defer spiffeSourcesMut.Unlock()
+
+	if source, exists := spiffeSources[addr]; exists {
+		return source, nil
+	}
+
+	var clientOpts []workloadapi.ClientOption
+	if addr != "" {
+		clientOpts = append(clientOpts, workloadapi.WithAddr(addr))
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), spiffeFetchTimeout)
+	defer done()
+
+	source, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(clientOpts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SVID from workload API: %w", err)
+	}
+	spiffeSources[addr] = source
+	return source, nil
+}
+
+// apply hooks a SPIFFE Workload API into a *tls.Config, where the SVID of the
+// workload is presented as a client certificate and server certificates are
+// verified against the bundles of the workload and authorized by their SPIFFE
+// ID.
+func (s SPIFFEConfig) apply(tlsConf *tls.Config) error {
+	authorizer := tlsconfig.AuthorizeAny()
+	if len(s.AuthorizedIDs) > 0 {
+		ids := make([]spiffeid.ID, 0, len(s.AuthorizedIDs))
+		for _, idStr := range s.AuthorizedIDs {
+			id, err := spiffeid.FromString(idStr)
+			if err != nil {
+				return fmt.Errorf("failed to parse authorized SPIFFE ID '%v': %w", idStr, err)
+			}
+			ids = append(ids, id)
+		}
+		authorizer = tlsconfig.AuthorizeOneOf(ids...)
+	}
+
+	source, err := getSPIFFESource(s.WorkloadAPIAddress)
+	if err != nil {
+		return err
+	}
+	tlsconfig.HookMTLSClientConfig(tlsConf, source, source, authorizer)
+	return nil
+}
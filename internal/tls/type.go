@@ -4,7 +4,9 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"os"
+	"time"
 )
 
 //------------------------------------------------------------------------------
@@ -38,6 +40,14 @@ type ClientCertConfig struct {
 	Key      string `json:"key" yaml:"key"`
 }
 
+// SPIFFEConfig contains configuration params for obtaining certificates from
+// a SPIFFE Workload API.
+type SPIFFEConfig struct {
+	Enabled            bool     `json:"enabled" yaml:"enabled"`
+	WorkloadAPIAddress string   `json:"workload_api_address" yaml:"workload_api_address"`
+	AuthorizedIDs      []string `json:"authorized_ids" yaml:"authorized_ids"`
+}
+
 // Config contains configuration params for TLS.
 type Config struct {
 	Enabled             bool               `json:"enabled" yaml:"enabled"`
@@ -46,6 +56,8 @@ type Config struct {
 	InsecureSkipVerify  bool               `json:"skip_cert_verify" yaml:"skip_cert_verify"`
 	ClientCertificates  []ClientCertConfig `json:"client_certs" yaml:"client_certs"`
 	EnableRenegotiation bool               `json:"enable_renegotiation" yaml:"enable_renegotiation"`
+	ReloadInterval      string             `json:"reload_interval" yaml:"reload_interval"`
+	SPIFFE              SPIFFEConfig       `json:"spiffe" yaml:"spiffe"`
 }
 
 // NewConfig creates a new Config with default values.
@@ -57,6 +69,12 @@ func NewConfig() Config {
 		InsecureSkipVerify:  false,
 		ClientCertificates:  []ClientCertConfig{},
 		EnableRenegotiation: false,
+		ReloadInterval:      "",
+		SPIFFE: SPIFFEConfig{
+			Enabled:            false,
+			WorkloadAPIAddress: "",
+			AuthorizedIDs:      []string{},
+		},
 	}
 }
 
@@ -79,29 +97,56 @@ func (c *Config) Get() (*tls.Config, error) {
 		return nil, errors.New("only one field between root_cas and root_cas_file can be specified")
 	}
 
-	if len(c.RootCAsFile) > 0 {
-		caCert, err := os.ReadFile(c.RootCAsFile)
-		if err != nil {
-			return nil, err
+	if c.SPIFFE.Enabled {
+		if len(c.RootCAs) > 0 || len(c.RootCAsFile) > 0 || len(c.ClientCertificates) > 0 {
+			return nil, errors.New("spiffe cannot be combined with root_cas, root_cas_file or client_certs")
 		}
 		initConf()
-		tlsConf.RootCAs = x509.NewCertPool()
-		tlsConf.RootCAs.AppendCertsFromPEM(caCert)
+		if err := c.SPIFFE.apply(tlsConf); err != nil {
+			return nil, err
+		}
 	}
 
-	if len(c.RootCAs) > 0 {
-		initConf()
-		tlsConf.RootCAs = x509.NewCertPool()
-		tlsConf.RootCAs.AppendCertsFromPEM([]byte(c.RootCAs))
+	var reloadInterval time.Duration
+	if len(c.ReloadInterval) > 0 {
+		var err error
+		if reloadInterval, err = time.ParseDuration(c.ReloadInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse reload_interval: %w", err)
+		}
 	}
 
-	for _, conf := range c.ClientCertificates {
-		cert, err := conf.Load()
+	if reloadInterval > 0 && c.hasFiles() {
+		r, err := newFileReloader(c, reloadInterval)
 		if err != nil {
 			return nil, err
 		}
 		initConf()
-		tlsConf.Certificates = append(tlsConf.Certificates, cert)
+		r.apply(tlsConf, !c.InsecureSkipVerify)
+	} else {
+		if len(c.RootCAsFile) > 0 {
+			caCert, err := os.ReadFile(c.RootCAsFile)
+			if err != nil {
+				return nil, err
+			}
+			initConf()
+			tlsConf.RootCAs = x509.NewCertPool()
+			tlsConf.RootCAs.AppendCertsFromPEM(caCert)
+		}
+
+		for _, conf := range c.ClientCertificates {
+			cert, err := conf.Load()
+			if err != nil {
+				return nil, err
+			}
+			initConf()
+			tlsConf.Certificates = append(tlsConf.Certificates, cert)
+		}
+	}
+
+	if len(c.RootCAs) > 0 {
+		initConf()
+		tlsConf.RootCAs = x509.NewCertPool()
+		tlsConf.RootCAs.AppendCertsFromPEM([]byte(c.RootCAs))
 	}
 
 	if c.EnableRenegotiation {
@@ -112,11 +157,26 @@ func (c *Config) Get() (*tls.Config, error) {
 	if c.InsecureSkipVerify {
 		initConf()
 		tlsConf.InsecureSkipVerify = true
+		tlsConf.VerifyPeerCertificate = nil
 	}
 
 	return tlsConf, nil
 }
 
+// hasFiles returns whether any certificates or root certificate authorities are
+// loaded from files.
+func (c *Config) hasFiles() bool {
+	if len(c.RootCAsFile) > 0 {
+		return true
+	}
+	for _, cc := range c.ClientCertificates {
+		if cc.CertFile != "" || cc.KeyFile != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // Load returns a TLS certificate, based on either file paths in the
 // config or the raw certs as strings.
 func (c *ClientCertConfig) Load() (tls.Certificate, error) {
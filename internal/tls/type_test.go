@@ -0,0 +1,309 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func (c testCert) tlsCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	require.NoError(t, err)
+	return cert
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		tmpl.DNSNames = []string{cn}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeTestFile writes a file with an explicit modification time so that
+// rewrites are detected regardless of the resolution of the filesystem.
+func writeTestFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+type testTLSServer struct {
+	addr string
+
+	mut  sync.Mutex
+	cert tls.Certificate
+}
+
+func (s *testTLSServer) setCert(cert tls.Certificate) {
+	s.mut.Lock()
+	s.cert = cert
+	s.mut.Unlock()
+}
+
+func startTestTLSServer(t *testing.T, cert tls.Certificate) *testTLSServer {
+	t.Helper()
+
+	s := &testTLSServer{cert: cert}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			s.mut.Lock()
+			defer s.mut.Unlock()
+			c := s.cert
+			return &c, nil
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+
+	s.addr = ln.Addr().String()
+	return s
+}
+
+func dialTestTLSServer(addr string, conf *tls.Config) error {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, conf)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func TestReloadRootCAsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	caA := newTestCert(t, "ca_a", nil)
+	caB := newTestCert(t, "ca_b", nil)
+	serverA := newTestCert(t, "localhost", &caA)
+	serverB := newTestCert(t, "localhost", &caB)
+
+	caPath := filepath.Join(dir, "ca.pem")
+	writeTestFile(t, caPath, caA.certPEM, time.Now().Add(-time.Minute))
+
+	server := startTestTLSServer(t, serverA.tlsCert(t))
+
+	conf := NewConfig()
+	conf.Enabled = true
+	conf.RootCAsFile = caPath
+	conf.ReloadInterval = "1ns"
+
+	tlsConf, err := conf.Get()
+	require.NoError(t, err)
+	tlsConf.ServerName = "localhost"
+
+	require.NoError(t, dialTestTLSServer(server.addr, tlsConf))
+
+	server.setCert(serverB.tlsCert(t))
+	require.Error(t, dialTestTLSServer(server.addr, tlsConf))
+
+	writeTestFile(t, caPath, caB.certPEM, time.Now())
+	require.NoError(t, dialTestTLSServer(server.addr, tlsConf))
+
+	server.setCert(serverA.tlsCert(t))
+	require.Error(t, dialTestTLSServer(server.addr, tlsConf))
+}
+
+func TestReloadRootCAsFileBadRewrite(t *testing.T) {
+	dir := t.TempDir()
+
+	ca := newTestCert(t, "ca", nil)
+	serverCert := newTestCert(t, "localhost", &ca)
+
+	caPath := filepath.Join(dir, "ca.pem")
+	writeTestFile(t, caPath, ca.certPEM, time.Now().Add(-time.Minute))
+
+	server := startTestTLSServer(t, serverCert.tlsCert(t))
+
+	conf := NewConfig()
+	conf.Enabled = true
+	conf.RootCAsFile = caPath
+	conf.ReloadInterval = "1ns"
+
+	tlsConf, err := conf.Get()
+	require.NoError(t, err)
+	tlsConf.ServerName = "localhost"
+
+	// A partially written file is ignored and the previous CAs remain in use.
+	writeTestFile(t, caPath, ca.certPEM[:20], time.Now())
+	require.NoError(t, dialTestTLSServer(server.addr, tlsConf))
+}
+
+func TestReloadClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+
+	ca := newTestCert(t, "ca", nil)
+	clientA := newTestCert(t, "client_a", &ca)
+	clientB := newTestCert(t, "client_b", &ca)
+
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeTestFile(t, certPath, clientA.certPEM, time.Now().Add(-time.Minute))
+	writeTestFile(t, keyPath, clientA.keyPEM, time.Now().Add(-time.Minute))
+
+	conf := NewConfig()
+	conf.Enabled = true
+	conf.ClientCertificates = []ClientCertConfig{
+		{CertFile: certPath, KeyFile: keyPath},
+	}
+	conf.ReloadInterval = "1ns"
+
+	tlsConf, err := conf.Get()
+	require.NoError(t, err)
+	require.NotNil(t, tlsConf.GetClientCertificate)
+	assert.Empty(t, tlsConf.Certificates)
+
+	cri := &tls.CertificateRequestInfo{
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		Version:          tls.VersionTLS13,
+	}
+
+	cert, err := tlsConf.GetClientCertificate(cri)
+	require.NoError(t, err)
+	require.Len(t, cert.Certificate, 1)
+	assert.Equal(t, clientA.cert.Raw, cert.Certificate[0])
+
+	writeTestFile(t, certPath, clientB.certPEM, time.Now())
+	writeTestFile(t, keyPath, clientB.keyPEM, time.Now())
+
+	cert, err = tlsConf.GetClientCertificate(cri)
+	require.NoError(t, err)
+	require.Len(t, cert.Certificate, 1)
+	assert.Equal(t, clientB.cert.Raw, cert.Certificate[0])
+}
+
+func TestReloadIntervalWithoutFiles(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+
+	conf := NewConfig()
+	conf.Enabled = true
+	conf.RootCAs = string(ca.certPEM)
+	conf.ReloadInterval = "10s"
+
+	tlsConf, err := conf.Get()
+	require.NoError(t, err)
+	assert.NotNil(t, tlsConf.RootCAs)
+	assert.Nil(t, tlsConf.VerifyConnection)
+	assert.Nil(t, tlsConf.GetClientCertificate)
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := map[string]struct {
+		conf        func(c *Config)
+		errContains string
+	}{
+		"bad reload interval": {
+			conf: func(c *Config) {
+				c.ReloadInterval = "not a duration"
+			},
+			errContains: "failed to parse reload_interval",
+		},
+		"spiffe with client certs": {
+			conf: func(c *Config) {
+				c.SPIFFE.Enabled = true
+				c.ClientCertificates = []ClientCertConfig{{Cert: "foo", Key: "bar"}}
+			},
+			errContains: "spiffe cannot be combined",
+		},
+		"spiffe with root cas": {
+			conf: func(c *Config) {
+				c.SPIFFE.Enabled = true
+				c.RootCAsFile = "./foo.pem"
+			},
+			errContains: "spiffe cannot be combined",
+		},
+		"spiffe with bad authorized id": {
+			conf: func(c *Config) {
+				c.SPIFFE.Enabled = true
+				c.SPIFFE.AuthorizedIDs = []string{"http://example.org/foo"}
+			},
+			errContains: "failed to parse authorized SPIFFE ID",
+		},
+		"missing root cas file": {
+			conf: func(c *Config) {
+				c.RootCAsFile = filepath.Join(t.TempDir(), "nope.pem")
+				c.ReloadInterval = "1s"
+			},
+			errContains: "nope.pem",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			conf := NewConfig()
+			conf.Enabled = true
+			test.conf(&conf)
+
+			_, err := conf.Get()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
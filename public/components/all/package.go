@@ -11,23 +11,40 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/amqp09"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/amqp1"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/aws"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/chat"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/confluent"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/containers"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/dgraph"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/email"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/enrich"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/feed"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/ftp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/gcp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/generic"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/html"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/influxdb"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/jaeger"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/journald"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/kafka"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/loki"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/maxmind"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/memcached"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/modbus"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/mongodb"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/msgpack"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/nats"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/opcua"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/otlp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/parquet"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/prometheus"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/redis"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/splunk"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/sql"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/statsd"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/tabular"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/vector"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/webhook"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/zeromq/zmq4n"
 	"github.com/benthosdev/benthos/v4/internal/template"
 
 	// Import all (supported) sql drivers
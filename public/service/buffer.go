@@ -69,6 +69,16 @@ type BatchBuffer interface {
 	Closer
 }
 
+// BatchBufferFillReporter is an optional interface that can be implemented by
+// a BatchBuffer that holds a bounded amount of data. When implemented the
+// fill percentage of the buffer is exported as the gauge metric
+// buffer_fill_percentage.
+type BatchBufferFillReporter interface {
+	// FillPercentage returns the percentage, between 0 and 100, of the capacity
+	// of the buffer that is currently occupied.
+	FillPercentage() int64
+}
+
 //------------------------------------------------------------------------------
 
 // Implements buffer.ReaderWriter
@@ -78,7 +88,11 @@ type airGapBatchBuffer struct {
 }
 
 func newAirGapBatchBuffer(b BatchBuffer) buffer.ReaderWriter {
-	return &airGapBatchBuffer{b, shutdown.NewSignaller()}
+	a := &airGapBatchBuffer{b, shutdown.NewSignaller()}
+	if f, ok := b.(BatchBufferFillReporter); ok {
+		return &airGapFillReportingBatchBuffer{airGapBatchBuffer: a, f: f}
+	}
+	return a
 }
 
 func (a *airGapBatchBuffer) Write(ctx context.Context, msg *message.Batch, aFn buffer.AckFunc) error {
@@ -116,3 +130,15 @@ func (a *airGapBatchBuffer) EndOfInput() {
 func (a *airGapBatchBuffer) Close(ctx context.Context) error {
 	return a.b.Close(ctx)
 }
+
+//------------------------------------------------------------------------------
+
+// Implements buffer.ReaderWriter and buffer.FillReporter
+type airGapFillReportingBatchBuffer struct {
+	*airGapBatchBuffer
+	f BatchBufferFillReporter
+}
+
+func (a *airGapFillReportingBatchBuffer) FillPercentage() int64 {
+	return a.f.FillPercentage()
+}
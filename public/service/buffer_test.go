@@ -260,3 +260,26 @@ func TestStreamBufferClosing(t *testing.T) {
 	// Should already be shut down.
 	assert.NoError(t, b.WaitForClose(time.Second))
 }
+
+type fillReportingMemoryBuffer struct {
+	*memoryBuffer
+}
+
+func (m fillReportingMemoryBuffer) FillPercentage() int64 {
+	return int64(len(m.messages) * 100 / cap(m.messages))
+}
+
+func TestBufferFillReporter(t *testing.T) {
+	_, ok := newAirGapBatchBuffer(newMemoryBuffer(4)).(buffer.FillReporter)
+	assert.False(t, ok)
+
+	b := fillReportingMemoryBuffer{newMemoryBuffer(4)}
+	rw := newAirGapBatchBuffer(b)
+	f, ok := rw.(buffer.FillReporter)
+	require.True(t, ok)
+
+	require.NoError(t, rw.Write(context.Background(), message.QuickBatch([][]byte{[]byte("hello")}), func(context.Context, error) error {
+		return nil
+	}))
+	assert.Equal(t, int64(25), f.FillPercentage())
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -739,3 +741,25 @@ func (p *ParsedConfig) FieldObjectList(path ...string) ([]*ParsedConfig, error)
 	}
 	return sList, nil
 }
+
+// FieldFingerprint accesses a field from the parsed config by its name and
+// returns a hash of its normalized value, which changes whenever the value of
+// the field (or any of its children) differs. This is useful for deriving keys
+// that identify the settings of a client, such as with
+// Resources.AcquireSharedClient.
+//
+// This method is not valid when the configuration spec was built around a
+// config constructor.
+func (p *ParsedConfig) FieldFingerprint(path ...string) (string, error) {
+	v, exists := p.field(path...)
+	if !exists {
+		return "", fmt.Errorf("field '%v' was not found in the config", p.fullDotPath(path...))
+	}
+	// Object keys are serialized in sorted order, which normalizes the value.
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize field '%v': %w", p.fullDotPath(path...), err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -22,6 +22,11 @@ type BatchPolicy struct {
 	Check    string
 	Period   string
 
+	// Coalesce continues to add messages to the next batch whilst an output is
+	// busy, up to the Count and ByteSize limits. This only applies to batch
+	// policies of outputs and has no effect on a Batcher.
+	Coalesce bool
+
 	// Only available when using NewBatchPolicyField.
 	procs []processor.Config
 }
@@ -36,6 +41,13 @@ func (b BatchPolicy) toInternal() policy.Config {
 	return batchConf
 }
 
+func (b BatchPolicy) toInternalOutput() policy.OutputConfig {
+	batchConf := policy.NewOutputConfig()
+	batchConf.Config = b.toInternal()
+	batchConf.Coalesce = b.Coalesce
+	return batchConf
+}
+
 // Batcher provides a batching mechanism where messages can be added one-by-one
 // with a boolean return indicating whether the batching policy has been
 // triggered.
@@ -115,7 +127,7 @@ func (b BatchPolicy) NewBatcher(res *Resources) (*Batcher, error) {
 // BatchPolicy from the resulting parsed config with the method
 // FieldBatchPolicy.
 func NewBatchPolicyField(name string) *ConfigField {
-	bs := policy.FieldSpec()
+	bs := policy.OutputFieldSpec()
 	bs.Name = name
 	bs.Type = docs.FieldTypeObject
 	var newChildren []docs.FieldSpec
@@ -147,6 +159,11 @@ func (p *ParsedConfig) FieldBatchPolicy(path ...string) (conf BatchPolicy, err e
 	if conf.Period, err = p.FieldString(append(path, "period")...); err != nil {
 		return conf, err
 	}
+	if p.Contains(append(path, "coalesce")...) {
+		if conf.Coalesce, err = p.FieldBool(append(path, "coalesce")...); err != nil {
+			return conf, err
+		}
+	}
 
 	procsNode, exists := p.field(append(path, "processors")...)
 	if !exists {
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/proxy"
+)
+
+// NewHTTPProxyField defines a new object type config field that describes
+// proxy settings for components that make HTTP requests. It is then possible
+// to extract a function for the Proxy field of an *http.Transport from the
+// resulting parsed config with the method FieldHTTPProxy.
+func NewHTTPProxyField(name string) *ConfigField {
+	pf := proxy.FieldSpec()
+	pf.Name = name
+	return &ConfigField{field: pf}
+}
+
+// FieldHTTPProxy accesses a field from a parsed config that was defined with
+// NewHTTPProxyField and returns a function that selects the proxy of each
+// request, or an error if the configuration was invalid. When the proxy
+// settings are left empty the function returned is nil, in which case the
+// default proxy settings of the transport should be left as they are.
+func (p *ParsedConfig) FieldHTTPProxy(path ...string) (func(*http.Request) (*url.URL, error), error) {
+	v, exists := p.field(path...)
+	if !exists {
+		return nil, fmt.Errorf("field '%v' was not found in the config", strings.Join(path, "."))
+	}
+
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+
+	conf := proxy.NewConfig()
+	if err := node.Decode(&conf); err != nil {
+		return nil, err
+	}
+
+	return conf.Func()
+}
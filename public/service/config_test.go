@@ -1,6 +1,7 @@
 package service
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -345,6 +346,42 @@ func TestConfigRootString(t *testing.T) {
 	assert.Equal(t, "hello world", v)
 }
 
+func TestConfigFieldFingerprint(t *testing.T) {
+	spec := NewConfigSpec().
+		Field(NewStringField("a")).
+		Field(NewObjectField("b",
+			NewStringField("c"),
+			NewIntField("d"),
+		))
+
+	fingerprints := func(conf string) (string, string) {
+		t.Helper()
+		parsedConfig, err := spec.ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		a, err := parsedConfig.FieldFingerprint("a")
+		require.NoError(t, err)
+		b, err := parsedConfig.FieldFingerprint("b")
+		require.NoError(t, err)
+		return a, b
+	}
+
+	a1, b1 := fingerprints(`{"a":"foo","b":{"c":"bar","d":10}}`)
+	a2, b2 := fingerprints(`{"b":{"d":10,"c":"bar"},"a":"foo"}`)
+	assert.Equal(t, a1, a2)
+	assert.Equal(t, b1, b2)
+	assert.NotEqual(t, a1, b1)
+
+	a3, b3 := fingerprints(`{"a":"foo","b":{"c":"bar","d":11}}`)
+	assert.Equal(t, a1, a3)
+	assert.NotEqual(t, b1, b3)
+
+	parsedConfig, err := spec.ParseYAML(`{"a":"foo","b":{"c":"bar","d":10}}`, nil)
+	require.NoError(t, err)
+	_, err = parsedConfig.FieldFingerprint("b", "e")
+	require.EqualError(t, err, "field 'b.e' was not found in the config")
+}
+
 func TestConfigListOfObjects(t *testing.T) {
 	spec := NewConfigSpec().
 		Field(NewObjectListField("objects",
@@ -447,6 +484,47 @@ b: and this
 	assert.True(t, tConf.InsecureSkipVerify)
 }
 
+func TestConfigHTTPProxy(t *testing.T) {
+	spec := NewConfigSpec().
+		Field(NewHTTPProxyField("a")).
+		Field(NewHTTPProxyField("b")).
+		Field(NewStringField("c"))
+
+	parsedConfig, err := spec.ParseYAML(`
+a:
+  url: socks5://proxy.example.com:1080
+  no_proxy: .internal.example.com
+c: and this
+`, nil)
+	require.NoError(t, err)
+
+	_, err = parsedConfig.FieldHTTPProxy("c")
+	require.Error(t, err)
+
+	_, err = parsedConfig.FieldHTTPProxy("d")
+	require.Error(t, err)
+
+	proxyFn, err := parsedConfig.FieldHTTPProxy("b")
+	require.NoError(t, err)
+	assert.Nil(t, proxyFn)
+
+	proxyFn, err = parsedConfig.FieldHTTPProxy("a")
+	require.NoError(t, err)
+	require.NotNil(t, proxyFn)
+
+	req, err := http.NewRequest("GET", "https://foo.example.com", nil)
+	require.NoError(t, err)
+	u, err := proxyFn(req)
+	require.NoError(t, err)
+	assert.Equal(t, "socks5://proxy.example.com:1080", u.String())
+
+	req, err = http.NewRequest("GET", "https://foo.internal.example.com", nil)
+	require.NoError(t, err)
+	u, err = proxyFn(req)
+	require.NoError(t, err)
+	assert.Nil(t, u)
+}
+
 func TestConfigInterpolatedString(t *testing.T) {
 	spec := NewConfigSpec().
 		Field(NewInterpolatedStringField("a")).
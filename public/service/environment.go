@@ -272,7 +272,7 @@ func (e *Environment) RegisterBatchOutput(name string, spec *ConfigSpec, ctor Ba
 			if err != nil {
 				return nil, err
 			}
-			return output.NewBatcherFromConfig(batchPolicy.toInternal(), o, nm, nm.Logger(), nm.Metrics())
+			return output.NewBatcherFromConfig(batchPolicy.toInternalOutput(), o, nm, nm.Logger(), nm.Metrics())
 		},
 	), componentSpec)
 }
@@ -307,7 +307,7 @@ func (e *Environment) RegisterProcessor(name string, spec *ConfigSpec, ctor Proc
 		if err != nil {
 			return nil, err
 		}
-		return newAirGapProcessor(conf.Type, r, nm.Metrics()), nil
+		return newAirGapProcessor(conf.Type, r, nm), nil
 	}, componentSpec)
 }
 
@@ -332,7 +332,7 @@ func (e *Environment) RegisterBatchProcessor(name string, spec *ConfigSpec, ctor
 		if err != nil {
 			return nil, err
 		}
-		return newAirGapBatchProcessor(conf.Type, r, nm.Metrics()), nil
+		return newAirGapBatchProcessor(conf.Type, r, nm), nil
 	}, componentSpec)
 }
 
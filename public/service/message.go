@@ -26,6 +26,10 @@ type MessageBatchHandlerFunc func(context.Context, MessageBatch) error
 type Message struct {
 	part       *message.Part
 	partCopied bool
+
+	// The source of errors set on the message, which is the processor that
+	// the message was given to.
+	errSource string
 }
 
 // MessageBatch describes a collection of one or more messages.
@@ -52,7 +56,7 @@ func NewMessage(content []byte) *Message {
 }
 
 func newMessageFromPart(part *message.Part) *Message {
-	return &Message{part, false}
+	return &Message{part: part}
 }
 
 // Copy creates a shallow copy of a message that is safe to mutate with Set
@@ -66,6 +70,7 @@ func (m *Message) Copy() *Message {
 	return &Message{
 		part:       m.part.Copy(),
 		partCopied: true,
+		errSource:  m.errSource,
 	}
 }
 
@@ -87,6 +92,7 @@ func (m *Message) WithContext(ctx context.Context) *Message {
 	return &Message{
 		part:       message.WithContext(ctx, m.part),
 		partCopied: m.partCopied,
+		errSource:  m.errSource,
 	}
 }
 
@@ -116,10 +122,10 @@ func (m *Message) AsStructured() (interface{}, error) {
 // JSON document and returns either the structured result or an error.
 //
 // It is safe to mutate the contents of the returned value even if it is a
-// reference type (slice or map), as the structured contents will be lazily deep
-// cloned if it is still owned by an upstream component.
+// reference type (slice or map), as the returned value is a deep copy of the
+// structured contents. Mutations are therefore only reflected in the message
+// once the value is set with SetStructured.
 func (m *Message) AsStructuredMut() (interface{}, error) {
-	// TODO: Use refactored APIs to determine if the contents are owned.
 	v, err := m.part.JSON()
 	if err != nil {
 		return nil, err
@@ -127,6 +133,23 @@ func (m *Message) AsStructuredMut() (interface{}, error) {
 	return message.CopyJSON(v)
 }
 
+// AsStructuredMutInPlace returns the underlying structured contents of a
+// message or, if the contents are a byte array, attempts to parse the bytes
+// contents as a JSON document and returns either the structured result or an
+// error.
+//
+// Unlike AsStructuredMut the returned value is the live structured contents of
+// the message, which are only cloned when they are still owned by an upstream
+// component, and therefore mutations made to it change the message even when
+// they are not followed by SetStructured. The contents are serialized lazily
+// when the message is next read as bytes (e.g. with AsBytes), after which
+// further mutations to the value are not reflected in those bytes until this
+// method is called again.
+func (m *Message) AsStructuredMutInPlace() (interface{}, error) {
+	m.ensureCopied()
+	return m.part.JSONMut()
+}
+
 // SetBytes sets the underlying contents of the message as a byte slice.
 func (m *Message) SetBytes(b []byte) {
 	m.ensureCopied()
@@ -149,7 +172,7 @@ func (m *Message) SetStructured(i interface{}) {
 // range of methods outlined in https://www.benthos.dev/docs/configuration/error_handling.
 func (m *Message) SetError(err error) {
 	m.ensureCopied()
-	processor.FlagErr(m.part, err)
+	processor.FlagErr(m.part, m.errSource, err)
 }
 
 // GetError returns an error associated with a message, or nil if there isn't
@@ -181,6 +204,24 @@ func (m *Message) MetaSet(key, value string) {
 	}
 }
 
+// MetaGetTyped attempts to find a metadata key from the message and returns
+// its value in its typed form, which is one of string, []byte, int64, uint64,
+// float64, bool or time.Time, along with a boolean indicating whether it was
+// found.
+func (m *Message) MetaGetTyped(key string) (interface{}, bool) {
+	return m.part.MetaGetTyped(key)
+}
+
+// MetaSetTyped sets the value of a metadata key to a typed value, which can be
+// obtained in its typed form with MetaGetTyped, or in its string form with
+// MetaGet. Other integer and float types are widened to int64, uint64 and
+// float64, and values of any other type are stored as their JSON serialized
+// form.
+func (m *Message) MetaSetTyped(key string, value interface{}) {
+	m.ensureCopied()
+	m.part.MetaSetTyped(key, value)
+}
+
 // MetaDelete removes a key from the message metadata.
 func (m *Message) MetaDelete(key string) {
 	m.ensureCopied()
@@ -194,6 +235,14 @@ func (m *Message) MetaWalk(fn func(string, string) error) error {
 	return m.part.MetaIter(fn)
 }
 
+// MetaWalkTyped iterates each metadata key/value pair in their typed form and
+// executes a provided closure on each iteration. To stop iterating, return an
+// error from the closure. An error returned by the closure will be returned by
+// this function.
+func (m *Message) MetaWalkTyped(fn func(string, interface{}) error) error {
+	return m.part.MetaIterTyped(fn)
+}
+
 //------------------------------------------------------------------------------
 
 // BloblangQuery executes a parsed Bloblang mapping on a message and returns a
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// BatchError is an error type that can be returned by a batched output in
+// order to communicate which messages of a batch failed, allowing messages that
+// were delivered successfully to be acknowledged without being sent again.
+type BatchError struct {
+	err         error
+	batchLen    int
+	indexErrors map[int]error
+}
+
+// NewBatchError creates a new batch-wide error, where it's possible to add
+// granular errors for individual messages of the batch with Failed.
+func NewBatchError(b MessageBatch, headline error) *BatchError {
+	return &BatchError{
+		err:      headline,
+		batchLen: len(b),
+	}
+}
+
+// Failed stores an error state for a particular message of a batch. Returns a
+// pointer to the underlying error, allowing the method to be chained.
+//
+// If Failed is not called then all messages are assumed to have failed. If it
+// is called at least once then all message indexes that aren't explicitly
+// failed are assumed to have been processed successfully.
+func (err *BatchError) Failed(i int, merr error) *BatchError {
+	if err.indexErrors == nil {
+		err.indexErrors = map[int]error{}
+	}
+	err.indexErrors[i] = merr
+	return err
+}
+
+// IndexedErrors returns the number of indexed errors that have been registered
+// for the batch.
+func (err *BatchError) IndexedErrors() int {
+	return len(err.indexErrors)
+}
+
+// Error implements the common error interface.
+func (err *BatchError) Error() string {
+	return err.err.Error()
+}
+
+// Unwrap returns the underlying common error.
+func (err *BatchError) Unwrap() error {
+	return err.err
+}
+
+func (err *BatchError) toInternal(msg *message.Batch) error {
+	if msg.Len() != err.batchLen {
+		return err.err
+	}
+	bErr := batch.NewError(msg, err.err)
+	for i, merr := range err.indexErrors {
+		bErr.Failed(i, merr)
+	}
+	return bErr
+}
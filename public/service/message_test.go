@@ -76,6 +76,42 @@ func TestMessageCopyAirGap(t *testing.T) {
 	assert.Equal(t, "baz", v)
 }
 
+func TestMessageTypedMeta(t *testing.T) {
+	p := message.NewPart([]byte("hello"))
+	p.MetaSetTyped("count", int64(10))
+
+	g1 := newMessageFromPart(p)
+	g2 := g1.Copy()
+
+	g2.MetaSetTyped("count", 11)
+	g2.MetaSetTyped("enabled", true)
+
+	v, exists := g1.MetaGetTyped("count")
+	require.True(t, exists)
+	assert.Equal(t, int64(10), v)
+
+	v, exists = g2.MetaGetTyped("count")
+	require.True(t, exists)
+	assert.Equal(t, int64(11), v)
+
+	s, exists := g2.MetaGet("enabled")
+	require.True(t, exists)
+	assert.Equal(t, "true", s)
+
+	_, exists = g1.MetaGetTyped("enabled")
+	assert.False(t, exists)
+
+	seen := map[string]interface{}{}
+	require.NoError(t, g2.MetaWalkTyped(func(k string, v interface{}) error {
+		seen[k] = v
+		return nil
+	}))
+	assert.Equal(t, map[string]interface{}{
+		"count":   int64(11),
+		"enabled": true,
+	}, seen)
+}
+
 func TestMessageQuery(t *testing.T) {
 	p := message.NewPart([]byte(`{"foo":"bar"}`))
 	p.MetaSet("foo", "bar")
@@ -165,6 +201,51 @@ func TestMessageMutate(t *testing.T) {
 	assert.Equal(t, map[string]string{"foo": "new bar", "bar": "baz"}, seen)
 }
 
+func TestMessageAsStructuredMutDetached(t *testing.T) {
+	msg := NewMessage([]byte(`{"foo":"bar"}`))
+
+	v, err := msg.AsStructuredMut()
+	require.NoError(t, err)
+	v.(map[string]interface{})["foo"] = "baz"
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(b))
+
+	v, err = msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, v)
+}
+
+func TestMessageAsStructuredMutInPlace(t *testing.T) {
+	msg := NewMessage([]byte(`{"foo":"bar"}`))
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(b))
+
+	v, err := msg.AsStructuredMutInPlace()
+	require.NoError(t, err)
+	v.(map[string]interface{})["foo"] = "baz"
+
+	b, err = msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"baz"}`, string(b))
+
+	msgCopy := msg.Copy()
+	v, err = msgCopy.AsStructuredMutInPlace()
+	require.NoError(t, err)
+	v.(map[string]interface{})["foo"] = "qux"
+
+	b, err = msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"baz"}`, string(b))
+
+	b, err = msgCopy.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"qux"}`, string(b))
+}
+
 func TestNewMessageMutate(t *testing.T) {
 	g0 := NewMessage([]byte(`not a json doc`))
 	g0.MetaSet("foo", "bar")
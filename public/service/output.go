@@ -63,7 +63,8 @@ type BatchOutput interface {
 	Connect(context.Context) error
 
 	// Write a batch of messages to a sink, or return an error if delivery is
-	// not possible.
+	// not possible. A *BatchError can be returned in order to communicate that
+	// only some messages of the batch failed.
 	//
 	// If this method returns ErrNotConnected then write will not be called
 	// again until Connect has returned a nil error.
@@ -72,6 +73,23 @@ type BatchOutput interface {
 	Closer
 }
 
+// OrderedBatchOutput is an optional interface that a BatchOutput can implement
+// in order to preserve the order of batches whilst multiple batches are in
+// flight. Batches are enqueued one at a time in the order that they are
+// dispatched to the output, and up to MaxInFlight enqueued batches are then
+// awaiting acknowledgement in parallel.
+type OrderedBatchOutput interface {
+	BatchOutput
+
+	// Enqueue a batch of messages to a sink without waiting for it to be
+	// acknowledged, and return a function that blocks until the batch has been
+	// acknowledged, or an error if delivery is not possible.
+	//
+	// If this method returns ErrNotConnected then the batch is written with
+	// WriteBatch once Connect has returned a nil error.
+	EnqueueBatch(context.Context, MessageBatch) (func(context.Context) error, error)
+}
+
 //------------------------------------------------------------------------------
 
 // Implements output.AsyncSink
@@ -124,7 +142,11 @@ type airGapBatchWriter struct {
 }
 
 func newAirGapBatchWriter(w BatchOutput) output.AsyncSink {
-	return &airGapBatchWriter{w, shutdown.NewSignaller()}
+	a := &airGapBatchWriter{w, shutdown.NewSignaller()}
+	if o, ok := w.(OrderedBatchOutput); ok {
+		return &airGapOrderedBatchWriter{airGapBatchWriter: a, o: o}
+	}
+	return a
 }
 
 func (a *airGapBatchWriter) ConnectWithContext(ctx context.Context) error {
@@ -132,16 +154,24 @@ func (a *airGapBatchWriter) ConnectWithContext(ctx context.Context) error {
 }
 
 func (a *airGapBatchWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	err := a.w.WriteBatch(ctx, newMessageBatch(msg))
+	if err != nil && errors.Is(err, ErrNotConnected) {
+		err = component.ErrNotConnected
+	}
+	var bErr *BatchError
+	if err != nil && errors.As(err, &bErr) {
+		err = bErr.toInternal(msg)
+	}
+	return err
+}
+
+func newMessageBatch(msg *message.Batch) MessageBatch {
 	parts := make([]*Message, msg.Len())
 	_ = msg.Iter(func(i int, part *message.Part) error {
 		parts[i] = newMessageFromPart(part)
 		return nil
 	})
-	err := a.w.WriteBatch(ctx, parts)
-	if err != nil && errors.Is(err, ErrNotConnected) {
-		err = component.ErrNotConnected
-	}
-	return err
+	return parts
 }
 
 func (a *airGapBatchWriter) CloseAsync() {
@@ -161,6 +191,21 @@ func (a *airGapBatchWriter) WaitForClose(tout time.Duration) error {
 	return nil
 }
 
+// Implements output.AsyncEnqueuer
+type airGapOrderedBatchWriter struct {
+	*airGapBatchWriter
+
+	o OrderedBatchOutput
+}
+
+func (a *airGapOrderedBatchWriter) EnqueueWithContext(ctx context.Context, msg *message.Batch) (func(context.Context) error, error) {
+	wait, err := a.o.EnqueueBatch(ctx, newMessageBatch(msg))
+	if err != nil && errors.Is(err, ErrNotConnected) {
+		err = component.ErrNotConnected
+	}
+	return wait, err
+}
+
 //------------------------------------------------------------------------------
 
 // OwnedOutput provides direct ownership of an output extracted from a plugin
@@ -245,3 +290,33 @@ func (o *OwnedOutput) Close(ctx context.Context) error {
 		}
 	}
 }
+
+//------------------------------------------------------------------------------
+
+// ResourceOutput provides access to an output resource, which is shared with
+// other components and is therefore never closed by those that access it.
+type ResourceOutput struct {
+	o ioutput.Sync
+}
+
+// WriteBatch attempts to write a message batch to the output resource, and
+// returns an error either if delivery is not possible or the context is
+// cancelled.
+func (o *ResourceOutput) WriteBatch(ctx context.Context, b MessageBatch) error {
+	payload := message.QuickBatch(nil)
+	for _, m := range b {
+		payload.Append(m.part)
+	}
+
+	resChan := make(chan error, 1)
+	if err := o.o.WriteTransaction(ctx, message.NewTransaction(payload, resChan)); err != nil {
+		return err
+	}
+
+	select {
+	case res := <-resChan:
+		return res
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
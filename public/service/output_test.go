@@ -7,9 +7,12 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/output"
 )
 
 type fnOutput struct {
@@ -177,3 +180,72 @@ func TestBatchOutputAirGapHappy(t *testing.T) {
 
 	assert.Equal(t, "hello world", wroteMsg)
 }
+
+func TestBatchOutputAirGapBatchError(t *testing.T) {
+	o := &fnBatchOutput{
+		connect: func() error {
+			return nil
+		},
+		writeBatch: func(m MessageBatch) error {
+			return NewBatchError(m, errors.New("bad write")).Failed(1, errors.New("bad message"))
+		},
+	}
+	agi := newAirGapBatchWriter(o)
+
+	inMsg := message.QuickBatch([][]byte{[]byte("first"), []byte("second"), []byte("third")})
+
+	err := agi.WriteWithContext(context.Background(), inMsg)
+	require.EqualError(t, err, "bad write")
+
+	var bErr *batch.Error
+	require.ErrorAs(t, err, &bErr)
+
+	var failed []string
+	bErr.WalkParts(func(i int, p *message.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, string(p.Get()))
+		}
+		return true
+	})
+	assert.Equal(t, []string{"second"}, failed)
+}
+
+type fnOrderedBatchOutput struct {
+	fnBatchOutput
+	enqueueBatch func(msgs MessageBatch) (func(context.Context) error, error)
+}
+
+func (f *fnOrderedBatchOutput) EnqueueBatch(ctx context.Context, msgs MessageBatch) (func(context.Context) error, error) {
+	return f.enqueueBatch(msgs)
+}
+
+func TestBatchOutputAirGapOrdered(t *testing.T) {
+	agi := newAirGapBatchWriter(&fnBatchOutput{})
+	_, isEnqueuer := agi.(output.AsyncEnqueuer)
+	assert.False(t, isEnqueuer)
+
+	var enqueuedMsg string
+	o := &fnOrderedBatchOutput{
+		enqueueBatch: func(m MessageBatch) (func(context.Context) error, error) {
+			enqueuedBytes, _ := m[0].AsBytes()
+			enqueuedMsg = string(enqueuedBytes)
+			return func(context.Context) error {
+				return errors.New("bad ack")
+			}, nil
+		},
+	}
+	agi = newAirGapBatchWriter(o)
+	enqueuer, isEnqueuer := agi.(output.AsyncEnqueuer)
+	require.True(t, isEnqueuer)
+
+	wait, err := enqueuer.EnqueueWithContext(context.Background(), message.QuickBatch([][]byte{[]byte("hello world")}))
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", enqueuedMsg)
+	assert.EqualError(t, wait(context.Background()), "bad ack")
+
+	o.enqueueBatch = func(m MessageBatch) (func(context.Context) error, error) {
+		return nil, ErrNotConnected
+	}
+	_, err = enqueuer.EnqueueWithContext(context.Background(), message.QuickBatch(nil))
+	assert.Equal(t, component.ErrNotConnected, err)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"time"
 
-	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
@@ -66,15 +66,18 @@ type BatchProcessor interface {
 
 // Implements types.Processor for a Processor.
 type airGapProcessor struct {
-	p Processor
+	p         Processor
+	errSource string
 }
 
-func newAirGapProcessor(typeStr string, p Processor, stats metrics.Type) processor.V1 {
-	return processor.NewV2ToV1Processor(typeStr, &airGapProcessor{p}, stats)
+func newAirGapProcessor(typeStr string, p Processor, mgr component.Observability) processor.V1 {
+	return processor.NewV2ToV1Processor(typeStr, &airGapProcessor{p, processor.ErrSource(mgr)}, mgr)
 }
 
 func (a *airGapProcessor) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
-	msgs, err := a.p.Process(ctx, newMessageFromPart(msg))
+	m := newMessageFromPart(msg)
+	m.errSource = a.errSource
+	msgs, err := a.p.Process(ctx, m)
 	if err != nil {
 		return nil, err
 	}
@@ -93,17 +96,19 @@ func (a *airGapProcessor) Close(ctx context.Context) error {
 
 // Implements types.Processor for a BatchProcessor.
 type airGapBatchProcessor struct {
-	p BatchProcessor
+	p         BatchProcessor
+	errSource string
 }
 
-func newAirGapBatchProcessor(typeStr string, p BatchProcessor, stats metrics.Type) processor.V1 {
-	return processor.NewV2BatchedToV1Processor(typeStr, &airGapBatchProcessor{p}, stats)
+func newAirGapBatchProcessor(typeStr string, p BatchProcessor, mgr component.Observability) processor.V1 {
+	return processor.NewV2BatchedToV1Processor(typeStr, &airGapBatchProcessor{p, processor.ErrSource(mgr)}, mgr)
 }
 
 func (a *airGapBatchProcessor) ProcessBatch(ctx context.Context, spans []*tracing.Span, batch *message.Batch) ([]*message.Batch, error) {
 	inputBatch := make([]*Message, batch.Len())
 	_ = batch.Iter(func(i int, p *message.Part) error {
 		inputBatch[i] = newMessageFromPart(p)
+		inputBatch[i].errSource = a.errSource
 		return nil
 	})
 
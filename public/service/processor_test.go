@@ -9,7 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/old/processor"
 )
@@ -30,7 +30,7 @@ func (p *fnProcessor) Close(ctx context.Context) error {
 
 func TestProcessorAirGapShutdown(t *testing.T) {
 	rp := &fnProcessor{}
-	agrp := newAirGapProcessor("foo", rp, metrics.Noop())
+	agrp := newAirGapProcessor("foo", rp, mock.NewManager())
 
 	err := agrp.WaitForClose(time.Millisecond * 5)
 	assert.EqualError(t, err, "action timed out")
@@ -51,7 +51,7 @@ func TestProcessorAirGapOneToOne(t *testing.T) {
 			m.SetBytes([]byte("changed"))
 			return MessageBatch{m}, nil
 		},
-	}, metrics.Noop())
+	}, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -68,7 +68,7 @@ func TestProcessorAirGapOneToError(t *testing.T) {
 			_, err := m.AsStructured()
 			return nil, err
 		},
-	}, metrics.Noop())
+	}, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{[]byte("not a structured doc")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -93,7 +93,7 @@ func TestProcessorAirGapOneToMany(t *testing.T) {
 			third.SetBytes([]byte("changed 3"))
 			return MessageBatch{m, second, third}, nil
 		},
-	}, metrics.Noop())
+	}, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -124,7 +124,7 @@ func (p *fnBatchProcessor) Close(ctx context.Context) error {
 
 func TestBatchProcessorAirGapShutdown(t *testing.T) {
 	rp := &fnBatchProcessor{}
-	agrp := newAirGapBatchProcessor("foo", rp, metrics.Noop())
+	agrp := newAirGapBatchProcessor("foo", rp, mock.NewManager())
 
 	err := agrp.WaitForClose(time.Millisecond * 5)
 	assert.EqualError(t, err, "action timed out")
@@ -145,7 +145,7 @@ func TestBatchProcessorAirGapOneToOne(t *testing.T) {
 			msgs[0].SetBytes([]byte("changed"))
 			return []MessageBatch{{msgs[0]}}, nil
 		},
-	}, metrics.Noop())
+	}, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -162,7 +162,7 @@ func TestBatchProcessorAirGapOneToError(t *testing.T) {
 			_, err := msgs[0].AsStructured()
 			return nil, err
 		},
-	}, metrics.Noop())
+	}, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{[]byte("not a structured doc")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -187,7 +187,7 @@ func TestBatchProcessorAirGapOneToMany(t *testing.T) {
 			third.SetBytes([]byte("changed 3"))
 			return []MessageBatch{{msgs[0], second}, {third}}, nil
 		},
-	}, metrics.Noop())
+	}, mock.NewManager())
 
 	msg := message.QuickBatch([][]byte{[]byte("unchanged")})
 	msgs, res := agrp.ProcessMessage(msg)
@@ -202,3 +202,29 @@ func TestBatchProcessorAirGapOneToMany(t *testing.T) {
 	assert.Equal(t, 1, msgs[1].Len())
 	assert.Equal(t, "changed 3", string(msgs[1].Get(0).Get()))
 }
+
+type labelledManager struct {
+	*mock.Manager
+	label string
+}
+
+func (l labelledManager) Label() string {
+	return l.label
+}
+
+func TestBatchProcessorAirGapSetErrorSource(t *testing.T) {
+	agrp := newAirGapBatchProcessor("foo", &fnBatchProcessor{
+		fn: func(c context.Context, msgs MessageBatch) ([]MessageBatch, error) {
+			second := msgs[0].Copy()
+			msgs[0].SetError(errors.New("nope"))
+			return []MessageBatch{{msgs[0], second}}, nil
+		},
+	}, labelledManager{Manager: mock.NewManager(), label: "bar"})
+
+	msgs, res := agrp.ProcessMessage(message.QuickBatch([][]byte{[]byte("hello world")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "nope", processor.GetFail(msgs[0].Get(0)))
+	assert.Equal(t, "bar", msgs[0].Get(0).MetaGet(message.ErrorSourceKey))
+	assert.Equal(t, "", processor.GetFail(msgs[0].Get(1)))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/bundle/mock"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 )
 
@@ -47,6 +48,22 @@ func (r *Resources) Metrics() *Metrics {
 	return newReverseAirGapMetrics(r.mgr.Metrics())
 }
 
+// ReportCheckpoint reports the latest checkpointed offset of a partition of the
+// data consumed by an input (a topic partition, shard, file, etc) in order for
+// it to be inspected via the `/checkpoints` HTTP endpoint and exported as a
+// metric. The offset should identify the next position to consume, such as the
+// offset following the last processed record of a Kafka partition.
+func (r *Resources) ReportCheckpoint(partition, offset string) {
+	r.mgr.ReportCheckpoint(partition, offset)
+}
+
+// RemoveCheckpoint removes the checkpoint previously reported for a partition
+// with ReportCheckpoint, which should be called once the input no longer
+// consumes the partition, such as when a Kafka topic partition is revoked.
+func (r *Resources) RemoveCheckpoint(partition string) {
+	r.mgr.RemoveCheckpoint(partition)
+}
+
 // AccessCache attempts to access a cache resource by name. This action can
 // block if CRUD operations are being actively performed on the resource.
 func (r *Resources) AccessCache(ctx context.Context, name string, fn func(c Cache)) error {
@@ -76,3 +93,33 @@ func (r *Resources) AccessRateLimit(ctx context.Context, name string, fn func(r
 func (r *Resources) HasRateLimit(name string) bool {
 	return r.mgr.ProbeRateLimit(name)
 }
+
+// AccessOutput attempts to access an output resource by name. This action can
+// block if CRUD operations are being actively performed on the resource.
+func (r *Resources) AccessOutput(ctx context.Context, name string, fn func(o *ResourceOutput)) error {
+	return r.mgr.AccessOutput(ctx, name, func(o ioutput.Sync) {
+		fn(&ResourceOutput{o: o})
+	})
+}
+
+// HasOutput confirms whether an output with a given name has been registered
+// as a resource. This method is useful during component initialisation as it
+// is defensive against ordering.
+func (r *Resources) HasOutput(name string) bool {
+	return r.mgr.ProbeOutput(name)
+}
+
+// AcquireSharedClient returns a client that is shared by all components of the
+// Benthos instance that acquire it under the same key, which reduces the number
+// of connections opened when many components target the same server. The key
+// should identify the target and all settings of the client, see
+// ParsedConfig.FieldFingerprint for a way of deriving it from config fields.
+//
+// When no client exists under the key the provided constructor is called,
+// which returns the client along with a function that closes it. The returned
+// release function must be called once the component no longer uses the
+// client (usually when it is closed), and the client is closed once all
+// components holding it have released it.
+func (r *Resources) AcquireSharedClient(key string, ctor func() (client interface{}, closeFn func() error, err error)) (client interface{}, release func() error, err error) {
+	return r.mgr.AcquireSharedClient(key, ctor)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bmock "github.com/benthosdev/benthos/v4/internal/bundle/mock"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestResourcesSharedClient(t *testing.T) {
+	res := MockResources()
+
+	var closed int
+	ctor := func(name string) func() (interface{}, func() error, error) {
+		return func() (interface{}, func() error, error) {
+			return name, func() error {
+				closed++
+				return nil
+			}, nil
+		}
+	}
+
+	c1, release1, err := res.AcquireSharedClient("foo", ctor("first"))
+	require.NoError(t, err)
+	c2, release2, err := res.AcquireSharedClient("foo", ctor("second"))
+	require.NoError(t, err)
+	assert.Equal(t, "first", c1)
+	assert.Equal(t, "first", c2)
+
+	require.NoError(t, release1())
+	assert.Equal(t, 0, closed)
+	require.NoError(t, release2())
+	assert.Equal(t, 1, closed)
+}
+
+func TestResourcesAccessOutput(t *testing.T) {
+	var written []string
+	mgr := bmock.NewManager()
+	mgr.Outputs["foo"] = mock.OutputWriter(func(ctx context.Context, tran message.Transaction) error {
+		_ = tran.Payload.Iter(func(i int, p *message.Part) error {
+			written = append(written, string(p.Get()))
+			return nil
+		})
+		var err error
+		if len(written) > 2 {
+			err = errors.New("nope")
+		}
+		go func() {
+			_ = tran.Ack(ctx, err)
+		}()
+		return nil
+	})
+	res := newResourcesFromManager(mgr)
+
+	assert.True(t, res.HasOutput("foo"))
+	assert.False(t, res.HasOutput("bar"))
+
+	var writeErr error
+	require.NoError(t, res.AccessOutput(context.Background(), "foo", func(o *ResourceOutput) {
+		writeErr = o.WriteBatch(context.Background(), MessageBatch{
+			NewMessage([]byte("hello")),
+			NewMessage([]byte("world")),
+		})
+	}))
+	require.NoError(t, writeErr)
+	assert.Equal(t, []string{"hello", "world"}, written)
+
+	require.NoError(t, res.AccessOutput(context.Background(), "foo", func(o *ResourceOutput) {
+		writeErr = o.WriteBatch(context.Background(), MessageBatch{NewMessage([]byte("again"))})
+	}))
+	require.EqualError(t, writeErr, "nope")
+
+	require.Error(t, res.AccessOutput(context.Background(), "bar", func(o *ResourceOutput) {}))
+}
@@ -731,6 +731,7 @@ func (s *StreamBuilder) buildWithEnv(env *bundle.Environment) (*Stream, error) {
 	if err != nil {
 		return nil, err
 	}
+	mgr.RegisterComponentEndpoints()
 
 	if s.producerChan != nil {
 		mgr.SetPipe(s.producerID, s.producerChan)
@@ -790,7 +791,10 @@ func (s *StreamBuilder) buildConfig() builderConfig {
 //------------------------------------------------------------------------------
 
 func getYAMLNode(b []byte) (*yaml.Node, error) {
-	b = config.ReplaceEnvVariables(b)
+	b, err := config.ReplaceEnvVariables(b)
+	if err != nil {
+		return nil, err
+	}
 	var nconf yaml.Node
 	if err := yaml.Unmarshal(b, &nconf); err != nil {
 		return nil, err
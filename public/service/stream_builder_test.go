@@ -34,6 +34,12 @@ func TestStreamBuilderDefault(t *testing.T) {
     none: {}`,
 		`pipeline:
     threads: 0
+    autoscale:
+        enabled: false
+        min_threads: 1
+        max_threads: -1
+        target_latency: 10ms
+        interval: 10s
     processors: []`,
 		`output:
     label: ""
@@ -353,6 +359,12 @@ type: local`))
     memory: {}`,
 		`pipeline:
     threads: 10
+    autoscale:
+        enabled: false
+        min_threads: 1
+        max_threads: -1
+        target_latency: 10ms
+        interval: 10s
     processors:`,
 		`
         - label: ""
@@ -670,6 +682,12 @@ output:
     none: {}`,
 		`pipeline:
     threads: 5
+    autoscale:
+        enabled: false
+        min_threads: 1
+        max_threads: -1
+        target_latency: 10ms
+        interval: 10s
     processors:`,
 		`
         - label: ""
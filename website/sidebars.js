@@ -31,6 +31,8 @@ module.exports = {
         'configuration/unit_testing',
         'configuration/templating',
         'configuration/dynamic_inputs_and_outputs',
+        'configuration/schedules',
+        'configuration/lifecycle_hooks',
       ],
     },
     {